@@ -0,0 +1,363 @@
+package apikey
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteAdminCollection = identity.RouteBase + "/:id/api-keys"
+	RouteAdminItem       = RouteAdminCollection + "/:key_id"
+	RouteAdminRotate     = RouteAdminItem + "/rotate"
+
+	RouteCollection = "/self-service/api-keys"
+	RouteItem       = RouteCollection + "/:key_id"
+	RouteRotate     = RouteItem + "/rotate"
+
+	RouteIntrospect = "/api-keys/introspect"
+)
+
+var ErrAPIKeysDisabled = herodot.ErrNotFound.WithReason("API key credentials are not enabled.")
+
+type (
+	handlerDependencies interface {
+		ManagementProvider
+		session.HandlerProvider
+		session.ManagementProvider
+		x.WriterProvider
+		x.CSRFProvider
+		config.Provider
+	}
+	HandlerProvider interface {
+		APIKeyHandler() *Handler
+	}
+	Handler struct {
+		d  handlerDependencies
+		dx *decoderx.HTTP
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d, dx: decoderx.NewHTTP()}
+}
+
+func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
+	h.d.CSRFHandler().ExemptPath(RouteIntrospect)
+	public.POST(RouteIntrospect, h.introspect)
+
+	public.GET(RouteCollection, h.d.SessionHandler().IsAuthenticated(h.withSession(h.list), nil))
+	public.POST(RouteCollection, h.d.SessionHandler().IsAuthenticated(h.withSession(h.create), nil))
+	public.DELETE(RouteItem, h.d.SessionHandler().IsAuthenticated(h.withSession(h.revoke), nil))
+	public.POST(RouteRotate, h.d.SessionHandler().IsAuthenticated(h.withSession(h.rotate), nil))
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteAdminCollection, h.withIdentityFromPath(h.list))
+	admin.POST(RouteAdminCollection, h.withIdentityFromPath(h.create))
+	admin.DELETE(RouteAdminItem, h.withIdentityFromPath(h.revoke))
+	admin.POST(RouteAdminRotate, h.withIdentityFromPath(h.rotate))
+}
+
+type identityHandle func(w http.ResponseWriter, r *http.Request, ps httprouter.Params, identityID uuid.UUID)
+
+func (h *Handler) enabled(w http.ResponseWriter, r *http.Request) bool {
+	if !h.d.Config(r.Context()).SelfServiceStrategy(string(identity.CredentialsTypeAPIKey)).Enabled {
+		h.d.Writer().WriteError(w, r, errors.WithStack(ErrAPIKeysDisabled))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) withIdentityFromPath(next identityHandle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if !h.enabled(w, r) {
+			return
+		}
+		next(w, r, ps, x.ParseUUID(ps.ByName("id")))
+	}
+}
+
+func (h *Handler) withSession(next identityHandle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if !h.enabled(w, r) {
+			return
+		}
+
+		s, err := h.d.SessionManager().FetchFromRequest(r.Context(), r)
+		if err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+
+		if identity.IsServiceAccount(h.d.Config(r.Context()), s.Identity) {
+			h.d.Writer().WriteError(w, r, errors.WithStack(identity.ErrServiceAccountSelfService))
+			return
+		}
+		next(w, r, ps, s.IdentityID)
+	}
+}
+
+// An API key together with its secret.
+//
+// swagger:model apiKeyWithSecret
+type KeyWithSecret struct {
+	// required: true
+	Key *Key `json:"key"`
+
+	// APIKey is the encoded API key which is used to authenticate. It is only returned once and can not be
+	// retrieved later on.
+	//
+	// required: true
+	APIKey string `json:"api_key"`
+}
+
+// swagger:parameters adminListIdentityAPIKeys adminCreateIdentityAPIKey
+// nolint:deadcode,unused
+type adminIdentityAPIKeysParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+}
+
+// A list of API keys.
+//
+// swagger:response apiKeyList
+// nolint:deadcode,unused
+type apiKeyListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []Key
+}
+
+// swagger:route GET /identities/{id}/api-keys admin adminListIdentityAPIKeys
+//
+// List an Identity's API Keys
+//
+// Lists all API keys of an identity. The API key secrets are never returned.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: apiKeyList
+//       404: genericError
+//       500: genericError
+func (h *Handler) list(w http.ResponseWriter, r *http.Request, _ httprouter.Params, identityID uuid.UUID) {
+	keys, err := h.d.APIKeyManager().List(r.Context(), identityID)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, keys)
+}
+
+// swagger:parameters adminCreateIdentityAPIKey
+// nolint:deadcode,unused
+type createAPIKeyParameters struct {
+	// in: body
+	Body CreateKey
+}
+
+type CreateKey struct {
+	// Name describes what the API key is used for.
+	Name string `json:"name"`
+
+	// ExpiresAt sets when the API key expires. If the expiry exceeds `selfservice.methods.api_key.config.max_lifespan`
+	// it will be shortened accordingly.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// swagger:route POST /identities/{id}/api-keys admin adminCreateIdentityAPIKey
+//
+// Create an API Key for an Identity
+//
+// Issues a new API key for the identity. The response contains the API key which can not be retrieved again.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: apiKeyWithSecret
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) create(w http.ResponseWriter, r *http.Request, _ httprouter.Params, identityID uuid.UUID) {
+	var body CreateKey
+	if err := h.dx.Decode(r, &body, decoderx.HTTPJSONDecoder(), decoderx.HTTPDecoderAllowedMethods("POST")); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	key, encoded, err := h.d.APIKeyManager().Create(r.Context(), identityID, body.Name, body.ExpiresAt)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().WriteCreated(w, r,
+		urlx.AppendPaths(h.d.Config(r.Context()).SelfAdminURL(), identity.RouteBase, identityID.String(), "api-keys", key.ID).String(),
+		&KeyWithSecret{Key: key, APIKey: encoded},
+	)
+}
+
+// swagger:parameters adminRevokeIdentityAPIKey adminRotateIdentityAPIKey
+// nolint:deadcode,unused
+type adminIdentityAPIKeyParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// KeyID is the API key's ID.
+	//
+	// required: true
+	// in: path
+	KeyID string `json:"key_id"`
+}
+
+// swagger:route DELETE /identities/{id}/api-keys/{key_id} admin adminRevokeIdentityAPIKey
+//
+// Revoke an Identity's API Key
+//
+// The API key stops working immediately.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       404: genericError
+//       500: genericError
+func (h *Handler) revoke(w http.ResponseWriter, r *http.Request, ps httprouter.Params, identityID uuid.UUID) {
+	if err := h.d.APIKeyManager().Revoke(r.Context(), identityID, ps.ByName("key_id")); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// swagger:route POST /identities/{id}/api-keys/{key_id}/rotate admin adminRotateIdentityAPIKey
+//
+// Rotate an Identity's API Key
+//
+// Replaces the API key's secret. The previous API key stops working immediately.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: apiKeyWithSecret
+//       404: genericError
+//       500: genericError
+func (h *Handler) rotate(w http.ResponseWriter, r *http.Request, ps httprouter.Params, identityID uuid.UUID) {
+	key, encoded, err := h.d.APIKeyManager().Rotate(r.Context(), identityID, ps.ByName("key_id"))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, &KeyWithSecret{Key: key, APIKey: encoded})
+}
+
+// swagger:parameters introspectAPIKey
+// nolint:deadcode,unused
+type introspectAPIKeyParameters struct {
+	// in: body
+	// required: true
+	Body introspectAPIKey
+}
+
+type introspectAPIKey struct {
+	// The API Key
+	//
+	// required: true
+	APIKey string `json:"api_key"`
+}
+
+// The result of an API key introspection.
+//
+// swagger:model apiKeyIntrospection
+type Introspection struct {
+	// Active is true if the API key is valid.
+	//
+	// required: true
+	Active bool `json:"active"`
+
+	// required: true
+	Key *Key `json:"key"`
+
+	// required: true
+	Identity *identity.Identity `json:"identity"`
+}
+
+// swagger:route POST /api-keys/introspect public introspectAPIKey
+//
+// Introspect an API Key
+//
+// Checks the API key and returns the identity it belongs to. Returns 401 if the API key is invalid,
+// expired, or was revoked.
+//
+// This endpoint is useful for reverse proxies and API Gateways.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: apiKeyIntrospection
+//       401: genericError
+//       500: genericError
+func (h *Handler) introspect(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !h.enabled(w, r) {
+		return
+	}
+
+	var body introspectAPIKey
+	if err := h.dx.Decode(r, &body, decoderx.HTTPJSONDecoder(), decoderx.HTTPDecoderAllowedMethods("POST")); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	i, key, err := h.d.APIKeyManager().Verify(r.Context(), body.APIKey)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("X-Kratos-Authenticated-Identity-Id", i.ID.String())
+	h.d.Writer().Write(w, r, &Introspection{Active: true, Key: key, Identity: i})
+}
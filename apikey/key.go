@@ -0,0 +1,136 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/randx"
+)
+
+var ErrInvalidAPIKey = herodot.ErrUnauthorized.WithError("api key is invalid").WithReason("The provided API key is invalid, has expired, or was revoked.")
+
+type (
+	// Key is an API key issued to an identity. The secret part of the key is never stored or returned
+	// except once when the key is created or rotated.
+	//
+	// swagger:model apiKey
+	Key struct {
+		// ID is the public identifier of the API key. It is also part of the API key itself.
+		//
+		// required: true
+		ID string `json:"id"`
+
+		// Name is a human-readable description of what the API key is used for.
+		Name string `json:"name"`
+
+		// IdentityID is the ID of the identity this API key belongs to.
+		//
+		// required: true
+		IdentityID uuid.UUID `json:"identity_id"`
+
+		// ExpiresAt is the time at which the API key expires. If unset the API key does not expire.
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+		// CreatedAt is the time at which the API key was issued.
+		//
+		// required: true
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// CredentialsConfig is the struct that is being used as part of the identity credentials.
+	CredentialsConfig struct {
+		Keys []CredentialsKey `json:"keys"`
+	}
+
+	// CredentialsKey is the stored representation of an API key.
+	CredentialsKey struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+
+		// HashedSecret is the hex-encoded SHA-256 hash of the key's secret. API key secrets are long and
+		// random which is why a fast hash is sufficient here.
+		HashedSecret string `json:"hashed_secret"`
+
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+)
+
+const (
+	idLength     = 16
+	secretLength = 32
+	separator    = "_"
+)
+
+func newCredentialsKey(name string, expiresAt *time.Time) (*CredentialsKey, string) {
+	secret := randx.MustString(secretLength, randx.AlphaNum)
+	return &CredentialsKey{
+		ID:           randx.MustString(idLength, randx.AlphaLowerNum),
+		Name:         name,
+		HashedSecret: hashSecret(secret),
+		ExpiresAt:    expiresAt,
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+	}, secret
+}
+
+func hashSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// Encode returns the API key as presented to the client: `<prefix>_<id>_<secret>`.
+func Encode(prefix, id, secret string) string {
+	return strings.Join([]string{prefix, id, secret}, separator)
+}
+
+// Decode splits an API key into its ID and secret. The prefix is not validated so that keys remain valid
+// even if the configured prefix changes.
+func Decode(key string) (id, secret string, err error) {
+	parts := strings.Split(key, separator)
+	if len(parts) != 3 || len(parts[1]) != idLength || len(parts[2]) != secretLength {
+		return "", "", errors.WithStack(ErrInvalidAPIKey.WithDebug("the API key is malformed"))
+	}
+	return parts[1], parts[2], nil
+}
+
+func (k *CredentialsKey) compare(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(k.HashedSecret), []byte(hashSecret(secret))) == 1
+}
+
+func (k *CredentialsKey) expired() bool {
+	return k.ExpiresAt != nil && k.ExpiresAt.Before(time.Now())
+}
+
+func (k *CredentialsKey) toKey(identityID uuid.UUID) *Key {
+	return &Key{
+		ID:         k.ID,
+		Name:       k.Name,
+		IdentityID: identityID,
+		ExpiresAt:  k.ExpiresAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+func (c *CredentialsConfig) find(id string) (int, error) {
+	for k := range c.Keys {
+		if c.Keys[k].ID == id {
+			return k, nil
+		}
+	}
+	return -1, errors.WithStack(herodot.ErrNotFound.WithReasonf("Unable to find API key %s.", id))
+}
+
+func (c *CredentialsConfig) identifiers() []string {
+	ids := make([]string, len(c.Keys))
+	for k := range c.Keys {
+		ids[k] = c.Keys[k].ID
+	}
+	return ids
+}
@@ -0,0 +1,195 @@
+package apikey
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+)
+
+type (
+	managerDependencies interface {
+		identity.PrivilegedPoolProvider
+		identity.ManagementProvider
+		config.Provider
+	}
+	ManagementProvider interface {
+		APIKeyManager() *Manager
+	}
+	Manager struct {
+		d managerDependencies
+	}
+)
+
+func NewManager(d managerDependencies) *Manager {
+	return &Manager{d: d}
+}
+
+func (m *Manager) credentials(ctx context.Context, identityID uuid.UUID) (*identity.Identity, *CredentialsConfig, error) {
+	i, err := m.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, identityID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var conf CredentialsConfig
+	if c, ok := i.GetCredentials(identity.CredentialsTypeAPIKey); ok && len(c.Config) > 0 {
+		if err := json.Unmarshal(c.Config, &conf); err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+	}
+
+	return i, &conf, nil
+}
+
+func (m *Manager) store(ctx context.Context, i *identity.Identity, conf *CredentialsConfig) error {
+	if len(conf.Keys) == 0 {
+		delete(i.Credentials, identity.CredentialsTypeAPIKey)
+		return m.d.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits)
+	}
+
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(conf); err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.SetCredentials(identity.CredentialsTypeAPIKey, identity.Credentials{
+		Type:        identity.CredentialsTypeAPIKey,
+		Identifiers: conf.identifiers(),
+		Config:      b.Bytes(),
+	})
+	return m.d.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits)
+}
+
+func (m *Manager) expiresAt(ctx context.Context, requested *time.Time) *time.Time {
+	max := m.d.Config(ctx).APIKeyMaxLifespan()
+	if max == 0 {
+		return requested
+	}
+
+	limit := time.Now().UTC().Add(max).Truncate(time.Second)
+	if requested == nil || requested.After(limit) {
+		return &limit
+	}
+	return requested
+}
+
+// List returns all API keys of an identity, including expired ones.
+func (m *Manager) List(ctx context.Context, identityID uuid.UUID) ([]Key, error) {
+	_, conf, err := m.credentials(ctx, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]Key, len(conf.Keys))
+	for k := range conf.Keys {
+		keys[k] = *conf.Keys[k].toKey(identityID)
+	}
+	return keys, nil
+}
+
+// Create issues a new API key for the identity and returns it together with the encoded API key. The encoded
+// API key can not be recovered later on.
+func (m *Manager) Create(ctx context.Context, identityID uuid.UUID, name string, expiresAt *time.Time) (*Key, string, error) {
+	i, conf, err := m.credentials(ctx, identityID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, secret := newCredentialsKey(name, m.expiresAt(ctx, expiresAt))
+	conf.Keys = append(conf.Keys, *key)
+	if err := m.store(ctx, i, conf); err != nil {
+		return nil, "", err
+	}
+
+	return key.toKey(identityID), Encode(m.d.Config(ctx).APIKeyPrefix(), key.ID, secret), nil
+}
+
+// Rotate replaces the secret of an API key. The key keeps its ID, name, and expiry while the old secret stops
+// working immediately.
+func (m *Manager) Rotate(ctx context.Context, identityID uuid.UUID, id string) (*Key, string, error) {
+	i, conf, err := m.credentials(ctx, identityID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pos, err := conf.find(id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	old := conf.Keys[pos]
+	key, secret := newCredentialsKey(old.Name, old.ExpiresAt)
+	key.ID = old.ID
+	conf.Keys[pos] = *key
+	if err := m.store(ctx, i, conf); err != nil {
+		return nil, "", err
+	}
+
+	return key.toKey(identityID), Encode(m.d.Config(ctx).APIKeyPrefix(), key.ID, secret), nil
+}
+
+// Revoke removes an API key from the identity.
+func (m *Manager) Revoke(ctx context.Context, identityID uuid.UUID, id string) error {
+	i, conf, err := m.credentials(ctx, identityID)
+	if err != nil {
+		return err
+	}
+
+	pos, err := conf.find(id)
+	if err != nil {
+		return err
+	}
+
+	conf.Keys = append(conf.Keys[:pos], conf.Keys[pos+1:]...)
+	return m.store(ctx, i, conf)
+}
+
+// Verify checks the encoded API key and returns the identity it belongs to. Keys of inactive identities are invalid.
+func (m *Manager) Verify(ctx context.Context, encoded string) (*identity.Identity, *Key, error) {
+	id, secret, err := Decode(encoded)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i, c, err := m.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypeAPIKey, id)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return nil, nil, errors.WithStack(ErrInvalidAPIKey.WithDebug("no API key with this ID exists"))
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	var conf CredentialsConfig
+	if err := json.Unmarshal(c.Config, &conf); err != nil {
+		return nil, nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode API key credentials: %s", err))
+	}
+
+	pos, err := conf.find(id)
+	if err != nil {
+		return nil, nil, errors.WithStack(ErrInvalidAPIKey.WithDebug("the API key is not part of the credentials"))
+	}
+
+	key := conf.Keys[pos]
+	if !key.compare(secret) {
+		return nil, nil, errors.WithStack(ErrInvalidAPIKey.WithDebug("the API key secret does not match"))
+	}
+
+	if key.expired() {
+		return nil, nil, errors.WithStack(ErrInvalidAPIKey.WithDebug("the API key has expired"))
+	}
+
+	// Keys of deactivated identities are treated like revoked keys.
+	if !i.IsActive() {
+		return nil, nil, errors.WithStack(ErrInvalidAPIKey.WithDebug("the identity is not active"))
+	}
+
+	return i, key.toKey(i.ID), nil
+}
@@ -0,0 +1,141 @@
+package apikey_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/apikey"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/x"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeyAPIKeyPrefix, "acme")
+
+	i := identity.NewIdentity("")
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+
+	key, encoded, err := reg.APIKeyManager().Create(ctx, i.ID, "ci", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ci", key.Name)
+	assert.Nil(t, key.ExpiresAt)
+	assert.Contains(t, encoded, "acme_"+key.ID+"_")
+
+	t.Run("case=verifies key", func(t *testing.T) {
+		actual, ak, err := reg.APIKeyManager().Verify(ctx, encoded)
+		require.NoError(t, err)
+		assert.Equal(t, i.ID, actual.ID)
+		assert.Equal(t, key.ID, ak.ID)
+	})
+
+	t.Run("case=rejects malformed and wrong keys", func(t *testing.T) {
+		for _, k := range []string{
+			"",
+			"acme_foo",
+			apikey.Encode("acme", key.ID, "00000000000000000000000000000000"),
+			apikey.Encode("acme", "0000000000000000", "00000000000000000000000000000000"),
+		} {
+			_, _, err := reg.APIKeyManager().Verify(ctx, k)
+			require.Error(t, err, k)
+			assert.Equal(t, http.StatusUnauthorized, x.RecoverStatusCode(err, 0), "%+v", err)
+		}
+	})
+
+	t.Run("case=lists keys", func(t *testing.T) {
+		keys, err := reg.APIKeyManager().List(ctx, i.ID)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.Equal(t, key.ID, keys[0].ID)
+	})
+
+	t.Run("case=caps expiry to max lifespan", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyAPIKeyMaxLifespan, "1h")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyAPIKeyMaxLifespan, "")
+		})
+
+		requested := time.Now().Add(time.Hour * 48)
+		capped, _, err := reg.APIKeyManager().Create(ctx, i.ID, "capped", &requested)
+		require.NoError(t, err)
+		require.NotNil(t, capped.ExpiresAt)
+		assert.True(t, capped.ExpiresAt.Before(time.Now().Add(time.Hour+time.Minute)))
+		require.NoError(t, reg.APIKeyManager().Revoke(ctx, i.ID, capped.ID))
+	})
+
+	t.Run("case=rejects expired keys", func(t *testing.T) {
+		past := time.Now().Add(-time.Minute)
+		_, expired, err := reg.APIKeyManager().Create(ctx, i.ID, "expired", &past)
+		require.NoError(t, err)
+
+		_, _, err = reg.APIKeyManager().Verify(ctx, expired)
+		require.Error(t, err)
+	})
+
+	t.Run("case=rejects keys of inactive identities", func(t *testing.T) {
+		inactive := identity.NewIdentity("")
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, inactive))
+		_, inactiveKey, err := reg.APIKeyManager().Create(ctx, inactive.ID, "ci", nil)
+		require.NoError(t, err)
+
+		inactive, err = reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, inactive.ID)
+		require.NoError(t, err)
+		inactive.State = identity.StateInactive
+		require.NoError(t, reg.PrivilegedIdentityPool().UpdateIdentity(ctx, inactive))
+
+		_, _, err = reg.APIKeyManager().Verify(ctx, inactiveKey)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, x.RecoverStatusCode(err, 0), "%+v", err)
+	})
+
+	t.Run("case=rotates key", func(t *testing.T) {
+		rotated, next, err := reg.APIKeyManager().Rotate(ctx, i.ID, key.ID)
+		require.NoError(t, err)
+		assert.Equal(t, key.ID, rotated.ID)
+		assert.NotEqual(t, encoded, next)
+
+		_, _, err = reg.APIKeyManager().Verify(ctx, encoded)
+		require.Error(t, err)
+
+		_, _, err = reg.APIKeyManager().Verify(ctx, next)
+		require.NoError(t, err)
+		encoded = next
+	})
+
+	t.Run("case=writes go through the identity manager", func(t *testing.T) {
+		var updates int
+		reg.WithIdentityManagerMiddleware(identity.ManagerMiddleware{
+			Update: func(next identity.ManagerUpdateFunc) identity.ManagerUpdateFunc {
+				return func(ctx context.Context, i *identity.Identity) error {
+					updates++
+					return next(ctx, i)
+				}
+			},
+		})
+
+		created, _, err := reg.APIKeyManager().Create(ctx, i.ID, "middleware", nil)
+		require.NoError(t, err)
+		require.NoError(t, reg.APIKeyManager().Revoke(ctx, i.ID, created.ID))
+		assert.Equal(t, 2, updates)
+	})
+
+	t.Run("case=revokes key", func(t *testing.T) {
+		require.NoError(t, reg.APIKeyManager().Revoke(ctx, i.ID, key.ID))
+
+		_, _, err := reg.APIKeyManager().Verify(ctx, encoded)
+		require.Error(t, err)
+
+		err = reg.APIKeyManager().Revoke(ctx, i.ID, key.ID)
+		assert.Equal(t, http.StatusNotFound, x.RecoverStatusCode(err, 0), "%+v", err)
+	})
+}
@@ -0,0 +1,78 @@
+package approval
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/corp"
+)
+
+// Approval permits a single destructive admin request.
+//
+// swagger:model adminApproval
+type Approval struct {
+	// ID is the approval's ID.
+	//
+	// required: true
+	ID  uuid.UUID `json:"id" db:"id" rw:"r"`
+	NID uuid.UUID `json:"-" db:"nid"`
+
+	// TokenHash is the hex-encoded SHA-256 hash of the approval token. The token itself is not stored.
+	TokenHash string `json:"-" db:"token_hash"`
+
+	// Method is the HTTP method of the approved request.
+	//
+	// required: true
+	Method string `json:"method" db:"request_method"`
+
+	// Path is the path of the approved request, for example `/identities/{id}`.
+	//
+	// required: true
+	Path string `json:"path" db:"request_path"`
+
+	// ApprovedBy is the ID of the identity which approved the request.
+	//
+	// required: true
+	ApprovedBy uuid.UUID `json:"approved_by" db:"approved_by"`
+
+	// Reason explains why the request was approved.
+	Reason string `json:"reason" db:"reason"`
+
+	// ExpiresAt defines when the approval token can no longer be used.
+	//
+	// required: true
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+
+	// UsedAt is set once the approval token was used.
+	UsedAt sqlxx.NullTime `json:"used_at" db:"used_at"`
+
+	// CreatedAt is a helper struct field for gobuffalo.pop.
+	CreatedAt time.Time `json:"-" db:"created_at"`
+
+	// UpdatedAt is a helper struct field for gobuffalo.pop.
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+}
+
+func (a Approval) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "admin_approvals")
+}
+
+func (a *Approval) GetID() uuid.UUID {
+	return a.ID
+}
+
+func (a *Approval) GetNID() uuid.UUID {
+	return a.NID
+}
+
+// HashToken returns the value stored for an approval token.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
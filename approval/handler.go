@@ -0,0 +1,165 @@
+package approval
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/randx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const RouteCollection = "/admin-approvals"
+
+type (
+	handlerDependencies interface {
+		AuthenticatorProvider
+		PersistenceProvider
+		config.Provider
+		x.ClockProvider
+		x.LoggingProvider
+		x.WriterProvider
+	}
+	HandlerProvider interface {
+		ApprovalHandler() *Handler
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.POST(RouteCollection, h.create)
+}
+
+// CreateApproval is the request body for issuing an approval token.
+type CreateApproval struct {
+	// Method is the HTTP method of the request to approve.
+	//
+	// required: true
+	Method string `json:"method"`
+
+	// Path is the path of the request to approve, for example `/identities/{id}`.
+	//
+	// required: true
+	Path string `json:"path"`
+
+	// Reason explains why the request is approved. It is written to the audit log.
+	Reason string `json:"reason"`
+}
+
+// The response for issuing an approval token.
+//
+// swagger:model adminApprovalToken
+type TokenResponse struct {
+	// Token is the approval token. Send it in the `X-Kratos-Approval` header of the approved request. It is
+	// only returned once.
+	//
+	// required: true
+	Token string `json:"token"`
+
+	// Approval is the issued approval.
+	//
+	// required: true
+	Approval *Approval `json:"approval"`
+}
+
+// swagger:parameters createAdminApproval
+// nolint:deadcode,unused
+type createAdminApprovalParameters struct {
+	// in: body
+	Body CreateApproval
+}
+
+// The issued approval token.
+//
+// swagger:response adminApprovalToken
+// nolint:deadcode,unused
+type adminApprovalTokenResponse struct {
+	// in: body
+	Body TokenResponse
+}
+
+// swagger:route POST /admin-approvals admin createAdminApproval
+//
+// Issue an Approval Token
+//
+// This endpoint issues a short-lived token approving a single destructive admin request, such as deleting an
+// identity. If `admin_approvals.enabled` is set, such requests are rejected unless they carry a token issued for
+// their method and path in the `X-Kratos-Approval` header. The approver authenticates with an API key in the
+// `X-Kratos-API-Key` header or with a session. The approved request must be authenticated the same way by
+// another identity, so that a second person signs off on it.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: adminApprovalToken
+//       400: genericError
+//       401: genericError
+//       500: genericError
+func (h *Handler) create(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var p CreateApproval
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&p); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to decode the request body: %s", err)))
+		return
+	}
+
+	if len(p.Method) == 0 || !strings.HasPrefix(p.Path, "/") {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The method and an absolute path of the request to approve are required.")))
+		return
+	}
+
+	approvedBy, err := h.d.AuthenticateAdminRequest(r)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	token := randx.MustString(32, randx.AlphaNum)
+	now := h.d.Clock().Now().UTC()
+	a := &Approval{
+		ID:         x.NewUUID(),
+		TokenHash:  HashToken(token),
+		Method:     strings.ToUpper(p.Method),
+		Path:       p.Path,
+		ApprovedBy: approvedBy,
+		Reason:     p.Reason,
+		ExpiresAt:  now.Add(h.d.Config(r.Context()).AdminApprovalsLifespan()),
+	}
+
+	if err := h.d.ApprovalPersister().CreateApproval(r.Context(), a); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if _, err := h.d.ApprovalPersister().DeleteExpiredApprovals(r.Context(), now); err != nil {
+		h.d.Logger().WithRequest(r).WithError(err).Warn("Unable to remove expired approvals.")
+	}
+
+	h.d.Audit().
+		WithRequest(r).
+		WithField("approval_id", a.ID).
+		WithField("approved_by", a.ApprovedBy).
+		WithField("approved_method", a.Method).
+		WithField("approved_path", a.Path).
+		WithField("reason", a.Reason).
+		Info("An approval token was issued for a destructive admin operation.")
+
+	h.d.Writer().WriteCode(w, r, http.StatusCreated, &TokenResponse{Token: token, Approval: a})
+}
@@ -0,0 +1,158 @@
+package approval_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/approval"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/x"
+)
+
+func TestApprovals(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	_, admin := testhelpers.NewKratosServer(t, reg)
+
+	newIdentity := func(t *testing.T) *identity.Identity {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+		return i
+	}
+
+	newAPIKey := func(t *testing.T) (*identity.Identity, string) {
+		i := newIdentity(t)
+		_, key, err := reg.APIKeyManager().Create(ctx, i.ID, "admin", nil)
+		require.NoError(t, err)
+		return i, key
+	}
+	approver, approverKey := newAPIKey(t)
+	_, requesterKey := newAPIKey(t)
+
+	doAs := func(t *testing.T, apiKey, method, path, token, body string) (*http.Response, gjson.Result) {
+		req, err := http.NewRequest(method, admin.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if len(token) > 0 {
+			req.Header.Set(approval.HeaderToken, token)
+		}
+		if len(apiKey) > 0 {
+			req.Header.Set(approval.HeaderAPIKey, apiKey)
+		}
+		res, err := admin.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res, gjson.ParseBytes(x.MustReadAll(res.Body))
+	}
+
+	do := func(t *testing.T, method, path, token, body string) (*http.Response, gjson.Result) {
+		return doAs(t, requesterKey, method, path, token, body)
+	}
+
+	approve := func(t *testing.T, method, path string) string {
+		res, body := doAs(t, approverKey, "POST", approval.RouteCollection, "", `{"method":"`+method+`","path":"`+path+`","reason":"account takeover"}`)
+		require.Equal(t, http.StatusCreated, res.StatusCode, "%s", body.Raw)
+		assert.Equal(t, approver.ID.String(), body.Get("approval.approved_by").String(), "%s", body.Raw)
+		assert.False(t, body.Get("approval.token_hash").Exists(), "%s", body.Raw)
+		return body.Get("token").String()
+	}
+
+	t.Run("case=does not require approvals by default", func(t *testing.T) {
+		i := newIdentity(t)
+		res, body := do(t, "DELETE", "/identities/"+i.ID.String(), "", "")
+		assert.Equal(t, http.StatusNoContent, res.StatusCode, "%s", body.Raw)
+	})
+
+	t.Run("case=rejects invalid approval requests", func(t *testing.T) {
+		res, _ := doAs(t, approverKey, "POST", approval.RouteCollection, "", `{"method":"DELETE","path":"identities/1"}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		res, _ = doAs(t, approverKey, "POST", approval.RouteCollection, "", `{"method":"DELETE","path":"/identities/1","approved_by":"security@ory.sh"}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, "the approver can not be named freely")
+	})
+
+	t.Run("case=requires an authenticated approver", func(t *testing.T) {
+		res, _ := doAs(t, "", "POST", approval.RouteCollection, "", `{"method":"DELETE","path":"/identities/1"}`)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		res, _ = doAs(t, "kratos_invalid", "POST", approval.RouteCollection, "", `{"method":"DELETE","path":"/identities/1"}`)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	conf.MustSet(config.ViperKeyAdminApprovalsEnabled, true)
+
+	t.Run("case=deleting an identity requires an approval", func(t *testing.T) {
+		i := newIdentity(t)
+		path := "/identities/" + i.ID.String()
+
+		res, body := do(t, "DELETE", path, "", "")
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, "%s", body.Raw)
+
+		res, body = do(t, "DELETE", path, approve(t, "DELETE", "/identities/"+x.NewUUID().String()), "")
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, "tokens are bound to the path: %s", body.Raw)
+
+		token := approve(t, "delete", path)
+		res, body = do(t, "DELETE", path, token, "")
+		assert.Equal(t, http.StatusNoContent, res.StatusCode, "%s", body.Raw)
+
+		res, body = do(t, "DELETE", path, token, "")
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, "tokens are used only once: %s", body.Raw)
+	})
+
+	t.Run("case=approvals require another identity", func(t *testing.T) {
+		i := newIdentity(t)
+		path := "/identities/" + i.ID.String()
+		token := approve(t, "DELETE", path)
+
+		res, body := doAs(t, "", "DELETE", path, token, "")
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "%s", body.Raw)
+
+		res, body = doAs(t, approverKey, "DELETE", path, token, "")
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, "approvers can not approve their own requests: %s", body.Raw)
+
+		res, body = do(t, "DELETE", path, token, "")
+		assert.Equal(t, http.StatusNoContent, res.StatusCode, "%s", body.Raw)
+	})
+
+	t.Run("case=returning credentials requires an approval", func(t *testing.T) {
+		i := newIdentity(t)
+		path := "/identities/" + i.ID.String()
+
+		res, body := do(t, "GET", path, "", "")
+		assert.Equal(t, http.StatusOK, res.StatusCode, "%s", body.Raw)
+
+		res, body = do(t, "GET", path+"?include_credential=oidc", "", "")
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, "%s", body.Raw)
+
+		res, body = do(t, "GET", path+"?include_credential=oidc", approve(t, "GET", path), "")
+		assert.Equal(t, http.StatusOK, res.StatusCode, "%s", body.Raw)
+	})
+
+	t.Run("case=revoking all sessions requires an approval", func(t *testing.T) {
+		i := newIdentity(t)
+		path := "/identities/" + i.ID.String() + "/sessions"
+
+		res, body := do(t, "DELETE", path, "", "")
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, "%s", body.Raw)
+
+		res, body = do(t, "DELETE", path, approve(t, "DELETE", path), "")
+		assert.Equal(t, http.StatusNoContent, res.StatusCode, "%s", body.Raw)
+	})
+	t.Run("case=marking an identity as compromised requires an approval", func(t *testing.T) {
+		i := newIdentity(t)
+		path := "/identities/" + i.ID.String() + "/compromised"
+
+		res, body := do(t, "POST", path, "", "")
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, "%s", body.Raw)
+
+		res, body = do(t, "POST", path, approve(t, "POST", path), "")
+		assert.Equal(t, http.StatusOK, res.StatusCode, "%s", body.Raw)
+	})
+}
@@ -0,0 +1,102 @@
+package approval
+
+import (
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	// HeaderToken is the request header carrying the approval token.
+	HeaderToken = "X-Kratos-Approval"
+
+	// HeaderAPIKey is the request header carrying the API key which authenticates approvers and the requests they
+	// approved. Requests without it are authenticated by their session instead.
+	HeaderAPIKey = "X-Kratos-API-Key"
+)
+
+var (
+	ErrApprovalRequired = herodot.ErrForbidden.WithReasonf("This operation requires an approval token in the %s header. Request one using POST %s.", HeaderToken, RouteCollection)
+	ErrApprovalInvalid  = herodot.ErrForbidden.WithReason("The approval token is invalid, expired, already used, was issued for a different request, or was issued by the requester.")
+)
+
+type (
+	// AuthenticatorProvider identifies who sent a request to the admin API.
+	AuthenticatorProvider interface {
+		// AuthenticateAdminRequest returns the ID of the identity whose API key (see HeaderAPIKey) or session
+		// authenticates the request.
+		AuthenticateAdminRequest(r *http.Request) (uuid.UUID, error)
+	}
+	middlewareDependencies interface {
+		AuthenticatorProvider
+		PersistenceProvider
+		config.Provider
+		x.ClockProvider
+		x.LoggingProvider
+		x.WriterProvider
+	}
+	MiddlewareProvider interface {
+		ApprovalMiddleware() *Middleware
+	}
+	// Middleware guards destructive admin operations with approval tokens if `admin_approvals.enabled` is set.
+	Middleware struct {
+		d middlewareDependencies
+	}
+)
+
+func NewMiddleware(d middlewareDependencies) *Middleware {
+	return &Middleware{d: d}
+}
+
+// Wrap requires an approval token for all requests to the handler.
+func (m *Middleware) Wrap(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if err := m.Verify(r); err != nil {
+			m.d.Writer().WriteError(w, r, err)
+			return
+		}
+		next(w, r, ps)
+	}
+}
+
+// Verify uses up the approval token of the request. The request must be authenticated by another identity than the
+// one which approved it. It returns nil without checking anything if admin approvals are disabled. Handlers call it
+// directly if only some of their requests are destructive.
+func (m *Middleware) Verify(r *http.Request) error {
+	if !m.d.Config(r.Context()).AdminApprovalsEnabled() {
+		return nil
+	}
+
+	token := r.Header.Get(HeaderToken)
+	if len(token) == 0 {
+		return errors.WithStack(ErrApprovalRequired)
+	}
+
+	requestedBy, err := m.d.AuthenticateAdminRequest(r)
+	if err != nil {
+		return err
+	}
+
+	a, err := m.d.ApprovalPersister().UseApproval(r.Context(), HashToken(token), r.Method, r.URL.Path, requestedBy, m.d.Clock().Now().UTC())
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return errors.WithStack(ErrApprovalInvalid)
+	} else if err != nil {
+		return err
+	}
+
+	m.d.Audit().
+		WithRequest(r).
+		WithField("approval_id", a.ID).
+		WithField("approved_by", a.ApprovedBy).
+		WithField("requested_by", requestedBy).
+		Info("An approval token was used for a destructive admin operation.")
+	return nil
+}
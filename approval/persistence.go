@@ -0,0 +1,23 @@
+package approval
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type PersistenceProvider interface {
+	ApprovalPersister() Persister
+}
+
+type Persister interface {
+	CreateApproval(ctx context.Context, a *Approval) error
+
+	// UseApproval marks the approval with the given token hash as used and returns it. It returns
+	// sqlcon.ErrNoRows if there is no such approval for the method and path, if it expired or was already used, or
+	// if it was approved by requestedBy.
+	UseApproval(ctx context.Context, tokenHash, method, path string, requestedBy uuid.UUID, now time.Time) (*Approval, error)
+
+	DeleteExpiredApprovals(ctx context.Context, expiresBefore time.Time) (int, error)
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/approval"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/x"
+)
+
+func TestPersister(ctx context.Context, p persistence.Persister) func(t *testing.T) {
+	approver, requester := x.NewUUID(), x.NewUUID()
+	var newApproval = func(token string, expiresIn time.Duration) *approval.Approval {
+		return &approval.Approval{
+			ID:         x.NewUUID(),
+			TokenHash:  approval.HashToken(token),
+			Method:     "DELETE",
+			Path:       "/identities/1",
+			ApprovedBy: approver,
+			ExpiresAt:  time.Now().Add(expiresIn).UTC().Truncate(time.Second),
+		}
+	}
+
+	return func(t *testing.T) {
+		_, p := testhelpers.NewNetworkUnlessExisting(t, ctx, p)
+		now := time.Now().UTC()
+
+		t.Run("case=is used only once", func(t *testing.T) {
+			token := x.NewUUID().String()
+			expected := newApproval(token, time.Hour)
+			require.NoError(t, p.CreateApproval(ctx, expected))
+
+			actual, err := p.UseApproval(ctx, approval.HashToken(token), "DELETE", "/identities/1", requester, now)
+			require.NoError(t, err)
+			assert.Equal(t, expected.ID, actual.ID)
+			assert.Equal(t, approver, actual.ApprovedBy)
+			assert.False(t, time.Time(actual.UsedAt).IsZero())
+
+			_, err = p.UseApproval(ctx, approval.HashToken(token), "DELETE", "/identities/1", requester, now)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=is bound to the request", func(t *testing.T) {
+			token := x.NewUUID().String()
+			require.NoError(t, p.CreateApproval(ctx, newApproval(token, time.Hour)))
+
+			_, err := p.UseApproval(ctx, approval.HashToken(token), "GET", "/identities/1", requester, now)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			_, err = p.UseApproval(ctx, approval.HashToken(token), "DELETE", "/identities/2", requester, now)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			_, err = p.UseApproval(ctx, approval.HashToken(x.NewUUID().String()), "DELETE", "/identities/1", requester, now)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=is not used by the approver", func(t *testing.T) {
+			token := x.NewUUID().String()
+			require.NoError(t, p.CreateApproval(ctx, newApproval(token, time.Hour)))
+
+			_, err := p.UseApproval(ctx, approval.HashToken(token), "DELETE", "/identities/1", approver, now)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			_, err = p.UseApproval(ctx, approval.HashToken(token), "DELETE", "/identities/1", requester, now)
+			require.NoError(t, err)
+		})
+
+		t.Run("case=expires", func(t *testing.T) {
+			token := x.NewUUID().String()
+			require.NoError(t, p.CreateApproval(ctx, newApproval(token, -time.Minute)))
+
+			_, err := p.UseApproval(ctx, approval.HashToken(token), "DELETE", "/identities/1", requester, now)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			count, err := p.DeleteExpiredApprovals(ctx, now)
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package attempt
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/corp"
+)
+
+// Flow is the self-service flow an attempt was made in.
+//
+// swagger:model attemptFlow
+type Flow string
+
+const (
+	FlowLogin        Flow = "login"
+	FlowRegistration Flow = "registration"
+)
+
+type (
+	// Attempt records a login or registration attempt. The identifier is only stored as a keyed hash, so
+	// attempts can be filtered by identifier without storing it.
+	//
+	// swagger:model authenticationAttempt
+	Attempt struct {
+		// ID is the attempt's unique identifier.
+		//
+		// required: true
+		ID  uuid.UUID `json:"id" faker:"-" db:"id"`
+		NID uuid.UUID `json:"-" faker:"-" db:"nid"`
+
+		// Flow is the self-service flow the attempt was made in.
+		//
+		// required: true
+		Flow Flow `json:"flow" db:"flow"`
+
+		// FlowID is the ID of the self-service flow the attempt was made in.
+		//
+		// required: true
+		FlowID uuid.UUID `json:"flow_id" faker:"-" db:"flow_id"`
+
+		// Method is the method used in the attempt, for example `password` or `oidc`.
+		//
+		// required: true
+		Method string `json:"method" db:"method"`
+
+		// IdentifierHash is the keyed hash of the identifier used in the attempt. It is empty if the attempt
+		// did not use an identifier.
+		IdentifierHash string `json:"identifier_hash" db:"identifier_hash"`
+
+		// IP is the IP address of the client.
+		IP string `json:"ip" db:"ip"`
+
+		// Success is true if the identity signed in or registered.
+		//
+		// required: true
+		Success bool `json:"success" db:"success"`
+
+		// IdentityID is the ID of the identity which signed in or registered. It is not set for failed attempts.
+		IdentityID uuid.NullUUID `json:"identity_id" faker:"-" db:"identity_id"`
+
+		// CreatedAt is the time the attempt was made at.
+		//
+		// required: true
+		CreatedAt time.Time `json:"created_at" faker:"-" db:"created_at"`
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+	}
+
+	// Filter narrows down the attempts which are listed or counted. Empty fields match all attempts.
+	Filter struct {
+		// IdentifierHashes matches attempts with one of the hashes. Identifiers have one hash per secret.
+		IdentifierHashes []string
+		IdentityID       uuid.UUID
+		IP               string
+		Flow             Flow
+		Method           string
+		Success          *bool
+		// Since matches attempts made at or after the time.
+		Since time.Time
+		// Until matches attempts made before the time.
+		Until time.Time
+	}
+
+	Persister interface {
+		AddAttempt(ctx context.Context, a *Attempt) error
+
+		// ListAttempts returns the attempts matching the filter, the most recent one first.
+		ListAttempts(ctx context.Context, filter Filter, page, itemsPerPage int) ([]Attempt, error)
+
+		// CountAttempts returns the number of attempts matching the filter.
+		CountAttempts(ctx context.Context, filter Filter) (int64, error)
+
+		// DeleteAttemptsBefore removes all attempts made before the given time and returns how many were
+		// removed.
+		DeleteAttemptsBefore(ctx context.Context, before time.Time) (int, error)
+	}
+
+	PersistenceProvider interface {
+		AttemptPersister() Persister
+	}
+)
+
+func (a Attempt) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "authentication_attempts")
+}
@@ -0,0 +1,18 @@
+package attempt
+
+import (
+	"context"
+)
+
+type identifierContextKey struct{}
+
+// WithIdentifier makes attempts recorded while handling the request use the identifier the request carries.
+func WithIdentifier(ctx context.Context, identifier string) context.Context {
+	return context.WithValue(ctx, identifierContextKey{}, identifier)
+}
+
+// IdentifierFromContext returns the identifier added by WithIdentifier or an empty string.
+func IdentifierFromContext(ctx context.Context) string {
+	identifier, _ := ctx.Value(identifierContextKey{}).(string)
+	return identifier
+}
@@ -0,0 +1,240 @@
+package attempt
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const RouteCollection = "/attempts"
+
+type (
+	handlerDependencies interface {
+		ManagementProvider
+		PersistenceProvider
+		config.Provider
+		x.WriterProvider
+	}
+	HandlerProvider interface {
+		AttemptHandler() *Handler
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteCollection, h.list)
+}
+
+// A list of authentication attempts.
+// swagger:response authenticationAttemptList
+// nolint:deadcode,unused
+type authenticationAttemptListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []Attempt
+}
+
+// swagger:parameters listAuthenticationAttempts
+// nolint:deadcode,unused
+type listAuthenticationAttemptsParameters struct {
+	// Items per Page
+	//
+	// This is the number of items per page.
+	//
+	// required: false
+	// in: query
+	// default: 100
+	// min: 1
+	// max: 500
+	PerPage int `json:"per_page"`
+
+	// Pagination Page
+	//
+	// required: false
+	// in: query
+	// default: 0
+	// min: 0
+	Page int `json:"page"`
+
+	// Pagination Meta
+	//
+	// Wrap the list in an object with the keys `items` and `meta`, which contains the pagination meta data
+	// otherwise only sent in the `Link` and `X-Total-Count` headers. Sending the header
+	// `Accept: application/json; profile="pagination-meta"` has the same effect.
+	//
+	// required: false
+	// in: query
+	Meta bool `json:"meta"`
+
+	// Identifier
+	//
+	// Only return attempts using this identifier, for example an email address. Identifiers are compared
+	// case insensitively.
+	//
+	// required: false
+	// in: query
+	Identifier string `json:"identifier"`
+
+	// Identity ID
+	//
+	// Only return attempts in which this identity signed in or registered.
+	//
+	// required: false
+	// in: query
+	IdentityID string `json:"identity_id"`
+
+	// IP Address
+	//
+	// Only return attempts made from this IP address.
+	//
+	// required: false
+	// in: query
+	IP string `json:"ip"`
+
+	// Flow
+	//
+	// Only return attempts made in this flow, either `login` or `registration`.
+	//
+	// required: false
+	// in: query
+	Flow string `json:"flow"`
+
+	// Method
+	//
+	// Only return attempts using this method, for example `password`.
+	//
+	// required: false
+	// in: query
+	Method string `json:"method"`
+
+	// Success
+	//
+	// Only return successful (`true`) or failed (`false`) attempts.
+	//
+	// required: false
+	// in: query
+	Success bool `json:"success"`
+
+	// Since
+	//
+	// Only return attempts made at or after this time, formatted as RFC 3339.
+	//
+	// required: false
+	// in: query
+	Since string `json:"since"`
+
+	// Until
+	//
+	// Only return attempts made before this time, formatted as RFC 3339.
+	//
+	// required: false
+	// in: query
+	Until string `json:"until"`
+}
+
+// swagger:route GET /attempts admin listAuthenticationAttempts
+//
+// List Login and Registration Attempts
+//
+// This endpoint returns the login and registration attempts recorded if `attempts.enabled` is set, the most
+// recent one first. Attempts are kept for the duration configured at `attempts.retention` and removed by the
+// `attempts-cleanup` background job. Identifiers are only stored as keyed hashes, so filtering by identifier
+// only finds attempts recorded while one of the secrets at `secrets.default` was configured.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: authenticationAttemptList
+//       400: genericError
+//       500: genericError
+func (h *Handler) list(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	filter, err := h.parseFilter(r)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	page, itemsPerPage := x.ParsePagination(r)
+	attempts, err := h.d.AttemptPersister().ListAttempts(r.Context(), filter, page, itemsPerPage)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	total, err := h.d.AttemptPersister().CountAttempts(r.Context(), filter)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	query := url.Values{}
+	for _, key := range []string{"identifier", "identity_id", "ip", "flow", "method", "success", "since", "until"} {
+		if value := r.URL.Query().Get(key); value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	x.PaginationHeader(w, urlx.CopyWithQuery(urlx.AppendPaths(h.d.Config(r.Context()).SelfAdminURL(), RouteCollection), query), total, page, itemsPerPage)
+	h.d.Writer().Write(w, r, x.PaginationBody(r, attempts, total, page, itemsPerPage))
+}
+
+func (h *Handler) parseFilter(r *http.Request) (filter Filter, err error) {
+	query := r.URL.Query()
+	if identifier := query.Get("identifier"); identifier != "" {
+		filter.IdentifierHashes = h.d.AttemptManager().HashIdentifier(r.Context(), identifier)
+	}
+
+	if id := query.Get("identity_id"); id != "" {
+		if filter.IdentityID, err = uuid.FromString(id); err != nil {
+			return filter, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The query parameter identity_id must be a UUID: %s", err))
+		}
+	}
+
+	filter.IP = query.Get("ip")
+	filter.Method = query.Get("method")
+	switch f := Flow(query.Get("flow")); f {
+	case "", FlowLogin, FlowRegistration:
+		filter.Flow = f
+	default:
+		return filter, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The query parameter flow must be either %s or %s.", FlowLogin, FlowRegistration))
+	}
+
+	if s := query.Get("success"); s != "" {
+		success, err := strconv.ParseBool(s)
+		if err != nil {
+			return filter, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The query parameter success must be a boolean: %s", err))
+		}
+		filter.Success = &success
+	}
+
+	for key, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(key); value != "" {
+			if *t, err = time.Parse(time.RFC3339, value); err != nil {
+				return filter, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The query parameter %s must be formatted as RFC 3339: %s", key, err))
+			}
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,177 @@
+package attempt
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	_ "embed"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/decoderx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/reputation"
+	"github.com/ory/kratos/x"
+)
+
+//go:embed .schema/identifier.schema.json
+var identifierSchema []byte
+
+var hd = decoderx.NewHTTP()
+
+type (
+	managerDependencies interface {
+		PersistenceProvider
+		config.Provider
+		x.LoggingProvider
+	}
+	ManagementProvider interface {
+		AttemptManager() *Manager
+	}
+	// Manager records login and registration attempts if `attempts.enabled` is set and removes them once
+	// `attempts.retention` has passed.
+	Manager struct {
+		d managerDependencies
+	}
+)
+
+func NewManager(d managerDependencies) *Manager {
+	return &Manager{d: d}
+}
+
+// IdentifierFromRequest returns the identifier the login or registration request carries in the `identifier`
+// or `password_identifier` field. The request body can still be read afterwards. An empty string is returned if
+// the request carries no identifier.
+func IdentifierFromRequest(r *http.Request) string {
+	var p struct {
+		Identifier         string `json:"identifier" form:"identifier"`
+		PasswordIdentifier string `json:"password_identifier" form:"password_identifier"`
+	}
+
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(identifierSchema)
+	if err != nil {
+		return ""
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return ""
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// The clone is decoded so that a malformed form is not cached in the request and left to the strategies
+	// to report.
+	clone := r.Clone(r.Context())
+	clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err := hd.Decode(clone, &p, compiler,
+		decoderx.HTTPDecoderAllowedMethods("POST", "PUT", "PATCH", "GET"),
+		decoderx.HTTPDecoderSetValidatePayloads(false),
+		decoderx.HTTPDecoderJSONFollowsFormFormat()); err != nil {
+		return ""
+	}
+
+	if p.PasswordIdentifier != "" {
+		return p.PasswordIdentifier
+	}
+	return p.Identifier
+}
+
+// RecordSuccess records that the identity signed in or registered using the method. The identifier added to the
+// request's context using WithIdentifier is recorded or, if there is none, the identity's first identifier for
+// the method.
+func (m *Manager) RecordSuccess(r *http.Request, flow Flow, flowID uuid.UUID, method identity.CredentialsType, i *identity.Identity) {
+	identifier := IdentifierFromContext(r.Context())
+	if c, ok := i.GetCredentials(method); identifier == "" && ok && len(c.Identifiers) > 0 {
+		identifier = c.Identifiers[0]
+	}
+
+	m.record(r, &Attempt{
+		Flow:       flow,
+		FlowID:     flowID,
+		Method:     string(method),
+		Success:    true,
+		IdentityID: uuid.NullUUID{UUID: i.ID, Valid: true},
+	}, identifier)
+}
+
+// RecordFailure records that signing in or registering using the method failed. The identifier added to the
+// request's context using WithIdentifier is recorded.
+func (m *Manager) RecordFailure(r *http.Request, flow Flow, flowID uuid.UUID, method identity.CredentialsType) {
+	m.record(r, &Attempt{
+		Flow:   flow,
+		FlowID: flowID,
+		Method: string(method),
+	}, IdentifierFromContext(r.Context()))
+}
+
+// record stores the attempt. Errors are logged but not returned because failing to record an attempt must not
+// fail the flow.
+func (m *Manager) record(r *http.Request, a *Attempt, identifier string) {
+	conf := m.d.Config(r.Context())
+	if !conf.AttemptsEnabled() {
+		return
+	}
+
+	a.IP = reputation.ClientIP(r, conf.IPReputationClientIPHeader())
+	if identifier != "" {
+		a.IdentifierHash = m.HashIdentifier(r.Context(), identifier)[0]
+	}
+
+	if err := m.d.AttemptPersister().AddAttempt(r.Context(), a); err != nil {
+		m.d.Logger().
+			WithError(err).
+			WithField("flow_id", a.FlowID).
+			WithField("method", a.Method).
+			Warn("Unable to record the authentication attempt.")
+	}
+}
+
+// HashIdentifier returns the hashes of the identifier, one for each of the secrets configured at
+// `secrets.default`. Attempts are recorded with the hash of the current secret. Identifiers are compared case
+// insensitively.
+func (m *Manager) HashIdentifier(ctx context.Context, identifier string) []string {
+	identifier = strings.ToLower(strings.TrimSpace(identifier))
+	secrets := m.d.Config(ctx).SecretsDefault()
+	hashes := make([]string, len(secrets))
+	for k, secret := range secrets {
+		h := hmac.New(sha512.New512_256, secret)
+		_, _ = h.Write([]byte(identifier))
+		hashes[k] = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	return hashes
+}
+
+// CountFailures returns how many attempts using the identifier failed since the given time, for example to
+// slow down or block guessing passwords.
+func (m *Manager) CountFailures(ctx context.Context, identifier string, since time.Time) (int64, error) {
+	success := false
+	return m.d.AttemptPersister().CountAttempts(ctx, Filter{
+		IdentifierHashes: m.HashIdentifier(ctx, identifier),
+		Success:          &success,
+		Since:            since,
+	})
+}
+
+// DeleteExpired removes the attempts which are older than `attempts.retention`. It returns how many attempts
+// were removed.
+func (m *Manager) DeleteExpired(ctx context.Context) (int, error) {
+	deleted, err := m.d.AttemptPersister().DeleteAttemptsBefore(ctx, time.Now().UTC().Add(-m.d.Config(ctx).AttemptsRetention()))
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		m.d.Logger().
+			WithField("deleted", deleted).
+			Debug("Deleted expired authentication attempts.")
+	}
+	return deleted, nil
+}
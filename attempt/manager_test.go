@@ -0,0 +1,167 @@
+package attempt_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/attempt"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestIdentifierFromRequest(t *testing.T) {
+	for k, tc := range []struct {
+		contentType, body, expected string
+	}{
+		{contentType: "application/x-www-form-urlencoded", body: url.Values{"identifier": {"foo@ory.sh"}, "password": {"secret"}}.Encode(), expected: "foo@ory.sh"},
+		{contentType: "application/x-www-form-urlencoded", body: url.Values{"password_identifier": {"bar@ory.sh"}}.Encode(), expected: "bar@ory.sh"},
+		{contentType: "application/json", body: `{"identifier":"foo@ory.sh","method":"password"}`, expected: "foo@ory.sh"},
+		{contentType: "application/json", body: `{"method":"oidc"}`, expected: ""},
+		{contentType: "application/json", body: `not json`, expected: ""},
+	} {
+		r := httptest.NewRequest("POST", "/self-service/login", strings.NewReader(tc.body))
+		r.Header.Set("Content-Type", tc.contentType)
+		assert.Equal(t, tc.expected, attempt.IdentifierFromRequest(r), "%d", k)
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, tc.body, string(body), "the request body can still be read")
+	}
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySecretsDefault, []string{"a-very-secret-secret-with-32-chars"})
+	m := reg.AttemptManager()
+
+	newRequest := func(identifier string) *http.Request {
+		r := httptest.NewRequest("POST", "/self-service/login", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		return r.WithContext(attempt.WithIdentifier(r.Context(), identifier))
+	}
+
+	list := func(t *testing.T, filter attempt.Filter) []attempt.Attempt {
+		attempts, err := reg.AttemptPersister().ListAttempts(ctx, filter, 0, 100)
+		require.NoError(t, err)
+		return attempts
+	}
+
+	t.Run("case=hashes identifiers case insensitively with every secret", func(t *testing.T) {
+		hashes := m.HashIdentifier(ctx, "Foo@ory.sh ")
+		require.Len(t, hashes, 1)
+		assert.Len(t, hashes[0], 64)
+		assert.Equal(t, hashes, m.HashIdentifier(ctx, "foo@ory.sh"))
+		assert.NotEqual(t, hashes, m.HashIdentifier(ctx, "bar@ory.sh"))
+
+		conf.MustSet(config.ViperKeySecretsDefault, []string{"a-new-secret-secret-with-32-chars", "a-very-secret-secret-with-32-chars"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySecretsDefault, []string{"a-very-secret-secret-with-32-chars"})
+		})
+		rotated := m.HashIdentifier(ctx, "foo@ory.sh")
+		require.Len(t, rotated, 2)
+		assert.Equal(t, hashes[0], rotated[1])
+	})
+
+	t.Run("case=records attempts", func(t *testing.T) {
+		identifier := x.NewUUID().String() + "@ory.sh"
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{Type: identity.CredentialsTypePassword, Identifiers: []string{identifier}})
+		flowID := x.NewUUID()
+
+		m.RecordFailure(newRequest(identifier), attempt.FlowLogin, flowID, identity.CredentialsTypePassword)
+		m.RecordFailure(newRequest(strings.ToUpper(identifier)), attempt.FlowLogin, flowID, identity.CredentialsTypePassword)
+		m.RecordSuccess(newRequest(""), attempt.FlowLogin, flowID, identity.CredentialsTypePassword, i)
+
+		attempts := list(t, attempt.Filter{IdentifierHashes: m.HashIdentifier(ctx, identifier)})
+		require.Len(t, attempts, 3)
+		for _, a := range attempts {
+			assert.Equal(t, attempt.FlowLogin, a.Flow)
+			assert.Equal(t, flowID, a.FlowID)
+			assert.Equal(t, "password", a.Method)
+			assert.Equal(t, "192.0.2.1", a.IP)
+		}
+		success := true
+		assert.Len(t, list(t, attempt.Filter{IdentityID: i.ID, Success: &success}), 1)
+
+		failures, err := m.CountFailures(ctx, identifier, time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, failures)
+	})
+
+	t.Run("case=does not record attempts if disabled", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyAttemptsEnabled, false)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyAttemptsEnabled, true)
+		})
+
+		identifier := x.NewUUID().String()
+		m.RecordFailure(newRequest(identifier), attempt.FlowRegistration, x.NewUUID(), identity.CredentialsTypePassword)
+		assert.Empty(t, list(t, attempt.Filter{IdentifierHashes: m.HashIdentifier(ctx, identifier)}))
+	})
+
+	t.Run("case=deletes expired attempts", func(t *testing.T) {
+		ip := x.NewUUID().String()
+		for _, createdAt := range []time.Time{time.Now().Add(-2 * time.Hour), time.Now()} {
+			require.NoError(t, reg.AttemptPersister().AddAttempt(ctx, &attempt.Attempt{
+				ID: x.NewUUID(), Flow: attempt.FlowLogin, FlowID: x.NewUUID(), Method: "password", IP: ip, CreatedAt: createdAt,
+			}))
+		}
+
+		conf.MustSet(config.ViperKeyAttemptsRetention, "1h")
+		deleted, err := m.DeleteExpired(ctx)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, 1)
+		assert.Len(t, list(t, attempt.Filter{IP: ip}), 1)
+	})
+
+	t.Run("case=lists attempts at the admin endpoint", func(t *testing.T) {
+		identifier := x.NewUUID().String()
+		m.RecordFailure(newRequest(identifier), attempt.FlowLogin, x.NewUUID(), identity.CredentialsTypePassword)
+		m.RecordFailure(newRequest(identifier), attempt.FlowRegistration, x.NewUUID(), identity.CredentialsTypePassword)
+
+		router := x.NewRouterAdmin()
+		reg.AttemptHandler().RegisterAdminRoutes(router)
+		ts := httptest.NewServer(router)
+		t.Cleanup(ts.Close)
+
+		get := func(t *testing.T, query url.Values) (*http.Response, []attempt.Attempt) {
+			res, err := ts.Client().Get(ts.URL + attempt.RouteCollection + "?" + query.Encode())
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			var attempts []attempt.Attempt
+			if res.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&attempts))
+			}
+			return res, attempts
+		}
+
+		res, attempts := get(t, url.Values{"identifier": {strings.ToUpper(identifier)}, "success": {"false"}})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Len(t, attempts, 2)
+		assert.Equal(t, "2", res.Header.Get("X-Total-Count"))
+
+		res, attempts = get(t, url.Values{"identifier": {identifier}, "flow": {"registration"}, "since": {time.Now().Add(-time.Hour).Format(time.RFC3339)}})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Len(t, attempts, 1)
+		assert.Equal(t, attempt.FlowRegistration, attempts[0].Flow)
+
+		for _, query := range []url.Values{{"flow": {"settings"}}, {"success": {"maybe"}}, {"identity_id": {"not-a-uuid"}}, {"since": {"yesterday"}}} {
+			res, _ = get(t, query)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, "%s", query.Encode())
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/attempt"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/x"
+)
+
+func TestPersister(ctx context.Context, p persistence.Persister) func(t *testing.T) {
+	var ids = func(attempts []attempt.Attempt) (ids []uuid.UUID) {
+		for _, a := range attempts {
+			ids = append(ids, a.ID)
+		}
+		return ids
+	}
+
+	return func(t *testing.T) {
+		nid, p := testhelpers.NewNetworkUnlessExisting(t, ctx, p)
+		now := time.Now().UTC().Truncate(time.Second)
+
+		var add = func(t *testing.T, a attempt.Attempt, createdAt time.Time) attempt.Attempt {
+			a.ID = x.NewUUID()
+			a.FlowID = x.NewUUID()
+			a.CreatedAt = createdAt
+			require.NoError(t, p.AddAttempt(ctx, &a))
+			assert.Equal(t, nid, a.NID)
+			return a
+		}
+
+		t.Run("case=lists and counts attempts matching the filter", func(t *testing.T) {
+			ip := x.NewUUID().String()
+			identityID := x.NewUUID()
+			success, failure := true, false
+
+			failed := add(t, attempt.Attempt{Flow: attempt.FlowLogin, Method: "password", IdentifierHash: "a", IP: ip}, now.Add(-time.Hour))
+			rotated := add(t, attempt.Attempt{Flow: attempt.FlowLogin, Method: "password", IdentifierHash: "b", IP: ip}, now.Add(-time.Minute))
+			succeeded := add(t, attempt.Attempt{Flow: attempt.FlowLogin, Method: "password", IdentifierHash: "a", IP: ip, Success: true, IdentityID: uuid.NullUUID{UUID: identityID, Valid: true}}, now)
+			registered := add(t, attempt.Attempt{Flow: attempt.FlowRegistration, Method: "oidc", IP: ip, Success: true}, now.Add(-2*time.Hour))
+
+			for k, tc := range []struct {
+				filter   attempt.Filter
+				expected []uuid.UUID
+			}{
+				{filter: attempt.Filter{IP: ip}, expected: []uuid.UUID{succeeded.ID, rotated.ID, failed.ID, registered.ID}},
+				{filter: attempt.Filter{IP: ip, IdentifierHashes: []string{"a", "b"}}, expected: []uuid.UUID{succeeded.ID, rotated.ID, failed.ID}},
+				{filter: attempt.Filter{IP: ip, IdentifierHashes: []string{"a"}, Success: &failure}, expected: []uuid.UUID{failed.ID}},
+				{filter: attempt.Filter{IP: ip, Success: &success}, expected: []uuid.UUID{succeeded.ID, registered.ID}},
+				{filter: attempt.Filter{IdentityID: identityID}, expected: []uuid.UUID{succeeded.ID}},
+				{filter: attempt.Filter{IP: ip, Flow: attempt.FlowRegistration}, expected: []uuid.UUID{registered.ID}},
+				{filter: attempt.Filter{IP: ip, Method: "oidc"}, expected: []uuid.UUID{registered.ID}},
+				{filter: attempt.Filter{IP: ip, Since: now.Add(-time.Hour), Until: now}, expected: []uuid.UUID{rotated.ID, failed.ID}},
+			} {
+				actual, err := p.ListAttempts(ctx, tc.filter, 0, 100)
+				require.NoError(t, err, "%d", k)
+				assert.Equal(t, tc.expected, ids(actual), "%d", k)
+
+				count, err := p.CountAttempts(ctx, tc.filter)
+				require.NoError(t, err, "%d", k)
+				assert.EqualValues(t, len(tc.expected), count, "%d", k)
+			}
+
+			actual, err := p.ListAttempts(ctx, attempt.Filter{IP: ip}, 1, 1)
+			require.NoError(t, err)
+			assert.Equal(t, []uuid.UUID{rotated.ID}, ids(actual))
+			assert.False(t, actual[0].IdentityID.Valid)
+
+			actual, err = p.ListAttempts(ctx, attempt.Filter{IP: ip}, 0, 1)
+			require.NoError(t, err)
+			assert.Equal(t, uuid.NullUUID{UUID: identityID, Valid: true}, actual[0].IdentityID)
+		})
+
+		t.Run("case=deletes old attempts", func(t *testing.T) {
+			ip := x.NewUUID().String()
+			add(t, attempt.Attempt{Flow: attempt.FlowLogin, Method: "password", IP: ip}, now.Add(-48*time.Hour))
+			recent := add(t, attempt.Attempt{Flow: attempt.FlowLogin, Method: "password", IP: ip}, now)
+
+			deleted, err := p.DeleteAttemptsBefore(ctx, now.Add(-24*time.Hour))
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, deleted, 1)
+
+			actual, err := p.ListAttempts(ctx, attempt.Filter{IP: ip}, 0, 100)
+			require.NoError(t, err)
+			assert.Equal(t, []uuid.UUID{recent.ID}, ids(actual))
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package capability
+
+import (
+	"time"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/link"
+)
+
+// Version is the version of the capabilities document. It changes if fields are removed or change their meaning.
+const Version = "v1"
+
+// The APIs endpoints are served on.
+const (
+	APIPublic = "public"
+	APIAdmin  = "admin"
+)
+
+// The self-service flows listed in Capabilities.Flows.
+const (
+	FlowLogin        = "login"
+	FlowRegistration = "registration"
+	FlowSettings     = "settings"
+	FlowRecovery     = "recovery"
+	FlowVerification = "verification"
+)
+
+// strategyVersion is the version of all strategies. A strategy's version is bumped if its requests or responses
+// change incompatibly, and the previous version is listed as deprecated until it is removed.
+const strategyVersion = "v1"
+
+type (
+	// Capabilities tells clients which features are enabled, so that they can detect them instead of assuming
+	// the behavior of a deployment.
+	//
+	// swagger:model capabilities
+	Capabilities struct {
+		// Version is the version of this document's format.
+		//
+		// required: true
+		Version string `json:"version"`
+
+		// KratosVersion is the version of the running build.
+		//
+		// required: true
+		KratosVersion string `json:"kratos_version"`
+
+		// Flows are the self-service flows by name, for example `login`.
+		//
+		// required: true
+		Flows map[string]Flow `json:"flows"`
+
+		// Strategies are the self-service strategies, whether they are enabled or not.
+		//
+		// required: true
+		Strategies []Strategy `json:"strategies"`
+
+		// Endpoints are endpoints which are not part of upstream ORY Kratos.
+		//
+		// required: true
+		Endpoints []Endpoint `json:"endpoints"`
+	}
+
+	// Flow tells whether a self-service flow is enabled and which methods complete it.
+	Flow struct {
+		// required: true
+		Enabled bool `json:"enabled"`
+
+		// Methods are the IDs of the enabled strategies which complete the flow, for example `password`.
+		//
+		// required: true
+		Methods []string `json:"methods"`
+	}
+
+	// Strategy is a self-service strategy.
+	Strategy struct {
+		// ID is the strategy's ID, for example `password`. It is the `method` submitted to complete flows.
+		//
+		// required: true
+		ID string `json:"id"`
+
+		// Enabled is true if the strategy completes at least one flow. Strategies can be disabled at
+		// `selfservice.methods.<id>.enabled` or by their feature flag.
+		//
+		// required: true
+		Enabled bool `json:"enabled"`
+
+		// Flows are the flows the strategy supports if enabled.
+		//
+		// required: true
+		Flows []string `json:"flows"`
+
+		// required: true
+		Version string `json:"version"`
+
+		Deprecation *Deprecation `json:"deprecation,omitempty"`
+	}
+
+	// Endpoint is an API endpoint.
+	Endpoint struct {
+		// ID identifies the endpoint, for example `identity_lookup`.
+		//
+		// required: true
+		ID string `json:"id"`
+
+		// API is either `public` or `admin`.
+		//
+		// required: true
+		API string `json:"api"`
+
+		// required: true
+		Method string `json:"method"`
+
+		// Path is the endpoint's path. Path parameters are enclosed in braces, for example `{id}`.
+		//
+		// required: true
+		Path string `json:"path"`
+
+		// required: true
+		Enabled bool `json:"enabled"`
+
+		// required: true
+		Version string `json:"version"`
+
+		Deprecation *Deprecation `json:"deprecation,omitempty"`
+	}
+
+	// Deprecation tells clients that a strategy or endpoint will be removed and what to use instead.
+	Deprecation struct {
+		// Since is the Kratos version which deprecated the feature.
+		//
+		// required: true
+		Since string `json:"since"`
+
+		// SunsetAt is when the feature will be removed, if that is decided.
+		SunsetAt *time.Time `json:"sunset_at,omitempty"`
+
+		// Replacement is the ID of the strategy or endpoint to use instead.
+		Replacement string `json:"replacement,omitempty"`
+
+		// required: true
+		Message string `json:"message"`
+	}
+)
+
+// deprecations are the strategies and endpoints which are going to be removed, by ID. Deprecated items are
+// still listed with their deprecation until they are removed.
+var deprecations = map[string]*Deprecation{}
+
+// endpoint is an entry of endpoints. If enabled is nil, the endpoint is always enabled.
+type endpoint struct {
+	Endpoint
+	enabled func(c *config.Config) bool
+}
+
+// endpoints are the endpoints this fork adds.
+var endpoints = []endpoint{
+	{Endpoint: Endpoint{
+		ID:      "known_credentials",
+		API:     APIAdmin,
+		Method:  "POST",
+		Path:    identity.RouteKnownCredentials + "/batch",
+		Version: "v1",
+	}},
+	{Endpoint: Endpoint{
+		ID:      "identity_lookup",
+		API:     APIAdmin,
+		Method:  "GET",
+		Path:    identity.RouteLookup + "/{via}/{value}",
+		Version: "v1",
+	}},
+	{
+		Endpoint: Endpoint{
+			ID:      "verification_link",
+			API:     APIAdmin,
+			Method:  "POST",
+			Path:    link.RouteAdminCreateVerificationLink,
+			Version: "v1",
+		},
+		enabled: func(c *config.Config) bool {
+			return c.SelfServiceStrategy(verification.StrategyVerificationLinkName).Enabled && c.SelfServiceFlowVerificationEnabled()
+		},
+	},
+}
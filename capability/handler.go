@@ -0,0 +1,176 @@
+package capability
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/x"
+)
+
+const RouteCapabilities = "/.well-known/kratos/capabilities"
+
+type (
+	handlerDependencies interface {
+		config.Provider
+		x.WriterProvider
+
+		login.StrategyProvider
+		registration.StrategyProvider
+		settings.StrategyProvider
+		recovery.StrategyProvider
+		verification.StrategyProvider
+	}
+	HandlerProvider interface {
+		CapabilityHandler() *Handler
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
+	public.GET(RouteCapabilities, h.get)
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteCapabilities, h.get)
+}
+
+// The enabled features.
+//
+// swagger:response capabilities
+// nolint:deadcode,unused
+type capabilitiesResponse struct {
+	// in: body
+	Body Capabilities
+}
+
+// swagger:route GET /.well-known/kratos/capabilities public admin getCapabilities
+//
+// Get the Enabled Features
+//
+// This endpoint returns which self-service flows, strategies, and endpoints are enabled, with their versions
+// and deprecations. Clients should use it to detect features instead of assuming the behavior of a
+// deployment.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: capabilities
+//       500: genericError
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.d.Writer().Write(w, r, h.Capabilities(r))
+}
+
+// Capabilities returns the features enabled for the request.
+func (h *Handler) Capabilities(r *http.Request) *Capabilities {
+	ctx := r.Context()
+	c := h.d.Config(ctx)
+
+	var all, enabled ids
+	for _, s := range h.d.AllLoginStrategies() {
+		all.add(FlowLogin, string(s.ID()))
+	}
+	for _, s := range h.d.LoginStrategies(ctx) {
+		enabled.add(FlowLogin, string(s.ID()))
+	}
+	for _, s := range h.d.AllRegistrationStrategies() {
+		all.add(FlowRegistration, string(s.ID()))
+	}
+	for _, s := range h.d.RegistrationStrategies(ctx) {
+		enabled.add(FlowRegistration, string(s.ID()))
+	}
+	for _, s := range h.d.AllSettingsStrategies() {
+		all.add(FlowSettings, s.SettingsStrategyID())
+	}
+	for _, s := range h.d.SettingsStrategies(ctx) {
+		enabled.add(FlowSettings, s.SettingsStrategyID())
+	}
+	for _, s := range h.d.AllRecoveryStrategies() {
+		all.add(FlowRecovery, s.RecoveryStrategyID())
+	}
+	for _, s := range h.d.RecoveryStrategies(ctx) {
+		enabled.add(FlowRecovery, s.RecoveryStrategyID())
+	}
+	for _, s := range h.d.AllVerificationStrategies() {
+		all.add(FlowVerification, s.VerificationStrategyID())
+	}
+	for _, s := range h.d.VerificationStrategies(ctx) {
+		enabled.add(FlowVerification, s.VerificationStrategyID())
+	}
+
+	flows := map[string]bool{
+		FlowLogin:        true,
+		FlowRegistration: true,
+		FlowSettings:     true,
+		FlowRecovery:     c.SelfServiceFlowRecoveryEnabled(),
+		FlowVerification: c.SelfServiceFlowVerificationEnabled(),
+	}
+
+	caps := &Capabilities{
+		Version:       Version,
+		KratosVersion: config.Version,
+		Flows:         make(map[string]Flow, len(flows)),
+		Strategies:    make([]Strategy, 0, len(all.order)),
+		Endpoints:     make([]Endpoint, 0, len(endpoints)),
+	}
+
+	for name, on := range flows {
+		methods := []string{}
+		if on {
+			methods = append(methods, enabled.byFlow[name]...)
+		}
+		caps.Flows[name] = Flow{Enabled: on, Methods: methods}
+	}
+
+	for _, id := range all.order {
+		caps.Strategies = append(caps.Strategies, Strategy{
+			ID:          id,
+			Enabled:     len(enabled.flows[id]) > 0,
+			Flows:       all.flows[id],
+			Version:     strategyVersion,
+			Deprecation: deprecations[id],
+		})
+	}
+
+	for _, e := range endpoints {
+		e.Endpoint.Enabled = e.enabled == nil || e.enabled(c)
+		e.Deprecation = deprecations[e.ID]
+		caps.Endpoints = append(caps.Endpoints, e.Endpoint)
+	}
+
+	return caps
+}
+
+// ids collects strategy IDs by flow and flows by strategy ID, in the order they were added.
+type ids struct {
+	order  []string
+	flows  map[string][]string
+	byFlow map[string][]string
+}
+
+func (i *ids) add(flow, id string) {
+	if i.flows == nil {
+		i.flows = map[string][]string{}
+		i.byFlow = map[string][]string{}
+	}
+	if _, ok := i.flows[id]; !ok {
+		i.order = append(i.order, id)
+	}
+	i.flows[id] = append(i.flows[id], flow)
+	i.byFlow[flow] = append(i.byFlow[flow], id)
+}
@@ -0,0 +1,106 @@
+package capability_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/capability"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestHandler(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".password.enabled", true)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".profile.enabled", true)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".link.enabled", true)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".oidc.enabled", false)
+	conf.MustSet(config.ViperKeySelfServiceVerificationEnabled, true)
+	conf.MustSet(config.ViperKeySelfServiceRecoveryEnabled, false)
+
+	public, admin := x.NewRouterPublic(), x.NewRouterAdmin()
+	reg.CapabilityHandler().RegisterPublicRoutes(public)
+	reg.CapabilityHandler().RegisterAdminRoutes(admin)
+	publicTS, adminTS := httptest.NewServer(public), httptest.NewServer(admin)
+	t.Cleanup(publicTS.Close)
+	t.Cleanup(adminTS.Close)
+
+	get := func(t *testing.T, ts *httptest.Server) gjson.Result {
+		res, err := ts.Client().Get(ts.URL + capability.RouteCapabilities)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		return gjson.ParseBytes(body)
+	}
+
+	for name, ts := range map[string]*httptest.Server{"public": publicTS, "admin": adminTS} {
+		t.Run("api="+name, func(t *testing.T) {
+			actual := get(t, ts)
+			assert.Equal(t, capability.Version, actual.Get("version").String())
+			assert.Equal(t, config.Version, actual.Get("kratos_version").String())
+
+			t.Run("case=lists the flows and their enabled methods", func(t *testing.T) {
+				assert.True(t, actual.Get("flows.login.enabled").Bool())
+				assert.Contains(t, actual.Get("flows.login.methods").Raw, `"password"`)
+				assert.NotContains(t, actual.Get("flows.login.methods").Raw, `"oidc"`)
+				assert.Contains(t, actual.Get("flows.settings.methods").Raw, `"profile"`)
+				assert.True(t, actual.Get("flows.verification.enabled").Bool())
+				assert.Equal(t, `["link"]`, actual.Get("flows.verification.methods").Raw)
+
+				assert.False(t, actual.Get("flows.recovery.enabled").Bool())
+				assert.Equal(t, "[]", actual.Get("flows.recovery.methods").Raw)
+			})
+
+			t.Run("case=lists all strategies", func(t *testing.T) {
+				password := actual.Get(`strategies.#(id=="password")`)
+				require.True(t, password.Exists(), "%s", actual.Get("strategies").Raw)
+				assert.True(t, password.Get("enabled").Bool())
+				assert.Equal(t, "v1", password.Get("version").String())
+				assert.Contains(t, password.Get("flows").Raw, `"login"`)
+				assert.Contains(t, password.Get("flows").Raw, `"registration"`)
+				assert.False(t, password.Get("deprecation").Exists())
+
+				oidc := actual.Get(`strategies.#(id=="oidc")`)
+				require.True(t, oidc.Exists(), "%s", actual.Get("strategies").Raw)
+				assert.False(t, oidc.Get("enabled").Bool())
+			})
+
+			t.Run("case=lists the fork's endpoints", func(t *testing.T) {
+				for id, path := range map[string]string{
+					"known_credentials": "/credentials/known/batch",
+					"identity_lookup":   "/identity-lookup/{via}/{value}",
+					"verification_link": "/verification/link",
+				} {
+					e := actual.Get(`endpoints.#(id=="` + id + `")`)
+					require.True(t, e.Exists(), "%s", actual.Get("endpoints").Raw)
+					assert.Equal(t, path, e.Get("path").String())
+					assert.Equal(t, capability.APIAdmin, e.Get("api").String())
+					assert.True(t, e.Get("enabled").Bool())
+					assert.Equal(t, "v1", e.Get("version").String())
+				}
+			})
+		})
+	}
+
+	t.Run("case=strategies are disabled by their feature flag", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyFeatureFlags, []map[string]interface{}{{"name": "disabled", "enabled": false}})
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".password.feature_flag", "disabled")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".password.feature_flag", "")
+		})
+
+		actual := get(t, publicTS)
+		assert.False(t, actual.Get(`strategies.#(id=="password").enabled`).Bool())
+		assert.NotContains(t, actual.Get("flows.login.methods").Raw, `"password"`)
+	})
+}
@@ -0,0 +1,87 @@
+package cipher
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// AES encrypts messages using AES-256-GCM. Messages are encrypted using the first cipher secret and
+// decrypted using any of them, which allows rotating the secrets.
+type AES struct {
+	c AESConfiguration
+}
+
+type AESConfiguration interface {
+	config.Provider
+}
+
+func NewCryptAES(c AESConfiguration) *AES {
+	return &AES{c: c}
+}
+
+func (a *AES) Encrypt(ctx context.Context, message []byte) (string, error) {
+	keys := a.c.Config(ctx).SecretsCipher()
+	if len(keys) == 0 {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReason("Unable to encrypt message because no cipher secrets were configured."))
+	}
+
+	aead, err := newGCM(keys[0])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to generate nonce: %s", err))
+	}
+
+	return hex.EncodeToString(aead.Seal(nonce, nonce, message, nil)), nil
+}
+
+func (a *AES) Decrypt(ctx context.Context, ciphertext string) ([]byte, error) {
+	raw, err := hex.DecodeString(ciphertext)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode hex encrypted string: %s", err))
+	}
+
+	for _, key := range a.c.Config(ctx).SecretsCipher() {
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(raw) < aead.NonceSize() {
+			break
+		}
+
+		message, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil)
+		if err == nil {
+			return message, nil
+		}
+	}
+
+	return nil, errors.WithStack(herodot.ErrInternalServerError.WithReason("Unable to decrypt string with any of the configured cipher secrets."))
+}
+
+func newGCM(key [32]byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to initialize the cipher: %s", err))
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to initialize the cipher: %s", err))
+	}
+	return aead, nil
+}
@@ -0,0 +1,62 @@
+package cipher_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/cipher"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+)
+
+func TestAES(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	c := cipher.NewCryptAES(reg)
+
+	t.Run("case=derives the keys from the default secrets", func(t *testing.T) {
+		encrypted, err := c.Encrypt(ctx, []byte("secret message"))
+		require.NoError(t, err)
+		assert.NotContains(t, encrypted, "secret message")
+
+		decrypted, err := c.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "secret message", string(decrypted))
+	})
+
+	t.Run("case=rotates the cipher secrets", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySecretsCipher, []string{"00000000000000000000000000000001"})
+		encrypted, err := c.Encrypt(ctx, []byte("secret message"))
+		require.NoError(t, err)
+
+		conf.MustSet(config.ViperKeySecretsCipher, []string{"00000000000000000000000000000002", "00000000000000000000000000000001"})
+		decrypted, err := c.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "secret message", string(decrypted))
+
+		conf.MustSet(config.ViperKeySecretsCipher, []string{"00000000000000000000000000000002"})
+		_, err = c.Decrypt(ctx, encrypted)
+		require.Error(t, err)
+	})
+
+	t.Run("case=rejects tampered ciphertexts", func(t *testing.T) {
+		encrypted, err := c.Encrypt(ctx, []byte("secret message"))
+		require.NoError(t, err)
+
+		tampered := []byte(encrypted)
+		if tampered[len(tampered)-1] == '0' {
+			tampered[len(tampered)-1] = '1'
+		} else {
+			tampered[len(tampered)-1] = '0'
+		}
+
+		_, err = c.Decrypt(ctx, string(tampered))
+		require.Error(t, err)
+
+		_, err = c.Decrypt(ctx, "not-hex")
+		require.Error(t, err)
+	})
+}
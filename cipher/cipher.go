@@ -0,0 +1,16 @@
+package cipher
+
+import "context"
+
+// Cipher encrypts data which is stored in the database, such as the claims received from OpenID Connect providers.
+type Cipher interface {
+	// Encrypt returns the hex-encoded ciphertext of message.
+	Encrypt(ctx context.Context, message []byte) (string, error)
+
+	// Decrypt returns the message of a ciphertext returned by Encrypt.
+	Decrypt(ctx context.Context, ciphertext string) ([]byte, error)
+}
+
+type Provider interface {
+	Cipher() Cipher
+}
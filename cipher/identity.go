@@ -0,0 +1,232 @@
+package cipher
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+// identityCiphertextPrefix marks ciphertexts which were encrypted using an identity's data key. Ciphertexts
+// without it were encrypted using the cipher secrets directly.
+const identityCiphertextPrefix = "identity:"
+
+// ErrDataKeyDestroyed is returned when decrypting data whose identity's data key was destroyed.
+var ErrDataKeyDestroyed = herodot.ErrNotFound.WithReason("Unable to decrypt the data because the data key of the identity it belongs to was destroyed.")
+
+type (
+	// DataKey is the key used to encrypt the sensitive data of one identity. It is stored wrapped, that is
+	// encrypted using the cipher secrets, and destroyed when the identity is deleted.
+	DataKey struct {
+		ID         uuid.UUID `json:"-" faker:"-" db:"id"`
+		NID        uuid.UUID `json:"-" faker:"-" db:"nid"`
+		IdentityID uuid.UUID `json:"-" faker:"-" db:"identity_id"`
+		WrappedKey string    `json:"-" db:"wrapped_key"`
+
+		// CreatedAt is a helper struct field for gobuffalo.pop.
+		CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+	}
+
+	DataKeyPersister interface {
+		// GetIdentityDataKey returns the data key of the identity or sqlcon.ErrNoRows if it has none.
+		GetIdentityDataKey(ctx context.Context, identityID uuid.UUID) (*DataKey, error)
+
+		// CreateIdentityDataKey stores the data key. It returns sqlcon.ErrUniqueViolation if the identity
+		// already has one.
+		CreateIdentityDataKey(ctx context.Context, k *DataKey) error
+
+		// DeleteOrphanedIdentityDataKeys deletes the data keys created before the given time whose identity does
+		// not exist and returns how many were deleted.
+		DeleteOrphanedIdentityDataKeys(ctx context.Context, createdBefore time.Time) (int, error)
+	}
+
+	DataKeyPersistenceProvider interface {
+		DataKeyPersister() DataKeyPersister
+	}
+
+	identityKeysDependencies interface {
+		Provider
+		DataKeyPersistenceProvider
+		config.Provider
+		x.LoggingProvider
+	}
+
+	// IdentityKeys encrypts the sensitive data of an identity using its data key, which is created when the
+	// identity's data is encrypted for the first time. Once the identity is deleted, its data key is destroyed and
+	// data which remains, for example in backups, can no longer be decrypted.
+	//
+	// The data key protects the OIDC claims snapshots, the notes about the identity, and the hashes of its recovery
+	// answers. Courier messages are not encrypted with it because they are purged or anonymized when the identity
+	// is deleted, see `courier.message_retention`.
+	IdentityKeys struct {
+		d identityKeysDependencies
+	}
+
+	IdentityKeysProvider interface {
+		IdentityKeys() *IdentityKeys
+	}
+
+	identityCipher struct {
+		k  *IdentityKeys
+		id uuid.UUID
+	}
+)
+
+func (k DataKey) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "identity_data_keys")
+}
+
+func (k *DataKey) GetID() uuid.UUID {
+	return k.ID
+}
+
+func (k *DataKey) GetNID() uuid.UUID {
+	return k.NID
+}
+
+func NewIdentityKeys(d identityKeysDependencies) *IdentityKeys {
+	return &IdentityKeys{d: d}
+}
+
+// ForIdentity returns a cipher which encrypts messages using the identity's data key. It decrypts messages
+// which were encrypted using the cipher secrets directly as well.
+func (k *IdentityKeys) ForIdentity(id uuid.UUID) Cipher {
+	return &identityCipher{k: k, id: id}
+}
+
+// DeleteOrphaned deletes the data keys of identities which do not exist and returns how many were deleted. Such keys
+// are left behind when claims are encrypted during a registration which fails afterwards. Only keys older than the
+// registration flow lifespan are deleted, because younger ones may still belong to a registration in progress.
+func (k *IdentityKeys) DeleteOrphaned(ctx context.Context) (int, error) {
+	createdBefore := time.Now().UTC().Add(-k.d.Config(ctx).SelfServiceFlowRegistrationRequestLifespan())
+	deleted, err := k.d.DataKeyPersister().DeleteOrphanedIdentityDataKeys(ctx, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		k.d.Logger().
+			WithField("deleted", deleted).
+			Debug("Deleted orphaned identity data keys.")
+	}
+	return deleted, nil
+}
+
+func (c *identityCipher) Encrypt(ctx context.Context, message []byte) (string, error) {
+	key, err := c.k.dataKey(ctx, c.id, true)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to generate nonce: %s", err))
+	}
+
+	return identityCiphertextPrefix + hex.EncodeToString(aead.Seal(nonce, nonce, message, nil)), nil
+}
+
+func (c *identityCipher) Decrypt(ctx context.Context, ciphertext string) ([]byte, error) {
+	if !strings.HasPrefix(ciphertext, identityCiphertextPrefix) {
+		return c.k.d.Cipher().Decrypt(ctx, ciphertext)
+	}
+
+	raw, err := hex.DecodeString(strings.TrimPrefix(ciphertext, identityCiphertextPrefix))
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode hex encrypted string: %s", err))
+	}
+
+	key, err := c.k.dataKey(ctx, c.id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) < aead.NonceSize() {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReason("Unable to decrypt string because it is too short."))
+	}
+
+	message, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReason("Unable to decrypt string with the identity's data key."))
+	}
+	return message, nil
+}
+
+// dataKey returns the unwrapped data key of the identity. If the identity has none, it is created if create is
+// true and ErrDataKeyDestroyed is returned otherwise.
+func (k *IdentityKeys) dataKey(ctx context.Context, id uuid.UUID, create bool) ([32]byte, error) {
+	var key [32]byte
+
+	stored, err := k.d.DataKeyPersister().GetIdentityDataKey(ctx, id)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		if !create {
+			return key, errors.WithStack(ErrDataKeyDestroyed)
+		}
+		return k.createDataKey(ctx, id)
+	} else if err != nil {
+		return key, err
+	}
+
+	return k.unwrap(ctx, stored)
+}
+
+func (k *IdentityKeys) createDataKey(ctx context.Context, id uuid.UUID) ([32]byte, error) {
+	var key [32]byte
+	if _, err := io.ReadFull(rand.Reader, key[:]); err != nil {
+		return key, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to generate data key: %s", err))
+	}
+
+	wrapped, err := k.d.Cipher().Encrypt(ctx, key[:])
+	if err != nil {
+		return key, err
+	}
+
+	if err := k.d.DataKeyPersister().CreateIdentityDataKey(ctx, &DataKey{IdentityID: id, WrappedKey: wrapped}); errors.Is(err, sqlcon.ErrUniqueViolation) {
+		// Another request created the data key concurrently.
+		return k.dataKey(ctx, id, false)
+	} else if err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
+
+func (k *IdentityKeys) unwrap(ctx context.Context, stored *DataKey) ([32]byte, error) {
+	var key [32]byte
+
+	raw, err := k.d.Cipher().Decrypt(ctx, stored.WrappedKey)
+	if err != nil {
+		return key, err
+	}
+
+	if len(raw) != len(key) {
+		return key, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Expected the data key to be %d bytes long but got %d bytes.", len(key), len(raw)))
+	}
+
+	copy(key[:], raw)
+	return key, nil
+}
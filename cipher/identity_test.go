@@ -0,0 +1,127 @@
+package cipher_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/cipher"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/x"
+)
+
+func TestIdentityKeys(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/identity.schema.json")
+
+	createIdentity := func(t *testing.T) *identity.Identity {
+		i := identity.NewIdentity("")
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+		return i
+	}
+
+	t.Run("case=encrypts using the identity's data key", func(t *testing.T) {
+		i := createIdentity(t)
+		c := reg.IdentityKeys().ForIdentity(i.ID)
+
+		encrypted, err := c.Encrypt(ctx, []byte("secret message"))
+		require.NoError(t, err)
+		assert.NotContains(t, encrypted, "secret message")
+
+		decrypted, err := c.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "secret message", string(decrypted))
+
+		k, err := reg.DataKeyPersister().GetIdentityDataKey(ctx, i.ID)
+		require.NoError(t, err)
+
+		again, err := c.Encrypt(ctx, []byte("another message"))
+		require.NoError(t, err)
+		actual, err := reg.DataKeyPersister().GetIdentityDataKey(ctx, i.ID)
+		require.NoError(t, err)
+		assert.Equal(t, k.ID, actual.ID, "the data key is reused")
+
+		_, err = reg.IdentityKeys().ForIdentity(createIdentity(t).ID).Decrypt(ctx, again)
+		require.Error(t, err, "other identities can not decrypt the data")
+
+		_, err = reg.Cipher().Decrypt(ctx, encrypted)
+		require.Error(t, err, "the cipher secrets can not decrypt the data directly")
+	})
+
+	t.Run("case=decrypts data encrypted using the cipher secrets", func(t *testing.T) {
+		encrypted, err := reg.Cipher().Encrypt(ctx, []byte("secret message"))
+		require.NoError(t, err)
+
+		decrypted, err := reg.IdentityKeys().ForIdentity(createIdentity(t).ID).Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "secret message", string(decrypted))
+	})
+
+	t.Run("case=the data key is wrapped using the rotated cipher secrets", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySecretsCipher, []string{"00000000000000000000000000000001"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySecretsCipher, []string{})
+		})
+
+		c := reg.IdentityKeys().ForIdentity(createIdentity(t).ID)
+		encrypted, err := c.Encrypt(ctx, []byte("secret message"))
+		require.NoError(t, err)
+
+		conf.MustSet(config.ViperKeySecretsCipher, []string{"00000000000000000000000000000002", "00000000000000000000000000000001"})
+		decrypted, err := c.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "secret message", string(decrypted))
+
+		conf.MustSet(config.ViperKeySecretsCipher, []string{"00000000000000000000000000000002"})
+		_, err = c.Decrypt(ctx, encrypted)
+		require.Error(t, err)
+	})
+
+	t.Run("case=deleting the identity destroys the data key", func(t *testing.T) {
+		i := createIdentity(t)
+		c := reg.IdentityKeys().ForIdentity(i.ID)
+		encrypted, err := c.Encrypt(ctx, []byte("secret message"))
+		require.NoError(t, err)
+
+		report, err := reg.PrivilegedIdentityPool().DescribeIdentityDeletion(ctx, i.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.DataKeys)
+
+		require.NoError(t, reg.PrivilegedIdentityPool().DeleteIdentity(ctx, i.ID))
+
+		_, err = c.Decrypt(ctx, encrypted)
+		require.ErrorIs(t, err, cipher.ErrDataKeyDestroyed)
+	})
+
+	t.Run("case=deletes the data keys of identities which were never created", func(t *testing.T) {
+		existing := createIdentity(t)
+		orphaned := x.NewUUID()
+		for _, id := range []uuid.UUID{existing.ID, orphaned} {
+			_, err := reg.IdentityKeys().ForIdentity(id).Encrypt(ctx, []byte("secret message"))
+			require.NoError(t, err)
+		}
+
+		deleted, err := reg.IdentityKeys().DeleteOrphaned(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, deleted, "keys of registrations which may still be in progress are kept")
+
+		deleted, err = reg.DataKeyPersister().DeleteOrphanedIdentityDataKeys(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		_, err = reg.DataKeyPersister().GetIdentityDataKey(ctx, orphaned)
+		require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		_, err = reg.DataKeyPersister().GetIdentityDataKey(ctx, existing.ID)
+		require.NoError(t, err)
+	})
+}
@@ -44,6 +44,13 @@ func NewClient(cmd *cobra.Command) *kratos.APIClient {
 		panic(fmt.Sprintf("ClientContextKey was expected to be *client.OryKratos but it contained an invalid type %T ", f))
 	}
 
+	conf := kratos.NewConfiguration()
+	conf.Servers = kratos.ServerConfigurations{{URL: NewEndpoint(cmd).String()}}
+	return kratos.NewAPIClient(conf)
+}
+
+// NewEndpoint returns the URL of ORY Kratos' Admin API, for requests to endpoints the SDK does not cover.
+func NewEndpoint(cmd *cobra.Command) *url.URL {
 	endpoint, err := cmd.Flags().GetString(FlagEndpoint)
 	cmdx.Must(err, "flag access error: %s", err)
 
@@ -59,10 +66,7 @@ func NewClient(cmd *cobra.Command) *kratos.APIClient {
 
 	u, err := url.Parse(endpoint)
 	cmdx.Must(err, `Could not parse the endpoint URL "%s".`, endpoint)
-
-	conf := kratos.NewConfiguration()
-	conf.Servers = kratos.ServerConfigurations{{URL: u.String()}}
-	return kratos.NewAPIClient(conf)
+	return u
 }
 
 func RegisterClientFlags(flags *pflag.FlagSet) {
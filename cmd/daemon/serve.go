@@ -22,6 +22,8 @@ import (
 	"github.com/gorilla/context"
 	"github.com/spf13/cobra"
 	"github.com/urfave/negroni"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/ory/graceful"
 	"github.com/ory/x/metricsx"
@@ -77,6 +79,8 @@ func ServePublic(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	csrf := x.NewCSRFHandler(router, r)
 
 	n.UseFunc(x.CleanPath) // Prevent double slashes from breaking CSRF.
+	n.Use(x.NewPartitionedCookieHandler(r))
+	n.Use(r.PublicMiddleware())
 	r.WithCSRFHandler(csrf)
 	n.UseHandler(r.CSRFHandler())
 
@@ -95,10 +99,7 @@ func ServePublic(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 		handler = cors.New(options).Handler(handler)
 	}
 
-	server := graceful.WithDefaults(&http.Server{
-		Addr:    c.PublicListenOn(),
-		Handler: context.ClearHandler(handler),
-	})
+	server := newServer(r, c, "public", c.PublicListenOn(), handler)
 
 	l.Printf("Starting the public httpd on: %s", server.Addr)
 	if err := graceful.Graceful(server.ListenAndServe, server.Shutdown); err != nil {
@@ -130,10 +131,7 @@ func ServeAdmin(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	}
 
 	n.UseHandler(router)
-	server := graceful.WithDefaults(&http.Server{
-		Addr:    c.AdminListenOn(),
-		Handler: context.ClearHandler(n),
-	})
+	server := newServer(r, c, "admin", c.AdminListenOn(), n)
 
 	l.Printf("Starting the admin httpd on: %s", server.Addr)
 	if err := graceful.Graceful(server.ListenAndServe, server.Shutdown); err != nil {
@@ -142,6 +140,43 @@ func ServeAdmin(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	l.Println("Admin httpd was shutdown gracefully")
 }
 
+func newServer(r driver.Registry, c *config.Config, iface, addr string, handler http.Handler) *http.Server {
+	handler = x.NewRequestLimitsHandler(handler, r.Writer(), c.MaxBodyBytes(iface), c.MaxJSONDepth(iface))
+	if compression := c.Compression(iface); compression.Enabled {
+		var excluded []string
+		if iface == "public" {
+			excluded = x.UncompressedPublicPathPrefixes
+		}
+		handler = x.NewCompressionHandler(handler, compression.MinSize, excluded...)
+	}
+	handler = context.ClearHandler(handler)
+
+	timeouts := c.ServerTimeouts(iface)
+	h2 := c.HTTP2(iface)
+	h2s := &http2.Server{
+		MaxConcurrentStreams: h2.MaxConcurrentStreams,
+		MaxReadFrameSize:     h2.MaxReadFrameSize,
+		IdleTimeout:          timeouts.Idle,
+	}
+	if h2.Cleartext {
+		handler = h2c.NewHandler(handler, h2s)
+	}
+
+	server := graceful.WithDefaults(&http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       timeouts.Read,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+		MaxHeaderBytes:    c.MaxHeaderBytes(iface),
+	})
+	if err := http2.ConfigureServer(server, h2s); err != nil {
+		r.Logger().WithError(err).Fatalf("Unable to configure HTTP/2 for the %s httpd.", iface)
+	}
+	return server
+}
+
 func sqa(cmd *cobra.Command, d driver.Registry) *metricsx.Service {
 	// Creates only ones
 	// instance
@@ -213,6 +248,12 @@ func bgTasks(d driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args []s
 	if d.Config(cmd.Context()).IsBackgroundCourierEnabled() {
 		go courier.Watch(cmd.Context(), d)
 	}
+
+	go d.WebhookClient().Work(cmd.Context())
+
+	if len(d.Config(cmd.Context()).JobSchedules()) > 0 {
+		go d.JobScheduler().Work(cmd.Context())
+	}
 }
 
 func ServeAll(d driver.Registry, opts ...Option) func(cmd *cobra.Command, args []string) {
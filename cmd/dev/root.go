@@ -0,0 +1,23 @@
+package dev
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/configx"
+)
+
+// devCmd represents the dev command
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Tools for developing applications and user interfaces against ORY Kratos",
+}
+
+func init() {
+	configx.RegisterFlags(devCmd.PersistentFlags())
+}
+
+func RegisterCommandRecursive(parent *cobra.Command) {
+	parent.AddCommand(devCmd)
+
+	devCmd.AddCommand(exportUIFixturesCmd)
+}
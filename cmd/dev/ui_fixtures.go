@@ -0,0 +1,322 @@
+package dev
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/configx"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/ui/container"
+	"github.com/ory/kratos/x"
+)
+
+var exportUIFixturesCmd = &cobra.Command{
+	Use:   "export-ui-fixtures <directory>",
+	Short: "Write the UI of every self-service flow to JSON files",
+	Long: `Renders the UI container of every self-service flow, flow type, and enabled strategy using the given configuration
+and writes it to <directory>/<flow>/<api|browser>/<strategy>.json. The file all.json contains the UI shown when all
+enabled strategies are combined, which is what the flow returns to the user interface.
+
+Use the files to snapshot-test user interfaces against the exact nodes ORY Kratos emits. Flow IDs and CSRF tokens are
+replaced by fixed values, so the files only change when the configuration or ORY Kratos changes.
+
+The command never connects to the configured database. The identity shown in settings flows has the traits given by
+--traits, which must be valid for the default identity schema.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		traits, err := cmd.Flags().GetString("traits")
+		if err != nil {
+			return err
+		}
+
+		r := driver.New(cmd.Context(),
+			configx.WithFlags(cmd.Flags()),
+			configx.WithValue(config.ViperKeyDSN, "memory"))
+		return ExportUIFixtures(cmd, r, args[0], json.RawMessage(traits))
+	},
+}
+
+func init() {
+	exportUIFixturesCmd.Flags().String("traits", "{}", "The traits of the identity shown in settings flows.")
+}
+
+// FixtureFlowID replaces the flow ID in the form actions of exported fixtures.
+var FixtureFlowID = uuid.Nil
+
+func ExportUIFixtures(cmd *cobra.Command, r driver.Registry, dir string, traits json.RawMessage) error {
+	ctx := cmd.Context()
+	r.WithCSRFTokenGenerator(x.FakeCSRFTokenGenerator)
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(traits)
+	if err := r.IdentityManager().Create(ctx, i); err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not create the identity shown in settings flows, use --traits to set traits which are valid for the default identity schema: %s\n", err)
+		return cmdx.FailSilently(cmd)
+	}
+
+	e := &uiFixtureExporter{r: r, dir: dir, cmd: cmd, identity: i}
+	for _, ft := range []flow.Type{flow.TypeAPI, flow.TypeBrowser} {
+		if err := e.exportLogin(ctx, ft); err != nil {
+			return err
+		}
+		if err := e.exportRegistration(ctx, ft); err != nil {
+			return err
+		}
+		if err := e.exportSettings(ctx, ft); err != nil {
+			return err
+		}
+		if err := e.exportRecovery(ctx, ft); err != nil {
+			return err
+		}
+		if err := e.exportVerification(ctx, ft); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type uiFixtureExporter struct {
+	r        driver.Registry
+	dir      string
+	cmd      *cobra.Command
+	identity *identity.Identity
+}
+
+func (e *uiFixtureExporter) request(ctx context.Context, ft flow.Type, browserRoute, apiRoute string) (*http.Request, error) {
+	route := apiRoute
+	if ft == flow.TypeBrowser {
+		route = browserRoute
+	}
+
+	r, err := http.NewRequestWithContext(ctx, "GET", urlx.AppendPaths(e.r.Config(ctx).SelfPublicURL(nil), route).String(), nil)
+	return r, errors.WithStack(err)
+}
+
+func (e *uiFixtureExporter) action(r *http.Request, route string) string {
+	return flow.AppendFlowTo(urlx.AppendPaths(e.r.Config(r.Context()).SelfPublicURL(r), route), FixtureFlowID).String()
+}
+
+func (e *uiFixtureExporter) write(name string, ft flow.Type, strategy string, ui *container.Container) error {
+	out, err := json.MarshalIndent(ui, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	path := filepath.Join(e.dir, name, string(ft), strategy+".json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ioutil.WriteFile(path, append(out, '\n'), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, _ = fmt.Fprintln(e.cmd.OutOrStdout(), path)
+	return nil
+}
+
+func (e *uiFixtureExporter) exportLogin(ctx context.Context, ft flow.Type) error {
+	render := func(strategies login.Strategies) (*container.Container, error) {
+		r, err := e.request(ctx, ft, login.RouteInitBrowserFlow, login.RouteInitAPIFlow)
+		if err != nil {
+			return nil, err
+		}
+
+		conf := e.r.Config(ctx)
+		f := login.NewFlow(conf, time.Now(), conf.SelfServiceFlowLoginRequestLifespan(), x.FakeCSRFToken, r, ft)
+		f.UI.Action = e.action(r, login.RouteSubmitFlow)
+		for _, s := range strategies {
+			if err := s.PopulateLoginMethod(r, f); err != nil {
+				return nil, err
+			}
+		}
+		return f.UI, login.SortNodes(f.UI.Nodes)
+	}
+
+	strategies := e.r.LoginStrategies(ctx)
+	for _, s := range strategies {
+		ui, err := render(login.Strategies{s})
+		if err != nil {
+			return err
+		}
+		if err := e.write("login", ft, s.ID().String(), ui); err != nil {
+			return err
+		}
+	}
+
+	ui, err := render(strategies)
+	if err != nil {
+		return err
+	}
+	return e.write("login", ft, "all", ui)
+}
+
+func (e *uiFixtureExporter) exportRegistration(ctx context.Context, ft flow.Type) error {
+	render := func(strategies registration.Strategies) (*container.Container, error) {
+		r, err := e.request(ctx, ft, registration.RouteInitBrowserFlow, registration.RouteInitAPIFlow)
+		if err != nil {
+			return nil, err
+		}
+
+		conf := e.r.Config(ctx)
+		f := registration.NewFlow(conf, time.Now(), conf.SelfServiceFlowRegistrationRequestLifespan(), x.FakeCSRFToken, r, ft)
+		f.UI.Action = e.action(r, registration.RouteSubmitFlow)
+		for _, s := range strategies {
+			if err := s.PopulateRegistrationMethod(r, f); err != nil {
+				return nil, err
+			}
+		}
+		return f.UI, registration.SortNodes(f.UI.Nodes, conf.DefaultIdentityTraitsSchemaURL().String())
+	}
+
+	strategies := e.r.RegistrationStrategies(ctx)
+	for _, s := range strategies {
+		ui, err := render(registration.Strategies{s})
+		if err != nil {
+			return err
+		}
+		if err := e.write("registration", ft, s.ID().String(), ui); err != nil {
+			return err
+		}
+	}
+
+	ui, err := render(strategies)
+	if err != nil {
+		return err
+	}
+	return e.write("registration", ft, "all", ui)
+}
+
+func (e *uiFixtureExporter) exportSettings(ctx context.Context, ft flow.Type) error {
+	render := func(strategies settings.Strategies) (*container.Container, error) {
+		r, err := e.request(ctx, ft, settings.RouteInitBrowserFlow, settings.RouteInitAPIFlow)
+		if err != nil {
+			return nil, err
+		}
+
+		conf := e.r.Config(ctx)
+		f := settings.NewFlow(conf, time.Now(), conf.SelfServiceFlowSettingsFlowLifespan(), r, e.identity, ft)
+		f.UI.Action = e.action(r, settings.RouteSubmitFlow)
+		for _, s := range strategies {
+			if err := s.PopulateSettingsMethod(r, e.identity, f); err != nil {
+				return nil, err
+			}
+		}
+		return f.UI, settings.SortNodes(f.UI.Nodes, conf.DefaultIdentityTraitsSchemaURL().String())
+	}
+
+	strategies := e.r.SettingsStrategies(ctx)
+	for _, s := range strategies {
+		ui, err := render(settings.Strategies{s})
+		if err != nil {
+			return err
+		}
+		if err := e.write("settings", ft, s.SettingsStrategyID(), ui); err != nil {
+			return err
+		}
+	}
+
+	ui, err := render(strategies)
+	if err != nil {
+		return err
+	}
+	return e.write("settings", ft, "all", ui)
+}
+
+func (e *uiFixtureExporter) exportRecovery(ctx context.Context, ft flow.Type) error {
+	if !e.r.Config(ctx).SelfServiceFlowRecoveryEnabled() {
+		return nil
+	}
+
+	render := func(strategies recovery.Strategies) (*container.Container, error) {
+		r, err := e.request(ctx, ft, recovery.RouteInitBrowserFlow, recovery.RouteInitAPIFlow)
+		if err != nil {
+			return nil, err
+		}
+
+		conf := e.r.Config(ctx)
+		f, err := recovery.NewFlow(conf, time.Now(), conf.SelfServiceFlowRecoveryRequestLifespan(), x.FakeCSRFToken, r, strategies, ft)
+		if err != nil {
+			return nil, err
+		}
+		f.UI.Action = e.action(r, recovery.RouteSubmitFlow)
+		return f.UI, nil
+	}
+
+	strategies := e.r.RecoveryStrategies(ctx)
+	for _, s := range strategies {
+		ui, err := render(recovery.Strategies{s})
+		if err != nil {
+			return err
+		}
+		if err := e.write("recovery", ft, s.RecoveryStrategyID(), ui); err != nil {
+			return err
+		}
+	}
+
+	ui, err := render(strategies)
+	if err != nil {
+		return err
+	}
+	return e.write("recovery", ft, "all", ui)
+}
+
+func (e *uiFixtureExporter) exportVerification(ctx context.Context, ft flow.Type) error {
+	if !e.r.Config(ctx).SelfServiceFlowVerificationEnabled() {
+		return nil
+	}
+
+	render := func(strategies verification.Strategies) (*container.Container, error) {
+		r, err := e.request(ctx, ft, verification.RouteInitBrowserFlow, verification.RouteInitAPIFlow)
+		if err != nil {
+			return nil, err
+		}
+
+		conf := e.r.Config(ctx)
+		f, err := verification.NewFlow(conf, time.Now(), conf.SelfServiceFlowVerificationRequestLifespan(), x.FakeCSRFToken, r, strategies, ft)
+		if err != nil {
+			return nil, err
+		}
+		f.UI.Action = e.action(r, verification.RouteSubmitFlow)
+		return f.UI, nil
+	}
+
+	strategies := e.r.VerificationStrategies(ctx)
+	for _, s := range strategies {
+		ui, err := render(verification.Strategies{s})
+		if err != nil {
+			return err
+		}
+		if err := e.write("verification", ft, s.VerificationStrategyID(), ui); err != nil {
+			return err
+		}
+	}
+
+	ui, err := render(strategies)
+	if err != nil {
+		return err
+	}
+	return e.write("verification", ft, "all", ui)
+}
@@ -0,0 +1,83 @@
+package dev_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/cmd/dev"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/x"
+)
+
+func TestExportUIFixtures(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stubs/identity.schema.json")
+	conf.MustSet(config.ViperKeyPublicBaseURL, "https://kratos.example.com/")
+	for _, s := range []string{"password", "profile", "username", "link"} {
+		testhelpers.StrategyEnable(t, conf, s, true)
+	}
+	conf.MustSet(config.ViperKeySelfServiceRecoveryEnabled, true)
+	conf.MustSet(config.ViperKeySelfServiceVerificationEnabled, true)
+
+	dir := t.TempDir()
+	export := func(t *testing.T, traits string) (*cobra.Command, error) {
+		cmd := &cobra.Command{
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return dev.ExportUIFixtures(cmd, reg, dir, json.RawMessage(traits))
+			},
+			SilenceErrors: true,
+			SilenceUsage:  true,
+		}
+		cmd.SetArgs([]string{})
+		cmd.SetOut(new(bytes.Buffer))
+		cmd.SetErr(new(bytes.Buffer))
+		return cmd, cmd.ExecuteContext(context.Background())
+	}
+
+	read := func(t *testing.T, path ...string) []byte {
+		out, err := ioutil.ReadFile(filepath.Join(append([]string{dir}, path...)...))
+		require.NoError(t, err)
+		return out
+	}
+
+	t.Run("case=fails if the identity is invalid", func(t *testing.T) {
+		cmd, err := export(t, `{}`)
+		require.Error(t, err)
+		assert.Contains(t, cmd.ErrOrStderr().(*bytes.Buffer).String(), "--traits")
+	})
+
+	cmd, err := export(t, `{"email":"fixture@ory.sh","username":"fixture"}`)
+	require.NoError(t, err)
+	assert.Contains(t, cmd.OutOrStdout().(*bytes.Buffer).String(), filepath.Join(dir, "login", "browser", "password.json"))
+
+	t.Run("case=renders every strategy", func(t *testing.T) {
+		login := read(t, "login", "api", "password.json")
+		assert.Equal(t, "https://kratos.example.com/self-service/login?flow="+dev.FixtureFlowID.String(), gjson.GetBytes(login, "action").String(), "%s", login)
+		assert.True(t, gjson.GetBytes(login, `nodes.#(attributes.name=="password_identifier")`).Exists(), "%s", login)
+
+		settings := read(t, "settings", "browser", "username.json")
+		assert.Equal(t, "fixture", gjson.GetBytes(settings, `nodes.#(attributes.name=="username").attributes.value`).String(), "%s", settings)
+		assert.Equal(t, x.FakeCSRFToken, gjson.GetBytes(settings, `nodes.#(attributes.name=="csrf_token").attributes.value`).String(), "%s", settings)
+
+		all := read(t, "settings", "browser", "all.json")
+		for _, group := range []string{"profile", "password", "username"} {
+			assert.True(t, gjson.GetBytes(all, `nodes.#(group=="`+group+`")`).Exists(), "%s: %s", group, all)
+		}
+
+		read(t, "registration", "api", "password.json")
+		read(t, "recovery", "browser", "link.json")
+		read(t, "verification", "browser", "link.json")
+	})
+
+}
@@ -26,7 +26,7 @@ func TestGetCmd(t *testing.T) {
 		ij, err := json.Marshal(i)
 		require.NoError(t, err)
 
-		assert.Equal(t, string(ij)+"\n", stdOut)
+		assert.JSONEq(t, string(ij), stdOut)
 	})
 
 	t.Run("case=gets three identities", func(t *testing.T) {
@@ -37,7 +37,7 @@ func TestGetCmd(t *testing.T) {
 		isj, err := json.Marshal(is)
 		require.NoError(t, err)
 
-		assert.Equal(t, string(isj)+"\n", stdOut)
+		assert.JSONEq(t, string(isj), stdOut)
 	})
 
 	t.Run("case=fails with unknown ID", func(t *testing.T) {
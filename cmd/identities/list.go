@@ -14,7 +14,7 @@ import (
 var ListCmd = &cobra.Command{
 	Use:   "list [<page> <per-page>]",
 	Short: "List identities",
-	Long:  "List identities (paginated)",
+	Long:  "List identities (paginated). Pages start at 0.",
 	Args: func(cmd *cobra.Command, args []string) error {
 		// zero or exactly two args
 		if len(args) != 0 && len(args) != 2 {
@@ -38,11 +38,11 @@ func TestListCmd(t *testing.T) {
 		is, ids := makeIdentities(t, reg, 6)
 		defer deleteIdentities(t, is)
 
-		stdoutP1 := execNoErr(t, ListCmd, "1", "3")
-		stdoutP2 := execNoErr(t, ListCmd, "2", "3")
+		stdoutP1 := execNoErr(t, ListCmd, "0", "3")
+		stdoutP2 := execNoErr(t, ListCmd, "1", "3")
 
 		for _, id := range ids {
-			// exactly one of page 1 and 2 should contain the id
+			// exactly one of page 0 and 1 should contain the id
 			assert.True(t, strings.Contains(stdoutP1, id) != strings.Contains(stdoutP2, id), "%s \n %s", stdoutP1, stdoutP2)
 		}
 	})
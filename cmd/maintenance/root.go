@@ -0,0 +1,25 @@
+package maintenance
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/configx"
+)
+
+// maintenanceCmd represents the maintenance command
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Maintenance tasks which run directly against the ORY Kratos database",
+}
+
+func init() {
+	configx.RegisterFlags(maintenanceCmd.PersistentFlags())
+	cmdx.RegisterFormatFlags(maintenanceCmd.PersistentFlags())
+}
+
+func RegisterCommandRecursive(parent *cobra.Command) {
+	parent.AddCommand(maintenanceCmd)
+
+	maintenanceCmd.AddCommand(backfillVerifiableAddressesCmd)
+}
@@ -0,0 +1,97 @@
+package maintenance
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/configx"
+
+	"github.com/ory/kratos/driver"
+	"github.com/ory/kratos/identity"
+)
+
+var backfillVerifiableAddressesCmd = &cobra.Command{
+	Use:   "backfill-verifiable-addresses",
+	Short: "Create missing verifiable addresses for all identities",
+	Long: `Runs the verification extension of each identity's schema and creates the verifiable addresses which are not yet tracked.
+
+Use this command after adding "verification": {"via": "email"} to a trait of an identity schema which is already in use.
+Existing addresses and their verification status are left untouched. The command can be run while ORY Kratos is serving
+traffic, repeatedly, and from several machines at once.
+
+Identities whose traits do not validate against their schema are skipped and listed in the output.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return BackfillVerifiableAddresses(cmd, driver.New(cmd.Context(), configx.WithFlags(cmd.Flags())))
+	},
+}
+
+func init() {
+	backfillVerifiableAddressesCmd.Flags().Int("batch-size", 100, "The number of identities loaded at once.")
+	backfillVerifiableAddressesCmd.Flags().Int("workers", 4, "The number of identities processed concurrently.")
+	backfillVerifiableAddressesCmd.Flags().Bool("dry-run", false, "Only count the addresses which would be created.")
+}
+
+func BackfillVerifiableAddresses(cmd *cobra.Command, r driver.Registry) error {
+	batchSize, err := cmd.Flags().GetInt("batch-size")
+	if err != nil {
+		return err
+	}
+	workers, err := cmd.Flags().GetInt("workers")
+	if err != nil {
+		return err
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	report, err := r.IdentityManager().BackfillVerifiableAddresses(cmd.Context(), identity.BackfillOptions{
+		BatchSize: batchSize,
+		Workers:   workers,
+		DryRun:    dryRun,
+		OnBatch: func(report identity.BackfillReport) {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Processed %d identities, created %d addresses.\n", report.Identities, report.Created)
+		},
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not backfill verifiable addresses: %s\n", err)
+		return cmdx.FailSilently(cmd)
+	}
+
+	cmdx.PrintRow(cmd, (*outputBackfillReport)(report))
+	return nil
+}
+
+type outputBackfillReport identity.BackfillReport
+
+func (_ *outputBackfillReport) Header() []string {
+	return []string{"IDENTITIES", "CREATED", "SKIPPED", "INVALID"}
+}
+
+func (r *outputBackfillReport) Columns() []string {
+	invalid := cmdx.None
+	if len(r.Invalid) > 0 {
+		invalid = ""
+		for k, id := range r.Invalid {
+			if k > 0 {
+				invalid += ", "
+			}
+			invalid += id.String()
+		}
+	}
+
+	return []string{
+		strconv.Itoa(r.Identities),
+		strconv.Itoa(r.Created),
+		strconv.Itoa(r.Skipped),
+		invalid,
+	}
+}
+
+func (r *outputBackfillReport) Interface() interface{} {
+	return r
+}
@@ -0,0 +1,62 @@
+package maintenance
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/cmdx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+)
+
+func TestBackfillVerifiableAddresses(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stubs/identity.schema.json")
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"email":"maintenance@ory.sh"}`)
+	// The pool does not run the schema extensions, which leaves the identity without verifiable addresses like one
+	// that was created before the schema required verification.
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+	require.Empty(t, i.VerifiableAddresses)
+
+	run := func(t *testing.T, args ...string) string {
+		cmd := &cobra.Command{RunE: func(cmd *cobra.Command, _ []string) error {
+			return BackfillVerifiableAddresses(cmd, reg)
+		}}
+		// The flags are shared with backfillVerifiableAddressesCmd and keep their values between runs.
+		cmd.Flags().AddFlagSet(backfillVerifiableAddressesCmd.Flags())
+		cmdx.RegisterFormatFlags(cmd.Flags())
+
+		stdOut, stdErr := new(bytes.Buffer), new(bytes.Buffer)
+		cmd.SetOut(stdOut)
+		cmd.SetErr(stdErr)
+		cmd.SetArgs(append(args, "--"+cmdx.FlagFormat, string(cmdx.FormatJSON)))
+		require.NoError(t, cmd.ExecuteContext(ctx), stdErr.String())
+		return stdOut.String()
+	}
+
+	out := run(t, "--dry-run=true")
+	assert.EqualValues(t, 1, gjson.Get(out, "created").Int(), out)
+
+	out = run(t, "--dry-run=false")
+	assert.EqualValues(t, 1, gjson.Get(out, "identities").Int(), out)
+	assert.EqualValues(t, 1, gjson.Get(out, "created").Int(), out)
+
+	actual, err := reg.PrivilegedIdentityPool().GetIdentity(ctx, i.ID)
+	require.NoError(t, err)
+	require.Len(t, actual.VerifiableAddresses, 1)
+	assert.Equal(t, "maintenance@ory.sh", actual.VerifiableAddresses[0].Value)
+
+	out = run(t, "--dry-run=false")
+	assert.EqualValues(t, 0, gjson.Get(out, "created").Int(), out)
+}
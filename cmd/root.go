@@ -8,13 +8,17 @@ import (
 	"github.com/ory/kratos/driver/config"
 
 	"github.com/ory/kratos/cmd/courier"
+	"github.com/ory/kratos/cmd/dev"
 	"github.com/ory/kratos/cmd/hashers"
 
 	"github.com/ory/kratos/cmd/remote"
 
 	"github.com/ory/kratos/cmd/identities"
 	"github.com/ory/kratos/cmd/jsonnet"
+	"github.com/ory/kratos/cmd/maintenance"
 	"github.com/ory/kratos/cmd/migrate"
+	"github.com/ory/kratos/cmd/schemas"
+	"github.com/ory/kratos/cmd/seed"
 	"github.com/ory/kratos/cmd/serve"
 	"github.com/ory/x/cmdx"
 
@@ -47,6 +51,11 @@ func init() {
 	remote.RegisterCommandRecursive(RootCmd)
 	hashers.RegisterCommandRecursive(RootCmd)
 	courier.RegisterCommandRecursive(RootCmd)
+	maintenance.RegisterCommandRecursive(RootCmd)
+	dev.RegisterCommandRecursive(RootCmd)
+	schemas.RegisterCommandRecursive(RootCmd)
+	schemas.RegisterFlags()
+	seed.RegisterCommandRecursive(RootCmd)
 
 	RootCmd.AddCommand(cmdx.Version(&config.Version, &config.Commit, &config.Date))
 }
@@ -0,0 +1,112 @@
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/cmd/cliclient"
+	"github.com/ory/kratos/schema"
+)
+
+const FlagTypesFormat = "format"
+
+var GenerateTypesCmd = &cobra.Command{
+	Use:   "generate-types [<id-0> [<id-1> ...]]",
+	Short: "Generate types for the traits of identity schemas",
+	Long: `This command generates types for the traits of the given identity schemas, or of all identity schemas if no ID is given.
+
+The typescript format prints a TypeScript module which exports an interface for each schema, for example "CustomerTraits" for the schema "customer". The json format prints a type descriptor for each schema, keyed by the schema's ID, which other code generators can use.`,
+	Example: `To generate TypeScript interfaces for all identity schemas, run:
+
+	$ kratos schemas generate-types --endpoint http://localhost:4434 > src/kratos-traits.ts`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := cmd.Flags().GetString(FlagTypesFormat)
+		cmdx.Must(err, "flag access error: %s", err)
+		if format != schema.TypesFormatTypeScript && format != schema.TypesFormatJSON {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The format must be %q or %q.\n", schema.TypesFormatTypeScript, schema.TypesFormatJSON)
+			return cmdx.FailSilently(cmd)
+		}
+
+		c, endpoint := cliclient.NewHTTPClient(cmd), cliclient.NewEndpoint(cmd)
+
+		ids := args
+		if len(ids) == 0 {
+			body, err := get(c, urlx.AppendPaths(endpoint, schema.SchemasPath))
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not list the identity schemas: %s\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			for _, id := range gjson.GetBytes(body, "#.id").Array() {
+				ids = append(ids, id.String())
+			}
+		}
+
+		modules := make([]string, 0, len(ids))
+		descriptors := make(map[string]json.RawMessage, len(ids))
+		failed := make(map[string]error)
+		for _, id := range ids {
+			u := urlx.AppendPaths(endpoint, schema.SchemasPath, id, "types")
+			u.RawQuery = url.Values{"format": {format}}.Encode()
+
+			body, err := get(c, u)
+			if err != nil {
+				failed[id] = err
+				continue
+			}
+
+			modules = append(modules, string(body))
+			descriptors[id] = body
+		}
+
+		if format == schema.TypesFormatJSON {
+			out, err := json.MarshalIndent(descriptors, "", "  ")
+			cmdx.Must(err, "Could not encode the type descriptors: %s", err)
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(out))
+		} else if len(modules) > 0 {
+			_, _ = fmt.Fprint(cmd.OutOrStdout(), strings.Join(modules, "\n"))
+		}
+		cmdx.PrintErrors(cmd, failed)
+
+		if len(failed) != 0 {
+			return cmdx.FailSilently(cmd)
+		}
+		return nil
+	},
+}
+
+func init() {
+	GenerateTypesCmd.Flags().String(FlagTypesFormat, schema.TypesFormatTypeScript, fmt.Sprintf("The format of the types, either %q or %q.", schema.TypesFormatTypeScript, schema.TypesFormatJSON))
+}
+
+func get(c *http.Client, u *url.URL) ([]byte, error) {
+	res, err := c.Get(u.String())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		if reason := gjson.GetBytes(body, "error.reason").String(); len(reason) > 0 {
+			return nil, errors.Errorf("%s: %s", res.Status, reason)
+		}
+		return nil, errors.Errorf("%s: %s", res.Status, gjson.GetBytes(body, "error.message").String())
+	}
+
+	return body, nil
+}
@@ -0,0 +1,72 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/cmdx"
+
+	"github.com/ory/kratos/cmd/cliclient"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/schema"
+)
+
+func exec(cmd *cobra.Command, args ...string) (string, string, error) {
+	stdOut, stdErr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.SetErr(stdErr)
+	cmd.SetOut(stdOut)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return stdOut.String(), stdErr.String(), err
+}
+
+func TestGenerateTypesCmd(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	_, admin := testhelpers.NewKratosServerWithCSRF(t, reg)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stubs/customer.schema.json")
+
+	cliclient.RegisterClientFlags(GenerateTypesCmd.Flags())
+	require.NoError(t, GenerateTypesCmd.Flags().Set(cliclient.FlagEndpoint, admin.URL))
+
+	t.Run("case=generates typescript for all schemas", func(t *testing.T) {
+		stdOut, stdErr, err := exec(GenerateTypesCmd, "--format", schema.TypesFormatTypeScript)
+		require.NoError(t, err, stdErr)
+		assert.Contains(t, stdOut, "export interface DefaultTraits {\n  email: string\n}")
+	})
+
+	t.Run("case=generates type descriptors", func(t *testing.T) {
+		stdOut, stdErr, err := exec(GenerateTypesCmd, "--format", schema.TypesFormatJSON, config.DefaultIdentityTraitsSchemaID)
+		require.NoError(t, err, stdErr)
+
+		var descriptors map[string]schema.TypeDescriptor
+		require.NoError(t, json.Unmarshal([]byte(stdOut), &descriptors))
+		assert.Equal(t, "email", descriptors[config.DefaultIdentityTraitsSchemaID].Properties["email"].Format)
+	})
+
+	t.Run("case=fails for unknown formats", func(t *testing.T) {
+		_, stdErr, err := exec(GenerateTypesCmd, "--format", "go")
+		require.True(t, errors.Is(err, cmdx.ErrNoPrintButFail))
+		assert.Contains(t, stdErr, "The format must be")
+	})
+
+	t.Run("case=reports schemas without traits", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyIdentitySchemas, []config.Schema{{ID: "no-traits", URL: "file://./stubs/no-traits.schema.json"}})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentitySchemas, []config.Schema{})
+		})
+
+		stdOut, stdErr, err := exec(GenerateTypesCmd, "--format", schema.TypesFormatTypeScript)
+		require.True(t, errors.Is(err, cmdx.ErrNoPrintButFail))
+		assert.Contains(t, stdOut, "export interface DefaultTraits")
+		assert.Contains(t, stdErr, "no-traits")
+		assert.Contains(t, stdErr, "does not define any traits")
+	})
+}
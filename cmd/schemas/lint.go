@@ -0,0 +1,50 @@
+package schemas
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+
+	"github.com/ory/kratos/schema"
+)
+
+var LintCmd = &cobra.Command{
+	Use:   "lint <path-0> [<path-1> ...]",
+	Short: "Lint identity schema files",
+	Long: `This command checks identity schema files for common mistakes which JSON Schema validation does not catch, for example password identifiers which are not strings or email addresses used for recovery which identities do not need to have.
+
+It reads the files from the local file system and does not need a running server. It prints one line per issue and exits with a status code of 1 if any file is invalid or has issues.`,
+	Example: `$ kratos schemas lint contrib/quickstart/kratos/email-password/identity.schema.json`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var failed bool
+		for _, path := range args {
+			raw, err := ioutil.ReadFile(path)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s: Could not read the file: %s\n", path, err)
+				failed = true
+				continue
+			}
+
+			issues, err := schema.Lint(raw)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", path, err)
+				failed = true
+				continue
+			}
+
+			for _, issue := range issues {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", path, issue)
+				failed = true
+			}
+		}
+
+		if failed {
+			return cmdx.FailSilently(cmd)
+		}
+		return nil
+	},
+}
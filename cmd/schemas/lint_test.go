@@ -0,0 +1,32 @@
+package schemas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/schema"
+)
+
+func TestLintCmd(t *testing.T) {
+	t.Run("case=passes schemas without issues", func(t *testing.T) {
+		stdOut, stdErr, err := exec(LintCmd, "stubs/linted.schema.json")
+		require.NoError(t, err, stdErr)
+		assert.Empty(t, stdOut)
+	})
+
+	t.Run("case=prints issues", func(t *testing.T) {
+		stdOut, _, err := exec(LintCmd, "stubs/linted.schema.json", "stubs/customer.schema.json")
+		require.Error(t, err)
+		assert.Contains(t, stdOut, "stubs/customer.schema.json: No trait is marked as password identifier")
+		assert.Contains(t, stdOut, "("+schema.LintNoIdentifier+")")
+		assert.NotContains(t, stdOut, "stubs/linted.schema.json")
+	})
+
+	t.Run("case=fails on unreadable files", func(t *testing.T) {
+		_, stdErr, err := exec(LintCmd, "stubs/does-not-exist.schema.json")
+		require.Error(t, err)
+		assert.Contains(t, stdErr, "Could not read the file")
+	})
+}
@@ -0,0 +1,24 @@
+package schemas
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/kratos/cmd/cliclient"
+)
+
+// schemasCmd represents the schemas command
+var schemasCmd = &cobra.Command{
+	Use:   "schemas",
+	Short: "Tools to interact with identity schemas",
+}
+
+func RegisterCommandRecursive(parent *cobra.Command) {
+	parent.AddCommand(schemasCmd)
+
+	schemasCmd.AddCommand(GenerateTypesCmd)
+	schemasCmd.AddCommand(LintCmd)
+}
+
+func RegisterFlags() {
+	cliclient.RegisterClientFlags(schemasCmd.PersistentFlags())
+}
@@ -0,0 +1,68 @@
+package seed
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/configx"
+
+	"github.com/ory/kratos/driver"
+)
+
+// seedCmd represents the seed command
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Create identity schemas, tenants, and identities from a fixture file",
+	Long: `Reads a YAML or JSON fixture file and creates the tenants and identities it declares directly in the
+configured database, so that local development and preview environments start with a known dataset.
+
+Seeding is idempotent: tenants and identities are identified by the IDs given in the fixture file, and those which
+exist already are left untouched. Running the command again therefore creates nothing new.
+
+An example fixture file:
+
+	schemas:
+	  - id: customer
+	    path: ./customer.schema.json
+	tenants:
+	  - id: 8f2e1d47-0f3a-4c61-9c6f-0f9d3e4c1a01
+	identities:
+	  - id: 5e3f1a0c-2b5d-4c1e-8a7f-9d0b6c4e2f11
+	    schema_id: customer
+	    traits:
+	      email: alice@example.org
+	    credentials:
+	      password:
+	        password: alice-password
+	      oidc:
+	        providers:
+	          - provider: google
+	            subject: "1234567890"
+
+Schemas are added to "identity.schemas" while seeding so that the identities can be validated. ORY Kratos does not
+store identity schemas in the database, so the configuration used to serve must list them as well. Schema paths are
+relative to the fixture file.
+
+Identities without a tenant are created in the default tenant. The password credentials take either "password", which
+is hashed using the configured hasher, or "hashed_password", which must be a bcrypt or argon2id hash.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := cmd.Flags().GetString("file")
+		if err != nil {
+			return err
+		}
+
+		return Seed(cmd, driver.New(cmd.Context(), configx.WithFlags(cmd.Flags())), file)
+	},
+}
+
+func init() {
+	configx.RegisterFlags(seedCmd.PersistentFlags())
+	cmdx.RegisterFormatFlags(seedCmd.PersistentFlags())
+	seedCmd.Flags().String("file", "", "The fixture file.")
+	_ = seedCmd.MarkFlagRequired("file")
+}
+
+func RegisterCommandRecursive(parent *cobra.Command) {
+	parent.AddCommand(seedCmd)
+}
@@ -0,0 +1,284 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ghodss/yaml"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/networkx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/persistence"
+)
+
+type (
+	// Fixtures is the content of a fixture file.
+	Fixtures struct {
+		Schemas    []SchemaFixture   `json:"schemas"`
+		Tenants    []TenantFixture   `json:"tenants"`
+		Identities []IdentityFixture `json:"identities"`
+	}
+
+	SchemaFixture struct {
+		ID string `json:"id"`
+
+		// URL is the schema's URL. Path is an alternative to URL which is relative to the fixture file.
+		URL  string `json:"url"`
+		Path string `json:"path"`
+	}
+
+	TenantFixture struct {
+		ID uuid.UUID `json:"id"`
+	}
+
+	IdentityFixture struct {
+		ID          uuid.UUID           `json:"id"`
+		SchemaID    string              `json:"schema_id"`
+		Tenant      uuid.UUID           `json:"tenant"`
+		State       identity.State      `json:"state"`
+		Traits      json.RawMessage     `json:"traits"`
+		Credentials CredentialsFixtures `json:"credentials"`
+	}
+
+	CredentialsFixtures struct {
+		Password *PasswordFixture `json:"password"`
+		OIDC     *OIDCFixture     `json:"oidc"`
+	}
+
+	PasswordFixture struct {
+		Password       string `json:"password"`
+		HashedPassword string `json:"hashed_password"`
+	}
+
+	OIDCFixture struct {
+		Providers []identity.OIDCProvider `json:"providers"`
+	}
+
+	// Report summarizes what a seed run created.
+	Report struct {
+		Schemas            int `json:"schemas"`
+		TenantsCreated     int `json:"tenants_created"`
+		IdentitiesCreated  int `json:"identities_created"`
+		IdentitiesExisting int `json:"identities_existing"`
+	}
+)
+
+// Seed creates the tenants and identities declared in the fixture file which do not exist yet.
+func Seed(cmd *cobra.Command, r driver.Registry, file string) error {
+	report, err := seedFile(cmd.Context(), r, file)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not seed the fixtures of %s: %s\n", file, err)
+		return cmdx.FailSilently(cmd)
+	}
+
+	cmdx.PrintRow(cmd, (*outputReport)(report))
+	return nil
+}
+
+func seedFile(ctx context.Context, r driver.Registry, file string) (*Report, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var f Fixtures
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return nil, errors.Wrap(err, "the fixture file is invalid")
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var report Report
+	if err := seedSchemas(ctx, r, dir, f.Schemas); err != nil {
+		return nil, err
+	}
+	report.Schemas = len(f.Schemas)
+
+	for _, t := range f.Tenants {
+		created, err := seedTenant(ctx, r, t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to seed tenant %s", t.ID)
+		}
+		if created {
+			report.TenantsCreated++
+		}
+	}
+
+	for _, i := range f.Identities {
+		created, err := seedIdentity(ctx, r, i)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to seed identity %s", i.ID)
+		}
+		if created {
+			report.IdentitiesCreated++
+		} else {
+			report.IdentitiesExisting++
+		}
+	}
+
+	return &report, nil
+}
+
+// seedSchemas adds the schemas to `identity.schemas`. A schema which is configured already must have the same URL.
+func seedSchemas(ctx context.Context, r driver.Registry, dir string, schemas []SchemaFixture) error {
+	conf := r.Config(ctx)
+	configured := conf.IdentityTraitsSchemas()
+
+	var updated []config.Schema
+	for _, s := range configured {
+		if s.ID != config.DefaultIdentityTraitsSchemaID {
+			updated = append(updated, s)
+		}
+	}
+
+	for _, s := range schemas {
+		if len(s.ID) == 0 {
+			return errors.New("every schema needs an id")
+		}
+
+		u := s.URL
+		if len(s.Path) > 0 {
+			u = (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dir, s.Path))}).String()
+		}
+		if len(u) == 0 {
+			return errors.Errorf("schema %s needs a url or a path", s.ID)
+		}
+
+		if existing, err := configured.FindSchemaByID(s.ID); err == nil {
+			if existing.URL != u {
+				return errors.Errorf("schema %s is configured with the URL %s instead of %s", s.ID, existing.URL, u)
+			}
+			continue
+		}
+
+		updated = append(updated, config.Schema{ID: s.ID, URL: u})
+	}
+
+	values := make([]map[string]interface{}, len(updated))
+	for k, s := range updated {
+		values[k] = map[string]interface{}{"id": s.ID, "url": s.URL}
+	}
+	return errors.WithStack(conf.Set(config.ViperKeyIdentitySchemas, values))
+}
+
+func seedTenant(ctx context.Context, r driver.Registry, t TenantFixture) (bool, error) {
+	if t.ID == uuid.Nil {
+		return false, errors.New("every tenant needs an id")
+	}
+
+	c := r.Persister().GetConnection(ctx)
+	var n networkx.Network
+	if err := sqlcon.HandleError(c.Find(&n, t.ID)); err == nil {
+		return false, nil
+	} else if !errors.Is(err, sqlcon.ErrNoRows) {
+		return false, err
+	}
+
+	n.ID = t.ID
+	return true, sqlcon.HandleError(c.Create(&n))
+}
+
+func seedIdentity(ctx context.Context, r driver.Registry, f IdentityFixture) (bool, error) {
+	if f.ID == uuid.Nil {
+		// Without a fixed ID, the identity could not be found again the next time the fixtures are seeded.
+		return false, errors.New("every identity needs an id")
+	}
+
+	p := r.Persister()
+	if f.Tenant != uuid.Nil {
+		p = p.WithNetworkID(f.Tenant)
+	}
+
+	if _, err := p.GetIdentity(ctx, f.ID); err == nil {
+		return false, nil
+	} else if !errors.Is(err, sqlcon.ErrNoRows) {
+		return false, err
+	}
+
+	i := identity.NewIdentity(f.SchemaID)
+	i.ID = f.ID
+	if len(f.State) > 0 {
+		if err := f.State.Validate(); err != nil {
+			return false, err
+		}
+		i.State = f.State
+	}
+	if len(f.Traits) > 0 {
+		i.Traits = identity.Traits(f.Traits)
+	}
+
+	if pw := f.Credentials.Password; pw != nil {
+		hashed := []byte(pw.HashedPassword)
+		if len(pw.Password) > 0 {
+			var err error
+			if hashed, err = r.Hasher().Generate(ctx, []byte(pw.Password)); err != nil {
+				return false, err
+			}
+		} else if !hash.IsBcryptHash(hashed) && !hash.IsArgon2idHash(hashed) {
+			return false, errors.New("the password needs a password or a bcrypt or argon2id hashed_password")
+		}
+
+		if err := i.SetPassword(hashed); err != nil {
+			return false, err
+		}
+	}
+
+	if oidc := f.Credentials.OIDC; oidc != nil {
+		if err := i.SetOIDCProviders(oidc.Providers); err != nil {
+			return false, err
+		}
+	}
+
+	// The manager's validation sets the identifiers of the password credentials and the verifiable and recovery
+	// addresses.
+	if err := identity.NewManager(&tenantRegistry{Registry: r, p: p}).Create(ctx, i); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// tenantRegistry makes the identity manager write to the network of the tenant the identity belongs to.
+type tenantRegistry struct {
+	driver.Registry
+	p persistence.Persister
+}
+
+func (r *tenantRegistry) IdentityPool() identity.Pool {
+	return r.p
+}
+
+type outputReport Report
+
+func (_ *outputReport) Header() []string {
+	return []string{"SCHEMAS", "TENANTS CREATED", "IDENTITIES CREATED", "IDENTITIES EXISTING"}
+}
+
+func (r *outputReport) Columns() []string {
+	return []string{
+		strconv.Itoa(r.Schemas),
+		strconv.Itoa(r.TenantsCreated),
+		strconv.Itoa(r.IdentitiesCreated),
+		strconv.Itoa(r.IdentitiesExisting),
+	}
+}
+
+func (r *outputReport) Interface() interface{} {
+	return r
+}
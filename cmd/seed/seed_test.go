@@ -0,0 +1,90 @@
+package seed
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/cmdx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stubs/customer.schema.json")
+
+	run := func(t *testing.T, file string) (string, error) {
+		cmd := &cobra.Command{RunE: func(cmd *cobra.Command, _ []string) error {
+			return Seed(cmd, reg, file)
+		}}
+		cmdx.RegisterFormatFlags(cmd.Flags())
+
+		stdOut, stdErr := new(bytes.Buffer), new(bytes.Buffer)
+		cmd.SetOut(stdOut)
+		cmd.SetErr(stdErr)
+		cmd.SetArgs([]string{"--" + cmdx.FlagFormat, string(cmdx.FormatJSON)})
+		err := cmd.ExecuteContext(ctx)
+		return stdOut.String() + stdErr.String(), err
+	}
+
+	out, err := run(t, "stubs/fixtures.yaml")
+	require.NoError(t, err, out)
+	assert.EqualValues(t, 1, gjson.Get(out, "schemas").Int(), out)
+	assert.EqualValues(t, 1, gjson.Get(out, "tenants_created").Int(), out)
+	assert.EqualValues(t, 3, gjson.Get(out, "identities_created").Int(), out)
+
+	_, err = conf.IdentityTraitsSchemas().FindSchemaByID("customer")
+	require.NoError(t, err)
+
+	alice, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, x.ParseUUID("5e3f1a0c-2b5d-4c1e-8a7f-9d0b6c4e2f11"))
+	require.NoError(t, err)
+	assert.Equal(t, "customer", alice.SchemaID)
+	require.Len(t, alice.VerifiableAddresses, 1)
+	assert.Equal(t, "alice@example.org", alice.VerifiableAddresses[0].Value)
+	password, ok := alice.GetCredentials(identity.CredentialsTypePassword)
+	require.True(t, ok)
+	assert.Equal(t, []string{"alice@example.org"}, password.Identifiers)
+	assert.NoError(t, hash.Compare(ctx, []byte("alice-password"), []byte(gjson.GetBytes(password.Config, "hashed_password").String())))
+	oidc, ok := alice.GetCredentials(identity.CredentialsTypeOIDC)
+	require.True(t, ok)
+	assert.Equal(t, []string{"google:1234567890"}, oidc.Identifiers)
+
+	bob, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, x.ParseUUID("0b7c2f5e-6d1a-4e8b-9f3c-2a4d6e8f0b13"))
+	require.NoError(t, err)
+	assert.Equal(t, identity.StateInactive, bob.State)
+	password, ok = bob.GetCredentials(identity.CredentialsTypePassword)
+	require.True(t, ok)
+	assert.NoError(t, hash.Compare(ctx, []byte("bob-password"), []byte(gjson.GetBytes(password.Config, "hashed_password").String())))
+
+	tenant := x.ParseUUID("8f2e1d47-0f3a-4c61-9c6f-0f9d3e4c1a01")
+	carol := x.ParseUUID("3c9d4e1f-7a2b-4c5d-8e6f-1a3b5c7d9e24")
+	_, err = reg.Persister().GetIdentity(ctx, carol)
+	require.Error(t, err, "the identity belongs to another tenant")
+	_, err = reg.Persister().WithNetworkID(tenant).GetIdentity(ctx, carol)
+	require.NoError(t, err)
+
+	t.Run("case=seeding again creates nothing", func(t *testing.T) {
+		out, err := run(t, "stubs/fixtures.yaml")
+		require.NoError(t, err, out)
+		assert.EqualValues(t, 0, gjson.Get(out, "tenants_created").Int(), out)
+		assert.EqualValues(t, 0, gjson.Get(out, "identities_created").Int(), out)
+		assert.EqualValues(t, 3, gjson.Get(out, "identities_existing").Int(), out)
+	})
+
+	t.Run("case=fails on invalid fixtures", func(t *testing.T) {
+		out, err := run(t, "stubs/does-not-exist.yaml")
+		require.Error(t, err)
+		assert.Contains(t, out, "Could not seed the fixtures", out)
+	})
+}
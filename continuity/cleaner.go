@@ -0,0 +1,45 @@
+package continuity
+
+import (
+	"context"
+	"time"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+type (
+	cleanerDependencies interface {
+		PersistenceProvider
+		config.Provider
+		x.LoggingProvider
+	}
+	CleanerProvider interface {
+		ContinuityCleaner() *Cleaner
+	}
+	// Cleaner removes expired continuity containers from the database.
+	Cleaner struct {
+		d cleanerDependencies
+	}
+)
+
+func NewCleaner(d cleanerDependencies) *Cleaner {
+	return &Cleaner{d: d}
+}
+
+// Cleanup removes all expired containers and returns how many were removed.
+func (c *Cleaner) Cleanup(ctx context.Context) (int, error) {
+	deleted, err := c.d.ContinuityPersister().DeleteExpiredContinuitySessions(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	expiredContainers.Add(float64(deleted))
+
+	count, err := c.d.ContinuityPersister().CountContinuitySessions(ctx)
+	if err != nil {
+		return deleted, err
+	}
+	storedContainers.Set(float64(count))
+
+	return deleted, nil
+}
@@ -1,7 +1,10 @@
 package continuity
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"io/ioutil"
 	"time"
 
 	"github.com/ory/kratos/corp"
@@ -27,6 +30,10 @@ type Container struct {
 	// Payload is the container's payload.
 	Payload sqlxx.NullJSONRawMessage `json:"payload" db:"payload"`
 
+	// CompressedPayload holds the gzip-compressed payload if `continuity.compression` is enabled. Payload
+	// is empty in that case.
+	CompressedPayload []byte `json:"-" db:"compressed_payload"`
+
 	// CreatedAt is a helper struct field for gobuffalo.pop.
 	CreatedAt time.Time `json:"-" db:"created_at"`
 
@@ -55,6 +62,46 @@ func NewContainer(name string, o managerOptions) *Container {
 	}
 }
 
+func (c *Container) compress() error {
+	if len(c.Payload) == 0 {
+		return nil
+	}
+
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	if _, err := w.Write(c.Payload); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := w.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.CompressedPayload = b.Bytes()
+	c.Payload = nil
+	return nil
+}
+
+func (c *Container) decompress() error {
+	if len(c.CompressedPayload) == 0 {
+		return nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(c.CompressedPayload))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer r.Close()
+
+	payload, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.Payload = payload
+	c.CompressedPayload = nil
+	return nil
+}
+
 func (c *Container) Valid(identity uuid.UUID) error {
 	if c.ExpiresAt.Before(time.Now()) {
 		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("You must restart the flow because the resumable session has expired."))
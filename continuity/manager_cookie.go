@@ -12,20 +12,21 @@ import (
 	"github.com/ory/herodot"
 	"github.com/ory/x/sqlcon"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
 )
 
 var _ Manager = new(ManagerCookie)
 var ErrNotResumable = *herodot.ErrBadRequest.WithError("session is not resumable").WithReasonf("No resumable session could be found in the HTTP Header.")
-
-const cookieName = "ory_kratos_continuity"
+var ErrPayloadTooLarge = herodot.ErrBadRequest.WithError("continuity payload too large").WithReason("The flow could not be paused because its state exceeds the configured size limit.")
 
 type (
 	managerCookieDependencies interface {
 		PersistenceProvider
 		x.CookieProvider
 		session.ManagementProvider
+		config.Provider
 	}
 	ManagerCookie struct {
 		d managerCookieDependencies
@@ -45,9 +46,25 @@ func (m *ManagerCookie) Pause(ctx context.Context, w http.ResponseWriter, r *htt
 	if err != nil {
 		return err
 	}
+
+	conf := m.d.Config(ctx)
+	if max := conf.ContinuityMaxPayloadSize(); len(o.payload) > max {
+		rejectedContainers.Inc()
+		return errors.WithStack(ErrPayloadTooLarge.WithDebugf("payload has %d bytes but at most %d bytes are allowed", len(o.payload), max))
+	}
+
+	if max := conf.ContinuityMaxLifespan(); o.ttl > max {
+		o.ttl = max
+	}
+
 	c := NewContainer(name, *o)
+	if conf.ContinuityCompression() {
+		if err := c.compress(); err != nil {
+			return err
+		}
+	}
 
-	if err := x.SessionPersistValues(w, r, m.d.ContinuityCookieManager(ctx), cookieName, map[string]interface{}{
+	if err := x.SessionPersistValues(w, r, m.d.ContinuityCookieManager(ctx), m.d.Config(ctx).ContinuityCookieName(), map[string]interface{}{
 		name: c.ID.String(),
 	}); err != nil {
 		return err
@@ -57,6 +74,8 @@ func (m *ManagerCookie) Pause(ctx context.Context, w http.ResponseWriter, r *htt
 		return errors.WithStack(err)
 	}
 
+	pausedContainers.Inc()
+	payloadSize.Observe(float64(len(o.payload)))
 	return nil
 }
 
@@ -75,6 +94,10 @@ func (m *ManagerCookie) Continue(ctx context.Context, w http.ResponseWriter, r *
 		return nil, err
 	}
 
+	if err := container.decompress(); err != nil {
+		return nil, err
+	}
+
 	if o.payloadRaw != nil && container.Payload != nil {
 		if err := json.NewDecoder(bytes.NewBuffer(container.Payload)).Decode(o.payloadRaw); err != nil {
 			return nil, errors.WithStack(err)
@@ -85,16 +108,17 @@ func (m *ManagerCookie) Continue(ctx context.Context, w http.ResponseWriter, r *
 		return nil, err
 	}
 
-	if err := x.SessionUnsetKey(w, r, m.d.ContinuityCookieManager(ctx), cookieName, name); err != nil {
+	if err := x.SessionUnsetKey(w, r, m.d.ContinuityCookieManager(ctx), m.d.Config(ctx).ContinuityCookieName(), name); err != nil {
 		return nil, err
 	}
 
+	resumedContainers.Inc()
 	return container, nil
 }
 
 func (m *ManagerCookie) sid(ctx context.Context, r *http.Request, name string) (uuid.UUID, error) {
 	var sid uuid.UUID
-	if s, err := x.SessionGetString(r, m.d.ContinuityCookieManager(ctx), cookieName, name); err != nil {
+	if s, err := x.SessionGetString(r, m.d.ContinuityCookieManager(ctx), m.d.Config(ctx).ContinuityCookieName(), name); err != nil {
 		return sid, errors.WithStack(ErrNotResumable.WithDebugf("%+v", err))
 	} else if sid = x.ParseUUID(s); sid == uuid.Nil {
 		return sid, errors.WithStack(ErrNotResumable.WithDebug("session id is not a valid uuid"))
@@ -127,7 +151,7 @@ func (m ManagerCookie) Abort(ctx context.Context, w http.ResponseWriter, r *http
 		return err
 	}
 
-	if err := x.SessionUnsetKey(w, r, m.d.ContinuityCookieManager(ctx), cookieName, name); err != nil {
+	if err := x.SessionUnsetKey(w, r, m.d.ContinuityCookieManager(ctx), m.d.Config(ctx).ContinuityCookieName(), name); err != nil {
 		return err
 	}
 
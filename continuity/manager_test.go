@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ory/x/ioutilx"
 
@@ -217,3 +218,61 @@ func TestManager(t *testing.T) {
 		})
 	}
 }
+
+func TestManagerLimits(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyPublicBaseURL, "https://www.ory.sh")
+
+	writer := herodot.NewJSONWriter(logrusx.New("", ""))
+	router := httprouter.New()
+	router.POST("/:name", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if err := reg.ContinuityManager().Pause(r.Context(), w, r, ps.ByName("name"),
+			continuity.WithPayload(&persisterTestPayload{ps.ByName("name")}),
+			continuity.WithLifespan(time.Hour*24)); err != nil {
+			writer.WriteError(w, r, err)
+			return
+		}
+
+		c, err := reg.ContinuityManager().Continue(r.Context(), w, r, ps.ByName("name"), continuity.WithPayload(&persisterTestPayload{}))
+		if err != nil {
+			writer.WriteError(w, r, err)
+			return
+		}
+		writer.Write(w, r, c)
+	})
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	pauseAndContinue := func(t *testing.T, name string) (int, []byte) {
+		res, err := (&http.Client{Jar: x.EasyCookieJar(t, nil)}).Do(x.NewTestHTTPRequest(t, "POST", ts.URL+"/"+name, nil))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res.StatusCode, ioutilx.MustReadAll(res.Body)
+	}
+
+	t.Run("case=caps the lifespan", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyContinuityMaxLifespan, "1h")
+
+		code, body := pauseAndContinue(t, "lifespan")
+		require.Equal(t, http.StatusOK, code, "%s", body)
+		assert.True(t, gjson.GetBytes(body, "expires_at").Time().Before(time.Now().Add(time.Hour+time.Minute)), "%s", body)
+	})
+
+	t.Run("case=rejects payloads which are too large", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyContinuityMaxPayloadSize, 16)
+		t.Cleanup(func() { conf.MustSet(config.ViperKeyContinuityMaxPayloadSize, 1024*1024) })
+
+		code, body := pauseAndContinue(t, "this-name-makes-the-payload-too-large")
+		assert.Equal(t, http.StatusBadRequest, code, "%s", body)
+		assert.Contains(t, gjson.GetBytes(body, "error.reason").String(), "size limit", "%s", body)
+	})
+
+	t.Run("case=compresses payloads", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyContinuityCompression, true)
+		t.Cleanup(func() { conf.MustSet(config.ViperKeyContinuityCompression, false) })
+
+		code, body := pauseAndContinue(t, "compressed")
+		require.Equal(t, http.StatusOK, code, "%s", body)
+		assert.Equal(t, "compressed", gjson.GetBytes(body, "payload.foo").String(), "%s", body)
+	})
+}
@@ -0,0 +1,35 @@
+package continuity
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	pausedContainers = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kratos_continuity_containers_paused_total",
+		Help: "Number of continuity containers which were created.",
+	})
+	resumedContainers = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kratos_continuity_containers_resumed_total",
+		Help: "Number of continuity containers which were resumed.",
+	})
+	rejectedContainers = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kratos_continuity_containers_rejected_total",
+		Help: "Number of continuity containers which were rejected because their payload was too large.",
+	})
+	expiredContainers = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kratos_continuity_containers_expired_total",
+		Help: "Number of expired continuity containers which were removed by the cleanup job.",
+	})
+	storedContainers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kratos_continuity_containers",
+		Help: "Number of continuity containers in the database as of the last cleanup run.",
+	})
+	payloadSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kratos_continuity_container_payload_bytes",
+		Help:    "Size of continuity container payloads before compression.",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+	})
+)
+
+func init() {
+	prometheus.MustRegister(pausedContainers, resumedContainers, rejectedContainers, expiredContainers, storedContainers, payloadSize)
+}
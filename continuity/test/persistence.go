@@ -65,6 +65,31 @@ func TestPersister(ctx context.Context, p interface {
 			require.EqualError(t, err, sqlcon.ErrNoRows.Error())
 		})
 
+		t.Run("case=delete expired", func(t *testing.T) {
+			expired := createContainer(t)
+			expired.ExpiresAt = time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+			require.NoError(t, p.SaveContinuitySession(ctx, &expired))
+
+			active := createContainer(t)
+			require.NoError(t, p.SaveContinuitySession(ctx, &active))
+
+			before, err := p.CountContinuitySessions(ctx)
+			require.NoError(t, err)
+
+			deleted, err := p.DeleteExpiredContinuitySessions(ctx, time.Now().UTC())
+			require.NoError(t, err)
+			assert.Equal(t, 1, deleted)
+
+			after, err := p.CountContinuitySessions(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, before-1, after)
+
+			_, err = p.GetContinuitySession(ctx, expired.ID)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			_, err = p.GetContinuitySession(ctx, active.ID)
+			require.NoError(t, err)
+		})
+
 		t.Run("case=network", func(t *testing.T) {
 			id := x.NewUUID()
 
@@ -4,7 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
-	"fmt"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -13,6 +13,8 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
 
 	gomail "github.com/ory/mail/v3"
 
@@ -23,12 +25,15 @@ import (
 type (
 	smtpDependencies interface {
 		PersistenceProvider
+		PreferencesPersistenceProvider
+		DeliverabilityPersistenceProvider
 		x.LoggingProvider
 		config.Provider
 	}
 	Courier struct {
-		Dialer *gomail.Dialer
-		d      smtpDependencies
+		Dialer    *gomail.Dialer
+		fallbacks []mailer
+		d         smtpDependencies
 	}
 	Provider interface {
 		Courier(ctx context.Context) *Courier
@@ -36,7 +41,19 @@ type (
 )
 
 func NewSMTP(d smtpDependencies, c *config.Config) *Courier {
-	uri := c.CourierSMTPURL()
+	var fallbacks []mailer
+	for _, u := range c.CourierFallbackProviders() {
+		fallbacks = append(fallbacks, newMailer(u))
+	}
+
+	return &Courier{
+		d:         d,
+		Dialer:    newDialer(c.CourierSMTPURL()),
+		fallbacks: fallbacks,
+	}
+}
+
+func newDialer(uri *url.URL) *gomail.Dialer {
 	password, _ := uri.User.Password()
 	port, _ := strconv.ParseInt(uri.Port(), 10, 0)
 
@@ -49,23 +66,45 @@ func NewSMTP(d smtpDependencies, c *config.Config) *Courier {
 		tlsConfig = &tls.Config{InsecureSkipVerify: sslSkipVerify, ServerName: uri.Hostname()}
 	}
 
-	return &Courier{
-		d: d,
-		Dialer: &gomail.Dialer{
-			/* #nosec we need to support SMTP servers without TLS */
-			TLSConfig:    tlsConfig,
-			Host:         uri.Hostname(),
-			Port:         int(port),
-			Username:     uri.User.Username(),
-			Password:     password,
-			SSL:          ssl,
-			Timeout:      time.Second * 10,
-			RetryFailure: true,
-		},
+	return &gomail.Dialer{
+		/* #nosec we need to support SMTP servers without TLS */
+		TLSConfig:    tlsConfig,
+		Host:         uri.Hostname(),
+		Port:         int(port),
+		Username:     uri.User.Username(),
+		Password:     password,
+		SSL:          ssl,
+		Timeout:      time.Second * 10,
+		RetryFailure: true,
 	}
 }
 
+// QueueEmail adds the message to the queue. Messages of non-critical templates are held back during the
+// recipient's quiet hours and are not queued at all if the recipient prefers another channel, in which case
+// uuid.Nil is returned. If the context was prepared using WithFlowDelivery, the email is subject to
+// `courier.degradation.policy` while the SMTP server is failing.
 func (m *Courier) QueueEmail(ctx context.Context, t EmailTemplate) (uuid.UUID, error) {
+	var sendAt sqlxx.NullTime
+	if nc, ok := t.(NonCriticalTemplate); ok {
+		p, err := m.d.CommunicationPreferencesPersister().GetCommunicationPreferences(ctx, nc.RecipientIdentityID())
+		if err != nil && !errors.Is(err, sqlcon.ErrNoRows) {
+			return uuid.Nil, err
+		} else if err == nil {
+			if p.Channel != "" && p.Channel != ChannelEmail {
+				m.d.Logger().
+					WithField("identity_id", p.IdentityID).
+					WithField("channel", p.Channel).
+					Debug("Not queueing a non-critical email because the recipient prefers another channel.")
+				return uuid.Nil, nil
+			}
+
+			now := time.Now().UTC()
+			if until := p.HoldUntil(now); until.After(now) {
+				sendAt = sqlxx.NullTime(until.UTC())
+			}
+		}
+	}
+
 	recipient, err := t.EmailRecipient()
 	if err != nil {
 		return uuid.Nil, err
@@ -99,7 +138,16 @@ func (m *Courier) QueueEmail(ctx context.Context, t EmailTemplate) (uuid.UUID, e
 		Subject:      subject,
 		TemplateType: templateType,
 		TemplateData: templateData,
+		SendAt:       sendAt,
+	}
+
+	if delivery := flowDeliveryFromContext(ctx); delivery != nil && m.Degraded(ctx) {
+		if err := m.queueDegraded(ctx, message, delivery); err != nil {
+			return uuid.Nil, err
+		}
+		return message.ID, nil
 	}
+
 	if err := m.d.CourierPersister().AddMessage(ctx, message); err != nil {
 		return uuid.Nil, err
 	}
@@ -136,21 +184,31 @@ func (m *Courier) watchMessages(ctx context.Context, errChan chan error) {
 }
 
 func (m *Courier) DispatchMessage(ctx context.Context, msg Message) error {
+	return m.dispatch(ctx, m.mailers(), msg)
+}
+
+// mailers returns the SMTP server of `courier.smtp.connection_uri` followed by the fallback providers.
+func (m *Courier) mailers() []mailer {
+	return append([]mailer{&smtpMailer{dialer: m.Dialer}}, m.fallbacks...)
+}
+
+// dispatch hands the message to the first mailer and fails over to the next one as long as the error is
+// temporary.
+func (m *Courier) dispatch(ctx context.Context, mailers []mailer, msg Message) error {
 	switch msg.Type {
+	case MessageTypeSMS:
+		return m.dispatchSMS(ctx, msg)
 	case MessageTypeEmail:
 		from := m.d.Config(ctx).CourierSMTPFrom()
-		fromName := m.d.Config(ctx).CourierSMTPFromName()
-		gm := gomail.NewMessage()
-		if fromName == "" {
-			gm.SetHeader("From", from)
-		} else {
-			gm.SetAddressHeader("From", from, fromName)
+		e := &email{
+			From:         from,
+			FromName:     m.d.Config(ctx).CourierSMTPFromName(),
+			EnvelopeFrom: m.d.Config(ctx).CourierSMTPEnvelopeFrom(),
+			To:           msg.Recipient,
+			Subject:      msg.Subject,
+			Body:         msg.Body,
 		}
 
-		gm.SetHeader("To", msg.Recipient)
-		gm.SetHeader("Subject", msg.Subject)
-		gm.SetBody("text/plain", msg.Body)
-
 		tmpl, err := NewEmailTemplateFromMessage(m.d.Config(ctx), msg)
 		if err != nil {
 			m.d.Logger().
@@ -165,22 +223,42 @@ func (m *Courier) DispatchMessage(ctx context.Context, msg Message) error {
 					WithField("message_id", msg.ID).
 					Error(`Unable to get email body from template.`)
 			} else {
-				gm.AddAlternative("text/html", htmlBody)
+				e.HTMLBody = htmlBody
 			}
 		}
+		e.Headers = m.headers(ctx, &msg, tmpl)
+
+		var provider string
+		for k, ml := range mailers {
+			start := time.Now()
+			err := ml.send(ctx, e)
+			if err == nil {
+				m.recordDelivery(ctx, ml.provider(), ChannelEmail, DeliveryOutcomeSent, time.Since(start))
+				provider = ml.provider()
+				break
+			}
 
-		if err := m.Dialer.DialAndSend(ctx, gm); err != nil {
+			m.recordDelivery(ctx, ml.provider(), ChannelEmail, DeliveryOutcomeFailed, time.Since(start))
 			m.d.Logger().
 				WithError(err).
-				WithField("smtp_server", fmt.Sprintf("%s:%d", m.Dialer.Host, m.Dialer.Port)).
-				WithField("smtp_ssl_enabled", m.Dialer.SSL).
+				WithField("provider", ml.provider()).
 				// WithField("email_to", msg.Recipient).
 				WithField("message_from", from).
-				Error("Unable to send email using SMTP connection.")
-			return errors.WithStack(err)
+				Error("Unable to send email.")
+
+			if k == len(mailers)-1 || !shouldFailOver(err) {
+				return errors.WithStack(err)
+			}
+
+			failovers.WithLabelValues(ml.provider(), mailers[k+1].provider(), string(ChannelEmail)).Inc()
+			m.d.Logger().
+				WithField("message_id", msg.ID).
+				WithField("provider", ml.provider()).
+				WithField("fallback_provider", mailers[k+1].provider()).
+				Warn("Failing over to the next provider.")
 		}
 
-		if err := m.d.CourierPersister().SetMessageStatus(ctx, msg.ID, MessageStatusSent); err != nil {
+		if err := m.d.CourierPersister().SetMessageSent(ctx, msg.ID, provider); err != nil {
 			m.d.Logger().
 				WithError(err).
 				WithField("message_id", msg.ID).
@@ -193,14 +271,20 @@ func (m *Courier) DispatchMessage(ctx context.Context, msg Message) error {
 			WithField("message_type", msg.Type).
 			WithField("message_template_type", msg.TemplateType).
 			WithField("message_subject", msg.Subject).
+			WithField("provider", provider).
 			Debug("Courier sent out message.")
 		return nil
 	}
 	return errors.Errorf("received unexpected message type: %d", msg.Type)
 }
 
+// smtpProvider identifies the SMTP server in metrics and in the deliverability summary.
+func smtpProvider(dialer *gomail.Dialer) string {
+	return "smtp:" + dialer.Host
+}
+
 func (m *Courier) DispatchQueue(ctx context.Context) error {
-	if len(m.Dialer.Host) == 0 {
+	if len(m.Dialer.Host) == 0 && !m.d.Config(ctx).CourierSMSEnabled() {
 		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Courier tried to deliver an email but courier.smtp_url is not set!"))
 	}
 
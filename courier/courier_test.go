@@ -20,8 +20,10 @@ import (
 
 	dhelper "github.com/ory/x/sqlcon/dockertest"
 
+	"github.com/ory/kratos/courier"
 	templates "github.com/ory/kratos/courier/template"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
 )
 
@@ -125,3 +127,100 @@ func TestSMTP(t *testing.T) {
 	// Assertion for the third email with sender name
 	assert.Contains(t, string(body), "Bob")
 }
+
+func TestQueueEmailWithPreferences(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://../test/stub/identity/empty.schema.json")
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+
+	warning := func() *templates.InactivityWarning {
+		return templates.NewInactivityWarning(conf, &templates.InactivityWarningModel{
+			To:         "inactive@ory.sh",
+			Action:     "delete",
+			ActionAt:   time.Now().Add(time.Hour),
+			IdentityID: i.ID,
+		})
+	}
+
+	t.Run("case=sends right away without preferences", func(t *testing.T) {
+		id, err := reg.Courier(ctx).QueueEmail(ctx, warning())
+		require.NoError(t, err)
+
+		messages, err := reg.CourierPersister().NextMessages(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, id, messages[0].ID)
+	})
+
+	t.Run("case=holds back messages during quiet hours", func(t *testing.T) {
+		now := time.Now().UTC()
+		require.NoError(t, reg.CommunicationPreferencesPersister().UpsertCommunicationPreferences(ctx, &courier.Preferences{
+			IdentityID: i.ID,
+			QuietHours: &courier.QuietHours{
+				Start: now.Add(-time.Hour).Format("15:04"),
+				End:   now.Add(time.Hour).Format("15:04"),
+			},
+		}))
+
+		id, err := reg.Courier(ctx).QueueEmail(ctx, warning())
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, id)
+
+		_, err = reg.CourierPersister().NextMessages(ctx, 10)
+		require.ErrorIs(t, err, courier.ErrQueueEmpty)
+
+		t.Run("case=does not hold back critical messages", func(t *testing.T) {
+			id, err := reg.Courier(ctx).QueueEmail(ctx, templates.NewTestStub(conf, &templates.TestStubModel{
+				To:      "inactive@ory.sh",
+				Subject: "critical",
+				Body:    "critical",
+			}))
+			require.NoError(t, err)
+
+			messages, err := reg.CourierPersister().NextMessages(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+			assert.Equal(t, id, messages[0].ID)
+		})
+	})
+
+	t.Run("case=skips messages if another channel is preferred", func(t *testing.T) {
+		require.NoError(t, reg.CommunicationPreferencesPersister().UpsertCommunicationPreferences(ctx, &courier.Preferences{
+			IdentityID: i.ID,
+			Channel:    courier.ChannelSMS,
+		}))
+
+		id, err := reg.Courier(ctx).QueueEmail(ctx, warning())
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Nil, id)
+
+		_, err = reg.CourierPersister().NextMessages(ctx, 10)
+		require.ErrorIs(t, err, courier.ErrQueueEmpty)
+	})
+}
+
+func TestRedactSentMessages(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+
+	id, err := reg.Courier(ctx).QueueEmail(ctx, templates.NewTestStub(conf, &templates.TestStubModel{
+		To:      "redact@ory.sh",
+		Subject: "redact",
+		Body:    "code 123",
+	}))
+	require.NoError(t, err)
+	require.NoError(t, reg.CourierPersister().SetMessageStatus(ctx, id, courier.MessageStatusSent))
+	time.Sleep(time.Millisecond)
+
+	redacted, err := reg.Courier(ctx).RedactSentMessages(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, redacted, "messages are kept unless configured otherwise")
+
+	conf.MustSet(config.ViperKeyCourierMessageRedactAfter, "1ns")
+	redacted, err = reg.Courier(ctx).RedactSentMessages(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, redacted)
+}
@@ -0,0 +1,104 @@
+package courier
+
+import (
+	"context"
+	"time"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
+)
+
+type flowDeliveryContextKey struct{}
+
+// FlowDelivery reports how the emails a self-service flow queued were handled.
+type FlowDelivery struct {
+	// Delayed is true if an email was queued while the SMTP server was failing.
+	Delayed bool
+}
+
+// WithFlowDelivery subjects the emails queued using the returned context to `courier.degradation.policy`. The
+// returned FlowDelivery is updated by QueueEmail so that the flow can tell the user that emails may be delayed.
+//
+// Emails which are not sent on behalf of a user waiting for them, for example notifications sent by background
+// jobs, must not use it.
+func WithFlowDelivery(ctx context.Context) (context.Context, *FlowDelivery) {
+	delivery := new(FlowDelivery)
+	return context.WithValue(ctx, flowDeliveryContextKey{}, delivery), delivery
+}
+
+func flowDeliveryFromContext(ctx context.Context) *FlowDelivery {
+	delivery, _ := ctx.Value(flowDeliveryContextKey{}).(*FlowDelivery)
+	return delivery
+}
+
+// Degraded returns true if the most recent `courier.degradation.failure_threshold` delivery attempts within
+// `courier.degradation.window` all failed for the SMTP server and for each of `courier.fallback_providers`. The
+// delivery events are shared by all instances, so this works even if the courier runs in a separate process.
+func (m *Courier) Degraded(ctx context.Context) bool {
+	threshold, window := m.d.Config(ctx).CourierDegradationThreshold()
+
+	events, err := m.d.DeliverabilityPersister().ListDeliveryEvents(ctx, time.Now().UTC().Add(-window))
+	if err != nil {
+		m.d.Logger().
+			WithError(err).
+			Warn("Unable to load the delivery events, assuming that the SMTP server works.")
+		return false
+	}
+
+	for _, ml := range m.mailers() {
+		if !failing(events, ml.provider(), threshold) {
+			return false
+		}
+	}
+	return true
+}
+
+func failing(events []DeliveryEvent, provider string, threshold int) bool {
+	var failures int
+	for k := len(events) - 1; k >= 0; k-- {
+		e := events[k]
+		if e.Provider != provider || e.Channel != ChannelEmail {
+			continue
+		}
+
+		switch e.Outcome {
+		case DeliveryOutcomeFailed:
+			failures++
+			if failures >= threshold {
+				return true
+			}
+		case DeliveryOutcomeSent:
+			return false
+		}
+	}
+	return false
+}
+
+func (m *Courier) queueDegraded(ctx context.Context, message *Message, delivery *FlowDelivery) error {
+	switch m.d.Config(ctx).CourierDegradationPolicy() {
+	case config.CourierDegradationFailFast:
+		m.d.Logger().
+			WithField("message_template_type", message.TemplateType).
+			Warn("Not queueing an email because the SMTP server is failing.")
+		return schema.NewCourierUnavailableError()
+	case config.CourierDegradationFallback:
+		if fallback := m.d.Config(ctx).CourierDegradationFallbackURL(); fallback != nil && time.Time(message.SendAt).IsZero() {
+			// The message is marked as processing so that the courier does not pick it up at the same time.
+			message.Status = MessageStatusProcessing
+			if err := m.d.CourierPersister().AddMessage(ctx, message); err != nil {
+				return err
+			}
+
+			if err := m.dispatch(ctx, []mailer{newMailer(fallback)}, *message); err == nil {
+				return nil
+			}
+
+			// The fallback failed as well, so the courier retries the message once the SMTP server is back.
+			delivery.Delayed = true
+			return m.d.CourierPersister().SetMessageStatus(ctx, message.ID, MessageStatusQueued)
+		}
+	}
+
+	delivery.Delayed = true
+	return m.d.CourierPersister().AddMessage(ctx, message)
+}
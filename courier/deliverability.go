@@ -0,0 +1,294 @@
+package courier
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/corp"
+)
+
+// DeliveryOutcome is what happened to a message handed to a provider.
+//
+// swagger:model courierDeliveryOutcome
+type DeliveryOutcome string
+
+const (
+	// DeliveryOutcomeSent is recorded when the provider accepted a message.
+	DeliveryOutcomeSent DeliveryOutcome = "sent"
+	// DeliveryOutcomeFailed is recorded when the provider rejected a message or could not be reached.
+	DeliveryOutcomeFailed DeliveryOutcome = "failed"
+	// DeliveryOutcomeBounced is recorded when the provider reports that an accepted message bounced.
+	DeliveryOutcomeBounced DeliveryOutcome = "bounced"
+	// DeliveryOutcomeComplained is recorded when the provider reports that the recipient marked a message as spam.
+	DeliveryOutcomeComplained DeliveryOutcome = "complained"
+)
+
+type (
+	// DeliveryEvent records a delivery attempt or a bounce report for the deliverability summary. It does not
+	// reference the message or its recipient.
+	DeliveryEvent struct {
+		ID  uuid.UUID `json:"-" faker:"-" db:"id"`
+		NID uuid.UUID `json:"-" faker:"-" db:"nid"`
+
+		// Provider identifies the provider the message was handed to, for example `smtp:mail.example.org`.
+		Provider string `json:"provider" db:"provider"`
+
+		// Channel is the channel the message was sent on.
+		Channel Channel `json:"channel" db:"channel"`
+
+		// Outcome is what happened to the message.
+		Outcome DeliveryOutcome `json:"outcome" db:"outcome"`
+
+		// LatencyMilliseconds is how long the provider took to accept or reject the message. It is zero for
+		// bounce reports.
+		LatencyMilliseconds int64 `json:"latency_ms" db:"latency_ms"`
+
+		// CreatedAt is a helper struct field for gobuffalo.pop.
+		CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+	}
+
+	DeliverabilityPersister interface {
+		AddDeliveryEvent(ctx context.Context, e *DeliveryEvent) error
+
+		// ListDeliveryEvents returns all events recorded at or after since, starting with the oldest one.
+		ListDeliveryEvents(ctx context.Context, since time.Time) ([]DeliveryEvent, error)
+
+		// DeleteDeliveryEventsBefore removes all events recorded before the given time and returns how many
+		// were removed.
+		DeleteDeliveryEventsBefore(ctx context.Context, before time.Time) (int, error)
+	}
+
+	DeliverabilityPersistenceProvider interface {
+		DeliverabilityPersister() DeliverabilityPersister
+	}
+
+	// DeliverabilityCounts counts delivery events by outcome.
+	//
+	// swagger:model courierDeliverabilityCounts
+	DeliverabilityCounts struct {
+		// Sent is the number of messages the provider accepted.
+		//
+		// required: true
+		Sent int `json:"sent"`
+
+		// Failed is the number of messages the provider rejected or which could not be handed to the provider.
+		//
+		// required: true
+		Failed int `json:"failed"`
+
+		// Bounced is the number of bounces reported by the provider.
+		//
+		// required: true
+		Bounced int `json:"bounced"`
+
+		// Complained is the number of spam complaints reported by the provider.
+		//
+		// required: true
+		Complained int `json:"complained"`
+
+		// SuccessRate is the share of delivery attempts which were accepted and did not bounce, between 0 and 1.
+		// It is 1 if there were no delivery attempts.
+		//
+		// required: true
+		SuccessRate float64 `json:"success_rate"`
+
+		// AverageLatencyMilliseconds is the average time the provider took to accept or reject a message.
+		//
+		// required: true
+		AverageLatencyMilliseconds int64 `json:"average_latency_ms"`
+
+		latency int64
+	}
+
+	// DeliverabilityInterval counts the delivery events of one provider in one interval.
+	//
+	// swagger:model courierDeliverabilityInterval
+	DeliverabilityInterval struct {
+		DeliverabilityCounts
+
+		// Start is the start of the interval.
+		//
+		// required: true
+		Start time.Time `json:"start"`
+	}
+
+	// ProviderDeliverability summarizes the delivery events of one provider and channel.
+	//
+	// swagger:model courierProviderDeliverability
+	ProviderDeliverability struct {
+		DeliverabilityCounts
+
+		// Provider identifies the provider, for example `smtp:mail.example.org`.
+		//
+		// required: true
+		Provider string `json:"provider"`
+
+		// Channel is the channel the provider delivers messages on.
+		//
+		// required: true
+		Channel Channel `json:"channel"`
+
+		// Intervals counts the events per interval, starting with the oldest interval. Intervals without
+		// events are included.
+		//
+		// required: true
+		Intervals []DeliverabilityInterval `json:"intervals"`
+	}
+
+	// DeliverabilitySummary summarizes how well the courier's providers delivered messages over time.
+	//
+	// swagger:model courierDeliverabilitySummary
+	DeliverabilitySummary struct {
+		// Since is the start of the first interval.
+		//
+		// required: true
+		Since time.Time `json:"since"`
+
+		// Until is the end of the last interval.
+		//
+		// required: true
+		Until time.Time `json:"until"`
+
+		// Interval is the length of each interval in seconds.
+		//
+		// required: true
+		Interval int64 `json:"interval"`
+
+		// Providers summarizes each provider which had events since the start of the first interval, ordered by
+		// provider and channel.
+		//
+		// required: true
+		Providers []ProviderDeliverability `json:"providers"`
+	}
+)
+
+func (e DeliveryEvent) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "courier_delivery_events")
+}
+
+func (c *DeliverabilityCounts) add(e *DeliveryEvent) {
+	switch e.Outcome {
+	case DeliveryOutcomeSent:
+		c.Sent++
+		c.latency += e.LatencyMilliseconds
+	case DeliveryOutcomeFailed:
+		c.Failed++
+		c.latency += e.LatencyMilliseconds
+	case DeliveryOutcomeBounced:
+		c.Bounced++
+	case DeliveryOutcomeComplained:
+		c.Complained++
+	}
+}
+
+func (c *DeliverabilityCounts) finish() {
+	attempts := c.Sent + c.Failed
+	c.SuccessRate = 1
+	if attempts == 0 {
+		return
+	}
+
+	delivered := c.Sent - c.Bounced
+	if delivered < 0 {
+		delivered = 0
+	}
+	c.SuccessRate = float64(delivered) / float64(attempts)
+	c.AverageLatencyMilliseconds = c.latency / int64(attempts)
+}
+
+// SummarizeDeliverability groups the events by provider and channel and counts them in intervals of the given
+// length, starting at since and ending at until. Events outside of that range are ignored.
+func SummarizeDeliverability(events []DeliveryEvent, since, until time.Time, interval time.Duration) *DeliverabilitySummary {
+	buckets := int(until.Sub(since) / interval)
+	if until.Sub(since)%interval > 0 {
+		buckets++
+	}
+
+	type key struct {
+		provider string
+		channel  Channel
+	}
+
+	providers := make(map[key]*ProviderDeliverability)
+	for k := range events {
+		e := &events[k]
+		if e.CreatedAt.Before(since) || !e.CreatedAt.Before(until) {
+			continue
+		}
+
+		p, ok := providers[key{provider: e.Provider, channel: e.Channel}]
+		if !ok {
+			p = &ProviderDeliverability{Provider: e.Provider, Channel: e.Channel, Intervals: make([]DeliverabilityInterval, buckets)}
+			for i := range p.Intervals {
+				p.Intervals[i].Start = since.Add(time.Duration(i) * interval)
+			}
+			providers[key{provider: e.Provider, channel: e.Channel}] = p
+		}
+
+		p.add(e)
+		p.Intervals[int(e.CreatedAt.Sub(since)/interval)].add(e)
+	}
+
+	summary := &DeliverabilitySummary{
+		Since:     since,
+		Until:     until,
+		Interval:  int64(interval / time.Second),
+		Providers: make([]ProviderDeliverability, 0, len(providers)),
+	}
+	for _, p := range providers {
+		p.finish()
+		for i := range p.Intervals {
+			p.Intervals[i].finish()
+		}
+		summary.Providers = append(summary.Providers, *p)
+	}
+
+	sort.Slice(summary.Providers, func(i, j int) bool {
+		if summary.Providers[i].Provider == summary.Providers[j].Provider {
+			return summary.Providers[i].Channel < summary.Providers[j].Channel
+		}
+		return summary.Providers[i].Provider < summary.Providers[j].Provider
+	})
+
+	return summary
+}
+
+// recordDelivery updates the metrics of the provider and stores the outcome for the deliverability summary.
+// Errors are only logged because they must not fail the delivery.
+func (m *Courier) recordDelivery(ctx context.Context, provider string, channel Channel, outcome DeliveryOutcome, latency time.Duration) {
+	deliveries.WithLabelValues(provider, string(channel), string(outcome)).Inc()
+	deliveryDuration.WithLabelValues(provider, string(channel)).Observe(latency.Seconds())
+
+	if err := m.d.DeliverabilityPersister().AddDeliveryEvent(ctx, &DeliveryEvent{
+		Provider:            provider,
+		Channel:             channel,
+		Outcome:             outcome,
+		LatencyMilliseconds: latency.Milliseconds(),
+	}); err != nil {
+		m.d.Logger().
+			WithError(err).
+			WithField("provider", provider).
+			Warn("Unable to store the delivery outcome.")
+	}
+}
+
+// DeleteExpiredDeliveryEvents removes the delivery events which are older than
+// `courier.deliverability.retention`. It returns how many events were removed.
+func (m *Courier) DeleteExpiredDeliveryEvents(ctx context.Context) (int, error) {
+	deleted, err := m.d.DeliverabilityPersister().DeleteDeliveryEventsBefore(ctx, time.Now().UTC().Add(-m.d.Config(ctx).CourierDeliverabilityRetention()))
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		m.d.Logger().
+			WithField("deleted", deleted).
+			Debug("Deleted expired delivery events.")
+	}
+	return deleted, nil
+}
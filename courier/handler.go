@@ -0,0 +1,215 @@
+package courier
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteDeliverability = "/courier/deliverability"
+	RouteBounces        = RouteDeliverability + "/bounces"
+
+	// maxDeliverabilityIntervals limits the size of the deliverability summary.
+	maxDeliverabilityIntervals = 1000
+)
+
+type (
+	handlerDependencies interface {
+		DeliverabilityPersistenceProvider
+		config.Provider
+		x.WriterProvider
+	}
+	HandlerProvider interface {
+		CourierHandler() *Handler
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteDeliverability, h.getDeliverability)
+	admin.POST(RouteBounces, h.reportBounce)
+}
+
+// The deliverability summary of the courier's providers.
+//
+// swagger:response courierDeliverabilitySummary
+// nolint:deadcode,unused
+type deliverabilitySummaryResponse struct {
+	// in: body
+	Body DeliverabilitySummary
+}
+
+// swagger:parameters getCourierDeliverability
+// nolint:deadcode,unused
+type getDeliverabilityParameters struct {
+	// Since
+	//
+	// How far back the summary goes, for example `24h`. It can not go back further than
+	// `courier.deliverability.retention`.
+	//
+	// required: false
+	// in: query
+	// default: 24h
+	Since string `json:"since"`
+
+	// Interval
+	//
+	// The length of the intervals the events are counted in, for example `1h`. The summary has at most
+	// 1000 intervals.
+	//
+	// required: false
+	// in: query
+	// default: 1h
+	Interval string `json:"interval"`
+}
+
+// swagger:route GET /courier/deliverability admin getCourierDeliverability
+//
+// Summarize the Deliverability of Courier Providers
+//
+// This endpoint counts the messages each provider, for example an SMTP server, accepted or rejected and the
+// bounces and spam complaints reported for it, in total and per interval. Use it together with the
+// `kratos_courier_*` metrics exported at `/metrics/prometheus` to detect providers which degrade.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: courierDeliverabilitySummary
+//       400: genericError
+//       500: genericError
+func (h *Handler) getDeliverability(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	since, err := parseDurationQuery(r, "since", time.Hour*24)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	interval, err := parseDurationQuery(r, "interval", time.Hour)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if retention := h.d.Config(r.Context()).CourierDeliverabilityRetention(); since > retention {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The value of "since" must not exceed the retention of %s.`, retention)))
+		return
+	} else if since/interval > maxDeliverabilityIntervals {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The summary must not have more than %d intervals, increase "interval" or decrease "since".`, maxDeliverabilityIntervals)))
+		return
+	}
+
+	until := time.Now().UTC()
+	events, err := h.d.DeliverabilityPersister().ListDeliveryEvents(r.Context(), until.Add(-since))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, SummarizeDeliverability(events, until.Add(-since), until, interval))
+}
+
+func parseDurationQuery(r *http.Request, key string, fallback time.Duration) (time.Duration, error) {
+	raw := r.URL.Query().Get(key)
+	if len(raw) == 0 {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`Unable to parse "%s" whose format should be a positive duration such as "1h" but was: %s`, key, raw))
+	}
+	return d, nil
+}
+
+// swagger:parameters reportCourierBounce
+// nolint:deadcode,unused
+type reportBounceParameters struct {
+	// in: body
+	Body BounceReport
+}
+
+// BounceReport reports that a message bounced or that its recipient marked it as spam.
+type BounceReport struct {
+	// Provider identifies the provider which delivered the message. Use the identifier the courier uses in its
+	// metrics, for example `smtp:mail.example.org`, to count the report towards that provider.
+	//
+	// required: true
+	Provider string `json:"provider"`
+
+	// Channel is the channel the message was sent on. Defaults to `email`.
+	Channel Channel `json:"channel"`
+
+	// Outcome is either `bounced` or `complained`.
+	//
+	// required: true
+	Outcome DeliveryOutcome `json:"outcome"`
+}
+
+// swagger:route POST /courier/deliverability/bounces admin reportCourierBounce
+//
+// Report a Bounced Message
+//
+// Forward the bounce and complaint notifications of your email or SMS provider to this endpoint to count
+// them in the deliverability summary and in the `kratos_courier_bounce_reports_total` metric.
+//
+//     Consumes:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       500: genericError
+func (h *Handler) reportBounce(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var body BounceReport
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.d.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	if body.Channel == "" {
+		body.Channel = ChannelEmail
+	}
+
+	if len(body.Provider) == 0 || len(body.Provider) > 255 {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason(`The provider must be set and must not be longer than 255 characters.`)))
+		return
+	} else if body.Channel != ChannelEmail && body.Channel != ChannelSMS {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The channel must be one of "%s" or "%s".`, ChannelEmail, ChannelSMS)))
+		return
+	} else if body.Outcome != DeliveryOutcomeBounced && body.Outcome != DeliveryOutcomeComplained {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The outcome must be one of "%s" or "%s".`, DeliveryOutcomeBounced, DeliveryOutcomeComplained)))
+		return
+	}
+
+	if err := h.d.DeliverabilityPersister().AddDeliveryEvent(r.Context(), &DeliveryEvent{
+		Provider: body.Provider,
+		Channel:  body.Channel,
+		Outcome:  body.Outcome,
+	}); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	bounceReports.WithLabelValues(body.Provider, string(body.Channel), string(body.Outcome)).Inc()
+	w.WriteHeader(http.StatusNoContent)
+}
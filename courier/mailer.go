@@ -0,0 +1,210 @@
+package courier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	gomail "github.com/ory/mail/v3"
+)
+
+type (
+	// email is what the courier hands to a provider.
+	email struct {
+		From         string            `json:"from"`
+		FromName     string            `json:"from_name,omitempty"`
+		EnvelopeFrom string            `json:"envelope_from"`
+		To           string            `json:"to"`
+		Subject      string            `json:"subject"`
+		Body         string            `json:"body"`
+		HTMLBody     string            `json:"html_body,omitempty"`
+		Headers      map[string]string `json:"headers,omitempty"`
+	}
+
+	// mailer delivers emails using one provider.
+	mailer interface {
+		// provider identifies the provider in metrics, in the deliverability summary, and in sent messages.
+		provider() string
+		send(ctx context.Context, e *email) error
+	}
+
+	smtpMailer struct {
+		dialer *gomail.Dialer
+	}
+
+	httpMailer struct {
+		url    *url.URL
+		client *http.Client
+	}
+
+	// httpStatusError is returned if an HTTP provider responds with a status code other than 2xx.
+	httpStatusError struct {
+		StatusCode int
+	}
+)
+
+func newMailer(u *url.URL) mailer {
+	switch u.Scheme {
+	case "http", "https":
+		return &httpMailer{url: u, client: &http.Client{Timeout: time.Second * 10}}
+	}
+	return &smtpMailer{dialer: newDialer(u)}
+}
+
+func (m *smtpMailer) provider() string {
+	return smtpProvider(m.dialer)
+}
+
+func (m *smtpMailer) send(ctx context.Context, e *email) error {
+	gm := gomail.NewMessage()
+	if e.FromName == "" {
+		gm.SetHeader("From", e.From)
+	} else {
+		gm.SetAddressHeader("From", e.From, e.FromName)
+	}
+
+	gm.SetHeader("To", e.To)
+	gm.SetHeader("Subject", e.Subject)
+	gm.SetBody("text/plain", e.Body)
+	if e.HTMLBody != "" {
+		gm.AddAlternative("text/html", e.HTMLBody)
+	}
+	for k, v := range e.Headers {
+		gm.SetHeader(k, v)
+	}
+
+	s, err := m.dialer.Dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	// The envelope sender is passed explicitly because gomail would otherwise use the From header.
+	return s.Send(ctx, e.EnvelopeFrom, []string{e.To}, gm)
+}
+
+func (m *httpMailer) provider() string {
+	return "http:" + m.url.Host
+}
+
+func (m *httpMailer) send(ctx context.Context, e *email) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	target := *m.url
+	target.User = nil
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if m.url.User != nil {
+		password, _ := m.url.User.Password()
+		req.SetBasicAuth(m.url.User.Username(), password)
+	}
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.WithStack(&httpStatusError{StatusCode: res.StatusCode})
+	}
+	return nil
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("the provider responded with status code %d", e.StatusCode)
+}
+
+// shouldFailOver returns true if the error is temporary, in which case the next provider may be able to deliver
+// the email. Permanent rejections, such as an unknown recipient (SMTP 5xx) or an invalid request (HTTP 4xx), are
+// likely to be rejected by every provider.
+func shouldFailOver(err error) bool {
+	var se *gomail.SendError
+	if errors.As(err, &se) {
+		err = se.Cause
+	}
+
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code < 500
+	}
+
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+
+	// Everything else, for example a refused connection, a failed TLS handshake, or a timeout, means that the
+	// provider could not be reached.
+	return true
+}
+
+// reservedHeaders are set by the courier and can not be overridden by `courier.smtp.headers`.
+var reservedHeaders = map[string]bool{
+	"from":                      true,
+	"to":                        true,
+	"subject":                   true,
+	"date":                      true,
+	"message-id":                true,
+	"mime-version":              true,
+	"content-type":              true,
+	"content-transfer-encoding": true,
+	"list-unsubscribe":          true,
+	"list-unsubscribe-post":     true,
+}
+
+// headers returns the additional headers of the message. The template is nil if it could not be loaded.
+func (m *Courier) headers(ctx context.Context, msg *Message, tmpl EmailTemplate) map[string]string {
+	conf := m.d.Config(ctx)
+	headers := make(map[string]string)
+	for k, v := range conf.CourierSMTPHeaders() {
+		if reservedHeaders[strings.ToLower(k)] {
+			m.d.Logger().
+				WithField("header", k).
+				Warn("Ignoring a custom email header because the courier sets it.")
+			continue
+		}
+		headers[k] = v
+	}
+
+	if domain := conf.CourierSMTPMessageIDDomain(); domain != "" {
+		// The message's ID keeps the Message-ID stable when the message is retried.
+		headers["Message-ID"] = fmt.Sprintf("<%s@%s>", msg.ID, domain)
+	}
+
+	if _, ok := tmpl.(NonCriticalTemplate); ok {
+		if unsubscribe := conf.CourierSMTPListUnsubscribe(); len(unsubscribe) > 0 {
+			values := make([]string, len(unsubscribe))
+			for k, u := range unsubscribe {
+				values[k] = "<" + u + ">"
+				if strings.HasPrefix(u, "https:") {
+					headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+				}
+			}
+			headers["List-Unsubscribe"] = strings.Join(values, ", ")
+		}
+	}
+
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
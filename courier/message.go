@@ -7,6 +7,8 @@ import (
 	"github.com/ory/kratos/corp"
 
 	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlxx"
 )
 
 type MessageStatus int
@@ -21,6 +23,7 @@ type MessageType int
 
 const (
 	MessageTypeEmail MessageType = iota + 1
+	MessageTypeSMS
 )
 
 type Message struct {
@@ -34,6 +37,14 @@ type Message struct {
 	TemplateType TemplateType  `json:"-" db:"template_type"`
 	TemplateData []byte        `json:"-" db:"template_data"`
 
+	// SendAt is set if the message must not be sent before this time, for example because of the recipient's
+	// quiet hours.
+	SendAt sqlxx.NullTime `json:"-" faker:"-" db:"send_at"`
+
+	// Provider identifies the provider which delivered the message, for example `smtp:mail.example.org`. It is
+	// empty until the message was sent.
+	Provider string `json:"-" faker:"-" db:"provider"`
+
 	// CreatedAt is a helper struct field for gobuffalo.pop.
 	CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
 	// UpdatedAt is a helper struct field for gobuffalo.pop.
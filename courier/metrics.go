@@ -0,0 +1,27 @@
+package courier
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	deliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kratos_courier_deliveries_total",
+		Help: "Number of messages handed to a provider by provider, channel, and outcome (sent or failed).",
+	}, []string{"provider", "channel", "outcome"})
+	deliveryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kratos_courier_delivery_duration_seconds",
+		Help:    "Time it took the provider to accept or reject a message by provider and channel.",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"provider", "channel"})
+	failovers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kratos_courier_failovers_total",
+		Help: "Number of times the courier failed over from one provider to the next by provider, fallback provider, and channel.",
+	}, []string{"provider", "fallback_provider", "channel"})
+	bounceReports = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kratos_courier_bounce_reports_total",
+		Help: "Number of bounce and complaint reports ingested by the bounce webhook by provider, channel, and outcome (bounced or complained).",
+	}, []string{"provider", "channel", "outcome"})
+)
+
+func init() {
+	prometheus.MustRegister(deliveries, deliveryDuration, failovers, bounceReports)
+}
@@ -2,6 +2,7 @@ package courier
 
 import (
 	"context"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
@@ -17,7 +18,23 @@ type (
 
 		SetMessageStatus(context.Context, uuid.UUID, MessageStatus) error
 
+		// SetMessageSent marks the message as sent and records the provider which delivered it.
+		SetMessageSent(ctx context.Context, id uuid.UUID, provider string) error
+
 		LatestQueuedMessage(ctx context.Context) (*Message, error)
+
+		// RedactSentMessages removes the body and template data of messages which were sent before the given
+		// time. It returns how many messages were redacted.
+		RedactSentMessages(ctx context.Context, sentBefore time.Time) (int, error)
+
+		// DeleteMessagesByRecipient deletes all messages sent or queued to one of the given recipients,
+		// regardless of their status. Recipients are compared case-insensitively.
+		DeleteMessagesByRecipient(ctx context.Context, recipients []string) (int, error)
+
+		// AnonymizeMessagesByRecipient removes the recipient, body, and template data of all sent messages to one
+		// of the given recipients. Messages which were not sent yet are deleted instead because they can no longer
+		// be delivered. Recipients are compared case-insensitively.
+		AnonymizeMessagesByRecipient(ctx context.Context, recipients []string) (anonymized, deleted int, err error)
 	}
 
 	PersistenceProvider interface {
@@ -0,0 +1,204 @@
+package courier
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/corp"
+)
+
+// Channel is the channel an identity prefers to receive messages on.
+//
+// swagger:model communicationChannel
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+)
+
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+type (
+	// Preferences are the communication preferences of an identity. They only apply to messages which are not
+	// required to complete a self-service flow, such as inactivity warnings.
+	//
+	// swagger:model identityCommunicationPreferences
+	Preferences struct {
+		ID  uuid.UUID `json:"-" faker:"-" db:"id"`
+		NID uuid.UUID `json:"-" faker:"-" db:"nid"`
+
+		// IdentityID is the ID of the identity the preferences belong to.
+		//
+		// required: true
+		IdentityID uuid.UUID `json:"identity_id" faker:"-" db:"identity_id"`
+
+		// Channel is the channel the identity prefers to receive messages on. If it is empty, messages are sent
+		// by email.
+		Channel Channel `json:"channel" db:"channel"`
+
+		// Locale is the BCP 47 language tag of the language the identity prefers, for example `en-US`.
+		Locale string `json:"locale" db:"locale"`
+
+		// QuietHours is the time of day during which messages are held back.
+		QuietHours *QuietHours `json:"quiet_hours,omitempty" faker:"-" db:"quiet_hours"`
+
+		// CreatedAt is a helper struct field for gobuffalo.pop.
+		CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+	}
+
+	// QuietHours is a time of day during which messages are held back until the quiet hours end.
+	//
+	// swagger:model communicationQuietHours
+	QuietHours struct {
+		// Start is the time of day at which the quiet hours start, for example `22:00`.
+		//
+		// required: true
+		Start string `json:"start"`
+
+		// End is the time of day at which the quiet hours end, for example `07:00`. Quiet hours which end
+		// before they start span midnight.
+		//
+		// required: true
+		End string `json:"end"`
+
+		// Timezone is the IANA time zone Start and End are given in, for example `Europe/Berlin`. Defaults to UTC.
+		Timezone string `json:"timezone,omitempty"`
+	}
+
+	// NonCriticalTemplate is implemented by templates which are not required to complete a self-service flow.
+	// They are held back or skipped according to the recipient's communication preferences.
+	NonCriticalTemplate interface {
+		EmailTemplate
+
+		// RecipientIdentityID returns the ID of the identity the message is sent to.
+		RecipientIdentityID() uuid.UUID
+	}
+
+	PreferencesPersister interface {
+		// GetCommunicationPreferences returns the preferences of the identity or sqlcon.ErrNoRows if it has none.
+		GetCommunicationPreferences(ctx context.Context, identityID uuid.UUID) (*Preferences, error)
+
+		// UpsertCommunicationPreferences creates or replaces the preferences of the identity.
+		UpsertCommunicationPreferences(ctx context.Context, p *Preferences) error
+	}
+
+	PreferencesPersistenceProvider interface {
+		CommunicationPreferencesPersister() PreferencesPersister
+	}
+)
+
+func (p Preferences) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "identity_communication_preferences")
+}
+
+func (p *Preferences) GetID() uuid.UUID {
+	return p.ID
+}
+
+func (p *Preferences) GetNID() uuid.UUID {
+	return p.NID
+}
+
+// Validate returns a bad request error if the preferences are invalid.
+func (p *Preferences) Validate() error {
+	switch p.Channel {
+	case "", ChannelEmail, ChannelSMS:
+	default:
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Channel %q is not supported, use one of %q or %q.", p.Channel, ChannelEmail, ChannelSMS))
+	}
+
+	if p.Locale != "" && !localePattern.MatchString(p.Locale) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("%q is not a valid BCP 47 language tag.", p.Locale))
+	}
+
+	if p.QuietHours != nil {
+		if _, err := p.QuietHours.location(); err != nil {
+			return err
+		}
+		if _, err := parseTimeOfDay(p.QuietHours.Start); err != nil {
+			return err
+		}
+		if _, err := parseTimeOfDay(p.QuietHours.End); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HoldUntil returns when a message which is due at now may be sent. It returns now if the message may be sent
+// right away.
+func (p *Preferences) HoldUntil(now time.Time) time.Time {
+	if p.QuietHours == nil {
+		return now
+	}
+
+	loc, err := p.QuietHours.location()
+	if err != nil {
+		return now
+	}
+	start, err := parseTimeOfDay(p.QuietHours.Start)
+	if err != nil {
+		return now
+	}
+	end, err := parseTimeOfDay(p.QuietHours.End)
+	if err != nil || start == end {
+		return now
+	}
+
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	sinceMidnight := local.Sub(midnight)
+
+	switch {
+	case start < end && sinceMidnight >= start && sinceMidnight < end:
+		return midnight.Add(end)
+	case start > end && sinceMidnight >= start:
+		return midnight.AddDate(0, 0, 1).Add(end)
+	case start > end && sinceMidnight < end:
+		return midnight.Add(end)
+	}
+
+	return now
+}
+
+func (q *QuietHours) location() (*time.Location, error) {
+	if q.Timezone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%q is not a valid time zone.", q.Timezone))
+	}
+	return loc, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hours, &minutes); err != nil ||
+		len(value) != 5 || hours < 0 || hours > 23 || minutes < 0 || minutes > 59 {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%q is not a valid time of day, use the format HH:MM.", value))
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+func (q *QuietHours) Scan(value interface{}) error {
+	return sqlxx.JSONScan(q, value)
+}
+
+func (q QuietHours) Value() (driver.Value, error) {
+	return sqlxx.JSONValue(&q)
+}
@@ -0,0 +1,67 @@
+package courier_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/courier"
+)
+
+func TestPreferences(t *testing.T) {
+	t.Run("method=Validate", func(t *testing.T) {
+		for k, tc := range []struct {
+			p     courier.Preferences
+			valid bool
+		}{
+			{p: courier.Preferences{}, valid: true},
+			{p: courier.Preferences{Channel: courier.ChannelSMS, Locale: "en-US"}, valid: true},
+			{p: courier.Preferences{QuietHours: &courier.QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Berlin"}}, valid: true},
+			{p: courier.Preferences{Channel: "pigeon"}},
+			{p: courier.Preferences{Locale: "not a locale"}},
+			{p: courier.Preferences{QuietHours: &courier.QuietHours{Start: "22:00"}}},
+			{p: courier.Preferences{QuietHours: &courier.QuietHours{Start: "7:00", End: "08:00"}}},
+			{p: courier.Preferences{QuietHours: &courier.QuietHours{Start: "22:00", End: "24:00"}}},
+			{p: courier.Preferences{QuietHours: &courier.QuietHours{Start: "22:00", End: "07:00", Timezone: "Mars/Olympus"}}},
+		} {
+			err := tc.p.Validate()
+			if tc.valid {
+				assert.NoError(t, err, "%d", k)
+			} else {
+				assert.Error(t, err, "%d", k)
+			}
+		}
+	})
+
+	t.Run("method=HoldUntil", func(t *testing.T) {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		if err != nil {
+			t.Skipf("time zone data is not available: %s", err)
+		}
+
+		at := func(hour, minute int) time.Time {
+			return time.Date(2021, 5, 16, hour, minute, 0, 0, time.UTC)
+		}
+
+		for k, tc := range []struct {
+			q        *courier.QuietHours
+			now      time.Time
+			expected time.Time
+		}{
+			{q: nil, now: at(3, 0), expected: at(3, 0)},
+			{q: &courier.QuietHours{Start: "01:00", End: "05:00"}, now: at(0, 59), expected: at(0, 59)},
+			{q: &courier.QuietHours{Start: "01:00", End: "05:00"}, now: at(1, 0), expected: at(5, 0)},
+			{q: &courier.QuietHours{Start: "01:00", End: "05:00"}, now: at(5, 0), expected: at(5, 0)},
+			{q: &courier.QuietHours{Start: "22:00", End: "07:00"}, now: at(23, 30), expected: at(31, 0)},
+			{q: &courier.QuietHours{Start: "22:00", End: "07:00"}, now: at(6, 0), expected: at(7, 0)},
+			{q: &courier.QuietHours{Start: "22:00", End: "07:00"}, now: at(12, 0), expected: at(12, 0)},
+			{q: &courier.QuietHours{Start: "22:00", End: "22:00"}, now: at(22, 0), expected: at(22, 0)},
+			// 21:30 UTC is 23:30 in Berlin during summer time.
+			{q: &courier.QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Berlin"}, now: at(21, 30), expected: time.Date(2021, 5, 17, 7, 0, 0, 0, berlin)},
+		} {
+			p := courier.Preferences{QuietHours: tc.q}
+			assert.True(t, tc.expected.Equal(p.HoldUntil(tc.now)), "%d: expected %s but got %s", k, tc.expected, p.HoldUntil(tc.now))
+		}
+	})
+}
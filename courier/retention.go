@@ -0,0 +1,62 @@
+package courier
+
+import (
+	"context"
+	"time"
+)
+
+// RedactSentMessages removes the body and template data of sent messages once
+// `courier.message_retention.redact_after` has passed. The recipient, subject, type, status, and timestamps are
+// kept. It returns how many messages were redacted.
+func (m *Courier) RedactSentMessages(ctx context.Context) (int, error) {
+	after := m.d.Config(ctx).CourierMessageRedactAfter()
+	if after <= 0 {
+		return 0, nil
+	}
+
+	redacted, err := m.d.CourierPersister().RedactSentMessages(ctx, time.Now().UTC().Add(-after))
+	if err != nil {
+		return 0, err
+	}
+
+	if redacted > 0 {
+		m.d.Logger().
+			WithField("redacted", redacted).
+			Debug("Redacted sent messages.")
+	}
+	return redacted, nil
+}
+
+// PurgeMessages deletes all messages to the given recipients, including messages which were not sent yet.
+// It is used to remove the messages of identities which are deleted.
+func (m *Courier) PurgeMessages(ctx context.Context, recipients []string) (int, error) {
+	purged, err := m.d.CourierPersister().DeleteMessagesByRecipient(ctx, recipients)
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		m.d.Logger().
+			WithField("purged", purged).
+			Debug("Purged messages.")
+	}
+	return purged, nil
+}
+
+// AnonymizeMessages removes the recipient and content of all sent messages to the given recipients and deletes
+// the messages which were not sent yet. It is used instead of PurgeMessages if the messages of deleted identities
+// are kept for statistics.
+func (m *Courier) AnonymizeMessages(ctx context.Context, recipients []string) (anonymized, deleted int, err error) {
+	anonymized, deleted, err = m.d.CourierPersister().AnonymizeMessagesByRecipient(ctx, recipients)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if anonymized > 0 || deleted > 0 {
+		m.d.Logger().
+			WithField("anonymized", anonymized).
+			WithField("purged", deleted).
+			Debug("Anonymized messages.")
+	}
+	return anonymized, deleted, nil
+}
@@ -0,0 +1,150 @@
+package courier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// sms is what the courier hands to the SMS gateway if `courier.sms.request_config.format` is `json`.
+type sms struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Body string `json:"body"`
+}
+
+var smsClient = &http.Client{Timeout: time.Second * 10}
+
+// QueueSMS adds the SMS to the queue. It fails if SMS delivery is disabled.
+func (m *Courier) QueueSMS(ctx context.Context, t SMSTemplate) (uuid.UUID, error) {
+	if !m.d.Config(ctx).CourierSMSEnabled() {
+		return uuid.Nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Courier tried to queue an SMS but courier.sms.enabled is not set!"))
+	}
+
+	recipient, err := t.SMSRecipient()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	body, err := t.SMSBody()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	templateType, err := GetTemplateType(t)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	templateData, err := json.Marshal(t)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	message := &Message{
+		Status:       MessageStatusQueued,
+		Type:         MessageTypeSMS,
+		Recipient:    recipient,
+		Body:         body,
+		TemplateType: templateType,
+		TemplateData: templateData,
+	}
+
+	if err := m.d.CourierPersister().AddMessage(ctx, message); err != nil {
+		return uuid.Nil, err
+	}
+	return message.ID, nil
+}
+
+// dispatchSMS sends the SMS to the gateway of `courier.sms.request_config`.
+func (m *Courier) dispatchSMS(ctx context.Context, msg Message) error {
+	rc := m.d.Config(ctx).CourierSMSRequestConfig()
+	if rc.URL == nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Courier tried to deliver an SMS but courier.sms.request_config.url is not set!"))
+	}
+
+	provider := "http:" + rc.URL.Host
+	start := time.Now()
+	if err := m.sendSMS(ctx, rc, &sms{From: m.d.Config(ctx).CourierSMSFrom(), To: msg.Recipient, Body: msg.Body}); err != nil {
+		m.recordDelivery(ctx, provider, ChannelSMS, DeliveryOutcomeFailed, time.Since(start))
+		m.d.Logger().
+			WithError(err).
+			WithField("message_id", msg.ID).
+			WithField("provider", provider).
+			Error("Unable to send SMS.")
+		return err
+	}
+	m.recordDelivery(ctx, provider, ChannelSMS, DeliveryOutcomeSent, time.Since(start))
+
+	if err := m.d.CourierPersister().SetMessageSent(ctx, msg.ID, provider); err != nil {
+		m.d.Logger().
+			WithError(err).
+			WithField("message_id", msg.ID).
+			Error(`Unable to set the message status to "sent".`)
+		return err
+	}
+
+	m.d.Logger().
+		WithField("message_id", msg.ID).
+		WithField("message_type", msg.Type).
+		WithField("message_template_type", msg.TemplateType).
+		WithField("provider", provider).
+		Debug("Courier sent out message.")
+	return nil
+}
+
+func (m *Courier) sendSMS(ctx context.Context, rc *config.CourierSMSRequestConfig, s *sms) error {
+	var body []byte
+	var contentType string
+	switch rc.Format {
+	case config.CourierSMSFormatForm:
+		body = []byte(url.Values{"From": {s.From}, "To": {s.To}, "Body": {s.Body}}.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	default:
+		var err error
+		if body, err = json.Marshal(s); err != nil {
+			return errors.WithStack(err)
+		}
+		contentType = "application/json"
+	}
+
+	target := *rc.URL
+	target.User = nil
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for k, v := range rc.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if rc.URL.User != nil {
+		password, _ := rc.URL.User.Password()
+		req.SetBasicAuth(rc.URL.User.Username(), password)
+	}
+
+	res, err := smsClient.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.WithStack(&httpStatusError{StatusCode: res.StatusCode})
+	}
+	return nil
+}
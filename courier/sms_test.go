@@ -0,0 +1,93 @@
+package courier_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier"
+	templates "github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+)
+
+func TestQueueSMS(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyCourierSMSFrom, "+12065550100")
+
+	var received []*http.Request
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		received = append(received, r)
+		if r.Header.Get("Content-Type") == "application/json" {
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			bodies = append(bodies, body["to"]+" "+body["from"]+" "+body["body"])
+		} else {
+			bodies = append(bodies, r.PostForm.Get("To")+" "+r.PostForm.Get("From")+" "+r.PostForm.Get("Body"))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(ts.Close)
+
+	send := func(t *testing.T, to string) error {
+		c := reg.Courier(ctx)
+		id, err := c.QueueSMS(ctx, templates.NewVerificationSMS(conf, &templates.VerificationSMSModel{To: to, Code: "123456"}))
+		if err != nil {
+			return err
+		}
+
+		var m courier.Message
+		require.NoError(t, reg.Persister().GetConnection(ctx).Where("id = ?", id).First(&m))
+		return c.DispatchMessage(ctx, m)
+	}
+
+	t.Run("case=fails if SMS delivery is disabled", func(t *testing.T) {
+		require.Error(t, send(t, "+12065550101"))
+	})
+
+	conf.MustSet(config.ViperKeyCourierSMSEnabled, true)
+
+	t.Run("case=fails if no gateway is configured", func(t *testing.T) {
+		require.Error(t, send(t, "+12065550102"))
+	})
+
+	t.Run("case=sends JSON", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyCourierSMSRequestConfigURL, ts.URL+"/json")
+		conf.MustSet(config.ViperKeyCourierSMSRequestConfigHeaders, map[string]string{"X-Api-Key": "secret"})
+		require.NoError(t, send(t, "+12065550103"))
+
+		require.Len(t, received, 1)
+		assert.Equal(t, "secret", received[0].Header.Get("X-Api-Key"))
+		assert.Equal(t, "+12065550103 +12065550100 Your verification code is: 123456\n", bodies[0])
+
+		var sent courier.Message
+		require.NoError(t, reg.Persister().GetConnection(ctx).Where("recipient = ?", "+12065550103").First(&sent))
+		assert.Equal(t, courier.MessageTypeSMS, sent.Type)
+		assert.Equal(t, courier.MessageStatusSent, sent.Status)
+	})
+
+	t.Run("case=sends Twilio compatible forms", func(t *testing.T) {
+		u, err := url.Parse(strings.Replace(ts.URL, "http://", "http://AC123:token@", 1) + "/form")
+		require.NoError(t, err)
+		conf.MustSet(config.ViperKeyCourierSMSRequestConfigURL, u.String())
+		conf.MustSet(config.ViperKeyCourierSMSRequestConfigFormat, "form")
+		require.NoError(t, send(t, "+12065550104"))
+
+		require.Len(t, received, 2)
+		user, password, ok := received[1].BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "token", password)
+		assert.Equal(t, "+12065550104 +12065550100 Your verification code is: 123456\n", bodies[1])
+	})
+}
@@ -0,0 +1,43 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	AccountCompromised struct {
+		c *config.Config
+		m *AccountCompromisedModel
+	}
+	AccountCompromisedModel struct {
+		To          string
+		RecoveryURL string
+	}
+)
+
+func NewAccountCompromised(c *config.Config, m *AccountCompromisedModel) *AccountCompromised {
+	return &AccountCompromised{c: c, m: m}
+}
+
+func (t *AccountCompromised) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *AccountCompromised) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "account/compromised/email.subject.gotmpl"), t.m)
+}
+
+func (t *AccountCompromised) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "account/compromised/email.body.gotmpl"), t.m)
+}
+
+func (t *AccountCompromised) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "account/compromised/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *AccountCompromised) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
@@ -0,0 +1,28 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestAccountCompromised(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	tpl := template.NewAccountCompromised(conf, &template.AccountCompromisedModel{RecoveryURL: "https://www.ory.sh/recovery"})
+
+	rendered, err := tpl.EmailBody()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "https://www.ory.sh/recovery")
+
+	rendered, err = tpl.EmailBodyPlaintext()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "https://www.ory.sh/recovery")
+
+	rendered, err = tpl.EmailSubject()
+	require.NoError(t, err)
+	assert.NotEmpty(t, rendered)
+}
@@ -0,0 +1,53 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	InactivityWarning struct {
+		c *config.Config
+		m *InactivityWarningModel
+	}
+	InactivityWarningModel struct {
+		To         string
+		Action     string
+		ActionAt   time.Time
+		LoginURL   string
+		IdentityID uuid.UUID
+	}
+)
+
+func NewInactivityWarning(c *config.Config, m *InactivityWarningModel) *InactivityWarning {
+	return &InactivityWarning{c: c, m: m}
+}
+
+func (t *InactivityWarning) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *InactivityWarning) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "inactivity/warning/email.subject.gotmpl"), t.m)
+}
+
+func (t *InactivityWarning) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "inactivity/warning/email.body.gotmpl"), t.m)
+}
+
+func (t *InactivityWarning) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "inactivity/warning/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *InactivityWarning) RecipientIdentityID() uuid.UUID {
+	return t.m.IdentityID
+}
+
+func (t *InactivityWarning) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
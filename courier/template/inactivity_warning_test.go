@@ -0,0 +1,30 @@
+package template_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestInactivityWarning(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	tpl := template.NewInactivityWarning(conf, &template.InactivityWarningModel{
+		Action:   "delete",
+		ActionAt: time.Date(2021, 5, 14, 0, 0, 0, 0, time.UTC),
+		LoginURL: "https://www.ory.sh/login",
+	})
+
+	rendered, err := tpl.EmailBody()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "https://www.ory.sh/login")
+	assert.Contains(t, rendered, "deleted on May 14, 2021")
+
+	rendered, err = tpl.EmailSubject()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "Your account will be deleted soon")
+}
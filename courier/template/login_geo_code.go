@@ -0,0 +1,43 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	LoginGeoCode struct {
+		c *config.Config
+		m *LoginGeoCodeModel
+	}
+	LoginGeoCodeModel struct {
+		To   string
+		Code string
+	}
+)
+
+func NewLoginGeoCode(c *config.Config, m *LoginGeoCodeModel) *LoginGeoCode {
+	return &LoginGeoCode{c: c, m: m}
+}
+
+func (t *LoginGeoCode) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *LoginGeoCode) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "login/geo_code/email.subject.gotmpl"), t.m)
+}
+
+func (t *LoginGeoCode) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "login/geo_code/email.body.gotmpl"), t.m)
+}
+
+func (t *LoginGeoCode) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "login/geo_code/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *LoginGeoCode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
@@ -0,0 +1,28 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestLoginGeoCode(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	tpl := template.NewLoginGeoCode(conf, &template.LoginGeoCodeModel{To: "foo@ory.sh", Code: "123456"})
+
+	rendered, err := tpl.EmailBody()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "123456")
+
+	rendered, err = tpl.EmailBodyPlaintext()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "123456")
+
+	rendered, err = tpl.EmailSubject()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "Confirm your sign in")
+}
@@ -0,0 +1,46 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	LoginNewCountry struct {
+		c *config.Config
+		m *LoginNewCountryModel
+	}
+	LoginNewCountryModel struct {
+		To              string
+		Country         string
+		PreviousCountry string
+		SignedInAt      time.Time
+	}
+)
+
+func NewLoginNewCountry(c *config.Config, m *LoginNewCountryModel) *LoginNewCountry {
+	return &LoginNewCountry{c: c, m: m}
+}
+
+func (t *LoginNewCountry) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *LoginNewCountry) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "login/new_country/email.subject.gotmpl"), t.m)
+}
+
+func (t *LoginNewCountry) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "login/new_country/email.body.gotmpl"), t.m)
+}
+
+func (t *LoginNewCountry) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "login/new_country/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *LoginNewCountry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
@@ -0,0 +1,34 @@
+package template_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestLoginNewCountry(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	tpl := template.NewLoginNewCountry(conf, &template.LoginNewCountryModel{
+		To:              "foo@ory.sh",
+		Country:         "DE",
+		PreviousCountry: "US",
+		SignedInAt:      time.Date(2021, 5, 28, 10, 0, 0, 0, time.UTC),
+	})
+
+	rendered, err := tpl.EmailBody()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "from DE while you usually sign in from US at 2021-05-28 10:00 UTC")
+
+	rendered, err = tpl.EmailBodyPlaintext()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "from DE while you usually sign in from US")
+
+	rendered, err = tpl.EmailSubject()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "New sign in to your account")
+}
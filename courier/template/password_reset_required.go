@@ -0,0 +1,43 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	PasswordResetRequired struct {
+		c *config.Config
+		m *PasswordResetRequiredModel
+	}
+	PasswordResetRequiredModel struct {
+		To       string
+		LoginURL string
+	}
+)
+
+func NewPasswordResetRequired(c *config.Config, m *PasswordResetRequiredModel) *PasswordResetRequired {
+	return &PasswordResetRequired{c: c, m: m}
+}
+
+func (t *PasswordResetRequired) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *PasswordResetRequired) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "password_reset/required/email.subject.gotmpl"), t.m)
+}
+
+func (t *PasswordResetRequired) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "password_reset/required/email.body.gotmpl"), t.m)
+}
+
+func (t *PasswordResetRequired) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "password_reset/required/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *PasswordResetRequired) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
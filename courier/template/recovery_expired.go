@@ -0,0 +1,50 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	RecoveryExpired struct {
+		c *config.Config
+		m *RecoveryExpiredModel
+	}
+	RecoveryExpiredModel struct {
+		To          string
+		RecoveryURL string
+		IdentityID  uuid.UUID
+	}
+)
+
+func NewRecoveryExpired(c *config.Config, m *RecoveryExpiredModel) *RecoveryExpired {
+	return &RecoveryExpired{c: c, m: m}
+}
+
+func (t *RecoveryExpired) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *RecoveryExpired) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "recovery/expired/email.subject.gotmpl"), t.m)
+}
+
+func (t *RecoveryExpired) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "recovery/expired/email.body.gotmpl"), t.m)
+}
+
+func (t *RecoveryExpired) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "recovery/expired/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *RecoveryExpired) RecipientIdentityID() uuid.UUID {
+	return t.m.IdentityID
+}
+
+func (t *RecoveryExpired) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
@@ -0,0 +1,24 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestRecoverExpired(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	tpl := template.NewRecoveryExpired(conf, &template.RecoveryExpiredModel{RecoveryURL: "https://www.ory.sh/self-service/recovery/browser"})
+
+	rendered, err := tpl.EmailBody()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "https://www.ory.sh/self-service/recovery/browser")
+
+	rendered, err = tpl.EmailSubject()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "expired")
+}
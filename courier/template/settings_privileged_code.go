@@ -0,0 +1,43 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	SettingsPrivilegedCode struct {
+		c *config.Config
+		m *SettingsPrivilegedCodeModel
+	}
+	SettingsPrivilegedCodeModel struct {
+		To   string
+		Code string
+	}
+)
+
+func NewSettingsPrivilegedCode(c *config.Config, m *SettingsPrivilegedCodeModel) *SettingsPrivilegedCode {
+	return &SettingsPrivilegedCode{c: c, m: m}
+}
+
+func (t *SettingsPrivilegedCode) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *SettingsPrivilegedCode) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "settings/privileged_code/email.subject.gotmpl"), t.m)
+}
+
+func (t *SettingsPrivilegedCode) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "settings/privileged_code/email.body.gotmpl"), t.m)
+}
+
+func (t *SettingsPrivilegedCode) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "settings/privileged_code/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *SettingsPrivilegedCode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
@@ -0,0 +1,28 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestSettingsPrivilegedCode(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	tpl := template.NewSettingsPrivilegedCode(conf, &template.SettingsPrivilegedCodeModel{To: "foo@ory.sh", Code: "123456"})
+
+	rendered, err := tpl.EmailBody()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "123456")
+
+	rendered, err = tpl.EmailBodyPlaintext()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "123456")
+
+	rendered, err = tpl.EmailSubject()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "Confirm the changes to your account")
+}
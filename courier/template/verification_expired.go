@@ -0,0 +1,50 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	VerificationExpired struct {
+		c *config.Config
+		m *VerificationExpiredModel
+	}
+	VerificationExpiredModel struct {
+		To              string
+		VerificationURL string
+		IdentityID      uuid.UUID
+	}
+)
+
+func NewVerificationExpired(c *config.Config, m *VerificationExpiredModel) *VerificationExpired {
+	return &VerificationExpired{c: c, m: m}
+}
+
+func (t *VerificationExpired) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *VerificationExpired) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "verification/expired/email.subject.gotmpl"), t.m)
+}
+
+func (t *VerificationExpired) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "verification/expired/email.body.gotmpl"), t.m)
+}
+
+func (t *VerificationExpired) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "verification/expired/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *VerificationExpired) RecipientIdentityID() uuid.UUID {
+	return t.m.IdentityID
+}
+
+func (t *VerificationExpired) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
@@ -0,0 +1,35 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	VerificationSMS struct {
+		c *config.Config
+		m *VerificationSMSModel
+	}
+	VerificationSMSModel struct {
+		To   string
+		Code string
+	}
+)
+
+func NewVerificationSMS(c *config.Config, m *VerificationSMSModel) *VerificationSMS {
+	return &VerificationSMS{c: c, m: m}
+}
+
+func (t *VerificationSMS) SMSRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *VerificationSMS) SMSBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "verification/sms/sms.body.gotmpl"), t.m)
+}
+
+func (t *VerificationSMS) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
@@ -0,0 +1,24 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestVerificationSMS(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	tpl := template.NewVerificationSMS(conf, &template.VerificationSMSModel{To: "+12065550100", Code: "123456"})
+
+	recipient, err := tpl.SMSRecipient()
+	require.NoError(t, err)
+	assert.Equal(t, "+12065550100", recipient)
+
+	rendered, err := tpl.SMSBody()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "123456")
+}
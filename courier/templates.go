@@ -12,11 +12,21 @@ import (
 type TemplateType string
 
 const (
-	TypeRecoveryInvalid     TemplateType = "recovery_invalid"
-	TypeRecoveryValid       TemplateType = "recovery_valid"
-	TypeVerificationInvalid TemplateType = "verification_invalid"
-	TypeVerificationValid   TemplateType = "verification_valid"
-	TypeTestStub            TemplateType = "stub"
+	TypeRecoveryInvalid        TemplateType = "recovery_invalid"
+	TypeRecoveryValid          TemplateType = "recovery_valid"
+	TypeRecoveryExpired        TemplateType = "recovery_expired"
+	TypeRecoveryCodeValid      TemplateType = "recovery_code_valid"
+	TypeVerificationInvalid    TemplateType = "verification_invalid"
+	TypeVerificationValid      TemplateType = "verification_valid"
+	TypeVerificationExpired    TemplateType = "verification_expired"
+	TypeVerificationSMS        TemplateType = "verification_sms"
+	TypePasswordResetRequired  TemplateType = "password_reset_required"
+	TypeInactivityWarning      TemplateType = "inactivity_warning"
+	TypeSettingsPrivilegedCode TemplateType = "settings_privileged_code"
+	TypeAccountCompromised     TemplateType = "account_compromised"
+	TypeLoginGeoCode           TemplateType = "login_geo_code"
+	TypeLoginNewCountry        TemplateType = "login_new_country"
+	TypeTestStub               TemplateType = "stub"
 )
 
 type EmailTemplate interface {
@@ -27,16 +37,44 @@ type EmailTemplate interface {
 	EmailRecipient() (string, error)
 }
 
-func GetTemplateType(t EmailTemplate) (TemplateType, error) {
+// SMSTemplate is a template of messages which are sent by SMS.
+type SMSTemplate interface {
+	json.Marshaler
+	SMSBody() (string, error)
+	SMSRecipient() (string, error)
+}
+
+// GetTemplateType returns the type of an EmailTemplate or SMSTemplate.
+func GetTemplateType(t json.Marshaler) (TemplateType, error) {
 	switch t.(type) {
 	case *template.RecoveryInvalid:
 		return TypeRecoveryInvalid, nil
 	case *template.RecoveryValid:
 		return TypeRecoveryValid, nil
+	case *template.RecoveryExpired:
+		return TypeRecoveryExpired, nil
+	case *template.RecoveryCodeValid:
+		return TypeRecoveryCodeValid, nil
 	case *template.VerificationInvalid:
 		return TypeVerificationInvalid, nil
 	case *template.VerificationValid:
 		return TypeVerificationValid, nil
+	case *template.VerificationExpired:
+		return TypeVerificationExpired, nil
+	case *template.VerificationSMS:
+		return TypeVerificationSMS, nil
+	case *template.PasswordResetRequired:
+		return TypePasswordResetRequired, nil
+	case *template.InactivityWarning:
+		return TypeInactivityWarning, nil
+	case *template.SettingsPrivilegedCode:
+		return TypeSettingsPrivilegedCode, nil
+	case *template.AccountCompromised:
+		return TypeAccountCompromised, nil
+	case *template.LoginGeoCode:
+		return TypeLoginGeoCode, nil
+	case *template.LoginNewCountry:
+		return TypeLoginNewCountry, nil
 	case *template.TestStub:
 		return TypeTestStub, nil
 	default:
@@ -58,6 +96,18 @@ func NewEmailTemplateFromMessage(c *config.Config, m Message) (EmailTemplate, er
 			return nil, err
 		}
 		return template.NewRecoveryValid(c, &t), nil
+	case TypeRecoveryExpired:
+		var t template.RecoveryExpiredModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewRecoveryExpired(c, &t), nil
+	case TypeRecoveryCodeValid:
+		var t template.RecoveryCodeValidModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewRecoveryCodeValid(c, &t), nil
 	case TypeVerificationInvalid:
 		var t template.VerificationInvalidModel
 		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
@@ -70,6 +120,48 @@ func NewEmailTemplateFromMessage(c *config.Config, m Message) (EmailTemplate, er
 			return nil, err
 		}
 		return template.NewVerificationValid(c, &t), nil
+	case TypeVerificationExpired:
+		var t template.VerificationExpiredModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewVerificationExpired(c, &t), nil
+	case TypePasswordResetRequired:
+		var t template.PasswordResetRequiredModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewPasswordResetRequired(c, &t), nil
+	case TypeInactivityWarning:
+		var t template.InactivityWarningModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewInactivityWarning(c, &t), nil
+	case TypeSettingsPrivilegedCode:
+		var t template.SettingsPrivilegedCodeModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewSettingsPrivilegedCode(c, &t), nil
+	case TypeAccountCompromised:
+		var t template.AccountCompromisedModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewAccountCompromised(c, &t), nil
+	case TypeLoginGeoCode:
+		var t template.LoginGeoCodeModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewLoginGeoCode(c, &t), nil
+	case TypeLoginNewCountry:
+		var t template.LoginNewCountryModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewLoginNewCountry(c, &t), nil
 	case TypeTestStub:
 		var t template.TestStubModel
 		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
@@ -80,3 +172,16 @@ func NewEmailTemplateFromMessage(c *config.Config, m Message) (EmailTemplate, er
 		return nil, errors.Errorf("received unexpected message template type: %s", m.TemplateType)
 	}
 }
+
+func NewSMSTemplateFromMessage(c *config.Config, m Message) (SMSTemplate, error) {
+	switch m.TemplateType {
+	case TypeVerificationSMS:
+		var t template.VerificationSMSModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewVerificationSMS(c, &t), nil
+	default:
+		return nil, errors.Errorf("received unexpected message template type: %s", m.TemplateType)
+	}
+}
@@ -13,12 +13,22 @@ import (
 )
 
 func TestGetTemplateType(t *testing.T) {
-	for expectedType, tmpl := range map[courier.TemplateType]courier.EmailTemplate{
-		courier.TypeRecoveryInvalid:     &template.RecoveryInvalid{},
-		courier.TypeRecoveryValid:       &template.RecoveryValid{},
-		courier.TypeVerificationInvalid: &template.VerificationInvalid{},
-		courier.TypeVerificationValid:   &template.VerificationValid{},
-		courier.TypeTestStub:            &template.TestStub{},
+	for expectedType, tmpl := range map[courier.TemplateType]json.Marshaler{
+		courier.TypeRecoveryInvalid:        &template.RecoveryInvalid{},
+		courier.TypeRecoveryValid:          &template.RecoveryValid{},
+		courier.TypeRecoveryExpired:        &template.RecoveryExpired{},
+		courier.TypeRecoveryCodeValid:      &template.RecoveryCodeValid{},
+		courier.TypeVerificationInvalid:    &template.VerificationInvalid{},
+		courier.TypeVerificationValid:      &template.VerificationValid{},
+		courier.TypeVerificationExpired:    &template.VerificationExpired{},
+		courier.TypeVerificationSMS:        &template.VerificationSMS{},
+		courier.TypePasswordResetRequired:  &template.PasswordResetRequired{},
+		courier.TypeInactivityWarning:      &template.InactivityWarning{},
+		courier.TypeSettingsPrivilegedCode: &template.SettingsPrivilegedCode{},
+		courier.TypeAccountCompromised:     &template.AccountCompromised{},
+		courier.TypeLoginGeoCode:           &template.LoginGeoCode{},
+		courier.TypeLoginNewCountry:        &template.LoginNewCountry{},
+		courier.TypeTestStub:               &template.TestStub{},
 	} {
 		t.Run(fmt.Sprintf("case=%s", expectedType), func(t *testing.T) {
 			actualType, err := courier.GetTemplateType(tmpl)
@@ -33,11 +43,20 @@ func TestGetTemplateType(t *testing.T) {
 func TestNewEmailTemplateFromMessage(t *testing.T) {
 	conf := internal.NewConfigurationWithDefaults(t)
 	for tmplType, expectedTmpl := range map[courier.TemplateType]courier.EmailTemplate{
-		courier.TypeRecoveryInvalid:     template.NewRecoveryInvalid(conf, &template.RecoveryInvalidModel{To: "foo"}),
-		courier.TypeRecoveryValid:       template.NewRecoveryValid(conf, &template.RecoveryValidModel{To: "bar", RecoveryURL: "http://foo.bar"}),
-		courier.TypeVerificationInvalid: template.NewVerificationInvalid(conf, &template.VerificationInvalidModel{To: "baz"}),
-		courier.TypeVerificationValid:   template.NewVerificationValid(conf, &template.VerificationValidModel{To: "faz", VerificationURL: "http://bar.foo"}),
-		courier.TypeTestStub:            template.NewTestStub(conf, &template.TestStubModel{To: "far", Subject: "test subject", Body: "test body"}),
+		courier.TypeRecoveryInvalid:        template.NewRecoveryInvalid(conf, &template.RecoveryInvalidModel{To: "foo"}),
+		courier.TypeRecoveryValid:          template.NewRecoveryValid(conf, &template.RecoveryValidModel{To: "bar", RecoveryURL: "http://foo.bar"}),
+		courier.TypeRecoveryExpired:        template.NewRecoveryExpired(conf, &template.RecoveryExpiredModel{To: "bab", RecoveryURL: "http://foo.bar"}),
+		courier.TypeRecoveryCodeValid:      template.NewRecoveryCodeValid(conf, &template.RecoveryCodeValidModel{To: "bac", Code: "123456"}),
+		courier.TypeVerificationInvalid:    template.NewVerificationInvalid(conf, &template.VerificationInvalidModel{To: "baz"}),
+		courier.TypeVerificationValid:      template.NewVerificationValid(conf, &template.VerificationValidModel{To: "faz", VerificationURL: "http://bar.foo"}),
+		courier.TypeVerificationExpired:    template.NewVerificationExpired(conf, &template.VerificationExpiredModel{To: "fax", VerificationURL: "http://bar.foo"}),
+		courier.TypePasswordResetRequired:  template.NewPasswordResetRequired(conf, &template.PasswordResetRequiredModel{To: "fab", LoginURL: "http://foo.baz"}),
+		courier.TypeInactivityWarning:      template.NewInactivityWarning(conf, &template.InactivityWarningModel{To: "fac", Action: "delete", LoginURL: "http://foo.baz"}),
+		courier.TypeSettingsPrivilegedCode: template.NewSettingsPrivilegedCode(conf, &template.SettingsPrivilegedCodeModel{To: "fad", Code: "123456"}),
+		courier.TypeAccountCompromised:     template.NewAccountCompromised(conf, &template.AccountCompromisedModel{To: "fae", RecoveryURL: "http://foo.baz"}),
+		courier.TypeLoginGeoCode:           template.NewLoginGeoCode(conf, &template.LoginGeoCodeModel{To: "faf", Code: "123456"}),
+		courier.TypeLoginNewCountry:        template.NewLoginNewCountry(conf, &template.LoginNewCountryModel{To: "fag", Country: "DE"}),
+		courier.TypeTestStub:               template.NewTestStub(conf, &template.TestStubModel{To: "far", Subject: "test subject", Body: "test body"}),
 	} {
 		t.Run(fmt.Sprintf("case=%s", tmplType), func(t *testing.T) {
 			tmplData, err := json.Marshal(expectedTmpl)
@@ -13,9 +13,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/persistence"
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
 )
 
 func TestPersister(ctx context.Context, p persistence.Persister) func(t *testing.T) {
@@ -83,6 +85,230 @@ func TestPersister(ctx context.Context, p persistence.Persister) func(t *testing
 			require.EqualError(t, err, courier.ErrQueueEmpty.Error())
 		})
 
+		t.Run("case=setting message sent", func(t *testing.T) {
+			require.NoError(t, p.SetMessageStatus(ctx, messages[1].ID, courier.MessageStatusQueued))
+			require.NoError(t, p.SetMessageSent(ctx, messages[1].ID, "smtp:fallback.example.org"))
+			_, err := p.NextMessages(ctx, 1)
+			require.EqualError(t, err, courier.ErrQueueEmpty.Error())
+
+			var actual courier.Message
+			require.NoError(t, p.GetConnection(ctx).Find(&actual, messages[1].ID))
+			assert.Equal(t, "smtp:fallback.example.org", actual.Provider)
+			assert.Equal(t, courier.MessageStatusSent, actual.Status)
+
+			require.ErrorIs(t, p.SetMessageSent(ctx, x.NewUUID(), "smtp:fallback.example.org"), sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=held back messages", func(t *testing.T) {
+			held := courier.Message{Subject: "held", SendAt: sqlxx.NullTime(time.Now().UTC().Add(time.Hour))}
+			require.NoError(t, p.AddMessage(ctx, &held))
+
+			_, err := p.NextMessages(ctx, 10)
+			require.ErrorIs(t, err, courier.ErrQueueEmpty)
+
+			due := courier.Message{Subject: "due", SendAt: sqlxx.NullTime(time.Now().UTC().Add(-time.Minute))}
+			require.NoError(t, p.AddMessage(ctx, &due))
+
+			ms, err := p.NextMessages(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, ms, 1)
+			assert.Equal(t, due.ID, ms[0].ID)
+
+			require.NoError(t, p.SetMessageStatus(ctx, held.ID, courier.MessageStatusSent))
+		})
+
+		t.Run("case=redact sent messages", func(t *testing.T) {
+			sent := courier.Message{Recipient: "redact@ory.sh", Subject: "sent", Body: "code 123", TemplateData: []byte(`{"code":"123"}`)}
+			require.NoError(t, p.AddMessage(ctx, &sent))
+			require.NoError(t, p.SetMessageStatus(ctx, sent.ID, courier.MessageStatusSent))
+
+			queued := courier.Message{Recipient: "redact@ory.sh", Subject: "queued", Body: "code 456"}
+			require.NoError(t, p.AddMessage(ctx, &queued))
+
+			redacted, err := p.RedactSentMessages(ctx, time.Now().UTC().Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 0, redacted)
+
+			redacted, err = p.RedactSentMessages(ctx, time.Now().UTC().Add(time.Minute))
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, redacted, 1)
+
+			redacted, err = p.RedactSentMessages(ctx, time.Now().UTC().Add(time.Minute))
+			require.NoError(t, err)
+			assert.Equal(t, 0, redacted, "already redacted messages are skipped")
+
+			ms, err := p.NextMessages(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, ms, 1)
+			assert.Equal(t, queued.ID, ms[0].ID)
+			assert.Equal(t, "code 456", ms[0].Body, "messages which were not sent are not redacted")
+			require.NoError(t, p.SetMessageStatus(ctx, queued.ID, courier.MessageStatusSent))
+
+			require.NoError(t, p.SetMessageStatus(ctx, sent.ID, courier.MessageStatusQueued))
+			actual, err := p.LatestQueuedMessage(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, sent.ID, actual.ID)
+			assert.Equal(t, "sent", actual.Subject)
+			assert.Equal(t, "redact@ory.sh", actual.Recipient)
+			assert.Empty(t, actual.Body)
+			assert.Empty(t, actual.TemplateData)
+			require.NoError(t, p.SetMessageStatus(ctx, sent.ID, courier.MessageStatusSent))
+		})
+
+		t.Run("case=delete messages by recipient", func(t *testing.T) {
+			for _, to := range []string{"Purge@ory.sh", "purge@ory.sh", "other-purge@ory.sh", "keep@ory.sh"} {
+				require.NoError(t, p.AddMessage(ctx, &courier.Message{Recipient: to, Subject: "purge"}))
+			}
+
+			deleted, err := p.DeleteMessagesByRecipient(ctx, nil)
+			require.NoError(t, err)
+			assert.Equal(t, 0, deleted)
+
+			deleted, err = p.DeleteMessagesByRecipient(ctx, []string{"PURGE@ory.sh", "other-purge@ory.sh"})
+			require.NoError(t, err)
+			assert.Equal(t, 3, deleted)
+
+			ms, err := p.NextMessages(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, ms, 1)
+			assert.Equal(t, "keep@ory.sh", ms[0].Recipient)
+			require.NoError(t, p.SetMessageStatus(ctx, ms[0].ID, courier.MessageStatusSent))
+
+			t.Run("can not delete on another network", func(t *testing.T) {
+				require.NoError(t, p.SetMessageStatus(ctx, ms[0].ID, courier.MessageStatusQueued))
+
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				deleted, err := other.DeleteMessagesByRecipient(ctx, []string{"keep@ory.sh"})
+				require.NoError(t, err)
+				assert.Equal(t, 0, deleted)
+
+				actual, err := p.LatestQueuedMessage(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, ms[0].ID, actual.ID)
+				require.NoError(t, p.SetMessageStatus(ctx, ms[0].ID, courier.MessageStatusSent))
+			})
+		})
+
+		t.Run("case=anonymize messages by recipient", func(t *testing.T) {
+			for _, to := range []string{"Anonymize@ory.sh", "anonymize@ory.sh", "other-anonymize@ory.sh"} {
+				require.NoError(t, p.AddMessage(ctx, &courier.Message{Recipient: to, Subject: "anonymize", Body: "anonymize"}))
+			}
+
+			ms, err := p.NextMessages(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, ms, 3)
+			status := courier.MessageStatusSent
+			for _, m := range ms {
+				if m.Recipient == "other-anonymize@ory.sh" {
+					require.NoError(t, p.SetMessageStatus(ctx, m.ID, courier.MessageStatusQueued))
+					continue
+				}
+				require.NoError(t, p.SetMessageStatus(ctx, m.ID, status))
+				status = courier.MessageStatusQueued
+			}
+
+			_, other := testhelpers.NewNetwork(t, ctx, p)
+			anonymized, deleted, err := other.AnonymizeMessagesByRecipient(ctx, []string{"anonymize@ory.sh"})
+			require.NoError(t, err)
+			assert.Equal(t, 0, anonymized)
+			assert.Equal(t, 0, deleted)
+
+			anonymized, deleted, err = p.AnonymizeMessagesByRecipient(ctx, []string{"ANONYMIZE@ory.sh"})
+			require.NoError(t, err)
+			assert.Equal(t, 1, anonymized)
+			assert.Equal(t, 1, deleted)
+
+			anonymized, deleted, err = p.AnonymizeMessagesByRecipient(ctx, []string{"anonymize@ory.sh"})
+			require.NoError(t, err)
+			assert.Equal(t, 0, anonymized)
+			assert.Equal(t, 0, deleted)
+
+			ms, err = p.NextMessages(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, ms, 1)
+			assert.Equal(t, "other-anonymize@ory.sh", ms[0].Recipient)
+			require.NoError(t, p.SetMessageStatus(ctx, ms[0].ID, courier.MessageStatusSent))
+		})
+
+		t.Run("case=communication preferences", func(t *testing.T) {
+			i := identity.NewIdentity("")
+			require.NoError(t, p.CreateIdentity(ctx, i))
+
+			_, err := p.GetCommunicationPreferences(ctx, i.ID)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			expected := courier.Preferences{
+				IdentityID: i.ID,
+				Channel:    courier.ChannelSMS,
+				Locale:     "en-US",
+				QuietHours: &courier.QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Berlin"},
+			}
+			require.NoError(t, p.UpsertCommunicationPreferences(ctx, &expected))
+			assert.EqualValues(t, nid, expected.NID)
+
+			actual, err := p.GetCommunicationPreferences(ctx, i.ID)
+			require.NoError(t, err)
+			assert.Equal(t, expected.Channel, actual.Channel)
+			assert.Equal(t, expected.Locale, actual.Locale)
+			assert.Equal(t, expected.QuietHours, actual.QuietHours)
+
+			t.Run("replaces existing preferences", func(t *testing.T) {
+				require.NoError(t, p.UpsertCommunicationPreferences(ctx, &courier.Preferences{IdentityID: i.ID, Channel: courier.ChannelEmail}))
+
+				actual, err := p.GetCommunicationPreferences(ctx, i.ID)
+				require.NoError(t, err)
+				assert.Equal(t, expected.ID, actual.ID)
+				assert.Equal(t, courier.ChannelEmail, actual.Channel)
+				assert.Empty(t, actual.Locale)
+				assert.Nil(t, actual.QuietHours)
+			})
+
+			t.Run("can not get on another network", func(t *testing.T) {
+				_, p := testhelpers.NewNetwork(t, ctx, p)
+				_, err := p.GetCommunicationPreferences(ctx, i.ID)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+		})
+
+		t.Run("case=delivery events", func(t *testing.T) {
+			start := time.Now().UTC().Add(-time.Second)
+			for _, outcome := range []courier.DeliveryOutcome{courier.DeliveryOutcomeSent, courier.DeliveryOutcomeFailed, courier.DeliveryOutcomeBounced} {
+				e := courier.DeliveryEvent{Provider: "smtp:mail.example.org", Channel: courier.ChannelEmail, Outcome: outcome, LatencyMilliseconds: 20}
+				require.NoError(t, p.AddDeliveryEvent(ctx, &e))
+				assert.EqualValues(t, nid, e.NID)
+			}
+
+			events, err := p.ListDeliveryEvents(ctx, start)
+			require.NoError(t, err)
+			require.Len(t, events, 3)
+			assert.Equal(t, "smtp:mail.example.org", events[0].Provider)
+			assert.Equal(t, courier.ChannelEmail, events[0].Channel)
+			assert.EqualValues(t, 20, events[0].LatencyMilliseconds)
+
+			events, err = p.ListDeliveryEvents(ctx, time.Now().UTC().Add(time.Minute))
+			require.NoError(t, err)
+			assert.Len(t, events, 0)
+
+			t.Run("can not list or delete on another network", func(t *testing.T) {
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				events, err := other.ListDeliveryEvents(ctx, start)
+				require.NoError(t, err)
+				assert.Len(t, events, 0)
+
+				deleted, err := other.DeleteDeliveryEventsBefore(ctx, time.Now().UTC().Add(time.Minute))
+				require.NoError(t, err)
+				assert.Equal(t, 0, deleted)
+			})
+
+			deleted, err := p.DeleteDeliveryEventsBefore(ctx, start)
+			require.NoError(t, err)
+			assert.Equal(t, 0, deleted)
+
+			deleted, err = p.DeleteDeliveryEventsBefore(ctx, time.Now().UTC().Add(time.Minute))
+			require.NoError(t, err)
+			assert.Equal(t, 3, deleted)
+		})
+
 		t.Run("case=network", func(t *testing.T) {
 			id := x.NewUUID()
 
@@ -121,6 +347,9 @@ func TestPersister(ctx context.Context, p persistence.Persister) func(t *testing
 				_, p := testhelpers.NewNetwork(t, ctx, p)
 				err := p.SetMessageStatus(ctx, id, courier.MessageStatusProcessing)
 				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				err = p.SetMessageSent(ctx, id, "smtp:fallback.example.org")
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
 			})
 		})
 	}
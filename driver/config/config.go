@@ -3,6 +3,8 @@ package config
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -11,6 +13,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -23,6 +26,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/inhies/go-bytesize"
+	"github.com/knadh/koanf"
 	kjson "github.com/knadh/koanf/parsers/json"
 	"github.com/pkg/errors"
 	"github.com/rs/cors"
@@ -39,54 +43,146 @@ const (
 	DefaultBrowserReturnURL                                         = "default_browser_return_url"
 	DefaultSQLiteMemoryDSN                                          = dbal.SQLiteInMemory
 	DefaultPasswordHashingAlgorithm                                 = "argon2"
+	DefaultAPIKeyPrefix                                             = "ory"
 	UnknownVersion                                                  = "unknown version"
 	ViperKeyDSN                                                     = "dsn"
 	ViperKeyCourierSMTPURL                                          = "courier.smtp.connection_uri"
 	ViperKeyCourierTemplatesPath                                    = "courier.template_override_path"
 	ViperKeyCourierSMTPFrom                                         = "courier.smtp.from_address"
 	ViperKeyCourierSMTPFromName                                     = "courier.smtp.from_name"
+	ViperKeyCourierSMTPEnvelopeFrom                                 = "courier.smtp.envelope_from"
+	ViperKeyCourierSMTPMessageIDDomain                              = "courier.smtp.message_id_domain"
+	ViperKeyCourierSMTPHeaders                                      = "courier.smtp.headers"
+	ViperKeyCourierSMTPListUnsubscribe                              = "courier.smtp.list_unsubscribe"
+	ViperKeyCourierMessageRedactAfter                               = "courier.message_retention.redact_after"
+	ViperKeyCourierDeliverabilityRetention                          = "courier.deliverability.retention"
+	ViperKeyCourierDegradationPolicy                                = "courier.degradation.policy"
+	ViperKeyCourierDegradationFailureThreshold                      = "courier.degradation.failure_threshold"
+	ViperKeyCourierDegradationWindow                                = "courier.degradation.window"
+	ViperKeyCourierDegradationFallbackURL                           = "courier.degradation.fallback_connection_uri"
+	ViperKeyCourierFallbackProviders                                = "courier.fallback_providers"
+	ViperKeyCourierSMSEnabled                                       = "courier.sms.enabled"
+	ViperKeyCourierSMSFrom                                          = "courier.sms.from"
+	ViperKeyCourierSMSRequestConfigURL                              = "courier.sms.request_config.url"
+	ViperKeyCourierSMSRequestConfigFormat                           = "courier.sms.request_config.format"
+	ViperKeyCourierSMSRequestConfigHeaders                          = "courier.sms.request_config.headers"
 	ViperKeySecretsDefault                                          = "secrets.default"
 	ViperKeySecretsCookie                                           = "secrets.cookie"
+	ViperKeySecretsWebhook                                          = "secrets.webhook"
+	ViperKeySecretsPrefill                                          = "secrets.prefill"
+	ViperKeySecretsCipher                                           = "secrets.cipher"
+	ViperKeySecretsPepper                                           = "secrets.pepper"
 	ViperKeyPublicBaseURL                                           = "serve.public.base_url"
 	ViperKeyPublicDomainAliases                                     = "serve.public.domain_aliases"
+	ViperKeyPublicCSRFRouteGroups                                   = "serve.public.csrf.route_groups"
+	ViperKeyPublicCSRFHeaderName                                    = "serve.public.csrf.header_name"
+	ViperKeyPublicCSRFAllowedOrigins                                = "serve.public.csrf.allowed_origins"
+	ViperKeyPublicCSRFCookie                                        = "serve.public.csrf.cookie"
+	ViperKeyPublicMiddleware                                        = "serve.public.middleware"
 	ViperKeyPublicPort                                              = "serve.public.port"
 	ViperKeyPublicHost                                              = "serve.public.host"
 	ViperKeyAdminBaseURL                                            = "serve.admin.base_url"
 	ViperKeyAdminPort                                               = "serve.admin.port"
 	ViperKeyAdminHost                                               = "serve.admin.host"
+	ViperKeyAdminProfilingEnabled                                   = "serve.admin.profiling.enabled"
+	ViperKeyAdminTraitMaskingEnabled                                = "serve.admin.trait_masking.enabled"
+	ViperKeyAdminTraitMaskingScopeHeader                            = "serve.admin.trait_masking.scope_header"
+	ViperKeyAdminTraitMaskingUnmaskScope                            = "serve.admin.trait_masking.unmask_scope"
 	ViperKeySessionLifespan                                         = "session.lifespan"
 	ViperKeySessionSameSite                                         = "session.cookie.same_site"
 	ViperKeySessionDomain                                           = "session.cookie.domain"
 	ViperKeySessionName                                             = "session.cookie.name"
 	ViperKeySessionPath                                             = "session.cookie.path"
 	ViperKeySessionPersistentCookie                                 = "session.cookie.persistent"
+	ViperKeySessionCookie                                           = "session.cookie"
+	ViperKeySessionNamePrefix                                       = "session.cookie.name_prefix"
+	ViperKeySessionSecure                                           = "session.cookie.secure"
+	ViperKeySessionPartitioned                                      = "session.cookie.partitioned"
+	ViperKeySessionServiceAccountLifespan                           = "session.service_account_lifespan"
+	ViperKeySessionRememberMeEnabled                                = "session.remember_me.enabled"
+	ViperKeySessionRememberMePersistentLifespan                     = "session.remember_me.persistent_lifespan"
+	ViperKeySessionRememberMeSessionLifespan                        = "session.remember_me.session_lifespan"
+	ViperKeyContinuityMaxPayloadSize                                = "continuity.max_payload_size"
+	ViperKeyContinuityMaxLifespan                                   = "continuity.max_lifespan"
+	ViperKeyContinuityCompression                                   = "continuity.compression"
+	ViperKeyContinuityCleanupEnabled                                = "continuity.cleanup.enabled"
+	ViperKeyContinuityCleanupInterval                               = "continuity.cleanup.interval"
+	ViperKeyContinuityCookie                                        = "continuity.cookie"
+	ViperKeyWebhookTimeout                                          = "webhooks.timeout"
+	ViperKeyWebhookMaxRetries                                       = "webhooks.retries.max_retries"
+	ViperKeyWebhookRetryInitialInterval                             = "webhooks.retries.initial_interval"
+	ViperKeyWebhookRetryMaxInterval                                 = "webhooks.retries.max_interval"
+	ViperKeyWebhookCircuitBreakerThreshold                          = "webhooks.circuit_breaker.failure_threshold"
+	ViperKeyWebhookCircuitBreakerOpenDuration                       = "webhooks.circuit_breaker.open_duration"
+	ViperKeyIdempotencyTTL                                          = "idempotency.ttl"
+	ViperKeyAdminApprovalsEnabled                                   = "admin_approvals.enabled"
+	ViperKeyAdminApprovalsLifespan                                  = "admin_approvals.lifespan"
+	ViperKeyMultiRegionEnabled                                      = "multi_region.enabled"
+	ViperKeyMultiRegionName                                         = "multi_region.region"
+	ViperKeyMultiRegionReplicaLagTolerance                          = "multi_region.replica_lag_tolerance"
+	ViperKeyMultiRegionSessionAffinity                              = "multi_region.session_affinity"
+	ViperKeyJobsSchedules                                           = "jobs.schedules"
+	ViperKeyJobsLockTTL                                             = "jobs.lock_ttl"
+	ViperKeyJobsHistoryLifespan                                     = "jobs.history_lifespan"
 	ViperKeySelfServiceStrategyConfig                               = "selfservice.methods"
 	ViperKeySelfServiceBrowserDefaultReturnTo                       = "selfservice." + DefaultBrowserReturnURL
 	ViperKeyURLsWhitelistedReturnToDomains                          = "selfservice.whitelisted_return_urls"
+	ViperKeySelfServiceFlowCallbacksAllowedURLs                     = "selfservice.flow_callbacks.allowed_urls"
+	ViperKeySelfServiceFlowCallbacksRetryLifespan                   = "selfservice.flow_callbacks.retry_lifespan"
+	ViperKeySelfServiceFlowReplayProtectionEnabled                  = "selfservice.flow_replay_protection.enabled"
 	ViperKeySelfServiceRegistrationUI                               = "selfservice.flows.registration.ui_url"
 	ViperKeySelfServiceRegistrationRequestLifespan                  = "selfservice.flows.registration.lifespan"
+	ViperKeySelfServiceRegistrationLifespanBounds                   = "selfservice.flows.registration.lifespan_bounds"
 	ViperKeySelfServiceRegistrationAfter                            = "selfservice.flows.registration.after"
 	ViperKeySelfServiceRegistrationBeforeHooks                      = "selfservice.flows.registration.before.hooks"
 	ViperKeySelfServiceLoginUI                                      = "selfservice.flows.login.ui_url"
 	ViperKeySelfServiceLoginRequestLifespan                         = "selfservice.flows.login.lifespan"
+	ViperKeySelfServiceLoginLifespanBounds                          = "selfservice.flows.login.lifespan_bounds"
 	ViperKeySelfServiceLoginAfter                                   = "selfservice.flows.login.after"
 	ViperKeySelfServiceLoginBeforeHooks                             = "selfservice.flows.login.before.hooks"
 	ViperKeySelfServiceErrorUI                                      = "selfservice.flows.error.ui_url"
+	ViperKeySelfServiceErrorRoutes                                  = "selfservice.flows.error.routes"
 	ViperKeySelfServiceLogoutBrowserDefaultReturnTo                 = "selfservice.flows.logout.after." + DefaultBrowserReturnURL
 	ViperKeySelfServiceSettingsURL                                  = "selfservice.flows.settings.ui_url"
 	ViperKeySelfServiceSettingsAfter                                = "selfservice.flows.settings.after"
 	ViperKeySelfServiceSettingsRequestLifespan                      = "selfservice.flows.settings.lifespan"
+	ViperKeySelfServiceSettingsLifespanBounds                       = "selfservice.flows.settings.lifespan_bounds"
 	ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter        = "selfservice.flows.settings.privileged_session_max_age"
+	ViperKeySelfServiceSettingsPrivilegedMaxAgeMethods              = "selfservice.flows.settings.privileged_session_max_age_per_method"
+	ViperKeySelfServiceSettingsPrivilegedCodeEnabled                = "selfservice.flows.settings.privileged_code.enabled"
+	ViperKeySelfServiceSettingsPrivilegedCodeLifespan               = "selfservice.flows.settings.privileged_code.lifespan"
+	ViperKeySelfServiceSettingsPrivilegedCodeMaxAttempts            = "selfservice.flows.settings.privileged_code.max_attempts"
+	ViperKeySelfServiceSettingsPrivilegedCodeResendInterval         = "selfservice.flows.settings.privileged_code.resend_interval"
+	ViperKeySelfServiceSettingsPrivilegedCodeMaxSends               = "selfservice.flows.settings.privileged_code.max_sends"
 	ViperKeySelfServiceRecoveryEnabled                              = "selfservice.flows.recovery.enabled"
 	ViperKeySelfServiceRecoveryUI                                   = "selfservice.flows.recovery.ui_url"
 	ViperKeySelfServiceRecoveryRequestLifespan                      = "selfservice.flows.recovery.lifespan"
+	ViperKeySelfServiceRecoveryLifespanBounds                       = "selfservice.flows.recovery.lifespan_bounds"
 	ViperKeySelfServiceRecoveryBrowserDefaultReturnTo               = "selfservice.flows.recovery.after." + DefaultBrowserReturnURL
+	ViperKeySelfServiceRecoveryExpiredLinks                         = "selfservice.flows.recovery.expired_links"
 	ViperKeySelfServiceVerificationEnabled                          = "selfservice.flows.verification.enabled"
 	ViperKeySelfServiceVerificationUI                               = "selfservice.flows.verification.ui_url"
 	ViperKeySelfServiceVerificationRequestLifespan                  = "selfservice.flows.verification.lifespan"
+	ViperKeySelfServiceVerificationLifespanBounds                   = "selfservice.flows.verification.lifespan_bounds"
+	ViperKeySelfServiceVerificationResendKeepPreviousLinks          = "selfservice.flows.verification.resend.keep_previous_links"
+	ViperKeySelfServiceVerificationResendGracePeriod                = "selfservice.flows.verification.resend.grace_period"
+	ViperKeySelfServiceVerificationAfterAPISession                  = "selfservice.flows.verification.after.api_session"
 	ViperKeySelfServiceVerificationBrowserDefaultReturnTo           = "selfservice.flows.verification.after." + DefaultBrowserReturnURL
+	ViperKeySelfServiceVerificationExpiredLinks                     = "selfservice.flows.verification.expired_links"
 	ViperKeyDefaultIdentitySchemaURL                                = "identity.default_schema_url"
 	ViperKeyIdentitySchemas                                         = "identity.schemas"
+	ViperKeyIdentityInactivityCheckInterval                         = "identity.inactivity.check_interval"
+	ViperKeyIdentityInactivityPolicies                              = "identity.inactivity.policies"
+	ViperKeyIdentityVerifiableAddressesMergePolicy                  = "identity.verifiable_addresses.merge_policy"
+	ViperKeyIdentityEmailDomains                                    = "identity.email_domains"
+	ViperKeyIdentityReservedNamesWords                              = "identity.reserved_names.words"
+	ViperKeyIdentityReservedNamesPatterns                           = "identity.reserved_names.patterns"
+	ViperKeyIdentityDeletionCourierMessages                         = "identity.deletion.courier_messages"
+	ViperKeyIdentityDeletionAuditLog                                = "identity.deletion.audit_log"
+	ViperKeyIdentityRelationshipsChildRestrictions                  = "identity.relationships.child_restrictions"
+	ViperKeyIdentityRelationshipsSessionAnnotations                 = "identity.relationships.session_annotations"
+	ViperKeyIdentityRelationshipsActOnBehalf                        = "identity.relationships.act_on_behalf"
+	ViperKeyIdentitySchemaExtensions                                = "identity.schema_extensions"
 	ViperKeyHasherAlgorithm                                         = "hashers.algorithm"
 	ViperKeyHasherArgon2ConfigMemory                                = "hashers.argon2.memory"
 	ViperKeyHasherArgon2ConfigIterations                            = "hashers.argon2.iterations"
@@ -99,6 +195,56 @@ const (
 	ViperKeyHasherBcryptCost                                        = "hashers.bcrypt.cost"
 	ViperKeyPasswordMaxBreaches                                     = "selfservice.methods.password.config.max_breaches"
 	ViperKeyIgnoreNetworkErrors                                     = "selfservice.methods.password.config.ignore_network_errors"
+	ViperKeyLinkCodeDigits                                          = "selfservice.methods.link.config.code_digits"
+	ViperKeyLinkCodeInterval                                        = "selfservice.methods.link.config.code_interval"
+	ViperKeyLinkCodeGracePeriods                                    = "selfservice.methods.link.config.code_grace_periods"
+	ViperKeyLinkCodeMaxAttempts                                     = "selfservice.methods.link.config.code_max_attempts"
+	ViperKeyLinkCodeResendInterval                                  = "selfservice.methods.link.config.code_resend_interval"
+	ViperKeyLinkRecoveryCode                                        = "selfservice.methods.link.config.recovery_code"
+	ViperKeyAPIKeyPrefix                                            = "selfservice.methods.api_key.config.prefix"
+	ViperKeyAPIKeyMaxLifespan                                       = "selfservice.methods.api_key.config.max_lifespan"
+	ViperKeyUsernameTrait                                           = "selfservice.methods.username.config.trait"
+	ViperKeyUsernameChangeCooldown                                  = "selfservice.methods.username.config.cooldown"
+	ViperKeyUsernameReservationLifespan                             = "selfservice.methods.username.config.reserve_old_username_for"
+	ViperKeySIWEDomain                                              = "selfservice.methods.siwe.config.domain"
+	ViperKeySIWEChainIDs                                            = "selfservice.methods.siwe.config.chain_ids"
+	ViperKeyRecoveryQuestions                                       = "selfservice.methods.questions.config.questions"
+	ViperKeyRecoveryQuestionsMaxAttempts                            = "selfservice.methods.questions.config.max_attempts"
+	ViperKeyFeatureFlags                                            = "feature_flags.flags"
+	ViperKeyFeatureFlagsRemoteURL                                   = "feature_flags.remote.url"
+	ViperKeyFeatureFlagsRemoteRefreshInterval                       = "feature_flags.remote.refresh_interval"
+	ViperKeyIPReputationClientIPHeader                              = "ip_reputation.client_ip_header"
+	ViperKeyIPReputationTrustedCIDRs                                = "ip_reputation.trusted_cidrs"
+	ViperKeyIPReputationFlaggedCIDRs                                = "ip_reputation.flagged_cidrs"
+	ViperKeyIPReputationAPIURL                                      = "ip_reputation.api.url"
+	ViperKeyIPReputationAPIScorePath                                = "ip_reputation.api.score_path"
+	ViperKeyIPReputationAPIThreshold                                = "ip_reputation.api.threshold"
+	ViperKeyIPReputationAPITimeout                                  = "ip_reputation.api.timeout"
+	ViperKeyIPReputationAPICacheTTL                                 = "ip_reputation.api.cache_ttl"
+	ViperKeyIPReputationCaptchaProvider                             = "ip_reputation.friction.captcha.provider"
+	ViperKeyIPReputationCaptchaSiteKey                              = "ip_reputation.friction.captcha.site_key"
+	ViperKeyIPReputationCaptchaSecretKey                            = "ip_reputation.friction.captcha.secret_key"
+	ViperKeyIPReputationCaptchaVerifyURL                            = "ip_reputation.friction.captcha.verify_url"
+	ViperKeyIPReputationRequireEmailCode                            = "ip_reputation.friction.require_email_code"
+	ViperKeyIPReputationRateLimitMaxSubmissions                     = "ip_reputation.friction.rate_limit.max_submissions"
+	ViperKeyIPReputationRateLimitWindow                             = "ip_reputation.friction.rate_limit.window"
+	ViperKeyGeoPoliciesCountryHeader                                = "geo_policies.country_header"
+	ViperKeyGeoPoliciesAPIURL                                       = "geo_policies.api.url"
+	ViperKeyGeoPoliciesAPICountryPath                               = "geo_policies.api.country_path"
+	ViperKeyGeoPoliciesAPITimeout                                   = "geo_policies.api.timeout"
+	ViperKeyGeoPoliciesAPICacheTTL                                  = "geo_policies.api.cache_ttl"
+	ViperKeyGeoPoliciesRules                                        = "geo_policies.rules"
+	ViperKeyGeoPoliciesCodeLifespan                                 = "geo_policies.code.lifespan"
+	ViperKeyGeoPoliciesCodeMaxAttempts                              = "geo_policies.code.max_attempts"
+	ViperKeyAttemptsEnabled                                         = "attempts.enabled"
+	ViperKeyAttemptsRetention                                       = "attempts.retention"
+	ViperKeyEnumerationRateLimitEnabled                             = "enumeration_protection.rate_limit.enabled"
+	ViperKeyEnumerationRateLimitIPBurst                             = "enumeration_protection.rate_limit.per_ip.burst"
+	ViperKeyEnumerationRateLimitIPInterval                          = "enumeration_protection.rate_limit.per_ip.interval"
+	ViperKeyEnumerationRateLimitIdentifierBurst                     = "enumeration_protection.rate_limit.per_identifier.burst"
+	ViperKeyEnumerationRateLimitIdentifierInterval                  = "enumeration_protection.rate_limit.per_identifier.interval"
+	ViperKeyEnumerationLimitedResponse                              = "enumeration_protection.limited_response"
+	ViperKeyEnumerationMinResponseTime                              = "enumeration_protection.min_response_time"
 	ViperKeyVersion                                                 = "version"
 	Argon2DefaultMemory                                             = 128 * bytesize.MB
 	Argon2DefaultIterations                                  uint32 = 1
@@ -110,9 +256,139 @@ const (
 	BcryptDefaultCost                                        uint32 = 12
 )
 
+const (
+	// MaxSelfServiceFlowLifespan is the longest lifespan a self-service flow can be configured with.
+	MaxSelfServiceFlowLifespan = time.Hour * 24 * 7
+	// MaxPrivilegedSessionMaxAge is the longest privileged session max age that can be configured.
+	MaxPrivilegedSessionMaxAge = time.Hour * 24 * 30
+	// MaxPrivilegedCodeLifespan is the longest lifespan a privileged code can be configured with.
+	MaxPrivilegedCodeLifespan = time.Hour
+)
+
+const (
+	// CSRFModeCookie uses the double-submit cookie pattern.
+	CSRFModeCookie CSRFMode = "cookie"
+	// CSRFModeHeader requires a custom request header.
+	CSRFModeHeader CSRFMode = "header"
+	// CSRFModeOrigin only checks the Origin and Referer headers.
+	CSRFModeOrigin CSRFMode = "origin"
+)
+
+const (
+	// PublicMiddlewareRateLimit limits requests per client IP address.
+	PublicMiddlewareRateLimit PublicMiddlewareName = "rate_limit"
+	// PublicMiddlewareCaptcha requires a valid CAPTCHA response.
+	PublicMiddlewareCaptcha PublicMiddlewareName = "captcha"
+	// PublicMiddlewareIPFilter allows or denies requests by client IP address.
+	PublicMiddlewareIPFilter PublicMiddlewareName = "ip_filter"
+	// PublicMiddlewareHeaders adds headers to responses.
+	PublicMiddlewareHeaders PublicMiddlewareName = "headers"
+)
+
+const (
+	// InactivityActionDeactivate prevents inactive identities from signing in.
+	InactivityActionDeactivate InactivityAction = "deactivate"
+	// InactivityActionDelete deletes inactive identities.
+	InactivityActionDelete InactivityAction = "delete"
+)
+
+const (
+	// VerifiableAddressMergeReplace removes verifiable addresses which no longer appear in the traits.
+	VerifiableAddressMergeReplace VerifiableAddressMergePolicy = "replace"
+	// VerifiableAddressMergeKeep keeps verifiable addresses which no longer appear in the traits unchanged.
+	VerifiableAddressMergeKeep VerifiableAddressMergePolicy = "keep"
+	// VerifiableAddressMergeMarkStale keeps verifiable addresses which no longer appear in the traits but marks
+	// them as stale.
+	VerifiableAddressMergeMarkStale VerifiableAddressMergePolicy = "mark_stale"
+)
+
+const (
+	// DeletionCourierMessagesDelete deletes the courier messages of deleted identities.
+	DeletionCourierMessagesDelete DeletionCourierMessagesPolicy = "delete"
+	// DeletionCourierMessagesAnonymize removes the recipient, body, and template data of the courier messages of
+	// deleted identities but keeps their type, subject, status, and timestamps.
+	DeletionCourierMessagesAnonymize DeletionCourierMessagesPolicy = "anonymize"
+	// DeletionCourierMessagesKeep keeps the courier messages of deleted identities unchanged.
+	DeletionCourierMessagesKeep DeletionCourierMessagesPolicy = "keep"
+)
+
+const (
+	// CourierDegradationQueue queues emails while the SMTP server is failing and tells the user that they may
+	// be delayed.
+	CourierDegradationQueue CourierDegradationPolicy = "queue"
+	// CourierDegradationFailFast does not queue emails while the SMTP server is failing and asks the user to
+	// try again later.
+	CourierDegradationFailFast CourierDegradationPolicy = "fail_fast"
+	// CourierDegradationFallback sends emails using the fallback SMTP server while the SMTP server is failing.
+	CourierDegradationFallback CourierDegradationPolicy = "fallback"
+)
+
+const (
+	// CourierSMSFormatJSON sends SMS to the gateway as a JSON object with the keys `from`, `to`, and `body`.
+	CourierSMSFormatJSON CourierSMSFormat = "json"
+	// CourierSMSFormatForm sends SMS to the gateway as the form fields `From`, `To`, and `Body`, as expected by
+	// Twilio.
+	CourierSMSFormatForm CourierSMSFormat = "form"
+)
+
+const (
+	// DeletionAuditLogAnonymized records the deletion in the audit log without the identity's addresses.
+	DeletionAuditLogAnonymized DeletionAuditLogPolicy = "anonymized"
+	// DeletionAuditLogFull records the deletion in the audit log including the identity's addresses.
+	DeletionAuditLogFull DeletionAuditLogPolicy = "full"
+)
+
+const (
+	// FeatureFlagRolloutKeyIdentityID rolls a feature flag out to a percentage of identities.
+	FeatureFlagRolloutKeyIdentityID FeatureFlagRolloutKey = "identity_id"
+	// FeatureFlagRolloutKeyFlowID rolls a feature flag out to a percentage of self-service flows.
+	FeatureFlagRolloutKeyFlowID FeatureFlagRolloutKey = "flow_id"
+)
+
+const (
+	// GeoPolicyActionBlock rejects the login.
+	GeoPolicyActionBlock GeoPolicyAction = "block"
+	// GeoPolicyActionRequireMFA requires a code sent to a verified email address before the session is issued.
+	GeoPolicyActionRequireMFA GeoPolicyAction = "require_mfa"
+	// GeoPolicyActionNotify lets the login succeed and notifies the identity by email.
+	GeoPolicyActionNotify GeoPolicyAction = "notify"
+)
+
+const (
+	// LimitedResponseReject responds to rate limited requests with HTTP 429.
+	LimitedResponseReject LimitedResponse = "reject"
+	// LimitedResponseDummy responds to rate limited requests as if none of the identifiers were known.
+	LimitedResponseDummy LimitedResponse = "dummy"
+)
+
+const (
+	// ErrorClassFlowExpired matches errors of expired self-service flows.
+	ErrorClassFlowExpired ErrorClass = "flow_expired"
+	// ErrorClassCSRF matches errors of missing or invalid anti-CSRF tokens.
+	ErrorClassCSRF ErrorClass = "csrf"
+	// ErrorClassClientError matches all other errors with a 4xx status code.
+	ErrorClassClientError ErrorClass = "client_error"
+	// ErrorClassServerError matches all errors with a 5xx status code.
+	ErrorClassServerError ErrorClass = "server_error"
+	// ErrorClassAny matches all errors.
+	ErrorClassAny ErrorClass = "any"
+)
+
+const (
+	// ErrorRouteClientBrowser matches requests which do not accept JSON.
+	ErrorRouteClientBrowser ErrorRouteClient = "browser"
+	// ErrorRouteClientAPI matches requests which accept JSON.
+	ErrorRouteClientAPI ErrorRouteClient = "api"
+	// ErrorRouteClientAny matches all requests.
+	ErrorRouteClientAny ErrorRouteClient = "any"
+)
+
 // DefaultSessionCookieName returns the default cookie name for the kratos session.
 const DefaultSessionCookieName = "ory_kratos_session"
 
+// DefaultContinuityCookieName returns the default cookie name for continuity containers.
+const DefaultContinuityCookieName = "ory_kratos_continuity"
+
 type (
 	Argon2 struct {
 		Memory            bytesize.ByteSize `json:"memory"`
@@ -130,23 +406,237 @@ type (
 	SelfServiceHook struct {
 		Name   string          `json:"hook"`
 		Config json.RawMessage `json:"config"`
+
+		// FeatureFlag is the name of the feature flag which must be enabled for the hook to run.
+		FeatureFlag string `json:"feature_flag"`
 	}
 	SelfServiceStrategy struct {
 		Enabled bool            `json:"enabled"`
 		Config  json.RawMessage `json:"config"`
+
+		// FeatureFlag is the name of the feature flag which must be enabled for the strategy to be offered.
+		FeatureFlag string `json:"feature_flag"`
 	}
 	Schema struct {
 		ID  string `json:"id"`
 		URL string `json:"url"`
+
+		// ServiceAccount marks identities using this schema as machine identities.
+		ServiceAccount bool `json:"service_account"`
+
+		// EmailDomains overrides the email domain policy for identities using this schema.
+		EmailDomains *EmailDomainPolicy `json:"email_domains"`
+
+		// RememberMe restricts `session.remember_me` for identities using this schema.
+		RememberMe *SchemaRememberMe `json:"remember_me"`
+	}
+	SchemaRememberMe struct {
+		Enabled            *bool  `json:"enabled"`
+		PersistentLifespan string `json:"persistent_lifespan"`
+		SessionLifespan    string `json:"session_lifespan"`
+	}
+	// RememberMePolicy defines the lifespans of sessions issued by login flows depending on whether the identity
+	// checked `remember`.
+	RememberMePolicy struct {
+		// Enabled is false if identities can not choose a persistent session.
+		Enabled            bool
+		PersistentLifespan time.Duration
+		SessionLifespan    time.Duration
+	}
+	// EmailDomainPolicy restricts the domains of the email addresses identities register and update their
+	// traits with.
+	EmailDomainPolicy struct {
+		Allow          []string `json:"allow"`
+		Deny           []string `json:"deny"`
+		DenyDisposable bool     `json:"deny_disposable"`
+		RequireMX      bool     `json:"require_mx"`
+	}
+	CSRFRouteGroup struct {
+		PathPrefix string   `json:"path_prefix"`
+		Mode       CSRFMode `json:"mode"`
+	}
+	CSRFMode string
+	// PublicMiddleware is a middleware of the public router's chain. It applies to all routes starting with one of
+	// the path prefixes or to all routes if there are none.
+	PublicMiddleware struct {
+		Name         PublicMiddlewareName `json:"name"`
+		PathPrefixes []string             `json:"path_prefixes"`
+		Config       json.RawMessage      `json:"config"`
+	}
+	PublicMiddlewareName string
+	// RecoveryQuestion is a question identities answer to recover their account using the questions method.
+	RecoveryQuestion struct {
+		ID   string `json:"id"`
+		Text string `json:"text"`
+	}
+	// InactivityPolicy deactivates or deletes identities which did not sign in for the duration of After.
+	InactivityPolicy struct {
+		ID               string
+		Action           InactivityAction
+		After            time.Duration
+		NotifyBefore     time.Duration
+		ExcludeSchemaIDs []string
+	}
+	InactivityAction string
+	// SchemaExtension is an identity schema extension which evaluates the Jsonnet code at URL.
+	SchemaExtension struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	// ExpiredLinksConfig decides how links which expired without being used are reported. Nothing is
+	// reported if WebHookURL is nil and SendEmail is false.
+	ExpiredLinksConfig struct {
+		WebHookURL *url.URL
+		SendEmail  bool
+		Lookback   time.Duration
+	}
+	// LifespanBounds limits the lifespan clients can request when they initialize a self-service flow. Clients
+	// can not request a lifespan if Max is zero.
+	LifespanBounds struct {
+		Min time.Duration
+		Max time.Duration
+	}
+	// VerifiableAddressMergePolicy decides what happens to an identity's verifiable addresses which no longer
+	// appear in its traits.
+	VerifiableAddressMergePolicy string
+	// DeletionCourierMessagesPolicy decides what happens to the courier messages of identities which are deleted.
+	DeletionCourierMessagesPolicy string
+	// CourierDegradationPolicy decides what happens to emails self-service flows send while the SMTP server is
+	// failing.
+	CourierDegradationPolicy string
+	// CourierSMSFormat decides how SMS are encoded in requests to the SMS gateway.
+	CourierSMSFormat string
+	// CourierSMSRequestConfig configures the requests to the SMS gateway.
+	CourierSMSRequestConfig struct {
+		URL     *url.URL
+		Format  CourierSMSFormat
+		Headers map[string]string
+	}
+	// DeletionAuditLogPolicy decides how the deletion of an identity is recorded in the audit log.
+	DeletionAuditLogPolicy string
+	// ActOnBehalfPolicy decides whether identities with a certain type of relationship may obtain sessions acting
+	// on behalf of the related identity.
+	ActOnBehalfPolicy struct {
+		Enabled  bool
+		Lifespan time.Duration
+	}
+	// CookieConfig holds the attributes of one type of cookie. Empty values fall back to the defaults of
+	// the respective cookie.
+	CookieConfig struct {
+		NamePrefix  string
+		Domain      string
+		Path        string
+		SameSite    http.SameSite
+		Secure      bool
+		Partitioned bool
+	}
+	// ServerTimeouts configures the timeouts of the public or admin HTTP server. Zero values fall back
+	// to the server's defaults.
+	ServerTimeouts struct {
+		Read       time.Duration
+		ReadHeader time.Duration
+		Write      time.Duration
+		Idle       time.Duration
+	}
+	// Compression configures response compression of the public or admin HTTP server.
+	Compression struct {
+		Enabled bool
+		MinSize int
+	}
+	// HTTP2 tunes HTTP/2 connections of the public or admin HTTP server.
+	HTTP2 struct {
+		MaxConcurrentStreams uint32
+		MaxReadFrameSize     uint32
+		// Cleartext accepts HTTP/2 without TLS (h2c), for example from a reverse proxy terminating TLS.
+		Cleartext bool
+	}
+	// FeatureFlag enables a feature for RolloutPercentage percent of the identities or flows, depending
+	// on RolloutKey. Which bucket an identity or flow falls into only depends on its ID and the flag's name.
+	FeatureFlag struct {
+		Name              string                `json:"name"`
+		Enabled           bool                  `json:"enabled"`
+		RolloutPercentage int                   `json:"rollout_percentage"`
+		RolloutKey        FeatureFlagRolloutKey `json:"rollout_key"`
+	}
+	// FeatureFlagRolloutKey decides whether a feature flag is rolled out on identities or flows.
+	FeatureFlagRolloutKey string
+	// IPReputationAPI scores IP addresses using an HTTP endpoint. Addresses scored at or above the
+	// threshold are flagged.
+	IPReputationAPI struct {
+		URL       *url.URL
+		ScorePath string
+		Threshold float64
+		Timeout   time.Duration
+		CacheTTL  time.Duration
+	}
+	// GeoAPI resolves the country of IP addresses using an HTTP endpoint.
+	GeoAPI struct {
+		URL         *url.URL
+		CountryPath string
+		Timeout     time.Duration
+		CacheTTL    time.Duration
+	}
+	// GeoPolicyRule applies its action to logins from one of the countries or, if CountryChanged is set, to
+	// logins from a different country than the identity's last login.
+	GeoPolicyRule struct {
+		Countries      []string        `json:"countries"`
+		CountryChanged bool            `json:"country_changed"`
+		Action         GeoPolicyAction `json:"action"`
+	}
+	// GeoPolicyAction decides what happens to a login a geo policy rule applies to.
+	GeoPolicyAction string
+	// RateLimit is a token bucket which holds up to Burst tokens and gains one token per Interval.
+	RateLimit struct {
+		Burst    int
+		Interval time.Duration
+	}
+	// LimitedResponse decides how rate limited requests to enumeration protected endpoints are answered.
+	LimitedResponse string
+	// ErrorRoute redirects browsers to RedirectTo or responds to API clients with Body when a self-service flow
+	// fails with an error of the class.
+	ErrorRoute struct {
+		Error      ErrorClass       `json:"error"`
+		Client     ErrorRouteClient `json:"client"`
+		RedirectTo string           `json:"redirect_to"`
+		Body       json.RawMessage  `json:"body"`
+	}
+	// ErrorClass groups errors for error routes.
+	ErrorClass string
+	// ErrorRouteClient is the type of client an error route applies to.
+	ErrorRouteClient string
+	// Captcha configures the CAPTCHA flagged IP addresses have to solve.
+	Captcha struct {
+		Provider  string
+		SiteKey   string
+		SecretKey string
+		VerifyURL *url.URL
 	}
 	PasswordPolicy struct {
 		MaxBreaches         uint `json:"max_breaches"`
 		IgnoreNetworkErrors bool `json:"ignore_network_errors"`
 	}
+	// LinkCodeConfig configures the codes the link method sends by SMS or, for recovery, by email.
+	LinkCodeConfig struct {
+		Digits int
+		// Interval is zero if codes are valid for as long as their flow.
+		Interval     time.Duration
+		GracePeriods int
+		MaxAttempts  int
+		// ResendInterval is the minimum time between two verification messages or recovery codes sent to the same address.
+		ResendInterval time.Duration
+	}
 	Schemas []Schema
 	Config  struct {
 		l *logrusx.Logger
 		p *configx.Provider
+
+		// csrfRouteGroups caches the CSRF route groups, which are looked up on every public request, until the
+		// configuration is reloaded or changed.
+		csrfRouteGroups struct {
+			sync.Mutex
+			source *koanf.Koanf
+			groups []CSRFRouteGroup
+		}
 	}
 
 	Provider interface {
@@ -194,7 +684,13 @@ func (s Schemas) FindSchemaByID(id string) (*Schema, error) {
 	return nil, errors.Errorf("could not find schema with id \"%s\"", id)
 }
 
-func MustNew(t *testing.T, l *logrusx.Logger, opts ...configx.OptionModifier) *Config {
+// IsServiceAccountSchema returns true if the identity schema with the given ID is used for service accounts.
+func (s Schemas) IsServiceAccountSchema(id string) bool {
+	sc, err := s.FindSchemaByID(id)
+	return err == nil && sc.ServiceAccount
+}
+
+func MustNew(t testing.TB, l *logrusx.Logger, opts ...configx.OptionModifier) *Config {
 	p, err := New(context.TODO(), l, opts...)
 	require.NoError(t, err)
 	return p
@@ -203,7 +699,7 @@ func MustNew(t *testing.T, l *logrusx.Logger, opts ...configx.OptionModifier) *C
 func New(ctx context.Context, l *logrusx.Logger, opts ...configx.OptionModifier) (*Config, error) {
 	opts = append([]configx.OptionModifier{
 		configx.WithStderrValidationReporter(),
-		configx.OmitKeysFromTracing("dsn", "secrets.default", "secrets.cookie", "client_secret"),
+		configx.OmitKeysFromTracing("dsn", "secrets.default", "secrets.cookie", "secrets.webhook", "secrets.prefill", "client_secret"),
 		configx.WithImmutables("serve", "profiling", "log"),
 		configx.WithLogrusWatcher(l),
 		configx.WithLogger(l),
@@ -258,7 +754,7 @@ func (p *Config) SessionDomain() string {
 }
 
 func (p *Config) SessionName() string {
-	return stringsx.Coalesce(p.p.String(ViperKeySessionName), DefaultSessionCookieName)
+	return p.p.String(ViperKeySessionNamePrefix) + stringsx.Coalesce(p.p.String(ViperKeySessionName), DefaultSessionCookieName)
 }
 
 func (p *Config) SessionPath() string {
@@ -291,6 +787,62 @@ func (p *Config) HasherBcrypt() *Bcrypt {
 	return &Bcrypt{Cost: cost}
 }
 
+func (p *Config) serverKey(iface string) string {
+	switch iface {
+	case "admin", "public":
+		return "serve." + iface
+	default:
+		panic(fmt.Sprintf("Received unexpected server interface: %s", iface))
+	}
+}
+
+func (p *Config) ServerTimeouts(iface string) ServerTimeouts {
+	key := p.serverKey(iface) + ".timeouts"
+	return ServerTimeouts{
+		Read:       p.p.DurationF(key+".read", 0),
+		ReadHeader: p.p.DurationF(key+".read_header", 0),
+		Write:      p.p.DurationF(key+".write", 0),
+		Idle:       p.p.DurationF(key+".idle", 0),
+	}
+}
+
+// Compression returns the response compression settings of the public or admin server. Compression is only
+// enabled by default on the admin server, because compressing responses which carry tokens next to reflected
+// input makes them vulnerable to BREACH.
+func (p *Config) Compression(iface string) Compression {
+	key := p.serverKey(iface) + ".compression"
+	return Compression{
+		Enabled: p.p.BoolF(key+".enabled", iface == "admin"),
+		MinSize: p.p.IntF(key+".min_size", 1024),
+	}
+}
+
+func (p *Config) HTTP2(iface string) HTTP2 {
+	key := p.serverKey(iface) + ".http2"
+	return HTTP2{
+		MaxConcurrentStreams: uint32(p.p.IntF(key+".max_concurrent_streams", 250)),
+		MaxReadFrameSize:     uint32(p.p.IntF(key+".max_read_frame_size", 1<<20)),
+		Cleartext:            p.p.Bool(key + ".cleartext"),
+	}
+}
+
+// MaxHeaderBytes returns the maximum size of the request headers accepted by the public or admin server.
+func (p *Config) MaxHeaderBytes(iface string) int {
+	return p.p.IntF(p.serverKey(iface)+".request_limits.max_header_bytes", http.DefaultMaxHeaderBytes)
+}
+
+// MaxBodyBytes returns the maximum size of request bodies accepted by the public or admin server. Zero
+// disables the limit.
+func (p *Config) MaxBodyBytes(iface string) int64 {
+	return int64(p.p.IntF(p.serverKey(iface)+".request_limits.max_body_bytes", 10*1024*1024))
+}
+
+// MaxJSONDepth returns how deep JSON request bodies accepted by the public or admin server may be nested.
+// Zero disables the limit.
+func (p *Config) MaxJSONDepth(iface string) int {
+	return p.p.IntF(p.serverKey(iface)+".request_limits.max_json_depth", 64)
+}
+
 func (p *Config) listenOn(key string) string {
 	fb := 4433
 	if key == "admin" {
@@ -339,6 +891,460 @@ func (p *Config) IdentityTraitsSchemas() Schemas {
 	return append(ss, ds)
 }
 
+// IdentityEmailDomainPolicy returns the email domain policy of the identity schema with the given ID, which falls
+// back to the policy at `identity.email_domains`.
+func (p *Config) IdentityEmailDomainPolicy(schemaID string) *EmailDomainPolicy {
+	if s, err := p.IdentityTraitsSchemas().FindSchemaByID(schemaID); err == nil && s.EmailDomains != nil {
+		return s.EmailDomains
+	}
+
+	return &EmailDomainPolicy{
+		Allow:          p.p.Strings(ViperKeyIdentityEmailDomains + ".allow"),
+		Deny:           p.p.Strings(ViperKeyIdentityEmailDomains + ".deny"),
+		DenyDisposable: p.p.Bool(ViperKeyIdentityEmailDomains + ".deny_disposable"),
+		RequireMX:      p.p.Bool(ViperKeyIdentityEmailDomains + ".require_mx"),
+	}
+}
+
+// IdentityReservedNameWords returns the words usernames and display names must not equal.
+func (p *Config) IdentityReservedNameWords() []string {
+	return p.p.Strings(ViperKeyIdentityReservedNamesWords)
+}
+
+// IdentityReservedNamePatterns returns the regular expressions usernames and display names must not match.
+func (p *Config) IdentityReservedNamePatterns() []string {
+	return p.p.Strings(ViperKeyIdentityReservedNamesPatterns)
+}
+
+// IsEmpty returns true if the policy accepts all email addresses.
+func (p *EmailDomainPolicy) IsEmpty() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0 && !p.DenyDisposable && !p.RequireMX
+}
+
+// PublicMiddleware returns the middleware chain of the public router in the order the middlewares run in.
+func (p *Config) PublicMiddleware() []PublicMiddleware {
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warn("Unable to marshal public middleware configuration.")
+		return nil
+	}
+
+	config := gjson.GetBytes(out, ViperKeyPublicMiddleware).Raw
+	if len(config) == 0 {
+		return nil
+	}
+
+	var chain []PublicMiddleware
+	if err := json.NewDecoder(bytes.NewBufferString(config)).Decode(&chain); err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeyPublicMiddleware)
+		return nil
+	}
+
+	return chain
+}
+
+// CSRFRouteGroups returns the configured CSRF route groups. They are parsed once per loaded configuration
+// because the configuration is replaced as a whole whenever it is reloaded or changed.
+func (p *Config) CSRFRouteGroups() []CSRFRouteGroup {
+	p.csrfRouteGroups.Lock()
+	defer p.csrfRouteGroups.Unlock()
+
+	if source := p.p.Koanf; p.csrfRouteGroups.source != source {
+		p.csrfRouteGroups.groups = p.parseCSRFRouteGroups(source)
+		p.csrfRouteGroups.source = source
+	}
+	return p.csrfRouteGroups.groups
+}
+
+func (p *Config) parseCSRFRouteGroups(source *koanf.Koanf) []CSRFRouteGroup {
+	out, err := source.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warn("Unable to marshal CSRF route group configuration.")
+		return nil
+	}
+
+	config := gjson.GetBytes(out, ViperKeyPublicCSRFRouteGroups).Raw
+	if len(config) == 0 {
+		return nil
+	}
+
+	var groups []CSRFRouteGroup
+	if err := json.NewDecoder(bytes.NewBufferString(config)).Decode(&groups); err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeyPublicCSRFRouteGroups)
+		return nil
+	}
+
+	return groups
+}
+
+// IdentityInactivityPolicies returns the configured inactivity policies. Policies with invalid durations
+// are skipped.
+func (p *Config) IdentityInactivityPolicies() []InactivityPolicy {
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warn("Unable to marshal identity inactivity policies.")
+		return nil
+	}
+
+	config := gjson.GetBytes(out, ViperKeyIdentityInactivityPolicies).Raw
+	if len(config) == 0 {
+		return nil
+	}
+
+	var raw []struct {
+		ID               string           `json:"id"`
+		Action           InactivityAction `json:"action"`
+		After            string           `json:"after"`
+		NotifyBefore     string           `json:"notify_before"`
+		ExcludeSchemaIDs []string         `json:"exclude_schema_ids"`
+	}
+	if err := json.NewDecoder(bytes.NewBufferString(config)).Decode(&raw); err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeyIdentityInactivityPolicies)
+		return nil
+	}
+
+	policies := make([]InactivityPolicy, 0, len(raw))
+	for _, r := range raw {
+		policy := InactivityPolicy{ID: r.ID, Action: r.Action, ExcludeSchemaIDs: r.ExcludeSchemaIDs}
+		if policy.After, err = time.ParseDuration(r.After); err != nil {
+			p.l.WithError(err).Warnf("Skipping identity inactivity policy %s because its duration is invalid.", r.ID)
+			continue
+		}
+
+		if len(r.NotifyBefore) > 0 {
+			if policy.NotifyBefore, err = time.ParseDuration(r.NotifyBefore); err != nil || policy.NotifyBefore >= policy.After {
+				p.l.WithError(err).Warnf("Skipping identity inactivity policy %s because its advance warning is invalid or not shorter than its inactivity period.", r.ID)
+				continue
+			}
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies
+}
+
+func (p *Config) IdentityInactivityCheckInterval() time.Duration {
+	return p.p.DurationF(ViperKeyIdentityInactivityCheckInterval, time.Hour)
+}
+
+// IdentitySchemaExtensions returns the configured Jsonnet schema extensions.
+func (p *Config) IdentitySchemaExtensions() []SchemaExtension {
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warn("Unable to marshal identity schema extensions.")
+		return nil
+	}
+
+	config := gjson.GetBytes(out, ViperKeyIdentitySchemaExtensions).Raw
+	if len(config) == 0 {
+		return nil
+	}
+
+	var extensions []SchemaExtension
+	if err := json.NewDecoder(bytes.NewBufferString(config)).Decode(&extensions); err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeyIdentitySchemaExtensions)
+		return nil
+	}
+
+	return extensions
+}
+
+// FeatureFlags returns the feature flags configured at `feature_flags.flags`.
+func (p *Config) FeatureFlags() []FeatureFlag {
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warn("Unable to marshal feature flags.")
+		return nil
+	}
+
+	config := gjson.GetBytes(out, ViperKeyFeatureFlags).Raw
+	if len(config) == 0 {
+		return nil
+	}
+
+	flags, err := ParseFeatureFlags([]byte(config))
+	if err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeyFeatureFlags)
+		return nil
+	}
+
+	return flags
+}
+
+// ParseFeatureFlags decodes a JSON array of feature flags. Flags are enabled and rolled out to all
+// identities unless configured otherwise.
+func ParseFeatureFlags(raw []byte) ([]FeatureFlag, error) {
+	var decoded []struct {
+		FeatureFlag
+		Enabled           *bool `json:"enabled"`
+		RolloutPercentage *int  `json:"rollout_percentage"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	flags := make([]FeatureFlag, len(decoded))
+	for k, d := range decoded {
+		flags[k] = d.FeatureFlag
+		flags[k].Enabled = d.Enabled == nil || *d.Enabled
+		flags[k].RolloutPercentage = 100
+		if d.RolloutPercentage != nil {
+			flags[k].RolloutPercentage = *d.RolloutPercentage
+		}
+		if flags[k].RolloutKey != FeatureFlagRolloutKeyFlowID {
+			flags[k].RolloutKey = FeatureFlagRolloutKeyIdentityID
+		}
+	}
+
+	return flags, nil
+}
+
+// FeatureFlagsRemoteURL returns the URL feature flags are fetched from or nil if only the configured
+// flags are used.
+func (p *Config) FeatureFlagsRemoteURL() *url.URL {
+	if p.p.String(ViperKeyFeatureFlagsRemoteURL) == "" {
+		return nil
+	}
+	return p.ParseURIOrFail(ViperKeyFeatureFlagsRemoteURL)
+}
+
+func (p *Config) FeatureFlagsRemoteRefreshInterval() time.Duration {
+	return p.p.DurationF(ViperKeyFeatureFlagsRemoteRefreshInterval, time.Minute)
+}
+
+// IPReputationClientIPHeader returns the HTTP header containing the client's IP address or an empty string if
+// the address of the connection is used.
+func (p *Config) IPReputationClientIPHeader() string {
+	return p.p.String(ViperKeyIPReputationClientIPHeader)
+}
+
+func (p *Config) IPReputationTrustedCIDRs() []*net.IPNet {
+	return p.parseCIDRs(ViperKeyIPReputationTrustedCIDRs)
+}
+
+func (p *Config) IPReputationFlaggedCIDRs() []*net.IPNet {
+	return p.parseCIDRs(ViperKeyIPReputationFlaggedCIDRs)
+}
+
+func (p *Config) parseCIDRs(key string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range p.p.Strings(key) {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			p.l.WithError(err).Warnf("Ignoring invalid CIDR range %q at %s.", cidr, key)
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// IPReputationAPI returns the reputation API or nil if none is configured.
+func (p *Config) IPReputationAPI() *IPReputationAPI {
+	if p.p.String(ViperKeyIPReputationAPIURL) == "" {
+		return nil
+	}
+
+	return &IPReputationAPI{
+		URL:       p.ParseURIOrFail(ViperKeyIPReputationAPIURL),
+		ScorePath: p.p.StringF(ViperKeyIPReputationAPIScorePath, "score"),
+		Threshold: p.p.Float64F(ViperKeyIPReputationAPIThreshold, 50),
+		Timeout:   p.p.DurationF(ViperKeyIPReputationAPITimeout, 2*time.Second),
+		CacheTTL:  p.p.DurationF(ViperKeyIPReputationAPICacheTTL, 10*time.Minute),
+	}
+}
+
+// IPReputationCaptcha returns the CAPTCHA flagged IP addresses have to solve or nil if none is configured.
+func (p *Config) IPReputationCaptcha() *Captcha {
+	c := &Captcha{
+		Provider:  p.p.String(ViperKeyIPReputationCaptchaProvider),
+		SiteKey:   p.p.String(ViperKeyIPReputationCaptchaSiteKey),
+		SecretKey: p.p.String(ViperKeyIPReputationCaptchaSecretKey),
+	}
+	if c.Provider == "" || c.SiteKey == "" || c.SecretKey == "" {
+		return nil
+	}
+
+	if p.p.String(ViperKeyIPReputationCaptchaVerifyURL) != "" {
+		c.VerifyURL = p.ParseURIOrFail(ViperKeyIPReputationCaptchaVerifyURL)
+	}
+	return c
+}
+
+func (p *Config) IPReputationRequireEmailCode() bool {
+	return p.p.Bool(ViperKeyIPReputationRequireEmailCode)
+}
+
+// IPReputationRateLimit returns how many submissions a flagged IP address may make per window. A maximum of
+// zero disables the limit.
+func (p *Config) IPReputationRateLimit() (max int, window time.Duration) {
+	return p.p.IntF(ViperKeyIPReputationRateLimitMaxSubmissions, 0), p.p.DurationF(ViperKeyIPReputationRateLimitWindow, time.Hour)
+}
+
+// GeoPoliciesCountryHeader returns the HTTP header containing the client's ISO 3166-1 alpha-2 country code or an
+// empty string if the country is resolved using the GeoIP API.
+func (p *Config) GeoPoliciesCountryHeader() string {
+	return p.p.String(ViperKeyGeoPoliciesCountryHeader)
+}
+
+// GeoPoliciesAPI returns the GeoIP API or nil if none is configured.
+func (p *Config) GeoPoliciesAPI() *GeoAPI {
+	if p.p.String(ViperKeyGeoPoliciesAPIURL) == "" {
+		return nil
+	}
+
+	return &GeoAPI{
+		URL:         p.ParseURIOrFail(ViperKeyGeoPoliciesAPIURL),
+		CountryPath: p.p.StringF(ViperKeyGeoPoliciesAPICountryPath, "country_code"),
+		Timeout:     p.p.DurationF(ViperKeyGeoPoliciesAPITimeout, 2*time.Second),
+		CacheTTL:    p.p.DurationF(ViperKeyGeoPoliciesAPICacheTTL, time.Hour),
+	}
+}
+
+// GeoPoliciesRules returns the geo policy rules. Country codes are upper cased and rules with an unknown action
+// are ignored.
+func (p *Config) GeoPoliciesRules() []GeoPolicyRule {
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warn("Unable to marshal geo policy rules.")
+		return nil
+	}
+
+	config := gjson.GetBytes(out, ViperKeyGeoPoliciesRules).Raw
+	if len(config) == 0 {
+		return nil
+	}
+
+	var rules []GeoPolicyRule
+	if err := json.Unmarshal([]byte(config), &rules); err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeyGeoPoliciesRules)
+		return nil
+	}
+
+	valid := make([]GeoPolicyRule, 0, len(rules))
+	for _, rule := range rules {
+		switch rule.Action {
+		case GeoPolicyActionBlock, GeoPolicyActionRequireMFA, GeoPolicyActionNotify:
+		default:
+			p.l.Warnf("Ignoring geo policy rule with unknown action %q.", rule.Action)
+			continue
+		}
+
+		for k, country := range rule.Countries {
+			rule.Countries[k] = strings.ToUpper(country)
+		}
+		valid = append(valid, rule)
+	}
+	return valid
+}
+
+// GeoPoliciesCode returns how long the code sent to confirm a login is valid and how often it may be guessed.
+func (p *Config) GeoPoliciesCode() (lifespan time.Duration, maxAttempts int) {
+	return p.p.DurationF(ViperKeyGeoPoliciesCodeLifespan, 15*time.Minute), p.p.IntF(ViperKeyGeoPoliciesCodeMaxAttempts, 5)
+}
+
+func (p *Config) AttemptsEnabled() bool {
+	return p.p.BoolF(ViperKeyAttemptsEnabled, true)
+}
+
+// AttemptsRetention returns for how long login and registration attempts are kept.
+func (p *Config) AttemptsRetention() time.Duration {
+	return p.p.DurationF(ViperKeyAttemptsRetention, time.Hour*24*30)
+}
+
+// EnumerationProtectionRateLimits returns the token buckets of client IP addresses and identifiers or nil if
+// requests to enumeration protected endpoints are not rate limited.
+func (p *Config) EnumerationProtectionRateLimits() (perIP, perIdentifier *RateLimit) {
+	if !p.p.Bool(ViperKeyEnumerationRateLimitEnabled) {
+		return nil, nil
+	}
+
+	return &RateLimit{
+		Burst:    p.p.IntF(ViperKeyEnumerationRateLimitIPBurst, 60),
+		Interval: p.p.DurationF(ViperKeyEnumerationRateLimitIPInterval, time.Second),
+	}, &RateLimit{
+		Burst:    p.p.IntF(ViperKeyEnumerationRateLimitIdentifierBurst, 10),
+		Interval: p.p.DurationF(ViperKeyEnumerationRateLimitIdentifierInterval, time.Minute),
+	}
+}
+
+func (p *Config) EnumerationProtectionLimitedResponse() LimitedResponse {
+	switch r := LimitedResponse(p.p.StringF(ViperKeyEnumerationLimitedResponse, string(LimitedResponseReject))); r {
+	case LimitedResponseDummy:
+		return r
+	default:
+		return LimitedResponseReject
+	}
+}
+
+// EnumerationProtectionMinResponseTime returns how long responses of enumeration protected endpoints take at least.
+func (p *Config) EnumerationProtectionMinResponseTime() time.Duration {
+	return p.p.DurationF(ViperKeyEnumerationMinResponseTime, 0)
+}
+
+func (p *Config) IdentityVerifiableAddressMergePolicy() VerifiableAddressMergePolicy {
+	switch policy := VerifiableAddressMergePolicy(p.p.StringF(ViperKeyIdentityVerifiableAddressesMergePolicy, string(VerifiableAddressMergeReplace))); policy {
+	case VerifiableAddressMergeKeep, VerifiableAddressMergeMarkStale:
+		return policy
+	}
+	return VerifiableAddressMergeReplace
+}
+
+func (p *Config) IdentityDeletionCourierMessages() DeletionCourierMessagesPolicy {
+	switch policy := DeletionCourierMessagesPolicy(p.p.StringF(ViperKeyIdentityDeletionCourierMessages, string(DeletionCourierMessagesDelete))); policy {
+	case DeletionCourierMessagesAnonymize, DeletionCourierMessagesKeep:
+		return policy
+	}
+	return DeletionCourierMessagesDelete
+}
+
+func (p *Config) IdentityDeletionAuditLog() DeletionAuditLogPolicy {
+	if DeletionAuditLogPolicy(p.p.String(ViperKeyIdentityDeletionAuditLog)) == DeletionAuditLogFull {
+		return DeletionAuditLogFull
+	}
+	return DeletionAuditLogAnonymized
+}
+
+// IdentityRelationshipsChildRestrictions returns the self-service flows and methods identities with a guardian
+// can not use, for example `settings` or `recovery.link`.
+func (p *Config) IdentityRelationshipsChildRestrictions() []string {
+	return p.p.StringsF(ViperKeyIdentityRelationshipsChildRestrictions, []string{})
+}
+
+func (p *Config) IdentityRelationshipsSessionAnnotations() bool {
+	return p.p.Bool(ViperKeyIdentityRelationshipsSessionAnnotations)
+}
+
+// IdentityRelationshipsActOnBehalf returns whether identities with a relationship of the given type may obtain
+// sessions acting on behalf of the related identity, and how long these sessions are active.
+func (p *Config) IdentityRelationshipsActOnBehalf(relationshipType string) *ActOnBehalfPolicy {
+	key := ViperKeyIdentityRelationshipsActOnBehalf + "." + relationshipType
+	return &ActOnBehalfPolicy{
+		Enabled:  p.p.Bool(key + ".enabled"),
+		Lifespan: p.p.DurationF(key+".lifespan", time.Hour),
+	}
+}
+
+// CSRFMode returns the CSRF protection mode of the route group with the longest path prefix matching the path.
+func (p *Config) CSRFMode(path string) CSRFMode {
+	mode, longest := CSRFModeCookie, -1
+	for _, g := range p.CSRFRouteGroups() {
+		if strings.HasPrefix(path, g.PathPrefix) && len(g.PathPrefix) > longest {
+			mode, longest = g.Mode, len(g.PathPrefix)
+		}
+	}
+	return mode
+}
+
+func (p *Config) CSRFHeaderName() string {
+	return p.p.StringF(ViperKeyPublicCSRFHeaderName, "X-Kratos-CSRF")
+}
+
+func (p *Config) CSRFAllowedOrigins() []string {
+	return p.p.Strings(ViperKeyPublicCSRFAllowedOrigins)
+}
+
 func (p *Config) AdminListenOn() string {
 	return p.listenOn("admin")
 }
@@ -370,6 +1376,18 @@ func (p *Config) DisableAPIFlowEnforcement() bool {
 	return false
 }
 
+// DevFaultInjection returns the faults configured in "DEV_FAULT_INJECTION" as raw JSON, but only if the "--dev" flag
+// is set.
+func (p *Config) DevFaultInjection() []byte {
+	raw := os.Getenv("DEV_FAULT_INJECTION")
+	if raw == "" || !p.IsInsecureDevMode() {
+		return nil
+	}
+
+	p.l.Warn("Because \"DEV_FAULT_INJECTION\" and the \"--dev\" flag are set, persister and courier calls will be delayed or fail on purpose. This option should only be used for testing error handling and never come close to real user data anywhere.")
+	return []byte(raw)
+}
+
 func (p *Config) SelfServiceFlowVerificationEnabled() bool {
 	return p.p.Bool(ViperKeySelfServiceVerificationEnabled)
 }
@@ -439,8 +1457,9 @@ func (p *Config) SelfServiceStrategy(strategy string) *SelfServiceStrategy {
 
 	enabledKey := fmt.Sprintf("%s.%s.enabled", ViperKeySelfServiceStrategyConfig, strategy)
 	s := &SelfServiceStrategy{
-		Enabled: p.p.Bool(enabledKey),
-		Config:  json.RawMessage(config),
+		Enabled:     p.p.Bool(enabledKey),
+		Config:      json.RawMessage(config),
+		FeatureFlag: p.p.String(fmt.Sprintf("%s.%s.feature_flag", ViperKeySelfServiceStrategyConfig, strategy)),
 	}
 
 	// The default value can easily be overwritten by setting e.g. `{"selfservice": "null"}` which means that
@@ -460,37 +1479,187 @@ func (p *Config) SelfServiceStrategy(strategy string) *SelfServiceStrategy {
 		s.Config = json.RawMessage("{}")
 	}
 
-	return s
+	return s
+}
+
+func (p *Config) SecretsDefault() [][]byte {
+	secrets := p.p.Strings(ViperKeySecretsDefault)
+
+	if len(secrets) == 0 {
+		secrets = []string{uuid.New().String()}
+		p.MustSet(ViperKeySecretsDefault, secrets)
+	}
+
+	result := make([][]byte, len(secrets))
+	for k, v := range secrets {
+		result[k] = []byte(v)
+	}
+
+	return result
+}
+
+func (p *Config) SecretsSession() [][]byte {
+	secrets := p.p.Strings(ViperKeySecretsCookie)
+	if len(secrets) == 0 {
+		return p.SecretsDefault()
+	}
+
+	result := make([][]byte, len(secrets))
+	for k, v := range secrets {
+		result[k] = []byte(v)
+	}
+
+	return result
+}
+
+// SecretsWebhook returns the secrets used to sign outgoing webhook requests. Unlike the other secrets, they
+// do not fall back to the default secret because they are shared with the webhook receivers.
+func (p *Config) SecretsWebhook() [][]byte {
+	secrets := p.p.Strings(ViperKeySecretsWebhook)
+	result := make([][]byte, len(secrets))
+	for k, v := range secrets {
+		result[k] = []byte(v)
+	}
+	return result
+}
+
+// SecretsPrefill returns the secrets used to verify the prefills which landing pages hand off to new login and
+// registration flows. Like the webhook secrets, they do not fall back to the default secret because they are shared
+// with the landing pages.
+func (p *Config) SecretsPrefill() [][]byte {
+	secrets := p.p.Strings(ViperKeySecretsPrefill)
+	result := make([][]byte, len(secrets))
+	for k, v := range secrets {
+		result[k] = []byte(v)
+	}
+	return result
+}
+
+// SecretsCipher returns the keys used to encrypt data at rest. If no cipher secrets are set, the keys are derived
+// from the default secrets.
+// SecretsPepper returns the peppers applied to passwords before they are hashed. The first one is used for new hashes,
+// all others only to compare passwords to hashes created before the pepper was rotated. Passwords are not peppered if
+// no pepper is configured.
+func (p *Config) SecretsPepper() [][]byte {
+	secrets := p.p.Strings(ViperKeySecretsPepper)
+	result := make([][]byte, len(secrets))
+	for k, v := range secrets {
+		result[k] = []byte(v)
+	}
+	return result
+}
+
+func (p *Config) SecretsCipher() [][32]byte {
+	secrets := p.p.Strings(ViperKeySecretsCipher)
+	if len(secrets) == 0 {
+		defaults := p.SecretsDefault()
+		result := make([][32]byte, len(defaults))
+		for k, v := range defaults {
+			result[k] = sha256.Sum256(v)
+		}
+		return result
+	}
+
+	result := make([][32]byte, len(secrets))
+	for k, v := range secrets {
+		copy(result[k][:], v)
+	}
+	return result
+}
+
+// MultiRegionEnabled returns true if ORY Kratos is deployed active-active in several regions which share one database.
+func (p *Config) MultiRegionEnabled() bool {
+	return p.p.Bool(ViperKeyMultiRegionEnabled)
+}
+
+// MultiRegionName returns the name of the region this instance runs in.
+func (p *Config) MultiRegionName() string {
+	return p.p.String(ViperKeyMultiRegionName)
+}
+
+// MultiRegionReplicaLagTolerance returns for how long reads which follow writes are retried if the data was not
+// replicated yet. It is zero unless multi-region mode is enabled.
+func (p *Config) MultiRegionReplicaLagTolerance() time.Duration {
+	if !p.MultiRegionEnabled() {
+		return 0
+	}
+	return p.p.DurationF(ViperKeyMultiRegionReplicaLagTolerance, 2*time.Second)
+}
+
+// MultiRegionSessionAffinity returns true if the whoami endpoint should wait for sessions which were issued recently
+// to be replicated instead of responding that no session was found.
+func (p *Config) MultiRegionSessionAffinity() bool {
+	return p.MultiRegionEnabled() && p.p.Bool(ViperKeyMultiRegionSessionAffinity)
+}
+
+// JobSchedules returns the schedules of the background jobs by job ID. Jobs without a schedule do not run.
+//
+// Unless configured otherwise, expired continuity containers are removed every `continuity.cleanup.interval` and
+// the identity inactivity policies are applied every `identity.inactivity.check_interval`.
+func (p *Config) JobSchedules() map[string]string {
+	schedules := map[string]string{}
+	if p.ContinuityCleanupEnabled() {
+		schedules["continuity-cleanup"] = "@every " + p.ContinuityCleanupInterval().String()
+	}
+	if len(p.IdentityInactivityPolicies()) > 0 {
+		schedules["identity-inactivity"] = "@every " + p.IdentityInactivityCheckInterval().String()
+	}
+	for id, spec := range p.p.StringMap(ViperKeyJobsSchedules) {
+		schedules[id] = spec
+	}
+	return schedules
+}
+
+// JobLockTTL returns for how long an instance holds the lock of a job before it has to renew it. If the instance
+// stops renewing the lock, for example because it crashed, another instance takes over once the lock expired.
+func (p *Config) JobLockTTL() time.Duration {
+	return p.p.DurationF(ViperKeyJobsLockTTL, time.Minute)
 }
 
-func (p *Config) SecretsDefault() [][]byte {
-	secrets := p.p.Strings(ViperKeySecretsDefault)
+// JobHistoryLifespan returns for how long job runs are kept in the run history.
+func (p *Config) JobHistoryLifespan() time.Duration {
+	return p.p.DurationF(ViperKeyJobsHistoryLifespan, 7*24*time.Hour)
+}
 
-	if len(secrets) == 0 {
-		secrets = []string{uuid.New().String()}
-		p.MustSet(ViperKeySecretsDefault, secrets)
-	}
+// IdempotencyTTL returns for how long responses to requests with an Idempotency-Key header are stored.
+func (p *Config) IdempotencyTTL() time.Duration {
+	return p.p.DurationF(ViperKeyIdempotencyTTL, 24*time.Hour)
+}
 
-	result := make([][]byte, len(secrets))
-	for k, v := range secrets {
-		result[k] = []byte(v)
-	}
+// AdminApprovalsEnabled returns true if destructive admin operations require an approval token.
+func (p *Config) AdminApprovalsEnabled() bool {
+	return p.p.Bool(ViperKeyAdminApprovalsEnabled)
+}
 
-	return result
+// AdminApprovalsLifespan returns for how long an approval token can be used.
+func (p *Config) AdminApprovalsLifespan() time.Duration {
+	return p.p.DurationF(ViperKeyAdminApprovalsLifespan, 5*time.Minute)
 }
 
-func (p *Config) SecretsSession() [][]byte {
-	secrets := p.p.Strings(ViperKeySecretsCookie)
-	if len(secrets) == 0 {
-		return p.SecretsDefault()
-	}
+func (p *Config) WebhookTimeout() time.Duration {
+	return p.p.DurationF(ViperKeyWebhookTimeout, 10*time.Second)
+}
 
-	result := make([][]byte, len(secrets))
-	for k, v := range secrets {
-		result[k] = []byte(v)
-	}
+func (p *Config) WebhookMaxRetries() int {
+	return p.p.IntF(ViperKeyWebhookMaxRetries, 3)
+}
 
-	return result
+func (p *Config) WebhookRetryInitialInterval() time.Duration {
+	return p.p.DurationF(ViperKeyWebhookRetryInitialInterval, 500*time.Millisecond)
+}
+
+func (p *Config) WebhookRetryMaxInterval() time.Duration {
+	return p.p.DurationF(ViperKeyWebhookRetryMaxInterval, 5*time.Second)
+}
+
+// WebhookCircuitBreakerThreshold returns the number of consecutive failures after which calls to a web hook
+// target are rejected. Zero disables the circuit breaker.
+func (p *Config) WebhookCircuitBreakerThreshold() int {
+	return p.p.IntF(ViperKeyWebhookCircuitBreakerThreshold, 5)
+}
+
+func (p *Config) WebhookCircuitBreakerOpenDuration() time.Duration {
+	return p.p.DurationF(ViperKeyWebhookCircuitBreakerOpenDuration, 30*time.Second)
 }
 
 func (p *Config) SelfServiceBrowserDefaultReturnTo() *url.URL {
@@ -609,6 +1778,34 @@ func (p *Config) SelfServiceFlowErrorURL() *url.URL {
 	return p.ParseURIOrFail(ViperKeySelfServiceErrorUI)
 }
 
+// SelfServiceFlowErrorRoutes returns the routes for errors of self-service flows in the order they are
+// configured. Routes without a client apply to all clients.
+func (p *Config) SelfServiceFlowErrorRoutes() []ErrorRoute {
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warn("Unable to marshal error routes.")
+		return nil
+	}
+
+	config := gjson.GetBytes(out, ViperKeySelfServiceErrorRoutes).Raw
+	if len(config) == 0 {
+		return nil
+	}
+
+	var routes []ErrorRoute
+	if err := json.Unmarshal([]byte(config), &routes); err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeySelfServiceErrorRoutes)
+		return nil
+	}
+
+	for k := range routes {
+		if routes[k].Client == "" {
+			routes[k].Client = ErrorRouteClientAny
+		}
+	}
+	return routes
+}
+
 func (p *Config) SelfServiceFlowRegistrationUI() *url.URL {
 	return p.ParseURIOrFail(ViperKeySelfServiceRegistrationUI)
 }
@@ -622,6 +1819,158 @@ func (p *Config) SessionLifespan() time.Duration {
 	return p.p.DurationF(ViperKeySessionLifespan, time.Hour*24)
 }
 
+func (p *Config) SessionServiceAccountLifespan() time.Duration {
+	return p.p.DurationF(ViperKeySessionServiceAccountLifespan, time.Hour*24*365)
+}
+
+// SessionRememberMeEnabled returns true if login flows let the identity choose between a persistent and a
+// browser-session cookie.
+func (p *Config) SessionRememberMeEnabled() bool {
+	return p.p.Bool(ViperKeySessionRememberMeEnabled)
+}
+
+// SessionRememberMe returns the remember me policy for identities using the schema. The schema's bounds can
+// disable remember me and shorten, but not extend, the lifespans at `session.remember_me`.
+func (p *Config) SessionRememberMe(schemaID string) *RememberMePolicy {
+	policy := &RememberMePolicy{
+		Enabled:            p.SessionRememberMeEnabled(),
+		PersistentLifespan: p.p.DurationF(ViperKeySessionRememberMePersistentLifespan, time.Hour*24*30),
+		SessionLifespan:    p.p.DurationF(ViperKeySessionRememberMeSessionLifespan, p.SessionLifespan()),
+	}
+
+	s, err := p.IdentityTraitsSchemas().FindSchemaByID(schemaID)
+	if err != nil || s.RememberMe == nil {
+		return policy
+	}
+
+	if s.RememberMe.Enabled != nil && !*s.RememberMe.Enabled {
+		policy.Enabled = false
+	}
+	for _, b := range []struct {
+		value    string
+		lifespan *time.Duration
+	}{
+		{value: s.RememberMe.PersistentLifespan, lifespan: &policy.PersistentLifespan},
+		{value: s.RememberMe.SessionLifespan, lifespan: &policy.SessionLifespan},
+	} {
+		if len(b.value) == 0 {
+			continue
+		}
+		bound, err := time.ParseDuration(b.value)
+		if err != nil {
+			p.l.WithError(err).Warnf("Ignoring the remember me lifespan of identity schema %s because it is invalid.", schemaID)
+			continue
+		}
+		if bound < *b.lifespan {
+			*b.lifespan = bound
+		}
+	}
+
+	return policy
+}
+
+func (p *Config) ContinuityMaxPayloadSize() int {
+	return p.p.IntF(ViperKeyContinuityMaxPayloadSize, 1024*1024)
+}
+
+func (p *Config) ContinuityMaxLifespan() time.Duration {
+	return p.p.DurationF(ViperKeyContinuityMaxLifespan, time.Hour)
+}
+
+func (p *Config) ContinuityCompression() bool {
+	return p.p.Bool(ViperKeyContinuityCompression)
+}
+
+func (p *Config) ContinuityCleanupEnabled() bool {
+	return p.p.BoolF(ViperKeyContinuityCleanupEnabled, true)
+}
+
+func (p *Config) ContinuityCleanupInterval() time.Duration {
+	return p.p.DurationF(ViperKeyContinuityCleanupInterval, time.Minute*15)
+}
+
+// SessionCookie returns the attributes of the session cookie.
+func (p *Config) SessionCookie() *CookieConfig {
+	c := p.cookieConfig(ViperKeySessionCookie)
+	c.SameSite = p.SessionSameSiteMode()
+	return c
+}
+
+// CSRFCookie returns the attributes of the anti-CSRF cookie. Domain and path default to the public base URL.
+func (p *Config) CSRFCookie() *CookieConfig {
+	return p.cookieConfig(ViperKeyPublicCSRFCookie)
+}
+
+// ContinuityCookie returns the attributes of the continuity cookie.
+func (p *Config) ContinuityCookie() *CookieConfig {
+	c := p.cookieConfig(ViperKeyContinuityCookie)
+	if c.SameSite == http.SameSiteDefaultMode {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	return c
+}
+
+// ContinuityCookieName returns the name of the continuity cookie including the configured name prefix.
+func (p *Config) ContinuityCookieName() string {
+	return p.ContinuityCookie().NamePrefix + DefaultContinuityCookieName
+}
+
+// CSRFCookieName returns the name of the anti-CSRF cookie. The name is derived from the public base URL so
+// that several deployments on the same domain do not overwrite each other's cookies.
+func (p *Config) CSRFCookieName(r *http.Request) string {
+	return p.CSRFCookie().NamePrefix + base64.RawURLEncoding.EncodeToString([]byte(p.SelfPublicURL(r).String())) + "_csrf_token"
+}
+
+func (p *Config) cookieConfig(key string) *CookieConfig {
+	return &CookieConfig{
+		NamePrefix:  p.p.String(key + ".name_prefix"),
+		Domain:      p.p.String(key + ".domain"),
+		Path:        p.p.String(key + ".path"),
+		SameSite:    sameSiteMode(p.p.String(key + ".same_site")),
+		Secure:      p.p.BoolF(key+".secure", !p.IsInsecureDevMode()),
+		Partitioned: p.p.Bool(key + ".partitioned"),
+	}
+}
+
+// ValidateCookies checks that the attributes of all cookie types are accepted by browsers.
+func (p *Config) ValidateCookies() error {
+	for key, c := range map[string]*CookieConfig{
+		ViperKeySessionCookie:    p.SessionCookie(),
+		ViperKeyPublicCSRFCookie: p.CSRFCookie(),
+		ViperKeyContinuityCookie: p.ContinuityCookie(),
+	} {
+		if err := c.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid configuration for %s", key)
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if browsers would reject a cookie with these attributes.
+func (c *CookieConfig) Validate() error {
+	if !c.Secure {
+		switch {
+		case c.Partitioned:
+			return errors.New("partitioned cookies must be secure")
+		case c.SameSite == http.SameSiteNoneMode:
+			return errors.New("cookies with SameSite=None must be secure")
+		case strings.HasPrefix(c.NamePrefix, "__Secure-"), c.HostOnly():
+			return errors.Errorf("cookies with name prefix %s must be secure", c.NamePrefix)
+		}
+	}
+
+	if c.HostOnly() && (c.Domain != "" || (c.Path != "" && c.Path != "/")) {
+		return errors.New("cookies with name prefix __Host- must not set a domain and must use path /")
+	}
+
+	return nil
+}
+
+// HostOnly returns true if the cookie uses the `__Host-` name prefix and must therefore be bound to the exact host.
+func (c *CookieConfig) HostOnly() bool {
+	return strings.HasPrefix(c.NamePrefix, "__Host-")
+}
+
 func (p *Config) SessionPersistentCookie() bool {
 	return p.p.Bool(ViperKeySessionPersistentCookie)
 }
@@ -645,16 +1994,42 @@ func (p *Config) SelfServiceBrowserWhitelistedReturnToDomains() (us []url.URL) {
 	return us
 }
 
+// SelfServiceFlowCallbacksAllowedURLs returns the URLs that results of verification and recovery flows may be
+// sent to.
+func (p *Config) SelfServiceFlowCallbacksAllowedURLs() (us []url.URL) {
+	for k, u := range p.p.Strings(ViperKeySelfServiceFlowCallbacksAllowedURLs) {
+		parsed, err := url.ParseRequestURI(u)
+		if err != nil {
+			p.l.WithError(err).Warnf("Ignoring URL \"%s\" from configuration key \"%s.%d\".", u, ViperKeySelfServiceFlowCallbacksAllowedURLs, k)
+			continue
+		}
+
+		us = append(us, *parsed)
+	}
+
+	return us
+}
+
+func (p *Config) SelfServiceFlowCallbacksRetryLifespan() time.Duration {
+	return p.p.DurationF(ViperKeySelfServiceFlowCallbacksRetryLifespan, 24*time.Hour)
+}
+
+// SelfServiceFlowReplayProtectionEnabled returns true if submissions of recovery and verification flows must carry
+// the flow's one-time nonce.
+func (p *Config) SelfServiceFlowReplayProtectionEnabled() bool {
+	return p.p.Bool(ViperKeySelfServiceFlowReplayProtectionEnabled)
+}
+
 func (p *Config) SelfServiceFlowLoginRequestLifespan() time.Duration {
-	return p.p.DurationF(ViperKeySelfServiceLoginRequestLifespan, time.Hour)
+	return p.boundedDuration(ViperKeySelfServiceLoginRequestLifespan, time.Hour, MaxSelfServiceFlowLifespan)
 }
 
 func (p *Config) SelfServiceFlowSettingsFlowLifespan() time.Duration {
-	return p.p.DurationF(ViperKeySelfServiceSettingsRequestLifespan, time.Hour)
+	return p.boundedDuration(ViperKeySelfServiceSettingsRequestLifespan, time.Hour, MaxSelfServiceFlowLifespan)
 }
 
 func (p *Config) SelfServiceFlowRegistrationRequestLifespan() time.Duration {
-	return p.p.DurationF(ViperKeySelfServiceRegistrationRequestLifespan, time.Hour)
+	return p.boundedDuration(ViperKeySelfServiceRegistrationRequestLifespan, time.Hour, MaxSelfServiceFlowLifespan)
 }
 
 func (p *Config) SelfServiceFlowLogoutRedirectURL() *url.URL {
@@ -669,10 +2044,113 @@ func (p *Config) CourierSMTPFromName() string {
 	return p.p.StringF(ViperKeyCourierSMTPFromName, "")
 }
 
+// CourierSMTPEnvelopeFrom returns the SMTP envelope sender, which is CourierSMTPFrom unless configured otherwise.
+func (p *Config) CourierSMTPEnvelopeFrom() string {
+	return p.p.StringF(ViperKeyCourierSMTPEnvelopeFrom, p.CourierSMTPFrom())
+}
+
+// CourierSMTPMessageIDDomain returns an empty string if the SMTP server sets the Message-ID.
+func (p *Config) CourierSMTPMessageIDDomain() string {
+	return p.p.String(ViperKeyCourierSMTPMessageIDDomain)
+}
+
+func (p *Config) CourierSMTPHeaders() map[string]string {
+	return p.p.StringMap(ViperKeyCourierSMTPHeaders)
+}
+
+func (p *Config) CourierSMTPListUnsubscribe() []string {
+	return p.p.Strings(ViperKeyCourierSMTPListUnsubscribe)
+}
+
 func (p *Config) CourierTemplatesRoot() string {
 	return p.p.StringF(ViperKeyCourierTemplatesPath, "courier/builtin/templates")
 }
 
+// CourierMessageRedactAfter returns zero if sent messages are never redacted.
+func (p *Config) CourierMessageRedactAfter() time.Duration {
+	return p.p.DurationF(ViperKeyCourierMessageRedactAfter, 0)
+}
+
+// CourierDeliverabilityRetention returns for how long delivery outcomes and bounce reports are kept for the
+// deliverability summary.
+func (p *Config) CourierDeliverabilityRetention() time.Duration {
+	return p.p.DurationF(ViperKeyCourierDeliverabilityRetention, time.Hour*24*30)
+}
+
+// CourierFallbackProviders returns the URLs of the providers the courier fails over to, in order. Invalid URLs
+// are logged and skipped.
+func (p *Config) CourierFallbackProviders() []*url.URL {
+	var providers []*url.URL
+	for _, raw := range p.p.Strings(ViperKeyCourierFallbackProviders) {
+		u, err := url.ParseRequestURI(raw)
+		if err != nil {
+			p.l.WithError(errors.WithStack(err)).Errorf("Configuration value from key %s contains an invalid URL.", ViperKeyCourierFallbackProviders)
+			continue
+		}
+		providers = append(providers, u)
+	}
+	return providers
+}
+
+func (p *Config) CourierDegradationPolicy() CourierDegradationPolicy {
+	switch policy := CourierDegradationPolicy(p.p.StringF(ViperKeyCourierDegradationPolicy, string(CourierDegradationQueue))); policy {
+	case CourierDegradationFailFast, CourierDegradationFallback:
+		return policy
+	}
+	return CourierDegradationQueue
+}
+
+// CourierDegradationThreshold returns how many of the most recent delivery attempts within the window must have
+// failed for the SMTP server to be considered failing.
+func (p *Config) CourierDegradationThreshold() (failures int, window time.Duration) {
+	failures = p.p.IntF(ViperKeyCourierDegradationFailureThreshold, 3)
+	if failures < 1 {
+		failures = 1
+	}
+	return failures, p.p.DurationF(ViperKeyCourierDegradationWindow, 5*time.Minute)
+}
+
+// CourierDegradationFallbackURL returns nil if no fallback SMTP server is configured.
+func (p *Config) CourierDegradationFallbackURL() *url.URL {
+	raw := p.p.String(ViperKeyCourierDegradationFallbackURL)
+	if raw == "" {
+		return nil
+	}
+
+	u, err := url.ParseRequestURI(raw)
+	if err != nil {
+		p.l.WithError(errors.WithStack(err)).Errorf("Configuration value from key %s is not a valid URL.", ViperKeyCourierDegradationFallbackURL)
+		return nil
+	}
+	return u
+}
+
+func (p *Config) CourierSMSEnabled() bool {
+	return p.p.Bool(ViperKeyCourierSMSEnabled)
+}
+
+func (p *Config) CourierSMSFrom() string {
+	return p.p.String(ViperKeyCourierSMSFrom)
+}
+
+// CourierSMSRequestConfig returns how SMS are sent to the gateway. The URL is nil if no gateway is configured.
+func (p *Config) CourierSMSRequestConfig() *CourierSMSRequestConfig {
+	rc := &CourierSMSRequestConfig{
+		Format:  CourierSMSFormat(p.p.StringF(ViperKeyCourierSMSRequestConfigFormat, string(CourierSMSFormatJSON))),
+		Headers: p.p.StringMap(ViperKeyCourierSMSRequestConfigHeaders),
+	}
+
+	if raw := p.p.String(ViperKeyCourierSMSRequestConfigURL); raw != "" {
+		u, err := url.ParseRequestURI(raw)
+		if err != nil {
+			p.l.WithError(errors.WithStack(err)).Errorf("Configuration value from key %s is not a valid URL.", ViperKeyCourierSMSRequestConfigURL)
+		} else {
+			rc.URL = u
+		}
+	}
+	return rc
+}
+
 func splitUrlAndFragment(s string) (string, string) {
 	i := strings.IndexByte(s, '#')
 	if i < 0 {
@@ -715,6 +2193,23 @@ func (p *Config) CourierExposeMetricsPort() int {
 	return p.Source().Int("expose-metrics-port")
 }
 
+func (p *Config) AdminProfilingEnabled() bool {
+	return p.p.Bool(ViperKeyAdminProfilingEnabled)
+}
+
+func (p *Config) AdminTraitMaskingEnabled() bool {
+	return p.p.Bool(ViperKeyAdminTraitMaskingEnabled)
+}
+
+// AdminTraitMaskingScopeHeader returns the request header carrying the space-separated scopes of the caller.
+func (p *Config) AdminTraitMaskingScopeHeader() string {
+	return p.p.StringF(ViperKeyAdminTraitMaskingScopeHeader, "X-Kratos-Scopes")
+}
+
+func (p *Config) AdminTraitMaskingUnmaskScope() string {
+	return p.p.StringF(ViperKeyAdminTraitMaskingUnmaskScope, "kratos:identities:unmask")
+}
+
 func (p *Config) MetricsListenOn() string {
 	return strings.Replace(p.AdminListenOn(), ":4434", fmt.Sprintf(":%d", p.CourierExposeMetricsPort()), 1)
 }
@@ -724,7 +2219,47 @@ func (p *Config) SelfServiceFlowVerificationUI() *url.URL {
 }
 
 func (p *Config) SelfServiceFlowVerificationRequestLifespan() time.Duration {
-	return p.p.DurationF(ViperKeySelfServiceVerificationRequestLifespan, time.Hour)
+	return p.boundedDuration(ViperKeySelfServiceVerificationRequestLifespan, time.Hour, MaxSelfServiceFlowLifespan)
+}
+
+// SelfServiceFlowVerificationResendKeepPreviousLinks returns how many previously sent verification links stay
+// valid when a new link is sent to the same address.
+func (p *Config) SelfServiceFlowVerificationResendKeepPreviousLinks() int {
+	return p.p.IntF(ViperKeySelfServiceVerificationResendKeepPreviousLinks, 0)
+}
+
+func (p *Config) SelfServiceFlowVerificationResendGracePeriod() time.Duration {
+	return p.p.DurationF(ViperKeySelfServiceVerificationResendGracePeriod, 10*time.Minute)
+}
+
+// SelfServiceFlowVerificationAfterAPISession returns true if API flows receive a session token once the address
+// was verified.
+func (p *Config) SelfServiceFlowVerificationAfterAPISession() bool {
+	return p.p.Bool(ViperKeySelfServiceVerificationAfterAPISession)
+}
+
+func (p *Config) SelfServiceFlowVerificationExpiredLinks() ExpiredLinksConfig {
+	return p.expiredLinks(ViperKeySelfServiceVerificationExpiredLinks)
+}
+
+func (p *Config) SelfServiceFlowRecoveryExpiredLinks() ExpiredLinksConfig {
+	return p.expiredLinks(ViperKeySelfServiceRecoveryExpiredLinks)
+}
+
+func (p *Config) expiredLinks(key string) ExpiredLinksConfig {
+	c := ExpiredLinksConfig{
+		SendEmail: p.p.Bool(key + ".send_email"),
+		Lookback:  p.p.DurationF(key+".lookback", 24*time.Hour),
+	}
+	if raw := p.p.String(key + ".web_hook_url"); raw != "" {
+		c.WebHookURL = p.ParseURIOrFail(key + ".web_hook_url")
+	}
+	return c
+}
+
+// Enabled returns true if expired links are reported.
+func (c ExpiredLinksConfig) Enabled() bool {
+	return c.WebHookURL != nil || c.SendEmail
 }
 
 func (p *Config) SelfServiceFlowVerificationReturnTo(defaultReturnTo *url.URL) *url.URL {
@@ -736,15 +2271,114 @@ func (p *Config) SelfServiceFlowRecoveryReturnTo() *url.URL {
 }
 
 func (p *Config) SelfServiceFlowRecoveryRequestLifespan() time.Duration {
-	return p.p.DurationF(ViperKeySelfServiceRecoveryRequestLifespan, time.Hour)
+	return p.boundedDuration(ViperKeySelfServiceRecoveryRequestLifespan, time.Hour, MaxSelfServiceFlowLifespan)
 }
 
 func (p *Config) SelfServiceFlowSettingsPrivilegedSessionMaxAge() time.Duration {
-	return p.p.DurationF(ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, time.Hour)
+	return p.boundedDuration(ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, time.Hour, MaxPrivilegedSessionMaxAge)
+}
+
+// SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod returns the privileged session max age of the given
+// settings method and falls back to SelfServiceFlowSettingsPrivilegedSessionMaxAge if the method has none.
+func (p *Config) SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(method string) time.Duration {
+	fallback := p.SelfServiceFlowSettingsPrivilegedSessionMaxAge()
+	key := ViperKeySelfServiceSettingsPrivilegedMaxAgeMethods + "." + method
+	if !p.p.Exists(key) {
+		return fallback
+	}
+
+	return p.boundedDuration(key, fallback, MaxPrivilegedSessionMaxAge)
+}
+
+// SelfServiceFlowSettingsPrivilegedCodeEnabled returns true if changes which require a more recent sign in may be
+// confirmed with a code sent to a verified email address instead.
+func (p *Config) SelfServiceFlowSettingsPrivilegedCodeEnabled() bool {
+	return p.p.Bool(ViperKeySelfServiceSettingsPrivilegedCodeEnabled)
+}
+
+func (p *Config) SelfServiceFlowSettingsPrivilegedCodeLifespan() time.Duration {
+	return p.boundedDuration(ViperKeySelfServiceSettingsPrivilegedCodeLifespan, time.Minute*15, MaxPrivilegedCodeLifespan)
+}
+
+func (p *Config) SelfServiceFlowSettingsPrivilegedCodeMaxAttempts() int {
+	if n := p.p.IntF(ViperKeySelfServiceSettingsPrivilegedCodeMaxAttempts, 5); n > 0 {
+		return n
+	}
+	return 5
+}
+
+// SelfServiceFlowSettingsPrivilegedCodeResendInterval returns the minimum time between two privileged codes sent
+// for the same settings flow.
+func (p *Config) SelfServiceFlowSettingsPrivilegedCodeResendInterval() time.Duration {
+	return p.p.DurationF(ViperKeySelfServiceSettingsPrivilegedCodeResendInterval, 30*time.Second)
+}
+
+// SelfServiceFlowSettingsPrivilegedCodeMaxSends returns how many privileged codes may be sent for one settings
+// flow. Once they are used up, the identity has to sign in again.
+func (p *Config) SelfServiceFlowSettingsPrivilegedCodeMaxSends() int {
+	if n := p.p.IntF(ViperKeySelfServiceSettingsPrivilegedCodeMaxSends, 3); n > 0 {
+		return n
+	}
+	return 3
+}
+
+func (p *Config) SelfServiceFlowLoginLifespanBounds() LifespanBounds {
+	return p.lifespanBounds(ViperKeySelfServiceLoginLifespanBounds)
+}
+
+func (p *Config) SelfServiceFlowRegistrationLifespanBounds() LifespanBounds {
+	return p.lifespanBounds(ViperKeySelfServiceRegistrationLifespanBounds)
+}
+
+func (p *Config) SelfServiceFlowSettingsLifespanBounds() LifespanBounds {
+	return p.lifespanBounds(ViperKeySelfServiceSettingsLifespanBounds)
+}
+
+func (p *Config) SelfServiceFlowRecoveryLifespanBounds() LifespanBounds {
+	return p.lifespanBounds(ViperKeySelfServiceRecoveryLifespanBounds)
+}
+
+func (p *Config) SelfServiceFlowVerificationLifespanBounds() LifespanBounds {
+	return p.lifespanBounds(ViperKeySelfServiceVerificationLifespanBounds)
+}
+
+func (p *Config) lifespanBounds(key string) LifespanBounds {
+	b := LifespanBounds{
+		Min: p.p.DurationF(key+".min", 0),
+		Max: p.p.DurationF(key+".max", 0),
+	}
+
+	if b.Max > MaxSelfServiceFlowLifespan {
+		p.l.Warnf("Reducing the value %s of \"%s.max\" to the longest possible flow lifespan of %s.", b.Max, key, MaxSelfServiceFlowLifespan)
+		b.Max = MaxSelfServiceFlowLifespan
+	}
+
+	if b.Min < 0 || b.Min > b.Max {
+		p.l.Warnf("Ignoring \"%s\" because its minimum is negative or greater than its maximum.", key)
+		return LifespanBounds{}
+	}
+
+	return b
+}
+
+// boundedDuration returns the duration set at key, or the fallback if the duration is not greater than zero or
+// exceeds max. Because the value is read on every call, changes to the configuration apply without a restart.
+func (p *Config) boundedDuration(key string, fallback, max time.Duration) time.Duration {
+	d := p.p.DurationF(key, fallback)
+	if d <= 0 || d > max {
+		p.l.Warnf("Ignoring the value %s of \"%s\" because it must be greater than zero and must not exceed %s. Using %s instead.", d, key, max, fallback)
+		return fallback
+	}
+
+	return d
 }
 
 func (p *Config) SessionSameSiteMode() http.SameSite {
-	switch p.p.StringF(ViperKeySessionSameSite, "Lax") {
+	return sameSiteMode(p.p.StringF(ViperKeySessionSameSite, "Lax"))
+}
+
+func sameSiteMode(mode string) http.SameSite {
+	switch mode {
 	case "Lax":
 		return http.SameSiteLaxMode
 	case "Strict":
@@ -792,6 +2426,92 @@ func (p *Config) PasswordPolicyConfig() *PasswordPolicy {
 	}
 }
 
+func (p *Config) LinkCodeConfig() *LinkCodeConfig {
+	return &LinkCodeConfig{
+		Digits:         p.p.IntF(ViperKeyLinkCodeDigits, 6),
+		Interval:       p.p.DurationF(ViperKeyLinkCodeInterval, 0),
+		GracePeriods:   p.p.IntF(ViperKeyLinkCodeGracePeriods, 0),
+		MaxAttempts:    p.p.IntF(ViperKeyLinkCodeMaxAttempts, 5),
+		ResendInterval: p.p.DurationF(ViperKeyLinkCodeResendInterval, 30*time.Second),
+	}
+}
+
+// LinkRecoveryCodeEnabled returns true if recovery emails contain a code instead of a link.
+func (p *Config) LinkRecoveryCodeEnabled() bool {
+	return p.p.Bool(ViperKeyLinkRecoveryCode)
+}
+
+func (p *Config) APIKeyPrefix() string {
+	return p.p.StringF(ViperKeyAPIKeyPrefix, DefaultAPIKeyPrefix)
+}
+
+// APIKeyMaxLifespan returns zero if API keys may be issued without an expiry.
+func (p *Config) APIKeyMaxLifespan() time.Duration {
+	return p.p.DurationF(ViperKeyAPIKeyMaxLifespan, 0)
+}
+
+// UsernameTrait returns the path of the trait which is changed by the username settings method.
+func (p *Config) UsernameTrait() string {
+	return p.p.StringF(ViperKeyUsernameTrait, "username")
+}
+
+// UsernameChangeCooldown returns zero if the username may be changed at any time.
+func (p *Config) UsernameChangeCooldown() time.Duration {
+	return p.p.DurationF(ViperKeyUsernameChangeCooldown, 0)
+}
+
+// UsernameReservationLifespan returns zero if usernames become available as soon as they are changed.
+func (p *Config) UsernameReservationLifespan() time.Duration {
+	return p.p.DurationF(ViperKeyUsernameReservationLifespan, 0)
+}
+
+// SIWEDomain returns the domain which Sign-In with Ethereum messages must be issued for. It defaults to the host of
+// the public URL.
+func (p *Config) SIWEDomain(r *http.Request) string {
+	if domain := p.p.String(ViperKeySIWEDomain); len(domain) > 0 {
+		return domain
+	}
+	return p.SelfPublicURL(r).Host
+}
+
+// SIWEChainIDs returns the EIP-155 chain IDs which Sign-In with Ethereum messages may be issued for. It defaults to
+// the Ethereum mainnet.
+func (p *Config) SIWEChainIDs() []int {
+	if ids := p.p.Ints(ViperKeySIWEChainIDs); len(ids) > 0 {
+		return ids
+	}
+	return []int{1}
+}
+
+// RecoveryQuestions returns the questions which identities answer to recover their account using the questions
+// method.
+func (p *Config) RecoveryQuestions() []RecoveryQuestion {
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warn("Unable to marshal recovery questions.")
+		return nil
+	}
+
+	config := gjson.GetBytes(out, ViperKeyRecoveryQuestions).Raw
+	if len(config) == 0 {
+		return nil
+	}
+
+	var questions []RecoveryQuestion
+	if err := json.NewDecoder(bytes.NewBufferString(config)).Decode(&questions); err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeyRecoveryQuestions)
+		return nil
+	}
+
+	return questions
+}
+
+// RecoveryQuestionsMaxAttempts returns how often the answers to the recovery questions may be wrong before
+// recovering the account using the questions method is locked until an administrator sets new answers.
+func (p *Config) RecoveryQuestionsMaxAttempts() int {
+	return p.p.IntF(ViperKeyRecoveryQuestionsMaxAttempts, 5)
+}
+
 func (p *Config) HasherPasswordHashingAlgorithm() string {
 	configValue := p.p.StringF(ViperKeyHasherAlgorithm, DefaultPasswordHashingAlgorithm)
 	switch configValue {
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
 	"github.com/ory/x/configx"
 	"github.com/ory/x/logrusx"
 )
@@ -15,6 +16,12 @@ func New(ctx context.Context, opts ...configx.OptionModifier) Registry {
 		l.WithError(err).Fatal("Unable to instantiate configuration.")
 	}
 
+	if err := c.ValidateCookies(); err != nil {
+		l.WithError(err).Fatal("Cookie configuration is invalid.")
+	}
+
+	x.UseTimeOrderedUUIDs(c.MultiRegionEnabled())
+
 	r, err := NewRegistryFromDSN(c, l)
 	if err != nil {
 		l.WithError(err).Fatal("Unable to instantiate service registry.")
@@ -11,18 +11,33 @@ import (
 
 	"github.com/ory/x/logrusx"
 
+	"github.com/ory/kratos/apikey"
+	"github.com/ory/kratos/approval"
+	"github.com/ory/kratos/attempt"
+	"github.com/ory/kratos/capability"
+	"github.com/ory/kratos/cipher"
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/feature"
+	"github.com/ory/kratos/geo"
 	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/idempotency"
+	"github.com/ory/kratos/inactivity"
+	"github.com/ory/kratos/job"
+	"github.com/ory/kratos/middleware"
+	"github.com/ory/kratos/ratelimit"
+	"github.com/ory/kratos/reputation"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/flow/verification"
 	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/webhook"
 
 	"github.com/ory/x/healthx"
 
 	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/selfservice/flow/callback"
 	"github.com/ory/kratos/selfservice/flow/login"
 	"github.com/ory/kratos/selfservice/flow/logout"
 	"github.com/ory/kratos/selfservice/flow/registration"
@@ -47,6 +62,14 @@ type Registry interface {
 
 	WithCSRFHandler(c x.CSRFHandler)
 	WithCSRFTokenGenerator(cg x.CSRFToken)
+	WithIdentityManagerMiddleware(mws ...identity.ManagerMiddleware)
+	WithIdentitySchemaExtensions(extensions ...identity.SchemaExtensionFactory)
+	WithJobs(jobs ...job.Job)
+	WithHasher(name string, h hash.Hasher)
+	WithPasswordValidator(v password2.Validator)
+	WithPasswordStrengthEstimator(e password2.StrengthEstimator)
+	WithRateLimitStore(s ratelimit.Store)
+	WithClock(c x.Clock)
 
 	HealthHandler(ctx context.Context) *healthx.Handler
 	CookieManager(ctx context.Context) sessions.Store
@@ -65,11 +88,56 @@ type Registry interface {
 	x.CSRFProvider
 	x.WriterProvider
 	x.LoggingProvider
+	x.ClockProvider
+
+	apikey.HandlerProvider
+	apikey.ManagementProvider
 
 	continuity.ManagementProvider
 	continuity.PersistenceProvider
+	continuity.CleanerProvider
 
 	courier.Provider
+	courier.PreferencesPersistenceProvider
+	courier.DeliverabilityPersistenceProvider
+	courier.HandlerProvider
+
+	webhook.ClientProvider
+	webhook.HandlerProvider
+
+	feature.Provider
+	reputation.Provider
+	ratelimit.Provider
+	ratelimit.StoreProvider
+	middleware.Provider
+
+	geo.PersistenceProvider
+	geo.EnforcerProvider
+	geo.HandlerProvider
+
+	capability.HandlerProvider
+
+	idempotency.PersistenceProvider
+	idempotency.MiddlewareProvider
+
+	approval.PersistenceProvider
+	approval.MiddlewareProvider
+	approval.HandlerProvider
+
+	callback.PersistenceProvider
+	callback.NotifierProvider
+
+	attempt.PersistenceProvider
+	attempt.ManagementProvider
+	attempt.HandlerProvider
+
+	inactivity.PersistenceProvider
+	inactivity.ManagementProvider
+	inactivity.HandlerProvider
+
+	job.PersistenceProvider
+	job.SchedulerProvider
+	job.HandlerProvider
 
 	persistence.Provider
 
@@ -78,17 +146,26 @@ type Registry interface {
 	errorx.PersistenceProvider
 
 	hash.HashProvider
+	hash.RegistryProvider
+	cipher.Provider
+	cipher.IdentityKeysProvider
+	cipher.DataKeyPersistenceProvider
 
 	identity.HandlerProvider
 	identity.ValidationProvider
 	identity.PoolProvider
 	identity.PrivilegedPoolProvider
+	identity.NotePersistenceProvider
+	identity.RelationshipPersistenceProvider
+	identity.ScheduledStateChangePersistenceProvider
 	identity.ManagementProvider
+	identity.ManagerMiddlewareProvider
 	identity.ActiveCredentialsCounterStrategyProvider
 
 	schema.HandlerProvider
 
 	password2.ValidationProvider
+	password2.StrengthEstimationProvider
 
 	session.HandlerProvider
 	session.ManagementProvider
@@ -98,6 +175,7 @@ type Registry interface {
 	settings.ErrorHandlerProvider
 	settings.FlowPersistenceProvider
 	settings.StrategyProvider
+	settings.PrivilegedCodeManagerProvider
 
 	login.FlowPersistenceProvider
 	login.ErrorHandlerProvider
@@ -107,6 +185,7 @@ type Registry interface {
 	login.StrategyProvider
 
 	logout.HandlerProvider
+	logout.StrategyProvider
 
 	registration.FlowPersistenceProvider
 	registration.ErrorHandlerProvider
@@ -123,6 +202,8 @@ type Registry interface {
 	link.SenderProvider
 	link.VerificationTokenPersistenceProvider
 	link.RecoveryTokenPersistenceProvider
+	link.ExpiryPersistenceProvider
+	link.ExpiryNotifierProvider
 
 	recovery.FlowPersistenceProvider
 	recovery.ErrorHandlerProvider
@@ -16,22 +16,40 @@ import (
 
 	"github.com/gobuffalo/pop/v5"
 
+	"github.com/ory/kratos/apikey"
+	"github.com/ory/kratos/approval"
+	"github.com/ory/kratos/attempt"
+	"github.com/ory/kratos/capability"
 	"github.com/ory/kratos/continuity"
+	"github.com/ory/kratos/geo"
 	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/idempotency"
+	"github.com/ory/kratos/inactivity"
+	"github.com/ory/kratos/job"
+	"github.com/ory/kratos/middleware"
+	"github.com/ory/kratos/ratelimit"
+	"github.com/ory/kratos/reputation"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/flow/verification"
 	"github.com/ory/kratos/selfservice/hook"
 	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/selfservice/strategy/preferences"
 	"github.com/ory/kratos/selfservice/strategy/profile"
+	"github.com/ory/kratos/selfservice/strategy/questions"
+	"github.com/ory/kratos/selfservice/strategy/siwe"
+	"github.com/ory/kratos/selfservice/strategy/username"
+	"github.com/ory/kratos/webhook"
 	"github.com/ory/kratos/x"
 
 	"github.com/cenkalti/backoff"
+	"github.com/gofrs/uuid"
 	"github.com/gorilla/sessions"
 	"github.com/pkg/errors"
 
 	"github.com/ory/x/dbal"
+	"github.com/ory/x/fetcher"
 	"github.com/ory/x/healthx"
 	"github.com/ory/x/sqlcon"
 
@@ -40,8 +58,11 @@ import (
 	"github.com/ory/x/logrusx"
 
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/feature"
 	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/persistence/fault"
 	"github.com/ory/kratos/persistence/sql"
+	"github.com/ory/kratos/selfservice/flow/callback"
 	"github.com/ory/kratos/selfservice/flow/login"
 	"github.com/ory/kratos/selfservice/flow/logout"
 	"github.com/ory/kratos/selfservice/flow/registration"
@@ -49,6 +70,7 @@ import (
 
 	"github.com/ory/herodot"
 
+	"github.com/ory/kratos/cipher"
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/errorx"
@@ -64,23 +86,67 @@ type RegistryDefault struct {
 	injectedSelfserviceHooks map[string]func(config.SelfServiceHook) interface{}
 
 	nosurf         x.CSRFHandler
+	clock          x.Clock
 	trc            *tracing.Tracer
 	pmm            *prometheus.MetricsManager
 	writer         herodot.Writer
 	healthxHandler *healthx.Handler
 	metricsHandler *prometheus.Handler
 
-	persister persistence.Persister
+	persister     persistence.Persister
+	faultInjector *fault.Injector
 
 	hookVerifier         *hook.Verifier
 	hookSessionIssuer    *hook.SessionIssuer
 	hookSessionDestroyer *hook.SessionDestroyer
 
+	webhookClient  *webhook.Client
+	webhookHandler *webhook.Handler
+
+	courierHandler *courier.Handler
+
+	idempotencyMiddleware *idempotency.Middleware
+
+	approvalMiddleware *approval.Middleware
+	approvalHandler    *approval.Handler
+
+	flowCallbackNotifier *callback.Notifier
+
+	featureFlags *feature.Flags
+
+	ipReputation *reputation.Checker
+
+	enumerationLimiter *ratelimit.Limiter
+	rateLimitStore     ratelimit.Store
+	publicMiddleware   *middleware.Chain
+
+	geoEnforcer *geo.Enforcer
+	geoHandler  *geo.Handler
+
+	capabilityHandler *capability.Handler
+
+	attemptManager *attempt.Manager
+	attemptHandler *attempt.Handler
+
+	inactivityManager *inactivity.Manager
+	inactivityHandler *inactivity.Handler
+
+	jobs         []job.Job
+	jobScheduler *job.Scheduler
+	jobHandler   *job.Handler
+
 	identityHandler   *identity.Handler
 	identityValidator *identity.Validator
 	identityManager   *identity.Manager
 
+	identityManagerMiddlewares []identity.ManagerMiddleware
+	identitySchemaExtensions   []identity.SchemaExtensionFactory
+
 	continuityManager continuity.Manager
+	continuityCleaner *continuity.Cleaner
+
+	apiKeyHandler *apikey.Handler
+	apiKeyManager *apikey.Manager
 
 	schemaHandler *schema.Handler
 
@@ -88,7 +154,12 @@ type RegistryDefault struct {
 	sessionManager session.Manager
 
 	passwordHasher    hash.Hasher
+	hashers           *hash.Registry
+	customHashers     []namedHasher
+	cipher            cipher.Cipher
+	identityKeys      *cipher.IdentityKeys
 	passwordValidator password2.Validator
+	passwordStrength  password2.StrengthEstimator
 
 	errorHandler *errorx.Handler
 	errorManager *errorx.Manager
@@ -102,15 +173,17 @@ type RegistryDefault struct {
 	selfserviceLoginHandler             *login.Handler
 	selfserviceLoginRequestErrorHandler *login.ErrorHandler
 
-	selfserviceSettingsHandler      *settings.Handler
-	selfserviceSettingsErrorHandler *settings.ErrorHandler
-	selfserviceSettingsExecutor     *settings.HookExecutor
+	selfserviceSettingsHandler               *settings.Handler
+	selfserviceSettingsErrorHandler          *settings.ErrorHandler
+	selfserviceSettingsExecutor              *settings.HookExecutor
+	selfserviceSettingsPrivilegedCodeManager *settings.PrivilegedCodeManager
 
 	selfserviceVerifyErrorHandler *verification.ErrorHandler
 	selfserviceVerifyManager      *identity.Manager
 	selfserviceVerifyHandler      *verification.Handler
 
 	selfserviceLinkSender *link.Sender
+	linkExpiryNotifier    *link.ExpiryNotifier
 
 	selfserviceRecoveryErrorHandler *recovery.ErrorHandler
 	selfserviceRecoveryHandler      *recovery.Handler
@@ -126,6 +199,11 @@ type RegistryDefault struct {
 	csrfTokenGenerator x.CSRFToken
 }
 
+type namedHasher struct {
+	name   string
+	hasher hash.Hasher
+}
+
 func (m *RegistryDefault) Audit() *logrusx.Logger {
 	return m.Logger().WithField("audience", "audit")
 }
@@ -141,6 +219,8 @@ func (m *RegistryDefault) RegisterPublicRoutes(ctx context.Context, router *x.Ro
 	m.SessionHandler().RegisterPublicRoutes(router)
 	m.SelfServiceErrorHandler().RegisterPublicRoutes(router)
 	m.SchemaHandler().RegisterPublicRoutes(router)
+	m.APIKeyHandler().RegisterPublicRoutes(router)
+	m.CapabilityHandler().RegisterPublicRoutes(router)
 
 	m.AllRecoveryStrategies().RegisterPublicRoutes(router)
 	m.RecoveryHandler().RegisterPublicRoutes(router)
@@ -154,10 +234,20 @@ func (m *RegistryDefault) RegisterPublicRoutes(ctx context.Context, router *x.Ro
 func (m *RegistryDefault) RegisterAdminRoutes(ctx context.Context, router *x.RouterAdmin) {
 	m.RegistrationHandler().RegisterAdminRoutes(router)
 	m.LoginHandler().RegisterAdminRoutes(router)
+	m.AllLoginStrategies().RegisterAdminRoutes(router)
 	m.SchemaHandler().RegisterAdminRoutes(router)
 	m.SettingsHandler().RegisterAdminRoutes(router)
 	m.IdentityHandler().RegisterAdminRoutes(router)
+	m.InactivityHandler().RegisterAdminRoutes(router)
+	m.AttemptHandler().RegisterAdminRoutes(router)
+	m.GeoHandler().RegisterAdminRoutes(router)
+	m.CapabilityHandler().RegisterAdminRoutes(router)
+	m.WebhookHandler().RegisterAdminRoutes(router)
+	m.JobHandler().RegisterAdminRoutes(router)
+	m.CourierHandler().RegisterAdminRoutes(router)
 	m.SessionHandler().RegisterAdminRoutes(router)
+	m.APIKeyHandler().RegisterAdminRoutes(router)
+	m.ApprovalHandler().RegisterAdminRoutes(router)
 	m.SelfServiceErrorHandler().RegisterAdminRoutes(router)
 
 	m.RecoveryHandler().RegisterAdminRoutes(router)
@@ -169,6 +259,10 @@ func (m *RegistryDefault) RegisterAdminRoutes(ctx context.Context, router *x.Rou
 	m.HealthHandler(ctx).SetHealthRoutes(router.Router, true)
 	m.HealthHandler(ctx).SetVersionRoutes(router.Router)
 	m.MetricsHandler().SetRoutes(router.Router)
+
+	if m.Config(ctx).AdminProfilingEnabled() {
+		x.AddProfilingRoutes(router)
+	}
 }
 
 func (m *RegistryDefault) RegisterRoutes(ctx context.Context, public *x.RouterPublic, admin *x.RouterAdmin) {
@@ -249,7 +343,11 @@ func (m *RegistryDefault) selfServiceStrategies() []interface{} {
 			password2.NewStrategy(m),
 			oidc.NewStrategy(m),
 			profile.NewStrategy(m),
+			username.NewStrategy(m),
+			preferences.NewStrategy(m),
+			siwe.NewStrategy(m),
 			link.NewStrategy(m),
+			questions.NewStrategy(m),
 		}
 	}
 
@@ -259,7 +357,7 @@ func (m *RegistryDefault) selfServiceStrategies() []interface{} {
 func (m *RegistryDefault) RegistrationStrategies(ctx context.Context) (registrationStrategies registration.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(registration.Strategy); ok {
-			if m.Config(ctx).SelfServiceStrategy(string(s.ID())).Enabled {
+			if m.strategyEnabled(ctx, string(s.ID())) {
 				registrationStrategies = append(registrationStrategies, s)
 			}
 		}
@@ -281,7 +379,7 @@ func (m *RegistryDefault) AllRegistrationStrategies() registration.Strategies {
 func (m *RegistryDefault) LoginStrategies(ctx context.Context) (loginStrategies login.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(login.Strategy); ok {
-			if m.Config(ctx).SelfServiceStrategy(string(s.ID())).Enabled {
+			if m.strategyEnabled(ctx, string(s.ID())) {
 				loginStrategies = append(loginStrategies, s)
 			}
 		}
@@ -289,6 +387,24 @@ func (m *RegistryDefault) LoginStrategies(ctx context.Context) (loginStrategies
 	return
 }
 
+func (m *RegistryDefault) LogoutStrategies(ctx context.Context) (logoutStrategies logout.Strategies) {
+	for _, strategy := range m.selfServiceStrategies() {
+		if s, ok := strategy.(logout.Strategy); ok {
+			if m.Config(ctx).SelfServiceStrategy(string(s.ID())).Enabled {
+				logoutStrategies = append(logoutStrategies, s)
+			}
+		}
+	}
+	return
+}
+
+// strategyEnabled returns true if the strategy is enabled and its feature flag, if any, is enabled for
+// the request.
+func (m *RegistryDefault) strategyEnabled(ctx context.Context, id string) bool {
+	s := m.Config(ctx).SelfServiceStrategy(id)
+	return s.Enabled && m.FeatureFlags().Enabled(ctx, s.FeatureFlag)
+}
+
 func (m *RegistryDefault) AllLoginStrategies() login.Strategies {
 	var loginStrategies []login.Strategy
 	for _, strategy := range m.selfServiceStrategies() {
@@ -323,11 +439,23 @@ func (m *RegistryDefault) WithConfig(c *config.Config) Registry {
 func (m *RegistryDefault) Writer() herodot.Writer {
 	if m.writer == nil {
 		h := herodot.NewJSONWriter(m.Logger())
-		m.writer = h
+		m.writer = errorx.NewRouteWriter(m, h)
 	}
 	return m.writer
 }
 
+// WithClock replaces the clock used to issue and check flows, tokens, and sessions.
+func (m *RegistryDefault) WithClock(c x.Clock) {
+	m.clock = c
+}
+
+func (m *RegistryDefault) Clock() x.Clock {
+	if m.clock == nil {
+		m.clock = x.SystemClock{}
+	}
+	return m.clock
+}
+
 func (m *RegistryDefault) Logger() *logrusx.Logger {
 	if m.l == nil {
 		m.l = logrusx.New("ORY Kratos", config.Version)
@@ -342,6 +470,20 @@ func (m *RegistryDefault) IdentityHandler() *identity.Handler {
 	return m.identityHandler
 }
 
+func (m *RegistryDefault) APIKeyHandler() *apikey.Handler {
+	if m.apiKeyHandler == nil {
+		m.apiKeyHandler = apikey.NewHandler(m)
+	}
+	return m.apiKeyHandler
+}
+
+func (m *RegistryDefault) APIKeyManager() *apikey.Manager {
+	if m.apiKeyManager == nil {
+		m.apiKeyManager = apikey.NewManager(m)
+	}
+	return m.apiKeyManager
+}
+
 func (m *RegistryDefault) SchemaHandler() *schema.Handler {
 	if m.schemaHandler == nil {
 		m.schemaHandler = schema.NewHandler(m)
@@ -356,17 +498,53 @@ func (m *RegistryDefault) SessionHandler() *session.Handler {
 	return m.sessionHandler
 }
 
+// Hasher returns the hasher configured at `hashers.algorithm`, applying the pepper configured at `secrets.pepper`.
 func (m *RegistryDefault) Hasher() hash.Hasher {
 	if m.passwordHasher == nil {
-		if m.c.HasherPasswordHashingAlgorithm() == "bcrypt" {
-			m.passwordHasher = hash.NewHasherBcrypt(m)
-		} else {
-			m.passwordHasher = hash.NewHasherArgon2(m)
+		h, err := m.Hashers().Get(m.c.HasherPasswordHashingAlgorithm())
+		if err != nil {
+			m.Logger().WithError(err).Fatalf("Unable to initialize the password hasher.")
 		}
+		m.passwordHasher = hash.NewPeppered(m, h)
 	}
 	return m.passwordHasher
 }
 
+// Hashers returns the built-in hashers followed by the hashers registered using WithHasher.
+func (m *RegistryDefault) Hashers() *hash.Registry {
+	if m.hashers == nil {
+		m.hashers = hash.NewRegistry(m)
+		for _, h := range m.customHashers {
+			m.hashers.Register(h.name, h.hasher)
+		}
+	}
+	return m.hashers
+}
+
+// WithHasher registers a password hasher which can be selected at `hashers.algorithm`. Registered hashers are
+// used to compare passwords even if they are not selected. It must be called before the registry serves requests.
+func (m *RegistryDefault) WithHasher(name string, h hash.Hasher) {
+	m.customHashers = append(m.customHashers, namedHasher{name: name, hasher: h})
+}
+
+func (m *RegistryDefault) Cipher() cipher.Cipher {
+	if m.cipher == nil {
+		m.cipher = cipher.NewCryptAES(m)
+	}
+	return m.cipher
+}
+
+func (m *RegistryDefault) IdentityKeys() *cipher.IdentityKeys {
+	if m.identityKeys == nil {
+		m.identityKeys = cipher.NewIdentityKeys(m)
+	}
+	return m.identityKeys
+}
+
+func (m *RegistryDefault) DataKeyPersister() cipher.DataKeyPersister {
+	return m.persister
+}
+
 func (m *RegistryDefault) PasswordValidator() password2.Validator {
 	if m.passwordValidator == nil {
 		m.passwordValidator = password2.NewDefaultPasswordValidatorStrategy(m)
@@ -374,6 +552,25 @@ func (m *RegistryDefault) PasswordValidator() password2.Validator {
 	return m.passwordValidator
 }
 
+// WithPasswordValidator replaces the validator which rejects weak or leaked passwords, for example to enforce a
+// corporate password policy. It must be called before the registry serves requests.
+func (m *RegistryDefault) WithPasswordValidator(v password2.Validator) {
+	m.passwordValidator = v
+}
+
+func (m *RegistryDefault) PasswordStrengthEstimator() password2.StrengthEstimator {
+	if m.passwordStrength == nil {
+		m.passwordStrength = password2.NewDefaultStrengthEstimator()
+	}
+	return m.passwordStrength
+}
+
+// WithPasswordStrengthEstimator replaces the estimator which rates passwords at the password strength endpoint.
+// It must be called before the registry serves requests.
+func (m *RegistryDefault) WithPasswordStrengthEstimator(e password2.StrengthEstimator) {
+	m.passwordStrength = e
+}
+
 func (m *RegistryDefault) SelfServiceErrorHandler() *errorx.Handler {
 	if m.errorHandler == nil {
 		m.errorHandler = errorx.NewHandler(m)
@@ -383,7 +580,7 @@ func (m *RegistryDefault) SelfServiceErrorHandler() *errorx.Handler {
 
 func (m *RegistryDefault) CookieManager(ctx context.Context) sessions.Store {
 	cs := sessions.NewCookieStore(m.Config(ctx).SecretsSession()...)
-	cs.Options.Secure = !m.Config(ctx).IsInsecureDevMode()
+	cs.Options.Secure = m.Config(ctx).SessionCookie().Secure
 	cs.Options.HttpOnly = true
 
 	if domain := m.Config(ctx).SessionDomain(); domain != "" {
@@ -407,10 +604,16 @@ func (m *RegistryDefault) CookieManager(ctx context.Context) sessions.Store {
 
 func (m *RegistryDefault) ContinuityCookieManager(ctx context.Context) sessions.Store {
 	// To support hot reloading, this can not be instantiated only once.
+	c := m.Config(ctx).ContinuityCookie()
 	cs := sessions.NewCookieStore(m.Config(ctx).SecretsSession()...)
-	cs.Options.Secure = !m.Config(ctx).IsInsecureDevMode()
+	cs.Options.Secure = c.Secure
 	cs.Options.HttpOnly = true
-	cs.Options.SameSite = http.SameSiteLaxMode
+	cs.Options.SameSite = c.SameSite
+	cs.Options.Domain = c.Domain
+
+	if c.Path != "" {
+		cs.Options.Path = c.Path
+	}
 	return cs
 }
 
@@ -520,7 +723,18 @@ func (m *RegistryDefault) Init(ctx context.Context) error {
 				}
 			}
 
+			if raw := m.Config(ctx).DevFaultInjection(); len(raw) > 0 && m.faultInjector == nil {
+				faults, err := fault.ParseFaults(raw)
+				if err != nil {
+					return backoff.Permanent(err)
+				}
+				m.faultInjector = fault.NewInjector(faults...)
+			}
+
 			m.persister = p.WithNetworkID(net.ID)
+			if m.faultInjector != nil {
+				m.persister = fault.NewPersister(m.persister, m.faultInjector)
+			}
 			return nil
 		}, bc),
 	)
@@ -537,6 +751,231 @@ func (m *RegistryDefault) ContinuityManager() continuity.Manager {
 	return m.continuityManager
 }
 
+func (m *RegistryDefault) ContinuityCleaner() *continuity.Cleaner {
+	if m.continuityCleaner == nil {
+		m.continuityCleaner = continuity.NewCleaner(m)
+	}
+	return m.continuityCleaner
+}
+
+func (m *RegistryDefault) IdempotencyPersister() idempotency.Persister {
+	return m.persister
+}
+
+func (m *RegistryDefault) IdempotencyMiddleware() *idempotency.Middleware {
+	if m.idempotencyMiddleware == nil {
+		m.idempotencyMiddleware = idempotency.NewMiddleware(m)
+	}
+	return m.idempotencyMiddleware
+}
+
+func (m *RegistryDefault) ApprovalPersister() approval.Persister {
+	return m.persister
+}
+
+func (m *RegistryDefault) ApprovalMiddleware() *approval.Middleware {
+	if m.approvalMiddleware == nil {
+		m.approvalMiddleware = approval.NewMiddleware(m)
+	}
+	return m.approvalMiddleware
+}
+
+// AuthenticateAdminRequest implements approval.AuthenticatorProvider. Sessions obtained to act on behalf of another
+// identity are attributed to the actor.
+func (m *RegistryDefault) AuthenticateAdminRequest(r *http.Request) (uuid.UUID, error) {
+	if key := r.Header.Get(approval.HeaderAPIKey); len(key) > 0 {
+		i, _, err := m.APIKeyManager().Verify(r.Context(), key)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return i.ID, nil
+	}
+
+	s, err := m.SessionManager().FetchFromRequest(r.Context(), r)
+	if err != nil {
+		return uuid.Nil, err
+	} else if s.Actor != nil {
+		return s.Actor.IdentityID, nil
+	}
+	return s.IdentityID, nil
+}
+
+func (m *RegistryDefault) ApprovalHandler() *approval.Handler {
+	if m.approvalHandler == nil {
+		m.approvalHandler = approval.NewHandler(m)
+	}
+	return m.approvalHandler
+}
+
+func (m *RegistryDefault) FlowCallbackPersister() callback.Persister {
+	return m.persister
+}
+
+func (m *RegistryDefault) FlowCallbackNotifier() *callback.Notifier {
+	if m.flowCallbackNotifier == nil {
+		m.flowCallbackNotifier = callback.NewNotifier(m)
+	}
+	return m.flowCallbackNotifier
+}
+
+func (m *RegistryDefault) FeatureFlags() *feature.Flags {
+	if m.featureFlags == nil {
+		m.featureFlags = feature.NewFlags(m)
+	}
+	return m.featureFlags
+}
+
+func (m *RegistryDefault) EnumerationLimiter() *ratelimit.Limiter {
+	if m.enumerationLimiter == nil {
+		m.enumerationLimiter = ratelimit.NewLimiter(m)
+	}
+	return m.enumerationLimiter
+}
+
+func (m *RegistryDefault) RateLimitStore() ratelimit.Store {
+	if m.rateLimitStore == nil {
+		m.rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	return m.rateLimitStore
+}
+
+// WithRateLimitStore replaces the in-memory store of the rate limiter's token buckets, for example with a store
+// which is shared between instances. It must be called before the registry serves requests.
+func (m *RegistryDefault) WithRateLimitStore(s ratelimit.Store) {
+	m.rateLimitStore = s
+}
+
+func (m *RegistryDefault) PublicMiddleware() *middleware.Chain {
+	if m.publicMiddleware == nil {
+		m.publicMiddleware = middleware.NewChain(m)
+	}
+	return m.publicMiddleware
+}
+
+func (m *RegistryDefault) IPReputation() *reputation.Checker {
+	if m.ipReputation == nil {
+		m.ipReputation = reputation.NewChecker(m)
+	}
+	return m.ipReputation
+}
+
+func (m *RegistryDefault) GeoPersister() geo.Persister {
+	return m.persister
+}
+
+func (m *RegistryDefault) GeoPolicy() *geo.Enforcer {
+	if m.geoEnforcer == nil {
+		m.geoEnforcer = geo.NewEnforcer(m)
+	}
+	return m.geoEnforcer
+}
+
+func (m *RegistryDefault) GeoHandler() *geo.Handler {
+	if m.geoHandler == nil {
+		m.geoHandler = geo.NewHandler(m)
+	}
+	return m.geoHandler
+}
+
+func (m *RegistryDefault) CapabilityHandler() *capability.Handler {
+	if m.capabilityHandler == nil {
+		m.capabilityHandler = capability.NewHandler(m)
+	}
+	return m.capabilityHandler
+}
+
+func (m *RegistryDefault) AttemptPersister() attempt.Persister {
+	return m.persister
+}
+
+func (m *RegistryDefault) AttemptManager() *attempt.Manager {
+	if m.attemptManager == nil {
+		m.attemptManager = attempt.NewManager(m)
+	}
+	return m.attemptManager
+}
+
+func (m *RegistryDefault) AttemptHandler() *attempt.Handler {
+	if m.attemptHandler == nil {
+		m.attemptHandler = attempt.NewHandler(m)
+	}
+	return m.attemptHandler
+}
+
+func (m *RegistryDefault) InactivityPersister() inactivity.Persister {
+	return m.persister
+}
+
+func (m *RegistryDefault) InactivityManager() *inactivity.Manager {
+	if m.inactivityManager == nil {
+		m.inactivityManager = inactivity.NewManager(m)
+	}
+	return m.inactivityManager
+}
+
+func (m *RegistryDefault) InactivityHandler() *inactivity.Handler {
+	if m.inactivityHandler == nil {
+		m.inactivityHandler = inactivity.NewHandler(m)
+	}
+	return m.inactivityHandler
+}
+
+func (m *RegistryDefault) JobPersister() job.Persister {
+	return m.persister
+}
+
+// JobScheduler returns the scheduler of the built-in jobs followed by the jobs registered using WithJobs.
+func (m *RegistryDefault) JobScheduler() *job.Scheduler {
+	if m.jobScheduler == nil {
+		m.jobScheduler = job.NewScheduler(m, append([]job.Job{
+			job.NewFunc("attempts-cleanup", func(ctx context.Context) error {
+				_, err := m.AttemptManager().DeleteExpired(ctx)
+				return err
+			}),
+			job.NewFunc("continuity-cleanup", func(ctx context.Context) error {
+				_, err := m.ContinuityCleaner().Cleanup(ctx)
+				return err
+			}),
+			job.NewFunc("identity-inactivity", func(ctx context.Context) error {
+				_, err := m.InactivityManager().Enforce(ctx)
+				return err
+			}),
+			job.NewFunc("identity-state-changes", func(ctx context.Context) error {
+				_, err := m.IdentityManager().ExecuteScheduledStateChanges(ctx)
+				return err
+			}),
+			job.NewFunc("link-expiry", func(ctx context.Context) error {
+				_, err := m.LinkExpiryNotifier().Notify(ctx)
+				return err
+			}),
+			job.NewFunc("flow-callbacks", func(ctx context.Context) error {
+				_, err := m.FlowCallbackNotifier().Retry(ctx)
+				return err
+			}),
+			job.NewFunc("courier-redaction", func(ctx context.Context) error {
+				_, err := m.Courier(ctx).RedactSentMessages(ctx)
+				return err
+			}),
+			job.NewFunc("courier-deliverability-cleanup", func(ctx context.Context) error {
+				_, err := m.Courier(ctx).DeleteExpiredDeliveryEvents(ctx)
+				return err
+			}),
+			job.NewFunc("data-key-cleanup", func(ctx context.Context) error {
+				_, err := m.IdentityKeys().DeleteOrphaned(ctx)
+				return err
+			}),
+		}, m.jobs...)...)
+	}
+	return m.jobScheduler
+}
+
+func (m *RegistryDefault) JobHandler() *job.Handler {
+	if m.jobHandler == nil {
+		m.jobHandler = job.NewHandler(m)
+	}
+	return m.jobHandler
+}
+
 func (m *RegistryDefault) ContinuityPersister() continuity.Persister {
 	return m.persister
 }
@@ -577,10 +1016,48 @@ func (m *RegistryDefault) CourierPersister() courier.Persister {
 	return m.persister
 }
 
+func (m *RegistryDefault) CommunicationPreferencesPersister() courier.PreferencesPersister {
+	return m.persister
+}
+
+func (m *RegistryDefault) IdentityNotePersister() identity.NotePersister {
+	return m.persister
+}
+
+func (m *RegistryDefault) IdentityRelationshipPersister() identity.RelationshipPersister {
+	return m.persister
+}
+
+func (m *RegistryDefault) ScheduledStateChangePersister() identity.ScheduledStateChangePersister {
+	return m.persister
+}
+
+func (m *RegistryDefault) DeliverabilityPersister() courier.DeliverabilityPersister {
+	return m.persister
+}
+
+func (m *RegistryDefault) CourierHandler() *courier.Handler {
+	if m.courierHandler == nil {
+		m.courierHandler = courier.NewHandler(m)
+	}
+	return m.courierHandler
+}
+
 func (m *RegistryDefault) RecoveryTokenPersister() link.RecoveryTokenPersister {
 	return m.Persister()
 }
 
+func (m *RegistryDefault) LinkExpiryPersister() link.ExpiryPersister {
+	return m.persister
+}
+
+func (m *RegistryDefault) LinkExpiryNotifier() *link.ExpiryNotifier {
+	if m.linkExpiryNotifier == nil {
+		m.linkExpiryNotifier = link.NewExpiryNotifier(m)
+	}
+	return m.linkExpiryNotifier
+}
+
 func (m *RegistryDefault) VerificationTokenPersister() link.VerificationTokenPersister {
 	return m.Persister()
 }
@@ -589,6 +1066,25 @@ func (m *RegistryDefault) Persister() persistence.Persister {
 	return m.persister
 }
 
+// WithFaultInjector routes persister and courier calls through the injector so that they can be delayed or made to
+// fail. It is meant for tests and for the "--dev" mode only and may be called before or after Init.
+func (m *RegistryDefault) WithFaultInjector(i *fault.Injector) {
+	m.faultInjector = i
+	if m.persister == nil {
+		return
+	}
+
+	if fp, ok := m.persister.(*fault.Persister); ok {
+		m.persister = fp.Persister
+	}
+	m.persister = fault.NewPersister(m.persister, i)
+}
+
+// FaultInjector returns the injector set by WithFaultInjector or nil.
+func (m *RegistryDefault) FaultInjector() *fault.Injector {
+	return m.faultInjector
+}
+
 func (m *RegistryDefault) Ping() error {
 	return m.persister.Ping()
 }
@@ -604,6 +1100,41 @@ func (m *RegistryDefault) GenerateCSRFToken(r *http.Request) string {
 	return m.csrfTokenGenerator(r)
 }
 
+// WithIdentityManagerMiddleware registers middlewares which wrap the identity manager's create, update, and
+// delete operations. It must be called before the registry serves requests.
+func (m *RegistryDefault) WithIdentityManagerMiddleware(mws ...identity.ManagerMiddleware) {
+	m.identityManagerMiddlewares = append(m.identityManagerMiddlewares, mws...)
+}
+
+func (m *RegistryDefault) IdentityManagerMiddlewares() []identity.ManagerMiddleware {
+	return m.identityManagerMiddlewares
+}
+
+// WithIdentitySchemaExtensions registers additional extensions which run whenever identity traits are validated. It
+// must be called before the registry serves requests.
+func (m *RegistryDefault) WithIdentitySchemaExtensions(extensions ...identity.SchemaExtensionFactory) {
+	m.identitySchemaExtensions = append(m.identitySchemaExtensions, extensions...)
+}
+
+// WithJobs registers additional background jobs. It must be called before the job scheduler is used.
+func (m *RegistryDefault) WithJobs(jobs ...job.Job) {
+	m.jobs = append(m.jobs, jobs...)
+}
+
+// IdentitySchemaExtensions returns the Jsonnet extensions from the configuration followed by the extensions registered
+// using WithIdentitySchemaExtensions.
+func (m *RegistryDefault) IdentitySchemaExtensions(ctx context.Context) []identity.SchemaExtensionFactory {
+	configured := m.Config(ctx).IdentitySchemaExtensions()
+	extensions := make([]identity.SchemaExtensionFactory, 0, len(configured)+len(m.identitySchemaExtensions))
+	for k := range configured {
+		c := configured[k]
+		extensions = append(extensions, func(_ context.Context, i *identity.Identity) schema.Extension {
+			return identity.NewSchemaExtensionJsonnet(i, c.Name, c.URL, fetcher.NewFetcher())
+		})
+	}
+	return append(extensions, m.identitySchemaExtensions...)
+}
+
 func (m *RegistryDefault) IdentityManager() *identity.Manager {
 	if m.identityManager == nil {
 		m.identityManager = identity.NewManager(m)
@@ -1,8 +1,11 @@
 package driver
 
 import (
+	"context"
+
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/webhook"
 )
 
 func (m *RegistryDefault) HookVerifier() *hook.Verifier {
@@ -26,17 +29,53 @@ func (m *RegistryDefault) HookSessionDestroyer() *hook.SessionDestroyer {
 	return m.hookSessionDestroyer
 }
 
+func (m *RegistryDefault) WebhookClient() *webhook.Client {
+	if m.webhookClient == nil {
+		m.webhookClient = webhook.NewClient(m)
+	}
+	return m.webhookClient
+}
+
+func (m *RegistryDefault) WebhookHandler() *webhook.Handler {
+	if m.webhookHandler == nil {
+		m.webhookHandler = webhook.NewHandler(m)
+	}
+	return m.webhookHandler
+}
+
 func (m *RegistryDefault) WithHooks(hooks map[string]func(config.SelfServiceHook) interface{}) {
 	m.injectedSelfserviceHooks = hooks
 }
 
-func (m *RegistryDefault) getHooks(credentialsType string, configs []config.SelfServiceHook) (i []interface{}) {
+func (m *RegistryDefault) getHooks(ctx context.Context, credentialsType string, configs []config.SelfServiceHook) (i []interface{}) {
 	for _, h := range configs {
+		if !m.FeatureFlags().Enabled(ctx, h.FeatureFlag) {
+			continue
+		}
+
 		switch h.Name {
 		case hook.KeySessionIssuer:
 			i = append(i, m.HookSessionIssuer())
 		case hook.KeySessionDestroyer:
 			i = append(i, m.HookSessionDestroyer())
+		case hook.KeyWebHook:
+			h, err := hook.NewWebHook(m, h.Config)
+			if err != nil {
+				m.l.WithError(err).
+					WithField("for", credentialsType).
+					Errorf("Unable to initialize the web hook")
+				continue
+			}
+			i = append(i, h)
+		case hook.KeyIdentityEnricher:
+			h, err := hook.NewIdentityEnricher(m, h.Config)
+			if err != nil {
+				m.l.WithError(err).
+					WithField("for", credentialsType).
+					Errorf("Unable to initialize the identity enrichment hook")
+				continue
+			}
+			i = append(i, h)
 		default:
 			var found bool
 			for name, m := range m.injectedSelfserviceHooks {
@@ -25,7 +25,7 @@ func (m *RegistryDefault) RecoveryHandler() *recovery.Handler {
 func (m *RegistryDefault) RecoveryStrategies(ctx context.Context) (recoveryStrategies recovery.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(recovery.Strategy); ok {
-			if m.Config(ctx).SelfServiceStrategy(s.RecoveryStrategyID()).Enabled {
+			if m.strategyEnabled(ctx, s.RecoveryStrategyID()) {
 				recoveryStrategies = append(recoveryStrategies, s)
 			}
 		}
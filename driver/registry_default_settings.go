@@ -7,7 +7,7 @@ import (
 )
 
 func (m *RegistryDefault) PostSettingsPrePersistHooks(ctx context.Context, settingsType string) (b []settings.PostHookPrePersistExecutor) {
-	for _, v := range m.getHooks(settingsType, m.Config(ctx).SelfServiceFlowSettingsAfterHooks(settingsType)) {
+	for _, v := range m.getHooks(ctx, settingsType, m.Config(ctx).SelfServiceFlowSettingsAfterHooks(settingsType)) {
 		if hook, ok := v.(settings.PostHookPrePersistExecutor); ok {
 			b = append(b, hook)
 		}
@@ -20,7 +20,7 @@ func (m *RegistryDefault) PostSettingsPostPersistHooks(ctx context.Context, sett
 		b = append(b, m.HookVerifier())
 	}
 
-	for _, v := range m.getHooks(settingsType, m.Config(ctx).SelfServiceFlowSettingsAfterHooks(settingsType)) {
+	for _, v := range m.getHooks(ctx, settingsType, m.Config(ctx).SelfServiceFlowSettingsAfterHooks(settingsType)) {
 		if hook, ok := v.(settings.PostHookPostPersistExecutor); ok {
 			b = append(b, hook)
 		}
@@ -49,10 +49,17 @@ func (m *RegistryDefault) SettingsFlowErrorHandler() *settings.ErrorHandler {
 	return m.selfserviceSettingsErrorHandler
 }
 
+func (m *RegistryDefault) SettingsPrivilegedCodeManager() *settings.PrivilegedCodeManager {
+	if m.selfserviceSettingsPrivilegedCodeManager == nil {
+		m.selfserviceSettingsPrivilegedCodeManager = settings.NewPrivilegedCodeManager(m)
+	}
+	return m.selfserviceSettingsPrivilegedCodeManager
+}
+
 func (m *RegistryDefault) SettingsStrategies(ctx context.Context) (profileStrategies settings.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(settings.Strategy); ok {
-			if m.Config(ctx).SelfServiceStrategy(s.SettingsStrategyID()).Enabled {
+			if m.strategyEnabled(ctx, s.SettingsStrategyID()) {
 				profileStrategies = append(profileStrategies, s)
 			}
 		}
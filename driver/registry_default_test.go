@@ -3,6 +3,8 @@ package driver_test
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/ory/kratos/driver"
@@ -13,12 +15,14 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/selfservice/flow/login"
 	"github.com/ory/kratos/selfservice/flow/registration"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/x"
 )
 
 func TestDriverDefault_Hooks(t *testing.T) {
@@ -112,6 +116,10 @@ func TestDriverDefault_Strategies(t *testing.T) {
 			{prep: func(conf *config.Config) {
 				conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".link.enabled", true)
 			}, expect: []string{"link"}},
+			{prep: func(conf *config.Config) {
+				conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".link.enabled", true)
+				conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".questions.enabled", true)
+			}, expect: []string{"link", "questions"}},
 		} {
 			t.Run(fmt.Sprintf("run=%d", k), func(t *testing.T) {
 				conf, reg := internal.NewFastRegistryWithMocks(t)
@@ -196,7 +204,7 @@ func TestDefaultRegistry_AllStrategies(t *testing.T) {
 	_, reg := internal.NewFastRegistryWithMocks(t)
 
 	t.Run("case=all login strategies", func(t *testing.T) {
-		expects := []string{"password", "oidc"}
+		expects := []string{"password", "oidc", "siwe"}
 		s := reg.AllLoginStrategies()
 		require.Len(t, s, len(expects))
 		for k, e := range expects {
@@ -205,7 +213,7 @@ func TestDefaultRegistry_AllStrategies(t *testing.T) {
 	})
 
 	t.Run("case=all registration strategies", func(t *testing.T) {
-		expects := []string{"password", "oidc"}
+		expects := []string{"password", "oidc", "siwe"}
 		s := reg.AllRegistrationStrategies()
 		require.Len(t, s, len(expects))
 		for k, e := range expects {
@@ -214,7 +222,7 @@ func TestDefaultRegistry_AllStrategies(t *testing.T) {
 	})
 
 	t.Run("case=all settings strategies", func(t *testing.T) {
-		expects := []string{"password", "oidc", "profile"}
+		expects := []string{"password", "oidc", "profile", "username", "preferences", "siwe"}
 		s := reg.AllSettingsStrategies()
 		require.Len(t, s, len(expects))
 		for k, e := range expects {
@@ -223,7 +231,7 @@ func TestDefaultRegistry_AllStrategies(t *testing.T) {
 	})
 
 	t.Run("case=all recovery strategies", func(t *testing.T) {
-		expects := []string{"link"}
+		expects := []string{"link", "questions"}
 		s := reg.AllRecoveryStrategies()
 		require.Len(t, s, len(expects))
 		for k, e := range expects {
@@ -231,3 +239,40 @@ func TestDefaultRegistry_AllStrategies(t *testing.T) {
 		}
 	})
 }
+
+func TestDefaultRegistry_Hashers(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyHasherAlgorithm, "custom")
+
+	custom := hash.NewHasherBcrypt(reg)
+	reg.WithHasher("custom", custom)
+
+	h, err := reg.Hashers().Get("custom")
+	require.NoError(t, err)
+	assert.Same(t, custom, h)
+
+	hs, err := reg.Hasher().Generate(context.Background(), []byte("secret"))
+	require.NoError(t, err)
+	assert.True(t, hash.IsBcryptHash(hs))
+	assert.Equal(t, []string{"argon2", "bcrypt", "custom"}, reg.Hashers().Names())
+}
+
+func TestDefaultRegistry_ProfilingRoutes(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			conf, reg := internal.NewFastRegistryWithMocks(t)
+			conf.MustSet(config.ViperKeyAdminProfilingEnabled, enabled)
+
+			admin := x.NewRouterAdmin()
+			reg.RegisterAdminRoutes(context.Background(), admin)
+
+			w := httptest.NewRecorder()
+			admin.ServeHTTP(w, httptest.NewRequest("GET", x.RouteAdminProfiling+"/", nil))
+			if enabled {
+				assert.Equal(t, http.StatusOK, w.Code)
+			} else {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			}
+		})
+	}
+}
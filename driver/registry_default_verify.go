@@ -47,7 +47,7 @@ func (m *RegistryDefault) LinkSender() *link.Sender {
 func (m *RegistryDefault) VerificationStrategies(ctx context.Context) (verificationStrategies verification.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(verification.Strategy); ok {
-			if m.Config(ctx).SelfServiceStrategy(s.VerificationStrategyID()).Enabled {
+			if m.strategyEnabled(ctx, s.VerificationStrategyID()) {
 				verificationStrategies = append(verificationStrategies, s)
 			}
 		}
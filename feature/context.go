@@ -0,0 +1,34 @@
+package feature
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+)
+
+type (
+	identityContextKey struct{}
+	flowContextKey     struct{}
+)
+
+// WithIdentity makes feature flags evaluated while handling the request roll out on the identity.
+func WithIdentity(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, id)
+}
+
+// WithFlow makes feature flags evaluated while handling the request roll out on the self-service flow.
+func WithFlow(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, flowContextKey{}, id)
+}
+
+// IdentityFromContext returns the identity ID added by WithIdentity or uuid.Nil.
+func IdentityFromContext(ctx context.Context) uuid.UUID {
+	id, _ := ctx.Value(identityContextKey{}).(uuid.UUID)
+	return id
+}
+
+// FlowFromContext returns the flow ID added by WithFlow or uuid.Nil.
+func FlowFromContext(ctx context.Context) uuid.UUID {
+	id, _ := ctx.Value(flowContextKey{}).(uuid.UUID)
+	return id
+}
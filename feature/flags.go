@@ -0,0 +1,163 @@
+package feature
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+// PasswordBreachCheck gates checking new passwords against the Have I Been Pwned database.
+const PasswordBreachCheck = "password_breach_check"
+
+// maxRemoteResponseSize limits how much of the remote provider's response is read.
+const maxRemoteResponseSize = 1 << 20
+
+type (
+	flagsDependencies interface {
+		config.Provider
+		x.LoggingProvider
+		x.ClockProvider
+	}
+	Provider interface {
+		FeatureFlags() *Flags
+	}
+	// Flags decides whether a feature is enabled for the identity or flow of a request. Flags are
+	// configured at `feature_flags.flags` and optionally fetched from `feature_flags.remote.url`.
+	Flags struct {
+		d flagsDependencies
+		c *http.Client
+
+		sync.Mutex
+		remote    []config.FeatureFlag
+		remoteURL string
+		fetchedAt time.Time
+	}
+)
+
+func NewFlags(d flagsDependencies) *Flags {
+	return &Flags{d: d, c: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Enabled returns true if the feature flag with the given name is enabled for the identity or flow
+// added to the context using WithIdentity or WithFlow. Features without a flag, or with a flag which
+// is not defined, are always enabled.
+func (f *Flags) Enabled(ctx context.Context, name string) bool {
+	if name == "" {
+		return true
+	}
+
+	flag, ok := f.find(ctx, name)
+	if !ok {
+		return true
+	} else if !flag.Enabled || flag.RolloutPercentage <= 0 {
+		return false
+	} else if flag.RolloutPercentage >= 100 {
+		return true
+	}
+
+	key := IdentityFromContext(ctx)
+	if flag.RolloutKey == config.FeatureFlagRolloutKeyFlowID {
+		key = FlowFromContext(ctx)
+	}
+	if key == uuid.Nil {
+		return false
+	}
+
+	return bucket(name, key) < flag.RolloutPercentage
+}
+
+// bucket deterministically maps the ID to a number between 0 and 99. The flag's name is part of the
+// input so that the same identities are not always the first to receive every feature.
+func bucket(name string, id uuid.UUID) int {
+	h := sha256.Sum256(append([]byte(name+":"), id.Bytes()...))
+	return int(binary.BigEndian.Uint32(h[:4]) % 100)
+}
+
+func (f *Flags) find(ctx context.Context, name string) (config.FeatureFlag, bool) {
+	for _, flag := range f.remoteFlags(ctx) {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+
+	for _, flag := range f.d.Config(ctx).FeatureFlags() {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+
+	return config.FeatureFlag{}, false
+}
+
+// remoteFlags returns the flags of the remote provider, fetching them again once the refresh interval
+// has passed. If fetching fails, the flags fetched last are returned.
+func (f *Flags) remoteFlags(ctx context.Context) []config.FeatureFlag {
+	conf := f.d.Config(ctx)
+	u := conf.FeatureFlagsRemoteURL()
+	if u == nil {
+		return nil
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	now := f.d.Clock().Now()
+	if f.remoteURL == u.String() && now.Sub(f.fetchedAt) < conf.FeatureFlagsRemoteRefreshInterval() {
+		return f.remote
+	}
+
+	flags, err := f.fetch(ctx, u.String())
+	if err != nil {
+		f.d.Logger().WithError(err).WithField("url", u.String()).Warn("Unable to fetch feature flags from the remote provider.")
+		if f.remoteURL != u.String() {
+			f.remote = nil
+		}
+	} else {
+		f.remote = flags
+	}
+
+	// Failures are not retried before the refresh interval passed to keep an unavailable provider from
+	// slowing down every request.
+	f.remoteURL, f.fetchedAt = u.String(), now
+	return f.remote
+}
+
+func (f *Flags) fetch(ctx context.Context, target string) ([]config.FeatureFlag, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := f.c.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("expected status code 200 but got %d", res.StatusCode)
+	}
+
+	var body struct {
+		Flags json.RawMessage `json:"flags"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxRemoteResponseSize)).Decode(&body); err != nil {
+		return nil, errors.WithStack(err)
+	} else if len(body.Flags) == 0 {
+		return nil, nil
+	}
+
+	return config.ParseFeatureFlags(body.Flags)
+}
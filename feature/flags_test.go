@@ -0,0 +1,112 @@
+package feature_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/feature"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestFlags(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	clock := x.NewFrozenClock(time.Now())
+	reg.WithClock(clock)
+	flags := reg.FeatureFlags()
+	ctx := context.Background()
+
+	setFlags := func(t *testing.T, values ...map[string]interface{}) {
+		conf.MustSet(config.ViperKeyFeatureFlags, values)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyFeatureFlags, []map[string]interface{}{})
+		})
+	}
+
+	// rollout counts for how many of n random IDs the flag is enabled.
+	rollout := func(name string, n int, with func(context.Context) context.Context) (enabled int) {
+		for i := 0; i < n; i++ {
+			if flags.Enabled(with(ctx), name) {
+				enabled++
+			}
+		}
+		return
+	}
+
+	t.Run("case=features without a defined flag are enabled", func(t *testing.T) {
+		assert.True(t, flags.Enabled(ctx, ""))
+		assert.True(t, flags.Enabled(ctx, "unknown"))
+	})
+
+	t.Run("case=disabled flags are disabled for everyone", func(t *testing.T) {
+		setFlags(t, map[string]interface{}{"name": "off", "enabled": false})
+		assert.False(t, flags.Enabled(feature.WithIdentity(ctx, x.NewUUID()), "off"))
+	})
+
+	t.Run("case=flags without a rollout percentage are enabled for everyone", func(t *testing.T) {
+		setFlags(t, map[string]interface{}{"name": "on"})
+		assert.True(t, flags.Enabled(ctx, "on"))
+	})
+
+	t.Run("case=rolls out on identities", func(t *testing.T) {
+		setFlags(t, map[string]interface{}{"name": "partial", "rollout_percentage": 20})
+
+		id := x.NewUUID()
+		first := flags.Enabled(feature.WithIdentity(ctx, id), "partial")
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, first, flags.Enabled(feature.WithFlow(feature.WithIdentity(ctx, id), x.NewUUID()), "partial"))
+		}
+
+		enabled := rollout("partial", 2000, func(ctx context.Context) context.Context {
+			return feature.WithIdentity(ctx, x.NewUUID())
+		})
+		assert.InDelta(t, 400, enabled, 100)
+
+		assert.False(t, flags.Enabled(feature.WithFlow(ctx, x.NewUUID()), "partial"), "flows without an identity are not part of the rollout")
+	})
+
+	t.Run("case=rolls out on flows", func(t *testing.T) {
+		setFlags(t, map[string]interface{}{"name": "partial", "rollout_percentage": 50, "rollout_key": "flow_id"})
+
+		enabled := rollout("partial", 2000, func(ctx context.Context) context.Context {
+			return feature.WithFlow(ctx, x.NewUUID())
+		})
+		assert.InDelta(t, 1000, enabled, 150)
+		assert.False(t, flags.Enabled(feature.WithIdentity(ctx, x.NewUUID()), "partial"))
+	})
+
+	t.Run("case=remote flags take precedence", func(t *testing.T) {
+		var requests int32
+		var status int32 = http.StatusOK
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.WriteHeader(int(atomic.LoadInt32(&status)))
+			fmt.Fprint(w, `{"flags":[{"name":"remote","enabled":false}]}`)
+		}))
+		t.Cleanup(ts.Close)
+
+		setFlags(t, map[string]interface{}{"name": "remote"}, map[string]interface{}{"name": "local", "enabled": false})
+		conf.MustSet(config.ViperKeyFeatureFlagsRemoteURL, ts.URL)
+		conf.MustSet(config.ViperKeyFeatureFlagsRemoteRefreshInterval, "1m")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyFeatureFlagsRemoteURL, "")
+		})
+
+		assert.False(t, flags.Enabled(ctx, "remote"))
+		assert.False(t, flags.Enabled(ctx, "local"))
+		assert.EqualValues(t, 1, atomic.LoadInt32(&requests), "flags are cached until the refresh interval passed")
+
+		atomic.StoreInt32(&status, http.StatusInternalServerError)
+		clock.Advance(time.Minute)
+		assert.False(t, flags.Enabled(ctx, "remote"), "the flags fetched last are used if the provider fails")
+		assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+	})
+}
@@ -0,0 +1,289 @@
+package geo
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/courier"
+	templates "github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/reputation"
+	"github.com/ory/kratos/x"
+)
+
+// maxRemoteResponseSize limits how much of the GeoIP API's response is read.
+const maxRemoteResponseSize = 1 << 20
+
+const (
+	// ReasonCountry means that a rule lists the login's country.
+	ReasonCountry = "country"
+	// ReasonCountryChanged means that the login comes from a different country than the identity's last login.
+	ReasonCountryChanged = "country_changed"
+	// ReasonIdentityBlockedCountry means that the identity's policy blocks the login's country.
+	ReasonIdentityBlockedCountry = "identity_blocked_country"
+)
+
+type (
+	enforcerDependencies interface {
+		config.Provider
+		courier.Provider
+		x.LoggingProvider
+		x.ClockProvider
+
+		PersistenceProvider
+	}
+	EnforcerProvider interface {
+		GeoPolicy() *Enforcer
+	}
+	// Enforcer decides which of the rules configured at `geo_policies.rules` apply to a login, taking the
+	// identity's policy into account.
+	Enforcer struct {
+		d enforcerDependencies
+		c *http.Client
+
+		sync.Mutex
+		countries map[string]cachedCountry
+	}
+	// Decision is the result of evaluating the rules for a login.
+	Decision struct {
+		// Country is the ISO 3166-1 alpha-2 code of the login's country or empty if it could not be determined.
+		Country string
+		// Action is the strictest action of the rules which apply or empty if none applies.
+		Action config.GeoPolicyAction
+		// Reason is one of ReasonCountry, ReasonCountryChanged, or ReasonIdentityBlockedCountry if an action
+		// applies.
+		Reason string
+		// PreviousCountry is the country of the identity's last login.
+		PreviousCountry string
+	}
+	cachedCountry struct {
+		country   string
+		fetchedAt time.Time
+	}
+)
+
+var severity = map[config.GeoPolicyAction]int{
+	config.GeoPolicyActionNotify:     1,
+	config.GeoPolicyActionRequireMFA: 2,
+	config.GeoPolicyActionBlock:      3,
+}
+
+func NewEnforcer(d enforcerDependencies) *Enforcer {
+	return &Enforcer{
+		d:         d,
+		c:         &http.Client{Timeout: 10 * time.Second},
+		countries: map[string]cachedCountry{},
+	}
+}
+
+// Evaluate returns which action applies to the identity signing in using the request. Rules do not apply if the
+// country can not be determined, if the identity is exempt, or if its policy allows the country.
+func (e *Enforcer) Evaluate(r *http.Request, i *identity.Identity) (*Decision, error) {
+	d := &Decision{Country: e.Country(r)}
+	if d.Country == "" {
+		return d, nil
+	}
+
+	p, err := e.identityPolicy(r.Context(), i.ID)
+	if err != nil {
+		return nil, err
+	}
+	d.PreviousCountry = p.LastCountry
+
+	if p.Exempt || contains(p.AllowedCountries, d.Country) {
+		return d, nil
+	} else if contains(p.BlockedCountries, d.Country) {
+		d.Action, d.Reason = config.GeoPolicyActionBlock, ReasonIdentityBlockedCountry
+		return d, nil
+	}
+
+	for _, rule := range e.d.Config(r.Context()).GeoPoliciesRules() {
+		var reason string
+		if contains(rule.Countries, d.Country) {
+			reason = ReasonCountry
+		} else if rule.CountryChanged && p.LastCountry != "" && p.LastCountry != d.Country {
+			reason = ReasonCountryChanged
+		} else {
+			continue
+		}
+
+		if severity[rule.Action] > severity[d.Action] {
+			d.Action, d.Reason = rule.Action, reason
+		}
+	}
+
+	if d.Action != "" {
+		e.d.Logger().
+			WithField("identity_id", i.ID).
+			WithField("country", d.Country).
+			WithField("previous_country", d.PreviousCountry).
+			WithField("action", d.Action).
+			WithField("reason", d.Reason).
+			Info("A geo policy applies to the login.")
+	}
+	return d, nil
+}
+
+// Succeeded records the country of the identity's login and, if the decision is to notify, notifies the identity
+// by email. Errors are logged but not returned because the identity already signed in.
+func (e *Enforcer) Succeeded(ctx context.Context, i *identity.Identity, d *Decision) {
+	if d == nil || d.Country == "" {
+		return
+	}
+
+	if d.Country != d.PreviousCountry {
+		p, err := e.identityPolicy(ctx, i.ID)
+		if err == nil {
+			p.LastCountry = d.Country
+			err = e.d.GeoPersister().UpsertIdentityPolicy(ctx, p)
+		}
+		if err != nil {
+			e.d.Logger().
+				WithError(err).
+				WithField("identity_id", i.ID).
+				Warn("Unable to record the country of the login.")
+		}
+	}
+
+	if d.Action != config.GeoPolicyActionNotify {
+		return
+	}
+
+	address := VerifiedEmailAddress(i)
+	if address == nil {
+		e.d.Logger().
+			WithField("identity_id", i.ID).
+			Info("Not notifying the identity of the login because it has no verified email address.")
+		return
+	}
+
+	if _, err := e.d.Courier(ctx).QueueEmail(ctx, templates.NewLoginNewCountry(e.d.Config(ctx), &templates.LoginNewCountryModel{
+		To:              address.Value,
+		Country:         d.Country,
+		PreviousCountry: d.PreviousCountry,
+		SignedInAt:      e.d.Clock().Now().UTC(),
+	})); err != nil {
+		e.d.Logger().
+			WithError(err).
+			WithField("identity_id", i.ID).
+			Warn("Unable to notify the identity of the login.")
+	}
+}
+
+// identityPolicy returns the policy of the identity or an empty policy if it has none.
+func (e *Enforcer) identityPolicy(ctx context.Context, id uuid.UUID) (*IdentityPolicy, error) {
+	p, err := e.d.GeoPersister().GetIdentityPolicy(ctx, id)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return &IdentityPolicy{IdentityID: id}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 code of the request's country, read from `geo_policies.country_header`
+// or resolved using the GeoIP API. An empty string is returned if it can not be determined.
+func (e *Enforcer) Country(r *http.Request) string {
+	conf := e.d.Config(r.Context())
+	if header := conf.GeoPoliciesCountryHeader(); header != "" {
+		if country := strings.ToUpper(strings.TrimSpace(r.Header.Get(header))); isCountryCode(country) {
+			return country
+		}
+		return ""
+	}
+
+	api := conf.GeoPoliciesAPI()
+	ip := reputation.ClientIP(r, conf.IPReputationClientIPHeader())
+	if api == nil || ip == "" {
+		return ""
+	}
+	return e.resolve(r.Context(), api, ip)
+}
+
+// resolve returns the country the GeoIP API resolves the IP address to. Countries are cached for the
+// configured TTL.
+func (e *Enforcer) resolve(ctx context.Context, api *config.GeoAPI, ip string) string {
+	now := e.d.Clock().Now()
+
+	e.Lock()
+	cached, ok := e.countries[ip]
+	e.Unlock()
+	if ok && now.Sub(cached.fetchedAt) < api.CacheTTL {
+		return cached.country
+	}
+
+	country, err := e.fetchCountry(ctx, api, ip)
+	if err != nil {
+		e.d.Logger().WithError(err).WithField("ip", ip).Warn("Unable to resolve the IP address' country using the GeoIP API.")
+	}
+
+	// Failures are cached as well to keep an unavailable API from slowing down every login.
+	e.Lock()
+	defer e.Unlock()
+	for k, c := range e.countries {
+		if now.Sub(c.fetchedAt) >= api.CacheTTL {
+			delete(e.countries, k)
+		}
+	}
+	e.countries[ip] = cachedCountry{country: country, fetchedAt: now}
+	return country
+}
+
+func (e *Enforcer) fetchCountry(ctx context.Context, api *config.GeoAPI, ip string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, api.Timeout)
+	defer cancel()
+
+	u := *api.URL
+	q := u.Query()
+	q.Set("ip", ip)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := e.c.Do(req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Errorf("expected status code 200 but got %d", res.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxRemoteResponseSize))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	country := strings.ToUpper(gjson.GetBytes(body, api.CountryPath).String())
+	if !isCountryCode(country) {
+		return "", errors.Errorf("expected an ISO 3166-1 alpha-2 country code at %q in the response but got %q", api.CountryPath, country)
+	}
+	return country, nil
+}
+
+// VerifiedEmailAddress returns the identity's first verified email address or nil if it has none.
+func VerifiedEmailAddress(i *identity.Identity) *identity.VerifiableAddress {
+	for k, a := range i.VerifiableAddresses {
+		if a.Via == identity.VerifiableAddressTypeEmail && a.Verified {
+			return &i.VerifiableAddresses[k]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package geo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/geo"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/x"
+)
+
+func TestEnforcer(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeyGeoPoliciesCountryHeader, "CF-IPCountry")
+
+	setRules := func(t *testing.T, rules ...map[string]interface{}) {
+		conf.MustSet(config.ViperKeyGeoPoliciesRules, rules)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyGeoPoliciesRules, []map[string]interface{}{})
+		})
+	}
+
+	createIdentity := func(t *testing.T) *identity.Identity {
+		i := identity.NewIdentity("")
+		email := x.NewUUID().String() + "@ory.sh"
+		i.Traits = identity.Traits(`{"email":"` + email + `"}`)
+		i.VerifiableAddresses = []identity.VerifiableAddress{{Value: email, Via: identity.VerifiableAddressTypeEmail, Verified: true, Status: identity.VerifiableAddressStatusCompleted}}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+		return i
+	}
+
+	request := func(country string) *http.Request {
+		r := httptest.NewRequest("POST", "/self-service/login", nil)
+		if country != "" {
+			r.Header.Set("CF-IPCountry", country)
+		}
+		return r
+	}
+
+	evaluate := func(t *testing.T, country string, i *identity.Identity) *geo.Decision {
+		d, err := reg.GeoPolicy().Evaluate(request(country), i)
+		require.NoError(t, err)
+		return d
+	}
+
+	t.Run("case=no rule applies without a country", func(t *testing.T) {
+		setRules(t, map[string]interface{}{"countries": []string{"KP"}, "action": "block"})
+
+		d := evaluate(t, "", createIdentity(t))
+		assert.Empty(t, d.Country)
+		assert.Empty(t, d.Action)
+
+		d = evaluate(t, "not-a-country", createIdentity(t))
+		assert.Empty(t, d.Country)
+		assert.Empty(t, d.Action)
+	})
+
+	t.Run("case=applies the strictest rule listing the country", func(t *testing.T) {
+		setRules(t,
+			map[string]interface{}{"countries": []string{"kp", "ir"}, "action": "notify"},
+			map[string]interface{}{"countries": []string{"KP"}, "action": "block"},
+			map[string]interface{}{"countries": []string{"KP"}, "action": "require_mfa"},
+		)
+
+		d := evaluate(t, "kp", createIdentity(t))
+		assert.Equal(t, "KP", d.Country)
+		assert.Equal(t, config.GeoPolicyActionBlock, d.Action)
+		assert.Equal(t, geo.ReasonCountry, d.Reason)
+
+		d = evaluate(t, "IR", createIdentity(t))
+		assert.Equal(t, config.GeoPolicyActionNotify, d.Action)
+
+		d = evaluate(t, "DE", createIdentity(t))
+		assert.Empty(t, d.Action)
+	})
+
+	t.Run("case=applies rules to changed countries", func(t *testing.T) {
+		setRules(t, map[string]interface{}{"country_changed": true, "action": "require_mfa"})
+		i := createIdentity(t)
+
+		d := evaluate(t, "DE", i)
+		assert.Empty(t, d.Action, "the first login has no previous country")
+		reg.GeoPolicy().Succeeded(ctx, i, d)
+
+		d = evaluate(t, "DE", i)
+		assert.Empty(t, d.Action)
+		assert.Equal(t, "DE", d.PreviousCountry)
+
+		d = evaluate(t, "FR", i)
+		assert.Equal(t, config.GeoPolicyActionRequireMFA, d.Action)
+		assert.Equal(t, geo.ReasonCountryChanged, d.Reason)
+		assert.Equal(t, "DE", d.PreviousCountry)
+	})
+
+	t.Run("case=identity policy overrides the rules", func(t *testing.T) {
+		setRules(t, map[string]interface{}{"countries": []string{"KP", "IR"}, "action": "block"})
+
+		exempt := createIdentity(t)
+		require.NoError(t, reg.GeoPersister().UpsertIdentityPolicy(ctx, &geo.IdentityPolicy{IdentityID: exempt.ID, Exempt: true}))
+		assert.Empty(t, evaluate(t, "KP", exempt).Action)
+
+		allowed := createIdentity(t)
+		require.NoError(t, reg.GeoPersister().UpsertIdentityPolicy(ctx, &geo.IdentityPolicy{
+			IdentityID:       allowed.ID,
+			AllowedCountries: sqlxx.StringSlicePipeDelimiter{"KP"},
+			BlockedCountries: sqlxx.StringSlicePipeDelimiter{"DE"},
+		}))
+		assert.Empty(t, evaluate(t, "KP", allowed).Action)
+		assert.Equal(t, config.GeoPolicyActionBlock, evaluate(t, "IR", allowed).Action)
+
+		d := evaluate(t, "DE", allowed)
+		assert.Equal(t, config.GeoPolicyActionBlock, d.Action)
+		assert.Equal(t, geo.ReasonIdentityBlockedCountry, d.Reason)
+	})
+
+	t.Run("case=notifies the identity", func(t *testing.T) {
+		setRules(t, map[string]interface{}{"country_changed": true, "action": "notify"})
+		i := createIdentity(t)
+		reg.GeoPolicy().Succeeded(ctx, i, evaluate(t, "DE", i))
+
+		d := evaluate(t, "FR", i)
+		require.Equal(t, config.GeoPolicyActionNotify, d.Action)
+		reg.GeoPolicy().Succeeded(ctx, i, d)
+
+		message := testhelpers.CourierExpectMessage(t, reg, i.VerifiableAddresses[0].Value, "New sign in to your account")
+		assert.Contains(t, message.Body, "FR")
+		assert.Contains(t, message.Body, "DE")
+
+		p, err := reg.GeoPersister().GetIdentityPolicy(ctx, i.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "FR", p.LastCountry)
+	})
+
+	t.Run("case=resolves the country using the GeoIP API", func(t *testing.T) {
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			switch r.URL.Query().Get("ip") {
+			case "192.0.2.1":
+				_, _ = w.Write([]byte(`{"location":{"country":"de"}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(ts.Close)
+
+		conf.MustSet(config.ViperKeyGeoPoliciesCountryHeader, "")
+		conf.MustSet(config.ViperKeyGeoPoliciesAPIURL, ts.URL)
+		conf.MustSet(config.ViperKeyGeoPoliciesAPICountryPath, "location.country")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyGeoPoliciesCountryHeader, "CF-IPCountry")
+			conf.MustSet(config.ViperKeyGeoPoliciesAPIURL, "")
+		})
+
+		r := request("")
+		r.RemoteAddr = "192.0.2.1:1234"
+		assert.Equal(t, "DE", reg.GeoPolicy().Country(r))
+		assert.Equal(t, "DE", reg.GeoPolicy().Country(r))
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls), "the country is cached")
+
+		r.RemoteAddr = "192.0.2.2:1234"
+		assert.Empty(t, reg.GeoPolicy().Country(r))
+	})
+}
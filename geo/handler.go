@@ -0,0 +1,176 @@
+package geo
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/x"
+)
+
+const RouteIdentityPolicy = "/identities/:id/geo-policy"
+
+type (
+	handlerDependencies interface {
+		identity.PoolProvider
+		x.WriterProvider
+
+		PersistenceProvider
+	}
+	HandlerProvider interface {
+		GeoHandler() *Handler
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteIdentityPolicy, h.get)
+	admin.PUT(RouteIdentityPolicy, h.update)
+}
+
+// The geo policy of an identity.
+//
+// swagger:response identityGeoPolicy
+// nolint:deadcode,unused
+type identityGeoPolicyResponse struct {
+	// in: body
+	Body IdentityPolicy
+}
+
+// swagger:parameters getIdentityGeoPolicy
+// nolint:deadcode,unused
+type getIdentityGeoPolicyParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+}
+
+// swagger:route GET /identities/{id}/geo-policy admin getIdentityGeoPolicy
+//
+// Get the Geo Policy of an Identity
+//
+// This endpoint returns how the rules configured at `geo_policies.rules` are overridden for the identity and
+// the country of its last login.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityGeoPolicy
+//       404: genericError
+//       500: genericError
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	i, err := h.d.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	p, err := h.d.GeoPersister().GetIdentityPolicy(r.Context(), i.ID)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		p = &IdentityPolicy{IdentityID: i.ID, AllowedCountries: []string{}, BlockedCountries: []string{}}
+	} else if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, p)
+}
+
+// swagger:parameters updateIdentityGeoPolicy
+// nolint:deadcode,unused
+type updateIdentityGeoPolicyParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body UpdateIdentityPolicy
+}
+
+type UpdateIdentityPolicy struct {
+	// Exempt disables all rules for the identity.
+	Exempt bool `json:"exempt"`
+
+	// AllowedCountries are the ISO 3166-1 alpha-2 codes of countries no rule applies to for this identity.
+	AllowedCountries []string `json:"allowed_countries"`
+
+	// BlockedCountries are the ISO 3166-1 alpha-2 codes of countries this identity can not sign in from.
+	BlockedCountries []string `json:"blocked_countries"`
+}
+
+// swagger:route PUT /identities/{id}/geo-policy admin updateIdentityGeoPolicy
+//
+// Update the Geo Policy of an Identity
+//
+// This endpoint replaces how the rules configured at `geo_policies.rules` are overridden for the identity. The
+// identity can always sign in from allowed countries and never from blocked countries. Exempt identities are
+// not subject to any rule. The country of the identity's last login is kept.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityGeoPolicy
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) update(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body UpdateIdentityPolicy
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.d.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	i, err := h.d.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	p, err := h.d.GeoPersister().GetIdentityPolicy(r.Context(), i.ID)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		p = &IdentityPolicy{IdentityID: i.ID}
+	} else if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	p.Exempt = body.Exempt
+	p.AllowedCountries = append(sqlxx.StringSlicePipeDelimiter{}, body.AllowedCountries...)
+	p.BlockedCountries = append(sqlxx.StringSlicePipeDelimiter{}, body.BlockedCountries...)
+	if err := p.Normalize(); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := h.d.GeoPersister().UpsertIdentityPolicy(r.Context(), p); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, p)
+}
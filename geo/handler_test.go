@@ -0,0 +1,84 @@
+package geo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/x"
+)
+
+func TestHandler(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/identity.schema.json")
+	router := x.NewRouterAdmin()
+	reg.GeoHandler().RegisterAdminRoutes(router)
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, method, path string, body interface{}, expectCode int) gjson.Result {
+		var b bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&b).Encode(body))
+		}
+
+		req, err := http.NewRequest(method, ts.URL+path, &b)
+		require.NoError(t, err)
+		res, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		actual, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.EqualValues(t, expectCode, res.StatusCode, "%s", actual)
+		return gjson.ParseBytes(actual)
+	}
+
+	i := identity.NewIdentity("")
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+	path := "/identities/" + i.ID.String() + "/geo-policy"
+
+	t.Run("case=returns an empty policy", func(t *testing.T) {
+		actual := do(t, "GET", path, nil, http.StatusOK)
+		assert.Equal(t, i.ID.String(), actual.Get("identity_id").String())
+		assert.False(t, actual.Get("exempt").Bool())
+		assert.Equal(t, "[]", actual.Get("allowed_countries").Raw)
+		assert.Equal(t, "[]", actual.Get("blocked_countries").Raw)
+	})
+
+	t.Run("case=updates the policy", func(t *testing.T) {
+		actual := do(t, "PUT", path, map[string]interface{}{"exempt": true, "allowed_countries": []string{"de", " fr"}, "blocked_countries": []string{"KP"}}, http.StatusOK)
+		assert.True(t, actual.Get("exempt").Bool())
+		assert.Equal(t, `["DE","FR"]`, actual.Get("allowed_countries").Raw)
+		assert.Equal(t, `["KP"]`, actual.Get("blocked_countries").Raw)
+
+		actual = do(t, "GET", path, nil, http.StatusOK)
+		assert.True(t, actual.Get("exempt").Bool())
+		assert.Equal(t, `["DE","FR"]`, actual.Get("allowed_countries").Raw)
+	})
+
+	t.Run("case=rejects invalid countries", func(t *testing.T) {
+		actual := do(t, "PUT", path, map[string]interface{}{"allowed_countries": []string{"Germany"}}, http.StatusBadRequest)
+		assert.Contains(t, actual.Get("error.reason").String(), "Germany")
+	})
+
+	t.Run("case=rejects unknown fields", func(t *testing.T) {
+		do(t, "PUT", path, map[string]interface{}{"last_country": "DE"}, http.StatusBadRequest)
+	})
+
+	t.Run("case=fails for unknown identities", func(t *testing.T) {
+		do(t, "GET", "/identities/"+x.NewUUID().String()+"/geo-policy", nil, http.StatusNotFound)
+		do(t, "PUT", "/identities/"+x.NewUUID().String()+"/geo-policy", map[string]interface{}{}, http.StatusNotFound)
+	})
+}
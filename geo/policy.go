@@ -0,0 +1,157 @@
+package geo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/corp"
+)
+
+type (
+	// IdentityPolicy overrides the geo policy rules for one identity and records the country of its last login.
+	//
+	// swagger:model identityGeoPolicy
+	IdentityPolicy struct {
+		ID  uuid.UUID `json:"-" faker:"-" db:"id"`
+		NID uuid.UUID `json:"-" faker:"-" db:"nid"`
+
+		// IdentityID is the ID of the identity the policy belongs to.
+		//
+		// required: true
+		IdentityID uuid.UUID `json:"identity_id" faker:"-" db:"identity_id"`
+
+		// Exempt disables all rules for the identity.
+		//
+		// required: true
+		Exempt bool `json:"exempt" db:"exempt"`
+
+		// AllowedCountries are the ISO 3166-1 alpha-2 codes of countries no rule applies to for this identity,
+		// for example because the identity travels there regularly.
+		//
+		// required: true
+		AllowedCountries sqlxx.StringSlicePipeDelimiter `json:"allowed_countries" faker:"-" db:"allowed_countries"`
+
+		// BlockedCountries are the ISO 3166-1 alpha-2 codes of countries this identity can not sign in from,
+		// in addition to the countries blocked by the rules.
+		//
+		// required: true
+		BlockedCountries sqlxx.StringSlicePipeDelimiter `json:"blocked_countries" faker:"-" db:"blocked_countries"`
+
+		// LastCountry is the country of the identity's last login. It is empty if the identity never signed in
+		// or if the country could not be determined.
+		LastCountry string `json:"last_country" db:"last_country"`
+
+		// CreatedAt is a helper struct field for gobuffalo.pop.
+		CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+	}
+
+	// Challenge is the code sent to confirm a login the `require_mfa` action applies to. Only a keyed hash of
+	// the code is stored.
+	Challenge struct {
+		ID         uuid.UUID `json:"-" faker:"-" db:"id"`
+		NID        uuid.UUID `json:"-" faker:"-" db:"nid"`
+		FlowID     uuid.UUID `json:"-" faker:"-" db:"flow_id"`
+		IdentityID uuid.UUID `json:"-" faker:"-" db:"identity_id"`
+		// Method is the credentials type the identity signed in with.
+		Method    string    `json:"-" db:"method"`
+		Country   string    `json:"-" db:"country"`
+		CodeHMAC  string    `json:"-" db:"code_hmac"`
+		ExpiresAt time.Time `json:"-" faker:"-" db:"expires_at"`
+		Attempts  int       `json:"-" db:"attempts"`
+
+		// CreatedAt is a helper struct field for gobuffalo.pop.
+		CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+	}
+
+	Persister interface {
+		// GetIdentityPolicy returns the policy of the identity or sqlcon.ErrNoRows if it has none.
+		GetIdentityPolicy(ctx context.Context, identityID uuid.UUID) (*IdentityPolicy, error)
+
+		// UpsertIdentityPolicy creates or replaces the policy of the identity.
+		UpsertIdentityPolicy(ctx context.Context, p *IdentityPolicy) error
+
+		// CreateLoginChallenge stores the challenge, replacing any challenge issued for the same login flow.
+		CreateLoginChallenge(ctx context.Context, c *Challenge) error
+
+		// GetLoginChallenge returns the challenge issued for the login flow or sqlcon.ErrNoRows if there is none.
+		GetLoginChallenge(ctx context.Context, flowID uuid.UUID) (*Challenge, error)
+
+		UpdateLoginChallenge(ctx context.Context, c *Challenge) error
+
+		DeleteLoginChallenge(ctx context.Context, id uuid.UUID) error
+	}
+
+	PersistenceProvider interface {
+		GeoPersister() Persister
+	}
+)
+
+func (p IdentityPolicy) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "identity_geo_policies")
+}
+
+func (p *IdentityPolicy) GetID() uuid.UUID {
+	return p.ID
+}
+
+func (p *IdentityPolicy) GetNID() uuid.UUID {
+	return p.NID
+}
+
+// Normalize upper cases the country codes and returns a bad request error if one of them is invalid.
+func (p *IdentityPolicy) Normalize() error {
+	for _, countries := range []sqlxx.StringSlicePipeDelimiter{p.AllowedCountries, p.BlockedCountries} {
+		for k, country := range countries {
+			country = strings.ToUpper(strings.TrimSpace(country))
+			if !isCountryCode(country) {
+				return errors.WithStack(herodot.ErrBadRequest.WithReasonf("%q is not an ISO 3166-1 alpha-2 country code.", countries[k]))
+			}
+			countries[k] = country
+		}
+	}
+	return nil
+}
+
+func (c Challenge) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "selfservice_login_geo_challenges")
+}
+
+func (c *Challenge) GetID() uuid.UUID {
+	return c.ID
+}
+
+func (c *Challenge) GetNID() uuid.UUID {
+	return c.NID
+}
+
+func isCountryCode(country string) bool {
+	if len(country) != 2 {
+		return false
+	}
+	for _, r := range country {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(countries []string, country string) bool {
+	for _, c := range countries {
+		if c == country {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/geo"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/login"
+)
+
+func TestPersister(ctx context.Context, conf *config.Config, p persistence.Persister) func(t *testing.T) {
+	return func(t *testing.T) {
+		nid, p := testhelpers.NewNetworkUnlessExisting(t, ctx, p)
+
+		conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+
+		var createIdentity = func(t *testing.T) *identity.Identity {
+			i := identity.NewIdentity("")
+			require.NoError(t, p.CreateIdentity(ctx, i))
+			return i
+		}
+
+		var createFlow = func(t *testing.T) *login.Flow {
+			f := login.NewFlow(conf, time.Now(), time.Hour, "nosurf", &http.Request{URL: urlx.ParseOrPanic("/")}, flow.TypeBrowser)
+			require.NoError(t, p.CreateLoginFlow(ctx, f))
+			return f
+		}
+
+		t.Run("case=identity policy", func(t *testing.T) {
+			i := createIdentity(t)
+
+			_, err := p.GetIdentityPolicy(ctx, i.ID)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			expected := &geo.IdentityPolicy{
+				IdentityID:       i.ID,
+				AllowedCountries: sqlxx.StringSlicePipeDelimiter{"DE", "FR"},
+				BlockedCountries: sqlxx.StringSlicePipeDelimiter{},
+			}
+			require.NoError(t, p.UpsertIdentityPolicy(ctx, expected))
+			assert.Equal(t, nid, expected.NID)
+
+			actual, err := p.GetIdentityPolicy(ctx, i.ID)
+			require.NoError(t, err)
+			assert.Equal(t, expected.ID, actual.ID)
+			assert.Equal(t, []string{"DE", "FR"}, []string(actual.AllowedCountries))
+			assert.False(t, actual.Exempt)
+
+			t.Run("case=replaces the existing policy", func(t *testing.T) {
+				require.NoError(t, p.UpsertIdentityPolicy(ctx, &geo.IdentityPolicy{IdentityID: i.ID, Exempt: true, LastCountry: "US"}))
+
+				actual, err := p.GetIdentityPolicy(ctx, i.ID)
+				require.NoError(t, err)
+				assert.Equal(t, expected.ID, actual.ID)
+				assert.True(t, actual.Exempt)
+				assert.Equal(t, "US", actual.LastCountry)
+				assert.Empty(t, actual.AllowedCountries)
+			})
+
+			t.Run("case=is not visible in another network", func(t *testing.T) {
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				_, err := other.GetIdentityPolicy(ctx, i.ID)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+		})
+
+		t.Run("case=login challenge", func(t *testing.T) {
+			i := createIdentity(t)
+			f := createFlow(t)
+
+			_, err := p.GetLoginChallenge(ctx, f.ID)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			first := &geo.Challenge{FlowID: f.ID, IdentityID: i.ID, Method: "password", Country: "DE", CodeHMAC: "first", ExpiresAt: time.Now().UTC().Add(time.Hour)}
+			require.NoError(t, p.CreateLoginChallenge(ctx, first))
+
+			second := &geo.Challenge{FlowID: f.ID, IdentityID: i.ID, Method: "password", Country: "DE", CodeHMAC: "second", ExpiresAt: time.Now().UTC().Add(time.Hour)}
+			require.NoError(t, p.CreateLoginChallenge(ctx, second))
+
+			actual, err := p.GetLoginChallenge(ctx, f.ID)
+			require.NoError(t, err)
+			assert.Equal(t, second.ID, actual.ID)
+			assert.Equal(t, "second", actual.CodeHMAC)
+
+			actual.Attempts++
+			require.NoError(t, p.UpdateLoginChallenge(ctx, actual))
+			actual, err = p.GetLoginChallenge(ctx, f.ID)
+			require.NoError(t, err)
+			assert.Equal(t, 1, actual.Attempts)
+
+			t.Run("case=is not visible in another network", func(t *testing.T) {
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				_, err := other.GetLoginChallenge(ctx, f.ID)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+				require.ErrorIs(t, other.DeleteLoginChallenge(ctx, actual.ID), sqlcon.ErrNoRows)
+			})
+
+			require.NoError(t, p.DeleteLoginChallenge(ctx, actual.ID))
+			_, err = p.GetLoginChallenge(ctx, f.ID)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			require.ErrorIs(t, p.DeleteLoginChallenge(ctx, uuid.Must(uuid.NewV4())), sqlcon.ErrNoRows)
+		})
+	}
+}
@@ -6,6 +6,12 @@ import "context"
 type Hasher interface {
 	// Generate returns a hash derived from the password or an error if the hash method failed.
 	Generate(ctx context.Context, password []byte) ([]byte, error)
+
+	// Understands returns true if the hash has the format of the hashes this hasher generates.
+	Understands(hash []byte) bool
+
+	// Compare returns nil if the hash was derived from the password.
+	Compare(ctx context.Context, password []byte, hash []byte) error
 }
 
 type HashProvider interface {
@@ -63,3 +63,11 @@ func (h *Argon2) Generate(ctx context.Context, password []byte) ([]byte, error)
 
 	return b.Bytes(), nil
 }
+
+func (h *Argon2) Understands(hash []byte) bool {
+	return IsArgon2idHash(hash)
+}
+
+func (h *Argon2) Compare(ctx context.Context, password []byte, hash []byte) error {
+	return CompareArgon2id(ctx, password, hash)
+}
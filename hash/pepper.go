@@ -0,0 +1,117 @@
+package hash
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// pepperPrefix marks peppered hashes. It is followed by the ID of the pepper, a `$`, and the hash of the peppered
+// password, for example `$pepper$1a2b3c4d$$argon2id$v=19$...`.
+const pepperPrefix = "$pepper$"
+
+var ErrUnknownPepper = errors.New("the hash was created using a pepper which is not configured")
+
+type PepperConfiguration interface {
+	config.Provider
+}
+
+// Peppered applies the pepper configured at `secrets.pepper` to passwords before they are hashed or compared by the
+// wrapped hasher.
+type Peppered struct {
+	c PepperConfiguration
+	h Hasher
+}
+
+func NewPeppered(c PepperConfiguration, h Hasher) *Peppered {
+	return &Peppered{c: c, h: h}
+}
+
+func (h *Peppered) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	peppers := h.c.Config(ctx).SecretsPepper()
+	if len(peppers) == 0 {
+		return h.h.Generate(ctx, password)
+	}
+
+	hash, err := h.h.Generate(ctx, pepper(peppers[0], password))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(pepperPrefix+PepperID(peppers[0])+"$"), hash...), nil
+}
+
+func (h *Peppered) Understands(hash []byte) bool {
+	if _, inner, ok := splitPeppered(hash); ok {
+		return h.h.Understands(inner)
+	}
+	return h.h.Understands(hash)
+}
+
+func (h *Peppered) Compare(ctx context.Context, password []byte, hash []byte) error {
+	password, hash, err := unpepper(ctx, h.c, password, hash)
+	if err != nil {
+		return err
+	}
+	return h.h.Compare(ctx, password, hash)
+}
+
+// PepperID identifies the pepper in the hashes it was applied to without revealing it.
+func PepperID(pepper []byte) string {
+	sum := sha256.Sum256(pepper)
+	return hex.EncodeToString(sum[:4])
+}
+
+// PepperOutdated returns true if the hash should be replaced because it was not created using the current pepper.
+func PepperOutdated(ctx context.Context, c PepperConfiguration, hash []byte) bool {
+	peppers := c.Config(ctx).SecretsPepper()
+	if len(peppers) == 0 {
+		return false
+	}
+
+	id, _, ok := splitPeppered(hash)
+	return !ok || id != PepperID(peppers[0])
+}
+
+// pepper derives the password which is hashed from the password and the pepper. The result is 43 bytes long, which
+// also keeps passwords within the limit of bcrypt.
+func pepper(pepper []byte, password []byte) []byte {
+	mac := hmac.New(sha256.New, pepper)
+	_, _ = mac.Write(password)
+	return []byte(base64.RawStdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func splitPeppered(hash []byte) (id string, inner []byte, ok bool) {
+	if !bytes.HasPrefix(hash, []byte(pepperPrefix)) {
+		return "", nil, false
+	}
+
+	rest := hash[len(pepperPrefix):]
+	i := bytes.IndexByte(rest, '$')
+	if i <= 0 {
+		return "", nil, false
+	}
+	return string(rest[:i]), rest[i+1:], true
+}
+
+// unpepper returns the peppered password and the hash of the peppered password if the hash is peppered. Otherwise,
+// the password and hash are returned unchanged.
+func unpepper(ctx context.Context, c PepperConfiguration, password []byte, hash []byte) ([]byte, []byte, error) {
+	id, inner, ok := splitPeppered(hash)
+	if !ok {
+		return password, hash, nil
+	}
+
+	for _, p := range c.Config(ctx).SecretsPepper() {
+		if PepperID(p) == id {
+			return pepper(p, password), inner, nil
+		}
+	}
+	return nil, nil, errors.WithStack(ErrUnknownPepper)
+}
@@ -0,0 +1,79 @@
+package hash_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/internal"
+)
+
+func TestPeppered(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	hashers := hash.NewRegistry(reg)
+	hasher := hash.NewPeppered(reg, hash.NewHasherBcrypt(reg))
+
+	setPeppers := func(t *testing.T, peppers ...string) {
+		conf.MustSet(config.ViperKeySecretsPepper, peppers)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySecretsPepper, []string{})
+		})
+	}
+
+	t.Run("case=does not pepper without a pepper", func(t *testing.T) {
+		hs, err := hasher.Generate(ctx, []byte("secret"))
+		require.NoError(t, err)
+		assert.True(t, hash.IsBcryptHash(hs))
+		assert.False(t, hash.PepperOutdated(ctx, reg, hs))
+		assert.NoError(t, hashers.Compare(ctx, []byte("secret"), hs))
+	})
+
+	t.Run("case=peppers passwords", func(t *testing.T) {
+		setPeppers(t, "a-very-secret-pepper")
+
+		hs, err := hasher.Generate(ctx, []byte("secret"))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(hs), "$pepper$"+hash.PepperID([]byte("a-very-secret-pepper"))+"$$2a$"), "%s", hs)
+		assert.NotContains(t, string(hs), "a-very-secret-pepper")
+
+		assert.True(t, hasher.Understands(hs))
+		assert.True(t, hashers.Understands(hs))
+		assert.False(t, hash.PepperOutdated(ctx, reg, hs))
+
+		assert.NoError(t, hasher.Compare(ctx, []byte("secret"), hs))
+		assert.NoError(t, hashers.Compare(ctx, []byte("secret"), hs))
+		assert.Error(t, hashers.Compare(ctx, []byte("wrong"), hs))
+
+		inner := hs[strings.LastIndex(string(hs), "$$")+1:]
+		assert.Error(t, hashers.Compare(ctx, []byte("secret"), inner), "the hash alone must not match the password")
+	})
+
+	t.Run("case=supports rotating the pepper", func(t *testing.T) {
+		setPeppers(t, "the-original-pepper")
+		old, err := hasher.Generate(ctx, []byte("secret"))
+		require.NoError(t, err)
+		unpeppered, err := hash.NewHasherBcrypt(reg).Generate(ctx, []byte("secret"))
+		require.NoError(t, err)
+
+		setPeppers(t, "the-rotated-pepper", "the-original-pepper")
+		assert.True(t, hash.PepperOutdated(ctx, reg, old))
+		assert.True(t, hash.PepperOutdated(ctx, reg, unpeppered))
+		assert.NoError(t, hashers.Compare(ctx, []byte("secret"), old))
+		assert.NoError(t, hashers.Compare(ctx, []byte("secret"), unpeppered))
+
+		current, err := hasher.Generate(ctx, []byte("secret"))
+		require.NoError(t, err)
+		assert.False(t, hash.PepperOutdated(ctx, reg, current))
+
+		setPeppers(t, "the-rotated-pepper")
+		assert.True(t, errors.Is(hashers.Compare(ctx, []byte("secret"), old), hash.ErrUnknownPepper))
+		assert.NoError(t, hashers.Compare(ctx, []byte("secret"), current))
+	})
+}
@@ -0,0 +1,96 @@
+package hash
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type RegistryProvider interface {
+	Hashers() *Registry
+}
+
+type RegistryConfiguration interface {
+	config.Provider
+}
+
+// Registry maps the names which can be configured at `hashers.algorithm` to hashers. Passwords are hashed
+// using the configured hasher only, but are compared using whichever registered hasher generated the hash,
+// so that existing hashes keep working when the configured hasher changes. Peppered hashes are compared after
+// applying the pepper they were created with.
+type Registry struct {
+	c       RegistryConfiguration
+	names   []string
+	hashers map[string]Hasher
+}
+
+// NewRegistry returns a registry containing the built-in hashers `argon2` and `bcrypt`.
+func NewRegistry(c RegistryConfiguration) *Registry {
+	r := &Registry{c: c, hashers: map[string]Hasher{}}
+	r.Register("argon2", NewHasherArgon2(c))
+	r.Register("bcrypt", NewHasherBcrypt(c))
+	return r
+}
+
+// Register adds the hasher under the given name, replacing any hasher previously registered under it.
+func (r *Registry) Register(name string, h Hasher) {
+	if _, ok := r.hashers[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.hashers[name] = h
+}
+
+// Get returns the hasher registered under the given name. Use NewPeppered to apply the configured pepper to the
+// passwords it hashes.
+func (r *Registry) Get(name string) (Hasher, error) {
+	h, ok := r.hashers[name]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownHashAlgorithm, "no hasher is registered under the name %q", name)
+	}
+	return h, nil
+}
+
+// Names returns the names of all registered hashers in the order they were registered.
+func (r *Registry) Names() []string {
+	return append([]string{}, r.names...)
+}
+
+// Understands returns true if one of the registered hashers can compare passwords to the hash.
+func (r *Registry) Understands(hash []byte) bool {
+	if _, inner, ok := splitPeppered(hash); ok {
+		hash = inner
+	}
+
+	for _, h := range r.hashers {
+		if h.Understands(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare compares the password to the hash using every registered hasher which understands the hash, starting
+// with the most recently registered one, and succeeds as soon as one of them matches. Several hashers may
+// understand the same hash, for example a peppered bcrypt hasher and the built-in one for hashes created before
+// the pepper was introduced.
+func (r *Registry) Compare(ctx context.Context, password []byte, hash []byte) error {
+	password, hash, err := unpepper(ctx, r.c, password, hash)
+	if err != nil {
+		return err
+	}
+
+	err = errors.WithStack(ErrUnknownHashAlgorithm)
+	for k := len(r.names) - 1; k >= 0; k-- {
+		h := r.hashers[r.names[k]]
+		if !h.Understands(hash) {
+			continue
+		}
+
+		if err = h.Compare(ctx, password, hash); err == nil {
+			return nil
+		}
+	}
+	return err
+}
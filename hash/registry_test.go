@@ -0,0 +1,102 @@
+package hash_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/internal"
+)
+
+// pepperedHasher stands in for a hasher backed by a hardware security module.
+type pepperedHasher struct {
+	pepper []byte
+}
+
+func (h *pepperedHasher) Generate(_ context.Context, password []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, h.pepper)
+	_, _ = mac.Write(password)
+	return []byte("$peppered$" + hex.EncodeToString(mac.Sum(nil))), nil
+}
+
+func (h *pepperedHasher) Understands(hs []byte) bool {
+	return strings.HasPrefix(string(hs), "$peppered$")
+}
+
+func (h *pepperedHasher) Compare(ctx context.Context, password []byte, hs []byte) error {
+	expected, _ := h.Generate(ctx, password)
+	if !hmac.Equal(expected, hs) {
+		return hash.ErrMismatchedHashAndPassword
+	}
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	_, reg := internal.NewFastRegistryWithMocks(t)
+	hashers := hash.NewRegistry(reg)
+	hashers.Register("peppered", &pepperedHasher{pepper: []byte("pepper")})
+
+	assert.Equal(t, []string{"argon2", "bcrypt", "peppered"}, hashers.Names())
+
+	_, err := hashers.Get("unknown")
+	assert.True(t, errors.Is(err, hash.ErrUnknownHashAlgorithm))
+
+	for _, name := range hashers.Names() {
+		t.Run("hasher="+name, func(t *testing.T) {
+			h, err := hashers.Get(name)
+			require.NoError(t, err)
+
+			hs, err := h.Generate(ctx, []byte("secret"))
+			require.NoError(t, err)
+
+			assert.True(t, hashers.Understands(hs))
+			assert.NoError(t, hashers.Compare(ctx, []byte("secret"), hs))
+			assert.Error(t, hashers.Compare(ctx, []byte("wrong"), hs))
+		})
+	}
+
+	t.Run("case=unknown hashes", func(t *testing.T) {
+		assert.False(t, hashers.Understands([]byte("$md5$foo")))
+		assert.True(t, errors.Is(hashers.Compare(ctx, []byte("secret"), []byte("$md5$foo")), hash.ErrUnknownHashAlgorithm))
+	})
+
+	t.Run("case=compares with every hasher which understands the hash", func(t *testing.T) {
+		legacy, err := hash.NewHasherBcrypt(reg).Generate(ctx, []byte("secret"))
+		require.NoError(t, err)
+
+		hashers := hash.NewRegistry(reg)
+		hashers.Register("peppered-bcrypt", &pepperedBcrypt{Bcrypt: hash.NewHasherBcrypt(reg), pepper: "pepper"})
+
+		peppered, err := hashers.Get("peppered-bcrypt")
+		require.NoError(t, err)
+		hs, err := peppered.Generate(ctx, []byte("secret"))
+		require.NoError(t, err)
+
+		assert.NoError(t, hashers.Compare(ctx, []byte("secret"), hs))
+		assert.NoError(t, hashers.Compare(ctx, []byte("secret"), legacy))
+		assert.Error(t, hashers.Compare(ctx, []byte("wrong"), legacy))
+	})
+}
+
+// pepperedBcrypt generates regular bcrypt hashes of the peppered password.
+type pepperedBcrypt struct {
+	*hash.Bcrypt
+	pepper string
+}
+
+func (h *pepperedBcrypt) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	return h.Bcrypt.Generate(ctx, append([]byte(h.pepper), password...))
+}
+
+func (h *pepperedBcrypt) Compare(ctx context.Context, password []byte, hs []byte) error {
+	return h.Bcrypt.Compare(ctx, append([]byte(h.pepper), password...), hs)
+}
@@ -0,0 +1,222 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/cipher"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	// HeaderKey is the request header carrying the idempotency key.
+	HeaderKey = "Idempotency-Key"
+
+	// HeaderReplayed is set on responses which were replayed from a stored record.
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+
+	// claimLease is how long a key stays claimed by a request which is still being processed. A request
+	// which does not complete within the lease, for example because the process crashed, no longer blocks
+	// retries with the same key.
+	claimLease = time.Minute
+)
+
+var (
+	ErrKeyReused = herodot.DefaultError{
+		StatusField: http.StatusText(http.StatusUnprocessableEntity),
+		ErrorField:  "The idempotency key was already used for a different request",
+		ReasonField: "The Idempotency-Key header was already used with a different request body. Use a new key for new requests.",
+		CodeField:   http.StatusUnprocessableEntity,
+	}
+	ErrRequestInProgress = herodot.ErrConflict.WithReason("A request with this Idempotency-Key header is still being processed. Retry the request later.")
+)
+
+type (
+	middlewareDependencies interface {
+		PersistenceProvider
+		cipher.Provider
+		config.Provider
+		x.WriterProvider
+		x.LoggingProvider
+	}
+	MiddlewareProvider interface {
+		IdempotencyMiddleware() *Middleware
+	}
+	// Middleware makes handlers idempotent for requests with an Idempotency-Key header. The response of
+	// the first successful request is stored encrypted and returned for all further requests with the same
+	// key, method, and path until it expires.
+	Middleware struct {
+		d middlewareDependencies
+	}
+)
+
+func NewMiddleware(d middlewareDependencies) *Middleware {
+	return &Middleware{d: d}
+}
+
+func (m *Middleware) Wrap(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		key := r.Header.Get(HeaderKey)
+		if key == "" {
+			next(w, r, ps)
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			m.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.
+				WithReasonf("The %s header must not be longer than %d characters.", HeaderKey, maxKeyLength)))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			m.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to read the request body: %s", err)))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		record := &Record{
+			ID:          x.NewUUID(),
+			Key:         key,
+			Scope:       r.Method + " " + r.URL.Path,
+			RequestHash: hex.EncodeToString(hash[:]),
+			ExpiresAt:   time.Now().UTC().Add(claimLease),
+		}
+
+		existing, err := m.claim(r, record)
+		if err != nil {
+			m.d.Writer().WriteError(w, r, err)
+			return
+		} else if existing != nil {
+			m.replay(w, r, record, existing)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r, ps)
+		m.store(r, record, rec)
+	}
+}
+
+// claim stores the record unless its key is already in use, in which case the existing record is returned.
+func (m *Middleware) claim(r *http.Request, record *Record) (*Record, error) {
+	p := m.d.IdempotencyPersister()
+	for {
+		err := p.CreateIdempotencyRecord(r.Context(), record)
+		if err == nil {
+			return nil, nil
+		} else if !errors.Is(err, sqlcon.ErrUniqueViolation) {
+			return nil, err
+		}
+
+		existing, err := p.GetIdempotencyRecord(r.Context(), record.Scope, record.Key)
+		if errors.Is(err, sqlcon.ErrNoRows) {
+			// The record was removed in the meantime.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !existing.expired() {
+			return existing, nil
+		}
+
+		if err := p.DeleteIdempotencyRecord(r.Context(), existing.ID); err != nil && !errors.Is(err, sqlcon.ErrNoRows) {
+			return nil, err
+		}
+	}
+}
+
+func (m *Middleware) replay(w http.ResponseWriter, r *http.Request, record, existing *Record) {
+	if existing.RequestHash != record.RequestHash {
+		m.d.Writer().WriteError(w, r, errors.WithStack(ErrKeyReused))
+		return
+	}
+
+	if !existing.Completed() {
+		m.d.Writer().WriteError(w, r, errors.WithStack(ErrRequestInProgress))
+		return
+	}
+
+	body, err := m.d.Cipher().Decrypt(r.Context(), string(existing.Body))
+	if err != nil {
+		m.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if existing.Location != "" {
+		w.Header().Set("Location", string(existing.Location))
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(existing.StatusCode)
+	_, _ = w.Write(body)
+}
+
+// store saves the response if the request succeeded and keeps the key for the configured TTL. Otherwise
+// the key is released so that the request can be retried.
+func (m *Middleware) store(r *http.Request, record *Record, rec *responseRecorder) {
+	p := m.d.IdempotencyPersister()
+	if rec.status < 200 || rec.status > 299 {
+		m.release(r, record)
+		return
+	}
+
+	// Responses may contain secrets such as recovery links, so they are never stored in plaintext.
+	body, err := m.d.Cipher().Encrypt(r.Context(), rec.body.Bytes())
+	if err != nil {
+		m.d.Logger().WithRequest(r).WithError(err).Error("Unable to encrypt response for idempotency key.")
+		m.release(r, record)
+		return
+	}
+
+	record.StatusCode = rec.status
+	record.Location = sqlxx.NullString(rec.Header().Get("Location"))
+	record.Body = sqlxx.NullString(body)
+	record.ExpiresAt = time.Now().UTC().Add(m.d.Config(r.Context()).IdempotencyTTL())
+	if err := p.UpdateIdempotencyRecord(r.Context(), record); err != nil {
+		m.d.Logger().WithRequest(r).WithError(err).Error("Unable to store response for idempotency key.")
+		return
+	}
+
+	if _, err := p.DeleteExpiredIdempotencyRecords(r.Context(), time.Now().UTC()); err != nil {
+		m.d.Logger().WithRequest(r).WithError(err).Warn("Unable to remove expired idempotency keys.")
+	}
+}
+
+func (m *Middleware) release(r *http.Request, record *Record) {
+	if err := m.d.IdempotencyPersister().DeleteIdempotencyRecord(r.Context(), record.ID); err != nil {
+		m.d.Logger().WithRequest(r).WithError(err).Warn("Unable to release idempotency key.")
+	}
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
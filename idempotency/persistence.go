@@ -0,0 +1,22 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type PersistenceProvider interface {
+	IdempotencyPersister() Persister
+}
+
+type Persister interface {
+	// CreateIdempotencyRecord stores a new record. It returns sqlcon.ErrUniqueViolation if the key is
+	// already in use for the record's scope.
+	CreateIdempotencyRecord(ctx context.Context, r *Record) error
+	GetIdempotencyRecord(ctx context.Context, scope, key string) (*Record, error)
+	UpdateIdempotencyRecord(ctx context.Context, r *Record) error
+	DeleteIdempotencyRecord(ctx context.Context, id uuid.UUID) error
+	DeleteExpiredIdempotencyRecords(ctx context.Context, expiresBefore time.Time) (int, error)
+}
@@ -0,0 +1,69 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/corp"
+)
+
+// Record maps an idempotency key to the response of the request which first used it.
+type Record struct {
+	ID  uuid.UUID `json:"id" db:"id" rw:"r"`
+	NID uuid.UUID `json:"-" db:"nid"`
+
+	// Key is the value of the Idempotency-Key header.
+	Key string `json:"key" db:"idempotency_key"`
+
+	// Scope is the HTTP method and path of the request. Keys are unique per scope.
+	Scope string `json:"scope" db:"request_scope"`
+
+	// RequestHash is the hex-encoded SHA-256 hash of the request body. Reusing a key with a different body
+	// is rejected.
+	RequestHash string `json:"request_hash" db:"request_hash"`
+
+	// StatusCode is the status code of the stored response. It is zero while the first request is still
+	// being processed.
+	StatusCode int `json:"status_code" db:"status_code"`
+
+	// Location is the Location header of the stored response.
+	Location sqlxx.NullString `json:"location" db:"location"`
+
+	// Body is the stored response body, encrypted with the configured cipher.
+	Body sqlxx.NullString `json:"body" db:"body"`
+
+	// ExpiresAt defines when the key can be used again. While the request is being processed, the key
+	// is only claimed for a short lease.
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+
+	// CreatedAt is a helper struct field for gobuffalo.pop.
+	CreatedAt time.Time `json:"-" db:"created_at"`
+
+	// UpdatedAt is a helper struct field for gobuffalo.pop.
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+}
+
+func (r Record) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "idempotency_keys")
+}
+
+func (r *Record) GetID() uuid.UUID {
+	return r.ID
+}
+
+func (r *Record) GetNID() uuid.UUID {
+	return r.NID
+}
+
+// Completed returns true if the response of the request is stored.
+func (r *Record) Completed() bool {
+	return r.StatusCode != 0
+}
+
+func (r *Record) expired() bool {
+	return r.ExpiresAt.Before(time.Now())
+}
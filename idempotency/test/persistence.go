@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/idempotency"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/x"
+)
+
+func TestPersister(ctx context.Context, p persistence.Persister) func(t *testing.T) {
+	var newRecord = func(key string, expiresIn time.Duration) *idempotency.Record {
+		return &idempotency.Record{
+			ID:          x.NewUUID(),
+			Key:         key,
+			Scope:       "POST /identities",
+			RequestHash: "hash",
+			ExpiresAt:   time.Now().Add(expiresIn).UTC().Truncate(time.Second),
+		}
+	}
+
+	return func(t *testing.T) {
+		_, p := testhelpers.NewNetworkUnlessExisting(t, ctx, p)
+
+		t.Run("case=not found", func(t *testing.T) {
+			_, err := p.GetIdempotencyRecord(ctx, "POST /identities", x.NewUUID().String())
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=create, update, and find", func(t *testing.T) {
+			expected := newRecord(x.NewUUID().String(), time.Hour)
+			require.NoError(t, p.CreateIdempotencyRecord(ctx, expected))
+
+			expected.StatusCode = 201
+			expected.Location = "https://www.ory.sh/identities/1"
+			expected.Body = `{"id":"1"}`
+			require.NoError(t, p.UpdateIdempotencyRecord(ctx, expected))
+
+			actual, err := p.GetIdempotencyRecord(ctx, expected.Scope, expected.Key)
+			require.NoError(t, err)
+			assert.Equal(t, expected.ID, actual.ID)
+			assert.Equal(t, 201, actual.StatusCode)
+			assert.EqualValues(t, expected.Location, actual.Location)
+			assert.EqualValues(t, expected.Body, actual.Body)
+			assert.True(t, actual.Completed())
+		})
+
+		t.Run("case=keys are unique per scope", func(t *testing.T) {
+			key := x.NewUUID().String()
+			require.NoError(t, p.CreateIdempotencyRecord(ctx, newRecord(key, time.Hour)))
+			require.ErrorIs(t, p.CreateIdempotencyRecord(ctx, newRecord(key, time.Hour)), sqlcon.ErrUniqueViolation)
+
+			other := newRecord(key, time.Hour)
+			other.Scope = "POST /recovery/link"
+			require.NoError(t, p.CreateIdempotencyRecord(ctx, other))
+		})
+
+		t.Run("case=delete", func(t *testing.T) {
+			r := newRecord(x.NewUUID().String(), time.Hour)
+			require.NoError(t, p.CreateIdempotencyRecord(ctx, r))
+			require.NoError(t, p.DeleteIdempotencyRecord(ctx, r.ID))
+			require.ErrorIs(t, p.DeleteIdempotencyRecord(ctx, r.ID), sqlcon.ErrNoRows)
+
+			_, err := p.GetIdempotencyRecord(ctx, r.Scope, r.Key)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=delete expired", func(t *testing.T) {
+			expired := newRecord(x.NewUUID().String(), -time.Minute)
+			require.NoError(t, p.CreateIdempotencyRecord(ctx, expired))
+			active := newRecord(x.NewUUID().String(), time.Hour)
+			require.NoError(t, p.CreateIdempotencyRecord(ctx, active))
+
+			deleted, err := p.DeleteExpiredIdempotencyRecords(ctx, time.Now().UTC())
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, deleted, 1)
+
+			_, err = p.GetIdempotencyRecord(ctx, expired.Scope, expired.Key)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			_, err = p.GetIdempotencyRecord(ctx, active.Scope, active.Key)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package identity
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	// BackfillOptions configures Manager.BackfillVerifiableAddresses.
+	BackfillOptions struct {
+		// BatchSize is the number of identities loaded per page. Defaults to 100.
+		BatchSize int
+
+		// Workers is the number of identities of a batch processed concurrently. Defaults to 1.
+		Workers int
+
+		// DryRun only counts the addresses which would be created.
+		DryRun bool
+
+		// OnBatch, if set, is called after each batch with the running totals.
+		OnBatch func(BackfillReport)
+	}
+
+	// BackfillReport summarizes a run of Manager.BackfillVerifiableAddresses.
+	BackfillReport struct {
+		// Identities is the number of identities which were looked at.
+		Identities int `json:"identities"`
+
+		// Created is the number of verifiable addresses which were added (or would be added in a dry run).
+		Created int `json:"created"`
+
+		// Skipped is the number of addresses which were tracked by the time they were written, for example
+		// because another run or a concurrent update created them first.
+		Skipped int `json:"skipped"`
+
+		// Invalid lists identities whose traits do not validate against their current schema. They are not changed.
+		Invalid []uuid.UUID `json:"invalid"`
+	}
+)
+
+// BackfillVerifiableAddresses runs the verification schema extension for every identity and creates the verifiable
+// addresses it yields which are not yet tracked. Existing addresses, including their verification status, are never
+// modified or removed, which makes it safe to run while the server is serving traffic and to run it more than once, also
+// concurrently. It is intended to be used after a schema gained `"verification": {"via": "email"}` on a trait.
+func (m *Manager) BackfillVerifiableAddresses(ctx context.Context, o BackfillOptions) (*BackfillReport, error) {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+
+	pool, ok := m.r.IdentityPool().(PrivilegedPool)
+	if !ok {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReason("The identity pool does not support writing verifiable addresses."))
+	}
+
+	var (
+		l      sync.Mutex
+		report BackfillReport
+	)
+
+	for page := 0; ; page++ {
+		is, err := pool.ListIdentities(ctx, page, o.BatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		work := make(chan *Identity)
+		eg, wctx := errgroup.WithContext(ctx)
+		for w := 0; w < o.Workers; w++ {
+			eg.Go(func() error {
+				for i := range work {
+					created, skipped, err := m.backfillVerifiableAddresses(wctx, pool, i, o.DryRun)
+
+					l.Lock()
+					report.Identities++
+					report.Created += created
+					report.Skipped += skipped
+					if errors.Is(err, errInvalidTraits) {
+						report.Invalid = append(report.Invalid, i.ID)
+						err = nil
+					}
+					l.Unlock()
+
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}
+
+	dispatch:
+		for k := range is {
+			select {
+			case work <- &is[k]:
+			case <-wctx.Done():
+				break dispatch
+			}
+		}
+		close(work)
+
+		if err := eg.Wait(); err != nil {
+			return nil, err
+		}
+
+		if o.OnBatch != nil {
+			o.OnBatch(report)
+		}
+
+		if len(is) < o.BatchSize {
+			return &report, nil
+		}
+	}
+}
+
+var errInvalidTraits = errors.New("identity traits are invalid")
+
+func (m *Manager) backfillVerifiableAddresses(ctx context.Context, pool PrivilegedPool, i *Identity, dryRun bool) (created, skipped int, err error) {
+	derived := *i
+	if err := m.r.IdentityValidator().ValidateWithRunner(ctx, &derived,
+		NewSchemaExtensionVerification(&derived, m.r.Config(ctx).SelfServiceFlowVerificationRequestLifespan(), config.VerifiableAddressMergeReplace),
+	); err != nil {
+		return 0, 0, errors.Wrap(errInvalidTraits, err.Error())
+	}
+
+	for k := range derived.VerifiableAddresses {
+		address := derived.VerifiableAddresses[k]
+		if address.ID != uuid.Nil {
+			continue
+		}
+
+		if dryRun {
+			created++
+			continue
+		}
+
+		address.IdentityID = i.ID
+		if err := pool.CreateVerifiableAddress(ctx, &address); errors.Is(err, sqlcon.ErrUniqueViolation) || errors.Is(err, sqlcon.ErrNoRows) {
+			skipped++
+			continue
+		} else if err != nil {
+			return created, skipped, err
+		}
+		created++
+	}
+
+	return created, skipped, nil
+}
@@ -0,0 +1,107 @@
+package identity_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+)
+
+func TestBackfillVerifiableAddresses(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/backfill/before.schema.json")
+
+	create := func(t *testing.T, traits string) *identity.Identity {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(traits)
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+		require.Empty(t, i.VerifiableAddresses)
+		return i
+	}
+
+	addresses := func(t *testing.T, i *identity.Identity) map[string]identity.VerifiableAddress {
+		fromStore, err := reg.PrivilegedIdentityPool().GetIdentity(ctx, i.ID)
+		require.NoError(t, err)
+		result := make(map[string]identity.VerifiableAddress)
+		for _, a := range fromStore.VerifiableAddresses {
+			result[a.Value] = a
+		}
+		return result
+	}
+
+	single := create(t, `{"email":"backfill-single@ory.sh"}`)
+	both := create(t, `{"email":"backfill-both@ory.sh","backup_email":"backfill-backup@ory.sh"}`)
+	invalid := create(t, `{"email":"not-an-email"}`)
+
+	verified := identity.NewVerifiableEmailAddress("backfill-both@ory.sh", both.ID)
+	verified.Verified = true
+	verified.Status = identity.VerifiableAddressStatusCompleted
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateVerifiableAddress(ctx, verified))
+
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/backfill/after.schema.json")
+
+	t.Run("case=dry run does not write", func(t *testing.T) {
+		report, err := reg.IdentityManager().BackfillVerifiableAddresses(ctx, identity.BackfillOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Identities)
+		assert.Equal(t, 2, report.Created)
+		assert.Empty(t, addresses(t, single))
+	})
+
+	t.Run("case=creates missing addresses only", func(t *testing.T) {
+		report, err := reg.IdentityManager().BackfillVerifiableAddresses(ctx, identity.BackfillOptions{BatchSize: 2, Workers: 4})
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Identities)
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 0, report.Skipped)
+		assert.Equal(t, []uuid.UUID{invalid.ID}, report.Invalid)
+
+		assert.Contains(t, addresses(t, single), "backfill-single@ory.sh")
+
+		actual := addresses(t, both)
+		require.Len(t, actual, 2)
+		assert.Equal(t, verified.ID, actual["backfill-both@ory.sh"].ID)
+		assert.True(t, actual["backfill-both@ory.sh"].Verified)
+		assert.Equal(t, identity.VerifiableAddressStatusCompleted, actual["backfill-both@ory.sh"].Status)
+		assert.False(t, actual["backfill-backup@ory.sh"].Verified)
+		assert.Equal(t, identity.VerifiableAddressStatusPending, actual["backfill-backup@ory.sh"].Status)
+
+		assert.Empty(t, addresses(t, invalid))
+	})
+
+	t.Run("case=concurrent runs create each address once", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/backfill/before.schema.json")
+		fresh := create(t, `{"email":"backfill-concurrent@ory.sh"}`)
+		conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/backfill/after.schema.json")
+
+		var (
+			wg      sync.WaitGroup
+			l       sync.Mutex
+			created int
+		)
+		for k := 0; k < 4; k++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				report, err := reg.IdentityManager().BackfillVerifiableAddresses(ctx, identity.BackfillOptions{Workers: 2})
+				require.NoError(t, err)
+				l.Lock()
+				defer l.Unlock()
+				created += report.Created
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, created)
+		assert.Len(t, addresses(t, fresh), 1)
+		assert.Len(t, addresses(t, both), 2)
+	})
+}
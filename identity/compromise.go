@@ -0,0 +1,44 @@
+package identity
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// CompromiseReport lists what was revoked when an identity was marked as compromised.
+//
+// swagger:model identityCompromiseReport
+type CompromiseReport struct {
+	// IdentityID is the ID of the compromised identity.
+	//
+	// required: true
+	IdentityID uuid.UUID `json:"identity_id"`
+
+	// CompromisedAt is the time at which the identity was marked as compromised.
+	//
+	// required: true
+	CompromisedAt time.Time `json:"compromised_at"`
+
+	// Sessions is the number of deleted sessions. Sessions are the only record of the devices the identity
+	// signed in with, so none of these devices is trusted anymore.
+	Sessions int `json:"sessions"`
+
+	// VerificationTokens is the number of deleted verification tokens.
+	VerificationTokens int `json:"verification_tokens"`
+
+	// RecoveryTokens is the number of deleted recovery tokens.
+	RecoveryTokens int `json:"recovery_tokens"`
+
+	// APIKeys is the number of revoked API keys.
+	APIKeys int `json:"api_keys"`
+
+	// PasswordRevoked is true if the identity had a password which can no longer be used to sign in.
+	PasswordRevoked bool `json:"password_revoked"`
+
+	// UnlinkedOIDCProviders lists the OpenID Connect providers which were unlinked from the identity.
+	UnlinkedOIDCProviders []string `json:"unlinked_oidc_providers"`
+
+	// NotifiedAddresses is the number of email addresses the identity was notified at.
+	NotifiedAddresses int `json:"notified_addresses"`
+}
@@ -24,6 +24,19 @@ const (
 	// make sure to add all of these values to the test that ensures they are created during migration
 	CredentialsTypePassword CredentialsType = "password"
 	CredentialsTypeOIDC     CredentialsType = "oidc"
+	CredentialsTypeAPIKey   CredentialsType = "api_key"
+
+	// CredentialsTypeUsernameReservation holds usernames an identity gave up recently. They can not be used to
+	// sign in but keep other identities from claiming them.
+	CredentialsTypeUsernameReservation CredentialsType = "username_reservation"
+
+	// CredentialsTypeSIWE holds the Ethereum wallet addresses an identity signs in with using Sign-In with Ethereum
+	// (EIP-4361). The identifiers are the lowercase addresses.
+	CredentialsTypeSIWE CredentialsType = "siwe"
+
+	// CredentialsTypeQuestions holds the hashed answers to the recovery questions an administrator set up for an
+	// identity. They can not be used to sign in and have no identifiers.
+	CredentialsTypeQuestions CredentialsType = "questions"
 )
 
 // Credentials represents a specific credential type
@@ -0,0 +1,120 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/cipher"
+)
+
+const (
+	// credentialsOIDCProvidersPath is the path of the linked providers in the oidc credentials config.
+	credentialsOIDCProvidersPath = "providers"
+
+	// credentialsOIDCClaimsPath is the path of a linked provider's encrypted claims snapshot.
+	credentialsOIDCClaimsPath = "claims"
+)
+
+// OIDCProvider links an identity to a subject of an OpenID Connect provider.
+type OIDCProvider struct {
+	// Provider is the ID of the provider at `selfservice.methods.oidc.config.providers`.
+	//
+	// required: true
+	Provider string `json:"provider"`
+
+	// Subject is the `sub` claim the provider returns for the identity.
+	//
+	// required: true
+	Subject string `json:"subject"`
+}
+
+// SetOIDCProviders replaces the identity's linked OpenID Connect providers. Links which are kept keep their
+// claims snapshot. Passing no providers removes the oidc credentials.
+func (i *Identity) SetOIDCProviders(providers []OIDCProvider) error {
+	var existing []gjson.Result
+	if c, ok := i.GetCredentials(CredentialsTypeOIDC); ok {
+		existing = gjson.GetBytes(c.Config, credentialsOIDCProvidersPath).Array()
+	}
+
+	if len(providers) == 0 {
+		delete(i.Credentials, CredentialsTypeOIDC)
+		return nil
+	}
+
+	identifiers := make([]string, len(providers))
+	linked := make([]json.RawMessage, len(providers))
+	seen := make(map[string]bool, len(providers))
+	for k, p := range providers {
+		if len(p.Provider) == 0 || len(p.Subject) == 0 {
+			return errors.WithStack(herodot.ErrBadRequest.WithReason("The provider and the subject of a linked OpenID Connect provider must not be empty."))
+		}
+		if seen[p.Provider] {
+			return errors.WithStack(herodot.ErrBadRequest.WithReasonf("The OpenID Connect provider %s can only be linked once.", p.Provider))
+		}
+		seen[p.Provider] = true
+
+		// The identifier format must match the one used by the oidc strategy.
+		identifiers[k] = fmt.Sprintf("%s:%s", p.Provider, p.Subject)
+
+		for _, e := range existing {
+			if e.Get("provider").String() == p.Provider && e.Get("subject").String() == p.Subject {
+				linked[k] = json.RawMessage(e.Raw)
+			}
+		}
+		if linked[k] == nil {
+			raw, err := json.Marshal(p)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			linked[k] = raw
+		}
+	}
+
+	config, err := json.Marshal(map[string]interface{}{credentialsOIDCProvidersPath: linked})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.SetCredentials(CredentialsTypeOIDC, Credentials{
+		Type:        CredentialsTypeOIDC,
+		Identifiers: identifiers,
+		Config:      config,
+	})
+	return nil
+}
+
+// DeclassifyCredentialsOIDC returns a copy of the oidc credentials in which the encrypted claims snapshot of
+// every linked provider is replaced with the decrypted claims.
+func DeclassifyCredentialsOIDC(ctx context.Context, c cipher.Cipher, creds Credentials) (*Credentials, error) {
+	config := []byte(creds.Config)
+	for k, p := range gjson.GetBytes(config, credentialsOIDCProvidersPath).Array() {
+		encrypted := p.Get(credentialsOIDCClaimsPath).String()
+		if len(encrypted) == 0 {
+			continue
+		}
+
+		claims, err := c.Decrypt(ctx, encrypted)
+		if err != nil {
+			return nil, err
+		}
+
+		if !gjson.ValidBytes(claims) {
+			return nil, errors.WithStack(herodot.ErrInternalServerError.WithReason("The decrypted OpenID Connect claims are not valid JSON."))
+		}
+
+		config, err = sjson.SetRawBytes(config, fmt.Sprintf("%s.%d.%s", credentialsOIDCProvidersPath, k, credentialsOIDCClaimsPath), claims)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	creds.Config = config
+	return &creds, nil
+}
@@ -0,0 +1,131 @@
+package identity
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/herodot"
+)
+
+const (
+	// credentialsPasswordExpiredPath is the path of the expiry flag in the password credentials config.
+	credentialsPasswordExpiredPath = "expired"
+
+	// credentialsPasswordHashPath is the path of the password hash in the password credentials config.
+	credentialsPasswordHashPath = "hashed_password"
+
+	// credentialsPasswordMustChangePath is the path of the flag marking a temporary password.
+	credentialsPasswordMustChangePath = "must_change"
+
+	// credentialsPasswordConsumedPath is the path of the flag marking a temporary password which was used to sign in.
+	credentialsPasswordConsumedPath = "consumed"
+)
+
+// PasswordExpired returns true if an administrator forced the identity to reset its password or set a
+// temporary password.
+func (i *Identity) PasswordExpired() bool {
+	c, ok := i.GetCredentials(CredentialsTypePassword)
+	return ok && (gjson.GetBytes(c.Config, credentialsPasswordExpiredPath).Bool() ||
+		gjson.GetBytes(c.Config, credentialsPasswordMustChangePath).Bool())
+}
+
+// ExpirePassword marks the identity's password as expired. Signing in with an expired password only
+// issues a session which can be used to set a new password. Setting a new password removes the mark.
+func (i *Identity) ExpirePassword() error {
+	c, ok := i.GetCredentials(CredentialsTypePassword)
+	if !ok || len(gjson.GetBytes(c.Config, credentialsPasswordHashPath).String()) == 0 {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The identity does not have a password."))
+	}
+
+	config, err := sjson.SetBytes(c.Config, credentialsPasswordExpiredPath, true)
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to mark the password as expired: %s", err))
+	}
+
+	c.Config = config
+	i.SetCredentials(CredentialsTypePassword, *c)
+	return nil
+}
+
+// RevokePassword makes the identity's password unusable without removing its identifiers. Signing in with a
+// revoked password fails like signing in with a temporary password which was used before, so the identity
+// has to recover its account to set a new password. It returns false if the identity does not have a password.
+func (i *Identity) RevokePassword() (bool, error) {
+	c, ok := i.GetCredentials(CredentialsTypePassword)
+	if !ok || len(gjson.GetBytes(c.Config, credentialsPasswordHashPath).String()) == 0 {
+		return false, nil
+	}
+
+	config, err := sjson.SetBytes(c.Config, credentialsPasswordMustChangePath, true)
+	if err == nil {
+		config, err = sjson.SetBytes(config, credentialsPasswordConsumedPath, true)
+	}
+	if err != nil {
+		return false, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to revoke the password: %s", err))
+	}
+
+	c.Config = config
+	i.SetCredentials(CredentialsTypePassword, *c)
+	return true, nil
+}
+
+// SetPassword replaces the identity's password. It removes the marks set by ExpirePassword and
+// SetTemporaryPassword.
+func (i *Identity) SetPassword(hashedPassword []byte) error {
+	return i.setPassword(map[string]interface{}{
+		credentialsPasswordHashPath: string(hashedPassword),
+	})
+}
+
+// SetTemporaryPassword replaces the identity's password with a temporary one. The temporary password can be
+// used to sign in exactly once and the resulting session can only be used to set a new password.
+func (i *Identity) SetTemporaryPassword(hashedPassword []byte) error {
+	return i.setPassword(map[string]interface{}{
+		credentialsPasswordHashPath:       string(hashedPassword),
+		credentialsPasswordMustChangePath: true,
+	})
+}
+
+func (i *Identity) setPassword(c map[string]interface{}) error {
+	config, err := json.Marshal(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var identifiers []string
+	if c, ok := i.GetCredentials(CredentialsTypePassword); ok {
+		identifiers = c.Identifiers
+	}
+
+	i.SetCredentials(CredentialsTypePassword, Credentials{
+		Type:        CredentialsTypePassword,
+		Identifiers: identifiers,
+		Config:      config,
+	})
+	return nil
+}
+
+// ConsumeTemporaryPassword marks a temporary password as used. It returns an error if the temporary
+// password was used before and does nothing if the password is not temporary.
+func (i *Identity) ConsumeTemporaryPassword() error {
+	c, ok := i.GetCredentials(CredentialsTypePassword)
+	if !ok || !gjson.GetBytes(c.Config, credentialsPasswordMustChangePath).Bool() {
+		return nil
+	}
+
+	if gjson.GetBytes(c.Config, credentialsPasswordConsumedPath).Bool() {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The temporary password was already used."))
+	}
+
+	config, err := sjson.SetBytes(c.Config, credentialsPasswordConsumedPath, true)
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to consume the temporary password: %s", err))
+	}
+
+	c.Config = config
+	i.SetCredentials(CredentialsTypePassword, *c)
+	return nil
+}
@@ -0,0 +1,57 @@
+package identity
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// DeletionReport lists what was removed or changed when an identity was deleted. It does not contain any
+// personal data of the identity and can be kept for compliance records.
+//
+// swagger:model identityDeletionReport
+type DeletionReport struct {
+	// IdentityID is the ID of the deleted identity.
+	//
+	// required: true
+	IdentityID uuid.UUID `json:"identity_id"`
+
+	// DeletedAt is the time at which the identity was deleted.
+	//
+	// required: true
+	DeletedAt time.Time `json:"deleted_at"`
+
+	// Credentials is the number of deleted credentials.
+	Credentials int `json:"credentials"`
+
+	// VerifiableAddresses is the number of deleted verifiable addresses.
+	VerifiableAddresses int `json:"verifiable_addresses"`
+
+	// RecoveryAddresses is the number of deleted recovery addresses.
+	RecoveryAddresses int `json:"recovery_addresses"`
+
+	// Sessions is the number of deleted sessions, including sessions which were no longer active.
+	Sessions int `json:"sessions"`
+
+	// VerificationTokens is the number of deleted verification tokens.
+	VerificationTokens int `json:"verification_tokens"`
+
+	// RecoveryTokens is the number of deleted recovery tokens.
+	RecoveryTokens int `json:"recovery_tokens"`
+
+	// ContinuityContainers is the number of deleted continuity containers.
+	ContinuityContainers int `json:"continuity_containers"`
+
+	// CommunicationPreferences is 1 if the identity's communication preferences were deleted.
+	CommunicationPreferences int `json:"communication_preferences"`
+
+	// DataKeys is 1 if the identity's data key was destroyed, after which data encrypted with it can no longer
+	// be decrypted.
+	DataKeys int `json:"data_keys"`
+
+	// CourierMessagesDeleted is the number of deleted courier messages.
+	CourierMessagesDeleted int `json:"courier_messages_deleted"`
+
+	// CourierMessagesAnonymized is the number of courier messages whose recipient and content were removed.
+	CourierMessagesAnonymized int `json:"courier_messages_anonymized"`
+}
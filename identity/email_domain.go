@@ -0,0 +1,140 @@
+package identity
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
+)
+
+// disposableEmailDomains are well-known providers of disposable email addresses which are rejected if
+// `deny_disposable` is enabled. Subdomains are rejected as well.
+var disposableEmailDomains = map[string]bool{
+	"10minutemail.com":       true,
+	"20minutemail.com":       true,
+	"33mail.com":             true,
+	"dispostable.com":        true,
+	"emailondeck.com":        true,
+	"fakeinbox.com":          true,
+	"getairmail.com":         true,
+	"getnada.com":            true,
+	"guerrillamail.biz":      true,
+	"guerrillamail.com":      true,
+	"guerrillamail.de":       true,
+	"guerrillamail.net":      true,
+	"guerrillamail.org":      true,
+	"guerrillamailblock.com": true,
+	"maildrop.cc":            true,
+	"mailinator.com":         true,
+	"mailinator.net":         true,
+	"mailnesia.com":          true,
+	"mintemail.com":          true,
+	"mohmal.com":             true,
+	"mytemp.email":           true,
+	"sharklasers.com":        true,
+	"spamgourmet.com":        true,
+	"temp-mail.io":           true,
+	"temp-mail.org":          true,
+	"tempail.com":            true,
+	"tempmail.net":           true,
+	"tempmailo.com":          true,
+	"tempr.email":            true,
+	"throwawaymail.com":      true,
+	"trashmail.com":          true,
+	"trashmail.de":           true,
+	"yopmail.com":            true,
+	"yopmail.fr":             true,
+}
+
+type lookupMXFunc func(ctx context.Context, name string) ([]*net.MX, error)
+
+// ValidateEmailDomains rejects email addresses in the identity's traits whose domain the email domain policy of
+// the identity's schema does not allow. Only traits verified or recovered via email are checked. Addresses the
+// original identity already uses are accepted, so that identities are not locked out of unrelated changes when
+// the policy becomes stricter. The original identity is nil for new identities.
+func (v *Validator) ValidateEmailDomains(ctx context.Context, i *Identity, original *Identity) error {
+	policy := v.d.Config(ctx).IdentityEmailDomainPolicy(i.SchemaID)
+	if policy.IsEmpty() {
+		return nil
+	}
+
+	collector := &traitCollector{match: func(c schema.ExtensionConfig) bool {
+		return c.Verification.Via == "email" || c.Recovery.Via == "email"
+	}}
+	if err := v.ValidateWithRunner(ctx, i, collector); err != nil {
+		return err
+	}
+
+	known := make(map[string]bool)
+	if original != nil {
+		for _, address := range original.Addresses() {
+			known[strings.ToLower(address)] = true
+		}
+	}
+
+	for _, address := range collector.values {
+		if known[strings.ToLower(address)] {
+			continue
+		}
+
+		domain, reason := checkEmailDomain(ctx, policy, address, v.lookupMX)
+		if reason != "" {
+			return schema.NewEmailDomainRejectedError(traitErrorPointer(i, address), domain, reason)
+		}
+	}
+
+	return nil
+}
+
+// checkEmailDomain returns the domain of the email address and, if the policy rejects it, the reason why.
+func checkEmailDomain(ctx context.Context, policy *config.EmailDomainPolicy, address string, lookupMX lookupMXFunc) (domain, reason string) {
+	domain = strings.TrimSuffix(strings.ToLower(address[strings.LastIndex(address, "@")+1:]), ".")
+
+	if len(policy.Allow) > 0 && !matchesEmailDomain(domain, policy.Allow) {
+		return domain, "it is not allowed"
+	} else if matchesEmailDomain(domain, policy.Deny) {
+		return domain, "it is not allowed"
+	}
+
+	if policy.DenyDisposable {
+		for d := domain; d != ""; d = parentDomain(d) {
+			if disposableEmailDomains[d] {
+				return domain, "it belongs to a disposable email provider"
+			}
+		}
+	}
+
+	if policy.RequireMX {
+		records, err := lookupMX(ctx, domain)
+		if dnsErr := new(net.DNSError); errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return domain, "it does not accept email"
+		} else if err == nil && (len(records) == 0 || (len(records) == 1 && records[0].Host == ".")) {
+			// A single record pointing to "." is a null MX record (RFC 7505).
+			return domain, "it does not accept email"
+		}
+	}
+
+	return domain, ""
+}
+
+// matchesEmailDomain returns true if the domain is one of the given domains or one of their subdomains.
+func matchesEmailDomain(domain string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.TrimSuffix(strings.ToLower(d), ".")
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func parentDomain(domain string) string {
+	if i := strings.Index(domain, "."); i >= 0 {
+		return domain[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestCheckEmailDomain(t *testing.T) {
+	lookupMX := func(_ context.Context, name string) ([]*net.MX, error) {
+		switch name {
+		case "ory.sh", "mail.ory.sh":
+			return []*net.MX{{Host: "mx.ory.sh.", Pref: 10}}, nil
+		case "null-mx.com":
+			return []*net.MX{{Host: "."}}, nil
+		case "timeout.com":
+			return nil, &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true}
+		}
+		return nil, errors.WithStack(&net.DNSError{Err: "no such host", Name: name, IsNotFound: true})
+	}
+
+	for k, tc := range []struct {
+		policy  config.EmailDomainPolicy
+		address string
+		reason  string
+	}{
+		{policy: config.EmailDomainPolicy{}, address: "foo@mailinator.com"},
+		{policy: config.EmailDomainPolicy{Allow: []string{"ory.sh"}}, address: "foo@ory.sh"},
+		{policy: config.EmailDomainPolicy{Allow: []string{"ory.sh"}}, address: "foo@Mail.Ory.Sh"},
+		{policy: config.EmailDomainPolicy{Allow: []string{"ory.sh"}}, address: "foo@notory.sh", reason: "it is not allowed"},
+		{policy: config.EmailDomainPolicy{Deny: []string{"example.org"}}, address: "foo@ory.sh"},
+		{policy: config.EmailDomainPolicy{Deny: []string{"example.org"}}, address: "foo@sub.example.org", reason: "it is not allowed"},
+		{policy: config.EmailDomainPolicy{Allow: []string{"example.org"}, Deny: []string{"sub.example.org"}}, address: "foo@sub.example.org", reason: "it is not allowed"},
+		{policy: config.EmailDomainPolicy{DenyDisposable: true}, address: "foo@ory.sh"},
+		{policy: config.EmailDomainPolicy{DenyDisposable: true}, address: "foo@mailinator.com", reason: "it belongs to a disposable email provider"},
+		{policy: config.EmailDomainPolicy{DenyDisposable: true}, address: "foo@inbox.yopmail.com", reason: "it belongs to a disposable email provider"},
+		{policy: config.EmailDomainPolicy{RequireMX: true}, address: "foo@mail.ory.sh"},
+		{policy: config.EmailDomainPolicy{RequireMX: true}, address: "foo@timeout.com"},
+		{policy: config.EmailDomainPolicy{RequireMX: true}, address: "foo@null-mx.com", reason: "it does not accept email"},
+		{policy: config.EmailDomainPolicy{RequireMX: true}, address: "foo@does-not-exist.com", reason: "it does not accept email"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			_, reason := checkEmailDomain(context.Background(), &tc.policy, tc.address, lookupMX)
+			assert.Equal(t, tc.reason, reason)
+		})
+	}
+}
+
+func TestTraitPointer(t *testing.T) {
+	traits := gjson.Parse(`{"email":"foo@ory.sh","backup":{"emails":["bar@ory.sh","baz@ory.sh"]},"a/b":"qux@ory.sh"}`)
+	for value, expected := range map[string]string{
+		"foo@ory.sh": "#/traits/email",
+		"baz@ory.sh": "#/traits/backup/emails/1",
+		"qux@ory.sh": "#/traits/a~1b",
+	} {
+		actual, ok := traitPointer(traits, "#/traits", value)
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, actual, value)
+	}
+
+	_, ok := traitPointer(traits, "#/traits", "unknown@ory.sh")
+	assert.False(t, ok)
+}
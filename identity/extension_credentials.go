@@ -1,9 +1,7 @@
 package identity
 
 import (
-	"fmt"
 	"strings"
-	"sync"
 
 	"github.com/ory/go-convenience/stringslice"
 	"github.com/ory/jsonschema/v3"
@@ -14,34 +12,40 @@ import (
 
 type SchemaExtensionCredentials struct {
 	i *Identity
-	v []string
-	l sync.Mutex
 }
 
 func NewSchemaExtensionCredentials(i *Identity) *SchemaExtensionCredentials {
 	return &SchemaExtensionCredentials{i: i}
 }
 
-func (r *SchemaExtensionCredentials) Run(_ jsonschema.ValidationContext, s schema.ExtensionConfig, value interface{}) error {
-	r.l.Lock()
-	defer r.l.Unlock()
-	if s.Credentials.Password.Identifier {
-		cred, ok := r.i.GetCredentials(CredentialsTypePassword)
-		if !ok {
-			cred = &Credentials{
-				Type:        CredentialsTypePassword,
-				Identifiers: []string{},
-				Config:      sqlxx.JSONRawMessage{},
-			}
+func (r *SchemaExtensionCredentials) Run(_ jsonschema.ValidationContext, _ schema.ExtensionConfig, _ interface{}) error {
+	return nil
+}
+
+func (r *SchemaExtensionCredentials) Finish(values []schema.ExtensionValue) error {
+	var identifiers []string
+	for _, v := range values {
+		if !v.Config.Credentials.Password.Identifier {
+			continue
 		}
+		for _, value := range extensionStrings(v.Value) {
+			identifiers = append(identifiers, strings.ToLower(value))
+		}
+	}
 
-		r.v = stringslice.Unique(append(r.v, strings.ToLower(fmt.Sprintf("%s", value))))
-		cred.Identifiers = r.v
-		r.i.SetCredentials(CredentialsTypePassword, *cred)
+	if len(identifiers) == 0 {
+		return nil
+	}
+
+	cred, ok := r.i.GetCredentials(CredentialsTypePassword)
+	if !ok {
+		cred = &Credentials{
+			Type:   CredentialsTypePassword,
+			Config: sqlxx.JSONRawMessage{},
+		}
 	}
-	return nil
-}
 
-func (r *SchemaExtensionCredentials) Finish() error {
+	cred.Identifiers = stringslice.Unique(identifiers)
+	r.i.SetCredentials(CredentialsTypePassword, *cred)
 	return nil
 }
@@ -1,7 +1,6 @@
 package identity_test
 
 import (
-	"bytes"
 	"fmt"
 	"testing"
 
@@ -54,15 +53,15 @@ func TestSchemaExtensionCredentials(t *testing.T) {
 			}
 
 			runner.AddRunner(e).Register(c)
-			err = c.MustCompile(tc.schema).Validate(bytes.NewBufferString(tc.doc))
+			err = runner.Validate(c.MustCompile(tc.schema), []byte(tc.doc))
 			if tc.expectErr != nil {
 				require.EqualError(t, err, tc.expectErr.Error())
 			}
-			require.NoError(t, e.Finish())
+			require.NoError(t, err)
 
 			credentials, ok := i.GetCredentials(identity.CredentialsTypePassword)
 			require.True(t, ok)
-			assert.ElementsMatch(t, tc.expect, credentials.Identifiers)
+			assert.Equal(t, tc.expect, credentials.Identifiers)
 		})
 	}
 }
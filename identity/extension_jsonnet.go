@@ -0,0 +1,136 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/google/go-jsonnet"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/jsonschema/v3"
+	"github.com/ory/x/fetcher"
+
+	"github.com/ory/kratos/schema"
+)
+
+type (
+	// SchemaExtensionFactory returns an extension which runs next to the built-in credentials, verification, and
+	// recovery extensions whenever the traits of i are validated. Extensions are enabled per trait with
+	//
+	//	"ory.sh/kratos": {"extensions": {"<name>": <settings>}}
+	//
+	// and read their settings from schema.ExtensionConfig.Extensions. Finish is only called if the traits are valid
+	// and may change i, for example to add derived traits.
+	SchemaExtensionFactory func(ctx context.Context, i *Identity) schema.Extension
+
+	SchemaExtensionProvider interface {
+		IdentitySchemaExtensions(ctx context.Context) []SchemaExtensionFactory
+	}
+
+	// SchemaExtensionJsonnet evaluates a Jsonnet snippet for every trait which enables the extension. The snippet
+	// receives the trait's value and the extension's settings as std.extVar('ctx').value and std.extVar('ctx').config
+	// and must return an object. Once the traits are valid, the objects are merged into the identity's traits in
+	// document order. The resulting traits are not validated again, so the schema should declare the derived keys.
+	SchemaExtensionJsonnet struct {
+		name string
+		url  string
+		f    *fetcher.Fetcher
+		i    *Identity
+	}
+)
+
+func NewSchemaExtensionJsonnet(i *Identity, name, url string, f *fetcher.Fetcher) *SchemaExtensionJsonnet {
+	return &SchemaExtensionJsonnet{i: i, name: name, url: url, f: f}
+}
+
+func (r *SchemaExtensionJsonnet) Run(_ jsonschema.ValidationContext, _ schema.ExtensionConfig, _ interface{}) error {
+	return nil
+}
+
+// Finish evaluates the Jsonnet code for every trait which enables the extension. The code is evaluated only now
+// because the JSON Schema validator only accepts validation errors from Run.
+func (r *SchemaExtensionJsonnet) Finish(values []schema.ExtensionValue) error {
+	var snippet string
+	traits := make(map[string]interface{})
+	var changed bool
+	for _, v := range values {
+		settings, ok := v.Config.Extensions[r.name]
+		if !ok {
+			continue
+		}
+
+		if snippet == "" {
+			fetched, err := r.f.Fetch(r.url)
+			if err != nil {
+				return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to fetch the Jsonnet code of identity schema extension %s: %s", r.name, err))
+			}
+			snippet = fetched.String()
+
+			if len(r.i.Traits) > 0 {
+				if err := decodeJSONNumber(r.i.Traits, &traits); err != nil {
+					return err
+				}
+			}
+		}
+
+		derived, err := r.evaluate(snippet, settings, v.Value)
+		if err != nil {
+			return err
+		}
+		mergeJSONObjects(traits, derived)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	encoded, err := json.Marshal(traits)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	r.i.Traits = encoded
+	return nil
+}
+
+func (r *SchemaExtensionJsonnet) evaluate(snippet string, settings json.RawMessage, value interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(map[string]interface{}{"value": value, "config": settings})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("ctx", string(encoded))
+	evaluated, err := vm.EvaluateSnippet(r.url, snippet)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to evaluate identity schema extension %s: %s", r.name, err))
+	}
+
+	var derived map[string]interface{}
+	if err := decodeJSONNumber([]byte(evaluated), &derived); err != nil || derived == nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Identity schema extension %s must return an object.", r.name))
+	}
+	return derived, nil
+}
+
+func decodeJSONNumber(raw []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	return errors.WithStack(d.Decode(v))
+}
+
+// mergeJSONObjects merges src into dst. Nested objects are merged, all other values in src replace those in dst.
+func mergeJSONObjects(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if sv, ok := v.(map[string]interface{}); ok {
+			if dv, ok := dst[k].(map[string]interface{}); ok {
+				mergeJSONObjects(dv, sv)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
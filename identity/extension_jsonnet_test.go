@@ -0,0 +1,71 @@
+package identity
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/ory/jsonschema/v3"
+	_ "github.com/ory/jsonschema/v3/fileloader"
+	"github.com/ory/x/fetcher"
+
+	"github.com/ory/kratos/schema"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaExtensionJsonnet(t *testing.T) {
+	for k, tc := range []struct {
+		doc       string
+		url       string
+		expect    string
+		expectErr string
+	}{
+		{
+			doc:    `{"email":"Foo@Ory.sh","backup_email":"Bar@Ory.sh","name":"foo"}`,
+			url:    "file://./stub/extension/jsonnet/search_key.jsonnet",
+			expect: `{"email":"Foo@Ory.sh","backup_email":"Bar@Ory.sh","name":"foo","search":{"email":"foo@ory.sh","backup_email":"bar@ory.sh"}}`,
+		},
+		{
+			doc:    `{"name":"foo","search":{"email":"stale@ory.sh","other":"kept"}}`,
+			url:    "file://./stub/extension/jsonnet/search_key.jsonnet",
+			expect: `{"name":"foo","search":{"email":"stale@ory.sh","other":"kept"}}`,
+		},
+		{
+			doc:    `{"email":"Foo@Ory.sh","search":{"email":"stale@ory.sh","other":"kept"}}`,
+			url:    "file://./stub/extension/jsonnet/search_key.jsonnet",
+			expect: `{"email":"Foo@Ory.sh","search":{"email":"foo@ory.sh","other":"kept"}}`,
+		},
+		{
+			doc:       `{"email":"foo@ory.sh"}`,
+			url:       "base64://" + base64.StdEncoding.EncodeToString([]byte(`"not an object"`)),
+			expectErr: "must return an object",
+		},
+		{
+			doc:       `{"email":"foo@ory.sh"}`,
+			url:       "base64://" + base64.StdEncoding.EncodeToString([]byte(`{`)),
+			expectErr: "Unable to evaluate identity schema extension search_key",
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			id := &Identity{Traits: Traits(tc.doc)}
+			c := jsonschema.NewCompiler()
+			runner, err := schema.NewExtensionRunner(schema.ExtensionRunnerIdentityMetaSchema)
+			require.NoError(t, err)
+
+			e := NewSchemaExtensionJsonnet(id, "search_key", tc.url, fetcher.NewFetcher())
+			runner.AddRunner(e).Register(c)
+
+			err = runner.Validate(c.MustCompile("file://./stub/extension/jsonnet/schema.json"), []byte(tc.doc))
+			if tc.expectErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, fmt.Sprintf("%+v", err), tc.expectErr)
+				assert.JSONEq(t, tc.doc, string(id.Traits))
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expect, string(id.Traits))
+		})
+	}
+}
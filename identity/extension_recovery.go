@@ -1,17 +1,12 @@
 package identity
 
 import (
-	"fmt"
-	"sync"
-
 	"github.com/ory/jsonschema/v3"
 
 	"github.com/ory/kratos/schema"
 )
 
 type SchemaExtensionRecovery struct {
-	l sync.Mutex
-	v []RecoveryAddress
 	i *Identity
 }
 
@@ -20,28 +15,13 @@ func NewSchemaExtensionRecovery(i *Identity) *SchemaExtensionRecovery {
 }
 
 func (r *SchemaExtensionRecovery) Run(ctx jsonschema.ValidationContext, s schema.ExtensionConfig, value interface{}) error {
-	r.l.Lock()
-	defer r.l.Unlock()
-
 	switch s.Recovery.Via {
 	case "email":
-		if !jsonschema.Formats["email"](value) {
-			return ctx.Error("format", "%q is not valid %q", value, "email")
-		}
-
-		address := NewRecoveryEmailAddress(fmt.Sprintf("%s", value), r.i.ID)
-
-		if has := r.has(r.i.RecoveryAddresses, address); has != nil {
-			if r.has(r.v, address) == nil {
-				r.v = append(r.v, *has)
+		for _, address := range extensionStrings(value) {
+			if !jsonschema.Formats["email"](address) {
+				return ctx.Error("format", "%q is not valid %q", address, "email")
 			}
-			return nil
 		}
-
-		if has := r.has(r.v, address); has == nil {
-			r.v = append(r.v, *address)
-		}
-
 		return nil
 	case "":
 		return nil
@@ -59,7 +39,33 @@ func (r *SchemaExtensionRecovery) has(haystack []RecoveryAddress, needle *Recove
 	return nil
 }
 
-func (r *SchemaExtensionRecovery) Finish() error {
-	r.i.RecoveryAddresses = r.v
+func (r *SchemaExtensionRecovery) Finish(values []schema.ExtensionValue) error {
+	var addresses []RecoveryAddress
+	for _, v := range values {
+		if v.Config.Recovery.Via != "email" {
+			continue
+		}
+
+		for _, value := range extensionStrings(v.Value) {
+			address := NewRecoveryEmailAddress(value, r.i.ID)
+			if r.has(addresses, address) != nil {
+				continue
+			}
+
+			if has := r.has(r.i.RecoveryAddresses, address); has != nil {
+				address = has
+			}
+			addresses = append(addresses, *address)
+		}
+	}
+
+	// Addresses added using the admin API are not part of the traits and must survive trait updates.
+	for k := range r.i.RecoveryAddresses {
+		if a := r.i.RecoveryAddresses[k]; a.Source == RecoveryAddressSourceAdmin && r.has(addresses, &a) == nil {
+			addresses = append(addresses, a)
+		}
+	}
+
+	r.i.RecoveryAddresses = addresses
 	return nil
 }
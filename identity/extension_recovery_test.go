@@ -1,10 +1,8 @@
 package identity
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
-	"reflect"
 	"testing"
 
 	"github.com/ory/jsonschema/v3"
@@ -33,6 +31,7 @@ func TestSchemaExtensionRecovery(t *testing.T) {
 				{
 					Value:      "foo@ory.sh",
 					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceSchema,
 					IdentityID: iid,
 				},
 			},
@@ -44,6 +43,7 @@ func TestSchemaExtensionRecovery(t *testing.T) {
 				{
 					Value:      "foo@ory.sh",
 					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceSchema,
 					IdentityID: iid,
 				},
 			},
@@ -60,12 +60,13 @@ func TestSchemaExtensionRecovery(t *testing.T) {
 			schema: "file://./stub/extension/recovery/schema.json",
 			expect: []RecoveryAddress{
 				{
-					Value:      "foo@ory.sh",
+					Value:      "baz@ory.sh",
 					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceSchema,
 					IdentityID: iid,
 				},
 				{
-					Value:      "baz@ory.sh",
+					Value:      "foo@ory.sh",
 					Via:        RecoveryAddressTypeEmail,
 					IdentityID: iid,
 				},
@@ -95,6 +96,7 @@ func TestSchemaExtensionRecovery(t *testing.T) {
 				{
 					Value:      "baz@ory.sh",
 					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceSchema,
 					IdentityID: iid,
 				},
 			},
@@ -123,16 +125,75 @@ func TestSchemaExtensionRecovery(t *testing.T) {
 				{
 					Value:      "foo@ory.sh",
 					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceSchema,
 					IdentityID: iid,
 				},
 				{
 					Value:      "bar@ory.sh",
 					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceSchema,
 					IdentityID: iid,
 				},
 				{
 					Value:      "foobar@ory.sh",
 					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceSchema,
+					IdentityID: iid,
+				},
+			},
+		},
+		{
+			doc:    `{"username":"foo@ory.sh"}`,
+			schema: "file://./stub/extension/recovery/schema.json",
+			expect: []RecoveryAddress{
+				{
+					Value:      "foo@ory.sh",
+					Via:        RecoveryAddressTypeEmail,
+					Disabled:   true,
+					IdentityID: iid,
+				},
+				{
+					Value:      "bar@ory.sh",
+					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceAdmin,
+					IdentityID: iid,
+				},
+			},
+			existing: []RecoveryAddress{
+				{
+					Value:      "foo@ory.sh",
+					Via:        RecoveryAddressTypeEmail,
+					Disabled:   true,
+					IdentityID: iid,
+				},
+				{
+					Value:      "bar@ory.sh",
+					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceAdmin,
+					IdentityID: iid,
+				},
+				{
+					Value:      "baz@ory.sh",
+					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceSchema,
+					IdentityID: iid,
+				},
+			},
+		},
+		{
+			doc:    `{"contact":{"emails":["b@ory.sh","a@ory.sh"]},"backup":{"email":"a@ory.sh"}}`,
+			schema: "file://./stub/extension/recovery/multi.schema.json",
+			expect: []RecoveryAddress{
+				{
+					Value:      "a@ory.sh",
+					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceSchema,
+					IdentityID: iid,
+				},
+				{
+					Value:      "b@ory.sh",
+					Via:        RecoveryAddressTypeEmail,
+					Source:     RecoveryAddressSourceSchema,
 					IdentityID: iid,
 				},
 			},
@@ -147,27 +208,14 @@ func TestSchemaExtensionRecovery(t *testing.T) {
 			e := NewSchemaExtensionRecovery(id)
 			runner.AddRunner(e).Register(c)
 
-			err = c.MustCompile(tc.schema).Validate(bytes.NewBufferString(tc.doc))
+			err = runner.Validate(c.MustCompile(tc.schema), []byte(tc.doc))
 			if tc.expectErr != nil {
 				require.EqualError(t, err, tc.expectErr.Error())
 				return
 			}
+			require.NoError(t, err)
 
-			require.NoError(t, e.Finish())
-
-			addresses := id.RecoveryAddresses
-			require.Len(t, addresses, len(tc.expect))
-
-			for _, actual := range addresses {
-				var found bool
-				for _, expect := range tc.expect {
-					if reflect.DeepEqual(actual, expect) {
-						found = true
-						break
-					}
-				}
-				assert.True(t, found, "%+v not in %+v", actual, tc.expect)
-			}
+			assert.Equal(t, tc.expect, id.RecoveryAddresses)
 		})
 	}
 }
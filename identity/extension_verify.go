@@ -2,48 +2,46 @@ package identity
 
 import (
 	"fmt"
-	"sync"
+	"regexp"
 	"time"
 
 	"github.com/ory/jsonschema/v3"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/schema"
 )
 
+// phoneNumber matches phone numbers in E.164 format, such as +12065550100.
+var phoneNumber = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
 type SchemaExtensionVerification struct {
 	lifespan time.Duration
-	l        sync.Mutex
-	v        []VerifiableAddress
+	policy   config.VerifiableAddressMergePolicy
 	i        *Identity
 }
 
-func NewSchemaExtensionVerification(i *Identity, lifespan time.Duration) *SchemaExtensionVerification {
-	return &SchemaExtensionVerification{i: i, lifespan: lifespan}
+// NewSchemaExtensionVerification returns an extension which derives the identity's verifiable addresses from its
+// traits. Addresses which are already tracked keep their verification status. The policy decides what happens to
+// tracked addresses which no longer appear in the traits.
+func NewSchemaExtensionVerification(i *Identity, lifespan time.Duration, policy config.VerifiableAddressMergePolicy) *SchemaExtensionVerification {
+	return &SchemaExtensionVerification{i: i, lifespan: lifespan, policy: policy}
 }
 
 func (r *SchemaExtensionVerification) Run(ctx jsonschema.ValidationContext, s schema.ExtensionConfig, value interface{}) error {
-	r.l.Lock()
-	defer r.l.Unlock()
-
 	switch s.Verification.Via {
 	case "email":
-		if !jsonschema.Formats["email"](value) {
-			return ctx.Error("format", "%q is not valid %q", value, "email")
-		}
-
-		address := NewVerifiableEmailAddress(fmt.Sprintf("%s", value), r.i.ID)
-
-		if has := r.has(r.i.VerifiableAddresses, address); has != nil {
-			if r.has(r.v, address) == nil {
-				r.v = append(r.v, *has)
+		for _, address := range extensionStrings(value) {
+			if !jsonschema.Formats["email"](address) {
+				return ctx.Error("format", "%q is not valid %q", address, "email")
 			}
-			return nil
 		}
-
-		if has := r.has(r.v, address); has == nil {
-			r.v = append(r.v, *address)
+		return nil
+	case AddressTypeSMS:
+		for _, address := range extensionStrings(value) {
+			if !phoneNumber.MatchString(address) {
+				return ctx.Error("format", "%q is not a valid phone number in E.164 format, such as +12065550100", address)
+			}
 		}
-
 		return nil
 	case "":
 		return nil
@@ -61,7 +59,65 @@ func (r *SchemaExtensionVerification) has(haystack []VerifiableAddress, needle *
 	return nil
 }
 
-func (r *SchemaExtensionVerification) Finish() error {
-	r.i.VerifiableAddresses = r.v
+func (r *SchemaExtensionVerification) Finish(values []schema.ExtensionValue) error {
+	var addresses []VerifiableAddress
+	for _, v := range values {
+		newAddress := NewVerifiableEmailAddress
+		switch v.Config.Verification.Via {
+		case AddressTypeEmail:
+		case AddressTypeSMS:
+			newAddress = NewVerifiableSMSAddress
+		default:
+			continue
+		}
+
+		for _, value := range extensionStrings(v.Value) {
+			address := newAddress(value, r.i.ID)
+			if r.has(addresses, address) != nil {
+				continue
+			}
+
+			if has := r.has(r.i.VerifiableAddresses, address); has != nil {
+				if has.Status == VerifiableAddressStatusStale {
+					has.Status = VerifiableAddressStatusPending
+					if has.Verified {
+						has.Status = VerifiableAddressStatusCompleted
+					}
+				}
+				address = has
+			}
+			addresses = append(addresses, *address)
+		}
+	}
+
+	if r.policy == config.VerifiableAddressMergeKeep || r.policy == config.VerifiableAddressMergeMarkStale {
+		for k := range r.i.VerifiableAddresses {
+			unrelated := r.i.VerifiableAddresses[k]
+			if r.has(addresses, &unrelated) != nil {
+				continue
+			}
+			if r.policy == config.VerifiableAddressMergeMarkStale {
+				unrelated.Status = VerifiableAddressStatusStale
+			}
+			addresses = append(addresses, unrelated)
+		}
+	}
+
+	r.i.VerifiableAddresses = addresses
 	return nil
 }
+
+// extensionStrings returns the value of a trait as strings. Arrays, such as a list of email addresses, return one
+// string per element.
+func extensionStrings(value interface{}) []string {
+	values, ok := value.([]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("%s", value)}
+	}
+
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, fmt.Sprintf("%s", v))
+	}
+	return result
+}
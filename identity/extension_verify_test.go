@@ -1,16 +1,15 @@
 package identity
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
-	"reflect"
 	"testing"
 	"time"
 
 	"github.com/ory/jsonschema/v3"
 	_ "github.com/ory/jsonschema/v3/fileloader"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/x"
 
@@ -26,6 +25,7 @@ func TestSchemaExtensionVerification(t *testing.T) {
 		doc       string
 		expect    []VerifiableAddress
 		existing  []VerifiableAddress
+		policy    config.VerifiableAddressMergePolicy
 	}{
 		{
 			doc:    `{"username":"foo@ory.sh"}`,
@@ -67,16 +67,16 @@ func TestSchemaExtensionVerification(t *testing.T) {
 			schema: "file://./stub/extension/verify/schema.json",
 			expect: []VerifiableAddress{
 				{
-					Value:      "foo@ory.sh",
-					Verified:   true,
-					Status:     VerifiableAddressStatusCompleted,
+					Value:      "baz@ory.sh",
+					Verified:   false,
+					Status:     VerifiableAddressStatusPending,
 					Via:        VerifiableAddressTypeEmail,
 					IdentityID: iid,
 				},
 				{
-					Value:      "baz@ory.sh",
-					Verified:   false,
-					Status:     VerifiableAddressStatusPending,
+					Value:      "foo@ory.sh",
+					Verified:   true,
+					Status:     VerifiableAddressStatusCompleted,
 					Via:        VerifiableAddressTypeEmail,
 					IdentityID: iid,
 				},
@@ -166,6 +166,176 @@ func TestSchemaExtensionVerification(t *testing.T) {
 				},
 			},
 		},
+		{
+			doc:    `{"emails":["baz@ory.sh"]}`,
+			schema: "file://./stub/extension/verify/schema.json",
+			policy: config.VerifiableAddressMergeKeep,
+			expect: []VerifiableAddress{
+				{
+					Value:      "baz@ory.sh",
+					Verified:   false,
+					Status:     VerifiableAddressStatusPending,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+				{
+					Value:      "bar@ory.sh",
+					Verified:   true,
+					Status:     VerifiableAddressStatusCompleted,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+			existing: []VerifiableAddress{
+				{
+					Value:      "bar@ory.sh",
+					Verified:   true,
+					Status:     VerifiableAddressStatusCompleted,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+		},
+		{
+			doc:    `{"emails":["baz@ory.sh"]}`,
+			schema: "file://./stub/extension/verify/schema.json",
+			policy: config.VerifiableAddressMergeMarkStale,
+			expect: []VerifiableAddress{
+				{
+					Value:      "baz@ory.sh",
+					Verified:   false,
+					Status:     VerifiableAddressStatusPending,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+				{
+					Value:      "bar@ory.sh",
+					Verified:   true,
+					Status:     VerifiableAddressStatusStale,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+				{
+					Value:      "foo@ory.sh",
+					Verified:   false,
+					Status:     VerifiableAddressStatusStale,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+			existing: []VerifiableAddress{
+				{
+					Value:      "bar@ory.sh",
+					Verified:   true,
+					Status:     VerifiableAddressStatusCompleted,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+				{
+					Value:      "foo@ory.sh",
+					Verified:   false,
+					Status:     VerifiableAddressStatusPending,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+		},
+		{
+			doc:    `{"emails":["bar@ory.sh","foo@ory.sh"]}`,
+			schema: "file://./stub/extension/verify/schema.json",
+			policy: config.VerifiableAddressMergeMarkStale,
+			expect: []VerifiableAddress{
+				{
+					Value:      "bar@ory.sh",
+					Verified:   true,
+					Status:     VerifiableAddressStatusCompleted,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+				{
+					Value:      "foo@ory.sh",
+					Verified:   false,
+					Status:     VerifiableAddressStatusPending,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+			existing: []VerifiableAddress{
+				{
+					Value:      "bar@ory.sh",
+					Verified:   true,
+					Status:     VerifiableAddressStatusStale,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+				{
+					Value:      "foo@ory.sh",
+					Verified:   false,
+					Status:     VerifiableAddressStatusStale,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+		},
+		{
+			doc:    `{"contact":{"emails":["b@ory.sh","a@ory.sh"]},"backup":{"email":"a@ory.sh"}}`,
+			schema: "file://./stub/extension/verify/multi.schema.json",
+			expect: []VerifiableAddress{
+				{
+					Value:      "a@ory.sh",
+					Verified:   true,
+					Status:     VerifiableAddressStatusCompleted,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+				{
+					Value:      "b@ory.sh",
+					Verified:   false,
+					Status:     VerifiableAddressStatusPending,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+			existing: []VerifiableAddress{
+				{
+					Value:      "a@ory.sh",
+					Verified:   true,
+					Status:     VerifiableAddressStatusCompleted,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+		},
+		{
+			doc:    `{"username":"foo@ory.sh","phone":"+12065550100"}`,
+			schema: "file://./stub/extension/verify/schema.json",
+			expect: []VerifiableAddress{
+				{
+					Value:      "+12065550100",
+					Verified:   false,
+					Status:     VerifiableAddressStatusPending,
+					Via:        VerifiableAddressTypeSMS,
+					IdentityID: iid,
+				},
+				{
+					Value:      "foo@ory.sh",
+					Verified:   false,
+					Status:     VerifiableAddressStatusPending,
+					Via:        VerifiableAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+		},
+		{
+			doc:       `{"phone":"2065550100"}`,
+			schema:    "file://./stub/extension/verify/schema.json",
+			expectErr: errors.New("I[#/phone] S[#/properties/phone/format] \"2065550100\" is not a valid phone number in E.164 format, such as +12065550100"),
+		},
+		{
+			doc:       `{"contact":{"emails":["a@ory.sh","not-an-email"]}}`,
+			schema:    "file://./stub/extension/verify/multi.schema.json",
+			expectErr: errors.New("I[#/contact/emails] S[#/properties/contact/properties/emails/format] \"not-an-email\" is not valid \"email\""),
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			id := &Identity{ID: iid, VerifiableAddresses: tc.existing}
@@ -174,30 +344,17 @@ func TestSchemaExtensionVerification(t *testing.T) {
 			require.NoError(t, err)
 
 			const expiresAt = time.Minute
-			e := NewSchemaExtensionVerification(id, time.Minute)
+			e := NewSchemaExtensionVerification(id, time.Minute, tc.policy)
 			runner.AddRunner(e).Register(c)
 
-			err = c.MustCompile(tc.schema).Validate(bytes.NewBufferString(tc.doc))
+			err = runner.Validate(c.MustCompile(tc.schema), []byte(tc.doc))
 			if tc.expectErr != nil {
 				require.EqualError(t, err, tc.expectErr.Error())
 				return
 			}
+			require.NoError(t, err)
 
-			require.NoError(t, e.Finish())
-
-			addresses := id.VerifiableAddresses
-			require.Len(t, addresses, len(tc.expect))
-
-			for _, actual := range addresses {
-				var found bool
-				for _, expect := range tc.expect {
-					if reflect.DeepEqual(actual, expect) {
-						found = true
-						break
-					}
-				}
-				assert.True(t, found, "%+v not in %+v", actual, tc.expect)
-			}
+			assert.Equal(t, tc.expect, id.VerifiableAddresses)
 		})
 	}
 }
@@ -3,15 +3,26 @@ package identity
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/ory/kratos/driver/config"
 
+	"github.com/gofrs/uuid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 
+	"github.com/ory/go-convenience/stringslice"
+	"github.com/ory/herodot"
 	"github.com/ory/x/jsonx"
 	"github.com/ory/x/urlx"
 
+	"github.com/ory/kratos/approval"
+	"github.com/ory/kratos/cipher"
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/idempotency"
+	"github.com/ory/kratos/ratelimit"
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/x"
 )
 
@@ -22,8 +33,18 @@ type (
 		PoolProvider
 		PrivilegedPoolProvider
 		ManagementProvider
+		idempotency.MiddlewareProvider
+		approval.MiddlewareProvider
 		x.WriterProvider
+		x.LoggingProvider
 		config.Provider
+		schema.IdentityTraitsProvider
+		cipher.IdentityKeysProvider
+		courier.PreferencesPersistenceProvider
+		NotePersistenceProvider
+		RelationshipPersistenceProvider
+		ScheduledStateChangePersistenceProvider
+		ratelimit.Provider
 	}
 	HandlerProvider interface {
 		IdentityHandler() *Handler
@@ -40,10 +61,34 @@ func NewHandler(r handlerDependencies) *Handler {
 func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 	admin.GET(RouteBase, h.list)
 	admin.GET(RouteBase+"/:id", h.get)
-	admin.DELETE(RouteBase+"/:id", h.delete)
+	admin.HEAD(RouteBase+"/:id", h.get)
+	admin.DELETE(RouteBase+"/:id", h.r.ApprovalMiddleware().Wrap(h.delete))
 
-	admin.POST(RouteBase, h.create)
+	admin.POST(RouteBase, h.r.IdempotencyMiddleware().Wrap(h.create))
 	admin.PUT(RouteBase+"/:id", h.update)
+
+	admin.POST(RouteBase+"/:id"+RouteRecoveryAddresses, h.createRecoveryAddress)
+	admin.PATCH(RouteBase+"/:id"+RouteRecoveryAddresses+"/:address_id", h.updateRecoveryAddress)
+	admin.DELETE(RouteBase+"/:id"+RouteRecoveryAddresses+"/:address_id", h.deleteRecoveryAddress)
+	admin.POST(RouteBase+"/:id"+RouteForcePasswordReset, h.forcePasswordReset)
+	admin.PUT(RouteBase+"/:id"+RouteTemporaryPassword, h.setTemporaryPassword)
+	admin.PUT(RouteBase+"/:id"+RouteCredentials+"/password", h.setPasswordCredentials)
+	admin.PUT(RouteBase+"/:id"+RouteCredentials+"/oidc", h.setOIDCCredentials)
+	admin.POST(RouteBase+"/:id"+RouteCompromised, h.r.ApprovalMiddleware().Wrap(h.markCompromised))
+	admin.PUT(RouteBase+"/:id"+RouteVerifiableAddresses+"/:address_id/attestation", h.attestVerifiableAddress)
+	admin.GET(RouteBase+"/:id"+RouteCommunicationPreferences, h.getCommunicationPreferences)
+	admin.PUT(RouteBase+"/:id"+RouteCommunicationPreferences, h.updateCommunicationPreferences)
+	admin.GET(RouteBase+"/:id"+RouteNotes, h.listNotes)
+	admin.POST(RouteBase+"/:id"+RouteNotes, h.createNote)
+	admin.GET(RouteBase+"/:id"+RouteRelationships, h.listRelationships)
+	admin.POST(RouteBase+"/:id"+RouteRelationships, h.createRelationship)
+	admin.DELETE(RouteBase+"/:id"+RouteRelationships+"/:relationship_id", h.deleteRelationship)
+	admin.GET(RouteBase+"/:id"+RouteStateChanges, h.listScheduledStateChanges)
+	admin.POST(RouteBase+"/:id"+RouteStateChanges, h.scheduleStateChange)
+	admin.DELETE(RouteBase+"/:id"+RouteStateChanges+"/:change_id", h.cancelScheduledStateChange)
+
+	admin.POST(RouteKnownCredentials+"/batch", h.checkKnownCredentialsBatch)
+	admin.GET(RouteLookup+"/:via/:value", h.lookup)
 }
 
 // A single identity.
@@ -87,6 +132,34 @@ type listIdentityParameters struct {
 	// default: 0
 	// min: 0
 	Page int `json:"page"`
+
+	// Pagination Meta
+	//
+	// Wrap the list in an object with the keys `items` and `meta`, which contains the pagination meta data
+	// otherwise only sent in the `Link` and `X-Total-Count` headers. Sending the header
+	// `Accept: application/json; profile="pagination-meta"` has the same effect.
+	//
+	// required: false
+	// in: query
+	Meta bool `json:"meta"`
+
+	// Trait Fields
+	//
+	// Only return the given trait paths, for example `email` or `name.first`. Can be repeated or
+	// contain a comma-separated list of paths. If omitted, all traits are returned.
+	//
+	// required: false
+	// in: query
+	Fields []string `json:"fields"`
+	// Unmask Sensitive Traits
+	//
+	// If trait masking is enabled, traits marked as sensitive in the identity schema are masked. Set
+	// this to true to receive the original values. This requires the unmask scope and every such
+	// read is written to the audit log.
+	//
+	// required: false
+	// in: query
+	Unmask bool `json:"unmask"`
 }
 
 // swagger:route GET /identities admin listIdentities
@@ -104,8 +177,15 @@ type listIdentityParameters struct {
 //
 //     Responses:
 //       200: identityList
+//       403: genericError
 //       500: genericError
 func (h *Handler) list(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	fields, err := TraitFieldsFromRequest(r)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
 	page, itemsPerPage := x.ParsePagination(r)
 	is, err := h.r.IdentityPool().ListIdentities(r.Context(), page, itemsPerPage)
 	if err != nil {
@@ -113,6 +193,27 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request, _ httprouter.Para
 		return
 	}
 
+	if err := h.maskSensitiveTraits(r, is); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	resolve := make([]*Identity, len(is))
+	for k := range is {
+		resolve[k] = &is[k]
+	}
+	if err := ResolveProfiles(h.r.IdentityTraitsSchemas(r.Context()), resolve...); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	for k := range is {
+		if is[k].Traits, err = ProjectTraits(is[k].Traits, fields); err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+	}
+
 	total, err := h.r.IdentityPool().CountIdentities(r.Context())
 	if err != nil {
 		h.r.Writer().WriteError(w, r, err)
@@ -120,7 +221,7 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request, _ httprouter.Para
 	}
 
 	x.PaginationHeader(w, urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), RouteBase), total, page, itemsPerPage)
-	h.r.Writer().Write(w, r, is)
+	h.r.Writer().Write(w, r, x.PaginationBody(r, is, total, page, itemsPerPage))
 }
 
 // swagger:parameters getIdentity
@@ -131,12 +232,59 @@ type getIdentityParameters struct {
 	// required: true
 	// in: path
 	ID string `json:"id"`
+
+	// Trait Fields
+	//
+	// Only return the given trait paths, for example `email` or `name.first`. Can be repeated or
+	// contain a comma-separated list of paths. If omitted, all traits are returned.
+	//
+	// required: false
+	// in: query
+	Fields []string `json:"fields"`
+	// Unmask Sensitive Traits
+	//
+	// If trait masking is enabled, traits marked as sensitive in the identity schema are masked. Set
+	// this to true to receive the original values. This requires the unmask scope and every such
+	// read is written to the audit log.
+	//
+	// required: false
+	// in: query
+	Unmask bool `json:"unmask"`
+
+	// Include Credentials
+	//
+	// Include the identity's credentials of the given types in the response. Only `oidc` is supported,
+	// which returns the decrypted claims each linked provider returned when the identity last used it.
+	// If trait masking is enabled, this requires the unmask scope. Every such read is written to the
+	// audit log. If `admin_approvals.enabled` is set, this requires an approval token.
+	//
+	// required: false
+	// in: query
+	IncludeCredential []CredentialsType `json:"include_credential"`
+}
+
+// WithCredentials is an identity including the credentials requested using `include_credential`.
+//
+// swagger:model identityWithCredentials
+type WithCredentials struct {
+	*Identity
+
+	// Credentials contains the requested credentials.
+	Credentials map[CredentialsType]Credentials `json:"credentials,omitempty"`
 }
 
 // swagger:route GET /identities/{id} admin getIdentity
 //
 // Get an Identity
 //
+// Set `include_credential=oidc` to receive the claims which were received from the OpenID Connect
+// providers the identity linked. Those can be used to audit the source of traits set by the
+// Jsonnet mappers without calling the providers again.
+//
+// Responses contain an `ETag` and a `Last-Modified` header. Send them back using `If-None-Match` or
+// `If-Modified-Since` to receive a `304 Not Modified` response without a body if the identity did not change.
+// Use a `HEAD` request to check whether an identity exists without transferring it.
+//
 // Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
 //
 //     Consumes:
@@ -149,17 +297,205 @@ type getIdentityParameters struct {
 //
 //     Responses:
 //       200: identityResponse
+//       304: emptyResponse
 //       400: genericError
+//       403: genericError
 //       404: genericError
 //       500: genericError
 func (h *Handler) get(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// The router does not allow a static path next to the `:id` parameter.
+	if "/"+ps.ByName("id") == RouteByCredential {
+		h.findByCredential(w, r, ps)
+		return
+	}
+
+	fields, err := TraitFieldsFromRequest(r)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	included, err := h.includedCredentialsFromRequest(r)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if len(included) > 0 {
+		if err := h.r.ApprovalMiddleware().Verify(r); err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+	}
+
 	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
 	if err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
 	}
 
-	h.r.Writer().Write(w, r, i)
+	if i, err = h.present(r, i, fields); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	var body interface{} = i
+	lastModified := i.LastModified()
+	if len(included) > 0 {
+		credentials, err := h.declassifiedCredentials(r, i.ID, included)
+		if err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+
+		body = &WithCredentials{Identity: i, Credentials: credentials}
+		// Credentials do not change the identity's update time, so only the entity tag can be compared.
+		lastModified = time.Time{}
+	}
+
+	etag, err := x.WeakETag(body)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if x.NotModified(w, r, etag, lastModified) {
+		return
+	}
+
+	h.r.Writer().Write(w, r, body)
+}
+
+// Present masks and resolves the profile of an identity which other admin API handlers embed in their responses,
+// the same way the identity endpoints return it.
+func (h *Handler) Present(r *http.Request, i *Identity) (*Identity, error) {
+	return h.present(r, i, nil)
+}
+
+// present masks, resolves the profile of, and projects the traits of an identity which is about to be
+// returned by the admin API.
+func (h *Handler) present(r *http.Request, i *Identity, fields []string) (*Identity, error) {
+	is := []Identity{*i}
+	if err := h.maskSensitiveTraits(r, is); err != nil {
+		return nil, err
+	}
+	i = &is[0]
+
+	if err := ResolveProfiles(h.r.IdentityTraitsSchemas(r.Context()), i); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if i.Traits, err = ProjectTraits(i.Traits, fields); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// includedCredentialsFromRequest returns the credential types requested using `include_credential`.
+func (h *Handler) includedCredentialsFromRequest(r *http.Request) ([]CredentialsType, error) {
+	var included []CredentialsType
+	for _, v := range r.URL.Query()["include_credential"] {
+		if CredentialsType(v) != CredentialsTypeOIDC {
+			return nil, errors.WithStack(herodot.ErrBadRequest.
+				WithReasonf(`Including credentials of type "%s" is not supported. Only "%s" credentials can be included.`, v, CredentialsTypeOIDC))
+		}
+		included = append(included, CredentialsType(v))
+	}
+
+	if len(included) > 0 {
+		c := h.r.Config(r.Context())
+		if c.AdminTraitMaskingEnabled() && !h.grantsUnmaskScope(r) {
+			return nil, errors.WithStack(ErrUnmaskForbidden)
+		}
+	}
+
+	return included, nil
+}
+
+// declassifiedCredentials returns the identity's credentials of the given types with their secrets decrypted.
+func (h *Handler) declassifiedCredentials(r *http.Request, id uuid.UUID, included []CredentialsType) (map[CredentialsType]Credentials, error) {
+	i, err := h.r.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make(map[CredentialsType]Credentials)
+	for _, t := range included {
+		c, ok := i.GetCredentials(t)
+		if !ok {
+			continue
+		}
+
+		declassified, err := DeclassifyCredentialsOIDC(r.Context(), h.r.IdentityKeys().ForIdentity(id), *c)
+		if err != nil {
+			return nil, err
+		}
+		credentials[t] = *declassified
+	}
+
+	h.r.Audit().
+		WithRequest(r).
+		WithField("identity_id", id).
+		WithField("credentials", included).
+		Info("Declassified identity credentials were read using the admin API.")
+
+	return credentials, nil
+}
+
+// grantsUnmaskScope returns true if the scope header of the request grants the unmask scope.
+func (h *Handler) grantsUnmaskScope(r *http.Request) bool {
+	c := h.r.Config(r.Context())
+	scopes := strings.FieldsFunc(r.Header.Get(c.AdminTraitMaskingScopeHeader()), func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	return stringslice.Has(scopes, c.AdminTraitMaskingUnmaskScope())
+}
+
+// maskSensitiveTraits masks the sensitive traits of the identities if trait masking is enabled. Requests
+// with `unmask=true` receive the original traits if the scope header grants the unmask scope.
+func (h *Handler) maskSensitiveTraits(r *http.Request, is []Identity) error {
+	c := h.r.Config(r.Context())
+	if !c.AdminTraitMaskingEnabled() {
+		return nil
+	}
+
+	if UnmaskRequested(r) {
+		if !h.grantsUnmaskScope(r) {
+			return errors.WithStack(ErrUnmaskForbidden)
+		}
+
+		ids := make([]string, len(is))
+		for k := range is {
+			ids[k] = is[k].ID.String()
+		}
+		h.r.Audit().
+			WithRequest(r).
+			WithField("identity_ids", ids).
+			Info("Unmasked identity traits were read using the admin API.")
+		return nil
+	}
+
+	paths := make(map[string][]string)
+	for k := range is {
+		sensitive, ok := paths[is[k].SchemaID]
+		if !ok {
+			s, err := h.r.IdentityTraitsSchemas(r.Context()).GetByID(is[k].SchemaID)
+			if err != nil {
+				return err
+			}
+			if sensitive, err = SensitiveTraitPaths(s.URL.String()); err != nil {
+				return err
+			}
+			paths[is[k].SchemaID] = sensitive
+		}
+
+		if err := MaskTraits(&is[k], sensitive); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // swagger:parameters createIdentity
@@ -167,9 +503,37 @@ func (h *Handler) get(w http.ResponseWriter, r *http.Request, ps httprouter.Para
 type createIdentityParameters struct {
 	// in: body
 	Body CreateIdentity
+
+	// Idempotency Key
+	//
+	// If set, retrying the request with the same key returns the response of the first successful request
+	// instead of executing it again. Keys expire after `idempotency.ttl`.
+	//
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
 }
 
 type CreateIdentity struct {
+	// ID is the identity's ID. If omitted, a new ID is generated. Set it to keep the IDs of identities
+	// imported from other systems. Creating an identity with an ID that is already taken fails with
+	// status code 409.
+	//
+	// format: uuid
+	// in: body
+	ID uuid.UUID `json:"id"`
+
+	// CreatedAt sets when the identity was created. Use it to keep the original sign up date of imported
+	// identities. Defaults to the current time and must not be in the future.
+	//
+	// in: body
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// UpdatedAt sets when the identity was last updated. Defaults to the current time, must not be in the
+	// future, and must not be before `created_at`.
+	//
+	// in: body
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+
 	// SchemaID is the ID of the JSON Schema to be used for validating the identity's traits.
 	//
 	// required: true
@@ -185,12 +549,29 @@ type CreateIdentity struct {
 	Traits json.RawMessage `json:"traits"`
 }
 
+func (cr *CreateIdentity) validateTimestamps() error {
+	now := time.Now()
+	if cr.CreatedAt != nil && cr.CreatedAt.After(now) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("Field created_at must not be in the future."))
+	}
+	if cr.UpdatedAt != nil && cr.UpdatedAt.After(now) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("Field updated_at must not be in the future."))
+	}
+	if cr.CreatedAt != nil && cr.UpdatedAt != nil && cr.UpdatedAt.Before(*cr.CreatedAt) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("Field updated_at must not be before created_at."))
+	}
+	return nil
+}
+
 // swagger:route POST /identities admin createIdentity
 //
 // Create an Identity
 //
 // This endpoint creates an identity. It is NOT possible to set an identity's credentials (password, ...)
-// using this method! A way to achieve that will be introduced in the future.
+// using this method! Use `PUT /identities/{id}/credentials/password` and `PUT /identities/{id}/credentials/oidc`
+// instead.
+//
+// The identity's ID can be set to preserve IDs when importing identities from other systems.
 //
 // Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
 //
@@ -214,7 +595,18 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request, _ httprouter.Pa
 		return
 	}
 
-	i := &Identity{SchemaID: cr.SchemaID, Traits: []byte(cr.Traits)}
+	if err := cr.validateTimestamps(); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	i := &Identity{ID: cr.ID, SchemaID: cr.SchemaID, Traits: []byte(cr.Traits)}
+	if cr.CreatedAt != nil {
+		i.CreatedAt = cr.CreatedAt.UTC()
+	}
+	if cr.UpdatedAt != nil {
+		i.UpdatedAt = cr.UpdatedAt.UTC()
+	}
 	if err := h.r.IdentityManager().Create(r.Context(), i); err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
@@ -253,6 +645,10 @@ type UpdateIdentity struct {
 	//
 	// required: true
 	Traits json.RawMessage `json:"traits"`
+
+	// State sets the identity's state. If empty, the state is not changed. Inactive identities can
+	// not sign in and their sessions can not be used.
+	State State `json:"state"`
 }
 
 // swagger:route PUT /identities/{id} admin updateIdentity
@@ -260,7 +656,8 @@ type UpdateIdentity struct {
 // Update an Identity
 //
 // This endpoint updates an identity. It is NOT possible to set an identity's credentials (password, ...)
-// using this method! A way to achieve that will be introduced in the future.
+// using this method! Use `PUT /identities/{id}/credentials/password` and `PUT /identities/{id}/credentials/oidc`
+// instead.
 //
 // The full identity payload (except credentials) is expected. This endpoint does not support patching.
 //
@@ -281,8 +678,8 @@ type UpdateIdentity struct {
 //       500: genericError
 func (h *Handler) update(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	var ur UpdateIdentity
-	if err := errors.WithStack(jsonx.NewStrictDecoder(r.Body).Decode(&ur)); err != nil {
-		h.r.Writer().WriteError(w, r, err)
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&ur); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
 		return
 	}
 
@@ -297,6 +694,14 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request, ps httprouter.P
 		identity.SchemaID = ur.SchemaID
 	}
 
+	if ur.State != "" {
+		if err := ur.State.Validate(); err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+		identity.SetState(ur.State)
+	}
+
 	identity.Traits = []byte(ur.Traits)
 	if err := h.r.IdentityManager().Update(
 		r.Context(),
@@ -318,6 +723,23 @@ type deleteIdentityParameters struct {
 	// required: true
 	// in: path
 	ID string `json:"id"`
+
+	// Return a Deletion Report
+	//
+	// If true, the endpoint responds with 200 and a report of what was removed instead of 204.
+	//
+	// required: false
+	// in: query
+	Report bool `json:"report"`
+}
+
+// The report of an identity deletion.
+//
+// swagger:response identityDeletionReport
+// nolint:deadcode,unused
+type identityDeletionReportResponse struct {
+	// in: body
+	Body DeletionReport
 }
 
 // swagger:route DELETE /identities/{id} admin deleteIdentity
@@ -328,6 +750,13 @@ type deleteIdentityParameters struct {
 // This endpoint returns 204 when the identity was deleted or when the identity was not found, in which case it is
 // assumed that is has been deleted already.
 //
+// The identity's credentials, addresses, sessions, verification and recovery tokens, and continuity containers are
+// deleted with it. What happens to messages sent to the identity is configured at `identity.deletion`. Set
+// `report=true` to receive a report of what was removed, which contains no personal data and can be kept for
+// compliance records.
+//
+// If `admin_approvals.enabled` is set, this endpoint requires an approval token in the `X-Kratos-Approval` header.
+//
 // Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
 //
 //     Produces:
@@ -336,14 +765,22 @@ type deleteIdentityParameters struct {
 //     Schemes: http, https
 //
 //     Responses:
+//       200: identityDeletionReport
 //       204: emptyResponse
+//       403: genericError
 //		 404: genericError
 //       500: genericError
 func (h *Handler) delete(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	if err := h.r.IdentityPool().(PrivilegedPool).DeleteIdentity(r.Context(), x.ParseUUID(ps.ByName("id"))); err != nil {
+	report, err := h.r.IdentityManager().Delete(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
 	}
 
+	if r.URL.Query().Get("report") == "true" {
+		h.r.Writer().Write(w, r, report)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,154 @@
+package identity
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+)
+
+const RouteByCredential = "/by-credential"
+
+type (
+	// CredentialsMetadata describes a credential of an identity without its secrets.
+	//
+	// swagger:model identityCredentialsMetadata
+	CredentialsMetadata struct {
+		// Type discriminates between different types of credentials.
+		//
+		// required: true
+		Type CredentialsType `json:"type"`
+
+		// Identifiers represents a list of unique identifiers this credential type matches.
+		//
+		// required: true
+		Identifiers []string `json:"identifiers"`
+	}
+
+	// WithCredentialsMetadata is an identity including the metadata of all its credentials.
+	//
+	// swagger:model identityWithCredentialsMetadata
+	WithCredentialsMetadata struct {
+		*Identity
+
+		// Credentials contains the metadata of the identity's credentials by their type.
+		//
+		// required: true
+		Credentials map[CredentialsType]CredentialsMetadata `json:"credentials"`
+	}
+)
+
+// An identity including the metadata of its credentials.
+//
+// swagger:response identityWithCredentialsMetadata
+// nolint:deadcode,unused
+type identityWithCredentialsMetadataResponse struct {
+	// in: body
+	Body *WithCredentialsMetadata
+}
+
+// swagger:parameters findIdentityByCredential
+// nolint:deadcode,unused
+type findByCredentialParameters struct {
+	// Credentials Type
+	//
+	// The type of the credentials the identifier belongs to, for example `password` or `oidc`.
+	//
+	// required: true
+	// in: query
+	Type CredentialsType `json:"type"`
+
+	// Credentials Identifier
+	//
+	// The identifier to look up, for example a username. Identifiers of `password` credentials are matched
+	// case-insensitively.
+	//
+	// required: true
+	// in: query
+	Identifier string `json:"identifier"`
+
+	// Trait Fields
+	//
+	// Only return the given trait paths, for example `email` or `name.first`. Can be repeated or
+	// contain a comma-separated list of paths. If omitted, all traits are returned.
+	//
+	// required: false
+	// in: query
+	Fields []string `json:"fields"`
+
+	// Unmask Sensitive Traits
+	//
+	// If trait masking is enabled, traits marked as sensitive in the identity schema are masked. Set
+	// this to true to receive the original values. This requires the unmask scope and every such
+	// read is written to the audit log.
+	//
+	// required: false
+	// in: query
+	Unmask bool `json:"unmask"`
+}
+
+// swagger:route GET /identities/by-credential admin findIdentityByCredential
+//
+// Find an Identity by a Credentials Identifier
+//
+// This endpoint returns the identity which uses the given identifier to sign in, for example to go from
+// a username to the identity. The response includes the type and identifiers of all of the identity's
+// credentials, but none of their secrets.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityWithCredentialsMetadata
+//       400: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) findByCredential(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ct, identifier := CredentialsType(r.URL.Query().Get("type")), r.URL.Query().Get("identifier")
+	if len(ct) == 0 || len(identifier) == 0 {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The query parameters type and identifier are required.")))
+		return
+	}
+
+	fields, err := TraitFieldsFromRequest(r)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	found, _, err := h.r.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), ct, identifier)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	i, err := h.r.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), found.ID)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	credentials := make(map[CredentialsType]CredentialsMetadata, len(i.Credentials))
+	for t, c := range i.Credentials {
+		identifiers := c.Identifiers
+		if identifiers == nil {
+			identifiers = []string{}
+		}
+		credentials[t] = CredentialsMetadata{Type: t, Identifiers: identifiers}
+	}
+	i.Credentials = nil
+
+	if i, err = h.present(r, i, fields); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, &WithCredentialsMetadata{Identity: i, Credentials: credentials})
+}
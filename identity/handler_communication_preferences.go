@@ -0,0 +1,149 @@
+package identity
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/x"
+)
+
+const RouteCommunicationPreferences = "/communication-preferences"
+
+// The communication preferences of an identity.
+//
+// swagger:response identityCommunicationPreferences
+// nolint:deadcode,unused
+type communicationPreferencesResponse struct {
+	// in: body
+	Body courier.Preferences
+}
+
+// swagger:parameters getIdentityCommunicationPreferences
+// nolint:deadcode,unused
+type getCommunicationPreferencesParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+}
+
+// swagger:route GET /identities/{id}/communication-preferences admin getIdentityCommunicationPreferences
+//
+// Get the Communication Preferences of an Identity
+//
+// This endpoint returns how the identity prefers to receive messages which are not required to complete a
+// self-service flow, such as inactivity warnings. Identities which never set preferences receive such
+// messages by email at any time of day.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityCommunicationPreferences
+//       404: genericError
+//       500: genericError
+func (h *Handler) getCommunicationPreferences(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	p, err := h.r.CommunicationPreferencesPersister().GetCommunicationPreferences(r.Context(), i.ID)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		p = &courier.Preferences{IdentityID: i.ID}
+	} else if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, p)
+}
+
+// swagger:parameters updateIdentityCommunicationPreferences
+// nolint:deadcode,unused
+type updateCommunicationPreferencesParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body UpdateCommunicationPreferences
+}
+
+type UpdateCommunicationPreferences struct {
+	// Channel is the channel the identity prefers to receive messages on, either `email` or `sms`.
+	Channel courier.Channel `json:"channel"`
+
+	// Locale is the BCP 47 language tag of the language the identity prefers, for example `en-US`.
+	Locale string `json:"locale"`
+
+	// QuietHours is the time of day during which messages are held back. Omit it to receive messages
+	// at any time of day.
+	QuietHours *courier.QuietHours `json:"quiet_hours"`
+}
+
+// swagger:route PUT /identities/{id}/communication-preferences admin updateIdentityCommunicationPreferences
+//
+// Update the Communication Preferences of an Identity
+//
+// This endpoint replaces how the identity prefers to receive messages which are not required to complete a
+// self-service flow. Such messages are held back during the quiet hours and are not sent by email at all if
+// the identity prefers another channel. Verification, recovery, and other flow messages are always sent
+// right away.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityCommunicationPreferences
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) updateCommunicationPreferences(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body UpdateCommunicationPreferences
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	p := &courier.Preferences{
+		IdentityID: i.ID,
+		Channel:    body.Channel,
+		Locale:     body.Locale,
+		QuietHours: body.QuietHours,
+	}
+	if err := p.Validate(); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := h.r.CommunicationPreferencesPersister().UpsertCommunicationPreferences(r.Context(), p); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, p)
+}
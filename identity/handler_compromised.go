@@ -0,0 +1,91 @@
+package identity
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/jsonx"
+
+	"github.com/ory/kratos/x"
+)
+
+const RouteCompromised = "/compromised"
+
+// The report of marking an identity as compromised.
+//
+// swagger:response identityCompromiseReport
+// nolint:deadcode,unused
+type compromiseReportResponse struct {
+	// in: body
+	Body CompromiseReport
+}
+
+// swagger:parameters markIdentityCompromised
+// nolint:deadcode,unused
+type markCompromisedParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body MarkCompromised
+}
+
+type MarkCompromised struct {
+	// UnlinkOIDC unlinks all OpenID Connect providers from the identity, for example because the attacker
+	// linked their own account at a provider.
+	UnlinkOIDC bool `json:"unlink_oidc"`
+
+	// Notify sends an email to the identity's enabled email recovery addresses telling it that its account
+	// was locked and how to recover it.
+	Notify bool `json:"notify"`
+}
+
+// swagger:route POST /identities/{id}/compromised admin markIdentityCompromised
+//
+// Mark an Identity as Compromised
+//
+// This endpoint responds to an account takeover in one call. It deletes all of the identity's sessions,
+// which also forgets every device it signed in with, and its verification and recovery tokens. It revokes
+// the identity's API keys and its password, so that the identity has to recover its account to set a new
+// password. Optionally, all OpenID Connect providers are unlinked and the identity is notified by email.
+//
+// Like revoking all sessions of an identity, this request requires an approval token if `admin_approvals.enabled`
+// is set.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityCompromiseReport
+//       400: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) markCompromised(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body MarkCompromised
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	report, err := h.r.IdentityManager().MarkCompromised(r.Context(), x.ParseUUID(ps.ByName("id")), body.UnlinkOIDC, body.Notify)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, report)
+}
@@ -0,0 +1,149 @@
+package identity
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+
+	"github.com/ory/kratos/x"
+)
+
+const RouteCredentials = "/credentials"
+
+// swagger:parameters setIdentityPasswordCredentials
+// nolint:deadcode,unused
+type setPasswordCredentialsParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body SetPasswordCredentials
+}
+
+type SetPasswordCredentials struct {
+	// Password is the new password. It is hashed using the configured hasher and not checked against the
+	// password policy.
+	Password string `json:"password,omitempty"`
+
+	// HashedPassword is a bcrypt or argon2id hash in PHC string format, for example one exported from
+	// another system. It is stored as is and replaced with a hash of the configured hasher once the
+	// identity changes its password.
+	HashedPassword string `json:"hashed_password,omitempty"`
+}
+
+// swagger:route PUT /identities/{id}/credentials/password admin setIdentityPasswordCredentials
+//
+// Set an Identity's Password
+//
+// This endpoint sets or replaces the identity's password. Either `password` or `hashed_password` must be
+// set. Importing password hashes allows migrating identities from other systems without resetting their
+// passwords. The identifiers of the password are taken from the identity's traits as defined by the
+// identity schema.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) setPasswordCredentials(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body SetPasswordCredentials
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	id := x.ParseUUID(ps.ByName("id"))
+	var err error
+	switch {
+	case len(body.Password) > 0 && len(body.HashedPassword) > 0:
+		err = errors.WithStack(herodot.ErrBadRequest.WithReason("Only one of password and hashed_password can be set."))
+	case len(body.HashedPassword) > 0:
+		err = h.r.IdentityManager().ImportPasswordHash(r.Context(), id, body.HashedPassword)
+	default:
+		err = h.r.IdentityManager().SetPassword(r.Context(), id, body.Password)
+	}
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// swagger:parameters setIdentityOIDCCredentials
+// nolint:deadcode,unused
+type setOIDCCredentialsParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body SetOIDCCredentials
+}
+
+type SetOIDCCredentials struct {
+	// Providers are the OpenID Connect providers the identity is linked to. They replace the current links.
+	//
+	// required: true
+	Providers []OIDCProvider `json:"providers"`
+}
+
+// swagger:route PUT /identities/{id}/credentials/oidc admin setIdentityOIDCCredentials
+//
+// Set an Identity's OpenID Connect Links
+//
+// This endpoint replaces the OpenID Connect providers the identity is linked to, for example to import
+// identities which signed in using social sign in at another system. The identity can then sign in using
+// the linked providers. Links which are kept keep the claims received when the identity last signed in.
+// An empty list of providers removes all links.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) setOIDCCredentials(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body SetOIDCCredentials
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	if err := h.r.IdentityManager().SetOIDCProviders(r.Context(), x.ParseUUID(ps.ByName("id")), body.Providers); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,179 @@
+package identity
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/ratelimit"
+)
+
+const (
+	RouteKnownCredentials = "/credentials/known"
+
+	// KnownCredentialsBatchMaxIdentifiers is how many identifiers can be checked in one request.
+	KnownCredentialsBatchMaxIdentifiers = 500
+)
+
+type (
+	// KnownCredentials is a credentials identifier together with the identity and the type of the credentials it
+	// belongs to.
+	KnownCredentials struct {
+		Identifier string
+		IdentityID uuid.UUID
+		Type       CredentialsType
+	}
+
+	KnownCredentialsBatch struct {
+		// Identifiers are the credentials identifiers to check, for example email addresses or usernames.
+		//
+		// required: true
+		Identifiers []string `json:"identifiers"`
+	}
+
+	// KnownCredentialsBatchResult tells which credentials the identifiers belong to.
+	//
+	// swagger:model knownCredentialsBatchResult
+	KnownCredentialsBatchResult struct {
+		// Results contains the result of every requested identifier.
+		//
+		// required: true
+		Results map[string]KnownCredentialsResult `json:"results"`
+	}
+
+	// KnownCredentialsResult tells which credentials an identifier belongs to.
+	KnownCredentialsResult struct {
+		// Known is true if the identifier belongs to credentials.
+		//
+		// required: true
+		Known bool `json:"known"`
+
+		// IdentityID is the ID of the identity the credentials belong to.
+		IdentityID *uuid.UUID `json:"identity_id,omitempty"`
+
+		// Credentials are the types of the credentials the identifier belongs to.
+		//
+		// required: true
+		Credentials []CredentialsType `json:"credentials"`
+	}
+)
+
+// The result of checking credentials identifiers.
+//
+// swagger:response knownCredentialsBatchResult
+// nolint:deadcode,unused
+type knownCredentialsBatchResponse struct {
+	// in: body
+	Body KnownCredentialsBatchResult
+}
+
+// swagger:parameters checkKnownCredentialsBatch
+// nolint:deadcode,unused
+type checkKnownCredentialsBatchParameters struct {
+	// in: body
+	Body KnownCredentialsBatch
+}
+
+// swagger:route POST /credentials/known/batch admin checkKnownCredentialsBatch
+//
+// Check Which Credentials Identifiers Are Known
+//
+// This endpoint checks up to 500 identifiers, for example email addresses, in one request and returns for each
+// of them whether it belongs to credentials, the credentials types, and the identity. Identifiers of password
+// credentials are matched case-insensitively.
+//
+// Requests can be rate limited per client IP address and per identifier and delayed to a minimum response time
+// using the `enumeration_protection` configuration.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: knownCredentialsBatchResult
+//       400: genericError
+//       429: genericError
+//       500: genericError
+func (h *Handler) checkKnownCredentialsBatch(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+	result, err := h.knownCredentialsBatch(r)
+
+	// The response is delayed to the minimum response time before it is written, regardless of its outcome.
+	h.r.EnumerationLimiter().Delay(r, start)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, result)
+}
+
+func (h *Handler) knownCredentialsBatch(r *http.Request) (*KnownCredentialsBatchResult, error) {
+	var body KnownCredentialsBatch
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason(err.Error()))
+	}
+
+	if len(body.Identifiers) == 0 {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("At least one identifier is required."))
+	} else if len(body.Identifiers) > KnownCredentialsBatchMaxIdentifiers {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("At most %d identifiers can be checked at once.", KnownCredentialsBatchMaxIdentifiers))
+	}
+
+	results := make(map[string]KnownCredentialsResult, len(body.Identifiers))
+	for _, identifier := range body.Identifiers {
+		results[identifier] = KnownCredentialsResult{Credentials: []CredentialsType{}}
+	}
+
+	allowed, err := h.r.EnumerationLimiter().Allow(r, body.Identifiers)
+	if err != nil {
+		return nil, err
+	} else if !allowed {
+		if h.r.Config(r.Context()).EnumerationProtectionLimitedResponse() == config.LimitedResponseDummy {
+			// The client can not tell a rate limited response from one where no identifier is known.
+			return &KnownCredentialsBatchResult{Results: results}, nil
+		}
+		return nil, errors.WithStack(ratelimit.ErrRateLimited.WithReason("Too many identifiers were checked, please try again later."))
+	}
+
+	known, err := h.r.PrivilegedIdentityPool().FindCredentialsByIdentifiers(r.Context(), body.Identifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range known {
+		result := results[k.Identifier]
+		if result.IdentityID == nil {
+			id := k.IdentityID
+			result.IdentityID = &id
+		} else if *result.IdentityID != k.IdentityID {
+			// Credentials of different types can share an identifier, only the first identity is reported.
+			continue
+		}
+		result.Known = true
+		result.Credentials = append(result.Credentials, k.Type)
+		results[k.Identifier] = result
+	}
+
+	for _, result := range results {
+		sort.Slice(result.Credentials, func(i, j int) bool {
+			return result.Credentials[i] < result.Credentials[j]
+		})
+	}
+
+	return &KnownCredentialsBatchResult{Results: results}, nil
+}
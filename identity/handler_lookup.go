@@ -0,0 +1,124 @@
+package identity
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/ratelimit"
+)
+
+const RouteLookup = "/identity-lookup"
+
+// swagger:parameters lookupIdentityByVerifiableAddress
+// nolint:deadcode,unused
+type lookupIdentityByVerifiableAddressParameters struct {
+	// Address Type
+	//
+	// The type of the verifiable address, for example `email`.
+	//
+	// required: true
+	// in: path
+	Via VerifiableAddressType `json:"via"`
+
+	// Address Value
+	//
+	// The verifiable address, for example an email address.
+	//
+	// required: true
+	// in: path
+	Value string `json:"value"`
+
+	// Trait Fields
+	//
+	// Only return the given trait paths, for example `email` or `name.first`. Can be repeated or
+	// contain a comma-separated list of paths. If omitted, all traits are returned.
+	//
+	// required: false
+	// in: query
+	Fields []string `json:"fields"`
+}
+
+// swagger:route GET /identity-lookup/{via}/{value} admin lookupIdentityByVerifiableAddress
+//
+// Look up an Identity by a Verified Address
+//
+// This endpoint returns the identity a verified address of the given type belongs to, for example to go from
+// an email address to the identity. Addresses which are not verified, or which no longer appear in the
+// identity's traits, are treated as unknown. Lookups are rate limited as configured at `enumeration_protection`.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityResponse
+//       400: genericError
+//       404: genericError
+//       429: genericError
+//       500: genericError
+func (h *Handler) lookup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+	i, err := h.lookupIdentity(r, VerifiableAddressType(ps.ByName("via")), ps.ByName("value"))
+
+	// Like the known credentials check, this endpoint tells whether an address is known and is therefore
+	// protected against enumeration.
+	h.r.EnumerationLimiter().Delay(r, start)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, i)
+}
+
+func (h *Handler) lookupIdentity(r *http.Request, via VerifiableAddressType, value string) (*Identity, error) {
+	if len(via) == 0 || len(value) == 0 {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("The address type and value are required."))
+	}
+
+	fields, err := TraitFieldsFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	errUnknown := errors.WithStack(herodot.ErrNotFound.WithReason("No identity has this verified address."))
+	allowed, err := h.r.EnumerationLimiter().Allow(r, []string{value})
+	if err != nil {
+		return nil, err
+	} else if !allowed {
+		if h.r.Config(r.Context()).EnumerationProtectionLimitedResponse() == config.LimitedResponseDummy {
+			// The client can not tell a rate limited response from one where the address is unknown.
+			return nil, errUnknown
+		}
+		return nil, errors.WithStack(ratelimit.ErrRateLimited.WithReason("Too many addresses were looked up, please try again later."))
+	}
+
+	address, err := h.r.PrivilegedIdentityPool().FindVerifiableAddressByValue(r.Context(), via, value)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return nil, errUnknown
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Unverified addresses are not told apart from unknown ones.
+	if !address.Verified || address.Status == VerifiableAddressStatusStale {
+		return nil, errUnknown
+	}
+
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), address.IdentityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.present(r, i, fields)
+}
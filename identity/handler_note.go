@@ -0,0 +1,205 @@
+package identity
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/x"
+)
+
+const RouteNotes = "/notes"
+
+// A list of notes about an identity.
+//
+// swagger:response identityNotes
+// nolint:deadcode,unused
+type notesResponse struct {
+	// in: body
+	Body []Note
+}
+
+// A note about an identity.
+//
+// swagger:response identityNote
+// nolint:deadcode,unused
+type noteResponse struct {
+	// in: body
+	Body Note
+}
+
+// swagger:parameters listIdentityNotes
+// nolint:deadcode,unused
+type listNotesParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// Items per Page
+	//
+	// This is the number of items per page.
+	//
+	// required: false
+	// in: query
+	// default: 100
+	// min: 1
+	// max: 500
+	PerPage int `json:"per_page"`
+
+	// Pagination Page
+	//
+	// required: false
+	// in: query
+	// default: 0
+	// min: 0
+	Page int `json:"page"`
+
+	// Pagination Meta
+	//
+	// Wrap the list in an object with the keys `items` and `meta`, which contains the pagination meta data
+	// otherwise only sent in the `Link` and `X-Total-Count` headers. Sending the header
+	// `Accept: application/json; profile="pagination-meta"` has the same effect.
+	//
+	// required: false
+	// in: query
+	Meta bool `json:"meta"`
+}
+
+// swagger:route GET /identities/{id}/notes admin listIdentityNotes
+//
+// List the Notes about an Identity
+//
+// This endpoint returns the notes administrators recorded about an identity, most recent first. It
+// supports the same pagination as listing identities.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityNotes
+//       404: genericError
+//       500: genericError
+func (h *Handler) listNotes(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	page, itemsPerPage := x.ParsePagination(r)
+	notes, err := h.r.IdentityNotePersister().ListIdentityNotes(r.Context(), i.ID, page, itemsPerPage)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	for k := range notes {
+		text, err := h.r.IdentityKeys().ForIdentity(i.ID).Decrypt(r.Context(), notes[k].Text)
+		if err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+		notes[k].Text = string(text)
+	}
+
+	total, err := h.r.IdentityNotePersister().CountIdentityNotes(r.Context(), i.ID)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	x.PaginationHeader(w, urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), RouteBase, i.ID.String(), RouteNotes), total, page, itemsPerPage)
+	h.r.Writer().Write(w, r, x.PaginationBody(r, notes, total, page, itemsPerPage))
+}
+
+// swagger:parameters createIdentityNote
+// nolint:deadcode,unused
+type createNoteParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body CreateNote
+}
+
+type CreateNote struct {
+	// Author names whoever writes the note, for example the email address of a support agent.
+	//
+	// required: true
+	Author string `json:"author"`
+
+	// Text is the content of the note, for example "verified ownership via support ticket #123".
+	//
+	// required: true
+	Text string `json:"text"`
+}
+
+// swagger:route POST /identities/{id}/notes admin createIdentityNote
+//
+// Add a Note about an Identity
+//
+// This endpoint records a note about an identity, for example to document why support changed it. Notes
+// can not be changed once written and are deleted together with the identity.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: identityNote
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) createNote(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body CreateNote
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	n := &Note{IdentityID: i.ID, Author: body.Author, Text: body.Text}
+	if err := n.Validate(); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	// The text is stored encrypted using the identity's data key so that it can not be read once the
+	// identity is deleted.
+	n.Text, err = h.r.IdentityKeys().ForIdentity(i.ID).Encrypt(r.Context(), []byte(body.Text))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := h.r.IdentityNotePersister().CreateIdentityNote(r.Context(), n); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+	n.Text = body.Text
+
+	h.r.Writer().WriteCreated(w, r,
+		urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), RouteBase, i.ID.String(), RouteNotes).String(),
+		n,
+	)
+}
@@ -0,0 +1,133 @@
+package identity
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/jsonx"
+
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteForcePasswordReset = "/force-password-reset"
+	RouteTemporaryPassword  = "/temporary-password"
+)
+
+// swagger:parameters forceIdentityPasswordReset
+// nolint:deadcode,unused
+type forcePasswordResetParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body ForcePasswordReset
+}
+
+type ForcePasswordReset struct {
+	// Notify sends an email to the identity's enabled email recovery addresses asking it to sign in
+	// and set a new password.
+	Notify bool `json:"notify"`
+}
+
+// swagger:route POST /identities/{id}/force-password-reset admin forceIdentityPasswordReset
+//
+// Force a Password Reset
+//
+// This endpoint marks the identity's password as expired. The identity can still sign in with the expired
+// password, but the session it receives can only be used to set a new password using the settings flow.
+// Calling `/sessions/whoami` with that session returns 403 until a new password was set.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) forcePasswordReset(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body ForcePasswordReset
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	if err := h.r.IdentityManager().ExpirePassword(r.Context(), x.ParseUUID(ps.ByName("id")), body.Notify); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// swagger:parameters setIdentityTemporaryPassword
+// nolint:deadcode,unused
+type setTemporaryPasswordParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body SetTemporaryPassword
+}
+
+type SetTemporaryPassword struct {
+	// Password is the temporary password. It is not checked against the password policy.
+	//
+	// required: true
+	Password string `json:"password"`
+}
+
+// swagger:route PUT /identities/{id}/temporary-password admin setIdentityTemporaryPassword
+//
+// Set a Temporary Password
+//
+// This endpoint replaces the identity's password with a temporary password, for example when a helpdesk
+// resets the password on behalf of the user. The temporary password works for exactly one login, and the
+// session issued by that login can only be used to set a new password using the settings flow.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) setTemporaryPassword(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body SetTemporaryPassword
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	if err := h.r.IdentityManager().SetTemporaryPassword(r.Context(), x.ParseUUID(ps.ByName("id")), body.Password); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,262 @@
+package identity
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/jsonschema/v3"
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/x"
+)
+
+const RouteRecoveryAddresses = "/recovery-addresses"
+
+// swagger:parameters createIdentityRecoveryAddress
+// nolint:deadcode,unused
+type createRecoveryAddressParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body CreateRecoveryAddress
+}
+
+type CreateRecoveryAddress struct {
+	// Via is the type of the address. Only `email` is supported at the moment.
+	//
+	// required: true
+	Via RecoveryAddressType `json:"via"`
+
+	// Value is the address itself, for example the email address.
+	//
+	// required: true
+	Value string `json:"value"`
+}
+
+// swagger:route POST /identities/{id}/recovery-addresses admin createIdentityRecoveryAddress
+//
+// Add a Recovery Address
+//
+// This endpoint adds a recovery address to an identity. Unlike addresses derived from the identity's traits,
+// addresses added using this endpoint are kept when the traits change and can only be removed using the
+// delete endpoint.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: identityResponse
+//       400: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) createRecoveryAddress(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body CreateRecoveryAddress
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	if body.Via != RecoveryAddressTypeEmail {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Recovery address type %q is not supported.", body.Via)))
+		return
+	}
+
+	body.Value = strings.TrimSpace(body.Value)
+	if !jsonschema.Formats["email"](body.Value) {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%q is not a valid email address.", body.Value)))
+		return
+	}
+
+	i, err := h.r.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	for _, a := range i.RecoveryAddresses {
+		if a.Via == body.Via && a.Value == body.Value {
+			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrConflict.WithReason("The identity already has this recovery address.")))
+			return
+		}
+	}
+
+	i.RecoveryAddresses = append(i.RecoveryAddresses, RecoveryAddress{
+		Via:        body.Via,
+		Value:      body.Value,
+		Source:     RecoveryAddressSourceAdmin,
+		IdentityID: i.ID,
+	})
+	if err := h.r.IdentityManager().Update(r.Context(), i, ManagerAllowWriteProtectedTraits); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().WriteCreated(w, r,
+		urlx.AppendPaths(
+			h.r.Config(r.Context()).SelfAdminURL(),
+			"identities",
+			i.ID.String(),
+		).String(),
+		i,
+	)
+}
+
+// swagger:parameters updateIdentityRecoveryAddress
+// nolint:deadcode,unused
+type updateRecoveryAddressParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// AddressID is the recovery address' ID.
+	//
+	// required: true
+	// in: path
+	AddressID string `json:"address_id"`
+
+	// in: body
+	Body UpdateRecoveryAddress
+}
+
+type UpdateRecoveryAddress struct {
+	// Disabled controls whether the address can be used to recover the identity.
+	//
+	// required: true
+	Disabled bool `json:"disabled"`
+}
+
+// swagger:route PATCH /identities/{id}/recovery-addresses/{address_id} admin updateIdentityRecoveryAddress
+//
+// Enable or Disable a Recovery Address
+//
+// This endpoint sets whether a recovery address can be used to recover the identity. Use it to exclude
+// addresses derived from the identity's traits which should not receive recovery links.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) updateRecoveryAddress(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body UpdateRecoveryAddress
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	i, k, err := h.findRecoveryAddress(r, ps)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	i.RecoveryAddresses[k].Disabled = body.Disabled
+	if err := h.r.IdentityManager().Update(r.Context(), i, ManagerAllowWriteProtectedTraits); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, i)
+}
+
+// swagger:parameters deleteIdentityRecoveryAddress
+// nolint:deadcode,unused
+type deleteRecoveryAddressParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// AddressID is the recovery address' ID.
+	//
+	// required: true
+	// in: path
+	AddressID string `json:"address_id"`
+}
+
+// swagger:route DELETE /identities/{id}/recovery-addresses/{address_id} admin deleteIdentityRecoveryAddress
+//
+// Delete a Recovery Address
+//
+// This endpoint removes a recovery address which was added using the admin API. Addresses derived from
+// the identity's traits can not be deleted because they would be re-added on the next update. Disable
+// them instead or change the traits.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) deleteRecoveryAddress(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	i, k, err := h.findRecoveryAddress(r, ps)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if i.RecoveryAddresses[k].Source != RecoveryAddressSourceAdmin {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("Recovery addresses derived from the identity's traits can not be deleted. Disable the address or update the traits instead.")))
+		return
+	}
+
+	i.RecoveryAddresses = append(i.RecoveryAddresses[:k], i.RecoveryAddresses[k+1:]...)
+	if err := h.r.IdentityManager().Update(r.Context(), i, ManagerAllowWriteProtectedTraits); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) findRecoveryAddress(r *http.Request, ps httprouter.Params) (*Identity, int, error) {
+	i, err := h.r.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	id := x.ParseUUID(ps.ByName("address_id"))
+	for k := range i.RecoveryAddresses {
+		if i.RecoveryAddresses[k].ID == id {
+			return i, k, nil
+		}
+	}
+
+	return nil, 0, errors.WithStack(herodot.ErrNotFound.WithReason("The identity does not have a recovery address with this ID."))
+}
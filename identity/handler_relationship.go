@@ -0,0 +1,228 @@
+package identity
+
+import (
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/x"
+)
+
+const RouteRelationships = "/relationships"
+
+// A list of relationships of an identity.
+//
+// swagger:response identityRelationships
+// nolint:deadcode,unused
+type relationshipsResponse struct {
+	// in: body
+	Body []Relationship
+}
+
+// A relationship between two identities.
+//
+// swagger:response identityRelationship
+// nolint:deadcode,unused
+type relationshipResponse struct {
+	// in: body
+	Body Relationship
+}
+
+// swagger:parameters listIdentityRelationships
+// nolint:deadcode,unused
+type listRelationshipsParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+}
+
+// swagger:route GET /identities/{id}/relationships admin listIdentityRelationships
+//
+// List the Relationships of an Identity
+//
+// This endpoint returns the relationships an identity takes part in, regardless of whether it is the guardian
+// or primary account, or the child or delegate account.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityRelationships
+//       404: genericError
+//       500: genericError
+func (h *Handler) listRelationships(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	relationships, err := h.r.IdentityRelationshipPersister().ListIdentityRelationships(r.Context(), i.ID)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, relationships)
+}
+
+// swagger:parameters createIdentityRelationship
+// nolint:deadcode,unused
+type createRelationshipParameters struct {
+	// ID is the ID of the guardian or primary identity.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body CreateRelationship
+}
+
+type CreateRelationship struct {
+	// Type is the kind of the relationship, either `guardian` or `delegate`.
+	//
+	// required: true
+	Type RelationshipType `json:"type"`
+
+	// RelatedIdentityID is the ID of the child or delegate identity.
+	//
+	// required: true
+	RelatedIdentityID uuid.UUID `json:"related_identity_id"`
+}
+
+// swagger:route POST /identities/{id}/relationships admin createIdentityRelationship
+//
+// Create a Relationship between two Identities
+//
+// This endpoint links the identity, as guardian or primary account, to a child or delegate account. Two
+// identities can not be each other's guardian or delegate. Relationships are deleted together with either
+// identity.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: identityRelationship
+//       400: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) createRelationship(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body CreateRelationship
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	rel := &Relationship{Type: body.Type, IdentityID: i.ID, RelatedIdentityID: body.RelatedIdentityID}
+	if err := rel.Validate(); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if _, err := h.r.IdentityPool().GetIdentity(r.Context(), rel.RelatedIdentityID); errors.Is(err, sqlcon.ErrNoRows) {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The related identity %s does not exist.", rel.RelatedIdentityID)))
+		return
+	} else if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	existing, err := h.r.IdentityRelationshipPersister().ListIdentityRelationships(r.Context(), i.ID)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	for _, e := range existing {
+		if e.Type == rel.Type && e.IdentityID == rel.RelatedIdentityID && e.RelatedIdentityID == rel.IdentityID {
+			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrConflict.WithReasonf("Identity %s already is the %s of identity %s.", e.IdentityID, e.Type, e.RelatedIdentityID)))
+			return
+		}
+	}
+
+	if err := h.r.IdentityRelationshipPersister().CreateIdentityRelationship(r.Context(), rel); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().WriteCreated(w, r,
+		urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), RouteBase, i.ID.String(), RouteRelationships, rel.ID.String()).String(),
+		rel,
+	)
+}
+
+// swagger:parameters deleteIdentityRelationship
+// nolint:deadcode,unused
+type deleteRelationshipParameters struct {
+	// ID is the ID of either identity in the relationship.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// RelationshipID is the relationship's ID.
+	//
+	// required: true
+	// in: path
+	RelationshipID string `json:"relationship_id"`
+}
+
+// swagger:route DELETE /identities/{id}/relationships/{relationship_id} admin deleteIdentityRelationship
+//
+// Delete a Relationship between two Identities
+//
+// This endpoint removes a relationship. The identities themselves are not changed.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       404: genericError
+//       500: genericError
+func (h *Handler) deleteRelationship(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := x.ParseUUID(ps.ByName("id"))
+	rel, err := h.r.IdentityRelationshipPersister().GetIdentityRelationship(r.Context(), x.ParseUUID(ps.ByName("relationship_id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if rel.IdentityID != id && rel.RelatedIdentityID != id {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReasonf("Identity %s has no relationship with ID %s.", id, rel.ID)))
+		return
+	}
+
+	if err := h.r.IdentityRelationshipPersister().DeleteIdentityRelationship(r.Context(), rel.ID); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
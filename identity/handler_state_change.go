@@ -0,0 +1,236 @@
+package identity
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/x"
+)
+
+const RouteStateChanges = "/state-changes"
+
+// A list of scheduled state changes of an identity.
+//
+// swagger:response identityScheduledStateChanges
+// nolint:deadcode,unused
+type scheduledStateChangesResponse struct {
+	// in: body
+	Body []ScheduledStateChange
+}
+
+// A scheduled state change of an identity.
+//
+// swagger:response identityScheduledStateChange
+// nolint:deadcode,unused
+type scheduledStateChangeResponse struct {
+	// in: body
+	Body ScheduledStateChange
+}
+
+// swagger:parameters listIdentityScheduledStateChanges
+// nolint:deadcode,unused
+type listScheduledStateChangesParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// Items per Page
+	//
+	// This is the number of items per page.
+	//
+	// required: false
+	// in: query
+	// default: 100
+	// min: 1
+	// max: 500
+	PerPage int `json:"per_page"`
+
+	// Pagination Page
+	//
+	// required: false
+	// in: query
+	// default: 0
+	// min: 0
+	Page int `json:"page"`
+}
+
+// swagger:route GET /identities/{id}/state-changes admin listIdentityScheduledStateChanges
+//
+// List the Scheduled State Changes of an Identity
+//
+// This endpoint returns the pending and executed state changes scheduled for an identity, the next one
+// first. It supports the same pagination as listing identities.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityScheduledStateChanges
+//       404: genericError
+//       500: genericError
+func (h *Handler) listScheduledStateChanges(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	page, itemsPerPage := x.ParsePagination(r)
+	changes, err := h.r.ScheduledStateChangePersister().ListScheduledStateChanges(r.Context(), i.ID, page, itemsPerPage)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	total, err := h.r.ScheduledStateChangePersister().CountScheduledStateChanges(r.Context(), i.ID)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	x.PaginationHeader(w, urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), RouteBase, i.ID.String(), RouteStateChanges), total, page, itemsPerPage)
+	h.r.Writer().Write(w, r, changes)
+}
+
+// swagger:parameters scheduleIdentityStateChange
+// nolint:deadcode,unused
+type scheduleStateChangeParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body ScheduleStateChange
+}
+
+type ScheduleStateChange struct {
+	// State is the state the identity changes to, either `active` or `inactive`.
+	//
+	// required: true
+	State State `json:"state"`
+
+	// ExecuteAt is the time at which the state changes. It must be in the future.
+	//
+	// required: true
+	ExecuteAt time.Time `json:"execute_at"`
+}
+
+// swagger:route POST /identities/{id}/state-changes admin scheduleIdentityStateChange
+//
+// Schedule a State Change of an Identity
+//
+// This endpoint schedules a future state change of an identity, for example to deactivate it when a contract
+// ends or to reactivate it when a term starts. State changes are executed by the `identity-state-changes`
+// background job, so they only happen if the job is scheduled at `jobs.schedules`.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: identityScheduledStateChange
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) scheduleStateChange(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body ScheduleStateChange
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	c := &ScheduledStateChange{IdentityID: i.ID, State: body.State, ExecuteAt: body.ExecuteAt.UTC()}
+	if err := c.Validate(time.Now()); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := h.r.ScheduledStateChangePersister().CreateScheduledStateChange(r.Context(), c); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().WriteCreated(w, r,
+		urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), RouteBase, i.ID.String(), RouteStateChanges).String(),
+		c,
+	)
+}
+
+// swagger:parameters cancelIdentityScheduledStateChange
+// nolint:deadcode,unused
+type cancelScheduledStateChangeParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// ChangeID is the scheduled state change's ID.
+	//
+	// required: true
+	// in: path
+	ChangeID string `json:"change_id"`
+}
+
+// swagger:route DELETE /identities/{id}/state-changes/{change_id} admin cancelIdentityScheduledStateChange
+//
+// Cancel a Scheduled State Change of an Identity
+//
+// This endpoint cancels a pending state change. State changes which were already executed can not be
+// canceled.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) cancelScheduledStateChange(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	c, err := h.r.ScheduledStateChangePersister().GetScheduledStateChange(r.Context(), x.ParseUUID(ps.ByName("change_id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if c.IdentityID != x.ParseUUID(ps.ByName("id")) {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReasonf("Identity %s has no scheduled state change with ID %s.", ps.ByName("id"), c.ID)))
+		return
+	} else if !c.IsPending() {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrConflict.WithReason("The state change was already executed and can not be canceled.")))
+		return
+	}
+
+	if err := h.r.ScheduledStateChangePersister().DeleteScheduledStateChange(r.Context(), c.ID); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
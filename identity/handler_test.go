@@ -2,22 +2,35 @@ package identity_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/sqlcon"
 	"github.com/ory/x/urlx"
 
+	"github.com/ory/kratos/idempotency"
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/text"
 
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
+	"golang.org/x/crypto/bcrypt"
 
+	"github.com/ory/kratos/courier"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/x"
@@ -36,8 +49,10 @@ func TestHandler(t *testing.T) {
 	conf.MustSet(config.ViperKeyAdminBaseURL, ts.URL)
 	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/identity.schema.json")
 	testhelpers.SetIdentitySchemas(t, conf, map[string]string{
-		"customer": "file://./stub/handler/customer.schema.json",
-		"employee": "file://./stub/handler/employee.schema.json",
+		"customer":  "file://./stub/handler/customer.schema.json",
+		"employee":  "file://./stub/handler/employee.schema.json",
+		"sensitive": "file://./stub/handler/sensitive.schema.json",
+		"profile":   "file://./stub/handler/profile.schema.json",
 	})
 	conf.MustSet(config.ViperKeyPublicBaseURL, mockServerURL.String())
 
@@ -100,6 +115,10 @@ func TestHandler(t *testing.T) {
 		i.Traits = []byte(`{"bar":123}`)
 		res := send(t, "POST", "/identities", http.StatusBadRequest, &i)
 		assert.Contains(t, res.Get("error.reason").String(), "I[#/traits/bar] S[#/properties/traits/properties/bar/type] expected string, but got number")
+		assert.Equal(t, "/traits/bar", res.Get("error.details.validation_errors.0.pointer").String(), "%s", res.Raw)
+		assert.Equal(t, "type", res.Get("error.details.validation_errors.0.keyword").String(), "%s", res.Raw)
+		assert.Equal(t, "expected string, but got number", res.Get("error.details.validation_errors.0.message").String(), "%s", res.Raw)
+		assert.EqualValues(t, text.ErrorValidationGeneric, res.Get("error.details.validation_errors.0.id").Int(), "%s", res.Raw)
 	})
 
 	t.Run("case=should fail to create an entity with schema_url set", func(t *testing.T) {
@@ -116,11 +135,103 @@ func TestHandler(t *testing.T) {
 		assert.Empty(t, res.Get("credentials").String(), "%s", res.Raw)
 	})
 
-	t.Run("case=unable to set ID itself", func(t *testing.T) {
+	t.Run("case=should not create duplicate identities when an idempotency key is reused", func(t *testing.T) {
+		create := func(t *testing.T, key string, body string) (*http.Response, gjson.Result) {
+			req, err := http.NewRequest("POST", ts.URL+"/identities", bytes.NewBufferString(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(idempotency.HeaderKey, key)
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			raw, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			return res, gjson.ParseBytes(raw)
+		}
+
+		key := x.NewUUID().String()
+		first, created := create(t, key, `{"traits": {"bar":"idempotent"}}`)
+		assert.Equal(t, http.StatusCreated, first.StatusCode, "%s", created.Raw)
+		assert.Empty(t, first.Header.Get(idempotency.HeaderReplayed))
+
+		second, replayed := create(t, key, `{"traits": {"bar":"idempotent"}}`)
+		assert.Equal(t, http.StatusCreated, second.StatusCode, "%s", replayed.Raw)
+		assert.Equal(t, "true", second.Header.Get(idempotency.HeaderReplayed))
+		assert.Equal(t, first.Header.Get("Location"), second.Header.Get("Location"))
+		assert.Equal(t, created.Get("id").String(), replayed.Get("id").String())
+
+		res, body := create(t, key, `{"traits": {"bar":"different"}}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode, "%s", body.Raw)
+
+		t.Run("case=stores the response encrypted", func(t *testing.T) {
+			record, err := reg.IdempotencyPersister().GetIdempotencyRecord(context.Background(), "POST /identities", key)
+			require.NoError(t, err)
+			assert.NotContains(t, string(record.Body), "idempotent")
+			assert.NotContains(t, string(record.Body), created.Get("id").String())
+			assert.True(t, record.ExpiresAt.After(time.Now().Add(time.Hour)), "completed records are kept for the configured TTL")
+		})
+
+		t.Run("case=abandoned in-flight requests do not block the key", func(t *testing.T) {
+			key := x.NewUUID().String()
+			body := `{"traits": {"bar":"abandoned"}}`
+			hash := sha256.Sum256([]byte(body))
+			require.NoError(t, reg.IdempotencyPersister().CreateIdempotencyRecord(context.Background(), &idempotency.Record{
+				ID:          x.NewUUID(),
+				Key:         key,
+				Scope:       "POST /identities",
+				RequestHash: hex.EncodeToString(hash[:]),
+				ExpiresAt:   time.Now().UTC().Add(-time.Second),
+			}))
+
+			res, created := create(t, key, body)
+			assert.Equal(t, http.StatusCreated, res.StatusCode, "%s", created.Raw)
+			assert.Empty(t, res.Header.Get(idempotency.HeaderReplayed))
+		})
+
+		t.Run("case=failed requests release the key", func(t *testing.T) {
+			key := x.NewUUID().String()
+			res, body := create(t, key, `{"traits": {"bar":123}}`)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, "%s", body.Raw)
+
+			res, body = create(t, key, `{"traits": {"bar":123}}`)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, "%s", body.Raw)
+			assert.Empty(t, res.Header.Get(idempotency.HeaderReplayed))
+		})
+	})
+
+	t.Run("case=unable to set an invalid ID", func(t *testing.T) {
 		res := send(t, "POST", "/identities", http.StatusBadRequest, json.RawMessage(`{"id":"12345","traits":{}}`))
 		assert.Contains(t, res.Raw, "id")
 	})
 
+	t.Run("case=should create an identity with imported timestamps", func(t *testing.T) {
+		res := send(t, "POST", "/identities", http.StatusCreated, json.RawMessage(`{"traits":{"bar":"baz"},"created_at":"2019-01-02T03:04:05Z","updated_at":"2020-01-02T03:04:05Z"}`))
+		assert.Equal(t, "2019-01-02T03:04:05Z", res.Get("created_at").String(), "%s", res.Raw)
+		assert.Equal(t, "2020-01-02T03:04:05Z", res.Get("updated_at").String(), "%s", res.Raw)
+
+		res = get(t, "/identities/"+res.Get("id").String(), http.StatusOK)
+		assert.Equal(t, "2019-01-02T03:04:05Z", res.Get("created_at").String(), "%s", res.Raw)
+		assert.Equal(t, "2020-01-02T03:04:05Z", res.Get("updated_at").String(), "%s", res.Raw)
+
+		future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		res = send(t, "POST", "/identities", http.StatusBadRequest, json.RawMessage(`{"traits":{"bar":"baz"},"created_at":"`+future+`"}`))
+		assert.Contains(t, res.Get("error.reason").String(), "created_at", "%s", res.Raw)
+
+		res = send(t, "POST", "/identities", http.StatusBadRequest, json.RawMessage(`{"traits":{"bar":"baz"},"created_at":"2020-01-02T03:04:05Z","updated_at":"2019-01-02T03:04:05Z"}`))
+		assert.Contains(t, res.Get("error.reason").String(), "updated_at", "%s", res.Raw)
+	})
+
+	t.Run("case=should create an identity with a client-provided ID", func(t *testing.T) {
+		id := x.NewUUID()
+		res := send(t, "POST", "/identities", http.StatusCreated, json.RawMessage(`{"id":"`+id.String()+`","traits":{"bar":"baz"}}`))
+		assert.Equal(t, id.String(), res.Get("id").String(), "%s", res.Raw)
+
+		res = get(t, "/identities/"+id.String(), http.StatusOK)
+		assert.Equal(t, "baz", res.Get("traits.bar").String(), "%s", res.Raw)
+
+		_ = send(t, "POST", "/identities", http.StatusConflict, json.RawMessage(`{"id":"`+id.String()+`","traits":{"bar":"baz"}}`))
+	})
+
 	t.Run("suite=create and update", func(t *testing.T) {
 		var i identity.Identity
 		t.Run("case=should create an identity with an ID which is ignored", func(t *testing.T) {
@@ -145,6 +256,19 @@ func TestHandler(t *testing.T) {
 			assert.Empty(t, res.Get("credentials").String(), "%s", res.Raw)
 		})
 
+		t.Run("case=should only return the requested trait fields", func(t *testing.T) {
+			res := get(t, "/identities/"+i.ID.String()+"?fields=bar,does-not-exist", http.StatusOK)
+			assert.EqualValues(t, i.ID.String(), res.Get("id").String(), "%s", res.Raw)
+			assert.JSONEq(t, `{"bar":"baz"}`, res.Get("traits").Raw, "%s", res.Raw)
+
+			res = get(t, "/identities?fields=does-not-exist", http.StatusOK)
+			for _, item := range res.Array() {
+				assert.JSONEq(t, `{}`, item.Get("traits").Raw, "%s", res.Raw)
+			}
+
+			_ = get(t, "/identities/"+i.ID.String()+"?fields=bar.*", http.StatusBadRequest)
+		})
+
 		t.Run("case=should update an identity and persist the changes", func(t *testing.T) {
 			ur := identity.UpdateIdentity{Traits: []byte(`{"bar":"baz","foo":"baz"}`), SchemaID: i.SchemaID}
 			res := send(t, "PUT", "/identities/"+i.ID.String(), http.StatusOK, &ur)
@@ -162,6 +286,18 @@ func TestHandler(t *testing.T) {
 		})
 	})
 
+	t.Run("case=should return a report when deleting an identity", func(t *testing.T) {
+		i := identity.NewIdentity("")
+		i.Traits = identity.Traits(`{"bar":"baz"}`)
+		require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+
+		res := send(t, "DELETE", "/identities/"+i.ID.String()+"?report=true", http.StatusOK, nil)
+		assert.Equal(t, i.ID.String(), res.Get("identity_id").String(), "%s", res.Raw)
+		assert.True(t, res.Get("deleted_at").Exists(), "%s", res.Raw)
+		assert.EqualValues(t, 0, res.Get("sessions").Int(), "%s", res.Raw)
+		_ = get(t, "/identities/"+i.ID.String(), http.StatusNotFound)
+	})
+
 	t.Run("case=should not be able to create an identity with an invalid schema", func(t *testing.T) {
 		var cr identity.CreateIdentity
 		cr.SchemaID = "unknown"
@@ -204,6 +340,580 @@ func TestHandler(t *testing.T) {
 		assert.EqualValues(t, updatedEmail, res.Get("verifiable_addresses.0.value").String(), "%s", res.Raw)
 	})
 
+	t.Run("case=should manage recovery addresses", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		schemaEmail := x.NewUUID().String() + "@ory.sh"
+		cr.Traits = []byte(`{"email":"` + schemaEmail + `"}`)
+		res := send(t, "POST", "/identities", http.StatusCreated, &cr)
+		id := res.Get("id").String()
+		schemaAddressID := res.Get("recovery_addresses.0.id").String()
+		assert.EqualValues(t, identity.RecoveryAddressSourceSchema, res.Get("recovery_addresses.0.source").String(), "%s", res.Raw)
+		assert.False(t, res.Get("recovery_addresses.0.disabled").Bool(), "%s", res.Raw)
+
+		adminEmail := x.NewUUID().String() + "@ory.sh"
+		res = send(t, "POST", "/identities/"+id+"/recovery-addresses", http.StatusCreated, &identity.CreateRecoveryAddress{Via: identity.RecoveryAddressTypeEmail, Value: adminEmail})
+		require.Len(t, res.Get("recovery_addresses").Array(), 2, "%s", res.Raw)
+		adminAddress := res.Get(`recovery_addresses.#(value=="` + adminEmail + `")`)
+		assert.EqualValues(t, identity.RecoveryAddressSourceAdmin, adminAddress.Get("source").String(), "%s", res.Raw)
+		adminAddressID := adminAddress.Get("id").String()
+
+		t.Run("case=should reject duplicate and invalid addresses", func(t *testing.T) {
+			send(t, "POST", "/identities/"+id+"/recovery-addresses", http.StatusConflict, &identity.CreateRecoveryAddress{Via: identity.RecoveryAddressTypeEmail, Value: schemaEmail})
+			send(t, "POST", "/identities/"+id+"/recovery-addresses", http.StatusBadRequest, &identity.CreateRecoveryAddress{Via: identity.RecoveryAddressTypeEmail, Value: "not-an-email"})
+			send(t, "POST", "/identities/"+id+"/recovery-addresses", http.StatusBadRequest, &identity.CreateRecoveryAddress{Via: "sms", Value: "+49123456789"})
+		})
+
+		t.Run("case=should keep admin addresses when the traits change", func(t *testing.T) {
+			res := send(t, "PUT", "/identities/"+id, http.StatusOK, &identity.UpdateIdentity{
+				Traits: []byte(`{"email":"` + schemaEmail + `", "department": "ory"}`),
+			})
+			require.Len(t, res.Get("recovery_addresses").Array(), 2, "%s", res.Raw)
+		})
+
+		t.Run("case=should disable an address", func(t *testing.T) {
+			res := send(t, "PATCH", "/identities/"+id+"/recovery-addresses/"+schemaAddressID, http.StatusOK, &identity.UpdateRecoveryAddress{Disabled: true})
+			assert.True(t, res.Get(`recovery_addresses.#(id=="`+schemaAddressID+`").disabled`).Bool(), "%s", res.Raw)
+
+			_, err := reg.IdentityPool().FindRecoveryAddressByValue(context.Background(), identity.RecoveryAddressTypeEmail, schemaEmail)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			res = get(t, "/identities/"+id, http.StatusOK)
+			assert.True(t, res.Get(`recovery_addresses.#(id=="`+schemaAddressID+`").disabled`).Bool(), "%s", res.Raw)
+
+			send(t, "PATCH", "/identities/"+id+"/recovery-addresses/"+x.NewUUID().String(), http.StatusNotFound, &identity.UpdateRecoveryAddress{Disabled: true})
+		})
+
+		t.Run("case=should only delete admin addresses", func(t *testing.T) {
+			remove(t, "/identities/"+id+"/recovery-addresses/"+schemaAddressID, http.StatusBadRequest)
+			remove(t, "/identities/"+id+"/recovery-addresses/"+adminAddressID, http.StatusNoContent)
+
+			res := get(t, "/identities/"+id, http.StatusOK)
+			require.Len(t, res.Get("recovery_addresses").Array(), 1, "%s", res.Raw)
+			assert.EqualValues(t, schemaAddressID, res.Get("recovery_addresses.0.id").String(), "%s", res.Raw)
+		})
+	})
+
+	t.Run("case=should attest verifiable addresses", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		email := x.NewUUID().String() + "@ory.sh"
+		cr.Traits = []byte(`{"email":"` + email + `"}`)
+		res := send(t, "POST", "/identities", http.StatusCreated, &cr)
+		id := res.Get("id").String()
+		addressID := res.Get("verifiable_addresses.0.id").String()
+		assert.False(t, res.Get("verifiable_addresses.0.verified").Bool(), "%s", res.Raw)
+		assert.False(t, res.Get("verifiable_addresses.0.attestation").Exists(), "%s", res.Raw)
+
+		route := "/identities/" + id + "/verifiable-addresses/" + addressID + "/attestation"
+
+		t.Run("case=should reject invalid attestations", func(t *testing.T) {
+			send(t, "PUT", route, http.StatusBadRequest, &identity.AttestVerifiableAddress{})
+			send(t, "PUT", route, http.StatusBadRequest, &identity.AttestVerifiableAddress{Attester: "legacy-import", Metadata: []byte(`"not an object"`)})
+			future := time.Now().Add(time.Hour)
+			send(t, "PUT", route, http.StatusBadRequest, &identity.AttestVerifiableAddress{Attester: "legacy-import", AttestedAt: &future})
+			send(t, "PUT", "/identities/"+id+"/verifiable-addresses/"+x.NewUUID().String()+"/attestation", http.StatusNotFound, &identity.AttestVerifiableAddress{Attester: "legacy-import"})
+		})
+
+		t.Run("case=should mark the address as verified", func(t *testing.T) {
+			attestedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+			res := send(t, "PUT", route, http.StatusOK, &identity.AttestVerifiableAddress{
+				Attester:   "legacy-import",
+				Method:     "email-link",
+				AttestedAt: &attestedAt,
+				Metadata:   []byte(`{"legacy_id":"1234"}`),
+			})
+			assert.True(t, res.Get("verifiable_addresses.0.verified").Bool(), "%s", res.Raw)
+
+			res = get(t, "/identities/"+id, http.StatusOK)
+			address := res.Get("verifiable_addresses.0")
+			assert.True(t, address.Get("verified").Bool(), "%s", res.Raw)
+			assert.EqualValues(t, identity.VerifiableAddressStatusCompleted, address.Get("status").String(), "%s", res.Raw)
+			assert.EqualValues(t, attestedAt, address.Get("verified_at").Time(), "%s", res.Raw)
+			assert.EqualValues(t, "legacy-import", address.Get("attestation.attester").String(), "%s", res.Raw)
+			assert.EqualValues(t, "email-link", address.Get("attestation.method").String(), "%s", res.Raw)
+			assert.EqualValues(t, "1234", address.Get("attestation.metadata.legacy_id").String(), "%s", res.Raw)
+			assert.True(t, address.Get("attestation.recorded_at").Time().After(attestedAt), "%s", res.Raw)
+		})
+
+		t.Run("case=should keep the attestation when the traits change", func(t *testing.T) {
+			res := send(t, "PUT", "/identities/"+id, http.StatusOK, &identity.UpdateIdentity{
+				Traits: []byte(`{"email":"` + email + `", "department": "ory"}`),
+			})
+			assert.True(t, res.Get("verifiable_addresses.0.verified").Bool(), "%s", res.Raw)
+			assert.EqualValues(t, "legacy-import", res.Get("verifiable_addresses.0.attestation.attester").String(), "%s", res.Raw)
+		})
+	})
+
+	t.Run("case=should look up identities by verified addresses", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		email := x.NewUUID().String() + "@ory.sh"
+		cr.Traits = []byte(`{"email":"` + email + `"}`)
+		res := send(t, "POST", "/identities", http.StatusCreated, &cr)
+		id := res.Get("id").String()
+
+		_ = get(t, "/identity-lookup/email/"+email, http.StatusNotFound)
+		_ = get(t, "/identity-lookup/phone/"+email, http.StatusNotFound)
+		_ = get(t, "/identity-lookup/email/"+x.NewUUID().String()+"@ory.sh", http.StatusNotFound)
+
+		_ = send(t, "PUT", "/identities/"+id+"/verifiable-addresses/"+res.Get("verifiable_addresses.0.id").String()+"/attestation", http.StatusOK, &identity.AttestVerifiableAddress{Attester: "legacy-import"})
+
+		res = get(t, "/identity-lookup/email/"+email, http.StatusOK)
+		assert.Equal(t, id, res.Get("id").String(), "%s", res.Raw)
+		assert.Equal(t, email, res.Get("traits.email").String(), "%s", res.Raw)
+		_ = get(t, "/identity-lookup/phone/"+email, http.StatusNotFound)
+	})
+
+	t.Run("case=should rate limit lookups", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyEnumerationRateLimitEnabled, true)
+		conf.MustSet(config.ViperKeyEnumerationRateLimitIdentifierBurst, 2)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyEnumerationRateLimitEnabled, false)
+			conf.MustSet(config.ViperKeyEnumerationLimitedResponse, "reject")
+		})
+
+		email := x.NewUUID().String() + "@ory.sh"
+		_ = get(t, "/identity-lookup/email/"+email, http.StatusNotFound)
+		_ = get(t, "/identity-lookup/email/"+strings.ToUpper(email), http.StatusNotFound)
+		_ = get(t, "/identity-lookup/email/"+email, http.StatusTooManyRequests)
+
+		// Other addresses have their own budget.
+		_ = get(t, "/identity-lookup/email/"+x.NewUUID().String()+"@ory.sh", http.StatusNotFound)
+
+		conf.MustSet(config.ViperKeyEnumerationLimitedResponse, "dummy")
+		_ = get(t, "/identity-lookup/email/"+email, http.StatusNotFound)
+	})
+
+	t.Run("case=should force a password reset", func(t *testing.T) {
+		createIdentity := func(t *testing.T, traits string, credentials map[identity.CredentialsType]identity.Credentials) *identity.Identity {
+			i := identity.NewIdentity("employee")
+			i.Traits = identity.Traits(traits)
+			i.Credentials = credentials
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+			return i
+		}
+
+		email := x.NewUUID().String() + "@ory.sh"
+		i := createIdentity(t, `{"email":"`+email+`"}`, map[identity.CredentialsType]identity.Credentials{
+			identity.CredentialsTypePassword: {Type: identity.CredentialsTypePassword, Identifiers: []string{email}, Config: []byte(`{"hashed_password":"foo"}`)},
+		})
+
+		send(t, "POST", "/identities/"+i.ID.String()+"/force-password-reset", http.StatusNoContent, &identity.ForcePasswordReset{Notify: true})
+
+		actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+		require.NoError(t, err)
+		assert.True(t, actual.PasswordExpired())
+
+		messages, err := reg.CourierPersister().NextMessages(context.Background(), 10)
+		require.NoError(t, err)
+		var found bool
+		for _, m := range messages {
+			found = found || m.Recipient == email
+		}
+		assert.True(t, found, "%+v", messages)
+
+		t.Run("case=should fail if the identity has no password", func(t *testing.T) {
+			i := createIdentity(t, `{"email":"`+x.NewUUID().String()+`@ory.sh"}`, nil)
+			send(t, "POST", "/identities/"+i.ID.String()+"/force-password-reset", http.StatusBadRequest, json.RawMessage(`{}`))
+		})
+
+		t.Run("case=should fail to notify an identity without recovery addresses", func(t *testing.T) {
+			email := x.NewUUID().String() + "@ory.sh"
+			i := createIdentity(t, `{"email":"`+email+`"}`, map[identity.CredentialsType]identity.Credentials{
+				identity.CredentialsTypePassword: {Type: identity.CredentialsTypePassword, Identifiers: []string{email}, Config: []byte(`{"hashed_password":"foo"}`)},
+			})
+			require.NotEmpty(t, i.RecoveryAddresses)
+			for k := range i.RecoveryAddresses {
+				send(t, "PATCH", "/identities/"+i.ID.String()+"/recovery-addresses/"+i.RecoveryAddresses[k].ID.String(), http.StatusOK, &identity.UpdateRecoveryAddress{Disabled: true})
+			}
+			send(t, "POST", "/identities/"+i.ID.String()+"/force-password-reset", http.StatusBadRequest, &identity.ForcePasswordReset{Notify: true})
+		})
+
+		t.Run("case=should return 404 for an unknown identity", func(t *testing.T) {
+			send(t, "POST", "/identities/"+x.NewUUID().String()+"/force-password-reset", http.StatusNotFound, json.RawMessage(`{}`))
+		})
+	})
+
+	t.Run("case=should set a temporary password", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		cr.Traits = []byte(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		id := send(t, "POST", "/identities", http.StatusCreated, &cr).Get("id").String()
+
+		send(t, "PUT", "/identities/"+id+"/temporary-password", http.StatusBadRequest, &identity.SetTemporaryPassword{})
+		send(t, "PUT", "/identities/"+id+"/temporary-password", http.StatusNoContent, &identity.SetTemporaryPassword{Password: "temporary-password"})
+
+		actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), x.ParseUUID(id))
+		require.NoError(t, err)
+		assert.True(t, actual.PasswordExpired())
+		c, ok := actual.GetCredentials(identity.CredentialsTypePassword)
+		require.True(t, ok)
+		assert.Equal(t, []string{gjson.GetBytes(actual.Traits, "email").String()}, c.Identifiers)
+		assert.NotContains(t, string(c.Config), "temporary-password")
+
+		send(t, "PUT", "/identities/"+x.NewUUID().String()+"/temporary-password", http.StatusNotFound, &identity.SetTemporaryPassword{Password: "temporary-password"})
+	})
+
+	t.Run("case=should set the password credentials", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		cr.Traits = []byte(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		id := send(t, "POST", "/identities", http.StatusCreated, &cr).Get("id").String()
+		href := "/identities/" + id + "/credentials/password"
+
+		assertPassword := func(t *testing.T, password string) {
+			actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), x.ParseUUID(id))
+			require.NoError(t, err)
+			assert.False(t, actual.PasswordExpired())
+			c, ok := actual.GetCredentials(identity.CredentialsTypePassword)
+			require.True(t, ok)
+			assert.Equal(t, []string{gjson.GetBytes(actual.Traits, "email").String()}, c.Identifiers)
+			require.NoError(t, hash.Compare(context.Background(), []byte(password), []byte(gjson.GetBytes(c.Config, "hashed_password").String())))
+		}
+
+		send(t, "PUT", href, http.StatusBadRequest, &identity.SetPasswordCredentials{})
+		send(t, "PUT", href, http.StatusBadRequest, &identity.SetPasswordCredentials{Password: "foo", HashedPassword: "$2a$04$foo"})
+		send(t, "PUT", href, http.StatusBadRequest, &identity.SetPasswordCredentials{HashedPassword: "md5:acbd18db4cc2f85cedef654fccc4a4d8"})
+
+		send(t, "PUT", href, http.StatusNoContent, &identity.SetPasswordCredentials{Password: "new-password"})
+		assertPassword(t, "new-password")
+
+		t.Run("case=imports bcrypt hashes", func(t *testing.T) {
+			hashed, err := bcrypt.GenerateFromPassword([]byte("legacy-password"), bcrypt.MinCost)
+			require.NoError(t, err)
+			send(t, "PUT", href, http.StatusNoContent, &identity.SetPasswordCredentials{HashedPassword: string(hashed)})
+			assertPassword(t, "legacy-password")
+		})
+
+		t.Run("case=replaces temporary passwords", func(t *testing.T) {
+			send(t, "PUT", "/identities/"+id+"/temporary-password", http.StatusNoContent, &identity.SetTemporaryPassword{Password: "temporary-password"})
+			send(t, "PUT", href, http.StatusNoContent, &identity.SetPasswordCredentials{Password: "new-password"})
+			assertPassword(t, "new-password")
+		})
+
+		t.Run("case=should return 404 for an unknown identity", func(t *testing.T) {
+			send(t, "PUT", "/identities/"+x.NewUUID().String()+"/credentials/password", http.StatusNotFound, &identity.SetPasswordCredentials{Password: "new-password"})
+		})
+	})
+
+	t.Run("case=should set the oidc credentials", func(t *testing.T) {
+		claims, err := reg.Cipher().Encrypt(context.Background(), []byte(`{"sub":"foo"}`))
+		require.NoError(t, err)
+
+		subject := x.NewUUID().String()
+		i := identity.NewIdentity("employee")
+		i.Traits = identity.Traits(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		i.Credentials = map[identity.CredentialsType]identity.Credentials{
+			identity.CredentialsTypeOIDC: {
+				Type:        identity.CredentialsTypeOIDC,
+				Identifiers: []string{"google:" + subject},
+				Config:      []byte(`{"providers":[{"subject":"` + subject + `","provider":"google","claims":"` + claims + `"}]}`),
+			},
+		}
+		require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+		href := "/identities/" + i.ID.String() + "/credentials/oidc"
+
+		send(t, "PUT", href, http.StatusBadRequest, &identity.SetOIDCCredentials{Providers: []identity.OIDCProvider{{Provider: "github"}}})
+		send(t, "PUT", href, http.StatusBadRequest, &identity.SetOIDCCredentials{Providers: []identity.OIDCProvider{{Provider: "github", Subject: "a"}, {Provider: "github", Subject: "b"}}})
+
+		github := x.NewUUID().String()
+		send(t, "PUT", href, http.StatusNoContent, &identity.SetOIDCCredentials{Providers: []identity.OIDCProvider{
+			{Provider: "google", Subject: subject},
+			{Provider: "github", Subject: github},
+		}})
+
+		actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+		require.NoError(t, err)
+		c, ok := actual.GetCredentials(identity.CredentialsTypeOIDC)
+		require.True(t, ok)
+		assert.ElementsMatch(t, []string{"google:" + subject, "github:" + github}, c.Identifiers)
+
+		res := get(t, "/identities/"+i.ID.String()+"?include_credential=oidc", http.StatusOK)
+		assert.JSONEq(t, `{"sub":"foo"}`, res.Get("credentials.oidc.config.providers.0.claims").Raw, "%s", res.Raw)
+		assert.Equal(t, github, res.Get("credentials.oidc.config.providers.1.subject").String(), "%s", res.Raw)
+
+		t.Run("case=should not link a subject linked to another identity", func(t *testing.T) {
+			other := identity.NewIdentity("employee")
+			other.Traits = identity.Traits(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), other))
+
+			send(t, "PUT", "/identities/"+other.ID.String()+"/credentials/oidc", http.StatusConflict, &identity.SetOIDCCredentials{Providers: []identity.OIDCProvider{{Provider: "github", Subject: github}}})
+		})
+
+		t.Run("case=should remove all links", func(t *testing.T) {
+			send(t, "PUT", href, http.StatusNoContent, &identity.SetOIDCCredentials{Providers: []identity.OIDCProvider{}})
+
+			actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+			require.NoError(t, err)
+			_, ok := actual.GetCredentials(identity.CredentialsTypeOIDC)
+			assert.False(t, ok)
+		})
+	})
+
+	t.Run("case=should mark an identity as compromised", func(t *testing.T) {
+		email := x.NewUUID().String() + "@ory.sh"
+		i := identity.NewIdentity("employee")
+		i.Traits = identity.Traits(`{"email":"` + email + `"}`)
+		i.Credentials = map[identity.CredentialsType]identity.Credentials{
+			identity.CredentialsTypePassword: {Type: identity.CredentialsTypePassword, Identifiers: []string{email}, Config: []byte(`{"hashed_password":"foo"}`)},
+		}
+		require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+
+		res := send(t, "POST", "/identities/"+i.ID.String()+"/compromised", http.StatusOK, &identity.MarkCompromised{UnlinkOIDC: true, Notify: true})
+		assert.Equal(t, i.ID.String(), res.Get("identity_id").String(), "%s", res.Raw)
+		assert.True(t, res.Get("password_revoked").Bool(), "%s", res.Raw)
+		assert.EqualValues(t, 1, res.Get("notified_addresses").Int(), "%s", res.Raw)
+		assert.Empty(t, res.Get("unlinked_oidc_providers").Array(), "%s", res.Raw)
+
+		t.Run("case=should accept an empty body", func(t *testing.T) {
+			res := send(t, "POST", "/identities/"+i.ID.String()+"/compromised", http.StatusOK, json.RawMessage(`{}`))
+			assert.EqualValues(t, 0, res.Get("notified_addresses").Int(), "%s", res.Raw)
+		})
+
+		t.Run("case=should return 404 for an unknown identity", func(t *testing.T) {
+			send(t, "POST", "/identities/"+x.NewUUID().String()+"/compromised", http.StatusNotFound, json.RawMessage(`{}`))
+		})
+	})
+
+	t.Run("case=should manage communication preferences", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		cr.Traits = []byte(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		id := send(t, "POST", "/identities", http.StatusCreated, &cr).Get("id").String()
+
+		res := get(t, "/identities/"+id+"/communication-preferences", http.StatusOK)
+		assert.Equal(t, id, res.Get("identity_id").String())
+		assert.Empty(t, res.Get("channel").String())
+
+		send(t, "PUT", "/identities/"+id+"/communication-preferences", http.StatusBadRequest, &identity.UpdateCommunicationPreferences{Channel: "pigeon"})
+		send(t, "PUT", "/identities/"+id+"/communication-preferences", http.StatusBadRequest, &identity.UpdateCommunicationPreferences{
+			QuietHours: &courier.QuietHours{Start: "25:00", End: "07:00"}})
+
+		res = send(t, "PUT", "/identities/"+id+"/communication-preferences", http.StatusOK, &identity.UpdateCommunicationPreferences{
+			Channel:    courier.ChannelSMS,
+			Locale:     "de-DE",
+			QuietHours: &courier.QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Berlin"},
+		})
+		assert.Equal(t, "sms", res.Get("channel").String())
+
+		res = get(t, "/identities/"+id+"/communication-preferences", http.StatusOK)
+		assert.Equal(t, "sms", res.Get("channel").String())
+		assert.Equal(t, "de-DE", res.Get("locale").String())
+		assert.Equal(t, "22:00", res.Get("quiet_hours.start").String())
+		assert.Equal(t, "Europe/Berlin", res.Get("quiet_hours.timezone").String())
+
+		res = send(t, "PUT", "/identities/"+id+"/communication-preferences", http.StatusOK, &identity.UpdateCommunicationPreferences{Channel: courier.ChannelEmail})
+		assert.False(t, res.Get("quiet_hours").Exists(), "%s", res.Raw)
+
+		get(t, "/identities/"+x.NewUUID().String()+"/communication-preferences", http.StatusNotFound)
+		send(t, "PUT", "/identities/"+x.NewUUID().String()+"/communication-preferences", http.StatusNotFound, &identity.UpdateCommunicationPreferences{})
+	})
+
+	t.Run("case=should manage notes", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		cr.Traits = []byte(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		id := send(t, "POST", "/identities", http.StatusCreated, &cr).Get("id").String()
+
+		assert.Empty(t, get(t, "/identities/"+id+"/notes", http.StatusOK).Array())
+
+		send(t, "POST", "/identities/"+id+"/notes", http.StatusBadRequest, &identity.CreateNote{Author: "support@ory.sh"})
+		send(t, "POST", "/identities/"+id+"/notes", http.StatusBadRequest, &identity.CreateNote{Text: "verified ownership via support ticket #123"})
+
+		res := send(t, "POST", "/identities/"+id+"/notes", http.StatusCreated, &identity.CreateNote{Author: "support@ory.sh", Text: "verified ownership via support ticket #123"})
+		assert.Equal(t, id, res.Get("identity_id").String())
+		assert.Equal(t, "support@ory.sh", res.Get("author").String())
+		assert.NotEmpty(t, res.Get("created_at").String())
+
+		res = get(t, "/identities/"+id+"/notes", http.StatusOK)
+		require.Len(t, res.Array(), 1, "%s", res.Raw)
+		assert.Equal(t, "verified ownership via support ticket #123", res.Get("0.text").String())
+
+		stored, err := reg.IdentityNotePersister().ListIdentityNotes(context.Background(), x.ParseUUID(id), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		assert.NotContains(t, stored[0].Text, "support ticket", "the text is stored encrypted")
+
+		assert.False(t, get(t, "/identities/"+id, http.StatusOK).Get("notes").Exists(), "notes are not part of the identity")
+
+		get(t, "/identities/"+x.NewUUID().String()+"/notes", http.StatusNotFound)
+		send(t, "POST", "/identities/"+x.NewUUID().String()+"/notes", http.StatusNotFound, &identity.CreateNote{Author: "support@ory.sh", Text: "text"})
+	})
+
+	t.Run("case=should manage relationships", func(t *testing.T) {
+		create := func(t *testing.T) string {
+			var cr identity.CreateIdentity
+			cr.SchemaID = "employee"
+			cr.Traits = []byte(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+			return send(t, "POST", "/identities", http.StatusCreated, &cr).Get("id").String()
+		}
+		guardian, child := create(t), create(t)
+
+		assert.Empty(t, get(t, "/identities/"+guardian+"/relationships", http.StatusOK).Array())
+
+		send(t, "POST", "/identities/"+guardian+"/relationships", http.StatusBadRequest, &identity.CreateRelationship{Type: "sibling", RelatedIdentityID: x.ParseUUID(child)})
+		send(t, "POST", "/identities/"+guardian+"/relationships", http.StatusBadRequest, &identity.CreateRelationship{Type: identity.RelationshipTypeGuardian, RelatedIdentityID: x.ParseUUID(guardian)})
+		send(t, "POST", "/identities/"+guardian+"/relationships", http.StatusBadRequest, &identity.CreateRelationship{Type: identity.RelationshipTypeGuardian, RelatedIdentityID: x.NewUUID()})
+
+		res := send(t, "POST", "/identities/"+guardian+"/relationships", http.StatusCreated, &identity.CreateRelationship{Type: identity.RelationshipTypeGuardian, RelatedIdentityID: x.ParseUUID(child)})
+		assert.Equal(t, "guardian", res.Get("type").String(), "%s", res.Raw)
+		assert.Equal(t, guardian, res.Get("identity_id").String(), "%s", res.Raw)
+		assert.Equal(t, child, res.Get("related_identity_id").String(), "%s", res.Raw)
+		rid := res.Get("id").String()
+
+		send(t, "POST", "/identities/"+guardian+"/relationships", http.StatusConflict, &identity.CreateRelationship{Type: identity.RelationshipTypeGuardian, RelatedIdentityID: x.ParseUUID(child)})
+		send(t, "POST", "/identities/"+child+"/relationships", http.StatusConflict, &identity.CreateRelationship{Type: identity.RelationshipTypeGuardian, RelatedIdentityID: x.ParseUUID(guardian)})
+		send(t, "POST", "/identities/"+child+"/relationships", http.StatusCreated, &identity.CreateRelationship{Type: identity.RelationshipTypeDelegate, RelatedIdentityID: x.ParseUUID(guardian)})
+
+		for _, id := range []string{guardian, child} {
+			res = get(t, "/identities/"+id+"/relationships", http.StatusOK)
+			require.Len(t, res.Array(), 2, "%s", res.Raw)
+			assert.Equal(t, rid, res.Get("0.id").String(), "%s", res.Raw)
+		}
+
+		remove(t, "/identities/"+create(t)+"/relationships/"+rid, http.StatusNotFound)
+		remove(t, "/identities/"+child+"/relationships/"+rid, http.StatusNoContent)
+		remove(t, "/identities/"+child+"/relationships/"+rid, http.StatusNotFound)
+
+		res = get(t, "/identities/"+guardian+"/relationships", http.StatusOK)
+		require.Len(t, res.Array(), 1, "%s", res.Raw)
+		assert.Equal(t, "delegate", res.Get("0.type").String(), "%s", res.Raw)
+
+		remove(t, "/identities/"+guardian, http.StatusNoContent)
+		assert.Empty(t, get(t, "/identities/"+child+"/relationships", http.StatusOK).Array(), "relationships are deleted with either identity")
+
+		get(t, "/identities/"+x.NewUUID().String()+"/relationships", http.StatusNotFound)
+	})
+
+	t.Run("case=should schedule state changes", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		cr.Traits = []byte(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		id := send(t, "POST", "/identities", http.StatusCreated, &cr).Get("id").String()
+
+		assert.Empty(t, get(t, "/identities/"+id+"/state-changes", http.StatusOK).Array())
+
+		send(t, "POST", "/identities/"+id+"/state-changes", http.StatusBadRequest, &identity.ScheduleStateChange{State: "suspended", ExecuteAt: time.Now().Add(time.Hour)})
+		send(t, "POST", "/identities/"+id+"/state-changes", http.StatusBadRequest, &identity.ScheduleStateChange{State: identity.StateInactive, ExecuteAt: time.Now().Add(-time.Hour)})
+		send(t, "POST", "/identities/"+x.NewUUID().String()+"/state-changes", http.StatusNotFound, &identity.ScheduleStateChange{State: identity.StateInactive, ExecuteAt: time.Now().Add(time.Hour)})
+
+		reactivate := send(t, "POST", "/identities/"+id+"/state-changes", http.StatusCreated, &identity.ScheduleStateChange{State: identity.StateActive, ExecuteAt: time.Now().Add(2 * time.Hour)})
+		deactivate := send(t, "POST", "/identities/"+id+"/state-changes", http.StatusCreated, &identity.ScheduleStateChange{State: identity.StateInactive, ExecuteAt: time.Now().Add(time.Hour)})
+		assert.Equal(t, "inactive", deactivate.Get("state").String(), "%s", deactivate.Raw)
+		assert.Equal(t, id, deactivate.Get("identity_id").String(), "%s", deactivate.Raw)
+		assert.False(t, deactivate.Get("executed_at").Exists(), "%s", deactivate.Raw)
+
+		res := get(t, "/identities/"+id+"/state-changes", http.StatusOK)
+		require.Len(t, res.Array(), 2, "%s", res.Raw)
+		assert.Equal(t, deactivate.Get("id").String(), res.Get("0.id").String(), "the next change is listed first: %s", res.Raw)
+
+		remove(t, "/identities/"+x.NewUUID().String()+"/state-changes/"+reactivate.Get("id").String(), http.StatusNotFound)
+		remove(t, "/identities/"+id+"/state-changes/"+reactivate.Get("id").String(), http.StatusNoContent)
+		remove(t, "/identities/"+id+"/state-changes/"+reactivate.Get("id").String(), http.StatusNotFound)
+
+		require.NoError(t, reg.ScheduledStateChangePersister().MarkScheduledStateChangeExecuted(context.Background(), x.ParseUUID(deactivate.Get("id").String()), time.Now()))
+		remove(t, "/identities/"+id+"/state-changes/"+deactivate.Get("id").String(), http.StatusConflict)
+
+		res = get(t, "/identities/"+id+"/state-changes", http.StatusOK)
+		require.Len(t, res.Array(), 1, "%s", res.Raw)
+		assert.True(t, res.Get("0.executed_at").Exists(), "%s", res.Raw)
+
+		get(t, "/identities/"+x.NewUUID().String()+"/state-changes", http.StatusNotFound)
+	})
+
+	t.Run("case=should include declassified oidc credentials", func(t *testing.T) {
+		claims, err := reg.Cipher().Encrypt(context.Background(), []byte(`{"sub":"foo","hd":"ory.sh"}`))
+		require.NoError(t, err)
+
+		i := identity.NewIdentity("employee")
+		i.Traits = identity.Traits(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		i.Credentials = map[identity.CredentialsType]identity.Credentials{
+			identity.CredentialsTypeOIDC: {
+				Type:        identity.CredentialsTypeOIDC,
+				Identifiers: []string{"google:foo", "github:bar"},
+				Config:      []byte(`{"providers":[{"subject":"foo","provider":"google","claims":"` + claims + `"},{"subject":"bar","provider":"github"}]}`),
+			},
+		}
+		require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+
+		res := get(t, "/identities/"+i.ID.String(), http.StatusOK)
+		assert.False(t, res.Get("credentials").Exists(), "%s", res.Raw)
+
+		res = get(t, "/identities/"+i.ID.String()+"?include_credential=oidc", http.StatusOK)
+		assert.EqualValues(t, i.Traits, res.Get("traits").Raw, "%s", res.Raw)
+		assert.JSONEq(t, `{"sub":"foo","hd":"ory.sh"}`, res.Get("credentials.oidc.config.providers.0.claims").Raw, "%s", res.Raw)
+		assert.False(t, res.Get("credentials.oidc.config.providers.1.claims").Exists(), "%s", res.Raw)
+
+		_ = get(t, "/identities/"+i.ID.String()+"?include_credential=password", http.StatusBadRequest)
+	})
+
+	t.Run("case=should support conditional requests", func(t *testing.T) {
+		i := identity.NewIdentity("employee")
+		i.Traits = identity.Traits(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+
+		request := func(t *testing.T, method, href string, header http.Header, expectCode int) *http.Response {
+			req, err := http.NewRequest(method, ts.URL+href, nil)
+			require.NoError(t, err)
+			for k, v := range header {
+				req.Header[k] = v
+			}
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			require.EqualValues(t, expectCode, res.StatusCode)
+			return res
+		}
+
+		href := "/identities/" + i.ID.String()
+		res := request(t, "HEAD", href, nil, http.StatusOK)
+		etag, lastModified := res.Header.Get("ETag"), res.Header.Get("Last-Modified")
+		require.NotEmpty(t, etag)
+		require.NotEmpty(t, lastModified)
+
+		request(t, "GET", href, http.Header{"If-None-Match": {etag}}, http.StatusNotModified)
+		request(t, "GET", href, http.Header{"If-Modified-Since": {lastModified}}, http.StatusNotModified)
+		request(t, "GET", href+"?fields=unknown", http.Header{"If-None-Match": {etag}}, http.StatusOK)
+		request(t, "HEAD", "/identities/"+x.NewUUID().String(), nil, http.StatusNotFound)
+
+		i.Traits = identity.Traits(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		require.NoError(t, reg.IdentityManager().Update(context.Background(), i, identity.ManagerAllowWriteProtectedTraits))
+		res = request(t, "GET", href, http.Header{"If-None-Match": {etag}}, http.StatusOK)
+		assert.NotEqual(t, etag, res.Header.Get("ETag"))
+	})
+
+	t.Run("case=should find an identity by its credentials identifier", func(t *testing.T) {
+		email := x.NewUUID().String() + "@ory.sh"
+		i := identity.NewIdentity("employee")
+		i.Traits = identity.Traits(`{"email":"` + email + `"}`)
+		i.Credentials = map[identity.CredentialsType]identity.Credentials{
+			identity.CredentialsTypePassword: {Type: identity.CredentialsTypePassword, Identifiers: []string{email}, Config: []byte(`{"hashed_password":"foo"}`)},
+			identity.CredentialsTypeOIDC:     {Type: identity.CredentialsTypeOIDC, Identifiers: []string{"github:" + email}, Config: []byte(`{"providers":[{"subject":"` + email + `","provider":"github"}]}`)},
+		}
+		require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+
+		res := get(t, "/identities/by-credential?type=password&identifier="+strings.ToUpper(email), http.StatusOK)
+		assert.Equal(t, i.ID.String(), res.Get("id").String(), "%s", res.Raw)
+		assert.Equal(t, email, res.Get("traits.email").String(), "%s", res.Raw)
+		assert.Equal(t, email, res.Get("credentials.password.identifiers.0").String(), "%s", res.Raw)
+		assert.Equal(t, "github:"+email, res.Get("credentials.oidc.identifiers.0").String(), "%s", res.Raw)
+		assert.False(t, res.Get("credentials.password.config").Exists(), "%s", res.Raw)
+		assert.NotContains(t, res.Raw, "hashed_password")
+
+		res = get(t, "/identities/by-credential?type=oidc&identifier=github:"+email+"&fields=email", http.StatusOK)
+		assert.Equal(t, i.ID.String(), res.Get("id").String(), "%s", res.Raw)
+
+		_ = get(t, "/identities/by-credential?type=oidc&identifier="+email, http.StatusNotFound)
+		_ = get(t, "/identities/by-credential?type=password", http.StatusBadRequest)
+	})
+
+	t.Run("case=should fail to update an identity with an unknown field", func(t *testing.T) {
+		res := send(t, "POST", "/identities", http.StatusCreated, json.RawMessage(`{"traits": {"bar":"baz"}}`))
+		res = send(t, "PUT", "/identities/"+res.Get("id").String(), http.StatusBadRequest, json.RawMessage(`{"traits": {"bar":"baz"}, "unknown": true}`))
+		assert.Contains(t, res.Raw, "unknown", "%s", res.Raw)
+	})
+
 	t.Run("case=should update the schema id and fail because traits are invalid", func(t *testing.T) {
 		var cr identity.CreateIdentity
 		cr.SchemaID = "employee"
@@ -258,6 +968,176 @@ func TestHandler(t *testing.T) {
 		assert.EqualValues(t, "baz", res.Get(`#(traits.bar=="baz").traits.bar`).String(), "%s", res.Raw)
 	})
 
+	t.Run("case=should list identities with pagination meta", func(t *testing.T) {
+		res := get(t, "/identities?meta=true&per_page=1", http.StatusOK)
+		assert.Len(t, res.Get("items").Array(), 1, "%s", res.Raw)
+		assert.True(t, res.Get("meta.total").Int() > 1, "%s", res.Raw)
+		assert.EqualValues(t, 0, res.Get("meta.page").Int(), "%s", res.Raw)
+		assert.EqualValues(t, 1, res.Get("meta.per_page").Int(), "%s", res.Raw)
+		assert.EqualValues(t, 1, res.Get("meta.next_page").Int(), "%s", res.Raw)
+	})
+
+	t.Run("case=should resolve the display name and avatar", func(t *testing.T) {
+		email := x.NewUUID().String() + "@ory.sh"
+		for _, tc := range []struct {
+			traits      string
+			displayName string
+			avatarURL   string
+		}{
+			{
+				traits:      `{"email":"` + email + `","name":{"nickname":"Foo","full":"Foo Bar"},"picture":"https://www.ory.sh/foo.png"}`,
+				displayName: "Foo Bar",
+				avatarURL:   "https://www.ory.sh/foo.png",
+			},
+			{
+				traits:      `{"email":"` + email + `","name":{"nickname":"Foo"}}`,
+				displayName: "Foo",
+			},
+			{
+				traits:      `{"email":"` + email + `","name":{"full":""}}`,
+				displayName: strings.Split(email, "@")[0],
+			},
+			{
+				traits: `{}`,
+			},
+		} {
+			var cr identity.CreateIdentity
+			cr.SchemaID = "profile"
+			cr.Traits = []byte(tc.traits)
+			created := send(t, "POST", "/identities", http.StatusCreated, &cr)
+			id := created.Get("id").String()
+			if tc.displayName == "" {
+				tc.displayName = id
+			}
+
+			for _, res := range []gjson.Result{
+				get(t, "/identities/"+id, http.StatusOK),
+				get(t, "/identities?per_page=1000", http.StatusOK).Get(`#(id=="` + id + `")`),
+				get(t, "/identities/"+id+"?fields=email", http.StatusOK),
+			} {
+				assert.Equal(t, tc.displayName, res.Get("display_name").String(), "%s", res.Raw)
+				assert.Equal(t, tc.avatarURL, res.Get("avatar_url").String(), "%s", res.Raw)
+			}
+			remove(t, "/identities/"+id, http.StatusNoContent)
+		}
+	})
+
+	t.Run("case=should mask sensitive traits", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "sensitive"
+		email := x.NewUUID().String() + "@ory.sh"
+		cr.Traits = []byte(`{"email":"` + email + `","phones":["+49123","+49456"],"age":42,"department":"ory"}`)
+		id := send(t, "POST", "/identities", http.StatusCreated, &cr).Get("id").String()
+
+		getWithScopes := func(t *testing.T, href, scopes string, expectCode int) gjson.Result {
+			req, err := http.NewRequest("GET", ts.URL+href, nil)
+			require.NoError(t, err)
+			req.Header.Set("X-Kratos-Scopes", scopes)
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			require.EqualValues(t, expectCode, res.StatusCode, "%s", body)
+			return gjson.ParseBytes(body)
+		}
+
+		t.Run("case=disabled by default", func(t *testing.T) {
+			res := get(t, "/identities/"+id+"?unmask=true", http.StatusOK)
+			assert.EqualValues(t, email, res.Get("traits.email").String(), "%s", res.Raw)
+		})
+
+		conf.MustSet(config.ViperKeyAdminTraitMaskingEnabled, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyAdminTraitMaskingEnabled, false)
+		})
+
+		t.Run("case=masks get and list", func(t *testing.T) {
+			for _, res := range []gjson.Result{
+				get(t, "/identities/"+id, http.StatusOK),
+				get(t, "/identities", http.StatusOK).Get(`#(id=="` + id + `")`),
+			} {
+				assert.JSONEq(t, `{"email":"***","phones":["***","***"],"age":null,"department":"ory"}`, res.Get("traits").Raw, "%s", res.Raw)
+				assert.EqualValues(t, identity.MaskedTraitValue, res.Get("verifiable_addresses.0.value").String(), "%s", res.Raw)
+				assert.EqualValues(t, identity.MaskedTraitValue, res.Get("recovery_addresses.0.value").String(), "%s", res.Raw)
+				assert.EqualValues(t, identity.MaskedTraitValue, res.Get("display_name").String(), "%s", res.Raw)
+			}
+
+			res := get(t, "/identities/"+id+"?fields=department", http.StatusOK)
+			assert.JSONEq(t, `{"department":"ory"}`, res.Get("traits").Raw, "%s", res.Raw)
+			assert.EqualValues(t, identity.MaskedTraitValue, res.Get("verifiable_addresses.0.value").String(), "%s", res.Raw)
+		})
+
+		t.Run("case=unmask requires the scope", func(t *testing.T) {
+			_ = getWithScopes(t, "/identities/"+id+"?unmask=true", "", http.StatusForbidden)
+			_ = getWithScopes(t, "/identities?unmask=true", "kratos:identities:read", http.StatusForbidden)
+		})
+
+		t.Run("case=unmasked reads are audited", func(t *testing.T) {
+			hook := new(test.Hook)
+			reg.WithLogger(logrusx.New("", "", logrusx.WithHook(hook)))
+
+			res := getWithScopes(t, "/identities/"+id+"?unmask=true", "kratos:identities:read,kratos:identities:unmask", http.StatusOK)
+			assert.JSONEq(t, string(cr.Traits), res.Get("traits").Raw, "%s", res.Raw)
+			assert.EqualValues(t, email, res.Get("verifiable_addresses.0.value").String(), "%s", res.Raw)
+
+			var audited int
+			for _, e := range hook.AllEntries() {
+				if e.Data["audience"] == "audit" && e.Message == "Unmasked identity traits were read using the admin API." {
+					assert.Equal(t, []string{id}, e.Data["identity_ids"])
+					audited++
+				}
+			}
+			assert.Equal(t, 1, audited)
+		})
+	})
+
+	t.Run("case=should check which credentials identifiers are known", func(t *testing.T) {
+		email := x.NewUUID().String() + "@ory.sh"
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		cr.Traits = []byte(`{"email":"` + email + `"}`)
+		id := send(t, "POST", "/identities", http.StatusCreated, &cr).Get("id").String()
+		send(t, "PUT", "/identities/"+id+"/credentials/password", http.StatusNoContent, &identity.SetPasswordCredentials{Password: "new-password"})
+
+		unknown := x.NewUUID().String() + "@ory.sh"
+		res := send(t, "POST", "/credentials/known/batch", http.StatusOK, &identity.KnownCredentialsBatch{Identifiers: []string{strings.ToUpper(email), unknown}})
+
+		known := res.Get("results." + strings.ReplaceAll(strings.ToUpper(email), ".", `\.`))
+		assert.True(t, known.Get("known").Bool(), "%s", res.Raw)
+		assert.Equal(t, id, known.Get("identity_id").String(), "%s", res.Raw)
+		assert.Equal(t, `["password"]`, known.Get("credentials").Raw, "%s", res.Raw)
+
+		notKnown := res.Get("results." + strings.ReplaceAll(unknown, ".", `\.`))
+		assert.False(t, notKnown.Get("known").Bool(), "%s", res.Raw)
+		assert.False(t, notKnown.Get("identity_id").Exists(), "%s", res.Raw)
+		assert.Equal(t, `[]`, notKnown.Get("credentials").Raw, "%s", res.Raw)
+
+		t.Run("case=should rate limit the identifiers", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyEnumerationRateLimitEnabled, true)
+			conf.MustSet(config.ViperKeyEnumerationRateLimitIdentifierBurst, 1)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyEnumerationRateLimitEnabled, false)
+				conf.MustSet(config.ViperKeyEnumerationLimitedResponse, "reject")
+			})
+
+			limited := x.NewUUID().String() + "@ory.sh"
+			send(t, "POST", "/credentials/known/batch", http.StatusOK, &identity.KnownCredentialsBatch{Identifiers: []string{email, limited}})
+			send(t, "POST", "/credentials/known/batch", http.StatusTooManyRequests, &identity.KnownCredentialsBatch{Identifiers: []string{email}})
+
+			conf.MustSet(config.ViperKeyEnumerationLimitedResponse, "dummy")
+			res := send(t, "POST", "/credentials/known/batch", http.StatusOK, &identity.KnownCredentialsBatch{Identifiers: []string{email}})
+			assert.False(t, res.Get("results."+strings.ReplaceAll(email, ".", `\.`)+".known").Bool(), "%s", res.Raw)
+		})
+
+		t.Run("case=should reject an empty or too large batch", func(t *testing.T) {
+			send(t, "POST", "/credentials/known/batch", http.StatusBadRequest, &identity.KnownCredentialsBatch{})
+			send(t, "POST", "/credentials/known/batch", http.StatusBadRequest, &identity.KnownCredentialsBatch{
+				Identifiers: make([]string, identity.KnownCredentialsBatchMaxIdentifiers+1),
+			})
+		})
+	})
+
 	t.Run("case=should not be able to update an identity that does not exist yet", func(t *testing.T) {
 		res := send(t, "PUT", "/identities/not-found", http.StatusNotFound, json.RawMessage(`{"traits": {"bar":"baz"}}`))
 		assert.Contains(t, res.Get("error.message").String(), "Unable to locate the resource", "%s", res.Raw)
@@ -0,0 +1,164 @@
+package identity
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/x"
+)
+
+const RouteVerifiableAddresses = "/verifiable-addresses"
+
+// swagger:parameters attestIdentityVerifiableAddress
+// nolint:deadcode,unused
+type attestVerifiableAddressParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// AddressID is the verifiable address' ID.
+	//
+	// required: true
+	// in: path
+	AddressID string `json:"address_id"`
+
+	// in: body
+	Body AttestVerifiableAddress
+}
+
+type AttestVerifiableAddress struct {
+	// Attester identifies the system which verified the address, for example `legacy-import`.
+	//
+	// required: true
+	Attester string `json:"attester"`
+
+	// Method describes how the attester verified the address, for example `email-link`.
+	Method string `json:"method"`
+
+	// AttestedAt is the time the attester verified the address. Defaults to the current time.
+	AttestedAt *time.Time `json:"attested_at"`
+
+	// Metadata is stored with the attestation and must be a JSON object.
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// swagger:route PUT /identities/{id}/verifiable-addresses/{address_id}/attestation admin attestIdentityVerifiableAddress
+//
+// Attest that a Verifiable Address Is Verified
+//
+// This endpoint marks a verifiable address as verified on behalf of a trusted system which already verified
+// it, for example the system identities are imported from. The attester and the metadata are stored with
+// the address so that the provenance of the verification is known. Attesting an address again replaces the
+// previous attestation.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) attestVerifiableAddress(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var body AttestVerifiableAddress
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.r.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	body.Attester = strings.TrimSpace(body.Attester)
+	if len(body.Attester) == 0 {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The attester must be set.")))
+		return
+	}
+
+	if string(body.Metadata) == "null" {
+		body.Metadata = nil
+	} else if len(body.Metadata) > 0 {
+		var metadata map[string]interface{}
+		if err := json.Unmarshal(body.Metadata, &metadata); err != nil || metadata == nil {
+			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The attestation metadata must be a JSON object.")))
+			return
+		}
+	}
+
+	now := time.Now().UTC().Round(time.Second)
+	attestedAt := now
+	if body.AttestedAt != nil {
+		if body.AttestedAt.After(now) {
+			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The attestation time must not be in the future.")))
+			return
+		}
+		attestedAt = body.AttestedAt.UTC()
+	}
+
+	i, k, err := h.findVerifiableAddress(r, ps)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	address := &i.VerifiableAddresses[k]
+	if address.Status == VerifiableAddressStatusStale {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The address no longer appears in the identity's traits and can not be attested.")))
+		return
+	}
+
+	address.Verified = true
+	address.VerifiedAt = sqlxx.NullTime(attestedAt)
+	address.Status = VerifiableAddressStatusCompleted
+	address.Attestation = &VerifiableAddressAttestation{
+		Attester:   body.Attester,
+		Method:     body.Method,
+		AttestedAt: attestedAt,
+		RecordedAt: now,
+		Metadata:   body.Metadata,
+	}
+	if err := h.r.PrivilegedIdentityPool().UpdateVerifiableAddress(r.Context(), address); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Audit().
+		WithRequest(r).
+		WithField("identity_id", i.ID).
+		WithField("verifiable_address_id", address.ID).
+		WithField("attester", body.Attester).
+		Info("A verifiable address was marked as verified by an attestation.")
+
+	h.r.Writer().Write(w, r, i)
+}
+
+func (h *Handler) findVerifiableAddress(r *http.Request, ps httprouter.Params) (*Identity, int, error) {
+	i, err := h.r.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	id := x.ParseUUID(ps.ByName("address_id"))
+	for k := range i.VerifiableAddresses {
+		if i.VerifiableAddresses[k].ID == id {
+			return i, k, nil
+		}
+	}
+
+	return nil, 0, errors.WithStack(herodot.ErrNotFound.WithReason("The identity does not have a verifiable address with this ID."))
+}
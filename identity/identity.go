@@ -75,11 +75,35 @@ type (
 		// ---
 		RecoveryAddresses []RecoveryAddress `json:"recovery_addresses,omitempty" faker:"-" has_many:"identity_recovery_addresses" fk_id:"identity_id"`
 
-		// CreatedAt is a helper struct field for gobuffalo.pop.
-		CreatedAt time.Time `json:"-" db:"created_at"`
+		// DisplayName is the name to show for the identity. It is read from the trait marked with
+		// `"ory.sh/kratos": {"profile": {"display_name": true}}` and falls back to the local part of the
+		// identity's first address and then to its ID.
+		//
+		// Extensions:
+		// ---
+		// x-omitempty: true
+		// ---
+		DisplayName string `json:"display_name,omitempty" faker:"-" db:"-"`
+
+		// AvatarURL is read from the trait marked with `"ory.sh/kratos": {"profile": {"avatar": true}}`.
+		//
+		// Extensions:
+		// ---
+		// x-omitempty: true
+		// ---
+		AvatarURL string `json:"avatar_url,omitempty" faker:"-" db:"-"`
+
+		// State is the identity's state. Inactive identities can not sign in.
+		State State `json:"state" faker:"-" db:"state"`
+
+		// StateChangedAt is the time at which the identity's state was last changed.
+		StateChangedAt *sqlxx.NullTime `json:"state_changed_at,omitempty" faker:"-" db:"state_changed_at"`
+
+		// CreatedAt is the time at which the identity was created. It can be set when importing identities.
+		CreatedAt time.Time `json:"created_at" db:"created_at"`
 
-		// UpdatedAt is a helper struct field for gobuffalo.pop.
-		UpdatedAt time.Time `json:"-" db:"updated_at"`
+		// UpdatedAt is the time at which the identity was last updated.
+		UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 		NID       uuid.UUID `json:"-"  faker:"-" db:"nid"`
 	}
 	Traits json.RawMessage
@@ -178,10 +202,48 @@ func NewIdentity(traitsSchemaID string) *Identity {
 		Traits:              Traits("{}"),
 		SchemaID:            traitsSchemaID,
 		VerifiableAddresses: []VerifiableAddress{},
+		State:               StateActive,
 		l:                   new(sync.RWMutex),
 	}
 }
 
+// Addresses returns the values of all verifiable and recovery addresses without duplicates.
+func (i *Identity) Addresses() []string {
+	seen := map[string]bool{}
+	var addresses []string
+	add := func(value string) {
+		if !seen[value] {
+			seen[value] = true
+			addresses = append(addresses, value)
+		}
+	}
+
+	for _, a := range i.VerifiableAddresses {
+		add(a.Value)
+	}
+	for _, a := range i.RecoveryAddresses {
+		add(a.Value)
+	}
+	return addresses
+}
+
+// LastModified returns the time at which the identity or one of its addresses was last updated. Changes
+// to the identity's credentials are not taken into account.
+func (i *Identity) LastModified() time.Time {
+	last := i.UpdatedAt
+	for _, a := range i.VerifiableAddresses {
+		if a.UpdatedAt.After(last) {
+			last = a.UpdatedAt
+		}
+	}
+	for _, a := range i.RecoveryAddresses {
+		if a.UpdatedAt.After(last) {
+			last = a.UpdatedAt
+		}
+	}
+	return last
+}
+
 func (i Identity) GetID() uuid.UUID {
 	return i.ID
 }
@@ -11,6 +11,12 @@ import (
 
 const (
 	RecoveryAddressTypeEmail RecoveryAddressType = AddressTypeEmail
+
+	// RecoveryAddressSourceSchema marks addresses derived from the identity's traits using the identity schema.
+	RecoveryAddressSourceSchema RecoveryAddressSource = "schema"
+
+	// RecoveryAddressSourceAdmin marks addresses added using the admin API. They are kept when the traits change.
+	RecoveryAddressSourceAdmin RecoveryAddressSource = "admin"
 )
 
 type (
@@ -20,6 +26,9 @@ type (
 	// RecoveryAddressStatus must not exceed 16 characters as that is the limitation in the SQL Schema.
 	RecoveryAddressStatus string
 
+	// RecoveryAddressSource must not exceed 16 characters as that is the limitation in the SQL Schema.
+	RecoveryAddressSource string
+
 	// swagger:model recoveryIdentityAddress
 	RecoveryAddress struct {
 		// required: true
@@ -31,6 +40,13 @@ type (
 		// required: true
 		Via RecoveryAddressType `json:"via" db:"via"`
 
+		// Disabled is true if the address can not be used to recover the identity.
+		Disabled bool `json:"disabled" db:"disabled"`
+
+		// Source is either `schema` if the address was derived from the identity's traits, or `admin`
+		// if it was added using the admin API.
+		Source RecoveryAddressSource `json:"source" db:"source"`
+
 		// IdentityID is a helper struct field for gobuffalo.pop.
 		IdentityID uuid.UUID `json:"-" faker:"-" db:"identity_id"`
 		// CreatedAt is a helper struct field for gobuffalo.pop.
@@ -60,6 +76,18 @@ func NewRecoveryEmailAddress(
 	return &RecoveryAddress{
 		Value:      value,
 		Via:        RecoveryAddressTypeEmail,
+		Source:     RecoveryAddressSourceSchema,
 		IdentityID: identity,
 	}
 }
+
+// EnabledRecoveryAddresses returns the recovery addresses which have not been disabled.
+func (i *Identity) EnabledRecoveryAddresses() []RecoveryAddress {
+	var addresses []RecoveryAddress
+	for _, a := range i.RecoveryAddresses {
+		if !a.Disabled {
+			addresses = append(addresses, a)
+		}
+	}
+	return addresses
+}
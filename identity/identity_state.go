@@ -0,0 +1,52 @@
+package identity
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlxx"
+)
+
+// State is the lifecycle state of an identity.
+//
+// swagger:model identityState
+type State string
+
+const (
+	// StateActive is the state of all identities which were not deactivated.
+	StateActive State = "active"
+
+	// StateInactive identities can not sign in and their sessions can not be used.
+	StateInactive State = "inactive"
+)
+
+// ErrIdentityInactive is returned when an inactive identity tries to sign in or use a session.
+var ErrIdentityInactive = herodot.ErrForbidden.
+	WithError("identity is inactive").
+	WithReason("This account was deactivated. Contact the system administrator to reactivate it.")
+
+// Validate returns an error if the state is unknown.
+func (s State) Validate() error {
+	switch s {
+	case StateActive, StateInactive:
+		return nil
+	}
+	return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Identity state %q is unknown, expected one of %q or %q.", s, StateActive, StateInactive))
+}
+
+// IsActive returns false if the identity was deactivated.
+func (i *Identity) IsActive() bool {
+	return i.State != StateInactive
+}
+
+// SetState changes the identity's state and records when it changed.
+func (i *Identity) SetState(s State) {
+	if i.State == s {
+		return
+	}
+	i.State = s
+	now := sqlxx.NullTime(time.Now().UTC())
+	i.StateChangedAt = &now
+}
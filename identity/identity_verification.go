@@ -2,6 +2,8 @@ package identity
 
 import (
 	"context"
+	"database/sql/driver"
+	"encoding/json"
 	"time"
 
 	"github.com/ory/kratos/corp"
@@ -13,9 +15,14 @@ import (
 
 const (
 	VerifiableAddressTypeEmail VerifiableAddressType = AddressTypeEmail
+	VerifiableAddressTypeSMS   VerifiableAddressType = AddressTypeSMS
 
 	VerifiableAddressStatusPending   VerifiableAddressStatus = "pending"
 	VerifiableAddressStatusCompleted VerifiableAddressStatus = "completed"
+
+	// VerifiableAddressStatusStale marks an address which no longer appears in the identity's traits. See
+	// config.VerifiableAddressMergeMarkStale.
+	VerifiableAddressStatusStale VerifiableAddressStatus = "stale"
 )
 
 type (
@@ -44,6 +51,9 @@ type (
 
 		VerifiedAt sqlxx.NullTime `json:"verified_at" faker:"-" db:"verified_at"`
 
+		// Attestation is set if a trusted system verified the address instead of the identity.
+		Attestation *VerifiableAddressAttestation `json:"attestation,omitempty" faker:"-" db:"attestation"`
+
 		// IdentityID is a helper struct field for gobuffalo.pop.
 		IdentityID uuid.UUID `json:"-" faker:"-" db:"identity_id"`
 		// CreatedAt is a helper struct field for gobuffalo.pop.
@@ -52,6 +62,33 @@ type (
 		UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
 		NID       uuid.UUID `json:"-"  faker:"-" db:"nid"`
 	}
+
+	// VerifiableAddressAttestation records the provenance of an address which a trusted system, for example the
+	// system identities are imported from, attested to be verified.
+	//
+	// swagger:model verifiableIdentityAddressAttestation
+	VerifiableAddressAttestation struct {
+		// Attester identifies the system which verified the address.
+		//
+		// required: true
+		Attester string `json:"attester"`
+
+		// Method describes how the attester verified the address.
+		Method string `json:"method,omitempty"`
+
+		// AttestedAt is the time the attester verified the address.
+		//
+		// required: true
+		AttestedAt time.Time `json:"attested_at"`
+
+		// RecordedAt is the time the attestation was submitted to ORY Kratos.
+		//
+		// required: true
+		RecordedAt time.Time `json:"recorded_at"`
+
+		// Metadata is additional information provided by the attester.
+		Metadata json.RawMessage `json:"metadata,omitempty"`
+	}
 )
 
 func (v VerifiableAddressType) HTMLFormInputType() string {
@@ -76,6 +113,17 @@ func NewVerifiableEmailAddress(value string, identity uuid.UUID) *VerifiableAddr
 	}
 }
 
+// NewVerifiableSMSAddress returns a phone number which is verified using a code sent by SMS.
+func NewVerifiableSMSAddress(value string, identity uuid.UUID) *VerifiableAddress {
+	return &VerifiableAddress{
+		Value:      value,
+		Verified:   false,
+		Status:     VerifiableAddressStatusPending,
+		Via:        VerifiableAddressTypeSMS,
+		IdentityID: identity,
+	}
+}
+
 func (a VerifiableAddress) GetID() uuid.UUID {
 	return a.ID
 }
@@ -83,3 +131,11 @@ func (a VerifiableAddress) GetID() uuid.UUID {
 func (a VerifiableAddress) GetNID() uuid.UUID {
 	return a.NID
 }
+
+func (a *VerifiableAddressAttestation) Scan(value interface{}) error {
+	return sqlxx.JSONScan(a, value)
+}
+
+func (a VerifiableAddressAttestation) Value() (driver.Value, error) {
+	return sqlxx.JSONValue(&a)
+}
@@ -3,17 +3,24 @@ package identity
 import (
 	"context"
 	"reflect"
+	"time"
 
 	"github.com/gofrs/uuid"
 
 	"github.com/mohae/deepcopy"
 	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
 
 	"github.com/ory/herodot"
 	"github.com/ory/jsonschema/v3"
 	"github.com/ory/x/errorsx"
 
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/x"
 )
 
 var ErrProtectedFieldModified = herodot.ErrForbidden.
@@ -21,9 +28,14 @@ var ErrProtectedFieldModified = herodot.ErrForbidden.
 
 type (
 	managerDependencies interface {
+		config.Provider
 		PoolProvider
 		courier.Provider
+		hash.HashProvider
 		ValidationProvider
+		ManagerMiddlewareProvider
+		x.LoggingProvider
+		ScheduledStateChangePersistenceProvider
 	}
 	ManagementProvider interface {
 		IdentityManager() *Manager
@@ -35,6 +47,9 @@ type (
 	managerOptions struct {
 		ExposeValidationErrors    bool
 		AllowWriteProtectedTraits bool
+		EnforceEmailDomainPolicy  bool
+		EnforceReservedNames      bool
+		RejectConcurrentUpdates   bool
 	}
 
 	ManagerOption func(*managerOptions)
@@ -52,6 +67,34 @@ func ManagerAllowWriteProtectedTraits(options *managerOptions) {
 	options.AllowWriteProtectedTraits = true
 }
 
+// ManagerEnforceEmailDomainPolicy rejects email addresses whose domain the email domain policy of the identity's
+// schema does not allow. Self-service flows enforce the policy, the admin API does not.
+func ManagerEnforceEmailDomainPolicy(options *managerOptions) {
+	options.EnforceEmailDomainPolicy = true
+}
+
+// ManagerEnforceReservedNames rejects usernames and display names which are reserved at
+// `identity.reserved_names`. Self-service flows enforce the reserved names, the admin API does not.
+func ManagerEnforceReservedNames(options *managerOptions) {
+	options.EnforceReservedNames = true
+}
+
+// ManagerRejectConcurrentUpdates makes Update fail with sqlcon.ErrConcurrentUpdate if the identity was updated
+// since it was loaded, judging by its UpdatedAt. Use it for changes which must be applied at most once, for example
+// consuming a temporary password.
+func ManagerRejectConcurrentUpdates(options *managerOptions) {
+	options.RejectConcurrentUpdates = true
+}
+
+type expectedUpdatedAtContextKey struct{}
+
+// ExpectedUpdatedAt returns the time at which the identity passed to the Pool's UpdateIdentity was last updated
+// if the update must be rejected when the identity changed in the meantime.
+func ExpectedUpdatedAt(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(expectedUpdatedAtContextKey{}).(time.Time)
+	return t, ok
+}
+
 func newManagerOptions(opts []ManagerOption) *managerOptions {
 	var o managerOptions
 	for _, f := range opts {
@@ -66,7 +109,19 @@ func (m *Manager) Create(ctx context.Context, i *Identity, opts ...ManagerOption
 		return err
 	}
 
-	return m.r.IdentityPool().(PrivilegedPool).CreateIdentity(ctx, i)
+	if o.EnforceEmailDomainPolicy {
+		if err := m.r.IdentityValidator().ValidateEmailDomains(ctx, i, nil); err != nil {
+			return err
+		}
+	}
+
+	if o.EnforceReservedNames {
+		if err := m.r.IdentityValidator().ValidateReservedNames(ctx, i, nil); err != nil {
+			return err
+		}
+	}
+
+	return m.create()(ctx, i)
 }
 
 func (m *Manager) requiresPrivilegedAccess(_ context.Context, original, updated *Identity, o *managerOptions) error {
@@ -103,7 +158,28 @@ func (m *Manager) Update(ctx context.Context, updated *Identity, opts ...Manager
 		return err
 	}
 
-	return m.r.IdentityPool().(PrivilegedPool).UpdateIdentity(ctx, updated)
+	if o.EnforceEmailDomainPolicy {
+		if err := m.r.IdentityValidator().ValidateEmailDomains(ctx, updated, original); err != nil {
+			return err
+		}
+	}
+
+	if o.EnforceReservedNames {
+		if err := m.r.IdentityValidator().ValidateReservedNames(ctx, updated, original); err != nil {
+			return err
+		}
+	}
+
+	if o.RejectConcurrentUpdates {
+		ctx = context.WithValue(ctx, expectedUpdatedAtContextKey{}, updated.UpdatedAt)
+	}
+
+	if err := m.update()(ctx, updated); err != nil {
+		return err
+	}
+
+	m.auditVerifiableAddressChanges(ctx, original.VerifiableAddresses, updated)
+	return nil
 }
 
 func (m *Manager) UpdateSchemaID(ctx context.Context, id uuid.UUID, schemaID string, opts ...ManagerOption) error {
@@ -117,48 +193,402 @@ func (m *Manager) UpdateSchemaID(ctx context.Context, id uuid.UUID, schemaID str
 		return errors.WithStack(ErrProtectedFieldModified)
 	}
 
+	addresses := append([]VerifiableAddress(nil), original.VerifiableAddresses...)
 	original.SchemaID = schemaID
 	if err := m.validate(ctx, original, o); err != nil {
 		return err
 	}
 
-	return m.r.IdentityPool().(PrivilegedPool).UpdateIdentity(ctx, original)
+	if err := m.update()(ctx, original); err != nil {
+		return err
+	}
+
+	m.auditVerifiableAddressChanges(ctx, addresses, original)
+	return nil
 }
 
 func (m *Manager) SetTraits(ctx context.Context, id uuid.UUID, traits Traits, opts ...ManagerOption) (*Identity, error) {
+	_, updated, err := m.setTraits(ctx, id, traits, opts...)
+	return updated, err
+}
+
+func (m *Manager) setTraits(ctx context.Context, id uuid.UUID, traits Traits, opts ...ManagerOption) (original, updated *Identity, err error) {
 	o := newManagerOptions(opts)
-	original, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, id)
+	original, err = m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// original is used to check whether protected traits were modified
-	updated := deepcopy.Copy(original).(*Identity)
+	updated = deepcopy.Copy(original).(*Identity)
 	updated.Traits = traits
 	if err := m.validate(ctx, updated, o); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if err := m.requiresPrivilegedAccess(ctx, original, updated, o); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return updated, nil
+	return original, updated, nil
 }
 
 func (m *Manager) UpdateTraits(ctx context.Context, id uuid.UUID, traits Traits, opts ...ManagerOption) error {
-	updated, err := m.SetTraits(ctx, id, traits, opts...)
+	original, updated, err := m.setTraits(ctx, id, traits, opts...)
+	if err != nil {
+		return err
+	}
+
+	if err := m.update()(ctx, updated); err != nil {
+		return err
+	}
+
+	m.auditVerifiableAddressChanges(ctx, original.VerifiableAddresses, updated)
+	return nil
+}
+
+// auditVerifiableAddressChanges writes an audit log entry for every verifiable address which was removed or marked
+// as stale because it no longer appears in the identity's traits.
+func (m *Manager) auditVerifiableAddressChanges(ctx context.Context, before []VerifiableAddress, updated *Identity) {
+	for _, previous := range before {
+		var current *VerifiableAddress
+		for k := range updated.VerifiableAddresses {
+			if a := &updated.VerifiableAddresses[k]; a.Via == previous.Via && a.Value == previous.Value {
+				current = a
+				break
+			}
+		}
+
+		l := m.r.Audit().
+			WithField("identity_id", updated.ID).
+			WithField("via", previous.Via).
+			WithField("verified", previous.Verified).
+			WithSensitiveField("address", previous.Value)
+		if current == nil {
+			l.Info("A verifiable address was removed because it no longer appears in the identity's traits.")
+		} else if current.Status == VerifiableAddressStatusStale && previous.Status != VerifiableAddressStatusStale {
+			l.Info("A verifiable address was marked as stale because it no longer appears in the identity's traits.")
+		}
+	}
+}
+
+// Delete removes the identity together with its credentials, addresses, sessions, tokens, and continuity
+// containers. Courier messages sent to its addresses are deleted, anonymized, or kept according to
+// `identity.deletion.courier_messages`. It returns what was removed or sqlcon.ErrNoRows if the identity does not
+// exist.
+func (m *Manager) Delete(ctx context.Context, id uuid.UUID) (*DeletionReport, error) {
+	i, err := m.r.IdentityPool().GetIdentity(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := m.r.IdentityPool().(PrivilegedPool).DescribeIdentityDeletion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.delete()(ctx, id); err != nil {
+		return nil, err
+	}
+	report.DeletedAt = time.Now().UTC()
+
+	c := m.r.Config(ctx)
+	switch c.IdentityDeletionCourierMessages() {
+	case config.DeletionCourierMessagesDelete:
+		if report.CourierMessagesDeleted, err = m.r.Courier(ctx).PurgeMessages(ctx, i.Addresses()); err != nil {
+			return nil, err
+		}
+	case config.DeletionCourierMessagesAnonymize:
+		if report.CourierMessagesAnonymized, report.CourierMessagesDeleted, err = m.r.Courier(ctx).AnonymizeMessages(ctx, i.Addresses()); err != nil {
+			return nil, err
+		}
+	}
+
+	l := m.r.Audit().
+		WithField("identity_id", report.IdentityID).
+		WithField("deletion_report", report)
+	if c.IdentityDeletionAuditLog() == config.DeletionAuditLogFull {
+		l = l.WithSensitiveField("addresses", i.Addresses())
+	}
+	l.Info("An identity was deleted.")
+
+	return report, nil
+}
+
+// SetState activates or deactivates the identity. Inactive identities can not sign in and their
+// sessions can not be used.
+func (m *Manager) SetState(ctx context.Context, id uuid.UUID, state State) error {
+	if err := state.Validate(); err != nil {
+		return err
+	}
+
+	i, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	i.SetState(state)
+	return m.update()(ctx, i)
+}
+
+const scheduledStateChangesBatchSize = 500
+
+// ExecuteScheduledStateChanges changes the state of all identities with a scheduled state change which is due,
+// in the order the changes are scheduled in, and returns how many changes were executed. If a change fails,
+// the remaining changes of the batch are still executed and the first error is returned. The failed change is
+// retried the next time.
+func (m *Manager) ExecuteScheduledStateChanges(ctx context.Context) (int, error) {
+	var executed int
+	for {
+		now := time.Now().UTC()
+		changes, err := m.r.ScheduledStateChangePersister().ListDueScheduledStateChanges(ctx, now, scheduledStateChangesBatchSize)
+		if err != nil {
+			return executed, err
+		}
+
+		var firstErr error
+		for k := range changes {
+			c := &changes[k]
+			if err := m.SetState(ctx, c.IdentityID, c.State); err != nil {
+				m.r.Logger().WithError(err).
+					WithField("identity_id", c.IdentityID).
+					WithField("scheduled_state_change_id", c.ID).
+					Error("Unable to execute a scheduled identity state change.")
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+
+			if err := m.r.ScheduledStateChangePersister().MarkScheduledStateChangeExecuted(ctx, c.ID, now); err != nil {
+				return executed, err
+			}
+			executed++
+
+			m.r.Audit().
+				WithField("identity_id", c.IdentityID).
+				WithField("scheduled_state_change_id", c.ID).
+				WithField("state", c.State).
+				Info("A scheduled identity state change was executed.")
+		}
+
+		if firstErr != nil {
+			return executed, firstErr
+		} else if len(changes) < scheduledStateChangesBatchSize {
+			return executed, nil
+		}
+	}
+}
+
+// ExpirePassword forces the identity to set a new password after signing in the next time. If notify is
+// true, an email is sent to each of the identity's enabled email recovery addresses.
+func (m *Manager) ExpirePassword(ctx context.Context, id uuid.UUID, notify bool) error {
+	i, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	recipients := emailRecipients(i)
+	if notify && len(recipients) == 0 {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The identity can not be notified because it does not have any enabled email recovery addresses."))
+	}
+
+	if err := i.ExpirePassword(); err != nil {
+		return err
+	}
+
+	if err := m.update()(ctx, i); err != nil {
+		return err
+	}
+
+	if !notify {
+		return nil
+	}
+
+	for _, to := range recipients {
+		if _, err := m.r.Courier(ctx).QueueEmail(ctx, template.NewPasswordResetRequired(m.r.Config(ctx), &template.PasswordResetRequiredModel{
+			To:       to,
+			LoginURL: m.r.Config(ctx).SelfServiceFlowLoginUI().String(),
+		})); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarkCompromised responds to an account takeover. It deletes the identity's sessions and tokens and revokes
+// its API keys and password, so that only recovering the account restores access. If unlinkOIDC is true, the
+// identity's OpenID Connect providers are unlinked as well. If notify is true, an email is sent to each of the
+// identity's enabled email recovery addresses.
+func (m *Manager) MarkCompromised(ctx context.Context, id uuid.UUID, unlinkOIDC, notify bool) (*CompromiseReport, error) {
+	i, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := emailRecipients(i)
+	if notify && len(recipients) == 0 {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("The identity can not be notified because it does not have any enabled email recovery addresses."))
+	}
+
+	// Sessions are revoked first so that an attacker is signed out even if one of the later steps fails.
+	report, err := m.r.IdentityPool().(PrivilegedPool).RevokeIdentityAccess(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	report.CompromisedAt = time.Now().UTC()
+
+	if c, ok := i.GetCredentials(CredentialsTypeAPIKey); ok {
+		report.APIKeys = len(c.Identifiers)
+		delete(i.Credentials, CredentialsTypeAPIKey)
+	}
+
+	if report.PasswordRevoked, err = i.RevokePassword(); err != nil {
+		return nil, err
+	}
+
+	report.UnlinkedOIDCProviders = []string{}
+	if c, ok := i.GetCredentials(CredentialsTypeOIDC); ok && unlinkOIDC {
+		for _, p := range gjson.GetBytes(c.Config, credentialsOIDCProvidersPath+".#.provider").Array() {
+			report.UnlinkedOIDCProviders = append(report.UnlinkedOIDCProviders, p.String())
+		}
+		delete(i.Credentials, CredentialsTypeOIDC)
+	}
+
+	if err := m.update()(ctx, i); err != nil {
+		return nil, err
+	}
+
+	if notify {
+		for _, to := range recipients {
+			if _, err := m.r.Courier(ctx).QueueEmail(ctx, template.NewAccountCompromised(m.r.Config(ctx), &template.AccountCompromisedModel{
+				To:          to,
+				RecoveryURL: m.r.Config(ctx).SelfServiceFlowRecoveryUI().String(),
+			})); err != nil {
+				return nil, err
+			}
+		}
+		report.NotifiedAddresses = len(recipients)
+	}
+
+	m.r.Audit().
+		WithField("identity_id", id).
+		WithField("compromise_report", report).
+		Info("An identity was marked as compromised.")
+
+	return report, nil
+}
+
+// emailRecipients returns the values of the identity's enabled email recovery addresses.
+func emailRecipients(i *Identity) (recipients []string) {
+	for _, a := range i.EnabledRecoveryAddresses() {
+		if a.Via == RecoveryAddressTypeEmail {
+			recipients = append(recipients, a.Value)
+		}
+	}
+	return recipients
+}
+
+// SetTemporaryPassword replaces the identity's password with a temporary password which must be changed
+// after signing in with it. See Identity.SetTemporaryPassword.
+func (m *Manager) SetTemporaryPassword(ctx context.Context, id uuid.UUID, password string) error {
+	if len(password) == 0 {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The temporary password must not be empty."))
+	}
+
+	i, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	hashed, err := m.r.Hasher().Generate(ctx, []byte(password))
+	if err != nil {
+		return err
+	}
+
+	if err := i.SetTemporaryPassword(hashed); err != nil {
+		return err
+	}
+
+	if err := m.validate(ctx, i, newManagerOptions(nil)); err != nil {
+		return err
+	}
+
+	if c, ok := i.GetCredentials(CredentialsTypePassword); !ok || len(c.Identifiers) == 0 {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The identity does not have a password identifier. Check the identity schema."))
+	}
+
+	return m.update()(ctx, i)
+}
+
+// SetPassword replaces the identity's password. The password is not checked against the password policy.
+func (m *Manager) SetPassword(ctx context.Context, id uuid.UUID, password string) error {
+	if len(password) == 0 {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The password must not be empty."))
+	}
+
+	hashed, err := m.r.Hasher().Generate(ctx, []byte(password))
+	if err != nil {
+		return err
+	}
+
+	return m.setPasswordHash(ctx, id, hashed)
+}
+
+// ImportPasswordHash replaces the identity's password with a bcrypt or argon2id hash, for example one exported
+// from another system.
+func (m *Manager) ImportPasswordHash(ctx context.Context, id uuid.UUID, hashedPassword string) error {
+	if !hash.IsBcryptHash([]byte(hashedPassword)) && !hash.IsArgon2idHash([]byte(hashedPassword)) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The hashed password must be a bcrypt or argon2id hash in PHC string format."))
+	}
+
+	return m.setPasswordHash(ctx, id, []byte(hashedPassword))
+}
+
+func (m *Manager) setPasswordHash(ctx context.Context, id uuid.UUID, hashed []byte) error {
+	i, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := i.SetPassword(hashed); err != nil {
+		return err
+	}
+
+	if err := m.validate(ctx, i, newManagerOptions(nil)); err != nil {
+		return err
+	}
+
+	if c, ok := i.GetCredentials(CredentialsTypePassword); !ok || len(c.Identifiers) == 0 {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The identity does not have a password identifier. Check the identity schema."))
+	}
+
+	return m.update()(ctx, i)
+}
+
+// SetOIDCProviders replaces the identity's linked OpenID Connect providers. See Identity.SetOIDCProviders.
+func (m *Manager) SetOIDCProviders(ctx context.Context, id uuid.UUID, providers []OIDCProvider) error {
+	i, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	return m.r.IdentityPool().(PrivilegedPool).UpdateIdentity(ctx, updated)
+	if err := i.SetOIDCProviders(providers); err != nil {
+		return err
+	}
+
+	if err := m.validate(ctx, i, newManagerOptions(nil)); err != nil {
+		return err
+	}
+
+	return m.update()(ctx, i)
 }
 
 func (m *Manager) validate(ctx context.Context, i *Identity, o *managerOptions) error {
 	if err := m.r.IdentityValidator().Validate(ctx, i); err != nil {
 		if _, ok := errorsx.Cause(err).(*jsonschema.ValidationError); ok && !o.ExposeValidationErrors {
-			return herodot.ErrBadRequest.WithReasonf("%s", err).WithWrap(err)
+			return herodot.ErrBadRequest.WithReasonf("%s", err).WithDetail("validation_errors", schema.FieldErrors(err)).WithWrap(err)
 		}
 		return err
 	}
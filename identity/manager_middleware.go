@@ -0,0 +1,71 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+)
+
+type (
+	// ManagerCreateFunc persists a new, already validated identity.
+	ManagerCreateFunc func(ctx context.Context, i *Identity) error
+
+	// ManagerUpdateFunc persists changes to an existing, already validated identity.
+	ManagerUpdateFunc func(ctx context.Context, i *Identity) error
+
+	// ManagerDeleteFunc deletes an identity.
+	ManagerDeleteFunc func(ctx context.Context, id uuid.UUID) error
+
+	// ManagerMiddleware allows programs embedding Kratos to wrap the write operations of the
+	// identity Manager, for example to add custom validation or to write identities to a
+	// legacy system as well.
+	//
+	// Each function receives the next step in the chain and returns the function which replaces it.
+	// Returning an error without calling next aborts the operation. The identity passed to Create and
+	// Update has already passed JSON Schema validation. Fields left nil are skipped.
+	//
+	// Middlewares are registered using the registry's WithIdentityManagerMiddleware method. The first
+	// registered middleware is the outermost one.
+	ManagerMiddleware struct {
+		Create func(next ManagerCreateFunc) ManagerCreateFunc
+		Update func(next ManagerUpdateFunc) ManagerUpdateFunc
+		Delete func(next ManagerDeleteFunc) ManagerDeleteFunc
+	}
+
+	ManagerMiddlewareProvider interface {
+		IdentityManagerMiddlewares() []ManagerMiddleware
+	}
+)
+
+func (m *Manager) create() ManagerCreateFunc {
+	f := m.r.IdentityPool().(PrivilegedPool).CreateIdentity
+	mws := m.r.IdentityManagerMiddlewares()
+	for k := len(mws) - 1; k >= 0; k-- {
+		if mws[k].Create != nil {
+			f = mws[k].Create(f)
+		}
+	}
+	return f
+}
+
+func (m *Manager) update() ManagerUpdateFunc {
+	f := m.r.IdentityPool().(PrivilegedPool).UpdateIdentity
+	mws := m.r.IdentityManagerMiddlewares()
+	for k := len(mws) - 1; k >= 0; k-- {
+		if mws[k].Update != nil {
+			f = mws[k].Update(f)
+		}
+	}
+	return f
+}
+
+func (m *Manager) delete() ManagerDeleteFunc {
+	f := m.r.IdentityPool().(PrivilegedPool).DeleteIdentity
+	mws := m.r.IdentityManagerMiddlewares()
+	for k := len(mws) - 1; k >= 0; k-- {
+		if mws[k].Delete != nil {
+			f = mws[k].Delete(f)
+		}
+	}
+	return f
+}
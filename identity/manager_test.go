@@ -3,15 +3,29 @@ package identity_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/courier/template"
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
 
@@ -149,6 +163,21 @@ func TestManager(t *testing.T) {
 			}
 			require.True(t, foundVerifiableAddress)
 		})
+
+		t.Run("case=should reject stale updates with option", func(t *testing.T) {
+			original := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			original.Traits = newTraits(x.NewUUID().String()+"@ory.sh", "")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), original))
+
+			first, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), original.ID)
+			require.NoError(t, err)
+			second, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), original.ID)
+			require.NoError(t, err)
+
+			require.NoError(t, reg.IdentityManager().Update(context.Background(), first, identity.ManagerAllowWriteProtectedTraits, identity.ManagerRejectConcurrentUpdates))
+			require.ErrorIs(t, reg.IdentityManager().Update(context.Background(), second, identity.ManagerAllowWriteProtectedTraits, identity.ManagerRejectConcurrentUpdates), sqlcon.ErrConcurrentUpdate)
+			require.NoError(t, reg.IdentityManager().Update(context.Background(), second, identity.ManagerAllowWriteProtectedTraits), "updates without the option still apply")
+		})
 	})
 
 	t.Run("method=UpdateTraits", func(t *testing.T) {
@@ -203,4 +232,370 @@ func TestManager(t *testing.T) {
 			checkExtensionFields(fromStore, "email-updatetraits-1@ory.sh")(t)
 		})
 	})
+
+	t.Run("method=Delete", func(t *testing.T) {
+		ctx := context.Background()
+		for k, tc := range []struct {
+			policy     config.DeletionCourierMessagesPolicy
+			deleted    int
+			anonymized int
+			remaining  []string
+		}{
+			{policy: config.DeletionCourierMessagesDelete, deleted: 2, remaining: []string{"email-delete-other@ory.sh"}},
+			{policy: config.DeletionCourierMessagesAnonymize, deleted: 1, anonymized: 1, remaining: []string{"", "email-delete-other@ory.sh"}},
+			{policy: config.DeletionCourierMessagesKeep, remaining: []string{"email-delete-2@ory.sh", "email-delete-2@ory.sh", "email-delete-other@ory.sh"}},
+		} {
+			t.Run("policy="+string(tc.policy), func(t *testing.T) {
+				conf.MustSet(config.ViperKeyIdentityDeletionCourierMessages, string(tc.policy))
+				t.Cleanup(func() {
+					conf.MustSet(config.ViperKeyIdentityDeletionCourierMessages, string(config.DeletionCourierMessagesDelete))
+				})
+
+				email := fmt.Sprintf("email-delete-%d@ory.sh", k)
+				original := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+				original.Traits = newTraits(email, "")
+				require.NoError(t, reg.IdentityManager().Create(ctx, original))
+
+				require.NoError(t, reg.SessionPersister().CreateSession(ctx, session.NewActiveSession(original, conf, time.Now().UTC())))
+				require.NoError(t, reg.RecoveryTokenPersister().CreateRecoveryToken(ctx, link.NewRecoveryToken(&original.RecoveryAddresses[0], time.Now().UTC(), time.Hour)))
+
+				var queued []uuid.UUID
+				for _, to := range []string{strings.ToUpper(email), email, "email-delete-other@ory.sh"} {
+					id, err := reg.Courier(ctx).QueueEmail(ctx, template.NewTestStub(conf, &template.TestStubModel{To: to, Subject: "delete", Body: "delete"}))
+					require.NoError(t, err)
+					queued = append(queued, id)
+				}
+				require.NoError(t, reg.CourierPersister().SetMessageStatus(ctx, queued[1], courier.MessageStatusSent))
+
+				report, err := reg.IdentityManager().Delete(ctx, original.ID)
+				require.NoError(t, err)
+				assert.Equal(t, original.ID, report.IdentityID)
+				assert.False(t, report.DeletedAt.IsZero())
+				assert.Equal(t, 1, report.Credentials)
+				assert.Equal(t, 1, report.VerifiableAddresses)
+				assert.Equal(t, 1, report.RecoveryAddresses)
+				assert.Equal(t, 1, report.Sessions)
+				assert.Equal(t, 1, report.RecoveryTokens)
+				assert.Equal(t, tc.deleted, report.CourierMessagesDeleted)
+				assert.Equal(t, tc.anonymized, report.CourierMessagesAnonymized)
+
+				_, err = reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, original.ID)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				var messages []courier.Message
+				require.NoError(t, reg.Persister().GetConnection(ctx).Where("id IN (?)", queued[0], queued[1], queued[2]).All(&messages))
+				var recipients []string
+				for _, m := range messages {
+					recipients = append(recipients, strings.ToLower(m.Recipient))
+				}
+				assert.ElementsMatch(t, tc.remaining, recipients)
+			})
+		}
+
+		t.Run("case=should fail if the identity does not exist", func(t *testing.T) {
+			_, err := reg.IdentityManager().Delete(ctx, x.NewUUID())
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+	})
+
+	t.Run("method=MarkCompromised", func(t *testing.T) {
+		ctx := context.Background()
+		create := func(t *testing.T, email string) *identity.Identity {
+			i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.Traits = newTraits(email, "")
+			i.Credentials = map[identity.CredentialsType]identity.Credentials{
+				identity.CredentialsTypePassword: {Type: identity.CredentialsTypePassword, Identifiers: []string{email}, Config: []byte(`{"hashed_password":"foo"}`)},
+				identity.CredentialsTypeOIDC:     {Type: identity.CredentialsTypeOIDC, Identifiers: []string{"github:" + email}, Config: []byte(`{"providers":[{"provider":"github","subject":"` + email + `"}]}`)},
+				identity.CredentialsTypeAPIKey:   {Type: identity.CredentialsTypeAPIKey, Identifiers: []string{"key-" + email}, Config: []byte(`{}`)},
+			}
+			require.NoError(t, reg.IdentityManager().Create(ctx, i))
+			require.NoError(t, reg.SessionPersister().CreateSession(ctx, session.NewActiveSession(i, conf, time.Now().UTC())))
+			require.NoError(t, reg.SessionPersister().CreateSession(ctx, session.NewActiveSession(i, conf, time.Now().UTC())))
+			require.NoError(t, reg.RecoveryTokenPersister().CreateRecoveryToken(ctx, link.NewRecoveryToken(&i.RecoveryAddresses[0], time.Now().UTC(), time.Hour)))
+			return i
+		}
+
+		t.Run("case=should revoke access", func(t *testing.T) {
+			email := "email-compromised-1@ory.sh"
+			original := create(t, email)
+			other := create(t, "email-compromised-other@ory.sh")
+
+			report, err := reg.IdentityManager().MarkCompromised(ctx, original.ID, true, true)
+			require.NoError(t, err)
+			assert.Equal(t, original.ID, report.IdentityID)
+			assert.False(t, report.CompromisedAt.IsZero())
+			assert.Equal(t, 2, report.Sessions)
+			assert.Equal(t, 1, report.RecoveryTokens)
+			assert.Equal(t, 1, report.APIKeys)
+			assert.True(t, report.PasswordRevoked)
+			assert.Equal(t, []string{"github"}, report.UnlinkedOIDCProviders)
+			assert.Equal(t, 1, report.NotifiedAddresses)
+
+			actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, original.ID)
+			require.NoError(t, err)
+			c, ok := actual.GetCredentials(identity.CredentialsTypePassword)
+			require.True(t, ok, "the password identifiers are kept")
+			assert.Equal(t, []string{email}, c.Identifiers)
+			assert.Error(t, actual.ConsumeTemporaryPassword(), "the password can not be used to sign in")
+			_, ok = actual.GetCredentials(identity.CredentialsTypeOIDC)
+			assert.False(t, ok)
+			_, ok = actual.GetCredentials(identity.CredentialsTypeAPIKey)
+			assert.False(t, ok)
+
+			var sessions int
+			sessions, err = reg.Persister().GetConnection(ctx).Where("identity_id = ?", original.ID).Count(new(session.Session))
+			require.NoError(t, err)
+			assert.Zero(t, sessions)
+			sessions, err = reg.Persister().GetConnection(ctx).Where("identity_id = ?", other.ID).Count(new(session.Session))
+			require.NoError(t, err)
+			assert.Equal(t, 2, sessions, "the sessions of other identities are kept")
+
+			var messages []courier.Message
+			require.NoError(t, reg.Persister().GetConnection(ctx).Where("recipient = ? AND template_type = ?", email, courier.TypeAccountCompromised).All(&messages))
+			assert.Len(t, messages, 1)
+		})
+
+		t.Run("case=should keep oidc providers", func(t *testing.T) {
+			original := create(t, "email-compromised-2@ory.sh")
+
+			report, err := reg.IdentityManager().MarkCompromised(ctx, original.ID, false, false)
+			require.NoError(t, err)
+			assert.Empty(t, report.UnlinkedOIDCProviders)
+			assert.Zero(t, report.NotifiedAddresses)
+
+			actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, original.ID)
+			require.NoError(t, err)
+			_, ok := actual.GetCredentials(identity.CredentialsTypeOIDC)
+			assert.True(t, ok)
+		})
+
+		t.Run("case=should fail if the identity does not exist", func(t *testing.T) {
+			_, err := reg.IdentityManager().MarkCompromised(ctx, x.NewUUID(), false, false)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+	})
+
+	t.Run("option=EnforceEmailDomainPolicy", func(t *testing.T) {
+		ctx := context.Background()
+		conf.MustSet(config.ViperKeyIdentityEmailDomains+".deny", []string{"example.org"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentityEmailDomains, nil)
+		})
+
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = newTraits("email-domain@example.org", "")
+		err := reg.IdentityManager().Create(ctx, i, identity.ManagerEnforceEmailDomainPolicy)
+		var ve *schema.ValidationError
+		require.ErrorAs(t, err, &ve)
+		assert.Equal(t, "#/traits/email", ve.InstancePtr)
+		assert.Equal(t, text.ErrorValidationEmailDomainRejected, ve.Messages[0].ID)
+
+		require.NoError(t, reg.IdentityManager().Create(ctx, i), "the admin API does not enforce the policy")
+
+		i.Traits = newTraits("email-domain@example.org", "changed")
+		require.NoError(t, reg.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits, identity.ManagerEnforceEmailDomainPolicy), "addresses the identity already uses are accepted")
+
+		i.Traits = newTraits("email-domain@sub.example.org", "")
+		require.ErrorAs(t, reg.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits, identity.ManagerEnforceEmailDomainPolicy), &ve)
+
+		i.Traits = newTraits("email-domain@ory.sh", "")
+		require.NoError(t, reg.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits, identity.ManagerEnforceEmailDomainPolicy))
+	})
+
+	t.Run("option=EnforceReservedNames", func(t *testing.T) {
+		ctx := context.Background()
+		conf.MustSet(config.ViperKeyIdentitySchemas, []config.Schema{{ID: "profile", URL: "file://./stub/handler/profile.schema.json"}})
+		conf.MustSet(config.ViperKeyIdentityReservedNamesWords, []string{"admin"})
+		conf.MustSet(config.ViperKeyIdentityReservedNamesPatterns, []string{"^support"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentitySchemas, nil)
+			conf.MustSet(config.ViperKeyIdentityReservedNamesWords, nil)
+			conf.MustSet(config.ViperKeyIdentityReservedNamesPatterns, nil)
+		})
+
+		i := identity.NewIdentity("profile")
+		i.Traits = identity.Traits(`{"email":"reserved-name@ory.sh","name":{"nickname":"Ad.Min"}}`)
+		err := reg.IdentityManager().Create(ctx, i, identity.ManagerEnforceReservedNames)
+		var ve *schema.ValidationError
+		require.ErrorAs(t, err, &ve)
+		assert.Equal(t, "#/traits/name/nickname", ve.InstancePtr)
+		assert.Equal(t, text.ErrorValidationNameReserved, ve.Messages[0].ID)
+
+		require.NoError(t, reg.IdentityManager().Create(ctx, i), "the admin API does not enforce reserved names")
+
+		i.Traits = identity.Traits(`{"email":"reserved-name@ory.sh","name":{"nickname":"Ad.Min","full":"Jane Doe"}}`)
+		require.NoError(t, reg.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits, identity.ManagerEnforceReservedNames), "names the identity already uses are accepted")
+
+		i.Traits = identity.Traits(`{"email":"reserved-name@ory.sh","name":{"nickname":"Ad.Min","full":"Support Team"}}`)
+		require.ErrorAs(t, reg.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits, identity.ManagerEnforceReservedNames), &ve)
+		assert.Equal(t, "#/traits/name/full", ve.InstancePtr)
+	})
+
+	t.Run("method=ExecuteScheduledStateChanges", func(t *testing.T) {
+		ctx := context.Background()
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = newTraits("scheduled-state-change@ory.sh", "")
+		require.NoError(t, reg.IdentityManager().Create(ctx, i))
+
+		due := &identity.ScheduledStateChange{IdentityID: i.ID, State: identity.StateInactive, ExecuteAt: time.Now().UTC().Add(-time.Minute)}
+		pending := &identity.ScheduledStateChange{IdentityID: i.ID, State: identity.StateActive, ExecuteAt: time.Now().UTC().Add(time.Hour)}
+		require.NoError(t, reg.ScheduledStateChangePersister().CreateScheduledStateChange(ctx, due))
+		require.NoError(t, reg.ScheduledStateChangePersister().CreateScheduledStateChange(ctx, pending))
+
+		executed, err := reg.IdentityManager().ExecuteScheduledStateChanges(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, executed)
+
+		actual, err := reg.IdentityPool().GetIdentity(ctx, i.ID)
+		require.NoError(t, err)
+		assert.Equal(t, identity.StateInactive, actual.State)
+
+		change, err := reg.ScheduledStateChangePersister().GetScheduledStateChange(ctx, due.ID)
+		require.NoError(t, err)
+		assert.False(t, change.IsPending())
+
+		change, err = reg.ScheduledStateChangePersister().GetScheduledStateChange(ctx, pending.ID)
+		require.NoError(t, err)
+		assert.True(t, change.IsPending())
+
+		executed, err = reg.IdentityManager().ExecuteScheduledStateChanges(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, executed, "executed changes are not executed again")
+	})
+}
+
+func TestManagerMiddleware(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/manager.schema.json")
+
+	var calls []string
+	errRejected := errors.New("rejected by middleware")
+	trace := func(name string) identity.ManagerMiddleware {
+		return identity.ManagerMiddleware{
+			Create: func(next identity.ManagerCreateFunc) identity.ManagerCreateFunc {
+				return func(ctx context.Context, i *identity.Identity) error {
+					calls = append(calls, name+":create")
+					if gjson.GetBytes(i.Traits, "unprotected").String() == "reject" {
+						return errRejected
+					}
+					return next(ctx, i)
+				}
+			},
+			Update: func(next identity.ManagerUpdateFunc) identity.ManagerUpdateFunc {
+				return func(ctx context.Context, i *identity.Identity) error {
+					calls = append(calls, name+":update")
+					return next(ctx, i)
+				}
+			},
+			Delete: func(next identity.ManagerDeleteFunc) identity.ManagerDeleteFunc {
+				return func(ctx context.Context, id uuid.UUID) error {
+					calls = append(calls, name+":delete")
+					return next(ctx, id)
+				}
+			},
+		}
+	}
+	reg.WithIdentityManagerMiddleware(trace("outer"), identity.ManagerMiddleware{}, trace("inner"))
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"email":"middleware@ory.sh"}`)
+	require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+	require.NoError(t, reg.IdentityManager().Update(context.Background(), i, identity.ManagerAllowWriteProtectedTraits))
+	_, err := reg.IdentityManager().Delete(context.Background(), i.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"outer:create", "inner:create",
+		"outer:update", "inner:update",
+		"outer:delete", "inner:delete",
+	}, calls)
+
+	_, err = reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+	require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+	t.Run("case=middleware aborts the operation", func(t *testing.T) {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"email":"middleware-rejected@ory.sh","unprotected":"reject"}`)
+		require.ErrorIs(t, reg.IdentityManager().Create(context.Background(), i), errRejected)
+
+		_, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+		require.ErrorIs(t, err, sqlcon.ErrNoRows)
+	})
+}
+
+func TestManagerVerifiableAddressMergePolicy(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/manager.schema.json")
+
+	hook := new(test.Hook)
+	reg.WithLogger(logrusx.New("", "", logrusx.WithHook(hook)))
+
+	for _, tc := range []struct {
+		policy   config.VerifiableAddressMergePolicy
+		expected map[string]identity.VerifiableAddressStatus
+		message  string
+	}{
+		{
+			policy:   config.VerifiableAddressMergeReplace,
+			expected: map[string]identity.VerifiableAddressStatus{"merge-new@ory.sh": identity.VerifiableAddressStatusPending},
+			message:  "A verifiable address was removed because it no longer appears in the identity's traits.",
+		},
+		{
+			policy: config.VerifiableAddressMergeKeep,
+			expected: map[string]identity.VerifiableAddressStatus{
+				"merge-new@ory.sh": identity.VerifiableAddressStatusPending,
+				"merge-old@ory.sh": identity.VerifiableAddressStatusCompleted,
+			},
+		},
+		{
+			policy: config.VerifiableAddressMergeMarkStale,
+			expected: map[string]identity.VerifiableAddressStatus{
+				"merge-new@ory.sh": identity.VerifiableAddressStatusPending,
+				"merge-old@ory.sh": identity.VerifiableAddressStatusStale,
+			},
+			message: "A verifiable address was marked as stale because it no longer appears in the identity's traits.",
+		},
+	} {
+		t.Run("policy="+string(tc.policy), func(t *testing.T) {
+			conf.MustSet(config.ViperKeyIdentityVerifiableAddressesMergePolicy, string(tc.policy))
+			hook.Reset()
+
+			email := "merge-" + string(tc.policy) + "@ory.sh"
+			i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.Traits = identity.Traits(`{"email":"` + email + `","email_verify":"merge-old@ory.sh"}`)
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+
+			require.Len(t, i.VerifiableAddresses, 1)
+			i.VerifiableAddresses[0].Verified = true
+			i.VerifiableAddresses[0].Status = identity.VerifiableAddressStatusCompleted
+			require.NoError(t, reg.PrivilegedIdentityPool().UpdateVerifiableAddress(context.Background(), &i.VerifiableAddresses[0]))
+
+			require.NoError(t, reg.IdentityManager().UpdateTraits(context.Background(), i.ID,
+				identity.Traits(`{"email":"`+email+`","email_verify":"merge-new@ory.sh"}`), identity.ManagerAllowWriteProtectedTraits))
+
+			fromStore, err := reg.PrivilegedIdentityPool().GetIdentity(context.Background(), i.ID)
+			require.NoError(t, err)
+			actual := make(map[string]identity.VerifiableAddressStatus)
+			for _, a := range fromStore.VerifiableAddresses {
+				actual[a.Value] = a.Status
+			}
+			assert.Equal(t, tc.expected, actual)
+
+			var messages []string
+			for _, e := range hook.AllEntries() {
+				if e.Data["audience"] == "audit" {
+					messages = append(messages, e.Message)
+					assert.Equal(t, i.ID, e.Data["identity_id"])
+				}
+			}
+			if tc.message == "" {
+				assert.Empty(t, messages)
+			} else {
+				assert.Equal(t, []string{tc.message}, messages)
+			}
+
+			// Cleaning up is required because kept addresses remain reserved for the identity.
+			_, err = reg.IdentityManager().Delete(context.Background(), i.ID)
+			require.NoError(t, err)
+		})
+	}
 }
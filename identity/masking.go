@@ -0,0 +1,117 @@
+package identity
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/herodot"
+	"github.com/ory/jsonschema/v3"
+	"github.com/ory/x/jsonschemax"
+
+	"github.com/ory/kratos/schema"
+)
+
+// MaskedTraitValue replaces string values of sensitive traits in admin API responses.
+const MaskedTraitValue = "***"
+
+var ErrUnmaskForbidden = herodot.ErrForbidden.
+	WithReason("Reading unmasked traits requires a scope which was not granted to this request.")
+
+// SensitiveTraitPaths returns the paths of all traits, such as `email` or `name.last`, which are marked
+// with `"ory.sh/kratos": {"sensitive": true}` in the identity schema located at url.
+func SensitiveTraitPaths(url string) ([]string, error) {
+	return markedTraitPaths(url, schema.SensitiveProperty)
+}
+
+// markedTraitPaths returns the paths of all traits for which the jsonschemax.Path custom property is true.
+func markedTraitPaths(url string, property string) ([]string, error) {
+	runner, err := schema.NewExtensionRunner(schema.ExtensionRunnerIdentityMetaSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	c := jsonschema.NewCompiler()
+	runner.Register(c)
+
+	paths, err := jsonschemax.ListPaths(url, c)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to list the traits of identity schema %s: %s", url, err))
+	}
+
+	var marked []string
+	for _, p := range paths {
+		if ok, _ := p.CustomProperties[property].(bool); !ok {
+			continue
+		}
+		if name := strings.TrimPrefix(p.Name, "traits."); name != p.Name {
+			marked = append(marked, name)
+		}
+	}
+	return marked, nil
+}
+
+// MaskTraits replaces the values at the given trait paths. Strings become MaskedTraitValue, arrays and
+// objects are masked element by element, and all other values become null. Verifiable and recovery
+// addresses whose value appears in a masked trait are masked as well.
+func MaskTraits(i *Identity, paths []string) error {
+	masked := make(map[string]bool)
+	traits := []byte(i.Traits)
+	for _, p := range paths {
+		value := gjson.GetBytes(traits, p)
+		if !value.Exists() {
+			continue
+		}
+
+		raw, err := json.Marshal(maskValue(value.Value(), masked))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if traits, err = sjson.SetRawBytes(traits, p, raw); err != nil {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Unable to mask trait "%s": %s`, p, err))
+		}
+	}
+	i.Traits = traits
+
+	for k := range i.VerifiableAddresses {
+		if masked[strings.ToLower(i.VerifiableAddresses[k].Value)] {
+			i.VerifiableAddresses[k].Value = MaskedTraitValue
+		}
+	}
+	for k := range i.RecoveryAddresses {
+		if masked[strings.ToLower(i.RecoveryAddresses[k].Value)] {
+			i.RecoveryAddresses[k].Value = MaskedTraitValue
+		}
+	}
+	return nil
+}
+
+func maskValue(v interface{}, masked map[string]bool) interface{} {
+	switch t := v.(type) {
+	case string:
+		masked[strings.ToLower(t)] = true
+		return MaskedTraitValue
+	case []interface{}:
+		for k := range t {
+			t[k] = maskValue(t[k], masked)
+		}
+		return t
+	case map[string]interface{}:
+		for k := range t {
+			t[k] = maskValue(t[k], masked)
+		}
+		return t
+	default:
+		return nil
+	}
+}
+
+// UnmaskRequested returns true if the `unmask` query parameter is set to true.
+func UnmaskRequested(r *http.Request) bool {
+	return r.URL.Query().Get("unmask") == "true"
+}
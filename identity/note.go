@@ -0,0 +1,101 @@
+package identity
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/corp"
+)
+
+const (
+	maxNoteAuthorLength = 255
+	maxNoteTextLength   = 4096
+)
+
+type (
+	// Note is a note administrators recorded about an identity, for example how its ownership was verified
+	// by support. Notes are kept apart from the identity's traits and metadata and are never shown to the
+	// identity itself.
+	//
+	// swagger:model identityNote
+	Note struct {
+		// ID is the note's unique identifier.
+		//
+		// required: true
+		ID  uuid.UUID `json:"id" faker:"-" db:"id"`
+		NID uuid.UUID `json:"-" faker:"-" db:"nid"`
+
+		// IdentityID is the ID of the identity the note is about.
+		//
+		// required: true
+		IdentityID uuid.UUID `json:"identity_id" faker:"-" db:"identity_id"`
+
+		// Author names whoever wrote the note, for example the email address of a support agent.
+		//
+		// required: true
+		Author string `json:"author" db:"author"`
+
+		// Text is the content of the note.
+		//
+		// required: true
+		Text string `json:"text" db:"text"`
+
+		// CreatedAt is the time at which the note was written.
+		//
+		// required: true
+		CreatedAt time.Time `json:"created_at" faker:"-" db:"created_at"`
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+	}
+
+	NotePersister interface {
+		// CreateIdentityNote adds a note to an identity.
+		CreateIdentityNote(ctx context.Context, n *Note) error
+
+		// ListIdentityNotes returns the notes about an identity, most recent first.
+		ListIdentityNotes(ctx context.Context, identityID uuid.UUID, page, itemsPerPage int) ([]Note, error)
+
+		// CountIdentityNotes returns the number of notes about an identity.
+		CountIdentityNotes(ctx context.Context, identityID uuid.UUID) (int64, error)
+	}
+
+	NotePersistenceProvider interface {
+		IdentityNotePersister() NotePersister
+	}
+)
+
+func (n Note) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "identity_notes")
+}
+
+func (n *Note) GetID() uuid.UUID {
+	return n.ID
+}
+
+func (n *Note) GetNID() uuid.UUID {
+	return n.NID
+}
+
+// Validate returns a bad request error if the note has no author or text or if either is too long.
+func (n *Note) Validate() error {
+	if strings.TrimSpace(n.Author) == "" {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The note's author must not be empty."))
+	} else if utf8.RuneCountInString(n.Author) > maxNoteAuthorLength {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("The note's author must not be longer than %d characters.", maxNoteAuthorLength))
+	}
+
+	if strings.TrimSpace(n.Text) == "" {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The note's text must not be empty."))
+	} else if utf8.RuneCountInString(n.Text) > maxNoteTextLength {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("The note's text must not be longer than %d characters.", maxNoteTextLength))
+	}
+
+	return nil
+}
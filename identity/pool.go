@@ -8,7 +8,7 @@ import (
 
 type (
 	Pool interface {
-		// ListIdentities lists all identities in the store given the page and itemsPerPage.
+		// ListIdentities lists all identities in the store given the zero-based page and itemsPerPage.
 		ListIdentities(ctx context.Context, page, itemsPerPage int) ([]Identity, error)
 
 		// CountIdentities counts the number of identities in the store.
@@ -21,7 +21,8 @@ type (
 		// FindVerifiableAddressByValue returns a matching address or sql.ErrNoRows if no address could be found.
 		FindVerifiableAddressByValue(ctx context.Context, via VerifiableAddressType, address string) (*VerifiableAddress, error)
 
-		// FindRecoveryAddressByValue returns a matching address or sql.ErrNoRows if no address could be found.
+		// FindRecoveryAddressByValue returns a matching address or sql.ErrNoRows if no address could be found. Disabled
+		// addresses are ignored.
 		FindRecoveryAddressByValue(ctx context.Context, via RecoveryAddressType, address string) (*RecoveryAddress, error)
 	}
 
@@ -39,13 +40,31 @@ type (
 		// FindByCredentialsIdentifier returns an identity by querying for it's credential identifiers.
 		FindByCredentialsIdentifier(ctx context.Context, ct CredentialsType, match string) (*Identity, *Credentials, error)
 
+		// FindCredentialsByIdentifiers returns the credentials of all types the identifiers belong to. Identifiers
+		// of credentials types which are matched case-insensitively by FindByCredentialsIdentifier are matched
+		// case-insensitively as well. Identifiers which belong to no credentials are omitted.
+		FindCredentialsByIdentifiers(ctx context.Context, identifiers []string) ([]KnownCredentials, error)
+
 		// DeleteIdentity removes an identity by its id. Will return an error
 		// if identity exists, backend connectivity is broken, or trait validation fails.
 		DeleteIdentity(context.Context, uuid.UUID) error
 
+		// DescribeIdentityDeletion counts the records which are deleted together with the identity. Courier
+		// messages are not counted. Will return sqlcon.ErrNoRows if the identity does not exist.
+		DescribeIdentityDeletion(ctx context.Context, id uuid.UUID) (*DeletionReport, error)
+
+		// RevokeIdentityAccess deletes the identity's sessions and the verification and recovery tokens of its
+		// addresses and returns how many were deleted. Will return sqlcon.ErrNoRows if the identity does not exist.
+		RevokeIdentityAccess(ctx context.Context, id uuid.UUID) (*CompromiseReport, error)
+
 		// UpdateVerifiableAddress updates an identity's verifiable address.
 		UpdateVerifiableAddress(ctx context.Context, address *VerifiableAddress) error
 
+		// CreateVerifiableAddress adds an address to an existing identity without touching its other addresses. Will
+		// return sqlcon.ErrNoRows if the identity does not exist and sqlcon.ErrUniqueViolation if the address is
+		// already tracked.
+		CreateVerifiableAddress(ctx context.Context, address *VerifiableAddress) error
+
 		// CreateIdentity creates an identity. It is capable of setting credentials without encoding. Will return an error
 		// if identity exists, backend connectivity is broken, or trait validation fails.
 		CreateIdentity(context.Context, *Identity) error
@@ -0,0 +1,79 @@
+package identity
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/schema"
+)
+
+// ProfileTraitPaths are the paths of the traits which hold the identity's display name and avatar URL. The traits are
+// marked with `"ory.sh/kratos": {"profile": {"display_name": true}}` and `"ory.sh/kratos": {"profile": {"avatar": true}}`.
+// If more than one trait is marked, the first one which is set is used, in the alphabetical order of the paths.
+type ProfileTraitPaths struct {
+	DisplayName []string
+	Avatar      []string
+}
+
+// GetProfileTraitPaths returns the profile trait paths of the identity schema located at url.
+func GetProfileTraitPaths(url string) (*ProfileTraitPaths, error) {
+	displayName, err := markedTraitPaths(url, schema.DisplayNameProperty)
+	if err != nil {
+		return nil, err
+	}
+
+	avatar, err := markedTraitPaths(url, schema.AvatarProperty)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProfileTraitPaths{DisplayName: displayName, Avatar: avatar}, nil
+}
+
+// ResolveProfile sets the identity's display name and avatar URL. The display name falls back to the local part
+// of the identity's first address and then to its ID. The avatar URL has no fallback.
+func (i *Identity) ResolveProfile(paths *ProfileTraitPaths) {
+	i.DisplayName = firstTrait(i.Traits, paths.DisplayName)
+	if i.DisplayName == "" {
+		if addresses := i.Addresses(); len(addresses) > 0 {
+			i.DisplayName = strings.SplitN(addresses[0], "@", 2)[0]
+		}
+	}
+	if i.DisplayName == "" {
+		i.DisplayName = i.ID.String()
+	}
+
+	i.AvatarURL = firstTrait(i.Traits, paths.Avatar)
+}
+
+// ResolveProfiles resolves the display name and avatar URL of the identities. The schemas are parsed once per
+// schema ID.
+func ResolveProfiles(schemas schema.Schemas, is ...*Identity) error {
+	paths := make(map[string]*ProfileTraitPaths)
+	for _, i := range is {
+		p, ok := paths[i.SchemaID]
+		if !ok {
+			s, err := schemas.GetByID(i.SchemaID)
+			if err != nil {
+				return err
+			}
+			if p, err = GetProfileTraitPaths(s.URL.String()); err != nil {
+				return err
+			}
+			paths[i.SchemaID] = p
+		}
+
+		i.ResolveProfile(p)
+	}
+	return nil
+}
+
+func firstTrait(traits Traits, paths []string) string {
+	for _, p := range paths {
+		if v := gjson.GetBytes(traits, p); v.Type == gjson.String && v.String() != "" {
+			return v.String()
+		}
+	}
+	return ""
+}
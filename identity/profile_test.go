@@ -0,0 +1,68 @@
+package identity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/x"
+)
+
+func TestGetProfileTraitPaths(t *testing.T) {
+	paths, err := GetProfileTraitPaths("file://./stub/handler/profile.schema.json")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name.full", "name.nickname"}, paths.DisplayName)
+	assert.Equal(t, []string{"picture"}, paths.Avatar)
+
+	paths, err = GetProfileTraitPaths("file://./stub/identity.schema.json")
+	require.NoError(t, err)
+	assert.Empty(t, paths.DisplayName)
+	assert.Empty(t, paths.Avatar)
+}
+
+func TestResolveProfile(t *testing.T) {
+	paths := &ProfileTraitPaths{DisplayName: []string{"name.full", "name.nickname"}, Avatar: []string{"picture"}}
+	id := x.NewUUID()
+	for k, tc := range []struct {
+		traits      string
+		addresses   []VerifiableAddress
+		recovery    []RecoveryAddress
+		displayName string
+		avatarURL   string
+	}{
+		{
+			traits:      `{"name":{"full":"Foo Bar","nickname":"Foo"},"picture":"https://www.ory.sh/foo.png"}`,
+			displayName: "Foo Bar",
+			avatarURL:   "https://www.ory.sh/foo.png",
+		},
+		{
+			traits:      `{"name":{"full":"","nickname":"Foo"}}`,
+			addresses:   []VerifiableAddress{{Value: "foo.bar@ory.sh"}},
+			displayName: "Foo",
+		},
+		{
+			traits:      `{"name":{"full":42},"picture":{"url":"https://www.ory.sh/foo.png"}}`,
+			addresses:   []VerifiableAddress{{Value: "foo.bar@ory.sh"}},
+			recovery:    []RecoveryAddress{{Value: "recovery@ory.sh"}},
+			displayName: "foo.bar",
+		},
+		{
+			traits:      `{}`,
+			recovery:    []RecoveryAddress{{Value: "recovery@ory.sh"}},
+			displayName: "recovery",
+		},
+		{
+			traits:      `{}`,
+			displayName: id.String(),
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			i := &Identity{ID: id, Traits: Traits(tc.traits), VerifiableAddresses: tc.addresses, RecoveryAddresses: tc.recovery}
+			i.ResolveProfile(paths)
+			assert.Equal(t, tc.displayName, i.DisplayName)
+			assert.Equal(t, tc.avatarURL, i.AvatarURL)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package identity
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/herodot"
+)
+
+// TraitFieldsFromRequest returns the trait paths requested using the `fields` query parameter. The parameter
+// may be repeated or contain a comma-separated list of paths. Returns nil if no projection was requested.
+func TraitFieldsFromRequest(r *http.Request) ([]string, error) {
+	var fields []string
+	for _, v := range r.URL.Query()["fields"] {
+		for _, f := range strings.Split(v, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			if strings.ContainsAny(f, "#*?|@") {
+				return nil, errors.WithStack(herodot.ErrBadRequest.
+					WithReasonf(`Trait field "%s" is not supported. Only paths using dot notation such as "name.first" are allowed.`, f))
+			}
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// ProjectTraits returns a copy of the traits which only contains the given paths. Paths which do not
+// exist in the traits are omitted. If fields is empty, the traits are returned as-is.
+func ProjectTraits(traits Traits, fields []string) (Traits, error) {
+	if len(fields) == 0 {
+		return traits, nil
+	}
+
+	projected := []byte(`{}`)
+	for _, f := range fields {
+		value := gjson.GetBytes(traits, f)
+		if !value.Exists() {
+			continue
+		}
+
+		var err error
+		projected, err = sjson.SetRawBytes(projected, f, []byte(value.Raw))
+		if err != nil {
+			return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`Unable to project trait field "%s": %s`, f, err))
+		}
+	}
+	return projected, nil
+}
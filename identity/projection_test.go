@@ -0,0 +1,43 @@
+package identity
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectTraits(t *testing.T) {
+	traits := Traits(`{"email":"foo@ory.sh","name":{"first":"Foo","last":"Bar"},"tags":["a","b"]}`)
+
+	for k, tc := range []struct {
+		fields   []string
+		expected string
+	}{
+		{fields: nil, expected: string(traits)},
+		{fields: []string{"email"}, expected: `{"email":"foo@ory.sh"}`},
+		{fields: []string{"name.first", "tags"}, expected: `{"name":{"first":"Foo"},"tags":["a","b"]}`},
+		{fields: []string{"does.not.exist"}, expected: `{}`},
+	} {
+		actual, err := ProjectTraits(traits, tc.fields)
+		require.NoError(t, err, "%d", k)
+		assert.JSONEq(t, tc.expected, string(actual), "%d", k)
+	}
+}
+
+func TestTraitFieldsFromRequest(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/?fields=email,+name.first&fields=tags", nil)
+	fields, err := TraitFieldsFromRequest(r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"email", "name.first", "tags"}, fields)
+
+	r, _ = http.NewRequest("GET", "/", nil)
+	fields, err = TraitFieldsFromRequest(r)
+	require.NoError(t, err)
+	assert.Nil(t, fields)
+
+	r, _ = http.NewRequest("GET", "/?fields=tags.%23", nil)
+	_, err = TraitFieldsFromRequest(r)
+	require.Error(t, err)
+}
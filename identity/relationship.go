@@ -0,0 +1,228 @@
+package identity
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/driver/config"
+)
+
+// RelationshipType is the kind of a relationship between two identities.
+type RelationshipType string
+
+const (
+	// RelationshipTypeGuardian links a guardian, for example a parent or a teacher, to a child account.
+	RelationshipTypeGuardian RelationshipType = "guardian"
+
+	// RelationshipTypeDelegate links a primary account to an account which acts on its behalf.
+	RelationshipTypeDelegate RelationshipType = "delegate"
+)
+
+// RelationshipRole is the role an identity has in a relationship.
+type RelationshipRole string
+
+const (
+	RelationshipRoleGuardian RelationshipRole = "guardian"
+	RelationshipRoleChild    RelationshipRole = "child"
+	RelationshipRolePrimary  RelationshipRole = "primary"
+	RelationshipRoleDelegate RelationshipRole = "delegate"
+)
+
+// ErrChildSelfService is returned when an identity with a guardian tries to use a self-service flow which
+// is restricted at `identity.relationships.child_restrictions`.
+var ErrChildSelfService = herodot.ErrForbidden.
+	WithError("self-service flow is restricted for child accounts").
+	WithReason("This account is managed by a guardian and can not use this self-service flow.")
+
+type (
+	// Relationship links two identities, for example a guardian to a child account or a primary account to
+	// its delegate. Relationships are directed: IdentityID is the guardian or primary account and
+	// RelatedIdentityID is the child or delegate account.
+	//
+	// swagger:model identityRelationship
+	Relationship struct {
+		// ID is the relationship's unique identifier.
+		//
+		// required: true
+		ID  uuid.UUID `json:"id" faker:"-" db:"id"`
+		NID uuid.UUID `json:"-" faker:"-" db:"nid"`
+
+		// Type is the kind of the relationship, either `guardian` or `delegate`.
+		//
+		// required: true
+		Type RelationshipType `json:"type" db:"type"`
+
+		// IdentityID is the ID of the guardian or primary identity.
+		//
+		// required: true
+		IdentityID uuid.UUID `json:"identity_id" faker:"-" db:"identity_id"`
+
+		// RelatedIdentityID is the ID of the child or delegate identity.
+		//
+		// required: true
+		RelatedIdentityID uuid.UUID `json:"related_identity_id" faker:"-" db:"related_identity_id"`
+
+		// CreatedAt is the time at which the relationship was created.
+		//
+		// required: true
+		CreatedAt time.Time `json:"created_at" faker:"-" db:"created_at"`
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+	}
+
+	// RelationshipAnnotation describes a relationship from the point of view of one of its identities. It is
+	// added to sessions if `identity.relationships.session_annotations` is enabled.
+	//
+	// swagger:model identityRelationshipAnnotation
+	RelationshipAnnotation struct {
+		// Type is the kind of the relationship.
+		//
+		// required: true
+		Type RelationshipType `json:"type"`
+
+		// Role is the role the session's identity has in the relationship, for example `child`.
+		//
+		// required: true
+		Role RelationshipRole `json:"role"`
+
+		// IdentityID is the ID of the other identity in the relationship.
+		//
+		// required: true
+		IdentityID uuid.UUID `json:"identity_id"`
+	}
+
+	RelationshipPersister interface {
+		// CreateIdentityRelationship links two identities.
+		CreateIdentityRelationship(ctx context.Context, r *Relationship) error
+
+		// GetIdentityRelationship returns the relationship with the given ID.
+		GetIdentityRelationship(ctx context.Context, id uuid.UUID) (*Relationship, error)
+
+		// DeleteIdentityRelationship removes the relationship with the given ID.
+		DeleteIdentityRelationship(ctx context.Context, id uuid.UUID) error
+
+		// ListIdentityRelationships returns the relationships an identity takes part in on either side, oldest
+		// first.
+		ListIdentityRelationships(ctx context.Context, identityID uuid.UUID) ([]Relationship, error)
+	}
+
+	RelationshipPersistenceProvider interface {
+		IdentityRelationshipPersister() RelationshipPersister
+	}
+)
+
+func (r Relationship) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "identity_relationships")
+}
+
+func (r *Relationship) GetID() uuid.UUID {
+	return r.ID
+}
+
+func (r *Relationship) GetNID() uuid.UUID {
+	return r.NID
+}
+
+// Validate returns a bad request error if the relationship's type is unknown or if it links an identity to
+// itself.
+func (r *Relationship) Validate() error {
+	switch r.Type {
+	case RelationshipTypeGuardian, RelationshipTypeDelegate:
+	default:
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The relationship's type must be "%s" or "%s" but got "%s".`, RelationshipTypeGuardian, RelationshipTypeDelegate, r.Type))
+	}
+
+	if r.RelatedIdentityID == uuid.Nil {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The related identity must be set."))
+	} else if r.IdentityID == r.RelatedIdentityID {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("An identity can not have a relationship with itself."))
+	}
+
+	return nil
+}
+
+// ActorID returns the ID of the identity which may act on behalf of the other one: the guardian, or the delegate.
+func (r *Relationship) ActorID() uuid.UUID {
+	if r.Type == RelationshipTypeDelegate {
+		return r.RelatedIdentityID
+	}
+	return r.IdentityID
+}
+
+// SubjectID returns the ID of the identity which may be acted on behalf of: the child, or the primary account.
+func (r *Relationship) SubjectID() uuid.UUID {
+	if r.Type == RelationshipTypeDelegate {
+		return r.IdentityID
+	}
+	return r.RelatedIdentityID
+}
+
+// Annotate describes the relationship from the point of view of the given identity.
+func (r *Relationship) Annotate(identityID uuid.UUID) RelationshipAnnotation {
+	a := RelationshipAnnotation{Type: r.Type, IdentityID: r.RelatedIdentityID}
+	switch r.Type {
+	case RelationshipTypeGuardian:
+		a.Role = RelationshipRoleGuardian
+	case RelationshipTypeDelegate:
+		a.Role = RelationshipRolePrimary
+	}
+
+	if r.RelatedIdentityID == identityID {
+		a.IdentityID = r.IdentityID
+		switch r.Type {
+		case RelationshipTypeGuardian:
+			a.Role = RelationshipRoleChild
+		case RelationshipTypeDelegate:
+			a.Role = RelationshipRoleDelegate
+		}
+	}
+
+	return a
+}
+
+const (
+	ChildRestrictedFlowSettings = "settings"
+	ChildRestrictedFlowRecovery = "recovery"
+)
+
+// ChildRestrictions are the self-service flows and methods an identity can not use because it has a guardian.
+type ChildRestrictions []string
+
+// FindChildRestrictions returns the restrictions configured at `identity.relationships.child_restrictions` if
+// the identity has a guardian, and no restrictions otherwise.
+func FindChildRestrictions(ctx context.Context, c *config.Config, p RelationshipPersistenceProvider, i *Identity) (ChildRestrictions, error) {
+	restrictions := c.IdentityRelationshipsChildRestrictions()
+	if len(restrictions) == 0 {
+		return nil, nil
+	}
+
+	relationships, err := p.IdentityRelationshipPersister().ListIdentityRelationships(ctx, i.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range relationships {
+		if r.Type == RelationshipTypeGuardian && r.RelatedIdentityID == i.ID {
+			return restrictions, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Restricts returns true if the self-service flow, for example `settings`, or the method within it is restricted.
+// Pass an empty method to check whether the whole flow is restricted.
+func (r ChildRestrictions) Restricts(flow, method string) bool {
+	for _, restriction := range r {
+		if restriction == flow || (method != "" && restriction == flow+"."+method) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package identity
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/schema"
+)
+
+// reservedNameSeparators are removed before names are compared with reserved words.
+var reservedNameSeparators = strings.NewReplacer(".", "", "-", "", "_", "", " ", "", "\t", "")
+
+// ValidateReservedNames rejects usernames and display names in the identity's traits which equal a reserved word
+// or match a reserved pattern. Usernames are password identifiers which are not email addresses. Names the original
+// identity already uses are accepted. The original identity is nil for new identities.
+func (v *Validator) ValidateReservedNames(ctx context.Context, i *Identity, original *Identity) error {
+	words, patterns := v.d.Config(ctx).IdentityReservedNameWords(), v.d.Config(ctx).IdentityReservedNamePatterns()
+	if len(words) == 0 && len(patterns) == 0 {
+		return nil
+	}
+
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for k, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to compile reserved name pattern %q: %s", p, err))
+		}
+		compiled[k] = re
+	}
+
+	collector := &traitCollector{match: func(c schema.ExtensionConfig) bool {
+		return c.Credentials.Password.Identifier || c.Profile.DisplayName
+	}}
+	if err := v.ValidateWithRunner(ctx, i, collector); err != nil {
+		return err
+	}
+
+	known := make(map[string]bool)
+	if original != nil {
+		for _, name := range traitStrings(original.Traits) {
+			known[name] = true
+		}
+	}
+
+	for _, name := range collector.values {
+		if known[name] || strings.Contains(name, "@") {
+			continue
+		}
+
+		if isReservedName(name, words, compiled) {
+			return schema.NewNameReservedError(traitErrorPointer(i, name), name)
+		}
+	}
+
+	return nil
+}
+
+func isReservedName(name string, words []string, patterns []*regexp.Regexp) bool {
+	lower := strings.ToLower(name)
+	normalized := reservedNameSeparators.Replace(lower)
+	for _, w := range words {
+		if normalized == reservedNameSeparators.Replace(strings.ToLower(w)) {
+			return true
+		}
+	}
+
+	for _, p := range patterns {
+		if p.MatchString(lower) {
+			return true
+		}
+	}
+
+	return false
+}
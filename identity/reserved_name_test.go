@@ -0,0 +1,28 @@
+package identity
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsReservedName(t *testing.T) {
+	words := []string{"admin", "Customer Support"}
+	patterns := []*regexp.Regexp{regexp.MustCompile(`^(official|staff)[-_.]?`), regexp.MustCompile(`moderator`)}
+
+	for name, expected := range map[string]bool{
+		"admin":              true,
+		"Ad.Min":             true,
+		"a_d-m i.n":          true,
+		"customer-support":   true,
+		"administrator":      false,
+		"official_ory":       true,
+		"Staff.Jane":         true,
+		"unofficial":         false,
+		"chief-moderator-42": true,
+		"jane":               false,
+	} {
+		assert.Equal(t, expected, isReservedName(name, words, patterns), name)
+	}
+}
@@ -0,0 +1,17 @@
+package identity
+
+import (
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// ErrServiceAccountSelfService is returned when a service account tries to use a self-service flow.
+var ErrServiceAccountSelfService = herodot.ErrForbidden.
+	WithError("self-service flows are not available to service accounts").
+	WithReason("Service accounts can not use self-service flows. Their credentials can only be managed using the admin API.")
+
+// IsServiceAccount returns true if the identity's schema is configured for service accounts.
+func IsServiceAccount(c *config.Config, i *Identity) bool {
+	return c.IdentityTraitsSchemas().IsServiceAccountSchema(i.SchemaID)
+}
@@ -0,0 +1,112 @@
+package identity
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/corp"
+)
+
+type (
+	// ScheduledStateChange changes the state of an identity at a future point in time, for example to
+	// deactivate it when a contract ends or to reactivate it when a term starts. Scheduled state changes are
+	// executed by the `identity-state-changes` background job.
+	//
+	// swagger:model identityScheduledStateChange
+	ScheduledStateChange struct {
+		// ID is the scheduled state change's unique identifier.
+		//
+		// required: true
+		ID  uuid.UUID `json:"id" faker:"-" db:"id"`
+		NID uuid.UUID `json:"-" faker:"-" db:"nid"`
+
+		// IdentityID is the ID of the identity whose state changes.
+		//
+		// required: true
+		IdentityID uuid.UUID `json:"identity_id" faker:"-" db:"identity_id"`
+
+		// State is the state the identity changes to.
+		//
+		// required: true
+		State State `json:"state" db:"state"`
+
+		// ExecuteAt is the time at which the state changes.
+		//
+		// required: true
+		ExecuteAt time.Time `json:"execute_at" faker:"-" db:"execute_at"`
+
+		// ExecutedAt is the time at which the state was changed. It is not set while the change is pending.
+		ExecutedAt *sqlxx.NullTime `json:"executed_at,omitempty" faker:"-" db:"executed_at"`
+
+		// CreatedAt is the time at which the state change was scheduled.
+		//
+		// required: true
+		CreatedAt time.Time `json:"created_at" faker:"-" db:"created_at"`
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+	}
+
+	ScheduledStateChangePersister interface {
+		// CreateScheduledStateChange schedules a state change.
+		CreateScheduledStateChange(ctx context.Context, c *ScheduledStateChange) error
+
+		// GetScheduledStateChange returns the scheduled state change with the given ID.
+		GetScheduledStateChange(ctx context.Context, id uuid.UUID) (*ScheduledStateChange, error)
+
+		// DeleteScheduledStateChange removes the scheduled state change with the given ID.
+		DeleteScheduledStateChange(ctx context.Context, id uuid.UUID) error
+
+		// ListScheduledStateChanges returns the state changes scheduled for an identity, the next one first.
+		ListScheduledStateChanges(ctx context.Context, identityID uuid.UUID, page, itemsPerPage int) ([]ScheduledStateChange, error)
+
+		// CountScheduledStateChanges returns the number of state changes scheduled for an identity.
+		CountScheduledStateChanges(ctx context.Context, identityID uuid.UUID) (int64, error)
+
+		// ListDueScheduledStateChanges returns up to limit pending state changes which are due at the given
+		// time, the earliest one first.
+		ListDueScheduledStateChanges(ctx context.Context, now time.Time, limit int) ([]ScheduledStateChange, error)
+
+		// MarkScheduledStateChangeExecuted records that the scheduled state change was executed.
+		MarkScheduledStateChangeExecuted(ctx context.Context, id uuid.UUID, executedAt time.Time) error
+	}
+
+	ScheduledStateChangePersistenceProvider interface {
+		ScheduledStateChangePersister() ScheduledStateChangePersister
+	}
+)
+
+func (c ScheduledStateChange) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "identity_scheduled_state_changes")
+}
+
+func (c *ScheduledStateChange) GetID() uuid.UUID {
+	return c.ID
+}
+
+func (c *ScheduledStateChange) GetNID() uuid.UUID {
+	return c.NID
+}
+
+// IsPending returns true if the state change was not executed yet.
+func (c *ScheduledStateChange) IsPending() bool {
+	return c.ExecutedAt == nil
+}
+
+// Validate returns a bad request error if the state is unknown or if the change is not scheduled after now.
+func (c *ScheduledStateChange) Validate(now time.Time) error {
+	if err := c.State.Validate(); err != nil {
+		return err
+	}
+
+	if !c.ExecuteAt.After(now) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The state change must be scheduled in the future."))
+	}
+
+	return nil
+}
@@ -462,6 +462,168 @@ func TestPool(ctx context.Context, conf *config.Config, p interface {
 			})
 		})
 
+		t.Run("case=find credentials by identifiers", func(t *testing.T) {
+			password := x.NewUUID().String()
+			oidc := x.NewUUID().String()
+			unknown := x.NewUUID().String()
+
+			expected := passwordIdentity("", password)
+			expected.SetCredentials(identity.CredentialsTypeOIDC, identity.Credentials{
+				Type: identity.CredentialsTypeOIDC, Identifiers: []string{oidc},
+				Config: sqlxx.JSONRawMessage(`{}`),
+			})
+			require.NoError(t, p.CreateIdentity(ctx, expected))
+			createdIDs = append(createdIDs, expected.ID)
+
+			actual, err := p.FindCredentialsByIdentifiers(ctx, []string{strings.ToUpper(password), oidc, strings.ToUpper(oidc), unknown})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []identity.KnownCredentials{
+				{Identifier: strings.ToUpper(password), IdentityID: expected.ID, Type: identity.CredentialsTypePassword},
+				{Identifier: oidc, IdentityID: expected.ID, Type: identity.CredentialsTypeOIDC},
+			}, actual)
+
+			t.Run("not if on another network", func(t *testing.T) {
+				_, p := testhelpers.NewNetwork(t, ctx, p)
+				actual, err := p.FindCredentialsByIdentifiers(ctx, []string{password, oidc})
+				require.NoError(t, err)
+				assert.Empty(t, actual)
+			})
+		})
+
+		t.Run("case=notes", func(t *testing.T) {
+			i := identity.NewIdentity("")
+			require.NoError(t, p.CreateIdentity(ctx, i))
+			createdIDs = append(createdIDs, i.ID)
+
+			actual, err := p.ListIdentityNotes(ctx, i.ID, 0, 10)
+			require.NoError(t, err)
+			assert.Empty(t, actual)
+
+			for k := 0; k < 3; k++ {
+				n := &identity.Note{IdentityID: i.ID, Author: "support@ory.sh", Text: fmt.Sprintf("note %d", k), CreatedAt: time.Now().UTC().Add(time.Duration(k) * time.Minute)}
+				require.NoError(t, p.CreateIdentityNote(ctx, n))
+				assert.EqualValues(t, nid, n.NID)
+			}
+
+			actual, err = p.ListIdentityNotes(ctx, i.ID, 0, 2)
+			require.NoError(t, err)
+			require.Len(t, actual, 2)
+			assert.Equal(t, "note 2", actual[0].Text, "most recent notes come first")
+			assert.Equal(t, "note 1", actual[1].Text)
+
+			actual, err = p.ListIdentityNotes(ctx, i.ID, 1, 2)
+			require.NoError(t, err)
+			require.Len(t, actual, 1)
+			assert.Equal(t, "note 0", actual[0].Text)
+
+			count, err := p.CountIdentityNotes(ctx, i.ID)
+			require.NoError(t, err)
+			assert.EqualValues(t, 3, count)
+
+			t.Run("not if on another network", func(t *testing.T) {
+				_, p := testhelpers.NewNetwork(t, ctx, p)
+				actual, err := p.ListIdentityNotes(ctx, i.ID, 0, 10)
+				require.NoError(t, err)
+				assert.Empty(t, actual)
+			})
+		})
+
+		t.Run("case=relationships", func(t *testing.T) {
+			guardian, child := identity.NewIdentity(""), identity.NewIdentity("")
+			require.NoError(t, p.CreateIdentity(ctx, guardian))
+			require.NoError(t, p.CreateIdentity(ctx, child))
+			createdIDs = append(createdIDs, guardian.ID, child.ID)
+
+			actual, err := p.ListIdentityRelationships(ctx, child.ID)
+			require.NoError(t, err)
+			assert.Empty(t, actual)
+
+			r := &identity.Relationship{Type: identity.RelationshipTypeGuardian, IdentityID: guardian.ID, RelatedIdentityID: child.ID}
+			require.NoError(t, p.CreateIdentityRelationship(ctx, r))
+			assert.EqualValues(t, nid, r.NID)
+
+			err = p.CreateIdentityRelationship(ctx, &identity.Relationship{Type: identity.RelationshipTypeGuardian, IdentityID: guardian.ID, RelatedIdentityID: child.ID})
+			assert.ErrorIs(t, err, sqlcon.ErrUniqueViolation)
+
+			for _, id := range []uuid.UUID{guardian.ID, child.ID} {
+				actual, err = p.ListIdentityRelationships(ctx, id)
+				require.NoError(t, err)
+				require.Len(t, actual, 1)
+				assert.Equal(t, r.ID, actual[0].ID)
+			}
+
+			t.Run("not if on another network", func(t *testing.T) {
+				_, p := testhelpers.NewNetwork(t, ctx, p)
+				actual, err := p.ListIdentityRelationships(ctx, child.ID)
+				require.NoError(t, err)
+				assert.Empty(t, actual)
+
+				_, err = p.GetIdentityRelationship(ctx, r.ID)
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+				assert.ErrorIs(t, p.DeleteIdentityRelationship(ctx, r.ID), sqlcon.ErrNoRows)
+			})
+
+			fetched, err := p.GetIdentityRelationship(ctx, r.ID)
+			require.NoError(t, err)
+			assert.Equal(t, child.ID, fetched.RelatedIdentityID)
+
+			require.NoError(t, p.DeleteIdentityRelationship(ctx, r.ID))
+			_, err = p.GetIdentityRelationship(ctx, r.ID)
+			assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=scheduled state changes", func(t *testing.T) {
+			i := identity.NewIdentity("")
+			require.NoError(t, p.CreateIdentity(ctx, i))
+			createdIDs = append(createdIDs, i.ID)
+
+			now := time.Now().UTC()
+			due := &identity.ScheduledStateChange{IdentityID: i.ID, State: identity.StateInactive, ExecuteAt: now.Add(-time.Minute)}
+			pending := &identity.ScheduledStateChange{IdentityID: i.ID, State: identity.StateActive, ExecuteAt: now.Add(time.Hour)}
+			require.NoError(t, p.CreateScheduledStateChange(ctx, pending))
+			require.NoError(t, p.CreateScheduledStateChange(ctx, due))
+			assert.EqualValues(t, nid, due.NID)
+
+			actual, err := p.ListScheduledStateChanges(ctx, i.ID, 0, 10)
+			require.NoError(t, err)
+			require.Len(t, actual, 2)
+			assert.Equal(t, due.ID, actual[0].ID)
+
+			count, err := p.CountScheduledStateChanges(ctx, i.ID)
+			require.NoError(t, err)
+			assert.EqualValues(t, 2, count)
+
+			actual, err = p.ListDueScheduledStateChanges(ctx, now, 10)
+			require.NoError(t, err)
+			require.Len(t, actual, 1)
+			assert.Equal(t, due.ID, actual[0].ID)
+
+			t.Run("not if on another network", func(t *testing.T) {
+				_, p := testhelpers.NewNetwork(t, ctx, p)
+				actual, err := p.ListDueScheduledStateChanges(ctx, now, 10)
+				require.NoError(t, err)
+				assert.Empty(t, actual)
+
+				_, err = p.GetScheduledStateChange(ctx, due.ID)
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+				assert.ErrorIs(t, p.MarkScheduledStateChangeExecuted(ctx, due.ID, now), sqlcon.ErrNoRows)
+				assert.ErrorIs(t, p.DeleteScheduledStateChange(ctx, due.ID), sqlcon.ErrNoRows)
+			})
+
+			require.NoError(t, p.MarkScheduledStateChangeExecuted(ctx, due.ID, now))
+			fetched, err := p.GetScheduledStateChange(ctx, due.ID)
+			require.NoError(t, err)
+			assert.False(t, fetched.IsPending())
+
+			actual, err = p.ListDueScheduledStateChanges(ctx, now, 10)
+			require.NoError(t, err)
+			assert.Empty(t, actual)
+
+			require.NoError(t, p.DeleteScheduledStateChange(ctx, pending.ID))
+			_, err = p.GetScheduledStateChange(ctx, pending.ID)
+			assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
 		t.Run("suite=verifiable-address", func(t *testing.T) {
 			createIdentityWithAddresses := func(t *testing.T, email string) identity.VerifiableAddress {
 				var i identity.Identity
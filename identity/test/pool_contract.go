@@ -0,0 +1,321 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/x"
+)
+
+// TestPoolContract is the conformance suite for identity.PrivilegedPool implementations. Unlike TestPool it only
+// relies on the pool interface, so it can be run against stores which are not backed by SQL.
+//
+// newPool must return an empty pool for a network of its own every time it is called. Pools returned by different
+// calls must not see each other's identities. The default identity schema must accept the traits "{}" and
+// {"bar": "<string>"}.
+func TestPoolContract(ctx context.Context, newPool func(t *testing.T) identity.PrivilegedPool) func(t *testing.T) {
+	return func(t *testing.T) {
+		var withCredentials = func(ct identity.CredentialsType, identifiers ...string) *identity.Identity {
+			i := identity.NewIdentity("")
+			i.Traits = identity.Traits(`{}`)
+			i.SetCredentials(ct, identity.Credentials{
+				Type: ct, Identifiers: identifiers,
+				Config: sqlxx.JSONRawMessage(`{}`),
+			})
+			return i
+		}
+
+		var createMany = func(t *testing.T, p identity.PrivilegedPool, n int) map[uuid.UUID]bool {
+			ids := make(map[uuid.UUID]bool, n)
+			for k := 0; k < n; k++ {
+				i := withCredentials(identity.CredentialsTypePassword, x.NewUUID().String())
+				require.NoError(t, p.CreateIdentity(ctx, i))
+				ids[i.ID] = true
+			}
+			return ids
+		}
+
+		t.Run("suite=pagination", func(t *testing.T) {
+			p := newPool(t)
+
+			t.Run("case=empty pool", func(t *testing.T) {
+				is, err := p.ListIdentities(ctx, 0, 10)
+				require.NoError(t, err)
+				assert.Len(t, is, 0)
+
+				count, err := p.CountIdentities(ctx)
+				require.NoError(t, err)
+				assert.EqualValues(t, 0, count)
+			})
+
+			expected := createMany(t, p, 7)
+
+			t.Run("case=pages are zero-based and do not overlap", func(t *testing.T) {
+				seen := make(map[uuid.UUID]bool)
+				for page, size := range []int{3, 3, 1} {
+					is, err := p.ListIdentities(ctx, page, 3)
+					require.NoError(t, err)
+					require.Len(t, is, size, "page %d", page)
+
+					for _, i := range is {
+						assert.False(t, seen[i.ID], "identity %s was returned on more than one page", i.ID)
+						seen[i.ID] = true
+					}
+				}
+				assert.Equal(t, expected, seen)
+			})
+
+			t.Run("case=page size matches the total", func(t *testing.T) {
+				is, err := p.ListIdentities(ctx, 0, len(expected))
+				require.NoError(t, err)
+				assert.Len(t, is, len(expected))
+
+				is, err = p.ListIdentities(ctx, 1, len(expected))
+				require.NoError(t, err)
+				assert.Len(t, is, 0)
+			})
+
+			t.Run("case=page beyond the end is empty", func(t *testing.T) {
+				is, err := p.ListIdentities(ctx, 100, 3)
+				require.NoError(t, err)
+				assert.Len(t, is, 0)
+			})
+
+			t.Run("case=negative page is the first page", func(t *testing.T) {
+				first, err := p.ListIdentities(ctx, 0, 3)
+				require.NoError(t, err)
+				negative, err := p.ListIdentities(ctx, -1, 3)
+				require.NoError(t, err)
+				assert.Equal(t, first, negative)
+			})
+
+			t.Run("case=order is stable", func(t *testing.T) {
+				a, err := p.ListIdentities(ctx, 0, 5)
+				require.NoError(t, err)
+				b, err := p.ListIdentities(ctx, 0, 5)
+				require.NoError(t, err)
+				assert.Equal(t, a, b)
+			})
+
+			t.Run("case=count ignores pagination", func(t *testing.T) {
+				count, err := p.CountIdentities(ctx)
+				require.NoError(t, err)
+				assert.EqualValues(t, len(expected), count)
+			})
+		})
+
+		t.Run("suite=network isolation", func(t *testing.T) {
+			a, b := newPool(t), newPool(t)
+
+			i := withCredentials(identity.CredentialsTypePassword, "isolated@ory.sh")
+			i.VerifiableAddresses = []identity.VerifiableAddress{*identity.NewVerifiableEmailAddress("isolated@ory.sh", i.ID)}
+			i.RecoveryAddresses = []identity.RecoveryAddress{*identity.NewRecoveryEmailAddress("isolated@ory.sh", i.ID)}
+			require.NoError(t, a.CreateIdentity(ctx, i))
+
+			t.Run("case=reads", func(t *testing.T) {
+				_, err := b.GetIdentity(ctx, i.ID)
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				_, err = b.GetIdentityConfidential(ctx, i.ID)
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				_, _, err = b.FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, "isolated@ory.sh")
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				_, err = b.FindVerifiableAddressByValue(ctx, identity.VerifiableAddressTypeEmail, "isolated@ory.sh")
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				_, err = b.FindRecoveryAddressByValue(ctx, identity.RecoveryAddressTypeEmail, "isolated@ory.sh")
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				is, err := b.ListIdentities(ctx, 0, 10)
+				require.NoError(t, err)
+				assert.Len(t, is, 0)
+
+				va, err := b.ListVerifiableAddresses(ctx, 0, 10)
+				require.NoError(t, err)
+				assert.Len(t, va, 0)
+
+				ra, err := b.ListRecoveryAddresses(ctx, 0, 10)
+				require.NoError(t, err)
+				assert.Len(t, ra, 0)
+
+				count, err := b.CountIdentities(ctx)
+				require.NoError(t, err)
+				assert.EqualValues(t, 0, count)
+			})
+
+			t.Run("case=writes", func(t *testing.T) {
+				other := *i
+				other.Traits = identity.Traits(`{"bar":"changed"}`)
+				assert.ErrorIs(t, b.UpdateIdentity(ctx, &other), sqlcon.ErrNoRows)
+				assert.ErrorIs(t, b.UpdateVerifiableAddress(ctx, &i.VerifiableAddresses[0]), sqlcon.ErrNoRows)
+				assert.ErrorIs(t, b.DeleteIdentity(ctx, i.ID), sqlcon.ErrNoRows)
+
+				actual, err := a.GetIdentity(ctx, i.ID)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{}`, string(actual.Traits))
+			})
+
+			t.Run("case=same identifier on another network", func(t *testing.T) {
+				same := withCredentials(identity.CredentialsTypePassword, "isolated@ory.sh")
+				require.NoError(t, b.CreateIdentity(ctx, same))
+
+				actual, _, err := b.FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, "isolated@ory.sh")
+				require.NoError(t, err)
+				assert.Equal(t, same.ID, actual.ID)
+
+				actual, _, err = a.FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, "isolated@ory.sh")
+				require.NoError(t, err)
+				assert.Equal(t, i.ID, actual.ID)
+			})
+		})
+
+		t.Run("suite=identifier case", func(t *testing.T) {
+			p := newPool(t)
+
+			t.Run("case=password identifiers are case insensitive", func(t *testing.T) {
+				i := withCredentials(identity.CredentialsTypePassword, "Mixed.Case@ory.sh")
+				require.NoError(t, p.CreateIdentity(ctx, i))
+
+				for _, match := range []string{"Mixed.Case@ory.sh", "mixed.case@ory.sh", "MIXED.CASE@ORY.SH"} {
+					actual, creds, err := p.FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, match)
+					require.NoError(t, err, match)
+					assert.Equal(t, i.ID, actual.ID, match)
+					assert.Equal(t, []string{"mixed.case@ory.sh"}, creds.Identifiers, match)
+				}
+
+				err := p.CreateIdentity(ctx, withCredentials(identity.CredentialsTypePassword, "MIXED.case@ory.sh"))
+				assert.ErrorIs(t, err, sqlcon.ErrUniqueViolation)
+			})
+
+			t.Run("case=oidc identifiers are case sensitive", func(t *testing.T) {
+				lower := withCredentials(identity.CredentialsTypeOIDC, "provider:subject")
+				require.NoError(t, p.CreateIdentity(ctx, lower))
+				upper := withCredentials(identity.CredentialsTypeOIDC, "provider:SUBJECT")
+				require.NoError(t, p.CreateIdentity(ctx, upper))
+
+				actual, _, err := p.FindByCredentialsIdentifier(ctx, identity.CredentialsTypeOIDC, "provider:subject")
+				require.NoError(t, err)
+				assert.Equal(t, lower.ID, actual.ID)
+
+				actual, _, err = p.FindByCredentialsIdentifier(ctx, identity.CredentialsTypeOIDC, "provider:SUBJECT")
+				require.NoError(t, err)
+				assert.Equal(t, upper.ID, actual.ID)
+
+				_, _, err = p.FindByCredentialsIdentifier(ctx, identity.CredentialsTypeOIDC, "PROVIDER:subject")
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+
+			t.Run("case=changing the case of an own identifier", func(t *testing.T) {
+				i := withCredentials(identity.CredentialsTypePassword, "own@ory.sh")
+				require.NoError(t, p.CreateIdentity(ctx, i))
+
+				i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+					Type: identity.CredentialsTypePassword, Identifiers: []string{"OWN@ory.sh"},
+					Config: sqlxx.JSONRawMessage(`{}`),
+				})
+				require.NoError(t, p.UpdateIdentity(ctx, i))
+
+				actual, _, err := p.FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, "own@ory.sh")
+				require.NoError(t, err)
+				assert.Equal(t, i.ID, actual.ID)
+			})
+		})
+
+		t.Run("suite=concurrent writes", func(t *testing.T) {
+			p := newPool(t)
+
+			// Concurrent writes may either succeed or be rejected, but must never leave a mix of the writes behind.
+			var allowed = func(t *testing.T, err error) {
+				if err != nil {
+					assert.True(t, errors.Is(err, sqlcon.ErrConcurrentUpdate) || errors.Is(err, sqlcon.ErrUniqueViolation), "%+v", err)
+				}
+			}
+
+			t.Run("case=updates of the same identity", func(t *testing.T) {
+				i := withCredentials(identity.CredentialsTypePassword, x.NewUUID().String())
+				require.NoError(t, p.CreateIdentity(ctx, i))
+
+				const workers = 8
+				var wg sync.WaitGroup
+				errs := make([]error, workers)
+				for k := 0; k < workers; k++ {
+					wg.Add(1)
+					go func(k int) {
+						defer wg.Done()
+						u := withCredentials(identity.CredentialsTypePassword, fmt.Sprintf("writer-%d-%s", k, i.ID))
+						u.ID = i.ID
+						u.Traits = identity.Traits(fmt.Sprintf(`{"bar":"writer-%d"}`, k))
+						errs[k] = p.UpdateIdentity(ctx, u)
+					}(k)
+				}
+				wg.Wait()
+
+				var succeeded int
+				for _, err := range errs {
+					allowed(t, err)
+					if err == nil {
+						succeeded++
+					}
+				}
+				require.NotZero(t, succeeded, "at least one concurrent update must succeed")
+
+				actual, err := p.GetIdentityConfidential(ctx, i.ID)
+				require.NoError(t, err)
+				require.Len(t, actual.Credentials, 1)
+
+				creds := actual.Credentials[identity.CredentialsTypePassword]
+				require.Len(t, creds.Identifiers, 1, "credentials of concurrent writers must not be merged")
+
+				writer := gjson.GetBytes(actual.Traits, "bar").String()
+				assert.True(t, strings.HasPrefix(creds.Identifiers[0], writer+"-"), "traits of %s do not belong to the credentials %s", writer, creds.Identifiers[0])
+			})
+
+			t.Run("case=creates with the same identifier", func(t *testing.T) {
+				identifier := x.NewUUID().String()
+
+				const workers = 8
+				var wg sync.WaitGroup
+				errs := make([]error, workers)
+				for k := 0; k < workers; k++ {
+					wg.Add(1)
+					go func(k int) {
+						defer wg.Done()
+						casing := identifier
+						if k%2 == 1 {
+							casing = strings.ToUpper(identifier)
+						}
+						errs[k] = p.CreateIdentity(ctx, withCredentials(identity.CredentialsTypePassword, casing))
+					}(k)
+				}
+				wg.Wait()
+
+				var succeeded int
+				for _, err := range errs {
+					allowed(t, err)
+					if err == nil {
+						succeeded++
+					}
+				}
+				assert.Equal(t, 1, succeeded, "exactly one identity may claim the identifier")
+
+				_, _, err := p.FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, identifier)
+				require.NoError(t, err)
+			})
+		})
+	}
+}
@@ -2,9 +2,15 @@ package identity
 
 import (
 	"context"
+	"net"
+	"strconv"
+	"strings"
 
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 
+	"github.com/ory/jsonschema/v3"
+
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/schema"
 )
@@ -12,11 +18,13 @@ import (
 type (
 	validatorDependencies interface {
 		IdentityTraitsSchemas(ctx context.Context) schema.Schemas
+		SchemaExtensionProvider
 		config.Provider
 	}
 	Validator struct {
-		v *schema.Validator
-		d validatorDependencies
+		v        *schema.Validator
+		d        validatorDependencies
+		lookupMX lookupMXFunc
 	}
 	ValidationProvider interface {
 		IdentityValidator() *Validator
@@ -24,7 +32,7 @@ type (
 )
 
 func NewValidator(d validatorDependencies) *Validator {
-	return &Validator{v: schema.NewValidator(), d: d}
+	return &Validator{v: schema.NewValidator(), d: d, lookupMX: net.DefaultResolver.LookupMX}
 }
 
 func (v *Validator) ValidateWithRunner(ctx context.Context, i *Identity, runners ...schema.Extension) error {
@@ -47,9 +55,86 @@ func (v *Validator) ValidateWithRunner(ctx context.Context, i *Identity, runners
 }
 
 func (v *Validator) Validate(ctx context.Context, i *Identity) error {
-	return v.ValidateWithRunner(ctx, i,
+	runners := []schema.Extension{
 		NewSchemaExtensionCredentials(i),
-		NewSchemaExtensionVerification(i, v.d.Config(ctx).SelfServiceFlowVerificationRequestLifespan()),
+		NewSchemaExtensionVerification(i, v.d.Config(ctx).SelfServiceFlowVerificationRequestLifespan(), v.d.Config(ctx).IdentityVerifiableAddressMergePolicy()),
 		NewSchemaExtensionRecovery(i),
-	)
+	}
+	for _, newExtension := range v.d.IdentitySchemaExtensions(ctx) {
+		runners = append(runners, newExtension(ctx, i))
+	}
+
+	return v.ValidateWithRunner(ctx, i, runners...)
+}
+
+// traitCollector collects the string values of traits whose extension settings match. Arrays contribute one
+// value per element.
+type traitCollector struct {
+	match  func(c schema.ExtensionConfig) bool
+	values []string
+}
+
+func (c *traitCollector) Run(_ jsonschema.ValidationContext, _ schema.ExtensionConfig, _ interface{}) error {
+	return nil
+}
+
+func (c *traitCollector) Finish(values []schema.ExtensionValue) error {
+	for _, v := range values {
+		if c.match(v.Config) {
+			c.values = append(c.values, extensionStrings(v.Value)...)
+		}
+	}
+	return nil
+}
+
+// traitPointer returns the JSON pointer of the first string in the traits which equals value.
+func traitPointer(traits gjson.Result, ptr string, value string) (string, bool) {
+	switch {
+	case traits.Type == gjson.String:
+		return ptr, traits.String() == value
+	case traits.IsArray():
+		for k, v := range traits.Array() {
+			if found, ok := traitPointer(v, ptr+"/"+strconv.Itoa(k), value); ok {
+				return found, true
+			}
+		}
+	case traits.IsObject():
+		var found string
+		var ok bool
+		traits.ForEach(func(key, v gjson.Result) bool {
+			found, ok = traitPointer(v, ptr+"/"+jsonPointerEscaper.Replace(key.String()), value)
+			return !ok
+		})
+		return found, ok
+	}
+	return "", false
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// traitErrorPointer returns the JSON pointer of the first trait with the given value, or the pointer to the traits
+// if there is none.
+func traitErrorPointer(i *Identity, value string) string {
+	if ptr, ok := traitPointer(gjson.ParseBytes(i.Traits), "#/traits", value); ok {
+		return ptr
+	}
+	return "#/traits"
+}
+
+// traitStrings returns all string values in the traits, including those nested in objects and arrays.
+func traitStrings(traits Traits) []string {
+	var values []string
+	var walk func(v gjson.Result)
+	walk = func(v gjson.Result) {
+		if v.Type == gjson.String {
+			values = append(values, v.String())
+			return
+		}
+		v.ForEach(func(_, child gjson.Result) bool {
+			walk(child)
+			return true
+		})
+	}
+	walk(gjson.ParseBytes(traits))
+	return values
 }
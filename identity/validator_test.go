@@ -9,11 +9,15 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/jsonschema/v3"
+
 	"github.com/ory/kratos/driver/config"
 	. "github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/schema"
 )
 
 func TestSchemaValidator(t *testing.T) {
@@ -115,3 +119,39 @@ func TestSchemaValidator(t *testing.T) {
 		})
 	}
 }
+
+type customExtension struct {
+	settings *[]string
+}
+
+func (e *customExtension) Run(_ jsonschema.ValidationContext, _ schema.ExtensionConfig, _ interface{}) error {
+	return nil
+}
+
+func (e *customExtension) Finish(values []schema.ExtensionValue) error {
+	for _, v := range values {
+		if settings, ok := v.Config.Extensions["custom"]; ok {
+			*e.settings = append(*e.settings, string(settings))
+		}
+	}
+	return nil
+}
+
+func TestSchemaValidatorExtensions(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/extension/jsonnet/identity.schema.json")
+	conf.MustSet(config.ViperKeyIdentitySchemaExtensions, []config.SchemaExtension{
+		{Name: "search_key", URL: "file://./stub/extension/jsonnet/search_key.jsonnet"},
+	})
+	var settings []string
+	reg.WithIdentitySchemaExtensions(func(_ context.Context, _ *Identity) schema.Extension {
+		return &customExtension{settings: &settings}
+	})
+
+	i := NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = Traits(`{"email":"Foo@Ory.sh"}`)
+	require.NoError(t, NewValidator(reg).Validate(context.Background(), i))
+
+	assert.JSONEq(t, `{"email":"Foo@Ory.sh","search":{"email":"foo@ory.sh"}}`, string(i.Traits))
+	assert.Equal(t, []string{`{"enabled":true}`}, settings)
+}
@@ -0,0 +1,68 @@
+package inactivity
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ory/kratos/x"
+)
+
+const RouteReport = "/inactive-identities"
+
+type (
+	handlerDependencies interface {
+		ManagementProvider
+		x.WriterProvider
+	}
+	HandlerProvider interface {
+		InactivityHandler() *Handler
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteReport, h.report)
+}
+
+// A list of inactive identities.
+// swagger:response inactiveIdentityList
+// nolint:deadcode,unused
+type inactiveIdentityListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []ReportEntry
+}
+
+// swagger:route GET /inactive-identities admin listInactiveIdentities
+//
+// Preview the Identity Inactivity Policies
+//
+// This endpoint returns what the inactivity policies configured at `identity.inactivity.policies` would do
+// if they were applied now, without doing it. Identities which would only be warned are listed with the
+// action `notify`.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: inactiveIdentityList
+//       500: genericError
+func (h *Handler) report(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	entries, err := h.d.InactivityManager().Report(r.Context())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, entries)
+}
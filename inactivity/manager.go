@@ -0,0 +1,227 @@
+package inactivity
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/x"
+)
+
+const batchSize = 500
+
+// Action is what a policy does to an inactive identity.
+//
+// swagger:model inactivityAction
+type Action string
+
+const (
+	// ActionNotify warns the identity that a policy will act on it.
+	ActionNotify     Action = "notify"
+	ActionDeactivate Action = Action(config.InactivityActionDeactivate)
+	ActionDelete     Action = Action(config.InactivityActionDelete)
+)
+
+type (
+	managerDependencies interface {
+		PersistenceProvider
+		config.Provider
+		x.LoggingProvider
+		courier.Provider
+		identity.ManagementProvider
+	}
+	ManagementProvider interface {
+		InactivityManager() *Manager
+	}
+	// Manager applies the inactivity policies configured at `identity.inactivity.policies`.
+	Manager struct {
+		d managerDependencies
+	}
+
+	// ReportEntry describes what an inactivity policy does to an identity.
+	//
+	// swagger:model inactiveIdentity
+	ReportEntry struct {
+		// IdentityID is the ID of the inactive identity.
+		//
+		// required: true
+		IdentityID uuid.UUID `json:"identity_id"`
+
+		// PolicyID is the ID of the policy acting on the identity.
+		//
+		// required: true
+		PolicyID string `json:"policy_id"`
+
+		// Action is what the policy does to the identity.
+		//
+		// required: true
+		Action Action `json:"action"`
+
+		// LastActiveAt is the time at which the identity last signed in, was created, or had its state changed.
+		//
+		// required: true
+		LastActiveAt time.Time `json:"last_active_at"`
+	}
+)
+
+func NewManager(d managerDependencies) *Manager {
+	return &Manager{d: d}
+}
+
+// Report returns what the policies would do to which identities without doing it.
+func (m *Manager) Report(ctx context.Context) ([]ReportEntry, error) {
+	return m.apply(ctx, true)
+}
+
+// Enforce applies all policies and returns what was done to which identities.
+func (m *Manager) Enforce(ctx context.Context) ([]ReportEntry, error) {
+	return m.apply(ctx, false)
+}
+
+func (m *Manager) apply(ctx context.Context, dryRun bool) ([]ReportEntry, error) {
+	now := time.Now().UTC()
+	entries := make([]ReportEntry, 0)
+	for _, policy := range m.d.Config(ctx).IdentityInactivityPolicies() {
+		since := now.Add(policy.NotifyBefore - policy.After)
+		after := uuid.Nil
+		for {
+			is, err := m.d.InactivityPersister().ListInactiveIdentities(ctx, since, policy.ExcludeSchemaIDs, after, batchSize)
+			if err != nil {
+				return nil, err
+			}
+
+			for k := range is {
+				i := &is[k]
+				entry, err := m.evaluate(ctx, policy, i, now)
+				if err != nil {
+					return nil, err
+				} else if entry == nil {
+					continue
+				}
+
+				if !dryRun {
+					if err := m.execute(ctx, policy, i, entry, now); err != nil {
+						return nil, err
+					}
+				}
+
+				entries = append(entries, *entry)
+			}
+
+			if len(is) < batchSize {
+				break
+			}
+			after = is[len(is)-1].ID
+		}
+	}
+
+	return entries, nil
+}
+
+// evaluate returns what the policy does to the identity right now, or nil if it does nothing.
+func (m *Manager) evaluate(ctx context.Context, policy config.InactivityPolicy, i *identity.Identity, now time.Time) (*ReportEntry, error) {
+	if policy.Action == config.InactivityActionDeactivate && !i.IsActive() {
+		return nil, nil
+	}
+
+	lastActiveAt := i.CreatedAt
+	if i.StateChangedAt != nil && time.Time(*i.StateChangedAt).After(lastActiveAt) {
+		lastActiveAt = time.Time(*i.StateChangedAt)
+	}
+
+	authenticatedAt, err := m.d.InactivityPersister().LastAuthenticatedAt(ctx, i.ID)
+	if err != nil {
+		return nil, err
+	} else if authenticatedAt.After(lastActiveAt) {
+		lastActiveAt = authenticatedAt
+	}
+
+	entry := &ReportEntry{IdentityID: i.ID, PolicyID: policy.ID, LastActiveAt: lastActiveAt}
+	if policy.NotifyBefore > 0 {
+		n, err := m.d.InactivityPersister().LatestInactivityNotification(ctx, i.ID, policy.ID)
+		if err != nil && !errors.Is(err, sqlcon.ErrNoRows) {
+			return nil, err
+		}
+
+		// Warnings sent before the identity was last active do not count.
+		if n == nil || n.CreatedAt.Before(lastActiveAt) {
+			entry.Action = ActionNotify
+			return entry, nil
+		}
+
+		if now.Sub(n.CreatedAt) < policy.NotifyBefore {
+			return nil, nil
+		}
+	}
+
+	if now.Sub(lastActiveAt) < policy.After {
+		return nil, nil
+	}
+
+	entry.Action = Action(policy.Action)
+	return entry, nil
+}
+
+func (m *Manager) execute(ctx context.Context, policy config.InactivityPolicy, i *identity.Identity, entry *ReportEntry, now time.Time) error {
+	l := m.d.Logger().
+		WithField("identity_id", i.ID).
+		WithField("policy_id", policy.ID).
+		WithField("action", entry.Action)
+
+	switch entry.Action {
+	case ActionNotify:
+		return m.notify(ctx, policy, i, now)
+	case ActionDeactivate:
+		if err := m.d.IdentityManager().SetState(ctx, i.ID, identity.StateInactive); err != nil {
+			return err
+		}
+	case ActionDelete:
+		if _, err := m.d.IdentityManager().Delete(ctx, i.ID); err != nil && !errors.Is(err, sqlcon.ErrNoRows) {
+			return err
+		}
+	}
+
+	l.Info("Applied identity inactivity policy.")
+	return nil
+}
+
+func (m *Manager) notify(ctx context.Context, policy config.InactivityPolicy, i *identity.Identity, now time.Time) error {
+	var sent int
+	for _, a := range i.EnabledRecoveryAddresses() {
+		if a.Via != identity.RecoveryAddressTypeEmail {
+			continue
+		}
+
+		if _, err := m.d.Courier(ctx).QueueEmail(ctx, template.NewInactivityWarning(m.d.Config(ctx), &template.InactivityWarningModel{
+			To:         a.Value,
+			Action:     string(policy.Action),
+			ActionAt:   now.Add(policy.NotifyBefore),
+			LoginURL:   m.d.Config(ctx).SelfServiceFlowLoginUI().String(),
+			IdentityID: i.ID,
+		})); err != nil {
+			return err
+		}
+		sent++
+	}
+
+	if sent == 0 {
+		m.d.Logger().
+			WithField("identity_id", i.ID).
+			WithField("policy_id", policy.ID).
+			Warn("Unable to warn an inactive identity because it has no enabled email recovery addresses.")
+	}
+
+	// The notification is recorded even if no email was sent so that the policy can proceed.
+	return m.d.InactivityPersister().CreateInactivityNotification(ctx, &Notification{
+		IdentityID: i.ID,
+		PolicyID:   policy.ID,
+	})
+}
@@ -0,0 +1,127 @@
+package inactivity_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/inactivity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/identity.schema.json")
+	testhelpers.SetIdentitySchemas(t, conf, map[string]string{"excluded": "file://./stub/identity.schema.json"})
+	conf.MustSet(config.ViperKeySelfServiceLoginUI, "https://www.ory.sh/login")
+
+	setPolicy := func(t *testing.T, policy map[string]interface{}) {
+		conf.MustSet(config.ViperKeyIdentityInactivityPolicies, []map[string]interface{}{policy})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentityInactivityPolicies, []map[string]interface{}{})
+		})
+	}
+
+	createIdentity := func(t *testing.T, schemaID string, createdAt time.Time) *identity.Identity {
+		i := identity.NewIdentity(schemaID)
+		i.Traits = identity.Traits(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		i.CreatedAt = createdAt
+		require.NoError(t, reg.IdentityManager().Create(ctx, i))
+		return i
+	}
+
+	find := func(entries []inactivity.ReportEntry, id uuid.UUID) *inactivity.ReportEntry {
+		for k := range entries {
+			if entries[k].IdentityID == id {
+				return &entries[k]
+			}
+		}
+		return nil
+	}
+
+	longAgo := time.Now().Add(-time.Hour * 48)
+
+	t.Run("case=deactivates inactive identities", func(t *testing.T) {
+		setPolicy(t, map[string]interface{}{"id": "deactivate", "action": "deactivate", "after": "24h", "exclude_schema_ids": []string{"excluded"}})
+
+		inactive := createIdentity(t, "", longAgo)
+		excluded := createIdentity(t, "excluded", longAgo)
+		signedIn := createIdentity(t, "", longAgo)
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, session.NewActiveSession(signedIn, conf, time.Now())))
+
+		report, err := reg.InactivityManager().Report(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, find(report, inactive.ID), "%+v", report)
+		assert.Equal(t, inactivity.ActionDeactivate, find(report, inactive.ID).Action)
+		assert.Nil(t, find(report, excluded.ID), "%+v", report)
+		assert.Nil(t, find(report, signedIn.ID), "%+v", report)
+
+		actual, err := reg.IdentityPool().GetIdentity(ctx, inactive.ID)
+		require.NoError(t, err)
+		assert.True(t, actual.IsActive(), "a report must not change identities")
+
+		_, err = reg.InactivityManager().Enforce(ctx)
+		require.NoError(t, err)
+
+		actual, err = reg.IdentityPool().GetIdentity(ctx, inactive.ID)
+		require.NoError(t, err)
+		assert.False(t, actual.IsActive())
+
+		report, err = reg.InactivityManager().Report(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, find(report, inactive.ID), "deactivated identities are not deactivated again: %+v", report)
+
+		t.Run("case=reactivated identities are measured from the reactivation", func(t *testing.T) {
+			require.NoError(t, reg.IdentityManager().SetState(ctx, inactive.ID, identity.StateActive))
+
+			report, err := reg.InactivityManager().Report(ctx)
+			require.NoError(t, err)
+			assert.Nil(t, find(report, inactive.ID), "%+v", report)
+		})
+	})
+
+	t.Run("case=warns before deleting identities", func(t *testing.T) {
+		setPolicy(t, map[string]interface{}{"id": "delete", "action": "delete", "after": "24h", "notify_before": "1s"})
+
+		i := createIdentity(t, "", longAgo)
+
+		entries, err := reg.InactivityManager().Enforce(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, find(entries, i.ID), "%+v", entries)
+		assert.Equal(t, inactivity.ActionNotify, find(entries, i.ID).Action)
+
+		messages, err := reg.CourierPersister().NextMessages(ctx, 255)
+		require.NoError(t, err)
+		var found bool
+		for _, m := range messages {
+			found = found || m.Recipient == i.RecoveryAddresses[0].Value
+		}
+		assert.True(t, found, "%+v", messages)
+
+		entries, err = reg.InactivityManager().Enforce(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, find(entries, i.ID), "the identity must not be deleted before the warning period passed: %+v", entries)
+
+		time.Sleep(time.Second * 2)
+
+		entries, err = reg.InactivityManager().Enforce(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, find(entries, i.ID), "%+v", entries)
+		assert.Equal(t, inactivity.ActionDelete, find(entries, i.ID).Action)
+
+		_, err = reg.IdentityPool().GetIdentity(ctx, i.ID)
+		require.ErrorIs(t, err, sqlcon.ErrNoRows)
+	})
+}
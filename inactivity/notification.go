@@ -0,0 +1,32 @@
+package inactivity
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/corp"
+)
+
+// Notification records that an identity was warned about the action of an inactivity policy.
+type Notification struct {
+	ID  uuid.UUID `json:"id" db:"id"`
+	NID uuid.UUID `json:"-" db:"nid"`
+
+	// IdentityID is the ID of the identity which was warned.
+	IdentityID uuid.UUID `json:"identity_id" db:"identity_id"`
+
+	// PolicyID is the ID of the policy which will act on the identity.
+	PolicyID string `json:"policy_id" db:"policy_id"`
+
+	// CreatedAt is the time at which the warning was sent.
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// UpdatedAt is a helper struct field for gobuffalo.pop.
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+}
+
+func (n Notification) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "identity_inactivity_notifications")
+}
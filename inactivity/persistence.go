@@ -0,0 +1,31 @@
+package inactivity
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/identity"
+)
+
+type PersistenceProvider interface {
+	InactivityPersister() Persister
+}
+
+type Persister interface {
+	// ListInactiveIdentities returns up to limit identities with an ID greater than after, ordered by ID,
+	// which were created before since and have neither authenticated a session nor changed their state
+	// since then. Identities using one of the excluded schemas are skipped.
+	ListInactiveIdentities(ctx context.Context, since time.Time, excludeSchemaIDs []string, after uuid.UUID, limit int) ([]identity.Identity, error)
+
+	// LastAuthenticatedAt returns when the identity last authenticated a session, or the zero time if it
+	// never did.
+	LastAuthenticatedAt(ctx context.Context, identityID uuid.UUID) (time.Time, error)
+
+	CreateInactivityNotification(ctx context.Context, n *Notification) error
+
+	// LatestInactivityNotification returns the most recent notification sent to the identity for the
+	// policy. It returns sqlcon.ErrNoRows if there is none.
+	LatestInactivityNotification(ctx context.Context, identityID uuid.UUID, policyID string) (*Notification, error)
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/inactivity"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+func TestPersister(ctx context.Context, conf *config.Config, p persistence.Persister) func(t *testing.T) {
+	return func(t *testing.T) {
+		_, p := testhelpers.NewNetworkUnlessExisting(t, ctx, p)
+
+		conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+		conf.MustSet(config.ViperKeyIdentitySchemas, []config.Schema{{ID: "excluded", URL: "file://./stub/identity.schema.json"}})
+
+		var createIdentity = func(t *testing.T, schemaID string, createdAt time.Time) *identity.Identity {
+			i := identity.NewIdentity(schemaID)
+			i.CreatedAt = createdAt
+			require.NoError(t, p.CreateIdentity(ctx, i))
+			return i
+		}
+
+		var ids = func(is []identity.Identity) (ids []uuid.UUID) {
+			for _, i := range is {
+				ids = append(ids, i.ID)
+			}
+			return ids
+		}
+
+		now := time.Now().UTC().Truncate(time.Second)
+
+		t.Run("case=lists inactive identities", func(t *testing.T) {
+			inactive := createIdentity(t, "", now.Add(-time.Hour*48))
+			recent := createIdentity(t, "", now.Add(-time.Minute))
+			excluded := createIdentity(t, "excluded", now.Add(-time.Hour*48))
+
+			signedIn := createIdentity(t, "", now.Add(-time.Hour*48))
+			s := session.NewActiveSession(signedIn, conf, now.Add(-time.Minute))
+			require.NoError(t, p.CreateSession(ctx, s))
+
+			actual, err := p.ListInactiveIdentities(ctx, now.Add(-time.Hour*24), []string{"excluded"}, uuid.Nil, 100)
+			require.NoError(t, err)
+			assert.Contains(t, ids(actual), inactive.ID)
+			assert.NotContains(t, ids(actual), recent.ID)
+			assert.NotContains(t, ids(actual), excluded.ID)
+			assert.NotContains(t, ids(actual), signedIn.ID)
+
+			actual, err = p.ListInactiveIdentities(ctx, now.Add(-time.Hour*24), nil, uuid.Nil, 100)
+			require.NoError(t, err)
+			assert.Contains(t, ids(actual), excluded.ID)
+
+			t.Run("case=paginates by id", func(t *testing.T) {
+				all, err := p.ListInactiveIdentities(ctx, now.Add(-time.Hour*24), nil, uuid.Nil, 100)
+				require.NoError(t, err)
+				require.True(t, len(all) >= 2)
+
+				page, err := p.ListInactiveIdentities(ctx, now.Add(-time.Hour*24), nil, all[0].ID, 1)
+				require.NoError(t, err)
+				require.Len(t, page, 1)
+				assert.Equal(t, all[1].ID, page[0].ID)
+			})
+
+			t.Run("case=last authentication", func(t *testing.T) {
+				actual, err := p.LastAuthenticatedAt(ctx, signedIn.ID)
+				require.NoError(t, err)
+				assert.Equal(t, s.AuthenticatedAt.Unix(), actual.Unix())
+
+				actual, err = p.LastAuthenticatedAt(ctx, inactive.ID)
+				require.NoError(t, err)
+				assert.True(t, actual.IsZero())
+			})
+		})
+
+		t.Run("case=notifications", func(t *testing.T) {
+			i := createIdentity(t, "", now)
+
+			_, err := p.LatestInactivityNotification(ctx, i.ID, "policy")
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			n := &inactivity.Notification{ID: x.NewUUID(), IdentityID: i.ID, PolicyID: "policy"}
+			require.NoError(t, p.CreateInactivityNotification(ctx, n))
+
+			actual, err := p.LatestInactivityNotification(ctx, i.ID, "policy")
+			require.NoError(t, err)
+			assert.Equal(t, n.ID, actual.ID)
+
+			_, err = p.LatestInactivityNotification(ctx, i.ID, "other-policy")
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+	}
+}
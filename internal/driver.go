@@ -28,7 +28,7 @@ func init() {
 	})
 }
 
-func NewConfigurationWithDefaults(t *testing.T) *config.Config {
+func NewConfigurationWithDefaults(t testing.TB) *config.Config {
 	c := config.MustNew(t, logrusx.New("", ""),
 		configx.WithValues(map[string]interface{}{
 			"log.level":                                      "trace",
@@ -50,7 +50,7 @@ func NewConfigurationWithDefaults(t *testing.T) *config.Config {
 
 // NewFastRegistryWithMocks returns a registry with several mocks and an SQLite in memory database that make testing
 // easier and way faster. This suite does not work for e2e or advanced integration tests.
-func NewFastRegistryWithMocks(t *testing.T) (*config.Config, *driver.RegistryDefault) {
+func NewFastRegistryWithMocks(t testing.TB) (*config.Config, *driver.RegistryDefault) {
 	conf, reg := NewRegistryDefaultWithDSN(t, "")
 	reg.WithCSRFTokenGenerator(x.FakeCSRFTokenGenerator)
 	reg.WithCSRFHandler(x.NewFakeCSRFHandler(""))
@@ -66,7 +66,7 @@ func NewFastRegistryWithMocks(t *testing.T) (*config.Config, *driver.RegistryDef
 }
 
 // NewRegistryDefaultWithDSN returns a more standard registry without mocks. Good for e2e and advanced integration testing!
-func NewRegistryDefaultWithDSN(t *testing.T, dsn string) (*config.Config, *driver.RegistryDefault) {
+func NewRegistryDefaultWithDSN(t testing.TB, dsn string) (*config.Config, *driver.RegistryDefault) {
 	c := NewConfigurationWithDefaults(t)
 	c.MustSet(config.ViperKeyDSN, stringsx.Coalesce(dsn, dbal.SQLiteInMemory))
 
@@ -13,18 +13,26 @@ package kratos
 
 import (
 	"encoding/json"
+	"time"
 )
 
 // Identity struct for Identity
 type Identity struct {
-	Id string `json:"id"`
+	// CreatedAt is the time at which the identity was created. It can be set when importing identities.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Id        string     `json:"id"`
 	// RecoveryAddresses contains all the addresses that can be used to recover an identity.
 	RecoveryAddresses []RecoveryAddress `json:"recovery_addresses,omitempty"`
 	// SchemaID is the ID of the JSON Schema to be used for validating the identity's traits.
 	SchemaId string `json:"schema_id"`
 	// SchemaURL is the URL of the endpoint where the identity's traits schema can be fetched from.  format: url
-	SchemaUrl string                 `json:"schema_url"`
-	Traits    map[string]interface{} `json:"traits"`
+	SchemaUrl string  `json:"schema_url"`
+	State     *string `json:"state,omitempty"`
+	// StateChangedAt is the time at which the identity's state was last changed.
+	StateChangedAt *time.Time             `json:"state_changed_at,omitempty"`
+	Traits         map[string]interface{} `json:"traits"`
+	// UpdatedAt is the time at which the identity was last updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
 	// VerifiableAddresses contains all the addresses that can be verified by the user.
 	VerifiableAddresses []VerifiableAddress `json:"verifiable_addresses,omitempty"`
 }
@@ -50,6 +58,38 @@ func NewIdentityWithDefaults() *Identity {
 	return &this
 }
 
+// GetCreatedAt returns the CreatedAt field value if set, zero value otherwise.
+func (o *Identity) GetCreatedAt() time.Time {
+	if o == nil || o.CreatedAt == nil {
+		var ret time.Time
+		return ret
+	}
+	return *o.CreatedAt
+}
+
+// GetCreatedAtOk returns a tuple with the CreatedAt field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Identity) GetCreatedAtOk() (*time.Time, bool) {
+	if o == nil || o.CreatedAt == nil {
+		return nil, false
+	}
+	return o.CreatedAt, true
+}
+
+// HasCreatedAt returns a boolean if a field has been set.
+func (o *Identity) HasCreatedAt() bool {
+	if o != nil && o.CreatedAt != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetCreatedAt gets a reference to the given time.Time and assigns it to the CreatedAt field.
+func (o *Identity) SetCreatedAt(v time.Time) {
+	o.CreatedAt = &v
+}
+
 // GetId returns the Id field value
 func (o *Identity) GetId() string {
 	if o == nil {
@@ -154,6 +194,70 @@ func (o *Identity) SetSchemaUrl(v string) {
 	o.SchemaUrl = v
 }
 
+// GetState returns the State field value if set, zero value otherwise.
+func (o *Identity) GetState() string {
+	if o == nil || o.State == nil {
+		var ret string
+		return ret
+	}
+	return *o.State
+}
+
+// GetStateOk returns a tuple with the State field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Identity) GetStateOk() (*string, bool) {
+	if o == nil || o.State == nil {
+		return nil, false
+	}
+	return o.State, true
+}
+
+// HasState returns a boolean if a field has been set.
+func (o *Identity) HasState() bool {
+	if o != nil && o.State != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetState gets a reference to the given string and assigns it to the State field.
+func (o *Identity) SetState(v string) {
+	o.State = &v
+}
+
+// GetStateChangedAt returns the StateChangedAt field value if set, zero value otherwise.
+func (o *Identity) GetStateChangedAt() time.Time {
+	if o == nil || o.StateChangedAt == nil {
+		var ret time.Time
+		return ret
+	}
+	return *o.StateChangedAt
+}
+
+// GetStateChangedAtOk returns a tuple with the StateChangedAt field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Identity) GetStateChangedAtOk() (*time.Time, bool) {
+	if o == nil || o.StateChangedAt == nil {
+		return nil, false
+	}
+	return o.StateChangedAt, true
+}
+
+// HasStateChangedAt returns a boolean if a field has been set.
+func (o *Identity) HasStateChangedAt() bool {
+	if o != nil && o.StateChangedAt != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetStateChangedAt gets a reference to the given time.Time and assigns it to the StateChangedAt field.
+func (o *Identity) SetStateChangedAt(v time.Time) {
+	o.StateChangedAt = &v
+}
+
 // GetTraits returns the Traits field value
 func (o *Identity) GetTraits() map[string]interface{} {
 	if o == nil {
@@ -178,6 +282,38 @@ func (o *Identity) SetTraits(v map[string]interface{}) {
 	o.Traits = v
 }
 
+// GetUpdatedAt returns the UpdatedAt field value if set, zero value otherwise.
+func (o *Identity) GetUpdatedAt() time.Time {
+	if o == nil || o.UpdatedAt == nil {
+		var ret time.Time
+		return ret
+	}
+	return *o.UpdatedAt
+}
+
+// GetUpdatedAtOk returns a tuple with the UpdatedAt field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Identity) GetUpdatedAtOk() (*time.Time, bool) {
+	if o == nil || o.UpdatedAt == nil {
+		return nil, false
+	}
+	return o.UpdatedAt, true
+}
+
+// HasUpdatedAt returns a boolean if a field has been set.
+func (o *Identity) HasUpdatedAt() bool {
+	if o != nil && o.UpdatedAt != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetUpdatedAt gets a reference to the given time.Time and assigns it to the UpdatedAt field.
+func (o *Identity) SetUpdatedAt(v time.Time) {
+	o.UpdatedAt = &v
+}
+
 // GetVerifiableAddresses returns the VerifiableAddresses field value if set, zero value otherwise.
 func (o *Identity) GetVerifiableAddresses() []VerifiableAddress {
 	if o == nil || o.VerifiableAddresses == nil {
@@ -212,6 +348,9 @@ func (o *Identity) SetVerifiableAddresses(v []VerifiableAddress) {
 
 func (o Identity) MarshalJSON() ([]byte, error) {
 	toSerialize := map[string]interface{}{}
+	if o.CreatedAt != nil {
+		toSerialize["created_at"] = o.CreatedAt
+	}
 	if true {
 		toSerialize["id"] = o.Id
 	}
@@ -224,9 +363,18 @@ func (o Identity) MarshalJSON() ([]byte, error) {
 	if true {
 		toSerialize["schema_url"] = o.SchemaUrl
 	}
+	if o.State != nil {
+		toSerialize["state"] = o.State
+	}
+	if o.StateChangedAt != nil {
+		toSerialize["state_changed_at"] = o.StateChangedAt
+	}
 	if true {
 		toSerialize["traits"] = o.Traits
 	}
+	if o.UpdatedAt != nil {
+		toSerialize["updated_at"] = o.UpdatedAt
+	}
 	if o.VerifiableAddresses != nil {
 		toSerialize["verifiable_addresses"] = o.VerifiableAddresses
 	}
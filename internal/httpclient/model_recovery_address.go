@@ -17,9 +17,13 @@ import (
 
 // RecoveryAddress struct for RecoveryAddress
 type RecoveryAddress struct {
-	Id    string `json:"id"`
-	Value string `json:"value"`
-	Via   string `json:"via"`
+	// Disabled is true if the address can not be used to recover the identity.
+	Disabled *bool  `json:"disabled,omitempty"`
+	Id       string `json:"id"`
+	// Source is either `schema` if the address was derived from the identity's traits, or `admin` if it was added using the admin API.
+	Source *string `json:"source,omitempty"`
+	Value  string  `json:"value"`
+	Via    string  `json:"via"`
 }
 
 // NewRecoveryAddress instantiates a new RecoveryAddress object
@@ -42,6 +46,38 @@ func NewRecoveryAddressWithDefaults() *RecoveryAddress {
 	return &this
 }
 
+// GetDisabled returns the Disabled field value if set, zero value otherwise.
+func (o *RecoveryAddress) GetDisabled() bool {
+	if o == nil || o.Disabled == nil {
+		var ret bool
+		return ret
+	}
+	return *o.Disabled
+}
+
+// GetDisabledOk returns a tuple with the Disabled field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *RecoveryAddress) GetDisabledOk() (*bool, bool) {
+	if o == nil || o.Disabled == nil {
+		return nil, false
+	}
+	return o.Disabled, true
+}
+
+// HasDisabled returns a boolean if a field has been set.
+func (o *RecoveryAddress) HasDisabled() bool {
+	if o != nil && o.Disabled != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetDisabled gets a reference to the given bool and assigns it to the Disabled field.
+func (o *RecoveryAddress) SetDisabled(v bool) {
+	o.Disabled = &v
+}
+
 // GetId returns the Id field value
 func (o *RecoveryAddress) GetId() string {
 	if o == nil {
@@ -66,6 +102,38 @@ func (o *RecoveryAddress) SetId(v string) {
 	o.Id = v
 }
 
+// GetSource returns the Source field value if set, zero value otherwise.
+func (o *RecoveryAddress) GetSource() string {
+	if o == nil || o.Source == nil {
+		var ret string
+		return ret
+	}
+	return *o.Source
+}
+
+// GetSourceOk returns a tuple with the Source field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *RecoveryAddress) GetSourceOk() (*string, bool) {
+	if o == nil || o.Source == nil {
+		return nil, false
+	}
+	return o.Source, true
+}
+
+// HasSource returns a boolean if a field has been set.
+func (o *RecoveryAddress) HasSource() bool {
+	if o != nil && o.Source != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetSource gets a reference to the given string and assigns it to the Source field.
+func (o *RecoveryAddress) SetSource(v string) {
+	o.Source = &v
+}
+
 // GetValue returns the Value field value
 func (o *RecoveryAddress) GetValue() string {
 	if o == nil {
@@ -116,9 +184,15 @@ func (o *RecoveryAddress) SetVia(v string) {
 
 func (o RecoveryAddress) MarshalJSON() ([]byte, error) {
 	toSerialize := map[string]interface{}{}
+	if o.Disabled != nil {
+		toSerialize["disabled"] = o.Disabled
+	}
 	if true {
 		toSerialize["id"] = o.Id
 	}
+	if o.Source != nil {
+		toSerialize["source"] = o.Source
+	}
 	if true {
 		toSerialize["value"] = o.Value
 	}
@@ -19,6 +19,7 @@ import (
 type UpdateIdentity struct {
 	// SchemaID is the ID of the JSON Schema to be used for validating the identity's traits. If set will update the Identity's SchemaID.
 	SchemaId *string `json:"schema_id,omitempty"`
+	State    *string `json:"state,omitempty"`
 	// Traits represent an identity's traits. The identity is able to create, modify, and delete traits in a self-service manner. The input will always be validated against the JSON Schema defined in `schema_id`.
 	Traits map[string]interface{} `json:"traits"`
 }
@@ -73,6 +74,38 @@ func (o *UpdateIdentity) SetSchemaId(v string) {
 	o.SchemaId = &v
 }
 
+// GetState returns the State field value if set, zero value otherwise.
+func (o *UpdateIdentity) GetState() string {
+	if o == nil || o.State == nil {
+		var ret string
+		return ret
+	}
+	return *o.State
+}
+
+// GetStateOk returns a tuple with the State field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *UpdateIdentity) GetStateOk() (*string, bool) {
+	if o == nil || o.State == nil {
+		return nil, false
+	}
+	return o.State, true
+}
+
+// HasState returns a boolean if a field has been set.
+func (o *UpdateIdentity) HasState() bool {
+	if o != nil && o.State != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetState gets a reference to the given string and assigns it to the State field.
+func (o *UpdateIdentity) SetState(v string) {
+	o.State = &v
+}
+
 // GetTraits returns the Traits field value
 func (o *UpdateIdentity) GetTraits() map[string]interface{} {
 	if o == nil {
@@ -102,6 +135,9 @@ func (o UpdateIdentity) MarshalJSON() ([]byte, error) {
 	if o.SchemaId != nil {
 		toSerialize["schema_id"] = o.SchemaId
 	}
+	if o.State != nil {
+		toSerialize["state"] = o.State
+	}
 	if true {
 		toSerialize["traits"] = o.Traits
 	}
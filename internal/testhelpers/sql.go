@@ -8,9 +8,17 @@ import (
 
 	"github.com/ory/kratos/selfservice/errorx"
 
+	"github.com/ory/kratos/approval"
+	"github.com/ory/kratos/attempt"
+	"github.com/ory/kratos/cipher"
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/geo"
+	"github.com/ory/kratos/idempotency"
 	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/inactivity"
+	"github.com/ory/kratos/job"
+	"github.com/ory/kratos/selfservice/flow/callback"
 	"github.com/ory/kratos/selfservice/flow/login"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/flow/registration"
@@ -23,16 +31,24 @@ import (
 func CleanSQL(t *testing.T, c *pop.Connection) {
 	ctx := context.Background()
 	for _, table := range []string{
+		new(attempt.Attempt).TableName(ctx),
 		new(continuity.Container).TableName(ctx),
 		new(courier.Message).TableName(ctx),
+		new(courier.DeliveryEvent).TableName(ctx),
+		new(idempotency.Record).TableName(ctx),
+		new(job.Run).TableName(ctx),
+		new(job.Lock).TableName(ctx),
 
+		new(geo.Challenge).TableName(ctx),
 		new(login.Flow).TableName(ctx),
 		new(registration.Flow).TableName(ctx),
 		new(settings.Flow).TableName(ctx),
 
+		new(link.ExpiryNotification).TableName(ctx),
 		new(link.RecoveryToken).TableName(ctx),
 		new(link.VerificationToken).TableName(ctx),
 
+		new(callback.Callback).TableName(ctx),
 		new(recovery.Flow).TableName(ctx),
 
 		new(verification.Flow).TableName(ctx),
@@ -40,6 +56,14 @@ func CleanSQL(t *testing.T, c *pop.Connection) {
 		new(errorx.ErrorContainer).TableName(ctx),
 
 		new(session.Session).TableName(ctx),
+		new(courier.Preferences).TableName(ctx),
+		new(geo.IdentityPolicy).TableName(ctx),
+		new(cipher.DataKey).TableName(ctx),
+		new(approval.Approval).TableName(ctx),
+		new(identity.Note).TableName(ctx),
+		new(identity.Relationship).TableName(ctx),
+		new(identity.ScheduledStateChange).TableName(ctx),
+		new(inactivity.Notification).TableName(ctx),
 		new(identity.CredentialIdentifierCollection).TableName(ctx),
 		new(identity.CredentialsCollection).TableName(ctx),
 		new(identity.VerifiableAddress).TableName(ctx),
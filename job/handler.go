@@ -0,0 +1,126 @@
+package job
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const RouteRuns = "/jobs/runs"
+
+type (
+	handlerDependencies interface {
+		PersistenceProvider
+		config.Provider
+		x.WriterProvider
+	}
+	HandlerProvider interface {
+		JobHandler() *Handler
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteRuns, h.listRuns)
+}
+
+// A list of job runs.
+// swagger:response jobRunList
+// nolint:deadcode,unused
+type jobRunListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []Run
+}
+
+// swagger:parameters listJobRuns
+// nolint:deadcode,unused
+type listJobRunsParameters struct {
+	// Items per Page
+	//
+	// This is the number of items per page.
+	//
+	// required: false
+	// in: query
+	// default: 100
+	// min: 1
+	// max: 500
+	PerPage int `json:"per_page"`
+
+	// Pagination Page
+	//
+	// required: false
+	// in: query
+	// default: 0
+	// min: 0
+	Page int `json:"page"`
+
+	// Pagination Meta
+	//
+	// Wrap the list in an object with the keys `items` and `meta`, which contains the pagination meta data
+	// otherwise only sent in the `Link` and `X-Total-Count` headers. Sending the header
+	// `Accept: application/json; profile="pagination-meta"` has the same effect.
+	//
+	// required: false
+	// in: query
+	Meta bool `json:"meta"`
+
+	// Job ID
+	//
+	// Only return the runs of this job.
+	//
+	// required: false
+	// in: query
+	JobID string `json:"job_id"`
+}
+
+// swagger:route GET /jobs/runs admin listJobRuns
+//
+// List Background Job Runs
+//
+// This endpoint returns the runs of the background jobs configured at `jobs.schedules`, starting with the
+// most recent one. Runs which are still in progress have the status `running`.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: jobRunList
+//       500: genericError
+func (h *Handler) listRuns(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	jobID := r.URL.Query().Get("job_id")
+	page, itemsPerPage := x.ParsePagination(r)
+	runs, err := h.d.JobPersister().ListJobRuns(r.Context(), jobID, page, itemsPerPage)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	total, err := h.d.JobPersister().CountJobRuns(r.Context(), jobID)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	u := urlx.AppendPaths(h.d.Config(r.Context()).SelfAdminURL(), RouteRuns)
+	if jobID != "" {
+		u = urlx.CopyWithQuery(u, url.Values{"job_id": {jobID}})
+	}
+	x.PaginationHeader(w, u, total, page, itemsPerPage)
+	h.d.Writer().Write(w, r, x.PaginationBody(r, runs, total, page, itemsPerPage))
+}
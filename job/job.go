@@ -0,0 +1,138 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/corp"
+)
+
+// Job is background work which the scheduler runs on the schedule configured at `jobs.schedules.<id>`.
+type Job interface {
+	// ID identifies the job in the configuration and in the run history.
+	ID() string
+
+	// Run does the work. It should return once the context is canceled.
+	Run(ctx context.Context) error
+}
+
+type funcJob struct {
+	id  string
+	run func(ctx context.Context) error
+}
+
+// NewFunc returns a job which calls run.
+func NewFunc(id string, run func(ctx context.Context) error) Job {
+	return &funcJob{id: id, run: run}
+}
+
+func (j *funcJob) ID() string {
+	return j.id
+}
+
+func (j *funcJob) Run(ctx context.Context) error {
+	return j.run(ctx)
+}
+
+// RunStatus is the status of a job run.
+//
+// swagger:model jobRunStatus
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+)
+
+// Run records one execution of a job.
+//
+// swagger:model jobRun
+type Run struct {
+	// ID is the ID of the run.
+	//
+	// required: true
+	ID  uuid.UUID `json:"id" db:"id" rw:"r"`
+	NID uuid.UUID `json:"-" db:"nid"`
+
+	// JobID is the ID of the job.
+	//
+	// required: true
+	JobID string `json:"job_id" db:"job_id"`
+
+	// Status is the status of the run.
+	//
+	// required: true
+	Status RunStatus `json:"status" db:"status"`
+
+	// Instance identifies the Ory Kratos instance which executed the run.
+	//
+	// required: true
+	Instance string `json:"instance" db:"instance"`
+
+	// Error is the error the run failed with.
+	Error sqlxx.NullString `json:"error,omitempty" db:"error"`
+
+	// StartedAt is the time at which the run started.
+	//
+	// required: true
+	StartedAt time.Time `json:"started_at" db:"started_at"`
+
+	// FinishedAt is the time at which the run finished. It is not set while the run is still running.
+	FinishedAt sqlxx.NullTime `json:"finished_at" db:"finished_at"`
+
+	// CreatedAt is a helper struct field for gobuffalo.pop.
+	CreatedAt time.Time `json:"-" db:"created_at"`
+
+	// UpdatedAt is a helper struct field for gobuffalo.pop.
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+}
+
+func (r Run) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "job_runs")
+}
+
+func (r *Run) GetID() uuid.UUID {
+	return r.ID
+}
+
+func (r *Run) GetNID() uuid.UUID {
+	return r.NID
+}
+
+// Lock elects the instance which runs a job. Only the holder of an unexpired lock runs the job.
+type Lock struct {
+	ID  uuid.UUID `json:"id" db:"id" rw:"r"`
+	NID uuid.UUID `json:"-" db:"nid"`
+
+	// JobID is the ID of the job the lock is for. There is one lock per job and network.
+	JobID string `json:"job_id" db:"job_id"`
+
+	// Holder identifies the instance holding the lock.
+	Holder string `json:"holder" db:"holder"`
+
+	// LockedUntil is the time at which the lock expires unless the holder renews it.
+	LockedUntil time.Time `json:"locked_until" db:"locked_until"`
+
+	// CreatedAt is a helper struct field for gobuffalo.pop.
+	CreatedAt time.Time `json:"-" db:"created_at"`
+
+	// UpdatedAt is a helper struct field for gobuffalo.pop.
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+}
+
+func (l Lock) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "job_locks")
+}
+
+func (l *Lock) GetID() uuid.UUID {
+	return l.ID
+}
+
+func (l *Lock) GetNID() uuid.UUID {
+	return l.NID
+}
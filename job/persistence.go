@@ -0,0 +1,30 @@
+package job
+
+import (
+	"context"
+	"time"
+)
+
+type PersistenceProvider interface {
+	JobPersister() Persister
+}
+
+type Persister interface {
+	CreateJobRun(ctx context.Context, r *Run) error
+	UpdateJobRun(ctx context.Context, r *Run) error
+
+	// ListJobRuns returns the runs of the job, or of all jobs if jobID is empty, starting with the most
+	// recent one.
+	ListJobRuns(ctx context.Context, jobID string, page, itemsPerPage int) ([]Run, error)
+	CountJobRuns(ctx context.Context, jobID string) (int64, error)
+
+	// DeleteJobRunsBefore removes all runs started before the given time and returns how many were removed.
+	DeleteJobRunsBefore(ctx context.Context, before time.Time) (int, error)
+
+	// AcquireJobLock acquires or renews the lock of the job for holder until the given time. It returns
+	// false if another holder has an unexpired lock.
+	AcquireJobLock(ctx context.Context, jobID, holder string, until time.Time) (bool, error)
+
+	// ReleaseJobLock releases the lock of the job if holder holds it.
+	ReleaseJobLock(ctx context.Context, jobID, holder string) error
+}
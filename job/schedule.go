@@ -0,0 +1,172 @@
+package job
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schedule computes when a job runs next.
+type Schedule interface {
+	// Next returns the first time after t at which the job runs.
+	Next(t time.Time) time.Time
+}
+
+type (
+	intervalSchedule struct {
+		every time.Duration
+	}
+
+	// cronSchedule is a five field cron expression (minute, hour, day of month, month, day of week)
+	// evaluated in UTC.
+	cronSchedule struct {
+		minute, hour, dom, month, dow uint64
+		anyDOM, anyDOW                bool
+	}
+)
+
+var descriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// ParseSchedule parses a cron expression with five fields, one of the descriptors `@hourly`, `@daily`,
+// `@weekly`, and `@monthly`, or an interval such as `@every 10m`. Fields support `*`, single values,
+// ranges (`1-5`), lists (`1,15`), and steps (`*/15`, `0-30/10`).
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse interval of schedule %q", spec)
+		} else if every < time.Second {
+			return nil, errors.Errorf("the interval of schedule %q must be at least one second", spec)
+		}
+		return &intervalSchedule{every: every}, nil
+	}
+
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("schedule %q must have five fields but has %d", spec, len(fields))
+	}
+
+	var (
+		s   cronSchedule
+		err error
+	)
+	for k, f := range []struct {
+		bits     *uint64
+		min, max int
+	}{
+		{bits: &s.minute, min: 0, max: 59},
+		{bits: &s.hour, min: 0, max: 23},
+		{bits: &s.dom, min: 1, max: 31},
+		{bits: &s.month, min: 1, max: 12},
+		{bits: &s.dow, min: 0, max: 7},
+	} {
+		if *f.bits, err = parseField(fields[k], f.min, f.max); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse schedule %q", spec)
+		}
+	}
+
+	// Sunday is both 0 and 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.anyDOM = strings.HasPrefix(fields[2], "*")
+	s.anyDOW = strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, errors.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, errors.Errorf("invalid value %q", part)
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, errors.Errorf("invalid range %q", part)
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+
+		if from < min || to > max || from > to {
+			return 0, errors.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (s *intervalSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(time.Second).Add(s.every)
+}
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+
+	// Every valid expression matches at least once within five years (February 29th).
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchesDay follows cron semantics: if both day of month and day of week are restricted, either one
+// has to match.
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.anyDOM && s.anyDOW:
+		return true
+	case s.anyDOM:
+		return dow
+	case s.anyDOW:
+		return dom
+	}
+	return dom || dow
+}
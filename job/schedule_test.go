@@ -0,0 +1,60 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2021, 5, 12, 10, 17, 42, 0, time.UTC)
+
+	for k, tc := range []struct {
+		spec     string
+		expected time.Time
+	}{
+		{spec: "* * * * *", expected: time.Date(2021, 5, 12, 10, 18, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", expected: time.Date(2021, 5, 12, 10, 30, 0, 0, time.UTC)},
+		{spec: "5,50 * * * *", expected: time.Date(2021, 5, 12, 10, 50, 0, 0, time.UTC)},
+		{spec: "0 9-17 * * *", expected: time.Date(2021, 5, 12, 11, 0, 0, 0, time.UTC)},
+		{spec: "30 2 * * *", expected: time.Date(2021, 5, 13, 2, 30, 0, 0, time.UTC)},
+		{spec: "0 0 1 * *", expected: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 1-5", expected: time.Date(2021, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", expected: time.Date(2021, 5, 16, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 13 * 5", expected: time.Date(2021, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", expected: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", expected: time.Date(2021, 5, 12, 11, 0, 0, 0, time.UTC)},
+		{spec: "@daily", expected: time.Date(2021, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{spec: "@weekly", expected: time.Date(2021, 5, 16, 0, 0, 0, 0, time.UTC)},
+		{spec: "@every 90s", expected: time.Date(2021, 5, 12, 10, 19, 12, 0, time.UTC)},
+	} {
+		t.Run("spec="+tc.spec, func(t *testing.T) {
+			s, err := ParseSchedule(tc.spec)
+			require.NoError(t, err, "%d", k)
+			assert.Equal(t, tc.expected, s.Next(now))
+		})
+	}
+
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 10",
+		"@every 10ms",
+		"@yearly",
+	} {
+		t.Run("invalid="+spec, func(t *testing.T) {
+			_, err := ParseSchedule(spec)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package job
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+type (
+	schedulerDependencies interface {
+		PersistenceProvider
+		config.Provider
+		x.LoggingProvider
+	}
+	SchedulerProvider interface {
+		JobScheduler() *Scheduler
+	}
+	// Scheduler runs the registered jobs on the schedules configured at `jobs.schedules`. Instances sharing
+	// a database elect the instance which runs a job using a lock stored in the database.
+	Scheduler struct {
+		d        schedulerDependencies
+		jobs     []Job
+		instance string
+
+		mu      sync.Mutex
+		running map[string]bool
+	}
+
+	scheduled struct {
+		spec     string
+		schedule Schedule
+		next     time.Time
+	}
+)
+
+func NewScheduler(d schedulerDependencies, jobs ...Job) *Scheduler {
+	hostname, _ := os.Hostname()
+	return &Scheduler{
+		d:        d,
+		jobs:     jobs,
+		instance: hostname + "-" + x.NewUUID().String()[:8],
+		running:  map[string]bool{},
+	}
+}
+
+// Jobs returns all registered jobs.
+func (s *Scheduler) Jobs() []Job {
+	return s.jobs
+}
+
+// Execute runs the job now unless another instance is running it, and records the run. The returned run is
+// nil if another instance holds the lock of the job. Errors of the job itself are recorded in the run
+// instead of being returned.
+func (s *Scheduler) Execute(ctx context.Context, j Job) (*Run, error) {
+	if !s.markRunning(j.ID()) {
+		return nil, nil
+	}
+	defer s.markDone(j.ID())
+
+	ttl := s.d.Config(ctx).JobLockTTL()
+	if acquired, err := s.d.JobPersister().AcquireJobLock(ctx, j.ID(), s.instance, time.Now().UTC().Add(ttl)); err != nil {
+		return nil, err
+	} else if !acquired {
+		return nil, nil
+	}
+	defer func() {
+		if err := s.d.JobPersister().ReleaseJobLock(ctx, j.ID(), s.instance); err != nil {
+			s.d.Logger().WithError(err).WithField("job_id", j.ID()).Warn("Unable to release the lock of the job.")
+		}
+	}()
+
+	run := &Run{
+		ID:        x.NewUUID(),
+		JobID:     j.ID(),
+		Status:    RunStatusRunning,
+		Instance:  s.instance,
+		StartedAt: time.Now().UTC(),
+	}
+	if err := s.d.JobPersister().CreateJobRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	go s.renewLock(jobCtx, cancel, j.ID(), ttl)
+	err := j.Run(jobCtx)
+	cancel()
+
+	run.FinishedAt = sqlxx.NullTime(time.Now().UTC())
+	run.Status = RunStatusSucceeded
+	if err != nil {
+		run.Status = RunStatusFailed
+		run.Error = sqlxx.NullString(err.Error())
+	}
+	if err := s.d.JobPersister().UpdateJobRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// renewLock renews the lock of the job until the context is canceled. If the lock was lost, for example
+// because renewing it failed for longer than its time to live, the job is canceled.
+func (s *Scheduler) renewLock(ctx context.Context, cancel context.CancelFunc, jobID string, ttl time.Duration) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		acquired, err := s.d.JobPersister().AcquireJobLock(ctx, jobID, s.instance, time.Now().UTC().Add(ttl))
+		if err != nil {
+			s.d.Logger().WithError(err).WithField("job_id", jobID).Warn("Unable to renew the lock of the job.")
+			continue
+		} else if !acquired {
+			s.d.Logger().WithField("job_id", jobID).Warn("Another instance took over the lock of the job, canceling it.")
+			cancel()
+			return
+		}
+	}
+}
+
+func (s *Scheduler) markRunning(jobID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[jobID] {
+		return false
+	}
+	s.running[jobID] = true
+	return true
+}
+
+func (s *Scheduler) markDone(jobID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, jobID)
+}
+
+// Work runs the jobs whenever they are due until the context is canceled. Schedules are read from the
+// configuration on every check, so changes apply without a restart.
+func (s *Scheduler) Work(ctx context.Context) {
+	schedules := map[string]*scheduled{}
+	for {
+		now := time.Now().UTC()
+		specs := s.d.Config(ctx).JobSchedules()
+		for _, j := range s.jobs {
+			current, err := s.schedule(schedules[j.ID()], specs[j.ID()], now)
+			if err != nil {
+				s.d.Logger().WithError(err).WithField("job_id", j.ID()).Error("Unable to parse the schedule of the job.")
+			}
+			schedules[j.ID()] = current
+			if current == nil || current.schedule == nil || now.Before(current.next) {
+				continue
+			}
+
+			current.next = current.schedule.Next(now)
+			go s.work(ctx, j)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// schedule returns the schedule of the job, reusing the previous one unless the configuration changed.
+func (s *Scheduler) schedule(previous *scheduled, spec string, now time.Time) (*scheduled, error) {
+	if spec == "" {
+		return nil, nil
+	} else if previous != nil && previous.spec == spec {
+		return previous, nil
+	}
+
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		// Remember the invalid spec to report it only once.
+		return &scheduled{spec: spec}, err
+	}
+	return &scheduled{spec: spec, schedule: schedule, next: schedule.Next(now)}, nil
+}
+
+func (s *Scheduler) work(ctx context.Context, j Job) {
+	l := s.d.Logger().WithField("job_id", j.ID())
+	run, err := s.Execute(ctx, j)
+	if err != nil {
+		l.WithError(err).Error("Unable to run the job.")
+		return
+	} else if run == nil {
+		l.Debug("Skipped the job because another instance is running it.")
+		return
+	}
+
+	l = l.WithField("job_run_id", run.ID)
+	if run.Status == RunStatusFailed {
+		l.WithField("reason", run.Error).Error("The job failed.")
+	} else {
+		l.Debug("The job succeeded.")
+	}
+
+	deleted, err := s.d.JobPersister().DeleteJobRunsBefore(ctx, time.Now().UTC().Add(-s.d.Config(ctx).JobHistoryLifespan()))
+	if err != nil {
+		l.WithError(err).Warn("Unable to remove old job runs.")
+	} else if deleted > 0 {
+		l.WithField("deleted", deleted).Debug("Removed old job runs.")
+	}
+}
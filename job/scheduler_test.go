@@ -0,0 +1,110 @@
+package job_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/job"
+	"github.com/ory/kratos/x"
+)
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+
+	t.Run("case=records successful and failed runs", func(t *testing.T) {
+		s := job.NewScheduler(reg)
+
+		run, err := s.Execute(ctx, job.NewFunc("succeeds", func(context.Context) error { return nil }))
+		require.NoError(t, err)
+		require.NotNil(t, run)
+		assert.Equal(t, job.RunStatusSucceeded, run.Status)
+		assert.False(t, time.Time(run.FinishedAt).IsZero())
+
+		run, err = s.Execute(ctx, job.NewFunc("fails", func(context.Context) error { return errors.New("oh no") }))
+		require.NoError(t, err)
+		require.NotNil(t, run)
+		assert.Equal(t, job.RunStatusFailed, run.Status)
+		assert.EqualValues(t, "oh no", run.Error)
+
+		runs, err := reg.JobPersister().ListJobRuns(ctx, "fails", 0, 10)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, run.ID, runs[0].ID)
+		assert.Equal(t, job.RunStatusFailed, runs[0].Status)
+	})
+
+	t.Run("case=skips jobs locked by another instance", func(t *testing.T) {
+		acquired, err := reg.JobPersister().AcquireJobLock(ctx, "locked", "another-instance", time.Now().UTC().Add(time.Hour))
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		var called bool
+		run, err := job.NewScheduler(reg).Execute(ctx, job.NewFunc("locked", func(context.Context) error {
+			called = true
+			return nil
+		}))
+		require.NoError(t, err)
+		assert.Nil(t, run)
+		assert.False(t, called)
+	})
+
+	t.Run("case=runs scheduled jobs", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyJobsSchedules, map[string]string{"scheduled": "@every 1s"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyJobsSchedules, map[string]string{})
+		})
+
+		var calls int32
+		s := job.NewScheduler(reg,
+			job.NewFunc("scheduled", func(context.Context) error {
+				atomic.AddInt32(&calls, 1)
+				return nil
+			}),
+			job.NewFunc("unscheduled", func(context.Context) error {
+				t.Error("the job must not run without a schedule")
+				return nil
+			}),
+		)
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go s.Work(ctx)
+
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt32(&calls) >= 2
+		}, 10*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("case=lists runs", func(t *testing.T) {
+		_, err := job.NewScheduler(reg).Execute(ctx, job.NewFunc("listed", func(context.Context) error { return nil }))
+		require.NoError(t, err)
+
+		router := x.NewRouterAdmin()
+		reg.JobHandler().RegisterAdminRoutes(router)
+		ts := httptest.NewServer(router)
+		t.Cleanup(ts.Close)
+
+		res, err := ts.Client().Get(ts.URL + job.RouteRuns + "?job_id=listed")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var runs []job.Run
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&runs))
+		require.Len(t, runs, 1)
+		assert.Equal(t, "listed", runs[0].JobID)
+		assert.Equal(t, job.RunStatusSucceeded, runs[0].Status)
+	})
+}
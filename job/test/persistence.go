@@ -0,0 +1,132 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/job"
+	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/x"
+)
+
+func TestPersister(ctx context.Context, p persistence.Persister) func(t *testing.T) {
+	var newRun = func(jobID string, startedAt time.Time) *job.Run {
+		return &job.Run{
+			ID:        x.NewUUID(),
+			JobID:     jobID,
+			Status:    job.RunStatusRunning,
+			Instance:  "instance",
+			StartedAt: startedAt.UTC().Truncate(time.Second),
+		}
+	}
+
+	var ids = func(runs []job.Run) (ids []string) {
+		for _, r := range runs {
+			ids = append(ids, r.ID.String())
+		}
+		return ids
+	}
+
+	return func(t *testing.T) {
+		nid, p := testhelpers.NewNetworkUnlessExisting(t, ctx, p)
+		now := time.Now().UTC()
+
+		t.Run("case=create, update, and list runs", func(t *testing.T) {
+			jobID := x.NewUUID().String()
+			older := newRun(jobID, now.Add(-time.Hour))
+			newer := newRun(jobID, now)
+			other := newRun(x.NewUUID().String(), now)
+			for _, r := range []*job.Run{older, newer, other} {
+				require.NoError(t, p.CreateJobRun(ctx, r))
+				assert.Equal(t, nid, r.NID)
+			}
+
+			newer.Status = job.RunStatusFailed
+			newer.Error = "something went wrong"
+			newer.FinishedAt = sqlxx.NullTime(now.Add(time.Minute).Truncate(time.Second))
+			require.NoError(t, p.UpdateJobRun(ctx, newer))
+
+			actual, err := p.ListJobRuns(ctx, jobID, 0, 10)
+			require.NoError(t, err)
+			assert.Equal(t, []string{newer.ID.String(), older.ID.String()}, ids(actual))
+			assert.Equal(t, job.RunStatusFailed, actual[0].Status)
+			assert.EqualValues(t, "something went wrong", actual[0].Error)
+			assert.Equal(t, time.Time(newer.FinishedAt).Unix(), time.Time(actual[0].FinishedAt).Unix())
+			assert.True(t, time.Time(actual[1].FinishedAt).IsZero())
+
+			count, err := p.CountJobRuns(ctx, jobID)
+			require.NoError(t, err)
+			assert.EqualValues(t, 2, count)
+
+			all, err := p.ListJobRuns(ctx, "", 0, 500)
+			require.NoError(t, err)
+			assert.Subset(t, ids(all), []string{newer.ID.String(), older.ID.String(), other.ID.String()})
+
+			page, err := p.ListJobRuns(ctx, jobID, 1, 1)
+			require.NoError(t, err)
+			assert.Equal(t, []string{older.ID.String()}, ids(page))
+		})
+
+		t.Run("case=deletes old runs", func(t *testing.T) {
+			jobID := x.NewUUID().String()
+			old := newRun(jobID, now.Add(-48*time.Hour))
+			recent := newRun(jobID, now)
+			require.NoError(t, p.CreateJobRun(ctx, old))
+			require.NoError(t, p.CreateJobRun(ctx, recent))
+
+			deleted, err := p.DeleteJobRunsBefore(ctx, now.Add(-24*time.Hour))
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, deleted, 1)
+
+			actual, err := p.ListJobRuns(ctx, jobID, 0, 10)
+			require.NoError(t, err)
+			assert.Equal(t, []string{recent.ID.String()}, ids(actual))
+		})
+
+		t.Run("case=elects one lock holder", func(t *testing.T) {
+			jobID := x.NewUUID().String()
+
+			acquired, err := p.AcquireJobLock(ctx, jobID, "a", now.Add(time.Hour))
+			require.NoError(t, err)
+			assert.True(t, acquired)
+
+			acquired, err = p.AcquireJobLock(ctx, jobID, "b", now.Add(time.Hour))
+			require.NoError(t, err)
+			assert.False(t, acquired, "the lock is held by a")
+
+			acquired, err = p.AcquireJobLock(ctx, jobID, "a", now.Add(2*time.Hour))
+			require.NoError(t, err)
+			assert.True(t, acquired, "the holder can renew the lock")
+
+			require.NoError(t, p.ReleaseJobLock(ctx, jobID, "b"))
+			acquired, err = p.AcquireJobLock(ctx, jobID, "b", now.Add(time.Hour))
+			require.NoError(t, err)
+			assert.False(t, acquired, "only the holder can release the lock")
+
+			require.NoError(t, p.ReleaseJobLock(ctx, jobID, "a"))
+			time.Sleep(time.Second)
+			acquired, err = p.AcquireJobLock(ctx, jobID, "b", now.Add(time.Hour))
+			require.NoError(t, err)
+			assert.True(t, acquired, "the released lock can be acquired")
+		})
+
+		t.Run("case=takes over expired locks", func(t *testing.T) {
+			jobID := x.NewUUID().String()
+
+			acquired, err := p.AcquireJobLock(ctx, jobID, "a", now.Add(-time.Minute))
+			require.NoError(t, err)
+			assert.True(t, acquired)
+
+			acquired, err = p.AcquireJobLock(ctx, jobID, "b", now.Add(time.Hour))
+			require.NoError(t, err)
+			assert.True(t, acquired)
+		})
+	}
+}
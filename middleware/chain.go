@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/negroni"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/ratelimit"
+	"github.com/ory/kratos/reputation"
+	"github.com/ory/kratos/x"
+)
+
+var (
+	ErrIPDenied        = herodot.ErrForbidden.WithReason("Requests from this IP address are not allowed.")
+	ErrCaptchaRequired = herodot.ErrForbidden.WithReason("A valid CAPTCHA response is required in the captcha_response field.")
+)
+
+type (
+	chainDependencies interface {
+		config.Provider
+		x.ClockProvider
+		x.LoggingProvider
+		x.WriterProvider
+		ratelimit.StoreProvider
+		reputation.Provider
+	}
+	Provider interface {
+		PublicMiddleware() *Chain
+	}
+	// Chain runs the middlewares configured at `serve.public.middleware` in order before requests reach the
+	// public router.
+	Chain struct {
+		d chainDependencies
+	}
+
+	rateLimitConfig struct {
+		Burst    int    `json:"burst"`
+		Interval string `json:"interval"`
+	}
+	captchaConfig struct {
+		FlaggedOnly *bool    `json:"flagged_only"`
+		Methods     []string `json:"methods"`
+	}
+	ipFilterConfig struct {
+		Allow []string `json:"allow"`
+		Deny  []string `json:"deny"`
+	}
+	headersConfig struct {
+		Set map[string]string `json:"set"`
+	}
+)
+
+var _ negroni.Handler = new(Chain)
+
+func NewChain(d chainDependencies) *Chain {
+	return &Chain{d: d}
+}
+
+func (c *Chain) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	conf := c.d.Config(r.Context())
+	for k, m := range conf.PublicMiddleware() {
+		if !matchesPath(m.PathPrefixes, r.URL.Path) {
+			continue
+		}
+
+		if err := c.apply(w, r, k, m); err != nil {
+			c.d.Writer().WriteError(w, r, err)
+			return
+		}
+	}
+
+	next(w, r)
+}
+
+// apply runs the middleware. If the request must not reach the router, an error is returned. Middlewares which
+// are configured incorrectly are skipped.
+func (c *Chain) apply(w http.ResponseWriter, r *http.Request, index int, m config.PublicMiddleware) error {
+	var err error
+	switch m.Name {
+	case config.PublicMiddlewareRateLimit:
+		var mc rateLimitConfig
+		if err = decodeConfig(m, &mc); err == nil {
+			err = c.rateLimit(r, index, mc)
+		}
+	case config.PublicMiddlewareCaptcha:
+		var mc captchaConfig
+		if err = decodeConfig(m, &mc); err == nil {
+			err = c.captcha(r, mc)
+		}
+	case config.PublicMiddlewareIPFilter:
+		var mc ipFilterConfig
+		if err = decodeConfig(m, &mc); err == nil {
+			err = c.ipFilter(r, mc)
+		}
+	case config.PublicMiddlewareHeaders:
+		var mc headersConfig
+		if err = decodeConfig(m, &mc); err == nil {
+			for name, value := range mc.Set {
+				w.Header().Set(name, value)
+			}
+		}
+	default:
+		err = &invalidConfigError{err: errors.Errorf("unknown middleware %q", m.Name)}
+	}
+
+	var invalid *invalidConfigError
+	if errors.As(err, &invalid) {
+		c.d.Logger().WithError(err).WithField("middleware", m.Name).Warnf("Skipping invalid middleware %d at %s.", index, config.ViperKeyPublicMiddleware)
+		return nil
+	}
+	return err
+}
+
+func (c *Chain) rateLimit(r *http.Request, index int, mc rateLimitConfig) error {
+	ip := reputation.ClientIP(r, c.d.Config(r.Context()).IPReputationClientIPHeader())
+	if len(ip) == 0 {
+		return nil
+	}
+
+	burst, interval := 100, time.Second
+	if mc.Burst > 0 {
+		burst = mc.Burst
+	}
+	if len(mc.Interval) > 0 {
+		d, err := time.ParseDuration(mc.Interval)
+		if err != nil {
+			return &invalidConfigError{err: err}
+		}
+		interval = d
+	}
+
+	allowed, err := c.d.RateLimitStore().Take(r.Context(), fmt.Sprintf("public_middleware:%d:ip:%s", index, ip), burst, interval, c.d.Clock().Now())
+	if err != nil {
+		return err
+	} else if !allowed {
+		return errors.WithStack(ratelimit.ErrRateLimited)
+	}
+	return nil
+}
+
+func (c *Chain) captcha(r *http.Request, mc captchaConfig) error {
+	methods := mc.Methods
+	if len(methods) == 0 {
+		methods = []string{"POST"}
+	}
+	if !containsMethod(methods, r.Method) {
+		return nil
+	}
+
+	flaggedOnly := true
+	if mc.FlaggedOnly != nil {
+		flaggedOnly = *mc.FlaggedOnly
+	}
+
+	ok, err := c.d.IPReputation().VerifyCaptcha(r, flaggedOnly)
+	if err != nil {
+		return err
+	} else if !ok {
+		return errors.WithStack(ErrCaptchaRequired)
+	}
+	return nil
+}
+
+func (c *Chain) ipFilter(r *http.Request, mc ipFilterConfig) error {
+	allow, err := parseCIDRs(mc.Allow)
+	if err != nil {
+		return err
+	}
+	deny, err := parseCIDRs(mc.Deny)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(reputation.ClientIP(r, c.d.Config(r.Context()).IPReputationClientIPHeader()))
+	if ip == nil {
+		// Requests without a client IP address can not be filtered, they only pass if there is no allow list.
+		if len(allow) > 0 {
+			return errors.WithStack(ErrIPDenied)
+		}
+		return nil
+	}
+
+	if containsIP(deny, ip) || (len(allow) > 0 && !containsIP(allow, ip)) {
+		return errors.WithStack(ErrIPDenied)
+	}
+	return nil
+}
+
+type invalidConfigError struct {
+	err error
+}
+
+func (e *invalidConfigError) Error() string {
+	return e.err.Error()
+}
+
+func decodeConfig(m config.PublicMiddleware, v interface{}) error {
+	if len(m.Config) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(m.Config, v); err != nil {
+		return &invalidConfigError{err: err}
+	}
+	return nil
+}
+
+func matchesPath(prefixes []string, path string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, &invalidConfigError{err: err}
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestChain(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	clock := x.NewFrozenClock(time.Now())
+	reg.WithClock(clock)
+	chain := reg.PublicMiddleware()
+
+	setChain := func(t *testing.T, chain []map[string]interface{}) {
+		conf.MustSet(config.ViperKeyPublicMiddleware, chain)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyPublicMiddleware, nil)
+		})
+	}
+
+	serve := func(method, path, ip string, form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		chain.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		return w
+	}
+
+	t.Run("case=passes all requests without middlewares", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve("GET", "/self-service/login/browser", "192.0.2.1", nil).Code)
+	})
+
+	t.Run("case=adds headers", func(t *testing.T) {
+		setChain(t, []map[string]interface{}{
+			{"name": "headers", "config": map[string]interface{}{"set": map[string]string{"Cache-Control": "no-store"}}},
+		})
+
+		w := serve("GET", "/self-service/login/browser", "192.0.2.1", nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("case=filters IP addresses", func(t *testing.T) {
+		setChain(t, []map[string]interface{}{
+			{"name": "ip_filter", "path_prefixes": []string{"/self-service/registration"}, "config": map[string]interface{}{"deny": []string{"192.0.2.0/24"}}},
+		})
+
+		assert.Equal(t, http.StatusForbidden, serve("GET", "/self-service/registration/browser", "192.0.2.1", nil).Code)
+		assert.Equal(t, http.StatusNoContent, serve("GET", "/self-service/registration/browser", "198.51.100.1", nil).Code)
+		assert.Equal(t, http.StatusNoContent, serve("GET", "/self-service/login/browser", "192.0.2.1", nil).Code, "other route groups are not filtered")
+
+		setChain(t, []map[string]interface{}{
+			{"name": "ip_filter", "config": map[string]interface{}{"allow": []string{"10.0.0.0/8"}}},
+		})
+
+		assert.Equal(t, http.StatusForbidden, serve("GET", "/self-service/login/browser", "192.0.2.1", nil).Code)
+		assert.Equal(t, http.StatusNoContent, serve("GET", "/self-service/login/browser", "10.1.2.3", nil).Code)
+	})
+
+	t.Run("case=rate limits per client IP address", func(t *testing.T) {
+		setChain(t, []map[string]interface{}{
+			{"name": "rate_limit", "path_prefixes": []string{"/self-service/login"}, "config": map[string]interface{}{"burst": 2, "interval": "1m"}},
+		})
+
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, http.StatusNoContent, serve("GET", "/self-service/login/browser", "192.0.2.10", nil).Code, "%d", i)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, serve("GET", "/self-service/login/browser", "192.0.2.10", nil).Code)
+		assert.Equal(t, http.StatusNoContent, serve("GET", "/self-service/login/browser", "192.0.2.11", nil).Code, "other IP addresses have their own bucket")
+
+		clock.Advance(time.Minute)
+		assert.Equal(t, http.StatusNoContent, serve("GET", "/self-service/login/browser", "192.0.2.10", nil).Code)
+	})
+
+	t.Run("case=requires a CAPTCHA response", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			_, _ = fmt.Fprintf(w, `{"success":%t}`, r.PostForm.Get("response") == "valid")
+		}))
+		t.Cleanup(ts.Close)
+
+		conf.MustSet(config.ViperKeyIPReputationCaptchaProvider, "turnstile")
+		conf.MustSet(config.ViperKeyIPReputationCaptchaSiteKey, "site")
+		conf.MustSet(config.ViperKeyIPReputationCaptchaSecretKey, "secret")
+		conf.MustSet(config.ViperKeyIPReputationCaptchaVerifyURL, ts.URL)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIPReputationCaptchaProvider, "")
+		})
+
+		setChain(t, []map[string]interface{}{
+			{"name": "captcha", "config": map[string]interface{}{"flagged_only": false}},
+		})
+
+		assert.Equal(t, http.StatusForbidden, serve("POST", "/self-service/registration", "192.0.2.1", url.Values{"method": {"password"}}).Code)
+		assert.Equal(t, http.StatusForbidden, serve("POST", "/self-service/registration", "192.0.2.1", url.Values{"captcha_response": {"invalid"}}).Code)
+		assert.Equal(t, http.StatusNoContent, serve("POST", "/self-service/registration", "192.0.2.1", url.Values{"captcha_response": {"valid"}}).Code)
+		assert.Equal(t, http.StatusNoContent, serve("GET", "/self-service/registration/browser", "192.0.2.1", nil).Code, "only POST requests require a response by default")
+
+		setChain(t, []map[string]interface{}{
+			{"name": "captcha"},
+		})
+		assert.Equal(t, http.StatusNoContent, serve("POST", "/self-service/registration", "192.0.2.1", nil).Code, "IP addresses which are not flagged pass by default")
+	})
+
+	t.Run("case=runs middlewares in order", func(t *testing.T) {
+		setChain(t, []map[string]interface{}{
+			{"name": "ip_filter", "config": map[string]interface{}{"deny": []string{"192.0.2.0/24"}}},
+			{"name": "headers", "config": map[string]interface{}{"set": map[string]string{"X-Foo": "bar"}}},
+		})
+
+		w := serve("GET", "/self-service/login/browser", "192.0.2.1", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("X-Foo"), "the chain stops at the first middleware rejecting the request")
+	})
+}
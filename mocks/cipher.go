@@ -0,0 +1,103 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ory/kratos/cipher (interfaces: Cipher,Provider)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	cipher "github.com/ory/kratos/cipher"
+)
+
+// MockCipher is a mock of Cipher interface.
+type MockCipher struct {
+	ctrl     *gomock.Controller
+	recorder *MockCipherMockRecorder
+}
+
+// MockCipherMockRecorder is the mock recorder for MockCipher.
+type MockCipherMockRecorder struct {
+	mock *MockCipher
+}
+
+// NewMockCipher creates a new mock instance.
+func NewMockCipher(ctrl *gomock.Controller) *MockCipher {
+	mock := &MockCipher{ctrl: ctrl}
+	mock.recorder = &MockCipherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCipher) EXPECT() *MockCipherMockRecorder {
+	return m.recorder
+}
+
+// Decrypt mocks base method.
+func (m *MockCipher) Decrypt(arg0 context.Context, arg1 string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decrypt", arg0, arg1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decrypt indicates an expected call of Decrypt.
+func (mr *MockCipherMockRecorder) Decrypt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decrypt", reflect.TypeOf((*MockCipher)(nil).Decrypt), arg0, arg1)
+}
+
+// Encrypt mocks base method.
+func (m *MockCipher) Encrypt(arg0 context.Context, arg1 []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Encrypt", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Encrypt indicates an expected call of Encrypt.
+func (mr *MockCipherMockRecorder) Encrypt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Encrypt", reflect.TypeOf((*MockCipher)(nil).Encrypt), arg0, arg1)
+}
+
+// MockCipherProvider is a mock of Provider interface.
+type MockCipherProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCipherProviderMockRecorder
+}
+
+// MockCipherProviderMockRecorder is the mock recorder for MockCipherProvider.
+type MockCipherProviderMockRecorder struct {
+	mock *MockCipherProvider
+}
+
+// NewMockCipherProvider creates a new mock instance.
+func NewMockCipherProvider(ctrl *gomock.Controller) *MockCipherProvider {
+	mock := &MockCipherProvider{ctrl: ctrl}
+	mock.recorder = &MockCipherProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCipherProvider) EXPECT() *MockCipherProviderMockRecorder {
+	return m.recorder
+}
+
+// Cipher mocks base method.
+func (m *MockCipherProvider) Cipher() cipher.Cipher {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cipher")
+	ret0, _ := ret[0].(cipher.Cipher)
+	return ret0
+}
+
+// Cipher indicates an expected call of Cipher.
+func (mr *MockCipherProviderMockRecorder) Cipher() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cipher", reflect.TypeOf((*MockCipherProvider)(nil).Cipher))
+}
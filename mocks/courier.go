@@ -0,0 +1,230 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ory/kratos/courier (interfaces: Persister,PersistenceProvider,Provider)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/gofrs/uuid"
+	gomock "github.com/golang/mock/gomock"
+	courier "github.com/ory/kratos/courier"
+)
+
+// MockCourierPersister is a mock of Persister interface.
+type MockCourierPersister struct {
+	ctrl     *gomock.Controller
+	recorder *MockCourierPersisterMockRecorder
+}
+
+// MockCourierPersisterMockRecorder is the mock recorder for MockCourierPersister.
+type MockCourierPersisterMockRecorder struct {
+	mock *MockCourierPersister
+}
+
+// NewMockCourierPersister creates a new mock instance.
+func NewMockCourierPersister(ctrl *gomock.Controller) *MockCourierPersister {
+	mock := &MockCourierPersister{ctrl: ctrl}
+	mock.recorder = &MockCourierPersisterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCourierPersister) EXPECT() *MockCourierPersisterMockRecorder {
+	return m.recorder
+}
+
+// AddMessage mocks base method.
+func (m *MockCourierPersister) AddMessage(arg0 context.Context, arg1 *courier.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMessage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMessage indicates an expected call of AddMessage.
+func (mr *MockCourierPersisterMockRecorder) AddMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMessage", reflect.TypeOf((*MockCourierPersister)(nil).AddMessage), arg0, arg1)
+}
+
+// AnonymizeMessagesByRecipient mocks base method.
+func (m *MockCourierPersister) AnonymizeMessagesByRecipient(arg0 context.Context, arg1 []string) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeMessagesByRecipient", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AnonymizeMessagesByRecipient indicates an expected call of AnonymizeMessagesByRecipient.
+func (mr *MockCourierPersisterMockRecorder) AnonymizeMessagesByRecipient(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeMessagesByRecipient", reflect.TypeOf((*MockCourierPersister)(nil).AnonymizeMessagesByRecipient), arg0, arg1)
+}
+
+// DeleteMessagesByRecipient mocks base method.
+func (m *MockCourierPersister) DeleteMessagesByRecipient(arg0 context.Context, arg1 []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessagesByRecipient", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMessagesByRecipient indicates an expected call of DeleteMessagesByRecipient.
+func (mr *MockCourierPersisterMockRecorder) DeleteMessagesByRecipient(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessagesByRecipient", reflect.TypeOf((*MockCourierPersister)(nil).DeleteMessagesByRecipient), arg0, arg1)
+}
+
+// LatestQueuedMessage mocks base method.
+func (m *MockCourierPersister) LatestQueuedMessage(arg0 context.Context) (*courier.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LatestQueuedMessage", arg0)
+	ret0, _ := ret[0].(*courier.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LatestQueuedMessage indicates an expected call of LatestQueuedMessage.
+func (mr *MockCourierPersisterMockRecorder) LatestQueuedMessage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestQueuedMessage", reflect.TypeOf((*MockCourierPersister)(nil).LatestQueuedMessage), arg0)
+}
+
+// NextMessages mocks base method.
+func (m *MockCourierPersister) NextMessages(arg0 context.Context, arg1 byte) ([]courier.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextMessages", arg0, arg1)
+	ret0, _ := ret[0].([]courier.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextMessages indicates an expected call of NextMessages.
+func (mr *MockCourierPersisterMockRecorder) NextMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextMessages", reflect.TypeOf((*MockCourierPersister)(nil).NextMessages), arg0, arg1)
+}
+
+// RedactSentMessages mocks base method.
+func (m *MockCourierPersister) RedactSentMessages(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedactSentMessages", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedactSentMessages indicates an expected call of RedactSentMessages.
+func (mr *MockCourierPersisterMockRecorder) RedactSentMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedactSentMessages", reflect.TypeOf((*MockCourierPersister)(nil).RedactSentMessages), arg0, arg1)
+}
+
+// SetMessageSent mocks base method.
+func (m *MockCourierPersister) SetMessageSent(arg0 context.Context, arg1 uuid.UUID, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMessageSent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMessageSent indicates an expected call of SetMessageSent.
+func (mr *MockCourierPersisterMockRecorder) SetMessageSent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMessageSent", reflect.TypeOf((*MockCourierPersister)(nil).SetMessageSent), arg0, arg1, arg2)
+}
+
+// SetMessageStatus mocks base method.
+func (m *MockCourierPersister) SetMessageStatus(arg0 context.Context, arg1 uuid.UUID, arg2 courier.MessageStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMessageStatus", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMessageStatus indicates an expected call of SetMessageStatus.
+func (mr *MockCourierPersisterMockRecorder) SetMessageStatus(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMessageStatus", reflect.TypeOf((*MockCourierPersister)(nil).SetMessageStatus), arg0, arg1, arg2)
+}
+
+// MockCourierPersistenceProvider is a mock of PersistenceProvider interface.
+type MockCourierPersistenceProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCourierPersistenceProviderMockRecorder
+}
+
+// MockCourierPersistenceProviderMockRecorder is the mock recorder for MockCourierPersistenceProvider.
+type MockCourierPersistenceProviderMockRecorder struct {
+	mock *MockCourierPersistenceProvider
+}
+
+// NewMockCourierPersistenceProvider creates a new mock instance.
+func NewMockCourierPersistenceProvider(ctrl *gomock.Controller) *MockCourierPersistenceProvider {
+	mock := &MockCourierPersistenceProvider{ctrl: ctrl}
+	mock.recorder = &MockCourierPersistenceProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCourierPersistenceProvider) EXPECT() *MockCourierPersistenceProviderMockRecorder {
+	return m.recorder
+}
+
+// CourierPersister mocks base method.
+func (m *MockCourierPersistenceProvider) CourierPersister() courier.Persister {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CourierPersister")
+	ret0, _ := ret[0].(courier.Persister)
+	return ret0
+}
+
+// CourierPersister indicates an expected call of CourierPersister.
+func (mr *MockCourierPersistenceProviderMockRecorder) CourierPersister() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CourierPersister", reflect.TypeOf((*MockCourierPersistenceProvider)(nil).CourierPersister))
+}
+
+// MockCourierProvider is a mock of Provider interface.
+type MockCourierProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCourierProviderMockRecorder
+}
+
+// MockCourierProviderMockRecorder is the mock recorder for MockCourierProvider.
+type MockCourierProviderMockRecorder struct {
+	mock *MockCourierProvider
+}
+
+// NewMockCourierProvider creates a new mock instance.
+func NewMockCourierProvider(ctrl *gomock.Controller) *MockCourierProvider {
+	mock := &MockCourierProvider{ctrl: ctrl}
+	mock.recorder = &MockCourierProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCourierProvider) EXPECT() *MockCourierProviderMockRecorder {
+	return m.recorder
+}
+
+// Courier mocks base method.
+func (m *MockCourierProvider) Courier(arg0 context.Context) *courier.Courier {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Courier", arg0)
+	ret0, _ := ret[0].(*courier.Courier)
+	return ret0
+}
+
+// Courier indicates an expected call of Courier.
+func (mr *MockCourierProviderMockRecorder) Courier(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Courier", reflect.TypeOf((*MockCourierProvider)(nil).Courier), arg0)
+}
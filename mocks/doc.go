@@ -0,0 +1,4 @@
+// Package mocks contains GoMock test doubles of the interfaces handlers and hooks depend on, such as the identity
+// pools, the courier persister, and the cipher. They let handlers be unit tested without a registry or a database.
+// Regenerate them using `make mocks`.
+package mocks
@@ -0,0 +1,306 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: identity/handler.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	herodot "github.com/ory/herodot"
+	approval "github.com/ory/kratos/approval"
+	cipher "github.com/ory/kratos/cipher"
+	courier "github.com/ory/kratos/courier"
+	config "github.com/ory/kratos/driver/config"
+	idempotency "github.com/ory/kratos/idempotency"
+	identity "github.com/ory/kratos/identity"
+	ratelimit "github.com/ory/kratos/ratelimit"
+	schema "github.com/ory/kratos/schema"
+	logrusx "github.com/ory/x/logrusx"
+)
+
+// MockIdentityHandlerDependencies is a mock of handlerDependencies interface.
+type MockIdentityHandlerDependencies struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityHandlerDependenciesMockRecorder
+}
+
+// MockIdentityHandlerDependenciesMockRecorder is the mock recorder for MockIdentityHandlerDependencies.
+type MockIdentityHandlerDependenciesMockRecorder struct {
+	mock *MockIdentityHandlerDependencies
+}
+
+// NewMockIdentityHandlerDependencies creates a new mock instance.
+func NewMockIdentityHandlerDependencies(ctrl *gomock.Controller) *MockIdentityHandlerDependencies {
+	mock := &MockIdentityHandlerDependencies{ctrl: ctrl}
+	mock.recorder = &MockIdentityHandlerDependenciesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityHandlerDependencies) EXPECT() *MockIdentityHandlerDependenciesMockRecorder {
+	return m.recorder
+}
+
+// ApprovalMiddleware mocks base method.
+func (m *MockIdentityHandlerDependencies) ApprovalMiddleware() *approval.Middleware {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApprovalMiddleware")
+	ret0, _ := ret[0].(*approval.Middleware)
+	return ret0
+}
+
+// ApprovalMiddleware indicates an expected call of ApprovalMiddleware.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) ApprovalMiddleware() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApprovalMiddleware", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).ApprovalMiddleware))
+}
+
+// Audit mocks base method.
+func (m *MockIdentityHandlerDependencies) Audit() *logrusx.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Audit")
+	ret0, _ := ret[0].(*logrusx.Logger)
+	return ret0
+}
+
+// Audit indicates an expected call of Audit.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) Audit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Audit", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).Audit))
+}
+
+// CommunicationPreferencesPersister mocks base method.
+func (m *MockIdentityHandlerDependencies) CommunicationPreferencesPersister() courier.PreferencesPersister {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommunicationPreferencesPersister")
+	ret0, _ := ret[0].(courier.PreferencesPersister)
+	return ret0
+}
+
+// CommunicationPreferencesPersister indicates an expected call of CommunicationPreferencesPersister.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) CommunicationPreferencesPersister() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommunicationPreferencesPersister", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).CommunicationPreferencesPersister))
+}
+
+// Config mocks base method.
+func (m *MockIdentityHandlerDependencies) Config(ctx context.Context) *config.Config {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Config", ctx)
+	ret0, _ := ret[0].(*config.Config)
+	return ret0
+}
+
+// Config indicates an expected call of Config.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) Config(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Config", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).Config), ctx)
+}
+
+// EnumerationLimiter mocks base method.
+func (m *MockIdentityHandlerDependencies) EnumerationLimiter() *ratelimit.Limiter {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnumerationLimiter")
+	ret0, _ := ret[0].(*ratelimit.Limiter)
+	return ret0
+}
+
+// EnumerationLimiter indicates an expected call of EnumerationLimiter.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) EnumerationLimiter() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnumerationLimiter", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).EnumerationLimiter))
+}
+
+// IdempotencyMiddleware mocks base method.
+func (m *MockIdentityHandlerDependencies) IdempotencyMiddleware() *idempotency.Middleware {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdempotencyMiddleware")
+	ret0, _ := ret[0].(*idempotency.Middleware)
+	return ret0
+}
+
+// IdempotencyMiddleware indicates an expected call of IdempotencyMiddleware.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) IdempotencyMiddleware() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdempotencyMiddleware", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).IdempotencyMiddleware))
+}
+
+// IdentityKeys mocks base method.
+func (m *MockIdentityHandlerDependencies) IdentityKeys() *cipher.IdentityKeys {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentityKeys")
+	ret0, _ := ret[0].(*cipher.IdentityKeys)
+	return ret0
+}
+
+// IdentityKeys indicates an expected call of IdentityKeys.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) IdentityKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentityKeys", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).IdentityKeys))
+}
+
+// IdentityManager mocks base method.
+func (m *MockIdentityHandlerDependencies) IdentityManager() *identity.Manager {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentityManager")
+	ret0, _ := ret[0].(*identity.Manager)
+	return ret0
+}
+
+// IdentityManager indicates an expected call of IdentityManager.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) IdentityManager() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentityManager", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).IdentityManager))
+}
+
+// IdentityNotePersister mocks base method.
+func (m *MockIdentityHandlerDependencies) IdentityNotePersister() identity.NotePersister {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentityNotePersister")
+	ret0, _ := ret[0].(identity.NotePersister)
+	return ret0
+}
+
+// IdentityNotePersister indicates an expected call of IdentityNotePersister.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) IdentityNotePersister() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentityNotePersister", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).IdentityNotePersister))
+}
+
+// IdentityPool mocks base method.
+func (m *MockIdentityHandlerDependencies) IdentityPool() identity.Pool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentityPool")
+	ret0, _ := ret[0].(identity.Pool)
+	return ret0
+}
+
+// IdentityPool indicates an expected call of IdentityPool.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) IdentityPool() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentityPool", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).IdentityPool))
+}
+
+// IdentityRelationshipPersister mocks base method.
+func (m *MockIdentityHandlerDependencies) IdentityRelationshipPersister() identity.RelationshipPersister {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentityRelationshipPersister")
+	ret0, _ := ret[0].(identity.RelationshipPersister)
+	return ret0
+}
+
+// IdentityRelationshipPersister indicates an expected call of IdentityRelationshipPersister.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) IdentityRelationshipPersister() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentityRelationshipPersister", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).IdentityRelationshipPersister))
+}
+
+// IdentityTraitsSchemas mocks base method.
+func (m *MockIdentityHandlerDependencies) IdentityTraitsSchemas(ctx context.Context) schema.Schemas {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentityTraitsSchemas", ctx)
+	ret0, _ := ret[0].(schema.Schemas)
+	return ret0
+}
+
+// IdentityTraitsSchemas indicates an expected call of IdentityTraitsSchemas.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) IdentityTraitsSchemas(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentityTraitsSchemas", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).IdentityTraitsSchemas), ctx)
+}
+
+// Logger mocks base method.
+func (m *MockIdentityHandlerDependencies) Logger() *logrusx.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logger")
+	ret0, _ := ret[0].(*logrusx.Logger)
+	return ret0
+}
+
+// Logger indicates an expected call of Logger.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) Logger() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logger", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).Logger))
+}
+
+// PrivilegedIdentityPool mocks base method.
+func (m *MockIdentityHandlerDependencies) PrivilegedIdentityPool() identity.PrivilegedPool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrivilegedIdentityPool")
+	ret0, _ := ret[0].(identity.PrivilegedPool)
+	return ret0
+}
+
+// PrivilegedIdentityPool indicates an expected call of PrivilegedIdentityPool.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) PrivilegedIdentityPool() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrivilegedIdentityPool", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).PrivilegedIdentityPool))
+}
+
+// ScheduledStateChangePersister mocks base method.
+func (m *MockIdentityHandlerDependencies) ScheduledStateChangePersister() identity.ScheduledStateChangePersister {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduledStateChangePersister")
+	ret0, _ := ret[0].(identity.ScheduledStateChangePersister)
+	return ret0
+}
+
+// ScheduledStateChangePersister indicates an expected call of ScheduledStateChangePersister.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) ScheduledStateChangePersister() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduledStateChangePersister", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).ScheduledStateChangePersister))
+}
+
+// Writer mocks base method.
+func (m *MockIdentityHandlerDependencies) Writer() herodot.Writer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Writer")
+	ret0, _ := ret[0].(herodot.Writer)
+	return ret0
+}
+
+// Writer indicates an expected call of Writer.
+func (mr *MockIdentityHandlerDependenciesMockRecorder) Writer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Writer", reflect.TypeOf((*MockIdentityHandlerDependencies)(nil).Writer))
+}
+
+// MockIdentityHandlerProvider is a mock of HandlerProvider interface.
+type MockIdentityHandlerProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityHandlerProviderMockRecorder
+}
+
+// MockIdentityHandlerProviderMockRecorder is the mock recorder for MockIdentityHandlerProvider.
+type MockIdentityHandlerProviderMockRecorder struct {
+	mock *MockIdentityHandlerProvider
+}
+
+// NewMockIdentityHandlerProvider creates a new mock instance.
+func NewMockIdentityHandlerProvider(ctrl *gomock.Controller) *MockIdentityHandlerProvider {
+	mock := &MockIdentityHandlerProvider{ctrl: ctrl}
+	mock.recorder = &MockIdentityHandlerProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityHandlerProvider) EXPECT() *MockIdentityHandlerProviderMockRecorder {
+	return m.recorder
+}
+
+// IdentityHandler mocks base method.
+func (m *MockIdentityHandlerProvider) IdentityHandler() *identity.Handler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentityHandler")
+	ret0, _ := ret[0].(*identity.Handler)
+	return ret0
+}
+
+// IdentityHandler indicates an expected call of IdentityHandler.
+func (mr *MockIdentityHandlerProviderMockRecorder) IdentityHandler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentityHandler", reflect.TypeOf((*MockIdentityHandlerProvider)(nil).IdentityHandler))
+}
@@ -0,0 +1,460 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ory/kratos/identity (interfaces: Pool,PrivilegedPool,PoolProvider,PrivilegedPoolProvider)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	uuid "github.com/gofrs/uuid"
+	gomock "github.com/golang/mock/gomock"
+	identity "github.com/ory/kratos/identity"
+)
+
+// MockPool is a mock of Pool interface.
+type MockPool struct {
+	ctrl     *gomock.Controller
+	recorder *MockPoolMockRecorder
+}
+
+// MockPoolMockRecorder is the mock recorder for MockPool.
+type MockPoolMockRecorder struct {
+	mock *MockPool
+}
+
+// NewMockPool creates a new mock instance.
+func NewMockPool(ctrl *gomock.Controller) *MockPool {
+	mock := &MockPool{ctrl: ctrl}
+	mock.recorder = &MockPoolMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPool) EXPECT() *MockPoolMockRecorder {
+	return m.recorder
+}
+
+// CountIdentities mocks base method.
+func (m *MockPool) CountIdentities(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountIdentities", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountIdentities indicates an expected call of CountIdentities.
+func (mr *MockPoolMockRecorder) CountIdentities(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountIdentities", reflect.TypeOf((*MockPool)(nil).CountIdentities), arg0)
+}
+
+// FindRecoveryAddressByValue mocks base method.
+func (m *MockPool) FindRecoveryAddressByValue(arg0 context.Context, arg1 identity.RecoveryAddressType, arg2 string) (*identity.RecoveryAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRecoveryAddressByValue", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*identity.RecoveryAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRecoveryAddressByValue indicates an expected call of FindRecoveryAddressByValue.
+func (mr *MockPoolMockRecorder) FindRecoveryAddressByValue(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRecoveryAddressByValue", reflect.TypeOf((*MockPool)(nil).FindRecoveryAddressByValue), arg0, arg1, arg2)
+}
+
+// FindVerifiableAddressByValue mocks base method.
+func (m *MockPool) FindVerifiableAddressByValue(arg0 context.Context, arg1 identity.VerifiableAddressType, arg2 string) (*identity.VerifiableAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVerifiableAddressByValue", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*identity.VerifiableAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVerifiableAddressByValue indicates an expected call of FindVerifiableAddressByValue.
+func (mr *MockPoolMockRecorder) FindVerifiableAddressByValue(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVerifiableAddressByValue", reflect.TypeOf((*MockPool)(nil).FindVerifiableAddressByValue), arg0, arg1, arg2)
+}
+
+// GetIdentity mocks base method.
+func (m *MockPool) GetIdentity(arg0 context.Context, arg1 uuid.UUID) (*identity.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentity", arg0, arg1)
+	ret0, _ := ret[0].(*identity.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdentity indicates an expected call of GetIdentity.
+func (mr *MockPoolMockRecorder) GetIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentity", reflect.TypeOf((*MockPool)(nil).GetIdentity), arg0, arg1)
+}
+
+// ListIdentities mocks base method.
+func (m *MockPool) ListIdentities(arg0 context.Context, arg1, arg2 int) ([]identity.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIdentities", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]identity.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIdentities indicates an expected call of ListIdentities.
+func (mr *MockPoolMockRecorder) ListIdentities(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIdentities", reflect.TypeOf((*MockPool)(nil).ListIdentities), arg0, arg1, arg2)
+}
+
+// MockPrivilegedPool is a mock of PrivilegedPool interface.
+type MockPrivilegedPool struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrivilegedPoolMockRecorder
+}
+
+// MockPrivilegedPoolMockRecorder is the mock recorder for MockPrivilegedPool.
+type MockPrivilegedPoolMockRecorder struct {
+	mock *MockPrivilegedPool
+}
+
+// NewMockPrivilegedPool creates a new mock instance.
+func NewMockPrivilegedPool(ctrl *gomock.Controller) *MockPrivilegedPool {
+	mock := &MockPrivilegedPool{ctrl: ctrl}
+	mock.recorder = &MockPrivilegedPoolMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrivilegedPool) EXPECT() *MockPrivilegedPoolMockRecorder {
+	return m.recorder
+}
+
+// CountIdentities mocks base method.
+func (m *MockPrivilegedPool) CountIdentities(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountIdentities", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountIdentities indicates an expected call of CountIdentities.
+func (mr *MockPrivilegedPoolMockRecorder) CountIdentities(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountIdentities", reflect.TypeOf((*MockPrivilegedPool)(nil).CountIdentities), arg0)
+}
+
+// CreateIdentity mocks base method.
+func (m *MockPrivilegedPool) CreateIdentity(arg0 context.Context, arg1 *identity.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIdentity indicates an expected call of CreateIdentity.
+func (mr *MockPrivilegedPoolMockRecorder) CreateIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdentity", reflect.TypeOf((*MockPrivilegedPool)(nil).CreateIdentity), arg0, arg1)
+}
+
+// CreateVerifiableAddress mocks base method.
+func (m *MockPrivilegedPool) CreateVerifiableAddress(arg0 context.Context, arg1 *identity.VerifiableAddress) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVerifiableAddress", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateVerifiableAddress indicates an expected call of CreateVerifiableAddress.
+func (mr *MockPrivilegedPoolMockRecorder) CreateVerifiableAddress(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVerifiableAddress", reflect.TypeOf((*MockPrivilegedPool)(nil).CreateVerifiableAddress), arg0, arg1)
+}
+
+// DeleteIdentity mocks base method.
+func (m *MockPrivilegedPool) DeleteIdentity(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdentity indicates an expected call of DeleteIdentity.
+func (mr *MockPrivilegedPoolMockRecorder) DeleteIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdentity", reflect.TypeOf((*MockPrivilegedPool)(nil).DeleteIdentity), arg0, arg1)
+}
+
+// DescribeIdentityDeletion mocks base method.
+func (m *MockPrivilegedPool) DescribeIdentityDeletion(arg0 context.Context, arg1 uuid.UUID) (*identity.DeletionReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeIdentityDeletion", arg0, arg1)
+	ret0, _ := ret[0].(*identity.DeletionReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeIdentityDeletion indicates an expected call of DescribeIdentityDeletion.
+func (mr *MockPrivilegedPoolMockRecorder) DescribeIdentityDeletion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeIdentityDeletion", reflect.TypeOf((*MockPrivilegedPool)(nil).DescribeIdentityDeletion), arg0, arg1)
+}
+
+// FindByCredentialsIdentifier mocks base method.
+func (m *MockPrivilegedPool) FindByCredentialsIdentifier(arg0 context.Context, arg1 identity.CredentialsType, arg2 string) (*identity.Identity, *identity.Credentials, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByCredentialsIdentifier", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*identity.Identity)
+	ret1, _ := ret[1].(*identity.Credentials)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindByCredentialsIdentifier indicates an expected call of FindByCredentialsIdentifier.
+func (mr *MockPrivilegedPoolMockRecorder) FindByCredentialsIdentifier(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByCredentialsIdentifier", reflect.TypeOf((*MockPrivilegedPool)(nil).FindByCredentialsIdentifier), arg0, arg1, arg2)
+}
+
+// FindCredentialsByIdentifiers mocks base method.
+func (m *MockPrivilegedPool) FindCredentialsByIdentifiers(arg0 context.Context, arg1 []string) ([]identity.KnownCredentials, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCredentialsByIdentifiers", arg0, arg1)
+	ret0, _ := ret[0].([]identity.KnownCredentials)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCredentialsByIdentifiers indicates an expected call of FindCredentialsByIdentifiers.
+func (mr *MockPrivilegedPoolMockRecorder) FindCredentialsByIdentifiers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCredentialsByIdentifiers", reflect.TypeOf((*MockPrivilegedPool)(nil).FindCredentialsByIdentifiers), arg0, arg1)
+}
+
+// FindRecoveryAddressByValue mocks base method.
+func (m *MockPrivilegedPool) FindRecoveryAddressByValue(arg0 context.Context, arg1 identity.RecoveryAddressType, arg2 string) (*identity.RecoveryAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRecoveryAddressByValue", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*identity.RecoveryAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRecoveryAddressByValue indicates an expected call of FindRecoveryAddressByValue.
+func (mr *MockPrivilegedPoolMockRecorder) FindRecoveryAddressByValue(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRecoveryAddressByValue", reflect.TypeOf((*MockPrivilegedPool)(nil).FindRecoveryAddressByValue), arg0, arg1, arg2)
+}
+
+// FindVerifiableAddressByValue mocks base method.
+func (m *MockPrivilegedPool) FindVerifiableAddressByValue(arg0 context.Context, arg1 identity.VerifiableAddressType, arg2 string) (*identity.VerifiableAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVerifiableAddressByValue", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*identity.VerifiableAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVerifiableAddressByValue indicates an expected call of FindVerifiableAddressByValue.
+func (mr *MockPrivilegedPoolMockRecorder) FindVerifiableAddressByValue(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVerifiableAddressByValue", reflect.TypeOf((*MockPrivilegedPool)(nil).FindVerifiableAddressByValue), arg0, arg1, arg2)
+}
+
+// GetIdentity mocks base method.
+func (m *MockPrivilegedPool) GetIdentity(arg0 context.Context, arg1 uuid.UUID) (*identity.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentity", arg0, arg1)
+	ret0, _ := ret[0].(*identity.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdentity indicates an expected call of GetIdentity.
+func (mr *MockPrivilegedPoolMockRecorder) GetIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentity", reflect.TypeOf((*MockPrivilegedPool)(nil).GetIdentity), arg0, arg1)
+}
+
+// GetIdentityConfidential mocks base method.
+func (m *MockPrivilegedPool) GetIdentityConfidential(arg0 context.Context, arg1 uuid.UUID) (*identity.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentityConfidential", arg0, arg1)
+	ret0, _ := ret[0].(*identity.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdentityConfidential indicates an expected call of GetIdentityConfidential.
+func (mr *MockPrivilegedPoolMockRecorder) GetIdentityConfidential(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentityConfidential", reflect.TypeOf((*MockPrivilegedPool)(nil).GetIdentityConfidential), arg0, arg1)
+}
+
+// ListIdentities mocks base method.
+func (m *MockPrivilegedPool) ListIdentities(arg0 context.Context, arg1, arg2 int) ([]identity.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIdentities", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]identity.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIdentities indicates an expected call of ListIdentities.
+func (mr *MockPrivilegedPoolMockRecorder) ListIdentities(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIdentities", reflect.TypeOf((*MockPrivilegedPool)(nil).ListIdentities), arg0, arg1, arg2)
+}
+
+// ListRecoveryAddresses mocks base method.
+func (m *MockPrivilegedPool) ListRecoveryAddresses(arg0 context.Context, arg1, arg2 int) ([]identity.RecoveryAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRecoveryAddresses", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]identity.RecoveryAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRecoveryAddresses indicates an expected call of ListRecoveryAddresses.
+func (mr *MockPrivilegedPoolMockRecorder) ListRecoveryAddresses(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecoveryAddresses", reflect.TypeOf((*MockPrivilegedPool)(nil).ListRecoveryAddresses), arg0, arg1, arg2)
+}
+
+// ListVerifiableAddresses mocks base method.
+func (m *MockPrivilegedPool) ListVerifiableAddresses(arg0 context.Context, arg1, arg2 int) ([]identity.VerifiableAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVerifiableAddresses", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]identity.VerifiableAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVerifiableAddresses indicates an expected call of ListVerifiableAddresses.
+func (mr *MockPrivilegedPoolMockRecorder) ListVerifiableAddresses(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVerifiableAddresses", reflect.TypeOf((*MockPrivilegedPool)(nil).ListVerifiableAddresses), arg0, arg1, arg2)
+}
+
+// RevokeIdentityAccess mocks base method.
+func (m *MockPrivilegedPool) RevokeIdentityAccess(arg0 context.Context, arg1 uuid.UUID) (*identity.CompromiseReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeIdentityAccess", arg0, arg1)
+	ret0, _ := ret[0].(*identity.CompromiseReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeIdentityAccess indicates an expected call of RevokeIdentityAccess.
+func (mr *MockPrivilegedPoolMockRecorder) RevokeIdentityAccess(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeIdentityAccess", reflect.TypeOf((*MockPrivilegedPool)(nil).RevokeIdentityAccess), arg0, arg1)
+}
+
+// UpdateIdentity mocks base method.
+func (m *MockPrivilegedPool) UpdateIdentity(arg0 context.Context, arg1 *identity.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIdentity indicates an expected call of UpdateIdentity.
+func (mr *MockPrivilegedPoolMockRecorder) UpdateIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIdentity", reflect.TypeOf((*MockPrivilegedPool)(nil).UpdateIdentity), arg0, arg1)
+}
+
+// UpdateVerifiableAddress mocks base method.
+func (m *MockPrivilegedPool) UpdateVerifiableAddress(arg0 context.Context, arg1 *identity.VerifiableAddress) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVerifiableAddress", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVerifiableAddress indicates an expected call of UpdateVerifiableAddress.
+func (mr *MockPrivilegedPoolMockRecorder) UpdateVerifiableAddress(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVerifiableAddress", reflect.TypeOf((*MockPrivilegedPool)(nil).UpdateVerifiableAddress), arg0, arg1)
+}
+
+// MockPoolProvider is a mock of PoolProvider interface.
+type MockPoolProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockPoolProviderMockRecorder
+}
+
+// MockPoolProviderMockRecorder is the mock recorder for MockPoolProvider.
+type MockPoolProviderMockRecorder struct {
+	mock *MockPoolProvider
+}
+
+// NewMockPoolProvider creates a new mock instance.
+func NewMockPoolProvider(ctrl *gomock.Controller) *MockPoolProvider {
+	mock := &MockPoolProvider{ctrl: ctrl}
+	mock.recorder = &MockPoolProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPoolProvider) EXPECT() *MockPoolProviderMockRecorder {
+	return m.recorder
+}
+
+// IdentityPool mocks base method.
+func (m *MockPoolProvider) IdentityPool() identity.Pool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentityPool")
+	ret0, _ := ret[0].(identity.Pool)
+	return ret0
+}
+
+// IdentityPool indicates an expected call of IdentityPool.
+func (mr *MockPoolProviderMockRecorder) IdentityPool() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentityPool", reflect.TypeOf((*MockPoolProvider)(nil).IdentityPool))
+}
+
+// MockPrivilegedPoolProvider is a mock of PrivilegedPoolProvider interface.
+type MockPrivilegedPoolProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrivilegedPoolProviderMockRecorder
+}
+
+// MockPrivilegedPoolProviderMockRecorder is the mock recorder for MockPrivilegedPoolProvider.
+type MockPrivilegedPoolProviderMockRecorder struct {
+	mock *MockPrivilegedPoolProvider
+}
+
+// NewMockPrivilegedPoolProvider creates a new mock instance.
+func NewMockPrivilegedPoolProvider(ctrl *gomock.Controller) *MockPrivilegedPoolProvider {
+	mock := &MockPrivilegedPoolProvider{ctrl: ctrl}
+	mock.recorder = &MockPrivilegedPoolProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrivilegedPoolProvider) EXPECT() *MockPrivilegedPoolProviderMockRecorder {
+	return m.recorder
+}
+
+// PrivilegedIdentityPool mocks base method.
+func (m *MockPrivilegedPoolProvider) PrivilegedIdentityPool() identity.PrivilegedPool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrivilegedIdentityPool")
+	ret0, _ := ret[0].(identity.PrivilegedPool)
+	return ret0
+}
+
+// PrivilegedIdentityPool indicates an expected call of PrivilegedIdentityPool.
+func (mr *MockPrivilegedPoolProviderMockRecorder) PrivilegedIdentityPool() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrivilegedIdentityPool", reflect.TypeOf((*MockPrivilegedPoolProvider)(nil).PrivilegedIdentityPool))
+}
@@ -0,0 +1,66 @@
+package mocks_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/approval"
+	"github.com/ory/kratos/cipher"
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/idempotency"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/mocks"
+	"github.com/ory/kratos/x"
+)
+
+var (
+	_ identity.Pool           = new(mocks.MockPool)
+	_ identity.PrivilegedPool = new(mocks.MockPrivilegedPool)
+	_ identity.PoolProvider   = new(mocks.MockPoolProvider)
+	_ cipher.Cipher           = new(mocks.MockCipher)
+	_ cipher.Provider         = new(mocks.MockCipherProvider)
+	_ courier.Persister       = new(mocks.MockCourierPersister)
+)
+
+func TestIdentityHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	d := mocks.NewMockIdentityHandlerDependencies(ctrl)
+	pool := mocks.NewMockPool(ctrl)
+
+	id := x.NewUUID()
+	d.EXPECT().Writer().Return(herodot.NewJSONWriter(nil)).AnyTimes()
+	// Registering the routes wraps some of them in middlewares, which the lookup below does not run.
+	d.EXPECT().ApprovalMiddleware().Return(approval.NewMiddleware(nil)).AnyTimes()
+	d.EXPECT().IdempotencyMiddleware().Return(idempotency.NewMiddleware(nil)).AnyTimes()
+	d.EXPECT().IdentityPool().Return(pool)
+	pool.EXPECT().GetIdentity(gomock.Any(), id).Return(nil, herodot.ErrNotFound)
+
+	router := x.NewRouterAdmin()
+	identity.NewHandler(d).RegisterAdminRoutes(router)
+
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	res, err := ts.Client().Get(ts.URL + identity.RouteBase + "/" + id.String())
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestCipher(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	c := mocks.NewMockCipher(ctrl)
+	c.EXPECT().Encrypt(gomock.Any(), []byte("secret")).Return("encrypted", nil)
+
+	encrypted, err := c.Encrypt(context.Background(), []byte("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "encrypted", encrypted)
+}
@@ -0,0 +1,175 @@
+// Package fault injects latency and errors into persister and courier calls. It exists to exercise error paths
+// which are hard to reach against a healthy database and must never be enabled in production.
+package fault
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+)
+
+// ErrUnavailable is returned by faults of kind "unavailable" and mimics a lost database connection.
+var ErrUnavailable = errors.New("fault: the persistence backend is unavailable")
+
+// MatchAll matches every method.
+const MatchAll = "*"
+
+var kinds = map[string]error{
+	"not_found":         sqlcon.ErrNoRows,
+	"conflict":          sqlcon.ErrUniqueViolation,
+	"concurrent_update": sqlcon.ErrConcurrentUpdate,
+	"unavailable":       ErrUnavailable,
+	"internal":          herodot.ErrInternalServerError,
+}
+
+type (
+	// Fault describes how calls to a method misbehave.
+	Fault struct {
+		// Method is the name of the persister method, for example "FindByCredentialsIdentifier", or "*" for all methods.
+		Method string `json:"method"`
+
+		// Kind is one of "not_found", "conflict", "concurrent_update", "unavailable", or "internal". It is only used
+		// when the fault is decoded from JSON and Err is empty.
+		Kind string `json:"error"`
+
+		// Err is returned instead of calling the persister. If nil, the call proceeds after the latency.
+		Err error `json:"-"`
+
+		// Latency delays the call. The delay is aborted when the context is canceled.
+		Latency time.Duration `json:"-"`
+
+		// Rate is the probability in (0, 1] that a call is affected. Zero is treated as one.
+		Rate float64 `json:"rate"`
+
+		// Times limits how many calls are affected. Zero means there is no limit.
+		Times int `json:"times"`
+
+		hits int
+	}
+
+	// Injector decides whether a call is affected by a fault.
+	Injector struct {
+		sync.Mutex
+		faults []*Fault
+		rand   *rand.Rand
+	}
+)
+
+func NewInjector(faults ...Fault) *Injector {
+	i := &Injector{rand: rand.New(rand.NewSource(time.Now().UnixNano()))} // #nosec G404 -- only used to simulate partial failures
+	for k := range faults {
+		i.Add(faults[k])
+	}
+	return i
+}
+
+// ParseFaults decodes a JSON list of faults such as
+//
+//	[{"method": "FindByCredentialsIdentifier", "error": "not_found", "latency": "50ms", "rate": 0.5}]
+func ParseFaults(raw []byte) ([]Fault, error) {
+	var faults []Fault
+	if err := json.Unmarshal(raw, &faults); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return faults, nil
+}
+
+func (f *Fault) UnmarshalJSON(raw []byte) error {
+	type fault Fault
+	var v struct {
+		fault
+		Latency string `json:"latency"`
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return errors.WithStack(err)
+	}
+
+	*f = Fault(v.fault)
+	if v.Latency != "" {
+		d, err := time.ParseDuration(v.Latency)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		f.Latency = d
+	}
+
+	if f.Kind != "" {
+		err, ok := kinds[f.Kind]
+		if !ok {
+			return errors.Errorf("unknown fault error kind: %s", f.Kind)
+		}
+		f.Err = err
+	}
+
+	if f.Method == "" {
+		return errors.New("fault method must be set")
+	}
+	return nil
+}
+
+// Add registers a fault. Faults are evaluated in the order they were added and the first matching one applies.
+func (i *Injector) Add(f Fault) {
+	i.Lock()
+	defer i.Unlock()
+	f.hits = 0
+	i.faults = append(i.faults, &f)
+}
+
+// Reset removes all faults.
+func (i *Injector) Reset() {
+	i.Lock()
+	defer i.Unlock()
+	i.faults = nil
+}
+
+// Inject applies the first fault matching method. It returns the fault's error, or the context's error if the context
+// is canceled while waiting.
+func (i *Injector) Inject(ctx context.Context, method string) error {
+	f := i.match(method)
+	if f == nil {
+		return nil
+	}
+
+	if f.Latency > 0 {
+		t := time.NewTimer(f.Latency)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-t.C:
+		}
+	}
+
+	if f.Err != nil {
+		return errors.WithStack(f.Err)
+	}
+	return nil
+}
+
+func (i *Injector) match(method string) *Fault {
+	i.Lock()
+	defer i.Unlock()
+
+	for _, f := range i.faults {
+		if f.Method != method && f.Method != MatchAll {
+			continue
+		}
+		if f.Times > 0 && f.hits >= f.Times {
+			continue
+		}
+		if f.Rate > 0 && f.Rate < 1 && i.rand.Float64() >= f.Rate {
+			return nil
+		}
+		f.hits++
+		c := *f
+		return &c
+	}
+	return nil
+}
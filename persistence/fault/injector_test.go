@@ -0,0 +1,82 @@
+package fault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+)
+
+func TestInjector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("case=only matching methods fail", func(t *testing.T) {
+		i := NewInjector(Fault{Method: "GetIdentity", Err: sqlcon.ErrNoRows})
+		assert.ErrorIs(t, i.Inject(ctx, "GetIdentity"), sqlcon.ErrNoRows)
+		assert.NoError(t, i.Inject(ctx, "UpdateIdentity"))
+
+		i.Reset()
+		assert.NoError(t, i.Inject(ctx, "GetIdentity"))
+	})
+
+	t.Run("case=wildcard matches all methods", func(t *testing.T) {
+		i := NewInjector(Fault{Method: MatchAll, Err: ErrUnavailable})
+		assert.ErrorIs(t, i.Inject(ctx, "GetIdentity"), ErrUnavailable)
+		assert.ErrorIs(t, i.Inject(ctx, "AddMessage"), ErrUnavailable)
+	})
+
+	t.Run("case=fails a limited number of times", func(t *testing.T) {
+		i := NewInjector(Fault{Method: "AddMessage", Err: ErrUnavailable, Times: 2})
+		assert.Error(t, i.Inject(ctx, "AddMessage"))
+		assert.Error(t, i.Inject(ctx, "AddMessage"))
+		assert.NoError(t, i.Inject(ctx, "AddMessage"))
+	})
+
+	t.Run("case=fails some calls", func(t *testing.T) {
+		i := NewInjector(Fault{Method: "AddMessage", Err: ErrUnavailable, Rate: 0.5})
+
+		var failed int
+		for k := 0; k < 1000; k++ {
+			if i.Inject(ctx, "AddMessage") != nil {
+				failed++
+			}
+		}
+		assert.InDelta(t, 500, failed, 150)
+	})
+
+	t.Run("case=delays calls", func(t *testing.T) {
+		i := NewInjector(Fault{Method: "GetIdentity", Latency: 50 * time.Millisecond})
+
+		start := time.Now()
+		require.NoError(t, i.Inject(ctx, "GetIdentity"))
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, i.Inject(ctx, "GetIdentity"), context.DeadlineExceeded)
+	})
+
+	t.Run("case=parses faults", func(t *testing.T) {
+		faults, err := ParseFaults([]byte(`[{"method":"FindByCredentialsIdentifier","error":"not_found","latency":"10ms","rate":0.5,"times":3}]`))
+		require.NoError(t, err)
+		require.Len(t, faults, 1)
+		assert.Equal(t, "FindByCredentialsIdentifier", faults[0].Method)
+		assert.Equal(t, sqlcon.ErrNoRows, faults[0].Err)
+		assert.Equal(t, 10*time.Millisecond, faults[0].Latency)
+		assert.Equal(t, 0.5, faults[0].Rate)
+		assert.Equal(t, 3, faults[0].Times)
+
+		for _, raw := range []string{
+			`[{"error":"not_found"}]`,
+			`[{"method":"GetIdentity","error":"unknown"}]`,
+			`[{"method":"GetIdentity","latency":"soon"}]`,
+		} {
+			_, err := ParseFaults([]byte(raw))
+			assert.Error(t, err, raw)
+		}
+	})
+}
@@ -0,0 +1,348 @@
+package fault
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/session"
+)
+
+var _ persistence.Persister = new(Persister)
+
+// Persister wraps a persister and consults the injector before the identity, session, flow, token, and courier
+// calls used by the self-service and admin handlers. All other calls are passed through unchanged.
+type Persister struct {
+	persistence.Persister
+	i *Injector
+}
+
+func NewPersister(p persistence.Persister, i *Injector) *Persister {
+	return &Persister{Persister: p, i: i}
+}
+
+func (p *Persister) Injector() *Injector {
+	return p.i
+}
+
+func (p *Persister) WithNetworkID(nid uuid.UUID) persistence.Persister {
+	return NewPersister(p.Persister.WithNetworkID(nid), p.i)
+}
+
+func (p *Persister) ListIdentities(ctx context.Context, page, itemsPerPage int) ([]identity.Identity, error) {
+	if err := p.i.Inject(ctx, "ListIdentities"); err != nil {
+		return nil, err
+	}
+	return p.Persister.ListIdentities(ctx, page, itemsPerPage)
+}
+
+func (p *Persister) GetIdentity(ctx context.Context, id uuid.UUID) (*identity.Identity, error) {
+	if err := p.i.Inject(ctx, "GetIdentity"); err != nil {
+		return nil, err
+	}
+	return p.Persister.GetIdentity(ctx, id)
+}
+
+func (p *Persister) GetIdentityConfidential(ctx context.Context, id uuid.UUID) (*identity.Identity, error) {
+	if err := p.i.Inject(ctx, "GetIdentityConfidential"); err != nil {
+		return nil, err
+	}
+	return p.Persister.GetIdentityConfidential(ctx, id)
+}
+
+func (p *Persister) FindVerifiableAddressByValue(ctx context.Context, via identity.VerifiableAddressType, address string) (*identity.VerifiableAddress, error) {
+	if err := p.i.Inject(ctx, "FindVerifiableAddressByValue"); err != nil {
+		return nil, err
+	}
+	return p.Persister.FindVerifiableAddressByValue(ctx, via, address)
+}
+
+func (p *Persister) FindRecoveryAddressByValue(ctx context.Context, via identity.RecoveryAddressType, address string) (*identity.RecoveryAddress, error) {
+	if err := p.i.Inject(ctx, "FindRecoveryAddressByValue"); err != nil {
+		return nil, err
+	}
+	return p.Persister.FindRecoveryAddressByValue(ctx, via, address)
+}
+
+func (p *Persister) FindByCredentialsIdentifier(ctx context.Context, ct identity.CredentialsType, match string) (*identity.Identity, *identity.Credentials, error) {
+	if err := p.i.Inject(ctx, "FindByCredentialsIdentifier"); err != nil {
+		return nil, nil, err
+	}
+	return p.Persister.FindByCredentialsIdentifier(ctx, ct, match)
+}
+
+func (p *Persister) CreateIdentity(ctx context.Context, i *identity.Identity) error {
+	if err := p.i.Inject(ctx, "CreateIdentity"); err != nil {
+		return err
+	}
+	return p.Persister.CreateIdentity(ctx, i)
+}
+
+func (p *Persister) UpdateIdentity(ctx context.Context, i *identity.Identity) error {
+	if err := p.i.Inject(ctx, "UpdateIdentity"); err != nil {
+		return err
+	}
+	return p.Persister.UpdateIdentity(ctx, i)
+}
+
+func (p *Persister) DeleteIdentity(ctx context.Context, id uuid.UUID) error {
+	if err := p.i.Inject(ctx, "DeleteIdentity"); err != nil {
+		return err
+	}
+	return p.Persister.DeleteIdentity(ctx, id)
+}
+
+func (p *Persister) UpdateVerifiableAddress(ctx context.Context, address *identity.VerifiableAddress) error {
+	if err := p.i.Inject(ctx, "UpdateVerifiableAddress"); err != nil {
+		return err
+	}
+	return p.Persister.UpdateVerifiableAddress(ctx, address)
+}
+
+func (p *Persister) CreateVerifiableAddress(ctx context.Context, address *identity.VerifiableAddress) error {
+	if err := p.i.Inject(ctx, "CreateVerifiableAddress"); err != nil {
+		return err
+	}
+	return p.Persister.CreateVerifiableAddress(ctx, address)
+}
+
+func (p *Persister) GetSession(ctx context.Context, sid uuid.UUID) (*session.Session, error) {
+	if err := p.i.Inject(ctx, "GetSession"); err != nil {
+		return nil, err
+	}
+	return p.Persister.GetSession(ctx, sid)
+}
+
+func (p *Persister) GetSessionByToken(ctx context.Context, token string) (*session.Session, error) {
+	if err := p.i.Inject(ctx, "GetSessionByToken"); err != nil {
+		return nil, err
+	}
+	return p.Persister.GetSessionByToken(ctx, token)
+}
+
+func (p *Persister) CreateSession(ctx context.Context, s *session.Session) error {
+	if err := p.i.Inject(ctx, "CreateSession"); err != nil {
+		return err
+	}
+	return p.Persister.CreateSession(ctx, s)
+}
+
+func (p *Persister) UpdateSession(ctx context.Context, s *session.Session) error {
+	if err := p.i.Inject(ctx, "UpdateSession"); err != nil {
+		return err
+	}
+	return p.Persister.UpdateSession(ctx, s)
+}
+
+func (p *Persister) CreateLoginFlow(ctx context.Context, f *login.Flow) error {
+	if err := p.i.Inject(ctx, "CreateLoginFlow"); err != nil {
+		return err
+	}
+	return p.Persister.CreateLoginFlow(ctx, f)
+}
+
+func (p *Persister) GetLoginFlow(ctx context.Context, id uuid.UUID) (*login.Flow, error) {
+	if err := p.i.Inject(ctx, "GetLoginFlow"); err != nil {
+		return nil, err
+	}
+	return p.Persister.GetLoginFlow(ctx, id)
+}
+
+func (p *Persister) UpdateLoginFlow(ctx context.Context, f *login.Flow) error {
+	if err := p.i.Inject(ctx, "UpdateLoginFlow"); err != nil {
+		return err
+	}
+	return p.Persister.UpdateLoginFlow(ctx, f)
+}
+
+func (p *Persister) CreateRegistrationFlow(ctx context.Context, f *registration.Flow) error {
+	if err := p.i.Inject(ctx, "CreateRegistrationFlow"); err != nil {
+		return err
+	}
+	return p.Persister.CreateRegistrationFlow(ctx, f)
+}
+
+func (p *Persister) GetRegistrationFlow(ctx context.Context, id uuid.UUID) (*registration.Flow, error) {
+	if err := p.i.Inject(ctx, "GetRegistrationFlow"); err != nil {
+		return nil, err
+	}
+	return p.Persister.GetRegistrationFlow(ctx, id)
+}
+
+func (p *Persister) UpdateRegistrationFlow(ctx context.Context, f *registration.Flow) error {
+	if err := p.i.Inject(ctx, "UpdateRegistrationFlow"); err != nil {
+		return err
+	}
+	return p.Persister.UpdateRegistrationFlow(ctx, f)
+}
+
+func (p *Persister) CreateSettingsFlow(ctx context.Context, f *settings.Flow) error {
+	if err := p.i.Inject(ctx, "CreateSettingsFlow"); err != nil {
+		return err
+	}
+	return p.Persister.CreateSettingsFlow(ctx, f)
+}
+
+func (p *Persister) GetSettingsFlow(ctx context.Context, id uuid.UUID) (*settings.Flow, error) {
+	if err := p.i.Inject(ctx, "GetSettingsFlow"); err != nil {
+		return nil, err
+	}
+	return p.Persister.GetSettingsFlow(ctx, id)
+}
+
+func (p *Persister) UpdateSettingsFlow(ctx context.Context, f *settings.Flow) error {
+	if err := p.i.Inject(ctx, "UpdateSettingsFlow"); err != nil {
+		return err
+	}
+	return p.Persister.UpdateSettingsFlow(ctx, f)
+}
+
+func (p *Persister) CreateRecoveryFlow(ctx context.Context, f *recovery.Flow) error {
+	if err := p.i.Inject(ctx, "CreateRecoveryFlow"); err != nil {
+		return err
+	}
+	return p.Persister.CreateRecoveryFlow(ctx, f)
+}
+
+func (p *Persister) GetRecoveryFlow(ctx context.Context, id uuid.UUID) (*recovery.Flow, error) {
+	if err := p.i.Inject(ctx, "GetRecoveryFlow"); err != nil {
+		return nil, err
+	}
+	return p.Persister.GetRecoveryFlow(ctx, id)
+}
+
+func (p *Persister) UpdateRecoveryFlow(ctx context.Context, f *recovery.Flow) error {
+	if err := p.i.Inject(ctx, "UpdateRecoveryFlow"); err != nil {
+		return err
+	}
+	return p.Persister.UpdateRecoveryFlow(ctx, f)
+}
+
+func (p *Persister) UseRecoveryFlowNonce(ctx context.Context, id uuid.UUID, nonce, next string) error {
+	if err := p.i.Inject(ctx, "UseRecoveryFlowNonce"); err != nil {
+		return err
+	}
+	return p.Persister.UseRecoveryFlowNonce(ctx, id, nonce, next)
+}
+
+func (p *Persister) IssueRecoveryFlowNonce(ctx context.Context, id uuid.UUID) error {
+	if err := p.i.Inject(ctx, "IssueRecoveryFlowNonce"); err != nil {
+		return err
+	}
+	return p.Persister.IssueRecoveryFlowNonce(ctx, id)
+}
+
+func (p *Persister) CreateVerificationFlow(ctx context.Context, f *verification.Flow) error {
+	if err := p.i.Inject(ctx, "CreateVerificationFlow"); err != nil {
+		return err
+	}
+	return p.Persister.CreateVerificationFlow(ctx, f)
+}
+
+func (p *Persister) GetVerificationFlow(ctx context.Context, id uuid.UUID) (*verification.Flow, error) {
+	if err := p.i.Inject(ctx, "GetVerificationFlow"); err != nil {
+		return nil, err
+	}
+	return p.Persister.GetVerificationFlow(ctx, id)
+}
+
+func (p *Persister) UpdateVerificationFlow(ctx context.Context, f *verification.Flow) error {
+	if err := p.i.Inject(ctx, "UpdateVerificationFlow"); err != nil {
+		return err
+	}
+	return p.Persister.UpdateVerificationFlow(ctx, f)
+}
+
+func (p *Persister) UseVerificationFlowNonce(ctx context.Context, id uuid.UUID, nonce, next string) error {
+	if err := p.i.Inject(ctx, "UseVerificationFlowNonce"); err != nil {
+		return err
+	}
+	return p.Persister.UseVerificationFlowNonce(ctx, id, nonce, next)
+}
+
+func (p *Persister) IssueVerificationFlowNonce(ctx context.Context, id uuid.UUID) error {
+	if err := p.i.Inject(ctx, "IssueVerificationFlowNonce"); err != nil {
+		return err
+	}
+	return p.Persister.IssueVerificationFlowNonce(ctx, id)
+}
+
+func (p *Persister) CreateRecoveryToken(ctx context.Context, token *link.RecoveryToken) error {
+	if err := p.i.Inject(ctx, "CreateRecoveryToken"); err != nil {
+		return err
+	}
+	return p.Persister.CreateRecoveryToken(ctx, token)
+}
+
+func (p *Persister) UseRecoveryToken(ctx context.Context, token string) (*link.RecoveryToken, error) {
+	if err := p.i.Inject(ctx, "UseRecoveryToken"); err != nil {
+		return nil, err
+	}
+	return p.Persister.UseRecoveryToken(ctx, token)
+}
+
+func (p *Persister) CreateVerificationToken(ctx context.Context, token *link.VerificationToken) error {
+	if err := p.i.Inject(ctx, "CreateVerificationToken"); err != nil {
+		return err
+	}
+	return p.Persister.CreateVerificationToken(ctx, token)
+}
+
+func (p *Persister) UseVerificationToken(ctx context.Context, token string) (*link.VerificationToken, error) {
+	if err := p.i.Inject(ctx, "UseVerificationToken"); err != nil {
+		return nil, err
+	}
+	return p.Persister.UseVerificationToken(ctx, token)
+}
+
+func (p *Persister) InvalidateVerificationTokens(ctx context.Context, addressID uuid.UUID, keep int, issuedAfter time.Time) error {
+	if err := p.i.Inject(ctx, "InvalidateVerificationTokens"); err != nil {
+		return err
+	}
+	return p.Persister.InvalidateVerificationTokens(ctx, addressID, keep, issuedAfter)
+}
+
+func (p *Persister) AddMessage(ctx context.Context, m *courier.Message) error {
+	if err := p.i.Inject(ctx, "AddMessage"); err != nil {
+		return err
+	}
+	return p.Persister.AddMessage(ctx, m)
+}
+
+func (p *Persister) NextMessages(ctx context.Context, limit uint8) ([]courier.Message, error) {
+	if err := p.i.Inject(ctx, "NextMessages"); err != nil {
+		return nil, err
+	}
+	return p.Persister.NextMessages(ctx, limit)
+}
+
+func (p *Persister) SetMessageStatus(ctx context.Context, id uuid.UUID, ms courier.MessageStatus) error {
+	if err := p.i.Inject(ctx, "SetMessageStatus"); err != nil {
+		return err
+	}
+	return p.Persister.SetMessageStatus(ctx, id, ms)
+}
+
+func (p *Persister) SetMessageSent(ctx context.Context, id uuid.UUID, provider string) error {
+	if err := p.i.Inject(ctx, "SetMessageSent"); err != nil {
+		return err
+	}
+	return p.Persister.SetMessageSent(ctx, id, provider)
+}
+
+func (p *Persister) LatestQueuedMessage(ctx context.Context) (*courier.Message, error) {
+	if err := p.i.Inject(ctx, "LatestQueuedMessage"); err != nil {
+		return nil, err
+	}
+	return p.Persister.LatestQueuedMessage(ctx)
+}
@@ -11,10 +11,18 @@ import (
 
 	"github.com/ory/x/popx"
 
+	"github.com/ory/kratos/approval"
+	"github.com/ory/kratos/attempt"
+	"github.com/ory/kratos/cipher"
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/geo"
+	"github.com/ory/kratos/idempotency"
 	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/inactivity"
+	"github.com/ory/kratos/job"
 	"github.com/ory/kratos/selfservice/errorx"
+	"github.com/ory/kratos/selfservice/flow/callback"
 	"github.com/ory/kratos/selfservice/flow/login"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/flow/registration"
@@ -29,18 +37,32 @@ type Provider interface {
 }
 
 type Persister interface {
+	approval.Persister
+	attempt.Persister
+	cipher.DataKeyPersister
 	continuity.Persister
+	idempotency.Persister
 	identity.PrivilegedPool
+	identity.NotePersister
+	identity.RelationshipPersister
+	identity.ScheduledStateChangePersister
+	inactivity.Persister
+	job.Persister
 	registration.FlowPersister
 	login.FlowPersister
 	settings.FlowPersister
 	courier.Persister
+	courier.PreferencesPersister
+	courier.DeliverabilityPersister
+	geo.Persister
 	session.Persister
 	errorx.Persister
 	verification.FlowPersister
 	recovery.FlowPersister
+	callback.Persister
 	link.RecoveryTokenPersister
 	link.VerificationTokenPersister
+	link.ExpiryPersister
 
 	Close(context.Context) error
 	Ping() error
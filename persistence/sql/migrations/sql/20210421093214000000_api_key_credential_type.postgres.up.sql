@@ -0,0 +1 @@
+INSERT INTO identity_credential_types (id, name) SELECT '3ee4a3ff-c2dc-4e5c-8f7f-4b8e0a0c3f5d', 'api_key' WHERE NOT EXISTS ( SELECT * FROM identity_credential_types WHERE name = 'api_key');
\ No newline at end of file
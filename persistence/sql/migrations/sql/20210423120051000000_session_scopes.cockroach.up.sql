@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "scopes" text;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `sessions` DROP COLUMN `scopes`;
\ No newline at end of file
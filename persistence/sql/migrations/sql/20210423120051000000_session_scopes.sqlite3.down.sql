@@ -0,0 +1 @@
+ALTER TABLE "_sessions_tmp" RENAME TO "sessions";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "scopes" TEXT;
\ No newline at end of file
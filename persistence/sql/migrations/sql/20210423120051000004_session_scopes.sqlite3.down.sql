@@ -0,0 +1 @@
+CREATE UNIQUE INDEX "sessions_token_uq_idx" ON "_sessions_tmp" (token);
\ No newline at end of file
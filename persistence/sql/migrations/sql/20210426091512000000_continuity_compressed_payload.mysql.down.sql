@@ -0,0 +1 @@
+ALTER TABLE `continuity_containers` DROP COLUMN `compressed_payload`;
\ No newline at end of file
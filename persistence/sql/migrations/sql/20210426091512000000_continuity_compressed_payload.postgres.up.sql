@@ -0,0 +1 @@
+ALTER TABLE "continuity_containers" ADD COLUMN "compressed_payload" bytea;
\ No newline at end of file
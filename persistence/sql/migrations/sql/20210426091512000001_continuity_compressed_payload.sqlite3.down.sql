@@ -0,0 +1,2 @@
+
+DROP TABLE "continuity_containers";
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX "continuity_containers_nid_idx" ON "_continuity_containers_tmp" (id, nid);
\ No newline at end of file
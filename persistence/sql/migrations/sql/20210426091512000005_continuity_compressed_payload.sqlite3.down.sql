@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "continuity_containers_nid_idx";
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE `idempotency_keys`;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE `idempotency_keys` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`idempotency_key` VARCHAR (255) NOT NULL,
+`request_scope` VARCHAR (255) NOT NULL,
+`request_hash` VARCHAR (64) NOT NULL,
+`status_code` INTEGER NOT NULL DEFAULT 0,
+`location` VARCHAR (2048),
+`body` text,
+`expires_at` DATETIME NOT NULL,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE "idempotency_keys" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"idempotency_key" VARCHAR (255) NOT NULL,
+"request_scope" VARCHAR (255) NOT NULL,
+"request_hash" VARCHAR (64) NOT NULL,
+"status_code" integer NOT NULL DEFAULT '0',
+"location" VARCHAR (2048),
+"body" text,
+"expires_at" timestamp NOT NULL,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade
+);
\ No newline at end of file
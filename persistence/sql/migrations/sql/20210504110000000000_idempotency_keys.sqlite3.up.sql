@@ -0,0 +1,14 @@
+CREATE TABLE "idempotency_keys" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"idempotency_key" TEXT NOT NULL,
+"request_scope" TEXT NOT NULL,
+"request_hash" TEXT NOT NULL,
+"status_code" INTEGER NOT NULL DEFAULT '0',
+"location" TEXT,
+"body" TEXT,
+"expires_at" DATETIME NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade
+);
\ No newline at end of file
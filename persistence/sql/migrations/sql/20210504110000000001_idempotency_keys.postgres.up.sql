@@ -0,0 +1 @@
+CREATE UNIQUE INDEX "idempotency_keys_nid_scope_key_uq_idx" ON "idempotency_keys" (nid, request_scope, idempotency_key);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX "idempotency_keys_expires_at_idx" ON "idempotency_keys" (expires_at);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "identity_recovery_addresses" DROP COLUMN "source";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `identity_recovery_addresses` ADD COLUMN `disabled` bool NOT NULL DEFAULT false;
\ No newline at end of file
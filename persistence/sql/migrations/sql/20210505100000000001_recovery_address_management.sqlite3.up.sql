@@ -0,0 +1 @@
+ALTER TABLE "identity_recovery_addresses" ADD COLUMN "source" TEXT NOT NULL DEFAULT 'schema';
\ No newline at end of file
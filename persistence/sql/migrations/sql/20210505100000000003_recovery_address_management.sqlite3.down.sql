@@ -0,0 +1 @@
+CREATE INDEX "identity_recovery_addresses_nid_idx" ON "_identity_recovery_addresses_tmp" (id, nid);
\ No newline at end of file
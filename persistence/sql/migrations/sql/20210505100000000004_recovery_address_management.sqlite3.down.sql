@@ -0,0 +1 @@
+CREATE UNIQUE INDEX "identity_recovery_addresses_status_via_uq_idx" ON "_identity_recovery_addresses_tmp" (nid, via, value);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "_identity_recovery_addresses_tmp" RENAME TO "identity_recovery_addresses";
\ No newline at end of file
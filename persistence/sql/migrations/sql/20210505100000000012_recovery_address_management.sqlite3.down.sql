@@ -0,0 +1 @@
+INSERT INTO "_identity_recovery_addresses_tmp" (id, via, value, identity_id, created_at, updated_at, nid, disabled) SELECT id, via, value, identity_id, created_at, updated_at, nid, disabled FROM "identity_recovery_addresses";
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE "_identity_recovery_addresses_tmp" (
+"id" TEXT PRIMARY KEY,
+"via" TEXT NOT NULL,
+"value" TEXT NOT NULL,
+"identity_id" char(36) NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+"nid" char(36),
+"disabled" bool NOT NULL DEFAULT 'false',
+FOREIGN KEY (identity_id) REFERENCES identities (id) ON UPDATE NO ACTION ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "identities" DROP COLUMN "state";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "identities" ADD COLUMN "state_changed_at" timestamp;
\ No newline at end of file
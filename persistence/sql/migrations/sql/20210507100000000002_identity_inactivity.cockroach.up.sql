@@ -0,0 +1,11 @@
+CREATE TABLE "identity_inactivity_notifications" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"identity_id" UUID NOT NULL,
+"policy_id" VARCHAR (64) NOT NULL,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+CONSTRAINT "identity_inactivity_notifications_identities_id_fk" FOREIGN KEY ("identity_id") REFERENCES "identities" ("id") ON DELETE cascade,
+CONSTRAINT "identity_inactivity_notifications_networks_id_fk" FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade
+);
\ No newline at end of file
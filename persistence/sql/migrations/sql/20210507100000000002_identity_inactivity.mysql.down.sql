@@ -0,0 +1 @@
+DROP TABLE `identity_inactivity_notifications`;
\ No newline at end of file
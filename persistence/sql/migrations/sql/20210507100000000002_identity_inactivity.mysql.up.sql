@@ -0,0 +1,11 @@
+CREATE TABLE `identity_inactivity_notifications` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`identity_id` char(36) NOT NULL,
+`policy_id` VARCHAR (64) NOT NULL,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`identity_id`) REFERENCES `identities` (`id`) ON DELETE cascade,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
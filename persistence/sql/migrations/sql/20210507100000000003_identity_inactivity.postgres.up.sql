@@ -0,0 +1 @@
+CREATE INDEX "identity_inactivity_notifications_nid_identity_id_policy_id_idx" ON "identity_inactivity_notifications" (nid, identity_id, policy_id);
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO "_identities_tmp" (id, schema_id, traits, created_at, updated_at, nid, state) SELECT id, schema_id, traits, created_at, updated_at, nid, state FROM "identities";
\ No newline at end of file
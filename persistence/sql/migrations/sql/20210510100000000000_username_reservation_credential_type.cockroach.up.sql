@@ -0,0 +1 @@
+INSERT INTO identity_credential_types (id, name) SELECT 'ac632338-1df2-41a9-8c41-3a466b67984b', 'username_reservation' WHERE NOT EXISTS ( SELECT * FROM identity_credential_types WHERE name = 'username_reservation');
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "identity_verifiable_addresses" DROP COLUMN "attestation";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "identity_verifiable_addresses" ADD COLUMN "attestation" jsonb;
\ No newline at end of file
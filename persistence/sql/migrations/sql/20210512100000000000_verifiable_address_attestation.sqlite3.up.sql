@@ -0,0 +1 @@
+ALTER TABLE "identity_verifiable_addresses" ADD COLUMN "attestation" TEXT;
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX "identity_verifiable_addresses_nid_idx" ON "identity_verifiable_addresses" (id, nid);
\ No newline at end of file
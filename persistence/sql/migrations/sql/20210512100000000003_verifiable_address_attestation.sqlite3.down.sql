@@ -0,0 +1 @@
+ALTER TABLE "_identity_verifiable_addresses_tmp" RENAME TO "identity_verifiable_addresses";
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE "identity_verifiable_addresses";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "upstream_session" json;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "upstream_session" TEXT;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE `job_runs` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`job_id` VARCHAR (255) NOT NULL,
+`status` VARCHAR (16) NOT NULL,
+`instance` VARCHAR (255) NOT NULL,
+`error` text,
+`started_at` DATETIME NOT NULL,
+`finished_at` DATETIME,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE "job_runs" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"job_id" VARCHAR (255) NOT NULL,
+"status" VARCHAR (16) NOT NULL,
+"instance" VARCHAR (255) NOT NULL,
+"error" text,
+"started_at" timestamp NOT NULL,
+"finished_at" timestamp,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE "job_runs" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"job_id" TEXT NOT NULL,
+"status" TEXT NOT NULL,
+"instance" TEXT NOT NULL,
+"error" TEXT,
+"started_at" DATETIME NOT NULL,
+"finished_at" DATETIME,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX `job_runs_nid_job_id_started_at_idx` ON `job_runs` (`nid`, `job_id`, `started_at`);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX `job_runs_nid_started_at_idx` ON `job_runs` (`nid`, `started_at`);
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE "job_locks" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"job_id" VARCHAR (255) NOT NULL,
+"holder" VARCHAR (255) NOT NULL,
+"locked_until" timestamp NOT NULL,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+CONSTRAINT "job_locks_networks_id_fk" FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE `job_locks`;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE `job_locks` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`job_id` VARCHAR (255) NOT NULL,
+`holder` VARCHAR (255) NOT NULL,
+`locked_until` DATETIME NOT NULL,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE "job_locks" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"job_id" TEXT NOT NULL,
+"holder" TEXT NOT NULL,
+"locked_until" DATETIME NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX `job_locks_nid_job_id_uq_idx` ON `job_locks` (`nid`, `job_id`);
\ No newline at end of file
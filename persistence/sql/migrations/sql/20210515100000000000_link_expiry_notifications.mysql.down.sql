@@ -0,0 +1 @@
+DROP TABLE `selfservice_link_expiry_notifications`;
\ No newline at end of file
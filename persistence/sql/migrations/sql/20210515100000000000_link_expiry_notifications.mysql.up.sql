@@ -0,0 +1,10 @@
+CREATE TABLE `selfservice_link_expiry_notifications` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`token_id` char(36) NOT NULL,
+`flow_type` VARCHAR (16) NOT NULL,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
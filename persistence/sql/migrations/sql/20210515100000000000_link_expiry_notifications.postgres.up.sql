@@ -0,0 +1,10 @@
+CREATE TABLE "selfservice_link_expiry_notifications" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"token_id" UUID NOT NULL,
+"flow_type" VARCHAR (16) NOT NULL,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade
+);
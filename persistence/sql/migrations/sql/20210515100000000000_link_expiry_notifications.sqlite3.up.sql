@@ -0,0 +1,9 @@
+CREATE TABLE "selfservice_link_expiry_notifications" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"token_id" char(36) NOT NULL,
+"flow_type" TEXT NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade
+);
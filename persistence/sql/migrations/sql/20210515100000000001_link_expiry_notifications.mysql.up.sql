@@ -0,0 +1 @@
+CREATE UNIQUE INDEX `selfservice_link_expiry_notifications_nid_token_id_uq_idx` ON `selfservice_link_expiry_notifications` (`nid`, `token_id`);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX `selfservice_link_expiry_notifications_nid_created_at_idx` ON `selfservice_link_expiry_notifications` (`nid`, `created_at`);
\ No newline at end of file
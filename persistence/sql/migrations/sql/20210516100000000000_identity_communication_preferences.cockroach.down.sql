@@ -0,0 +1 @@
+DROP TABLE "identity_communication_preferences";
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE `identity_communication_preferences` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`identity_id` char(36) NOT NULL,
+`channel` VARCHAR (16) NOT NULL,
+`locale` VARCHAR (64) NOT NULL,
+`quiet_hours` JSON,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade,
+FOREIGN KEY (`identity_id`) REFERENCES `identities` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
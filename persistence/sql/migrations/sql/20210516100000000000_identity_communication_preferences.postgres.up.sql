@@ -0,0 +1,13 @@
+CREATE TABLE "identity_communication_preferences" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"identity_id" UUID NOT NULL,
+"channel" VARCHAR (16) NOT NULL,
+"locale" VARCHAR (64) NOT NULL,
+"quiet_hours" jsonb,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade,
+FOREIGN KEY ("identity_id") REFERENCES "identities" ("id") ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX "identity_communication_preferences_nid_identity_id_uq_idx" ON "identity_communication_preferences" (nid, identity_id);
\ No newline at end of file
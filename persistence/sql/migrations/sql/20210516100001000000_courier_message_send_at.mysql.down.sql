@@ -0,0 +1 @@
+ALTER TABLE `courier_messages` DROP COLUMN `send_at`;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `courier_messages` ADD COLUMN `send_at` DATETIME;
\ No newline at end of file
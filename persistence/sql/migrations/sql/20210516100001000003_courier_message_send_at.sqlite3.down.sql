@@ -0,0 +1 @@
+CREATE INDEX "courier_messages_nid_idx" ON "_courier_messages_tmp" (id, nid);
\ No newline at end of file
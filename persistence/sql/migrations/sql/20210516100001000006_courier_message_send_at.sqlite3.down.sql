@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "courier_messages_status_idx";
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO identity_credential_types (id, name) SELECT 'f5238e36-7eac-438d-9838-aedd30c1015c', 'siwe' WHERE NOT EXISTS ( SELECT * FROM identity_credential_types WHERE name = 'siwe');
\ No newline at end of file
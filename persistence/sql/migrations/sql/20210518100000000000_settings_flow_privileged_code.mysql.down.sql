@@ -0,0 +1 @@
+ALTER TABLE `selfservice_settings_flows` DROP COLUMN `privileged_code_hmac`;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "selfservice_settings_flows" ADD COLUMN "privileged_code_hmac" TEXT;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `selfservice_settings_flows` ADD COLUMN `privileged_code_attempts` INTEGER NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "selfservice_settings_flows" DROP COLUMN "privileged_at";
\ No newline at end of file
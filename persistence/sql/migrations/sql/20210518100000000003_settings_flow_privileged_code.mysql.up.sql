@@ -0,0 +1 @@
+ALTER TABLE `selfservice_settings_flows` ADD COLUMN `privileged_at` DATETIME;
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX "selfservice_settings_flows_nid_idx" ON "_selfservice_settings_flows_tmp" (id, nid);
\ No newline at end of file
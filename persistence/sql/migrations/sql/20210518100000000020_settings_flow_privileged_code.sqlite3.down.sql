@@ -0,0 +1 @@
+INSERT INTO "_selfservice_settings_flows_tmp" (id, request_url, issued_at, expires_at, identity_id, created_at, updated_at, active_method, state, type, ui, nid, privileged_code_hmac, privileged_code_expires_at, privileged_code_attempts) SELECT id, request_url, issued_at, expires_at, identity_id, created_at, updated_at, active_method, state, type, ui, nid, privileged_code_hmac, privileged_code_expires_at, privileged_code_attempts FROM "selfservice_settings_flows";
\ No newline at end of file
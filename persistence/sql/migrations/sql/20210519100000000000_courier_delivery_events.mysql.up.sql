@@ -0,0 +1,12 @@
+CREATE TABLE `courier_delivery_events` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`provider` VARCHAR (255) NOT NULL,
+`channel` VARCHAR (16) NOT NULL,
+`outcome` VARCHAR (16) NOT NULL,
+`latency_ms` INTEGER NOT NULL DEFAULT 0,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
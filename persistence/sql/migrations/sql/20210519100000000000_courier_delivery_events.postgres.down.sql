@@ -0,0 +1 @@
+DROP TABLE "courier_delivery_events";
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE "courier_delivery_events" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"provider" VARCHAR (255) NOT NULL,
+"channel" VARCHAR (16) NOT NULL,
+"outcome" VARCHAR (16) NOT NULL,
+"latency_ms" integer NOT NULL DEFAULT '0',
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade
+);
\ No newline at end of file
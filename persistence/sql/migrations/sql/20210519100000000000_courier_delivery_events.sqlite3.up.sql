@@ -0,0 +1,11 @@
+CREATE TABLE "courier_delivery_events" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"provider" TEXT NOT NULL,
+"channel" TEXT NOT NULL,
+"outcome" TEXT NOT NULL,
+"latency_ms" INTEGER NOT NULL DEFAULT '0',
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX "courier_delivery_events_nid_created_at_idx" ON "courier_delivery_events" (nid, created_at);
\ No newline at end of file
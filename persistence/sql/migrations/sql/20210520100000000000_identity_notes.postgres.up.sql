@@ -0,0 +1,12 @@
+CREATE TABLE "identity_notes" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"identity_id" UUID NOT NULL,
+"author" VARCHAR (255) NOT NULL,
+"text" text NOT NULL,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade,
+FOREIGN KEY ("identity_id") REFERENCES "identities" ("id") ON DELETE cascade
+);
\ No newline at end of file
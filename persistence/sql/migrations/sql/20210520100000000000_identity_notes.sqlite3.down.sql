@@ -0,0 +1 @@
+DROP TABLE "identity_notes";
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX `identity_notes_nid_identity_id_created_at_idx` ON `identity_notes` (`nid`, `identity_id`, `created_at`);
\ No newline at end of file
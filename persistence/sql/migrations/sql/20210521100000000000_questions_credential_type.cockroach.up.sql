@@ -0,0 +1 @@
+INSERT INTO identity_credential_types (id, name) SELECT 'd9fe7970-8ab5-4a03-baf8-63938bff6818', 'questions' WHERE NOT EXISTS ( SELECT * FROM identity_credential_types WHERE name = 'questions');
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE `identity_relationships` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`type` VARCHAR (32) NOT NULL,
+`identity_id` char(36) NOT NULL,
+`related_identity_id` char(36) NOT NULL,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade,
+FOREIGN KEY (`identity_id`) REFERENCES `identities` (`id`) ON DELETE cascade,
+FOREIGN KEY (`related_identity_id`) REFERENCES `identities` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
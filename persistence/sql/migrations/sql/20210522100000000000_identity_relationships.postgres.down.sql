@@ -0,0 +1 @@
+DROP TABLE "identity_relationships";
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE "identity_relationships" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"type" VARCHAR (32) NOT NULL,
+"identity_id" UUID NOT NULL,
+"related_identity_id" UUID NOT NULL,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade,
+FOREIGN KEY ("identity_id") REFERENCES "identities" ("id") ON DELETE cascade,
+FOREIGN KEY ("related_identity_id") REFERENCES "identities" ("id") ON DELETE cascade
+);
\ No newline at end of file
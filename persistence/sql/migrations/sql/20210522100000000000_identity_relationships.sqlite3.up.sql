@@ -0,0 +1,12 @@
+CREATE TABLE "identity_relationships" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"type" TEXT NOT NULL,
+"identity_id" char(36) NOT NULL,
+"related_identity_id" char(36) NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade,
+FOREIGN KEY (identity_id) REFERENCES identities (id) ON DELETE cascade,
+FOREIGN KEY (related_identity_id) REFERENCES identities (id) ON DELETE cascade
+);
\ No newline at end of file
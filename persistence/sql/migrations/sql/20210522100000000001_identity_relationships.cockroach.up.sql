@@ -0,0 +1 @@
+CREATE UNIQUE INDEX "identity_relationships_nid_type_ids_uq_idx" ON "identity_relationships" (nid, type, identity_id, related_identity_id);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX `identity_relationships_nid_related_identity_id_idx` ON `identity_relationships` (`nid`, `related_identity_id`);
\ No newline at end of file
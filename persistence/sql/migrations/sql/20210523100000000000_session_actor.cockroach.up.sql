@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "actor" json;
\ No newline at end of file
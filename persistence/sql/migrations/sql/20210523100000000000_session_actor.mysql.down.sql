@@ -0,0 +1 @@
+ALTER TABLE `sessions` DROP COLUMN `actor`;
\ No newline at end of file
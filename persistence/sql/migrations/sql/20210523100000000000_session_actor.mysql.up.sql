@@ -0,0 +1 @@
+ALTER TABLE `sessions` ADD COLUMN `actor` JSON;
\ No newline at end of file
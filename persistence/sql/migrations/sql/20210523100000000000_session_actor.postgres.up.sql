@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "actor" jsonb;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "actor" TEXT;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE "identity_scheduled_state_changes" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"identity_id" UUID NOT NULL,
+"state" VARCHAR (32) NOT NULL,
+"execute_at" timestamp NOT NULL,
+"executed_at" timestamp,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+CONSTRAINT "identity_scheduled_state_changes_networks_id_fk" FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade,
+CONSTRAINT "identity_scheduled_state_changes_identities_id_fk" FOREIGN KEY ("identity_id") REFERENCES "identities" ("id") ON DELETE cascade
+);
\ No newline at end of file
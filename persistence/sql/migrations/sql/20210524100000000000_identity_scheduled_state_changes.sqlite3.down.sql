@@ -0,0 +1 @@
+DROP TABLE "identity_scheduled_state_changes";
\ No newline at end of file
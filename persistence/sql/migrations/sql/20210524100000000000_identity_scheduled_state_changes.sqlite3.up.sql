@@ -0,0 +1,12 @@
+CREATE TABLE "identity_scheduled_state_changes" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"identity_id" char(36) NOT NULL,
+"state" TEXT NOT NULL,
+"execute_at" DATETIME NOT NULL,
+"executed_at" DATETIME,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade,
+FOREIGN KEY (identity_id) REFERENCES identities (id) ON DELETE cascade
+);
\ No newline at end of file
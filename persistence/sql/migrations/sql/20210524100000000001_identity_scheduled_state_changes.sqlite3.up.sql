@@ -0,0 +1 @@
+CREATE INDEX "identity_scheduled_state_changes_nid_identity_id_idx" ON "identity_scheduled_state_changes" (nid, identity_id, execute_at);
\ No newline at end of file
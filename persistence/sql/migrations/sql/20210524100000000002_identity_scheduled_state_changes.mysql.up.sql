@@ -0,0 +1 @@
+CREATE INDEX `identity_scheduled_state_changes_nid_due_idx` ON `identity_scheduled_state_changes` (`nid`, `executed_at`, `execute_at`);
\ No newline at end of file
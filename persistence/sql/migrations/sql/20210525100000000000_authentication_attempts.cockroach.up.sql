@@ -0,0 +1,15 @@
+CREATE TABLE "authentication_attempts" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"flow" VARCHAR (32) NOT NULL,
+"flow_id" UUID NOT NULL,
+"method" VARCHAR (32) NOT NULL,
+"identifier_hash" VARCHAR (64) NOT NULL,
+"ip" VARCHAR (64) NOT NULL,
+"success" bool NOT NULL,
+"identity_id" UUID,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+CONSTRAINT "authentication_attempts_networks_id_fk" FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE `authentication_attempts`;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE `authentication_attempts` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`flow` VARCHAR (32) NOT NULL,
+`flow_id` char(36) NOT NULL,
+`method` VARCHAR (32) NOT NULL,
+`identifier_hash` VARCHAR (64) NOT NULL,
+`ip` VARCHAR (64) NOT NULL,
+`success` bool NOT NULL,
+`identity_id` char(36),
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE "authentication_attempts" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"flow" TEXT NOT NULL,
+"flow_id" char(36) NOT NULL,
+"method" TEXT NOT NULL,
+"identifier_hash" TEXT NOT NULL,
+"ip" TEXT NOT NULL,
+"success" bool NOT NULL,
+"identity_id" char(36),
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX "authentication_attempts_nid_created_at_idx" ON "authentication_attempts" (nid, created_at);
\ No newline at end of file
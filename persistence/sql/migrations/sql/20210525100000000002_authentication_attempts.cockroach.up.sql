@@ -0,0 +1 @@
+CREATE INDEX "authentication_attempts_nid_identifier_hash_idx" ON "authentication_attempts" (nid, identifier_hash, created_at);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX `authentication_attempts_nid_ip_idx` ON `authentication_attempts` (`nid`, `ip`, `created_at`);
\ No newline at end of file
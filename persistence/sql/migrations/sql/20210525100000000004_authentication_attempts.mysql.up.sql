@@ -0,0 +1 @@
+CREATE INDEX `authentication_attempts_nid_identity_id_idx` ON `authentication_attempts` (`nid`, `identity_id`, `created_at`);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `courier_messages` DROP COLUMN `provider`;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `courier_messages` ADD COLUMN `provider` VARCHAR (255) NOT NULL DEFAULT '';
\ No newline at end of file
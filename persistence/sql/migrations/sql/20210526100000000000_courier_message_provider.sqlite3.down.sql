@@ -0,0 +1 @@
+ALTER TABLE "_courier_messages_tmp" RENAME TO "courier_messages";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "courier_messages" ADD COLUMN "provider" TEXT NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1,2 @@
+
+DROP TABLE "courier_messages";
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO "_courier_messages_tmp" (id, type, status, body, subject, recipient, created_at, updated_at, template_type, template_data, nid, send_at) SELECT id, type, status, body, subject, recipient, created_at, updated_at, template_type, template_data, nid, send_at FROM "courier_messages";
\ No newline at end of file
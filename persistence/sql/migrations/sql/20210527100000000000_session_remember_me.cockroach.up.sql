@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "remember_me" bool NOT NULL DEFAULT 'false';
\ No newline at end of file
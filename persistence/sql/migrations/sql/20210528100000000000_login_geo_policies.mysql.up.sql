@@ -0,0 +1,14 @@
+CREATE TABLE `identity_geo_policies` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`identity_id` char(36) NOT NULL,
+`exempt` bool NOT NULL DEFAULT false,
+`allowed_countries` text NOT NULL,
+`blocked_countries` text NOT NULL,
+`last_country` VARCHAR (2) NOT NULL,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade,
+FOREIGN KEY (`identity_id`) REFERENCES `identities` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE "identity_geo_policies" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"identity_id" UUID NOT NULL,
+"exempt" bool NOT NULL DEFAULT 'false',
+"allowed_countries" text NOT NULL,
+"blocked_countries" text NOT NULL,
+"last_country" VARCHAR (2) NOT NULL,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade,
+FOREIGN KEY ("identity_id") REFERENCES "identities" ("id") ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE "identity_geo_policies";
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE "identity_geo_policies" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"identity_id" char(36) NOT NULL,
+"exempt" bool NOT NULL DEFAULT 'false',
+"allowed_countries" TEXT NOT NULL,
+"blocked_countries" TEXT NOT NULL,
+"last_country" TEXT NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade,
+FOREIGN KEY (identity_id) REFERENCES identities (id) ON DELETE cascade
+);
\ No newline at end of file
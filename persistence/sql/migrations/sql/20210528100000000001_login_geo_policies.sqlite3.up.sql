@@ -0,0 +1 @@
+CREATE UNIQUE INDEX "identity_geo_policies_nid_identity_id_uq_idx" ON "identity_geo_policies" (nid, identity_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE `selfservice_login_geo_challenges`;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE "selfservice_login_geo_challenges" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"flow_id" UUID NOT NULL,
+"identity_id" UUID NOT NULL,
+"method" VARCHAR (32) NOT NULL,
+"country" VARCHAR (2) NOT NULL,
+"code_hmac" VARCHAR (64) NOT NULL,
+"expires_at" timestamp NOT NULL,
+"attempts" integer NOT NULL DEFAULT '0',
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade,
+FOREIGN KEY ("flow_id") REFERENCES "selfservice_login_flows" ("id") ON DELETE cascade,
+FOREIGN KEY ("identity_id") REFERENCES "identities" ("id") ON DELETE cascade
+);
\ No newline at end of file
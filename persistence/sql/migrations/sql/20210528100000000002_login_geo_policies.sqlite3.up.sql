@@ -0,0 +1,16 @@
+CREATE TABLE "selfservice_login_geo_challenges" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"flow_id" char(36) NOT NULL,
+"identity_id" char(36) NOT NULL,
+"method" TEXT NOT NULL,
+"country" TEXT NOT NULL,
+"code_hmac" TEXT NOT NULL,
+"expires_at" DATETIME NOT NULL,
+"attempts" INTEGER NOT NULL DEFAULT '0',
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade,
+FOREIGN KEY (flow_id) REFERENCES selfservice_login_flows (id) ON DELETE cascade,
+FOREIGN KEY (identity_id) REFERENCES identities (id) ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX `selfservice_login_geo_challenges_nid_flow_id_uq_idx` ON `selfservice_login_geo_challenges` (`nid`, `flow_id`);
\ No newline at end of file
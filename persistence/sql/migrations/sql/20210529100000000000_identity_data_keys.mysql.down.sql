@@ -0,0 +1 @@
+DROP TABLE `identity_data_keys`;
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX `identity_data_keys_nid_identity_id_uq_idx` ON `identity_data_keys` (`nid`, `identity_id`);
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE `admin_approvals` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`token_hash` VARCHAR (64) NOT NULL,
+`request_method` VARCHAR (16) NOT NULL,
+`request_path` VARCHAR (2048) NOT NULL,
+`approved_by` VARCHAR (255) NOT NULL,
+`reason` text NOT NULL,
+`expires_at` DATETIME NOT NULL,
+`used_at` DATETIME,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE "admin_approvals";
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE "admin_approvals" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"token_hash" VARCHAR (64) NOT NULL,
+"request_method" VARCHAR (16) NOT NULL,
+"request_path" VARCHAR (2048) NOT NULL,
+"approved_by" VARCHAR (255) NOT NULL,
+"reason" text NOT NULL,
+"expires_at" timestamp NOT NULL,
+"used_at" timestamp,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE "admin_approvals" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"token_hash" TEXT NOT NULL,
+"request_method" TEXT NOT NULL,
+"request_path" TEXT NOT NULL,
+"approved_by" TEXT NOT NULL,
+"reason" TEXT NOT NULL,
+"expires_at" DATETIME NOT NULL,
+"used_at" DATETIME,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade
+);
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX `admin_approvals_nid_token_hash_uq_idx` ON `admin_approvals` (`nid`, `token_hash`);
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE `selfservice_flow_callbacks` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`flow_id` char(36) NOT NULL,
+`flow_type` VARCHAR (16) NOT NULL,
+`url` VARCHAR (2048) NOT NULL,
+`result` text,
+`expires_at` DATETIME NOT NULL,
+`completed_at` DATETIME,
+`delivered_at` DATETIME,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE "selfservice_flow_callbacks";
\ No newline at end of file
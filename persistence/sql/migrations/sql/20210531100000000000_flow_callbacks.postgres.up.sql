@@ -0,0 +1,15 @@
+CREATE TABLE "selfservice_flow_callbacks" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"flow_id" UUID NOT NULL,
+"flow_type" VARCHAR (16) NOT NULL,
+"url" VARCHAR (2048) NOT NULL,
+"result" text,
+"expires_at" timestamp NOT NULL,
+"completed_at" timestamp,
+"delivered_at" timestamp,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade
+);
\ No newline at end of file
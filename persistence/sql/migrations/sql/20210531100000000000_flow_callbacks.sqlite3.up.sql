@@ -0,0 +1,14 @@
+CREATE TABLE "selfservice_flow_callbacks" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"flow_id" char(36) NOT NULL,
+"flow_type" TEXT NOT NULL,
+"url" TEXT NOT NULL,
+"result" TEXT,
+"expires_at" DATETIME NOT NULL,
+"completed_at" DATETIME,
+"delivered_at" DATETIME,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade
+);
\ No newline at end of file
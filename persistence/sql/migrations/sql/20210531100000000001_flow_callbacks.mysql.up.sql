@@ -0,0 +1 @@
+CREATE UNIQUE INDEX `selfservice_flow_callbacks_nid_flow_id_uq_idx` ON `selfservice_flow_callbacks` (`nid`, `flow_id`);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE "identity_verification_tokens";
\ No newline at end of file
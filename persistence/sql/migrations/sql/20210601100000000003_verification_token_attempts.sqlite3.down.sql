@@ -0,0 +1 @@
+CREATE INDEX "identity_verification_tokens_verification_flow_id_idx" ON "_identity_verification_tokens_tmp" (selfservice_verification_flow_id);
\ No newline at end of file
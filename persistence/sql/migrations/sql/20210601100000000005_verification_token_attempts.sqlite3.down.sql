@@ -0,0 +1 @@
+CREATE INDEX "identity_verification_tokens_token_idx" ON "_identity_verification_tokens_tmp" (token);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE "identity_recovery_tokens";
\ No newline at end of file
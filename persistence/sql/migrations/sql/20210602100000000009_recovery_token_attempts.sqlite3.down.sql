@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "identity_recovery_tokens_nid_idx";
\ No newline at end of file
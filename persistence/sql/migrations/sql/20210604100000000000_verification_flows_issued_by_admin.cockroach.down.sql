@@ -0,0 +1 @@
+ALTER TABLE "selfservice_verification_flows" DROP COLUMN "issued_by_admin";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "selfservice_verification_flows" ADD COLUMN "issued_by_admin" bool NOT NULL DEFAULT 'false';
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "selfservice_recovery_flows" ADD COLUMN "submission_nonce" VARCHAR (255) NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX "selfservice_recovery_flows_nid_idx" ON "_selfservice_recovery_flows_tmp" (id, nid);
\ No newline at end of file
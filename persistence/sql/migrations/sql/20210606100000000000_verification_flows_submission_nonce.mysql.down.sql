@@ -0,0 +1 @@
+ALTER TABLE `selfservice_verification_flows` DROP COLUMN `submission_nonce`;
\ No newline at end of file
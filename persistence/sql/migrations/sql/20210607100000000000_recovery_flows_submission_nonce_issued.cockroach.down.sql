@@ -0,0 +1 @@
+ALTER TABLE "selfservice_recovery_flows" DROP COLUMN "submission_nonce_issued";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "_selfservice_recovery_flows_tmp" RENAME TO "selfservice_recovery_flows";
\ No newline at end of file
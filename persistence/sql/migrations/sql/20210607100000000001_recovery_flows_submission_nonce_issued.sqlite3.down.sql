@@ -0,0 +1 @@
+DROP TABLE "selfservice_recovery_flows";
\ No newline at end of file
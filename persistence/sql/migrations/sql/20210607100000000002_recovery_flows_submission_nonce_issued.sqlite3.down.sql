@@ -0,0 +1 @@
+INSERT INTO "_selfservice_recovery_flows_tmp" (id, request_url, issued_at, expires_at, active_method, csrf_token, state, recovered_identity_id, created_at, updated_at, type, ui, nid, issued_by_admin, submission_nonce) SELECT id, request_url, issued_at, expires_at, active_method, csrf_token, state, recovered_identity_id, created_at, updated_at, type, ui, nid, issued_by_admin, submission_nonce FROM "selfservice_recovery_flows";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "selfservice_verification_flows" ADD COLUMN "submission_nonce_issued" bool NOT NULL DEFAULT 'false';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE "selfservice_verification_flows";
\ No newline at end of file
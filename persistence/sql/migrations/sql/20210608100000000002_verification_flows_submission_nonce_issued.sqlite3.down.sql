@@ -0,0 +1 @@
+INSERT INTO "_selfservice_verification_flows_tmp" (id, request_url, issued_at, expires_at, csrf_token, created_at, updated_at, type, state, active_method, ui, nid, issued_by_admin, submission_nonce) SELECT id, request_url, issued_at, expires_at, csrf_token, created_at, updated_at, type, state, active_method, ui, nid, issued_by_admin, submission_nonce FROM "selfservice_verification_flows";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `selfservice_login_flows` DROP COLUMN `siwe_nonce`;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "selfservice_login_flows" ADD COLUMN "siwe_nonce" TEXT NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE "selfservice_login_flows";
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX "selfservice_login_flows_nid_idx" ON "_selfservice_login_flows_tmp" (id, nid);
\ No newline at end of file
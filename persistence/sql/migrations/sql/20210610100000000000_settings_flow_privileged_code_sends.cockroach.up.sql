@@ -0,0 +1,2 @@
+ALTER TABLE "selfservice_settings_flows" ADD COLUMN "privileged_code_sent_at" timestamp;
+ALTER TABLE "selfservice_settings_flows" ADD COLUMN "privileged_code_sends" int NOT NULL DEFAULT '0';
\ No newline at end of file
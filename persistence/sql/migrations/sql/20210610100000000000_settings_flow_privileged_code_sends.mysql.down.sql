@@ -0,0 +1,2 @@
+ALTER TABLE `selfservice_settings_flows` DROP COLUMN `privileged_code_sends`;
+ALTER TABLE `selfservice_settings_flows` DROP COLUMN `privileged_code_sent_at`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `selfservice_settings_flows` ADD COLUMN `privileged_code_sent_at` DATETIME;
+ALTER TABLE `selfservice_settings_flows` ADD COLUMN `privileged_code_sends` INTEGER NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE "selfservice_settings_flows";
\ No newline at end of file
@@ -0,0 +1,55 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/approval"
+	"github.com/ory/kratos/corp"
+)
+
+var _ approval.Persister = new(Persister)
+
+func (p *Persister) CreateApproval(ctx context.Context, a *approval.Approval) error {
+	a.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(a))
+}
+
+func (p *Persister) UseApproval(ctx context.Context, tokenHash, method, path string, requestedBy uuid.UUID, now time.Time) (*approval.Approval, error) {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	var a approval.Approval
+	if err := sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		// The update only succeeds once for each approval, even if the token is used concurrently.
+		count, err := tx.RawQuery(
+			// #nosec
+			fmt.Sprintf("UPDATE %s SET used_at = ? WHERE token_hash = ? AND request_method = ? AND request_path = ? AND approved_by <> ? AND used_at IS NULL AND expires_at > ? AND nid = ?",
+				a.TableName(ctx)), now, tokenHash, method, path, requestedBy, now, nid).ExecWithCount()
+		if err != nil {
+			return err
+		} else if count == 0 {
+			return sqlcon.ErrNoRows
+		}
+
+		return tx.Where("token_hash = ? AND nid = ?", tokenHash, nid).First(&a)
+	})); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (p *Persister) DeleteExpiredApprovals(ctx context.Context, expiresBefore time.Time) (int, error) {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at < ? AND nid = ?",
+			new(approval.Approval).TableName(ctx)), expiresBefore, corp.ContextualizeNID(ctx, p.nid)).ExecWithCount()
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
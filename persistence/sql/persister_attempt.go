@@ -0,0 +1,85 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gobuffalo/pop/v5"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/attempt"
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/x"
+)
+
+var _ attempt.Persister = new(Persister)
+
+func (p *Persister) AddAttempt(ctx context.Context, a *attempt.Attempt) error {
+	a.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(a))
+}
+
+func (p *Persister) attemptsQuery(ctx context.Context, filter attempt.Filter) *pop.Query {
+	q := p.GetConnection(ctx).Where("nid = ?", corp.ContextualizeNID(ctx, p.nid))
+	if len(filter.IdentifierHashes) > 0 {
+		hashes := make([]interface{}, len(filter.IdentifierHashes))
+		for k, hash := range filter.IdentifierHashes {
+			hashes[k] = hash
+		}
+		q = q.Where("identifier_hash IN (?)", hashes...)
+	}
+	if filter.IdentityID != x.EmptyUUID {
+		q = q.Where("identity_id = ?", filter.IdentityID)
+	}
+	if filter.IP != "" {
+		q = q.Where("ip = ?", filter.IP)
+	}
+	if filter.Flow != "" {
+		q = q.Where("flow = ?", filter.Flow)
+	}
+	if filter.Method != "" {
+		q = q.Where("method = ?", filter.Method)
+	}
+	if filter.Success != nil {
+		q = q.Where("success = ?", *filter.Success)
+	}
+	if !filter.Since.IsZero() {
+		q = q.Where("created_at >= ?", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		q = q.Where("created_at < ?", filter.Until.UTC())
+	}
+	return q
+}
+
+func (p *Persister) ListAttempts(ctx context.Context, filter attempt.Filter, page, itemsPerPage int) ([]attempt.Attempt, error) {
+	attempts := make([]attempt.Attempt, 0)
+	if err := p.attemptsQuery(ctx, filter).
+		Order("created_at DESC, id DESC").
+		Paginate(page+1, x.MaxItemsPerPage(itemsPerPage)).
+		All(&attempts); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return attempts, nil
+}
+
+func (p *Persister) CountAttempts(ctx context.Context, filter attempt.Filter) (int64, error) {
+	count, err := p.attemptsQuery(ctx, filter).Count(new(attempt.Attempt))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return int64(count), nil
+}
+
+func (p *Persister) DeleteAttemptsBefore(ctx context.Context, before time.Time) (int, error) {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec
+		fmt.Sprintf("DELETE FROM %s WHERE created_at < ? AND nid = ?",
+			new(attempt.Attempt).TableName(ctx)), before.UTC(), corp.ContextualizeNID(ctx, p.nid)).ExecWithCount()
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -41,3 +42,22 @@ func (p *Persister) DeleteContinuitySession(ctx context.Context, id uuid.UUID) e
 	}
 	return nil
 }
+
+func (p *Persister) DeleteExpiredContinuitySessions(ctx context.Context, expiresBefore time.Time) (int, error) {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at < ? AND nid = ?",
+			new(continuity.Container).TableName(ctx)), expiresBefore, corp.ContextualizeNID(ctx, p.nid)).ExecWithCount()
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
+
+func (p *Persister) CountContinuitySessions(ctx context.Context) (int, error) {
+	count, err := p.GetConnection(ctx).Where("nid = ?", corp.ContextualizeNID(ctx, p.nid)).Count(new(continuity.Container))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gobuffalo/pop/v5"
 	"github.com/gofrs/uuid"
@@ -32,6 +34,7 @@ func (p *Persister) NextMessages(ctx context.Context, limit uint8) (messages []c
 				corp.ContextualizeNID(ctx, p.nid),
 				courier.MessageStatusQueued,
 			).
+			Where("(send_at IS NULL OR send_at <= ?)", time.Now().UTC()).
 			Order("created_at ASC").
 			Limit(int(limit)).
 			All(&m); err != nil {
@@ -88,10 +91,11 @@ func (p *Persister) SetMessageStatus(ctx context.Context, id uuid.UUID, ms couri
 	count, err := p.GetConnection(ctx).RawQuery(
 		// #nosec G201
 		fmt.Sprintf(
-			"UPDATE %s SET status = ? WHERE id = ? AND nid = ?",
+			"UPDATE %s SET status = ?, updated_at = ? WHERE id = ? AND nid = ?",
 			corp.ContextualizeTableName(ctx, "courier_messages"),
 		),
 		ms,
+		time.Now().UTC(),
 		id,
 		corp.ContextualizeNID(ctx, p.nid),
 	).ExecWithCount()
@@ -105,3 +109,109 @@ func (p *Persister) SetMessageStatus(ctx context.Context, id uuid.UUID, ms couri
 
 	return nil
 }
+
+func (p *Persister) SetMessageSent(ctx context.Context, id uuid.UUID, provider string) error {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec G201
+		fmt.Sprintf(
+			"UPDATE %s SET status = ?, provider = ?, updated_at = ? WHERE id = ? AND nid = ?",
+			corp.ContextualizeTableName(ctx, "courier_messages"),
+		),
+		courier.MessageStatusSent,
+		provider,
+		time.Now().UTC(),
+		id,
+		corp.ContextualizeNID(ctx, p.nid),
+	).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (p *Persister) RedactSentMessages(ctx context.Context, sentBefore time.Time) (int, error) {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec G201
+		fmt.Sprintf(
+			"UPDATE %s SET body = '', template_data = NULL WHERE nid = ? AND status = ? AND updated_at < ? AND (body <> '' OR template_data IS NOT NULL)",
+			corp.ContextualizeTableName(ctx, "courier_messages"),
+		),
+		corp.ContextualizeNID(ctx, p.nid),
+		courier.MessageStatusSent,
+		sentBefore,
+	).ExecWithCount()
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+
+	return count, nil
+}
+
+func (p *Persister) DeleteMessagesByRecipient(ctx context.Context, recipients []string) (int, error) {
+	if len(recipients) == 0 {
+		return 0, nil
+	}
+
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec G201
+		fmt.Sprintf(
+			"DELETE FROM %s WHERE nid = ? AND LOWER(recipient) IN (%s)",
+			corp.ContextualizeTableName(ctx, "courier_messages"),
+			recipientPlaceholders(recipients),
+		),
+		recipientArgs(recipients, corp.ContextualizeNID(ctx, p.nid))...,
+	).ExecWithCount()
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+
+	return count, nil
+}
+
+func (p *Persister) AnonymizeMessagesByRecipient(ctx context.Context, recipients []string) (anonymized, deleted int, err error) {
+	if len(recipients) == 0 {
+		return 0, 0, nil
+	}
+
+	if err := p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		table := corp.ContextualizeTableName(ctx, "courier_messages")
+		nid := corp.ContextualizeNID(ctx, p.nid)
+
+		deleted, err = tx.RawQuery(
+			// #nosec G201
+			fmt.Sprintf("DELETE FROM %s WHERE nid = ? AND status <> ? AND LOWER(recipient) IN (%s)", table, recipientPlaceholders(recipients)),
+			recipientArgs(recipients, nid, courier.MessageStatusSent)...,
+		).ExecWithCount()
+		if err != nil {
+			return err
+		}
+
+		anonymized, err = tx.RawQuery(
+			// #nosec G201
+			fmt.Sprintf("UPDATE %s SET recipient = '', body = '', template_data = NULL WHERE nid = ? AND LOWER(recipient) IN (%s)", table, recipientPlaceholders(recipients)),
+			recipientArgs(recipients, nid)...,
+		).ExecWithCount()
+		return err
+	}); err != nil {
+		return 0, 0, sqlcon.HandleError(err)
+	}
+
+	return anonymized, deleted, nil
+}
+
+func recipientPlaceholders(recipients []string) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", len(recipients)), ", ")
+}
+
+// recipientArgs returns the query arguments for recipientPlaceholders, preceded by args.
+func recipientArgs(recipients []string, args ...interface{}) []interface{} {
+	for _, r := range recipients {
+		args = append(args, strings.ToLower(r))
+	}
+	return args
+}
@@ -17,7 +17,7 @@ func TestCredentialTypes(t *testing.T) {
 
 	for name, p := range ps {
 		t.Run(fmt.Sprintf("db=%s", name), func(t *testing.T) {
-			for _, ct := range []identity.CredentialsType{identity.CredentialsTypeOIDC, identity.CredentialsTypePassword} {
+			for _, ct := range []identity.CredentialsType{identity.CredentialsTypeOIDC, identity.CredentialsTypePassword, identity.CredentialsTypeAPIKey, identity.CredentialsTypeUsernameReservation, identity.CredentialsTypeSIWE, identity.CredentialsTypeQuestions} {
 				require.NoError(t, p.Persister().(*sql.Persister).Connection(context.Background()).Where("name = ?", ct).First(&identity.CredentialsTypeTable{}))
 			}
 		})
@@ -0,0 +1,43 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/cipher"
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/identity"
+)
+
+var _ cipher.DataKeyPersister = new(Persister)
+
+func (p *Persister) GetIdentityDataKey(ctx context.Context, identityID uuid.UUID) (*cipher.DataKey, error) {
+	var k cipher.DataKey
+	if err := p.GetConnection(ctx).Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid)).First(&k); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &k, nil
+}
+
+func (p *Persister) CreateIdentityDataKey(ctx context.Context, k *cipher.DataKey) error {
+	k.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(k))
+}
+
+func (p *Persister) DeleteOrphanedIdentityDataKeys(ctx context.Context, createdBefore time.Time) (int, error) {
+	/* #nosec G201 TableName is static */
+	count, err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(
+		"DELETE FROM %[1]s WHERE nid = ? AND created_at < ? AND NOT EXISTS (SELECT 1 FROM %[2]s WHERE %[2]s.id = %[1]s.identity_id AND %[2]s.nid = %[1]s.nid)",
+		new(cipher.DataKey).TableName(ctx), new(identity.Identity).TableName(ctx)),
+		corp.ContextualizeNID(ctx, p.nid), createdBefore.UTC(),
+	).ExecWithCount()
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
@@ -0,0 +1,41 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/courier"
+)
+
+var _ courier.DeliverabilityPersister = new(Persister)
+
+func (p *Persister) AddDeliveryEvent(ctx context.Context, e *courier.DeliveryEvent) error {
+	e.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(e))
+}
+
+func (p *Persister) ListDeliveryEvents(ctx context.Context, since time.Time) ([]courier.DeliveryEvent, error) {
+	events := make([]courier.DeliveryEvent, 0)
+	if err := p.GetConnection(ctx).
+		Where("nid = ? AND created_at >= ?", corp.ContextualizeNID(ctx, p.nid), since).
+		Order("created_at ASC").
+		All(&events); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return events, nil
+}
+
+func (p *Persister) DeleteDeliveryEventsBefore(ctx context.Context, before time.Time) (int, error) {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec G201
+		fmt.Sprintf("DELETE FROM %s WHERE created_at < ? AND nid = ?",
+			new(courier.DeliveryEvent).TableName(ctx)), before, corp.ContextualizeNID(ctx, p.nid)).ExecWithCount()
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
@@ -0,0 +1,74 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/selfservice/flow/callback"
+)
+
+var _ callback.Persister = new(Persister)
+
+func (p *Persister) CreateFlowCallback(ctx context.Context, c *callback.Callback) error {
+	c.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(c))
+}
+
+func (p *Persister) CompleteFlowCallback(ctx context.Context, flowID uuid.UUID, result []byte, completedAt time.Time) (*callback.Callback, error) {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	var c callback.Callback
+	if err := sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		// The update only succeeds once for each callback, even if the flow is completed concurrently.
+		count, err := tx.RawQuery(
+			// #nosec
+			fmt.Sprintf("UPDATE %s SET result = ?, completed_at = ? WHERE flow_id = ? AND completed_at IS NULL AND expires_at > ? AND nid = ?",
+				c.TableName(ctx)), string(result), completedAt, flowID, completedAt, nid).ExecWithCount()
+		if err != nil {
+			return err
+		} else if count == 0 {
+			return sqlcon.ErrNoRows
+		}
+
+		return tx.Where("flow_id = ? AND nid = ?", flowID, nid).First(&c)
+	})); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (p *Persister) MarkFlowCallbackDelivered(ctx context.Context, id uuid.UUID, deliveredAt time.Time) error {
+	return sqlcon.HandleError(p.GetConnection(ctx).RawQuery(
+		// #nosec
+		fmt.Sprintf("UPDATE %s SET delivered_at = ? WHERE id = ? AND nid = ?",
+			new(callback.Callback).TableName(ctx)), deliveredAt, id, corp.ContextualizeNID(ctx, p.nid)).Exec())
+}
+
+func (p *Persister) ListUndeliveredFlowCallbacks(ctx context.Context, now time.Time, limit int) ([]callback.Callback, error) {
+	var cs []callback.Callback
+	if err := p.GetConnection(ctx).
+		Where("completed_at IS NOT NULL AND delivered_at IS NULL AND expires_at > ? AND nid = ?", now, corp.ContextualizeNID(ctx, p.nid)).
+		Order("completed_at ASC").
+		Limit(limit).
+		All(&cs); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return cs, nil
+}
+
+func (p *Persister) DeleteExpiredFlowCallbacks(ctx context.Context, expiresBefore time.Time) (int, error) {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at < ? AND nid = ?",
+			new(callback.Callback).TableName(ctx)), expiresBefore, corp.ContextualizeNID(ctx, p.nid)).ExecWithCount()
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
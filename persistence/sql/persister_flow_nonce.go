@@ -0,0 +1,63 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+)
+
+// setFlowNonce sets the submission nonce of a flow. The nonce columns are read-only for pop, so that updating a
+// flow which was loaded before its nonce was used or handed out can not restore the used nonce or hand it out again.
+func (p *Persister) setFlowNonce(ctx context.Context, table string, id uuid.UUID, nonce string, issued bool) error {
+	if nonce == "" {
+		return nil
+	}
+
+	// #nosec
+	return sqlcon.HandleError(p.GetConnection(ctx).RawQuery(
+		fmt.Sprintf("UPDATE %s SET submission_nonce = ?, submission_nonce_issued = ? WHERE id = ? AND nid = ?", table),
+		nonce, issued, id, corp.ContextualizeNID(ctx, p.nid)).Exec())
+}
+
+func (p *Persister) useFlowNonce(ctx context.Context, table string, id uuid.UUID, nonce, next string) error {
+	return p.replaceFlowNonce(ctx, table, "submission_nonce", id, nonce, next)
+}
+
+// replaceFlowNonce replaces the nonce stored in the column with next if it equals nonce.
+func (p *Persister) replaceFlowNonce(ctx context.Context, table, column string, id uuid.UUID, nonce, next string) error {
+	if nonce == "" {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+
+	// The update only succeeds once for each nonce, even if it is submitted concurrently.
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec
+		fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ? AND nid = ? AND %s = ?", table, column, column),
+		next, id, corp.ContextualizeNID(ctx, p.nid), nonce).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	} else if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+	return nil
+}
+
+func (p *Persister) issueFlowNonce(ctx context.Context, table string, id uuid.UUID) error {
+	// The update only succeeds once for each flow, even if it is fetched concurrently.
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec
+		fmt.Sprintf("UPDATE %s SET submission_nonce_issued = ? WHERE id = ? AND nid = ? AND submission_nonce_issued = ?", table),
+		true, id, corp.ContextualizeNID(ctx, p.nid), false).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	} else if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/geo"
+)
+
+var _ geo.Persister = new(Persister)
+
+func (p *Persister) GetIdentityPolicy(ctx context.Context, identityID uuid.UUID) (*geo.IdentityPolicy, error) {
+	var policy geo.IdentityPolicy
+	if err := p.GetConnection(ctx).Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid)).First(&policy); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &policy, nil
+}
+
+func (p *Persister) UpsertIdentityPolicy(ctx context.Context, policy *geo.IdentityPolicy) error {
+	policy.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		var existing geo.IdentityPolicy
+		if err := tx.Where("identity_id = ? AND nid = ?", policy.IdentityID, policy.NID).First(&existing); err != nil {
+			if !errors.Is(sqlcon.HandleError(err), sqlcon.ErrNoRows) {
+				return err
+			}
+			return tx.Create(policy)
+		}
+
+		policy.ID = existing.ID
+		policy.CreatedAt = existing.CreatedAt
+		return p.update(ctx, policy)
+	}))
+}
+
+func (p *Persister) CreateLoginChallenge(ctx context.Context, c *geo.Challenge) error {
+	c.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		/* #nosec G201 TableName is static */
+		if err := tx.RawQuery(fmt.Sprintf("DELETE FROM %s WHERE flow_id = ? AND nid = ?", c.TableName(ctx)), c.FlowID, c.NID).Exec(); err != nil {
+			return err
+		}
+		return tx.Create(c)
+	}))
+}
+
+func (p *Persister) GetLoginChallenge(ctx context.Context, flowID uuid.UUID) (*geo.Challenge, error) {
+	var c geo.Challenge
+	if err := p.GetConnection(ctx).Where("flow_id = ? AND nid = ?", flowID, corp.ContextualizeNID(ctx, p.nid)).First(&c); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &c, nil
+}
+
+func (p *Persister) UpdateLoginChallenge(ctx context.Context, c *geo.Challenge) error {
+	c.NID = corp.ContextualizeNID(ctx, p.nid)
+	return p.update(ctx, c)
+}
+
+func (p *Persister) DeleteLoginChallenge(ctx context.Context, id uuid.UUID) error {
+	return p.delete(ctx, new(geo.Challenge), id)
+}
@@ -0,0 +1,52 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/idempotency"
+)
+
+var _ idempotency.Persister = new(Persister)
+
+func (p *Persister) CreateIdempotencyRecord(ctx context.Context, r *idempotency.Record) error {
+	r.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(r))
+}
+
+func (p *Persister) GetIdempotencyRecord(ctx context.Context, scope, key string) (*idempotency.Record, error) {
+	var r idempotency.Record
+	if err := p.GetConnection(ctx).
+		Where("request_scope = ? AND idempotency_key = ? AND nid = ?", scope, key, corp.ContextualizeNID(ctx, p.nid)).
+		First(&r); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &r, nil
+}
+
+func (p *Persister) UpdateIdempotencyRecord(ctx context.Context, r *idempotency.Record) error {
+	cp := *r
+	cp.NID = corp.ContextualizeNID(ctx, p.nid)
+	return p.update(ctx, &cp)
+}
+
+func (p *Persister) DeleteIdempotencyRecord(ctx context.Context, id uuid.UUID) error {
+	return p.delete(ctx, new(idempotency.Record), id)
+}
+
+func (p *Persister) DeleteExpiredIdempotencyRecords(ctx context.Context, expiresBefore time.Time) (int, error) {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at < ? AND nid = ?",
+			new(idempotency.Record).TableName(ctx)), expiresBefore, corp.ContextualizeNID(ctx, p.nid)).ExecWithCount()
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
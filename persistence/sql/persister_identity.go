@@ -24,6 +24,7 @@ import (
 	"github.com/ory/x/errorsx"
 	"github.com/ory/x/sqlcon"
 
+	"github.com/ory/kratos/cipher"
 	"github.com/ory/kratos/identity"
 )
 
@@ -31,7 +32,7 @@ var _ identity.Pool = new(Persister)
 var _ identity.PrivilegedPool = new(Persister)
 
 func (p *Persister) ListVerifiableAddresses(ctx context.Context, page, itemsPerPage int) (a []identity.VerifiableAddress, err error) {
-	if err := p.GetConnection(ctx).Where("nid = ?", corp.ContextualizeNID(ctx, p.nid)).Order("id DESC").Paginate(page, x.MaxItemsPerPage(itemsPerPage)).All(&a); err != nil {
+	if err := p.GetConnection(ctx).Where("nid = ?", corp.ContextualizeNID(ctx, p.nid)).Order("id DESC").Paginate(page+1, x.MaxItemsPerPage(itemsPerPage)).All(&a); err != nil {
 		return nil, sqlcon.HandleError(err)
 	}
 
@@ -39,7 +40,7 @@ func (p *Persister) ListVerifiableAddresses(ctx context.Context, page, itemsPerP
 }
 
 func (p *Persister) ListRecoveryAddresses(ctx context.Context, page, itemsPerPage int) (a []identity.RecoveryAddress, err error) {
-	if err := p.GetConnection(ctx).Where("nid = ?", corp.ContextualizeNID(ctx, p.nid)).Order("id DESC").Paginate(page, x.MaxItemsPerPage(itemsPerPage)).All(&a); err != nil {
+	if err := p.GetConnection(ctx).Where("nid = ?", corp.ContextualizeNID(ctx, p.nid)).Order("id DESC").Paginate(page+1, x.MaxItemsPerPage(itemsPerPage)).All(&a); err != nil {
 		return nil, sqlcon.HandleError(err)
 	}
 
@@ -59,7 +60,7 @@ func (p *Persister) FindByCredentialsIdentifier(ctx context.Context, ct identity
 	}
 
 	// Force case-insensitivity for identifiers
-	if ct == identity.CredentialsTypePassword {
+	if caseInsensitiveIdentifiers(ct) {
 		match = strings.ToLower(match)
 	}
 
@@ -102,6 +103,67 @@ WHERE ici.identifier = ?
 	return i.CopyWithoutCredentials(), creds, nil
 }
 
+func (p *Persister) FindCredentialsByIdentifiers(ctx context.Context, identifiers []string) ([]identity.KnownCredentials, error) {
+	if len(identifiers) == 0 {
+		return []identity.KnownCredentials{}, nil
+	}
+
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	seen := make(map[string]bool, len(identifiers)*2)
+	args := []interface{}{nid, nid}
+	for _, identifier := range identifiers {
+		for _, match := range []string{identifier, strings.ToLower(identifier)} {
+			if !seen[match] {
+				seen[match] = true
+				args = append(args, match)
+			}
+		}
+	}
+
+	var found []struct {
+		Identifier string                   `db:"identifier"`
+		IdentityID uuid.UUID                `db:"identity_id"`
+		Type       identity.CredentialsType `db:"name"`
+	}
+	// #nosec G201
+	if err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(`SELECT
+    ici.identifier, ic.identity_id, ict.name
+FROM %s ic
+         INNER JOIN %s ict on ic.identity_credential_type_id = ict.id
+         INNER JOIN %s ici on ic.id = ici.identity_credential_id
+WHERE ic.nid = ?
+  AND ici.nid = ?
+  AND ici.identifier IN (?%s)`,
+		corp.ContextualizeTableName(ctx, "identity_credentials"),
+		corp.ContextualizeTableName(ctx, "identity_credential_types"),
+		corp.ContextualizeTableName(ctx, "identity_credential_identifiers"),
+		strings.Repeat(", ?", len(args)-3),
+	), args...).All(&found); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	known := make([]identity.KnownCredentials, 0, len(found))
+	reported := make(map[string]bool, len(identifiers))
+	for _, identifier := range identifiers {
+		if reported[identifier] {
+			continue
+		}
+		reported[identifier] = true
+
+		for _, f := range found {
+			if f.Identifier == identifier || (caseInsensitiveIdentifiers(f.Type) && f.Identifier == strings.ToLower(identifier)) {
+				known = append(known, identity.KnownCredentials{Identifier: identifier, IdentityID: f.IdentityID, Type: f.Type})
+			}
+		}
+	}
+	return known, nil
+}
+
+// caseInsensitiveIdentifiers returns whether the identifiers of the credentials type are stored lower cased.
+func caseInsensitiveIdentifiers(ct identity.CredentialsType) bool {
+	return ct == identity.CredentialsTypePassword || ct == identity.CredentialsTypeUsernameReservation || ct == identity.CredentialsTypeSIWE
+}
+
 func (p *Persister) findIdentityCredentialsType(ctx context.Context, ct identity.CredentialsType) (*identity.CredentialsTypeTable, error) {
 	var m identity.CredentialsTypeTable
 	if err := p.GetConnection(ctx).Where("name = ?", ct).First(&m); err != nil {
@@ -135,7 +197,7 @@ func (p *Persister) createIdentityCredentials(ctx context.Context, i *identity.I
 
 		for _, ids := range cred.Identifiers {
 			// Force case-insensitivity for identifiers
-			if cred.Type == identity.CredentialsTypePassword {
+			if cred.Type == identity.CredentialsTypePassword || cred.Type == identity.CredentialsTypeSIWE {
 				ids = strings.ToLower(ids)
 			}
 
@@ -173,6 +235,9 @@ func (p *Persister) createRecoveryAddresses(ctx context.Context, i *identity.Ide
 	for k := range i.RecoveryAddresses {
 		i.RecoveryAddresses[k].IdentityID = i.ID
 		i.RecoveryAddresses[k].NID = corp.ContextualizeNID(ctx, p.nid)
+		if i.RecoveryAddresses[k].Source == "" {
+			i.RecoveryAddresses[k].Source = identity.RecoveryAddressSourceSchema
+		}
 		if err := p.GetConnection(ctx).Create(&i.RecoveryAddresses[k]); err != nil {
 			return err
 		}
@@ -217,6 +282,10 @@ func (p *Persister) CreateIdentity(ctx context.Context, i *identity.Identity) er
 		i.Traits = identity.Traits("{}")
 	}
 
+	if i.State == "" {
+		i.State = identity.StateActive
+	}
+
 	if err := p.injectTraitsSchemaURL(ctx, i); err != nil {
 		return err
 	}
@@ -225,11 +294,22 @@ func (p *Persister) CreateIdentity(ctx context.Context, i *identity.Identity) er
 		return err
 	}
 
+	// pop always overwrites updated_at on create which is why imported values are restored afterwards.
+	updatedAt := i.UpdatedAt
 	return p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
 		if err := tx.Create(i); err != nil {
 			return sqlcon.HandleError(err)
 		}
 
+		if !updatedAt.IsZero() {
+			i.UpdatedAt = updatedAt
+			/* #nosec G201 TableName is static */
+			if err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET updated_at = ? WHERE id = ? AND nid = ?", i.TableName(ctx)),
+				i.UpdatedAt, i.ID, i.NID).Exec(); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+
 		if err := p.createVerifiableAddresses(ctx, i); err != nil {
 			return sqlcon.HandleError(err)
 		}
@@ -245,9 +325,10 @@ func (p *Persister) CreateIdentity(ctx context.Context, i *identity.Identity) er
 func (p *Persister) ListIdentities(ctx context.Context, page, perPage int) ([]identity.Identity, error) {
 	is := make([]identity.Identity, 0)
 
+	// Pages are zero-based, as in x.ParsePagination, but pop counts them from one.
 	/* #nosec G201 TableName is static */
 	if err := sqlcon.HandleError(p.GetConnection(ctx).Where("nid = ?", corp.ContextualizeNID(ctx, p.nid)).
-		Paginate(page, perPage).Order("id DESC").
+		Paginate(page+1, perPage).Order("id DESC").
 		All(&is)); err != nil {
 		return nil, err
 	}
@@ -278,8 +359,26 @@ func (p *Persister) UpdateIdentity(ctx context.Context, i *identity.Identity) er
 	}
 
 	i.NID = corp.ContextualizeNID(ctx, p.nid)
+	if i.State == "" {
+		i.State = identity.StateActive
+	}
+
 	return sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
-		if count, err := tx.Where("id = ? AND nid = ?", i.ID, corp.ContextualizeNID(ctx, p.nid)).Count(i); err != nil {
+		if expected, ok := identity.ExpectedUpdatedAt(ctx); ok {
+			// Claims the identity with a single statement, so that only one of several concurrent updates which
+			// loaded the same version succeeds.
+			now := time.Now().UTC()
+			/* #nosec G201 TableName is static */
+			count, err := tx.RawQuery(fmt.Sprintf(
+				"UPDATE %s SET updated_at = ? WHERE id = ? AND nid = ? AND updated_at = ?", i.TableName(ctx)),
+				now, i.ID, corp.ContextualizeNID(ctx, p.nid), expected).ExecWithCount()
+			if err != nil {
+				return err
+			} else if count == 0 {
+				return errors.WithStack(sqlcon.ErrConcurrentUpdate)
+			}
+			i.UpdatedAt = now
+		} else if count, err := tx.Where("id = ? AND nid = ?", i.ID, corp.ContextualizeNID(ctx, p.nid)).Count(i); err != nil {
 			return err
 		} else if count == 0 {
 			return sql.ErrNoRows
@@ -314,7 +413,18 @@ func (p *Persister) UpdateIdentity(ctx context.Context, i *identity.Identity) er
 }
 
 func (p *Persister) DeleteIdentity(ctx context.Context, id uuid.UUID) error {
-	return p.delete(ctx, new(identity.Identity), id)
+	return p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		// The data key has no foreign key to the identity because it is created before the identity is, for
+		// example when the claims received during registration are encrypted. Keys of registrations which fail
+		// afterwards are removed by the data-key-cleanup job.
+		/* #nosec G201 TableName is static */
+		if err := tx.RawQuery(fmt.Sprintf("DELETE FROM %s WHERE identity_id = ? AND nid = ?", new(cipher.DataKey).TableName(ctx)),
+			id, corp.ContextualizeNID(ctx, p.nid)).Exec(); err != nil {
+			return sqlcon.HandleError(err)
+		}
+
+		return p.delete(ctx, new(identity.Identity), id)
+	})
 }
 
 func (p *Persister) GetIdentity(ctx context.Context, id uuid.UUID) (*identity.Identity, error) {
@@ -401,7 +511,7 @@ func (p *Persister) FindVerifiableAddressByValue(ctx context.Context, via identi
 
 func (p *Persister) FindRecoveryAddressByValue(ctx context.Context, via identity.RecoveryAddressType, value string) (*identity.RecoveryAddress, error) {
 	var address identity.RecoveryAddress
-	if err := p.GetConnection(ctx).Where("nid = ? AND via = ? AND value = ?", corp.ContextualizeNID(ctx, p.nid), via, value).First(&address); err != nil {
+	if err := p.GetConnection(ctx).Where("nid = ? AND via = ? AND value = ? AND disabled = ?", corp.ContextualizeNID(ctx, p.nid), via, value, false).First(&address); err != nil {
 		return nil, sqlcon.HandleError(err)
 	}
 
@@ -443,6 +553,19 @@ func (p *Persister) UpdateVerifiableAddress(ctx context.Context, address *identi
 	return p.update(ctx, address)
 }
 
+func (p *Persister) CreateVerifiableAddress(ctx context.Context, address *identity.VerifiableAddress) error {
+	address.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		if count, err := tx.Where("id = ? AND nid = ?", address.IdentityID, address.NID).Count(new(identity.Identity)); err != nil {
+			return err
+		} else if count == 0 {
+			return sql.ErrNoRows
+		}
+
+		return tx.Create(address)
+	}))
+}
+
 func (p *Persister) validateIdentity(ctx context.Context, i *identity.Identity) error {
 	if err := p.r.IdentityValidator().ValidateWithRunner(ctx, i); err != nil {
 		if _, ok := errorsx.Cause(err).(*jsonschema.ValidationError); ok {
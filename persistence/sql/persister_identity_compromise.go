@@ -0,0 +1,72 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/session"
+)
+
+func (p *Persister) RevokeIdentityAccess(ctx context.Context, id uuid.UUID) (*identity.CompromiseReport, error) {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	if _, err := p.GetIdentity(ctx, id); err != nil {
+		return nil, err
+	}
+
+	r := &identity.CompromiseReport{IdentityID: id}
+	if err := p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		count, err := tx.RawQuery(
+			// #nosec G201 table name is static
+			fmt.Sprintf("DELETE FROM %s WHERE identity_id = ? AND nid = ?", new(session.Session).TableName(ctx)),
+			id, nid,
+		).ExecWithCount()
+		if err != nil {
+			return err
+		}
+		r.Sessions = count
+
+		for _, c := range []struct {
+			count     *int
+			table     string
+			column    string
+			addresses string
+		}{
+			{
+				count:     &r.VerificationTokens,
+				table:     new(link.VerificationToken).TableName(ctx),
+				column:    "identity_verifiable_address_id",
+				addresses: new(identity.VerifiableAddress).TableName(ctx),
+			},
+			{
+				count:     &r.RecoveryTokens,
+				table:     new(link.RecoveryToken).TableName(ctx),
+				column:    "identity_recovery_address_id",
+				addresses: new(identity.RecoveryAddress).TableName(ctx),
+			},
+		} {
+			count, err := tx.RawQuery(
+				// #nosec G201 column and table names are static
+				fmt.Sprintf("DELETE FROM %s WHERE nid = ? AND %s IN (SELECT id FROM %s WHERE identity_id = ? AND nid = ?)", c.table, c.column, c.addresses),
+				nid, id, nid,
+			).ExecWithCount()
+			if err != nil {
+				return err
+			}
+			*c.count = count
+		}
+
+		return nil
+	}); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	return r, nil
+}
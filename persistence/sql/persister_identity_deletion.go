@@ -0,0 +1,76 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/cipher"
+	"github.com/ory/kratos/continuity"
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/session"
+)
+
+func (p *Persister) DescribeIdentityDeletion(ctx context.Context, id uuid.UUID) (*identity.DeletionReport, error) {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	if _, err := p.GetIdentity(ctx, id); err != nil {
+		return nil, err
+	}
+
+	r := &identity.DeletionReport{IdentityID: id}
+	for _, c := range []struct {
+		count *int
+		model interface{}
+	}{
+		{count: &r.Credentials, model: new(identity.Credentials)},
+		{count: &r.VerifiableAddresses, model: new(identity.VerifiableAddress)},
+		{count: &r.RecoveryAddresses, model: new(identity.RecoveryAddress)},
+		{count: &r.Sessions, model: new(session.Session)},
+		{count: &r.ContinuityContainers, model: new(continuity.Container)},
+		{count: &r.CommunicationPreferences, model: new(courier.Preferences)},
+		{count: &r.DataKeys, model: new(cipher.DataKey)},
+	} {
+		count, err := p.GetConnection(ctx).Where("identity_id = ? AND nid = ?", id, nid).Count(c.model)
+		if err != nil {
+			return nil, sqlcon.HandleError(err)
+		}
+		*c.count = count
+	}
+
+	for _, c := range []struct {
+		count     *int
+		model     interface{}
+		column    string
+		addresses string
+	}{
+		{
+			count:     &r.VerificationTokens,
+			model:     new(link.VerificationToken),
+			column:    "identity_verifiable_address_id",
+			addresses: new(identity.VerifiableAddress).TableName(ctx),
+		},
+		{
+			count:     &r.RecoveryTokens,
+			model:     new(link.RecoveryToken),
+			column:    "identity_recovery_address_id",
+			addresses: new(identity.RecoveryAddress).TableName(ctx),
+		},
+	} {
+		count, err := p.GetConnection(ctx).
+			// #nosec G201 column and table names are static
+			Where(fmt.Sprintf("nid = ? AND %s IN (SELECT id FROM %s WHERE identity_id = ? AND nid = ?)", c.column, c.addresses), nid, id, nid).
+			Count(c.model)
+		if err != nil {
+			return nil, sqlcon.HandleError(err)
+		}
+		*c.count = count
+	}
+
+	return r, nil
+}
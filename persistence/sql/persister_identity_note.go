@@ -0,0 +1,42 @@
+package sql
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/x"
+)
+
+var _ identity.NotePersister = new(Persister)
+
+func (p *Persister) CreateIdentityNote(ctx context.Context, n *identity.Note) error {
+	n.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(n))
+}
+
+func (p *Persister) ListIdentityNotes(ctx context.Context, identityID uuid.UUID, page, itemsPerPage int) ([]identity.Note, error) {
+	notes := make([]identity.Note, 0)
+	if err := p.GetConnection(ctx).
+		Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid)).
+		Order("created_at DESC, id DESC").
+		Paginate(page+1, x.MaxItemsPerPage(itemsPerPage)).
+		All(&notes); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return notes, nil
+}
+
+func (p *Persister) CountIdentityNotes(ctx context.Context, identityID uuid.UUID) (int64, error) {
+	count, err := p.GetConnection(ctx).
+		Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid)).
+		Count(new(identity.Note))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return int64(count), nil
+}
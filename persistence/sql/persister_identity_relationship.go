@@ -0,0 +1,42 @@
+package sql
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/identity"
+)
+
+var _ identity.RelationshipPersister = new(Persister)
+
+func (p *Persister) CreateIdentityRelationship(ctx context.Context, r *identity.Relationship) error {
+	r.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(r))
+}
+
+func (p *Persister) GetIdentityRelationship(ctx context.Context, id uuid.UUID) (*identity.Relationship, error) {
+	var r identity.Relationship
+	if err := p.GetConnection(ctx).Where("id = ? AND nid = ?", id, corp.ContextualizeNID(ctx, p.nid)).First(&r); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &r, nil
+}
+
+func (p *Persister) DeleteIdentityRelationship(ctx context.Context, id uuid.UUID) error {
+	return p.delete(ctx, new(identity.Relationship), id)
+}
+
+func (p *Persister) ListIdentityRelationships(ctx context.Context, identityID uuid.UUID) ([]identity.Relationship, error) {
+	relationships := make([]identity.Relationship, 0)
+	if err := p.GetConnection(ctx).
+		Where("(identity_id = ? OR related_identity_id = ?) AND nid = ?", identityID, identityID, corp.ContextualizeNID(ctx, p.nid)).
+		Order("created_at ASC, id ASC").
+		All(&relationships); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return relationships, nil
+}
@@ -0,0 +1,86 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/x"
+)
+
+var _ identity.ScheduledStateChangePersister = new(Persister)
+
+func (p *Persister) CreateScheduledStateChange(ctx context.Context, c *identity.ScheduledStateChange) error {
+	c.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(c))
+}
+
+func (p *Persister) GetScheduledStateChange(ctx context.Context, id uuid.UUID) (*identity.ScheduledStateChange, error) {
+	var c identity.ScheduledStateChange
+	if err := p.GetConnection(ctx).Where("id = ? AND nid = ?", id, corp.ContextualizeNID(ctx, p.nid)).First(&c); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &c, nil
+}
+
+func (p *Persister) DeleteScheduledStateChange(ctx context.Context, id uuid.UUID) error {
+	return p.delete(ctx, new(identity.ScheduledStateChange), id)
+}
+
+func (p *Persister) ListScheduledStateChanges(ctx context.Context, identityID uuid.UUID, page, itemsPerPage int) ([]identity.ScheduledStateChange, error) {
+	changes := make([]identity.ScheduledStateChange, 0)
+	if err := p.GetConnection(ctx).
+		Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid)).
+		Order("execute_at ASC, id ASC").
+		Paginate(page+1, x.MaxItemsPerPage(itemsPerPage)).
+		All(&changes); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return changes, nil
+}
+
+func (p *Persister) CountScheduledStateChanges(ctx context.Context, identityID uuid.UUID) (int64, error) {
+	count, err := p.GetConnection(ctx).
+		Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid)).
+		Count(new(identity.ScheduledStateChange))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return int64(count), nil
+}
+
+func (p *Persister) ListDueScheduledStateChanges(ctx context.Context, now time.Time, limit int) ([]identity.ScheduledStateChange, error) {
+	changes := make([]identity.ScheduledStateChange, 0)
+	if err := p.GetConnection(ctx).
+		Where("executed_at IS NULL AND execute_at <= ? AND nid = ?", now.UTC(), corp.ContextualizeNID(ctx, p.nid)).
+		Order("execute_at ASC, id ASC").
+		Limit(limit).
+		All(&changes); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return changes, nil
+}
+
+func (p *Persister) MarkScheduledStateChangeExecuted(ctx context.Context, id uuid.UUID, executedAt time.Time) error {
+	/* #nosec G201 TableName is static */
+	count, err := p.GetConnection(ctx).RawQuery(fmt.Sprintf("UPDATE %s SET executed_at = ?, updated_at = ? WHERE id = ? AND nid = ?", new(identity.ScheduledStateChange).TableName(ctx)),
+		executedAt.UTC(),
+		time.Now().UTC(),
+		id,
+		corp.ContextualizeNID(ctx, p.nid),
+	).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+	return nil
+}
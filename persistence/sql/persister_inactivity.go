@@ -0,0 +1,83 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/inactivity"
+	"github.com/ory/kratos/session"
+)
+
+var _ inactivity.Persister = new(Persister)
+
+func (p *Persister) ListInactiveIdentities(ctx context.Context, since time.Time, excludeSchemaIDs []string, after uuid.UUID, limit int) ([]identity.Identity, error) {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	identities := new(identity.Identity).TableName(ctx)
+
+	q := p.GetConnection(ctx).
+		Where(fmt.Sprintf("%s.nid = ? AND %s.id > ?", identities, identities), nid, after).
+		Where("created_at < ? AND (state_changed_at IS NULL OR state_changed_at < ?)", since, since).
+		// #nosec G201 TableName is static
+		Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s s WHERE s.identity_id = %s.id AND s.nid = ? AND s.authenticated_at >= ?)",
+			new(session.Session).TableName(ctx), identities), nid, since)
+
+	if len(excludeSchemaIDs) > 0 {
+		args := make([]interface{}, len(excludeSchemaIDs))
+		for k := range excludeSchemaIDs {
+			args[k] = excludeSchemaIDs[k]
+		}
+		q = q.Where("schema_id NOT IN (?)", args...)
+	}
+
+	is := make([]identity.Identity, 0)
+	if err := q.Order("id ASC").Limit(limit).All(&is); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	for k := range is {
+		if err := p.findRecoveryAddresses(ctx, &is[k]); err != nil {
+			return nil, sqlcon.HandleError(err)
+		}
+	}
+
+	return is, nil
+}
+
+func (p *Persister) LastAuthenticatedAt(ctx context.Context, identityID uuid.UUID) (time.Time, error) {
+	var s session.Session
+	if err := p.GetConnection(ctx).
+		Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid)).
+		Order("authenticated_at DESC").
+		First(&s); err != nil {
+		if err := sqlcon.HandleError(err); errors.Is(err, sqlcon.ErrNoRows) {
+			return time.Time{}, nil
+		} else {
+			return time.Time{}, err
+		}
+	}
+	return s.AuthenticatedAt, nil
+}
+
+func (p *Persister) CreateInactivityNotification(ctx context.Context, n *inactivity.Notification) error {
+	n.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(n))
+}
+
+func (p *Persister) LatestInactivityNotification(ctx context.Context, identityID uuid.UUID, policyID string) (*inactivity.Notification, error) {
+	var n inactivity.Notification
+	if err := p.GetConnection(ctx).
+		Where("identity_id = ? AND policy_id = ? AND nid = ?", identityID, policyID, corp.ContextualizeNID(ctx, p.nid)).
+		Order("created_at DESC").
+		First(&n); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &n, nil
+}
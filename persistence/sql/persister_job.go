@@ -0,0 +1,106 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/job"
+	"github.com/ory/kratos/x"
+)
+
+var _ job.Persister = new(Persister)
+
+func (p *Persister) CreateJobRun(ctx context.Context, r *job.Run) error {
+	r.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(r))
+}
+
+func (p *Persister) UpdateJobRun(ctx context.Context, r *job.Run) error {
+	cp := *r
+	cp.NID = corp.ContextualizeNID(ctx, p.nid)
+	return p.update(ctx, &cp)
+}
+
+func (p *Persister) jobRunsQuery(ctx context.Context, jobID string) *pop.Query {
+	q := p.GetConnection(ctx).Where("nid = ?", corp.ContextualizeNID(ctx, p.nid))
+	if jobID != "" {
+		q = q.Where("job_id = ?", jobID)
+	}
+	return q
+}
+
+func (p *Persister) ListJobRuns(ctx context.Context, jobID string, page, itemsPerPage int) ([]job.Run, error) {
+	runs := make([]job.Run, 0)
+	if err := p.jobRunsQuery(ctx, jobID).
+		Order("started_at DESC, id DESC").
+		Paginate(page+1, x.MaxItemsPerPage(itemsPerPage)).
+		All(&runs); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return runs, nil
+}
+
+func (p *Persister) CountJobRuns(ctx context.Context, jobID string) (int64, error) {
+	count, err := p.jobRunsQuery(ctx, jobID).Count(new(job.Run))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return int64(count), nil
+}
+
+func (p *Persister) DeleteJobRunsBefore(ctx context.Context, before time.Time) (int, error) {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec
+		fmt.Sprintf("DELETE FROM %s WHERE started_at < ? AND nid = ?",
+			new(job.Run).TableName(ctx)), before, corp.ContextualizeNID(ctx, p.nid)).ExecWithCount()
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
+
+func (p *Persister) AcquireJobLock(ctx context.Context, jobID, holder string, until time.Time) (bool, error) {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	now := time.Now().UTC()
+
+	/* #nosec G201 TableName is static */
+	count, err := p.GetConnection(ctx).RawQuery(
+		fmt.Sprintf("UPDATE %s SET holder = ?, locked_until = ?, updated_at = ? WHERE job_id = ? AND nid = ? AND (holder = ? OR locked_until < ?)",
+			new(job.Lock).TableName(ctx)), holder, until, now, jobID, nid, holder, now).ExecWithCount()
+	if err != nil {
+		return false, sqlcon.HandleError(err)
+	} else if count > 0 {
+		return true, nil
+	}
+
+	// The lock either does not exist yet or someone else holds it, in which case the insert violates the
+	// unique index on the job ID.
+	if err := sqlcon.HandleError(p.GetConnection(ctx).Create(&job.Lock{
+		ID:          x.NewUUID(),
+		NID:         nid,
+		JobID:       jobID,
+		Holder:      holder,
+		LockedUntil: until,
+	})); errors.Is(err, sqlcon.ErrUniqueViolation) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *Persister) ReleaseJobLock(ctx context.Context, jobID, holder string) error {
+	now := time.Now().UTC()
+
+	/* #nosec G201 TableName is static */
+	return sqlcon.HandleError(p.GetConnection(ctx).RawQuery(
+		fmt.Sprintf("UPDATE %s SET locked_until = ?, updated_at = ? WHERE job_id = ? AND nid = ? AND holder = ?",
+			new(job.Lock).TableName(ctx)), now, now, jobID, corp.ContextualizeNID(ctx, p.nid), holder).Exec())
+}
@@ -0,0 +1,79 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/strategy/link"
+)
+
+var _ link.ExpiryPersister = new(Persister)
+
+func (p *Persister) ListExpiredVerificationTokens(ctx context.Context, expiredAfter, expiredBefore time.Time, limit int) ([]link.VerificationToken, error) {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	tokens := new(link.VerificationToken).TableName(ctx)
+
+	ts := make([]link.VerificationToken, 0)
+	if err := p.GetConnection(ctx).
+		Where(fmt.Sprintf("%s.nid = ? AND NOT %s.used AND %s.expires_at > ? AND %s.expires_at <= ?", tokens, tokens, tokens, tokens), nid, expiredAfter.UTC(), expiredBefore.UTC()).
+		// #nosec G201 TableName is static
+		Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s n WHERE n.token_id = %s.id AND n.nid = ?)", new(link.ExpiryNotification).TableName(ctx), tokens), nid).
+		// #nosec G201 TableName is static
+		Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s o WHERE o.identity_verifiable_address_id = %s.identity_verifiable_address_id AND o.nid = ? AND o.issued_at > %s.issued_at)", tokens, tokens, tokens), nid).
+		Order("expires_at ASC").Limit(limit).All(&ts); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	for k := range ts {
+		var va identity.VerifiableAddress
+		if err := p.GetConnection(ctx).Where("id = ? AND nid = ?", ts[k].VerifiableAddressID, nid).First(&va); err != nil {
+			return nil, sqlcon.HandleError(err)
+		}
+		ts[k].VerifiableAddress = &va
+	}
+
+	return ts, nil
+}
+
+func (p *Persister) ListExpiredRecoveryTokens(ctx context.Context, expiredAfter, expiredBefore time.Time, limit int) ([]link.RecoveryToken, error) {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	tokens := new(link.RecoveryToken).TableName(ctx)
+
+	ts := make([]link.RecoveryToken, 0)
+	if err := p.GetConnection(ctx).
+		Where(fmt.Sprintf("%s.nid = ? AND NOT %s.used AND %s.expires_at > ? AND %s.expires_at <= ?", tokens, tokens, tokens, tokens), nid, expiredAfter.UTC(), expiredBefore.UTC()).
+		// #nosec G201 TableName is static
+		Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s n WHERE n.token_id = %s.id AND n.nid = ?)", new(link.ExpiryNotification).TableName(ctx), tokens), nid).
+		// #nosec G201 TableName is static
+		Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s o WHERE o.identity_recovery_address_id = %s.identity_recovery_address_id AND o.nid = ? AND o.issued_at > %s.issued_at)", tokens, tokens, tokens), nid).
+		Order("expires_at ASC").Limit(limit).All(&ts); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	for k := range ts {
+		var ra identity.RecoveryAddress
+		if err := p.GetConnection(ctx).Where("id = ? AND nid = ?", ts[k].RecoveryAddressID, nid).First(&ra); err != nil {
+			return nil, sqlcon.HandleError(err)
+		}
+		ts[k].RecoveryAddress = &ra
+	}
+
+	return ts, nil
+}
+
+func (p *Persister) CreateExpiryNotification(ctx context.Context, n *link.ExpiryNotification) error {
+	n.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(n))
+}
+
+func (p *Persister) DeleteExpiryNotifications(ctx context.Context, flowType string, createdBefore time.Time) error {
+	/* #nosec G201 TableName is static */
+	return sqlcon.HandleError(p.GetConnection(ctx).RawQuery(
+		fmt.Sprintf("DELETE FROM %s WHERE flow_type = ? AND nid = ? AND created_at < ?", new(link.ExpiryNotification).TableName(ctx)),
+		flowType, corp.ContextualizeNID(ctx, p.nid), createdBefore.UTC()).Exec())
+}
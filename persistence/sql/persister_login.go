@@ -2,6 +2,7 @@ package sql
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/ory/kratos/corp"
 
@@ -18,7 +19,18 @@ var _ login.FlowPersister = new(Persister)
 
 func (p *Persister) CreateLoginFlow(ctx context.Context, r *login.Flow) error {
 	r.NID = corp.ContextualizeNID(ctx, p.nid)
-	return p.GetConnection(ctx).Create(r)
+	return p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		if err := tx.Create(r); err != nil {
+			return err
+		} else if r.SIWENonce == "" {
+			return nil
+		}
+
+		// #nosec
+		return sqlcon.HandleError(tx.RawQuery(
+			fmt.Sprintf("UPDATE %s SET siwe_nonce = ? WHERE id = ? AND nid = ?", r.TableName(ctx)),
+			r.SIWENonce, r.ID, r.NID).Exec())
+	})
 }
 
 func (p *Persister) UpdateLoginFlow(ctx context.Context, r *login.Flow) error {
@@ -49,3 +61,7 @@ func (p *Persister) ForceLoginFlow(ctx context.Context, id uuid.UUID) error {
 		return tx.Save(lr, "nid")
 	})
 }
+
+func (p *Persister) UseLoginFlowSIWENonce(ctx context.Context, id uuid.UUID, nonce, next string) error {
+	return p.replaceFlowNonce(ctx, new(login.Flow).TableName(ctx), "siwe_nonce", id, nonce, next)
+}
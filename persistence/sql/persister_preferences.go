@@ -0,0 +1,41 @@
+package sql
+
+import (
+	"context"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/courier"
+)
+
+var _ courier.PreferencesPersister = new(Persister)
+
+func (p *Persister) GetCommunicationPreferences(ctx context.Context, identityID uuid.UUID) (*courier.Preferences, error) {
+	var prefs courier.Preferences
+	if err := p.GetConnection(ctx).Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid)).First(&prefs); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &prefs, nil
+}
+
+func (p *Persister) UpsertCommunicationPreferences(ctx context.Context, prefs *courier.Preferences) error {
+	prefs.NID = corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		var existing courier.Preferences
+		if err := tx.Where("identity_id = ? AND nid = ?", prefs.IdentityID, prefs.NID).First(&existing); err != nil {
+			if !errors.Is(sqlcon.HandleError(err), sqlcon.ErrNoRows) {
+				return err
+			}
+			return tx.Create(prefs)
+		}
+
+		prefs.ID = existing.ID
+		prefs.CreatedAt = existing.CreatedAt
+		return p.update(ctx, prefs)
+	}))
+}
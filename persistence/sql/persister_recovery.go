@@ -23,7 +23,12 @@ var _ link.RecoveryTokenPersister = new(Persister)
 
 func (p Persister) CreateRecoveryFlow(ctx context.Context, r *recovery.Flow) error {
 	r.NID = corp.ContextualizeNID(ctx, p.nid)
-	return p.GetConnection(ctx).Create(r)
+	return p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		if err := tx.Create(r); err != nil {
+			return err
+		}
+		return p.setFlowNonce(ctx, r.TableName(ctx), r.ID, r.SubmissionNonce, r.SubmissionNonceIssued)
+	})
 }
 
 func (p Persister) GetRecoveryFlow(ctx context.Context, id uuid.UUID) (*recovery.Flow, error) {
@@ -41,6 +46,14 @@ func (p Persister) UpdateRecoveryFlow(ctx context.Context, r *recovery.Flow) err
 	return p.update(ctx, cp)
 }
 
+func (p Persister) UseRecoveryFlowNonce(ctx context.Context, id uuid.UUID, nonce, next string) error {
+	return p.useFlowNonce(ctx, new(recovery.Flow).TableName(ctx), id, nonce, next)
+}
+
+func (p Persister) IssueRecoveryFlowNonce(ctx context.Context, id uuid.UUID) error {
+	return p.issueFlowNonce(ctx, new(recovery.Flow).TableName(ctx), id)
+}
+
 func (p *Persister) CreateRecoveryToken(ctx context.Context, token *link.RecoveryToken) error {
 	t := token.Token
 	token.Token = p.hmacValue(ctx, t)
@@ -82,7 +95,14 @@ func (p *Persister) UseRecoveryToken(ctx context.Context, token string) (*link.R
 		rt.RecoveryAddress = &ra
 
 		/* #nosec G201 TableName is static */
-		return tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE id=? AND nid = ?", rt.TableName(ctx)), time.Now().UTC(), rt.ID, nid).Exec()
+		count, err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE id=? AND nid = ? AND NOT used", rt.TableName(ctx)), time.Now().UTC(), rt.ID, nid).ExecWithCount()
+		if err != nil {
+			return err
+		} else if count == 0 {
+			// The token was used concurrently, for example by a request which was retried in another region.
+			return sqlcon.ErrNoRows
+		}
+		return nil
 	})); err != nil {
 		return nil, err
 	}
@@ -90,6 +110,51 @@ func (p *Persister) UseRecoveryToken(ctx context.Context, token string) (*link.R
 	return &rt, nil
 }
 
+func (p *Persister) ReserveRecoveryCodeAttempt(ctx context.Context, flowID uuid.UUID, maxAttempts int) (bool, error) {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	var locked bool
+	if err := sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		table := new(link.RecoveryToken).TableName(ctx)
+
+		/* #nosec G201 TableName is static */
+		if err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE selfservice_recovery_flow_id = ? AND nid = ? AND NOT used AND attempts >= ?", table), time.Now().UTC(), flowID, nid, maxAttempts).Exec(); err != nil {
+			return err
+		}
+
+		// The limit is part of the update, so that concurrent requests can not reserve more attempts than allowed.
+		/* #nosec G201 TableName is static */
+		count, err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET attempts = attempts + 1 WHERE selfservice_recovery_flow_id = ? AND nid = ? AND NOT used AND attempts < ?", table), flowID, nid, maxAttempts).ExecWithCount()
+		if err != nil {
+			return err
+		} else if count > 0 {
+			return nil
+		}
+
+		exhausted, err := tx.Where("selfservice_recovery_flow_id = ? AND nid = ? AND attempts >= ?", flowID, nid, maxAttempts).Count(new(link.RecoveryToken))
+		if err != nil {
+			return err
+		}
+
+		locked = exhausted > 0
+		return nil
+	})); err != nil {
+		return false, err
+	}
+	return locked, nil
+}
+
+func (p *Persister) LastRecoveryTokenIssuedAt(ctx context.Context, addressID uuid.UUID) (time.Time, error) {
+	var t link.RecoveryToken
+	if err := p.GetConnection(ctx).Where("identity_recovery_address_id = ? AND nid = ?", addressID, corp.ContextualizeNID(ctx, p.nid)).
+		Order("issued_at DESC").First(&t); err != nil {
+		if errors.Is(sqlcon.HandleError(err), sqlcon.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, sqlcon.HandleError(err)
+	}
+	return t.IssuedAt, nil
+}
+
 func (p *Persister) DeleteRecoveryToken(ctx context.Context, token string) error {
 	/* #nosec G201 TableName is static */
 	return p.GetConnection(ctx).RawQuery(fmt.Sprintf("DELETE FROM %s WHERE token=? AND nid = ?", new(link.RecoveryToken).TableName(ctx)), token, corp.ContextualizeNID(ctx, p.nid)).Exec()
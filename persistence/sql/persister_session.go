@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -40,6 +41,11 @@ func (p *Persister) CreateSession(ctx context.Context, s *session.Session) error
 	return p.GetConnection(ctx).Create(s) // This must not be eager or identities will be created / updated
 }
 
+func (p *Persister) UpdateSession(ctx context.Context, s *session.Session) error {
+	s.NID = corp.ContextualizeNID(ctx, p.nid)
+	return p.update(ctx, s)
+}
+
 func (p *Persister) DeleteSession(ctx context.Context, sid uuid.UUID) error {
 	return p.delete(ctx, new(session.Session), sid)
 }
@@ -116,3 +122,60 @@ func (p *Persister) RevokeSessionByToken(ctx context.Context, token string) erro
 	}
 	return nil
 }
+
+func (p *Persister) ListSessionsByIdentity(ctx context.Context, identityID uuid.UUID, active *bool, page, perPage int) ([]session.Session, int64, error) {
+	ss := make([]session.Session, 0)
+
+	q := p.GetConnection(ctx).Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid))
+	if active != nil {
+		if *active {
+			q = q.Where("active = ? AND expires_at > ?", true, time.Now().UTC())
+		} else {
+			q = q.Where("(active = ? OR expires_at <= ?)", false, time.Now().UTC())
+		}
+	}
+
+	total, err := q.Count(new(session.Session))
+	if err != nil {
+		return nil, 0, sqlcon.HandleError(err)
+	}
+
+	// Pages are zero-based, as in x.ParsePagination, but pop counts them from one.
+	if err := q.Paginate(page+1, perPage).Order("authenticated_at DESC, id DESC").All(&ss); err != nil {
+		return nil, 0, sqlcon.HandleError(err)
+	}
+
+	return ss, int64(total), nil
+}
+
+func (p *Persister) RevokeSession(ctx context.Context, sid uuid.UUID) error {
+	// #nosec G201
+	count, err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(
+		"UPDATE %s SET active = false WHERE id = ? AND nid = ?",
+		corp.ContextualizeTableName(ctx, "sessions"),
+	),
+		sid,
+		corp.ContextualizeNID(ctx, p.nid),
+	).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+	return nil
+}
+
+func (p *Persister) RevokeSessionsByIdentity(ctx context.Context, identityID uuid.UUID) error {
+	// #nosec G201
+	if err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(
+		"UPDATE %s SET active = false WHERE identity_id = ? AND nid = ?",
+		corp.ContextualizeTableName(ctx, "sessions"),
+	),
+		identityID,
+		corp.ContextualizeNID(ctx, p.nid),
+	).Exec(); err != nil {
+		return sqlcon.HandleError(err)
+	}
+	return nil
+}
@@ -2,8 +2,11 @@ package sql
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 
 	"github.com/ory/kratos/corp"
 
@@ -40,3 +43,35 @@ func (p *Persister) UpdateSettingsFlow(ctx context.Context, r *settings.Flow) er
 	cp.NID = corp.ContextualizeNID(ctx, p.nid)
 	return p.update(ctx, cp)
 }
+
+func (p *Persister) IssueSettingsFlowPrivilegedCode(ctx context.Context, f *settings.Flow, lastSentBefore time.Time, maxSends int) error {
+	// The conditions are checked in the update, so that concurrent requests can not send more codes.
+	return p.updatePrivilegedCode(ctx,
+		// #nosec G201 TableName is static
+		fmt.Sprintf("UPDATE %s SET privileged_code_hmac = ?, privileged_code_expires_at = ?, privileged_code_attempts = 0, privileged_code_sent_at = ?, privileged_code_sends = privileged_code_sends + 1 WHERE id = ? AND nid = ? AND privileged_code_sends < ? AND (privileged_code_sent_at IS NULL OR privileged_code_sent_at <= ?)", f.TableName(ctx)),
+		f.PrivilegedCodeHMAC, f.PrivilegedCodeExpiresAt, f.PrivilegedCodeSentAt, f.ID, corp.ContextualizeNID(ctx, p.nid), maxSends, lastSentBefore)
+}
+
+func (p *Persister) FailSettingsFlowPrivilegedCode(ctx context.Context, id uuid.UUID, hmac string, maxAttempts int) error {
+	return p.updatePrivilegedCode(ctx,
+		// #nosec G201 TableName is static
+		fmt.Sprintf("UPDATE %s SET privileged_code_attempts = privileged_code_attempts + 1 WHERE id = ? AND nid = ? AND privileged_code_hmac = ? AND privileged_code_attempts < ?", new(settings.Flow).TableName(ctx)),
+		id, corp.ContextualizeNID(ctx, p.nid), hmac, maxAttempts)
+}
+
+func (p *Persister) ConfirmSettingsFlowPrivilegedCode(ctx context.Context, id uuid.UUID, hmac string, maxAttempts int, at time.Time) error {
+	return p.updatePrivilegedCode(ctx,
+		// #nosec G201 TableName is static
+		fmt.Sprintf("UPDATE %s SET privileged_at = ?, privileged_code_hmac = NULL, privileged_code_expires_at = NULL, privileged_code_attempts = 0 WHERE id = ? AND nid = ? AND privileged_code_hmac = ? AND privileged_code_attempts < ?", new(settings.Flow).TableName(ctx)),
+		at, id, corp.ContextualizeNID(ctx, p.nid), hmac, maxAttempts)
+}
+
+func (p *Persister) updatePrivilegedCode(ctx context.Context, query string, args ...interface{}) error {
+	count, err := p.GetConnection(ctx).RawQuery(query, args...).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	} else if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+	return nil
+}
@@ -16,16 +16,25 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	approval "github.com/ory/kratos/approval/test"
+	attempt "github.com/ory/kratos/attempt/test"
 	continuity "github.com/ory/kratos/continuity/test"
 	"github.com/ory/kratos/corpx"
 	courier "github.com/ory/kratos/courier/test"
 	"github.com/ory/kratos/driver"
+	"github.com/ory/kratos/driver/config"
+	geo "github.com/ory/kratos/geo/test"
+	idempotency "github.com/ory/kratos/idempotency/test"
 	ri "github.com/ory/kratos/identity"
 	identity "github.com/ory/kratos/identity/test"
+	inactivity "github.com/ory/kratos/inactivity/test"
+	job "github.com/ory/kratos/job/test"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence"
 	"github.com/ory/kratos/persistence/sql"
 	errorx "github.com/ory/kratos/selfservice/errorx/test"
+	callback "github.com/ory/kratos/selfservice/flow/callback/test"
 	lf "github.com/ory/kratos/selfservice/flow/login"
 	login "github.com/ory/kratos/selfservice/flow/login/test"
 	recovery "github.com/ory/kratos/selfservice/flow/recovery/test"
@@ -37,6 +46,7 @@ import (
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/sqlcon"
 	"github.com/ory/x/sqlcon/dockertest"
+	"github.com/ory/x/sqlxx"
 )
 
 var sqlite = fmt.Sprintf("sqlite3://%s.sqlite?_fk=true&mode=rwc", filepath.Join(os.TempDir(), uuid.New().String()))
@@ -155,6 +165,14 @@ func TestPersister(t *testing.T) {
 				pop.SetLogger(pl(t))
 				identity.TestPool(ctx, conf, p)(t)
 			})
+			t.Run("contract=identity.TestPoolContract", func(t *testing.T) {
+				pop.SetLogger(pl(t))
+				conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+				identity.TestPoolContract(ctx, func(t *testing.T) ri.PrivilegedPool {
+					_, p := testhelpers.NewNetwork(t, ctx, p)
+					return p
+				})(t)
+			})
 			t.Run("contract=registration.TestFlowPersister", func(t *testing.T) {
 				pop.SetLogger(pl(t))
 				registration.TestFlowPersister(ctx, p)(t)
@@ -195,6 +213,34 @@ func TestPersister(t *testing.T) {
 				pop.SetLogger(pl(t))
 				continuity.TestPersister(ctx, p)(t)
 			})
+			t.Run("contract=idempotency.TestPersister", func(t *testing.T) {
+				pop.SetLogger(pl(t))
+				idempotency.TestPersister(ctx, p)(t)
+			})
+			t.Run("contract=approval.TestPersister", func(t *testing.T) {
+				pop.SetLogger(pl(t))
+				approval.TestPersister(ctx, p)(t)
+			})
+			t.Run("contract=callback.TestPersister", func(t *testing.T) {
+				pop.SetLogger(pl(t))
+				callback.TestPersister(ctx, p)(t)
+			})
+			t.Run("contract=inactivity.TestPersister", func(t *testing.T) {
+				pop.SetLogger(pl(t))
+				inactivity.TestPersister(ctx, conf, p)(t)
+			})
+			t.Run("contract=job.TestPersister", func(t *testing.T) {
+				pop.SetLogger(pl(t))
+				job.TestPersister(ctx, p)(t)
+			})
+			t.Run("contract=attempt.TestPersister", func(t *testing.T) {
+				pop.SetLogger(pl(t))
+				attempt.TestPersister(ctx, p)(t)
+			})
+			t.Run("contract=geo.TestPersister", func(t *testing.T) {
+				pop.SetLogger(pl(t))
+				geo.TestPersister(ctx, conf, p)(t)
+			})
 		})
 	}
 }
@@ -250,3 +296,75 @@ func TestPersister_Transaction(t *testing.T) {
 		assert.Equal(t, sqlcon.ErrNoRows.Error(), err.Error())
 	})
 }
+
+// benchmarkIdentities is the number of identities in the store while benchmarking lookups. Indices which are missing
+// or not used only show up once there is some data to scan.
+const benchmarkIdentities = 2000
+
+func seedIdentities(b *testing.B, p persistence.Persister, n int) []*ri.Identity {
+	is := make([]*ri.Identity, n)
+	for k := range is {
+		email := fmt.Sprintf("benchmark-%d@ory.sh", k)
+		i := ri.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = ri.Traits(fmt.Sprintf(`{"email":"%s"}`, email))
+		i.SetCredentials(ri.CredentialsTypePassword, ri.Credentials{
+			Type: ri.CredentialsTypePassword, Identifiers: []string{email},
+			Config: sqlxx.JSONRawMessage(`{"hashed_password":"$2a$04$zvZz1zV"}`),
+		})
+		i.SetCredentials(ri.CredentialsTypeOIDC, ri.Credentials{
+			Type: ri.CredentialsTypeOIDC, Identifiers: []string{"google:" + i.ID.String()},
+			Config: sqlxx.JSONRawMessage(`{"providers":[{"provider":"google","subject":"` + i.ID.String() + `"}]}`),
+		})
+		i.VerifiableAddresses = []ri.VerifiableAddress{*ri.NewVerifiableEmailAddress(email, i.ID)}
+		i.RecoveryAddresses = []ri.RecoveryAddress{*ri.NewRecoveryEmailAddress(email, i.ID)}
+		require.NoError(b, p.CreateIdentity(context.Background(), i))
+		is[k] = i
+	}
+	return is
+}
+
+func newBenchmarkPersister(b *testing.B) persistence.Persister {
+	conf, reg := internal.NewFastRegistryWithMocks(b)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeyPublicBaseURL, "http://localhost/")
+	return reg.Persister()
+}
+
+func BenchmarkPersister_GetIdentityConfidential(b *testing.B) {
+	p := newBenchmarkPersister(b)
+	is := seedIdentities(b, p, benchmarkIdentities)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		if _, err := p.GetIdentityConfidential(ctx, is[k%len(is)].ID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPersister_FindByCredentialsIdentifier(b *testing.B) {
+	p := newBenchmarkPersister(b)
+	is := seedIdentities(b, p, benchmarkIdentities)
+	ctx := context.Background()
+
+	b.Run("case=known identifier", func(b *testing.B) {
+		b.ReportAllocs()
+		for k := 0; k < b.N; k++ {
+			identifier := is[k%len(is)].Credentials[ri.CredentialsTypePassword].Identifiers[0]
+			if _, _, err := p.FindByCredentialsIdentifier(ctx, ri.CredentialsTypePassword, identifier); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("case=unknown identifier", func(b *testing.B) {
+		b.ReportAllocs()
+		for k := 0; k < b.N; k++ {
+			if _, _, err := p.FindByCredentialsIdentifier(ctx, ri.CredentialsTypePassword, "unknown@ory.sh"); err == nil {
+				b.Fatal("expected the identifier to be unknown")
+			}
+		}
+	})
+}
@@ -24,7 +24,12 @@ func (p Persister) CreateVerificationFlow(ctx context.Context, r *verification.F
 	r.NID = corp.ContextualizeNID(ctx, p.nid)
 	// This should not create the request eagerly because otherwise we might accidentally create an address
 	// that isn't supposed to be in the database.
-	return p.GetConnection(ctx).Create(r)
+	return p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		if err := tx.Create(r); err != nil {
+			return err
+		}
+		return p.setFlowNonce(ctx, r.TableName(ctx), r.ID, r.SubmissionNonce, r.SubmissionNonceIssued)
+	})
 }
 
 func (p Persister) GetVerificationFlow(ctx context.Context, id uuid.UUID) (*verification.Flow, error) {
@@ -42,6 +47,14 @@ func (p Persister) UpdateVerificationFlow(ctx context.Context, r *verification.F
 	return p.update(ctx, cp)
 }
 
+func (p Persister) UseVerificationFlowNonce(ctx context.Context, id uuid.UUID, nonce, next string) error {
+	return p.useFlowNonce(ctx, new(verification.Flow).TableName(ctx), id, nonce, next)
+}
+
+func (p Persister) IssueVerificationFlowNonce(ctx context.Context, id uuid.UUID) error {
+	return p.issueFlowNonce(ctx, new(verification.Flow).TableName(ctx), id)
+}
+
 func (p *Persister) CreateVerificationToken(ctx context.Context, token *link.VerificationToken) error {
 	t := token.Token
 	token.Token = p.hmacValue(ctx, t)
@@ -82,7 +95,17 @@ func (p *Persister) UseVerificationToken(ctx context.Context, token string) (*li
 		rt.VerifiableAddress = &va
 
 		/* #nosec G201 TableName is static */
-		return tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE id=? AND nid = ?", rt.TableName(ctx)), time.Now().UTC(), rt.ID, nid).Exec()
+		count, err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE id = ? AND nid = ? AND NOT used", rt.TableName(ctx)), time.Now().UTC(), rt.ID, nid).ExecWithCount()
+		if err != nil {
+			return err
+		} else if count == 0 {
+			// The token was used concurrently, for example by a request which was retried in another region.
+			return sqlcon.ErrNoRows
+		}
+
+		// Tokens which were kept valid when a new link was requested are used up together.
+		/* #nosec G201 TableName is static */
+		return tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE identity_verifiable_address_id = ? AND nid = ? AND NOT used", rt.TableName(ctx)), time.Now().UTC(), rt.VerifiableAddressID, nid).Exec()
 	})); err != nil {
 		return nil, err
 	}
@@ -90,6 +113,75 @@ func (p *Persister) UseVerificationToken(ctx context.Context, token string) (*li
 	return &rt, nil
 }
 
+func (p *Persister) InvalidateVerificationTokens(ctx context.Context, addressID uuid.UUID, keep int, issuedAfter time.Time) error {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	return sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		/* #nosec G201 TableName is static */
+		query := fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE identity_verifiable_address_id = ? AND nid = ? AND NOT used", new(link.VerificationToken).TableName(ctx))
+		args := []interface{}{time.Now().UTC(), addressID, nid}
+
+		if keep > 0 {
+			var kept []link.VerificationToken
+			if err := tx.Where("identity_verifiable_address_id = ? AND nid = ? AND NOT used AND issued_at > ?", addressID, nid, issuedAfter.UTC()).
+				Order("issued_at DESC").Limit(keep).All(&kept); err != nil {
+				return err
+			}
+
+			for _, t := range kept {
+				query += " AND id <> ?"
+				args = append(args, t.ID)
+			}
+		}
+
+		return tx.RawQuery(query, args...).Exec()
+	}))
+}
+
+func (p *Persister) ReserveVerificationCodeAttempt(ctx context.Context, flowID uuid.UUID, maxAttempts int) (bool, error) {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	var locked bool
+	if err := sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		table := new(link.VerificationToken).TableName(ctx)
+
+		/* #nosec G201 TableName is static */
+		if err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE selfservice_verification_flow_id = ? AND nid = ? AND NOT used AND attempts >= ?", table), time.Now().UTC(), flowID, nid, maxAttempts).Exec(); err != nil {
+			return err
+		}
+
+		// The limit is part of the update, so that concurrent requests can not reserve more attempts than allowed.
+		/* #nosec G201 TableName is static */
+		count, err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET attempts = attempts + 1 WHERE selfservice_verification_flow_id = ? AND nid = ? AND NOT used AND attempts < ?", table), flowID, nid, maxAttempts).ExecWithCount()
+		if err != nil {
+			return err
+		} else if count > 0 {
+			return nil
+		}
+
+		exhausted, err := tx.Where("selfservice_verification_flow_id = ? AND nid = ? AND attempts >= ?", flowID, nid, maxAttempts).Count(new(link.VerificationToken))
+		if err != nil {
+			return err
+		}
+
+		locked = exhausted > 0
+		return nil
+	})); err != nil {
+		return false, err
+	}
+	return locked, nil
+}
+
+func (p *Persister) LastVerificationTokenIssuedAt(ctx context.Context, addressID uuid.UUID) (time.Time, error) {
+	var t link.VerificationToken
+	if err := p.GetConnection(ctx).Where("identity_verifiable_address_id = ? AND nid = ?", addressID, corp.ContextualizeNID(ctx, p.nid)).
+		Order("issued_at DESC").First(&t); err != nil {
+		if errors.Is(sqlcon.HandleError(err), sqlcon.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, sqlcon.HandleError(err)
+	}
+	return t.IssuedAt, nil
+}
+
 func (p *Persister) DeleteVerificationToken(ctx context.Context, token string) error {
 	nid := corp.ContextualizeNID(ctx, p.nid)
 	/* #nosec G201 TableName is static */
@@ -0,0 +1,8 @@
+package ratelimit
+
+// Len returns the number of buckets in the store.
+func (s *MemoryStore) Len() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.buckets)
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/reputation"
+	"github.com/ory/kratos/x"
+)
+
+var ErrRateLimited = herodot.DefaultError{
+	StatusField: http.StatusText(http.StatusTooManyRequests),
+	ErrorField:  "Too many requests were made, please try again later",
+	CodeField:   http.StatusTooManyRequests,
+}
+
+type (
+	limiterDependencies interface {
+		config.Provider
+		x.ClockProvider
+		x.LoggingProvider
+		StoreProvider
+	}
+	Provider interface {
+		EnumerationLimiter() *Limiter
+	}
+	StoreProvider interface {
+		RateLimitStore() Store
+	}
+	// Limiter protects endpoints which tell whether credentials identifiers are known against enumeration. It
+	// limits requests per client IP address and per identifier as configured at `enumeration_protection`.
+	Limiter struct {
+		d limiterDependencies
+	}
+)
+
+func NewLimiter(d limiterDependencies) *Limiter {
+	return &Limiter{d: d}
+}
+
+// Allow takes a token from the bucket of the request's client IP address and one from the bucket of each
+// identifier. It returns false if any of the buckets is empty. Requests are always allowed if rate limiting is
+// disabled.
+func (l *Limiter) Allow(r *http.Request, identifiers []string) (bool, error) {
+	ctx := r.Context()
+	conf := l.d.Config(ctx)
+	perIP, perIdentifier := conf.EnumerationProtectionRateLimits()
+	if perIP == nil {
+		return true, nil
+	}
+
+	now := l.d.Clock().Now()
+	allowed := true
+	if ip := reputation.ClientIP(r, conf.IPReputationClientIPHeader()); len(ip) > 0 {
+		ok, err := l.d.RateLimitStore().Take(ctx, "ip:"+ip, perIP.Burst, perIP.Interval, now)
+		if err != nil {
+			return false, err
+		}
+		allowed = allowed && ok
+	}
+
+	for _, identifier := range identifiers {
+		ok, err := l.d.RateLimitStore().Take(ctx, "identifier:"+strings.ToLower(identifier), perIdentifier.Burst, perIdentifier.Interval, now)
+		if err != nil {
+			return false, err
+		}
+		allowed = allowed && ok
+	}
+
+	if !allowed {
+		l.d.Audit().
+			WithRequest(r).
+			WithField("identifiers", len(identifiers)).
+			Info("Rate limited a request to an enumeration protected endpoint.")
+	}
+	return allowed, nil
+}
+
+// Delay blocks until the minimum response time configured at `enumeration_protection.min_response_time` passed
+// since start.
+func (l *Limiter) Delay(r *http.Request, start time.Time) {
+	wait := l.d.Config(r.Context()).EnumerationProtectionMinResponseTime() - time.Since(start)
+	if wait <= 0 {
+		return
+	}
+
+	select {
+	case <-time.After(wait):
+	case <-r.Context().Done():
+	}
+}
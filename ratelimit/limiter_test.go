@@ -0,0 +1,134 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/ratelimit"
+	"github.com/ory/kratos/x"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := ratelimit.NewMemoryStore()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		ok, err := s.Take(ctx, "foo", 3, time.Minute, now)
+		require.NoError(t, err)
+		assert.True(t, ok, "%d", i)
+	}
+
+	ok, err := s.Take(ctx, "foo", 3, time.Minute, now)
+	require.NoError(t, err)
+	assert.False(t, ok, "the bucket is empty")
+
+	ok, err = s.Take(ctx, "bar", 3, time.Minute, now)
+	require.NoError(t, err)
+	assert.True(t, ok, "other keys have their own bucket")
+
+	ok, err = s.Take(ctx, "foo", 3, time.Minute, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.False(t, ok, "half a token was added")
+
+	ok, err = s.Take(ctx, "foo", 3, time.Minute, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, ok, "one token was added")
+
+	ok, err = s.Take(ctx, "foo", 3, time.Minute, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMemoryStorePrune(t *testing.T) {
+	ctx := context.Background()
+	s := ratelimit.NewMemoryStore()
+	now := time.Now()
+
+	take := func(key string, burst int, interval time.Duration, now time.Time) {
+		_, err := s.Take(ctx, key, burst, interval, now)
+		require.NoError(t, err)
+	}
+
+	take("short", 1, time.Second, now)
+	take("long", 2, time.Hour, now)
+	take("long", 2, time.Hour, now)
+	assert.Equal(t, 2, s.Len())
+
+	take("other", 1, time.Second, now.Add(30*time.Second))
+	assert.Equal(t, 3, s.Len(), "buckets are not pruned on every take")
+
+	take("other", 1, time.Second, now.Add(time.Minute))
+	assert.Equal(t, 2, s.Len(), "only buckets which are full again are pruned")
+
+	ok, err := s.Take(ctx, "long", 2, time.Hour, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, ok, "buckets with a long interval keep their state")
+}
+
+func TestLimiter(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	clock := x.NewFrozenClock(time.Now())
+	reg.WithClock(clock)
+	l := reg.EnumerationLimiter()
+
+	request := func(ip string) *http.Request {
+		r := httptest.NewRequest("POST", "/credentials/known/batch", nil)
+		r.RemoteAddr = ip + ":1234"
+		return r
+	}
+
+	t.Run("case=allows all requests if disabled", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			ok, err := l.Allow(request("192.0.2.1"), []string{"foo@ory.sh"})
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+	})
+
+	conf.MustSet(config.ViperKeyEnumerationRateLimitEnabled, true)
+	conf.MustSet(config.ViperKeyEnumerationRateLimitIPBurst, 2)
+	conf.MustSet(config.ViperKeyEnumerationRateLimitIdentifierBurst, 1)
+
+	t.Run("case=limits per identifier", func(t *testing.T) {
+		ok, err := l.Allow(request("192.0.2.2"), []string{"bar@ory.sh"})
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = l.Allow(request("192.0.2.3"), []string{"BAR@ory.sh"})
+		require.NoError(t, err)
+		assert.False(t, ok, "identifiers are case insensitive")
+
+		clock.Advance(time.Minute)
+		ok, err = l.Allow(request("192.0.2.4"), []string{"bar@ory.sh"})
+		require.NoError(t, err)
+		assert.True(t, ok, "the bucket refilled")
+	})
+
+	t.Run("case=limits per IP address", func(t *testing.T) {
+		for k, expected := range []bool{true, true, false} {
+			ok, err := l.Allow(request("192.0.2.5"), []string{x.NewUUID().String()})
+			require.NoError(t, err)
+			assert.Equal(t, expected, ok, "%d", k)
+		}
+	})
+
+	t.Run("case=delays to the minimum response time", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyEnumerationMinResponseTime, "50ms")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyEnumerationMinResponseTime, "0s")
+		})
+
+		start := time.Now()
+		l.Delay(request("192.0.2.6"), start)
+		assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	})
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// Store keeps the token buckets of the rate limiter. Taking a token must be atomic, so stores which are
+	// shared between instances, for example backed by Redis, have to implement the token bucket themselves.
+	Store interface {
+		// Take removes one token from the bucket of the key and returns true, or returns false if the bucket is
+		// empty. Buckets hold up to burst tokens, gain one token per interval, and start full.
+		Take(ctx context.Context, key string, burst int, interval time.Duration, now time.Time) (bool, error)
+	}
+
+	// MemoryStore keeps the token buckets in memory. Buckets are not shared between instances, so the effective
+	// limit grows with the number of instances.
+	MemoryStore struct {
+		sync.Mutex
+		buckets   map[string]*bucket
+		nextPrune time.Time
+	}
+
+	bucket struct {
+		tokens    float64
+		updatedAt time.Time
+		// fullAt is when the bucket holds burst tokens again. From then on it does not differ from a new bucket.
+		fullAt time.Time
+	}
+)
+
+// memoryStorePruneInterval is how often the memory store removes buckets which are full again.
+const memoryStorePruneInterval = time.Minute
+
+var _ Store = new(MemoryStore)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, burst int, interval time.Duration, now time.Time) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.prune(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.updatedAt); elapsed > 0 && interval > 0 {
+		b.tokens += float64(elapsed) / float64(interval)
+	}
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.fullAt = now.Add(time.Duration((float64(burst) - b.tokens) * float64(interval)))
+
+	return allowed, nil
+}
+
+// prune removes the buckets which are full again, so the store does not grow without bounds. It scans the buckets
+// at most once per memoryStorePruneInterval.
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Before(s.nextPrune) {
+		return
+	}
+	s.nextPrune = now.Add(memoryStorePruneInterval)
+
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
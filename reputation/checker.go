@@ -0,0 +1,230 @@
+package reputation
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/decoderx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+// maxRemoteResponseSize limits how much of the reputation API's or CAPTCHA provider's response is read.
+const maxRemoteResponseSize = 1 << 20
+
+const (
+	ReasonFlaggedCIDR = "flagged_cidr"
+	ReasonAPIScore    = "api_score"
+)
+
+type (
+	checkerDependencies interface {
+		config.Provider
+		x.LoggingProvider
+		x.ClockProvider
+	}
+	Provider interface {
+		IPReputation() *Checker
+	}
+	// Checker decides whether requests come from an IP address with a bad reputation and adds friction to the
+	// self-service flows of flagged requests. IP addresses are flagged using `ip_reputation.flagged_cidrs` and
+	// `ip_reputation.api`.
+	Checker struct {
+		d  checkerDependencies
+		c  *http.Client
+		hd *decoderx.HTTP
+
+		sync.Mutex
+		scores      map[string]cachedScore
+		submissions map[string]*submissionWindow
+	}
+	// Verdict is the result of checking a request's IP address.
+	Verdict struct {
+		IP      string
+		Flagged bool
+		// Reason is either ReasonFlaggedCIDR or ReasonAPIScore if the IP address is flagged.
+		Reason string
+	}
+	cachedScore struct {
+		flagged   bool
+		fetchedAt time.Time
+	}
+	submissionWindow struct {
+		count     int
+		startedAt time.Time
+	}
+)
+
+func NewChecker(d checkerDependencies) *Checker {
+	return &Checker{
+		d:           d,
+		c:           &http.Client{Timeout: 10 * time.Second},
+		hd:          decoderx.NewHTTP(),
+		scores:      map[string]cachedScore{},
+		submissions: map[string]*submissionWindow{},
+	}
+}
+
+// ClientIP returns the IP address of the request's client. If header is set, the first address in that header is
+// used and the address of the connection otherwise. An empty string is returned if the address can not be parsed.
+func ClientIP(r *http.Request, header string) string {
+	addr := r.RemoteAddr
+	if header != "" {
+		addr = strings.TrimSpace(strings.Split(r.Header.Get(header), ",")[0])
+	}
+
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// Check returns whether the request's IP address is flagged. Requests whose IP address can not be determined or
+// which the reputation API can not score are not flagged.
+func (c *Checker) Check(r *http.Request) *Verdict {
+	conf := c.d.Config(r.Context())
+	v := &Verdict{IP: ClientIP(r, conf.IPReputationClientIPHeader())}
+	ip := net.ParseIP(v.IP)
+	if ip == nil || containsIP(conf.IPReputationTrustedCIDRs(), ip) {
+		return v
+	}
+
+	if containsIP(conf.IPReputationFlaggedCIDRs(), ip) {
+		v.Flagged, v.Reason = true, ReasonFlaggedCIDR
+	} else if api := conf.IPReputationAPI(); api != nil && c.flaggedByAPI(r.Context(), api, v.IP) {
+		v.Flagged, v.Reason = true, ReasonAPIScore
+	}
+
+	if v.Flagged {
+		c.d.Logger().
+			WithField("ip", v.IP).
+			WithField("reason", v.Reason).
+			Info("Adding friction to a self-service flow because the client's IP address has a bad reputation.")
+	}
+	return v
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// flaggedByAPI returns whether the reputation API scores the IP address at or above the threshold. Scores are
+// cached for the configured TTL.
+func (c *Checker) flaggedByAPI(ctx context.Context, api *config.IPReputationAPI, ip string) bool {
+	now := c.d.Clock().Now()
+
+	c.Lock()
+	cached, ok := c.scores[ip]
+	c.Unlock()
+	if ok && now.Sub(cached.fetchedAt) < api.CacheTTL {
+		return cached.flagged
+	}
+
+	score, err := c.fetchScore(ctx, api, ip)
+	if err != nil {
+		c.d.Logger().WithError(err).WithField("ip", ip).Warn("Unable to fetch the IP address' score from the reputation API.")
+	}
+
+	// Failures are cached as well to keep an unavailable API from slowing down every request.
+	flagged := err == nil && score >= api.Threshold
+	c.Lock()
+	defer c.Unlock()
+	for k, s := range c.scores {
+		if now.Sub(s.fetchedAt) >= api.CacheTTL {
+			delete(c.scores, k)
+		}
+	}
+	c.scores[ip] = cachedScore{flagged: flagged, fetchedAt: now}
+	return flagged
+}
+
+func (c *Checker) fetchScore(ctx context.Context, api *config.IPReputationAPI, ip string) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, api.Timeout)
+	defer cancel()
+
+	u := *api.URL
+	q := u.Query()
+	q.Set("ip", ip)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.c.Do(req)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, errors.Errorf("expected status code 200 but got %d", res.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxRemoteResponseSize))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	score := gjson.GetBytes(body, api.ScorePath)
+	if score.Type != gjson.Number {
+		return 0, errors.Errorf("expected a number at %q in the response but got %s", api.ScorePath, score.Type)
+	}
+	return score.Float(), nil
+}
+
+// Throttle returns ErrTooManySubmissions if the request is flagged and its IP address exceeded the number of
+// submissions allowed per window.
+func (c *Checker) Throttle(r *http.Request) error {
+	return c.throttle(r, c.Check(r))
+}
+
+func (c *Checker) throttle(r *http.Request, v *Verdict) error {
+	max, window := c.d.Config(r.Context()).IPReputationRateLimit()
+	if max <= 0 || !v.Flagged {
+		return nil
+	}
+
+	now := c.d.Clock().Now()
+	c.Lock()
+	defer c.Unlock()
+
+	s, ok := c.submissions[v.IP]
+	if !ok || now.Sub(s.startedAt) >= window {
+		for k, s := range c.submissions {
+			if now.Sub(s.startedAt) >= window {
+				delete(c.submissions, k)
+			}
+		}
+		s = &submissionWindow{startedAt: now}
+		c.submissions[v.IP] = s
+	}
+
+	s.count++
+	if s.count > max {
+		return errors.WithStack(ErrTooManySubmissions.WithReasonf("Please wait %s before trying again.", s.startedAt.Add(window).Sub(now).Round(time.Second)))
+	}
+	return nil
+}
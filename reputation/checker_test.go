@@ -0,0 +1,194 @@
+package reputation_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/reputation"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+func TestClientIP(t *testing.T) {
+	for k, tc := range []struct {
+		remoteAddr, header, value, expected string
+	}{
+		{remoteAddr: "192.0.2.1:1234", expected: "192.0.2.1"},
+		{remoteAddr: "[2001:db8::1]:1234", expected: "2001:db8::1"},
+		{remoteAddr: "192.0.2.1:1234", header: "X-Forwarded-For", value: "198.51.100.7, 10.0.0.1", expected: "198.51.100.7"},
+		{remoteAddr: "192.0.2.1:1234", header: "CF-Connecting-IP", value: "198.51.100.7", expected: "198.51.100.7"},
+		{remoteAddr: "192.0.2.1:1234", header: "X-Forwarded-For", expected: ""},
+		{remoteAddr: "not-an-ip", expected: ""},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			r := &http.Request{RemoteAddr: tc.remoteAddr, Header: http.Header{}}
+			if tc.value != "" {
+				r.Header.Set(tc.header, tc.value)
+			}
+			assert.Equal(t, tc.expected, reputation.ClientIP(r, tc.header))
+		})
+	}
+}
+
+func TestChecker(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	clock := x.NewFrozenClock(time.Now())
+	reg.WithClock(clock)
+	checker := reg.IPReputation()
+
+	newRequest := func(ip string, form url.Values) *http.Request {
+		r := httptest.NewRequest("POST", "/self-service/login", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.RemoteAddr = ip + ":1234"
+		return r
+	}
+
+	setCIDRs := func(t *testing.T, trusted, flagged []string) {
+		conf.MustSet(config.ViperKeyIPReputationTrustedCIDRs, trusted)
+		conf.MustSet(config.ViperKeyIPReputationFlaggedCIDRs, flagged)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIPReputationTrustedCIDRs, []string{})
+			conf.MustSet(config.ViperKeyIPReputationFlaggedCIDRs, []string{})
+		})
+	}
+
+	t.Run("case=flags IP addresses in flagged CIDR ranges", func(t *testing.T) {
+		setCIDRs(t, []string{"198.51.100.7/32"}, []string{"198.51.100.0/24"})
+
+		assert.Equal(t, &reputation.Verdict{IP: "198.51.100.1", Flagged: true, Reason: reputation.ReasonFlaggedCIDR}, checker.Check(newRequest("198.51.100.1", nil)))
+		assert.Equal(t, &reputation.Verdict{IP: "198.51.100.7"}, checker.Check(newRequest("198.51.100.7", nil)))
+		assert.Equal(t, &reputation.Verdict{IP: "192.0.2.1"}, checker.Check(newRequest("192.0.2.1", nil)))
+	})
+
+	t.Run("case=flags IP addresses scored by the reputation API", func(t *testing.T) {
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			assert.Equal(t, "kratos", r.URL.Query().Get("key"))
+			switch r.URL.Query().Get("ip") {
+			case "192.0.2.1":
+				_, _ = w.Write([]byte(`{"data":{"score":80}}`))
+			case "192.0.2.2":
+				_, _ = w.Write([]byte(`{"data":{"score":10}}`))
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+		t.Cleanup(ts.Close)
+
+		conf.MustSet(config.ViperKeyIPReputationAPIURL, ts.URL+"?key=kratos")
+		conf.MustSet(config.ViperKeyIPReputationAPIScorePath, "data.score")
+		conf.MustSet(config.ViperKeyIPReputationAPICacheTTL, "1m")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIPReputationAPIURL, "")
+		})
+
+		assert.Equal(t, &reputation.Verdict{IP: "192.0.2.1", Flagged: true, Reason: reputation.ReasonAPIScore}, checker.Check(newRequest("192.0.2.1", nil)))
+		assert.False(t, checker.Check(newRequest("192.0.2.2", nil)).Flagged)
+		assert.False(t, checker.Check(newRequest("192.0.2.3", nil)).Flagged, "API errors do not flag the IP address")
+		assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+
+		assert.True(t, checker.Check(newRequest("192.0.2.1", nil)).Flagged)
+		assert.False(t, checker.Check(newRequest("192.0.2.3", nil)).Flagged)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&calls), "scores and failures are cached")
+
+		clock.Advance(time.Minute)
+		assert.True(t, checker.Check(newRequest("192.0.2.1", nil)).Flagged)
+		assert.EqualValues(t, 4, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=throttles flagged IP addresses", func(t *testing.T) {
+		setCIDRs(t, nil, []string{"198.51.100.0/24"})
+		conf.MustSet(config.ViperKeyIPReputationRateLimitMaxSubmissions, 2)
+		conf.MustSet(config.ViperKeyIPReputationRateLimitWindow, "1h")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIPReputationRateLimitMaxSubmissions, 0)
+		})
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, checker.Throttle(newRequest("198.51.100.1", nil)))
+		}
+		err := checker.Throttle(newRequest("198.51.100.1", nil))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, &reputation.ErrTooManySubmissions)
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, checker.Throttle(newRequest("192.0.2.1", nil)), "IP addresses which are not flagged are not throttled")
+		}
+
+		clock.Advance(time.Hour)
+		require.NoError(t, checker.Throttle(newRequest("198.51.100.1", nil)))
+	})
+
+	t.Run("case=requires flagged IP addresses to solve a CAPTCHA", func(t *testing.T) {
+		setCIDRs(t, nil, []string{"198.51.100.0/24"})
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "secret", r.PostForm.Get("secret"))
+			assert.Equal(t, "198.51.100.1", r.PostForm.Get("remoteip"))
+			_, _ = fmt.Fprintf(w, `{"success":%t}`, r.PostForm.Get("response") == "valid")
+		}))
+		t.Cleanup(ts.Close)
+
+		var nodes node.Nodes
+		checker.AddFriction(newRequest("198.51.100.1", nil), &nodes)
+		assert.Empty(t, nodes, "no CAPTCHA is configured")
+		require.NoError(t, checker.VerifySubmission(newRequest("198.51.100.1", nil), &nodes))
+
+		conf.MustSet(config.ViperKeyIPReputationCaptchaProvider, "turnstile")
+		conf.MustSet(config.ViperKeyIPReputationCaptchaSiteKey, "site")
+		conf.MustSet(config.ViperKeyIPReputationCaptchaSecretKey, "secret")
+		conf.MustSet(config.ViperKeyIPReputationCaptchaVerifyURL, ts.URL)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIPReputationCaptchaProvider, "")
+		})
+
+		checker.AddFriction(newRequest("192.0.2.1", nil), &nodes)
+		assert.Empty(t, nodes)
+		require.NoError(t, checker.VerifySubmission(newRequest("192.0.2.1", nil), &nodes))
+
+		checker.AddFriction(newRequest("198.51.100.1", nil), &nodes)
+		require.Len(t, nodes, 1)
+		assert.Equal(t, node.CaptchaGroup, nodes[0].Group)
+		assert.Equal(t, "captcha_response", nodes[0].ID())
+		assert.Contains(t, string(nodes[0].Meta.Label.Context), `"site_key":"site"`)
+
+		var nodesWithoutCaptcha node.Nodes
+		for _, form := range []url.Values{{"method": {"password"}}, {"captcha_response": {"invalid"}}} {
+			err := checker.VerifySubmission(newRequest("198.51.100.1", form), &nodesWithoutCaptcha)
+			require.Error(t, err)
+			assert.ErrorAs(t, err, new(*schema.ValidationError))
+			require.Len(t, nodesWithoutCaptcha, 1, "the CAPTCHA node is added if it is missing")
+		}
+
+		r := newRequest("198.51.100.1", url.Values{"captcha_response": {"valid"}, "method": {"password"}})
+		require.NoError(t, checker.VerifySubmission(r, &nodes))
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "password", r.PostForm.Get("method"), "the request body can still be read")
+	})
+
+	t.Run("case=requires flagged IP addresses to confirm settings with a code", func(t *testing.T) {
+		setCIDRs(t, nil, []string{"198.51.100.0/24"})
+		assert.False(t, checker.RequiresEmailCode(newRequest("198.51.100.1", nil)))
+
+		conf.MustSet(config.ViperKeyIPReputationRequireEmailCode, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIPReputationRequireEmailCode, false)
+		})
+		assert.True(t, checker.RequiresEmailCode(newRequest("198.51.100.1", nil)))
+		assert.False(t, checker.RequiresEmailCode(newRequest("192.0.2.1", nil)))
+	})
+}
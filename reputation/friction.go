@@ -0,0 +1,172 @@
+package reputation
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/decoderx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+)
+
+//go:embed .schema/captcha.schema.json
+var captchaSchema []byte
+
+const captchaNode = "captcha_response"
+
+var ErrTooManySubmissions = herodot.DefaultError{
+	StatusField: http.StatusText(http.StatusTooManyRequests),
+	ErrorField:  "Too many requests were made from your network",
+	CodeField:   http.StatusTooManyRequests,
+}
+
+// captchaVerifyURLs are the verification endpoints of the supported CAPTCHA providers. All of them accept the
+// same form parameters and respond with `{"success": true}` if the response is valid.
+var captchaVerifyURLs = map[string]string{
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+	"recaptcha": "https://www.google.com/recaptcha/api/siteverify",
+}
+
+// AddFriction adds the CAPTCHA node to the flow's nodes if the request is flagged and a CAPTCHA is configured.
+func (c *Checker) AddFriction(r *http.Request, nodes *node.Nodes) {
+	captcha := c.d.Config(r.Context()).IPReputationCaptcha()
+	if captcha == nil || !c.Check(r).Flagged {
+		return
+	}
+
+	addCaptchaNode(nodes, captcha)
+}
+
+func addCaptchaNode(nodes *node.Nodes, captcha *config.Captcha) {
+	nodes.Upsert(node.NewInputField(captchaNode, nil, node.CaptchaGroup, node.InputAttributeTypeHidden, node.WithRequiredInputAttribute).
+		WithMetaLabel(text.NewInfoNodeInputCaptcha(captcha.Provider, captcha.SiteKey)))
+}
+
+// VerifySubmission throttles flagged requests and, if a CAPTCHA is configured, verifies the CAPTCHA response they
+// carry. If the response is missing or invalid, the CAPTCHA node is added to the flow's nodes, so that flows
+// started before the IP address was flagged ask for it as well, and a validation error is returned.
+func (c *Checker) VerifySubmission(r *http.Request, nodes *node.Nodes) error {
+	v := c.Check(r)
+	if err := c.throttle(r, v); err != nil {
+		return err
+	}
+
+	captcha := c.d.Config(r.Context()).IPReputationCaptcha()
+	if captcha == nil || !v.Flagged {
+		return nil
+	}
+
+	ok, err := c.verifyCaptchaResponse(r, captcha, v.IP)
+	if err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	addCaptchaNode(nodes, captcha)
+	return schema.NewCaptchaFailedError()
+}
+
+// VerifyCaptcha returns true if the request carries a valid CAPTCHA response in the `captcha_response` field.
+// Requests from IP addresses which are not flagged pass without one unless flaggedOnly is false. All requests
+// pass if no CAPTCHA is configured.
+func (c *Checker) VerifyCaptcha(r *http.Request, flaggedOnly bool) (bool, error) {
+	captcha := c.d.Config(r.Context()).IPReputationCaptcha()
+	if captcha == nil {
+		return true, nil
+	}
+
+	v := c.Check(r)
+	if flaggedOnly && !v.Flagged {
+		return true, nil
+	}
+
+	return c.verifyCaptchaResponse(r, captcha, v.IP)
+}
+
+// verifyCaptchaResponse verifies the CAPTCHA response the request carries. It returns false if the response is
+// missing or invalid, or if the provider can not be reached.
+func (c *Checker) verifyCaptchaResponse(r *http.Request, captcha *config.Captcha, ip string) (bool, error) {
+	var p struct {
+		Response string `json:"captcha_response" form:"captcha_response"`
+	}
+
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(captchaSchema)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	if err := c.hd.Decode(r, &p, compiler,
+		decoderx.HTTPKeepRequestBody(true),
+		decoderx.HTTPDecoderAllowedMethods("POST", "PUT", "PATCH", "GET"),
+		decoderx.HTTPDecoderSetValidatePayloads(false),
+		decoderx.HTTPDecoderJSONFollowsFormFormat()); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	response := strings.TrimSpace(p.Response)
+	if len(response) == 0 {
+		return false, nil
+	}
+
+	ok, err := c.verifyCaptcha(r, captcha, response, ip)
+	if err != nil {
+		c.d.Logger().WithError(err).WithField("provider", captcha.Provider).Warn("Unable to verify the CAPTCHA response.")
+		return false, nil
+	}
+	return ok, nil
+}
+
+func (c *Checker) verifyCaptcha(r *http.Request, captcha *config.Captcha, response, ip string) (bool, error) {
+	target := captchaVerifyURLs[captcha.Provider]
+	if captcha.VerifyURL != nil {
+		target = captcha.VerifyURL.String()
+	}
+
+	form := url.Values{"secret": {captcha.SecretKey}, "response": {response}}
+	if ip != "" {
+		form.Set("remoteip", ip)
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), "POST", target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.c.Do(req)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return false, errors.Errorf("expected status code 200 but got %d", res.StatusCode)
+	}
+
+	var body struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxRemoteResponseSize)).Decode(&body); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return body.Success, nil
+}
+
+// RequiresEmailCode returns true if the request is flagged and `ip_reputation.friction.require_email_code` is
+// enabled, in which case privileged changes in the settings flow have to be confirmed with a code sent by email.
+func (c *Checker) RequiresEmailCode(r *http.Request) bool {
+	return c.d.Config(r.Context()).IPReputationRequireEmailCode() && c.Check(r).Flagged
+}
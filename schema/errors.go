@@ -2,6 +2,7 @@ package schema
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -114,6 +115,317 @@ func NewDuplicateCredentialsError() error {
 	})
 }
 
+type ValidationErrorContextSIWEMessageInvalidError struct {
+	Reason string
+}
+
+func (r *ValidationErrorContextSIWEMessageInvalidError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextSIWEMessageInvalidError) FinishInstanceContext() {}
+
+func NewSIWEMessageInvalidError(instancePtr string, reason string) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("the signed message can not be used because %s", reason),
+			InstancePtr: instancePtr,
+			Context: &ValidationErrorContextSIWEMessageInvalidError{
+				Reason: reason,
+			},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationSIWEMessageInvalid(reason)),
+	})
+}
+
+type ValidationErrorContextSIWEWalletUnknownError struct{}
+
+func (r *ValidationErrorContextSIWEWalletUnknownError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextSIWEWalletUnknownError) FinishInstanceContext() {}
+
+func NewSIWEWalletUnknownError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `no account uses this wallet`,
+			InstancePtr: "#/",
+			Context:     &ValidationErrorContextSIWEWalletUnknownError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationSIWEWalletUnknown()),
+	})
+}
+
+type ValidationErrorContextEmailDomainRejectedError struct {
+	Domain string
+	Reason string
+}
+
+func (r *ValidationErrorContextEmailDomainRejectedError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextEmailDomainRejectedError) FinishInstanceContext() {}
+
+func NewEmailDomainRejectedError(instancePtr, domain, reason string) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("email addresses from %s can not be used because %s", domain, reason),
+			InstancePtr: instancePtr,
+			Context: &ValidationErrorContextEmailDomainRejectedError{
+				Domain: domain,
+				Reason: reason,
+			},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationEmailDomainRejected(domain, reason)),
+	})
+}
+
+type ValidationErrorContextNameReservedError struct {
+	Name string
+}
+
+func (r *ValidationErrorContextNameReservedError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextNameReservedError) FinishInstanceContext() {}
+
+func NewNameReservedError(instancePtr, name string) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("%q is reserved or not allowed", name),
+			InstancePtr: instancePtr,
+			Context:     &ValidationErrorContextNameReservedError{Name: name},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationNameReserved(name)),
+	})
+}
+
+type ValidationErrorContextCaptchaFailedError struct{}
+
+func (r *ValidationErrorContextCaptchaFailedError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextCaptchaFailedError) FinishInstanceContext() {}
+
+func NewCaptchaFailedError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the challenge could not be verified`,
+			InstancePtr: "#/captcha_response",
+			Context:     &ValidationErrorContextCaptchaFailedError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationCaptchaFailed()),
+	})
+}
+
+type ValidationErrorContextCourierUnavailableError struct{}
+
+func (r *ValidationErrorContextCourierUnavailableError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextCourierUnavailableError) FinishInstanceContext() {}
+
+func NewCourierUnavailableError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the email could not be sent because the courier is unavailable`,
+			InstancePtr: "#/",
+			Context:     &ValidationErrorContextCourierUnavailableError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationCourierUnavailable()),
+	})
+}
+
+type ValidationErrorContextFlowNonceInvalidError struct{}
+
+func (r *ValidationErrorContextFlowNonceInvalidError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextFlowNonceInvalidError) FinishInstanceContext() {}
+
+func NewFlowNonceInvalidError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the submission nonce is missing or was already used`,
+			InstancePtr: "#/",
+			Context:     &ValidationErrorContextFlowNonceInvalidError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationFlowNonceInvalid()),
+	})
+}
+
+type ValidationErrorContextUsernameUnavailableError struct{}
+
+func (r *ValidationErrorContextUsernameUnavailableError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextUsernameUnavailableError) FinishInstanceContext() {}
+
+func NewUsernameUnavailableError(instancePtr string) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `this username is not available`,
+			InstancePtr: instancePtr,
+			Context:     &ValidationErrorContextUsernameUnavailableError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationSettingsUsernameUnavailable()),
+	})
+}
+
+type ValidationErrorContextUsernameChangeCooldownError struct {
+	AllowedAt time.Time
+}
+
+func (r *ValidationErrorContextUsernameChangeCooldownError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextUsernameChangeCooldownError) FinishInstanceContext() {}
+
+func NewUsernameChangeCooldownError(instancePtr string, allowedAt time.Time) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("the username was changed recently and can be changed again after %s", allowedAt.UTC().Format(time.RFC3339)),
+			InstancePtr: instancePtr,
+			Context: &ValidationErrorContextUsernameChangeCooldownError{
+				AllowedAt: allowedAt,
+			},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationSettingsUsernameChangeCooldown(allowedAt)),
+	})
+}
+
+type ValidationErrorContextPrivilegedCodeInvalidError struct{}
+
+func (r *ValidationErrorContextPrivilegedCodeInvalidError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextPrivilegedCodeInvalidError) FinishInstanceContext() {}
+
+func NewPrivilegedCodeInvalidError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the code is invalid or has expired`,
+			InstancePtr: "#/privileged_code",
+			Context:     &ValidationErrorContextPrivilegedCodeInvalidError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationSettingsPrivilegedCodeInvalid()),
+	})
+}
+
+type ValidationErrorContextLoginCountryBlockedError struct{}
+
+func (r *ValidationErrorContextLoginCountryBlockedError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextLoginCountryBlockedError) FinishInstanceContext() {}
+
+func NewLoginCountryBlockedError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `signing in from this country is not allowed`,
+			InstancePtr: "#/",
+			Context:     &ValidationErrorContextLoginCountryBlockedError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationLoginCountryBlocked()),
+	})
+}
+
+type ValidationErrorContextLoginGeoCodeRequiredError struct{}
+
+func (r *ValidationErrorContextLoginGeoCodeRequiredError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextLoginGeoCodeRequiredError) FinishInstanceContext() {}
+
+// NewLoginGeoCodeRequiredError is returned when a code was sent to confirm a login from a new location. It is
+// not a failure, its message tells the user where the code was sent to and whether its delivery is delayed.
+func NewLoginGeoCodeRequiredError(address string, delayed bool) error {
+	messages := new(text.Messages).Add(text.NewInfoSelfServiceLoginGeoCodeSent(address))
+	if delayed {
+		messages.Add(text.NewInfoSelfServiceCourierDelayed())
+	}
+
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `a code was sent to confirm the login`,
+			InstancePtr: "#/",
+			Context:     &ValidationErrorContextLoginGeoCodeRequiredError{},
+		},
+		Messages: messages,
+	})
+}
+
+type ValidationErrorContextLoginGeoCodeInvalidError struct{}
+
+func (r *ValidationErrorContextLoginGeoCodeInvalidError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextLoginGeoCodeInvalidError) FinishInstanceContext() {}
+
+func NewLoginGeoCodeInvalidError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the code is invalid or has expired`,
+			InstancePtr: "#/geo_code",
+			Context:     &ValidationErrorContextLoginGeoCodeInvalidError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationLoginGeoCodeInvalid()),
+	})
+}
+
+type ValidationErrorContextTokenInvalidError struct{}
+
+func (r *ValidationErrorContextTokenInvalidError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextTokenInvalidError) FinishInstanceContext() {}
+
+func NewVerificationTokenInvalidError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the verification token is invalid or has already been used`,
+			InstancePtr: "#/token",
+			Context:     &ValidationErrorContextTokenInvalidError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationVerificationTokenInvalidOrAlreadyUsed()),
+	})
+}
+
+func NewVerificationCodeInvalidError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the verification code is invalid or has already been used`,
+			InstancePtr: "#/code",
+			Context:     &ValidationErrorContextTokenInvalidError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationVerificationCodeInvalidOrAlreadyUsed()),
+	})
+}
+
+func NewRecoveryTokenInvalidError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the recovery token is invalid or has already been used`,
+			InstancePtr: "#/token",
+			Context:     &ValidationErrorContextTokenInvalidError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationRecoveryTokenInvalidOrAlreadyUsed()),
+	})
+}
+
+func NewRecoveryCodeInvalidError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the recovery code is invalid or has already been used`,
+			InstancePtr: "#/code",
+			Context:     &ValidationErrorContextTokenInvalidError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationRecoveryCodeInvalidOrAlreadyUsed()),
+	})
+}
+
+type ValidationErrorContextRecoveryAnswersInvalidError struct{}
+
+func (r *ValidationErrorContextRecoveryAnswersInvalidError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextRecoveryAnswersInvalidError) FinishInstanceContext() {}
+
+func NewRecoveryAnswersInvalidError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the answers are incorrect or the account can not be recovered using recovery questions`,
+			InstancePtr: "#/",
+			Context:     &ValidationErrorContextRecoveryAnswersInvalidError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationRecoveryAnswersInvalid()),
+	})
+}
+
 func NewNoLoginStrategyResponsible() error {
 	return errors.WithStack(&ValidationError{
 		ValidationError: &jsonschema.ValidationError{
@@ -163,3 +475,14 @@ func NewNoVerificationStrategyResponsible() error {
 		Messages: new(text.Messages).Add(text.NewErrorValidationVerificationNoStrategyFound()),
 	})
 }
+
+func NewProviderClaimsRejectedError(provider string) error {
+	t := text.NewErrorValidationProviderClaimsRejected(provider)
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     t.Text,
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(t),
+	})
+}
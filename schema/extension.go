@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"embed"
 	"encoding/json"
+	"sort"
+	"sync"
 
 	"github.com/pkg/errors"
 
 	"github.com/ory/jsonschema/v3"
+	"github.com/ory/x/jsonschemax"
 )
 
 //go:embed .schema/extension/*.json
@@ -16,6 +19,14 @@ var extensionSchemas embed.FS
 const (
 	ExtensionRunnerIdentityMetaSchema ExtensionRunnerMetaSchema = ".schema/extension/identity.schema.json"
 	extensionName                     string                    = "ory.sh/kratos"
+
+	// SensitiveProperty is the jsonschemax.Path custom property which is true for traits marked as sensitive.
+	SensitiveProperty = "sensitive"
+
+	// DisplayNameProperty and AvatarProperty are the jsonschemax.Path custom properties which are true for the
+	// traits holding the identity's display name and avatar URL.
+	DisplayNameProperty = "display_name"
+	AvatarProperty      = "avatar"
 )
 
 type (
@@ -32,6 +43,11 @@ type (
 		Recovery struct {
 			Via string `json:"via"`
 		} `json:"recovery"`
+		Sensitive bool `json:"sensitive"`
+		Profile   struct {
+			DisplayName bool `json:"display_name"`
+			Avatar      bool `json:"avatar"`
+		} `json:"profile"`
 		Mappings struct {
 			Identity struct {
 				Traits []struct {
@@ -39,11 +55,26 @@ type (
 				} `json:"traits"`
 			} `json:"identity"`
 		} `json:"mappings"`
+
+		// Extensions holds the settings of additional extensions keyed by the extension's name.
+		Extensions map[string]json.RawMessage `json:"extensions"`
+	}
+
+	// ExtensionValue is a value of the validated document whose schema has extension settings.
+	ExtensionValue struct {
+		Config ExtensionConfig
+		Value  interface{}
 	}
 
 	Extension interface {
+		// Run is called for every value with extension settings while the document is validated and may reject
+		// the value using ctx.Error. It must not keep any state because the validator visits values in random
+		// order and may visit the same value more than once.
 		Run(ctx jsonschema.ValidationContext, config ExtensionConfig, value interface{}) error
-		Finish() error
+
+		// Finish is called once the document is valid. It receives the values of the validation pass in
+		// document order, with object keys sorted and duplicates removed.
+		Finish(values []ExtensionValue) error
 	}
 
 	ExtensionRunner struct {
@@ -52,6 +83,11 @@ type (
 		validate func(ctx jsonschema.ValidationContext, s interface{}, v interface{}) error
 
 		runners []Extension
+
+		// pass serializes validation passes, l guards values which are collected during a pass.
+		pass   sync.Mutex
+		l      sync.Mutex
+		values []ExtensionValue
 	}
 )
 
@@ -96,6 +132,10 @@ func NewExtensionRunner(meta ExtensionRunnerMetaSchema, runners ...Extension) (*
 				return err
 			}
 		}
+
+		r.l.Lock()
+		defer r.l.Unlock()
+		r.values = append(r.values, ExtensionValue{Config: *c, Value: v})
 		return nil
 	}
 
@@ -103,6 +143,15 @@ func NewExtensionRunner(meta ExtensionRunnerMetaSchema, runners ...Extension) (*
 	return r, nil
 }
 
+// EnhancePath exposes the settings to jsonschemax.ListPaths.
+func (c *ExtensionConfig) EnhancePath(_ jsonschemax.Path) map[string]interface{} {
+	return map[string]interface{}{
+		SensitiveProperty:   c.Sensitive,
+		DisplayNameProperty: c.Profile.DisplayName,
+		AvatarProperty:      c.Profile.Avatar,
+	}
+}
+
 func (r *ExtensionRunner) Register(compiler *jsonschema.Compiler) *ExtensionRunner {
 	compiler.Extensions[extensionName] = r.Extension()
 	return r
@@ -121,11 +170,123 @@ func (r *ExtensionRunner) AddRunner(run Extension) *ExtensionRunner {
 	return r
 }
 
-func (r *ExtensionRunner) Finish() error {
+// Validate validates the document against s, which must have been compiled by a compiler the runner is registered
+// with. If the document is valid, the values collected during this validation pass are passed to Finish of every
+// extension. Concurrent calls are run one after another.
+func (r *ExtensionRunner) Validate(s *jsonschema.Schema, document []byte) error {
+	r.pass.Lock()
+	defer r.pass.Unlock()
+
+	r.l.Lock()
+	r.values = nil
+	r.l.Unlock()
+
+	if err := s.Validate(bytes.NewReader(document)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	r.l.Lock()
+	collected := r.values
+	r.values = nil
+	r.l.Unlock()
+
+	values, err := sortExtensionValues(document, collected)
+	if err != nil {
+		return err
+	}
+
 	for _, runner := range r.runners {
-		if err := runner.Finish(); err != nil {
+		if err := runner.Finish(append([]ExtensionValue(nil), values...)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// sortExtensionValues removes duplicate values and orders the rest by their first appearance in the document. Object
+// keys are visited in lexical order. Equal values with different settings are ordered by their settings.
+func sortExtensionValues(document []byte, values []ExtensionValue) ([]ExtensionValue, error) {
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(document))
+	d.UseNumber()
+	if err := d.Decode(&doc); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	position := make(map[string]int)
+	var walk func(v interface{}) error
+	walk = func(v interface{}) error {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if _, ok := position[string(encoded)]; !ok {
+			position[string(encoded)] = len(position)
+		}
+
+		switch v := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if err := walk(v[k]); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for _, e := range v {
+				if err := walk(e); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(doc); err != nil {
+		return nil, err
+	}
+
+	type keyed struct {
+		ExtensionValue
+		value, config string
+	}
+	seen := make(map[string]bool, len(values))
+	unique := make([]keyed, 0, len(values))
+	for _, v := range values {
+		value, err := json.Marshal(v.Value)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		config, err := json.Marshal(v.Config)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		key := string(value) + "\x00" + string(config)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, keyed{ExtensionValue: v, value: string(value), config: string(config)})
+	}
+
+	sort.Slice(unique, func(i, j int) bool {
+		a, b := unique[i], unique[j]
+		if position[a.value] != position[b.value] {
+			return position[a.value] < position[b.value]
+		}
+		if a.value != b.value {
+			return a.value < b.value
+		}
+		return a.config < b.config
+	})
+
+	sorted := make([]ExtensionValue, len(unique))
+	for k := range unique {
+		sorted[k] = unique[k].ExtensionValue
+	}
+	return sorted, nil
+}
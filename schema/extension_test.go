@@ -1,8 +1,8 @@
 package schema
 
 import (
-	"bytes"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,16 +13,25 @@ import (
 )
 
 type extensionStub struct {
-	identifiers []string
+	l           sync.Mutex
+	identifiers [][]string
 }
 
 func (r *extensionStub) Run(ctx jsonschema.ValidationContext, config ExtensionConfig, value interface{}) error {
-	if config.Credentials.Password.Identifier {
-		r.identifiers = append(r.identifiers, fmt.Sprintf("%s", value))
-	}
 	return nil
 }
-func (r *extensionStub) Finish() error {
+
+func (r *extensionStub) Finish(values []ExtensionValue) error {
+	var identifiers []string
+	for _, v := range values {
+		if v.Config.Credentials.Password.Identifier {
+			identifiers = append(identifiers, fmt.Sprintf("%s", v.Value))
+		}
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.identifiers = append(r.identifiers, identifiers)
 	return nil
 }
 
@@ -43,6 +52,26 @@ func TestExtensionRunner(t *testing.T) {
 			schema: "file://./stub/extension/schema.nested.json",
 			expect: []string{"foo@ory.sh", "bar@ory.sh"},
 		},
+		{
+			doc:    `{"emails":["foo@ory.sh","bar@ory.sh","foo@ory.sh"]}`,
+			schema: "file://./stub/extension/schema.nested.json",
+			expect: []string{"foo@ory.sh", "bar@ory.sh"},
+		},
+		{
+			doc:    `{"username":"foo","emails":["b@ory.sh","a@ory.sh"],"contact":{"work":"w@ory.sh","private":"p@ory.sh"}}`,
+			schema: "file://./stub/extension/schema.multi.json",
+			expect: []string{"p@ory.sh", "w@ory.sh", "b@ory.sh", "a@ory.sh", "foo"},
+		},
+		{
+			doc:    `{"username":"a@ory.sh","emails":["a@ory.sh"],"contact":{"work":"a@ory.sh"}}`,
+			schema: "file://./stub/extension/schema.multi.json",
+			expect: []string{"a@ory.sh"},
+		},
+		{
+			doc:       `{"emails":["foo@ory.sh","not-an-email"]}`,
+			schema:    "file://./stub/extension/schema.multi.json",
+			expectErr: fmt.Errorf(`I[#/emails/1] S[#/properties/emails/items/format] "not-an-email" is not valid "email"`),
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			c := jsonschema.NewCompiler()
@@ -51,13 +80,47 @@ func TestExtensionRunner(t *testing.T) {
 
 			r := new(extensionStub)
 			runner.AddRunner(r).Register(c)
+			s := c.MustCompile(tc.schema)
 
-			err = c.MustCompile(tc.schema).Validate(bytes.NewBufferString(tc.doc))
-			if tc.expectErr != nil {
-				require.EqualError(t, err, tc.expectErr.Error())
+			for i := 0; i < 2; i++ {
+				err = runner.Validate(s, []byte(tc.doc))
+				if tc.expectErr != nil {
+					require.EqualError(t, err, tc.expectErr.Error())
+					assert.Empty(t, r.identifiers)
+					return
+				}
+				require.NoError(t, err)
 			}
 
-			assert.EqualValues(t, tc.expect, r.identifiers)
+			require.Len(t, r.identifiers, 2)
+			for _, actual := range r.identifiers {
+				assert.Equal(t, tc.expect, actual)
+			}
 		})
 	}
+
+	t.Run("case=concurrent validation passes do not share values", func(t *testing.T) {
+		c := jsonschema.NewCompiler()
+		runner, err := NewExtensionRunner(ExtensionRunnerIdentityMetaSchema)
+		require.NoError(t, err)
+
+		r := new(extensionStub)
+		runner.AddRunner(r).Register(c)
+		s := c.MustCompile("file://./stub/extension/schema.multi.json")
+
+		var wg sync.WaitGroup
+		expected := make([][]string, 10)
+		for i := range expected {
+			expected[i] = []string{fmt.Sprintf("%d@ory.sh", i), fmt.Sprintf("user-%d", i)}
+
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, runner.Validate(s, []byte(fmt.Sprintf(`{"username":"user-%[1]d","emails":["%[1]d@ory.sh"]}`, i))))
+			}(i)
+		}
+		wg.Wait()
+
+		assert.ElementsMatch(t, expected, r.identifiers)
+	})
 }
@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/jsonschema/v3"
+
+	"github.com/ory/kratos/text"
+)
+
+// FieldError describes why a single field failed JSON Schema validation.
+//
+// swagger:model validationFieldError
+type FieldError struct {
+	// Pointer is the JSON Pointer to the invalid field, for example `/traits/email`.
+	//
+	// required: true
+	Pointer string `json:"pointer"`
+
+	// Message is the human-readable error message. It is the same text that is shown in self-service UI nodes.
+	//
+	// required: true
+	Message string `json:"message"`
+
+	// Keyword is the JSON Schema keyword which failed, for example `required` or `format`.
+	Keyword string `json:"keyword,omitempty"`
+
+	// ID is the ID of the message which is also used in self-service UI nodes.
+	//
+	// required: true
+	ID text.ID `json:"id"`
+}
+
+// FieldErrors flattens a JSON Schema validation error into one error per invalid field. It returns nil if
+// err is not a validation error.
+func FieldErrors(err error) []FieldError {
+	if e := new(ValidationError); errors.As(err, &e) {
+		result := make([]FieldError, len(e.Messages))
+		for k, m := range e.Messages {
+			result[k] = FieldError{Pointer: pointer(e.InstancePtr), Message: m.Text, Keyword: keyword(e.ValidationError), ID: m.ID}
+		}
+		return result
+	} else if e := new(jsonschema.ValidationError); errors.As(err, &e) {
+		return fieldErrors(e)
+	}
+	return nil
+}
+
+func fieldErrors(e *jsonschema.ValidationError) (result []FieldError) {
+	if ctx, ok := e.Context.(*jsonschema.ValidationErrorContextRequired); ok {
+		for _, missing := range ctx.Missing {
+			if !strings.HasPrefix(missing, "#") {
+				missing = e.InstancePtr + "/" + missing
+			}
+			segments := strings.Split(missing, "/")
+			m := text.NewValidationErrorRequired(segments[len(segments)-1])
+			result = append(result, FieldError{Pointer: pointer(missing), Message: m.Text, Keyword: "required", ID: m.ID})
+		}
+		return result
+	}
+
+	if len(e.Causes) == 0 {
+		m := text.NewValidationErrorGeneric(e.Message)
+		return []FieldError{{Pointer: pointer(e.InstancePtr), Message: m.Text, Keyword: keyword(e), ID: m.ID}}
+	}
+
+	for _, cause := range e.Causes {
+		result = append(result, fieldErrors(cause)...)
+	}
+	return result
+}
+
+func pointer(instancePtr string) string {
+	return strings.TrimPrefix(instancePtr, "#")
+}
+
+func keyword(e *jsonschema.ValidationError) string {
+	if e == nil || e.SchemaPtr == "" {
+		return ""
+	}
+	segments := strings.Split(e.SchemaPtr, "/")
+	return segments[len(segments)-1]
+}
@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/jsonschema/v3"
+
+	"github.com/ory/kratos/text"
+)
+
+func TestFieldErrors(t *testing.T) {
+	t.Run("case=not a validation error", func(t *testing.T) {
+		assert.Nil(t, FieldErrors(assert.AnError))
+	})
+
+	t.Run("case=kratos validation error", func(t *testing.T) {
+		assert.Equal(t, []FieldError{{
+			Pointer: "/traits/password",
+			Message: "Length must be >= 8, but got 2.",
+			ID:      text.ErrorValidationMinLength,
+		}}, FieldErrors(NewMinLengthError("#/traits/password", 8, 2)))
+	})
+
+	t.Run("case=nested jsonschema errors", func(t *testing.T) {
+		err := &jsonschema.ValidationError{
+			InstancePtr: "#",
+			Causes: []*jsonschema.ValidationError{
+				{
+					Message:     "missing properties: \"email\", \"name\"",
+					InstancePtr: "#/traits",
+					SchemaPtr:   "#/properties/traits/required",
+					Context:     &jsonschema.ValidationErrorContextRequired{Missing: []string{"email", "name"}},
+				},
+				{
+					Message:     "expected string, but got number",
+					InstancePtr: "#/traits/age",
+					SchemaPtr:   "#/properties/traits/properties/age/type",
+				},
+			},
+		}
+
+		assert.Equal(t, []FieldError{
+			{Pointer: "/traits/email", Message: "Property email is missing.", Keyword: "required", ID: text.ErrorValidationRequired},
+			{Pointer: "/traits/name", Message: "Property name is missing.", Keyword: "required", ID: text.ErrorValidationRequired},
+			{Pointer: "/traits/age", Message: "expected string, but got number", Keyword: "type", ID: text.ErrorValidationGeneric},
+		}, FieldErrors(err))
+	})
+}
@@ -1,22 +1,29 @@
 package schema
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 
 	"github.com/ory/herodot"
+	"github.com/ory/x/urlx"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/x"
 )
 
 type (
 	handlerDependencies interface {
+		config.Provider
 		x.WriterProvider
 		x.LoggingProvider
 		IdentityTraitsProvider
@@ -35,12 +42,27 @@ func NewHandler(r handlerDependencies) *Handler {
 
 const SchemasPath string = "schemas"
 
+const (
+	// listMaxAge is the number of seconds for which the schema list can be cached.
+	listMaxAge = 60
+
+	// immutableMaxAge is the number of seconds for which a version of a schema can be cached.
+	immutableMaxAge = 365 * 24 * 60 * 60
+)
+
 func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
+	public.GET("/"+SchemasPath, h.list)
 	public.GET(fmt.Sprintf("/%s/:id", SchemasPath), h.get)
+	public.HEAD(fmt.Sprintf("/%s/:id", SchemasPath), h.get)
+	public.GET(fmt.Sprintf("/%s/:id/versions/:version", SchemasPath), h.getVersion)
 }
 
 func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET("/"+SchemasPath, h.list)
 	admin.GET(fmt.Sprintf("/%s/:id", SchemasPath), h.get)
+	admin.HEAD(fmt.Sprintf("/%s/:id", SchemasPath), h.get)
+	admin.GET(fmt.Sprintf("/%s/:id/versions/:version", SchemasPath), h.getVersion)
+	admin.GET(fmt.Sprintf("/%s/:id/types", SchemasPath), h.getTypes)
 }
 
 // Raw JSON Schema
@@ -63,6 +85,10 @@ type getSchemaParameters struct {
 //
 // Get a Traits Schema Definition
 //
+// Responses contain an `ETag` header and, for schemas loaded from files, a `Last-Modified` header. Send them
+// back using `If-None-Match` or `If-Modified-Since` to receive a `304 Not Modified` response without a body
+// if the schema did not change.
+//
 //     Produces:
 //     - application/json
 //
@@ -70,6 +96,7 @@ type getSchemaParameters struct {
 //
 //     Responses:
 //       200: jsonSchema
+//       304: emptyResponse
 //       404: genericError
 //       500: genericError
 func (h *Handler) get(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
@@ -78,28 +105,289 @@ func (h *Handler) get(w http.ResponseWriter, r *http.Request, ps httprouter.Para
 		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithDebugf("%+v", err)))
 		return
 	}
+
+	body, lastModified, err := h.load(s)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.write(w, r, body, lastModified)
+}
+
+// nolint:deadcode,unused
+// swagger:parameters getSchemaVersion
+type getSchemaVersionParameters struct {
+	// ID must be set to the ID of schema you want to get
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// Version must be set to the version of the schema you want to get
+	//
+	// required: true
+	// in: path
+	Version string `json:"version"`
+}
+
+// swagger:route GET /schemas/{id}/versions/{version} public admin getSchemaVersion
+//
+// Get a Version of a Traits Schema Definition
+//
+// The content of this URL never changes, so responses can be cached for a year. Only the current version
+// of a schema is available. Get the current version and its URL by listing the schemas.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: jsonSchema
+//       304: emptyResponse
+//       404: genericError
+//       500: genericError
+func (h *Handler) getVersion(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	s, err := h.r.IdentityTraitsSchemas(r.Context()).GetByID(ps.ByName("id"))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithDebugf("%+v", err)))
+		return
+	}
+
+	body, lastModified, err := h.load(s)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if Version(body) != ps.ByName("version") {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReasonf("Version %s of the JSON Schema is not available.", ps.ByName("version"))))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(immutableMaxAge)+", immutable")
+	h.write(w, r, body, lastModified)
+}
+
+// A list of identity traits schemas.
+//
+// swagger:response schemaList
+// nolint:deadcode,unused
+type schemaListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []ListedSchema
+}
+
+// ListedSchema describes an identity traits schema.
+//
+// swagger:model listedSchema
+type ListedSchema struct {
+	// ID is the schema's ID.
+	//
+	// required: true
+	ID string `json:"id"`
+
+	// URL always returns the current version of the schema.
+	//
+	// required: true
+	URL string `json:"url"`
+
+	// Version identifies the current content of the schema. It is omitted if the schema could not be loaded.
+	Version string `json:"version,omitempty"`
+
+	// ImmutableURL returns this version of the schema and can be cached forever. It is omitted if the schema
+	// could not be loaded.
+	ImmutableURL string `json:"immutable_url,omitempty"`
+}
+
+// swagger:route GET /schemas public admin listSchemas
+//
+// List the Traits Schema Definitions
+//
+// Lists all identity traits schemas with their current versions. Each version has a URL whose content never
+// changes, so client apps can prefetch and cache the schemas they render forms for.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: schemaList
+//       304: emptyResponse
+//       500: genericError
+func (h *Handler) list(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	base := h.r.Config(r.Context()).SelfPublicURL(r)
+	schemas := h.r.IdentityTraitsSchemas(r.Context())
+
+	listed := make([]ListedSchema, len(schemas))
+	for k := range schemas {
+		s := &schemas[k]
+		listed[k] = ListedSchema{ID: s.ID, URL: s.SchemaURL(base).String()}
+
+		body, _, err := h.load(s)
+		if err != nil {
+			h.r.Logger().WithError(err).WithField("schema_id", s.ID).Warn("Unable to load the JSON Schema to determine its version.")
+			continue
+		}
+
+		listed[k].Version = Version(body)
+		listed[k].ImmutableURL = urlx.AppendPaths(s.SchemaURL(base), "versions", listed[k].Version).String()
+	}
+
+	etag, err := x.WeakETag(listed)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(listMaxAge))
+	if x.NotModified(w, r, etag, time.Time{}) {
+		return
+	}
+
+	h.r.Writer().Write(w, r, listed)
+}
+
+const (
+	TypesFormatTypeScript = "typescript"
+	TypesFormatJSON       = "json"
+)
+
+// The type descriptor of an identity schema's traits.
+//
+// swagger:response schemaTypeDescriptor
+// nolint:deadcode,unused
+type schemaTypeDescriptorResponse struct {
+	// in: body
+	Body *TypeDescriptor
+}
+
+// nolint:deadcode,unused
+// swagger:parameters getSchemaTypes
+type getSchemaTypesParameters struct {
+	// ID must be set to the ID of schema you want to get the types of
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// Format is either `typescript` for a TypeScript module or `json` for a type descriptor. Defaults to
+	// `typescript`.
+	//
+	// required: false
+	// in: query
+	Format string `json:"format"`
+}
+
+// swagger:route GET /schemas/{id}/types admin getSchemaTypes
+//
+// Generate the Types of a Traits Schema Definition
+//
+// Generates the types of an identity schema's traits, so that client apps can type identities without
+// maintaining the types by hand. The `typescript` format returns a TypeScript module exporting an interface
+// named after the schema, for example `CustomerTraits` for the schema `customer`. The `json` format returns
+// a type descriptor which other code generators can use.
+//
+//     Produces:
+//     - application/json
+//     - text/plain
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: schemaTypeDescriptor
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) getTypes(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	format := r.URL.Query().Get("format")
+	if len(format) == 0 {
+		format = TypesFormatTypeScript
+	}
+	if format != TypesFormatTypeScript && format != TypesFormatJSON {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The format must be "%s" or "%s".`, TypesFormatTypeScript, TypesFormatJSON)))
+		return
+	}
+
+	s, err := h.r.IdentityTraitsSchemas(r.Context()).GetByID(ps.ByName("id"))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithDebugf("%+v", err)))
+		return
+	}
+
+	body, _, err := h.load(s)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	d, err := DescribeTraits(body)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if format == TypesFormatJSON {
+		h.r.Writer().Write(w, r, d)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := io.WriteString(w, TypeScript(s.ID, d)); err != nil {
+		h.r.Logger().WithError(err).Error("Unable to write the TypeScript types.")
+	}
+}
+
+// Version returns the version of a schema, which is derived from its content.
+func Version(body []byte) string {
+	h := sha256.Sum256(body)
+	return hex.EncodeToString(h[:8])
+}
+
+// load returns the schema's content and, for schemas loaded from files, the time the file was modified.
+func (h *Handler) load(s *Schema) ([]byte, time.Time, error) {
 	var src io.ReadCloser
+	var lastModified time.Time
 
 	if s.URL.Scheme == "file" {
-		src, err = os.Open(s.URL.Host + s.URL.Path)
+		f, err := os.Open(s.URL.Host + s.URL.Path)
 		if err != nil {
-			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The file for this JSON Schema ID could not be found or opened. This is a configuration issue.").WithDebugf("%+v", err)))
-			return
+			return nil, lastModified, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The file for this JSON Schema ID could not be found or opened. This is a configuration issue.").WithDebugf("%+v", err))
+		}
+		defer f.Close()
+		src = f
+
+		if info, err := f.Stat(); err == nil {
+			lastModified = info.ModTime()
 		}
-		defer src.Close()
 	} else {
 		resp, err := http.Get(s.URL.String())
 		if err != nil {
-			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The file for this JSON Schema ID could not be found or opened. This is a configuration issue.").WithDebugf("%+v", err)))
-			return
+			return nil, lastModified, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The file for this JSON Schema ID could not be found or opened. This is a configuration issue.").WithDebugf("%+v", err))
 		}
 		defer resp.Body.Close()
 		src = resp.Body
 	}
 
-	w.Header().Add("Content-Type", "application/json")
-	if _, err := io.Copy(w, src); err != nil {
-		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The file for this JSON Schema ID could not be found or opened. This is a configuration issue.").WithDebugf("%+v", err)))
+	body, err := io.ReadAll(src)
+	if err != nil {
+		return nil, lastModified, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The file for this JSON Schema ID could not be found or opened. This is a configuration issue.").WithDebugf("%+v", err))
+	}
+
+	return body, lastModified, nil
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, body []byte, lastModified time.Time) {
+	if x.NotModified(w, r, x.ETag(body), lastModified) {
 		return
 	}
+
+	w.Header().Add("Content-Type", "application/json")
+	if _, err := w.Write(body); err != nil {
+		h.r.Logger().WithError(err).Error("Unable to write the JSON Schema.")
+	}
 }
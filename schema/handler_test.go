@@ -1,6 +1,7 @@
 package schema_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	_ "github.com/ory/jsonschema/v3/fileloader"
@@ -27,6 +29,11 @@ func TestHandler(t *testing.T) {
 	ts := httptest.NewServer(router)
 	defer ts.Close()
 
+	adminRouter := x.NewRouterAdmin()
+	reg.SchemaHandler().RegisterAdminRoutes(adminRouter)
+	adminTS := httptest.NewServer(adminRouter)
+	defer adminTS.Close()
+
 	schemas := schema.Schemas{
 		{
 			ID:     "default",
@@ -38,6 +45,11 @@ func TestHandler(t *testing.T) {
 			URL:    urlx.ParseOrPanic("file://./stub/identity-2.schema.json"),
 			RawURL: "file://./stub/identity-2.schema.json",
 		},
+		{
+			ID:     "types",
+			URL:    urlx.ParseOrPanic("file://./stub/types.schema.json"),
+			RawURL: "file://./stub/types.schema.json",
+		},
 		{
 			ID:     "unreachable",
 			URL:    urlx.ParseOrPanic("http://127.0.0.1:12345/unreachable-schema"),
@@ -125,4 +137,103 @@ func TestHandler(t *testing.T) {
 	t.Run("case=get not-existing schema", func(t *testing.T) {
 		_ = getFromTS("not-existing", http.StatusNotFound)
 	})
+
+	t.Run("case=list schemas", func(t *testing.T) {
+		res, err := ts.Client().Get(ts.URL + "/schemas")
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Equal(t, "public, max-age=60", res.Header.Get("Cache-Control"))
+
+		var listed []schema.ListedSchema
+		require.NoError(t, json.Unmarshal(body, &listed))
+		require.Len(t, listed, len(schemas))
+
+		for _, s := range listed {
+			assert.Equal(t, ts.URL+"/schemas/"+s.ID, s.URL)
+			switch s.ID {
+			case config.DefaultIdentityTraitsSchemaID, "identity2", "types":
+				assert.Equal(t, schema.Version([]byte(getFromFS(s.ID))), s.Version)
+				assert.Equal(t, s.URL+"/versions/"+s.Version, s.ImmutableURL)
+			default:
+				assert.Empty(t, s.Version, "schemas which can not be loaded have no version")
+				assert.Empty(t, s.ImmutableURL)
+			}
+		}
+
+		t.Run("case=get immutable version", func(t *testing.T) {
+			var immutable string
+			for _, s := range listed {
+				if s.ID == "identity2" {
+					immutable = s.ImmutableURL
+				}
+			}
+
+			res, err := ts.Client().Get(immutable)
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			require.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+			assert.Equal(t, getFromFS("identity2"), string(body))
+			assert.Equal(t, "public, max-age=31536000, immutable", res.Header.Get("Cache-Control"))
+
+			_ = getFromTS("identity2/versions/0000000000000000", http.StatusNotFound)
+		})
+	})
+
+	t.Run("case=conditional get", func(t *testing.T) {
+		send := func(t *testing.T, method string, header http.Header, expectCode int) *http.Response {
+			req, err := http.NewRequest(method, ts.URL+"/schemas/"+config.DefaultIdentityTraitsSchemaID, nil)
+			require.NoError(t, err)
+			for k, v := range header {
+				req.Header[k] = v
+			}
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			require.EqualValues(t, expectCode, res.StatusCode)
+			return res
+		}
+
+		res := send(t, "HEAD", nil, http.StatusOK)
+		etag, lastModified := res.Header.Get("ETag"), res.Header.Get("Last-Modified")
+		require.NotEmpty(t, etag)
+		require.NotEmpty(t, lastModified)
+
+		send(t, "GET", http.Header{"If-None-Match": {etag}}, http.StatusNotModified)
+		send(t, "GET", http.Header{"If-None-Match": {`"other"`}}, http.StatusOK)
+		send(t, "GET", http.Header{"If-Modified-Since": {lastModified}}, http.StatusNotModified)
+		send(t, "GET", http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {lastModified}}, http.StatusOK)
+	})
+
+	t.Run("case=generate types", func(t *testing.T) {
+		getTypes := func(t *testing.T, path string, expectCode int) *http.Response {
+			res, err := adminTS.Client().Get(adminTS.URL + "/schemas/" + path)
+			require.NoError(t, err)
+			require.EqualValues(t, expectCode, res.StatusCode)
+			return res
+		}
+
+		res := getTypes(t, "types/types", http.StatusOK)
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Contains(t, res.Header.Get("Content-Type"), "text/plain")
+		assert.Contains(t, string(body), "export interface TypesTraits {")
+		assert.Contains(t, string(body), "  email: string")
+
+		res = getTypes(t, "types/types?format=json", http.StatusOK)
+		var d schema.TypeDescriptor
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&d))
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, schema.TypeObject, d.Type)
+		assert.Equal(t, "email", d.Properties["email"].Format)
+
+		getTypes(t, "types/types?format=go", http.StatusBadRequest)
+		getTypes(t, "identity2/types", http.StatusBadRequest)
+		getTypes(t, "not-existing/types", http.StatusNotFound)
+	})
 }
@@ -0,0 +1,203 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/jsonschema/v3"
+)
+
+const (
+	// LintIdentifierType is reported for password identifiers which are not strings.
+	LintIdentifierType = "identifier-type"
+
+	// LintAddressFormat is reported for verification and recovery addresses which are not formatted as email
+	// addresses or phone numbers, or which use an unsupported channel.
+	LintAddressFormat = "address-format"
+
+	// LintEmailNotRequired is reported for email addresses used for signing in, verification, or recovery which
+	// identities do not need to have.
+	LintEmailNotRequired = "email-not-required"
+
+	// LintConflictingAnnotations is reported for traits whose `ory.sh/kratos` annotations contradict each other.
+	LintConflictingAnnotations = "conflicting-annotations"
+
+	// LintNoIdentifier is reported for schemas without password identifiers.
+	LintNoIdentifier = "no-identifier"
+)
+
+// LintIssue is a likely mistake in an identity schema which JSON Schema validation does not catch.
+type LintIssue struct {
+	// Path is the path of the trait, for example `traits.email`. It is empty for issues of the whole schema.
+	Path string `json:"path"`
+
+	// Rule is the name of the check which reported the issue.
+	Rule string `json:"rule"`
+
+	// Message explains the issue and how to fix it.
+	Message string `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	if len(i.Path) == 0 {
+		return fmt.Sprintf("%s (%s)", i.Message, i.Rule)
+	}
+	return fmt.Sprintf("%s: %s (%s)", i.Path, i.Message, i.Rule)
+}
+
+// Lint checks an identity schema for common mistakes. It returns an error if the schema is not a valid JSON
+// Schema. Issues are sorted by path.
+func Lint(raw []byte) ([]LintIssue, error) {
+	if !gjson.ValidBytes(raw) {
+		return nil, errors.New("the identity schema is not valid JSON")
+	}
+
+	runner, err := NewExtensionRunner(ExtensionRunnerIdentityMetaSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	runner.Register(compiler)
+	if err := compiler.AddResource("identity.schema.json", bytes.NewReader(raw)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if _, err := compiler.Compile("identity.schema.json"); err != nil {
+		return nil, errors.Wrap(err, "the identity schema is invalid")
+	}
+
+	root := gjson.ParseBytes(raw)
+	traits := root.Get("properties.traits")
+	if !traits.IsObject() {
+		return nil, errors.New("the identity schema does not define any traits")
+	}
+
+	l := &linter{root: root}
+	l.walk(traits, "traits", true, 0)
+
+	if !l.identifier {
+		l.report("", LintNoIdentifier, `No trait is marked as password identifier, so identities of this schema can not sign in with a password. Mark the email address or username with "ory.sh/kratos": {"credentials": {"password": {"identifier": true}}}.`)
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].Path < l.issues[j].Path
+	})
+	return l.issues, nil
+}
+
+type linter struct {
+	root       gjson.Result
+	issues     []LintIssue
+	identifier bool
+}
+
+func (l *linter) report(path, rule, message string) {
+	l.issues = append(l.issues, LintIssue{Path: path, Rule: rule, Message: message})
+}
+
+// walk checks the trait at path and its children. Required is false if the trait or one of its parents is
+// optional.
+func (l *linter) walk(s gjson.Result, path string, required bool, depth int) {
+	if depth > maxTypeDepth {
+		return
+	}
+
+	if ref := s.Get(`\$ref`); ref.Exists() {
+		if target, ok := resolveRef(l.root, ref.String()); ok {
+			l.walk(target, path, required, depth+1)
+		}
+		return
+	}
+
+	l.check(s, path, required)
+
+	requiredProperties := map[string]bool{}
+	for _, r := range s.Get("required").Array() {
+		requiredProperties[r.String()] = true
+	}
+	s.Get("properties").ForEach(func(key, value gjson.Result) bool {
+		l.walk(value, path+"."+key.String(), required && requiredProperties[key.String()], depth+1)
+		return true
+	})
+
+	// Array items are required if the array is.
+	if items := s.Get("items"); items.IsObject() {
+		l.walk(items, path, required, depth+1)
+	}
+}
+
+func (l *linter) check(s gjson.Result, path string, required bool) {
+	ext := s.Get(escapePath(extensionName))
+	if !ext.IsObject() {
+		return
+	}
+
+	identifier := ext.Get("credentials.password.identifier").Bool()
+	verification := ext.Get("verification.via").String()
+	recovery := ext.Get("recovery.via").String()
+	isString := typeIncludes(s, "string")
+	format := s.Get("format").String()
+
+	if identifier {
+		l.identifier = true
+		if !isString {
+			l.report(path, LintIdentifierType, `The trait is marked as password identifier but is not of type "string". Identities sign in with the identifier, so it must be a string or an array of strings.`)
+		}
+		if ext.Get("sensitive").Bool() {
+			l.report(path, LintConflictingAnnotations, "The trait is marked as password identifier and as sensitive. Identifiers are stored unmasked with the password credentials, so remove one of the two annotations.")
+		}
+	}
+
+	for _, via := range []struct{ name, value string }{{"verification", verification}, {"recovery", recovery}} {
+		switch {
+		case via.value == "sms" && via.name == "verification":
+			if !isString {
+				l.report(path, LintAddressFormat, `The trait is used for verification via SMS but is not of type "string". Phone numbers must be strings in E.164 format, such as "+12065550100".`)
+			}
+		case len(via.value) > 0 && via.value != "email":
+			l.report(path, LintAddressFormat, fmt.Sprintf(`The trait is used for %s via %q but only "email" is supported.`, via.name, via.value))
+		case via.value == "email" && (!isString || format != "email"):
+			l.report(path, LintAddressFormat, fmt.Sprintf(`The trait is used for %s via email but is not of type "string" with format "email". Add "format": "email" so that only email addresses are accepted.`, via.name))
+		}
+	}
+
+	if ext.Get("profile.display_name").Bool() && ext.Get("profile.avatar").Bool() {
+		l.report(path, LintConflictingAnnotations, "The trait is marked as display name and as avatar. The avatar must be a URL, so mark a different trait as display name.")
+	}
+
+	if !required && format == "email" && (identifier || len(verification) > 0 || len(recovery) > 0) {
+		var uses []string
+		if identifier {
+			uses = append(uses, "signing in")
+		}
+		if len(verification) > 0 {
+			uses = append(uses, "verification")
+		}
+		if len(recovery) > 0 {
+			uses = append(uses, "recovery")
+		}
+		l.report(path, LintEmailNotRequired, fmt.Sprintf(`The email address is used for %s but is not required, so identities can be created without it. Add it to the "required" list of its parent.`, strings.Join(uses, " and ")))
+	}
+}
+
+// typeIncludes returns true if the schema's type is t or, for arrays, if the items' type is t.
+func typeIncludes(s gjson.Result, t string) bool {
+	types := s.Get("type")
+	if types.IsArray() {
+		for _, tt := range types.Array() {
+			if tt.String() == t {
+				return true
+			}
+		}
+		return false
+	}
+	if types.String() == TypeArray {
+		return typeIncludes(s.Get("items"), t)
+	}
+	return types.String() == t
+}
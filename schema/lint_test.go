@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	lint := func(t *testing.T, path string) []LintIssue {
+		raw, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		issues, err := Lint(raw)
+		require.NoError(t, err)
+		return issues
+	}
+
+	t.Run("case=reports no issues for a valid schema", func(t *testing.T) {
+		assert.Empty(t, lint(t, "stub/lint/valid.schema.json"))
+	})
+
+	t.Run("case=reports common mistakes", func(t *testing.T) {
+		var actual [][2]string
+		for _, issue := range lint(t, "stub/lint/issues.schema.json") {
+			actual = append(actual, [2]string{issue.Path, issue.Rule})
+		}
+		assert.Equal(t, [][2]string{
+			{"traits.customer_number", LintIdentifierType},
+			{"traits.email", LintEmailNotRequired},
+			{"traits.phone", LintAddressFormat},
+			{"traits.phone", LintAddressFormat},
+			{"traits.picture", LintConflictingAnnotations},
+			{"traits.username", LintConflictingAnnotations},
+		}, actual)
+	})
+
+	t.Run("case=reports schemas without identifiers", func(t *testing.T) {
+		issues, err := Lint([]byte(`{"type":"object","properties":{"traits":{"type":"object","properties":{"name":{"type":"string"}}}}}`))
+		require.NoError(t, err)
+		require.Len(t, issues, 1)
+		assert.Equal(t, LintNoIdentifier, issues[0].Rule)
+		assert.Empty(t, issues[0].Path)
+	})
+
+	t.Run("case=rejects invalid schemas", func(t *testing.T) {
+		for _, raw := range []string{
+			`{`,
+			`{"type":"object","properties":{"traits":{"type":"foo"}}}`,
+			`{"type":"object"}`,
+		} {
+			_, err := Lint([]byte(raw))
+			assert.Error(t, err, raw)
+		}
+	})
+}
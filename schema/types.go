@@ -0,0 +1,357 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/herodot"
+)
+
+const (
+	TypeUnknown = "unknown"
+	TypeUnion   = "union"
+	TypeObject  = "object"
+	TypeArray   = "array"
+
+	// maxTypeDepth limits how deep references are followed to keep recursive schemas from looping forever.
+	maxTypeDepth = 32
+)
+
+var typeScriptIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// TypeDescriptor describes the type of an identity schema's traits. It is a simplified JSON Schema without
+// validation keywords and references, which code generators can turn into types.
+//
+// swagger:model schemaTypeDescriptor
+type TypeDescriptor struct {
+	// Type is one of `string`, `number`, `integer`, `boolean`, `null`, `object`, `array`, `union`, or
+	// `unknown`.
+	//
+	// required: true
+	Type string `json:"type"`
+
+	// Title is the title of the schema.
+	Title string `json:"title,omitempty"`
+
+	// Description is the description of the schema.
+	Description string `json:"description,omitempty"`
+
+	// Format is the format of strings, for example `email`.
+	Format string `json:"format,omitempty"`
+
+	// Enum lists the only values which are allowed.
+	Enum []interface{} `json:"enum,omitempty"`
+
+	// Properties describes the properties of objects.
+	Properties map[string]*TypeDescriptor `json:"properties,omitempty"`
+
+	// Required lists the properties of objects which must be set.
+	Required []string `json:"required,omitempty"`
+
+	// Items describes the items of arrays.
+	Items *TypeDescriptor `json:"items,omitempty"`
+
+	// OneOf lists the alternatives of unions.
+	OneOf []*TypeDescriptor `json:"one_of,omitempty"`
+
+	// order keeps the order in which the properties are defined in the schema.
+	order []string
+}
+
+// DescribeTraits returns the type descriptor of the traits of an identity schema. References within the
+// schema are resolved, other references are described as `unknown`.
+func DescribeTraits(raw []byte) (*TypeDescriptor, error) {
+	if !gjson.ValidBytes(raw) {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("The identity schema is not valid JSON."))
+	}
+
+	root := gjson.ParseBytes(raw)
+	traits := root.Get("properties.traits")
+	if !traits.IsObject() {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("The identity schema does not define any traits."))
+	}
+
+	return describe(root, traits, 0), nil
+}
+
+func describe(root, s gjson.Result, depth int) *TypeDescriptor {
+	d := &TypeDescriptor{
+		Type:        TypeUnknown,
+		Title:       s.Get("title").String(),
+		Description: s.Get("description").String(),
+	}
+	if depth > maxTypeDepth {
+		return d
+	}
+
+	if ref := s.Get(`\$ref`); ref.Exists() {
+		target, ok := resolveRef(root, ref.String())
+		if !ok {
+			return d
+		}
+
+		resolved := describe(root, target, depth+1)
+		if len(d.Title) > 0 {
+			resolved.Title = d.Title
+		}
+		if len(d.Description) > 0 {
+			resolved.Description = d.Description
+		}
+		return resolved
+	}
+
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if alternatives := s.Get(key); alternatives.IsArray() {
+			d.Type = TypeUnion
+			for _, a := range alternatives.Array() {
+				d.OneOf = append(d.OneOf, describe(root, a, depth+1))
+			}
+			return d
+		}
+	}
+
+	var values []gjson.Result
+	if c := s.Get("const"); c.Exists() {
+		values = []gjson.Result{c}
+	} else if e := s.Get("enum"); e.IsArray() {
+		values = e.Array()
+	}
+	for _, v := range values {
+		d.Enum = append(d.Enum, v.Value())
+	}
+
+	var types []string
+	if t := s.Get("type"); t.IsArray() {
+		for _, tt := range t.Array() {
+			types = append(types, tt.String())
+		}
+	} else if t.Exists() {
+		types = []string{t.String()}
+	} else if s.Get("properties").IsObject() {
+		types = []string{TypeObject}
+	} else if len(values) > 0 {
+		types = []string{valueType(values[0])}
+	}
+
+	switch len(types) {
+	case 0:
+		return d
+	case 1:
+		describeType(root, s, types[0], d, depth)
+		return d
+	}
+
+	d.Type = TypeUnion
+	for _, t := range types {
+		alternative := &TypeDescriptor{Enum: d.Enum}
+		describeType(root, s, t, alternative, depth)
+		d.OneOf = append(d.OneOf, alternative)
+	}
+	d.Enum = nil
+	return d
+}
+
+func describeType(root, s gjson.Result, t string, d *TypeDescriptor, depth int) {
+	d.Type = t
+	switch t {
+	case "string":
+		d.Format = s.Get("format").String()
+	case TypeObject:
+		d.Properties = map[string]*TypeDescriptor{}
+		s.Get("properties").ForEach(func(key, value gjson.Result) bool {
+			d.Properties[key.String()] = describe(root, value, depth+1)
+			d.order = append(d.order, key.String())
+			return true
+		})
+		for _, r := range s.Get("required").Array() {
+			d.Required = append(d.Required, r.String())
+		}
+	case TypeArray:
+		if items := s.Get("items"); items.IsObject() {
+			d.Items = describe(root, items, depth+1)
+		} else {
+			d.Items = &TypeDescriptor{Type: TypeUnknown}
+		}
+	}
+}
+
+// resolveRef returns the part of the schema a local reference such as `#/definitions/name` points to.
+func resolveRef(root gjson.Result, ref string) (gjson.Result, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return gjson.Result{}, false
+	}
+
+	pointer := strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/")
+	if len(pointer) == 0 {
+		return root, true
+	}
+
+	var path []string
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		path = append(path, escapePath(token))
+	}
+
+	target := root.Get(strings.Join(path, "."))
+	return target, target.IsObject()
+}
+
+func escapePath(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(`.*?|#@\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func valueType(v gjson.Result) string {
+	switch v.Type {
+	case gjson.String:
+		return "string"
+	case gjson.Number:
+		return "number"
+	case gjson.True, gjson.False:
+		return "boolean"
+	case gjson.Null:
+		return "null"
+	}
+	if v.IsArray() {
+		return TypeArray
+	}
+	return TypeObject
+}
+
+// TypeScriptName returns the name of the TypeScript interface generated for the traits of a schema, for
+// example `CustomerV2Traits` for the schema ID `customer-v2`.
+func TypeScriptName(schemaID string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range schemaID {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	name := b.String()
+	if len(name) == 0 || unicode.IsDigit(rune(name[0])) {
+		name = "Schema" + name
+	}
+	return name + "Traits"
+}
+
+// TypeScript returns a TypeScript module which exports the type of the traits of the schema with the given ID.
+func TypeScript(schemaID string, d *TypeDescriptor) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by ORY Kratos from the identity schema %q. DO NOT EDIT.\n\n", schemaID)
+	writeComment(&b, d, "")
+
+	name := TypeScriptName(schemaID)
+	if d.Type == TypeObject && len(d.Enum) == 0 {
+		fmt.Fprintf(&b, "export interface %s %s\n", name, typeScriptType(d, ""))
+	} else {
+		fmt.Fprintf(&b, "export type %s = %s\n", name, typeScriptType(d, ""))
+	}
+	return b.String()
+}
+
+func typeScriptType(d *TypeDescriptor, indent string) string {
+	if len(d.Enum) > 0 {
+		literals := make([]string, len(d.Enum))
+		for k, v := range d.Enum {
+			literal, err := json.Marshal(v)
+			if err != nil {
+				return "unknown"
+			}
+			literals[k] = string(literal)
+		}
+		return strings.Join(literals, " | ")
+	}
+
+	switch d.Type {
+	case "string", "boolean", "null":
+		return d.Type
+	case "number", "integer":
+		return "number"
+	case TypeArray:
+		if d.Items == nil {
+			return "unknown[]"
+		}
+		return "Array<" + typeScriptType(d.Items, indent) + ">"
+	case TypeUnion:
+		alternatives := make([]string, len(d.OneOf))
+		for k, a := range d.OneOf {
+			alternatives[k] = typeScriptType(a, indent)
+		}
+		return strings.Join(alternatives, " | ")
+	case TypeObject:
+		if len(d.Properties) == 0 {
+			return "Record<string, unknown>"
+		}
+
+		required := make(map[string]bool, len(d.Required))
+		for _, r := range d.Required {
+			required[r] = true
+		}
+
+		var b strings.Builder
+		b.WriteString("{\n")
+		for _, key := range d.order {
+			p := d.Properties[key]
+			writeComment(&b, p, indent+"  ")
+
+			name := key
+			if !typeScriptIdentifier.MatchString(key) {
+				quoted, _ := json.Marshal(key)
+				name = string(quoted)
+			}
+			if !required[key] {
+				name += "?"
+			}
+			fmt.Fprintf(&b, "%s  %s: %s\n", indent, name, typeScriptType(p, indent+"  "))
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	}
+
+	return "unknown"
+}
+
+func writeComment(b *strings.Builder, d *TypeDescriptor, indent string) {
+	var lines []string
+	for _, text := range []string{d.Title, d.Description} {
+		for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+			if line = strings.TrimSpace(line); len(line) > 0 {
+				lines = append(lines, strings.ReplaceAll(line, "*/", "*\\/"))
+			}
+		}
+	}
+
+	switch len(lines) {
+	case 0:
+		return
+	case 1:
+		fmt.Fprintf(b, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
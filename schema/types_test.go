@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeTraits(t *testing.T) {
+	raw, err := ioutil.ReadFile("stub/types.schema.json")
+	require.NoError(t, err)
+
+	d, err := DescribeTraits(raw)
+	require.NoError(t, err)
+
+	assert.Equal(t, TypeObject, d.Type)
+	assert.Equal(t, []string{"email"}, d.Required)
+	assert.Equal(t, []string{"email", "name", "age", "plan", "tags", "nickname", "contact", "external", "metadata", "first-name"}, d.order)
+
+	assert.Equal(t, &TypeDescriptor{Type: "string", Title: "E-Mail", Format: "email"}, d.Properties["email"])
+	assert.Equal(t, TypeObject, d.Properties["name"].Type, "local references are resolved")
+	assert.Equal(t, "string", d.Properties["name"].Properties["first"].Type)
+	assert.Equal(t, TypeUnknown, d.Properties["external"].Type, "other references are unknown")
+	assert.Equal(t, []interface{}{"free", "pro"}, d.Properties["plan"].Enum)
+	assert.Equal(t, "string", d.Properties["plan"].Type)
+	assert.Equal(t, "string", d.Properties["tags"].Items.Type)
+	assert.Equal(t, TypeUnion, d.Properties["nickname"].Type)
+	assert.Len(t, d.Properties["nickname"].OneOf, 2)
+	assert.Equal(t, TypeUnion, d.Properties["contact"].Type)
+
+	_, err = json.Marshal(d)
+	require.NoError(t, err)
+
+	t.Run("case=no traits", func(t *testing.T) {
+		_, err := DescribeTraits([]byte(`{"type":"object"}`))
+		require.Error(t, err)
+
+		_, err = DescribeTraits([]byte(`{`))
+		require.Error(t, err)
+	})
+
+	t.Run("case=recursive reference", func(t *testing.T) {
+		d, err := DescribeTraits([]byte(`{"properties":{"traits":{"$ref":"#/properties/traits"}}}`))
+		require.NoError(t, err)
+		assert.Equal(t, TypeUnknown, d.Type)
+	})
+}
+
+func TestTypeScript(t *testing.T) {
+	raw, err := ioutil.ReadFile("stub/types.schema.json")
+	require.NoError(t, err)
+
+	d, err := DescribeTraits(raw)
+	require.NoError(t, err)
+
+	assert.Equal(t, `// Code generated by ORY Kratos from the identity schema "customer-v2". DO NOT EDIT.
+
+export interface CustomerV2Traits {
+  /** E-Mail */
+  email: string
+  name?: {
+    first?: string
+    last?: string
+  }
+  age?: number
+  /** The plan the customer subscribed to. */
+  plan?: "free" | "pro"
+  tags?: Array<string>
+  nickname?: string | null
+  contact?: string | number
+  external?: unknown
+  metadata?: Record<string, unknown>
+  "first-name"?: boolean
+}
+`, TypeScript("customer-v2", d))
+}
+
+func TestTypeScriptName(t *testing.T) {
+	for id, expected := range map[string]string{
+		"default":     "DefaultTraits",
+		"customer-v2": "CustomerV2Traits",
+		"2fa_users":   "Schema2faUsersTraits",
+		"":            "SchemaTraits",
+	} {
+		assert.Equal(t, expected, TypeScriptName(id), id)
+	}
+}
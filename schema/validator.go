@@ -63,12 +63,12 @@ func (v *Validator) Validate(
 		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to parse validate JSON object against JSON schema.").WithDebugf("%s", err))
 	}
 
-	if err := schema.Validate(bytes.NewBuffer(document)); err != nil {
-		return errors.WithStack(err)
+	if o.e != nil {
+		return o.e.Validate(schema, document)
 	}
 
-	if o.e != nil {
-		return o.e.Finish()
+	if err := schema.Validate(bytes.NewBuffer(document)); err != nil {
+		return errors.WithStack(err)
 	}
 
 	return nil
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/gofrs/uuid"
+
 	"github.com/ory/kratos/driver/config"
 
 	"github.com/ory/x/urlx"
@@ -38,13 +40,9 @@ func NewManager(d managerDependencies) *Manager {
 // Create is a simple helper that saves all errors in the store and returns the
 // error url, appending the error ID.
 func (m *Manager) Create(ctx context.Context, w http.ResponseWriter, r *http.Request, errs ...error) (string, error) {
-	for _, err := range errs {
-		m.d.Logger().WithError(err).WithRequest(r).Errorf("An error occurred and is being forwarded to the error user interface.")
-	}
-
-	id, emerr := m.d.SelfServiceErrorPersister().Add(ctx, m.d.GenerateCSRFToken(r), errs...)
-	if emerr != nil {
-		return "", emerr
+	id, err := m.add(ctx, r, errs...)
+	if err != nil {
+		return "", err
 	}
 	q := url.Values{}
 	q.Set("error", id.String())
@@ -52,9 +50,22 @@ func (m *Manager) Create(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return urlx.CopyWithQuery(m.d.Config(ctx).SelfServiceFlowErrorURL(), q).String(), nil
 }
 
+func (m *Manager) add(ctx context.Context, r *http.Request, errs ...error) (uuid.UUID, error) {
+	for _, err := range errs {
+		m.d.Logger().WithError(err).WithRequest(r).Errorf("An error occurred and is being forwarded to the error user interface.")
+	}
+
+	return m.d.SelfServiceErrorPersister().Add(ctx, m.d.GenerateCSRFToken(r), errs...)
+}
+
 // Forward is a simple helper that saves all errors in the store and forwards the HTTP Request
-// to the error url, appending the error ID.
+// to the error url, appending the error ID. If an error route applies to the first error, the
+// HTTP Request is forwarded to the route's URL instead.
 func (m *Manager) Forward(ctx context.Context, w http.ResponseWriter, r *http.Request, errs ...error) {
+	if len(errs) > 0 && m.forwardToRoute(ctx, w, r, x.RecoverStatusCode(errs[0], http.StatusInternalServerError), errs...) {
+		return
+	}
+
 	to, err := m.Create(ctx, w, r, errs...)
 	if err != nil {
 		// Everything failed. Resort to standard error output.
@@ -63,3 +74,32 @@ func (m *Manager) Forward(ctx context.Context, w http.ResponseWriter, r *http.Re
 	}
 	http.Redirect(w, r, to, http.StatusFound)
 }
+
+// forwardToRoute responds as the error route which applies to the first error tells. It returns false if no
+// route applies or the route could not be used, in which case the error has not been written.
+func (m *Manager) forwardToRoute(ctx context.Context, w http.ResponseWriter, r *http.Request, code int, errs ...error) bool {
+	route, values := m.findRoute(r, code, errs[0])
+	if route == nil {
+		return false
+	}
+
+	if x.IsJSONRequest(r) {
+		if err := m.writeRouteBody(w, r, route, values); err != nil {
+			m.d.Logger().WithError(err).WithRequest(r).Warn("Unable to render the body of the error route, falling back to the default error format.")
+			return false
+		}
+		return true
+	}
+
+	id, err := m.add(ctx, r, errs...)
+	if err != nil {
+		return false
+	}
+	values.ErrorID = id.String()
+
+	if err := m.redirectToRoute(w, r, route, values); err != nil {
+		m.d.Logger().WithError(err).WithRequest(r).Warn("Unable to render the redirect URL of the error route, falling back to the error user interface.")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package errorx
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+// flowExpiredError is implemented by the errors which self-service flows return once they expired.
+type flowExpiredError interface {
+	FlowExpired()
+}
+
+// routeValues are the values the templates of error routes can use.
+type routeValues struct {
+	ErrorID   string
+	FlowID    string
+	Code      int
+	Class     config.ErrorClass
+	Reason    string
+	RequestID string
+}
+
+// Classify returns the class of the error which error routes match.
+func Classify(code int, err error) config.ErrorClass {
+	if fe := flowExpiredError(nil); errors.As(err, &fe) || code == http.StatusGone {
+		return config.ErrorClassFlowExpired
+	}
+
+	if de := new(herodot.DefaultError); errors.As(err, &de) {
+		switch de.Reason() {
+		case x.ErrInvalidCSRFToken.Reason(), x.ErrCSRFTokenMissingOrInvalid.Reason():
+			return config.ErrorClassCSRF
+		}
+	}
+
+	if code >= http.StatusInternalServerError {
+		return config.ErrorClassServerError
+	}
+	return config.ErrorClassClientError
+}
+
+// findRoute returns the first error route which applies to the error and the request's client or nil if none
+// does.
+func (m *Manager) findRoute(r *http.Request, code int, err error) (*config.ErrorRoute, *routeValues) {
+	routes := m.d.Config(r.Context()).SelfServiceFlowErrorRoutes()
+	if len(routes) == 0 {
+		return nil, nil
+	}
+
+	client := config.ErrorRouteClientBrowser
+	if x.IsJSONRequest(r) {
+		client = config.ErrorRouteClientAPI
+	}
+
+	class := Classify(code, err)
+	for k := range routes {
+		route := &routes[k]
+		if route.Error != config.ErrorClassAny && route.Error != class {
+			continue
+		} else if route.Client != config.ErrorRouteClientAny && route.Client != client {
+			continue
+		} else if client == config.ErrorRouteClientBrowser && len(route.RedirectTo) == 0 {
+			continue
+		} else if client == config.ErrorRouteClientAPI && len(route.Body) == 0 {
+			continue
+		}
+
+		return route, &routeValues{
+			FlowID:    flowIDFromRequest(r),
+			Code:      code,
+			Class:     class,
+			Reason:    herodot.ToDefaultError(err, "").Reason(),
+			RequestID: r.Header.Get("X-Request-ID"),
+		}
+	}
+
+	return nil, nil
+}
+
+func flowIDFromRequest(r *http.Request) string {
+	if id := r.URL.Query().Get("flow"); len(id) > 0 {
+		return id
+	}
+	return r.URL.Query().Get("id")
+}
+
+// redirectToRoute redirects the browser to the error route's URL.
+func (m *Manager) redirectToRoute(w http.ResponseWriter, r *http.Request, route *config.ErrorRoute, v *routeValues) error {
+	escaped := *v
+	escaped.ErrorID = url.QueryEscape(v.ErrorID)
+	escaped.FlowID = url.QueryEscape(v.FlowID)
+	escaped.Reason = url.QueryEscape(v.Reason)
+	escaped.RequestID = url.QueryEscape(v.RequestID)
+
+	to, err := renderRouteTemplate(route.RedirectTo, &escaped)
+	if err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, to, http.StatusFound)
+	return nil
+}
+
+// writeRouteBody responds with the error route's body.
+func (m *Manager) writeRouteBody(w http.ResponseWriter, r *http.Request, route *config.ErrorRoute, v *routeValues) error {
+	var body interface{}
+	if err := json.Unmarshal(route.Body, &body); err != nil {
+		return errors.WithStack(err)
+	}
+
+	body, err := renderRouteBody(body, v)
+	if err != nil {
+		return err
+	}
+
+	m.d.Writer().WriteCode(w, r, v.Code, body)
+	return nil
+}
+
+func renderRouteBody(body interface{}, v *routeValues) (interface{}, error) {
+	switch b := body.(type) {
+	case string:
+		return renderRouteTemplate(b, v)
+	case map[string]interface{}:
+		for key, value := range b {
+			rendered, err := renderRouteBody(value, v)
+			if err != nil {
+				return nil, err
+			}
+			b[key] = rendered
+		}
+	case []interface{}:
+		for key, value := range b {
+			rendered, err := renderRouteBody(value, v)
+			if err != nil {
+				return nil, err
+			}
+			b[key] = rendered
+		}
+	}
+	return body, nil
+}
+
+func renderRouteTemplate(source string, v *routeValues) (string, error) {
+	if !strings.Contains(source, "{{") {
+		return source, nil
+	}
+
+	t, err := template.New("").Parse(source)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, v); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return b.String(), nil
+}
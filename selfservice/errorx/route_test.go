@@ -0,0 +1,122 @@
+package errorx_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/errorx"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/x"
+)
+
+func TestClassify(t *testing.T) {
+	for k, tc := range []struct {
+		code     int
+		err      error
+		expected config.ErrorClass
+	}{
+		{code: http.StatusBadRequest, err: login.NewFlowExpiredError(time.Now()), expected: config.ErrorClassFlowExpired},
+		{code: http.StatusGone, err: errors.WithStack(x.ErrGone.WithReason("expired")), expected: config.ErrorClassFlowExpired},
+		{code: http.StatusForbidden, err: errors.WithStack(x.ErrInvalidCSRFToken), expected: config.ErrorClassCSRF},
+		{code: http.StatusBadRequest, err: errors.WithStack(x.ErrCSRFTokenMissingOrInvalid), expected: config.ErrorClassCSRF},
+		{code: http.StatusForbidden, err: herodot.ErrForbidden.WithReason("nope"), expected: config.ErrorClassClientError},
+		{code: http.StatusInternalServerError, err: errors.New("foo"), expected: config.ErrorClassServerError},
+	} {
+		assert.Equal(t, tc.expected, errorx.Classify(tc.code, tc.err), "%d", k)
+	}
+}
+
+func TestRouteWriter(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySelfServiceErrorUI, "https://example.com/error")
+	conf.MustSet(config.ViperKeySelfServiceErrorRoutes, []map[string]interface{}{
+		{"error": "flow_expired", "client": "browser", "redirect_to": "https://example.com/expired?flow={{ .FlowID }}&code={{ .Code }}"},
+		{"error": "server_error", "redirect_to": "https://example.com/oops?error={{ .ErrorID }}&reason={{ .Reason }}"},
+		{"error": "any", "client": "api", "body": map[string]interface{}{"error": map[string]interface{}{"class": "{{ .Class }}", "flow": "{{ .FlowID }}", "status": "{{ .Code }}"}}},
+	})
+
+	router := x.NewRouterPublic()
+	router.GET("/self-service/login/flows", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		reg.Writer().WriteError(w, r, errors.WithStack(x.ErrGone.WithReason("The login flow has expired.")))
+	})
+	router.GET("/self-service/fail", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		reg.Writer().WriteError(w, r, errors.WithStack(herodot.ErrInternalServerError.WithReason("a b")))
+	})
+	router.GET("/self-service/forward", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		reg.SelfServiceErrorManager().Forward(r.Context(), w, r, herodot.ErrNotFound.WithReason("not found"))
+	})
+	router.GET("/sessions/fail", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		reg.Writer().WriteError(w, r, errors.WithStack(herodot.ErrInternalServerError.WithReason("a b")))
+	})
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	hc := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	var get = func(t *testing.T, path string, json bool) *http.Response {
+		req, err := http.NewRequest("GET", ts.URL+path, nil)
+		require.NoError(t, err)
+		if json {
+			req.Header.Set("Accept", "application/json")
+		}
+		res, err := hc.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = res.Body.Close() })
+		return res
+	}
+
+	t.Run("case=redirects browsers to the matching route", func(t *testing.T) {
+		res := get(t, "/self-service/login/flows?id=some+flow", false)
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "https://example.com/expired?flow=some+flow&code=410", res.Header.Get("Location"))
+	})
+
+	t.Run("case=redirects browsers with the error ID", func(t *testing.T) {
+		res := get(t, "/self-service/fail", false)
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+
+		to, err := url.Parse(res.Header.Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "/oops", to.Path)
+		assert.Equal(t, "a b", to.Query().Get("reason"))
+
+		c, err := reg.SelfServiceErrorPersister().Read(context.Background(), x.ParseUUID(to.Query().Get("error")))
+		require.NoError(t, err)
+		assert.Equal(t, "a b", gjson.GetBytes(c.Errors, "0.reason").String(), "%s", c.Errors)
+	})
+
+	t.Run("case=renders the body for API clients", func(t *testing.T) {
+		res := get(t, "/self-service/login/flows?id=some-flow", true)
+		assert.Equal(t, http.StatusGone, res.StatusCode)
+		body := x.MustReadAll(res.Body)
+		assert.JSONEq(t, `{"error":{"class":"flow_expired","flow":"some-flow","status":"410"}}`, string(body))
+	})
+
+	t.Run("case=uses the error UI if no route matches", func(t *testing.T) {
+		res := get(t, "/self-service/forward", false)
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Contains(t, res.Header.Get("Location"), "https://example.com/error?error=")
+	})
+
+	t.Run("case=does not apply to other endpoints", func(t *testing.T) {
+		res := get(t, "/sessions/fail", true)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Equal(t, "a b", gjson.GetBytes(x.MustReadAll(res.Body), "error.reason").String())
+	})
+}
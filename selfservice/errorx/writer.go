@@ -0,0 +1,51 @@
+package errorx
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/x"
+)
+
+var _ herodot.Writer = new(RouteWriter)
+
+type (
+	routeWriterDependencies interface {
+		ManagementProvider
+	}
+
+	// RouteWriter writes errors of self-service flows as the error routes configured at
+	// `selfservice.flows.error.routes` tell. All other responses are written by the wrapped writer.
+	RouteWriter struct {
+		herodot.Writer
+		d routeWriterDependencies
+	}
+)
+
+func NewRouteWriter(d routeWriterDependencies, w herodot.Writer) *RouteWriter {
+	return &RouteWriter{Writer: w, d: d}
+}
+
+func (w *RouteWriter) WriteError(rw http.ResponseWriter, r *http.Request, err error, opts ...herodot.Option) {
+	if w.route(rw, r, x.RecoverStatusCode(err, http.StatusInternalServerError), err) {
+		return
+	}
+	w.Writer.WriteError(rw, r, err, opts...)
+}
+
+func (w *RouteWriter) WriteErrorCode(rw http.ResponseWriter, r *http.Request, code int, err error, opts ...herodot.Option) {
+	if w.route(rw, r, code, err) {
+		return
+	}
+	w.Writer.WriteErrorCode(rw, r, code, err, opts...)
+}
+
+func (w *RouteWriter) route(rw http.ResponseWriter, r *http.Request, code int, err error) bool {
+	// Error routes are meant for the end users of self-service flows and never apply to other endpoints.
+	if !strings.HasPrefix(r.URL.Path, "/self-service/") {
+		return false
+	}
+	return w.d.SelfServiceErrorManager().forwardToRoute(r.Context(), rw, r, code, err)
+}
@@ -0,0 +1,129 @@
+package callback
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlxx"
+	"github.com/ory/x/stringsx"
+
+	"github.com/ory/kratos/corp"
+)
+
+// QueryParameter is the query parameter of the verification and recovery flow initialization endpoints which
+// requests the flow's result to be sent to a URL.
+const QueryParameter = "callback_url"
+
+// The flows whose results can be sent to a callback URL.
+const (
+	FlowTypeVerification = "verification"
+	FlowTypeRecovery     = "recovery"
+)
+
+// Callback sends the result of a flow to the URL requested by the client which initialized the flow.
+type Callback struct {
+	ID  uuid.UUID `json:"id" db:"id"`
+	NID uuid.UUID `json:"-" db:"nid"`
+
+	// FlowID is the ID of the verification or recovery flow.
+	FlowID uuid.UUID `json:"flow_id" db:"flow_id"`
+
+	// FlowType is either `verification` or `recovery`.
+	FlowType string `json:"flow_type" db:"flow_type"`
+
+	// URL is the URL the result is sent to.
+	URL string `json:"url" db:"url"`
+
+	// Result is the payload sent to the URL. It is set once the flow completed.
+	Result sqlxx.NullJSONRawMessage `json:"result" db:"result"`
+
+	// ExpiresAt is the time after which the result is no longer sent.
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+
+	// CompletedAt is the time at which the flow completed.
+	CompletedAt sqlxx.NullTime `json:"completed_at" db:"completed_at"`
+
+	// DeliveredAt is the time at which the result was sent.
+	DeliveredAt sqlxx.NullTime `json:"delivered_at" db:"delivered_at"`
+
+	// CreatedAt is a helper struct field for gobuffalo.pop.
+	CreatedAt time.Time `json:"-" db:"created_at"`
+
+	// UpdatedAt is a helper struct field for gobuffalo.pop.
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+}
+
+// Result is sent to the callback URL once the flow completed. Changes to this struct must bump webhook.Version
+// and be reflected in the event schemas at webhook/.schema/events.
+type Result struct {
+	// Event is always `flow.completed`.
+	Event string `json:"event"`
+
+	// FlowID is the ID of the flow which completed. Results may be sent more than once, so receivers should
+	// ignore results of flows they already processed.
+	FlowID uuid.UUID `json:"flow_id"`
+
+	// FlowType is either `verification` or `recovery`.
+	FlowType string `json:"flow_type"`
+
+	// State is the flow's final state, for example `passed_challenge`.
+	State string `json:"state"`
+
+	// IdentityID is the ID of the verified or recovered identity.
+	IdentityID uuid.UUID `json:"identity_id"`
+
+	// Address is the verified address. It is empty for recovery flows.
+	Address string `json:"address,omitempty"`
+
+	// CompletedAt is the time at which the flow completed.
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+func (c Callback) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "selfservice_flow_callbacks")
+}
+
+func (c *Callback) GetID() uuid.UUID {
+	return c.ID
+}
+
+func (c *Callback) GetNID() uuid.UUID {
+	return c.NID
+}
+
+// RequestedURL returns the callback URL requested by the client initializing a flow, or nil if the client did
+// not request one. It returns an error if the URL is malformed or not allowed.
+func RequestedURL(r *http.Request, allowed []url.URL) (*url.URL, error) {
+	raw := r.URL.Query().Get(QueryParameter)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	if len(allowed) == 0 {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("Requesting a callback URL is not enabled."))
+	}
+
+	u, err := url.ParseRequestURI(raw)
+	if err != nil || !u.IsAbs() {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("The requested callback URL must be an absolute URL."))
+	}
+
+	for _, a := range allowed {
+		if strings.EqualFold(a.Scheme, u.Scheme) &&
+			strings.EqualFold(a.Host, u.Host) &&
+			strings.HasPrefix(stringsx.Coalesce(u.Path, "/"), stringsx.Coalesce(a.Path, "/")) {
+			return u, nil
+		}
+	}
+
+	return nil, errors.WithStack(herodot.ErrBadRequest.
+		WithReasonf("Requested callback URL \"%s\" is not allowed.", u).
+		WithDebugf("Allowed callback URLs are: %v", allowed))
+}
@@ -0,0 +1,142 @@
+package callback_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/flow/callback"
+	"github.com/ory/kratos/webhook"
+	"github.com/ory/kratos/x"
+)
+
+func TestRequestedURL(t *testing.T) {
+	allowed := []url.URL{*mustParseURL(t, "https://backend.ory.sh/callbacks")}
+
+	request := func(t *testing.T, callbackURL string) *http.Request {
+		r, err := http.NewRequest("GET", "/?"+url.Values{callback.QueryParameter: {callbackURL}}.Encode(), nil)
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("case=no callback was requested", func(t *testing.T) {
+		u, err := callback.RequestedURL(request(t, ""), nil)
+		require.NoError(t, err)
+		assert.Nil(t, u)
+	})
+
+	t.Run("case=callbacks are disabled", func(t *testing.T) {
+		_, err := callback.RequestedURL(request(t, "https://backend.ory.sh/callbacks"), nil)
+		require.Error(t, err)
+	})
+
+	t.Run("case=uses allowed URLs", func(t *testing.T) {
+		for _, v := range []string{"https://backend.ory.sh/callbacks", "https://BACKEND.ory.sh/callbacks/verification?id=1"} {
+			u, err := callback.RequestedURL(request(t, v), allowed)
+			require.NoError(t, err, v)
+			assert.Equal(t, v, u.String())
+		}
+	})
+
+	t.Run("case=rejects other URLs", func(t *testing.T) {
+		for _, v := range []string{"http://backend.ory.sh/callbacks", "https://evil.ory.sh/callbacks", "https://backend.ory.sh/other", "/callbacks", "not a url"} {
+			_, err := callback.RequestedURL(request(t, v), allowed)
+			require.Error(t, err, v)
+		}
+	})
+}
+
+func TestNotifier(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyWebhookMaxRetries, 0)
+
+	var lock sync.Mutex
+	var received [][]byte
+	fail := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, body)
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(ts.Close)
+
+	receivedCount := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received)
+	}
+
+	n := reg.FlowCallbackNotifier()
+	flowID := x.NewUUID()
+	require.NoError(t, n.Register(ctx, callback.FlowTypeVerification, flowID, time.Now().Add(time.Hour), mustParseURL(t, ts.URL+"/callbacks")))
+
+	t.Run("case=does nothing for flows without callback", func(t *testing.T) {
+		require.NoError(t, n.Register(ctx, callback.FlowTypeVerification, x.NewUUID(), time.Now().Add(time.Hour), nil))
+		n.Complete(ctx, &callback.Result{FlowID: x.NewUUID(), FlowType: callback.FlowTypeVerification})
+		assert.Equal(t, 0, receivedCount())
+	})
+
+	t.Run("case=sends the result and retries failures", func(t *testing.T) {
+		identityID := x.NewUUID()
+		n.Complete(ctx, &callback.Result{
+			FlowID:     flowID,
+			FlowType:   callback.FlowTypeVerification,
+			State:      "passed_challenge",
+			IdentityID: identityID,
+			Address:    "foo@ory.sh",
+		})
+		assert.Eventually(t, func() bool { return receivedCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+		lock.Lock()
+		fail = false
+		lock.Unlock()
+
+		delivered, err := n.Retry(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		require.Equal(t, 2, receivedCount())
+
+		body := received[1]
+		assert.Equal(t, received[0], body)
+		require.NoError(t, webhook.ValidateEventPayload(webhook.EventFlowCompleted, body), "%s", body)
+		assert.Equal(t, flowID.String(), gjson.GetBytes(body, "flow_id").String())
+		assert.Equal(t, identityID.String(), gjson.GetBytes(body, "identity_id").String())
+		assert.Equal(t, "foo@ory.sh", gjson.GetBytes(body, "address").String())
+
+		delivered, err = n.Retry(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+	})
+
+	t.Run("case=results are sent only once", func(t *testing.T) {
+		n.Complete(ctx, &callback.Result{FlowID: flowID, FlowType: callback.FlowTypeVerification})
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 2, receivedCount())
+	})
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
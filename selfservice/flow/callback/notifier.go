@@ -0,0 +1,129 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/webhook"
+	"github.com/ory/kratos/x"
+)
+
+const retryBatchSize = 100
+
+type (
+	notifierDependencies interface {
+		config.Provider
+		webhook.ClientProvider
+		x.ClockProvider
+		x.LoggingProvider
+
+		PersistenceProvider
+	}
+
+	NotifierProvider interface {
+		FlowCallbackNotifier() *Notifier
+	}
+
+	// Notifier sends the results of verification and recovery flows to the callback URLs requested by the
+	// clients which initialized them.
+	Notifier struct {
+		d notifierDependencies
+	}
+)
+
+func NewNotifier(d notifierDependencies) *Notifier {
+	return &Notifier{d: d}
+}
+
+// Register stores the callback URL of a flow. It does nothing if u is nil.
+func (n *Notifier) Register(ctx context.Context, flowType string, flowID uuid.UUID, flowExpiresAt time.Time, u *url.URL) error {
+	if u == nil {
+		return nil
+	}
+
+	return n.d.FlowCallbackPersister().CreateFlowCallback(ctx, &Callback{
+		ID:        x.NewUUID(),
+		FlowID:    flowID,
+		FlowType:  flowType,
+		URL:       u.String(),
+		ExpiresAt: flowExpiresAt.Add(n.d.Config(ctx).SelfServiceFlowCallbacksRetryLifespan()).UTC(),
+	})
+}
+
+// Complete stores the result of a flow and sends it to the flow's callback URL in the background. It does
+// nothing if the flow has no callback. Failures are logged but do not interrupt the flow, and results which
+// could not be delivered are sent again by Retry.
+func (n *Notifier) Complete(ctx context.Context, result *Result) {
+	result.Event = webhook.EventFlowCompleted
+	result.CompletedAt = n.d.Clock().Now().UTC()
+
+	l := n.d.Logger().WithField("flow_id", result.FlowID)
+	c, err := n.complete(ctx, result)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return
+	} else if err != nil {
+		l.WithError(err).Error("Unable to store the result of the flow for its callback.")
+		return
+	}
+
+	// The request context is canceled once the response was written.
+	go func() {
+		if err := n.deliver(context.Background(), c); err != nil {
+			l.WithError(err).WithField("callback_id", c.ID).Warn("Unable to send the result of the flow to its callback URL. It will be retried.")
+		}
+	}()
+}
+
+func (n *Notifier) complete(ctx context.Context, result *Result) (*Callback, error) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return n.d.FlowCallbackPersister().CompleteFlowCallback(ctx, result.FlowID, body, result.CompletedAt)
+}
+
+// Retry sends all results which could not be delivered before and removes expired callbacks. It returns how
+// many results were delivered.
+func (n *Notifier) Retry(ctx context.Context) (int, error) {
+	now := n.d.Clock().Now().UTC()
+	if _, err := n.d.FlowCallbackPersister().DeleteExpiredFlowCallbacks(ctx, now); err != nil {
+		return 0, err
+	}
+
+	callbacks, err := n.d.FlowCallbackPersister().ListUndeliveredFlowCallbacks(ctx, now, retryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int
+	for k := range callbacks {
+		if err := n.deliver(ctx, &callbacks[k]); err != nil {
+			n.d.Logger().
+				WithError(err).
+				WithField("callback_id", callbacks[k].ID).
+				WithField("flow_id", callbacks[k].FlowID).
+				Warn("Unable to send the result of the flow to its callback URL. It will be retried.")
+			continue
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+func (n *Notifier) deliver(ctx context.Context, c *Callback) error {
+	if err := n.d.WebhookClient().Send(ctx, "POST", c.URL, c.Result); err != nil {
+		return err
+	}
+
+	return n.d.FlowCallbackPersister().MarkFlowCallbackDelivered(ctx, c.ID, n.d.Clock().Now().UTC())
+}
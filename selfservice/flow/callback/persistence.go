@@ -0,0 +1,28 @@
+package callback
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type PersistenceProvider interface {
+	FlowCallbackPersister() Persister
+}
+
+type Persister interface {
+	CreateFlowCallback(ctx context.Context, c *Callback) error
+
+	// CompleteFlowCallback stores the result of the flow's callback and returns the callback. It returns
+	// sqlcon.ErrNoRows if the flow has no callback, or if it expired or was already completed.
+	CompleteFlowCallback(ctx context.Context, flowID uuid.UUID, result []byte, completedAt time.Time) (*Callback, error)
+
+	MarkFlowCallbackDelivered(ctx context.Context, id uuid.UUID, deliveredAt time.Time) error
+
+	// ListUndeliveredFlowCallbacks returns completed callbacks which were not delivered and did not expire,
+	// oldest first.
+	ListUndeliveredFlowCallbacks(ctx context.Context, now time.Time, limit int) ([]Callback, error)
+
+	DeleteExpiredFlowCallbacks(ctx context.Context, expiresBefore time.Time) (int, error)
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/selfservice/flow/callback"
+	"github.com/ory/kratos/x"
+)
+
+func TestPersister(ctx context.Context, p persistence.Persister) func(t *testing.T) {
+	var newCallback = func(expiresIn time.Duration) *callback.Callback {
+		return &callback.Callback{
+			ID:        x.NewUUID(),
+			FlowID:    x.NewUUID(),
+			FlowType:  callback.FlowTypeVerification,
+			URL:       "https://backend.ory.sh/callbacks",
+			ExpiresAt: time.Now().Add(expiresIn).UTC().Truncate(time.Second),
+		}
+	}
+
+	return func(t *testing.T) {
+		_, p := testhelpers.NewNetworkUnlessExisting(t, ctx, p)
+		now := time.Now().UTC().Truncate(time.Second)
+
+		t.Run("case=is completed only once", func(t *testing.T) {
+			expected := newCallback(time.Hour)
+			require.NoError(t, p.CreateFlowCallback(ctx, expected))
+
+			actual, err := p.CompleteFlowCallback(ctx, expected.FlowID, []byte(`{"state":"passed_challenge"}`), now)
+			require.NoError(t, err)
+			assert.Equal(t, expected.ID, actual.ID)
+			assert.JSONEq(t, `{"state":"passed_challenge"}`, string(actual.Result))
+			assert.False(t, time.Time(actual.CompletedAt).IsZero())
+
+			_, err = p.CompleteFlowCallback(ctx, expected.FlowID, []byte(`{}`), now)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			_, err = p.CompleteFlowCallback(ctx, x.NewUUID(), []byte(`{}`), now)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=lists undelivered callbacks", func(t *testing.T) {
+			pending := newCallback(time.Hour)
+			delivered := newCallback(time.Hour)
+			incomplete := newCallback(time.Hour)
+			for _, c := range []*callback.Callback{pending, delivered, incomplete} {
+				require.NoError(t, p.CreateFlowCallback(ctx, c))
+			}
+			for _, c := range []*callback.Callback{pending, delivered} {
+				_, err := p.CompleteFlowCallback(ctx, c.FlowID, []byte(`{}`), now)
+				require.NoError(t, err)
+			}
+			require.NoError(t, p.MarkFlowCallbackDelivered(ctx, delivered.ID, now))
+
+			actual, err := p.ListUndeliveredFlowCallbacks(ctx, now, 100)
+			require.NoError(t, err)
+			var ids []string
+			for _, c := range actual {
+				ids = append(ids, c.ID.String())
+			}
+			assert.Contains(t, ids, pending.ID.String())
+			assert.NotContains(t, ids, delivered.ID.String())
+			assert.NotContains(t, ids, incomplete.ID.String())
+		})
+
+		t.Run("case=expires", func(t *testing.T) {
+			expired := newCallback(-time.Minute)
+			require.NoError(t, p.CreateFlowCallback(ctx, expired))
+
+			_, err := p.CompleteFlowCallback(ctx, expired.FlowID, []byte(`{}`), now)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			count, err := p.DeleteExpiredFlowCallbacks(ctx, now)
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package flow
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// LifespanQueryParameter is the query parameter of the flow initialization endpoints which requests a lifespan
+// other than the configured one, for example `24h`.
+const LifespanQueryParameter = "lifespan"
+
+// RequestedLifespan returns the lifespan requested by the client initializing a flow, or fallback if the client
+// did not request one. It returns an error if the requested lifespan is malformed or outside the bounds.
+func RequestedLifespan(r *http.Request, fallback time.Duration, bounds config.LifespanBounds) (time.Duration, error) {
+	raw := r.URL.Query().Get(LifespanQueryParameter)
+	if len(raw) == 0 {
+		return fallback, nil
+	}
+
+	if bounds.Max <= 0 {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithReason("Requesting a lifespan is not enabled for this flow."))
+	}
+
+	lifespan, err := time.ParseDuration(raw)
+	if err != nil || lifespan < bounds.Min || lifespan > bounds.Max || lifespan <= 0 {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The requested lifespan must be a duration between %s and %s, for example %s.", bounds.Min, bounds.Max, bounds.Max))
+	}
+
+	return lifespan, nil
+}
@@ -0,0 +1,49 @@
+package flow
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestRequestedLifespan(t *testing.T) {
+	bounds := config.LifespanBounds{Min: time.Minute * 5, Max: time.Hour * 24}
+
+	request := func(t *testing.T, lifespan string) *http.Request {
+		r, err := http.NewRequest("GET", "/?"+LifespanQueryParameter+"="+lifespan, nil)
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("case=falls back to the configured lifespan", func(t *testing.T) {
+		lifespan, err := RequestedLifespan(request(t, ""), time.Hour, config.LifespanBounds{})
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, lifespan)
+	})
+
+	t.Run("case=uses the requested lifespan", func(t *testing.T) {
+		for _, v := range []string{"5m", "12h", "24h"} {
+			lifespan, err := RequestedLifespan(request(t, v), time.Hour, bounds)
+			require.NoError(t, err)
+			expected, _ := time.ParseDuration(v)
+			assert.Equal(t, expected, lifespan)
+		}
+	})
+
+	t.Run("case=rejects lifespans outside of the bounds", func(t *testing.T) {
+		for _, v := range []string{"1m", "25h", "-1h", "foo"} {
+			_, err := RequestedLifespan(request(t, v), time.Hour, bounds)
+			require.Error(t, err, v)
+		}
+	})
+
+	t.Run("case=rejects lifespans if no bounds are configured", func(t *testing.T) {
+		_, err := RequestedLifespan(request(t, "1h"), time.Hour, config.LifespanBounds{})
+		require.Error(t, err)
+	})
+}
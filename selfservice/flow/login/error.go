@@ -30,6 +30,7 @@ type (
 	errorHandlerDependencies interface {
 		errorx.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
 		x.LoggingProvider
 		config.Provider
 
@@ -60,6 +61,9 @@ func NewFlowExpiredError(at time.Time) *FlowExpiredError {
 	}
 }
 
+// FlowExpired marks the error as an expired flow for the error routes at `selfservice.flows.error.routes`.
+func (e *FlowExpiredError) FlowExpired() {}
+
 func NewFlowErrorHandler(d errorHandlerDependencies) *ErrorHandler {
 	return &ErrorHandler{d: d}
 }
@@ -106,7 +110,7 @@ func (s *ErrorHandler) WriteFlowError(w http.ResponseWriter, r *http.Request, f
 		return
 	}
 
-	if err := sortNodes(f.UI.Nodes); err != nil {
+	if err := SortNodes(f.UI.Nodes); err != nil {
 		s.forward(w, r, f, err)
 		return
 	}
@@ -82,10 +82,14 @@ type Flow struct {
 
 	// Forced stores whether this login flow should enforce re-authentication.
 	Forced bool `json:"forced" db:"forced"`
+
+	// SIWENonce is the nonce which the next message signed with an Ethereum wallet has to contain. It is read-only
+	// because only the persister may change it, see FlowPersister.UseLoginFlowSIWENonce.
+	SIWENonce string `json:"-" faker:"-" db:"siwe_nonce" rw:"r"`
 }
 
-func NewFlow(conf *config.Config, exp time.Duration, csrf string, r *http.Request, flowType flow.Type) *Flow {
-	now := time.Now().UTC()
+func NewFlow(conf *config.Config, now time.Time, exp time.Duration, csrf string, r *http.Request, flowType flow.Type) *Flow {
+	now = now.UTC()
 	id := x.NewUUID()
 	return &Flow{
 		ID:        id,
@@ -118,8 +122,8 @@ func (f Flow) WhereID(ctx context.Context, alias string) string {
 	return fmt.Sprintf("%s.%s = ? AND %s.%s = ?", alias, "id", alias, "nid")
 }
 
-func (f *Flow) Valid() error {
-	if f.ExpiresAt.Before(time.Now()) {
+func (f *Flow) Valid(now time.Time) error {
+	if f.ExpiresAt.Before(now) {
 		return errors.WithStack(NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
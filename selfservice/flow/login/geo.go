@@ -0,0 +1,202 @@
+package login
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"crypto/subtle"
+	_ "embed"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/randx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/attempt"
+	"github.com/ory/kratos/courier"
+	templates "github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/geo"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+)
+
+// GeoCodeNode is the name of the input which is added to login flows once a code was sent to confirm a login
+// the `require_mfa` geo policy action applies to.
+const GeoCodeNode = "geo_code"
+
+//go:embed .schema/geo_code.schema.json
+var geoCodeSchema []byte
+
+type geoCodeConfirmedContextKey struct{}
+
+// enforceGeoPolicy applies the geo policy to the login. If the login is blocked or has to be confirmed with a
+// code, the flow is written to the response and true is returned.
+func (e *HookExecutor) enforceGeoPolicy(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity) (*geo.Decision, bool, error) {
+	d, err := e.d.GeoPolicy().Evaluate(r, i)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if d.Action == config.GeoPolicyActionRequireMFA && r.Context().Value(geoCodeConfirmedContextKey{}) == nil {
+		if address := geo.VerifiedEmailAddress(i); address != nil {
+			return d, true, e.sendGeoCode(w, r, ct, a, i, d, address.Value)
+		}
+
+		// The identity can not receive a code, so the login is blocked instead of letting it through unconfirmed.
+		d.Action = config.GeoPolicyActionBlock
+	}
+
+	if d.Action == config.GeoPolicyActionBlock {
+		e.d.AttemptManager().RecordFailure(r, attempt.FlowLogin, a.ID, ct)
+		e.d.Audit().
+			WithRequest(r).
+			WithField("identity_id", i.ID).
+			WithField("country", d.Country).
+			WithField("reason", d.Reason).
+			Info("Blocked a login because of a geo policy.")
+		e.d.LoginFlowErrorHandler().WriteFlowError(w, r, a, node.DefaultGroup, schema.NewLoginCountryBlockedError())
+		return d, true, nil
+	}
+
+	return d, false, nil
+}
+
+func (e *HookExecutor) sendGeoCode(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity, d *geo.Decision, address string) error {
+	ctx := r.Context()
+	conf := e.d.Config(ctx)
+	lifespan, _ := conf.GeoPoliciesCode()
+
+	code := randx.MustString(6, randx.Numeric)
+	if err := e.d.GeoPersister().CreateLoginChallenge(ctx, &geo.Challenge{
+		FlowID:     a.ID,
+		IdentityID: i.ID,
+		Method:     string(ct),
+		Country:    d.Country,
+		CodeHMAC:   geoCodeHMAC(conf.SecretsDefault()[0], code),
+		ExpiresAt:  e.d.Clock().Now().UTC().Add(lifespan),
+	}); err != nil {
+		return err
+	}
+
+	ctx, delivery := courier.WithFlowDelivery(ctx)
+	if _, err := e.d.Courier(ctx).QueueEmail(ctx, templates.NewLoginGeoCode(conf, &templates.LoginGeoCodeModel{
+		To:   address,
+		Code: code,
+	})); err != nil {
+		return err
+	}
+
+	a.UI.Nodes.Upsert(node.NewInputField(GeoCodeNode, nil, node.DefaultGroup, node.InputAttributeTypeText, node.WithRequiredInputAttribute).
+		WithMetaLabel(text.NewInfoNodeInputLoginCode()))
+
+	e.d.Audit().
+		WithRequest(r).
+		WithField("identity_id", i.ID).
+		WithField("country", d.Country).
+		WithField("reason", d.Reason).
+		Info("Sent a code to confirm a login because of a geo policy.")
+	e.d.LoginFlowErrorHandler().WriteFlowError(w, r, a, node.DefaultGroup, schema.NewLoginGeoCodeRequiredError(address, delivery.Delayed))
+	return nil
+}
+
+// ConfirmGeoCode completes the login if the request carries the code which was sent to confirm it. It returns
+// false if the request carries no code, in which case the strategies handle the request.
+func (e *HookExecutor) ConfirmGeoCode(w http.ResponseWriter, r *http.Request, f *Flow) (bool, error) {
+	code := geoCodeFromRequest(r)
+	if len(code) == 0 {
+		return false, nil
+	}
+
+	ctx := r.Context()
+	c, err := e.d.GeoPersister().GetLoginChallenge(ctx, f.ID)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return true, schema.NewLoginGeoCodeInvalidError()
+	} else if err != nil {
+		return true, err
+	}
+
+	conf := e.d.Config(ctx)
+	if !compareGeoCode(conf.SecretsDefault(), code, c.CodeHMAC) {
+		c.Attempts++
+		if _, maxAttempts := conf.GeoPoliciesCode(); c.Attempts >= maxAttempts {
+			// The code can not be guessed any more, so the identity has to sign in again.
+			err = e.d.GeoPersister().DeleteLoginChallenge(ctx, c.ID)
+		} else {
+			err = e.d.GeoPersister().UpdateLoginChallenge(ctx, c)
+		}
+		if err != nil {
+			return true, err
+		}
+		return true, schema.NewLoginGeoCodeInvalidError()
+	}
+
+	if err := e.d.GeoPersister().DeleteLoginChallenge(ctx, c.ID); err != nil {
+		return true, err
+	}
+
+	if !e.d.Clock().Now().UTC().Before(c.ExpiresAt) {
+		return true, schema.NewLoginGeoCodeInvalidError()
+	}
+
+	i, err := e.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, c.IdentityID)
+	if err != nil {
+		return true, err
+	}
+
+	f.UI.Nodes.Remove(GeoCodeNode)
+	return true, e.PostLoginHook(w, r.WithContext(context.WithValue(ctx, geoCodeConfirmedContextKey{}, true)), identity.CredentialsType(c.Method), f, i)
+}
+
+// geoCodeFromRequest returns the code the request carries in the `geo_code` field. The request body can still be
+// read afterwards.
+func geoCodeFromRequest(r *http.Request) string {
+	var p struct {
+		Code string `json:"geo_code" form:"geo_code"`
+	}
+
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(geoCodeSchema)
+	if err != nil {
+		return ""
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return ""
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	clone := r.Clone(r.Context())
+	clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err := decoderx.NewHTTP().Decode(clone, &p, compiler,
+		decoderx.HTTPDecoderAllowedMethods("POST", "PUT", "PATCH", "GET"),
+		decoderx.HTTPDecoderSetValidatePayloads(false),
+		decoderx.HTTPDecoderJSONFollowsFormFormat()); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(p.Code)
+}
+
+func geoCodeHMAC(secret []byte, code string) string {
+	h := hmac.New(sha512.New512_256, secret)
+	_, _ = h.Write([]byte(code))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func compareGeoCode(secrets [][]byte, code, expected string) bool {
+	for _, secret := range secrets {
+		if subtle.ConstantTimeCompare([]byte(geoCodeHMAC(secret, code)), []byte(expected)) == 1 {
+			return true
+		}
+	}
+	return false
+}
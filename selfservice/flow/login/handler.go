@@ -2,19 +2,23 @@ package login
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/ui/node"
 	"github.com/ory/x/decoderx"
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 
 	"github.com/ory/x/urlx"
 
+	"github.com/ory/kratos/attempt"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/feature"
+	"github.com/ory/kratos/reputation"
 	"github.com/ory/kratos/selfservice/errorx"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/session"
@@ -28,6 +32,8 @@ const (
 	RouteGetFlow = "/self-service/login/flows"
 
 	RouteSubmitFlow = "/self-service/login"
+
+	RouteSimulateFlow = "/self-service/login/simulations"
 )
 
 type (
@@ -39,10 +45,13 @@ type (
 		session.HandlerProvider
 		session.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
 		x.CSRFTokenGeneratorProvider
 		x.CSRFProvider
 		config.Provider
 		ErrorHandlerProvider
+		reputation.Provider
+		attempt.ManagementProvider
 	}
 	HandlerProvider interface {
 		LoginHandler() *Handler
@@ -71,18 +80,35 @@ func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
 
 func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 	admin.GET(RouteGetFlow, h.fetchFlow)
+	admin.POST(RouteSimulateFlow, h.simulateFlow)
 }
 
-func (h *Handler) NewLoginFlow(w http.ResponseWriter, r *http.Request, flow flow.Type) (*Flow, error) {
+func (h *Handler) NewLoginFlow(w http.ResponseWriter, r *http.Request, ft flow.Type) (*Flow, error) {
 	conf := h.d.Config(r.Context())
-	f := NewFlow(conf, conf.SelfServiceFlowLoginRequestLifespan(), h.d.GenerateCSRFToken(r), r, flow)
+	lifespan, err := flow.RequestedLifespan(r, conf.SelfServiceFlowLoginRequestLifespan(), conf.SelfServiceFlowLoginLifespanBounds())
+	if err != nil {
+		return nil, err
+	}
+
+	f := NewFlow(conf, h.d.Clock().Now(), lifespan, h.d.GenerateCSRFToken(r), r, ft)
+	r = r.WithContext(feature.WithFlow(r.Context(), f.ID))
 	for _, s := range h.d.LoginStrategies(r.Context()) {
 		if err := s.PopulateLoginMethod(r, f); err != nil {
 			return nil, err
 		}
 	}
+	h.d.IPReputation().AddFriction(r, &f.UI.Nodes)
+	addRememberMeNode(conf, f)
+
+	prefill, err := flow.PrefillFromRequest(r, h.d.Clock().Now(), conf.SecretsPrefill()...)
+	if err != nil {
+		return nil, err
+	}
+	if prefill != nil {
+		prefill.Apply(f.UI.Nodes)
+	}
 
-	if err := sortNodes(f.UI.Nodes); err != nil {
+	if err := SortNodes(f.UI.Nodes); err != nil {
 		return nil, err
 	}
 
@@ -107,6 +133,22 @@ type initializeSelfServiceBrowserLoginFlow struct {
 	//
 	// in: query
 	Refresh bool `json:"refresh"`
+
+	// Signed Prefill
+	//
+	// Values to prefill the form with, for example the identifier a landing page already knows. The prefill
+	// must be signed with a secret configured at `secrets.prefill`.
+	//
+	// in: query
+	Prefill string `json:"prefill"`
+
+	// Requested Lifespan
+	//
+	// A lifespan other than the configured one, for example `24h`. It must be within the bounds configured at
+	// `selfservice.flows.login.lifespan_bounds`.
+	//
+	// in: query
+	Lifespan string `json:"lifespan"`
 }
 
 // swagger:route GET /self-service/login/api public initializeSelfServiceLoginViaAPIFlow
@@ -254,7 +296,7 @@ func (h *Handler) fetchFlow(w http.ResponseWriter, r *http.Request, _ httprouter
 		return
 	}
 
-	if ar.ExpiresAt.Before(time.Now()) {
+	if ar.ExpiresAt.Before(h.d.Clock().Now()) {
 		if ar.Type == flow.TypeBrowser {
 			h.d.Writer().WriteError(w, r, errors.WithStack(x.ErrGone.
 				WithReason("The login flow has expired. Redirect the user to the login flow init endpoint to initialize a new login flow.").
@@ -337,6 +379,7 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, _ httproute
 		h.d.LoginFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
 		return
 	}
+	r = r.WithContext(feature.WithFlow(r.Context(), f.ID))
 
 	if _, err := h.d.SessionManager().FetchFromRequest(r.Context(), r); err == nil && !f.Forced {
 		if f.Type == flow.TypeBrowser {
@@ -348,11 +391,32 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, _ httproute
 		return
 	}
 
-	if err := f.Valid(); err != nil {
+	if err := f.Valid(h.d.Clock().Now()); err != nil {
 		h.d.LoginFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
 		return
 	}
 
+	if err := h.d.IPReputation().VerifySubmission(r, &f.UI.Nodes); err != nil {
+		h.d.LoginFlowErrorHandler().WriteFlowError(w, r, f, node.CaptchaGroup, err)
+		return
+	}
+
+	r = r.WithContext(attempt.WithIdentifier(r.Context(), attempt.IdentifierFromRequest(r)))
+
+	if rememberMeFromRequest(r, f) {
+		if err := h.d.LoginFlowPersister().UpdateLoginFlow(r.Context(), f); err != nil {
+			h.d.LoginFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
+			return
+		}
+	}
+
+	if confirmed, err := h.d.LoginHookExecutor().ConfirmGeoCode(w, r, f); err != nil {
+		h.d.LoginFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
+		return
+	} else if confirmed {
+		return
+	}
+
 	var i *identity.Identity
 	var s identity.CredentialsType
 	for _, ss := range h.d.AllLoginStrategies() {
@@ -362,6 +426,7 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, _ httproute
 		} else if errors.Is(err, flow.ErrCompletedByStrategy) {
 			return
 		} else if err != nil {
+			h.d.AttemptManager().RecordFailure(r, attempt.FlowLogin, f.ID, ss.ID())
 			h.d.LoginFlowErrorHandler().WriteFlowError(w, r, f, ss.NodeGroup(), err)
 			return
 		}
@@ -383,3 +448,66 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, _ httproute
 		return
 	}
 }
+
+// nolint:deadcode,unused
+// swagger:parameters simulateSelfServiceLoginFlow
+type simulateSelfServiceLoginFlow struct {
+	// in: body
+	// required: true
+	Body flow.SimulationBody
+}
+
+// swagger:route POST /self-service/login/simulations admin simulateSelfServiceLoginFlow
+//
+// Simulate a Login Flow Submission
+//
+// This endpoint checks how a login payload would be handled: whether the method is enabled, whether its strategy
+// would accept the payload and which identity it would sign in, and which hooks would run. Nothing is persisted
+// and no hooks are executed.
+//
+// Strategies which do not support simulations only report the hooks.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: selfServiceFlowSimulation
+//       400: genericError
+//       500: genericError
+func (h *Handler) simulateFlow(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var body flow.SimulationBody
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.d.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	s, err := h.d.AllLoginStrategies().Strategy(identity.CredentialsType(body.Method))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("No login strategy handles the method %q.", body.Method)))
+		return
+	}
+
+	conf := h.d.Config(r.Context())
+	sim := flow.NewSimulation(body.Method, conf.SelfServiceStrategy(body.Method).Enabled)
+	sim.Hooks.Before = flow.SimulationHookNames(conf.SelfServiceFlowLoginBeforeHooks())
+	sim.Hooks.After = flow.SimulationHookNames(conf.SelfServiceFlowLoginAfterHooks(body.Method))
+
+	if ss, ok := s.(SimulationStrategy); ok && sim.MethodEnabled {
+		i, err := ss.SimulateLogin(r.Context(), body.Payload)
+		if err := sim.Evaluate(s.NodeGroup(), err); err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+
+		if sim.Valid {
+			sim.IdentityID = &i.ID
+		}
+	}
+
+	h.d.Writer().Write(w, r, sim)
+}
@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
 
+	"github.com/ory/kratos-client-go"
 	"github.com/ory/x/assertx"
 
 	"github.com/ory/kratos/driver/config"
@@ -24,6 +27,7 @@ import (
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
 
@@ -209,3 +213,261 @@ func TestGetFlow(t *testing.T) {
 		run(t, public)
 	})
 }
+
+func TestSimulateFlow(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/password.schema.json")
+	testhelpers.StrategyEnable(t, conf, identity.CredentialsTypePassword.String(), true)
+	conf.MustSet(config.HookStrategyKey(config.ViperKeySelfServiceLoginAfter, identity.CredentialsTypePassword.String()),
+		[]config.SelfServiceHook{{Name: "revoke_active_sessions"}})
+	_, admin := testhelpers.NewKratosServerWithRouters(t, reg, x.NewRouterPublic(), x.NewRouterAdmin())
+
+	hpw, err := reg.Hasher().Generate(context.Background(), []byte("a-very-secret-password"))
+	require.NoError(t, err)
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"email":"simulate@ory.sh"}`)
+	i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+		Type:        identity.CredentialsTypePassword,
+		Identifiers: []string{"simulate@ory.sh"},
+		Config:      []byte(`{"hashed_password":"` + string(hpw) + `"}`),
+	})
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+
+	simulate := func(t *testing.T, body string, expectedStatus int) []byte {
+		res, err := admin.Client().Post(admin.URL+login.RouteSimulateFlow, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, expectedStatus, res.StatusCode, "%s", b)
+		return b
+	}
+
+	t.Run("case=valid payload", func(t *testing.T) {
+		body := simulate(t, `{"method":"password","payload":{"method":"password","password_identifier":"simulate@ory.sh","password":"a-very-secret-password"}}`, http.StatusOK)
+		assert.True(t, gjson.GetBytes(body, "simulated").Bool(), "%s", body)
+		assert.True(t, gjson.GetBytes(body, "valid").Bool(), "%s", body)
+		assert.Equal(t, i.ID.String(), gjson.GetBytes(body, "identity_id").String(), "%s", body)
+		assert.Equal(t, `["revoke_active_sessions"]`, gjson.GetBytes(body, "hooks.after").Raw, "%s", body)
+	})
+
+	t.Run("case=wrong password", func(t *testing.T) {
+		body := simulate(t, `{"method":"password","payload":{"method":"password","password_identifier":"simulate@ory.sh","password":"not-the-password"}}`, http.StatusOK)
+		assert.True(t, gjson.GetBytes(body, "simulated").Bool(), "%s", body)
+		assert.False(t, gjson.GetBytes(body, "valid").Bool(), "%s", body)
+		assert.False(t, gjson.GetBytes(body, "identity_id").Exists(), "%s", body)
+		assert.EqualValues(t, text.ErrorValidationInvalidCredentials, gjson.GetBytes(body, "messages.0.id").Int(), "%s", body)
+	})
+
+	t.Run("case=disabled method", func(t *testing.T) {
+		testhelpers.StrategyEnable(t, conf, identity.CredentialsTypePassword.String(), false)
+		t.Cleanup(func() {
+			testhelpers.StrategyEnable(t, conf, identity.CredentialsTypePassword.String(), true)
+		})
+
+		body := simulate(t, `{"method":"password","payload":{}}`, http.StatusOK)
+		assert.False(t, gjson.GetBytes(body, "method_enabled").Bool(), "%s", body)
+		assert.False(t, gjson.GetBytes(body, "simulated").Bool(), "%s", body)
+	})
+
+	t.Run("case=unknown method", func(t *testing.T) {
+		simulate(t, `{"method":"unknown","payload":{}}`, http.StatusBadRequest)
+	})
+}
+
+func TestRememberMe(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/password.schema.json")
+	testhelpers.StrategyEnable(t, conf, identity.CredentialsTypePassword.String(), true)
+	conf.MustSet(config.ViperKeySessionRememberMeEnabled, true)
+	conf.MustSet(config.ViperKeySessionRememberMePersistentLifespan, "720h")
+	conf.MustSet(config.ViperKeySessionRememberMeSessionLifespan, "1h")
+	_ = testhelpers.NewLoginUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewRedirSessionEchoTS(t, reg)
+	public, _ := testhelpers.NewKratosServerWithRouters(t, reg, x.NewRouterPublic(), x.NewRouterAdmin())
+
+	hpw, err := reg.Hasher().Generate(context.Background(), []byte("a-very-secret-password"))
+	require.NoError(t, err)
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"email":"remember@ory.sh"}`)
+	i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+		Type:        identity.CredentialsTypePassword,
+		Identifiers: []string{"remember@ory.sh"},
+		Config:      []byte(`{"hashed_password":"` + string(hpw) + `"}`),
+	})
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+
+	values := func(remember string) func(v url.Values) {
+		return func(v url.Values) {
+			v.Set("method", "password")
+			v.Set("password_identifier", "remember@ory.sh")
+			v.Set("password", "a-very-secret-password")
+			v.Set(login.RememberMeNode, remember)
+		}
+	}
+
+	t.Run("case=adds the checkbox", func(t *testing.T) {
+		f := testhelpers.InitializeLoginFlowViaAPI(t, new(http.Client), public, false)
+		var found bool
+		for _, n := range f.Ui.Nodes {
+			found = found || n.Attributes.UiNodeInputAttributes.Name == login.RememberMeNode
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("type=api", func(t *testing.T) {
+		for remember, lifespan := range map[string]time.Duration{"true": 720 * time.Hour, "false": time.Hour} {
+			t.Run("remember="+remember, func(t *testing.T) {
+				body := testhelpers.SubmitLoginForm(t, true, nil, public, values(remember), identity.CredentialsTypePassword, false, http.StatusOK, public.URL+login.RouteSubmitFlow)
+				assert.Equal(t, remember == "true", gjson.Get(body, "session.remember_me").Bool(), "%s", body)
+
+				expiresAt := gjson.Get(body, "session.expires_at").Time()
+				assert.WithinDuration(t, time.Now().Add(lifespan), expiresAt, time.Minute, "%s", body)
+			})
+		}
+	})
+
+	t.Run("type=browser", func(t *testing.T) {
+		for remember, persistent := range map[string]bool{"true": true, "false": false} {
+			t.Run("remember="+remember, func(t *testing.T) {
+				hc := testhelpers.NewClientWithCookies(t)
+				f := testhelpers.InitializeLoginFlowViaBrowser(t, hc, public, false)
+				hc.CheckRedirect = func(*http.Request, []*http.Request) error {
+					return http.ErrUseLastResponse
+				}
+
+				payload := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+				values(remember)(payload)
+				_, res := testhelpers.LoginMakeRequest(t, false, f, hc, payload.Encode())
+				require.Equal(t, http.StatusFound, res.StatusCode, "%s", res.Header.Get("Location"))
+
+				var cookie *http.Cookie
+				for _, c := range res.Cookies() {
+					if c.Name == config.DefaultSessionCookieName {
+						cookie = c
+					}
+				}
+				require.NotNil(t, cookie)
+				if persistent {
+					assert.InDelta(t, (720 * time.Hour).Seconds(), cookie.MaxAge, time.Minute.Seconds())
+				} else {
+					assert.Zero(t, cookie.MaxAge)
+					assert.True(t, cookie.Expires.IsZero())
+				}
+			})
+		}
+	})
+}
+
+type countryTransport string
+
+func (c countryTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r.Header.Set("CF-IPCountry", string(c))
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestGeoPolicies(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/password.schema.json")
+	testhelpers.StrategyEnable(t, conf, identity.CredentialsTypePassword.String(), true)
+	conf.MustSet(config.ViperKeyGeoPoliciesCountryHeader, "CF-IPCountry")
+	conf.MustSet(config.ViperKeyGeoPoliciesCodeMaxAttempts, 2)
+	conf.MustSet(config.ViperKeyGeoPoliciesRules, []map[string]interface{}{
+		{"countries": []string{"KP"}, "action": "block"},
+		{"countries": []string{"IR"}, "action": "require_mfa"},
+		{"country_changed": true, "action": "notify"},
+	})
+	_ = testhelpers.NewLoginUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewRedirSessionEchoTS(t, reg)
+	public, _ := testhelpers.NewKratosServerWithRouters(t, reg, x.NewRouterPublic(), x.NewRouterAdmin())
+
+	hpw, err := reg.Hasher().Generate(context.Background(), []byte("a-very-secret-password"))
+	require.NoError(t, err)
+	createIdentity := func(t *testing.T, verified bool) string {
+		email := x.NewUUID().String() + "@ory.sh"
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"email":"` + email + `"}`)
+		i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+			Type:        identity.CredentialsTypePassword,
+			Identifiers: []string{email},
+			Config:      []byte(`{"hashed_password":"` + string(hpw) + `"}`),
+		})
+		i.VerifiableAddresses = []identity.VerifiableAddress{{Value: email, Via: identity.VerifiableAddressTypeEmail, Verified: verified, Status: identity.VerifiableAddressStatusCompleted}}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+		return email
+	}
+
+	submit := func(t *testing.T, hc *http.Client, f *kratos.LoginFlow, payload string, expectCode int) string {
+		body, res := testhelpers.LoginMakeRequest(t, true, f, hc, payload)
+		require.Equal(t, expectCode, res.StatusCode, "%s", body)
+		return body
+	}
+
+	password := func(email string) string {
+		return `{"method":"password","password_identifier":"` + email + `","password":"a-very-secret-password"}`
+	}
+
+	t.Run("case=blocks the login", func(t *testing.T) {
+		hc := &http.Client{Transport: countryTransport("KP")}
+		f := testhelpers.InitializeLoginFlowViaAPI(t, hc, public, false)
+
+		body := submit(t, hc, f, password(createIdentity(t, true)), http.StatusBadRequest)
+		assert.EqualValues(t, text.ErrorValidationLoginCountryBlocked, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+		assert.False(t, gjson.Get(body, "session").Exists(), "%s", body)
+	})
+
+	t.Run("case=requires a code", func(t *testing.T) {
+		hc := &http.Client{Transport: countryTransport("IR")}
+		email := createIdentity(t, true)
+
+		start := func(t *testing.T) (*kratos.LoginFlow, string) {
+			f := testhelpers.InitializeLoginFlowViaAPI(t, hc, public, false)
+			body := submit(t, hc, f, password(email), http.StatusBadRequest)
+			assert.EqualValues(t, text.InfoSelfServiceLoginGeoCodeSent, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+			assert.Equal(t, login.GeoCodeNode, gjson.Get(body, "ui.nodes.#(attributes.name==geo_code).attributes.name").String(), "%s", body)
+
+			message := testhelpers.CourierExpectMessage(t, reg, email, "Confirm your sign in")
+			code := regexp.MustCompile(`\d{6}`).FindString(message.Body)
+			require.NotEmpty(t, code)
+			return f, code
+		}
+
+		t.Run("case=signs in with the code", func(t *testing.T) {
+			f, code := start(t)
+
+			body := submit(t, hc, f, `{"geo_code":"`+code+`"}`, http.StatusOK)
+			assert.Equal(t, email, gjson.Get(body, "session.identity.traits.email").String(), "%s", body)
+
+			body = submit(t, hc, f, `{"geo_code":"`+code+`"}`, http.StatusBadRequest)
+			assert.EqualValues(t, text.ErrorValidationLoginGeoCodeInvalid, gjson.Get(body, "ui.nodes.#(attributes.name==geo_code).messages.0.id").Int(), "%s", body)
+		})
+
+		t.Run("case=invalidates the code after too many attempts", func(t *testing.T) {
+			f, code := start(t)
+
+			for k := 0; k < 2; k++ {
+				body := submit(t, hc, f, `{"geo_code":"not-the-code"}`, http.StatusBadRequest)
+				assert.EqualValues(t, text.ErrorValidationLoginGeoCodeInvalid, gjson.Get(body, "ui.nodes.#(attributes.name==geo_code).messages.0.id").Int(), "%s", body)
+			}
+			submit(t, hc, f, `{"geo_code":"`+code+`"}`, http.StatusBadRequest)
+		})
+
+		t.Run("case=blocks identities without a verified email address", func(t *testing.T) {
+			f := testhelpers.InitializeLoginFlowViaAPI(t, hc, public, false)
+			body := submit(t, hc, f, password(createIdentity(t, false)), http.StatusBadRequest)
+			assert.EqualValues(t, text.ErrorValidationLoginCountryBlocked, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+		})
+	})
+
+	t.Run("case=notifies about logins from another country", func(t *testing.T) {
+		email := createIdentity(t, true)
+		for _, country := range []string{"DE", "FR"} {
+			hc := &http.Client{Transport: countryTransport(country)}
+			f := testhelpers.InitializeLoginFlowViaAPI(t, hc, public, false)
+			submit(t, hc, f, password(email), http.StatusOK)
+		}
+
+		message := testhelpers.CourierExpectMessage(t, reg, email, "New sign in to your account")
+		assert.Contains(t, message.Body, "FR")
+	})
+}
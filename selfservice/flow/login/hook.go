@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/ory/kratos/attempt"
+	"github.com/ory/kratos/courier"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/feature"
+	"github.com/ory/kratos/geo"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/session"
@@ -37,8 +40,15 @@ type (
 		session.PersistenceProvider
 		x.WriterProvider
 		x.LoggingProvider
+		x.ClockProvider
+		attempt.ManagementProvider
+		identity.PrivilegedPoolProvider
+		courier.Provider
+		geo.EnforcerProvider
+		geo.PersistenceProvider
 
 		HooksProvider
+		ErrorHandlerProvider
 	}
 	HookExecutor struct {
 		d executorDependencies
@@ -61,7 +71,32 @@ func NewHookExecutor(d executorDependencies) *HookExecutor {
 }
 
 func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity) error {
-	s := session.NewActiveSession(i, e.d.Config(r.Context()), time.Now().UTC()).Declassify()
+	if identity.IsServiceAccount(e.d.Config(r.Context()), i) {
+		e.d.AttemptManager().RecordFailure(r, attempt.FlowLogin, a.ID, ct)
+		return errors.WithStack(identity.ErrServiceAccountSelfService)
+	}
+
+	if !i.IsActive() {
+		e.d.AttemptManager().RecordFailure(r, attempt.FlowLogin, a.ID, ct)
+		return errors.WithStack(identity.ErrIdentityInactive)
+	}
+
+	decision, handled, err := e.enforceGeoPolicy(w, r, ct, a, i)
+	if err != nil {
+		return err
+	} else if handled {
+		return nil
+	}
+	e.d.AttemptManager().RecordSuccess(r, attempt.FlowLogin, a.ID, ct, i)
+
+	r = r.WithContext(feature.WithIdentity(feature.WithFlow(r.Context(), a.ID), i.ID))
+	s := session.NewActiveSession(i, e.d.Config(r.Context()), e.d.Clock().Now().UTC())
+	s.UpstreamSession = session.UpstreamSessionFromContext(r.Context())
+	applyRememberMe(e.d.Config(r.Context()), a, i, s)
+	if ct == identity.CredentialsTypePassword && i.PasswordExpired() {
+		s.Scopes = append(s.Scopes, session.ScopePasswordReset)
+	}
+	s = s.Declassify()
 
 	e.d.Logger().
 		WithRequest(r).
@@ -98,10 +133,12 @@ func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct
 		if err := e.d.SessionPersister().CreateSession(r.Context(), s); err != nil {
 			return errors.WithStack(err)
 		}
+		e.d.GeoPolicy().Succeeded(r.Context(), i, decision)
 		e.d.Audit().
 			WithRequest(r).
 			WithField("session_id", s.ID).
 			WithField("identity_id", i.ID).
+			WithField("remember_me", s.RememberMe).
 			Info("Identity authenticated successfully and was issued an ORY Kratos Session Token.")
 
 		e.d.Writer().Write(w, r, &APIFlowResponse{Session: s, Token: s.Token})
@@ -111,17 +148,28 @@ func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct
 	if err := e.d.SessionManager().CreateAndIssueCookie(r.Context(), w, r, s); err != nil {
 		return errors.WithStack(err)
 	}
+	e.d.GeoPolicy().Succeeded(r.Context(), i, decision)
 
 	e.d.Audit().
 		WithRequest(r).
 		WithField("identity_id", i.ID).
 		WithField("session_id", s.ID).
+		WithField("remember_me", s.RememberMe).
 		Info("Identity authenticated successfully and was issued an ORY Kratos Session Cookie.")
+	if s.RequiresPasswordReset() {
+		// The session can only be used to set a new password. Using the settings UI as the source URL
+		// ignores the flow's return_to parameter.
+		settingsUI := e.d.Config(r.Context()).SelfServiceFlowSettingsUI()
+		return x.SecureContentNegotiationRedirection(w, r, s.Declassify(), settingsUI.String(),
+			e.d.Writer(), e.d.Config(r.Context()), x.SecureRedirectOverrideDefaultReturnTo(settingsUI))
+	}
+
 	return x.SecureContentNegotiationRedirection(w, r, s.Declassify(), a.RequestURL,
 		e.d.Writer(), e.d.Config(r.Context()), x.SecureRedirectOverrideDefaultReturnTo(e.d.Config(r.Context()).SelfServiceFlowLoginReturnTo(ct.String())))
 }
 
 func (e *HookExecutor) PreLoginHook(w http.ResponseWriter, r *http.Request, a *Flow) error {
+	r = r.WithContext(feature.WithFlow(r.Context(), a.ID))
 	for _, executor := range e.d.PreLoginHooks(r.Context()) {
 		if err := executor.ExecuteLoginPreHook(w, r, a); err != nil {
 			return err
@@ -12,6 +12,11 @@ type (
 		CreateLoginFlow(context.Context, *Flow) error
 		GetLoginFlow(context.Context, uuid.UUID) (*Flow, error)
 		ForceLoginFlow(ctx context.Context, id uuid.UUID) error
+
+		// UseLoginFlowSIWENonce replaces the flow's SIWE nonce with next if it equals nonce. It returns
+		// sqlcon.ErrNoRows if it does not, so that each nonce can only be used once, even if it is submitted
+		// concurrently.
+		UseLoginFlowSIWENonce(ctx context.Context, id uuid.UUID, nonce, next string) error
 	}
 	FlowPersistenceProvider interface {
 		LoginFlowPersister() FlowPersister
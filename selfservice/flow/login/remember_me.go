@@ -0,0 +1,100 @@
+package login
+
+import (
+	"bytes"
+	_ "embed"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/ory/x/decoderx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+)
+
+// RememberMeNode is the name of the checkbox which is added to login flows if `session.remember_me` is enabled.
+const RememberMeNode = "remember"
+
+//go:embed .schema/remember_me.schema.json
+var rememberMeSchema []byte
+
+func addRememberMeNode(conf *config.Config, f *Flow) {
+	if !conf.SessionRememberMeEnabled() {
+		return
+	}
+
+	f.UI.Nodes.Upsert(node.NewInputField(RememberMeNode, false, node.DefaultGroup, node.InputAttributeTypeCheckbox).
+		WithMetaLabel(text.NewInfoNodeInputRememberMe()))
+}
+
+// rememberMeFromRequest copies the `remember` field of the request to the flow's checkbox, so that it is known
+// once the flow completes, even if that happens in a later request such as an OpenID Connect callback. It
+// returns true if the value changed. The request body can still be read afterwards.
+func rememberMeFromRequest(r *http.Request, f *Flow) bool {
+	n := f.UI.Nodes.Find(RememberMeNode)
+	if n == nil {
+		return false
+	}
+
+	var p struct {
+		Remember bool `json:"remember" form:"remember"`
+	}
+
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(rememberMeSchema)
+	if err != nil {
+		return false
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return false
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// The clone is decoded so that a malformed form is not cached in the request and left to the strategies
+	// to report.
+	clone := r.Clone(r.Context())
+	clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err := decoderx.NewHTTP().Decode(clone, &p, compiler,
+		decoderx.HTTPDecoderAllowedMethods("POST", "PUT", "PATCH", "GET"),
+		decoderx.HTTPDecoderSetValidatePayloads(false),
+		decoderx.HTTPDecoderJSONFollowsFormFormat()); err != nil {
+		return false
+	}
+
+	if f.RememberMe() == p.Remember {
+		return false
+	}
+	return f.UI.Nodes.SetValueAttribute(RememberMeNode, p.Remember)
+}
+
+// RememberMe returns true if the identity checked `remember`.
+func (f *Flow) RememberMe() bool {
+	n := f.UI.Nodes.Find(RememberMeNode)
+	if n == nil {
+		return false
+	}
+
+	remember, _ := n.Attributes.GetValue().(bool)
+	return remember
+}
+
+// applyRememberMe sets the session's lifespan according to the identity's choice and the remember me policy of
+// its schema.
+func applyRememberMe(conf *config.Config, f *Flow, i *identity.Identity, s *session.Session) {
+	if !conf.SessionRememberMeEnabled() {
+		return
+	}
+
+	policy := conf.SessionRememberMe(i.SchemaID)
+	s.RememberMe = policy.Enabled && f.RememberMe()
+
+	lifespan := policy.SessionLifespan
+	if s.RememberMe {
+		lifespan = policy.PersistentLifespan
+	}
+	s.ExpiresAt = s.AuthenticatedAt.Add(lifespan)
+}
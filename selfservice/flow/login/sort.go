@@ -2,7 +2,7 @@ package login
 
 import "github.com/ory/kratos/ui/node"
 
-func sortNodes(n node.Nodes) error {
+func SortNodes(n node.Nodes) error {
 	return n.SortBySchema(
 		node.SortByGroups([]node.Group{
 			node.DefaultGroup,
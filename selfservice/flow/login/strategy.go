@@ -2,6 +2,7 @@ package login
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 
 	"github.com/pkg/errors"
@@ -19,6 +20,18 @@ type Strategy interface {
 	Login(w http.ResponseWriter, r *http.Request, f *Flow) (i *identity.Identity, err error)
 }
 
+// SimulationStrategy is implemented by strategies which can check a login payload without side effects.
+// SimulateLogin returns the identity the payload would sign in.
+type SimulationStrategy interface {
+	Strategy
+	SimulateLogin(ctx context.Context, payload json.RawMessage) (*identity.Identity, error)
+}
+
+// AdminHandler is implemented by strategies which serve routes on the admin API.
+type AdminHandler interface {
+	RegisterAdminLoginRoutes(admin *x.RouterAdmin)
+}
+
 type Strategies []Strategy
 
 func (s Strategies) Strategy(id identity.CredentialsType) (Strategy, error) {
@@ -47,6 +60,14 @@ func (s Strategies) RegisterPublicRoutes(r *x.RouterPublic) {
 	}
 }
 
+func (s Strategies) RegisterAdminRoutes(r *x.RouterAdmin) {
+	for _, ss := range s {
+		if h, ok := ss.(AdminHandler); ok {
+			h.RegisterAdminLoginRoutes(r)
+		}
+	}
+}
+
 type StrategyProvider interface {
 	AllLoginStrategies() Strategies
 	LoginStrategies(ctx context.Context) Strategies
@@ -111,6 +111,32 @@ func TestFlowPersister(ctx context.Context, p persistence.Persister) func(t *tes
 			assertx.EqualAsJSON(t, expected.UI, actual.UI)
 		})
 
+		t.Run("case=should use the SIWE nonce once", func(t *testing.T) {
+			expected := newFlow(t)
+			expected.SIWENonce = "nonce-1"
+			require.NoError(t, p.CreateLoginFlow(ctx, expected))
+
+			actual, err := p.GetLoginFlow(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "nonce-1", actual.SIWENonce)
+
+			t.Run("fail to use on other network", func(t *testing.T) {
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				require.ErrorIs(t, other.UseLoginFlowSIWENonce(ctx, expected.ID, "nonce-1", "nonce-2"), sqlcon.ErrNoRows)
+			})
+
+			require.ErrorIs(t, p.UseLoginFlowSIWENonce(ctx, expected.ID, "", "nonce-2"), sqlcon.ErrNoRows)
+			require.ErrorIs(t, p.UseLoginFlowSIWENonce(ctx, expected.ID, "wrong", "nonce-2"), sqlcon.ErrNoRows)
+			require.NoError(t, p.UseLoginFlowSIWENonce(ctx, expected.ID, "nonce-1", "nonce-2"))
+			require.ErrorIs(t, p.UseLoginFlowSIWENonce(ctx, expected.ID, "nonce-1", "nonce-3"), sqlcon.ErrNoRows)
+
+			// Updating the flow loaded before the nonce was used must not restore it.
+			require.NoError(t, p.UpdateLoginFlow(ctx, actual))
+			actual, err = p.GetLoginFlow(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "nonce-2", actual.SIWENonce)
+		})
+
 		t.Run("case=network", func(t *testing.T) {
 			id := x.NewUUID()
 			nid, p := testhelpers.NewNetwork(t, ctx, p)
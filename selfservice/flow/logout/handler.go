@@ -3,6 +3,7 @@ package logout
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -19,7 +20,9 @@ const (
 type (
 	handlerDependencies interface {
 		x.CSRFProvider
+		x.LoggingProvider
 		session.ManagementProvider
+		StrategyProvider
 		errorx.ManagementProvider
 		config.Provider
 	}
@@ -61,6 +64,9 @@ func (h *Handler) RegisterPublicRoutes(router *x.RouterPublic) {
 func (h *Handler) logout(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	_ = h.d.CSRFHandler().RegenerateToken(w, r)
 
+	// The session is only needed to end upstream sessions, so errors are handled by PurgeFromRequest below.
+	sess, _ := h.d.SessionManager().FetchFromRequest(r.Context(), r)
+
 	if err := h.d.SessionManager().PurgeFromRequest(r.Context(), w, r); err != nil {
 		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 		return
@@ -77,5 +83,31 @@ func (h *Handler) logout(w http.ResponseWriter, r *http.Request, ps httprouter.P
 		return
 	}
 
+	if sess != nil {
+		ret = h.endUpstreamSessions(w, r, sess, ret)
+	}
+
 	http.Redirect(w, r, ret.String(), http.StatusFound)
 }
+
+// endUpstreamSessions lets the logout strategies end the sessions the identity has at third parties and
+// returns the URL to redirect the browser to. Failures are logged because they must not prevent the logout.
+func (h *Handler) endUpstreamSessions(w http.ResponseWriter, r *http.Request, sess *session.Session, returnTo *url.URL) *url.URL {
+	for _, s := range h.d.LogoutStrategies(r.Context()) {
+		redirect, err := s.EndUpstreamSession(w, r, sess, returnTo)
+		if err != nil {
+			h.d.Logger().
+				WithRequest(r).
+				WithError(err).
+				WithField("session_id", sess.ID).
+				WithField("strategy", fmt.Sprintf("%T", s)).
+				Warn("Unable to end the upstream session of a session which was logged out.")
+			continue
+		}
+
+		if redirect != nil {
+			returnTo = redirect
+		}
+	}
+	return returnTo
+}
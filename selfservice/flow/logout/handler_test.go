@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/gobuffalo/httptest"
 	"github.com/julienschmidt/httprouter"
@@ -17,6 +18,7 @@ import (
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/selfservice/flow/logout"
+	"github.com/ory/kratos/selfservice/strategy/oidc"
 	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
 	"github.com/ory/nosurf"
@@ -108,4 +110,40 @@ func TestLogoutHandler(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, returnToURL, res.Request.URL.String())
 	})
+
+	t.Run("case=ends the session at the OpenID Connect provider", func(t *testing.T) {
+		var hint string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hint = r.URL.Query().Get("id_token_hint")
+			http.Redirect(w, r, r.URL.Query().Get("post_logout_redirect_uri"), http.StatusFound)
+		}))
+		defer upstream.Close()
+
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypeOIDC), map[string]interface{}{
+			"enabled": true,
+			"config": &oidc.ConfigurationCollection{Providers: []oidc.Configuration{{
+				ID:       "corp",
+				Provider: "generic",
+				ClientID: "client",
+				Logout:   oidc.LogoutConfiguration{Mode: oidc.LogoutModeRedirect, EndSessionURL: upstream.URL},
+			}}},
+		})
+
+		router.GET("/set-upstream", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(r.Context(), i))
+
+			s := session.NewActiveSession(i, conf, time.Now().UTC())
+			s.UpstreamSession = &session.UpstreamSession{Provider: "corp", IDToken: "id-token"}
+			require.NoError(t, reg.SessionManager().CreateAndIssueCookie(r.Context(), w, r, s))
+		})
+
+		client := testhelpers.NewClientWithCookies(t)
+		testhelpers.MockHydrateCookieClient(t, client, ts.URL+"/set-upstream")
+
+		res, err := client.Get(ts.URL + logout.RouteBrowser)
+		require.NoError(t, err)
+		assert.Equal(t, redirTS.URL, res.Request.URL.String())
+		assert.Equal(t, "id-token", hint)
+	})
 }
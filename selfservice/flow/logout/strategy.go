@@ -0,0 +1,27 @@
+package logout
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/session"
+)
+
+// Strategy is implemented by self-service strategies which need to end sessions at a third party, for example
+// at the OpenID Connect provider the identity signed in with, when the ORY Kratos session is logged out.
+type Strategy interface {
+	ID() identity.CredentialsType
+
+	// EndUpstreamSession is called after the session was revoked. It may return a URL the browser is redirected
+	// to instead of returnTo in order to end the upstream session. That URL should eventually lead to returnTo.
+	// Errors are logged but do not prevent the logout.
+	EndUpstreamSession(w http.ResponseWriter, r *http.Request, s *session.Session, returnTo *url.URL) (*url.URL, error)
+}
+
+type Strategies []Strategy
+
+type StrategyProvider interface {
+	LogoutStrategies(ctx context.Context) Strategies
+}
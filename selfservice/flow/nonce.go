@@ -0,0 +1,115 @@
+package flow
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/randx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/ui/container"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+	"github.com/ory/nosurf"
+)
+
+//go:embed .schema/nonce.schema.json
+var nonceSchema []byte
+
+// NonceName is the name of the hidden field carrying a flow's submission nonce.
+const NonceName = "flow_nonce"
+
+// NewNonce returns a submission nonce. Unlike the anti-CSRF token, which is bound to the browser, the nonce is bound
+// to the flow and can only be submitted once.
+func NewNonce() string {
+	return randx.MustString(32, randx.AlphaNum)
+}
+
+// SetNonce adds the hidden field carrying the nonce to c. It does nothing if the nonce is empty, which is the case
+// if replay protection was disabled when the flow was created or if the response may not reveal the nonce.
+//
+// The field is added when the flow is written to the response and never persisted with the flow.
+func SetNonce(c *container.Container, nonce string) {
+	if nonce == "" {
+		return
+	}
+
+	c.SetNode(node.NewInputField(NonceName, nonce, node.DefaultGroup, node.InputAttributeTypeHidden, node.WithRequiredInputAttribute))
+}
+
+// NonceFromRequest returns the submission nonce the request carries in its body, or an empty string if it carries
+// none. The request body can be read again afterwards.
+func NonceFromRequest(r *http.Request) (string, error) {
+	var p struct {
+		Nonce string `json:"flow_nonce" form:"flow_nonce"`
+	}
+
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(nonceSchema)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := dec.Decode(r, &p, compiler,
+		decoderx.HTTPKeepRequestBody(true),
+		decoderx.HTTPDecoderAllowedMethods("POST", "PUT", "PATCH"),
+		decoderx.HTTPDecoderSetValidatePayloads(false),
+		decoderx.HTTPDecoderJSONFollowsFormFormat()); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return p.Nonce, nil
+}
+
+type nonceContextKey struct{}
+
+// NonceBoundTo returns true if the request may see and submit the nonce of a flow of type ft whose anti-CSRF token
+// is csrfToken. The nonce of a browser flow is bound to the browser which initialized the flow, unless anti-CSRF
+// tokens are not verified for the request's route. The nonce of an API flow is not bound to a client and is
+// instead handed out only once.
+func NonceBoundTo(r *http.Request, ft Type, csrfToken string, generator func(r *http.Request) string) bool {
+	if ft != TypeBrowser || x.IsCSRFVerifiedByMode(r) {
+		return true
+	}
+	return nosurf.VerifyToken(generator(r), csrfToken)
+}
+
+// UseNonce checks the submission nonce of a POST request for a flow whose nonce is set. use must replace the
+// flow's nonce with next if it equals the submitted nonce, atomically, and return sqlcon.ErrNoRows otherwise.
+// Other requests, such as clicks on links sent by email, do not carry the nonce and are not checked.
+//
+// The returned request carries the flow's next nonce, see NextNonce. Only requests which submitted the valid
+// nonce learn the next one, so a rejected replay can not continue the flow.
+func UseNonce(r *http.Request, ft Type, csrfToken string, generator func(r *http.Request) string, nonce string, use func(nonce, next string) error) (*http.Request, error) {
+	if nonce == "" || r.Method != http.MethodPost {
+		return r, nil
+	}
+
+	if !NonceBoundTo(r, ft, csrfToken, generator) {
+		return r, schema.NewFlowNonceInvalidError()
+	}
+
+	submitted, err := NonceFromRequest(r)
+	if err != nil {
+		return r, err
+	}
+
+	next := NewNonce()
+	if err := use(submitted, next); errors.Is(err, sqlcon.ErrNoRows) {
+		return r, schema.NewFlowNonceInvalidError()
+	} else if err != nil {
+		return r, err
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), nonceContextKey{}, next)), nil
+}
+
+// NextNonce returns the flow's next nonce if the request submitted the valid nonce, or an empty string.
+func NextNonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(nonceContextKey{}).(string)
+	return nonce
+}
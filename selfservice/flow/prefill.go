@@ -0,0 +1,140 @@
+package flow
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/ui/node"
+)
+
+// PrefillQueryParameter is the query parameter of the login and registration flow initialization endpoints which
+// contains a signed Prefill.
+const PrefillQueryParameter = "prefill"
+
+var ErrInvalidPrefill = herodot.ErrBadRequest.WithReason("The prefill query parameter is malformed, expired, or not signed with a secret configured at secrets.prefill.")
+
+// Prefill contains values which a landing page hands off into the form of a new login or registration flow, for
+// example the email address a user entered in a newsletter signup.
+//
+// Landing pages encode the JSON representation of a Prefill using base64url without padding, sign the encoded
+// payload using HMAC-SHA256 with a secret configured at `secrets.prefill`, and append the base64url encoded
+// signature after a dot: `<payload>.<signature>`.
+type Prefill struct {
+	// Values maps the names of input nodes, for example `traits.email`, to their values. Values of passwords,
+	// buttons, and hidden nodes can not be set.
+	Values map[string]interface{} `json:"values"`
+
+	// Hidden lists the names of input nodes in Values which are rendered as hidden inputs, so that the user does
+	// not have to enter data the landing page already knows.
+	Hidden []string `json:"hidden,omitempty"`
+
+	// ExpiresAt is the unix timestamp after which the prefill is rejected.
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// SignPrefill encodes and signs a prefill.
+func SignPrefill(secret []byte, p *Prefill) (string, error) {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signPrefill(secret, payload)), nil
+}
+
+func signPrefill(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// ParsePrefill verifies and decodes a prefill signed with any of the secrets.
+func ParsePrefill(token string, now time.Time, secrets ...[]byte) (*Prefill, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, errors.WithStack(ErrInvalidPrefill.WithDebug("The prefill does not consist of a payload and a signature."))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidPrefill.WithDebugf("Unable to decode the signature: %s", err))
+	}
+
+	var valid bool
+	for _, secret := range secrets {
+		if hmac.Equal(signPrefill(secret, parts[0]), signature) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, errors.WithStack(ErrInvalidPrefill.WithDebug("The signature is invalid."))
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidPrefill.WithDebugf("Unable to decode the payload: %s", err))
+	}
+
+	var p Prefill
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, errors.WithStack(ErrInvalidPrefill.WithDebugf("Unable to decode the payload: %s", err))
+	}
+
+	if now.After(time.Unix(p.ExpiresAt, 0)) {
+		return nil, errors.WithStack(ErrInvalidPrefill.WithDebug("The prefill expired."))
+	}
+
+	return &p, nil
+}
+
+// PrefillFromRequest returns the prefill of a request which initializes a flow, or nil if it has none.
+func PrefillFromRequest(r *http.Request, now time.Time, secrets ...[]byte) (*Prefill, error) {
+	token := r.URL.Query().Get(PrefillQueryParameter)
+	if len(token) == 0 {
+		return nil, nil
+	}
+
+	return ParsePrefill(token, now, secrets...)
+}
+
+// Apply sets the values of the prefill on the input nodes with the same names. Values of nodes which do not exist
+// in the flow are ignored, so that one prefill works regardless of which strategies are enabled.
+func (p *Prefill) Apply(nodes node.Nodes) {
+	hidden := make(map[string]bool, len(p.Hidden))
+	for _, name := range p.Hidden {
+		hidden[name] = true
+	}
+
+	for _, n := range nodes {
+		a, ok := n.Attributes.(*node.InputAttributes)
+		if !ok {
+			continue
+		}
+
+		value, ok := p.Values[a.Name]
+		if !ok {
+			continue
+		}
+
+		switch a.Type {
+		case node.InputAttributeTypePassword, node.InputAttributeTypeSubmit, node.InputAttributeTypeHidden:
+			continue
+		}
+
+		a.SetValue(value)
+		if hidden[a.Name] {
+			a.Type = node.InputAttributeTypeHidden
+		}
+	}
+}
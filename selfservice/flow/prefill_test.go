@@ -0,0 +1,92 @@
+package flow
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+func TestPrefill(t *testing.T) {
+	now := time.Now()
+	secret, other := []byte("secret-secret-secret"), []byte("other-other-other")
+
+	token, err := SignPrefill(secret, &Prefill{
+		Values:    map[string]interface{}{"traits.email": "foo@ory.sh"},
+		ExpiresAt: now.Add(time.Minute).Unix(),
+	})
+	require.NoError(t, err)
+
+	t.Run("case=parses a prefill signed with any secret", func(t *testing.T) {
+		p, err := ParsePrefill(token, now, other, secret)
+		require.NoError(t, err)
+		assert.Equal(t, "foo@ory.sh", p.Values["traits.email"])
+	})
+
+	t.Run("case=rejects invalid prefills", func(t *testing.T) {
+		for k, tc := range []struct {
+			token   string
+			now     time.Time
+			secrets [][]byte
+		}{
+			{token: token, now: now, secrets: [][]byte{other}},
+			{token: token, now: now},
+			{token: token, now: now.Add(time.Hour), secrets: [][]byte{secret}},
+			{token: token + "a", now: now, secrets: [][]byte{secret}},
+			{token: "a" + token, now: now, secrets: [][]byte{secret}},
+			{token: "foo", now: now, secrets: [][]byte{secret}},
+		} {
+			_, err := ParsePrefill(tc.token, tc.now, tc.secrets...)
+			assert.ErrorIs(t, err, ErrInvalidPrefill, "%d", k)
+		}
+	})
+
+	t.Run("case=reads the prefill from the request", func(t *testing.T) {
+		r, err := http.NewRequest("GET", "/?prefill="+token, nil)
+		require.NoError(t, err)
+		p, err := PrefillFromRequest(r, now, secret)
+		require.NoError(t, err)
+		assert.NotNil(t, p)
+
+		r, err = http.NewRequest("GET", "/", nil)
+		require.NoError(t, err)
+		p, err = PrefillFromRequest(r, now, secret)
+		require.NoError(t, err)
+		assert.Nil(t, p)
+	})
+
+	t.Run("case=applies values to visible inputs only", func(t *testing.T) {
+		nodes := node.Nodes{
+			node.NewInputField("traits.email", nil, node.PasswordGroup, node.InputAttributeTypeEmail),
+			node.NewInputField("traits.code", nil, node.PasswordGroup, node.InputAttributeTypeText),
+			node.NewInputField("password", nil, node.PasswordGroup, node.InputAttributeTypePassword),
+			node.NewInputField("method", "password", node.PasswordGroup, node.InputAttributeTypeSubmit),
+			node.NewCSRFNode("csrf"),
+		}
+
+		(&Prefill{
+			Values: map[string]interface{}{
+				"traits.email":  "foo@ory.sh",
+				"traits.code":   "invite",
+				"traits.other":  "ignored",
+				"password":      "secret",
+				"method":        "oidc",
+				x.CSRFTokenName: "injected",
+			},
+			Hidden: []string{"traits.code"},
+		}).Apply(nodes)
+
+		assert.Equal(t, "foo@ory.sh", nodes.Find("traits.email").GetValue())
+		assert.Equal(t, "invite", nodes.Find("traits.code").GetValue())
+		assert.Equal(t, node.InputAttributeTypeHidden, nodes.Find("traits.code").Attributes.(*node.InputAttributes).Type)
+		assert.Equal(t, node.InputAttributeTypeEmail, nodes.Find("traits.email").Attributes.(*node.InputAttributes).Type)
+		assert.Nil(t, nodes.Find("password").GetValue())
+		assert.Equal(t, "password", nodes.Find("method").GetValue())
+		assert.Equal(t, "csrf", nodes.Find(x.CSRFTokenName).GetValue())
+	})
+}
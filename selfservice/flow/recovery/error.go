@@ -41,10 +41,14 @@ func NewFlowExpiredError(at time.Time) *FlowExpiredError {
 	}
 }
 
+// FlowExpired marks the error as an expired flow for the error routes at `selfservice.flows.error.routes`.
+func (e *FlowExpiredError) FlowExpired() {}
+
 type (
 	errorHandlerDependencies interface {
 		errorx.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
 		x.LoggingProvider
 		x.CSRFTokenGeneratorProvider
 		config.Provider
@@ -70,6 +74,8 @@ func MethodToNodeGroup(method string) node.Group {
 	switch method {
 	case StrategyRecoveryLinkName:
 		return node.RecoveryLinkGroup
+	case StrategyRecoveryQuestionsName:
+		return node.RecoveryQuestionsGroup
 	default:
 		return node.DefaultGroup
 	}
@@ -95,7 +101,7 @@ func (s *ErrorHandler) WriteFlowError(
 
 	if e := new(FlowExpiredError); errors.As(err, &e) {
 		// create new flow because the old one is not valid
-		a, err := NewFlow(s.d.Config(r.Context()), s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), f.Type)
+		a, err := NewFlow(s.d.Config(r.Context()), s.d.Clock().Now(), s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), f.Type)
 		if err != nil {
 			// failed to create a new session and redirect to it, handle that error as a new one
 			s.WriteFlowError(w, r, f, group, err)
@@ -135,9 +141,11 @@ func (s *ErrorHandler) WriteFlowError(
 
 	updatedFlow, innerErr := s.d.RecoveryFlowPersister().GetRecoveryFlow(r.Context(), f.ID)
 	if innerErr != nil {
-		s.forward(w, r, updatedFlow, innerErr)
+		s.forward(w, r, f, innerErr)
+		return
 	}
 
+	flow.SetNonce(updatedFlow.UI, flow.NextNonce(r.Context()))
 	s.d.Writer().WriteCode(w, r, x.RecoverStatusCode(err, http.StatusBadRequest), updatedFlow)
 }
 
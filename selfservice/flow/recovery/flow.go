@@ -75,6 +75,18 @@ type Flow struct {
 	// CSRFToken contains the anti-csrf token associated with this request.
 	CSRFToken string `json:"-" db:"csrf_token"`
 
+	// IssuedByAdmin is true if the flow was created by the admin API. Such flows were not initialized by the
+	// browser completing them, so their anti-CSRF token is reissued whenever they are fetched.
+	IssuedByAdmin bool `json:"-" faker:"-" db:"issued_by_admin"`
+
+	// SubmissionNonce must be submitted to complete the flow if it is set. It is read-only because only the
+	// persister may change it, see FlowPersister.UseRecoveryFlowNonce.
+	SubmissionNonce string `json:"-" faker:"-" db:"submission_nonce" rw:"r"`
+
+	// SubmissionNonceIssued is true if the nonce was handed out. The nonce of API flows and of browser flows created
+	// by the admin API is handed out only once, see FlowPersister.IssueRecoveryFlowNonce.
+	SubmissionNonceIssued bool `json:"-" faker:"-" db:"submission_nonce_issued" rw:"r"`
+
 	// CreatedAt is a helper struct field for gobuffalo.pop.
 	CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
 
@@ -86,8 +98,8 @@ type Flow struct {
 	NID                 uuid.UUID     `json:"-"  faker:"-" db:"nid"`
 }
 
-func NewFlow(conf *config.Config, exp time.Duration, csrf string, r *http.Request, strategies Strategies, ft flow.Type) (*Flow, error) {
-	now := time.Now().UTC()
+func NewFlow(conf *config.Config, now time.Time, exp time.Duration, csrf string, r *http.Request, strategies Strategies, ft flow.Type) (*Flow, error) {
+	now = now.UTC()
 	id := x.NewUUID()
 	req := &Flow{
 		ID:        id,
@@ -102,6 +114,10 @@ func NewFlow(conf *config.Config, exp time.Duration, csrf string, r *http.Reques
 		Type:      ft,
 	}
 
+	if conf.SelfServiceFlowReplayProtectionEnabled() {
+		req.SubmissionNonce = flow.NewNonce()
+	}
+
 	for _, strategy := range strategies {
 		if err := strategy.PopulateRecoveryMethod(r, req); err != nil {
 			return nil, err
@@ -131,8 +147,8 @@ func (f Flow) GetNID() uuid.UUID {
 	return f.NID
 }
 
-func (f *Flow) Valid() error {
-	if f.ExpiresAt.Before(time.Now().UTC()) {
+func (f *Flow) Valid(now time.Time) error {
+	if f.ExpiresAt.Before(now) {
 		return errors.WithStack(NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
@@ -29,11 +29,11 @@ func TestFlow(t *testing.T) {
 		r         *recovery.Flow
 		expectErr bool
 	}{
-		{r: must(recovery.NewFlow(conf, time.Hour, "", u, nil, flow.TypeBrowser))},
-		{r: must(recovery.NewFlow(conf, -time.Hour, "", u, nil, flow.TypeBrowser)), expectErr: true},
+		{r: must(recovery.NewFlow(conf, time.Now(), time.Hour, "", u, nil, flow.TypeBrowser))},
+		{r: must(recovery.NewFlow(conf, time.Now(), -time.Hour, "", u, nil, flow.TypeBrowser)), expectErr: true},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
-			err := tc.r.Valid()
+			err := tc.r.Valid(time.Now())
 			if tc.expectErr {
 				require.Error(t, err)
 				return
@@ -44,7 +44,7 @@ func TestFlow(t *testing.T) {
 	}
 
 	assert.EqualValues(t, recovery.StateChooseMethod,
-		must(recovery.NewFlow(conf, time.Hour, "", u, nil, flow.TypeBrowser)).State)
+		must(recovery.NewFlow(conf, time.Now(), time.Hour, "", u, nil, flow.TypeBrowser)).State)
 }
 
 func TestGetType(t *testing.T) {
@@ -2,7 +2,6 @@ package recovery
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/ory/kratos/schema"
 
@@ -18,8 +17,10 @@ import (
 
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/reputation"
 	"github.com/ory/kratos/selfservice/errorx"
 	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/callback"
 	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
 )
@@ -42,12 +43,15 @@ type (
 		identity.PrivilegedPoolProvider
 		session.HandlerProvider
 		StrategyProvider
+		callback.NotifierProvider
 		FlowPersistenceProvider
 		x.CSRFTokenGeneratorProvider
 		x.WriterProvider
+		x.ClockProvider
 		x.CSRFProvider
 		config.Provider
 		ErrorHandlerProvider
+		reputation.Provider
 	}
 	Handler struct {
 		d handlerDependencies
@@ -76,6 +80,26 @@ func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 	admin.GET(RouteGetFlow, h.fetch)
 }
 
+// nolint:deadcode,unused
+// swagger:parameters initializeSelfServiceRecoveryViaBrowserFlow initializeSelfServiceRecoveryViaAPIFlow
+type initializeSelfServiceRecoveryFlow struct {
+	// Requested Lifespan
+	//
+	// A lifespan other than the configured one, for example `24h`. It must be within the bounds configured at
+	// `selfservice.flows.recovery.lifespan_bounds`.
+	//
+	// in: query
+	Lifespan string `json:"lifespan"`
+
+	// Callback URL
+	//
+	// A URL that the flow's result is sent to once the flow completed. It must match one of the URLs configured at
+	// `selfservice.flow_callbacks.allowed_urls`.
+	//
+	// in: query
+	CallbackURL string `json:"callback_url"`
+}
+
 // swagger:route GET /self-service/recovery/api public initializeSelfServiceRecoveryViaAPIFlow
 //
 // Initialize Recovery Flow for API Clients
@@ -110,17 +134,39 @@ func (h *Handler) initAPIFlow(w http.ResponseWriter, r *http.Request, _ httprout
 		return
 	}
 
-	req, err := NewFlow(h.d.Config(r.Context()), h.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), h.d.GenerateCSRFToken(r), r, h.d.RecoveryStrategies(r.Context()), flow.TypeAPI)
+	lifespan, err := flow.RequestedLifespan(r, h.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), h.d.Config(r.Context()).SelfServiceFlowRecoveryLifespanBounds())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	callbackURL, err := callback.RequestedURL(r, h.d.Config(r.Context()).SelfServiceFlowCallbacksAllowedURLs())
 	if err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
 	}
 
+	req, err := NewFlow(h.d.Config(r.Context()), h.d.Clock().Now(), lifespan, h.d.GenerateCSRFToken(r), r, h.d.RecoveryStrategies(r.Context()), flow.TypeAPI)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.IPReputation().AddFriction(r, &req.UI.Nodes)
+
+	// The nonce of the flow is handed out with this response only.
+	req.SubmissionNonceIssued = true
+
 	if err := h.d.RecoveryFlowPersister().CreateRecoveryFlow(r.Context(), req); err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
 	}
 
+	if err := h.d.FlowCallbackNotifier().Register(r.Context(), callback.FlowTypeRecovery, req.ID, req.ExpiresAt, callbackURL); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	flow.SetNonce(req.UI, req.SubmissionNonce)
 	h.d.Writer().Write(w, r, req)
 }
 
@@ -147,17 +193,35 @@ func (h *Handler) initBrowserFlow(w http.ResponseWriter, r *http.Request, _ http
 		return
 	}
 
-	f, err := NewFlow(h.d.Config(r.Context()), h.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), h.d.GenerateCSRFToken(r), r, h.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
+	lifespan, err := flow.RequestedLifespan(r, h.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), h.d.Config(r.Context()).SelfServiceFlowRecoveryLifespanBounds())
+	if err != nil {
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+
+	callbackURL, err := callback.RequestedURL(r, h.d.Config(r.Context()).SelfServiceFlowCallbacksAllowedURLs())
+	if err != nil {
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+
+	f, err := NewFlow(h.d.Config(r.Context()), h.d.Clock().Now(), lifespan, h.d.GenerateCSRFToken(r), r, h.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
 	if err != nil {
 		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 		return
 	}
+	h.d.IPReputation().AddFriction(r, &f.UI.Nodes)
 
 	if err := h.d.RecoveryFlowPersister().CreateRecoveryFlow(r.Context(), f); err != nil {
 		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 		return
 	}
 
+	if err := h.d.FlowCallbackNotifier().Register(r.Context(), callback.FlowTypeRecovery, f.ID, f.ExpiresAt, callbackURL); err != nil {
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+
 	http.Redirect(w, r, f.AppendTo(h.d.Config(r.Context()).SelfServiceFlowRecoveryUI()).String(), http.StatusFound)
 }
 
@@ -205,7 +269,7 @@ func (h *Handler) fetch(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 		return
 	}
 
-	if f.ExpiresAt.Before(time.Now().UTC()) {
+	if f.ExpiresAt.Before(h.d.Clock().Now()) {
 		if f.Type == flow.TypeBrowser {
 			h.d.Writer().WriteError(w, r, errors.WithStack(x.ErrGone.
 				WithReason("The recovery flow has expired. Redirect the user to the recovery flow init endpoint to initialize a new recovery flow.").
@@ -218,6 +282,17 @@ func (h *Handler) fetch(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 		return
 	}
 
+	if f.IssuedByAdmin && f.Type == flow.TypeBrowser {
+		f.UI.SetCSRF(h.d.GenerateCSRFToken(r))
+	}
+
+	nonce, err := h.revealNonce(r, f)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	flow.SetNonce(f.UI, nonce)
 	h.d.Writer().Write(w, r, f)
 }
 
@@ -256,7 +331,7 @@ type submitSelfServiceRecoveryFlowBody struct{}
 // - `sent_email` is the success state after `choose_method` for the `link` method and allows the user to request another recovery email. It
 //   works for both API and Browser-initiated flows and returns the same responses as the flow in `choose_method` state.
 // - `passed_challenge` expects a `token` to be sent in the URL query and given the nature of the flow ("sending a recovery link")
-//   does not have any API capabilities. The server responds with a HTTP 302 Found redirect either to the Settings UI URL
+//   is meant for browsers when the link is clicked. The server responds with a HTTP 302 Found redirect either to the Settings UI URL
 //   (if the link was valid) and instructs the user to update their password, or a redirect to the Recover UI URL with
 //   a new Recovery Flow ID which contains an error message that the recovery link was invalid.
 //
@@ -291,11 +366,26 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, ps httprout
 		return
 	}
 
-	if err := f.Valid(); err != nil {
+	// The nonce is checked before the flow's expiry, so that replays of requests for an expired flow do not
+	// restart the flow either.
+	r, err = flow.UseNonce(r, f.Type, f.CSRFToken, h.d.GenerateCSRFToken, f.SubmissionNonce, func(nonce, next string) error {
+		return h.d.RecoveryFlowPersister().UseRecoveryFlowNonce(r.Context(), f.ID, nonce, next)
+	})
+	if err != nil {
 		h.d.RecoveryFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
 		return
 	}
 
+	if err := f.Valid(h.d.Clock().Now()); err != nil {
+		h.d.RecoveryFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
+		return
+	}
+
+	if err := h.d.IPReputation().VerifySubmission(r, &f.UI.Nodes); err != nil {
+		h.d.RecoveryFlowErrorHandler().WriteFlowError(w, r, f, node.CaptchaGroup, err)
+		return
+	}
+
 	var g node.Group
 	var found bool
 	for _, ss := range h.d.AllRecoveryStrategies() {
@@ -330,5 +420,36 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, ps httprout
 		return
 	}
 
+	flow.SetNonce(updatedFlow.UI, flow.NextNonce(r.Context()))
 	h.d.Writer().Write(w, r, updatedFlow)
 }
+
+// revealNonce returns the submission nonce of the flow if the response to the request may show it, or an empty
+// string. API flows hand out their nonce only once, so that fetching the flow does not reveal the nonce of a flow
+// that was already submitted. Browser flows created by the admin API are bound to the browser which fetches them
+// first.
+func (h *Handler) revealNonce(r *http.Request, f *Flow) (string, error) {
+	if f.SubmissionNonce == "" {
+		return "", nil
+	}
+
+	if f.Type == flow.TypeAPI || (f.IssuedByAdmin && !f.SubmissionNonceIssued) {
+		if err := h.d.RecoveryFlowPersister().IssueRecoveryFlowNonce(r.Context(), f.ID); errors.Is(err, sqlcon.ErrNoRows) {
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+
+		if f.Type == flow.TypeBrowser {
+			f.CSRFToken = h.d.GenerateCSRFToken(r)
+			if err := h.d.RecoveryFlowPersister().UpdateRecoveryFlow(r.Context(), f); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if !flow.NonceBoundTo(r, f.Type, f.CSRFToken, h.d.GenerateCSRFToken) {
+		return "", nil
+	}
+	return f.SubmissionNonce, nil
+}
@@ -11,6 +11,15 @@ type (
 		CreateRecoveryFlow(context.Context, *Flow) error
 		GetRecoveryFlow(ctx context.Context, id uuid.UUID) (*Flow, error)
 		UpdateRecoveryFlow(context.Context, *Flow) error
+
+		// UseRecoveryFlowNonce replaces the flow's submission nonce with next if it equals nonce. It returns
+		// sqlcon.ErrNoRows if it does not, so that each nonce can only be used once, even if it is submitted
+		// concurrently.
+		UseRecoveryFlowNonce(ctx context.Context, id uuid.UUID, nonce, next string) error
+
+		// IssueRecoveryFlowNonce marks the flow's submission nonce as handed out. It returns sqlcon.ErrNoRows if it
+		// already was, so that the nonce is only handed out once.
+		IssueRecoveryFlowNonce(ctx context.Context, id uuid.UUID) error
 	}
 	FlowPersistenceProvider interface {
 		RecoveryFlowPersister() FlowPersister
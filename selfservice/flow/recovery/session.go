@@ -0,0 +1,40 @@
+package recovery
+
+import (
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/session"
+)
+
+// The Response for Recovery Flows via API
+//
+// swagger:model recoveryViaApiResponse
+type APIFlowResponse struct {
+	// The Flow
+	//
+	// The flow is in state `passed_challenge` once the identity was recovered.
+	//
+	// required: true
+	Flow *Flow `json:"flow"`
+
+	// The Settings Flow
+	//
+	// The API settings flow in which the identity is asked to update their credentials.
+	//
+	// required: true
+	SettingsFlow *settings.Flow `json:"settings_flow"`
+
+	// The Session Token
+	//
+	// A session token is equivalent to a session cookie, but it can be sent in the HTTP Authorization
+	// Header:
+	//
+	// 		Authorization: bearer ${session-token}
+	//
+	// required: true
+	Token string `json:"session_token"`
+
+	// The Session
+	//
+	// required: true
+	Session *session.Session `json:"session"`
+}
@@ -12,7 +12,8 @@ import (
 )
 
 const (
-	StrategyRecoveryLinkName = "link"
+	StrategyRecoveryLinkName      = "link"
+	StrategyRecoveryQuestionsName = "questions"
 )
 
 type (
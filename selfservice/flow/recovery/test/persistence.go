@@ -159,6 +159,62 @@ func TestFlowPersister(ctx context.Context, conf *config.Config, p interface {
 			assertx.EqualAsJSON(t, expected.UI, actual.UI)
 		})
 
+		t.Run("case=should use the submission nonce once", func(t *testing.T) {
+			expected := newFlow(t)
+			expected.SubmissionNonce = "nonce-1"
+			require.NoError(t, p.CreateRecoveryFlow(ctx, expected))
+
+			actual, err := p.GetRecoveryFlow(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "nonce-1", actual.SubmissionNonce)
+
+			t.Run("fail to use on other network", func(t *testing.T) {
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				require.ErrorIs(t, other.UseRecoveryFlowNonce(ctx, expected.ID, "nonce-1", "nonce-2"), sqlcon.ErrNoRows)
+			})
+
+			require.ErrorIs(t, p.UseRecoveryFlowNonce(ctx, expected.ID, "", "nonce-2"), sqlcon.ErrNoRows)
+			require.ErrorIs(t, p.UseRecoveryFlowNonce(ctx, expected.ID, "wrong", "nonce-2"), sqlcon.ErrNoRows)
+			require.NoError(t, p.UseRecoveryFlowNonce(ctx, expected.ID, "nonce-1", "nonce-2"))
+			require.ErrorIs(t, p.UseRecoveryFlowNonce(ctx, expected.ID, "nonce-1", "nonce-3"), sqlcon.ErrNoRows)
+
+			// Updating the flow loaded before the nonce was used must not restore it.
+			require.NoError(t, p.UpdateRecoveryFlow(ctx, actual))
+			actual, err = p.GetRecoveryFlow(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "nonce-2", actual.SubmissionNonce)
+		})
+
+		t.Run("case=should hand out the submission nonce once", func(t *testing.T) {
+			expected := newFlow(t)
+			expected.SubmissionNonce = "nonce-1"
+			require.NoError(t, p.CreateRecoveryFlow(ctx, expected))
+
+			t.Run("fail to issue on other network", func(t *testing.T) {
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				require.ErrorIs(t, other.IssueRecoveryFlowNonce(ctx, expected.ID), sqlcon.ErrNoRows)
+			})
+
+			actual, err := p.GetRecoveryFlow(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.False(t, actual.SubmissionNonceIssued)
+
+			require.NoError(t, p.IssueRecoveryFlowNonce(ctx, expected.ID))
+			require.ErrorIs(t, p.IssueRecoveryFlowNonce(ctx, expected.ID), sqlcon.ErrNoRows)
+
+			// Updating the flow loaded before the nonce was handed out must not reset it.
+			require.NoError(t, p.UpdateRecoveryFlow(ctx, actual))
+			actual, err = p.GetRecoveryFlow(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.True(t, actual.SubmissionNonceIssued)
+
+			issued := newFlow(t)
+			issued.SubmissionNonce = "nonce-1"
+			issued.SubmissionNonceIssued = true
+			require.NoError(t, p.CreateRecoveryFlow(ctx, issued))
+			require.ErrorIs(t, p.IssueRecoveryFlowNonce(ctx, issued.ID), sqlcon.ErrNoRows)
+		})
+
 		t.Run("case=handle network reference issues", func(t *testing.T) {
 
 		})
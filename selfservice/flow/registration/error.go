@@ -29,6 +29,7 @@ type (
 	errorHandlerDependencies interface {
 		errorx.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
 		x.LoggingProvider
 		config.Provider
 
@@ -59,6 +60,9 @@ func NewFlowExpiredError(at time.Time) *FlowExpiredError {
 	}
 }
 
+// FlowExpired marks the error as an expired flow for the error routes at `selfservice.flows.error.routes`.
+func (e *FlowExpiredError) FlowExpired() {}
+
 func NewErrorHandler(d errorHandlerDependencies) *ErrorHandler {
 	return &ErrorHandler{d: d}
 }
@@ -69,8 +69,8 @@ type Flow struct {
 	NID       uuid.UUID `json:"-"  faker:"-" db:"nid"`
 }
 
-func NewFlow(conf *config.Config, exp time.Duration, csrf string, r *http.Request, ft flow.Type) *Flow {
-	now := time.Now().UTC()
+func NewFlow(conf *config.Config, now time.Time, exp time.Duration, csrf string, r *http.Request, ft flow.Type) *Flow {
+	now = now.UTC()
 	id := x.NewUUID()
 	return &Flow{
 		ID:         id,
@@ -98,8 +98,8 @@ func (f Flow) GetNID() uuid.UUID {
 	return f.NID
 }
 
-func (f *Flow) Valid() error {
-	if f.ExpiresAt.Before(time.Now()) {
+func (f *Flow) Valid(now time.Time) error {
+	if f.ExpiresAt.Before(now) {
 		return errors.WithStack(NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
@@ -2,7 +2,6 @@ package registration
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/ory/kratos/schema"
 
@@ -12,9 +11,14 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
 	"github.com/ory/x/urlx"
 
+	"github.com/ory/kratos/attempt"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/feature"
+	"github.com/ory/kratos/reputation"
 	"github.com/ory/kratos/selfservice/errorx"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/session"
@@ -28,6 +32,8 @@ const (
 	RouteGetFlow = "/self-service/registration/flows"
 
 	RouteSubmitFlow = "/self-service/registration"
+
+	RouteSimulateFlow = "/self-service/registration/simulations"
 )
 
 type (
@@ -37,12 +43,15 @@ type (
 		session.HandlerProvider
 		session.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
 		x.CSRFTokenGeneratorProvider
 		x.CSRFProvider
 		StrategyProvider
 		HookExecutorProvider
 		FlowPersistenceProvider
 		ErrorHandlerProvider
+		reputation.Provider
+		attempt.ManagementProvider
 	}
 	HandlerProvider interface {
 		RegistrationHandler() *Handler
@@ -81,15 +90,31 @@ func (h *Handler) onAuthenticated(w http.ResponseWriter, r *http.Request, ps htt
 
 func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 	admin.GET(RouteGetFlow, h.fetchFlow)
+	admin.POST(RouteSimulateFlow, h.simulateFlow)
 }
 
 func (h *Handler) NewRegistrationFlow(w http.ResponseWriter, r *http.Request, ft flow.Type) (*Flow, error) {
-	f := NewFlow(h.d.Config(r.Context()), h.d.Config(r.Context()).SelfServiceFlowRegistrationRequestLifespan(), h.d.GenerateCSRFToken(r), r, ft)
+	lifespan, err := flow.RequestedLifespan(r, h.d.Config(r.Context()).SelfServiceFlowRegistrationRequestLifespan(), h.d.Config(r.Context()).SelfServiceFlowRegistrationLifespanBounds())
+	if err != nil {
+		return nil, err
+	}
+
+	f := NewFlow(h.d.Config(r.Context()), h.d.Clock().Now(), lifespan, h.d.GenerateCSRFToken(r), r, ft)
+	r = r.WithContext(feature.WithFlow(r.Context(), f.ID))
 	for _, s := range h.d.RegistrationStrategies(r.Context()) {
 		if err := s.PopulateRegistrationMethod(r, f); err != nil {
 			return nil, err
 		}
 	}
+	h.d.IPReputation().AddFriction(r, &f.UI.Nodes)
+
+	prefill, err := flow.PrefillFromRequest(r, h.d.Clock().Now(), h.d.Config(r.Context()).SecretsPrefill()...)
+	if err != nil {
+		return nil, err
+	}
+	if prefill != nil {
+		prefill.Apply(f.UI.Nodes)
+	}
 
 	if err := SortNodes(f.UI.Nodes, h.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String()); err != nil {
 		return nil, err
@@ -106,6 +131,26 @@ func (h *Handler) NewRegistrationFlow(w http.ResponseWriter, r *http.Request, ft
 	return f, nil
 }
 
+// nolint:deadcode,unused
+// swagger:parameters initializeSelfServiceRegistrationViaBrowserFlow initializeSelfServiceRegistrationViaAPIFlow
+type initializeSelfServiceRegistrationFlow struct {
+	// Signed Prefill
+	//
+	// Values to prefill the form with, for example the email address a landing page already knows. The
+	// prefill must be signed with a secret configured at `secrets.prefill`.
+	//
+	// in: query
+	Prefill string `json:"prefill"`
+
+	// Requested Lifespan
+	//
+	// A lifespan other than the configured one, for example `24h`. It must be within the bounds configured at
+	// `selfservice.flows.registration.lifespan_bounds`.
+	//
+	// in: query
+	Lifespan string `json:"lifespan"`
+}
+
 // swagger:route GET /self-service/registration/api public initializeSelfServiceRegistrationViaAPIFlow
 //
 // Initialize Registration Flow for API clients
@@ -220,7 +265,7 @@ func (h *Handler) fetchFlow(w http.ResponseWriter, r *http.Request, ps httproute
 		return
 	}
 
-	if ar.ExpiresAt.Before(time.Now()) {
+	if ar.ExpiresAt.Before(h.d.Clock().Now()) {
 		if ar.Type == flow.TypeBrowser {
 			h.d.Writer().WriteError(w, r, errors.WithStack(x.ErrGone.
 				WithReason("The registration flow has expired. Redirect the user to the registration flow init endpoint to initialize a new registration flow.").
@@ -301,6 +346,7 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, _ httproute
 		h.d.RegistrationFlowErrorHandler().WriteFlowError(w, r, nil, node.DefaultGroup, err)
 		return
 	}
+	r = r.WithContext(feature.WithFlow(r.Context(), f.ID))
 
 	if _, err := h.d.SessionManager().FetchFromRequest(r.Context(), r); err == nil {
 		if f.Type == flow.TypeBrowser {
@@ -312,11 +358,18 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, _ httproute
 		return
 	}
 
-	if err := f.Valid(); err != nil {
+	if err := f.Valid(h.d.Clock().Now()); err != nil {
 		h.d.RegistrationFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
 		return
 	}
 
+	if err := h.d.IPReputation().VerifySubmission(r, &f.UI.Nodes); err != nil {
+		h.d.RegistrationFlowErrorHandler().WriteFlowError(w, r, f, node.CaptchaGroup, err)
+		return
+	}
+
+	r = r.WithContext(attempt.WithIdentifier(r.Context(), attempt.IdentifierFromRequest(r)))
+
 	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
 	var found bool
 	var s identity.CredentialsType
@@ -326,6 +379,7 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, _ httproute
 		} else if errors.Is(err, flow.ErrCompletedByStrategy) {
 			return
 		} else if err != nil {
+			h.d.AttemptManager().RecordFailure(r, attempt.FlowRegistration, f.ID, ss.ID())
 			h.d.RegistrationFlowErrorHandler().WriteFlowError(w, r, f, ss.NodeGroup(), err)
 			return
 		}
@@ -345,3 +399,73 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, _ httproute
 		return
 	}
 }
+
+// nolint:deadcode,unused
+// swagger:parameters simulateSelfServiceRegistrationFlow
+type simulateSelfServiceRegistrationFlow struct {
+	// in: body
+	// required: true
+	Body flow.SimulationBody
+}
+
+// swagger:route POST /self-service/registration/simulations admin simulateSelfServiceRegistrationFlow
+//
+// Simulate a Registration Flow Submission
+//
+// This endpoint checks how a registration payload would be handled: whether the method is enabled, whether its
+// strategy would accept the payload for the given identity schema, and which hooks would run. The identity is
+// not created and no hooks are executed.
+//
+// Strategies which do not support simulations only report the hooks.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: selfServiceFlowSimulation
+//       400: genericError
+//       500: genericError
+func (h *Handler) simulateFlow(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var body flow.SimulationBody
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		h.d.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	s, err := h.d.AllRegistrationStrategies().Strategy(identity.CredentialsType(body.Method))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("No registration strategy handles the method %q.", body.Method)))
+		return
+	}
+
+	conf := h.d.Config(r.Context())
+	if body.SchemaID == "" {
+		body.SchemaID = config.DefaultIdentityTraitsSchemaID
+	}
+	if _, err := conf.IdentityTraitsSchemas().FindSchemaByID(body.SchemaID); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The identity schema %q does not exist.", body.SchemaID)))
+		return
+	}
+
+	sim := flow.NewSimulation(body.Method, conf.SelfServiceStrategy(body.Method).Enabled)
+	sim.Hooks.Before = flow.SimulationHookNames(conf.SelfServiceFlowRegistrationBeforeHooks())
+	if conf.SelfServiceFlowVerificationEnabled() {
+		sim.Hooks.After = append(sim.Hooks.After, "verification")
+	}
+	sim.Hooks.After = append(sim.Hooks.After, flow.SimulationHookNames(conf.SelfServiceFlowRegistrationAfterHooks(body.Method))...)
+
+	if ss, ok := s.(SimulationStrategy); ok && sim.MethodEnabled {
+		err := ss.SimulateRegistration(r.Context(), identity.NewIdentity(body.SchemaID), body.Payload)
+		if err := sim.Evaluate(s.NodeGroup(), err); err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+	}
+
+	h.d.Writer().Write(w, r, sim)
+}
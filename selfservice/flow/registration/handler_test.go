@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
 
@@ -114,6 +116,35 @@ func TestInitFlow(t *testing.T) {
 			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 			assertx.EqualAsJSON(t, registration.ErrAlreadyLoggedIn, json.RawMessage(gjson.GetBytes(body, "error").Raw), "%s", body)
 		})
+
+		t.Run("case=prefills the form", func(t *testing.T) {
+			secret := "prefill-secret-for-landing-pages"
+			conf.MustSet(config.ViperKeySecretsPrefill, []string{secret})
+
+			token, err := flow.SignPrefill([]byte(secret), &flow.Prefill{
+				Values:    map[string]interface{}{"traits.bar": "baz", "password": "secret"},
+				Hidden:    []string{"traits.bar"},
+				ExpiresAt: time.Now().Add(time.Minute).Unix(),
+			})
+			require.NoError(t, err)
+
+			res, err := publicTS.Client().Get(publicTS.URL + registration.RouteInitAPIFlow + "?prefill=" + token)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+
+			bar := gjson.GetBytes(body, `ui.nodes.#(attributes.name=="traits.bar").attributes`)
+			assert.Equal(t, "baz", bar.Get("value").String(), "%s", body)
+			assert.Equal(t, "hidden", bar.Get("type").String(), "%s", body)
+			assert.False(t, gjson.GetBytes(body, `ui.nodes.#(attributes.name=="password").attributes.value`).Exists(), "%s", body)
+
+			res, err = publicTS.Client().Get(publicTS.URL + registration.RouteInitAPIFlow + "?prefill=" + token + "x")
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
 	})
 
 	t.Run("flow=browser", func(t *testing.T) {
@@ -198,3 +229,58 @@ func TestGetFlow(t *testing.T) {
 		run(t, public)
 	})
 }
+
+func TestSimulateFlow(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/password.schema.json")
+	testhelpers.StrategyEnable(t, conf, identity.CredentialsTypePassword.String(), true)
+	conf.MustSet(config.ViperKeySelfServiceVerificationEnabled, true)
+	conf.MustSet(config.HookStrategyKey(config.ViperKeySelfServiceRegistrationAfter, identity.CredentialsTypePassword.String()),
+		[]config.SelfServiceHook{{Name: "session"}})
+	_, admin := testhelpers.NewKratosServerWithRouters(t, reg, x.NewRouterPublic(), x.NewRouterAdmin())
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"email":"taken@ory.sh"}`)
+	i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+		Type:        identity.CredentialsTypePassword,
+		Identifiers: []string{"taken@ory.sh"},
+		Config:      []byte(`{"hashed_password":"foo"}`),
+	})
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+
+	simulate := func(t *testing.T, body string, expectedStatus int) []byte {
+		res, err := admin.Client().Post(admin.URL+registration.RouteSimulateFlow, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, expectedStatus, res.StatusCode, "%s", b)
+		return b
+	}
+
+	t.Run("case=valid payload", func(t *testing.T) {
+		body := simulate(t, `{"method":"password","payload":{"method":"password","traits.email":"new@ory.sh","password":"a-very-secret-password-8e3c"}}`, http.StatusOK)
+		assert.True(t, gjson.GetBytes(body, "simulated").Bool(), "%s", body)
+		assert.True(t, gjson.GetBytes(body, "valid").Bool(), "%s", body)
+		assert.Equal(t, `["verification","session"]`, gjson.GetBytes(body, "hooks.after").Raw, "%s", body)
+
+		_, _, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(context.Background(), identity.CredentialsTypePassword, "new@ory.sh")
+		require.Error(t, err, "the identity must not be created")
+	})
+
+	t.Run("case=invalid traits", func(t *testing.T) {
+		body := simulate(t, `{"method":"password","payload":{"method":"password","traits.email":"not-an-email","password":"a-very-secret-password-8e3c"}}`, http.StatusOK)
+		assert.False(t, gjson.GetBytes(body, "valid").Bool(), "%s", body)
+		assert.NotEmpty(t, gjson.GetBytes(body, "messages").Array(), "%s", body)
+	})
+
+	t.Run("case=duplicate identifier", func(t *testing.T) {
+		body := simulate(t, `{"method":"password","payload":{"method":"password","traits.email":"taken@ory.sh","password":"a-very-secret-password-8e3c"}}`, http.StatusOK)
+		assert.False(t, gjson.GetBytes(body, "valid").Bool(), "%s", body)
+		assert.EqualValues(t, text.ErrorValidationDuplicateCredentials, gjson.GetBytes(body, "messages.0.id").Int(), "%s", body)
+	})
+
+	t.Run("case=unknown schema", func(t *testing.T) {
+		simulate(t, `{"method":"password","schema_id":"unknown","payload":{}}`, http.StatusBadRequest)
+	})
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/ory/x/sqlcon"
 
+	"github.com/ory/kratos/attempt"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/feature"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
@@ -68,6 +69,8 @@ type (
 		HooksProvider
 		x.LoggingProvider
 		x.WriterProvider
+		x.ClockProvider
+		attempt.ManagementProvider
 	}
 	HookExecutor struct {
 		d executorDependencies
@@ -82,6 +85,7 @@ func NewHookExecutor(d executorDependencies) *HookExecutor {
 }
 
 func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity) error {
+	r = r.WithContext(feature.WithIdentity(feature.WithFlow(r.Context(), a.ID), i.ID))
 	e.d.Logger().
 		WithRequest(r).
 		WithField("identity_id", i.ID).
@@ -117,7 +121,7 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 		return err
 		// We're now creating the identity because any of the hooks could trigger a "redirect" or a "session" which
 		// would imply that the identity has to exist already.
-	} else if err := e.d.IdentityManager().Create(r.Context(), i); err != nil {
+	} else if err := e.d.IdentityManager().Create(r.Context(), i, identity.ManagerEnforceEmailDomainPolicy, identity.ManagerEnforceReservedNames); err != nil {
 		if errors.Is(err, sqlcon.ErrUniqueViolation) {
 			return schema.NewDuplicateCredentialsError()
 		}
@@ -128,8 +132,10 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 		WithRequest(r).
 		WithField("identity_id", i.ID).
 		Info("A new identity has registered using self-service registration.")
+	e.d.AttemptManager().RecordSuccess(r, attempt.FlowRegistration, a.ID, ct, i)
 
-	s := session.NewActiveSession(i, e.d.Config(r.Context()), time.Now().UTC())
+	s := session.NewActiveSession(i, e.d.Config(r.Context()), e.d.Clock().Now().UTC())
+	s.UpstreamSession = session.UpstreamSessionFromContext(r.Context())
 	e.d.Logger().
 		WithRequest(r).
 		WithField("identity_id", i.ID).
@@ -176,6 +182,7 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 }
 
 func (e *HookExecutor) PreRegistrationHook(w http.ResponseWriter, r *http.Request, a *Flow) error {
+	r = r.WithContext(feature.WithFlow(r.Context(), a.ID))
 	for _, executor := range e.d.PreRegistrationHooks(r.Context()) {
 		if err := executor.ExecuteRegistrationPreHook(w, r, a); err != nil {
 			return err
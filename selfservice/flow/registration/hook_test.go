@@ -36,7 +36,7 @@ func TestRegistrationExecutor(t *testing.T) {
 				router := httprouter.New()
 				handleErr := testhelpers.SelfServiceHookRegistrationErrorHandler
 				router.GET("/registration/pre", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-					if handleErr(t, w, r, reg.RegistrationHookExecutor().PreRegistrationHook(w, r, registration.NewFlow(conf, time.Minute, x.FakeCSRFToken, r, ft))) {
+					if handleErr(t, w, r, reg.RegistrationHookExecutor().PreRegistrationHook(w, r, registration.NewFlow(conf, time.Now(), time.Minute, x.FakeCSRFToken, r, ft))) {
 						_, _ = w.Write([]byte("ok"))
 					}
 				})
@@ -45,7 +45,7 @@ func TestRegistrationExecutor(t *testing.T) {
 					if i == nil {
 						i = testhelpers.SelfServiceHookFakeIdentity(t)
 					}
-					a := registration.NewFlow(conf, time.Minute, x.FakeCSRFToken, r, ft)
+					a := registration.NewFlow(conf, time.Now(), time.Minute, x.FakeCSRFToken, r, ft)
 					a.RequestURL = x.RequestURL(r).String()
 					_ = handleErr(t, w, r, reg.RegistrationHookExecutor().PostRegistrationHook(w, r, identity.CredentialsType(strategy), a, i))
 				})
@@ -2,6 +2,7 @@ package registration
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 
 	"github.com/ory/kratos/ui/node"
@@ -20,6 +21,13 @@ type Strategy interface {
 	Register(w http.ResponseWriter, r *http.Request, f *Flow, i *identity.Identity) (err error)
 }
 
+// SimulationStrategy is implemented by strategies which can check a registration payload without side effects.
+// SimulateRegistration populates the identity from the payload and validates it but does not persist it.
+type SimulationStrategy interface {
+	Strategy
+	SimulateRegistration(ctx context.Context, i *identity.Identity, payload json.RawMessage) error
+}
+
 type Strategies []Strategy
 
 func (s Strategies) Strategy(id identity.CredentialsType) (Strategy, error) {
@@ -51,6 +51,10 @@ func EnsureCSRF(
 
 		return nil
 	default:
+		if x.IsCSRFVerifiedByMode(r) {
+			return nil
+		}
+
 		if !nosurf.VerifyToken(generator(r), actual) {
 			return errors.WithStack(x.ErrInvalidCSRFToken)
 		}
@@ -30,10 +30,12 @@ type (
 		config.Provider
 		errorx.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
 		x.LoggingProvider
 
 		HandlerProvider
 		FlowPersistenceProvider
+		PrivilegedCodeManagerProvider
 	}
 
 	ErrorHandlerProvider interface{ SettingsFlowErrorHandler() *ErrorHandler }
@@ -68,6 +70,9 @@ func NewFlowExpiredError(at time.Time) *FlowExpiredError {
 	}
 }
 
+// FlowExpired marks the error as an expired flow for the error routes at `selfservice.flows.error.routes`.
+func (e *FlowExpiredError) FlowExpired() {}
+
 func NewErrorHandler(d errorHandlerDependencies) *ErrorHandler {
 	return &ErrorHandler{d: d}
 }
@@ -137,17 +142,25 @@ func (s *ErrorHandler) WriteFlowError(
 		return
 	}
 
+	code := x.RecoverStatusCode(err, http.StatusBadRequest)
 	if e := new(FlowNeedsReAuth); errors.As(err, &e) {
-		s.reauthenticate(w, r, f, err)
-		return
-	}
+		sent, sendErr := s.d.SettingsPrivilegedCodeManager().Send(r.Context(), f, id)
+		if sendErr != nil {
+			s.forward(w, r, f, sendErr)
+			return
+		} else if !sent {
+			s.reauthenticate(w, r, f, err)
+			return
+		}
 
-	if err := f.UI.ParseError(group, err); err != nil {
+		// The flow is shown again and asks for the code like it asks for missing fields.
+		code = http.StatusBadRequest
+	} else if err := f.UI.ParseError(group, err); err != nil {
 		s.forward(w, r, f, err)
 		return
 	}
 
-	if err := sortNodes(f.UI.Nodes, id.SchemaURL); err != nil {
+	if err := SortNodes(f.UI.Nodes, id.SchemaURL); err != nil {
 		s.forward(w, r, f, err)
 		return
 	}
@@ -167,7 +180,7 @@ func (s *ErrorHandler) WriteFlowError(
 		s.forward(w, r, updatedFlow, innerErr)
 	}
 
-	s.d.Writer().WriteCode(w, r, x.RecoverStatusCode(err, http.StatusBadRequest), updatedFlow)
+	s.d.Writer().WriteCode(w, r, code, updatedFlow)
 }
 
 func (s *ErrorHandler) forward(w http.ResponseWriter, r *http.Request, rr *Flow, err error) {
@@ -86,6 +86,25 @@ type Flow struct {
 	// required: true
 	State State `json:"state" faker:"-" db:"state"`
 
+	// The privileged code columns are read-only for pop and only change through the FlowPersister's privileged
+	// code methods, so that updating a flow which was loaded earlier can not reset them.
+
+	// PrivilegedCodeHMAC is the HMAC of the privileged code which was sent for this flow.
+	PrivilegedCodeHMAC sqlxx.NullString `json:"-" faker:"-" db:"privileged_code_hmac" rw:"r"`
+	// PrivilegedCodeExpiresAt is the time the privileged code expires at.
+	PrivilegedCodeExpiresAt sqlxx.NullTime `json:"-" faker:"-" db:"privileged_code_expires_at" rw:"r"`
+	// PrivilegedCodeAttempts counts how often a wrong privileged code was entered.
+	PrivilegedCodeAttempts int `json:"-" faker:"-" db:"privileged_code_attempts" rw:"r"`
+	// PrivilegedCodeSentAt is the time the last privileged code was sent for this flow.
+	PrivilegedCodeSentAt sqlxx.NullTime `json:"-" faker:"-" db:"privileged_code_sent_at" rw:"r"`
+	// PrivilegedCodeSends counts how many privileged codes were sent for this flow.
+	PrivilegedCodeSends int `json:"-" faker:"-" db:"privileged_code_sends" rw:"r"`
+	// PrivilegedAt is the time a privileged code was confirmed in this flow.
+	PrivilegedAt sqlxx.NullTime `json:"-" faker:"-" db:"privileged_at" rw:"r"`
+	// RequiresPrivilegedCode is set while handling a submission whose privileged changes have to be confirmed
+	// with a privileged code, regardless of when the session was authenticated.
+	RequiresPrivilegedCode bool `json:"-" faker:"-" db:"-"`
+
 	// IdentityID is a helper struct field for gobuffalo.pop.
 	IdentityID uuid.UUID `json:"-" faker:"-" db:"identity_id"`
 	// CreatedAt is a helper struct field for gobuffalo.pop.
@@ -112,8 +131,8 @@ type APIFlowResponse struct {
 	Identity *identity.Identity `json:"identity"`
 }
 
-func NewFlow(conf *config.Config, exp time.Duration, r *http.Request, i *identity.Identity, ft flow.Type) *Flow {
-	now := time.Now().UTC()
+func NewFlow(conf *config.Config, now time.Time, exp time.Duration, r *http.Request, i *identity.Identity, ft flow.Type) *Flow {
+	now = now.UTC()
 	id := x.NewUUID()
 	return &Flow{
 		ID:         id,
@@ -155,8 +174,8 @@ func (f *Flow) AppendTo(src *url.URL) *url.URL {
 	return flow.AppendFlowTo(src, f.ID)
 }
 
-func (f *Flow) Valid(s *session.Session) error {
-	if f.ExpiresAt.Before(time.Now().UTC()) {
+func (f *Flow) Valid(s *session.Session, now time.Time) error {
+	if f.ExpiresAt.Before(now) {
 		return errors.WithStack(NewFlowExpiredError(f.ExpiresAt))
 	}
 
@@ -38,7 +38,7 @@ func TestNewFlow(t *testing.T) {
 
 	id := &identity.Identity{ID: x.NewUUID()}
 	t.Run("case=0", func(t *testing.T) {
-		r := settings.NewFlow(conf, 0, &http.Request{URL: urlx.ParseOrPanic("/"),
+		r := settings.NewFlow(conf, time.Now(), 0, &http.Request{URL: urlx.ParseOrPanic("/"),
 			Host: "ory.sh", TLS: &tls.ConnectionState{}}, id, flow.TypeBrowser)
 		assert.Equal(t, r.IssuedAt, r.ExpiresAt)
 		assert.Equal(t, flow.TypeBrowser, r.Type)
@@ -46,7 +46,7 @@ func TestNewFlow(t *testing.T) {
 	})
 
 	t.Run("case=1", func(t *testing.T) {
-		r := settings.NewFlow(conf, 0, &http.Request{
+		r := settings.NewFlow(conf, time.Now(), 0, &http.Request{
 			URL:  urlx.ParseOrPanic("/?refresh=true"),
 			Host: "ory.sh"}, id, flow.TypeAPI)
 		assert.Equal(t, r.IssuedAt, r.ExpiresAt)
@@ -55,7 +55,7 @@ func TestNewFlow(t *testing.T) {
 	})
 
 	t.Run("case=2", func(t *testing.T) {
-		r := settings.NewFlow(conf, 0, &http.Request{
+		r := settings.NewFlow(conf, time.Now(), 0, &http.Request{
 			URL:  urlx.ParseOrPanic("https://ory.sh/"),
 			Host: "ory.sh"}, id, flow.TypeBrowser)
 		assert.Equal(t, "https://ory.sh/", r.RequestURL)
@@ -74,7 +74,7 @@ func TestFlow(t *testing.T) {
 	}{
 		{
 			r: settings.NewFlow(
-				conf,
+				conf, time.Now(),
 				time.Hour,
 				&http.Request{URL: urlx.ParseOrPanic("http://foo/bar/baz"), Host: "foo"},
 				&identity.Identity{ID: alice},
@@ -84,7 +84,7 @@ func TestFlow(t *testing.T) {
 		},
 		{
 			r: settings.NewFlow(
-				conf,
+				conf, time.Now(),
 				time.Hour,
 				&http.Request{URL: urlx.ParseOrPanic("http://foo/bar/baz"), Host: "foo"},
 				&identity.Identity{ID: alice},
@@ -95,7 +95,7 @@ func TestFlow(t *testing.T) {
 		},
 		{
 			r: settings.NewFlow(
-				conf,
+				conf, time.Now(),
 				-time.Hour,
 				&http.Request{URL: urlx.ParseOrPanic("http://foo/bar/baz"), Host: "foo"},
 				&identity.Identity{ID: alice},
@@ -106,7 +106,7 @@ func TestFlow(t *testing.T) {
 		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
-			err := tc.r.Valid(tc.s)
+			err := tc.r.Valid(tc.s, time.Now())
 			if tc.expectErr {
 				require.Error(t, err)
 				return
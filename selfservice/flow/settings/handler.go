@@ -2,7 +2,6 @@ package settings
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/ory/kratos/ui/node"
 	"github.com/ory/x/sqlcon"
@@ -13,8 +12,10 @@ import (
 	"github.com/ory/herodot"
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/feature"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/reputation"
 	"github.com/ory/kratos/selfservice/errorx"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/session"
@@ -41,6 +42,7 @@ type (
 	handlerDependencies interface {
 		x.CSRFProvider
 		x.WriterProvider
+		x.ClockProvider
 		x.LoggingProvider
 
 		config.Provider
@@ -53,6 +55,7 @@ type (
 		identity.ValidationProvider
 		identity.ManagementProvider
 		identity.PrivilegedPoolProvider
+		identity.RelationshipPersistenceProvider
 
 		errorx.ManagementProvider
 
@@ -60,6 +63,9 @@ type (
 		FlowPersistenceProvider
 		StrategyProvider
 		HookExecutorProvider
+		PrivilegedCodeManagerProvider
+
+		reputation.Provider
 
 		schema.IdentityTraitsProvider
 	}
@@ -96,18 +102,39 @@ func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 }
 
 func (h *Handler) NewFlow(w http.ResponseWriter, r *http.Request, i *identity.Identity, ft flow.Type) (*Flow, error) {
-	f := NewFlow(h.d.Config(r.Context()), h.d.Config(r.Context()).SelfServiceFlowSettingsFlowLifespan(), r, i, ft)
+	if identity.IsServiceAccount(h.d.Config(r.Context()), i) {
+		return nil, errors.WithStack(identity.ErrServiceAccountSelfService)
+	}
+
+	restrictions, err := identity.FindChildRestrictions(r.Context(), h.d.Config(r.Context()), h.d, i)
+	if err != nil {
+		return nil, err
+	} else if restrictions.Restricts(identity.ChildRestrictedFlowSettings, "") {
+		return nil, errors.WithStack(identity.ErrChildSelfService)
+	}
+
+	lifespan, err := flow.RequestedLifespan(r, h.d.Config(r.Context()).SelfServiceFlowSettingsFlowLifespan(), h.d.Config(r.Context()).SelfServiceFlowSettingsLifespanBounds())
+	if err != nil {
+		return nil, err
+	}
+
+	f := NewFlow(h.d.Config(r.Context()), h.d.Clock().Now(), lifespan, r, i, ft)
+	r = r.WithContext(feature.WithIdentity(feature.WithFlow(r.Context(), f.ID), i.ID))
 	for _, strategy := range h.d.SettingsStrategies(r.Context()) {
 		if err := h.d.ContinuityManager().Abort(r.Context(), w, r, ContinuityKey(strategy.SettingsStrategyID())); err != nil {
 			return nil, err
 		}
 
+		if restrictions.Restricts(identity.ChildRestrictedFlowSettings, strategy.SettingsStrategyID()) {
+			continue
+		}
+
 		if err := strategy.PopulateSettingsMethod(r, i, f); err != nil {
 			return nil, err
 		}
 	}
 
-	if err := sortNodes(f.UI.Nodes, h.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String()); err != nil {
+	if err := SortNodes(f.UI.Nodes, h.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String()); err != nil {
 		return nil, err
 	}
 
@@ -118,6 +145,18 @@ func (h *Handler) NewFlow(w http.ResponseWriter, r *http.Request, i *identity.Id
 	return f, nil
 }
 
+// nolint:deadcode,unused
+// swagger:parameters initializeSelfServiceSettingsViaBrowserFlow initializeSelfServiceSettingsViaAPIFlow
+type initializeSelfServiceSettingsFlow struct {
+	// Requested Lifespan
+	//
+	// A lifespan other than the configured one, for example `24h`. It must be within the bounds configured at
+	// `selfservice.flows.settings.lifespan_bounds`.
+	//
+	// in: query
+	Lifespan string `json:"lifespan"`
+}
+
 // swagger:route GET /self-service/settings/api public initializeSelfServiceSettingsViaAPIFlow
 //
 // Initialize Settings Flow for API Clients
@@ -285,7 +324,7 @@ func (h *Handler) fetchFlow(w http.ResponseWriter, r *http.Request, checkSession
 		}
 	}
 
-	if pr.ExpiresAt.Before(time.Now().UTC()) {
+	if pr.ExpiresAt.Before(h.d.Clock().Now()) {
 		if pr.Type == flow.TypeBrowser {
 			h.d.Writer().WriteError(w, r, errors.WithStack(x.ErrGone.
 				WithReason("The settings flow has expired. Redirect the user to the settings flow init endpoint to initialize a new settings flow.").
@@ -335,12 +374,15 @@ type submitSelfServiceSettingsFlowBody struct{}
 //   - HTTP 400 on form validation errors.
 //   - HTTP 401 when the endpoint is called without a valid session token.
 //   - HTTP 403 when `selfservice.flows.settings.privileged_session_max_age` was reached.
-//     Implies that the user needs to re-authenticate.
+//     Implies that the user needs to re-authenticate. If `selfservice.flows.settings.privileged_code.enabled`
+//     is set and the identity has a verified email address, HTTP 400 with a code sent to that address is
+//     returned instead. Submitting the form again with the code in `privileged_code` confirms the changes.
 //
 // Browser flows expect `application/x-www-form-urlencoded` to be sent in the body and responds with
 //   - a HTTP 302 redirect to the post/after settings URL or the `return_to` value if it was set and if the flow succeeded;
 //   - a HTTP 302 redirect to the Settings UI URL with the flow ID containing the validation errors otherwise.
-//   - a HTTP 302 redirect to the login endpoint when `selfservice.flows.settings.privileged_session_max_age` was reached.
+//   - a HTTP 302 redirect to the login endpoint when `selfservice.flows.settings.privileged_session_max_age` was reached,
+//     or to the Settings UI URL asking for a code if privileged codes are enabled, see above.
 //
 // More information can be found at [ORY Kratos User Settings & Profile Management Documentation](../self-service/flows/user-settings).
 //
@@ -390,14 +432,48 @@ func (h *Handler) submitSettingsFlow(w http.ResponseWriter, r *http.Request, ps
 		return
 	}
 
-	if err := f.Valid(ss); err != nil {
+	if err := f.Valid(ss, h.d.Clock().Now()); err != nil {
 		h.d.SettingsFlowErrorHandler().WriteFlowError(w, r, node.DefaultGroup, f, ss.Identity, err)
 		return
 	}
+	r = r.WithContext(feature.WithIdentity(feature.WithFlow(r.Context(), f.ID), ss.Identity.ID))
+
+	if err := h.d.IPReputation().Throttle(r); err != nil {
+		h.d.SettingsFlowErrorHandler().WriteFlowError(w, r, node.DefaultGroup, f, ss.Identity, err)
+		return
+	}
+
+	if err := h.d.SettingsPrivilegedCodeManager().VerifyFromRequest(r, f); err != nil {
+		h.d.SettingsFlowErrorHandler().WriteFlowError(w, r, node.DefaultGroup, f, ss.Identity, err)
+		return
+	}
+
+	// Flagged requests have to confirm privileged changes with a code even if the session is recent. Identities
+	// which can not receive a code are not affected because signing in again would not help them.
+	f.RequiresPrivilegedCode = h.d.SettingsPrivilegedCodeManager().CanSend(r.Context(), ss.Identity) &&
+		h.d.IPReputation().RequiresEmailCode(r)
+
+	restrictions, err := identity.FindChildRestrictions(r.Context(), h.d.Config(r.Context()), h.d, ss.Identity)
+	if err != nil {
+		h.d.SettingsFlowErrorHandler().WriteFlowError(w, r, node.DefaultGroup, f, ss.Identity, err)
+		return
+	} else if restrictions.Restricts(identity.ChildRestrictedFlowSettings, "") {
+		h.d.SettingsFlowErrorHandler().WriteFlowError(w, r, node.DefaultGroup, f, ss.Identity, errors.WithStack(identity.ErrChildSelfService))
+		return
+	}
 
 	var s string
 	var updateContext *UpdateContext
 	for _, strat := range h.d.AllSettingsStrategies() {
+		if restrictions.Restricts(identity.ChildRestrictedFlowSettings, strat.SettingsStrategyID()) {
+			// Restricted methods must be rejected before the strategy handles the request because some
+			// strategies store changes right away.
+			if err := flow.MethodEnabledAndAllowedFromRequest(r, strat.SettingsStrategyID(), h.d); err == nil {
+				h.d.SettingsFlowErrorHandler().WriteFlowError(w, r, strat.NodeGroup(), f, ss.Identity, errors.WithStack(identity.ErrChildSelfService))
+				return
+			}
+		}
+
 		uc, err := strat.Settings(w, r, f, ss)
 		if errors.Is(err, flow.ErrStrategyNotResponsible) {
 			continue
@@ -419,6 +495,11 @@ func (h *Handler) submitSettingsFlow(w http.ResponseWriter, r *http.Request, ps
 		return
 	}
 
+	if restrictions.Restricts(identity.ChildRestrictedFlowSettings, s) {
+		h.d.SettingsFlowErrorHandler().WriteFlowError(w, r, node.DefaultGroup, f, ss.Identity, errors.WithStack(identity.ErrChildSelfService))
+		return
+	}
+
 	if err := h.d.SettingsHookExecutor().PostSettingsHook(w, r, s, updateContext, updateContext.GetIdentityToUpdate()); err != nil {
 		h.d.SettingsFlowErrorHandler().WriteFlowError(w, r, node.DefaultGroup, f, ss.Identity, err)
 		return
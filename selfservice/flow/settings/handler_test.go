@@ -2,6 +2,7 @@ package settings_test
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"testing"
 	"time"
@@ -43,13 +44,15 @@ func TestHandler(t *testing.T) {
 
 	conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, "1ns")
 	primaryIdentity := &identity.Identity{ID: x.NewUUID(), Traits: identity.Traits(`{}`)}
+	childIdentity := &identity.Identity{ID: x.NewUUID(), Traits: identity.Traits(`{}`)}
 	publicTS, adminTS, clients := testhelpers.NewSettingsAPIServer(t, reg, map[string]*identity.Identity{
 		"primary":   primaryIdentity,
-		"secondary": {ID: x.NewUUID(), Traits: identity.Traits(`{}`)}})
+		"secondary": {ID: x.NewUUID(), Traits: identity.Traits(`{}`)},
+		"child":     childIdentity})
 
-	primaryUser, otherUser := clients["primary"], clients["secondary"]
+	primaryUser, otherUser, childUser := clients["primary"], clients["secondary"], clients["child"]
 	newExpiredFlow := func() *settings.Flow {
-		return settings.NewFlow(conf, -time.Minute,
+		return settings.NewFlow(conf, time.Now(), -time.Minute,
 			&http.Request{URL: urlx.ParseOrPanic(publicTS.URL + login.RouteInitBrowserFlow)},
 			primaryIdentity, flow.TypeBrowser)
 	}
@@ -133,5 +136,41 @@ func TestHandler(t *testing.T) {
 				assert.Equal(t, int64(http.StatusForbidden), gjson.GetBytes(err.(*kratos.GenericOpenAPIError).Body(), "error.code").Int(), "should return a 403 error because the identities from the cookies do not match")
 			})
 		})
+
+		t.Run("description=should restrict child accounts", func(t *testing.T) {
+			require.NoError(t, reg.IdentityRelationshipPersister().CreateIdentityRelationship(context.Background(), &identity.Relationship{
+				Type: identity.RelationshipTypeGuardian, IdentityID: primaryIdentity.ID, RelatedIdentityID: childIdentity.ID}))
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyIdentityRelationshipsChildRestrictions, []string{})
+			})
+
+			hasPasswordNodes := func(t *testing.T, f *kratos.SettingsFlow) bool {
+				nodes, err := json.Marshal(f.Ui.Nodes)
+				require.NoError(t, err)
+				return gjson.GetBytes(nodes, `#(attributes.value=="password")`).Exists()
+			}
+
+			t.Run("case=restricted methods are neither offered nor accepted", func(t *testing.T) {
+				conf.MustSet(config.ViperKeyIdentityRelationshipsChildRestrictions, []string{"settings.password"})
+
+				assert.True(t, hasPasswordNodes(t, testhelpers.InitializeSettingsFlowViaAPI(t, primaryUser, publicTS)), "guardians are not restricted")
+
+				f := testhelpers.InitializeSettingsFlowViaAPI(t, childUser, publicTS)
+				assert.False(t, hasPasswordNodes(t, f))
+
+				body, res := testhelpers.SettingsMakeRequest(t, true, f, childUser, `{"method":"password","password":"`+x.NewUUID().String()+`"}`)
+				assert.Equal(t, http.StatusForbidden, res.StatusCode, "%s", body)
+				assert.Equal(t, identity.ErrChildSelfService.ReasonField, gjson.Get(body, "error.reason").String(), "%s", body)
+			})
+
+			t.Run("case=restricted flows can not be started", func(t *testing.T) {
+				conf.MustSet(config.ViperKeyIdentityRelationshipsChildRestrictions, []string{"settings"})
+
+				res, err := childUser.Get(publicTS.URL + settings.RouteInitAPIFlow)
+				require.NoError(t, err)
+				defer res.Body.Close()
+				assert.Equal(t, http.StatusForbidden, res.StatusCode)
+			})
+		})
 	})
 }
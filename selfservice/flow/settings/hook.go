@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/ui/node"
@@ -16,8 +15,10 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/feature"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
 )
 
@@ -38,12 +39,14 @@ type (
 		identity.ManagementProvider
 		identity.ValidationProvider
 		config.Provider
+		session.PersistenceProvider
 
 		HooksProvider
 		FlowPersistenceProvider
 
 		x.LoggingProvider
 		x.WriterProvider
+		x.ClockProvider
 	}
 	HookExecutor struct {
 		d executorDependencies
@@ -94,6 +97,7 @@ func WithCallback(cb func(ctxUpdate *UpdateContext) error) func(o *postSettingsH
 }
 
 func (e *HookExecutor) PostSettingsHook(w http.ResponseWriter, r *http.Request, settingsType string, ctxUpdate *UpdateContext, i *identity.Identity, opts ...PostSettingsHookOption) error {
+	r = r.WithContext(feature.WithIdentity(feature.WithFlow(r.Context(), ctxUpdate.Flow.ID), i.ID))
 	e.d.Logger().
 		WithRequest(r).
 		WithField("identity_id", i.ID).
@@ -126,9 +130,9 @@ func (e *HookExecutor) PostSettingsHook(w http.ResponseWriter, r *http.Request,
 		e.d.Logger().WithRequest(r).WithFields(logFields).Debug("ExecuteSettingsPrePersistHook completed successfully.")
 	}
 
-	options := []identity.ManagerOption{identity.ManagerExposeValidationErrorsForInternalTypeAssertion}
-	ttl := e.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()
-	if ctxUpdate.Session.AuthenticatedAt.Add(ttl).After(time.Now()) {
+	options := []identity.ManagerOption{identity.ManagerExposeValidationErrorsForInternalTypeAssertion, identity.ManagerEnforceEmailDomainPolicy, identity.ManagerEnforceReservedNames}
+	ttl := e.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(settingsType)
+	if ctxUpdate.AuthenticatedAt().Add(ttl).After(e.d.Clock().Now()) {
 		options = append(options, identity.ManagerAllowWriteProtectedTraits)
 	}
 
@@ -147,6 +151,13 @@ func (e *HookExecutor) PostSettingsHook(w http.ResponseWriter, r *http.Request,
 		WithField("identity_id", i.ID).
 		Debug("An identity's settings have been updated.")
 
+	if ctxUpdate.Session.RequiresPasswordReset() && !i.PasswordExpired() {
+		ctxUpdate.Session.CompletePasswordReset()
+		if err := e.d.SessionPersister().UpdateSession(r.Context(), ctxUpdate.Session); err != nil {
+			return err
+		}
+	}
+
 	ctxUpdate.UpdateIdentity(i)
 	ctxUpdate.Flow.State = StateSuccess
 	if config.cb != nil {
@@ -2,6 +2,7 @@ package settings
 
 import (
 	"context"
+	"time"
 
 	"github.com/gofrs/uuid"
 )
@@ -11,6 +12,20 @@ type (
 		CreateSettingsFlow(context.Context, *Flow) error
 		GetSettingsFlow(ctx context.Context, id uuid.UUID) (*Flow, error)
 		UpdateSettingsFlow(context.Context, *Flow) error
+
+		// IssueSettingsFlowPrivilegedCode stores the flow's privileged code HMAC, expiry, and sent at time and
+		// resets its attempts. It returns sqlcon.ErrNoRows if maxSends codes were already sent for the flow or if
+		// the last one was sent after lastSentBefore.
+		IssueSettingsFlowPrivilegedCode(ctx context.Context, f *Flow, lastSentBefore time.Time, maxSends int) error
+
+		// FailSettingsFlowPrivilegedCode counts a wrong attempt to enter the privileged code with the given HMAC.
+		// It returns sqlcon.ErrNoRows if the code was replaced or maxAttempts wrong attempts were counted already.
+		FailSettingsFlowPrivilegedCode(ctx context.Context, id uuid.UUID, hmac string, maxAttempts int) error
+
+		// ConfirmSettingsFlowPrivilegedCode marks the flow as privileged at the given time and removes the
+		// privileged code with the given HMAC. It returns sqlcon.ErrNoRows if the code was replaced, confirmed
+		// already, or maxAttempts wrong attempts were counted, so that each code is confirmed at most once.
+		ConfirmSettingsFlowPrivilegedCode(ctx context.Context, id uuid.UUID, hmac string, maxAttempts int, at time.Time) error
 	}
 	FlowPersistenceProvider interface {
 		SettingsFlowPersister() FlowPersister
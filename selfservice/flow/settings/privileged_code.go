@@ -0,0 +1,234 @@
+package settings
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"crypto/subtle"
+	_ "embed"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/randx"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/courier"
+	templates "github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+//go:embed .schema/privileged_code.schema.json
+var privilegedCodeSchema []byte
+
+const privilegedCodeNode = "privileged_code"
+
+type (
+	privilegedCodeDependencies interface {
+		config.Provider
+		courier.Provider
+		x.ClockProvider
+		x.LoggingProvider
+
+		FlowPersistenceProvider
+	}
+
+	PrivilegedCodeManagerProvider interface {
+		SettingsPrivilegedCodeManager() *PrivilegedCodeManager
+	}
+
+	// PrivilegedCodeManager lets identities confirm changes which require a more recent sign in with a one-time code
+	// sent to their verified email address instead of signing in again.
+	PrivilegedCodeManager struct {
+		d  privilegedCodeDependencies
+		hd *decoderx.HTTP
+	}
+)
+
+func NewPrivilegedCodeManager(d privilegedCodeDependencies) *PrivilegedCodeManager {
+	return &PrivilegedCodeManager{d: d, hd: decoderx.NewHTTP()}
+}
+
+// Send issues a new code for the flow, replacing any code issued before, and sends it to the identity's first
+// verified email address. It returns false without sending anything if privileged codes are disabled, if the
+// identity has no verified email address, or if all codes for the flow were sent already, in which case the
+// identity has to sign in again. If the last code was sent too recently, no new code is sent and the identity is
+// asked to enter that one instead.
+func (m *PrivilegedCodeManager) Send(ctx context.Context, f *Flow, i *identity.Identity) (bool, error) {
+	if !m.CanSend(ctx, i) {
+		return false, nil
+	}
+
+	c := m.d.Config(ctx)
+	address := verifiedEmailAddress(i)
+	now := m.d.Clock().Now().UTC()
+
+	code := randx.MustString(6, randx.Numeric)
+	issued := *f
+	issued.PrivilegedCodeHMAC = sqlxx.NullString(m.hmac(c.SecretsDefault()[0], code))
+	issued.PrivilegedCodeExpiresAt = sqlxx.NullTime(now.Add(c.SelfServiceFlowSettingsPrivilegedCodeLifespan()))
+	issued.PrivilegedCodeSentAt = sqlxx.NullTime(now)
+	if err := m.d.SettingsFlowPersister().IssueSettingsFlowPrivilegedCode(ctx, &issued,
+		now.Add(-c.SelfServiceFlowSettingsPrivilegedCodeResendInterval()),
+		c.SelfServiceFlowSettingsPrivilegedCodeMaxSends(),
+	); errors.Is(err, sqlcon.ErrNoRows) {
+		return m.throttled(ctx, f)
+	} else if err != nil {
+		return false, err
+	}
+
+	f.PrivilegedCodeHMAC = issued.PrivilegedCodeHMAC
+	f.PrivilegedCodeExpiresAt = issued.PrivilegedCodeExpiresAt
+	f.PrivilegedCodeSentAt = issued.PrivilegedCodeSentAt
+	f.PrivilegedCodeAttempts = 0
+	f.PrivilegedCodeSends++
+
+	ctx, delivery := courier.WithFlowDelivery(ctx)
+	if _, err := m.d.Courier(ctx).QueueEmail(ctx, templates.NewSettingsPrivilegedCode(c, &templates.SettingsPrivilegedCodeModel{
+		To:   address.Value,
+		Code: code,
+	})); err != nil {
+		return false, err
+	}
+
+	f.UI.Nodes.Upsert(m.node())
+	f.UI.Messages.Add(text.NewInfoSelfServiceSettingsPrivilegedCodeSent(address.Value))
+	if delivery.Delayed {
+		f.UI.Messages.Add(text.NewInfoSelfServiceCourierDelayed())
+	}
+
+	m.d.Logger().
+		WithField("identity_id", i.ID).
+		WithField("settings_flow_id", f.ID).
+		Debug("Sent a privileged code to confirm changes in the settings flow.")
+	return true, nil
+}
+
+// throttled handles a code which was not sent because of the resend interval or the maximum number of codes per
+// flow. The flow is loaded again because f might not reflect codes sent by concurrent requests.
+func (m *PrivilegedCodeManager) throttled(ctx context.Context, f *Flow) (bool, error) {
+	current, err := m.d.SettingsFlowPersister().GetSettingsFlow(ctx, f.ID)
+	if err != nil {
+		return false, err
+	}
+
+	c := m.d.Config(ctx)
+	if current.PrivilegedCodeSends >= c.SelfServiceFlowSettingsPrivilegedCodeMaxSends() {
+		m.d.Logger().
+			WithField("settings_flow_id", f.ID).
+			Debug("Not sending a privileged code because all codes for the settings flow were sent already.")
+		return false, nil
+	}
+
+	f.UI.Nodes.Upsert(m.node())
+	f.UI.Messages.Add(text.NewInfoSelfServiceSettingsPrivilegedCodeResendThrottled(
+		time.Time(current.PrivilegedCodeSentAt).Add(c.SelfServiceFlowSettingsPrivilegedCodeResendInterval())))
+	return true, nil
+}
+
+func (m *PrivilegedCodeManager) node() *node.Node {
+	return node.NewInputField(privilegedCodeNode, nil, node.DefaultGroup, node.InputAttributeTypeText, node.WithRequiredInputAttribute).
+		WithMetaLabel(text.NewInfoNodeInputPrivilegedCode())
+}
+
+// CanSend returns true if privileged codes are enabled and the identity has a verified email address to send
+// them to.
+func (m *PrivilegedCodeManager) CanSend(ctx context.Context, i *identity.Identity) bool {
+	return m.d.Config(ctx).SelfServiceFlowSettingsPrivilegedCodeEnabled() && i != nil && verifiedEmailAddress(i) != nil
+}
+
+// VerifyFromRequest checks the code the request carries, if any, against the code issued for the flow. Once the
+// code was confirmed, the flow is privileged for as long as a session which signed in at that time would be.
+func (m *PrivilegedCodeManager) VerifyFromRequest(r *http.Request, f *Flow) error {
+	var p struct {
+		Code string `json:"privileged_code" form:"privileged_code"`
+	}
+
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(privilegedCodeSchema)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := m.hd.Decode(r, &p, compiler,
+		decoderx.HTTPKeepRequestBody(true),
+		decoderx.HTTPDecoderAllowedMethods("POST", "PUT", "PATCH", "GET"),
+		decoderx.HTTPDecoderSetValidatePayloads(false),
+		decoderx.HTTPDecoderJSONFollowsFormFormat()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	code := strings.TrimSpace(p.Code)
+	if len(code) == 0 {
+		return nil
+	}
+
+	ctx := r.Context()
+	c := m.d.Config(ctx)
+	maxAttempts := c.SelfServiceFlowSettingsPrivilegedCodeMaxAttempts()
+	hmac := string(f.PrivilegedCodeHMAC)
+	if !c.SelfServiceFlowSettingsPrivilegedCodeEnabled() || len(hmac) == 0 || f.PrivilegedCodeAttempts >= maxAttempts {
+		// Once all attempts are used up, the code can not be guessed any more and a new one has to be sent.
+		return schema.NewPrivilegedCodeInvalidError()
+	}
+
+	// Attempts are counted and codes are confirmed in the database, so that concurrent requests can not try
+	// more codes than allowed or confirm the same code twice.
+	now := m.d.Clock().Now().UTC()
+	if !m.compare(c.SecretsDefault(), code, hmac) {
+		if err := m.d.SettingsFlowPersister().FailSettingsFlowPrivilegedCode(ctx, f.ID, hmac, maxAttempts); err != nil && !errors.Is(err, sqlcon.ErrNoRows) {
+			return err
+		}
+		return schema.NewPrivilegedCodeInvalidError()
+	}
+
+	if !now.Before(time.Time(f.PrivilegedCodeExpiresAt)) {
+		return schema.NewPrivilegedCodeInvalidError()
+	}
+
+	if err := m.d.SettingsFlowPersister().ConfirmSettingsFlowPrivilegedCode(ctx, f.ID, hmac, maxAttempts, now); errors.Is(err, sqlcon.ErrNoRows) {
+		return schema.NewPrivilegedCodeInvalidError()
+	} else if err != nil {
+		return err
+	}
+
+	f.PrivilegedAt = sqlxx.NullTime(now)
+	f.PrivilegedCodeHMAC = ""
+	f.PrivilegedCodeExpiresAt = sqlxx.NullTime{}
+	f.PrivilegedCodeAttempts = 0
+	f.UI.Nodes.Remove(privilegedCodeNode)
+	return m.d.SettingsFlowPersister().UpdateSettingsFlow(ctx, f)
+}
+
+func (m *PrivilegedCodeManager) hmac(secret []byte, code string) string {
+	h := hmac.New(sha512.New512_256, secret)
+	_, _ = h.Write([]byte(code))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func (m *PrivilegedCodeManager) compare(secrets [][]byte, code, expected string) bool {
+	for _, secret := range secrets {
+		if subtle.ConstantTimeCompare([]byte(m.hmac(secret, code)), []byte(expected)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func verifiedEmailAddress(i *identity.Identity) *identity.VerifiableAddress {
+	for k, a := range i.VerifiableAddresses {
+		if a.Via == identity.VerifiableAddressTypeEmail && a.Verified {
+			return &i.VerifiableAddresses[k]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package settings_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	kratos "github.com/ory/kratos-client-go"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/x"
+)
+
+func TestPrivilegedCode(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	testhelpers.StrategyEnable(t, conf, identity.CredentialsTypePassword.String(), false)
+	testhelpers.StrategyEnable(t, conf, settings.StrategyProfile, true)
+	conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, "5m")
+	conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeEnabled, true)
+
+	_ = testhelpers.NewSettingsUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+
+	newUserAuthenticatedAt := func(t *testing.T, verified bool, authenticatedAt time.Time) (string, *http.Client) {
+		email := x.NewUUID().String() + "@ory.sh"
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"email":"` + email + `"}`)
+		require.NoError(t, reg.IdentityManager().Create(ctx, i))
+		require.Len(t, i.VerifiableAddresses, 1)
+
+		if verified {
+			address := i.VerifiableAddresses[0]
+			address.Verified = true
+			address.Status = identity.VerifiableAddressStatusCompleted
+			require.NoError(t, reg.PrivilegedIdentityPool().UpdateVerifiableAddress(ctx, &address))
+		}
+
+		return email, testhelpers.NewHTTPClientWithSessionToken(t, reg,
+			session.NewActiveSession(i, testhelpers.NewSessionLifespanProvider(time.Hour*24), authenticatedAt))
+	}
+
+	// The session is too old to change the email address without confirming it.
+	newUser := func(t *testing.T, verified bool) (string, *http.Client) {
+		return newUserAuthenticatedAt(t, verified, time.Now().Add(-time.Hour))
+	}
+
+	submit := func(t *testing.T, hc *http.Client, f *kratos.SettingsFlow, email, code string) (string, *http.Response) {
+		values := url.Values{"method": {"profile"}, "traits.email": {email}}
+		if len(code) > 0 {
+			values.Set("privileged_code", code)
+		}
+		return testhelpers.SettingsMakeRequest(t, true, f, hc, testhelpers.EncodeFormAsJSON(t, true, values))
+	}
+
+	codePattern := regexp.MustCompile(`\b[0-9]{6}\b`)
+	requestCode := func(t *testing.T, hc *http.Client, f *kratos.SettingsFlow, email string) string {
+		body, res := submit(t, hc, f, "changed-"+email, "")
+		require.Equal(t, http.StatusBadRequest, res.StatusCode, "%s", body)
+		assert.True(t, gjson.Get(body, "ui.nodes.#(attributes.name==privileged_code)").Exists(), "%s", body)
+		assert.EqualValues(t, text.InfoSelfServiceSettingsPrivilegedCodeSent, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+
+		message := testhelpers.CourierExpectMessage(t, reg, email, "Confirm the changes to your account")
+		code := codePattern.FindString(message.Body)
+		require.NotEmpty(t, code, "%s", message.Body)
+		return code
+	}
+
+	expectInvalidCode := func(t *testing.T, hc *http.Client, f *kratos.SettingsFlow, email, code string) {
+		body, res := submit(t, hc, f, "changed-"+email, code)
+		require.Equal(t, http.StatusBadRequest, res.StatusCode, "%s", body)
+		assert.EqualValues(t, text.ErrorValidationSettingsPrivilegedCodeInvalid, gjson.Get(body, "ui.nodes.#(attributes.name==privileged_code).messages.0.id").Int(), "%s", body)
+	}
+
+	t.Run("case=changes are confirmed with the code sent to the verified address", func(t *testing.T) {
+		email, hc := newUser(t, true)
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+		code := requestCode(t, hc, f, email)
+
+		body, res := submit(t, hc, f, "changed-"+email, code)
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Equal(t, "success", gjson.Get(body, "flow.state").String(), "%s", body)
+		assert.Equal(t, "changed-"+email, gjson.Get(body, "identity.traits.email").String(), "%s", body)
+		assert.False(t, gjson.Get(body, "flow.ui.nodes.#(attributes.name==privileged_code)").Exists(), "%s", body)
+	})
+
+	t.Run("case=wrong codes are rejected", func(t *testing.T) {
+		email, hc := newUser(t, true)
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+		code := requestCode(t, hc, f, email)
+
+		expectInvalidCode(t, hc, f, email, "not-"+code)
+	})
+
+	t.Run("case=the code is invalidated after too many wrong attempts", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeMaxAttempts, 2)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeMaxAttempts, 5)
+		})
+
+		email, hc := newUser(t, true)
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+		code := requestCode(t, hc, f, email)
+
+		expectInvalidCode(t, hc, f, email, "not-"+code)
+		expectInvalidCode(t, hc, f, email, "not-"+code)
+		expectInvalidCode(t, hc, f, email, code)
+
+		t.Run("case=a new code can be requested", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeResendInterval, "0s")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeResendInterval, "30s")
+			})
+
+			code := requestCode(t, hc, f, email)
+			body, res := submit(t, hc, f, "changed-"+email, code)
+			require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		})
+	})
+
+	t.Run("case=codes are not sent again within the resend interval", func(t *testing.T) {
+		email, hc := newUser(t, true)
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+		code := requestCode(t, hc, f, email)
+
+		body, res := submit(t, hc, f, "changed-"+email, "")
+		require.Equal(t, http.StatusBadRequest, res.StatusCode, "%s", body)
+		assert.True(t, gjson.Get(body, "ui.nodes.#(attributes.name==privileged_code)").Exists(), "%s", body)
+		assert.EqualValues(t, text.InfoSelfServiceSettingsPrivilegedCodeResendThrottled, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+
+		body, res = submit(t, hc, f, "changed-"+email, code)
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+	})
+
+	t.Run("case=identities have to sign in again once all codes were sent", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeResendInterval, "0s")
+		conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeMaxSends, 1)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeResendInterval, "30s")
+			conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeMaxSends, 3)
+		})
+
+		email, hc := newUser(t, true)
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+		_ = requestCode(t, hc, f, email)
+
+		body, res := submit(t, hc, f, "changed-"+email, "")
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, "%s", body)
+	})
+
+	t.Run("case=expired codes are rejected", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeLifespan, "1ns")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeLifespan, "15m")
+		})
+
+		email, hc := newUser(t, true)
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+		code := requestCode(t, hc, f, email)
+
+		expectInvalidCode(t, hc, f, email, code)
+	})
+
+	t.Run("case=identities without a verified address have to sign in again", func(t *testing.T) {
+		email, hc := newUser(t, false)
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+
+		body, res := submit(t, hc, f, "changed-"+email, "")
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, "%s", body)
+	})
+
+	t.Run("case=identities have to sign in again if privileged codes are disabled", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeEnabled, false)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedCodeEnabled, true)
+		})
+
+		email, hc := newUser(t, true)
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+
+		body, res := submit(t, hc, f, "changed-"+email, "")
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, "%s", body)
+	})
+
+	t.Run("case=flagged IP addresses have to confirm changes with a code even if the session is recent", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyIPReputationFlaggedCIDRs, []string{"127.0.0.0/8", "::1/128"})
+		conf.MustSet(config.ViperKeyIPReputationRequireEmailCode, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIPReputationFlaggedCIDRs, []string{})
+			conf.MustSet(config.ViperKeyIPReputationRequireEmailCode, false)
+		})
+
+		email, hc := newUserAuthenticatedAt(t, true, time.Now())
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+		code := requestCode(t, hc, f, email)
+
+		body, res := submit(t, hc, f, "changed-"+email, code)
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Equal(t, "changed-"+email, gjson.Get(body, "identity.traits.email").String(), "%s", body)
+	})
+}
@@ -2,7 +2,7 @@ package settings
 
 import "github.com/ory/kratos/ui/node"
 
-func sortNodes(n node.Nodes, schemaRef string) error {
+func SortNodes(n node.Nodes, schemaRef string) error {
 	return n.SortBySchema(
 		node.SortBySchema(schemaRef),
 		node.SortByGroups([]node.Group{
@@ -16,7 +16,10 @@ import (
 )
 
 const (
-	StrategyProfile = "profile"
+	StrategyProfile     = "profile"
+	StrategyUsername    = "username"
+	StrategyPreferences = "preferences"
+	StrategySIWE        = "siwe"
 )
 
 var pkgName = reflect.TypeOf(Strategies{}).PkgPath()
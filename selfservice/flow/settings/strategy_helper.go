@@ -47,6 +47,19 @@ func (c *UpdateContext) GetIdentityToUpdate() *identity.Identity {
 	return c.toUpdate
 }
 
+// AuthenticatedAt returns the time the identity last proved who they are. This is the time the session was
+// authenticated at or, if it is more recent, the time a privileged code was confirmed in the flow. If the flow
+// requires a privileged code, only the time the code was confirmed counts.
+func (c *UpdateContext) AuthenticatedAt() time.Time {
+	at := c.Session.AuthenticatedAt
+	if c.Flow != nil && c.Flow.RequiresPrivilegedCode {
+		return time.Time(c.Flow.PrivilegedAt)
+	} else if c.Flow != nil && time.Time(c.Flow.PrivilegedAt).After(at) {
+		return time.Time(c.Flow.PrivilegedAt)
+	}
+	return at
+}
+
 func (c UpdateContext) GetSessionIdentity() *identity.Identity {
 	if c.Session == nil {
 		return nil
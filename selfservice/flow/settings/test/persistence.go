@@ -12,6 +12,7 @@ import (
 	"github.com/ory/kratos/persistence"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
 
 	"github.com/ory/x/assertx"
 
@@ -155,6 +156,86 @@ func TestRequestPersister(ctx context.Context, conf *config.Config, p interface
 			}, actual.UI.Nodes)
 		})
 
+		t.Run("case=privileged codes", func(t *testing.T) {
+			issue := func(t *testing.T, f *settings.Flow, hmac string, now time.Time) error {
+				f.PrivilegedCodeHMAC = sqlxx.NullString(hmac)
+				f.PrivilegedCodeExpiresAt = sqlxx.NullTime(now.Add(time.Minute))
+				f.PrivilegedCodeSentAt = sqlxx.NullTime(now)
+				return p.IssueSettingsFlowPrivilegedCode(ctx, f, now.Add(-time.Second), 2)
+			}
+
+			t.Run("updates can not reset the code", func(t *testing.T) {
+				f := newFlow(t)
+				require.NoError(t, p.CreateSettingsFlow(ctx, f))
+				require.NoError(t, issue(t, f, "hmac", time.Now()))
+				require.NoError(t, p.FailSettingsFlowPrivilegedCode(ctx, f.ID, "hmac", 5))
+
+				f.PrivilegedCodeHMAC = ""
+				f.PrivilegedCodeAttempts = 0
+				require.NoError(t, p.UpdateSettingsFlow(ctx, f))
+
+				actual, err := p.GetSettingsFlow(ctx, f.ID)
+				require.NoError(t, err)
+				assert.EqualValues(t, "hmac", actual.PrivilegedCodeHMAC)
+				assert.Equal(t, 1, actual.PrivilegedCodeAttempts)
+				assert.Equal(t, 1, actual.PrivilegedCodeSends)
+			})
+
+			t.Run("codes are sent at most once per interval and up to the maximum", func(t *testing.T) {
+				f := newFlow(t)
+				require.NoError(t, p.CreateSettingsFlow(ctx, f))
+
+				now := time.Now()
+				require.NoError(t, issue(t, f, "first", now))
+				require.ErrorIs(t, issue(t, f, "second", now), sqlcon.ErrNoRows)
+				require.NoError(t, issue(t, f, "second", now.Add(time.Second)))
+				require.ErrorIs(t, issue(t, f, "third", now.Add(time.Hour)), sqlcon.ErrNoRows)
+
+				actual, err := p.GetSettingsFlow(ctx, f.ID)
+				require.NoError(t, err)
+				assert.EqualValues(t, "second", actual.PrivilegedCodeHMAC)
+				assert.Equal(t, 2, actual.PrivilegedCodeSends)
+			})
+
+			t.Run("wrong attempts are counted up to the maximum", func(t *testing.T) {
+				f := newFlow(t)
+				require.NoError(t, p.CreateSettingsFlow(ctx, f))
+				require.NoError(t, issue(t, f, "hmac", time.Now()))
+
+				require.ErrorIs(t, p.FailSettingsFlowPrivilegedCode(ctx, f.ID, "other", 2), sqlcon.ErrNoRows)
+				require.NoError(t, p.FailSettingsFlowPrivilegedCode(ctx, f.ID, "hmac", 2))
+				require.NoError(t, p.FailSettingsFlowPrivilegedCode(ctx, f.ID, "hmac", 2))
+				require.ErrorIs(t, p.FailSettingsFlowPrivilegedCode(ctx, f.ID, "hmac", 2), sqlcon.ErrNoRows)
+				require.ErrorIs(t, p.ConfirmSettingsFlowPrivilegedCode(ctx, f.ID, "hmac", 2, time.Now()), sqlcon.ErrNoRows)
+			})
+
+			t.Run("codes are confirmed once", func(t *testing.T) {
+				f := newFlow(t)
+				require.NoError(t, p.CreateSettingsFlow(ctx, f))
+				require.NoError(t, issue(t, f, "hmac", time.Now()))
+
+				now := time.Now().UTC().Truncate(time.Second)
+				require.ErrorIs(t, p.ConfirmSettingsFlowPrivilegedCode(ctx, f.ID, "other", 5, now), sqlcon.ErrNoRows)
+				require.NoError(t, p.ConfirmSettingsFlowPrivilegedCode(ctx, f.ID, "hmac", 5, now))
+				require.ErrorIs(t, p.ConfirmSettingsFlowPrivilegedCode(ctx, f.ID, "hmac", 5, now), sqlcon.ErrNoRows)
+
+				actual, err := p.GetSettingsFlow(ctx, f.ID)
+				require.NoError(t, err)
+				assert.Empty(t, actual.PrivilegedCodeHMAC)
+				x.AssertEqualTime(t, now, time.Time(actual.PrivilegedAt))
+			})
+
+			t.Run("can not use codes on another network", func(t *testing.T) {
+				f := newFlow(t)
+				require.NoError(t, p.CreateSettingsFlow(ctx, f))
+				require.NoError(t, issue(t, f, "hmac", time.Now()))
+
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				require.ErrorIs(t, other.FailSettingsFlowPrivilegedCode(ctx, f.ID, "hmac", 5), sqlcon.ErrNoRows)
+				require.ErrorIs(t, other.ConfirmSettingsFlowPrivilegedCode(ctx, f.ID, "hmac", 5, time.Now()), sqlcon.ErrNoRows)
+			})
+		})
+
 		t.Run("case=network", func(t *testing.T) {
 			id := x.NewUUID()
 			iid := x.NewUUID()
@@ -0,0 +1,133 @@
+package flow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/container"
+	"github.com/ory/kratos/ui/node"
+)
+
+// Simulation describes how a self-service flow would handle a submission. Simulations have no side effects: no flow,
+// session, or identity is created or modified and no hooks are executed.
+//
+// swagger:model selfServiceFlowSimulation
+type Simulation struct {
+	// Method is the method the payload was simulated for.
+	//
+	// required: true
+	Method string `json:"method"`
+
+	// MethodEnabled is false if the method is disabled in the configuration.
+	//
+	// required: true
+	MethodEnabled bool `json:"method_enabled"`
+
+	// Simulated is false if the strategy handling the method does not support simulations or the method is
+	// disabled. In that case, only the hooks are reported.
+	//
+	// required: true
+	Simulated bool `json:"simulated"`
+
+	// Valid is true if the strategy would accept the payload.
+	//
+	// required: true
+	Valid bool `json:"valid"`
+
+	// Messages explain why the strategy would reject the payload.
+	Messages text.Messages `json:"messages,omitempty"`
+
+	// IdentityID is the ID of the identity the payload would sign in. It is only set for login flows.
+	IdentityID *uuid.UUID `json:"identity_id,omitempty"`
+
+	// Hooks lists the names of the hooks which would run.
+	//
+	// required: true
+	Hooks SimulationHooks `json:"hooks"`
+}
+
+// swagger:model selfServiceFlowSimulationHooks
+type SimulationHooks struct {
+	// Before lists the hooks which would run before the flow is initialized.
+	//
+	// required: true
+	Before []string `json:"before"`
+
+	// After lists the hooks which would run after the payload was accepted.
+	//
+	// required: true
+	After []string `json:"after"`
+}
+
+// swagger:model selfServiceFlowSimulationBody
+type SimulationBody struct {
+	// Method is the method the payload is submitted with, for example `password`.
+	//
+	// required: true
+	Method string `json:"method"`
+
+	// SchemaID is the identity schema the payload is validated against. It is only used by registration flows
+	// and defaults to the default identity schema.
+	SchemaID string `json:"schema_id"`
+
+	// Payload is the JSON body which would be submitted to the flow, in the same format as the submission.
+	//
+	// required: true
+	Payload json.RawMessage `json:"payload"`
+}
+
+func NewSimulation(method string, enabled bool) *Simulation {
+	return &Simulation{
+		Method:        method,
+		MethodEnabled: enabled,
+		Hooks:         SimulationHooks{Before: []string{}, After: []string{}},
+	}
+}
+
+// Evaluate records the outcome of simulating the submission. Errors which would be shown to the user in the flow's UI
+// are added to the messages, all other errors are returned.
+func (s *Simulation) Evaluate(group node.Group, err error) error {
+	s.Simulated = true
+	if err == nil {
+		s.Valid = true
+		return nil
+	}
+
+	c := container.New("")
+	if err := c.ParseError(group, err); err != nil {
+		return err
+	}
+
+	s.Messages = append(s.Messages, c.Messages...)
+	for _, n := range c.Nodes {
+		s.Messages = append(s.Messages, n.Messages...)
+	}
+	return nil
+}
+
+// SimulationHookNames returns the names of the configured hooks.
+func SimulationHookNames(hooks []config.SelfServiceHook) []string {
+	names := make([]string, len(hooks))
+	for k, h := range hooks {
+		names[k] = h.Name
+	}
+	return names
+}
+
+// NewSimulationRequest wraps a simulated payload in a request so that strategies can decode it the same way they
+// decode submissions.
+func NewSimulationRequest(ctx context.Context, payload json.RawMessage) (*http.Request, error) {
+	r, err := http.NewRequestWithContext(ctx, "POST", "/", bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	r.Header.Set("Content-Type", "application/json")
+	return r, nil
+}
@@ -25,6 +25,7 @@ type (
 	errorHandlerDependencies interface {
 		errorx.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
 		x.LoggingProvider
 		x.CSRFTokenGeneratorProvider
 		config.Provider
@@ -57,6 +58,9 @@ func NewFlowExpiredError(at time.Time) *FlowExpiredError {
 	}
 }
 
+// FlowExpired marks the error as an expired flow for the error routes at `selfservice.flows.error.routes`.
+func (e *FlowExpiredError) FlowExpired() {}
+
 func NewErrorHandler(d errorHandlerDependencies) *ErrorHandler {
 	return &ErrorHandler{d: d}
 }
@@ -81,7 +85,7 @@ func (s *ErrorHandler) WriteFlowError(
 
 	if e := new(FlowExpiredError); errors.As(err, &e) {
 		// create new flow because the old one is not valid
-		a, err := NewFlow(s.d.Config(r.Context()), s.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(),
+		a, err := NewFlow(s.d.Config(r.Context()), s.d.Clock().Now(), s.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(),
 			s.d.GenerateCSRFToken(r), r, s.d.VerificationStrategies(r.Context()), f.Type)
 		if err != nil {
 			// failed to create a new session and redirect to it, handle that error as a new one
@@ -122,9 +126,11 @@ func (s *ErrorHandler) WriteFlowError(
 
 	updatedFlow, innerErr := s.d.VerificationFlowPersister().GetVerificationFlow(r.Context(), f.ID)
 	if innerErr != nil {
-		s.forward(w, r, updatedFlow, innerErr)
+		s.forward(w, r, f, innerErr)
+		return
 	}
 
+	flow.SetNonce(updatedFlow.UI, flow.NextNonce(r.Context()))
 	s.d.Writer().WriteCode(w, r, x.RecoverStatusCode(err, http.StatusBadRequest), updatedFlow)
 }
 
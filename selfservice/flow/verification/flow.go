@@ -71,6 +71,18 @@ type Flow struct {
 	// CSRFToken contains the anti-csrf token associated with this request.
 	CSRFToken string `json:"-" db:"csrf_token"`
 
+	// IssuedByAdmin is true if the flow was created by the admin API. Such flows were not initialized by the
+	// browser completing them, so their anti-CSRF token is reissued whenever they are fetched.
+	IssuedByAdmin bool `json:"-" faker:"-" db:"issued_by_admin"`
+
+	// SubmissionNonce must be submitted to complete the flow if it is set. It is read-only because only the
+	// persister may change it, see FlowPersister.UseVerificationFlowNonce.
+	SubmissionNonce string `json:"-" faker:"-" db:"submission_nonce" rw:"r"`
+
+	// SubmissionNonceIssued is true if the nonce was handed out. The nonce of API flows and of browser flows created
+	// by the admin API is handed out only once, see FlowPersister.IssueVerificationFlowNonce.
+	SubmissionNonceIssued bool `json:"-" faker:"-" db:"submission_nonce_issued" rw:"r"`
+
 	// CreatedAt is a helper struct field for gobuffalo.pop.
 	CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
 	// UpdatedAt is a helper struct field for gobuffalo.pop.
@@ -90,8 +102,8 @@ func (f Flow) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "selfservice_verification_flows")
 }
 
-func NewFlow(conf *config.Config, exp time.Duration, csrf string, r *http.Request, strategies Strategies, ft flow.Type) (*Flow, error) {
-	now := time.Now().UTC()
+func NewFlow(conf *config.Config, now time.Time, exp time.Duration, csrf string, r *http.Request, strategies Strategies, ft flow.Type) (*Flow, error) {
+	now = now.UTC()
 	id := x.NewUUID()
 	f := &Flow{
 		ID:        id,
@@ -106,6 +118,10 @@ func NewFlow(conf *config.Config, exp time.Duration, csrf string, r *http.Reques
 		Type:      ft,
 	}
 
+	if conf.SelfServiceFlowReplayProtectionEnabled() {
+		f.SubmissionNonce = flow.NewNonce()
+	}
+
 	for _, strategy := range strategies {
 		if err := strategy.PopulateVerificationMethod(r, f); err != nil {
 			return nil, err
@@ -115,8 +131,8 @@ func NewFlow(conf *config.Config, exp time.Duration, csrf string, r *http.Reques
 	return f, nil
 }
 
-func NewPostHookFlow(conf *config.Config, exp time.Duration, csrf string, r *http.Request, strategies Strategies, original flow.Flow) (*Flow, error) {
-	f, err := NewFlow(conf, exp, csrf, r, strategies, original.GetType())
+func NewPostHookFlow(conf *config.Config, now time.Time, exp time.Duration, csrf string, r *http.Request, strategies Strategies, original flow.Flow) (*Flow, error) {
+	f, err := NewFlow(conf, now, exp, csrf, r, strategies, original.GetType())
 	if err != nil {
 		return nil, err
 	}
@@ -132,8 +148,8 @@ func NewPostHookFlow(conf *config.Config, exp time.Duration, csrf string, r *htt
 	return f, nil
 }
 
-func (f *Flow) Valid() error {
-	if f.ExpiresAt.Before(time.Now()) {
+func (f *Flow) Valid(now time.Time) error {
+	if f.ExpiresAt.Before(now) {
 		return errors.WithStack(NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
@@ -38,11 +38,11 @@ func TestFlow(t *testing.T) {
 		r         *verification.Flow
 		expectErr bool
 	}{
-		{r: must(verification.NewFlow(conf, time.Hour, "", u, nil, flow.TypeBrowser))},
-		{r: must(verification.NewFlow(conf, -time.Hour, "", u, nil, flow.TypeBrowser)), expectErr: true},
+		{r: must(verification.NewFlow(conf, time.Now(), time.Hour, "", u, nil, flow.TypeBrowser))},
+		{r: must(verification.NewFlow(conf, time.Now(), -time.Hour, "", u, nil, flow.TypeBrowser)), expectErr: true},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
-			err := tc.r.Valid()
+			err := tc.r.Valid(time.Now())
 			if tc.expectErr {
 				require.Error(t, err)
 				return
@@ -53,7 +53,7 @@ func TestFlow(t *testing.T) {
 	}
 
 	assert.EqualValues(t, verification.StateChooseMethod,
-		must(verification.NewFlow(conf, time.Hour, "", u, nil, flow.TypeBrowser)).State)
+		must(verification.NewFlow(conf, time.Now(), time.Hour, "", u, nil, flow.TypeBrowser)).State)
 }
 
 func TestGetType(t *testing.T) {
@@ -82,7 +82,7 @@ func TestNewPostHookFlow(t *testing.T) {
 			RequestURL: "http://foo.com/bar?" + originalFlowRequestQueryParams.Encode(),
 		}
 		t.Log(originalFlow.RequestURL)
-		f, err := verification.NewPostHookFlow(conf, time.Second, "", u, nil, &originalFlow)
+		f, err := verification.NewPostHookFlow(conf, time.Now(), time.Second, "", u, nil, &originalFlow)
 		require.NoError(t, err)
 		url, err := urlx.Parse(f.RequestURL)
 		require.NoError(t, err)
@@ -2,7 +2,6 @@ package verification
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/ui/node"
@@ -19,6 +18,7 @@ import (
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/errorx"
 	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/callback"
 	"github.com/ory/kratos/x"
 )
 
@@ -42,8 +42,10 @@ type (
 
 		x.CSRFTokenGeneratorProvider
 		x.WriterProvider
+		x.ClockProvider
 		x.CSRFProvider
 
+		callback.NotifierProvider
 		FlowPersistenceProvider
 		ErrorHandlerProvider
 		StrategyProvider
@@ -72,6 +74,26 @@ func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 	admin.GET(RouteGetFlow, h.fetch)
 }
 
+// nolint:deadcode,unused
+// swagger:parameters initializeSelfServiceVerificationViaBrowserFlow initializeSelfServiceVerificationViaAPIFlow
+type initializeSelfServiceVerificationFlow struct {
+	// Requested Lifespan
+	//
+	// A lifespan other than the configured one, for example `24h`. It must be within the bounds configured at
+	// `selfservice.flows.verification.lifespan_bounds`.
+	//
+	// in: query
+	Lifespan string `json:"lifespan"`
+
+	// Callback URL
+	//
+	// A URL that the flow's result is sent to once the flow completed. It must match one of the URLs configured at
+	// `selfservice.flow_callbacks.allowed_urls`.
+	//
+	// in: query
+	CallbackURL string `json:"callback_url"`
+}
+
 // swagger:route GET /self-service/verification/api public initializeSelfServiceVerificationViaAPIFlow
 //
 // Initialize Verification Flow for API Clients
@@ -104,17 +126,38 @@ func (h *Handler) initAPIFlow(w http.ResponseWriter, r *http.Request, _ httprout
 		return
 	}
 
-	req, err := NewFlow(h.d.Config(r.Context()), h.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(), h.d.GenerateCSRFToken(r), r, h.d.VerificationStrategies(r.Context()), flow.TypeAPI)
+	lifespan, err := flow.RequestedLifespan(r, h.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(), h.d.Config(r.Context()).SelfServiceFlowVerificationLifespanBounds())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	callbackURL, err := callback.RequestedURL(r, h.d.Config(r.Context()).SelfServiceFlowCallbacksAllowedURLs())
 	if err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
 	}
 
+	req, err := NewFlow(h.d.Config(r.Context()), h.d.Clock().Now(), lifespan, h.d.GenerateCSRFToken(r), r, h.d.VerificationStrategies(r.Context()), flow.TypeAPI)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	// The nonce of the flow is handed out with this response only.
+	req.SubmissionNonceIssued = true
+
 	if err := h.d.VerificationFlowPersister().CreateVerificationFlow(r.Context(), req); err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
 	}
 
+	if err := h.d.FlowCallbackNotifier().Register(r.Context(), callback.FlowTypeVerification, req.ID, req.ExpiresAt, callbackURL); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	flow.SetNonce(req.UI, req.SubmissionNonce)
 	h.d.Writer().Write(w, r, req)
 }
 
@@ -140,7 +183,19 @@ func (h *Handler) initBrowserFlow(w http.ResponseWriter, r *http.Request, ps htt
 		return
 	}
 
-	req, err := NewFlow(h.d.Config(r.Context()), h.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(), h.d.GenerateCSRFToken(r), r, h.d.VerificationStrategies(r.Context()), flow.TypeBrowser)
+	lifespan, err := flow.RequestedLifespan(r, h.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(), h.d.Config(r.Context()).SelfServiceFlowVerificationLifespanBounds())
+	if err != nil {
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+
+	callbackURL, err := callback.RequestedURL(r, h.d.Config(r.Context()).SelfServiceFlowCallbacksAllowedURLs())
+	if err != nil {
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+
+	req, err := NewFlow(h.d.Config(r.Context()), h.d.Clock().Now(), lifespan, h.d.GenerateCSRFToken(r), r, h.d.VerificationStrategies(r.Context()), flow.TypeBrowser)
 	if err != nil {
 		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 		return
@@ -151,6 +206,11 @@ func (h *Handler) initBrowserFlow(w http.ResponseWriter, r *http.Request, ps htt
 		return
 	}
 
+	if err := h.d.FlowCallbackNotifier().Register(r.Context(), callback.FlowTypeVerification, req.ID, req.ExpiresAt, callbackURL); err != nil {
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+
 	http.Redirect(w, r, req.AppendTo(h.d.Config(r.Context()).SelfServiceFlowVerificationUI()).String(), http.StatusFound)
 }
 
@@ -198,7 +258,7 @@ func (h *Handler) fetch(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 		return
 	}
 
-	if req.ExpiresAt.Before(time.Now().UTC()) {
+	if req.ExpiresAt.Before(h.d.Clock().Now()) {
 		if req.Type == flow.TypeBrowser {
 			h.d.Writer().WriteError(w, r, errors.WithStack(x.ErrGone.
 				WithReason("The verification flow has expired. Redirect the user to the verification flow init endpoint to initialize a new verification flow.").
@@ -211,6 +271,17 @@ func (h *Handler) fetch(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 		return
 	}
 
+	if req.IssuedByAdmin && req.Type == flow.TypeBrowser {
+		req.UI.SetCSRF(h.d.GenerateCSRFToken(r))
+	}
+
+	nonce, err := h.revealNonce(r, req)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	flow.SetNonce(req.UI, nonce)
 	h.d.Writer().Write(w, r, req)
 }
 
@@ -249,9 +320,12 @@ type submitSelfServiceRecoveryFlowBody struct{}
 // - `sent_email` is the success state after `choose_method` when using the `link` method and allows the user to request another verification email. It
 //   works for both API and Browser-initiated flows and returns the same responses as the flow in `choose_method` state.
 // - `passed_challenge` expects a `token` to be sent in the URL query and given the nature of the flow ("sending a verification link")
-//   does not have any API capabilities. The server responds with a HTTP 302 Found redirect either to the Settings UI URL
+//   is meant for browsers when the link is clicked. The server responds with a HTTP 302 Found redirect either to the Settings UI URL
 //   (if the link was valid) and instructs the user to update their password, or a redirect to the Verification UI URL with
 //   a new Verification Flow ID which contains an error message that the verification link was invalid.
+//   API clients which let the user enter the token themselves send it as JSON in the body of a POST request together
+//   with the `flow`. They receive a HTTP 200 OK with the flow in `passed_challenge` state, and a session token if
+//   `selfservice.flows.verification.after.api_session` is enabled, or a HTTP 400 Bad Request if the token was invalid.
 //
 // More information can be found at [ORY Kratos Email and Phone Verification Documentation](https://www.ory.sh/docs/kratos/selfservice/flows/verify-email-account-activation).
 //
@@ -265,6 +339,7 @@ type submitSelfServiceRecoveryFlowBody struct{}
 //     Schemes: http, https
 //
 //     Responses:
+//       200: verificationViaApiResponse
 //       400: verificationFlow
 //       302: emptyResponse
 //       500: genericError
@@ -284,7 +359,17 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, ps httprout
 		return
 	}
 
-	if err := f.Valid(); err != nil {
+	// The nonce is checked before the flow's expiry, so that replays of requests for an expired flow do not
+	// restart the flow either.
+	r, err = flow.UseNonce(r, f.Type, f.CSRFToken, h.d.GenerateCSRFToken, f.SubmissionNonce, func(nonce, next string) error {
+		return h.d.VerificationFlowPersister().UseVerificationFlowNonce(r.Context(), f.ID, nonce, next)
+	})
+	if err != nil {
+		h.d.VerificationFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
+		return
+	}
+
+	if err := f.Valid(h.d.Clock().Now()); err != nil {
 		h.d.VerificationFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
 		return
 	}
@@ -323,5 +408,36 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, ps httprout
 		return
 	}
 
+	flow.SetNonce(updatedFlow.UI, flow.NextNonce(r.Context()))
 	h.d.Writer().Write(w, r, updatedFlow)
 }
+
+// revealNonce returns the submission nonce of the flow if the response to the request may show it, or an empty
+// string. API flows hand out their nonce only once, so that fetching the flow does not reveal the nonce of a flow
+// that was already submitted. Browser flows created by the admin API are bound to the browser which fetches them
+// first.
+func (h *Handler) revealNonce(r *http.Request, f *Flow) (string, error) {
+	if f.SubmissionNonce == "" {
+		return "", nil
+	}
+
+	if f.Type == flow.TypeAPI || (f.IssuedByAdmin && !f.SubmissionNonceIssued) {
+		if err := h.d.VerificationFlowPersister().IssueVerificationFlowNonce(r.Context(), f.ID); errors.Is(err, sqlcon.ErrNoRows) {
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+
+		if f.Type == flow.TypeBrowser {
+			f.CSRFToken = h.d.GenerateCSRFToken(r)
+			if err := h.d.VerificationFlowPersister().UpdateVerificationFlow(r.Context(), f); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if !flow.NonceBoundTo(r, f.Type, f.CSRFToken, h.d.GenerateCSRFToken) {
+		return "", nil
+	}
+	return f.SubmissionNonce, nil
+}
@@ -3,6 +3,7 @@ package verification_test
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -107,3 +108,65 @@ func TestGetFlow(t *testing.T) {
 		run(t, public)
 	})
 }
+
+func TestInitFlowWithRequestedLifespan(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySelfServiceVerificationEnabled, true)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+verification.StrategyVerificationLinkName,
+		map[string]interface{}{"enabled": true})
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+
+	public, _ := testhelpers.NewKratosServerWithCSRF(t, reg)
+
+	initFlow := func(t *testing.T, lifespan string, expectCode int) []byte {
+		res, body := x.EasyGet(t, public.Client(), public.URL+verification.RouteInitAPIFlow+"?lifespan="+lifespan)
+		require.EqualValues(t, expectCode, res.StatusCode, "%s", body)
+		return body
+	}
+
+	t.Run("case=rejects lifespans if no bounds are configured", func(t *testing.T) {
+		initFlow(t, "24h", http.StatusBadRequest)
+	})
+
+	t.Run("case=uses the requested lifespan", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceVerificationLifespanBounds, map[string]interface{}{"max": "48h"})
+
+		body := initFlow(t, "24h", http.StatusOK)
+		issuedAt, expiresAt := gjson.GetBytes(body, "issued_at").Time(), gjson.GetBytes(body, "expires_at").Time()
+		assert.InDelta(t, (time.Hour * 24).Seconds(), expiresAt.Sub(issuedAt).Seconds(), 1, "%s", body)
+
+		initFlow(t, "49h", http.StatusBadRequest)
+	})
+}
+
+func TestInitFlowWithCallbackURL(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySelfServiceVerificationEnabled, true)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+verification.StrategyVerificationLinkName,
+		map[string]interface{}{"enabled": true})
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+
+	public, _ := testhelpers.NewKratosServerWithCSRF(t, reg)
+
+	initFlow := func(t *testing.T, callbackURL string, expectCode int) []byte {
+		res, body := x.EasyGet(t, public.Client(), public.URL+verification.RouteInitAPIFlow+"?"+url.Values{"callback_url": {callbackURL}}.Encode())
+		require.EqualValues(t, expectCode, res.StatusCode, "%s", body)
+		return body
+	}
+
+	t.Run("case=rejects callback URLs if none are allowed", func(t *testing.T) {
+		initFlow(t, "https://backend.ory.sh/callbacks", http.StatusBadRequest)
+	})
+
+	t.Run("case=registers allowed callback URLs", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceFlowCallbacksAllowedURLs, []string{"https://backend.ory.sh/callbacks"})
+
+		body := initFlow(t, "https://backend.ory.sh/callbacks/verification", http.StatusOK)
+		flowID := x.ParseUUID(gjson.GetBytes(body, "id").String())
+
+		_, err := reg.FlowCallbackPersister().CompleteFlowCallback(context.Background(), flowID, []byte(`{}`), time.Now().UTC())
+		require.NoError(t, err)
+
+		initFlow(t, "https://evil.ory.sh/callbacks", http.StatusBadRequest)
+	})
+}
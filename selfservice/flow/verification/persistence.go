@@ -14,5 +14,14 @@ type (
 		CreateVerificationFlow(context.Context, *Flow) error
 		GetVerificationFlow(ctx context.Context, id uuid.UUID) (*Flow, error)
 		UpdateVerificationFlow(context.Context, *Flow) error
+
+		// UseVerificationFlowNonce replaces the flow's submission nonce with next if it equals nonce. It returns
+		// sqlcon.ErrNoRows if it does not, so that each nonce can only be used once, even if it is submitted
+		// concurrently.
+		UseVerificationFlowNonce(ctx context.Context, id uuid.UUID, nonce, next string) error
+
+		// IssueVerificationFlowNonce marks the flow's submission nonce as handed out. It returns sqlcon.ErrNoRows if it
+		// already was, so that the nonce is only handed out once.
+		IssueVerificationFlowNonce(ctx context.Context, id uuid.UUID) error
 	}
 )
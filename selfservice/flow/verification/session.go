@@ -0,0 +1,30 @@
+package verification
+
+import "github.com/ory/kratos/session"
+
+// The Response for Verification Flows via API
+//
+// swagger:model verificationViaApiResponse
+type APIFlowResponse struct {
+	// The Flow
+	//
+	// The flow is in state `passed_challenge` once the address was verified.
+	//
+	// required: true
+	Flow *Flow `json:"flow"`
+
+	// The Session Token
+	//
+	// This field is only set if `selfservice.flows.verification.after.api_session` is enabled.
+	//
+	// A session token is equivalent to a session cookie, but it can be sent in the HTTP Authorization
+	// Header:
+	//
+	// 		Authorization: bearer ${session-token}
+	Token string `json:"session_token,omitempty"`
+
+	// The Session
+	//
+	// This field is only set if `selfservice.flows.verification.after.api_session` is enabled.
+	Session *session.Session `json:"session,omitempty"`
+}
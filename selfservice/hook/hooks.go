@@ -3,4 +3,6 @@ package hook
 const (
 	KeySessionIssuer    = "session"
 	KeySessionDestroyer = "revoke_active_sessions"
+	KeyWebHook          = "web_hook"
+	KeyIdentityEnricher = "enrich_identity"
 )
@@ -0,0 +1,104 @@
+package hook
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/webhook"
+)
+
+var _ registration.PostHookPrePersistExecutor = new(IdentityEnricher)
+
+type (
+	identityEnricherDependencies interface {
+		webhook.ClientProvider
+	}
+	IdentityEnricherConfig struct {
+		URL    string `json:"url"`
+		Method string `json:"method"`
+	}
+
+	// IdentityEnricher calls an HTTP endpoint before a new identity is persisted and merges the `traits`
+	// object of the JSON response into the identity's traits. The identity is validated against its
+	// schema after all hooks ran.
+	IdentityEnricher struct {
+		r identityEnricherDependencies
+		c IdentityEnricherConfig
+	}
+)
+
+func NewIdentityEnricher(r identityEnricherDependencies, config json.RawMessage) (*IdentityEnricher, error) {
+	var c IdentityEnricherConfig
+	if err := json.Unmarshal(config, &c); err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode the identity enrichment hook configuration: %s", err))
+	}
+
+	if c.Method == "" {
+		c.Method = http.MethodPost
+	}
+
+	return &IdentityEnricher{r: r, c: c}, nil
+}
+
+func (e *IdentityEnricher) ExecutePostRegistrationPrePersistHook(_ http.ResponseWriter, r *http.Request, a *registration.Flow, i *identity.Identity) error {
+	body, err := json.Marshal(&webHookPayload{Event: webhook.EventRegistrationEnrich, FlowID: a.ID, RequestURL: a.RequestURL, Identity: i})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	res, err := e.r.WebhookClient().Do(r.Context(), e.c.Method, e.c.URL, body)
+	if err != nil {
+		return err
+	}
+
+	traits := gjson.GetBytes(res, "traits")
+	if !traits.Exists() {
+		return nil
+	} else if !traits.IsObject() {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReason("The identity enrichment endpoint returned traits which are not a JSON object."))
+	}
+
+	var current, patch map[string]interface{}
+	if len(i.Traits) > 0 {
+		if err := json.Unmarshal(i.Traits, &current); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := json.Unmarshal([]byte(traits.Raw), &patch); err != nil {
+		return errors.WithStack(err)
+	}
+
+	merged, err := json.Marshal(mergeObjects(current, patch))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.Traits = merged
+	return nil
+}
+
+// mergeObjects deep merges patch into target. Values from patch take precedence except for nested objects,
+// which are merged recursively.
+func mergeObjects(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+
+	for k, v := range patch {
+		if po, ok := v.(map[string]interface{}); ok {
+			if to, ok := target[k].(map[string]interface{}); ok {
+				target[k] = mergeObjects(to, po)
+				continue
+			}
+		}
+		target[k] = v
+	}
+	return target
+}
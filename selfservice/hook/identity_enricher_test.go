@@ -0,0 +1,65 @@
+package hook_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/webhook"
+	"github.com/ory/kratos/x"
+)
+
+func TestIdentityEnricher(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyWebhookMaxRetries, 0)
+
+	var response string
+	var received []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(ts.Close)
+
+	h, err := hook.NewIdentityEnricher(reg, json.RawMessage(`{"url":"`+ts.URL+`"}`))
+	require.NoError(t, err)
+
+	f := &registration.Flow{ID: x.NewUUID(), RequestURL: "http://localhost/self-service/registration/browser"}
+	newIdentity := func() *identity.Identity {
+		return &identity.Identity{ID: x.NewUUID(), Traits: identity.Traits(`{"email":"foo@ory.sh","company":{"name":"ORY"}}`)}
+	}
+
+	t.Run("case=merges returned traits", func(t *testing.T) {
+		response = `{"traits":{"tenant":"acme","company":{"plan":"enterprise"}}}`
+		i := newIdentity()
+		require.NoError(t, h.ExecutePostRegistrationPrePersistHook(nil, httptest.NewRequest("POST", "/", nil), f, i))
+
+		assert.JSONEq(t, `{"email":"foo@ory.sh","tenant":"acme","company":{"name":"ORY","plan":"enterprise"}}`, string(i.Traits))
+		assert.Equal(t, "registration.enrich", gjson.GetBytes(received, "event").String())
+		assert.Equal(t, "foo@ory.sh", gjson.GetBytes(received, "identity.traits.email").String())
+		assert.NoError(t, webhook.ValidateEventPayload(webhook.EventRegistrationEnrich, received))
+	})
+
+	t.Run("case=keeps traits if none are returned", func(t *testing.T) {
+		response = `{}`
+		i := newIdentity()
+		require.NoError(t, h.ExecutePostRegistrationPrePersistHook(nil, httptest.NewRequest("POST", "/", nil), f, i))
+		assert.JSONEq(t, `{"email":"foo@ory.sh","company":{"name":"ORY"}}`, string(i.Traits))
+	})
+
+	t.Run("case=rejects invalid traits", func(t *testing.T) {
+		response = `{"traits":"foo"}`
+		assert.Error(t, h.ExecutePostRegistrationPrePersistHook(nil, httptest.NewRequest("POST", "/", nil), f, newIdentity()))
+	})
+}
@@ -23,6 +23,7 @@ type (
 		link.VerificationTokenPersistenceProvider
 		config.Provider
 		x.CSRFTokenGeneratorProvider
+		x.ClockProvider
 		verification.StrategyProvider
 		verification.FlowPersistenceProvider
 	}
@@ -53,7 +54,13 @@ func (e *Verifier) do(r *http.Request, i *identity.Identity, f flow.Flow) error
 			continue
 		}
 
-		verificationFlow, err := verification.NewPostHookFlow(e.r.Config(r.Context()),
+		// Phone numbers can only be verified if the courier can send SMS.
+		if address.Via == identity.VerifiableAddressTypeSMS && !e.r.Config(r.Context()).CourierSMSEnabled() {
+			continue
+		}
+
+		now := e.r.Clock().Now()
+		verificationFlow, err := verification.NewPostHookFlow(e.r.Config(r.Context()), now,
 			e.r.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(),
 			e.r.GenerateCSRFToken(r), r, e.r.VerificationStrategies(r.Context()), f)
 		if err != nil {
@@ -64,7 +71,7 @@ func (e *Verifier) do(r *http.Request, i *identity.Identity, f flow.Flow) error
 			return err
 		}
 
-		token := link.NewSelfServiceVerificationToken(address, verificationFlow)
+		token := link.NewSelfServiceVerificationToken(e.r.Config(r.Context()), address, verificationFlow, now)
 		if err := e.r.VerificationTokenPersister().CreateVerificationToken(r.Context(), token); err != nil {
 			return err
 		}
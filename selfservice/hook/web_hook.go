@@ -0,0 +1,159 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/google/go-jsonnet"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/fetcher"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/webhook"
+	"github.com/ory/kratos/x"
+)
+
+var (
+	_ registration.PostHookPostPersistExecutor = new(WebHook)
+	_ login.PostHookExecutor                   = new(WebHook)
+	_ settings.PostHookPostPersistExecutor     = new(WebHook)
+)
+
+type (
+	webHookDependencies interface {
+		webhook.ClientProvider
+		x.LoggingProvider
+		config.Provider
+	}
+	WebHookConfig struct {
+		URL    string `json:"url"`
+		Method string `json:"method"`
+
+		// Body is the URL of a Jsonnet template which renders the request body. The template receives the
+		// event, flow, and identity as external variable `ctx`. If empty, the default payload is sent.
+		Body string `json:"body"`
+
+		// Async calls the web hook in the background. Failures are logged but do not interrupt the flow.
+		Async bool `json:"async"`
+	}
+	WebHook struct {
+		r webHookDependencies
+		c WebHookConfig
+		f *fetcher.Fetcher
+	}
+
+	// webHookPayload is the body of web hook requests. Changes to this struct must bump webhook.Version and be
+	// reflected in the event schemas at webhook/.schema/events.
+	webHookPayload struct {
+		Event      string             `json:"event"`
+		FlowID     uuid.UUID          `json:"flow_id"`
+		RequestURL string             `json:"request_url"`
+		Identity   *identity.Identity `json:"identity"`
+	}
+
+	// webHookContext is passed to Jsonnet body templates.
+	webHookContext struct {
+		Event    string             `json:"event"`
+		Flow     interface{}        `json:"flow"`
+		Identity *identity.Identity `json:"identity"`
+	}
+)
+
+func NewWebHook(r webHookDependencies, config json.RawMessage) (*WebHook, error) {
+	var c WebHookConfig
+	if err := json.Unmarshal(config, &c); err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode the web hook configuration: %s", err))
+	}
+
+	if c.Method == "" {
+		c.Method = http.MethodPost
+	}
+
+	return &WebHook{r: r, c: c, f: fetcher.NewFetcher()}, nil
+}
+
+func (e *WebHook) ExecutePostRegistrationPostPersistHook(_ http.ResponseWriter, r *http.Request, a *registration.Flow, s *session.Session) error {
+	return e.send(r.Context(),
+		&webHookPayload{Event: webhook.EventRegistrationAfter, FlowID: a.ID, RequestURL: a.RequestURL, Identity: s.Identity},
+		&webHookContext{Event: webhook.EventRegistrationAfter, Flow: a, Identity: s.Identity})
+}
+
+func (e *WebHook) ExecuteLoginPostHook(_ http.ResponseWriter, r *http.Request, a *login.Flow, s *session.Session) error {
+	return e.send(r.Context(),
+		&webHookPayload{Event: webhook.EventLoginAfter, FlowID: a.ID, RequestURL: a.RequestURL, Identity: s.Identity},
+		&webHookContext{Event: webhook.EventLoginAfter, Flow: a, Identity: s.Identity})
+}
+
+func (e *WebHook) ExecuteSettingsPostPersistHook(_ http.ResponseWriter, r *http.Request, a *settings.Flow, i *identity.Identity) error {
+	return e.send(r.Context(),
+		&webHookPayload{Event: webhook.EventSettingsAfter, FlowID: a.ID, RequestURL: a.RequestURL, Identity: i},
+		&webHookContext{Event: webhook.EventSettingsAfter, Flow: a, Identity: i})
+}
+
+func (e *WebHook) body(payload *webHookPayload, ctx *webHookContext) ([]byte, error) {
+	if e.c.Body == "" {
+		body, err := json.Marshal(payload)
+		return body, errors.WithStack(err)
+	}
+
+	template, err := e.f.Fetch(e.c.Body)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to fetch the web hook body template: %s", err))
+	}
+
+	encoded, err := json.Marshal(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("ctx", string(encoded))
+	evaluated, err := vm.EvaluateSnippet(e.c.Body, template.String())
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to render the web hook body template: %s", err))
+	}
+
+	return []byte(evaluated), nil
+}
+
+func (e *WebHook) send(ctx context.Context, payload *webHookPayload, jsonnetCtx *webHookContext) error {
+	body, err := e.body(payload, jsonnetCtx)
+	if err != nil {
+		return err
+	}
+
+	if !e.c.Async {
+		return e.r.WebhookClient().Send(ctx, e.c.Method, e.c.URL, body)
+	}
+
+	// In multi-region deployments, receivers which read the identity from another region must not see stale data.
+	var delay time.Duration
+	if conf := e.r.Config(ctx); conf.MultiRegionEnabled() {
+		delay = conf.MultiRegionReplicaLagTolerance()
+	}
+
+	logError := func(err error) {
+		e.r.Logger().
+			WithError(err).
+			WithField("url", e.c.URL).
+			WithField("event", payload.Event).
+			Error("Unable to call the asynchronous web hook.")
+	}
+
+	// The request is sent by the web hook client's workers because the request context is canceled once the
+	// response was written.
+	if err := e.r.WebhookClient().SendAsync(e.c.Method, e.c.URL, body, delay, logError); err != nil {
+		logError(err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package hook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/webhook"
+	"github.com/ory/kratos/x"
+)
+
+func TestWebHook(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	secret := "a-very-secret-webhook-key"
+	conf.MustSet(config.ViperKeySecretsWebhook, []string{secret})
+	conf.MustSet(config.ViperKeyWebhookMaxRetries, 0)
+
+	var (
+		status int
+		header http.Header
+		body   []byte
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(ts.Close)
+
+	h, err := hook.NewWebHook(reg, json.RawMessage(`{"url":"`+ts.URL+`"}`))
+	require.NoError(t, err)
+
+	i := &identity.Identity{ID: x.NewUUID(), Traits: identity.Traits(`{"email":"foo@ory.sh"}`)}
+	f := &registration.Flow{ID: x.NewUUID(), RequestURL: "http://localhost/self-service/registration/browser"}
+	r := httptest.NewRequest("POST", "/", nil)
+
+	t.Run("case=sends signed payload", func(t *testing.T) {
+		status = http.StatusOK
+		require.NoError(t, h.ExecutePostRegistrationPostPersistHook(nil, r, f, &session.Session{Identity: i}))
+
+		require.NoError(t, webhook.Verify(header, body, time.Minute, []byte(secret)))
+		assert.Equal(t, "registration.after", gjson.GetBytes(body, "event").String())
+		assert.Equal(t, f.ID.String(), gjson.GetBytes(body, "flow_id").String())
+		assert.Equal(t, i.ID.String(), gjson.GetBytes(body, "identity.id").String())
+		assert.Equal(t, "foo@ory.sh", gjson.GetBytes(body, "identity.traits.email").String())
+		assert.NoError(t, webhook.ValidateEventPayload(webhook.EventRegistrationAfter, body))
+	})
+
+	t.Run("case=fails on error response", func(t *testing.T) {
+		status = http.StatusBadGateway
+		assert.Error(t, h.ExecutePostRegistrationPostPersistHook(nil, r, f, &session.Session{Identity: i}))
+	})
+
+	t.Run("case=renders jsonnet body", func(t *testing.T) {
+		h, err := hook.NewWebHook(reg, json.RawMessage(`{"url":"`+ts.URL+`","body":"file://./stub/web_hook_body.jsonnet"}`))
+		require.NoError(t, err)
+
+		status = http.StatusOK
+		require.NoError(t, h.ExecutePostRegistrationPostPersistHook(nil, r, f, &session.Session{Identity: i}))
+		require.NoError(t, webhook.Verify(header, body, time.Minute, []byte(secret)))
+		assert.JSONEq(t, `{"type":"registration.after","user_id":"`+i.ID.String()+`","email":"foo@ory.sh","flow_url":"`+f.RequestURL+`"}`, string(body))
+	})
+
+	t.Run("case=fails on invalid jsonnet body", func(t *testing.T) {
+		h, err := hook.NewWebHook(reg, json.RawMessage(`{"url":"`+ts.URL+`","body":"base64://e30pKQ=="}`))
+		require.NoError(t, err)
+		assert.Error(t, h.ExecutePostRegistrationPostPersistHook(nil, r, f, &session.Session{Identity: i}))
+	})
+
+	t.Run("case=async hooks do not interrupt the flow", func(t *testing.T) {
+		h, err := hook.NewWebHook(reg, json.RawMessage(`{"url":"`+ts.URL+`","async":true}`))
+		require.NoError(t, err)
+
+		status = http.StatusBadGateway
+		assert.NoError(t, h.ExecutePostRegistrationPostPersistHook(nil, r, f, &session.Session{Identity: i}))
+	})
+
+	t.Run("case=async hooks are sent by the web hook client's workers", func(t *testing.T) {
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+		}))
+		t.Cleanup(ts.Close)
+
+		h, err := hook.NewWebHook(reg, json.RawMessage(`{"url":"`+ts.URL+`","async":true}`))
+		require.NoError(t, err)
+		require.NoError(t, h.ExecutePostRegistrationPostPersistHook(nil, r, f, &session.Session{Identity: i}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go reg.WebhookClient().Work(ctx)
+
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt32(&calls) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
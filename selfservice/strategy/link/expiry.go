@@ -0,0 +1,250 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/courier"
+	templates "github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/webhook"
+	"github.com/ory/kratos/x"
+)
+
+const expiryBatchSize = 100
+
+const (
+	ExpiredLinkTypeVerification = "verification"
+	ExpiredLinkTypeRecovery     = "recovery"
+)
+
+type (
+	expiryNotifierDependencies interface {
+		config.Provider
+		courier.Provider
+		webhook.ClientProvider
+		x.LoggingProvider
+		x.ClockProvider
+
+		ExpiryPersistenceProvider
+	}
+
+	ExpiryNotifierProvider interface {
+		LinkExpiryNotifier() *ExpiryNotifier
+	}
+
+	// ExpiryNotifier reports verification and recovery links which expired without being used.
+	ExpiryNotifier struct {
+		d expiryNotifierDependencies
+	}
+
+	// ExpiryNotification records that an expired link was reported.
+	ExpiryNotification struct {
+		ID  uuid.UUID `json:"id" db:"id"`
+		NID uuid.UUID `json:"-" db:"nid"`
+
+		// TokenID is the ID of the verification or recovery token which expired.
+		TokenID uuid.UUID `json:"token_id" db:"token_id"`
+
+		// FlowType is either `verification` or `recovery`.
+		FlowType string `json:"flow_type" db:"flow_type"`
+
+		// CreatedAt is the time at which the expired link was reported.
+		CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" db:"updated_at"`
+	}
+
+	// ExpiredLinkEvent is sent to `selfservice.flows.<verification|recovery>.expired_links.web_hook_url`
+	// when a link expired without being used.
+	ExpiredLinkEvent struct {
+		// Type is either `verification` or `recovery`.
+		Type string `json:"type"`
+
+		// LinkID is the ID of the link which expired. It stays the same when the event is retried.
+		LinkID uuid.UUID `json:"link_id"`
+
+		// IdentityID is the ID of the identity the link was sent to.
+		IdentityID uuid.UUID `json:"identity_id"`
+
+		// Address is the address the link was sent to.
+		Address string `json:"address"`
+
+		// Via is the type of the address, for example `email`.
+		Via string `json:"via"`
+
+		// IssuedAt is the time at which the link was sent.
+		IssuedAt time.Time `json:"issued_at"`
+
+		// ExpiresAt is the time at which the link expired.
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+)
+
+func (n ExpiryNotification) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "selfservice_link_expiry_notifications")
+}
+
+func NewExpiryNotifier(d expiryNotifierDependencies) *ExpiryNotifier {
+	return &ExpiryNotifier{d: d}
+}
+
+// Notify reports all links which expired within the configured lookback and were neither used nor reported
+// yet. Only the most recent link sent to an address is reported. It returns how many links were reported.
+func (n *ExpiryNotifier) Notify(ctx context.Context) (int, error) {
+	verified, err := n.notifyVerification(ctx)
+	if err != nil {
+		return verified, err
+	}
+
+	recovered, err := n.notifyRecovery(ctx)
+	return verified + recovered, err
+}
+
+func (n *ExpiryNotifier) notifyVerification(ctx context.Context) (int, error) {
+	c := n.d.Config(ctx).SelfServiceFlowVerificationExpiredLinks()
+	if !c.Enabled() {
+		return 0, nil
+	}
+
+	now := n.d.Clock().Now().UTC()
+	if err := n.d.LinkExpiryPersister().DeleteExpiryNotifications(ctx, ExpiredLinkTypeVerification, now.Add(-c.Lookback)); err != nil {
+		return 0, err
+	}
+
+	var sent int
+	for {
+		tokens, err := n.d.LinkExpiryPersister().ListExpiredVerificationTokens(ctx, now.Add(-c.Lookback), now, expiryBatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for k := range tokens {
+			t := &tokens[k]
+
+			// Addresses can be verified without the link, for example by an administrator.
+			if !t.VerifiableAddress.Verified {
+				if err := n.emit(ctx, c, &ExpiredLinkEvent{
+					Type:       ExpiredLinkTypeVerification,
+					LinkID:     t.ID,
+					IdentityID: t.VerifiableAddress.IdentityID,
+					Address:    t.VerifiableAddress.Value,
+					Via:        string(t.VerifiableAddress.Via),
+					IssuedAt:   t.IssuedAt,
+					ExpiresAt:  t.ExpiresAt,
+				}, templates.NewVerificationExpired(n.d.Config(ctx), &templates.VerificationExpiredModel{
+					To:              t.VerifiableAddress.Value,
+					VerificationURL: urlx.AppendPaths(n.d.Config(ctx).SelfPublicURL(nil), verification.RouteInitBrowserFlow).String(),
+					IdentityID:      t.VerifiableAddress.IdentityID,
+				})); err != nil {
+					return sent, err
+				}
+				sent++
+			}
+
+			if err := n.d.LinkExpiryPersister().CreateExpiryNotification(ctx, &ExpiryNotification{
+				TokenID:  t.ID,
+				FlowType: ExpiredLinkTypeVerification,
+			}); err != nil {
+				return sent, err
+			}
+		}
+
+		if len(tokens) < expiryBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+func (n *ExpiryNotifier) notifyRecovery(ctx context.Context) (int, error) {
+	c := n.d.Config(ctx).SelfServiceFlowRecoveryExpiredLinks()
+	if !c.Enabled() {
+		return 0, nil
+	}
+
+	now := n.d.Clock().Now().UTC()
+	if err := n.d.LinkExpiryPersister().DeleteExpiryNotifications(ctx, ExpiredLinkTypeRecovery, now.Add(-c.Lookback)); err != nil {
+		return 0, err
+	}
+
+	var sent int
+	for {
+		tokens, err := n.d.LinkExpiryPersister().ListExpiredRecoveryTokens(ctx, now.Add(-c.Lookback), now, expiryBatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for k := range tokens {
+			t := &tokens[k]
+
+			if !t.RecoveryAddress.Disabled {
+				if err := n.emit(ctx, c, &ExpiredLinkEvent{
+					Type:       ExpiredLinkTypeRecovery,
+					LinkID:     t.ID,
+					IdentityID: t.RecoveryAddress.IdentityID,
+					Address:    t.RecoveryAddress.Value,
+					Via:        string(t.RecoveryAddress.Via),
+					IssuedAt:   t.IssuedAt,
+					ExpiresAt:  t.ExpiresAt,
+				}, templates.NewRecoveryExpired(n.d.Config(ctx), &templates.RecoveryExpiredModel{
+					To:          t.RecoveryAddress.Value,
+					RecoveryURL: urlx.AppendPaths(n.d.Config(ctx).SelfPublicURL(nil), recovery.RouteInitBrowserFlow).String(),
+					IdentityID:  t.RecoveryAddress.IdentityID,
+				})); err != nil {
+					return sent, err
+				}
+				sent++
+			}
+
+			if err := n.d.LinkExpiryPersister().CreateExpiryNotification(ctx, &ExpiryNotification{
+				TokenID:  t.ID,
+				FlowType: ExpiredLinkTypeRecovery,
+			}); err != nil {
+				return sent, err
+			}
+		}
+
+		if len(tokens) < expiryBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// emit sends the event to the web hook before queueing the email so that a failing web hook does not
+// result in the email being sent again on the next run.
+func (n *ExpiryNotifier) emit(ctx context.Context, c config.ExpiredLinksConfig, e *ExpiredLinkEvent, t courier.EmailTemplate) error {
+	if c.WebHookURL != nil {
+		body, err := json.Marshal(e)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := n.d.WebhookClient().Send(ctx, "POST", c.WebHookURL.String(), body); err != nil {
+			return err
+		}
+	}
+
+	if c.SendEmail && e.Via == identity.AddressTypeEmail {
+		if _, err := n.d.Courier(ctx).QueueEmail(ctx, t); err != nil {
+			return err
+		}
+	}
+
+	n.d.Logger().
+		WithField("type", e.Type).
+		WithField("link_id", e.LinkID).
+		WithField("identity_id", e.IdentityID).
+		Debug("Reported a link which expired without being used.")
+	return nil
+}
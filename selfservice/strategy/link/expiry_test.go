@@ -0,0 +1,130 @@
+package link_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/webhook"
+	"github.com/ory/x/urlx"
+)
+
+func TestExpiryNotifier(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/default.schema.json")
+	conf.MustSet(config.ViperKeyPublicBaseURL, "https://www.ory.sh/")
+
+	var lock sync.Mutex
+	var events []link.ExpiredLinkEvent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		assert.NoError(t, webhook.ValidateEventPayload(webhook.EventLinkExpired, body))
+
+		var e link.ExpiredLinkEvent
+		require.NoError(t, json.Unmarshal(body, &e))
+
+		lock.Lock()
+		events = append(events, e)
+		lock.Unlock()
+	}))
+	t.Cleanup(ts.Close)
+
+	conf.MustSet(config.ViperKeySelfServiceVerificationExpiredLinks, map[string]interface{}{"web_hook_url": ts.URL, "send_email": true})
+	conf.MustSet(config.ViperKeySelfServiceRecoveryExpiredLinks, map[string]interface{}{"web_hook_url": ts.URL})
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"email": "expired@ory.sh"}`)
+	require.NoError(t, reg.IdentityManager().Create(ctx, i))
+
+	expiredAt := time.Now().UTC().Add(-time.Hour)
+
+	verificationToken := &link.VerificationToken{
+		Token:             "verification-token",
+		VerifiableAddress: &i.VerifiableAddresses[0],
+		IssuedAt:          expiredAt.Add(-time.Hour),
+		ExpiresAt:         expiredAt,
+	}
+	require.NoError(t, reg.VerificationTokenPersister().CreateVerificationToken(ctx, verificationToken))
+
+	recoveryToken := link.NewRecoveryToken(&i.RecoveryAddresses[0], expiredAt.Add(-time.Hour), time.Hour)
+	require.NoError(t, reg.RecoveryTokenPersister().CreateRecoveryToken(ctx, recoveryToken))
+
+	sent, err := reg.LinkExpiryNotifier().Notify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, link.ExpiredLinkTypeVerification, events[0].Type)
+	assert.Equal(t, verificationToken.ID, events[0].LinkID)
+	assert.Equal(t, i.ID, events[0].IdentityID)
+	assert.Equal(t, "expired@ory.sh", events[0].Address)
+	assert.Equal(t, "email", events[0].Via)
+	assert.Equal(t, link.ExpiredLinkTypeRecovery, events[1].Type)
+	assert.Equal(t, recoveryToken.ID, events[1].LinkID)
+
+	messages, err := reg.CourierPersister().NextMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1, "emails are only sent if enabled")
+	assert.Equal(t, "expired@ory.sh", messages[0].Recipient)
+	assert.Contains(t, messages[0].Subject, "Your verification link has expired")
+	assert.Contains(t, messages[0].Body, urlx.AppendPaths(conf.SelfPublicURL(nil), verification.RouteInitBrowserFlow).String())
+
+	t.Run("case=does not report links twice", func(t *testing.T) {
+		sent, err := reg.LinkExpiryNotifier().Notify(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, sent)
+		assert.Len(t, events, 2)
+	})
+
+	t.Run("case=does not report links whose address was verified otherwise", func(t *testing.T) {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"email": "verified@ory.sh"}`)
+		require.NoError(t, reg.IdentityManager().Create(ctx, i))
+
+		i.VerifiableAddresses[0].Verified = true
+		require.NoError(t, reg.PrivilegedIdentityPool().UpdateVerifiableAddress(ctx, &i.VerifiableAddresses[0]))
+
+		require.NoError(t, reg.VerificationTokenPersister().CreateVerificationToken(ctx, &link.VerificationToken{
+			Token:             "verified-token",
+			VerifiableAddress: &i.VerifiableAddresses[0],
+			IssuedAt:          expiredAt.Add(-time.Hour),
+			ExpiresAt:         expiredAt,
+		}))
+
+		sent, err := reg.LinkExpiryNotifier().Notify(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, sent)
+		assert.Len(t, events, 2)
+	})
+
+	t.Run("case=does not report anything if disabled", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRecoveryExpiredLinks+".web_hook_url", "")
+
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"email": "disabled@ory.sh"}`)
+		require.NoError(t, reg.IdentityManager().Create(ctx, i))
+		require.NoError(t, reg.RecoveryTokenPersister().CreateRecoveryToken(ctx,
+			link.NewRecoveryToken(&i.RecoveryAddresses[0], expiredAt.Add(-time.Hour), time.Hour)))
+
+		sent, err := reg.LinkExpiryNotifier().Notify(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, sent)
+		assert.Len(t, events, 2)
+	})
+}
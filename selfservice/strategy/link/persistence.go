@@ -2,6 +2,9 @@ package link
 
 import (
 	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
 )
 
 type (
@@ -9,6 +12,15 @@ type (
 		CreateRecoveryToken(ctx context.Context, token *RecoveryToken) error
 		UseRecoveryToken(ctx context.Context, token string) (*RecoveryToken, error)
 		DeleteRecoveryToken(ctx context.Context, token string) error
+
+		// ReserveRecoveryCodeAttempt counts an attempt against the unused codes of the flow before a code is compared.
+		// Codes which reached maxAttempts with earlier attempts become unusable. It returns true, and reserves
+		// nothing, if the flow has no code left with an attempt to spare.
+		ReserveRecoveryCodeAttempt(ctx context.Context, flowID uuid.UUID, maxAttempts int) (bool, error)
+
+		// LastRecoveryTokenIssuedAt returns when the last token was issued for the recovery address, or the zero
+		// time if none was issued.
+		LastRecoveryTokenIssuedAt(ctx context.Context, addressID uuid.UUID) (time.Time, error)
 	}
 
 	RecoveryTokenPersistenceProvider interface {
@@ -17,11 +29,43 @@ type (
 
 	VerificationTokenPersister interface {
 		CreateVerificationToken(ctx context.Context, token *VerificationToken) error
+		// UseVerificationToken marks the token as used. All other tokens of the same address become unusable as well.
 		UseVerificationToken(ctx context.Context, token string) (*VerificationToken, error)
 		DeleteVerificationToken(ctx context.Context, token string) error
+
+		// InvalidateVerificationTokens makes the unused tokens of the verifiable address unusable, except for the
+		// keep most recent tokens issued after issuedAfter.
+		InvalidateVerificationTokens(ctx context.Context, addressID uuid.UUID, keep int, issuedAfter time.Time) error
+
+		// ReserveVerificationCodeAttempt works like RecoveryTokenPersister.ReserveRecoveryCodeAttempt for
+		// verification codes.
+		ReserveVerificationCodeAttempt(ctx context.Context, flowID uuid.UUID, maxAttempts int) (bool, error)
+
+		// LastVerificationTokenIssuedAt returns when the last token was issued for the verifiable address, or the
+		// zero time if none was issued.
+		LastVerificationTokenIssuedAt(ctx context.Context, addressID uuid.UUID) (time.Time, error)
 	}
 
 	VerificationTokenPersistenceProvider interface {
 		VerificationTokenPersister() VerificationTokenPersister
 	}
+
+	ExpiryPersister interface {
+		// ListExpiredVerificationTokens returns up to limit unused verification tokens, ordered by expiry, which
+		// expired after expiredAfter and before expiredBefore, were not reported yet, and are the most recent
+		// token of their address. The verifiable address is loaded as well.
+		ListExpiredVerificationTokens(ctx context.Context, expiredAfter, expiredBefore time.Time, limit int) ([]VerificationToken, error)
+
+		// ListExpiredRecoveryTokens works like ListExpiredVerificationTokens for recovery tokens.
+		ListExpiredRecoveryTokens(ctx context.Context, expiredAfter, expiredBefore time.Time, limit int) ([]RecoveryToken, error)
+
+		CreateExpiryNotification(ctx context.Context, n *ExpiryNotification) error
+
+		// DeleteExpiryNotifications deletes the notifications of the flow type created before createdBefore.
+		DeleteExpiryNotifications(ctx context.Context, flowType string, createdBefore time.Time) error
+	}
+
+	ExpiryPersistenceProvider interface {
+		LinkExpiryPersister() ExpiryPersister
+	}
 )
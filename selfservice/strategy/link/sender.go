@@ -26,6 +26,7 @@ type (
 		identity.PoolProvider
 		identity.ManagementProvider
 		x.LoggingProvider
+		x.ClockProvider
 		config.Provider
 
 		VerificationTokenPersistenceProvider
@@ -41,15 +42,22 @@ type (
 	}
 )
 
-var ErrUnknownAddress = errors.New("verification requested for unknown address")
+var (
+	ErrUnknownAddress = errors.New("verification requested for unknown address")
+
+	// ErrResendThrottled is returned if a code was requested again before
+	// `selfservice.methods.link.config.code_resend_interval` passed.
+	ErrResendThrottled = errors.New("code requested again too soon")
+)
 
 func NewSender(r senderDependencies) *Sender {
 	return &Sender{r: r}
 }
 
-// SendRecoveryLink sends a recovery link to the specified address. If the address does not exist in the store, an email is
-// still being sent to prevent account enumeration attacks. In that case, this function returns the ErrUnknownAddress
-// error.
+// SendRecoveryLink sends a recovery link, or a recovery code if config.ViperKeyLinkRecoveryCode is set, to the specified
+// address. If the address does not exist in the store, an email is still being sent to prevent account enumeration
+// attacks. In that case, this function returns the ErrUnknownAddress error. An address receives at most one
+// recovery code per resend interval and ErrResendThrottled is returned otherwise.
 func (s *Sender) SendRecoveryLink(ctx context.Context, r *http.Request, f *recovery.Flow, via identity.VerifiableAddressType, to string) error {
 	s.r.Logger().
 		WithField("via", via).
@@ -58,13 +66,33 @@ func (s *Sender) SendRecoveryLink(ctx context.Context, r *http.Request, f *recov
 
 	address, err := s.r.IdentityPool().FindRecoveryAddressByValue(ctx, identity.RecoveryAddressTypeEmail, to)
 	if err != nil {
-		if err := s.send(ctx, string(via), templates.NewRecoveryInvalid(s.r.Config(ctx), &templates.RecoveryInvalidModel{To: to})); err != nil {
+		if errors.Is(err, sqlcon.ErrNoRows) {
+			if err := s.send(ctx, string(via), templates.NewRecoveryInvalid(s.r.Config(ctx), &templates.RecoveryInvalidModel{To: to})); err != nil {
+				return err
+			}
+			return errors.Cause(ErrUnknownAddress)
+		}
+		return err
+	}
+
+	now := s.r.Clock().Now()
+	if s.r.Config(ctx).LinkRecoveryCodeEnabled() {
+		last, err := s.r.RecoveryTokenPersister().LastRecoveryTokenIssuedAt(ctx, address.ID)
+		if err != nil {
 			return err
 		}
-		return errors.Cause(ErrUnknownAddress)
+
+		if interval := s.r.Config(ctx).LinkCodeConfig().ResendInterval; now.Before(last.Add(interval)) {
+			s.r.Audit().
+				WithField("via", via).
+				WithField("identity_id", address.IdentityID).
+				WithSensitiveField("email_address", to).
+				Info("Not sending out recovery email because the previous code was sent too recently.")
+			return errors.Cause(ErrResendThrottled)
+		}
 	}
 
-	token := NewSelfServiceRecoveryToken(address, f)
+	token := NewSelfServiceRecoveryToken(s.r.Config(ctx), address, f, now)
 	if err := s.r.RecoveryTokenPersister().CreateRecoveryToken(ctx, token); err != nil {
 		return err
 	}
@@ -76,9 +104,11 @@ func (s *Sender) SendRecoveryLink(ctx context.Context, r *http.Request, f *recov
 	return nil
 }
 
-// SendVerificationLink sends a verification link to the specified address. If the address does not exist in the store, an email is
-// still being sent to prevent account enumeration attacks. In that case, this function returns the ErrUnknownAddress
-// error.
+// SendVerificationLink sends a verification link to the specified address, or a code if it is a phone number. If
+// the address does not exist in the store, an email is still being sent to prevent account enumeration attacks. In
+// that case, this function returns the ErrUnknownAddress error. Unknown phone numbers do not receive an SMS, as
+// anyone could otherwise send SMS to arbitrary numbers at the operator's expense. For the same reason, an address
+// receives at most one message per resend interval and ErrResendThrottled is returned otherwise.
 func (s *Sender) SendVerificationLink(ctx context.Context, f *verification.Flow, via identity.VerifiableAddressType, to string) error {
 	s.r.Logger().
 		WithField("via", via).
@@ -88,6 +118,14 @@ func (s *Sender) SendVerificationLink(ctx context.Context, f *verification.Flow,
 	address, err := s.r.IdentityPool().FindVerifiableAddressByValue(ctx, via, to)
 	if err != nil {
 		if errorsx.Cause(err) == sqlcon.ErrNoRows {
+			if via == identity.VerifiableAddressTypeSMS {
+				s.r.Audit().
+					WithField("via", via).
+					WithSensitiveField("phone_number", to).
+					Info("Not sending out verification SMS because phone number is unknown.")
+				return errors.Cause(ErrUnknownAddress)
+			}
+
 			s.r.Audit().
 				WithField("via", via).
 				WithSensitiveField("email_address", address).
@@ -100,7 +138,29 @@ func (s *Sender) SendVerificationLink(ctx context.Context, f *verification.Flow,
 		return err
 	}
 
-	token := NewSelfServiceVerificationToken(address, f)
+	now := s.r.Clock().Now()
+	last, err := s.r.VerificationTokenPersister().LastVerificationTokenIssuedAt(ctx, address.ID)
+	if err != nil {
+		return err
+	}
+
+	if interval := s.r.Config(ctx).LinkCodeConfig().ResendInterval; now.Before(last.Add(interval)) {
+		s.r.Audit().
+			WithField("via", via).
+			WithField("identity_id", address.IdentityID).
+			WithSensitiveField("address", to).
+			Info("Not sending out verification message because the previous one was sent too recently.")
+		return errors.Cause(ErrResendThrottled)
+	}
+
+	if err := s.r.VerificationTokenPersister().InvalidateVerificationTokens(ctx, address.ID,
+		s.r.Config(ctx).SelfServiceFlowVerificationResendKeepPreviousLinks(),
+		now.Add(-s.r.Config(ctx).SelfServiceFlowVerificationResendGracePeriod()),
+	); err != nil {
+		return err
+	}
+
+	token := NewSelfServiceVerificationToken(s.r.Config(ctx), address, f, now)
 	if err := s.r.VerificationTokenPersister().CreateVerificationToken(ctx, token); err != nil {
 		return err
 	}
@@ -112,6 +172,17 @@ func (s *Sender) SendVerificationLink(ctx context.Context, f *verification.Flow,
 }
 
 func (s *Sender) SendRecoveryTokenTo(ctx context.Context, f *recovery.Flow, address *identity.RecoveryAddress, token *RecoveryToken) error {
+	if code := token.Code(); len(code) > 0 {
+		s.r.Audit().
+			WithField("via", address.Via).
+			WithField("identity_id", address.IdentityID).
+			WithField("recovery_code_id", token.ID).
+			WithSensitiveField("email_address", address.Value).
+			Info("Sending out recovery email with recovery code.")
+		return s.send(ctx, string(address.Via), templates.NewRecoveryCodeValid(s.r.Config(ctx),
+			&templates.RecoveryCodeValidModel{To: address.Value, Code: code}))
+	}
+
 	s.r.Audit().
 		WithField("via", address.Via).
 		WithField("identity_id", address.IdentityID).
@@ -129,6 +200,18 @@ func (s *Sender) SendRecoveryTokenTo(ctx context.Context, f *recovery.Flow, addr
 }
 
 func (s *Sender) SendVerificationTokenTo(ctx context.Context, f *verification.Flow, address *identity.VerifiableAddress, token *VerificationToken) error {
+	if address.Via == identity.VerifiableAddressTypeSMS {
+		s.r.Audit().
+			WithField("via", address.Via).
+			WithField("identity_id", address.IdentityID).
+			WithField("verification_code_id", token.ID).
+			WithSensitiveField("phone_number", address.Value).
+			Info("Sending out verification SMS with verification code.")
+		_, err := s.r.Courier(ctx).QueueSMS(ctx, templates.NewVerificationSMS(s.r.Config(ctx),
+			&templates.VerificationSMSModel{To: address.Value, Code: token.Code()}))
+		return err
+	}
+
 	s.r.Audit().
 		WithField("via", address.Via).
 		WithField("identity_id", address.IdentityID).
@@ -3,19 +3,23 @@ package link_test
 import (
 	"context"
 	"net/http"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/kratos/courier"
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/persistence/fault"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/flow/verification"
 	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/x/sqlcon"
 	"github.com/ory/x/urlx"
 )
 
@@ -32,7 +36,7 @@ func TestManager(t *testing.T) {
 	require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
 
 	t.Run("method=SendRecoveryLink", func(t *testing.T) {
-		f, err := recovery.NewFlow(conf, time.Hour, "", u, reg.RecoveryStrategies(context.Background()), flow.TypeBrowser)
+		f, err := recovery.NewFlow(conf, time.Now(), time.Hour, "", u, reg.RecoveryStrategies(context.Background()), flow.TypeBrowser)
 		require.NoError(t, err)
 
 		require.NoError(t, reg.RecoveryFlowPersister().CreateRecoveryFlow(context.Background(), f))
@@ -58,7 +62,7 @@ func TestManager(t *testing.T) {
 	})
 
 	t.Run("method=SendVerificationLink", func(t *testing.T) {
-		f, err := verification.NewFlow(conf, time.Hour, "", u, reg.VerificationStrategies(context.Background()), flow.TypeBrowser)
+		f, err := verification.NewFlow(conf, time.Now(), time.Hour, "", u, reg.VerificationStrategies(context.Background()), flow.TypeBrowser)
 		require.NoError(t, err)
 
 		require.NoError(t, reg.VerificationFlowPersister().CreateVerificationFlow(context.Background(), f))
@@ -80,4 +84,121 @@ func TestManager(t *testing.T) {
 		assert.Contains(t, messages[1].Subject, "tried to verify")
 		assert.NotContains(t, messages[1].Body, urlx.AppendPaths(conf.SelfPublicURL(nil), verification.RouteSubmitFlow).String()+"?")
 	})
+
+	t.Run("method=SendVerificationLink/case=resending", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyLinkCodeResendInterval, "0s")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyLinkCodeResendInterval, "30s")
+		})
+
+		f, err := verification.NewFlow(conf, time.Now(), time.Hour, "", u, reg.VerificationStrategies(context.Background()), flow.TypeBrowser)
+		require.NoError(t, err)
+		require.NoError(t, reg.VerificationFlowPersister().CreateVerificationFlow(context.Background(), f))
+
+		sendTwice := func(t *testing.T) (first, second string) {
+			for k := 0; k < 2; k++ {
+				require.NoError(t, reg.LinkSender().SendVerificationLink(context.Background(), f, "email", "tracked@ory.sh"))
+			}
+
+			messages, err := reg.CourierPersister().NextMessages(context.Background(), 12)
+			require.NoError(t, err)
+			require.Len(t, messages, 2)
+
+			token := func(body string) string {
+				matches := regexp.MustCompile(`token=([a-zA-Z0-9]+)`).FindStringSubmatch(body)
+				require.Len(t, matches, 2, "%s", body)
+				return matches[1]
+			}
+			return token(messages[0].Body), token(messages[1].Body)
+		}
+
+		t.Run("case=previous links are invalidated", func(t *testing.T) {
+			first, second := sendTwice(t)
+
+			_, err := reg.VerificationTokenPersister().UseVerificationToken(context.Background(), first)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			_, err = reg.VerificationTokenPersister().UseVerificationToken(context.Background(), second)
+			require.NoError(t, err)
+		})
+
+		t.Run("case=previous links are kept valid within the grace period", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceVerificationResendKeepPreviousLinks, 1)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceVerificationResendKeepPreviousLinks, 0)
+			})
+
+			first, second := sendTwice(t)
+
+			_, err := reg.VerificationTokenPersister().UseVerificationToken(context.Background(), first)
+			require.NoError(t, err)
+
+			_, err = reg.VerificationTokenPersister().UseVerificationToken(context.Background(), second)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=emails are not resent within the resend interval", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyLinkCodeResendInterval, "1h")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyLinkCodeResendInterval, "0s")
+			})
+
+			require.ErrorIs(t, reg.LinkSender().SendVerificationLink(context.Background(), f, "email", "tracked@ory.sh"), link.ErrResendThrottled)
+
+			messages, err := reg.CourierPersister().NextMessages(context.Background(), 12)
+			require.ErrorIs(t, err, courier.ErrQueueEmpty)
+			assert.Empty(t, messages)
+		})
+	})
+
+	t.Run("case=persistence faults", func(t *testing.T) {
+		inj := fault.NewInjector()
+		reg.WithFaultInjector(inj)
+		t.Cleanup(inj.Reset)
+
+		rf, err := recovery.NewFlow(conf, time.Now(), time.Hour, "", u, reg.RecoveryStrategies(context.Background()), flow.TypeBrowser)
+		require.NoError(t, err)
+		require.NoError(t, reg.RecoveryFlowPersister().CreateRecoveryFlow(context.Background(), rf))
+
+		vf, err := verification.NewFlow(conf, time.Now(), time.Hour, "", u, reg.VerificationStrategies(context.Background()), flow.TypeBrowser)
+		require.NoError(t, err)
+		require.NoError(t, reg.VerificationFlowPersister().CreateVerificationFlow(context.Background(), vf))
+
+		expectNoMessages := func(t *testing.T) {
+			_, err := reg.CourierPersister().NextMessages(context.Background(), 12)
+			require.ErrorIs(t, err, courier.ErrQueueEmpty)
+		}
+
+		t.Run("case=address lookup fails without sending an unknown address message", func(t *testing.T) {
+			t.Cleanup(inj.Reset)
+			inj.Add(fault.Fault{Method: "FindRecoveryAddressByValue", Err: fault.ErrUnavailable})
+			inj.Add(fault.Fault{Method: "FindVerifiableAddressByValue", Err: fault.ErrUnavailable})
+
+			assert.ErrorIs(t, reg.LinkSender().SendRecoveryLink(context.Background(), nil, rf, "email", "tracked@ory.sh"), fault.ErrUnavailable)
+			assert.ErrorIs(t, reg.LinkSender().SendVerificationLink(context.Background(), vf, "email", "tracked@ory.sh"), fault.ErrUnavailable)
+			expectNoMessages(t)
+		})
+
+		t.Run("case=address not found sends an unknown address message", func(t *testing.T) {
+			t.Cleanup(inj.Reset)
+			inj.Add(fault.Fault{Method: "FindRecoveryAddressByValue", Err: sqlcon.ErrNoRows})
+
+			require.EqualError(t, reg.LinkSender().SendRecoveryLink(context.Background(), nil, rf, "email", "tracked@ory.sh"), link.ErrUnknownAddress.Error())
+
+			messages, err := reg.CourierPersister().NextMessages(context.Background(), 12)
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+			assert.Contains(t, messages[0].Subject, "Account access attempted")
+		})
+
+		t.Run("case=queueing the message fails", func(t *testing.T) {
+			t.Cleanup(inj.Reset)
+			inj.Add(fault.Fault{Method: "AddMessage", Err: fault.ErrUnavailable, Times: 1})
+
+			assert.ErrorIs(t, reg.LinkSender().SendRecoveryLink(context.Background(), nil, rf, "email", "tracked@ory.sh"), fault.ErrUnavailable)
+			expectNoMessages(t)
+
+			require.NoError(t, reg.LinkSender().SendRecoveryLink(context.Background(), nil, rf, "email", "tracked@ory.sh"))
+		})
+	})
 }
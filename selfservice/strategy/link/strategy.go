@@ -1,11 +1,21 @@
 package link
 
 import (
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
 	"github.com/ory/kratos/courier"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/idempotency"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/errorx"
+	"github.com/ory/kratos/selfservice/flow/callback"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/flow/verification"
@@ -35,11 +45,13 @@ type (
 		x.CSRFTokenGeneratorProvider
 		x.WriterProvider
 		x.LoggingProvider
+		x.ClockProvider
 
 		config.Provider
 
 		session.HandlerProvider
 		session.ManagementProvider
+		session.PersistenceProvider
 		settings.HandlerProvider
 		settings.FlowPersistenceProvider
 
@@ -47,11 +59,16 @@ type (
 		identity.ManagementProvider
 		identity.PoolProvider
 		identity.PrivilegedPoolProvider
+		identity.RelationshipPersistenceProvider
+
+		idempotency.MiddlewareProvider
 
 		courier.Provider
 
 		errorx.ManagementProvider
 
+		callback.NotifierProvider
+
 		recovery.ErrorHandlerProvider
 		recovery.FlowPersistenceProvider
 		recovery.StrategyProvider
@@ -84,3 +101,28 @@ func (s *Strategy) RecoveryNodeGroup() node.Group {
 func (s *Strategy) VerificationNodeGroup() node.Group {
 	return node.VerificationLinkGroup
 }
+
+// isAPITokenSubmission returns true if the token was submitted as JSON for an API flow, in which case the result
+// is returned as JSON instead of a redirect. Links clicked in an email always complete the flow in the browser.
+func isAPITokenSubmission(r *http.Request, body interface{ GetFlow() uuid.UUID }) bool {
+	return r.Method == http.MethodPost && x.IsJSONRequest(r) && body.GetFlow() != uuid.Nil
+}
+
+// adminExpiresIn parses the `expires_in` duration of the admin endpoints which create links and codes. It returns
+// def if raw is empty.
+func (s *Strategy) adminExpiresIn(raw string, def time.Duration) (time.Duration, error) {
+	expiresIn := def
+	if len(raw) > 0 {
+		var err error
+		expiresIn, err = time.ParseDuration(raw)
+		if err != nil {
+			return 0, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`Unable to parse "expires_in" whose format should match "[0-9]+(ns|us|ms|s|m|h)" but did not: %s`, raw))
+		}
+	}
+
+	now := s.d.Clock().Now()
+	if now.Add(expiresIn).Before(now) {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`Value from "expires_in" must be result to a future time: %s`, raw))
+	}
+	return expiresIn, nil
+}
@@ -3,6 +3,7 @@ package link
 import (
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -10,9 +11,11 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/ory/herodot"
+	"github.com/ory/kratos/courier"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/callback"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/strategy"
 	"github.com/ory/kratos/session"
@@ -38,7 +41,7 @@ func (s *Strategy) RegisterPublicRecoveryRoutes(public *x.RouterPublic) {
 
 func (s *Strategy) RegisterAdminRecoveryRoutes(admin *x.RouterAdmin) {
 	wrappedCreateRecoveryLink := strategy.IsDisabled(s.d, s.RecoveryStrategyID(), s.createRecoveryLink)
-	admin.POST(RouteAdminCreateRecoveryLink, wrappedCreateRecoveryLink)
+	admin.POST(RouteAdminCreateRecoveryLink, s.d.IdempotencyMiddleware().Wrap(wrappedCreateRecoveryLink))
 }
 
 func (s *Strategy) PopulateRecoveryMethod(r *http.Request, f *recovery.Flow) error {
@@ -58,6 +61,13 @@ func (s *Strategy) PopulateRecoveryMethod(r *http.Request, f *recovery.Flow) err
 type createRecoveryLinkParameters struct {
 	// in: body
 	Body CreateRecoveryLink
+	// Idempotency Key
+	//
+	// If set, retrying the request with the same key returns the response of the first successful request
+	// instead of executing it again. Keys expire after `idempotency.ttl`.
+	//
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
 }
 
 type CreateRecoveryLink struct {
@@ -68,6 +78,12 @@ type CreateRecoveryLink struct {
 	// required: true
 	IdentityID uuid.UUID `json:"identity_id"`
 
+	// Recovery Address
+	//
+	// The enabled recovery address of the identity the link or code is for. Defaults to the identity's first
+	// enabled recovery address.
+	Address string `json:"address"`
+
 	// Link Expires In
 	//
 	// The recovery link will expire at that point in time. Defaults to the configuration value of
@@ -80,6 +96,13 @@ type CreateRecoveryLink struct {
 	//	- 1m
 	//	- 1s
 	ExpiresIn string `json:"expires_in"`
+
+	// Create a Recovery Code
+	//
+	// If set, a recovery code is created instead of a link token. The code is entered together with the
+	// recovery address in the flow at `recovery_link`. Its length is configured by
+	// `selfservice.methods.link.config.code_digits`.
+	Code bool `json:"code"`
 }
 
 // swagger:model recoveryLink
@@ -88,12 +111,19 @@ type CreateRecoveryLink struct {
 type recoveryLink struct {
 	// Recovery Link
 	//
-	// This link can be used to recover the account.
+	// This link can be used to recover the account. If a code was requested, it opens the recovery flow
+	// in which the code has to be entered.
 	//
 	// required: true
 	// format: uri
 	RecoveryLink string `json:"recovery_link"`
 
+	// Recovery Code
+	//
+	// The code which recovers the account in the flow at `recovery_link`. It is only set if a code
+	// was requested.
+	RecoveryCode string `json:"recovery_code,omitempty"`
+
 	// Recovery Link Expires At
 	//
 	// The timestamp when the recovery link expires.
@@ -104,8 +134,9 @@ type recoveryLink struct {
 //
 // Create a Recovery Link
 //
-// This endpoint creates a recovery link which should be given to the user in order for them to recover
-// (or activate) their account.
+// This endpoint creates a recovery link or code which should be given to the user in order for them to recover
+// (or activate) their account. No email is sent, so that the link or code can be given to the user through
+// another channel, for example by customer support.
 //
 //     Consumes:
 //     - application/json
@@ -127,46 +158,71 @@ func (s *Strategy) createRecoveryLink(w http.ResponseWriter, r *http.Request, _
 		return
 	}
 
-	expiresIn := s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan()
-	if len(p.ExpiresIn) > 0 {
-		var err error
-		expiresIn, err = time.ParseDuration(p.ExpiresIn)
-		if err != nil {
-			s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`Unable to parse "expires_in" whose format should match "[0-9]+(ns|us|ms|s|m|h)" but did not: %s`, p.ExpiresIn)))
-			return
-		}
+	expiresIn, err := s.adminExpiresIn(p.ExpiresIn, s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan())
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
 	}
 
-	if time.Now().Add(expiresIn).Before(time.Now()) {
-		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`Value from "expires_in" must be result to a future time: %s`, p.ExpiresIn)))
+	id, err := s.d.IdentityPool().GetIdentity(r.Context(), p.IdentityID)
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
 		return
 	}
 
-	req, err := recovery.NewFlow(s.d.Config(r.Context()), expiresIn, s.d.GenerateCSRFToken(r),
-		r, s.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
+	address, err := adminRecoveryAddress(id, p.Address)
 	if err != nil {
 		s.d.Writer().WriteError(w, r, err)
 		return
 	}
 
-	if err := s.d.RecoveryFlowPersister().CreateRecoveryFlow(r.Context(), req); err != nil {
+	now := s.d.Clock().Now()
+	req, err := recovery.NewFlow(s.d.Config(r.Context()), now, expiresIn, s.d.GenerateCSRFToken(r),
+		r, s.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
+	if err != nil {
 		s.d.Writer().WriteError(w, r, err)
 		return
 	}
 
-	id, err := s.d.IdentityPool().GetIdentity(r.Context(), p.IdentityID)
-	if err != nil {
+	req.IssuedByAdmin = true
+	if p.Code {
+		req.UI.GetNodes().Upsert(
+			node.NewInputField("email", address.Value, node.RecoveryLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
+		)
+		req.UI.GetNodes().Upsert(node.NewInputField("code", nil, node.RecoveryLinkGroup, node.InputAttributeTypeText, node.WithRequiredInputAttribute).
+			WithMetaLabel(text.NewInfoNodeInputRecoveryCode()))
+		req.Active = sqlxx.NullString(s.RecoveryNodeGroup())
+		req.State = recovery.StateEmailSent
+	}
+
+	if err := s.d.RecoveryFlowPersister().CreateRecoveryFlow(r.Context(), req); err != nil {
 		s.d.Writer().WriteError(w, r, err)
 		return
 	}
 
-	if len(id.RecoveryAddresses) == 0 {
-		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The identity does not have any recovery addresses set.")))
+	if p.Code {
+		token := NewRecoveryCode(address, req, s.d.Config(r.Context()).LinkCodeConfig().Digits, now)
+		if err := s.d.RecoveryTokenPersister().CreateRecoveryToken(r.Context(), token); err != nil {
+			s.d.Writer().WriteError(w, r, err)
+			return
+		}
+
+		s.d.Audit().
+			WithField("via", address.Via).
+			WithField("identity_id", address.IdentityID).
+			WithField("recovery_code_id", token.ID).
+			WithSensitiveField("email_address", address.Value).
+			Info("A recovery code has been created.")
+
+		s.d.Writer().Write(w, r, &recoveryLink{
+			ExpiresAt:    req.ExpiresAt.UTC(),
+			RecoveryLink: req.AppendTo(s.d.Config(r.Context()).SelfServiceFlowRecoveryUI()).String(),
+			RecoveryCode: token.Code()},
+			herodot.UnescapedHTML)
 		return
 	}
 
-	address := id.RecoveryAddresses[0]
-	token := NewRecoveryToken(&address, expiresIn)
+	token := NewRecoveryToken(address, now, expiresIn)
 	if err := s.d.RecoveryTokenPersister().CreateRecoveryToken(r.Context(), token); err != nil {
 		s.d.Writer().WriteError(w, r, err)
 		return
@@ -190,6 +246,24 @@ func (s *Strategy) createRecoveryLink(w http.ResponseWriter, r *http.Request, _
 		herodot.UnescapedHTML)
 }
 
+// adminRecoveryAddress returns the identity's enabled recovery address with the given value, or its first
+// enabled recovery address if value is empty.
+func adminRecoveryAddress(i *identity.Identity, value string) (*identity.RecoveryAddress, error) {
+	addresses := i.EnabledRecoveryAddresses()
+	if len(addresses) == 0 {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The identity does not have any enabled recovery addresses set."))
+	} else if len(value) == 0 {
+		return &addresses[0], nil
+	}
+
+	for k := range addresses {
+		if strings.EqualFold(addresses[k].Value, strings.TrimSpace(value)) {
+			return &addresses[k], nil
+		}
+	}
+	return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The identity does not have the enabled recovery address %q.", value))
+}
+
 // swagger:parameters submitSelfServiceRecoveryFlowWithLinkMethod
 // nolint:deadcode,unused
 type submitSelfServiceRecoveryFlowWithLinkMethodParameters struct {
@@ -229,8 +303,20 @@ type submitSelfServiceRecoveryFlowWithLinkMethod struct {
 	// in: body
 	Email string `json:"email" form:"email"`
 
+	// Recovery Code
+	//
+	// The code sent by email if `selfservice.methods.link.config.recovery_code` is set. It is submitted
+	// together with the email address it was sent to and completes the flow it was sent for.
+	//
+	// in: body
+	Code string `json:"code" form:"code"`
+
 	// Sending the anti-csrf token is only required for browser login flows.
 	CSRFToken string `form:"csrf_token" json:"csrf_token"`
+
+	// The flow's one-time submission nonce. It is required if `selfservice.flow_replay_protection.enabled` is set
+	// and is found in the `flow_nonce` node of the flow.
+	FlowNonce string `form:"flow_nonce" json:"flow_nonce"`
 }
 
 // swagger:route POST /self-service/recovery/methods/link public submitSelfServiceRecoveryFlowWithLinkMethod
@@ -248,9 +334,14 @@ type submitSelfServiceRecoveryFlowWithLinkMethod struct {
 // - `sent_email` is the success state after `choose_method` and allows the user to request another recovery email. It
 //   works for both API and Browser-initiated flows and returns the same responses as the flow in `choose_method` state.
 // - `passed_challenge` expects a `token` to be sent in the URL query and given the nature of the flow ("sending a recovery link")
-//   does not have any API capabilities. The server responds with a HTTP 302 Found redirect either to the Settings UI URL
+//   is meant for browsers when the link is clicked. The server responds with a HTTP 302 Found redirect either to the Settings UI URL
 //   (if the link was valid) and instructs the user to update their password, or a redirect to the Recover UI URL with
 //   a new Recovery Flow ID which contains an error message that the recovery link was invalid.
+//   API clients which let the user enter the token themselves send it as JSON in the body of a POST request together
+//   with the `flow`. They receive a HTTP 200 OK with a session token and an API settings flow in which the user
+//   updates their password, or a HTTP 400 Bad Request if the token was invalid.
+// - If `selfservice.methods.link.config.recovery_code` is set, `sent_email` also accepts the `code` from the email
+//   together with the `email` it was sent to. The flow then completes like with a recovery link.
 //
 // More information can be found at [ORY Kratos Account Recovery Documentation](../self-service/flows/account-recovery.mdx).
 //
@@ -264,6 +355,7 @@ type submitSelfServiceRecoveryFlowWithLinkMethod struct {
 //     Schemes: http, https
 //
 //     Responses:
+//       200: recoveryViaApiResponse
 //       400: recoveryFlow
 //       302: emptyResponse
 //       500: genericError
@@ -290,10 +382,14 @@ func (s *Strategy) Recover(w http.ResponseWriter, r *http.Request, f *recovery.F
 		return s.handleRecoveryError(w, r, req, body, err)
 	}
 
-	if err := req.Valid(); err != nil {
+	if err := req.Valid(s.d.Clock().Now()); err != nil {
 		return s.handleRecoveryError(w, r, req, body, err)
 	}
 
+	if len(body.Code) > 0 {
+		return s.recoveryUseCode(w, r, req, body)
+	}
+
 	switch req.State {
 	case recovery.StateChooseMethod:
 		fallthrough
@@ -307,10 +403,18 @@ func (s *Strategy) Recover(w http.ResponseWriter, r *http.Request, f *recovery.F
 	}
 }
 
-func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request, f *recovery.Flow, recoveredID uuid.UUID) error {
+// recoveryPassChallenge marks the flow as completed and returns the recovered identity.
+func (s *Strategy) recoveryPassChallenge(r *http.Request, f *recovery.Flow, recoveredID uuid.UUID) (*identity.Identity, error) {
 	recovered, err := s.d.IdentityPool().GetIdentity(r.Context(), recoveredID)
 	if err != nil {
-		return s.handleRecoveryError(w, r, f, nil, err)
+		return nil, err
+	}
+
+	restrictions, err := identity.FindChildRestrictions(r.Context(), s.d.Config(r.Context()), s.d, recovered)
+	if err != nil {
+		return nil, err
+	} else if restrictions.Restricts(identity.ChildRestrictedFlowRecovery, s.RecoveryStrategyID()) {
+		return nil, errors.WithStack(identity.ErrChildSelfService)
 	}
 
 	f.UI.Messages.Clear()
@@ -320,10 +424,26 @@ func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request,
 		Valid: true,
 	}
 	if err := s.d.RecoveryFlowPersister().UpdateRecoveryFlow(r.Context(), f); err != nil {
+		return nil, err
+	}
+
+	s.d.FlowCallbackNotifier().Complete(r.Context(), &callback.Result{
+		FlowID:     f.ID,
+		FlowType:   callback.FlowTypeRecovery,
+		State:      string(f.State),
+		IdentityID: recoveredID,
+	})
+	return recovered, nil
+}
+
+func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request, f *recovery.Flow, recoveredID uuid.UUID) error {
+	recovered, err := s.recoveryPassChallenge(r, f, recoveredID)
+	if err != nil {
 		return s.handleRecoveryError(w, r, f, nil, err)
 	}
 
-	sess := session.NewActiveSession(recovered, s.d.Config(r.Context()), time.Now().UTC())
+	now := s.d.Clock().Now().UTC()
+	sess := session.NewActiveSession(recovered, s.d.Config(r.Context()), now)
 	if err := s.d.SessionManager().CreateAndIssueCookie(r.Context(), w, r, sess); err != nil {
 		return s.handleRecoveryError(w, r, f, nil, err)
 	}
@@ -333,7 +453,7 @@ func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request,
 		return s.handleRecoveryError(w, r, f, nil, err)
 	}
 
-	sf.UI.Messages.Set(text.NewRecoverySuccessful(time.Now().Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge())))
+	sf.UI.Messages.Set(text.NewRecoverySuccessful(now.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(identity.CredentialsTypePassword.String()))))
 	if err := s.d.SettingsFlowPersister().UpdateSettingsFlow(r.Context(), sf); err != nil {
 		return s.handleRecoveryError(w, r, f, nil, err)
 	}
@@ -342,7 +462,51 @@ func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request,
 	return errors.WithStack(flow.ErrCompletedByStrategy)
 }
 
+// recoveryIssueSessionAPI completes an API flow by issuing a session token and an API settings flow in which the
+// identity can update their credentials. It responds with recovery.APIFlowResponse.
+func (s *Strategy) recoveryIssueSessionAPI(w http.ResponseWriter, r *http.Request, f *recovery.Flow, body *recoverySubmitPayload, recoveredID uuid.UUID) error {
+	recovered, err := s.recoveryPassChallenge(r, f, recoveredID)
+	if err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	now := s.d.Clock().Now().UTC()
+	sess := session.NewActiveSession(recovered, s.d.Config(r.Context()), now)
+	if err := s.d.SessionPersister().CreateSession(r.Context(), sess); err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	sf, err := s.d.SettingsHandler().NewFlow(w, r, sess.Identity, flow.TypeAPI)
+	if err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	sf.UI.Messages.Set(text.NewRecoverySuccessful(now.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(identity.CredentialsTypePassword.String()))))
+	if err := s.d.SettingsFlowPersister().UpdateSettingsFlow(r.Context(), sf); err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	s.d.Writer().Write(w, r, &recovery.APIFlowResponse{
+		Flow:         f,
+		SettingsFlow: sf,
+		Session:      sess,
+		Token:        sess.Token,
+	})
+	return errors.WithStack(flow.ErrCompletedByStrategy)
+}
+
 func (s *Strategy) recoveryUseToken(w http.ResponseWriter, r *http.Request, body *recoverySubmitPayload) error {
+	if isAPITokenSubmission(r, body) {
+		f, err := s.d.RecoveryFlowPersister().GetRecoveryFlow(r.Context(), body.GetFlow())
+		if err != nil {
+			return s.handleRecoveryError(w, r, nil, body, err)
+		}
+
+		if f.Type == flow.TypeAPI {
+			return s.recoveryUseTokenAPI(w, r, f, body)
+		}
+	}
+
 	token, err := s.d.RecoveryTokenPersister().UseRecoveryToken(r.Context(), body.Token)
 	if err != nil {
 		if errors.Is(err, sqlcon.ErrNoRows) {
@@ -354,7 +518,8 @@ func (s *Strategy) recoveryUseToken(w http.ResponseWriter, r *http.Request, body
 
 	var f *recovery.Flow
 	if !token.FlowID.Valid {
-		f, err = recovery.NewFlow(s.d.Config(r.Context()), time.Until(token.ExpiresAt), s.d.GenerateCSRFToken(r),
+		now := s.d.Clock().Now()
+		f, err = recovery.NewFlow(s.d.Config(r.Context()), now, token.ExpiresAt.Sub(now), s.d.GenerateCSRFToken(r),
 			r, s.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
 		if err != nil {
 			return s.handleRecoveryError(w, r, nil, body, err)
@@ -370,17 +535,87 @@ func (s *Strategy) recoveryUseToken(w http.ResponseWriter, r *http.Request, body
 		}
 	}
 
-	if err := token.Valid(); err != nil {
+	if err := token.Valid(s.d.Clock().Now()); err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	return s.recoveryIssueSession(w, r, f, token.RecoveryAddress.IdentityID)
+}
+
+// recoveryUseTokenAPI completes an API flow using the token submitted in the JSON body.
+func (s *Strategy) recoveryUseTokenAPI(w http.ResponseWriter, r *http.Request, f *recovery.Flow, body *recoverySubmitPayload) error {
+	if err := f.Valid(s.d.Clock().Now()); err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	if f.State == recovery.StatePassedChallenge {
+		return s.retryRecoveryFlowWithMessage(w, r, f.Type, text.NewErrorValidationRecoveryRetrySuccess())
+	}
+
+	token, err := s.d.RecoveryTokenPersister().UseRecoveryToken(r.Context(), body.Token)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return s.handleRecoveryError(w, r, f, body, schema.NewRecoveryTokenInvalidError())
+	} else if err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	// Tokens can only complete the flow they were sent for.
+	if token.FlowID.UUID != f.ID {
+		return s.handleRecoveryError(w, r, f, body, schema.NewRecoveryTokenInvalidError())
+	}
+
+	if err := token.Valid(s.d.Clock().Now()); err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	return s.recoveryIssueSessionAPI(w, r, f, body, token.RecoveryAddress.IdentityID)
+}
+
+// recoveryUseCode completes the flow using a code sent by email and the email address it was sent to. Every
+// submitted code counts as an attempt against the flow's codes, which become unusable once `code_max_attempts` is
+// reached. The attempt is reserved before the code is compared, so that concurrent submissions can not try more
+// codes than allowed. A code submitted with another email address is used up.
+func (s *Strategy) recoveryUseCode(w http.ResponseWriter, r *http.Request, f *recovery.Flow, body *recoverySubmitPayload) error {
+	if err := flow.EnsureCSRF(r, f.Type, s.d.Config(r.Context()).DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, body.CSRFToken); err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	if f.State == recovery.StatePassedChallenge {
+		return s.retryRecoveryFlowWithMessage(w, r, f.Type, text.NewErrorValidationRecoveryRetrySuccess())
+	}
+
+	locked, err := s.d.RecoveryTokenPersister().ReserveRecoveryCodeAttempt(r.Context(), f.ID, s.d.Config(r.Context()).LinkCodeConfig().MaxAttempts)
+	if err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	} else if locked {
+		return s.retryRecoveryFlowWithMessage(w, r, f.Type, text.NewErrorValidationRecoveryCodeAttemptsExceeded())
+	}
+
+	token, err := s.d.RecoveryTokenPersister().UseRecoveryToken(r.Context(), RecoveryCodeToken(f.ID, body.Code))
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return s.handleRecoveryError(w, r, f, body, schema.NewRecoveryCodeInvalidError())
+	} else if err != nil {
 		return s.handleRecoveryError(w, r, f, body, err)
 	}
 
+	if !strings.EqualFold(token.RecoveryAddress.Value, strings.TrimSpace(body.Email)) {
+		return s.handleRecoveryError(w, r, f, body, schema.NewRecoveryCodeInvalidError())
+	}
+
+	if err := token.Valid(s.d.Clock().Now()); err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	if f.Type == flow.TypeAPI {
+		return s.recoveryIssueSessionAPI(w, r, f, body, token.RecoveryAddress.IdentityID)
+	}
 	return s.recoveryIssueSession(w, r, f, token.RecoveryAddress.IdentityID)
 }
 
 func (s *Strategy) retryRecoveryFlowWithMessage(w http.ResponseWriter, r *http.Request, ft flow.Type, message *text.Message) error {
 	s.d.Logger().WithRequest(r).WithField("message", message).Debug("A recovery flow is being retried because a validation error occurred.")
 
-	req, err := recovery.NewFlow(s.d.Config(r.Context()), s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), ft)
+	req, err := recovery.NewFlow(s.d.Config(r.Context()), s.d.Clock().Now(), s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), ft)
 	if err != nil {
 		return err
 	}
@@ -414,8 +649,9 @@ func (s *Strategy) recoveryHandleFormSubmission(w http.ResponseWriter, r *http.R
 		return s.handleRecoveryError(w, r, req, body, err)
 	}
 
-	if err := s.d.LinkSender().SendRecoveryLink(r.Context(), r, req, identity.VerifiableAddressTypeEmail, body.Email); err != nil {
-		if !errors.Is(err, ErrUnknownAddress) {
+	ctx, delivery := courier.WithFlowDelivery(r.Context())
+	if err := s.d.LinkSender().SendRecoveryLink(ctx, r, req, identity.VerifiableAddressTypeEmail, body.Email); err != nil {
+		if !errors.Is(err, ErrUnknownAddress) && !errors.Is(err, ErrResendThrottled) {
 			return s.handleRecoveryError(w, r, req, body, err)
 		}
 		// Continue execution
@@ -431,6 +667,14 @@ func (s *Strategy) recoveryHandleFormSubmission(w http.ResponseWriter, r *http.R
 	req.Active = sqlxx.NullString(s.RecoveryNodeGroup())
 	req.State = recovery.StateEmailSent
 	req.UI.Messages.Set(text.NewRecoveryEmailSent())
+	if s.d.Config(r.Context()).LinkRecoveryCodeEnabled() {
+		req.UI.GetNodes().Upsert(node.NewInputField("code", nil, node.RecoveryLinkGroup, node.InputAttributeTypeText).
+			WithMetaLabel(text.NewInfoNodeInputRecoveryCode()))
+		req.UI.Messages.Set(text.NewRecoveryEmailWithCodeSent())
+	}
+	if delivery.Delayed {
+		req.UI.Messages.Add(text.NewInfoSelfServiceCourierDelayed())
+	}
 	if err := s.d.RecoveryFlowPersister().UpdateRecoveryFlow(r.Context(), req); err != nil {
 		return s.handleRecoveryError(w, r, req, body, err)
 	}
@@ -457,6 +701,11 @@ type recoverySubmitPayload struct {
 	CSRFToken string `json:"csrf_token" form:"csrf_token"`
 	Flow      string `json:"flow" form:"flow"`
 	Email     string `json:"email" form:"email"`
+	Code      string `json:"code" form:"code"`
+}
+
+func (p *recoverySubmitPayload) GetFlow() uuid.UUID {
+	return x.ParseUUID(p.Flow)
 }
 
 func (s *Strategy) decodeRecovery(r *http.Request) (*recoverySubmitPayload, error) {
@@ -1,10 +1,13 @@
 package link_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
 	"testing"
 	"time"
 
@@ -33,6 +36,7 @@ import (
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/strategy/link"
 	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
@@ -129,6 +133,55 @@ func TestAdminStrategy(t *testing.T) {
 		require.Len(t, sr.Ui.Messages, 1)
 		assert.Equal(t, "You successfully recovered your account. Please change your password or set up an alternative login method (e.g. social sign in) within the next 60.00 minutes.", sr.Ui.Messages[0].Text)
 	})
+
+	createCode := func(t *testing.T, body string, expectedStatus int) []byte {
+		res, err := adminTS.Client().Post(adminTS.URL+link.RouteAdminCreateRecoveryLink, "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		actual := ioutilx.MustReadAll(res.Body)
+		require.Equal(t, expectedStatus, res.StatusCode, "%s", actual)
+		return actual
+	}
+
+	t.Run("description=should create a recovery code which recovers the account in the browser", func(t *testing.T) {
+		id := identity.Identity{Traits: identity.Traits(`{"email":"recover.code@ory.sh"}`)}
+		require.NoError(t, reg.IdentityManager().Create(context.Background(),
+			&id, identity.ManagerAllowWriteProtectedTraits))
+
+		actual := createCode(t, fmt.Sprintf(`{"identity_id":"%s","address":"Recover.Code@ory.sh","code":true}`, id.ID), http.StatusOK)
+		code := gjson.GetBytes(actual, "recovery_code").String()
+		assert.Regexp(t, regexp.MustCompile(`^[0-9]{6}$`), code, "%s", actual)
+		rl := urlx.ParseOrPanic(gjson.GetBytes(actual, "recovery_link").String())
+		assert.Equal(t, conf.SelfServiceFlowRecoveryUI().Host, rl.Host, "%s", actual)
+		flowID := rl.Query().Get("flow")
+		require.NotEmpty(t, flowID, "%s", actual)
+
+		// The browser did not initialize the flow, so it receives a new anti-CSRF token when fetching it.
+		c := testhelpers.NewClientWithCookies(t)
+		res, err := c.Get(publicTS.URL + recovery.RouteGetFlow + "?id=" + flowID)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		f := ioutilx.MustReadAll(res.Body)
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", f)
+		assert.Equal(t, "recover.code@ory.sh", gjson.GetBytes(f, "ui.nodes.#(attributes.name==email).attributes.value").String(), "%s", f)
+		assert.True(t, gjson.GetBytes(f, "ui.nodes.#(attributes.name==code)").Exists(), "%s", f)
+
+		_, res = testhelpers.HTTPPostForm(t, c, publicTS.URL+recovery.RouteSubmitFlow+"?flow="+flowID, &url.Values{
+			"csrf_token": {gjson.GetBytes(f, "ui.nodes.#(attributes.name==csrf_token).attributes.value").String()},
+			"method":     {"link"},
+			"email":      {"recover.code@ory.sh"},
+			"code":       {code},
+		})
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String())
+	})
+
+	t.Run("description=should not create a recovery code for an unknown address", func(t *testing.T) {
+		id := identity.Identity{Traits: identity.Traits(`{"email":"recover.unknown@ory.sh"}`)}
+		require.NoError(t, reg.IdentityManager().Create(context.Background(),
+			&id, identity.ManagerAllowWriteProtectedTraits))
+
+		createCode(t, fmt.Sprintf(`{"identity_id":"%s","address":"someone-else@ory.sh","code":true}`, id.ID), http.StatusBadRequest)
+	})
 }
 
 func TestRecovery(t *testing.T) {
@@ -310,6 +363,187 @@ func TestRecovery(t *testing.T) {
 		})
 	})
 
+	t.Run("description=should recover an account of an API flow using the token in the JSON body", func(t *testing.T) {
+		actual := expectSuccess(t, true, func(v url.Values) {
+			v.Set("email", recoveryEmail)
+		})
+		flowID := gjson.Get(actual, "id").String()
+
+		message := testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account")
+		recoveryLink, err := url.Parse(testhelpers.CourierExpectLinkInMessage(t, message, 1))
+		require.NoError(t, err)
+
+		submit := func(t *testing.T, token string, expectedStatus int) string {
+			res, err := http.Post(public.URL+recovery.RouteSubmitFlow+"?flow="+flowID, "application/json",
+				bytes.NewBufferString(fmt.Sprintf(`{"method":"link","token":"%s"}`, token)))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			body := string(ioutilx.MustReadAll(res.Body))
+			require.Equal(t, expectedStatus, res.StatusCode, "%s", body)
+			return body
+		}
+
+		body := submit(t, "i-do-not-exist", http.StatusBadRequest)
+		assert.EqualValues(t, text.ErrorValidationRecoveryTokenInvalidOrAlreadyUsed,
+			gjson.Get(body, "ui.nodes.#(attributes.name==token).messages.0.id").Int(), "%s", body)
+
+		body = submit(t, recoveryLink.Query().Get("token"), http.StatusOK)
+		assert.EqualValues(t, recovery.StatePassedChallenge, gjson.Get(body, "flow.state").String(), "%s", body)
+		assert.NotEmpty(t, gjson.Get(body, "session_token").String(), "%s", body)
+		assert.EqualValues(t, identityToRecover.ID.String(), gjson.Get(body, "session.identity.id").String(), "%s", body)
+		assert.EqualValues(t, "api", gjson.Get(body, "settings_flow.type").String(), "%s", body)
+		assert.Equal(t, text.NewRecoverySuccessful(time.Now().Add(time.Hour)).Text,
+			gjson.Get(body, "settings_flow.ui.messages.0.text").String(), "%s", body)
+	})
+
+	t.Run("description=should recover an account using the code sent by email", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyLinkRecoveryCode, true)
+		conf.MustSet(config.ViperKeyLinkCodeResendInterval, "0s")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyLinkRecoveryCode, false)
+			conf.MustSet(config.ViperKeyLinkCodeResendInterval, "30s")
+		})
+
+		sendCode := func(t *testing.T) (string, string) {
+			actual := expectSuccess(t, true, func(v url.Values) {
+				v.Set("email", recoveryEmail)
+			})
+			assert.EqualValues(t, text.InfoSelfServiceRecoveryEmailWithCodeSent, gjson.Get(actual, "ui.messages.0.id").Int(), "%s", actual)
+			assert.True(t, gjson.Get(actual, "ui.nodes.#(attributes.name==code)").Exists(), "%s", actual)
+
+			message := testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account")
+			assert.Contains(t, message.Body, "please recover access to your account by entering the following code")
+			code := regexp.MustCompile(`[0-9]{6}`).FindString(message.Body)
+			require.NotEmpty(t, code, "%s", message.Body)
+			return gjson.Get(actual, "id").String(), code
+		}
+
+		submit := func(t *testing.T, flowID, email, code string, expectedStatus int) string {
+			res, err := http.Post(public.URL+recovery.RouteSubmitFlow+"?flow="+flowID, "application/json",
+				bytes.NewBufferString(fmt.Sprintf(`{"method":"link","email":"%s","code":"%s"}`, email, code)))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			body := string(ioutilx.MustReadAll(res.Body))
+			require.Equal(t, expectedStatus, res.StatusCode, "%s", body)
+			return body
+		}
+
+		t.Run("case=exchanges the code and email for a session", func(t *testing.T) {
+			flowID, code := sendCode(t)
+
+			body := submit(t, flowID, recoveryEmail, "000000", http.StatusBadRequest)
+			assert.EqualValues(t, text.ErrorValidationRecoveryCodeInvalidOrAlreadyUsed,
+				gjson.Get(body, "ui.nodes.#(attributes.name==code).messages.0.id").Int(), "%s", body)
+
+			body = submit(t, flowID, recoveryEmail, code, http.StatusOK)
+			assert.EqualValues(t, recovery.StatePassedChallenge, gjson.Get(body, "flow.state").String(), "%s", body)
+			assert.NotEmpty(t, gjson.Get(body, "session_token").String(), "%s", body)
+			assert.EqualValues(t, identityToRecover.ID.String(), gjson.Get(body, "session.identity.id").String(), "%s", body)
+			assert.EqualValues(t, "api", gjson.Get(body, "settings_flow.type").String(), "%s", body)
+		})
+
+		t.Run("case=requires the email address the code was sent to", func(t *testing.T) {
+			flowID, code := sendCode(t)
+			submit(t, flowID, "someone-else@ory.sh", code, http.StatusBadRequest)
+			submit(t, flowID, recoveryEmail, code, http.StatusBadRequest)
+		})
+
+		t.Run("case=restarts the flow after too many attempts", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyLinkCodeMaxAttempts, 2)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyLinkCodeMaxAttempts, 5)
+			})
+
+			flowID, code := sendCode(t)
+			for k := 0; k < 2; k++ {
+				submit(t, flowID, recoveryEmail, "000000", http.StatusBadRequest)
+			}
+
+			body := submit(t, flowID, recoveryEmail, code, http.StatusOK)
+			assert.NotEqual(t, flowID, gjson.Get(body, "id").String(), "%s", body)
+			assert.EqualValues(t, text.ErrorValidationRecoveryCodeAttemptsExceeded, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+		})
+
+		t.Run("case=does not resend codes within the resend interval", func(t *testing.T) {
+			flowID, code := sendCode(t)
+
+			conf.MustSet(config.ViperKeyLinkCodeResendInterval, "1h")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyLinkCodeResendInterval, "0s")
+			})
+
+			sent := testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account")
+			res, err := http.Post(public.URL+recovery.RouteSubmitFlow+"?flow="+flowID, "application/json",
+				bytes.NewBufferString(fmt.Sprintf(`{"method":"link","email":"%s"}`, recoveryEmail)))
+			require.NoError(t, err)
+			body := string(ioutilx.MustReadAll(res.Body))
+			require.NoError(t, res.Body.Close())
+			require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+			assert.EqualValues(t, text.InfoSelfServiceRecoveryEmailWithCodeSent, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+			assert.Equal(t, sent.ID, testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account").ID)
+
+			body = submit(t, flowID, recoveryEmail, code, http.StatusOK)
+			assert.EqualValues(t, recovery.StatePassedChallenge, gjson.Get(body, "flow.state").String(), "%s", body)
+		})
+	})
+
+	t.Run("description=should not send another email if a submission is replayed", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceFlowReplayProtectionEnabled, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceFlowReplayProtectionEnabled, false)
+		})
+
+		res, err := http.Get(public.URL + recovery.RouteInitAPIFlow)
+		require.NoError(t, err)
+		actual := string(ioutilx.MustReadAll(res.Body))
+		require.NoError(t, res.Body.Close())
+		flowID := gjson.Get(actual, "id").String()
+		nonce := gjson.Get(actual, "ui.nodes.#(attributes.name==flow_nonce).attributes.value").String()
+		require.NotEmpty(t, nonce, "%s", actual)
+
+		submit := func(t *testing.T, nonce string, expectedStatus int) string {
+			res, err := http.Post(public.URL+recovery.RouteSubmitFlow+"?flow="+flowID, "application/json",
+				bytes.NewBufferString(fmt.Sprintf(`{"method":"link","email":"%s","flow_nonce":"%s"}`, recoveryEmail, nonce)))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			body := string(ioutilx.MustReadAll(res.Body))
+			require.Equal(t, expectedStatus, res.StatusCode, "%s", body)
+			return body
+		}
+
+		body := submit(t, "", http.StatusBadRequest)
+		assert.EqualValues(t, text.ErrorValidationFlowNonceInvalid, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+
+		body = submit(t, nonce, http.StatusOK)
+		sent := testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account")
+		next := gjson.Get(body, "ui.nodes.#(attributes.name==flow_nonce).attributes.value").String()
+		require.NotEmpty(t, next, "%s", body)
+		assert.NotEqual(t, nonce, next)
+
+		body = submit(t, nonce, http.StatusBadRequest)
+		assert.True(t, gjson.Get(body, fmt.Sprintf("ui.messages.#(id==%d)", text.ErrorValidationFlowNonceInvalid)).Exists(), "%s", body)
+		leaked := gjson.Get(body, "ui.nodes.#(attributes.name==flow_nonce).attributes.value").String()
+		assert.Empty(t, leaked, "%s", body)
+
+		body = submit(t, leaked, http.StatusBadRequest)
+		assert.True(t, gjson.Get(body, fmt.Sprintf("ui.messages.#(id==%d)", text.ErrorValidationFlowNonceInvalid)).Exists(), "%s", body)
+
+		res, err = http.Get(public.URL + recovery.RouteGetFlow + "?id=" + flowID)
+		require.NoError(t, err)
+		body = string(ioutilx.MustReadAll(res.Body))
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		fetched := gjson.Get(body, "ui.nodes.#(attributes.name==flow_nonce).attributes.value").String()
+		assert.Empty(t, fetched, "%s", body)
+
+		body = submit(t, fetched, http.StatusBadRequest)
+		assert.True(t, gjson.Get(body, fmt.Sprintf("ui.messages.#(id==%d)", text.ErrorValidationFlowNonceInvalid)).Exists(), "%s", body)
+		assert.Equal(t, sent.ID, testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account").ID)
+
+		submit(t, next, http.StatusOK)
+		assert.NotEqual(t, sent.ID, testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account").ID)
+	})
+
 	t.Run("description=should not be able to use an invalid link", func(t *testing.T) {
 		c := testhelpers.NewClientWithCookies(t)
 		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, c, public)
@@ -3,15 +3,22 @@ package link
 import (
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 
+	"github.com/ory/herodot"
+	"github.com/ory/kratos/courier"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/callback"
 	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy"
+	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/ui/node"
 	"github.com/ory/kratos/x"
@@ -21,6 +28,10 @@ import (
 	"github.com/ory/x/urlx"
 )
 
+const (
+	RouteAdminCreateVerificationLink = "/verification/link"
+)
+
 func (s *Strategy) VerificationStrategyID() string {
 	return verification.StrategyVerificationLinkName
 }
@@ -29,14 +40,13 @@ func (s *Strategy) RegisterPublicVerificationRoutes(public *x.RouterPublic) {
 }
 
 func (s *Strategy) RegisterAdminVerificationRoutes(admin *x.RouterAdmin) {
+	wrappedCreateVerificationLink := strategy.IsVerificationDisabled(s.d, s.VerificationStrategyID(), s.createVerificationLink)
+	admin.POST(RouteAdminCreateVerificationLink, s.d.IdempotencyMiddleware().Wrap(wrappedCreateVerificationLink))
 }
 
 func (s *Strategy) PopulateVerificationMethod(r *http.Request, f *verification.Flow) error {
 	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
-	f.UI.GetNodes().Upsert(
-		// v0.5: form.Field{Name: "email", Type: "email", Required: true}
-		node.NewInputField("email", nil, node.VerificationLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
-	)
+	s.upsertVerificationNodes(r, f, nil)
 	f.UI.GetNodes().Append(node.NewInputField("method", s.VerificationStrategyID(), node.VerificationLinkGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSubmit()))
 	return nil
 }
@@ -47,6 +57,33 @@ type verificationSubmitPayload struct {
 	CSRFToken string `json:"csrf_token" form:"csrf_token"`
 	Flow      string `json:"flow" form:"flow"`
 	Email     string `json:"email" form:"email"`
+	Phone     string `json:"phone" form:"phone"`
+	Code      string `json:"code" form:"code"`
+}
+
+// upsertVerificationNodes adds the address fields to the flow. If SMS delivery is enabled, identities can enter a
+// phone number instead of an email address, and then the code they received by SMS.
+func (s *Strategy) upsertVerificationNodes(r *http.Request, f *verification.Flow, body *verificationSubmitPayload) {
+	var email, phone interface{}
+	if body != nil {
+		email, phone = body.Email, body.Phone
+	}
+
+	if !s.d.Config(r.Context()).CourierSMSEnabled() {
+		f.UI.GetNodes().Upsert(
+			// v0.5: form.Field{Name: "email", Type: "email", Required: true}
+			node.NewInputField("email", email, node.VerificationLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
+		)
+		return
+	}
+
+	f.UI.GetNodes().Upsert(node.NewInputField("email", email, node.VerificationLinkGroup, node.InputAttributeTypeEmail))
+	f.UI.GetNodes().Upsert(node.NewInputField("phone", phone, node.VerificationLinkGroup, node.InputAttributeTypeTel).
+		WithMetaLabel(text.NewInfoNodeInputPhone()))
+	if body != nil && len(body.Phone) > 0 {
+		f.UI.GetNodes().Upsert(node.NewInputField("code", nil, node.VerificationLinkGroup, node.InputAttributeTypeText).
+			WithMetaLabel(text.NewInfoNodeInputVerificationCode()))
+	}
 }
 
 func (s *Strategy) decodeVerification(r *http.Request) (*verificationSubmitPayload, error) {
@@ -74,15 +111,219 @@ func (s *Strategy) decodeVerification(r *http.Request) (*verificationSubmitPaylo
 func (s *Strategy) handleVerificationError(w http.ResponseWriter, r *http.Request, f *verification.Flow, body *verificationSubmitPayload, err error) error {
 	if f != nil {
 		f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
-		f.UI.GetNodes().Upsert(
-			// v0.5: form.Field{Name: "email", Type: "email", Required: true, Value: body.Body.Email}
-			node.NewInputField("email", body.Email, node.VerificationLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
-		)
+		s.upsertVerificationNodes(r, f, body)
 	}
 
 	return err
 }
 
+// swagger:parameters createVerificationLink
+//
+// nolint
+type createVerificationLinkParameters struct {
+	// in: body
+	Body CreateVerificationLink
+	// Idempotency Key
+	//
+	// If set, retrying the request with the same key returns the response of the first successful request
+	// instead of executing it again. Keys expire after `idempotency.ttl`.
+	//
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
+}
+
+type CreateVerificationLink struct {
+	// Identity to Verify
+	//
+	// The ID of the identity whose address should be verified.
+	//
+	// required: true
+	IdentityID uuid.UUID `json:"identity_id"`
+
+	// Address to Verify
+	//
+	// The verifiable address of the identity the link or code is for. Defaults to the identity's first
+	// address which is not verified yet.
+	Address string `json:"address"`
+
+	// Link Expires In
+	//
+	// The verification link will expire at that point in time. Defaults to the configuration value of
+	// `selfservice.flows.verification.request_lifespan`.
+	//
+	//
+	// pattern: ^[0-9]+(ns|us|ms|s|m|h)$
+	// example:
+	//	- 1h
+	//	- 1m
+	//	- 1s
+	ExpiresIn string `json:"expires_in"`
+
+	// Create a Verification Code
+	//
+	// If set, a verification code is created instead of a link token. The code is entered in the flow at
+	// `verification_link`. Its length is configured by `selfservice.methods.link.config.code_digits`.
+	Code bool `json:"code"`
+}
+
+// swagger:model verificationLink
+//
+// nolint
+type verificationLink struct {
+	// Verification Link
+	//
+	// This link verifies the address. If a code was requested, it opens the verification flow in which
+	// the code has to be entered.
+	//
+	// required: true
+	// format: uri
+	VerificationLink string `json:"verification_link"`
+
+	// Verification Code
+	//
+	// The code which verifies the address in the flow at `verification_link`. It is only set if a code
+	// was requested.
+	VerificationCode string `json:"verification_code,omitempty"`
+
+	// Verification Link Expires At
+	//
+	// The timestamp when the verification link expires.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// swagger:route POST /verification/link admin createVerificationLink
+//
+// Create a Verification Link
+//
+// This endpoint creates a verification link or code for an address of an identity. No email or SMS is sent, so
+// that the link or code can be given to the user through another channel, for example by customer support.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: verificationLink
+//       404: genericError
+//       400: genericError
+//       500: genericError
+func (s *Strategy) createVerificationLink(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var p CreateVerificationLink
+	if err := s.dx.Decode(r, &p, decoderx.HTTPJSONDecoder()); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	expiresIn, err := s.adminExpiresIn(p.ExpiresIn, s.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan())
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	id, err := s.d.IdentityPool().GetIdentity(r.Context(), p.IdentityID)
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	address, err := adminVerifiableAddress(id, p.Address)
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	now := s.d.Clock().Now()
+	f, err := verification.NewFlow(s.d.Config(r.Context()), now, expiresIn, s.d.GenerateCSRFToken(r),
+		r, s.d.VerificationStrategies(r.Context()), flow.TypeBrowser)
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	f.IssuedByAdmin = true
+	if p.Code {
+		body := &verificationSubmitPayload{Email: address.Value}
+		if address.Via == identity.VerifiableAddressTypeSMS {
+			body = &verificationSubmitPayload{Phone: address.Value}
+		}
+		s.upsertVerificationNodes(r, f, body)
+		f.UI.GetNodes().Upsert(node.NewInputField("code", nil, node.VerificationLinkGroup, node.InputAttributeTypeText, node.WithRequiredInputAttribute).
+			WithMetaLabel(text.NewInfoNodeInputVerificationCode()))
+		f.Active = sqlxx.NullString(s.VerificationNodeGroup())
+		f.State = verification.StateEmailSent
+	}
+
+	if err := s.d.VerificationFlowPersister().CreateVerificationFlow(r.Context(), f); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	token := NewVerificationToken(address, f, now)
+	if p.Code {
+		token = NewVerificationCode(address, f, s.d.Config(r.Context()).LinkCodeConfig().Digits, now)
+	}
+	if err := s.d.VerificationTokenPersister().CreateVerificationToken(r.Context(), token); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if p.Code {
+		s.d.Audit().
+			WithField("via", address.Via).
+			WithField("identity_id", address.IdentityID).
+			WithField("verification_code_id", token.ID).
+			WithSensitiveField("address", address.Value).
+			Info("A verification code has been created.")
+
+		s.d.Writer().Write(w, r, &verificationLink{
+			ExpiresAt:        f.ExpiresAt.UTC(),
+			VerificationLink: f.AppendTo(s.d.Config(r.Context()).SelfServiceFlowVerificationUI()).String(),
+			VerificationCode: token.Code()},
+			herodot.UnescapedHTML)
+		return
+	}
+
+	s.d.Audit().
+		WithField("via", address.Via).
+		WithField("identity_id", address.IdentityID).
+		WithField("verification_link_id", token.ID).
+		WithSensitiveField("address", address.Value).
+		WithSensitiveField("verification_link_token", token.Token).
+		Info("A verification link has been created.")
+
+	s.d.Writer().Write(w, r, &verificationLink{
+		ExpiresAt: f.ExpiresAt.UTC(),
+		VerificationLink: urlx.CopyWithQuery(
+			urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r), verification.RouteSubmitFlow),
+			url.Values{
+				"flow":  {f.ID.String()},
+				"token": {token.Token},
+			}).String()},
+		herodot.UnescapedHTML)
+}
+
+// adminVerifiableAddress returns the identity's verifiable address with the given value, or its first address
+// which is not verified yet if value is empty.
+func adminVerifiableAddress(i *identity.Identity, value string) (*identity.VerifiableAddress, error) {
+	for k := range i.VerifiableAddresses {
+		a := &i.VerifiableAddresses[k]
+		if len(value) == 0 && !a.Verified {
+			return a, nil
+		} else if len(value) > 0 && strings.EqualFold(a.Value, strings.TrimSpace(value)) {
+			return a, nil
+		}
+	}
+
+	if len(value) == 0 {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The identity does not have any addresses which are not verified yet."))
+	}
+	return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The identity does not have the verifiable address %q.", value))
+}
+
 // swagger:parameters submitSelfServiceVerificationFlowWithLinkMethod
 // nolint:deadcode,unused
 type submitSelfServiceVerificationFlowWithLinkMethodParameters struct {
@@ -120,8 +361,27 @@ type submitSelfServiceVerificationFlowWithLinkMethod struct {
 	// in: body
 	Email string `json:"email"`
 
+	// Phone Number to Verify
+	//
+	// Can be set instead of the email if `courier.sms.enabled` is set. If the phone number is a registered
+	// verification address, a code is sent to it by SMS. Unknown phone numbers do not receive an SMS.
+	//
+	// in: body
+	Phone string `json:"phone"`
+
+	// Verification Code
+	//
+	// The code sent by SMS. It completes the flow it was sent for.
+	//
+	// in: body
+	Code string `json:"code"`
+
 	// Sending the anti-csrf token is only required for browser login flows.
 	CSRFToken string `form:"csrf_token" json:"csrf_token"`
+
+	// The flow's one-time submission nonce. It is required if `selfservice.flow_replay_protection.enabled` is set
+	// and is found in the `flow_nonce` node of the flow.
+	FlowNonce string `form:"flow_nonce" json:"flow_nonce"`
 }
 
 func (s *Strategy) Verify(w http.ResponseWriter, r *http.Request, f *verification.Flow) (err error) {
@@ -138,11 +398,19 @@ func (s *Strategy) Verify(w http.ResponseWriter, r *http.Request, f *verificatio
 		return s.verificationUseToken(w, r, body)
 	}
 
+	if len(body.Code) > 0 {
+		if err := flow.MethodEnabledAndAllowed(r.Context(), s.VerificationStrategyID(), s.VerificationStrategyID(), s.d); err != nil {
+			return s.handleVerificationError(w, r, f, body, err)
+		}
+
+		return s.verificationUseCode(w, r, f, body)
+	}
+
 	if err := flow.MethodEnabledAndAllowed(r.Context(), s.VerificationStrategyID(), body.Method, s.d); err != nil {
 		return s.handleVerificationError(w, r, f, body, err)
 	}
 
-	if err := f.Valid(); err != nil {
+	if err := f.Valid(s.d.Clock().Now()); err != nil {
 		return s.handleVerificationError(w, r, f, body, err)
 	}
 
@@ -166,7 +434,12 @@ func (s *Strategy) verificationHandleFormSubmission(w http.ResponseWriter, r *ht
 		return s.handleVerificationError(w, r, f, body, err)
 	}
 
-	if len(body.Email) == 0 {
+	via, to := identity.VerifiableAddressTypeEmail, body.Email
+	if len(body.Email) == 0 && len(body.Phone) > 0 && s.d.Config(r.Context()).CourierSMSEnabled() {
+		via, to = identity.VerifiableAddressTypeSMS, body.Phone
+	}
+
+	if len(to) == 0 {
 		return s.handleVerificationError(w, r, f, body, schema.NewRequiredError("#/email", "email"))
 	}
 
@@ -174,22 +447,26 @@ func (s *Strategy) verificationHandleFormSubmission(w http.ResponseWriter, r *ht
 		return s.handleVerificationError(w, r, f, body, err)
 	}
 
-	if err := s.d.LinkSender().SendVerificationLink(r.Context(), f, identity.VerifiableAddressTypeEmail, body.Email); err != nil {
-		if !errors.Is(err, ErrUnknownAddress) {
+	ctx, delivery := courier.WithFlowDelivery(r.Context())
+	if err := s.d.LinkSender().SendVerificationLink(ctx, f, via, to); err != nil {
+		if !errors.Is(err, ErrUnknownAddress) && !errors.Is(err, ErrResendThrottled) {
 			return s.handleVerificationError(w, r, f, body, err)
 		}
 		// Continue execution
 	}
 
 	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
-	f.UI.GetNodes().Upsert(
-		// v0.5: form.Field{Name: "email", Type: "email", Required: true, Value: body.Body.Email}
-		node.NewInputField("email", body.Email, node.VerificationLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
-	)
+	s.upsertVerificationNodes(r, f, body)
 
 	f.Active = sqlxx.NullString(s.VerificationNodeGroup())
 	f.State = verification.StateEmailSent
 	f.UI.Messages.Set(text.NewVerificationEmailSent())
+	if via == identity.VerifiableAddressTypeSMS {
+		f.UI.Messages.Set(text.NewVerificationSMSSent())
+	}
+	if delivery.Delayed {
+		f.UI.Messages.Add(text.NewInfoSelfServiceCourierDelayed())
+	}
 	if err := s.d.VerificationFlowPersister().UpdateVerificationFlow(r.Context(), f); err != nil {
 		return s.handleVerificationError(w, r, f, body, err)
 	}
@@ -206,6 +483,17 @@ type selfServiceBrowserVerifyParameters struct {
 }
 
 func (s *Strategy) verificationUseToken(w http.ResponseWriter, r *http.Request, body *verificationSubmitPayload) error {
+	if isAPITokenSubmission(r, body) {
+		f, err := s.d.VerificationFlowPersister().GetVerificationFlow(r.Context(), body.GetFlow())
+		if err != nil {
+			return s.handleVerificationError(w, r, nil, body, err)
+		}
+
+		if f.Type == flow.TypeAPI {
+			return s.verificationUseTokenAPI(w, r, f, body)
+		}
+	}
+
 	token, err := s.d.VerificationTokenPersister().UseVerificationToken(r.Context(), body.Token)
 	if err != nil {
 		if errors.Is(err, sqlcon.ErrNoRows) {
@@ -217,7 +505,8 @@ func (s *Strategy) verificationUseToken(w http.ResponseWriter, r *http.Request,
 
 	var f *verification.Flow
 	if !token.FlowID.Valid {
-		f, err = verification.NewFlow(s.d.Config(r.Context()), time.Until(token.ExpiresAt), s.d.GenerateCSRFToken(r), r, s.d.VerificationStrategies(r.Context()), flow.TypeBrowser)
+		now := s.d.Clock().Now()
+		f, err = verification.NewFlow(s.d.Config(r.Context()), now, token.ExpiresAt.Sub(now), s.d.GenerateCSRFToken(r), r, s.d.VerificationStrategies(r.Context()), flow.TypeBrowser)
 		if err != nil {
 			return s.handleVerificationError(w, r, nil, body, err)
 		}
@@ -232,21 +521,11 @@ func (s *Strategy) verificationUseToken(w http.ResponseWriter, r *http.Request,
 		}
 	}
 
-	if err := token.Valid(); err != nil {
+	if err := token.Valid(s.d.Clock().Now()); err != nil {
 		return s.handleVerificationError(w, r, f, body, err)
 	}
 
-	f.UI.Messages.Clear()
-	f.State = verification.StatePassedChallenge
-	if err := s.d.VerificationFlowPersister().UpdateVerificationFlow(r.Context(), f); err != nil {
-		return s.handleVerificationError(w, r, f, body, err)
-	}
-
-	address := token.VerifiableAddress
-	address.Verified = true
-	address.VerifiedAt = sqlxx.NullTime(time.Now().UTC())
-	address.Status = identity.VerifiableAddressStatusCompleted
-	if err := s.d.PrivilegedIdentityPool().UpdateVerifiableAddress(r.Context(), address); err != nil {
+	if err := s.verificationPassChallenge(r, f, token); err != nil {
 		return s.handleVerificationError(w, r, f, body, err)
 	}
 
@@ -274,10 +553,122 @@ func (s *Strategy) verificationUseToken(w http.ResponseWriter, r *http.Request,
 	return errors.WithStack(flow.ErrCompletedByStrategy)
 }
 
+// verificationUseTokenAPI completes an API flow using the token submitted in the JSON body and responds with
+// verification.APIFlowResponse.
+func (s *Strategy) verificationUseTokenAPI(w http.ResponseWriter, r *http.Request, f *verification.Flow, body *verificationSubmitPayload) error {
+	if err := f.Valid(s.d.Clock().Now()); err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	}
+
+	if f.State == verification.StatePassedChallenge {
+		return s.retryVerificationFlowWithMessage(w, r, f.Type, text.NewErrorValidationVerificationRetrySuccess())
+	}
+
+	token, err := s.d.VerificationTokenPersister().UseVerificationToken(r.Context(), body.Token)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return s.handleVerificationError(w, r, f, body, schema.NewVerificationTokenInvalidError())
+	} else if err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	}
+
+	// Tokens can only complete the flow they were sent for.
+	if token.FlowID.UUID != f.ID {
+		return s.handleVerificationError(w, r, f, body, schema.NewVerificationTokenInvalidError())
+	}
+
+	if err := token.Valid(s.d.Clock().Now()); err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	}
+
+	if err := s.verificationPassChallenge(r, f, token); err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	}
+
+	response := &verification.APIFlowResponse{Flow: f}
+	if s.d.Config(r.Context()).SelfServiceFlowVerificationAfterAPISession() {
+		i, err := s.d.IdentityPool().GetIdentity(r.Context(), token.VerifiableAddress.IdentityID)
+		if err != nil {
+			return s.handleVerificationError(w, r, f, body, err)
+		}
+
+		sess := session.NewActiveSession(i, s.d.Config(r.Context()), s.d.Clock().Now().UTC())
+		if err := s.d.SessionPersister().CreateSession(r.Context(), sess); err != nil {
+			return s.handleVerificationError(w, r, f, body, err)
+		}
+		response.Session, response.Token = sess, sess.Token
+	}
+
+	s.d.Writer().Write(w, r, response)
+	return errors.WithStack(flow.ErrCompletedByStrategy)
+}
+
+// verificationUseCode completes the flow using a code sent by SMS. Codes are short, so every submitted code counts
+// as an attempt against the flow's codes, which become unusable once `code_max_attempts` is reached. The attempt is
+// reserved before the code is compared, so that concurrent submissions can not try more codes than allowed.
+func (s *Strategy) verificationUseCode(w http.ResponseWriter, r *http.Request, f *verification.Flow, body *verificationSubmitPayload) error {
+	if err := flow.EnsureCSRF(r, f.Type, s.d.Config(r.Context()).DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, body.CSRFToken); err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	}
+
+	if f.State == verification.StatePassedChallenge {
+		return s.retryVerificationFlowWithMessage(w, r, f.Type, text.NewErrorValidationVerificationRetrySuccess())
+	}
+
+	locked, err := s.d.VerificationTokenPersister().ReserveVerificationCodeAttempt(r.Context(), f.ID, s.d.Config(r.Context()).LinkCodeConfig().MaxAttempts)
+	if err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	} else if locked {
+		return s.retryVerificationFlowWithMessage(w, r, f.Type, text.NewErrorValidationVerificationCodeAttemptsExceeded())
+	}
+
+	token, err := s.d.VerificationTokenPersister().UseVerificationToken(r.Context(), VerificationCodeToken(f.ID, body.Code))
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return s.handleVerificationError(w, r, f, body, schema.NewVerificationCodeInvalidError())
+	} else if err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	}
+
+	if err := token.Valid(s.d.Clock().Now()); err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	}
+
+	if err := s.verificationPassChallenge(r, f, token); err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	}
+
+	return nil
+}
+
+// verificationPassChallenge marks the flow as completed and the token's address as verified.
+func (s *Strategy) verificationPassChallenge(r *http.Request, f *verification.Flow, token *VerificationToken) error {
+	f.UI.Messages.Clear()
+	f.State = verification.StatePassedChallenge
+	if err := s.d.VerificationFlowPersister().UpdateVerificationFlow(r.Context(), f); err != nil {
+		return err
+	}
+
+	address := token.VerifiableAddress
+	address.Verified = true
+	address.VerifiedAt = sqlxx.NullTime(s.d.Clock().Now().UTC())
+	address.Status = identity.VerifiableAddressStatusCompleted
+	if err := s.d.PrivilegedIdentityPool().UpdateVerifiableAddress(r.Context(), address); err != nil {
+		return err
+	}
+
+	s.d.FlowCallbackNotifier().Complete(r.Context(), &callback.Result{
+		FlowID:     f.ID,
+		FlowType:   callback.FlowTypeVerification,
+		State:      string(f.State),
+		IdentityID: address.IdentityID,
+		Address:    address.Value,
+	})
+	return nil
+}
+
 func (s *Strategy) retryVerificationFlowWithMessage(w http.ResponseWriter, r *http.Request, ft flow.Type, message *text.Message) error {
 	s.d.Logger().WithRequest(r).WithField("message", message).Debug("A verification flow is being retried because a validation error occurred.")
 
-	f, err := verification.NewFlow(s.d.Config(r.Context()),
+	f, err := verification.NewFlow(s.d.Config(r.Context()), s.d.Clock().Now(),
 		s.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.VerificationStrategies(r.Context()), ft)
 	if err != nil {
 		return s.handleVerificationError(w, r, f, nil, err)
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
 	"testing"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
 
+	"github.com/ory/kratos/courier"
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
@@ -34,6 +36,7 @@ import (
 func TestVerification(t *testing.T) {
 	conf, reg := internal.NewFastRegistryWithMocks(t)
 	initViper(t, conf)
+	conf.MustSet(config.ViperKeyLinkCodeResendInterval, "0s")
 
 	var identityToVerify = &identity.Identity{
 		ID:       x.NewUUID(),
@@ -299,8 +302,8 @@ func TestVerification(t *testing.T) {
 		})
 	})
 
-	newValidFlow := func(t *testing.T, requestURL string) (*verification.Flow, *link.VerificationToken) {
-		f, err := verification.NewFlow(conf, time.Hour, x.FakeCSRFToken, httptest.NewRequest("GET", requestURL, nil), nil, flow.TypeBrowser)
+	newValidFlow := func(t *testing.T, requestURL string, ft flow.Type) (*verification.Flow, *link.VerificationToken) {
+		f, err := verification.NewFlow(conf, time.Now(), time.Hour, x.FakeCSRFToken, httptest.NewRequest("GET", requestURL, nil), nil, ft)
 		require.NoError(t, err)
 		f.State = verification.StateEmailSent
 		require.NoError(t, reg.VerificationFlowPersister().CreateVerificationFlow(context.Background(), f))
@@ -308,7 +311,7 @@ func TestVerification(t *testing.T) {
 		identityToVerify.VerifiableAddresses = append(identityToVerify.VerifiableAddresses, *email)
 		require.NoError(t, reg.IdentityManager().Update(context.Background(), identityToVerify, identity.ManagerAllowWriteProtectedTraits))
 
-		token := link.NewSelfServiceVerificationToken(&identityToVerify.VerifiableAddresses[0], f)
+		token := link.NewSelfServiceVerificationToken(conf, &identityToVerify.VerifiableAddresses[0], f, time.Now())
 		require.NoError(t, reg.VerificationTokenPersister().CreateVerificationToken(context.Background(), token))
 		return f, token
 	}
@@ -327,7 +330,7 @@ func TestVerification(t *testing.T) {
 			conf.MustSet(config.ViperKeySelfServiceVerificationRequestLifespan, time.Minute)
 		})
 
-		flow, token := newValidFlow(t, public.URL+verification.RouteInitBrowserFlow+"?"+url.Values{"return_to": {returnToURL}}.Encode())
+		flow, token := newValidFlow(t, public.URL+verification.RouteInitBrowserFlow+"?"+url.Values{"return_to": {returnToURL}}.Encode(), flow.TypeBrowser)
 
 		body := fmt.Sprintf(
 			`{"csrf_token":"%s","email":"%s"}`, flow.CSRFToken, verificationEmail,
@@ -341,4 +344,276 @@ func TestVerification(t *testing.T) {
 		assert.Equal(t, returnToURL, redirectURL.String())
 
 	})
+
+	t.Run("case=verifies the address of an API flow using the token in the JSON body", func(t *testing.T) {
+		submit := func(t *testing.T, f *verification.Flow, token string, expectedStatus int) string {
+			res, err := http.Post(public.URL+verification.RouteSubmitFlow+"?flow="+f.ID.String(), "application/json",
+				bytes.NewBufferString(fmt.Sprintf(`{"method":"link","token":"%s"}`, token)))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			body := string(ioutilx.MustReadAll(res.Body))
+			require.Equal(t, expectedStatus, res.StatusCode, "%s", body)
+			return body
+		}
+
+		t.Run("case=without session", func(t *testing.T) {
+			f, token := newValidFlow(t, public.URL+verification.RouteInitAPIFlow, flow.TypeAPI)
+
+			body := submit(t, f, token.Token, http.StatusOK)
+			assert.EqualValues(t, f.ID.String(), gjson.Get(body, "flow.id").String(), "%s", body)
+			assert.EqualValues(t, verification.StatePassedChallenge, gjson.Get(body, "flow.state").String(), "%s", body)
+			assert.False(t, gjson.Get(body, "session_token").Exists(), "%s", body)
+
+			id, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), identityToVerify.ID)
+			require.NoError(t, err)
+			assert.True(t, id.VerifiableAddresses[0].Verified)
+		})
+
+		t.Run("case=with session", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceVerificationAfterAPISession, true)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceVerificationAfterAPISession, false)
+			})
+
+			f, token := newValidFlow(t, public.URL+verification.RouteInitAPIFlow, flow.TypeAPI)
+
+			body := submit(t, f, token.Token, http.StatusOK)
+			assert.EqualValues(t, verification.StatePassedChallenge, gjson.Get(body, "flow.state").String(), "%s", body)
+			assert.NotEmpty(t, gjson.Get(body, "session_token").String(), "%s", body)
+			assert.EqualValues(t, identityToVerify.ID.String(), gjson.Get(body, "session.identity.id").String(), "%s", body)
+		})
+
+		t.Run("case=invalid token", func(t *testing.T) {
+			f, _ := newValidFlow(t, public.URL+verification.RouteInitAPIFlow, flow.TypeAPI)
+
+			body := submit(t, f, "not-a-token", http.StatusBadRequest)
+			assert.EqualValues(t, f.ID.String(), gjson.Get(body, "id").String(), "%s", body)
+			assert.EqualValues(t, text.ErrorValidationVerificationTokenInvalidOrAlreadyUsed, gjson.Get(body, "ui.nodes.#(attributes.name==token).messages.0.id").Int(), "%s", body)
+		})
+
+		t.Run("case=token of another flow", func(t *testing.T) {
+			f, _ := newValidFlow(t, public.URL+verification.RouteInitAPIFlow, flow.TypeAPI)
+			_, token := newValidFlow(t, public.URL+verification.RouteInitAPIFlow, flow.TypeAPI)
+
+			body := submit(t, f, token.Token, http.StatusBadRequest)
+			assert.EqualValues(t, text.ErrorValidationVerificationTokenInvalidOrAlreadyUsed, gjson.Get(body, "ui.nodes.#(attributes.name==token).messages.0.id").Int(), "%s", body)
+		})
+	})
+}
+
+func TestVerificationSMS(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	initViper(t, conf)
+	conf.MustSet(config.ViperKeyCourierSMSEnabled, true)
+	conf.MustSet(config.ViperKeyLinkCodeResendInterval, "0s")
+
+	_ = testhelpers.NewVerificationUIFlowEchoServer(t, reg)
+	public, _ := testhelpers.NewKratosServer(t, reg)
+
+	const phone = "+12065550123"
+	i := &identity.Identity{
+		ID:       x.NewUUID(),
+		Traits:   identity.Traits(`{"email":"verifyphone@ory.sh","phone":"` + phone + `"}`),
+		SchemaID: config.DefaultIdentityTraitsSchemaID,
+	}
+	require.NoError(t, reg.IdentityManager().Create(ctx, i, identity.ManagerAllowWriteProtectedTraits))
+
+	submit := func(t *testing.T, flowID, body string, expectedStatus int) string {
+		res, err := http.Post(public.URL+verification.RouteSubmitFlow+"?flow="+flowID, "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		actual := string(ioutilx.MustReadAll(res.Body))
+		require.Equal(t, expectedStatus, res.StatusCode, "%s", actual)
+		return actual
+	}
+
+	countMessages := func(t *testing.T, recipient string) int {
+		count, err := reg.Persister().GetConnection(ctx).Where("recipient = ?", recipient).Count(new(courier.Message))
+		require.NoError(t, err)
+		return count
+	}
+
+	sendCode := func(t *testing.T) (string, string) {
+		f := testhelpers.InitializeVerificationFlowViaAPI(t, http.DefaultClient, public)
+		sent := countMessages(t, phone)
+		body := submit(t, f.Id, `{"method":"link","phone":"`+phone+`"}`, http.StatusOK)
+		assert.EqualValues(t, text.InfoSelfServiceVerificationSMSSent, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+		assert.True(t, gjson.Get(body, `ui.nodes.#(attributes.name=="phone")`).Exists(), "%s", body)
+		assert.True(t, gjson.Get(body, `ui.nodes.#(attributes.name=="code")`).Exists(), "%s", body)
+		require.Equal(t, sent+1, countMessages(t, phone))
+
+		var m courier.Message
+		require.NoError(t, reg.Persister().GetConnection(ctx).Where("recipient = ?", phone).Order("created_at DESC").First(&m))
+		assert.Equal(t, courier.MessageTypeSMS, m.Type)
+		code := regexp.MustCompile(`[0-9]{6}`).FindString(m.Body)
+		require.NotEmpty(t, code, "%s", m.Body)
+		return f.Id, code
+	}
+
+	t.Run("case=verifies the phone number with the code sent by SMS", func(t *testing.T) {
+		flowID, code := sendCode(t)
+
+		body := submit(t, flowID, `{"method":"link","code":"000000"}`, http.StatusBadRequest)
+		assert.EqualValues(t, text.ErrorValidationVerificationCodeInvalidOrAlreadyUsed, gjson.Get(body, `ui.nodes.#(attributes.name=="code").messages.0.id`).Int(), "%s", body)
+
+		body = submit(t, flowID, `{"method":"link","code":"`+code+`"}`, http.StatusOK)
+		assert.EqualValues(t, verification.StatePassedChallenge, gjson.Get(body, "state").String(), "%s", body)
+
+		actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+		require.NoError(t, err)
+		for _, a := range actual.VerifiableAddresses {
+			assert.Equal(t, a.Via == identity.VerifiableAddressTypeSMS, a.Verified, "%+v", a)
+		}
+	})
+
+	t.Run("case=codes only complete the flow they were sent for", func(t *testing.T) {
+		_, code := sendCode(t)
+		other := testhelpers.InitializeVerificationFlowViaAPI(t, http.DefaultClient, public)
+		submit(t, other.Id, `{"method":"link","code":"`+code+`"}`, http.StatusBadRequest)
+	})
+
+	t.Run("case=restarts the flow after too many attempts", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyLinkCodeMaxAttempts, 3)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyLinkCodeMaxAttempts, 5)
+		})
+
+		flowID, code := sendCode(t)
+		for k := 0; k < 3; k++ {
+			submit(t, flowID, `{"method":"link","code":"000000"}`, http.StatusBadRequest)
+		}
+
+		body := submit(t, flowID, `{"method":"link","code":"000000"}`, http.StatusOK)
+		assert.NotEqual(t, flowID, gjson.Get(body, "id").String(), "%s", body)
+		assert.EqualValues(t, text.ErrorValidationVerificationCodeAttemptsExceeded, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+
+		body = submit(t, flowID, `{"method":"link","code":"`+code+`"}`, http.StatusOK)
+		assert.NotEqual(t, flowID, gjson.Get(body, "id").String(), "%s", body)
+		assert.EqualValues(t, text.ErrorValidationVerificationCodeAttemptsExceeded, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+	})
+
+	t.Run("case=does not resend codes within the resend interval", func(t *testing.T) {
+		flowID, code := sendCode(t)
+
+		conf.MustSet(config.ViperKeyLinkCodeResendInterval, "1h")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyLinkCodeResendInterval, "0s")
+		})
+
+		sent := countMessages(t, phone)
+
+		body := submit(t, flowID, `{"method":"link","phone":"`+phone+`"}`, http.StatusOK)
+		assert.EqualValues(t, text.InfoSelfServiceVerificationSMSSent, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+		assert.Equal(t, sent, countMessages(t, phone))
+
+		body = submit(t, flowID, `{"method":"link","code":"`+code+`"}`, http.StatusOK)
+		assert.EqualValues(t, verification.StatePassedChallenge, gjson.Get(body, "state").String(), "%s", body)
+	})
+
+	t.Run("case=does not send SMS to unknown phone numbers", func(t *testing.T) {
+		f := testhelpers.InitializeVerificationFlowViaAPI(t, http.DefaultClient, public)
+		body := submit(t, f.Id, `{"method":"link","phone":"+12065550199"}`, http.StatusOK)
+		assert.EqualValues(t, text.InfoSelfServiceVerificationSMSSent, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+		assert.Zero(t, countMessages(t, "+12065550199"))
+	})
+}
+
+func TestAdminVerification(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	initViper(t, conf)
+
+	_ = testhelpers.NewVerificationUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+	public, admin := testhelpers.NewKratosServer(t, reg)
+
+	createIdentity := func(t *testing.T, email string) *identity.Identity {
+		i := &identity.Identity{
+			ID:       x.NewUUID(),
+			Traits:   identity.Traits(`{"email":"` + email + `"}`),
+			SchemaID: config.DefaultIdentityTraitsSchemaID,
+		}
+		require.NoError(t, reg.IdentityManager().Create(ctx, i, identity.ManagerAllowWriteProtectedTraits))
+		return i
+	}
+
+	create := func(t *testing.T, body string, expectedStatus int) []byte {
+		res, err := admin.Client().Post(admin.URL+link.RouteAdminCreateVerificationLink, "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		actual := ioutilx.MustReadAll(res.Body)
+		require.Equal(t, expectedStatus, res.StatusCode, "%s", actual)
+		return actual
+	}
+
+	expectVerified := func(t *testing.T, i *identity.Identity) {
+		actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+		require.NoError(t, err)
+		require.Len(t, actual.VerifiableAddresses, 1)
+		assert.True(t, actual.VerifiableAddresses[0].Verified)
+	}
+
+	t.Run("description=should create a verification link", func(t *testing.T) {
+		i := createIdentity(t, "admin-verify-link@ory.sh")
+
+		actual := create(t, fmt.Sprintf(`{"identity_id":"%s","expires_in":"1h"}`, i.ID), http.StatusOK)
+		vl := gjson.GetBytes(actual, "verification_link").String()
+		assert.Contains(t, vl, public.URL+verification.RouteSubmitFlow, "%s", actual)
+		assert.False(t, gjson.GetBytes(actual, "verification_code").Exists(), "%s", actual)
+		assert.True(t, gjson.GetBytes(actual, "expires_at").Time().Before(time.Now().Add(time.Hour+time.Second)), "%s", actual)
+
+		res, err := testhelpers.NewClientWithCookies(t).Get(vl)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowVerificationUI().String())
+		expectVerified(t, i)
+
+		t.Run("case=all addresses are verified", func(t *testing.T) {
+			create(t, fmt.Sprintf(`{"identity_id":"%s"}`, i.ID), http.StatusBadRequest)
+		})
+	})
+
+	t.Run("description=should create a verification code", func(t *testing.T) {
+		i := createIdentity(t, "admin-verify-code@ory.sh")
+
+		actual := create(t, fmt.Sprintf(`{"identity_id":"%s","address":"Admin-Verify-Code@ory.sh","code":true}`, i.ID), http.StatusOK)
+		code := gjson.GetBytes(actual, "verification_code").String()
+		assert.Regexp(t, regexp.MustCompile(`^[0-9]{6}$`), code, "%s", actual)
+		vl, err := url.Parse(gjson.GetBytes(actual, "verification_link").String())
+		require.NoError(t, err)
+		assert.Equal(t, conf.SelfServiceFlowVerificationUI().Host, vl.Host, "%s", actual)
+		flowID := vl.Query().Get("flow")
+
+		c := testhelpers.NewClientWithCookies(t)
+		res, err := c.Get(public.URL + verification.RouteGetFlow + "?id=" + flowID)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		f := ioutilx.MustReadAll(res.Body)
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", f)
+		assert.True(t, gjson.GetBytes(f, "ui.nodes.#(attributes.name==code)").Exists(), "%s", f)
+
+		values := url.Values{
+			"csrf_token": {gjson.GetBytes(f, "ui.nodes.#(attributes.name==csrf_token).attributes.value").String()},
+			"method":     {"link"},
+			"code":       {"000000"},
+		}
+		body, _ := testhelpers.HTTPPostForm(t, c, public.URL+verification.RouteSubmitFlow+"?flow="+flowID, &values)
+		assert.EqualValues(t, text.ErrorValidationVerificationCodeInvalidOrAlreadyUsed,
+			gjson.GetBytes(body, "ui.nodes.#(attributes.name==code).messages.0.id").Int(), "%s", body)
+
+		values.Set("code", code)
+		body, _ = testhelpers.HTTPPostForm(t, c, public.URL+verification.RouteSubmitFlow+"?flow="+flowID, &values)
+		assert.EqualValues(t, verification.StatePassedChallenge, gjson.GetBytes(body, "state").String(), "%s", body)
+		expectVerified(t, i)
+	})
+
+	t.Run("description=should not create a link for an unknown address", func(t *testing.T) {
+		i := createIdentity(t, "admin-verify-unknown@ory.sh")
+		create(t, fmt.Sprintf(`{"identity_id":"%s","address":"someone-else@ory.sh"}`, i.ID), http.StatusBadRequest)
+	})
+
+	t.Run("description=should not create a link for an unknown identity", func(t *testing.T) {
+		create(t, fmt.Sprintf(`{"identity_id":"%s"}`, x.NewUUID()), http.StatusNotFound)
+	})
 }
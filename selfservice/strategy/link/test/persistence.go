@@ -33,31 +33,52 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 		conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
 		conf.MustSet(config.ViperKeySecretsDefault, []string{"secret-a", "secret-b"})
 
-		t.Run("token=recovery", func(t *testing.T) {
-			t.Run("case=should error when the recovery token does not exist", func(t *testing.T) {
-				_, err := p.UseRecoveryToken(ctx, "i-do-not-exist")
-				require.Error(t, err)
-			})
+		newRecoveryToken := func(t *testing.T, email string) *link.RecoveryToken {
+			var req recovery.Flow
+			require.NoError(t, faker.FakeData(&req))
+			require.NoError(t, p.CreateRecoveryFlow(ctx, &req))
 
-			newRecoveryToken := func(t *testing.T, email string) *link.RecoveryToken {
-				var req recovery.Flow
-				require.NoError(t, faker.FakeData(&req))
-				require.NoError(t, p.CreateRecoveryFlow(ctx, &req))
+			var i identity.Identity
+			require.NoError(t, faker.FakeData(&i))
 
-				var i identity.Identity
-				require.NoError(t, faker.FakeData(&i))
+			address := &identity.RecoveryAddress{Value: email, Via: identity.RecoveryAddressTypeEmail}
+			i.RecoveryAddresses = append(i.RecoveryAddresses, *address)
 
-				address := &identity.RecoveryAddress{Value: email, Via: identity.RecoveryAddressTypeEmail}
-				i.RecoveryAddresses = append(i.RecoveryAddresses, *address)
+			require.NoError(t, p.CreateIdentity(ctx, &i))
 
-				require.NoError(t, p.CreateIdentity(ctx, &i))
+			return &link.RecoveryToken{Token: x.NewUUID().String(), FlowID: uuid.NullUUID{UUID: req.ID, Valid: true},
+				RecoveryAddress: &i.RecoveryAddresses[0],
+				ExpiresAt:       time.Now(),
+				IssuedAt:        time.Now(),
+			}
+		}
 
-				return &link.RecoveryToken{Token: x.NewUUID().String(), FlowID: uuid.NullUUID{UUID: req.ID, Valid: true},
-					RecoveryAddress: &i.RecoveryAddresses[0],
-					ExpiresAt:       time.Now(),
-					IssuedAt:        time.Now(),
-				}
+		newVerificationToken := func(t *testing.T, email string) *link.VerificationToken {
+			var req verification.Flow
+			require.NoError(t, faker.FakeData(&req))
+			require.NoError(t, p.CreateVerificationFlow(ctx, &req))
+
+			var i identity.Identity
+			require.NoError(t, faker.FakeData(&i))
+
+			address := &identity.VerifiableAddress{Value: email, Via: identity.VerifiableAddressTypeEmail}
+			i.VerifiableAddresses = append(i.VerifiableAddresses, *address)
+
+			require.NoError(t, p.CreateIdentity(ctx, &i))
+			return &link.VerificationToken{
+				Token:             x.NewUUID().String(),
+				FlowID:            uuid.NullUUID{UUID: req.ID, Valid: true},
+				VerifiableAddress: &i.VerifiableAddresses[0],
+				ExpiresAt:         time.Now(),
+				IssuedAt:          time.Now(),
 			}
+		}
+
+		t.Run("token=recovery", func(t *testing.T) {
+			t.Run("case=should error when the recovery token does not exist", func(t *testing.T) {
+				_, err := p.UseRecoveryToken(ctx, "i-do-not-exist")
+				require.Error(t, err)
+			})
 
 			t.Run("case=should error when the recovery token does not exist", func(t *testing.T) {
 				_, err := p.UseRecoveryToken(ctx, "i-do-not-exist")
@@ -91,6 +112,62 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 				require.Error(t, err)
 			})
 
+			t.Run("case=should lock the flow's codes after too many attempts", func(t *testing.T) {
+				token := newRecoveryToken(t, "attempts-user@ory.sh")
+				token.ExpiresAt = time.Now().Add(time.Hour)
+				require.NoError(t, p.CreateRecoveryToken(ctx, token))
+
+				t.Run("not count on another network", func(t *testing.T) {
+					_, p := testhelpers.NewNetwork(t, ctx, p)
+					locked, err := p.ReserveRecoveryCodeAttempt(ctx, token.FlowID.UUID, 1)
+					require.NoError(t, err)
+					assert.False(t, locked)
+				})
+
+				for i := 0; i < 2; i++ {
+					locked, err := p.ReserveRecoveryCodeAttempt(ctx, token.FlowID.UUID, 2)
+					require.NoError(t, err)
+					assert.False(t, locked)
+				}
+
+				locked, err := p.ReserveRecoveryCodeAttempt(ctx, token.FlowID.UUID, 2)
+				require.NoError(t, err)
+				assert.True(t, locked)
+
+				_, err = p.UseRecoveryToken(ctx, token.Token)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+
+			t.Run("case=should not lock a flow without codes", func(t *testing.T) {
+				locked, err := p.ReserveRecoveryCodeAttempt(ctx, x.NewUUID(), 1)
+				require.NoError(t, err)
+				assert.False(t, locked)
+			})
+
+			t.Run("case=should return when the last token was issued", func(t *testing.T) {
+				now := time.Now().UTC().Truncate(time.Second)
+				token := newRecoveryToken(t, "issued-user@ory.sh")
+				token.IssuedAt = now.Add(-time.Hour)
+				require.NoError(t, p.CreateRecoveryToken(ctx, token))
+
+				latest := *token
+				latest.ID = uuid.Nil
+				latest.Token = x.NewUUID().String()
+				latest.IssuedAt = now
+				require.NoError(t, p.CreateRecoveryToken(ctx, &latest))
+
+				t.Run("not find on another network", func(t *testing.T) {
+					_, p := testhelpers.NewNetwork(t, ctx, p)
+					actual, err := p.LastRecoveryTokenIssuedAt(ctx, token.RecoveryAddress.ID)
+					require.NoError(t, err)
+					assert.True(t, actual.IsZero())
+				})
+
+				actual, err := p.LastRecoveryTokenIssuedAt(ctx, token.RecoveryAddress.ID)
+				require.NoError(t, err)
+				assert.True(t, now.Equal(actual.UTC()), "%s != %s", now, actual)
+			})
+
 		})
 
 		t.Run("token=verification", func(t *testing.T) {
@@ -99,27 +176,6 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 				require.Error(t, err)
 			})
 
-			newVerificationToken := func(t *testing.T, email string) *link.VerificationToken {
-				var req verification.Flow
-				require.NoError(t, faker.FakeData(&req))
-				require.NoError(t, p.CreateVerificationFlow(ctx, &req))
-
-				var i identity.Identity
-				require.NoError(t, faker.FakeData(&i))
-
-				address := &identity.VerifiableAddress{Value: email, Via: identity.VerifiableAddressTypeEmail}
-				i.VerifiableAddresses = append(i.VerifiableAddresses, *address)
-
-				require.NoError(t, p.CreateIdentity(ctx, &i))
-				return &link.VerificationToken{
-					Token:             x.NewUUID().String(),
-					FlowID:            uuid.NullUUID{UUID: req.ID, Valid: true},
-					VerifiableAddress: &i.VerifiableAddresses[0],
-					ExpiresAt:         time.Now(),
-					IssuedAt:          time.Now(),
-				}
-			}
-
 			t.Run("case=should error when the verification token does not exist", func(t *testing.T) {
 				_, err := p.UseVerificationToken(ctx, "i-do-not-exist")
 				require.Error(t, err)
@@ -151,6 +207,178 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 				_, err = p.UseVerificationToken(ctx, expected.Token)
 				require.Error(t, err)
 			})
+
+			t.Run("case=should keep recent tokens valid when resending and use them up together", func(t *testing.T) {
+				now := time.Now()
+				old := newVerificationToken(t, "resend-user@ory.sh")
+				old.IssuedAt = now.Add(-time.Hour)
+				old.ExpiresAt = now.Add(time.Hour)
+				require.NoError(t, p.CreateVerificationToken(ctx, old))
+
+				resend := func(t *testing.T, issuedAt time.Time) *link.VerificationToken {
+					token := *old
+					token.ID = uuid.Nil
+					token.Token = x.NewUUID().String()
+					token.IssuedAt = issuedAt
+					require.NoError(t, p.CreateVerificationToken(ctx, &token))
+					return &token
+				}
+
+				outdated := resend(t, now.Add(-2*time.Minute))
+				recent := resend(t, now.Add(-time.Minute))
+
+				require.NoError(t, p.InvalidateVerificationTokens(ctx, old.VerifiableAddress.ID, 1, now.Add(-10*time.Minute)))
+				latest := resend(t, now)
+
+				for _, token := range []*link.VerificationToken{old, outdated} {
+					_, err := p.UseVerificationToken(ctx, token.Token)
+					require.ErrorIs(t, err, sqlcon.ErrNoRows)
+				}
+
+				_, err := p.UseVerificationToken(ctx, recent.Token)
+				require.NoError(t, err)
+
+				_, err = p.UseVerificationToken(ctx, latest.Token)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+
+			t.Run("case=should lock the flow's tokens after too many attempts", func(t *testing.T) {
+				token := newVerificationToken(t, "attempts-user@ory.sh")
+				token.ExpiresAt = time.Now().Add(time.Hour)
+				require.NoError(t, p.CreateVerificationToken(ctx, token))
+
+				t.Run("not count on another network", func(t *testing.T) {
+					_, p := testhelpers.NewNetwork(t, ctx, p)
+					locked, err := p.ReserveVerificationCodeAttempt(ctx, token.FlowID.UUID, 1)
+					require.NoError(t, err)
+					assert.False(t, locked)
+				})
+
+				for i := 0; i < 3; i++ {
+					locked, err := p.ReserveVerificationCodeAttempt(ctx, token.FlowID.UUID, 3)
+					require.NoError(t, err)
+					assert.False(t, locked)
+				}
+
+				locked, err := p.ReserveVerificationCodeAttempt(ctx, token.FlowID.UUID, 3)
+				require.NoError(t, err)
+				assert.True(t, locked)
+
+				_, err = p.UseVerificationToken(ctx, token.Token)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+
+			t.Run("case=should return when the last token was issued", func(t *testing.T) {
+				now := time.Now().UTC().Truncate(time.Second)
+				token := newVerificationToken(t, "issued-user@ory.sh")
+				token.IssuedAt = now.Add(-time.Hour)
+				require.NoError(t, p.CreateVerificationToken(ctx, token))
+
+				latest := *token
+				latest.ID = uuid.Nil
+				latest.Token = x.NewUUID().String()
+				latest.IssuedAt = now
+				require.NoError(t, p.CreateVerificationToken(ctx, &latest))
+
+				t.Run("not find on another network", func(t *testing.T) {
+					_, p := testhelpers.NewNetwork(t, ctx, p)
+					actual, err := p.LastVerificationTokenIssuedAt(ctx, token.VerifiableAddress.ID)
+					require.NoError(t, err)
+					assert.True(t, actual.IsZero())
+				})
+
+				actual, err := p.LastVerificationTokenIssuedAt(ctx, token.VerifiableAddress.ID)
+				require.NoError(t, err)
+				assert.True(t, now.Equal(actual.UTC()), "%s != %s", now, actual)
+			})
+		})
+
+		t.Run("token=expired", func(t *testing.T) {
+			expiredAt := time.Now().UTC().Add(-time.Hour * 100).Truncate(time.Second)
+			after, before := expiredAt.Add(-time.Minute), expiredAt.Add(time.Minute)
+
+			verificationIDs := func(t *testing.T) (ids []uuid.UUID) {
+				tokens, err := p.ListExpiredVerificationTokens(ctx, after, before, 100)
+				require.NoError(t, err)
+				for _, token := range tokens {
+					require.NotNil(t, token.VerifiableAddress)
+					assert.Equal(t, token.VerifiableAddressID, token.VerifiableAddress.ID)
+					ids = append(ids, token.ID)
+				}
+				return ids
+			}
+
+			recoveryIDs := func(t *testing.T) (ids []uuid.UUID) {
+				tokens, err := p.ListExpiredRecoveryTokens(ctx, after, before, 100)
+				require.NoError(t, err)
+				for _, token := range tokens {
+					require.NotNil(t, token.RecoveryAddress)
+					assert.Equal(t, token.RecoveryAddressID, token.RecoveryAddress.ID)
+					ids = append(ids, token.ID)
+				}
+				return ids
+			}
+
+			t.Run("case=lists expired verification tokens", func(t *testing.T) {
+				expired := newVerificationToken(t, "expired-verification@ory.sh")
+				expired.IssuedAt, expired.ExpiresAt = expiredAt.Add(-time.Hour), expiredAt
+				require.NoError(t, p.CreateVerificationToken(ctx, expired))
+
+				used := newVerificationToken(t, "used-verification@ory.sh")
+				used.IssuedAt, used.ExpiresAt = expiredAt.Add(-time.Hour), expiredAt
+				require.NoError(t, p.CreateVerificationToken(ctx, used))
+				_, err := p.UseVerificationToken(ctx, used.Token)
+				require.NoError(t, err)
+
+				superseded := newVerificationToken(t, "superseded-verification@ory.sh")
+				superseded.IssuedAt, superseded.ExpiresAt = expiredAt.Add(-time.Hour), expiredAt
+				require.NoError(t, p.CreateVerificationToken(ctx, superseded))
+				latest := *superseded
+				latest.ID, latest.Token = uuid.Nil, x.NewUUID().String()
+				latest.IssuedAt, latest.ExpiresAt = expiredAt.Add(-time.Minute), expiredAt.Add(time.Hour)
+				require.NoError(t, p.CreateVerificationToken(ctx, &latest))
+
+				actual := verificationIDs(t)
+				assert.Contains(t, actual, expired.ID)
+				assert.NotContains(t, actual, used.ID)
+				assert.NotContains(t, actual, superseded.ID)
+				assert.NotContains(t, actual, latest.ID, "the token has not expired within the window")
+
+				t.Run("not list on another network", func(t *testing.T) {
+					_, p := testhelpers.NewNetwork(t, ctx, p)
+					actual, err := p.ListExpiredVerificationTokens(ctx, after, before, 100)
+					require.NoError(t, err)
+					assert.Empty(t, actual)
+				})
+
+				require.NoError(t, p.CreateExpiryNotification(ctx, &link.ExpiryNotification{TokenID: expired.ID, FlowType: link.ExpiredLinkTypeVerification}))
+				assert.NotContains(t, verificationIDs(t), expired.ID, "reported tokens are not listed again")
+
+				require.NoError(t, p.DeleteExpiryNotifications(ctx, link.ExpiredLinkTypeRecovery, time.Now().Add(time.Minute)))
+				assert.NotContains(t, verificationIDs(t), expired.ID, "only notifications of the flow type are deleted")
+
+				require.NoError(t, p.DeleteExpiryNotifications(ctx, link.ExpiredLinkTypeVerification, time.Now().Add(time.Minute)))
+				assert.Contains(t, verificationIDs(t), expired.ID)
+			})
+
+			t.Run("case=lists expired recovery tokens", func(t *testing.T) {
+				expired := newRecoveryToken(t, "expired-recovery@ory.sh")
+				expired.IssuedAt, expired.ExpiresAt = expiredAt.Add(-time.Hour), expiredAt
+				require.NoError(t, p.CreateRecoveryToken(ctx, expired))
+
+				used := newRecoveryToken(t, "used-recovery@ory.sh")
+				used.IssuedAt, used.ExpiresAt = expiredAt.Add(-time.Hour), expiredAt
+				require.NoError(t, p.CreateRecoveryToken(ctx, used))
+				_, err := p.UseRecoveryToken(ctx, used.Token)
+				require.NoError(t, err)
+
+				actual := recoveryIDs(t)
+				assert.Contains(t, actual, expired.ID)
+				assert.NotContains(t, actual, used.ID)
+
+				require.NoError(t, p.CreateExpiryNotification(ctx, &link.ExpiryNotification{TokenID: expired.ID, FlowType: link.ExpiredLinkTypeRecovery}))
+				assert.NotContains(t, recoveryIDs(t), expired.ID)
+			})
 		})
 	}
 }
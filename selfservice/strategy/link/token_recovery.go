@@ -2,6 +2,7 @@ package link
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/ory/kratos/corp"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/ory/x/randx"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/x"
@@ -39,6 +41,9 @@ type RecoveryToken struct {
 	// required: true
 	IssuedAt time.Time `json:"issued_at" faker:"time_type" db:"issued_at"`
 
+	// Attempts is the number of wrong codes submitted for the flow of a code sent by email.
+	Attempts int `json:"-" faker:"-" db:"attempts"`
+
 	// CreatedAt is a helper struct field for gobuffalo.pop.
 	CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
 	// UpdatedAt is a helper struct field for gobuffalo.pop.
@@ -54,18 +59,42 @@ func (RecoveryToken) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "identity_recovery_tokens")
 }
 
-func NewSelfServiceRecoveryToken(address *identity.RecoveryAddress, f *recovery.Flow) *RecoveryToken {
-	return &RecoveryToken{
+// NewSelfServiceRecoveryToken returns a token for a link sent by email or, if config.ViperKeyLinkRecoveryCode is
+// set, a numeric code sent by email. Codes are configured like verification codes by config.LinkCodeConfig. See
+// RecoveryCodeToken.
+func NewSelfServiceRecoveryToken(c *config.Config, address *identity.RecoveryAddress, f *recovery.Flow, now time.Time) *RecoveryToken {
+	t := &RecoveryToken{
 		ID:              x.NewUUID(),
 		Token:           randx.MustString(32, randx.AlphaNum),
 		RecoveryAddress: address,
 		ExpiresAt:       f.ExpiresAt,
-		IssuedAt:        time.Now().UTC(),
+		IssuedAt:        now.UTC(),
 		FlowID:          uuid.NullUUID{UUID: f.ID, Valid: true}}
+
+	if c.LinkRecoveryCodeEnabled() {
+		cc := c.LinkCodeConfig()
+		t.Token = RecoveryCodeToken(f.ID, randx.MustString(cc.Digits, randx.Numeric))
+		t.ExpiresAt = codeExpiresAt(cc, t.IssuedAt, t.ExpiresAt)
+	}
+	return t
+}
+
+// RecoveryCodeToken returns the token of a recovery code. Like verification codes, recovery codes are stored
+// and looked up together with the flow they were sent for and can only complete that flow.
+func RecoveryCodeToken(flowID uuid.UUID, code string) string {
+	return flowID.String() + ":" + code
+}
+
+// Code returns the code to send by email, or an empty string if the token is sent as a link.
+func (f *RecoveryToken) Code() string {
+	if i := strings.LastIndexByte(f.Token, ':'); i >= 0 {
+		return f.Token[i+1:]
+	}
+	return ""
 }
 
-func NewRecoveryToken(address *identity.RecoveryAddress, expiresIn time.Duration) *RecoveryToken {
-	now := time.Now().UTC()
+func NewRecoveryToken(address *identity.RecoveryAddress, now time.Time, expiresIn time.Duration) *RecoveryToken {
+	now = now.UTC()
 	return &RecoveryToken{
 		ID:              x.NewUUID(),
 		Token:           randx.MustString(32, randx.AlphaNum),
@@ -75,8 +104,21 @@ func NewRecoveryToken(address *identity.RecoveryAddress, expiresIn time.Duration
 	}
 }
 
-func (f *RecoveryToken) Valid() error {
-	if f.ExpiresAt.Before(time.Now()) {
+// NewRecoveryCode returns a recovery code created by the admin API. Like codes sent by email, it can only complete
+// the flow f, but it expires together with the flow.
+func NewRecoveryCode(address *identity.RecoveryAddress, f *recovery.Flow, digits int, now time.Time) *RecoveryToken {
+	return &RecoveryToken{
+		ID:              x.NewUUID(),
+		Token:           RecoveryCodeToken(f.ID, randx.MustString(digits, randx.Numeric)),
+		RecoveryAddress: address,
+		ExpiresAt:       f.ExpiresAt,
+		IssuedAt:        now.UTC(),
+		FlowID:          uuid.NullUUID{UUID: f.ID, Valid: true},
+	}
+}
+
+func (f *RecoveryToken) Valid(now time.Time) error {
+	if f.ExpiresAt.Before(now) {
 		return errors.WithStack(recovery.NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
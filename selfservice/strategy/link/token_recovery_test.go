@@ -27,25 +27,43 @@ func TestRecoveryToken(t *testing.T) {
 	req := &http.Request{URL: urlx.ParseOrPanic("https://www.ory.sh/")}
 	t.Run("func=NewSelfServiceRecoveryToken", func(t *testing.T) {
 		t.Run("case=creates unique tokens", func(t *testing.T) {
-			f, err := recovery.NewFlow(conf, time.Hour, "", req, nil, flow.TypeBrowser)
+			f, err := recovery.NewFlow(conf, time.Now(), time.Hour, "", req, nil, flow.TypeBrowser)
 			require.NoError(t, err)
 
 			tokens := make([]string, 10)
 			for k := range tokens {
-				tokens[k] = NewSelfServiceRecoveryToken(nil, f).Token
+				tokens[k] = NewSelfServiceRecoveryToken(conf, nil, f, time.Now()).Token
 			}
 
 			assert.Len(t, stringslice.Unique(tokens), len(tokens))
 		})
+
+		t.Run("case=creates flow-scoped codes if enabled", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyLinkRecoveryCode, true)
+			conf.MustSet(config.ViperKeyLinkCodeInterval, "10m")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyLinkRecoveryCode, false)
+				conf.MustSet(config.ViperKeyLinkCodeInterval, "0s")
+			})
+
+			now := time.Now().UTC()
+			f, err := recovery.NewFlow(conf, now, time.Hour, "", req, nil, flow.TypeBrowser)
+			require.NoError(t, err)
+
+			token := NewSelfServiceRecoveryToken(conf, nil, f, now)
+			assert.Regexp(t, "^[0-9]{6}$", token.Code())
+			assert.Equal(t, RecoveryCodeToken(f.ID, token.Code()), token.Token)
+			assert.Equal(t, now.Add(10*time.Minute), token.ExpiresAt)
+		})
 	})
 	t.Run("method=Valid", func(t *testing.T) {
 		t.Run("case=is invalid when the flow is expired", func(t *testing.T) {
-			f, err := recovery.NewFlow(conf, -time.Hour, "", req, nil, flow.TypeBrowser)
+			f, err := recovery.NewFlow(conf, time.Now(), -time.Hour, "", req, nil, flow.TypeBrowser)
 			require.NoError(t, err)
 
-			token := NewSelfServiceRecoveryToken(nil, f)
-			require.Error(t, token.Valid())
-			assert.EqualError(t, token.Valid(), f.Valid().Error())
+			token := NewSelfServiceRecoveryToken(conf, nil, f, time.Now())
+			require.Error(t, token.Valid(time.Now()))
+			assert.EqualError(t, token.Valid(time.Now()), f.Valid(time.Now()).Error())
 		})
 	})
 }
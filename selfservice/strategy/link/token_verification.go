@@ -2,6 +2,7 @@ package link
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/ory/kratos/corp"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/ory/x/randx"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow/verification"
 	"github.com/ory/kratos/x"
@@ -39,6 +41,9 @@ type VerificationToken struct {
 	// required: true
 	IssuedAt time.Time `json:"issued_at" faker:"time_type" db:"issued_at"`
 
+	// Attempts is the number of wrong codes submitted for the flow of a code sent by SMS.
+	Attempts int `json:"-" faker:"-" db:"attempts"`
+
 	// CreatedAt is a helper struct field for gobuffalo.pop.
 	CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
 	// UpdatedAt is a helper struct field for gobuffalo.pop.
@@ -54,18 +59,73 @@ func (VerificationToken) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "identity_verification_tokens")
 }
 
-func NewSelfServiceVerificationToken(address *identity.VerifiableAddress, f *verification.Flow) *VerificationToken {
-	return &VerificationToken{
+// NewSelfServiceVerificationToken returns a token for a link sent by email or, for phone numbers, a numeric code
+// sent by SMS. The length and lifespan of codes are configured by config.LinkCodeConfig. See VerificationCodeToken.
+func NewSelfServiceVerificationToken(c *config.Config, address *identity.VerifiableAddress, f *verification.Flow, now time.Time) *VerificationToken {
+	t := &VerificationToken{
 		ID:                x.NewUUID(),
 		Token:             randx.MustString(32, randx.AlphaNum),
 		VerifiableAddress: address,
 		ExpiresAt:         f.ExpiresAt,
-		IssuedAt:          time.Now().UTC(),
+		IssuedAt:          now.UTC(),
 		FlowID:            uuid.NullUUID{UUID: f.ID, Valid: true}}
+
+	if address != nil && address.Via == identity.VerifiableAddressTypeSMS {
+		cc := c.LinkCodeConfig()
+		t.Token = VerificationCodeToken(f.ID, randx.MustString(cc.Digits, randx.Numeric))
+		t.ExpiresAt = codeExpiresAt(cc, t.IssuedAt, t.ExpiresAt)
+	}
+	return t
+}
+
+// codeExpiresAt returns when a code issued at issuedAt expires. Codes never outlive their flow.
+func codeExpiresAt(cc *config.LinkCodeConfig, issuedAt, flowExpiresAt time.Time) time.Time {
+	if cc.Interval > 0 {
+		// Like the time steps of TOTP codes, codes remain valid for the grace periods after their interval.
+		if expiresAt := issuedAt.Add(cc.Interval * time.Duration(1+cc.GracePeriods)); expiresAt.Before(flowExpiresAt) {
+			return expiresAt
+		}
+	}
+	return flowExpiresAt
+}
+
+// VerificationCodeToken returns the token of a code sent by SMS. Codes are too short to be unique, so they are
+// stored and looked up together with the flow they were sent for and can only complete that flow.
+func VerificationCodeToken(flowID uuid.UUID, code string) string {
+	return flowID.String() + ":" + code
+}
+
+// Code returns the code to send by SMS, or an empty string if the token is sent as a link.
+func (f *VerificationToken) Code() string {
+	if i := strings.LastIndexByte(f.Token, ':'); i >= 0 {
+		return f.Token[i+1:]
+	}
+	return ""
+}
+
+// NewVerificationToken returns a token for a verification link created by the admin API, regardless of the
+// address's type. It completes the flow f and expires together with it.
+func NewVerificationToken(address *identity.VerifiableAddress, f *verification.Flow, now time.Time) *VerificationToken {
+	return &VerificationToken{
+		ID:                x.NewUUID(),
+		Token:             randx.MustString(32, randx.AlphaNum),
+		VerifiableAddress: address,
+		ExpiresAt:         f.ExpiresAt,
+		IssuedAt:          now.UTC(),
+		FlowID:            uuid.NullUUID{UUID: f.ID, Valid: true},
+	}
+}
+
+// NewVerificationCode returns a verification code created by the admin API, regardless of the address's type. It
+// completes the flow f and expires together with it.
+func NewVerificationCode(address *identity.VerifiableAddress, f *verification.Flow, digits int, now time.Time) *VerificationToken {
+	t := NewVerificationToken(address, f, now)
+	t.Token = VerificationCodeToken(f.ID, randx.MustString(digits, randx.Numeric))
+	return t
 }
 
-func (f *VerificationToken) Valid() error {
-	if f.ExpiresAt.Before(time.Now()) {
+func (f *VerificationToken) Valid(now time.Time) error {
+	if f.ExpiresAt.Before(now) {
 		return errors.WithStack(verification.NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
@@ -16,8 +16,10 @@ import (
 	"github.com/ory/x/stringslice"
 	"github.com/ory/x/urlx"
 
+	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/x"
 )
 
 func TestVerificationToken(t *testing.T) {
@@ -27,25 +29,72 @@ func TestVerificationToken(t *testing.T) {
 	req := &http.Request{URL: urlx.ParseOrPanic("https://www.ory.sh/")}
 	t.Run("func=NewSelfServiceVerificationToken", func(t *testing.T) {
 		t.Run("case=creates unique tokens", func(t *testing.T) {
-			f, err := verification.NewFlow(conf, time.Hour, "", req, nil, flow.TypeBrowser)
+			f, err := verification.NewFlow(conf, time.Now(), time.Hour, "", req, nil, flow.TypeBrowser)
 			require.NoError(t, err)
 
 			tokens := make([]string, 10)
 			for k := range tokens {
-				tokens[k] = NewSelfServiceVerificationToken(nil, f).Token
+				tokens[k] = NewSelfServiceVerificationToken(conf, nil, f, time.Now()).Token
 			}
 
 			assert.Len(t, stringslice.Unique(tokens), len(tokens))
 		})
+
+		t.Run("case=creates flow-scoped codes for phone numbers", func(t *testing.T) {
+			f, err := verification.NewFlow(conf, time.Now(), time.Hour, "", req, nil, flow.TypeBrowser)
+			require.NoError(t, err)
+
+			token := NewSelfServiceVerificationToken(conf, identity.NewVerifiableSMSAddress("+12065550100", x.NewUUID()), f, time.Now())
+			assert.Regexp(t, "^[0-9]{6}$", token.Code())
+			assert.Equal(t, VerificationCodeToken(f.ID, token.Code()), token.Token)
+			assert.Empty(t, NewSelfServiceVerificationToken(conf, nil, f, time.Now()).Code())
+		})
+
+		t.Run("case=respects the configured code length and interval", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyLinkCodeDigits, 8)
+			conf.MustSet(config.ViperKeyLinkCodeInterval, "10m")
+			conf.MustSet(config.ViperKeyLinkCodeGracePeriods, 1)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyLinkCodeDigits, 6)
+				conf.MustSet(config.ViperKeyLinkCodeInterval, "0s")
+				conf.MustSet(config.ViperKeyLinkCodeGracePeriods, 0)
+			})
+
+			now := time.Now().UTC()
+			address := identity.NewVerifiableSMSAddress("+12065550100", x.NewUUID())
+			f, err := verification.NewFlow(conf, now, time.Hour, "", req, nil, flow.TypeBrowser)
+			require.NoError(t, err)
+
+			token := NewSelfServiceVerificationToken(conf, address, f, now)
+			assert.Regexp(t, "^[0-9]{8}$", token.Code())
+			assert.Equal(t, now.Add(20*time.Minute), token.ExpiresAt)
+			require.NoError(t, token.Valid(now.Add(19*time.Minute)))
+			require.Error(t, token.Valid(now.Add(21*time.Minute)))
+
+			f, err = verification.NewFlow(conf, now, 15*time.Minute, "", req, nil, flow.TypeBrowser)
+			require.NoError(t, err)
+			assert.Equal(t, f.ExpiresAt, NewSelfServiceVerificationToken(conf, address, f, now).ExpiresAt, "codes never outlive their flow")
+		})
 	})
 	t.Run("method=Valid", func(t *testing.T) {
 		t.Run("case=is invalid when the flow is expired", func(t *testing.T) {
-			f, err := verification.NewFlow(conf, -time.Hour, "", req, nil, flow.TypeBrowser)
+			f, err := verification.NewFlow(conf, time.Now(), -time.Hour, "", req, nil, flow.TypeBrowser)
+			require.NoError(t, err)
+
+			token := NewSelfServiceVerificationToken(conf, nil, f, time.Now())
+			require.Error(t, token.Valid(time.Now()))
+			assert.EqualError(t, token.Valid(time.Now()), f.Valid(time.Now()).Error())
+		})
+
+		t.Run("case=expires relative to the time it was issued at", func(t *testing.T) {
+			issuedAt := time.Date(2021, 5, 7, 10, 0, 0, 0, time.UTC)
+			f, err := verification.NewFlow(conf, issuedAt, time.Hour, "", req, nil, flow.TypeBrowser)
 			require.NoError(t, err)
 
-			token := NewSelfServiceVerificationToken(nil, f)
-			require.Error(t, token.Valid())
-			assert.EqualError(t, token.Valid(), f.Valid().Error())
+			token := NewSelfServiceVerificationToken(conf, nil, f, issuedAt)
+			assert.Equal(t, issuedAt, token.IssuedAt)
+			require.NoError(t, token.Valid(issuedAt.Add(time.Hour-time.Second)))
+			require.Error(t, token.Valid(issuedAt.Add(time.Hour+time.Second)))
 		})
 	})
 }
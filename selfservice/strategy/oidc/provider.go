@@ -36,4 +36,8 @@ type Claims struct {
 	PhoneNumberVerified bool   `json:"phone_number_verified,omitempty"`
 	UpdatedAt           int64  `json:"updated_at,omitempty"`
 	HD                  string `json:"hd,omitempty"`
+
+	// RawClaims contains all claims returned by providers which support OpenID Connect, including those
+	// which have no field in this struct.
+	RawClaims map[string]interface{} `json:"raw_claims,omitempty"`
 }
@@ -0,0 +1,199 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/identity"
+)
+
+// claimsDocument returns the claims as a JSON object. Raw claims take precedence over the decoded ones
+// because they also contain claims which are not part of the Claims struct.
+func claimsDocument(claims *Claims) ([]byte, error) {
+	var decoded map[string]interface{}
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	delete(decoded, "raw_claims")
+	for k, v := range claims.RawClaims {
+		decoded[k] = v
+	}
+
+	doc, err := json.Marshal(decoded)
+	return doc, errors.WithStack(err)
+}
+
+// unsatisfiedClaimConstraint returns the first constraint the claims do not satisfy, or nil if all are satisfied.
+func unsatisfiedClaimConstraint(claims *Claims, constraints []ClaimConstraint) (*ClaimConstraint, error) {
+	if len(constraints) == 0 {
+		return nil, nil
+	}
+
+	doc, err := claimsDocument(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	for k := range constraints {
+		c := &constraints[k]
+		ok, err := c.satisfiedBy(doc)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return c, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (c *ClaimConstraint) satisfiedBy(doc []byte) (bool, error) {
+	if len(c.Claim) == 0 || (c.Equals == nil && c.OneOf == nil && c.Contains == nil) {
+		return false, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The claim constraint for claim %q must set a claim and at least one of equals, one_of, or contains.", c.Claim))
+	}
+
+	result := gjson.GetBytes(doc, c.Claim)
+	if !result.Exists() {
+		return false, nil
+	}
+	actual := result.Value()
+
+	if c.Equals != nil && !claimEqual(actual, c.Equals) {
+		return false, nil
+	}
+
+	if c.OneOf != nil {
+		var found bool
+		for _, expected := range c.OneOf {
+			if claimEqual(actual, expected) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	if c.Contains != nil {
+		values, ok := actual.([]interface{})
+		if !ok {
+			return false, nil
+		}
+
+		var found bool
+		for _, v := range values {
+			if claimEqual(v, c.Contains) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// claimEqual compares both values after a JSON round trip so that, for example, integers from the
+// configuration equal the floats gjson decodes numbers to.
+func claimEqual(actual, expected interface{}) bool {
+	a, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+	e, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+
+	var av, ev interface{}
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(e, &ev) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, ev)
+}
+
+// newProviderCredentials returns the credentials of the subject at the provider including a snapshot of its claims,
+// which is encrypted using the data key of the identity.
+func (s *Strategy) newProviderCredentials(ctx context.Context, identityID uuid.UUID, provider Provider, claims *Claims) (ProviderCredentialsConfig, error) {
+	c := ProviderCredentialsConfig{Subject: claims.Subject, Provider: provider.Config().ID}
+	if err := s.setClaimsSnapshot(ctx, identityID, &c, claims); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+func (s *Strategy) setClaimsSnapshot(ctx context.Context, identityID uuid.UUID, c *ProviderCredentialsConfig, claims *Claims) error {
+	doc, err := claimsDocument(claims)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := s.d.IdentityKeys().ForIdentity(identityID).Encrypt(ctx, doc)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Round(time.Second)
+	c.Claims = encrypted
+	c.ClaimsUpdatedAt = &now
+	return nil
+}
+
+// updateClaimsSnapshot replaces the claims snapshot of the identity's credentials at the provider with the claims
+// received during login. Errors are only logged because an outdated snapshot must not prevent the login.
+func (s *Strategy) updateClaimsSnapshot(r *http.Request, id uuid.UUID, provider Provider, claims *Claims) {
+	if err := s.storeClaimsSnapshot(r.Context(), id, provider, claims); err != nil {
+		s.d.Logger().
+			WithRequest(r).
+			WithError(err).
+			WithField("identity_id", id).
+			WithField("oidc_provider", provider.Config().ID).
+			Warn("Unable to store the snapshot of the OpenID Connect claims received during login.")
+	}
+}
+
+func (s *Strategy) storeClaimsSnapshot(ctx context.Context, id uuid.UUID, provider Provider, claims *Claims) error {
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var conf CredentialsConfig
+	creds, err := i.ParseCredentials(s.ID(), &conf)
+	if err != nil {
+		return err
+	}
+
+	for k := range conf.Providers {
+		if conf.Providers[k].Subject == claims.Subject && conf.Providers[k].Provider == provider.Config().ID {
+			if err := s.setClaimsSnapshot(ctx, id, &conf.Providers[k], claims); err != nil {
+				return err
+			}
+		}
+	}
+
+	if creds.Config, err = json.Marshal(conf); err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.SetCredentials(s.ID(), *creds)
+	return s.d.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits)
+}
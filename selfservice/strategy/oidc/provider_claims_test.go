@@ -0,0 +1,82 @@
+package oidc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsatisfiedClaimConstraint(t *testing.T) {
+	claims := &Claims{
+		Subject:       "foo",
+		Email:         "foo@example.com",
+		EmailVerified: true,
+		HD:            "example.com",
+		RawClaims: map[string]interface{}{
+			"sub":                    "foo",
+			"groups":                 []interface{}{"staff", "admins"},
+			"tenant":                 map[string]interface{}{"id": float64(42)},
+			"https://example.com/id": "custom",
+		},
+	}
+
+	for k, tc := range []struct {
+		d           string
+		constraints []ClaimConstraint
+		failed      string
+	}{
+		{d: "no constraints"},
+		{
+			d: "all satisfied",
+			constraints: []ClaimConstraint{
+				{Claim: "hd", Equals: "example.com"},
+				{Claim: "email_verified", Equals: true},
+				{Claim: "groups", Contains: "staff"},
+				{Claim: "tenant.id", OneOf: []interface{}{1, 42}},
+				{Claim: `https://example\.com/id`, Equals: "custom"},
+			},
+		},
+		{
+			d:           "wrong hosted domain",
+			constraints: []ClaimConstraint{{Claim: "email_verified", Equals: true}, {Claim: "hd", Equals: "ory.sh"}},
+			failed:      "hd",
+		},
+		{
+			d:           "missing claim",
+			constraints: []ClaimConstraint{{Claim: "department", Equals: "engineering"}},
+			failed:      "department",
+		},
+		{
+			d:           "group not contained",
+			constraints: []ClaimConstraint{{Claim: "groups", Contains: "contractors"}},
+			failed:      "groups",
+		},
+		{
+			d:           "contains requires an array",
+			constraints: []ClaimConstraint{{Claim: "hd", Contains: "example.com"}},
+			failed:      "hd",
+		},
+		{
+			d:           "value not one of",
+			constraints: []ClaimConstraint{{Claim: "tenant.id", OneOf: []interface{}{1, 2}}},
+			failed:      "tenant.id",
+		},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			c, err := unsatisfiedClaimConstraint(claims, tc.constraints)
+			require.NoError(t, err, "%d", k)
+			if tc.failed == "" {
+				assert.Nil(t, c)
+				return
+			}
+			require.NotNil(t, c)
+			assert.Equal(t, tc.failed, c.Claim)
+		})
+	}
+
+	t.Run("case=fails on incomplete constraints", func(t *testing.T) {
+		_, err := unsatisfiedClaimConstraint(claims, []ClaimConstraint{{Claim: "hd"}})
+		require.Error(t, err)
+	})
+}
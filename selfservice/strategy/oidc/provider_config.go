@@ -69,6 +69,66 @@ type Configuration struct {
 	//
 	// More information: https://openid.net/specs/openid-connect-core-1_0.html#ClaimsParameter
 	RequestedClaims json.RawMessage `json:"requested_claims"`
+
+	// Logout configures whether logging out of ORY Kratos also ends the session at the provider.
+	Logout LogoutConfiguration `json:"logout"`
+
+	// ClaimConstraints must all be satisfied by the claims of an identity before it can sign up, sign in, or link
+	// its account using this provider. Use them to restrict the provider to a tenant, for example.
+	ClaimConstraints []ClaimConstraint `json:"claim_constraints"`
+
+	// UpdateTraitsOnLogin configures whether the mapper also runs when an identity signs in so that changes made
+	// at the provider, such as a new name or picture, are copied to the identity's traits.
+	UpdateTraitsOnLogin UpdateTraitsConfiguration `json:"update_traits_on_login"`
+}
+
+type UpdateTraitsConfiguration struct {
+	// Enabled runs the mapper against the claims on every login. Generic providers additionally fetch the
+	// claims from the userinfo endpoint if the provider advertises one.
+	Enabled bool `json:"enabled"`
+
+	// Traits are the paths of the traits which are updated, for example `name.first` or `picture`. Defaults to
+	// all traits returned by the mapper. Traits which the mapper no longer returns are kept.
+	Traits []string `json:"traits"`
+
+	// KeepExisting only sets traits which are not set yet instead of overwriting them.
+	KeepExisting bool `json:"keep_existing"`
+}
+
+type ClaimConstraint struct {
+	// Claim is the path of the claim, for example `hd` or `groups`. Nested claims are separated by dots and
+	// dots which are part of a claim's name must be escaped with a backslash.
+	Claim string `json:"claim"`
+
+	// Equals requires the claim to have exactly this value.
+	Equals interface{} `json:"equals,omitempty"`
+
+	// OneOf requires the claim to have one of these values.
+	OneOf []interface{} `json:"one_of,omitempty"`
+
+	// Contains requires the claim to be an array containing this value.
+	Contains interface{} `json:"contains,omitempty"`
+}
+
+const (
+	LogoutModeNone        = "none"
+	LogoutModeRedirect    = "redirect"
+	LogoutModeBackChannel = "back_channel"
+)
+
+type LogoutConfiguration struct {
+	// Mode is one of `none` (default), `redirect` which sends the browser to the provider's end session
+	// endpoint, or `back_channel` which calls the end session endpoint from ORY Kratos.
+	Mode string `json:"mode"`
+
+	// EndSessionURL is the provider's end session endpoint. Defaults to the endpoint found using OpenID
+	// Connect Discovery.
+	EndSessionURL string `json:"end_session_url"`
+}
+
+// Enabled returns true if the session at the provider should be ended on logout.
+func (c LogoutConfiguration) Enabled() bool {
+	return c.Mode == LogoutModeRedirect || c.Mode == LogoutModeBackChannel
 }
 
 func (p Configuration) Redir(public *url.URL) string {
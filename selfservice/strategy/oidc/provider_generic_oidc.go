@@ -72,6 +72,23 @@ func (g *ProviderGenericOIDC) OAuth2(ctx context.Context) (*oauth2.Config, error
 	return g.oauth2ConfigFromEndpoint(endpoint), nil
 }
 
+// EndSessionEndpoint returns the end_session_endpoint advertised using OpenID Connect Discovery or an empty
+// string if the provider does not support RP-initiated logout.
+func (g *ProviderGenericOIDC) EndSessionEndpoint(ctx context.Context) (string, error) {
+	p, err := g.provider(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var metadata struct {
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}
+	if err := p.Claims(&metadata); err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode the OpenID Connect Discovery document: %s", err))
+	}
+	return metadata.EndSessionEndpoint, nil
+}
+
 func (g *ProviderGenericOIDC) AuthCodeURLOptions(r ider) []oauth2.AuthCodeOption {
 	var options []oauth2.AuthCodeOption
 
@@ -100,6 +117,10 @@ func (g *ProviderGenericOIDC) verifyAndDecodeClaimsWithProvider(ctx context.Cont
 		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%s", err))
 	}
 
+	if err := token.Claims(&claims.RawClaims); err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%s", err))
+	}
+
 	return &claims, nil
 }
 
@@ -114,5 +135,56 @@ func (g *ProviderGenericOIDC) Claims(ctx context.Context, exchange *oauth2.Token
 		return nil, err
 	}
 
-	return g.verifyAndDecodeClaimsWithProvider(ctx, p, raw)
+	claims, err := g.verifyAndDecodeClaimsWithProvider(ctx, p, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.config.UpdateTraitsOnLogin.Enabled {
+		if err := g.addUserInfoClaims(ctx, p, exchange, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// addUserInfoClaims overwrites the claims with the ones returned by the userinfo endpoint, which may be more
+// recent than the ID Token's, if the provider advertises the endpoint.
+func (g *ProviderGenericOIDC) addUserInfoClaims(ctx context.Context, p *gooidc.Provider, exchange *oauth2.Token, claims *Claims) error {
+	var metadata struct {
+		UserInfoEndpoint string `json:"userinfo_endpoint"`
+	}
+	if err := p.Claims(&metadata); err != nil || len(metadata.UserInfoEndpoint) == 0 {
+		return nil
+	}
+
+	userInfo, err := p.UserInfo(ctx, oauth2.StaticTokenSource(exchange))
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to fetch the userinfo claims: %s", err))
+	}
+
+	// The userinfo response must be about the subject of the ID Token.
+	if userInfo.Subject != claims.Subject {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The subject of the userinfo claims does not match the subject of the ID Token."))
+	}
+
+	var raw map[string]interface{}
+	if err := userInfo.Claims(&raw); err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode the userinfo claims: %s", err))
+	}
+
+	rawClaims := claims.RawClaims
+	if err := userInfo.Claims(claims); err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode the userinfo claims: %s", err))
+	}
+
+	if rawClaims == nil {
+		rawClaims = make(map[string]interface{}, len(raw))
+	}
+	for k, v := range raw {
+		rawClaims[k] = v
+	}
+	claims.RawClaims = rawClaims
+	return nil
 }
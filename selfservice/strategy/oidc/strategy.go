@@ -26,6 +26,7 @@ import (
 	"github.com/ory/x/fetcher"
 
 	"github.com/ory/herodot"
+	"github.com/ory/kratos/cipher"
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
@@ -56,16 +57,19 @@ type dependencies interface {
 	config.Provider
 
 	x.LoggingProvider
+	x.ClockProvider
 	x.CookieProvider
 	x.CSRFTokenGeneratorProvider
 	x.WriterProvider
 
 	identity.ValidationProvider
 	identity.PrivilegedPoolProvider
+	identity.ManagementProvider
 	identity.ActiveCredentialsCounterStrategyProvider
 
 	session.ManagementProvider
 	session.HandlerProvider
+	session.PersistenceProvider
 
 	login.HookExecutorProvider
 	login.FlowPersistenceProvider
@@ -86,6 +90,8 @@ type dependencies interface {
 	settings.HookExecutorProvider
 
 	continuity.ManagementProvider
+
+	cipher.IdentityKeysProvider
 }
 
 func isForced(req interface{}) bool {
@@ -164,7 +170,7 @@ func (s *Strategy) validateFlow(ctx context.Context, r *http.Request, rid uuid.U
 			return ar, ErrAPIFlowNotSupported
 		}
 
-		if err := ar.Valid(); err != nil {
+		if err := ar.Valid(s.d.Clock().Now()); err != nil {
 			return ar, err
 		}
 		return ar, nil
@@ -175,7 +181,7 @@ func (s *Strategy) validateFlow(ctx context.Context, r *http.Request, rid uuid.U
 			return ar, ErrAPIFlowNotSupported
 		}
 
-		if err := ar.Valid(); err != nil {
+		if err := ar.Valid(s.d.Clock().Now()); err != nil {
 			return ar, err
 		}
 		return ar, nil
@@ -192,7 +198,7 @@ func (s *Strategy) validateFlow(ctx context.Context, r *http.Request, rid uuid.U
 			return ar, err
 		}
 
-		if err := ar.Valid(sess); err != nil {
+		if err := ar.Valid(sess, s.d.Clock().Now()); err != nil {
 			return ar, err
 		}
 		return ar, nil
@@ -294,6 +300,17 @@ func (s *Strategy) handleCallback(w http.ResponseWriter, r *http.Request, ps htt
 		return
 	}
 
+	if err := s.checkClaimConstraints(r, provider, claims); err != nil {
+		s.forwardError(w, r, req, s.handleError(w, r, req, pid, nil, err))
+		return
+	}
+
+	if provider.Config().Logout.Enabled() {
+		// Sessions issued for this callback remember the provider so that logging out ends the session there too.
+		idToken, _ := token.Extra("id_token").(string)
+		r = r.WithContext(session.ContextWithUpstreamSession(r.Context(), &session.UpstreamSession{Provider: pid, IDToken: idToken}))
+	}
+
 	switch a := req.(type) {
 	case *login.Flow:
 		if ff, err := s.processLogin(w, r, a, claims, provider, cntnr); err != nil {
@@ -331,6 +348,29 @@ func (s *Strategy) handleCallback(w http.ResponseWriter, r *http.Request, ps htt
 	}
 }
 
+// checkClaimConstraints rejects identities whose claims do not satisfy the provider's claim constraints before they
+// are used to sign up, sign in, or link an account.
+func (s *Strategy) checkClaimConstraints(r *http.Request, provider Provider, claims *Claims) error {
+	c, err := unsatisfiedClaimConstraint(claims, provider.Config().ClaimConstraints)
+	if err != nil {
+		return err
+	} else if c == nil {
+		return nil
+	}
+
+	s.d.Logger().
+		WithRequest(r).
+		WithField("oidc_provider", provider.Config().ID).
+		WithField("claim", c.Claim).
+		Info("The OpenID Connect claims do not satisfy the provider's claim constraints.")
+
+	label := provider.Config().Label
+	if len(label) == 0 {
+		label = provider.Config().ID
+	}
+	return schema.NewProviderClaimsRejectedError(label)
+}
+
 func uid(provider, subject string) string {
 	return fmt.Sprintf("%s:%s", provider, subject)
 }
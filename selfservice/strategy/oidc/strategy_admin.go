@@ -0,0 +1,248 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/strategy"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+const RouteAdminSessions = "/sessions/oidc"
+
+var _ login.AdminHandler = new(Strategy)
+
+// AdminCreateSession is the request body of the admin endpoint which issues sessions for identities of an
+// OpenID Connect provider.
+type AdminCreateSession struct {
+	// Provider is the ID of the OpenID Connect provider as configured at
+	// `selfservice.methods.oidc.config.providers`.
+	//
+	// required: true
+	Provider string `json:"provider"`
+
+	// Subject is the identity's subject at the provider.
+	//
+	// required: true
+	Subject string `json:"subject"`
+
+	// Claims are the claims the provider returned for the subject. They are passed to the provider's Jsonnet
+	// mapper when the identity is created and checked against the provider's claim constraints.
+	Claims json.RawMessage `json:"claims"`
+
+	// ExpiresAt sets when the session expires. It must be in the future and is capped at, and defaults to,
+	// now plus `session.lifespan`.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// The response for issuing a session for an identity of an OpenID Connect provider.
+//
+// swagger:model adminOIDCSession
+type AdminSessionResponse struct {
+	// Token is the session token.
+	//
+	// required: true
+	Token string `json:"session_token"`
+
+	// Session is the issued session.
+	//
+	// required: true
+	Session *session.Session `json:"session"`
+
+	// IdentityCreated is true if no identity was linked to the subject yet and one was created.
+	//
+	// required: true
+	IdentityCreated bool `json:"identity_created"`
+}
+
+// swagger:parameters adminCreateOIDCSession
+// nolint:deadcode,unused
+type adminCreateOIDCSessionParameters struct {
+	// in: body
+	Body AdminCreateSession
+}
+
+// The issued session.
+//
+// swagger:response adminOIDCSession
+// nolint:deadcode,unused
+type adminOIDCSessionResponse struct {
+	// in: body
+	Body AdminSessionResponse
+}
+
+func (s *Strategy) RegisterAdminLoginRoutes(admin *x.RouterAdmin) {
+	admin.POST(RouteAdminSessions, strategy.IsDisabled(s.d, s.ID().String(), s.adminCreateSession))
+}
+
+// swagger:route POST /sessions/oidc admin adminCreateOIDCSession
+//
+// Issue a Session for an Identity of an OpenID Connect Provider
+//
+// This endpoint lets trusted backend services sign in identities of an OpenID Connect provider without
+// redirecting a browser to the provider. If no identity is linked to the subject yet, one is created using the
+// provider's Jsonnet mapper and the given claims. Otherwise the claims snapshot is updated and, if
+// `update_traits_on_login` is enabled for the provider, so are the identity's traits.
+//
+// The caller is responsible for having verified the claims with the provider.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: adminOIDCSession
+//       400: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (s *Strategy) adminCreateSession(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var p AdminCreateSession
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&p); err != nil {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to decode the request body: %s", err)))
+		return
+	}
+
+	if len(p.Provider) == 0 || len(p.Subject) == 0 {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The provider and subject are required.")))
+		return
+	}
+
+	now := s.d.Clock().Now().UTC()
+	if p.ExpiresAt != nil && !p.ExpiresAt.After(now) {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The session expiry must be in the future.")))
+		return
+	}
+
+	claims, err := adminClaims(p)
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	provider, err := s.provider(r.Context(), r, p.Provider)
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := s.checkClaimConstraints(r, provider, claims); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	i, created, err := s.upsertIdentity(r, provider, claims)
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if identity.IsServiceAccount(s.d.Config(r.Context()), i) {
+		s.d.Writer().WriteError(w, r, errors.WithStack(identity.ErrServiceAccountSelfService))
+		return
+	} else if !i.IsActive() {
+		s.d.Writer().WriteError(w, r, errors.WithStack(identity.ErrIdentityInactive))
+		return
+	}
+
+	sess := session.NewActiveSession(i, s.d.Config(r.Context()), now)
+	if p.ExpiresAt != nil && p.ExpiresAt.Before(sess.ExpiresAt) {
+		// Sessions never outlive the configured lifespan.
+		sess.ExpiresAt = p.ExpiresAt.UTC()
+	}
+
+	if err := s.d.SessionPersister().CreateSession(r.Context(), sess); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	s.d.Audit().
+		WithRequest(r).
+		WithField("session_id", sess.ID).
+		WithField("identity_id", i.ID).
+		WithField("oidc_provider", provider.Config().ID).
+		WithField("identity_created", created).
+		Info("A session was issued for an identity of an OpenID Connect provider using the admin API.")
+
+	s.d.Writer().WriteCode(w, r, http.StatusCreated, &AdminSessionResponse{Token: sess.Token, Session: sess.Declassify(), IdentityCreated: created})
+}
+
+// upsertIdentity returns the identity linked to the subject at the provider. If there is none, it is created
+// from the claims. The returned boolean is true if the identity was created.
+func (s *Strategy) upsertIdentity(r *http.Request, provider Provider, claims *Claims) (*identity.Identity, bool, error) {
+	i, _, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), identity.CredentialsTypeOIDC, uid(provider.Config().ID, claims.Subject))
+	if err == nil {
+		s.updateClaimsSnapshot(r, i.ID, provider, claims)
+		if provider.Config().UpdateTraitsOnLogin.Enabled {
+			if i, err = s.updateTraitsOnLogin(r, i, claims, provider); err != nil {
+				return nil, false, err
+			}
+		}
+		return i, false, nil
+	} else if !errors.Is(err, sqlcon.ErrNoRows) {
+		return nil, false, err
+	}
+
+	traits, err := s.mapTraits(r, claims, provider)
+	if err != nil {
+		return nil, false, err
+	}
+
+	i = identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = traits
+
+	pc, err := s.newProviderCredentials(r.Context(), i.ID, provider, claims)
+	if err != nil {
+		return nil, false, err
+	}
+
+	creds, err := NewCredentials(pc)
+	if err != nil {
+		return nil, false, err
+	}
+	i.SetCredentials(s.ID(), *creds)
+
+	if err := s.d.IdentityManager().Create(r.Context(), i, identity.ManagerEnforceEmailDomainPolicy, identity.ManagerEnforceReservedNames); err != nil {
+		return nil, false, err
+	}
+
+	return i, true, nil
+}
+
+// adminClaims returns the claims of the request. The subject of the request takes precedence over the `sub`
+// claim.
+func adminClaims(p AdminCreateSession) (*Claims, error) {
+	claims := &Claims{RawClaims: map[string]interface{}{}}
+	if len(p.Claims) > 0 {
+		if err := json.Unmarshal(p.Claims, claims); err != nil {
+			return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The claims must be a JSON object: %s", err))
+		}
+		if err := json.Unmarshal(p.Claims, &claims.RawClaims); err != nil {
+			return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The claims must be a JSON object: %s", err))
+		}
+	}
+
+	if claims.RawClaims == nil {
+		claims.RawClaims = map[string]interface{}{}
+	}
+
+	claims.Subject = p.Subject
+	claims.RawClaims["sub"] = p.Subject
+	return claims, nil
+}
@@ -0,0 +1,110 @@
+package oidc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/strategy/oidc"
+	"github.com/ory/kratos/x"
+)
+
+func TestAdminCreateSession(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/registration.schema.json")
+	viperSetProviderConfig(t, conf, oidc.Configuration{
+		ID:           "valid",
+		Provider:     "generic",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Mapper:       "file://./stub/oidc.hydra.jsonnet",
+	})
+	_, admin := testhelpers.NewKratosServer(t, reg)
+
+	create := func(t *testing.T, body interface{}, expectCode int) gjson.Result {
+		var b bytes.Buffer
+		require.NoError(t, json.NewEncoder(&b).Encode(body))
+		res, err := admin.Client().Post(admin.URL+oidc.RouteAdminSessions, "application/json", &b)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		result := gjson.ParseBytes(x.MustReadAll(res.Body))
+		require.Equal(t, expectCode, res.StatusCode, "%s", result.Raw)
+		return result
+	}
+
+	subject := x.NewUUID().String() + "@ory.sh"
+
+	t.Run("case=creates the identity and issues a session", func(t *testing.T) {
+		res := create(t, &oidc.AdminCreateSession{
+			Provider: "valid",
+			Subject:  subject,
+			Claims:   json.RawMessage(`{"website":"https://www.ory.sh"}`),
+		}, http.StatusCreated)
+
+		assert.True(t, res.Get("identity_created").Bool(), "%s", res.Raw)
+		assert.NotEmpty(t, res.Get("session_token").String(), "%s", res.Raw)
+		assert.Equal(t, subject, res.Get("session.identity.traits.subject").String(), "%s", res.Raw)
+		assert.Equal(t, "https://www.ory.sh", res.Get("session.identity.traits.website").String(), "%s", res.Raw)
+
+		s, err := reg.SessionPersister().GetSessionByToken(context.Background(), res.Get("session_token").String())
+		require.NoError(t, err)
+		assert.Equal(t, res.Get("session.identity.id").String(), s.IdentityID.String())
+
+		i, _, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(context.Background(), identity.CredentialsTypeOIDC, "valid:"+subject)
+		require.NoError(t, err)
+		assert.Equal(t, s.IdentityID, i.ID)
+	})
+
+	t.Run("case=issues a session for the linked identity", func(t *testing.T) {
+		first := create(t, &oidc.AdminCreateSession{Provider: "valid", Subject: subject}, http.StatusCreated)
+		assert.False(t, first.Get("identity_created").Bool(), "%s", first.Raw)
+
+		second := create(t, &oidc.AdminCreateSession{Provider: "valid", Subject: subject}, http.StatusCreated)
+		assert.Equal(t, first.Get("session.identity.id").String(), second.Get("session.identity.id").String())
+		assert.NotEqual(t, first.Get("session_token").String(), second.Get("session_token").String())
+	})
+
+	t.Run("case=rejects invalid requests", func(t *testing.T) {
+		_ = create(t, &oidc.AdminCreateSession{Provider: "valid"}, http.StatusBadRequest)
+		_ = create(t, &oidc.AdminCreateSession{Provider: "unknown", Subject: subject}, http.StatusNotFound)
+		_ = create(t, &oidc.AdminCreateSession{Provider: "valid", Subject: subject, Claims: json.RawMessage(`"claims"`)}, http.StatusBadRequest)
+		_ = create(t, &oidc.AdminCreateSession{Provider: "valid", Subject: "not-an-email"}, http.StatusBadRequest)
+	})
+
+	t.Run("case=validates the session expiry", func(t *testing.T) {
+		subject := x.NewUUID().String() + "@ory.sh"
+		past := time.Now().Add(-time.Minute)
+		_ = create(t, &oidc.AdminCreateSession{Provider: "valid", Subject: subject, ExpiresAt: &past}, http.StatusBadRequest)
+		_, _, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(context.Background(), identity.CredentialsTypeOIDC, "valid:"+subject)
+		require.ErrorIs(t, err, sqlcon.ErrNoRows, "rejected requests must not create identities")
+
+		soon := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+		res := create(t, &oidc.AdminCreateSession{Provider: "valid", Subject: subject, ExpiresAt: &soon}, http.StatusCreated)
+		assert.Equal(t, soon, res.Get("session.expires_at").Time().UTC(), "%s", res.Raw)
+
+		later := time.Now().Add(conf.SessionLifespan() + time.Hour)
+		res = create(t, &oidc.AdminCreateSession{Provider: "valid", Subject: subject, ExpiresAt: &later}, http.StatusCreated)
+		assert.True(t, res.Get("session.expires_at").Time().Before(time.Now().Add(conf.SessionLifespan()+time.Minute)), "%s", res.Raw)
+	})
+
+	t.Run("case=is disabled with the oidc method", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypeOIDC)+".enabled", false)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypeOIDC)+".enabled", true)
+		})
+		_ = create(t, &oidc.AdminCreateSession{Provider: "valid", Subject: subject}, http.StatusNotFound)
+	})
+}
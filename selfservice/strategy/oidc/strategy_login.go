@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"reflect"
 	"time"
 
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
 	"github.com/ory/x/sqlcon"
 
 	"github.com/ory/kratos/selfservice/flow/registration"
@@ -78,6 +82,14 @@ func (s *Strategy) processLogin(w http.ResponseWriter, r *http.Request, a *login
 
 	for _, c := range o.Providers {
 		if c.Subject == claims.Subject && c.Provider == provider.Config().ID {
+			s.updateClaimsSnapshot(r, i.ID, provider, claims)
+
+			if provider.Config().UpdateTraitsOnLogin.Enabled {
+				if i, err = s.updateTraitsOnLogin(r, i, claims, provider); err != nil {
+					return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
+				}
+			}
+
 			if err = s.d.LoginHookExecutor().PostLoginHook(w, r, identity.CredentialsTypeOIDC, a, i); err != nil {
 				return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
 			}
@@ -88,6 +100,87 @@ func (s *Strategy) processLogin(w http.ResponseWriter, r *http.Request, a *login
 	return nil, s.handleError(w, r, a, provider.Config().ID, nil, errors.WithStack(herodot.ErrInternalServerError.WithReason("Unable to find matching OpenID Connect Credentials.").WithDebugf(`Unable to find credentials that match the given provider "%s" and subject "%s".`, provider.Config().ID, claims.Subject)))
 }
 
+// updateTraitsOnLogin copies the traits the mapper produces for the current claims to the identity. Invalid
+// traits are logged and skipped so that changes at the provider can not prevent the identity from signing in.
+func (s *Strategy) updateTraitsOnLogin(r *http.Request, i *identity.Identity, claims *Claims, provider Provider) (*identity.Identity, error) {
+	mapped, err := s.mapTraits(r, claims, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	traits, updated, err := updateTraits(i.Traits, mapped, provider.Config().UpdateTraitsOnLogin)
+	if err != nil {
+		return nil, err
+	} else if len(updated) == 0 {
+		return i, nil
+	}
+
+	if err := s.d.IdentityManager().UpdateTraits(r.Context(), i.ID, traits, identity.ManagerAllowWriteProtectedTraits); err != nil {
+		s.d.Logger().
+			WithRequest(r).
+			WithError(err).
+			WithField("identity_id", i.ID).
+			WithField("oidc_provider", provider.Config().ID).
+			WithField("traits", updated).
+			Warn("Unable to update the identity's traits using the OpenID Connect claims. The previous traits are kept.")
+		return i, nil
+	}
+
+	s.d.Audit().
+		WithRequest(r).
+		WithField("identity_id", i.ID).
+		WithField("oidc_provider", provider.Config().ID).
+		WithField("traits", updated).
+		Info("Updated the identity's traits using the OpenID Connect claims received during login.")
+
+	return s.d.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), i.ID)
+}
+
+// updateTraits sets the configured traits from mapped and returns the resulting traits and the paths which changed.
+func updateTraits(current, mapped identity.Traits, c UpdateTraitsConfiguration) (identity.Traits, []string, error) {
+	paths := c.Traits
+	if len(paths) == 0 {
+		gjson.ParseBytes(mapped).ForEach(func(key, _ gjson.Result) bool {
+			paths = append(paths, key.String())
+			return true
+		})
+	}
+
+	if len(current) == 0 {
+		current = identity.Traits("{}")
+	}
+
+	var updated []string
+	for _, path := range paths {
+		value := gjson.GetBytes(mapped, path)
+		if !value.Exists() {
+			continue
+		}
+
+		previous := gjson.GetBytes(current, path)
+		if previous.Exists() && (c.KeepExisting || jsonEqual(previous.Raw, value.Raw)) {
+			continue
+		}
+
+		next, err := sjson.SetRawBytes(current, path, []byte(value.Raw))
+		if err != nil {
+			return nil, nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Unable to update trait "%s": %s`, path, err))
+		}
+		current = next
+		updated = append(updated, path)
+	}
+
+	return current, updated, nil
+}
+
+func jsonEqual(a, b string) bool {
+	var av, bv interface{}
+	if json.Unmarshal([]byte(a), &av) != nil || json.Unmarshal([]byte(b), &bv) != nil {
+		return a == b
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
 func (s *Strategy) Login(w http.ResponseWriter, r *http.Request, f *login.Flow) (i *identity.Identity, err error) {
 	if err := r.ParseForm(); err != nil {
 		return nil, s.handleError(w, r, f, "", nil, errors.WithStack(herodot.ErrBadRequest.WithDebug(err.Error()).WithReasonf("Unable to parse HTTP form request: %s", err.Error())))
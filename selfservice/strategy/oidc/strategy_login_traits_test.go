@@ -0,0 +1,63 @@
+package oidc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/identity"
+)
+
+func TestUpdateTraits(t *testing.T) {
+	const current = `{"email":"foo@ory.sh","name":{"first":"Foo","last":"Bar"},"newsletter":true}`
+	const mapped = `{"email":"new@ory.sh","name":{"first":"Fooo","last":"Bar"},"picture":"https://www.ory.sh/foo.png"}`
+
+	for k, tc := range []struct {
+		d        string
+		current  string
+		conf     UpdateTraitsConfiguration
+		expected string
+		updated  []string
+	}{
+		{
+			d:        "updates all mapped traits",
+			current:  current,
+			expected: `{"email":"new@ory.sh","name":{"first":"Fooo","last":"Bar"},"newsletter":true,"picture":"https://www.ory.sh/foo.png"}`,
+			updated:  []string{"email", "name", "picture"},
+		},
+		{
+			d:        "only updates the configured traits",
+			current:  current,
+			conf:     UpdateTraitsConfiguration{Traits: []string{"name.first", "name.last", "picture", "unknown"}},
+			expected: `{"email":"foo@ory.sh","name":{"first":"Fooo","last":"Bar"},"newsletter":true,"picture":"https://www.ory.sh/foo.png"}`,
+			updated:  []string{"name.first", "picture"},
+		},
+		{
+			d:        "keeps existing traits",
+			current:  current,
+			conf:     UpdateTraitsConfiguration{KeepExisting: true},
+			expected: `{"email":"foo@ory.sh","name":{"first":"Foo","last":"Bar"},"newsletter":true,"picture":"https://www.ory.sh/foo.png"}`,
+			updated:  []string{"picture"},
+		},
+		{
+			d:        "reports no changes if the traits are up to date",
+			current:  `{"picture":"https://www.ory.sh/foo.png","name":{"last":"Bar","first":"Fooo"}}`,
+			conf:     UpdateTraitsConfiguration{Traits: []string{"name", "picture"}},
+			expected: `{"picture":"https://www.ory.sh/foo.png","name":{"last":"Bar","first":"Fooo"}}`,
+		},
+		{
+			d:        "handles identities without traits",
+			conf:     UpdateTraitsConfiguration{Traits: []string{"picture"}},
+			expected: `{"picture":"https://www.ory.sh/foo.png"}`,
+			updated:  []string{"picture"},
+		},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			traits, updated, err := updateTraits(identity.Traits(tc.current), identity.Traits(mapped), tc.conf)
+			require.NoError(t, err, "%d", k)
+			assert.JSONEq(t, tc.expected, string(traits))
+			assert.Equal(t, tc.updated, updated)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/selfservice/flow/logout"
+	"github.com/ory/kratos/session"
+)
+
+var _ logout.Strategy = new(Strategy)
+
+// EndUpstreamSession ends the session at the OpenID Connect provider the identity signed in with if the
+// provider's logout mode asks for it.
+func (s *Strategy) EndUpstreamSession(_ http.ResponseWriter, r *http.Request, sess *session.Session, returnTo *url.URL) (*url.URL, error) {
+	if sess.UpstreamSession == nil {
+		return nil, nil
+	}
+
+	provider, err := s.provider(r.Context(), r, sess.UpstreamSession.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	c := provider.Config()
+	if !c.Logout.Enabled() {
+		return nil, nil
+	}
+
+	endpoint, err := s.endSessionEndpoint(r.Context(), provider)
+	if err != nil {
+		return nil, err
+	}
+
+	query := endpoint.Query()
+	query.Set("client_id", c.ClientID)
+	if len(sess.UpstreamSession.IDToken) > 0 {
+		query.Set("id_token_hint", sess.UpstreamSession.IDToken)
+	}
+
+	if c.Logout.Mode == LogoutModeRedirect {
+		query.Set("post_logout_redirect_uri", returnTo.String())
+		endpoint.RawQuery = query.Encode()
+		return endpoint, nil
+	}
+
+	endpoint.RawQuery = query.Encode()
+	return nil, s.endSessionBackChannel(r.Context(), c.ID, endpoint)
+}
+
+func (s *Strategy) endSessionEndpoint(ctx context.Context, provider Provider) (*url.URL, error) {
+	endpoint := provider.Config().Logout.EndSessionURL
+	if len(endpoint) == 0 {
+		if p, ok := provider.(interface {
+			EndSessionEndpoint(ctx context.Context) (string, error)
+		}); ok {
+			var err error
+			if endpoint, err = p.EndSessionEndpoint(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(endpoint) == 0 {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`OpenID Connect provider "%s" does not advertise an end session endpoint. Please set "logout.end_session_url" for this provider.`, provider.Config().ID))
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`The end session endpoint of OpenID Connect provider "%s" is not a valid URL: %s`, provider.Config().ID, err))
+	}
+	return u, nil
+}
+
+func (s *Strategy) endSessionBackChannel(ctx context.Context, provider string, endpoint *url.URL) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint.String(), nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		// Providers usually redirect to a confirmation page which is meant for browsers.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Unable to end the session at OpenID Connect provider "%s": %s`, provider, err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`OpenID Connect provider "%s" responded with status code %d when ending the session.`, provider, res.StatusCode))
+	}
+
+	s.d.Logger().
+		WithField("provider", provider).
+		Debug("Ended the session at the OpenID Connect provider.")
+	return nil
+}
@@ -0,0 +1,107 @@
+package oidc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/strategy/oidc"
+	"github.com/ory/kratos/session"
+)
+
+func TestEndUpstreamSession(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	strategy := oidc.NewStrategy(reg)
+
+	var received url.Values
+	status := http.StatusOK
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.Query()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(upstream.Close)
+
+	provider := func(id, mode string) oidc.Configuration {
+		return oidc.Configuration{
+			ID:           id,
+			Provider:     "generic",
+			ClientID:     "client-" + id,
+			ClientSecret: "secret",
+			Mapper:       "file://./stub/oidc.hydra.jsonnet",
+			Logout:       oidc.LogoutConfiguration{Mode: mode, EndSessionURL: upstream.URL + "/logout?tenant=ory"},
+		}
+	}
+	viperSetProviderConfig(t, conf,
+		provider("redirect", oidc.LogoutModeRedirect),
+		provider("back-channel", oidc.LogoutModeBackChannel),
+		provider("none", ""),
+	)
+
+	returnTo, err := url.Parse("https://www.ory.sh/logged-out")
+	require.NoError(t, err)
+
+	end := func(t *testing.T, upstream *session.UpstreamSession) (*url.URL, error) {
+		received = nil
+		r := httptest.NewRequest("GET", "/self-service/browser/flows/logout", nil)
+		return strategy.EndUpstreamSession(httptest.NewRecorder(), r, &session.Session{UpstreamSession: upstream}, returnTo)
+	}
+
+	t.Run("case=does nothing without an upstream session", func(t *testing.T) {
+		redirect, err := end(t, nil)
+		require.NoError(t, err)
+		assert.Nil(t, redirect)
+		assert.Nil(t, received)
+	})
+
+	t.Run("case=does nothing if logout is disabled for the provider", func(t *testing.T) {
+		redirect, err := end(t, &session.UpstreamSession{Provider: "none", IDToken: "id-token"})
+		require.NoError(t, err)
+		assert.Nil(t, redirect)
+		assert.Nil(t, received)
+	})
+
+	t.Run("case=redirects to the end session endpoint", func(t *testing.T) {
+		redirect, err := end(t, &session.UpstreamSession{Provider: "redirect", IDToken: "id-token"})
+		require.NoError(t, err)
+		require.NotNil(t, redirect)
+
+		assert.Equal(t, upstream.URL+"/logout", redirect.Scheme+"://"+redirect.Host+redirect.Path)
+		query := redirect.Query()
+		assert.Equal(t, "ory", query.Get("tenant"))
+		assert.Equal(t, "client-redirect", query.Get("client_id"))
+		assert.Equal(t, "id-token", query.Get("id_token_hint"))
+		assert.Equal(t, returnTo.String(), query.Get("post_logout_redirect_uri"))
+		assert.Nil(t, received)
+	})
+
+	t.Run("case=calls the end session endpoint", func(t *testing.T) {
+		redirect, err := end(t, &session.UpstreamSession{Provider: "back-channel", IDToken: "id-token"})
+		require.NoError(t, err)
+		assert.Nil(t, redirect)
+
+		require.NotNil(t, received)
+		assert.Equal(t, "client-back-channel", received.Get("client_id"))
+		assert.Equal(t, "id-token", received.Get("id_token_hint"))
+		assert.Empty(t, received.Get("post_logout_redirect_uri"))
+	})
+
+	t.Run("case=fails if the end session endpoint fails", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		t.Cleanup(func() {
+			status = http.StatusOK
+		})
+
+		_, err := end(t, &session.UpstreamSession{Provider: "back-channel", IDToken: "id-token"})
+		require.Error(t, err)
+	})
+
+	t.Run("case=fails if the provider was removed", func(t *testing.T) {
+		_, err := end(t, &session.UpstreamSession{Provider: "unknown"})
+		require.Error(t, err)
+	})
+}
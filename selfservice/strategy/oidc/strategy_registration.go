@@ -126,43 +126,13 @@ func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a
 		return nil, nil
 	}
 
-	jn, err := s.f.Fetch(provider.Config().Mapper)
+	traits, err := s.mapTraits(r, claims, provider)
 	if err != nil {
 		return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
 	}
 
-	var jsonClaims bytes.Buffer
-	if err := json.NewEncoder(&jsonClaims).Encode(claims); err != nil {
-		return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
-	}
-
 	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
-
-	vm := jsonnet.MakeVM()
-	vm.ExtCode("claims", jsonClaims.String())
-	evaluated, err := vm.EvaluateSnippet(provider.Config().Mapper, jn.String())
-	if err != nil {
-		return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
-	} else if traits := gjson.Get(evaluated, "identity.traits"); !traits.IsObject() {
-		i.Traits = []byte{'{', '}'}
-		s.d.Logger().
-			WithRequest(r).
-			WithField("oidc_provider", provider.Config().ID).
-			WithSensitiveField("oidc_claims", claims).
-			WithField("mapper_jsonnet_output", evaluated).
-			WithField("mapper_jsonnet_url", provider.Config().Mapper).
-			Error("OpenID Connect Jsonnet mapper did not return an object for key identity.traits. Please check your Jsonnet code!")
-	} else {
-		i.Traits = []byte(traits.Raw)
-	}
-
-	s.d.Logger().
-		WithRequest(r).
-		WithField("oidc_provider", provider.Config().ID).
-		WithSensitiveField("oidc_claims", claims).
-		WithField("mapper_jsonnet_output", evaluated).
-		WithField("mapper_jsonnet_url", provider.Config().Mapper).
-		Debug("OpenID Connect Jsonnet mapper completed.")
+	i.Traits = traits
 
 	option, err := decoderRegistration(s.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String())
 	if err != nil {
@@ -179,7 +149,12 @@ func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a
 		return nil, s.handleError(w, r, a, provider.Config().ID, i.Traits, err)
 	}
 
-	creds, err := NewCredentials(provider.Config().ID, claims.Subject)
+	pc, err := s.newProviderCredentials(r.Context(), i.ID, provider, claims)
+	if err != nil {
+		return nil, s.handleError(w, r, a, provider.Config().ID, i.Traits, err)
+	}
+
+	creds, err := NewCredentials(pc)
 	if err != nil {
 		return nil, s.handleError(w, r, a, provider.Config().ID, i.Traits, err)
 	}
@@ -191,3 +166,46 @@ func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a
 
 	return nil, nil
 }
+
+// mapTraits runs the provider's Jsonnet mapper against the claims and returns the traits it produced.
+func (s *Strategy) mapTraits(r *http.Request, claims *Claims, provider Provider) (identity.Traits, error) {
+	jn, err := s.f.Fetch(provider.Config().Mapper)
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonClaims bytes.Buffer
+	if err := json.NewEncoder(&jsonClaims).Encode(claims); err != nil {
+		return nil, err
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("claims", jsonClaims.String())
+	evaluated, err := vm.EvaluateSnippet(provider.Config().Mapper, jn.String())
+	if err != nil {
+		return nil, err
+	}
+
+	traits := identity.Traits("{}")
+	if result := gjson.Get(evaluated, "identity.traits"); !result.IsObject() {
+		s.d.Logger().
+			WithRequest(r).
+			WithField("oidc_provider", provider.Config().ID).
+			WithSensitiveField("oidc_claims", claims).
+			WithField("mapper_jsonnet_output", evaluated).
+			WithField("mapper_jsonnet_url", provider.Config().Mapper).
+			Error("OpenID Connect Jsonnet mapper did not return an object for key identity.traits. Please check your Jsonnet code!")
+	} else {
+		traits = identity.Traits(result.Raw)
+	}
+
+	s.d.Logger().
+		WithRequest(r).
+		WithField("oidc_provider", provider.Config().ID).
+		WithSensitiveField("oidc_claims", claims).
+		WithField("mapper_jsonnet_output", evaluated).
+		WithField("mapper_jsonnet_url", provider.Config().Mapper).
+		Debug("OpenID Connect Jsonnet mapper completed.")
+
+	return traits, nil
+}
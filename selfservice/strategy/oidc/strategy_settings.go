@@ -296,7 +296,7 @@ func (s *Strategy) initLinkProvider(w http.ResponseWriter, r *http.Request, ctxU
 		return s.handleSettingsError(w, r, ctxUpdate, p, err)
 	}
 
-	if ctxUpdate.Session.AuthenticatedAt.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()).Before(time.Now()) {
+	if ctxUpdate.AuthenticatedAt().Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(s.SettingsStrategyID())).Before(s.d.Clock().Now()) {
 		return s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
 	}
 
@@ -333,7 +333,7 @@ func (s *Strategy) initLinkProvider(w http.ResponseWriter, r *http.Request, ctxU
 func (s *Strategy) linkProvider(w http.ResponseWriter, r *http.Request, ctxUpdate *settings.UpdateContext, claims *Claims, provider Provider) error {
 	p := &submitSelfServiceBrowserSettingsOIDCFlowPayload{
 		Link: provider.Config().ID, FlowID: ctxUpdate.Flow.ID.String()}
-	if ctxUpdate.Session.AuthenticatedAt.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()).Before(time.Now()) {
+	if ctxUpdate.AuthenticatedAt().Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(s.SettingsStrategyID())).Before(s.d.Clock().Now()) {
 		return s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
 	}
 
@@ -342,19 +342,23 @@ func (s *Strategy) linkProvider(w http.ResponseWriter, r *http.Request, ctxUpdat
 		return s.handleSettingsError(w, r, ctxUpdate, p, err)
 	}
 
+	pc, err := s.newProviderCredentials(r.Context(), i.ID, provider, claims)
+	if err != nil {
+		return s.handleSettingsError(w, r, ctxUpdate, p, err)
+	}
+
 	var conf CredentialsConfig
 	creds, err := i.ParseCredentials(s.ID(), &conf)
 	if errors.Is(err, herodot.ErrNotFound) {
 		var err error
-		if creds, err = NewCredentials(provider.Config().ID, claims.Subject); err != nil {
+		if creds, err = NewCredentials(pc); err != nil {
 			return s.handleSettingsError(w, r, ctxUpdate, p, err)
 		}
 	} else if err != nil {
 		return s.handleSettingsError(w, r, ctxUpdate, p, err)
 	} else {
 		creds.Identifiers = append(creds.Identifiers, uid(provider.Config().ID, claims.Subject))
-		conf.Providers = append(conf.Providers, ProviderCredentialsConfig{
-			Subject: claims.Subject, Provider: provider.Config().ID})
+		conf.Providers = append(conf.Providers, pc)
 
 		creds.Config, err = json.Marshal(conf)
 		if err != nil {
@@ -373,7 +377,7 @@ func (s *Strategy) linkProvider(w http.ResponseWriter, r *http.Request, ctxUpdat
 }
 
 func (s *Strategy) unlinkProvider(w http.ResponseWriter, r *http.Request, ctxUpdate *settings.UpdateContext, p *submitSelfServiceBrowserSettingsOIDCFlowPayload) error {
-	if ctxUpdate.Session.AuthenticatedAt.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()).Before(time.Now()) {
+	if ctxUpdate.AuthenticatedAt().Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(s.SettingsStrategyID())).Before(s.d.Clock().Now()) {
 		return s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
 	}
 
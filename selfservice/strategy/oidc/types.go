@@ -3,6 +3,7 @@ package oidc
 import (
 	"bytes"
 	"encoding/json"
+	"time"
 
 	"github.com/ory/kratos/text"
 	"github.com/ory/x/stringsx"
@@ -23,10 +24,10 @@ type CredentialsConfig struct {
 	Providers []ProviderCredentialsConfig `json:"providers"`
 }
 
-func NewCredentials(provider, subject string) (*identity.Credentials, error) {
+func NewCredentials(c ProviderCredentialsConfig) (*identity.Credentials, error) {
 	var b bytes.Buffer
 	if err := json.NewEncoder(&b).Encode(CredentialsConfig{
-		Providers: []ProviderCredentialsConfig{{Subject: subject, Provider: provider}},
+		Providers: []ProviderCredentialsConfig{c},
 	}); err != nil {
 		return nil, errors.WithStack(x.PseudoPanic.
 			WithDebugf("Unable to encode password options to JSON: %s", err))
@@ -34,7 +35,7 @@ func NewCredentials(provider, subject string) (*identity.Credentials, error) {
 
 	return &identity.Credentials{
 		Type:        identity.CredentialsTypeOIDC,
-		Identifiers: []string{uid(provider, subject)},
+		Identifiers: []string{uid(c.Provider, c.Subject)},
 		Config:      b.Bytes(),
 	}, nil
 }
@@ -42,6 +43,13 @@ func NewCredentials(provider, subject string) (*identity.Credentials, error) {
 type ProviderCredentialsConfig struct {
 	Subject  string `json:"subject"`
 	Provider string `json:"provider"`
+
+	// Claims is the encrypted snapshot of the claims the provider returned when the identity last signed in,
+	// signed up, or linked its account. The admin API returns them decrypted if the oidc credentials are requested.
+	Claims string `json:"claims,omitempty"`
+
+	// ClaimsUpdatedAt is the time at which the claims snapshot was taken.
+	ClaimsUpdatedAt *time.Time `json:"claims_updated_at,omitempty"`
 }
 
 type FlowMethod struct {
@@ -2,10 +2,12 @@ package password
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 
 	"github.com/ory/herodot"
@@ -18,6 +20,7 @@ import (
 	"github.com/ory/kratos/ui/node"
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/decoderx"
+	"github.com/ory/x/sqlcon"
 )
 
 func (s *Strategy) RegisterLoginRoutes(r *x.RouterPublic) {
@@ -65,25 +68,143 @@ func (s *Strategy) Login(w http.ResponseWriter, r *http.Request, f *login.Flow)
 		return nil, s.handleLoginError(w, r, f, &p, err)
 	}
 
-	i, c, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), s.ID(), p.Identifier)
+	i, c, o, err := s.authenticate(r.Context(), p.Identifier, p.Password)
 	if err != nil {
-		time.Sleep(x.RandomDelay(s.d.Config(r.Context()).HasherArgon2().ExpectedDuration, s.d.Config(r.Context()).HasherArgon2().ExpectedDeviation))
-		return nil, s.handleLoginError(w, r, f, &p, errors.WithStack(schema.NewInvalidCredentialsError()))
+		return nil, s.handleLoginError(w, r, f, &p, err)
+	}
+
+	if hash.PepperOutdated(r.Context(), s.d, []byte(o.HashedPassword)) {
+		s.rehashPassword(r, i.ID, []byte(p.Password))
+	}
+
+	// Temporary passwords set by an administrator work exactly once.
+	if o.MustChange {
+		if o.Consumed {
+			return nil, s.handleLoginError(w, r, f, &p, errors.WithStack(schema.NewInvalidCredentialsError()))
+		}
+
+		if err := s.consumeTemporaryPassword(r.Context(), i.ID); err != nil {
+			return nil, s.handleLoginError(w, r, f, &p, err)
+		}
+	}
+
+	// The login hooks need the password credentials to check whether the password expired. They are
+	// removed again when the session is declassified.
+	i.SetCredentials(s.ID(), *c)
+	return i, nil
+}
+
+// authenticate returns the identity with the given identifier, its password credentials, and their configuration if the
+// password matches.
+func (s *Strategy) authenticate(ctx context.Context, identifier, password string) (*identity.Identity, *identity.Credentials, *CredentialsConfig, error) {
+	i, c, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, s.ID(), identifier)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		time.Sleep(x.RandomDelay(s.d.Config(ctx).HasherArgon2().ExpectedDuration, s.d.Config(ctx).HasherArgon2().ExpectedDeviation))
+		return nil, nil, nil, errors.WithStack(schema.NewInvalidCredentialsError())
+	} else if err != nil {
+		return nil, nil, nil, err
 	}
 
 	var o CredentialsConfig
 	d := json.NewDecoder(bytes.NewBuffer(c.Config))
 	if err := d.Decode(&o); err != nil {
-		return nil, herodot.ErrInternalServerError.WithReason("The password credentials could not be decoded properly").WithDebug(err.Error()).WithWrap(err)
+		return nil, nil, nil, herodot.ErrInternalServerError.WithReason("The password credentials could not be decoded properly").WithDebug(err.Error()).WithWrap(err)
+	}
+
+	if err := s.d.Hashers().Compare(ctx, []byte(password), []byte(o.HashedPassword)); err != nil {
+		return nil, nil, nil, errors.WithStack(schema.NewInvalidCredentialsError())
 	}
 
-	if err := hash.Compare(r.Context(), []byte(p.Password), []byte(o.HashedPassword)); err != nil {
-		return nil, s.handleLoginError(w, r, f, &p, errors.WithStack(schema.NewInvalidCredentialsError()))
+	return i, c, &o, nil
+}
+
+// SimulateLogin checks the password like Login does but neither re-hashes the password nor consumes temporary
+// passwords.
+func (s *Strategy) SimulateLogin(ctx context.Context, payload json.RawMessage) (*identity.Identity, error) {
+	r, err := flow.NewSimulationRequest(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var p submitSelfServiceLoginFlowWithPasswordMethod
+	if err := s.hd.Decode(r, &p,
+		decoderx.HTTPDecoderSetValidatePayloads(true),
+		decoderx.MustHTTPRawJSONSchemaCompiler(loginSchema),
+		decoderx.HTTPDecoderJSONFollowsFormFormat()); err != nil {
+		return nil, err
+	}
+
+	i, _, o, err := s.authenticate(ctx, p.Identifier, p.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.MustChange && o.Consumed {
+		return nil, errors.WithStack(schema.NewInvalidCredentialsError())
 	}
 
 	return i, nil
 }
 
+func (s *Strategy) consumeTemporaryPassword(ctx context.Context, id uuid.UUID) error {
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := i.ConsumeTemporaryPassword(); err != nil {
+		return errors.WithStack(schema.NewInvalidCredentialsError())
+	}
+
+	// Concurrent logins with the same temporary password all read it as unused, but only one of them
+	// is able to store it as used.
+	if err := s.d.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits, identity.ManagerRejectConcurrentUpdates); errors.Is(err, sqlcon.ErrConcurrentUpdate) {
+		return errors.WithStack(schema.NewInvalidCredentialsError())
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// rehashPassword replaces the password hash of the identity with one using the current pepper. Errors are only logged
+// because the old hash keeps working as long as its pepper is configured.
+func (s *Strategy) rehashPassword(r *http.Request, id uuid.UUID, password []byte) {
+	if err := s.storePasswordHash(r.Context(), id, password); err != nil {
+		s.d.Logger().
+			WithRequest(r).
+			WithError(err).
+			WithField("identity_id", id).
+			Warn("Unable to re-hash the password using the current pepper.")
+	}
+}
+
+func (s *Strategy) storePasswordHash(ctx context.Context, id uuid.UUID, password []byte) error {
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var conf CredentialsConfig
+	creds, err := i.ParseCredentials(s.ID(), &conf)
+	if err != nil {
+		return err
+	}
+
+	hpw, err := s.d.Hasher().Generate(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	conf.HashedPassword = string(hpw)
+	if creds.Config, err = json.Marshal(conf); err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.SetCredentials(s.ID(), *creds)
+	return s.d.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits)
+}
+
 func (s *Strategy) PopulateLoginMethod(r *http.Request, sr *login.Flow) error {
 	// This block adds the identifier to the method when the request is forced - as a hint for the user.
 	var identifier string
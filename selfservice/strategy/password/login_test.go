@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
@@ -25,11 +26,14 @@ import (
 	"github.com/tidwall/gjson"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence/fault"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
@@ -250,6 +254,24 @@ func TestCompleteLogin(t *testing.T) {
 		})
 	})
 
+	t.Run("should return a server error because the identifier lookup fails", func(t *testing.T) {
+		inj := fault.NewInjector()
+		reg.WithFaultInjector(inj)
+		t.Cleanup(inj.Reset)
+
+		t.Run("type=api", func(t *testing.T) {
+			f := testhelpers.InitializeLoginFlowViaAPI(t, apiClient, publicTS, false)
+			values := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+			values.Set("password_identifier", "identifier")
+			values.Set("password", "password")
+
+			inj.Add(fault.Fault{Method: "FindByCredentialsIdentifier", Err: fault.ErrUnavailable, Times: 1})
+			body, _ := testhelpers.LoginMakeRequest(t, true, f, apiClient, testhelpers.EncodeFormAsJSON(t, true, values))
+			assert.EqualValues(t, http.StatusInternalServerError, gjson.Get(body, "error.code").Int(), "%s", body)
+			assert.Empty(t, gjson.Get(body, "ui.messages").Array(), "%s", body)
+		})
+	})
+
 	t.Run("should return an error because no identifier is set", func(t *testing.T) {
 		var check = func(t *testing.T, body string) {
 			assert.NotEmpty(t, gjson.Get(body, "id").String(), "%s", body)
@@ -522,6 +544,123 @@ func TestCompleteLogin(t *testing.T) {
 		assert.NotEqual(t, gjson.Get(body1, "id").String(), gjson.Get(body2, "id").String(), "%s\n\n%s\n", body1, body2)
 	})
 
+	t.Run("should issue a restricted session if the password expired", func(t *testing.T) {
+		identifier, pwd := x.NewUUID().String(), "password"
+		p, _ := reg.Hasher().Generate(context.Background(), []byte(pwd))
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &identity.Identity{
+			ID:     x.NewUUID(),
+			Traits: identity.Traits(fmt.Sprintf(`{"subject":"%s"}`, identifier)),
+			Credentials: map[identity.CredentialsType]identity.Credentials{
+				identity.CredentialsTypePassword: {
+					Type:        identity.CredentialsTypePassword,
+					Identifiers: []string{identifier},
+					Config:      sqlxx.JSONRawMessage(`{"hashed_password":"` + string(p) + `","expired":true}`),
+				},
+			},
+		}))
+
+		body := testhelpers.SubmitLoginForm(t, true, nil, publicTS, func(v url.Values) {
+			v.Set("password_identifier", identifier)
+			v.Set("password", pwd)
+		}, identity.CredentialsTypePassword, false, http.StatusOK, publicTS.URL+login.RouteSubmitFlow)
+
+		assert.Equal(t, `["`+session.ScopePasswordReset+`"]`, gjson.Get(body, "session.scopes").Raw, "%s", body)
+		assert.False(t, gjson.Get(body, "session.identity.credentials").Exists(), "%s", body)
+
+		req, err := http.NewRequest("GET", publicTS.URL+session.RouteWhoami, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+gjson.Get(body, "session_token").String())
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("should accept a temporary password exactly once", func(t *testing.T) {
+		identifier := x.NewUUID().String()
+		createIdentity(identifier, "password")
+		i, _, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(context.Background(), identity.CredentialsTypePassword, identifier)
+		require.NoError(t, err)
+		require.NoError(t, reg.IdentityManager().SetTemporaryPassword(context.Background(), i.ID, "temporary-password"))
+
+		values := func(v url.Values) {
+			v.Set("password_identifier", identifier)
+			v.Set("password", "temporary-password")
+		}
+
+		body := testhelpers.SubmitLoginForm(t, true, nil, publicTS, values,
+			identity.CredentialsTypePassword, false, http.StatusOK, publicTS.URL+login.RouteSubmitFlow)
+		assert.Equal(t, `["`+session.ScopePasswordReset+`"]`, gjson.Get(body, "session.scopes").Raw, "%s", body)
+
+		body = testhelpers.SubmitLoginForm(t, true, nil, publicTS, values,
+			identity.CredentialsTypePassword, false, http.StatusBadRequest, publicTS.URL+login.RouteSubmitFlow)
+		assert.Equal(t, text.NewErrorValidationInvalidCredentials().Text, gjson.Get(body, "ui.messages.0.text").String(), "%s", body)
+	})
+
+	t.Run("should accept a temporary password only once when used concurrently", func(t *testing.T) {
+		identifier := x.NewUUID().String()
+		createIdentity(identifier, "password")
+		i, _, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(context.Background(), identity.CredentialsTypePassword, identifier)
+		require.NoError(t, err)
+		require.NoError(t, reg.IdentityManager().SetTemporaryPassword(context.Background(), i.ID, "temporary-password"))
+
+		values := func(v url.Values) {
+			v.Set("password_identifier", identifier)
+			v.Set("password", "temporary-password")
+		}
+
+		// The second login starts after the first one read the temporary password as unused, but before it
+		// stored it as used.
+		var started bool
+		var concurrent string
+		reg.WithIdentityManagerMiddleware(identity.ManagerMiddleware{
+			Update: func(next identity.ManagerUpdateFunc) identity.ManagerUpdateFunc {
+				return func(ctx context.Context, updated *identity.Identity) error {
+					if updated.ID == i.ID && !started {
+						started = true
+						concurrent = testhelpers.SubmitLoginForm(t, true, nil, publicTS, values,
+							identity.CredentialsTypePassword, false, http.StatusOK, publicTS.URL+login.RouteSubmitFlow)
+					}
+					return next(ctx, updated)
+				}
+			},
+		})
+
+		body := testhelpers.SubmitLoginForm(t, true, nil, publicTS, values,
+			identity.CredentialsTypePassword, false, http.StatusBadRequest, publicTS.URL+login.RouteSubmitFlow)
+		assert.Equal(t, text.NewErrorValidationInvalidCredentials().Text, gjson.Get(body, "ui.messages.0.text").String(), "%s", body)
+		assert.Equal(t, `["`+session.ScopePasswordReset+`"]`, gjson.Get(concurrent, "session.scopes").Raw, "%s", concurrent)
+	})
+
+	t.Run("should re-hash the password after the pepper was rotated", func(t *testing.T) {
+		identifier := x.NewUUID().String()
+		createIdentity(identifier, "password")
+
+		conf.MustSet(config.ViperKeySecretsPepper, []string{"a-freshly-rotated-pepper"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySecretsPepper, []string{})
+		})
+
+		values := func(v url.Values) {
+			v.Set("password_identifier", identifier)
+			v.Set("password", "password")
+		}
+
+		hashedPassword := func(t *testing.T) string {
+			_, c, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(context.Background(), identity.CredentialsTypePassword, identifier)
+			require.NoError(t, err)
+			return gjson.GetBytes(c.Config, "hashed_password").String()
+		}
+
+		require.True(t, strings.HasPrefix(hashedPassword(t), "$argon2id$"))
+		testhelpers.SubmitLoginForm(t, true, nil, publicTS, values,
+			identity.CredentialsTypePassword, false, http.StatusOK, publicTS.URL+login.RouteSubmitFlow)
+		assert.True(t, strings.HasPrefix(hashedPassword(t), "$pepper$"+hash.PepperID([]byte("a-freshly-rotated-pepper"))+"$"))
+
+		testhelpers.SubmitLoginForm(t, true, nil, publicTS, values,
+			identity.CredentialsTypePassword, false, http.StatusOK, publicTS.URL+login.RouteSubmitFlow)
+	})
+
 	t.Run("should login same identity regardless of identifier capitalization", func(t *testing.T) {
 		identifier, pwd := x.NewUUID().String(), "password"
 		createIdentity(identifier, pwd)
@@ -540,3 +679,61 @@ func TestCompleteLogin(t *testing.T) {
 		assert.Equal(t, identifier, gjson.Get(body2, "identity.traits.subject").String(), "%s", body2)
 	})
 }
+
+func BenchmarkCompleteLogin(b *testing.B) {
+	conf, reg := internal.NewFastRegistryWithMocks(b)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypePassword),
+		map[string]interface{}{"enabled": true})
+	conf.MustSet(config.ViperKeyPublicBaseURL, "http://example.com")
+	conf.MustSet(config.ViperKeySelfServiceLoginUI, "http://example.com/login")
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/login.schema.json")
+	conf.MustSet(config.ViperKeySecretsDefault, []string{"not-a-secure-session-key"})
+
+	rp := x.NewRouterPublic()
+	reg.RegisterPublicRoutes(context.Background(), rp)
+
+	const password = "BenchmarkPassword123!"
+	hashed, err := reg.Hasher().Generate(context.Background(), []byte(password))
+	require.NoError(b, err)
+
+	identifiers := make([]string, 2000)
+	for k := range identifiers {
+		identifiers[k] = fmt.Sprintf("benchmark-%d@ory.sh", k)
+		require.NoError(b, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &identity.Identity{
+			ID:     x.NewUUID(),
+			Traits: identity.Traits(fmt.Sprintf(`{"subject":"%s"}`, identifiers[k])),
+			Credentials: map[identity.CredentialsType]identity.Credentials{
+				identity.CredentialsTypePassword: {
+					Type:        identity.CredentialsTypePassword,
+					Identifiers: []string{identifiers[k]},
+					Config:      sqlxx.JSONRawMessage(`{"hashed_password":"` + string(hashed) + `"}`),
+				},
+			},
+		}))
+	}
+
+	serve := func(req *http.Request) *bytes.Buffer {
+		w := httptest.NewRecorder()
+		rp.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status code %d for %s: %s", w.Code, req.URL, w.Body.String())
+		}
+		return w.Body
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		f := serve(httptest.NewRequest("GET", login.RouteInitAPIFlow, nil))
+		body, _ := json.Marshal(map[string]string{
+			"method":              "password",
+			"password_identifier": identifiers[k%len(identifiers)],
+			"password":            password,
+		})
+
+		req := httptest.NewRequest("POST", login.RouteSubmitFlow+"?flow="+gjson.Get(f.String(), "id").String(), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		serve(req)
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/decoderx"
 	"github.com/ory/x/errorsx"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
 )
 
 // RegistrationFormPayload is used to decode the registration form payload.
@@ -31,7 +33,9 @@ type RegistrationFormPayload struct {
 	CSRFToken string          `json:"csrf_token"`
 }
 
-func (s *Strategy) RegisterRegistrationRoutes(_ *x.RouterPublic) {
+func (s *Strategy) RegisterRegistrationRoutes(r *x.RouterPublic) {
+	s.d.CSRFHandler().IgnorePath(RouteStrength)
+	r.POST(RouteStrength, s.estimateStrength)
 }
 
 func (s *Strategy) handleRegistrationError(_ http.ResponseWriter, r *http.Request, f *registration.Flow, p *RegistrationFormPayload, err error) error {
@@ -52,8 +56,12 @@ func (s *Strategy) handleRegistrationError(_ http.ResponseWriter, r *http.Reques
 }
 
 func (s *Strategy) decode(p *RegistrationFormPayload, r *http.Request) error {
+	return s.decodeWithSchema(p, r, s.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String())
+}
+
+func (s *Strategy) decodeWithSchema(p *RegistrationFormPayload, r *http.Request, schemaURL string) error {
 	raw, err := sjson.SetBytes(registrationSchema,
-		"properties.traits.$ref", s.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String()+"#/properties/traits")
+		"properties.traits.$ref", schemaURL+"#/properties/traits")
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -112,6 +120,50 @@ func (s *Strategy) Register(w http.ResponseWriter, r *http.Request, f *registrat
 	return nil
 }
 
+// SimulateRegistration validates the payload like Register does and additionally checks that no other identity uses
+// the same identifiers, which would otherwise only be detected when the identity is persisted.
+func (s *Strategy) SimulateRegistration(ctx context.Context, i *identity.Identity, payload json.RawMessage) error {
+	sc, err := s.d.Config(ctx).IdentityTraitsSchemas().FindSchemaByID(i.SchemaID)
+	if err != nil {
+		return err
+	}
+
+	r, err := flow.NewSimulationRequest(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	var p RegistrationFormPayload
+	if err := s.decodeWithSchema(&p, r, sc.URL); err != nil {
+		return err
+	}
+
+	if len(p.Password) == 0 {
+		return schema.NewRequiredError("#/password", "password")
+	}
+
+	if len(p.Traits) == 0 {
+		p.Traits = json.RawMessage("{}")
+	}
+
+	i.Traits = identity.Traits(p.Traits)
+	i.SetCredentials(s.ID(), identity.Credentials{Type: s.ID(), Identifiers: []string{}, Config: sqlxx.JSONRawMessage("{}")})
+	if err := s.validateCredentials(ctx, i, p.Password); err != nil {
+		return err
+	}
+
+	c, _ := i.GetCredentials(s.ID())
+	for _, id := range c.Identifiers {
+		if _, _, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, s.ID(), id); err == nil {
+			return errors.WithStack(schema.NewDuplicateCredentialsError())
+		} else if !errors.Is(err, sqlcon.ErrNoRows) {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *Strategy) validateCredentials(ctx context.Context, i *identity.Identity, pw string) error {
 	if err := s.d.IdentityValidator().Validate(ctx, i); err != nil {
 		return err
@@ -485,6 +485,51 @@ func TestRegistration(t *testing.T) {
 			})
 		})
 
+		t.Run("case=should return an error because the email domain is not allowed", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/email.schema.json")
+			conf.MustSet(config.ViperKeyIdentityEmailDomains+".deny_disposable", true)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/registration.schema.json")
+				conf.MustSet(config.ViperKeyIdentityEmailDomains, nil)
+			})
+
+			values := func(v url.Values) {
+				v.Set("traits.email", "registration-email-domain@mailinator.com")
+				v.Set("password", x.NewUUID().String())
+			}
+
+			for _, isAPI := range []bool{true, false} {
+				t.Run(fmt.Sprintf("api=%t", isAPI), func(t *testing.T) {
+					body := expectValidationError(t, isAPI, values)
+					assert.EqualValues(t, text.ErrorValidationEmailDomainRejected, gjson.Get(body, "ui.nodes.#(attributes.name==traits.email).messages.0.id").Int(), "%s", body)
+					assert.Contains(t, gjson.Get(body, "ui.nodes.#(attributes.name==traits.email).messages.0.text").String(), "disposable email provider", "%s", body)
+					assert.Equal(t, "registration-email-domain@mailinator.com", gjson.Get(body, "ui.nodes.#(attributes.name==traits.email).attributes.value").String(), "%s", body)
+				})
+			}
+		})
+
+		t.Run("case=should return an error because the username is reserved", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/registration.schema.json")
+			conf.MustSet(config.ViperKeyIdentityReservedNamesWords, []string{"admin", "support"})
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyIdentityReservedNamesWords, nil)
+			})
+
+			values := func(v url.Values) {
+				v.Set("traits.username", "Sup.Port")
+				v.Set("password", x.NewUUID().String())
+				v.Set("traits.foobar", "bar")
+			}
+
+			for _, isAPI := range []bool{true, false} {
+				t.Run(fmt.Sprintf("api=%t", isAPI), func(t *testing.T) {
+					body := expectValidationError(t, isAPI, values)
+					assert.EqualValues(t, text.ErrorValidationNameReserved, gjson.Get(body, "ui.nodes.#(attributes.name==traits.username).messages.0.id").Int(), "%s", body)
+					assert.Equal(t, "Sup.Port", gjson.Get(body, "ui.nodes.#(attributes.name==traits.username).attributes.value").String(), "%s", body)
+				})
+			}
+		})
+
 		t.Run("case=should return an error because not passing validation and reset previous errors and values", func(t *testing.T) {
 			conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/registration.schema.json")
 
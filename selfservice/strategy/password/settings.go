@@ -3,7 +3,6 @@ package password
 import (
 	"encoding/json"
 	"net/http"
-	"time"
 
 	"github.com/ory/kratos/text"
 
@@ -126,7 +125,7 @@ func (s *Strategy) continueSettingsFlow(
 		return err
 	}
 
-	if ctxUpdate.Session.AuthenticatedAt.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()).Before(time.Now()) {
+	if ctxUpdate.AuthenticatedAt().Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(s.SettingsStrategyID())).Before(s.d.Clock().Now()) {
 		return errors.WithStack(settings.NewFlowNeedsReAuth())
 	}
 
@@ -29,6 +29,7 @@ var _ identity.ActiveCredentialsCounter = new(Strategy)
 type registrationStrategyDependencies interface {
 	x.LoggingProvider
 	x.WriterProvider
+	x.ClockProvider
 	x.CSRFTokenGeneratorProvider
 	x.CSRFProvider
 
@@ -38,7 +39,9 @@ type registrationStrategyDependencies interface {
 
 	errorx.ManagementProvider
 	ValidationProvider
+	StrengthEstimationProvider
 	hash.HashProvider
+	hash.RegistryProvider
 
 	registration.HandlerProvider
 	registration.HooksProvider
@@ -58,6 +61,7 @@ type registrationStrategyDependencies interface {
 	settings.ErrorHandlerProvider
 
 	identity.PrivilegedPoolProvider
+	identity.ManagementProvider
 	identity.ValidationProvider
 
 	session.HandlerProvider
@@ -87,7 +91,7 @@ func (s *Strategy) CountActiveCredentials(cc map[identity.CredentialsType]identi
 			}
 
 			if len(c.Identifiers) > 0 && len(c.Identifiers[0]) > 0 &&
-				(hash.IsBcryptHash([]byte(conf.HashedPassword)) || hash.IsArgon2idHash([]byte(conf.HashedPassword))) {
+				s.d.Hashers().Understands([]byte(conf.HashedPassword)) {
 				count++
 			}
 		}
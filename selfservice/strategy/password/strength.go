@@ -0,0 +1,209 @@
+package password
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"unicode"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+
+	"github.com/ory/kratos/text"
+)
+
+const RouteStrength = "/self-service/password/strength"
+
+var _ StrengthEstimator = new(DefaultStrengthEstimator)
+
+// Strength rates how hard a password is to guess.
+//
+// swagger:model passwordStrength
+type Strength struct {
+	// Score rates the password from 0 (too guessable) to 4 (very unguessable).
+	//
+	// required: true
+	Score int `json:"score"`
+
+	// Feedback contains suggestions on how to improve the password.
+	//
+	// required: true
+	Feedback text.Messages `json:"feedback"`
+}
+
+// DefaultStrengthEstimator implements StrengthEstimator. It rates passwords by the entropy of their characters,
+// discounting repeated characters and sequences, using the same 0 to 4 scale as zxcvbn. Whether a password was
+// leaked is checked by the Validator instead.
+type DefaultStrengthEstimator struct {
+	minIdentifierPasswordDist            int
+	maxIdentifierPasswordSubstrThreshold float32
+}
+
+func NewDefaultStrengthEstimator() *DefaultStrengthEstimator {
+	return &DefaultStrengthEstimator{minIdentifierPasswordDist: 5, maxIdentifierPasswordSubstrThreshold: 0.5}
+}
+
+func (e *DefaultStrengthEstimator) Estimate(_ context.Context, identifier, password string) (*Strength, error) {
+	s := &Strength{Feedback: text.Messages{}}
+
+	runes := []rune(password)
+	if len(runes) < minPasswordLength {
+		s.Feedback.Add(text.NewInfoPasswordStrengthTooShort(minPasswordLength))
+		return s, nil
+	}
+
+	if len(identifier) > 0 && similarToIdentifier(identifier, password, e.minIdentifierPasswordDist, e.maxIdentifierPasswordSubstrThreshold) {
+		s.Feedback.Add(text.NewInfoPasswordStrengthSimilarToIdentifier())
+		return s, nil
+	}
+
+	var lower, upper, digit, symbol, other bool
+	var effective float64
+	var repeatRun, sequenceRun int
+	var repeated, sequence bool
+	for i, c := range runes {
+		switch {
+		case unicode.IsLower(c) && c < unicode.MaxASCII:
+			lower = true
+		case unicode.IsUpper(c) && c < unicode.MaxASCII:
+			upper = true
+		case unicode.IsDigit(c) && c < unicode.MaxASCII:
+			digit = true
+		case c < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+
+		switch {
+		case i > 0 && c == runes[i-1]:
+			// Repeated characters add little to the number of guesses.
+			effective += 0.25
+			repeatRun++
+			sequenceRun = 0
+		case i > 0 && (c-runes[i-1] == 1 || c-runes[i-1] == -1):
+			// So do ascending or descending sequences.
+			effective += 0.25
+			sequenceRun++
+			repeatRun = 0
+		default:
+			effective++
+			repeatRun, sequenceRun = 0, 0
+		}
+
+		repeated = repeated || repeatRun >= 2
+		sequence = sequence || sequenceRun >= 2
+	}
+
+	var pool, kinds float64
+	for _, k := range []struct {
+		present bool
+		size    float64
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if k.present {
+			pool += k.size
+			kinds++
+		}
+	}
+
+	bits := effective * math.Log2(pool)
+	switch {
+	case bits < 30:
+		s.Score = 0
+	case bits < 45:
+		s.Score = 1
+	case bits < 60:
+		s.Score = 2
+	case bits < 80:
+		s.Score = 3
+	default:
+		s.Score = 4
+	}
+
+	if repeated {
+		s.Feedback.Add(text.NewInfoPasswordStrengthRepeatedCharacters())
+	}
+	if sequence {
+		s.Feedback.Add(text.NewInfoPasswordStrengthSequence())
+	}
+	if s.Score < 4 && kinds < 3 {
+		s.Feedback.Add(text.NewInfoPasswordStrengthAddCharacterKinds())
+	}
+	if s.Score < 3 {
+		s.Feedback.Add(text.NewInfoPasswordStrengthAddLength())
+	}
+
+	return s, nil
+}
+
+// swagger:parameters estimatePasswordStrength
+// nolint:deadcode,unused
+type estimatePasswordStrengthParameters struct {
+	// in: body
+	Body EstimateStrength
+}
+
+type EstimateStrength struct {
+	// Password is the password to rate.
+	//
+	// required: true
+	Password string `json:"password"`
+
+	// Identifier is the identifier the password is for, for example the email address or username. It is used
+	// to detect passwords which are similar to it.
+	Identifier string `json:"identifier"`
+}
+
+// The strength of a password.
+//
+// swagger:response passwordStrength
+// nolint:deadcode,unused
+type passwordStrengthResponse struct {
+	// in: body
+	Body Strength
+}
+
+// swagger:route POST /self-service/password/strength public estimatePasswordStrength
+//
+// Estimate the Strength of a Password
+//
+// This endpoint rates how hard a password is to guess and suggests how to improve it, for example to show a
+// strength meter while the password is typed in registration and settings forms. It does not check whether the
+// password was leaked or store the password.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: passwordStrength
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (s *Strategy) estimateStrength(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !s.d.Config(r.Context()).SelfServiceStrategy(s.ID().String()).Enabled {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReason("The password method is disabled.")))
+		return
+	}
+
+	var body EstimateStrength
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&body); err != nil {
+		s.d.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	strength, err := s.d.PasswordStrengthEstimator().Estimate(r.Context(), body.Identifier, body.Password)
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	s.d.Writer().Write(w, r, strength)
+}
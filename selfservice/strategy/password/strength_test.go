@@ -0,0 +1,107 @@
+package password_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/strategy/password"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/x"
+)
+
+type fixedStrengthEstimator struct{}
+
+func (fixedStrengthEstimator) Estimate(_ context.Context, _, _ string) (*password.Strength, error) {
+	return &password.Strength{Score: 2, Feedback: text.Messages{}}, nil
+}
+
+func TestDefaultStrengthEstimator(t *testing.T) {
+	e := password.NewDefaultStrengthEstimator()
+
+	for k, tc := range []struct {
+		id       string
+		pw       string
+		score    int
+		feedback []text.ID
+	}{
+		{pw: "", score: 0, feedback: []text.ID{text.InfoPasswordStrengthTooShort}},
+		{pw: "12345", score: 0, feedback: []text.ID{text.InfoPasswordStrengthTooShort}},
+		{pw: "123456789", score: 0, feedback: []text.ID{text.InfoPasswordStrengthSequence, text.InfoPasswordStrengthAddCharacterKinds, text.InfoPasswordStrengthAddLength}},
+		{pw: "aaaaaaaaaaaa", score: 0, feedback: []text.ID{text.InfoPasswordStrengthRepeatedCharacters, text.InfoPasswordStrengthAddCharacterKinds, text.InfoPasswordStrengthAddLength}},
+		{pw: "hello@example.com1", id: "hello@example.com", score: 0, feedback: []text.ID{text.InfoPasswordStrengthSimilarToIdentifier}},
+		{pw: "kjoklaqz", score: 1, feedback: []text.ID{text.InfoPasswordStrengthAddCharacterKinds, text.InfoPasswordStrengthAddLength}},
+		{pw: "l3f9toh1uaf", score: 2, feedback: []text.ID{text.InfoPasswordStrengthAddCharacterKinds, text.InfoPasswordStrengthAddLength}},
+		{pw: "l3F9t!h1uaf", score: 3, feedback: []text.ID{}},
+		{pw: "correct horse battery staple", id: "hello@example.com", score: 4, feedback: []text.ID{}},
+	} {
+		t.Run("case="+tc.pw, func(t *testing.T) {
+			s, err := e.Estimate(context.Background(), tc.id, tc.pw)
+			require.NoError(t, err)
+			assert.Equal(t, tc.score, s.Score, "%d", k)
+
+			ids := []text.ID{}
+			for _, m := range s.Feedback {
+				ids = append(ids, m.ID)
+			}
+			assert.Equal(t, tc.feedback, ids, "%d", k)
+		})
+	}
+}
+
+func TestStrengthEndpoint(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypePassword)+".enabled", true)
+	publicTS, _ := testhelpers.NewKratosServerWithRouters(t, reg, x.NewRouterPublic(), x.NewRouterAdmin())
+
+	var estimate = func(t *testing.T, body string, expectCode int) gjson.Result {
+		res, err := publicTS.Client().Post(publicTS.URL+password.RouteStrength, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		actual := x.MustReadAll(res.Body)
+		require.EqualValues(t, expectCode, res.StatusCode, "%s", actual)
+		return gjson.ParseBytes(actual)
+	}
+
+	t.Run("case=should rate the password", func(t *testing.T) {
+		res := estimate(t, `{"password":"12345"}`, http.StatusOK)
+		assert.EqualValues(t, 0, res.Get("score").Int(), "%s", res.Raw)
+		assert.EqualValues(t, text.InfoPasswordStrengthTooShort, res.Get("feedback.0.id").Int(), "%s", res.Raw)
+
+		res = estimate(t, `{"password":"correct horse battery staple","identifier":"hello@example.com"}`, http.StatusOK)
+		assert.EqualValues(t, 4, res.Get("score").Int(), "%s", res.Raw)
+		assert.Empty(t, res.Get("feedback").Array(), "%s", res.Raw)
+	})
+
+	t.Run("case=should reject unknown fields", func(t *testing.T) {
+		estimate(t, `{"password":"12345","foo":"bar"}`, http.StatusBadRequest)
+	})
+
+	t.Run("case=should use the configured estimator", func(t *testing.T) {
+		reg.WithPasswordStrengthEstimator(fixedStrengthEstimator{})
+		t.Cleanup(func() {
+			reg.WithPasswordStrengthEstimator(password.NewDefaultStrengthEstimator())
+		})
+
+		res := estimate(t, `{"password":"12345"}`, http.StatusOK)
+		assert.EqualValues(t, 2, res.Get("score").Int(), "%s", res.Raw)
+	})
+
+	t.Run("case=should not be found if the password method is disabled", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypePassword)+".enabled", false)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypePassword)+".enabled", true)
+		})
+
+		estimate(t, `{"password":"12345"}`, http.StatusNotFound)
+	})
+}
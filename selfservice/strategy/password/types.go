@@ -8,6 +8,15 @@ import (
 type CredentialsConfig struct {
 	// HashedPassword is a hash-representation of the password.
 	HashedPassword string `json:"hashed_password"`
+
+	// Expired is set when an administrator forced a password reset. See identity.Identity.ExpirePassword.
+	Expired bool `json:"expired,omitempty"`
+
+	// MustChange is set for temporary passwords. See identity.Identity.SetTemporaryPassword.
+	MustChange bool `json:"must_change,omitempty"`
+
+	// Consumed is set once a temporary password was used to sign in.
+	Consumed bool `json:"consumed,omitempty"`
 }
 
 // submitSelfServiceLoginFlowWithPasswordMethod is used to decode the login form payload.
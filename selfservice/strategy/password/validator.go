@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/go-retryablehttp"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/feature"
 
 	/* #nosec G505 sha1 is used for k-anonymity */
 	"crypto/sha1"
@@ -40,7 +41,23 @@ type ValidationProvider interface {
 	PasswordValidator() Validator
 }
 
+// StrengthEstimator rates how hard a password is to guess. Unlike Validator it does not reject passwords but
+// gives feedback on how to improve them, for example to show a strength meter while the password is typed.
+type StrengthEstimator interface {
+	// Estimate rates the password. The identifier is optional and, if set, is used to detect passwords which
+	// are similar to it.
+	Estimate(ctx context.Context, identifier, password string) (*Strength, error)
+}
+
+type StrengthEstimationProvider interface {
+	PasswordStrengthEstimator() StrengthEstimator
+}
+
 var _ Validator = new(DefaultPasswordValidator)
+
+// minPasswordLength is the length passwords must have at least.
+const minPasswordLength = 6
+
 var ErrNetworkFailure = errors.New("unable to check if password has been leaked because an unexpected network error occurred")
 var ErrUnexpectedStatusCode = errors.New("unexpected status code")
 
@@ -65,6 +82,7 @@ type DefaultPasswordValidator struct {
 
 type validatorDependencies interface {
 	config.Provider
+	feature.Provider
 }
 
 func NewDefaultPasswordValidatorStrategy(reg validatorDependencies) *DefaultPasswordValidator {
@@ -75,6 +93,15 @@ func NewDefaultPasswordValidatorStrategy(reg validatorDependencies) *DefaultPass
 		minIdentifierPasswordDist: 5, maxIdentifierPasswordSubstrThreshold: 0.5}
 }
 
+// similarToIdentifier returns true if the password is less than minDist edits away from the identifier or if
+// they share a substring longer than maxSubstr times the password's length.
+func similarToIdentifier(identifier, password string, minDist int, maxSubstr float32) bool {
+	compIdentifier, compPassword := strings.ToLower(identifier), strings.ToLower(password)
+	dist := levenshtein.Distance(compIdentifier, compPassword)
+	lcs := float32(lcsLength(compIdentifier, compPassword)) / float32(len(compPassword))
+	return dist < minDist || lcs > maxSubstr
+}
+
 func b20(src []byte) string {
 	return fmt.Sprintf("%X", src)
 }
@@ -145,17 +172,18 @@ func (s *DefaultPasswordValidator) fetch(hpw []byte) error {
 }
 
 func (s *DefaultPasswordValidator) Validate(ctx context.Context, identifier, password string) error {
-	if len(password) < 6 {
-		return errors.Errorf("password length must be at least 6 characters but only got %d", len(password))
+	if len(password) < minPasswordLength {
+		return errors.Errorf("password length must be at least %d characters but only got %d", minPasswordLength, len(password))
 	}
 
-	compIdentifier, compPassword := strings.ToLower(identifier), strings.ToLower(password)
-	dist := levenshtein.Distance(compIdentifier, compPassword)
-	lcs := float32(lcsLength(compIdentifier, compPassword)) / float32(len(compPassword))
-	if dist < s.minIdentifierPasswordDist || lcs > s.maxIdentifierPasswordSubstrThreshold {
+	if similarToIdentifier(identifier, password, s.minIdentifierPasswordDist, s.maxIdentifierPasswordSubstrThreshold) {
 		return errors.Errorf("the password is too similar to the user identifier")
 	}
 
+	if !s.reg.FeatureFlags().Enabled(ctx, feature.PasswordBreachCheck) {
+		return nil
+	}
+
 	/* #nosec G401 sha1 is used for k-anonymity */
 	h := sha1.New()
 	if _, err := h.Write([]byte(password)); err != nil {
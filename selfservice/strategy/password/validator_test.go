@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/feature"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/selfservice/strategy/password"
 )
@@ -108,6 +109,19 @@ func TestDefaultPasswordValidationStrategy(t *testing.T) {
 			fakeClient.RespondWith(http.StatusInternalServerError, "")
 			require.NoError(t, s.Validate(context.Background(), "", "jenuzuhjoj"))
 		})
+
+		t.Run("case=should not send request if the breach check feature flag is disabled", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyIgnoreNetworkErrors, false)
+			conf.MustSet(config.ViperKeyFeatureFlags, []map[string]interface{}{{"name": feature.PasswordBreachCheck, "enabled": false}})
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyFeatureFlags, []map[string]interface{}{})
+			})
+
+			fakeClient.RespondWithError("Network request failed")
+			requests := len(fakeClient.RequestedURLs())
+			require.NoError(t, s.Validate(context.Background(), "", "fuhzonbiwa"))
+			require.Len(t, fakeClient.RequestedURLs(), requests)
+		})
 	})
 
 	t.Run("max breaches", func(t *testing.T) {
@@ -0,0 +1,8 @@
+package preferences
+
+import (
+	_ "embed"
+)
+
+//go:embed .schema/settings.schema.json
+var settingsSchema []byte
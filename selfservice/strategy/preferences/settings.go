@@ -0,0 +1,211 @@
+package preferences
+
+import (
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+func (s *Strategy) RegisterSettingsRoutes(_ *x.RouterPublic) {
+}
+
+// nolint:deadcode,unused
+// swagger:parameters submitSelfServiceSettingsFlowWithPreferencesMethod
+type submitSelfServiceSettingsFlowWithPreferencesMethod struct {
+	// in: body
+	Body submitSelfServiceSettingsFlowWithPreferencesMethodBody
+
+	// Flow is flow ID.
+	//
+	// in: query
+	Flow string `json:"flow"`
+}
+
+// swagger:model submitSelfServiceSettingsFlowWithPreferencesMethod
+type submitSelfServiceSettingsFlowWithPreferencesMethodBody struct {
+	// Channel is the channel the identity prefers to receive messages on, either `email` or `sms`.
+	//
+	// type: string
+	Channel string `json:"channel"`
+
+	// Locale is the BCP 47 language tag of the language the identity prefers, for example `en-US`.
+	//
+	// type: string
+	Locale string `json:"locale"`
+
+	// QuietHours is the time of day during which messages are held back. Leave start and end empty to
+	// receive messages at any time of day.
+	QuietHours submitSelfServiceSettingsFlowWithPreferencesMethodQuietHours `json:"quiet_hours"`
+
+	// CSRFToken is the anti-CSRF token
+	//
+	// type: string
+	CSRFToken string `json:"csrf_token"`
+
+	// Method
+	//
+	// Should be set to preferences when trying to change the communication preferences.
+	//
+	// type: string
+	Method string `json:"method"`
+
+	// Flow is flow ID.
+	//
+	// swagger:ignore
+	Flow string `json:"flow"`
+}
+
+// swagger:model submitSelfServiceSettingsFlowWithPreferencesMethodQuietHours
+type submitSelfServiceSettingsFlowWithPreferencesMethodQuietHours struct {
+	// Start is the time of day at which the quiet hours start, for example `22:00`.
+	Start string `json:"start"`
+
+	// End is the time of day at which the quiet hours end, for example `07:00`.
+	End string `json:"end"`
+
+	// Timezone is the IANA time zone Start and End are given in, for example `Europe/Berlin`.
+	Timezone string `json:"timezone"`
+}
+
+func (p *submitSelfServiceSettingsFlowWithPreferencesMethodBody) GetFlowID() uuid.UUID {
+	return x.ParseUUID(p.Flow)
+}
+
+func (p *submitSelfServiceSettingsFlowWithPreferencesMethodBody) SetFlowID(rid uuid.UUID) {
+	p.Flow = rid.String()
+}
+
+func (s *Strategy) Settings(w http.ResponseWriter, r *http.Request, f *settings.Flow, ss *session.Session) (*settings.UpdateContext, error) {
+	var p submitSelfServiceSettingsFlowWithPreferencesMethodBody
+	ctxUpdate, err := settings.PrepareUpdate(s.d, w, r, f, ss, settings.ContinuityKey(s.SettingsStrategyID()), &p)
+	if errors.Is(err, settings.ErrContinuePreviousAction) {
+		return ctxUpdate, s.continueSettingsFlow(r, ctxUpdate, &p)
+	} else if err != nil {
+		return ctxUpdate, s.handleSettingsError(r, ctxUpdate, err)
+	}
+
+	if err := flow.MethodEnabledAndAllowedFromRequest(r, s.SettingsStrategyID(), s.d); err != nil {
+		return ctxUpdate, s.handleSettingsError(r, ctxUpdate, err)
+	}
+
+	if err := s.decodeSettingsFlow(r, &p); err != nil {
+		return ctxUpdate, s.handleSettingsError(r, ctxUpdate, err)
+	}
+
+	// This does not come from the payload!
+	p.Flow = ctxUpdate.Flow.ID.String()
+	if err := s.continueSettingsFlow(r, ctxUpdate, &p); err != nil {
+		return ctxUpdate, s.handleSettingsError(r, ctxUpdate, err)
+	}
+
+	return ctxUpdate, nil
+}
+
+func (s *Strategy) decodeSettingsFlow(r *http.Request, dest interface{}) error {
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(settingsSchema)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return s.hd.Decode(r, dest, compiler,
+		decoderx.HTTPDecoderSetValidatePayloads(true),
+		decoderx.HTTPDecoderJSONFollowsFormFormat(),
+	)
+}
+
+// continueSettingsFlow stores the preferences right away because they are not part of the identity. The
+// identity is only passed on so that the settings hooks run.
+func (s *Strategy) continueSettingsFlow(
+	r *http.Request,
+	ctxUpdate *settings.UpdateContext, p *submitSelfServiceSettingsFlowWithPreferencesMethodBody,
+) error {
+	ctx := r.Context()
+	c := s.d.Config(ctx)
+	if err := flow.MethodEnabledAndAllowed(ctx, s.SettingsStrategyID(), p.Method, s.d); err != nil {
+		return err
+	}
+
+	if err := flow.EnsureCSRF(r, ctxUpdate.Flow.Type, c.DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, p.CSRFToken); err != nil {
+		return err
+	}
+
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, ctxUpdate.Session.Identity.ID)
+	if err != nil {
+		return err
+	}
+
+	prefs := &courier.Preferences{
+		IdentityID: i.ID,
+		Channel:    courier.Channel(p.Channel),
+		Locale:     p.Locale,
+	}
+	if len(p.QuietHours.Start) > 0 || len(p.QuietHours.End) > 0 {
+		prefs.QuietHours = &courier.QuietHours{
+			Start:    p.QuietHours.Start,
+			End:      p.QuietHours.End,
+			Timezone: p.QuietHours.Timezone,
+		}
+	}
+
+	if err := prefs.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.d.CommunicationPreferencesPersister().UpsertCommunicationPreferences(ctx, prefs); err != nil {
+		return err
+	}
+
+	ctxUpdate.UpdateIdentity(i)
+	return nil
+}
+
+func (s *Strategy) PopulateSettingsMethod(r *http.Request, id *identity.Identity, f *settings.Flow) error {
+	prefs, err := s.d.CommunicationPreferencesPersister().GetCommunicationPreferences(r.Context(), id.ID)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		prefs = &courier.Preferences{IdentityID: id.ID}
+	} else if err != nil {
+		return err
+	}
+
+	quietHours := new(courier.QuietHours)
+	if prefs.QuietHours != nil {
+		quietHours = prefs.QuietHours
+	}
+
+	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	f.UI.Nodes.Upsert(node.NewInputField("channel", string(prefs.Channel), node.PreferencesGroup, node.InputAttributeTypeText).
+		WithMetaLabel(text.NewInfoNodeInputChannel()))
+	f.UI.Nodes.Upsert(node.NewInputField("locale", prefs.Locale, node.PreferencesGroup, node.InputAttributeTypeText).
+		WithMetaLabel(text.NewInfoNodeInputLocale()))
+	f.UI.Nodes.Upsert(node.NewInputField("quiet_hours.start", quietHours.Start, node.PreferencesGroup, node.InputAttributeTypeText).
+		WithMetaLabel(text.NewInfoNodeInputQuietHoursStart()))
+	f.UI.Nodes.Upsert(node.NewInputField("quiet_hours.end", quietHours.End, node.PreferencesGroup, node.InputAttributeTypeText).
+		WithMetaLabel(text.NewInfoNodeInputQuietHoursEnd()))
+	f.UI.Nodes.Upsert(node.NewInputField("quiet_hours.timezone", quietHours.Timezone, node.PreferencesGroup, node.InputAttributeTypeText).
+		WithMetaLabel(text.NewInfoNodeInputTimezone()))
+	f.UI.Nodes.Append(node.NewInputField("method", "preferences", node.PreferencesGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSave()))
+
+	return nil
+}
+
+func (s *Strategy) handleSettingsError(r *http.Request, ctxUpdate *settings.UpdateContext, err error) error {
+	if ctxUpdate.Flow != nil {
+		ctxUpdate.Flow.UI.ResetMessages()
+		ctxUpdate.Flow.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	}
+
+	return err
+}
@@ -0,0 +1,94 @@
+package preferences_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/x"
+)
+
+func TestSettings(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	testhelpers.StrategyEnable(t, conf, settings.StrategyPreferences, true)
+	testhelpers.StrategyEnable(t, conf, settings.StrategyProfile, false)
+
+	_ = testhelpers.NewSettingsUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+	require.NoError(t, reg.IdentityManager().Create(ctx, i))
+	hc := testhelpers.NewHTTPClientWithIdentitySessionToken(t, reg, i)
+
+	submit := func(t *testing.T, values url.Values, expectCode int) string {
+		return testhelpers.SubmitSettingsForm(t, true, hc, publicTS, func(v url.Values) {
+			v.Set("method", "preferences")
+			for k := range values {
+				v.Set(k, values.Get(k))
+			}
+		}, expectCode, publicTS.URL+settings.RouteSubmitFlow)
+	}
+
+	t.Run("case=shows empty preferences", func(t *testing.T) {
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+		values := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+		assert.Empty(t, values.Get("channel"))
+		assert.Contains(t, values, "quiet_hours.start")
+	})
+
+	t.Run("case=stores the preferences", func(t *testing.T) {
+		actual := submit(t, url.Values{
+			"channel":              {"sms"},
+			"locale":               {"de-DE"},
+			"quiet_hours.start":    {"22:00"},
+			"quiet_hours.end":      {"07:00"},
+			"quiet_hours.timezone": {"Europe/Berlin"},
+		}, http.StatusOK)
+		assert.Equal(t, "success", gjson.Get(actual, "flow.state").String(), "%s", actual)
+
+		p, err := reg.CommunicationPreferencesPersister().GetCommunicationPreferences(ctx, i.ID)
+		require.NoError(t, err)
+		assert.Equal(t, courier.ChannelSMS, p.Channel)
+		assert.Equal(t, "de-DE", p.Locale)
+		assert.Equal(t, &courier.QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Berlin"}, p.QuietHours)
+
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+		values := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+		assert.Equal(t, "sms", values.Get("channel"))
+		assert.Equal(t, "22:00", values.Get("quiet_hours.start"))
+	})
+
+	t.Run("case=removes the quiet hours", func(t *testing.T) {
+		_ = submit(t, url.Values{"channel": {"email"}, "quiet_hours.start": {""}, "quiet_hours.end": {""}}, http.StatusOK)
+
+		p, err := reg.CommunicationPreferencesPersister().GetCommunicationPreferences(ctx, i.ID)
+		require.NoError(t, err)
+		assert.Equal(t, courier.ChannelEmail, p.Channel)
+		assert.Nil(t, p.QuietHours)
+	})
+
+	t.Run("case=rejects invalid preferences", func(t *testing.T) {
+		_ = submit(t, url.Values{"channel": {"pigeon"}}, http.StatusBadRequest)
+		_ = submit(t, url.Values{"quiet_hours.start": {"24:00"}, "quiet_hours.end": {"07:00"}}, http.StatusBadRequest)
+		_ = submit(t, url.Values{"quiet_hours.start": {"22:00"}, "quiet_hours.end": {"07:00"}, "quiet_hours.timezone": {"Mars/Olympus"}}, http.StatusBadRequest)
+
+		p, err := reg.CommunicationPreferencesPersister().GetCommunicationPreferences(ctx, i.ID)
+		require.NoError(t, err)
+		assert.Equal(t, courier.ChannelEmail, p.Channel)
+	})
+}
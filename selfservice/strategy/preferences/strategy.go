@@ -0,0 +1,49 @@
+package preferences
+
+import (
+	"github.com/ory/x/decoderx"
+
+	"github.com/ory/kratos/continuity"
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+var _ settings.Strategy = new(Strategy)
+
+type (
+	strategyDependencies interface {
+		x.CSRFTokenGeneratorProvider
+		x.LoggingProvider
+
+		config.Provider
+
+		continuity.ManagementProvider
+
+		identity.PrivilegedPoolProvider
+
+		courier.PreferencesPersistenceProvider
+	}
+
+	// Strategy is a settings strategy which changes how identities want to receive messages which are not
+	// required to complete a self-service flow, such as inactivity warnings.
+	Strategy struct {
+		d  strategyDependencies
+		hd *decoderx.HTTP
+	}
+)
+
+func NewStrategy(d strategyDependencies) *Strategy {
+	return &Strategy{d: d, hd: decoderx.NewHTTP()}
+}
+
+func (s *Strategy) SettingsStrategyID() string {
+	return settings.StrategyPreferences
+}
+
+func (s *Strategy) NodeGroup() node.Group {
+	return node.PreferencesGroup
+}
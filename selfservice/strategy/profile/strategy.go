@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"time"
 
 	"github.com/ory/kratos/text"
 
@@ -36,6 +35,7 @@ type (
 		x.CSRFTokenGeneratorProvider
 		x.WriterProvider
 		x.LoggingProvider
+		x.ClockProvider
 
 		config.Provider
 
@@ -156,8 +156,8 @@ func (s *Strategy) continueFlow(w http.ResponseWriter, r *http.Request, ctxUpdat
 	}
 
 	options := []identity.ManagerOption{identity.ManagerExposeValidationErrorsForInternalTypeAssertion}
-	ttl := s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()
-	if ctxUpdate.Session.AuthenticatedAt.Add(ttl).After(time.Now()) {
+	ttl := s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(s.SettingsStrategyID())
+	if ctxUpdate.AuthenticatedAt().Add(ttl).After(s.d.Clock().Now()) {
 		options = append(options, identity.ManagerAllowWriteProtectedTraits)
 	}
 
@@ -0,0 +1,8 @@
+package questions
+
+import (
+	_ "embed"
+)
+
+//go:embed .schema/recovery.schema.json
+var recoveryMethodSchema []byte
@@ -0,0 +1,129 @@
+package questions
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/decoderx"
+
+	"github.com/ory/kratos/cipher"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/callback"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+var _ recovery.Strategy = new(Strategy)
+var _ recovery.AdminHandler = new(Strategy)
+
+type (
+	strategyDependencies interface {
+		x.CSRFProvider
+		x.CSRFTokenGeneratorProvider
+		x.WriterProvider
+		x.LoggingProvider
+		x.ClockProvider
+
+		config.Provider
+
+		cipher.IdentityKeysProvider
+
+		hash.HashProvider
+		hash.RegistryProvider
+
+		session.ManagementProvider
+		settings.HandlerProvider
+		settings.FlowPersistenceProvider
+
+		identity.PoolProvider
+		identity.PrivilegedPoolProvider
+		identity.ManagementProvider
+		identity.RelationshipPersistenceProvider
+
+		recovery.FlowPersistenceProvider
+		callback.NotifierProvider
+	}
+
+	// Strategy lets identities without a recovery address, for example identities which only have a username,
+	// recover their account by answering the recovery questions configured at
+	// `selfservice.methods.questions.config.questions`. The answers are set up by an administrator who verified the
+	// identity out of band, because letting anyone who is signed in pick their own answers would turn the answers
+	// into a second password which is easier to guess than the first.
+	Strategy struct {
+		d  strategyDependencies
+		dx *decoderx.HTTP
+	}
+
+	// CredentialsConfig is stored in the identity's questions credentials.
+	CredentialsConfig struct {
+		// Answers are the hashed answers to the recovery questions.
+		Answers []Answer `json:"answers"`
+
+		// FailedAttempts counts the wrong answers since the answers were set. Recovery using questions is locked
+		// once it reaches `selfservice.methods.questions.config.max_attempts`.
+		FailedAttempts int `json:"failed_attempts"`
+	}
+
+	Answer struct {
+		// QuestionID is the ID of the configured question this is the answer to.
+		QuestionID string `json:"question_id"`
+
+		// HashedAnswer is the hash of the normalized answer, encrypted using the identity's data key.
+		HashedAnswer string `json:"hashed_answer"`
+	}
+)
+
+func NewStrategy(d strategyDependencies) *Strategy {
+	return &Strategy{d: d, dx: decoderx.NewHTTP()}
+}
+
+func (s *Strategy) RecoveryNodeGroup() node.Group {
+	return node.RecoveryQuestionsGroup
+}
+
+func credentialsConfig(i *identity.Identity) (*CredentialsConfig, error) {
+	var conf CredentialsConfig
+	if c, ok := i.GetCredentials(identity.CredentialsTypeQuestions); ok && len(c.Config) > 0 {
+		if err := json.Unmarshal(c.Config, &conf); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return &conf, nil
+}
+
+func setCredentialsConfig(i *identity.Identity, conf *CredentialsConfig) error {
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(conf); err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.SetCredentials(identity.CredentialsTypeQuestions, identity.Credentials{
+		Type:        identity.CredentialsTypeQuestions,
+		Identifiers: []string{},
+		Config:      b.Bytes(),
+	})
+	return nil
+}
+
+// answer returns the answer to the question with the given ID or nil if there is none.
+func (c *CredentialsConfig) answer(questionID string) *Answer {
+	for k := range c.Answers {
+		if c.Answers[k].QuestionID == questionID {
+			return &c.Answers[k]
+		}
+	}
+	return nil
+}
+
+// NormalizeAnswer makes answers comparable regardless of how they were capitalized and spaced.
+func NormalizeAnswer(answer string) string {
+	return strings.ToLower(strings.Join(strings.Fields(answer), " "))
+}
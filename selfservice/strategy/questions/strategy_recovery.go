@@ -0,0 +1,452 @@
+package questions
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/callback"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/strategy"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteAdminSetRecoveryAnswers = "/recovery/questions"
+)
+
+func (s *Strategy) RecoveryStrategyID() string {
+	return recovery.StrategyRecoveryQuestionsName
+}
+
+func (s *Strategy) RegisterAdminRecoveryRoutes(admin *x.RouterAdmin) {
+	admin.PUT(RouteAdminSetRecoveryAnswers, strategy.IsDisabled(s.d, s.RecoveryStrategyID(), s.setRecoveryAnswers))
+}
+
+func (s *Strategy) PopulateRecoveryMethod(r *http.Request, f *recovery.Flow) error {
+	// Answering the questions signs the identity in using a cookie, which API clients can not use.
+	if f.Type != flow.TypeBrowser {
+		return nil
+	}
+
+	questions := s.d.Config(r.Context()).RecoveryQuestions()
+	if len(questions) == 0 {
+		return nil
+	}
+
+	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	f.UI.GetNodes().Upsert(node.NewInputField("identifier", nil, node.RecoveryQuestionsGroup, node.InputAttributeTypeText, node.WithRequiredInputAttribute).WithMetaLabel(text.NewInfoNodeLabelID()))
+	for _, q := range questions {
+		f.UI.GetNodes().Upsert(node.NewInputField("answers."+q.ID, nil, node.RecoveryQuestionsGroup, node.InputAttributeTypeText, node.WithRequiredInputAttribute).WithMetaLabel(text.NewInfoNodeInputRecoveryQuestion(q.ID, q.Text)))
+	}
+	f.UI.GetNodes().Append(node.NewInputField("method", s.RecoveryStrategyID(), node.RecoveryQuestionsGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSubmit()))
+
+	return nil
+}
+
+// swagger:parameters setRecoveryAnswers
+//
+// nolint
+type setRecoveryAnswersParameters struct {
+	// in: body
+	Body SetRecoveryAnswers
+}
+
+type SetRecoveryAnswers struct {
+	// Identity
+	//
+	// The ID of the identity whose answers are set.
+	//
+	// required: true
+	IdentityID uuid.UUID `json:"identity_id"`
+
+	// Answers
+	//
+	// Maps the ID of every question configured at `selfservice.methods.questions.config.questions` to the
+	// identity's answer. Answers are compared case-insensitively and ignoring surrounding and repeated whitespace.
+	//
+	// required: true
+	Answers map[string]string `json:"answers"`
+}
+
+// swagger:route PUT /recovery/questions admin setRecoveryAnswers
+//
+// Set the Answers to the Recovery Questions
+//
+// This endpoint sets the answers an identity gives to recover its account using the recovery questions method.
+// Only call it after verifying who the identity belongs to, for example when the user calls support, because
+// anyone who knows the answers can take over the account. Setting the answers unlocks recovery using questions
+// if the identity gave too many wrong answers.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (s *Strategy) setRecoveryAnswers(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var p SetRecoveryAnswers
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&p); err != nil {
+		s.d.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	questions := s.d.Config(r.Context()).RecoveryQuestions()
+	if len(questions) == 0 {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("No recovery questions are configured at selfservice.methods.questions.config.questions.")))
+		return
+	}
+
+	known := make(map[string]bool, len(questions))
+	var missing []string
+	for _, q := range questions {
+		known[q.ID] = true
+		if len(NormalizeAnswer(p.Answers[q.ID])) == 0 {
+			missing = append(missing, q.ID)
+		}
+	}
+	if len(missing) > 0 {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The answers to the recovery questions %s are missing.", strings.Join(missing, ", "))))
+		return
+	}
+	for id := range p.Answers {
+		if !known[id] {
+			s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The recovery question %s is not configured.", id)))
+			return
+		}
+	}
+
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), p.IdentityID)
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	var conf CredentialsConfig
+	for _, q := range questions {
+		hashed, err := s.d.Hasher().Generate(r.Context(), []byte(NormalizeAnswer(p.Answers[q.ID])))
+		if err != nil {
+			s.d.Writer().WriteError(w, r, err)
+			return
+		}
+
+		// Answers are easy to guess compared to passwords, so the hashes are encrypted using the identity's data
+		// key as well to keep them from being brute forced once the identity is deleted.
+		encrypted, err := s.d.IdentityKeys().ForIdentity(i.ID).Encrypt(r.Context(), hashed)
+		if err != nil {
+			s.d.Writer().WriteError(w, r, err)
+			return
+		}
+		conf.Answers = append(conf.Answers, Answer{QuestionID: q.ID, HashedAnswer: encrypted})
+	}
+
+	if err := setCredentialsConfig(i, &conf); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := s.d.IdentityManager().Update(r.Context(), i, identity.ManagerAllowWriteProtectedTraits); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	s.d.Audit().
+		WithRequest(r).
+		WithField("identity_id", i.ID).
+		Info("The answers to the recovery questions have been set.")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// swagger:parameters submitSelfServiceRecoveryFlowWithQuestionsMethod
+// nolint:deadcode,unused
+type submitSelfServiceRecoveryFlowWithQuestionsMethodParameters struct {
+	// in: body
+	Body submitSelfServiceRecoveryFlowWithQuestionsMethod
+
+	// The Flow ID
+	//
+	// format: uuid
+	// in: query
+	Flow string `json:"flow" form:"flow"`
+}
+
+// swagger:model submitSelfServiceRecoveryFlowWithQuestionsMethod
+// nolint:deadcode,unused
+type submitSelfServiceRecoveryFlowWithQuestionsMethod struct {
+	// Method should be set to "questions" when recovering the account using recovery questions.
+	//
+	// required: true
+	Method string `json:"method" form:"method"`
+
+	// The identifier the identity signs in with, for example its username.
+	//
+	// required: true
+	Identifier string `json:"identifier" form:"identifier"`
+
+	// Maps the IDs of the recovery questions to the answers.
+	//
+	// required: true
+	Answers map[string]string `json:"answers" form:"answers"`
+
+	// Sending the anti-csrf token is required.
+	CSRFToken string `form:"csrf_token" json:"csrf_token"`
+}
+
+// swagger:route POST /self-service/recovery/methods/questions public submitSelfServiceRecoveryFlowWithQuestionsMethod
+//
+// Complete Recovery Flow with Questions Method
+//
+// Use this endpoint to complete a browser recovery flow by answering the recovery questions an administrator
+// set up for the identity. If the answers are correct, the user is signed in and redirected to the Settings UI
+// URL to update their password. Otherwise, the user is redirected back to the Recovery UI URL which shows an
+// error. Recovery using questions is locked after too many wrong answers until an administrator sets new answers.
+//
+// More information can be found at [ORY Kratos Account Recovery Documentation](../self-service/flows/account-recovery.mdx).
+//
+//     Consumes:
+//     - application/json
+//     - application/x-www-form-urlencoded
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       302: emptyResponse
+//       400: recoveryFlow
+//       500: genericError
+func (s *Strategy) Recover(w http.ResponseWriter, r *http.Request, f *recovery.Flow) (err error) {
+	if err := flow.MethodEnabledAndAllowedFromRequest(r, s.RecoveryStrategyID(), s.d); err != nil {
+		return err
+	}
+
+	body, err := s.decodeRecovery(r)
+	if err != nil {
+		return s.handleRecoveryError(r, f, nil, err)
+	}
+
+	if f.Type != flow.TypeBrowser {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("Recovery questions can only be answered in browser flows."))
+	}
+
+	if err := flow.EnsureCSRF(r, f.Type, s.d.Config(r.Context()).DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, body.CSRFToken); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	if f.State != recovery.StateChooseMethod {
+		return s.handleRecoveryError(r, f, body, errors.WithStack(herodot.ErrBadRequest.WithReason(text.NewErrorValidationRecoveryRetrySuccess().Text)))
+	}
+
+	if len(body.Identifier) == 0 {
+		return s.handleRecoveryError(r, f, body, schema.NewRequiredError("#/identifier", "identifier"))
+	}
+
+	recoveredID, err := s.verifyAnswers(r.Context(), body.Identifier, body.Answers)
+	if err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	return s.recoveryIssueSession(w, r, f, body, recoveredID)
+}
+
+// verifyAnswers returns the ID of the identity with the given identifier if all answers to the questions it has
+// answers for are correct. Every reason for failing results in the same error so that the response does not
+// reveal whether the identity exists or set up recovery questions.
+func (s *Strategy) verifyAnswers(ctx context.Context, identifier string, answers map[string]string) (uuid.UUID, error) {
+	c := s.d.Config(ctx)
+	found, _, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, identifier)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		time.Sleep(x.RandomDelay(c.HasherArgon2().ExpectedDuration, c.HasherArgon2().ExpectedDeviation))
+		return uuid.Nil, errors.WithStack(schema.NewRecoveryAnswersInvalidError())
+	} else if err != nil {
+		return uuid.Nil, err
+	}
+
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, found.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	conf, err := credentialsConfig(i)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if conf.FailedAttempts >= c.RecoveryQuestionsMaxAttempts() {
+		s.d.Logger().WithField("identity_id", i.ID).Info("Rejected recovery questions answers because recovery using questions is locked.")
+		time.Sleep(x.RandomDelay(c.HasherArgon2().ExpectedDuration, c.HasherArgon2().ExpectedDeviation))
+		return uuid.Nil, errors.WithStack(schema.NewRecoveryAnswersInvalidError())
+	}
+
+	// Answers to questions which are no longer configured are ignored. All answers are compared even if one was
+	// wrong already to not reveal which one it was through the response time.
+	var compared int
+	correct := true
+	for _, q := range c.RecoveryQuestions() {
+		a := conf.answer(q.ID)
+		if a == nil {
+			continue
+		}
+
+		hashed, err := s.d.IdentityKeys().ForIdentity(i.ID).Decrypt(ctx, a.HashedAnswer)
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+		compared++
+		if err := s.d.Hashers().Compare(ctx, []byte(NormalizeAnswer(answers[q.ID])), hashed); err != nil {
+			correct = false
+		}
+	}
+
+	if compared == 0 {
+		time.Sleep(x.RandomDelay(c.HasherArgon2().ExpectedDuration, c.HasherArgon2().ExpectedDeviation))
+		return uuid.Nil, errors.WithStack(schema.NewRecoveryAnswersInvalidError())
+	}
+
+	if correct && conf.FailedAttempts == 0 {
+		return i.ID, nil
+	}
+
+	if correct {
+		conf.FailedAttempts = 0
+	} else {
+		conf.FailedAttempts++
+	}
+
+	if err := setCredentialsConfig(i, conf); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := s.d.IdentityManager().Update(ctx, i, identity.ManagerAllowWriteProtectedTraits); err != nil {
+		return uuid.Nil, err
+	}
+
+	if !correct {
+		return uuid.Nil, errors.WithStack(schema.NewRecoveryAnswersInvalidError())
+	}
+
+	return i.ID, nil
+}
+
+func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request, f *recovery.Flow, body *recoverySubmitPayload, recoveredID uuid.UUID) error {
+	recovered, err := s.d.IdentityPool().GetIdentity(r.Context(), recoveredID)
+	if err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	restrictions, err := identity.FindChildRestrictions(r.Context(), s.d.Config(r.Context()), s.d, recovered)
+	if err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	} else if restrictions.Restricts(identity.ChildRestrictedFlowRecovery, s.RecoveryStrategyID()) {
+		return s.handleRecoveryError(r, f, body, errors.WithStack(identity.ErrChildSelfService))
+	}
+
+	f.UI.Messages.Clear()
+	f.State = recovery.StatePassedChallenge
+	f.RecoveredIdentityID = uuid.NullUUID{
+		UUID:  recoveredID,
+		Valid: true,
+	}
+	if err := s.d.RecoveryFlowPersister().UpdateRecoveryFlow(r.Context(), f); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	s.d.FlowCallbackNotifier().Complete(r.Context(), &callback.Result{
+		FlowID:     f.ID,
+		FlowType:   callback.FlowTypeRecovery,
+		State:      string(f.State),
+		IdentityID: recoveredID,
+	})
+
+	now := s.d.Clock().Now().UTC()
+	sess := session.NewActiveSession(recovered, s.d.Config(r.Context()), now)
+	if err := s.d.SessionManager().CreateAndIssueCookie(r.Context(), w, r, sess); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	sf, err := s.d.SettingsHandler().NewFlow(w, r, sess.Identity, flow.TypeBrowser)
+	if err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	sf.UI.Messages.Set(text.NewRecoverySuccessful(now.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(identity.CredentialsTypePassword.String()))))
+	if err := s.d.SettingsFlowPersister().UpdateSettingsFlow(r.Context(), sf); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	http.Redirect(w, r, sf.AppendTo(s.d.Config(r.Context()).SelfServiceFlowSettingsUI()).String(), http.StatusFound)
+	return errors.WithStack(flow.ErrCompletedByStrategy)
+}
+
+// handleRecoveryError keeps the identifier but never the answers, which are as sensitive as a password.
+func (s *Strategy) handleRecoveryError(r *http.Request, f *recovery.Flow, body *recoverySubmitPayload, err error) error {
+	f.UI.ResetMessages()
+	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	if body != nil {
+		f.UI.GetNodes().SetValueAttribute("identifier", body.Identifier)
+	}
+
+	return err
+}
+
+type recoverySubmitPayload struct {
+	Method     string            `json:"method" form:"method"`
+	CSRFToken  string            `json:"csrf_token" form:"csrf_token"`
+	Identifier string            `json:"identifier" form:"identifier"`
+	Answers    map[string]string `json:"answers" form:"answers"`
+}
+
+func (s *Strategy) decodeRecovery(r *http.Request) (*recoverySubmitPayload, error) {
+	var body recoverySubmitPayload
+
+	// The answers are only decoded from forms if the schema lists the questions.
+	raw := recoveryMethodSchema
+	for _, q := range s.d.Config(r.Context()).RecoveryQuestions() {
+		var err error
+		if raw, err = sjson.SetBytes(raw, "properties.answers.properties."+q.ID, map[string]string{"type": "string"}); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(raw)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.dx.Decode(r, &body, compiler,
+		decoderx.HTTPKeepRequestBody(true),
+		decoderx.HTTPDecoderSetValidatePayloads(false),
+		decoderx.HTTPDecoderJSONFollowsFormFormat(),
+	); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &body, nil
+}
@@ -0,0 +1,197 @@
+package questions_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/ioutilx"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/strategy/questions"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+func TestNormalizeAnswer(t *testing.T) {
+	for in, expected := range map[string]string{
+		"Rex":                 "rex",
+		"  New   York\tCity ": "new york city",
+		"":                    "",
+		" \n ":                "",
+	} {
+		assert.Equal(t, expected, questions.NormalizeAnswer(in), "%q", in)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/username.schema.json")
+	conf.MustSet(config.ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh")
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+identity.CredentialsTypePassword.String()+".enabled", true)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+recovery.StrategyRecoveryLinkName+".enabled", false)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+recovery.StrategyRecoveryQuestionsName+".enabled", true)
+	conf.MustSet(config.ViperKeyRecoveryQuestions, []config.RecoveryQuestion{
+		{ID: "first_pet", Text: "What was the name of your first pet?"},
+		{ID: "birth_city", Text: "In which city were you born?"},
+	})
+	conf.MustSet(config.ViperKeySelfServiceRecoveryEnabled, true)
+
+	_ = testhelpers.NewRecoveryUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewSettingsUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewLoginUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+
+	public, admin := testhelpers.NewKratosServer(t, reg)
+
+	id := &identity.Identity{
+		Credentials: map[identity.CredentialsType]identity.Credentials{
+			"password": {Type: "password", Identifiers: []string{"recover-me"}, Config: sqlxx.JSONRawMessage(`{"hashed_password":"foo"}`)}},
+		Traits:   identity.Traits(`{"username":"recover-me"}`),
+		SchemaID: config.DefaultIdentityTraitsSchemaID,
+	}
+	require.NoError(t, reg.IdentityManager().Create(context.Background(), id, identity.ManagerAllowWriteProtectedTraits))
+
+	setAnswers := func(t *testing.T, body interface{}, expectCode int) {
+		var b bytes.Buffer
+		require.NoError(t, json.NewEncoder(&b).Encode(body))
+		req, err := http.NewRequest("PUT", admin.URL+questions.RouteAdminSetRecoveryAnswers, &b)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := admin.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, expectCode, res.StatusCode, "%s", ioutilx.MustReadAll(res.Body))
+	}
+
+	answers := map[string]string{"first_pet": "Rex", "birth_city": "New York"}
+
+	submit := func(t *testing.T, identifier string, answers map[string]string) (string, *http.Response) {
+		hc := testhelpers.NewClientWithCookies(t)
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public)
+
+		values := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+		values.Set("identifier", identifier)
+		for id, answer := range answers {
+			values.Set("answers."+id, answer)
+		}
+
+		return testhelpers.RecoveryMakeRequest(t, false, f, hc, values.Encode())
+	}
+
+	expectRejected := func(t *testing.T, identifier string, answers map[string]string) {
+		body, res := submit(t, identifier, answers)
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowRecoveryUI().String(), "%s", body)
+		assert.EqualValues(t, node.RecoveryQuestionsGroup, gjson.Get(body, "active").String(), "%s", body)
+		assert.EqualValues(t, text.ErrorValidationRecoveryAnswersInvalid, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+		assert.Equal(t, identifier, gjson.Get(body, "ui.nodes.#(attributes.name==identifier).attributes.value").String(), "%s", body)
+		assert.Empty(t, gjson.Get(body, `ui.nodes.#(attributes.name=="answers.first_pet").attributes.value`).String(), "%s", body)
+	}
+
+	expectRecovered := func(t *testing.T, answers map[string]string) {
+		body, res := submit(t, "recover-me", answers)
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String(), "%s", body)
+		assert.Equal(t, text.NewRecoverySuccessful(time.Now().Add(time.Hour)).Text, gjson.Get(body, "ui.messages.0.text").String(), "%s", body)
+	}
+
+	t.Run("case=admin sets answers", func(t *testing.T) {
+		t.Run("case=rejects missing answers", func(t *testing.T) {
+			setAnswers(t, questions.SetRecoveryAnswers{IdentityID: id.ID, Answers: map[string]string{"first_pet": "Rex", "birth_city": "  "}}, http.StatusBadRequest)
+		})
+
+		t.Run("case=rejects unknown questions", func(t *testing.T) {
+			setAnswers(t, questions.SetRecoveryAnswers{IdentityID: id.ID, Answers: map[string]string{"first_pet": "Rex", "birth_city": "New York", "first_car": "Beetle"}}, http.StatusBadRequest)
+		})
+
+		t.Run("case=rejects unknown identities", func(t *testing.T) {
+			setAnswers(t, questions.SetRecoveryAnswers{IdentityID: x.NewUUID(), Answers: answers}, http.StatusNotFound)
+		})
+
+		t.Run("case=stores hashed answers", func(t *testing.T) {
+			setAnswers(t, questions.SetRecoveryAnswers{IdentityID: id.ID, Answers: answers}, http.StatusNoContent)
+
+			i, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), id.ID)
+			require.NoError(t, err)
+			c, ok := i.GetCredentials(identity.CredentialsTypeQuestions)
+			require.True(t, ok)
+			assert.NotContains(t, string(c.Config), "rex")
+			assert.Len(t, gjson.GetBytes(c.Config, "answers").Array(), 2)
+			assert.NotContains(t, gjson.GetBytes(c.Config, "answers.0.hashed_answer").String(), "$", "the hashes are encrypted")
+		})
+	})
+
+	t.Run("case=renders the questions", func(t *testing.T) {
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, testhelpers.NewClientWithCookies(t), public)
+		nodes, err := json.Marshal(f.Ui.Nodes)
+		require.NoError(t, err)
+
+		assert.Equal(t, "In which city were you born?", gjson.GetBytes(nodes, `#(attributes.name=="answers.birth_city").meta.label.text`).String(), "%s", nodes)
+		assert.Equal(t, "birth_city", gjson.GetBytes(nodes, `#(attributes.name=="answers.birth_city").meta.label.context.question_id`).String(), "%s", nodes)
+		assert.Equal(t, "questions", gjson.GetBytes(nodes, `#(attributes.name==method).attributes.value`).String(), "%s", nodes)
+
+		t.Run("type=api", func(t *testing.T) {
+			f := testhelpers.InitializeRecoveryFlowViaAPI(t, &http.Client{}, public)
+			nodes, err := json.Marshal(f.Ui.Nodes)
+			require.NoError(t, err)
+			assert.False(t, gjson.GetBytes(nodes, `#(attributes.name=="answers.birth_city")`).Exists(), "%s", nodes)
+		})
+	})
+
+	t.Run("case=rejects unknown identifiers", func(t *testing.T) {
+		expectRejected(t, "does-not-exist", answers)
+	})
+
+	t.Run("case=recovers with correct answers", func(t *testing.T) {
+		expectRecovered(t, map[string]string{"first_pet": "  rEX ", "birth_city": "new   york"})
+	})
+
+	t.Run("case=locks after too many wrong answers", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyRecoveryQuestionsMaxAttempts, 2)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyRecoveryQuestionsMaxAttempts, 5)
+		})
+
+		wrong := map[string]string{"first_pet": "Rex", "birth_city": "Boston"}
+		expectRejected(t, "recover-me", wrong)
+		expectRejected(t, "recover-me", wrong)
+		expectRejected(t, "recover-me", answers)
+
+		setAnswers(t, questions.SetRecoveryAnswers{IdentityID: id.ID, Answers: answers}, http.StatusNoContent)
+		expectRecovered(t, answers)
+	})
+
+	t.Run("case=a correct answer resets the failed attempts", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyRecoveryQuestionsMaxAttempts, 2)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyRecoveryQuestionsMaxAttempts, 5)
+		})
+
+		wrong := map[string]string{"first_pet": "Max", "birth_city": "New York"}
+		expectRejected(t, "recover-me", wrong)
+		expectRecovered(t, answers)
+		expectRejected(t, "recover-me", wrong)
+		expectRecovered(t, answers)
+	})
+
+	t.Run("case=ignores unrelated methods", func(t *testing.T) {
+		hc := testhelpers.NewClientWithCookies(t)
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public)
+		body, _ := testhelpers.RecoveryMakeRequest(t, false, f, hc, url.Values{"method": {"link"}, "csrf_token": {x.FakeCSRFToken}}.Encode())
+		assert.NotEqual(t, node.RecoveryQuestionsGroup, gjson.Get(body, "active").String(), "%s", body)
+	})
+}
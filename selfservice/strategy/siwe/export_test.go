@@ -0,0 +1,30 @@
+package siwe
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+func privateKey(key *big.Int) *secp256k1.PrivateKey {
+	return secp256k1.PrivKeyFromBytes(key.FillBytes(make([]byte, 32)))
+}
+
+// SignMessage signs the message like a wallet does when asked to sign it using `personal_sign` and returns the hex
+// encoded signature.
+func SignMessage(key *big.Int, message string) string {
+	compact := ecdsa.SignCompact(privateKey(key), hashMessage(message), false)
+
+	// Ethereum signatures carry the recovery ID after r and s.
+	sig := make([]byte, 65)
+	copy(sig, compact[1:])
+	sig[64] = compact[0]
+	return "0x" + hex.EncodeToString(sig)
+}
+
+// Address returns the checksummed address of the key.
+func Address(key *big.Int) string {
+	return checksumAddress(publicKeyAddress(privateKey(key).PubKey()))
+}
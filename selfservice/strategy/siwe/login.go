@@ -0,0 +1,113 @@
+package siwe
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+// nolint:deadcode,unused
+// swagger:parameters submitSelfServiceLoginFlowWithSIWEMethod
+type submitSelfServiceLoginFlowWithSIWEMethodParameters struct {
+	// The Flow ID
+	//
+	// required: true
+	// in: query
+	Flow string `json:"flow"`
+
+	// in: body
+	Body submitSelfServiceLoginFlowWithSIWEMethod
+}
+
+// submitSelfServiceLoginFlowWithSIWEMethod is used to decode the login form payload.
+//
+// swagger:model submitSelfServiceLoginFlowWithSIWEMethod
+type submitSelfServiceLoginFlowWithSIWEMethod struct {
+	// Method should be set to "siwe" when signing in with an Ethereum wallet.
+	Method string `json:"method"`
+
+	// Sending the anti-csrf token is only required for browser login flows.
+	CSRFToken string `json:"csrf_token"`
+
+	// Message is the EIP-4361 message which contains the nonce of the flow.
+	Message string `json:"siwe_message"`
+
+	// Signature is the hex encoded signature of the message created using `personal_sign`.
+	Signature string `json:"siwe_signature"`
+}
+
+func (s *Strategy) RegisterLoginRoutes(_ *x.RouterPublic) {
+}
+
+func (s *Strategy) handleLoginError(r *http.Request, f *login.Flow, err error) error {
+	if f != nil && f.Type == flow.TypeBrowser {
+		f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	}
+
+	return err
+}
+
+func (s *Strategy) Login(w http.ResponseWriter, r *http.Request, f *login.Flow) (*identity.Identity, error) {
+	if err := flow.MethodEnabledAndAllowedFromRequest(r, s.ID().String(), s.d); err != nil {
+		return nil, err
+	}
+
+	var p submitSelfServiceLoginFlowWithSIWEMethod
+	if err := s.hd.Decode(r, &p,
+		decoderx.HTTPDecoderSetValidatePayloads(true),
+		decoderx.MustHTTPRawJSONSchemaCompiler(loginSchema),
+		decoderx.HTTPDecoderJSONFollowsFormFormat()); err != nil {
+		return nil, s.handleLoginError(r, f, err)
+	}
+
+	if err := flow.EnsureCSRF(r, f.Type, s.d.Config(r.Context()).DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, p.CSRFToken); err != nil {
+		return nil, s.handleLoginError(r, f, err)
+	}
+
+	// The nonce is replaced before the message is verified, so that it can only be used once even if the message is
+	// submitted concurrently.
+	nonce := f.SIWENonce
+	issueNonce(f.UI)
+	if err := s.d.LoginFlowPersister().UseLoginFlowSIWENonce(r.Context(), f.ID, nonce, currentNonce(f.UI)); errors.Is(err, sqlcon.ErrNoRows) {
+		return nil, s.handleLoginError(r, f, schema.NewSIWEMessageInvalidError("#/siwe_message", "the nonce was already used"))
+	} else if err != nil {
+		return nil, s.handleLoginError(r, f, err)
+	}
+
+	if err := s.d.LoginFlowPersister().UpdateLoginFlow(r.Context(), f); err != nil {
+		return nil, s.handleLoginError(r, f, err)
+	}
+
+	address, err := s.verify(r, nonce, p.Message, p.Signature)
+	if err != nil {
+		return nil, s.handleLoginError(r, f, err)
+	}
+
+	i, _, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), s.ID(), strings.ToLower(address))
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return nil, s.handleLoginError(r, f, schema.NewSIWEWalletUnknownError())
+	} else if err != nil {
+		return nil, s.handleLoginError(r, f, err)
+	}
+
+	return i, nil
+}
+
+func (s *Strategy) PopulateLoginMethod(r *http.Request, f *login.Flow) error {
+	s.populateNodes(r, f.UI)
+	f.SIWENonce = currentNonce(f.UI)
+	f.UI.GetNodes().Append(node.NewInputField("method", s.ID().String(), node.SIWEGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoLoginWith("Ethereum")))
+	return nil
+}
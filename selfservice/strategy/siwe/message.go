@@ -0,0 +1,242 @@
+package siwe
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	messageHeader  = " wants you to sign in with your Ethereum account:"
+	messageVersion = "1"
+)
+
+// Message is a Sign-In with Ethereum message as defined by EIP-4361.
+type Message struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseMessage parses a message which follows the format defined by EIP-4361. The optional scheme in front of the
+// domain is dropped.
+func ParseMessage(raw string) (*Message, error) {
+	lines := strings.Split(raw, "\n")
+	next := func() (string, bool) {
+		if len(lines) == 0 {
+			return "", false
+		}
+		line := lines[0]
+		lines = lines[1:]
+		return line, true
+	}
+
+	var m Message
+	header, _ := next()
+	if !strings.HasSuffix(header, messageHeader) {
+		return nil, errors.Errorf("the message must start with a line ending in %q", messageHeader)
+	}
+	m.Domain = strings.TrimSuffix(header, messageHeader)
+	if i := strings.Index(m.Domain, "://"); i >= 0 {
+		m.Domain = m.Domain[i+3:]
+	}
+	if len(m.Domain) == 0 {
+		return nil, errors.New("the message does not contain a domain")
+	}
+
+	m.Address, _ = next()
+	if _, err := parseAddress(m.Address); err != nil {
+		return nil, errors.Errorf("the message does not contain a valid address in the second line")
+	}
+
+	if line, _ := next(); line != "" {
+		return nil, errors.New("the address must be followed by an empty line")
+	}
+
+	// The statement is optional but the empty line which follows it is not.
+	if line, ok := next(); !ok {
+		return nil, errors.New("the message ends unexpectedly")
+	} else if line != "" {
+		m.Statement = line
+		if line, _ := next(); line != "" {
+			return nil, errors.New("the statement must be followed by an empty line")
+		}
+	}
+
+	field := func(name string, required bool) (string, error) {
+		if len(lines) > 0 && strings.HasPrefix(lines[0], name+": ") {
+			line, _ := next()
+			return strings.TrimPrefix(line, name+": "), nil
+		} else if required {
+			return "", errors.Errorf("the message does not contain the field %q", name)
+		}
+		return "", nil
+	}
+
+	timestamp := func(name string, required bool) (*time.Time, error) {
+		value, err := field(name, required)
+		if err != nil || value == "" {
+			return nil, err
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.Errorf("the field %q must be an RFC 3339 timestamp", name)
+		}
+		return &t, nil
+	}
+
+	var err error
+	if m.URI, err = field("URI", true); err != nil {
+		return nil, err
+	} else if _, err := url.Parse(m.URI); err != nil {
+		return nil, errors.New("the field \"URI\" must be a valid URI")
+	}
+
+	if m.Version, err = field("Version", true); err != nil {
+		return nil, err
+	} else if m.Version != messageVersion {
+		return nil, errors.Errorf("the field \"Version\" must be %q", messageVersion)
+	}
+
+	chainID, err := field("Chain ID", true)
+	if err != nil {
+		return nil, err
+	} else if m.ChainID, err = strconv.Atoi(chainID); err != nil {
+		return nil, errors.New("the field \"Chain ID\" must be a number")
+	}
+
+	if m.Nonce, err = field("Nonce", true); err != nil {
+		return nil, err
+	} else if len(m.Nonce) < 8 {
+		return nil, errors.New("the field \"Nonce\" must have at least eight characters")
+	}
+
+	issuedAt, err := timestamp("Issued At", true)
+	if err != nil {
+		return nil, err
+	}
+	m.IssuedAt = *issuedAt
+
+	if m.ExpirationTime, err = timestamp("Expiration Time", false); err != nil {
+		return nil, err
+	}
+
+	if m.NotBefore, err = timestamp("Not Before", false); err != nil {
+		return nil, err
+	}
+
+	if m.RequestID, err = field("Request ID", false); err != nil {
+		return nil, err
+	}
+
+	if len(lines) > 0 && lines[0] == "Resources:" {
+		_, _ = next()
+		for len(lines) > 0 && strings.HasPrefix(lines[0], "- ") {
+			line, _ := next()
+			m.Resources = append(m.Resources, strings.TrimPrefix(line, "- "))
+		}
+	}
+
+	if len(lines) > 1 || (len(lines) == 1 && lines[0] != "") {
+		return nil, errors.Errorf("the message contains unexpected content: %q", lines[0])
+	}
+
+	return &m, nil
+}
+
+// Verify checks that the message was issued for the domain, one of the chains, and the nonce, and that it is valid
+// at the given time.
+func (m *Message) Verify(domain string, chainIDs []int, nonce string, now time.Time) error {
+	if m.Domain != domain {
+		return errors.Errorf("the message was issued for the domain %q but must be issued for %q", m.Domain, domain)
+	}
+
+	var allowed bool
+	for _, id := range chainIDs {
+		if id == m.ChainID {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return errors.Errorf("the chain ID %d is not allowed", m.ChainID)
+	}
+
+	if len(nonce) == 0 || m.Nonce != nonce {
+		return errors.New("the nonce does not match")
+	}
+
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return errors.New("the message expired")
+	}
+
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return errors.New("the message is not valid yet")
+	}
+
+	return nil
+}
+
+// VerifySignature returns the checksummed address which signed the message if it is the address stated in the
+// message. The signature must be hex encoded.
+func (m *Message) VerifySignature(raw, signature string) (string, error) {
+	sig, err := decodeHex(signature)
+	if err != nil {
+		return "", errors.WithStack(ErrInvalidSignature)
+	}
+
+	signer, err := recoverAddress(raw, sig)
+	if err != nil {
+		return "", err
+	}
+
+	expected, err := parseAddress(m.Address)
+	if err != nil {
+		return "", err
+	}
+
+	if checksumAddress(signer) != checksumAddress(expected) {
+		return "", errors.WithStack(ErrInvalidSignature)
+	}
+
+	return checksumAddress(signer), nil
+}
+
+func (m *Message) String() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%s%s\n%s\n\n", m.Domain, messageHeader, m.Address)
+	if len(m.Statement) > 0 {
+		_, _ = fmt.Fprintf(&b, "%s\n", m.Statement)
+	}
+	_, _ = fmt.Fprintf(&b, "\nURI: %s\nVersion: %s\nChain ID: %d\nNonce: %s\nIssued At: %s", m.URI, m.Version, m.ChainID, m.Nonce, m.IssuedAt.Format(time.RFC3339))
+	if m.ExpirationTime != nil {
+		_, _ = fmt.Fprintf(&b, "\nExpiration Time: %s", m.ExpirationTime.Format(time.RFC3339))
+	}
+	if m.NotBefore != nil {
+		_, _ = fmt.Fprintf(&b, "\nNot Before: %s", m.NotBefore.Format(time.RFC3339))
+	}
+	if len(m.RequestID) > 0 {
+		_, _ = fmt.Fprintf(&b, "\nRequest ID: %s", m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, r := range m.Resources {
+			_, _ = fmt.Fprintf(&b, "\n- %s", r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package siwe
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fullMessage = `https://example.com wants you to sign in with your Ethereum account:
+0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf
+
+I accept the Terms of Service.
+
+URI: https://example.com/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756abcdefgh
+Issued At: 2021-09-30T16:25:24Z
+Expiration Time: 2021-09-30T17:25:24Z
+Not Before: 2021-09-30T16:20:24Z
+Request ID: some-request
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+func TestParseMessage(t *testing.T) {
+	t.Run("case=parses all fields", func(t *testing.T) {
+		m, err := ParseMessage(fullMessage)
+		require.NoError(t, err)
+
+		assert.Equal(t, "example.com", m.Domain)
+		assert.Equal(t, "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", m.Address)
+		assert.Equal(t, "I accept the Terms of Service.", m.Statement)
+		assert.Equal(t, "https://example.com/login", m.URI)
+		assert.Equal(t, "1", m.Version)
+		assert.Equal(t, 1, m.ChainID)
+		assert.Equal(t, "32891756abcdefgh", m.Nonce)
+		assert.Equal(t, time.Date(2021, 9, 30, 16, 25, 24, 0, time.UTC), m.IssuedAt.UTC())
+		require.NotNil(t, m.ExpirationTime)
+		assert.Equal(t, time.Date(2021, 9, 30, 17, 25, 24, 0, time.UTC), m.ExpirationTime.UTC())
+		require.NotNil(t, m.NotBefore)
+		assert.Equal(t, time.Date(2021, 9, 30, 16, 20, 24, 0, time.UTC), m.NotBefore.UTC())
+		assert.Equal(t, "some-request", m.RequestID)
+		assert.Equal(t, []string{"ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/", "https://example.com/my-web2-claim.json"}, m.Resources)
+
+		assert.Equal(t, strings.TrimPrefix(fullMessage, "https://"), m.String())
+	})
+
+	t.Run("case=parses a message without optional fields", func(t *testing.T) {
+		raw := "example.com wants you to sign in with your Ethereum account:\n0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf\n\n\nURI: https://example.com\nVersion: 1\nChain ID: 137\nNonce: abcdefgh12345678\nIssued At: 2021-09-30T16:25:24.000Z"
+		m, err := ParseMessage(raw)
+		require.NoError(t, err)
+
+		assert.Empty(t, m.Statement)
+		assert.Equal(t, 137, m.ChainID)
+		assert.Nil(t, m.ExpirationTime)
+		assert.Nil(t, m.NotBefore)
+		assert.Empty(t, m.Resources)
+	})
+
+	for k, tc := range []struct {
+		replace, with string
+	}{
+		{replace: "wants you to sign in", with: "wants you to log in"},
+		{replace: "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", with: "0x7e5F4552091A69125d5DfCb7b8C2659029395Bdf"},
+		{replace: "Service.\n\n", with: "Service.\n"},
+		{replace: "URI: https://example.com/login\n", with: ""},
+		{replace: "Version: 1", with: "Version: 2"},
+		{replace: "Chain ID: 1", with: "Chain ID: one"},
+		{replace: "Nonce: 32891756abcdefgh", with: "Nonce: 1234"},
+		{replace: "Issued At: 2021-09-30T16:25:24Z", with: "Issued At: yesterday"},
+		{replace: "Request ID: some-request", with: "Request ID: some-request\nFoo: bar"},
+	} {
+		t.Run("case=rejects invalid messages/"+string(rune('a'+k)), func(t *testing.T) {
+			require.Contains(t, fullMessage, tc.replace)
+			_, err := ParseMessage(strings.Replace(fullMessage, tc.replace, tc.with, 1))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMessageVerify(t *testing.T) {
+	m, err := ParseMessage(fullMessage)
+	require.NoError(t, err)
+
+	valid := time.Date(2021, 9, 30, 16, 30, 0, 0, time.UTC)
+	assert.NoError(t, m.Verify("example.com", []int{1}, "32891756abcdefgh", valid))
+
+	for name, err := range map[string]error{
+		"domain":      m.Verify("evil.com", []int{1}, "32891756abcdefgh", valid),
+		"chain":       m.Verify("example.com", []int{137}, "32891756abcdefgh", valid),
+		"nonce":       m.Verify("example.com", []int{1}, "other-nonce", valid),
+		"empty nonce": m.Verify("example.com", []int{1}, "", valid),
+		"expired":     m.Verify("example.com", []int{1}, "32891756abcdefgh", valid.Add(time.Hour)),
+		"not before":  m.Verify("example.com", []int{1}, "32891756abcdefgh", valid.Add(-time.Hour)),
+	} {
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,152 @@
+package siwe
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/x/decoderx"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/container"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+// nolint:deadcode,unused
+// swagger:parameters submitSelfServiceRegistrationFlowWithSIWEMethod
+type submitSelfServiceRegistrationFlowWithSIWEMethodParameters struct {
+	// The Flow ID
+	//
+	// required: true
+	// in: query
+	Flow string `json:"flow"`
+
+	// in: body
+	Body submitSelfServiceRegistrationFlowWithSIWEMethod
+}
+
+// submitSelfServiceRegistrationFlowWithSIWEMethod is used to decode the registration form payload.
+//
+// swagger:model submitSelfServiceRegistrationFlowWithSIWEMethod
+type submitSelfServiceRegistrationFlowWithSIWEMethod struct {
+	// Method should be set to "siwe" when signing up with an Ethereum wallet.
+	Method string `json:"method"`
+
+	// Sending the anti-csrf token is only required for browser registration flows.
+	CSRFToken string `json:"csrf_token"`
+
+	// Message is the EIP-4361 message which contains the nonce of the flow.
+	Message string `json:"siwe_message"`
+
+	// Signature is the hex encoded signature of the message created using `personal_sign`.
+	Signature string `json:"siwe_signature"`
+
+	// Traits are the identity's traits.
+	Traits json.RawMessage `json:"traits"`
+}
+
+func (s *Strategy) RegisterRegistrationRoutes(_ *x.RouterPublic) {
+}
+
+func (s *Strategy) handleRegistrationError(r *http.Request, f *registration.Flow, p *submitSelfServiceRegistrationFlowWithSIWEMethod, err error) error {
+	if f != nil {
+		if p != nil {
+			for _, n := range container.NewFromJSON("", node.SIWEGroup, p.Traits, "traits").Nodes {
+				// we only set the value and not the whole field because we want to keep types from the initial form generation
+				f.UI.Nodes.SetValueAttribute(n.ID(), n.Attributes.GetValue())
+			}
+		}
+
+		if f.Type == flow.TypeBrowser {
+			f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+		}
+	}
+
+	return err
+}
+
+func (s *Strategy) decodeRegistration(r *http.Request, p *submitSelfServiceRegistrationFlowWithSIWEMethod) error {
+	raw, err := sjson.SetBytes(registrationSchema,
+		"properties.traits.$ref", s.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String()+"#/properties/traits")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(raw)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return s.hd.Decode(r, p, compiler, decoderx.HTTPDecoderSetValidatePayloads(true), decoderx.HTTPDecoderJSONFollowsFormFormat())
+}
+
+func (s *Strategy) Register(w http.ResponseWriter, r *http.Request, f *registration.Flow, i *identity.Identity) error {
+	if err := flow.MethodEnabledAndAllowedFromRequest(r, s.ID().String(), s.d); err != nil {
+		return err
+	}
+
+	var p submitSelfServiceRegistrationFlowWithSIWEMethod
+	if err := s.decodeRegistration(r, &p); err != nil {
+		return s.handleRegistrationError(r, f, &p, err)
+	}
+
+	if err := flow.EnsureCSRF(r, f.Type, s.d.Config(r.Context()).DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, p.CSRFToken); err != nil {
+		return s.handleRegistrationError(r, f, &p, err)
+	}
+
+	nonce := issueNonce(f.UI)
+	if err := s.d.RegistrationFlowPersister().UpdateRegistrationFlow(r.Context(), f); err != nil {
+		return s.handleRegistrationError(r, f, &p, err)
+	}
+
+	address, err := s.verify(r, nonce, p.Message, p.Signature)
+	if err != nil {
+		return s.handleRegistrationError(r, f, &p, err)
+	}
+
+	if len(p.Traits) == 0 {
+		p.Traits = json.RawMessage("{}")
+	}
+	i.Traits = identity.Traits(p.Traits)
+
+	if err := setCredentialsConfig(i, &CredentialsConfig{
+		Wallets: []Wallet{{Address: address, LinkedAt: s.d.Clock().Now().UTC()}},
+	}); err != nil {
+		return s.handleRegistrationError(r, f, &p, err)
+	}
+
+	if err := s.d.IdentityValidator().Validate(r.Context(), i); err != nil {
+		return s.handleRegistrationError(r, f, &p, err)
+	}
+
+	if err := s.d.RegistrationExecutor().PostRegistrationHook(w, r, s.ID(), f, i); err != nil {
+		return s.handleRegistrationError(r, f, &p, err)
+	}
+
+	// The hooks wrote the response already and must not be executed again by the registration handler.
+	return errors.WithStack(flow.ErrCompletedByStrategy)
+}
+
+func (s *Strategy) PopulateRegistrationMethod(r *http.Request, f *registration.Flow) error {
+	nodes, err := container.NodesFromJSONSchema(node.SIWEGroup, s.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String(), "", nil)
+	if err != nil {
+		return err
+	}
+
+	// Other strategies may have added the trait nodes already. They are shared instead of being moved to this group.
+	for _, n := range nodes {
+		if f.UI.Nodes.Find(n.ID()) == nil {
+			f.UI.Nodes.Append(n)
+		}
+	}
+
+	s.populateNodes(r, f.UI)
+	f.UI.GetNodes().Append(node.NewInputField("method", s.ID().String(), node.SIWEGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoRegistrationWith("Ethereum")))
+	return nil
+}
@@ -0,0 +1,296 @@
+package siwe
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/jsonschema/v3"
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+var UnknownWalletValidationError = &jsonschema.ValidationError{
+	Message: "can not unlink a wallet which is not linked", InstancePtr: "#/siwe_unlink"}
+var LastCredentialValidationError = &jsonschema.ValidationError{
+	Message: "can not unlink the wallet because it is the last way to sign in", InstancePtr: "#/siwe_unlink"}
+var WalletLinkedValidationError = &jsonschema.ValidationError{
+	Message: "the wallet is linked already", InstancePtr: "#/siwe_message"}
+
+func (s *Strategy) RegisterSettingsRoutes(_ *x.RouterPublic) {
+}
+
+// nolint:deadcode,unused
+// swagger:parameters submitSelfServiceSettingsFlowWithSIWEMethod
+type submitSelfServiceSettingsFlowWithSIWEMethod struct {
+	// in: body
+	Body submitSelfServiceSettingsFlowWithSIWEMethodBody
+
+	// Flow is flow ID.
+	//
+	// in: query
+	Flow string `json:"flow"`
+}
+
+// swagger:model submitSelfServiceSettingsFlowWithSIWEMethod
+type submitSelfServiceSettingsFlowWithSIWEMethodBody struct {
+	// Message is the EIP-4361 message which contains the nonce of the flow. It is required to link a wallet.
+	//
+	// type: string
+	Message string `json:"siwe_message"`
+
+	// Signature is the hex encoded signature of the message. It is required to link a wallet.
+	//
+	// type: string
+	Signature string `json:"siwe_signature"`
+
+	// Unlink is the address of the wallet to unlink.
+	//
+	// type: string
+	Unlink string `json:"siwe_unlink"`
+
+	// CSRFToken is the anti-CSRF token
+	//
+	// type: string
+	CSRFToken string `json:"csrf_token"`
+
+	// Method
+	//
+	// Should be set to siwe when trying to link or unlink a wallet.
+	//
+	// type: string
+	Method string `json:"method"`
+
+	// Flow is flow ID.
+	//
+	// swagger:ignore
+	Flow string `json:"flow"`
+}
+
+func (p *submitSelfServiceSettingsFlowWithSIWEMethodBody) GetFlowID() uuid.UUID {
+	return x.ParseUUID(p.Flow)
+}
+
+func (p *submitSelfServiceSettingsFlowWithSIWEMethodBody) SetFlowID(rid uuid.UUID) {
+	p.Flow = rid.String()
+}
+
+func (s *Strategy) Settings(w http.ResponseWriter, r *http.Request, f *settings.Flow, ss *session.Session) (*settings.UpdateContext, error) {
+	var p submitSelfServiceSettingsFlowWithSIWEMethodBody
+	ctxUpdate, err := settings.PrepareUpdate(s.d, w, r, f, ss, settings.ContinuityKey(s.SettingsStrategyID()), &p)
+	if errors.Is(err, settings.ErrContinuePreviousAction) {
+		return ctxUpdate, s.continueSettingsFlow(w, r, ctxUpdate, &p)
+	} else if err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	if err := s.methodEnabledAndAllowedFromRequest(r); err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	if err := s.hd.Decode(r, &p,
+		decoderx.HTTPDecoderSetValidatePayloads(true),
+		decoderx.MustHTTPRawJSONSchemaCompiler(settingsSchema),
+		decoderx.HTTPDecoderJSONFollowsFormFormat()); err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	// This does not come from the payload!
+	p.Flow = ctxUpdate.Flow.ID.String()
+	if len(p.Unlink) > 0 {
+		p.Method = s.SettingsStrategyID()
+	}
+	if err := s.continueSettingsFlow(w, r, ctxUpdate, &p); err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	return ctxUpdate, nil
+}
+
+// methodEnabledAndAllowedFromRequest works like flow.MethodEnabledAndAllowedFromRequest but also accepts requests
+// without a method which unlink a wallet, because unlink buttons only submit the address.
+func (s *Strategy) methodEnabledAndAllowedFromRequest(r *http.Request) error {
+	var p submitSelfServiceSettingsFlowWithSIWEMethodBody
+	if err := s.hd.Decode(r, &p,
+		decoderx.HTTPKeepRequestBody(true),
+		decoderx.HTTPDecoderSetValidatePayloads(false),
+		decoderx.MustHTTPRawJSONSchemaCompiler(settingsSchema),
+		decoderx.HTTPDecoderJSONFollowsFormFormat()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if len(p.Unlink) > 0 {
+		p.Method = s.SettingsStrategyID()
+	}
+
+	return flow.MethodEnabledAndAllowed(r.Context(), s.SettingsStrategyID(), p.Method, s.d)
+}
+
+func (s *Strategy) continueSettingsFlow(
+	w http.ResponseWriter, r *http.Request,
+	ctxUpdate *settings.UpdateContext, p *submitSelfServiceSettingsFlowWithSIWEMethodBody,
+) error {
+	ctx := r.Context()
+	c := s.d.Config(ctx)
+	if err := flow.MethodEnabledAndAllowed(ctx, s.SettingsStrategyID(), p.Method, s.d); err != nil {
+		return err
+	}
+
+	if err := flow.EnsureCSRF(r, ctxUpdate.Flow.Type, c.DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, p.CSRFToken); err != nil {
+		return err
+	}
+
+	if ctxUpdate.AuthenticatedAt().Add(c.SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(s.SettingsStrategyID())).Before(s.d.Clock().Now()) {
+		return errors.WithStack(settings.NewFlowNeedsReAuth())
+	}
+
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, ctxUpdate.Session.Identity.ID)
+	if err != nil {
+		return err
+	}
+
+	conf, err := credentialsConfig(i)
+	if err != nil {
+		return err
+	}
+
+	if len(p.Unlink) > 0 {
+		if err := s.unlinkWallet(r, i, conf, p.Unlink); err != nil {
+			return err
+		}
+	} else if err := s.linkWallet(r, ctxUpdate, i, conf, p); err != nil {
+		return err
+	}
+
+	if err := setCredentialsConfig(i, conf); err != nil {
+		return err
+	}
+
+	ctxUpdate.UpdateIdentity(i)
+	return nil
+}
+
+func (s *Strategy) linkWallet(r *http.Request, ctxUpdate *settings.UpdateContext, i *identity.Identity, conf *CredentialsConfig, p *submitSelfServiceSettingsFlowWithSIWEMethodBody) error {
+	if len(p.Message) == 0 {
+		return schema.NewRequiredError("#/siwe_message", "siwe_message")
+	} else if len(p.Signature) == 0 {
+		return schema.NewRequiredError("#/siwe_signature", "siwe_signature")
+	}
+
+	nonce := issueNonce(ctxUpdate.Flow.UI)
+	if err := s.d.SettingsFlowPersister().UpdateSettingsFlow(r.Context(), ctxUpdate.Flow); err != nil {
+		return err
+	}
+
+	address, err := s.verify(r, nonce, p.Message, p.Signature)
+	if err != nil {
+		return err
+	}
+
+	if conf.wallet(address) != nil {
+		return errors.WithStack(WalletLinkedValidationError)
+	}
+
+	if _, _, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), s.ID(), strings.ToLower(address)); err == nil {
+		return errors.WithStack(schema.NewDuplicateCredentialsError())
+	} else if !errors.Is(err, sqlcon.ErrNoRows) {
+		return err
+	}
+
+	conf.Wallets = append(conf.Wallets, Wallet{Address: address, LinkedAt: s.d.Clock().Now().UTC()})
+	return nil
+}
+
+func (s *Strategy) unlinkWallet(r *http.Request, i *identity.Identity, conf *CredentialsConfig, address string) error {
+	if conf.wallet(address) == nil {
+		return errors.WithStack(UnknownWalletValidationError)
+	}
+
+	unlinkable, err := s.canUnlink(r, i)
+	if err != nil {
+		return err
+	} else if !unlinkable {
+		return errors.WithStack(LastCredentialValidationError)
+	}
+
+	kept := conf.Wallets[:0]
+	for _, w := range conf.Wallets {
+		if !strings.EqualFold(w.Address, address) {
+			kept = append(kept, w)
+		}
+	}
+	conf.Wallets = kept
+	return nil
+}
+
+// canUnlink returns false if the identity would not be able to sign in any more after a wallet is unlinked.
+func (s *Strategy) canUnlink(r *http.Request, i *identity.Identity) (bool, error) {
+	var count int
+	for _, strategy := range s.d.ActiveCredentialsCounterStrategies(r.Context()) {
+		current, err := strategy.CountActiveCredentials(i.Credentials)
+		if err != nil {
+			return false, err
+		}
+
+		count += current
+		if count > 1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Strategy) PopulateSettingsMethod(r *http.Request, id *identity.Identity, f *settings.Flow) error {
+	confidential, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), id.ID)
+	if err != nil {
+		return err
+	}
+
+	conf, err := credentialsConfig(confidential)
+	if err != nil {
+		return err
+	}
+
+	s.populateNodes(r, f.UI)
+	f.UI.Nodes.Append(node.NewInputField("method", s.SettingsStrategyID(), node.SIWEGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoSelfServiceSettingsUpdateLinkWallet()))
+
+	unlinkable, err := s.canUnlink(r, confidential)
+	if err != nil {
+		return err
+	} else if !unlinkable {
+		return nil
+	}
+
+	for _, w := range conf.Wallets {
+		f.UI.Nodes.Append(node.NewInputField("siwe_unlink", w.Address, node.SIWEGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoSelfServiceSettingsUpdateUnlinkWallet(w.Address)))
+	}
+
+	return nil
+}
+
+func (s *Strategy) handleSettingsError(w http.ResponseWriter, r *http.Request, ctxUpdate *settings.UpdateContext, p *submitSelfServiceSettingsFlowWithSIWEMethodBody, err error) error {
+	// Do not pause flow if the flow type is an API flow as we can't save cookies in those flows.
+	if e := new(settings.FlowNeedsReAuth); errors.As(err, &e) && ctxUpdate.Flow != nil && ctxUpdate.Flow.Type == flow.TypeBrowser {
+		if err := s.d.ContinuityManager().Pause(r.Context(), w, r, settings.ContinuityKey(s.SettingsStrategyID()), settings.ContinuityOptions(p, ctxUpdate.GetSessionIdentity())...); err != nil {
+			return err
+		}
+	}
+
+	if ctxUpdate.Flow != nil {
+		ctxUpdate.Flow.UI.ResetMessages()
+		ctxUpdate.Flow.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	}
+
+	return err
+}
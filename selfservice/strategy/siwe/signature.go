@@ -0,0 +1,101 @@
+package siwe
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	ErrInvalidSignature = errors.New("the signature is invalid")
+	ErrInvalidAddress   = errors.New("the address is invalid")
+)
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		_, _ = h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// hashMessage returns the EIP-191 hash which wallets sign when asked to sign a message using `personal_sign`.
+func hashMessage(message string) []byte {
+	return keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+}
+
+// publicKeyAddress returns the Ethereum address of the public key, which are the last 20 bytes of the hash of the
+// uncompressed key without its prefix.
+func publicKeyAddress(key *secp256k1.PublicKey) []byte {
+	return keccak256(key.SerializeUncompressed()[1:])[12:]
+}
+
+// recoverAddress returns the address of the account which signed the message. The signature consists of r, s, and
+// the recovery ID v, which may be 0, 1, 27, or 28.
+func recoverAddress(message string, signature []byte) ([]byte, error) {
+	if len(signature) != 65 {
+		return nil, errors.WithStack(ErrInvalidSignature)
+	}
+
+	v := signature[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return nil, errors.WithStack(ErrInvalidSignature)
+	}
+
+	// Compact signatures carry the recovery code in front of r and s. Ethereum signatures always refer to the
+	// uncompressed key.
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], signature[:64])
+
+	key, _, err := ecdsa.RecoverCompact(compact, hashMessage(message))
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidSignature)
+	}
+
+	return publicKeyAddress(key), nil
+}
+
+// decodeHex decodes a hex string with or without the 0x prefix.
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+}
+
+// parseAddress decodes an Ethereum address. Addresses using mixed case must carry a valid EIP-55 checksum.
+func parseAddress(address string) ([]byte, error) {
+	if !strings.HasPrefix(address, "0x") || len(address) != 42 {
+		return nil, errors.WithStack(ErrInvalidAddress)
+	}
+
+	b, err := decodeHex(address)
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidAddress)
+	}
+
+	digits := address[2:]
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && checksumAddress(b) != address {
+		return nil, errors.WithStack(ErrInvalidAddress)
+	}
+
+	return b, nil
+}
+
+// checksumAddress encodes the address using the mixed-case checksum defined by EIP-55.
+func checksumAddress(address []byte) string {
+	digits := []byte(hex.EncodeToString(address))
+	hash := keccak256(digits)
+	for i, c := range digits {
+		if c >= 'a' && c <= 'f' && (hash[i/2]>>(4*uint(1-i%2)))&0xf >= 8 {
+			digits[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(digits)
+}
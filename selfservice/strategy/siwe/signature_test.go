@@ -0,0 +1,88 @@
+package siwe
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksumAddress(t *testing.T) {
+	for _, address := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		t.Run("address="+address, func(t *testing.T) {
+			b, err := parseAddress(address)
+			require.NoError(t, err)
+			assert.Equal(t, address, checksumAddress(b))
+
+			_, err = parseAddress(strings.ToLower(address))
+			assert.NoError(t, err)
+
+			_, err = parseAddress(address[:41] + strings.ToUpper(address[41:]))
+			if address[41:] != strings.ToUpper(address[41:]) {
+				assert.ErrorIs(t, err, ErrInvalidAddress)
+			}
+		})
+	}
+
+	for _, address := range []string{"", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg"} {
+		_, err := parseAddress(address)
+		assert.ErrorIs(t, err, ErrInvalidAddress, "%s", address)
+	}
+}
+
+func TestRecoverAddress(t *testing.T) {
+	for k, tc := range []struct {
+		key     int64
+		address string
+	}{
+		{key: 1, address: "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"},
+		{key: 2, address: "0x2B5AD5c4795c026514f8317c7a215E218DcCD6cF"},
+	} {
+		key := big.NewInt(tc.key)
+		require.Equal(t, tc.address, Address(key), "%d", k)
+
+		for _, message := range []string{"hello", "example.com wants you to sign in with your Ethereum account:\n" + tc.address} {
+			sig, err := decodeHex(SignMessage(key, message))
+			require.NoError(t, err)
+
+			address, err := recoverAddress(message, sig)
+			require.NoError(t, err)
+			assert.Equal(t, tc.address, checksumAddress(address))
+
+			// Some wallets use 0 and 1 as the recovery ID.
+			sig[64] -= 27
+			address, err = recoverAddress(message, sig)
+			require.NoError(t, err)
+			assert.Equal(t, tc.address, checksumAddress(address))
+
+			address, err = recoverAddress(message+"!", sig)
+			require.NoError(t, err)
+			assert.NotEqual(t, tc.address, checksumAddress(address))
+		}
+	}
+
+	t.Run("case=rejects malformed signatures", func(t *testing.T) {
+		sig, err := decodeHex(SignMessage(big.NewInt(1), "hello"))
+		require.NoError(t, err)
+
+		_, err = recoverAddress("hello", sig[:64])
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+
+		invalid := append([]byte{}, sig...)
+		invalid[64] = 29
+		_, err = recoverAddress("hello", invalid)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+
+		invalid = append([]byte{}, sig...)
+		copy(invalid[:32], make([]byte, 32))
+		_, err = recoverAddress("hello", invalid)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+}
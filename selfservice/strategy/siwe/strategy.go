@@ -0,0 +1,191 @@
+package siwe
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/randx"
+
+	"github.com/ory/kratos/continuity"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/ui/container"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+var _ login.Strategy = new(Strategy)
+var _ registration.Strategy = new(Strategy)
+var _ settings.Strategy = new(Strategy)
+var _ identity.ActiveCredentialsCounter = new(Strategy)
+
+const nonceNode = "siwe_nonce"
+
+type (
+	strategyDependencies interface {
+		x.CSRFTokenGeneratorProvider
+		x.LoggingProvider
+		x.ClockProvider
+
+		config.Provider
+
+		continuity.ManagementProvider
+
+		identity.PrivilegedPoolProvider
+		identity.ValidationProvider
+		identity.ActiveCredentialsCounterStrategyProvider
+
+		login.FlowPersistenceProvider
+
+		registration.FlowPersistenceProvider
+		registration.HookExecutorProvider
+
+		settings.FlowPersistenceProvider
+	}
+
+	// Strategy implements Sign-In with Ethereum (EIP-4361). Identities sign up and sign in by signing a message
+	// which contains a nonce issued by the flow with their wallet and may link further wallets in the settings flow.
+	Strategy struct {
+		d  strategyDependencies
+		hd *decoderx.HTTP
+	}
+
+	// CredentialsConfig is stored in the identity's siwe credentials.
+	CredentialsConfig struct {
+		// Wallets are the wallets the identity signs in with.
+		Wallets []Wallet `json:"wallets"`
+	}
+
+	Wallet struct {
+		// Address is the EIP-55 checksummed address of the wallet.
+		Address string `json:"address"`
+
+		// LinkedAt is the time the wallet was linked to the identity.
+		LinkedAt time.Time `json:"linked_at"`
+	}
+)
+
+func NewStrategy(d strategyDependencies) *Strategy {
+	return &Strategy{d: d, hd: decoderx.NewHTTP()}
+}
+
+func (s *Strategy) ID() identity.CredentialsType {
+	return identity.CredentialsTypeSIWE
+}
+
+func (s *Strategy) SettingsStrategyID() string {
+	return settings.StrategySIWE
+}
+
+func (s *Strategy) NodeGroup() node.Group {
+	return node.SIWEGroup
+}
+
+func (s *Strategy) CountActiveCredentials(cc map[identity.CredentialsType]identity.Credentials) (count int, err error) {
+	if c, ok := cc[s.ID()]; ok {
+		for _, id := range c.Identifiers {
+			if len(id) > 0 {
+				count++
+			}
+		}
+	}
+	return
+}
+
+func credentialsConfig(i *identity.Identity) (*CredentialsConfig, error) {
+	var conf CredentialsConfig
+	if c, ok := i.GetCredentials(identity.CredentialsTypeSIWE); ok && len(c.Config) > 0 {
+		if err := json.Unmarshal(c.Config, &conf); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return &conf, nil
+}
+
+func setCredentialsConfig(i *identity.Identity, conf *CredentialsConfig) error {
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(conf); err != nil {
+		return errors.WithStack(err)
+	}
+
+	identifiers := make([]string, len(conf.Wallets))
+	for k, w := range conf.Wallets {
+		identifiers[k] = strings.ToLower(w.Address)
+	}
+
+	i.SetCredentials(identity.CredentialsTypeSIWE, identity.Credentials{
+		Type:        identity.CredentialsTypeSIWE,
+		Identifiers: identifiers,
+		Config:      b.Bytes(),
+	})
+	return nil
+}
+
+// wallet returns the linked wallet with the given address or nil if there is none.
+func (c *CredentialsConfig) wallet(address string) *Wallet {
+	for k := range c.Wallets {
+		if strings.EqualFold(c.Wallets[k].Address, address) {
+			return &c.Wallets[k]
+		}
+	}
+	return nil
+}
+
+// issueNonce replaces the nonce which the next signed message has to contain and returns the previous one. Nonces
+// are stored in a hidden node of the flow and are replaced on every attempt, which makes every nonce single-use.
+func issueNonce(c *container.Container) string {
+	previous := currentNonce(c)
+	c.Nodes.Upsert(node.NewInputField(nonceNode, randx.MustString(32, randx.AlphaNum), node.SIWEGroup, node.InputAttributeTypeHidden))
+	return previous
+}
+
+// currentNonce returns the nonce which the next signed message has to contain.
+func currentNonce(c *container.Container) string {
+	if n := c.Nodes.Find(nonceNode); n != nil {
+		nonce, _ := n.GetValue().(string)
+		return nonce
+	}
+	return ""
+}
+
+// populateNodes adds the nodes which every flow of this strategy needs.
+func (s *Strategy) populateNodes(r *http.Request, c *container.Container) {
+	c.SetCSRF(s.d.GenerateCSRFToken(r))
+	issueNonce(c)
+	c.Nodes.Upsert(node.NewInputField("siwe_message", nil, node.SIWEGroup, node.InputAttributeTypeHidden, node.WithRequiredInputAttribute))
+	c.Nodes.Upsert(node.NewInputField("siwe_signature", nil, node.SIWEGroup, node.InputAttributeTypeHidden, node.WithRequiredInputAttribute))
+}
+
+// verify checks that the message was issued for this instance and the nonce and that it was signed by the address it
+// states. It returns the checksummed address.
+func (s *Strategy) verify(r *http.Request, nonce, message, signature string) (string, error) {
+	// Form submissions may use CRLF line endings but wallets sign messages using LF.
+	message = strings.ReplaceAll(message, "\r\n", "\n")
+
+	m, err := ParseMessage(message)
+	if err != nil {
+		return "", schema.NewSIWEMessageInvalidError("#/siwe_message", err.Error())
+	}
+
+	c := s.d.Config(r.Context())
+	if err := m.Verify(c.SIWEDomain(r), c.SIWEChainIDs(), nonce, s.d.Clock().Now()); err != nil {
+		return "", schema.NewSIWEMessageInvalidError("#/siwe_message", err.Error())
+	}
+
+	address, err := m.VerifySignature(message, signature)
+	if err != nil {
+		return "", schema.NewSIWEMessageInvalidError("#/siwe_signature", "it was not signed by the address it contains")
+	}
+
+	return address, nil
+}
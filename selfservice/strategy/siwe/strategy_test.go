@@ -0,0 +1,226 @@
+package siwe_test
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/selfservice/strategy/siwe"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/x"
+)
+
+func TestStrategy(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	testhelpers.StrategyEnable(t, conf, identity.CredentialsTypeSIWE.String(), true)
+	testhelpers.StrategyEnable(t, conf, identity.CredentialsTypePassword.String(), false)
+	testhelpers.StrategyEnable(t, conf, settings.StrategyProfile, false)
+	conf.MustSet(config.ViperKeySIWEDomain, "example.com")
+	conf.MustSet(config.ViperKeySIWEChainIDs, []int{1, 137})
+	conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, "5m")
+
+	_ = testhelpers.NewLoginUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewRegistrationUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewSettingsUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+
+	alice := big.NewInt(1)
+	aliceSecondWallet := big.NewInt(2)
+	mallory := big.NewInt(3)
+
+	message := func(key *big.Int, nonce string, chainID int) string {
+		return (&siwe.Message{
+			Domain:    "example.com",
+			Address:   siwe.Address(key),
+			Statement: "Sign in to the example app.",
+			URI:       "https://example.com/login",
+			Version:   "1",
+			ChainID:   chainID,
+			Nonce:     nonce,
+			IssuedAt:  time.Now().UTC().Truncate(time.Second),
+		}).String()
+	}
+
+	sign := func(key *big.Int, chainID int) func(v url.Values) {
+		return func(v url.Values) {
+			m := message(key, v.Get("siwe_nonce"), chainID)
+			v.Set("method", "siwe")
+			v.Set("siwe_message", m)
+			v.Set("siwe_signature", siwe.SignMessage(key, m))
+		}
+	}
+
+	register := func(t *testing.T, key *big.Int, withValues func(v url.Values), expectCode int) string {
+		return testhelpers.SubmitRegistrationForm(t, true, nil, publicTS, func(v url.Values) {
+			v.Set("traits.email", x.NewUUID().String()+"@ory.sh")
+			sign(key, 1)(v)
+			withValues(v)
+		}, identity.CredentialsTypeSIWE, expectCode, publicTS.URL)
+	}
+
+	login := func(t *testing.T, withValues func(v url.Values), expectCode int) string {
+		return testhelpers.SubmitLoginForm(t, true, nil, publicTS, withValues, identity.CredentialsTypeSIWE, false, expectCode, publicTS.URL)
+	}
+
+	signsInWith := func(t *testing.T, key *big.Int) *identity.Identity {
+		i, _, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypeSIWE, siwe.Address(key))
+		if err != nil {
+			return nil
+		}
+		return i
+	}
+
+	t.Run("case=the flows contain a nonce", func(t *testing.T) {
+		f := testhelpers.InitializeLoginFlowViaAPI(t, new(http.Client), publicTS, false)
+		assert.Len(t, testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes).Get("siwe_nonce"), 32)
+	})
+
+	t.Run("case=signs up with a wallet", func(t *testing.T) {
+		actual := register(t, alice, func(v url.Values) {}, http.StatusOK)
+
+		i := signsInWith(t, alice)
+		require.NotNil(t, i, "%s", actual)
+		assert.Equal(t, gjson.Get(actual, "identity.id").String(), i.ID.String())
+
+		i, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+		require.NoError(t, err)
+		assert.Equal(t, siwe.Address(alice), gjson.GetBytes(i.Credentials[identity.CredentialsTypeSIWE].Config, "wallets.0.address").String())
+	})
+
+	t.Run("case=can not sign up with a wallet twice", func(t *testing.T) {
+		actual := register(t, alice, func(v url.Values) {}, http.StatusBadRequest)
+		assert.EqualValues(t, text.ErrorValidationDuplicateCredentials, gjson.Get(actual, "ui.messages.0.id").Int(), "%s", actual)
+	})
+
+	t.Run("case=signs in with a wallet", func(t *testing.T) {
+		actual := login(t, sign(alice, 137), http.StatusOK)
+		assert.Equal(t, signsInWith(t, alice).ID.String(), gjson.Get(actual, "session.identity.id").String(), "%s", actual)
+		assert.NotEmpty(t, gjson.Get(actual, "session_token").String(), "%s", actual)
+	})
+
+	t.Run("case=rejects unknown wallets", func(t *testing.T) {
+		actual := login(t, sign(mallory, 1), http.StatusBadRequest)
+		assert.EqualValues(t, text.ErrorValidationSIWEWalletUnknown, gjson.Get(actual, "ui.messages.0.id").Int(), "%s", actual)
+	})
+
+	for name, withValues := range map[string]func(v url.Values){
+		"signature of another wallet": func(v url.Values) {
+			sign(alice, 1)(v)
+			v.Set("siwe_signature", siwe.SignMessage(mallory, v.Get("siwe_message")))
+		},
+		"unknown nonce": func(v url.Values) {
+			v.Set("siwe_nonce", "abcdefghijklmnopqrstuvwxyz012345")
+			sign(alice, 1)(v)
+		},
+		"disallowed chain": sign(alice, 5),
+		"other domain": func(v url.Values) {
+			sign(alice, 1)(v)
+			m := strings.Replace(v.Get("siwe_message"), "example.com wants", "evil.com wants", 1)
+			v.Set("siwe_message", m)
+			v.Set("siwe_signature", siwe.SignMessage(alice, m))
+		},
+	} {
+		t.Run("case=rejects messages with "+name, func(t *testing.T) {
+			actual := login(t, withValues, http.StatusBadRequest)
+			assert.EqualValues(t, text.ErrorValidationSIWEMessageInvalid, gjson.Get(actual, "ui.nodes.#(attributes.name==siwe_message).messages.0.id").Int()+gjson.Get(actual, "ui.nodes.#(attributes.name==siwe_signature).messages.0.id").Int(), "%s", actual)
+		})
+	}
+
+	t.Run("case=nonces can only be used once", func(t *testing.T) {
+		f := testhelpers.InitializeLoginFlowViaAPI(t, new(http.Client), publicTS, false)
+		values := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+		sign(alice, 1)(values)
+
+		actual, res := testhelpers.LoginMakeRequest(t, true, f, new(http.Client), testhelpers.EncodeFormAsJSON(t, true, values))
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", actual)
+
+		actual, res = testhelpers.LoginMakeRequest(t, true, f, new(http.Client), testhelpers.EncodeFormAsJSON(t, true, values))
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, "%s", actual)
+		assert.NotEqual(t, values.Get("siwe_nonce"), gjson.Get(actual, "ui.nodes.#(attributes.name==siwe_nonce).attributes.value").String(), "%s", actual)
+	})
+
+	t.Run("case=nonces used by a concurrent submission are rejected", func(t *testing.T) {
+		f := testhelpers.InitializeLoginFlowViaAPI(t, new(http.Client), publicTS, false)
+		values := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+		sign(alice, 1)(values)
+
+		// Another submission used the nonce but did not store the flow's new nodes yet.
+		require.NoError(t, reg.LoginFlowPersister().UseLoginFlowSIWENonce(ctx, uuid.FromStringOrNil(f.Id), values.Get("siwe_nonce"), "concurrent"))
+
+		actual, res := testhelpers.LoginMakeRequest(t, true, f, new(http.Client), testhelpers.EncodeFormAsJSON(t, true, values))
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, "%s", actual)
+		assert.EqualValues(t, text.ErrorValidationSIWEMessageInvalid, gjson.Get(actual, "ui.nodes.#(attributes.name==siwe_message).messages.0.id").Int(), "%s", actual)
+	})
+
+	t.Run("suite=settings", func(t *testing.T) {
+		i, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, signsInWith(t, alice).ID)
+		require.NoError(t, err)
+		hc := testhelpers.NewHTTPClientWithIdentitySessionToken(t, reg, i)
+
+		submit := func(t *testing.T, withValues func(v url.Values), expectCode int) string {
+			return testhelpers.SubmitSettingsForm(t, true, hc, publicTS, func(v url.Values) {
+				v.Del("siwe_unlink")
+				withValues(v)
+			}, expectCode, publicTS.URL+settings.RouteSubmitFlow)
+		}
+
+		unlink := func(key *big.Int) func(v url.Values) {
+			return func(v url.Values) {
+				v.Del("method")
+				v.Set("siwe_unlink", siwe.Address(key))
+			}
+		}
+
+		t.Run("case=can not unlink the only wallet", func(t *testing.T) {
+			f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+			assert.Empty(t, testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes).Get("siwe_unlink"))
+
+			_ = submit(t, unlink(alice), http.StatusBadRequest)
+			assert.NotNil(t, signsInWith(t, alice))
+		})
+
+		t.Run("case=links another wallet", func(t *testing.T) {
+			actual := submit(t, sign(aliceSecondWallet, 1), http.StatusOK)
+			assert.Equal(t, "success", gjson.Get(actual, "flow.state").String(), "%s", actual)
+			require.NotNil(t, signsInWith(t, aliceSecondWallet))
+			assert.Equal(t, i.ID, signsInWith(t, aliceSecondWallet).ID)
+
+			f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+			var unlinkable []string
+			for _, n := range f.Ui.Nodes {
+				if n.Attributes.UiNodeInputAttributes != nil && n.Attributes.UiNodeInputAttributes.Name == "siwe_unlink" {
+					unlinkable = append(unlinkable, *n.Attributes.UiNodeInputAttributes.Value.String)
+				}
+			}
+			assert.ElementsMatch(t, []string{siwe.Address(alice), siwe.Address(aliceSecondWallet)}, unlinkable)
+		})
+
+		t.Run("case=can not link a wallet twice", func(t *testing.T) {
+			_ = submit(t, sign(aliceSecondWallet, 1), http.StatusBadRequest)
+		})
+
+		t.Run("case=unlinks a wallet", func(t *testing.T) {
+			actual := submit(t, unlink(alice), http.StatusOK)
+			assert.Equal(t, "success", gjson.Get(actual, "flow.state").String(), "%s", actual)
+			assert.Nil(t, signsInWith(t, alice))
+			assert.Equal(t, i.ID, signsInWith(t, aliceSecondWallet).ID)
+		})
+	})
+}
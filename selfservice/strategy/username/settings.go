@@ -0,0 +1,257 @@
+package username
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+func (s *Strategy) RegisterSettingsRoutes(_ *x.RouterPublic) {
+}
+
+// nolint:deadcode,unused
+// swagger:parameters submitSelfServiceSettingsFlowWithUsernameMethod
+type submitSelfServiceSettingsFlowWithUsernameMethod struct {
+	// in: body
+	Body submitSelfServiceSettingsFlowWithUsernameMethodBody
+
+	// Flow is flow ID.
+	//
+	// in: query
+	Flow string `json:"flow"`
+}
+
+// swagger:model submitSelfServiceSettingsFlowWithUsernameMethod
+type submitSelfServiceSettingsFlowWithUsernameMethodBody struct {
+	// Username is the new username
+	//
+	// type: string
+	// required: true
+	Username string `json:"username"`
+
+	// CSRFToken is the anti-CSRF token
+	//
+	// type: string
+	CSRFToken string `json:"csrf_token"`
+
+	// Method
+	//
+	// Should be set to username when trying to change the username.
+	//
+	// type: string
+	Method string `json:"method"`
+
+	// Flow is flow ID.
+	//
+	// swagger:ignore
+	Flow string `json:"flow"`
+}
+
+func (p *submitSelfServiceSettingsFlowWithUsernameMethodBody) GetFlowID() uuid.UUID {
+	return x.ParseUUID(p.Flow)
+}
+
+func (p *submitSelfServiceSettingsFlowWithUsernameMethodBody) SetFlowID(rid uuid.UUID) {
+	p.Flow = rid.String()
+}
+
+func (s *Strategy) Settings(w http.ResponseWriter, r *http.Request, f *settings.Flow, ss *session.Session) (*settings.UpdateContext, error) {
+	var p submitSelfServiceSettingsFlowWithUsernameMethodBody
+	ctxUpdate, err := settings.PrepareUpdate(s.d, w, r, f, ss, settings.ContinuityKey(s.SettingsStrategyID()), &p)
+	if errors.Is(err, settings.ErrContinuePreviousAction) {
+		return ctxUpdate, s.continueSettingsFlow(w, r, ctxUpdate, &p)
+	} else if err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	if err := flow.MethodEnabledAndAllowedFromRequest(r, s.SettingsStrategyID(), s.d); err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	if err := s.decodeSettingsFlow(r, &p); err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	// This does not come from the payload!
+	p.Flow = ctxUpdate.Flow.ID.String()
+	if err := s.continueSettingsFlow(w, r, ctxUpdate, &p); err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	return ctxUpdate, nil
+}
+
+func (s *Strategy) decodeSettingsFlow(r *http.Request, dest interface{}) error {
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(settingsSchema)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return s.hd.Decode(r, dest, compiler,
+		decoderx.HTTPDecoderSetValidatePayloads(true),
+		decoderx.HTTPDecoderJSONFollowsFormFormat(),
+	)
+}
+
+func (s *Strategy) continueSettingsFlow(
+	w http.ResponseWriter, r *http.Request,
+	ctxUpdate *settings.UpdateContext, p *submitSelfServiceSettingsFlowWithUsernameMethodBody,
+) error {
+	ctx := r.Context()
+	c := s.d.Config(ctx)
+	if err := flow.MethodEnabledAndAllowed(ctx, s.SettingsStrategyID(), p.Method, s.d); err != nil {
+		return err
+	}
+
+	if err := flow.EnsureCSRF(r, ctxUpdate.Flow.Type, c.DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, p.CSRFToken); err != nil {
+		return err
+	}
+
+	if ctxUpdate.AuthenticatedAt().Add(c.SelfServiceFlowSettingsPrivilegedSessionMaxAgeForMethod(s.SettingsStrategyID())).Before(s.d.Clock().Now()) {
+		return errors.WithStack(settings.NewFlowNeedsReAuth())
+	}
+
+	username := strings.TrimSpace(p.Username)
+	if len(username) == 0 {
+		return schema.NewRequiredError("#/username", "username")
+	}
+
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, ctxUpdate.Session.Identity.ID)
+	if err != nil {
+		return err
+	}
+
+	previous := gjson.GetBytes(i.Traits, c.UsernameTrait()).String()
+	if previous == username {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Did not receive any value changes."))
+	}
+
+	conf, err := credentialsConfig(i)
+	if err != nil {
+		return err
+	}
+
+	now := s.d.Clock().Now().UTC()
+	if cooldown := c.UsernameChangeCooldown(); cooldown > 0 && !conf.ChangedAt.IsZero() && conf.ChangedAt.Add(cooldown).After(now) {
+		return schema.NewUsernameChangeCooldownError("#/username", conf.ChangedAt.Add(cooldown))
+	}
+
+	// Changing the case of the username does not change the identifier.
+	if !strings.EqualFold(previous, username) {
+		if err := s.ensureAvailable(ctx, i, username, now); err != nil {
+			return err
+		}
+	}
+
+	traits, err := sjson.SetBytes(i.Traits, c.UsernameTrait(), username)
+	if err != nil {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to set the username trait: %s", err))
+	}
+	i.Traits = traits
+
+	conf.release(username, now)
+	if lifespan := c.UsernameReservationLifespan(); lifespan > 0 && len(previous) > 0 && !strings.EqualFold(previous, username) {
+		conf.Reservations = append(conf.Reservations, Reservation{Username: strings.ToLower(previous), ExpiresAt: now.Add(lifespan)})
+	}
+	conf.ChangedAt = now
+
+	if err := setCredentialsConfig(i, conf); err != nil {
+		return err
+	}
+
+	ctxUpdate.UpdateIdentity(i)
+	return nil
+}
+
+// ensureAvailable returns an error if username is used to sign in to another identity or is reserved by one.
+// Reservations of other identities which expired are released. Identifiers of other credential types are only
+// detected by the unique constraint once the identity is written.
+func (s *Strategy) ensureAvailable(ctx context.Context, i *identity.Identity, username string, now time.Time) error {
+	owner, _, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, username)
+	if err == nil && owner.ID != i.ID {
+		return schema.NewUsernameUnavailableError("#/username")
+	} else if err != nil && !errors.Is(err, sqlcon.ErrNoRows) {
+		return err
+	}
+
+	owner, _, err = s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypeUsernameReservation, username)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if owner.ID == i.ID {
+		// Identities may take back the usernames they reserved.
+		return nil
+	}
+
+	owner, err = s.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, owner.ID)
+	if err != nil {
+		return err
+	}
+
+	conf, err := credentialsConfig(owner)
+	if err != nil {
+		return err
+	}
+
+	if r := conf.reservation(username); r == nil || r.ExpiresAt.After(now) {
+		return schema.NewUsernameUnavailableError("#/username")
+	}
+
+	conf.release(username, now)
+	if err := setCredentialsConfig(owner, conf); err != nil {
+		return err
+	}
+
+	s.d.Logger().
+		WithField("identity_id", owner.ID).
+		WithSensitiveField("username", strings.ToLower(username)).
+		Debug("Released an expired username reservation.")
+	return s.d.IdentityManager().Update(ctx, owner, identity.ManagerAllowWriteProtectedTraits)
+}
+
+func (s *Strategy) PopulateSettingsMethod(r *http.Request, id *identity.Identity, f *settings.Flow) error {
+	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	f.UI.Nodes.Upsert(node.NewInputField("username", gjson.GetBytes(id.Traits, s.d.Config(r.Context()).UsernameTrait()).String(), node.UsernameGroup,
+		node.InputAttributeTypeText,
+		node.WithRequiredInputAttribute).
+		WithMetaLabel(text.NewInfoNodeInputUsername()))
+	f.UI.Nodes.Append(node.NewInputField("method", "username", node.UsernameGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSave()))
+
+	return nil
+}
+
+func (s *Strategy) handleSettingsError(w http.ResponseWriter, r *http.Request, ctxUpdate *settings.UpdateContext, p *submitSelfServiceSettingsFlowWithUsernameMethodBody, err error) error {
+	// Do not pause flow if the flow type is an API flow as we can't save cookies in those flows.
+	if e := new(settings.FlowNeedsReAuth); errors.As(err, &e) && ctxUpdate.Flow != nil && ctxUpdate.Flow.Type == flow.TypeBrowser {
+		if err := s.d.ContinuityManager().Pause(r.Context(), w, r, settings.ContinuityKey(s.SettingsStrategyID()), settings.ContinuityOptions(p, ctxUpdate.GetSessionIdentity())...); err != nil {
+			return err
+		}
+	}
+
+	if ctxUpdate.Flow != nil {
+		ctxUpdate.Flow.UI.ResetMessages()
+		ctxUpdate.Flow.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	}
+
+	return err
+}
@@ -0,0 +1,131 @@
+package username_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/x"
+)
+
+func TestSettings(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	testhelpers.StrategyEnable(t, conf, settings.StrategyUsername, true)
+	testhelpers.StrategyEnable(t, conf, settings.StrategyProfile, false)
+	conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, "5m")
+	conf.MustSet(config.ViperKeyUsernameReservationLifespan, "1h")
+
+	_ = testhelpers.NewSettingsUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+
+	newUser := func(t *testing.T, username string) (*identity.Identity, *http.Client) {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"email":"` + x.NewUUID().String() + `@ory.sh","username":"` + username + `"}`)
+		require.NoError(t, reg.IdentityManager().Create(ctx, i))
+		return i, testhelpers.NewHTTPClientWithIdentitySessionToken(t, reg, i)
+	}
+
+	changeUsername := func(t *testing.T, hc *http.Client, username string, expectCode int) string {
+		return testhelpers.SubmitSettingsForm(t, true, hc, publicTS, func(v url.Values) {
+			v.Set("method", "username")
+			v.Set("username", username)
+		}, expectCode, publicTS.URL+settings.RouteSubmitFlow)
+	}
+
+	expectUnavailable := func(t *testing.T, hc *http.Client, username string) {
+		actual := changeUsername(t, hc, username, http.StatusBadRequest)
+		assert.EqualValues(t, text.ErrorValidationSettingsUsernameUnavailable, gjson.Get(actual, "ui.nodes.#(attributes.name==username).messages.0.id").Int(), "%s", actual)
+	}
+
+	signsInWith := func(t *testing.T, username string) *identity.Identity {
+		i, _, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, username)
+		if err != nil {
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			return nil
+		}
+		return i
+	}
+
+	alice, aliceClient := newUser(t, "alice")
+	bob, bobClient := newUser(t, "bob")
+
+	t.Run("case=shows the current username", func(t *testing.T) {
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, aliceClient, publicTS)
+		values := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+		assert.Equal(t, "alice", values.Get("username"))
+	})
+
+	t.Run("case=changes the username and reserves the old one", func(t *testing.T) {
+		actual := changeUsername(t, aliceClient, "Alice-New", http.StatusOK)
+		assert.Equal(t, "success", gjson.Get(actual, "flow.state").String(), "%s", actual)
+		assert.Equal(t, "Alice-New", gjson.Get(actual, "identity.traits.username").String(), "%s", actual)
+
+		require.NotNil(t, signsInWith(t, "alice-new"))
+		assert.Equal(t, alice.ID, signsInWith(t, "ALICE-NEW").ID)
+		assert.Nil(t, signsInWith(t, "alice"))
+
+		owner, _, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypeUsernameReservation, "Alice")
+		require.NoError(t, err)
+		assert.Equal(t, alice.ID, owner.ID)
+	})
+
+	t.Run("case=reserved and used usernames are not available", func(t *testing.T) {
+		expectUnavailable(t, bobClient, "alice")
+		expectUnavailable(t, bobClient, "ALICE-new")
+		assert.Equal(t, bob.ID, signsInWith(t, "bob").ID)
+	})
+
+	t.Run("case=the previous owner can take back a reserved username", func(t *testing.T) {
+		_ = changeUsername(t, aliceClient, "alice", http.StatusOK)
+		assert.Equal(t, alice.ID, signsInWith(t, "alice").ID)
+
+		_, _, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypeUsernameReservation, "alice")
+		assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+	})
+
+	t.Run("case=expired reservations are released", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyUsernameReservationLifespan, "1ns")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyUsernameReservationLifespan, "1h")
+		})
+
+		_ = changeUsername(t, aliceClient, "alice-expired", http.StatusOK)
+		_ = changeUsername(t, bobClient, "alice", http.StatusOK)
+		assert.Equal(t, bob.ID, signsInWith(t, "alice").ID)
+
+		_, _, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypeUsernameReservation, "alice")
+		assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+	})
+
+	t.Run("case=enforces the cooldown", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyUsernameChangeCooldown, "1h")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyUsernameChangeCooldown, "")
+		})
+
+		actual := changeUsername(t, aliceClient, "alice-too-soon", http.StatusBadRequest)
+		assert.EqualValues(t, text.ErrorValidationSettingsUsernameChangeCooldown, gjson.Get(actual, "ui.nodes.#(attributes.name==username).messages.0.id").Int(), "%s", actual)
+		assert.Nil(t, signsInWith(t, "alice-too-soon"))
+	})
+
+	t.Run("case=validates the username against the identity schema", func(t *testing.T) {
+		actual := changeUsername(t, aliceClient, "al", http.StatusBadRequest)
+		assert.Nil(t, signsInWith(t, "al"), "%s", actual)
+	})
+}
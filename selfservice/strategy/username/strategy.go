@@ -0,0 +1,122 @@
+package username
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/decoderx"
+
+	"github.com/ory/kratos/continuity"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+var _ settings.Strategy = new(Strategy)
+
+type (
+	strategyDependencies interface {
+		x.CSRFTokenGeneratorProvider
+		x.LoggingProvider
+		x.ClockProvider
+
+		config.Provider
+
+		continuity.ManagementProvider
+
+		identity.PrivilegedPoolProvider
+		identity.ManagementProvider
+	}
+
+	// Strategy is a settings strategy which changes the username identities sign in with. The username is stored
+	// in a trait which the identity schema marks as a password identifier.
+	Strategy struct {
+		d  strategyDependencies
+		hd *decoderx.HTTP
+	}
+
+	// CredentialsConfig is stored in the identity's username_reservation credentials.
+	CredentialsConfig struct {
+		// ChangedAt is the time the username was last changed using this strategy.
+		ChangedAt time.Time `json:"changed_at"`
+
+		// Reservations are the usernames this identity gave up and which other identities can not claim yet.
+		Reservations []Reservation `json:"reservations"`
+	}
+
+	Reservation struct {
+		// Username is the lowercase username.
+		Username string `json:"username"`
+
+		// ExpiresAt is the time from which other identities can claim the username.
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+)
+
+func NewStrategy(d strategyDependencies) *Strategy {
+	return &Strategy{d: d, hd: decoderx.NewHTTP()}
+}
+
+func (s *Strategy) SettingsStrategyID() string {
+	return settings.StrategyUsername
+}
+
+func (s *Strategy) NodeGroup() node.Group {
+	return node.UsernameGroup
+}
+
+func credentialsConfig(i *identity.Identity) (*CredentialsConfig, error) {
+	var conf CredentialsConfig
+	if c, ok := i.GetCredentials(identity.CredentialsTypeUsernameReservation); ok && len(c.Config) > 0 {
+		if err := json.Unmarshal(c.Config, &conf); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return &conf, nil
+}
+
+func setCredentialsConfig(i *identity.Identity, conf *CredentialsConfig) error {
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(conf); err != nil {
+		return errors.WithStack(err)
+	}
+
+	identifiers := make([]string, len(conf.Reservations))
+	for k, r := range conf.Reservations {
+		identifiers[k] = r.Username
+	}
+
+	i.SetCredentials(identity.CredentialsTypeUsernameReservation, identity.Credentials{
+		Type:        identity.CredentialsTypeUsernameReservation,
+		Identifiers: identifiers,
+		Config:      b.Bytes(),
+	})
+	return nil
+}
+
+// reservation returns the reservation of username or nil if there is none.
+func (c *CredentialsConfig) reservation(username string) *Reservation {
+	for k := range c.Reservations {
+		if c.Reservations[k].Username == strings.ToLower(username) {
+			return &c.Reservations[k]
+		}
+	}
+	return nil
+}
+
+// release removes the reservation of username as well as all expired reservations.
+func (c *CredentialsConfig) release(username string, now time.Time) {
+	kept := c.Reservations[:0]
+	for _, r := range c.Reservations {
+		if r.Username != strings.ToLower(username) && r.ExpiresAt.After(now) {
+			kept = append(kept, r)
+		}
+	}
+	c.Reservations = kept
+}
@@ -1,7 +1,10 @@
 package session
 
 import (
+	"context"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
@@ -12,7 +15,10 @@ import (
 
 	"github.com/ory/herodot"
 
+	"github.com/ory/kratos/approval"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/x"
 )
 
@@ -23,6 +29,13 @@ type (
 		x.WriterProvider
 		x.LoggingProvider
 		x.CSRFProvider
+		x.ClockProvider
+		config.Provider
+		identity.PrivilegedPoolProvider
+		identity.HandlerProvider
+		approval.MiddlewareProvider
+		identity.RelationshipPersistenceProvider
+		IdentityTraitsSchemas(ctx context.Context) schema.Schemas
 	}
 	HandlerProvider interface {
 		SessionHandler() *Handler
@@ -46,11 +59,14 @@ const (
 	RouteWhoami = "/sessions/whoami"
 	RouteRevoke = "/sessions"
 	// SessionsWhoisPath  = "/sessions/whois"
+
+	RouteIdentitySessions = identity.RouteBase + "/:id/sessions"
 )
 
 func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
 	h.r.CSRFHandler().ExemptPath(RouteWhoami)
 	h.r.CSRFHandler().ExemptPath(RouteRevoke)
+	h.r.CSRFHandler().ExemptPath(RouteActOnBehalf)
 
 	for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
 		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace} {
@@ -58,10 +74,15 @@ func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
 	}
 
 	public.DELETE(RouteRevoke, h.revoke)
+	public.POST(RouteActOnBehalf, h.actOnBehalf)
 }
 
 func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 	// admin.GET(SessionsWhoisPath, h.fromPath)
+	admin.POST(RouteIdentitySessions, h.issueServiceAccountSession)
+	admin.GET(RouteIdentitySessions, h.listIdentitySessions)
+	admin.DELETE(RouteIdentitySessions, h.r.ApprovalMiddleware().Wrap(h.revokeIdentitySessions))
+	admin.DELETE(RouteAdminSession, h.revokeSessionByID)
 }
 
 // swagger:parameters revokeSession
@@ -119,6 +140,122 @@ func (h *Handler) revoke(w http.ResponseWriter, r *http.Request, _ httprouter.Pa
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// swagger:parameters adminIssueServiceAccountSession
+// nolint:deadcode,unused
+type adminIssueServiceAccountSessionParameters struct {
+	// ID is the service account's identity ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// in: body
+	Body issueServiceAccountSession
+}
+
+type issueServiceAccountSession struct {
+	// ExpiresAt sets when the session expires. Defaults to now plus `session.service_account_lifespan`.
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	// Scopes limits what the session may be used for. Scopes must not contain the `|` character.
+	Scopes []string `json:"scopes"`
+}
+
+// The response for issuing a service account session.
+//
+// swagger:model serviceAccountSession
+type ServiceAccountSessionResponse struct {
+	// The Session Token
+	//
+	// It is used the same way as session tokens issued by API flows:
+	//
+	// 		Authorization: bearer ${session-token}
+	//
+	// required: true
+	Token string `json:"session_token"`
+
+	// The Session
+	//
+	// required: true
+	Session *Session `json:"session"`
+}
+
+// swagger:route POST /identities/{id}/sessions admin adminIssueServiceAccountSession
+//
+// Issue a Session Token for a Service Account
+//
+// Issues a long-lived session token for an identity whose schema is configured as a service account schema.
+// Service accounts can not use the self-service login flow so this is the only way to obtain a session for them.
+//
+// The session can be limited to a list of scopes which are returned as part of the session. Enforcing them is
+// up to the service consuming the session.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: serviceAccountSession
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) issueServiceAccountSession(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var p issueServiceAccountSession
+	if err := h.dx.Decode(r, &p,
+		decoderx.HTTPJSONDecoder(),
+		decoderx.HTTPDecoderAllowedMethods("POST")); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	i, err := h.r.PrivilegedIdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if !identity.IsServiceAccount(h.r.Config(r.Context()), i) {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("Session tokens can only be issued for service accounts.")))
+		return
+	}
+
+	for _, scope := range p.Scopes {
+		if len(scope) == 0 || strings.Contains(scope, "|") {
+			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Scope %q is invalid. Scopes must not be empty or contain the \"|\" character.", scope)))
+			return
+		}
+	}
+
+	now := h.r.Clock().Now().UTC()
+	s := NewActiveSession(i, h.r.Config(r.Context()), now)
+	s.ExpiresAt = now.Add(h.r.Config(r.Context()).SessionServiceAccountLifespan())
+	if p.ExpiresAt != nil {
+		if !p.ExpiresAt.After(now) {
+			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The session expiry must be in the future.")))
+			return
+		}
+		s.ExpiresAt = p.ExpiresAt.UTC()
+	}
+	s.Scopes = p.Scopes
+
+	if err := h.r.SessionPersister().CreateSession(r.Context(), s); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Audit().
+		WithRequest(r).
+		WithField("session_id", s.ID).
+		WithField("identity_id", i.ID).
+		Info("A session token was issued for a service account using the admin API.")
+
+	h.r.Writer().WriteCode(w, r, http.StatusCreated, &ServiceAccountSessionResponse{Token: s.Token, Session: s.Declassify()})
+}
+
 // nolint:deadcode,unused
 // swagger:parameters whoami
 type whoamiParameters struct {
@@ -127,6 +264,15 @@ type whoamiParameters struct {
 
 	// in: header
 	Authorization string `json:"Authorization"`
+
+	// Trait Fields
+	//
+	// Only return the given trait paths of the session's identity, for example `email` or `name.first`.
+	// Can be repeated or contain a comma-separated list of paths. If omitted, all traits are returned.
+	//
+	// required: false
+	// in: query
+	Fields []string `json:"fields"`
 }
 
 // swagger:route GET /sessions/whoami public whoami
@@ -136,6 +282,7 @@ type whoamiParameters struct {
 // Uses the HTTP Headers in the GET request to determine (e.g. by using checking the cookies) who is authenticated.
 // Returns a session object in the body or 401 if the credentials are invalid or no credentials were sent.
 // Additionally when the request it successful it adds the user ID to the 'X-Kratos-Authenticated-Identity-Id' header in the response.
+// Returns 403 if the session may only be used to set a new password because an administrator forced a password reset.
 //
 // This endpoint is useful for reverse proxies and API Gateways.
 //
@@ -150,18 +297,53 @@ type whoamiParameters struct {
 //     Responses:
 //       200: session
 //       401: genericError
+//       403: genericError
 //       500: genericError
 func (h *Handler) whoami(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	s, err := h.r.SessionManager().FetchFromRequest(r.Context(), r)
+	s, err := h.r.SessionManager().FetchFromRequestWithAffinity(r.Context(), r)
 	if err != nil {
 		h.r.Audit().WithRequest(r).WithError(err).Info("No valid session cookie found.")
 		h.r.Writer().WriteError(w, r, herodot.ErrUnauthorized.WithWrap(err).WithReasonf("No valid session cookie found."))
 		return
 	}
 
+	if s.RequiresPasswordReset() {
+		h.r.Writer().WriteError(w, r, errors.WithStack(ErrPasswordResetRequired))
+		return
+	}
+
 	// s.Devices = nil
 	s.Identity = s.Identity.CopyWithoutCredentials()
 
+	fields, err := identity.TraitFieldsFromRequest(r)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := identity.ResolveProfiles(h.r.IdentityTraitsSchemas(r.Context()), s.Identity); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if s.Identity.Traits, err = identity.ProjectTraits(s.Identity.Traits, fields); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if h.r.Config(r.Context()).IdentityRelationshipsSessionAnnotations() {
+		relationships, err := h.r.IdentityRelationshipPersister().ListIdentityRelationships(r.Context(), s.Identity.ID)
+		if err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+
+		s.Relationships = make([]identity.RelationshipAnnotation, len(relationships))
+		for k := range relationships {
+			s.Relationships[k] = relationships[k].Annotate(s.Identity.ID)
+		}
+	}
+
 	// Set userId as the X-Kratos-Authenticated-Identity-Id header.
 	w.Header().Set("X-Kratos-Authenticated-Identity-Id", s.Identity.ID.String())
 
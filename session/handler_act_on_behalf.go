@@ -0,0 +1,166 @@
+package session
+
+import (
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/decoderx"
+
+	"github.com/ory/kratos/identity"
+)
+
+const RouteActOnBehalf = "/sessions/act-on-behalf"
+
+// ErrActOnBehalfForbidden is returned if the session's identity may not act on behalf of the requested identity.
+var ErrActOnBehalfForbidden = herodot.ErrForbidden.
+	WithError("acting on behalf of this identity is not allowed").
+	WithReason("You are not allowed to act on behalf of this identity.")
+
+// swagger:parameters actOnBehalf
+// nolint:deadcode,unused
+type actOnBehalfParameters struct {
+	// in: body
+	// required: true
+	Body actOnBehalf
+}
+
+type actOnBehalf struct {
+	// IdentityID is the ID of the identity to act on behalf of.
+	//
+	// required: true
+	IdentityID uuid.UUID `json:"identity_id"`
+}
+
+// The response for acting on behalf of another identity.
+//
+// swagger:model actOnBehalfSession
+type ActOnBehalfSessionResponse struct {
+	// The Session Token
+	//
+	// It is used the same way as session tokens issued by API flows:
+	//
+	// 		Authorization: bearer ${session-token}
+	//
+	// required: true
+	Token string `json:"session_token"`
+
+	// The Session
+	//
+	// Its identity is the identity acted on behalf of and its actor is the identity which obtained it.
+	//
+	// required: true
+	Session *Session `json:"session"`
+}
+
+// swagger:route POST /sessions/act-on-behalf public actOnBehalf
+//
+// Obtain a Session Acting on Behalf of Another Identity
+//
+// Issues a session token for the requested identity to the identity the current session belongs to, if a
+// relationship allows it. Guardians can act on behalf of their child accounts and delegates on behalf of their
+// primary accounts, if enabled at `identity.relationships.act_on_behalf.<relationship type>.enabled`.
+//
+// The returned session's identity is the identity acted on behalf of, and its `actor` records the acting
+// identity and the relationship. The session does not outlive the current session and stops working once the
+// current session is revoked, the acting identity is deactivated, or the relationship is deleted. Sessions acting
+// on behalf of another identity can not be used to obtain further such sessions.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       sessionToken:
+//
+//     Responses:
+//       201: actOnBehalfSession
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) actOnBehalf(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	actor, err := h.r.SessionManager().FetchFromRequest(r.Context(), r)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, herodot.ErrUnauthorized.WithWrap(err).WithReasonf("No valid session cookie found."))
+		return
+	}
+
+	if actor.RequiresPasswordReset() {
+		h.r.Writer().WriteError(w, r, errors.WithStack(ErrPasswordResetRequired))
+		return
+	} else if actor.Actor != nil {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrForbidden.WithReason("Sessions acting on behalf of another identity can not be used to act on behalf of further identities.")))
+		return
+	}
+
+	var p actOnBehalf
+	if err := h.dx.Decode(r, &p,
+		decoderx.HTTPJSONDecoder(),
+		decoderx.HTTPDecoderAllowedMethods("POST")); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	relationships, err := h.r.IdentityRelationshipPersister().ListIdentityRelationships(r.Context(), actor.IdentityID)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	var relationship *identity.Relationship
+	for k := range relationships {
+		rel := &relationships[k]
+		if rel.ActorID() == actor.IdentityID && rel.SubjectID() == p.IdentityID &&
+			h.r.Config(r.Context()).IdentityRelationshipsActOnBehalf(string(rel.Type)).Enabled {
+			relationship = rel
+			break
+		}
+	}
+
+	if relationship == nil {
+		h.r.Writer().WriteError(w, r, errors.WithStack(ErrActOnBehalfForbidden))
+		return
+	}
+
+	subject, err := h.r.PrivilegedIdentityPool().GetIdentity(r.Context(), relationship.SubjectID())
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	now := h.r.Clock().Now().UTC()
+	s := NewActiveSession(subject, h.r.Config(r.Context()), now)
+	s.ExpiresAt = now.Add(h.r.Config(r.Context()).IdentityRelationshipsActOnBehalf(string(relationship.Type)).Lifespan)
+	if s.ExpiresAt.After(actor.ExpiresAt) {
+		s.ExpiresAt = actor.ExpiresAt
+	}
+	s.Actor = &Actor{
+		IdentityID:       actor.IdentityID,
+		SessionID:        actor.ID,
+		RelationshipID:   relationship.ID,
+		RelationshipType: relationship.Type,
+	}
+
+	if err := h.r.SessionPersister().CreateSession(r.Context(), s); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Audit().
+		WithRequest(r).
+		WithField("session_id", s.ID).
+		WithField("identity_id", subject.ID).
+		WithField("actor_identity_id", actor.IdentityID).
+		WithField("relationship_id", relationship.ID).
+		Info("A session acting on behalf of another identity was issued.")
+
+	h.r.Writer().WriteCode(w, r, http.StatusCreated, &ActOnBehalfSessionResponse{Token: s.Token, Session: s.Declassify()})
+}
@@ -0,0 +1,204 @@
+package session
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/x"
+)
+
+const RouteAdminSession = "/sessions/:id"
+
+// A list of sessions.
+//
+// swagger:response sessionList
+// nolint:deadcode,unused
+type sessionList struct {
+	// in: body
+	Body []Session
+}
+
+// swagger:parameters adminListIdentitySessions
+// nolint:deadcode,unused
+type adminListIdentitySessionsParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// Active Sessions
+	//
+	// If true, only sessions which are active are returned. If false, only sessions which were revoked or
+	// expired are returned. If omitted, all sessions are returned.
+	//
+	// required: false
+	// in: query
+	Active bool `json:"active"`
+
+	// Items per Page
+	//
+	// This is the number of items per page.
+	//
+	// required: false
+	// in: query
+	// default: 100
+	// min: 1
+	// max: 500
+	PerPage int `json:"per_page"`
+
+	// Pagination Page
+	//
+	// required: false
+	// in: query
+	// default: 0
+	// min: 0
+	Page int `json:"page"`
+}
+
+// swagger:route GET /identities/{id}/sessions admin adminListIdentitySessions
+//
+// List the Sessions of an Identity
+//
+// This endpoint returns the sessions of an identity, newest first, for example to review where an identity is
+// signed in after its account was compromised. The identity's sensitive traits are masked like they are by the
+// identity endpoints.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: sessionList
+//       400: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) listIdentitySessions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var active *bool
+	if raw := r.URL.Query().Get("active"); len(raw) > 0 {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The query parameter active must be true or false.")))
+			return
+		}
+		active = &parsed
+	}
+
+	i, err := h.r.PrivilegedIdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	page, itemsPerPage := x.ParsePagination(r)
+	sessions, total, err := h.r.SessionPersister().ListSessionsByIdentity(r.Context(), i.ID, active, page, itemsPerPage)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	// The identity is masked like the identity endpoints mask it.
+	identity, err := h.r.IdentityHandler().Present(r, i.CopyWithoutCredentials())
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	for k := range sessions {
+		sessions[k].Identity = identity
+	}
+
+	x.PaginationHeader(w, urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), "identities", i.ID.String(), "sessions"), total, page, itemsPerPage)
+	h.r.Writer().Write(w, r, x.PaginationBody(r, sessions, total, page, itemsPerPage))
+}
+
+// swagger:parameters adminRevokeIdentitySessions
+// nolint:deadcode,unused
+type adminRevokeIdentitySessionsParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+}
+
+// swagger:route DELETE /identities/{id}/sessions admin adminRevokeIdentitySessions
+//
+// Revoke All Sessions of an Identity
+//
+// This endpoint revokes all sessions of an identity, for example after its account was compromised. Revoked
+// sessions can no longer be used but are still listed.
+//
+// If `admin_approvals.enabled` is set, this endpoint requires an approval token in the `X-Kratos-Approval` header.
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) revokeIdentitySessions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	i, err := h.r.PrivilegedIdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := h.r.SessionPersister().RevokeSessionsByIdentity(r.Context(), i.ID); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Audit().
+		WithRequest(r).
+		WithField("identity_id", i.ID).
+		Info("All sessions of an identity were revoked using the admin API.")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// swagger:parameters adminRevokeSession
+// nolint:deadcode,unused
+type adminRevokeSessionParameters struct {
+	// ID is the session's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+}
+
+// swagger:route DELETE /sessions/{id} admin adminRevokeSession
+//
+// Revoke a Session
+//
+// This endpoint revokes a session by its ID. The revoked session can no longer be used but is still listed.
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       404: genericError
+//       500: genericError
+func (h *Handler) revokeSessionByID(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	sid := x.ParseUUID(ps.ByName("id"))
+	if err := h.r.SessionPersister().RevokeSession(r.Context(), sid); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Audit().
+		WithRequest(r).
+		WithField("session_id", sid).
+		Info("A session was revoked using the admin API.")
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,16 +3,20 @@ package session_test
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ory/kratos/corpx"
 
+	"github.com/gofrs/uuid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 
 	"github.com/ory/kratos-client-go"
 	"github.com/ory/kratos/driver/config"
@@ -76,6 +80,54 @@ func TestSessionWhoAmI(t *testing.T) {
 				assert.NotEmpty(t, res.Header.Get("X-Kratos-Authenticated-Identity-Id"))
 			})
 		}
+
+		t.Run("case=projects traits", func(t *testing.T) {
+			res, err := client.Get(ts.URL + RouteWhoami + "?fields=does-not-exist")
+			require.NoError(t, err)
+			defer res.Body.Close()
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusOK, res.StatusCode)
+			assert.JSONEq(t, `{}`, gjson.GetBytes(body, "identity.traits").Raw, "%s", body)
+		})
+
+		t.Run("case=resolves the display name", func(t *testing.T) {
+			res, err := client.Get(ts.URL + RouteWhoami)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, gjson.GetBytes(body, "identity.id").String(), gjson.GetBytes(body, "identity.display_name").String(), "%s", body)
+		})
+
+		t.Run("case=annotates relationships", func(t *testing.T) {
+			whoami := func(t *testing.T) []byte {
+				res, err := client.Get(ts.URL + RouteWhoami)
+				require.NoError(t, err)
+				defer res.Body.Close()
+				body, err := ioutil.ReadAll(res.Body)
+				require.NoError(t, err)
+				require.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+				return body
+			}
+
+			guardian := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), guardian))
+			require.NoError(t, reg.IdentityRelationshipPersister().CreateIdentityRelationship(context.Background(), &identity.Relationship{
+				Type: identity.RelationshipTypeGuardian, IdentityID: guardian.ID, RelatedIdentityID: x.ParseUUID(gjson.GetBytes(whoami(t), "identity.id").String())}))
+
+			body := whoami(t)
+			assert.False(t, gjson.GetBytes(body, "relationships").Exists(), "%s", body)
+
+			conf.MustSet(config.ViperKeyIdentityRelationshipsSessionAnnotations, true)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyIdentityRelationshipsSessionAnnotations, false)
+			})
+
+			body = whoami(t)
+			assert.JSONEq(t, `[{"type":"guardian","role":"child","identity_id":"`+guardian.ID.String()+`"}]`, gjson.GetBytes(body, "relationships").Raw, "%s", body)
+		})
 	})
 }
 
@@ -96,7 +148,328 @@ func TestSessionRevoke(t *testing.T) {
 	actual, err := reg.SessionPersister().GetSession(context.Background(), sess.ID)
 	require.NoError(t, err)
 	assert.False(t, actual.Active)
-	assert.False(t, actual.IsActive())
+	assert.False(t, actual.IsActive(time.Now()))
+}
+
+func TestSessionWhoAmIPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
+
+	i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+	sess := NewActiveSession(i, conf, time.Now())
+	sess.Scopes = append(sess.Scopes, ScopePasswordReset)
+	require.NoError(t, reg.SessionPersister().CreateSession(ctx, sess))
+
+	whoami := func(t *testing.T) int {
+		req, err := http.NewRequest("GET", publicTS.URL+RouteWhoami, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+sess.Token)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+
+	assert.Equal(t, http.StatusForbidden, whoami(t))
+
+	sess.CompletePasswordReset()
+	require.NoError(t, reg.SessionPersister().UpdateSession(ctx, sess))
+	assert.Equal(t, http.StatusOK, whoami(t))
+}
+
+func TestIssueServiceAccountSession(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	publicTS, adminTS := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
+	conf.MustSet(config.ViperKeyIdentitySchemas, []config.Schema{{ID: "service", URL: "file://stub/identity.schema.json", ServiceAccount: true}})
+
+	human := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, human))
+	service := &identity.Identity{SchemaID: "service", Traits: identity.Traits(`{"baz":"bot"}`)}
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, service))
+
+	issue := func(t *testing.T, id uuid.UUID, body string) (*http.Response, []byte) {
+		res, err := http.Post(adminTS.URL+"/identities/"+id.String()+"/sessions", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res, b
+	}
+
+	t.Run("case=rejects regular identities", func(t *testing.T) {
+		res, _ := issue(t, human.ID, `{}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("case=rejects invalid scopes", func(t *testing.T) {
+		res, _ := issue(t, service.ID, `{"scopes":["a|b"]}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("case=issues a scoped session token", func(t *testing.T) {
+		res, body := issue(t, service.ID, `{"scopes":["read","write"]}`)
+		require.Equal(t, http.StatusCreated, res.StatusCode, "%s", body)
+		assert.Equal(t, service.ID.String(), gjson.GetBytes(body, "session.identity.id").String(), "%s", body)
+		assert.Equal(t, `["read","write"]`, gjson.GetBytes(body, "session.scopes").Raw, "%s", body)
+		assert.True(t, gjson.GetBytes(body, "session.expires_at").Time().After(time.Now().Add(conf.SessionLifespan())), "%s", body)
+
+		req, err := http.NewRequest("GET", publicTS.URL+RouteWhoami, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+gjson.GetBytes(body, "session_token").String())
+		whoami, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer whoami.Body.Close()
+		assert.Equal(t, http.StatusOK, whoami.StatusCode)
+	})
+}
+
+func TestAdminIdentitySessions(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	publicTS, adminTS := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
+
+	i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+	sessions := make([]*Session, 3)
+	for k := range sessions {
+		sessions[k] = NewActiveSession(i, conf, time.Now().Add(time.Duration(k)*time.Minute))
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, sessions[k]))
+	}
+
+	do := func(t *testing.T, method, path string) (*http.Response, []byte) {
+		req, err := http.NewRequest(method, adminTS.URL+path, nil)
+		require.NoError(t, err)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res, x.MustReadAll(res.Body)
+	}
+
+	whoami := func(t *testing.T, s *Session) int {
+		req, err := http.NewRequest("GET", publicTS.URL+RouteWhoami, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+
+	t.Run("case=lists the sessions newest first", func(t *testing.T) {
+		res, body := do(t, "GET", "/identities/"+i.ID.String()+"/sessions")
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Len(t, gjson.ParseBytes(body).Array(), 3, "%s", body)
+		assert.Equal(t, sessions[2].ID.String(), gjson.GetBytes(body, "0.id").String(), "%s", body)
+		assert.Equal(t, i.ID.String(), gjson.GetBytes(body, "0.identity.id").String(), "%s", body)
+		assert.False(t, gjson.GetBytes(body, "0.identity.credentials").Exists(), "%s", body)
+		assert.Equal(t, "3", res.Header.Get("X-Total-Count"))
+	})
+
+	t.Run("case=masks sensitive traits", func(t *testing.T) {
+		testhelpers.SetIdentitySchemas(t, conf, map[string]string{"sensitive": "file://stub/sensitive.schema.json"})
+		conf.MustSet(config.ViperKeyAdminTraitMaskingEnabled, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyAdminTraitMaskingEnabled, false)
+		})
+
+		sensitive := &identity.Identity{SchemaID: "sensitive", Traits: identity.Traits(`{"email":"masked@ory.sh"}`)}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, sensitive))
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, NewActiveSession(sensitive, conf, time.Now())))
+
+		res, body := do(t, "GET", "/identities/"+sensitive.ID.String()+"/sessions")
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Equal(t, identity.MaskedTraitValue, gjson.GetBytes(body, "0.identity.traits.email").String(), "%s", body)
+	})
+
+	t.Run("case=rejects invalid requests", func(t *testing.T) {
+		res, _ := do(t, "GET", "/identities/"+i.ID.String()+"/sessions?active=foo")
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		res, _ = do(t, "GET", "/identities/"+x.NewUUID().String()+"/sessions")
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		res, _ = do(t, "DELETE", "/identities/"+x.NewUUID().String()+"/sessions")
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		res, _ = do(t, "DELETE", "/sessions/"+x.NewUUID().String())
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("case=revokes a single session", func(t *testing.T) {
+		res, body := do(t, "DELETE", "/sessions/"+sessions[0].ID.String())
+		require.Equal(t, http.StatusNoContent, res.StatusCode, "%s", body)
+		assert.Equal(t, http.StatusUnauthorized, whoami(t, sessions[0]))
+		assert.Equal(t, http.StatusOK, whoami(t, sessions[1]))
+
+		res, body = do(t, "GET", "/identities/"+i.ID.String()+"/sessions?active=false")
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Len(t, gjson.ParseBytes(body).Array(), 1, "%s", body)
+		assert.Equal(t, sessions[0].ID.String(), gjson.GetBytes(body, "0.id").String(), "%s", body)
+	})
+
+	t.Run("case=revokes all sessions", func(t *testing.T) {
+		res, body := do(t, "DELETE", "/identities/"+i.ID.String()+"/sessions")
+		require.Equal(t, http.StatusNoContent, res.StatusCode, "%s", body)
+		for _, s := range sessions {
+			assert.Equal(t, http.StatusUnauthorized, whoami(t, s))
+		}
+
+		res, body = do(t, "GET", "/identities/"+i.ID.String()+"/sessions?active=true")
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Len(t, gjson.ParseBytes(body).Array(), 0, "%s", body)
+
+		res, body = do(t, "GET", "/identities/"+i.ID.String()+"/sessions")
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Len(t, gjson.ParseBytes(body).Array(), 3, "revoked sessions are still listed: %s", body)
+	})
+}
+
+func TestActOnBehalf(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
+
+	newIdentity := func(t *testing.T) (*identity.Identity, *Session) {
+		i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+		sess := NewActiveSession(i, conf, time.Now())
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, sess))
+		return i, sess
+	}
+	guardian, guardianSession := newIdentity(t)
+	child, childSession := newIdentity(t)
+	primary, _ := newIdentity(t)
+	delegate, delegateSession := newIdentity(t)
+
+	require.NoError(t, reg.IdentityRelationshipPersister().CreateIdentityRelationship(ctx, &identity.Relationship{
+		Type: identity.RelationshipTypeGuardian, IdentityID: guardian.ID, RelatedIdentityID: child.ID}))
+	require.NoError(t, reg.IdentityRelationshipPersister().CreateIdentityRelationship(ctx, &identity.Relationship{
+		Type: identity.RelationshipTypeDelegate, IdentityID: primary.ID, RelatedIdentityID: delegate.ID}))
+
+	do := func(t *testing.T, method, path, token, body string) (*http.Response, []byte) {
+		req, err := http.NewRequest(method, publicTS.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res, b
+	}
+
+	actOnBehalf := func(t *testing.T, token string, id uuid.UUID, expectCode int) []byte {
+		res, body := do(t, "POST", RouteActOnBehalf, token, `{"identity_id":"`+id.String()+`"}`)
+		require.Equal(t, expectCode, res.StatusCode, "%s", body)
+		return body
+	}
+
+	t.Run("case=rejects unauthenticated requests", func(t *testing.T) {
+		actOnBehalf(t, "", child.ID, http.StatusUnauthorized)
+	})
+
+	t.Run("case=is disabled by default", func(t *testing.T) {
+		actOnBehalf(t, guardianSession.Token, child.ID, http.StatusForbidden)
+	})
+
+	conf.MustSet(config.ViperKeyIdentityRelationshipsActOnBehalf+".guardian.enabled", true)
+	conf.MustSet(config.ViperKeyIdentityRelationshipsActOnBehalf+".guardian.lifespan", "10m")
+
+	t.Run("case=guardians act on behalf of children", func(t *testing.T) {
+		body := actOnBehalf(t, guardianSession.Token, child.ID, http.StatusCreated)
+		assert.Equal(t, child.ID.String(), gjson.GetBytes(body, "session.identity.id").String(), "%s", body)
+		assert.Equal(t, guardian.ID.String(), gjson.GetBytes(body, "session.actor.identity_id").String(), "%s", body)
+		assert.Equal(t, guardianSession.ID.String(), gjson.GetBytes(body, "session.actor.session_id").String(), "%s", body)
+		assert.Equal(t, "guardian", gjson.GetBytes(body, "session.actor.relationship_type").String(), "%s", body)
+		assert.True(t, gjson.GetBytes(body, "session.expires_at").Time().Before(time.Now().Add(11*time.Minute)), "%s", body)
+
+		token := gjson.GetBytes(body, "session_token").String()
+		res, whoami := do(t, "GET", RouteWhoami, token, "")
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", whoami)
+		assert.Equal(t, child.ID.String(), gjson.GetBytes(whoami, "identity.id").String(), "%s", whoami)
+		assert.Equal(t, guardian.ID.String(), gjson.GetBytes(whoami, "actor.identity_id").String(), "%s", whoami)
+
+		t.Run("case=can not be chained", func(t *testing.T) {
+			actOnBehalf(t, token, child.ID, http.StatusForbidden)
+		})
+	})
+
+	t.Run("case=does not outlive the actor's session", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyIdentityRelationshipsActOnBehalf+".guardian.lifespan", "87600h")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentityRelationshipsActOnBehalf+".guardian.lifespan", "10m")
+		})
+
+		body := actOnBehalf(t, guardianSession.Token, child.ID, http.StatusCreated)
+		assert.False(t, gjson.GetBytes(body, "session.expires_at").Time().After(guardianSession.ExpiresAt), "%s", body)
+	})
+
+	t.Run("case=children can not act on behalf of guardians", func(t *testing.T) {
+		actOnBehalf(t, childSession.Token, guardian.ID, http.StatusForbidden)
+	})
+
+	t.Run("case=is configured per relationship type", func(t *testing.T) {
+		actOnBehalf(t, delegateSession.Token, primary.ID, http.StatusForbidden)
+
+		conf.MustSet(config.ViperKeyIdentityRelationshipsActOnBehalf+".delegate.enabled", true)
+		body := actOnBehalf(t, delegateSession.Token, primary.ID, http.StatusCreated)
+		assert.Equal(t, primary.ID.String(), gjson.GetBytes(body, "session.identity.id").String(), "%s", body)
+		assert.Equal(t, "delegate", gjson.GetBytes(body, "session.actor.relationship_type").String(), "%s", body)
+	})
+
+	t.Run("case=stops working", func(t *testing.T) {
+		setup := func(t *testing.T) (*identity.Identity, *Session, *identity.Relationship, string) {
+			guardian, guardianSession := newIdentity(t)
+			child, _ := newIdentity(t)
+			rel := &identity.Relationship{Type: identity.RelationshipTypeGuardian, IdentityID: guardian.ID, RelatedIdentityID: child.ID}
+			require.NoError(t, reg.IdentityRelationshipPersister().CreateIdentityRelationship(ctx, rel))
+
+			token := gjson.GetBytes(actOnBehalf(t, guardianSession.Token, child.ID, http.StatusCreated), "session_token").String()
+			res, body := do(t, "GET", RouteWhoami, token, "")
+			require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+			return guardian, guardianSession, rel, token
+		}
+
+		expectRevoked := func(t *testing.T, token string) {
+			res, body := do(t, "GET", RouteWhoami, token, "")
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "%s", body)
+		}
+
+		t.Run("case=if the relationship is deleted", func(t *testing.T) {
+			_, _, rel, token := setup(t)
+			require.NoError(t, reg.IdentityRelationshipPersister().DeleteIdentityRelationship(ctx, rel.ID))
+			expectRevoked(t, token)
+		})
+
+		t.Run("case=if the actor's session is revoked", func(t *testing.T) {
+			_, guardianSession, _, token := setup(t)
+			require.NoError(t, reg.SessionPersister().RevokeSession(ctx, guardianSession.ID))
+			expectRevoked(t, token)
+		})
+
+		t.Run("case=if the actor is deactivated", func(t *testing.T) {
+			guardian, _, _, token := setup(t)
+			guardian.State = identity.StateInactive
+			require.NoError(t, reg.PrivilegedIdentityPool().UpdateIdentity(ctx, guardian))
+			expectRevoked(t, token)
+		})
+
+		t.Run("case=if acting on behalf is disabled for the relationship type", func(t *testing.T) {
+			_, _, _, token := setup(t)
+			conf.MustSet(config.ViperKeyIdentityRelationshipsActOnBehalf+".guardian.enabled", false)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyIdentityRelationshipsActOnBehalf+".guardian.enabled", true)
+			})
+			expectRevoked(t, token)
+		})
+	})
 }
 
 func TestIsNotAuthenticatedSecurecookie(t *testing.T) {
@@ -232,3 +605,37 @@ func TestIsAuthenticated(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkSessionWhoAmI(b *testing.B) {
+	conf, reg := internal.NewFastRegistryWithMocks(b)
+	conf.MustSet(config.ViperKeyPublicBaseURL, "http://example.com")
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+
+	r := x.NewRouterPublic()
+	NewHandler(reg).RegisterPublicRoutes(r)
+
+	// Sessions of other identities make the token lookup scan a realistically sized table.
+	tokens := make([]string, 2000)
+	for k := range tokens {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(fmt.Sprintf(`{"email":"benchmark-%d@ory.sh"}`, k))
+		require.NoError(b, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+
+		s := NewActiveSession(i, conf, time.Now())
+		require.NoError(b, reg.SessionPersister().CreateSession(context.Background(), s))
+		tokens[k] = s.Token
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		req := httptest.NewRequest("GET", RouteWhoami, nil)
+		req.Header.Set("Authorization", "Bearer "+tokens[k%len(tokens)])
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status code %d: %s", w.Code, w.Body.String())
+		}
+	}
+}
@@ -10,6 +10,9 @@ import (
 var (
 	// ErrNoActiveSessionFound is returned when no active cookie session could be found in the request.
 	ErrNoActiveSessionFound = herodot.ErrUnauthorized.WithError("request does not have a valid authentication session").WithReason("No active session was found in this request.")
+
+	// ErrPasswordResetRequired is returned when a session may only be used to set a new password.
+	ErrPasswordResetRequired = herodot.ErrForbidden.WithError("session requires a password reset").WithReason("The password of this identity has expired. Set a new password using the settings flow to use this session.")
 )
 
 // Manager handles identity sessions.
@@ -27,6 +30,10 @@ type Manager interface {
 	// FetchFromRequest creates an HTTP session using cookies.
 	FetchFromRequest(context.Context, *http.Request) (*Session, error)
 
+	// FetchFromRequestWithAffinity works like FetchFromRequest. If read-your-writes session affinity is enabled, it
+	// waits for sessions whose cookie was issued recently, possibly in another region, to be replicated.
+	FetchFromRequestWithAffinity(context.Context, *http.Request) (*Session, error)
+
 	// PurgeFromRequest removes an HTTP session.
 	PurgeFromRequest(context.Context, http.ResponseWriter, *http.Request) error
 }
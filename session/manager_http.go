@@ -3,6 +3,7 @@ package session
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -16,12 +17,21 @@ import (
 	"github.com/ory/kratos/x"
 )
 
+const (
+	// cookieKeyIssuedAt and cookieKeyRegion record when and where the session cookie was issued in multi-region mode.
+	cookieKeyIssuedAt = "issued_at"
+	cookieKeyRegion   = "region"
+)
+
 type (
 	managerHTTPDependencies interface {
 		config.Provider
+		x.LoggingProvider
 		identity.PoolProvider
+		identity.RelationshipPersistenceProvider
 		x.CookieProvider
 		x.CSRFProvider
+		x.ClockProvider
 		PersistenceProvider
 	}
 	ManagerHTTP struct {
@@ -56,8 +66,8 @@ func (s *ManagerHTTP) IssueCookie(ctx context.Context, w http.ResponseWriter, r
 
 	if domain := s.r.Config(ctx).SessionDomain(); domain != "" {
 		cookie.Options.Domain = domain
-	} else if alias := s.r.Config(ctx).SelfPublicURL(r); s.r.Config(ctx).SelfPublicURL(nil).String() != alias.String() {
-		// If a domain alias is detected use that instead.
+	} else if alias := s.r.Config(ctx).SelfPublicURL(r); s.r.Config(ctx).SelfPublicURL(nil).String() != alias.String() && !s.r.Config(ctx).SessionCookie().HostOnly() {
+		// If a domain alias is detected use that instead. Cookies with the __Host- prefix must not set a domain though.
 		cookie.Options.Domain = alias.Hostname()
 		cookie.Options.Path = alias.Path
 	}
@@ -80,11 +90,20 @@ func (s *ManagerHTTP) IssueCookie(ctx context.Context, w http.ResponseWriter, r
 	}
 
 	cookie.Options.MaxAge = 0
-	if s.r.Config(ctx).SessionPersistentCookie() {
+	if s.r.Config(ctx).SessionRememberMeEnabled() {
+		// The identity chose between a persistent and a browser-session cookie when signing in.
+		if session.RememberMe {
+			cookie.Options.MaxAge = int(session.ExpiresAt.Sub(s.r.Clock().Now()).Seconds())
+		}
+	} else if s.r.Config(ctx).SessionPersistentCookie() {
 		cookie.Options.MaxAge = int(s.r.Config(ctx).SessionLifespan().Seconds())
 	}
 
 	cookie.Values["session_token"] = session.Token
+	if s.r.Config(ctx).MultiRegionEnabled() {
+		cookie.Values[cookieKeyIssuedAt] = s.r.Clock().Now().UTC().Unix()
+		cookie.Values[cookieKeyRegion] = s.r.Config(ctx).MultiRegionName()
+	}
 	if err := cookie.Save(r, w); err != nil {
 		return errors.WithStack(err)
 	}
@@ -127,14 +146,87 @@ func (s *ManagerHTTP) FetchFromRequest(ctx context.Context, r *http.Request) (*S
 		return nil, err
 	}
 
-	if !se.IsActive() {
+	// Sessions of deactivated identities are treated like revoked sessions.
+	if !se.IsActive(s.r.Clock().Now()) || (se.Identity != nil && !se.Identity.IsActive()) {
 		return nil, errors.WithStack(ErrNoActiveSessionFound)
 	}
 
+	if se.Actor != nil {
+		if err := s.verifyActor(ctx, se); err != nil {
+			return nil, err
+		}
+	}
+
 	se.Identity = se.Identity.CopyWithoutCredentials()
 	return se, nil
 }
 
+// verifyActor treats a session acting on behalf of another identity like a revoked session once the actor's session
+// was revoked, the actor was deactivated, or the relationship it was obtained with was deleted or disabled.
+func (s *ManagerHTTP) verifyActor(ctx context.Context, se *Session) error {
+	actor, err := s.r.SessionPersister().GetSession(ctx, se.Actor.SessionID)
+	if errors.Is(err, herodot.ErrNotFound) || errors.Is(err, sqlcon.ErrNoRows) {
+		return errors.WithStack(ErrNoActiveSessionFound)
+	} else if err != nil {
+		return err
+	}
+
+	if actor.IdentityID != se.Actor.IdentityID || !actor.IsActive(s.r.Clock().Now()) ||
+		(actor.Identity != nil && !actor.Identity.IsActive()) {
+		return errors.WithStack(ErrNoActiveSessionFound)
+	}
+
+	rel, err := s.r.IdentityRelationshipPersister().GetIdentityRelationship(ctx, se.Actor.RelationshipID)
+	if errors.Is(err, herodot.ErrNotFound) || errors.Is(err, sqlcon.ErrNoRows) {
+		return errors.WithStack(ErrNoActiveSessionFound)
+	} else if err != nil {
+		return err
+	}
+
+	if rel.ActorID() != se.Actor.IdentityID || rel.SubjectID() != se.IdentityID ||
+		!s.r.Config(ctx).IdentityRelationshipsActOnBehalf(string(rel.Type)).Enabled {
+		return errors.WithStack(ErrNoActiveSessionFound)
+	}
+	return nil
+}
+
+func (s *ManagerHTTP) FetchFromRequestWithAffinity(ctx context.Context, r *http.Request) (*Session, error) {
+	se, err := s.FetchFromRequest(ctx, r)
+	if err == nil || !errors.Is(err, ErrNoActiveSessionFound) || !s.r.Config(ctx).MultiRegionSessionAffinity() {
+		return se, err
+	}
+
+	cookie, cerr := s.r.CookieManager(r.Context()).Get(r, s.cookieName(ctx))
+	if cerr != nil {
+		return nil, err
+	}
+
+	issuedAt, ok := cookie.Values[cookieKeyIssuedAt].(int64)
+	if !ok {
+		return nil, err
+	}
+
+	// Only wait for the remainder of the tolerance because older sessions must have been replicated already.
+	tolerance := time.Unix(issuedAt, 0).Add(s.r.Config(ctx).MultiRegionReplicaLagTolerance()).Sub(s.r.Clock().Now())
+	if tolerance <= 0 {
+		return nil, err
+	}
+
+	s.r.Logger().
+		WithRequest(r).
+		WithField("issued_in_region", cookie.Values[cookieKeyRegion]).
+		WithField("region", s.r.Config(ctx).MultiRegionName()).
+		Debug("The session was issued recently and might not have been replicated yet. Waiting for it.")
+
+	if err := x.RetryOnReplicaLag(ctx, tolerance, func() (err error) {
+		se, err = s.FetchFromRequest(ctx, r)
+		return err
+	}, ErrNoActiveSessionFound); err != nil {
+		return nil, err
+	}
+	return se, nil
+}
+
 func (s *ManagerHTTP) PurgeFromRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	if token, ok := bearerTokenFromRequest(r); ok {
 		return errors.WithStack(s.r.SessionPersister().RevokeSessionByToken(ctx, token))
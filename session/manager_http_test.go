@@ -12,10 +12,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/x/sqlcon"
+
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence/fault"
 	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
 )
@@ -77,6 +80,15 @@ func TestManagerHTTP(t *testing.T) {
 			reg.Writer().Write(w, r, sess)
 		})
 
+		rp.GET("/session/get-with-affinity", func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+			sess, err := reg.SessionManager().FetchFromRequestWithAffinity(r.Context(), r)
+			if err != nil {
+				reg.Writer().WriteError(w, r, err)
+				return
+			}
+			reg.Writer().Write(w, r, sess)
+		})
+
 		pts := httptest.NewServer(x.NewTestCSRFHandler(rp, reg))
 		t.Cleanup(pts.Close)
 		conf.MustSet(config.ViperKeyPublicBaseURL, pts.URL)
@@ -117,6 +129,110 @@ func TestManagerHTTP(t *testing.T) {
 			assert.EqualValues(t, http.StatusUnauthorized, res.StatusCode)
 		})
 
+		t.Run("case=expired according to the registry clock", func(t *testing.T) {
+			clock := x.NewFrozenClock(time.Now())
+			reg.WithClock(clock)
+			t.Cleanup(func() {
+				reg.WithClock(x.SystemClock{})
+			})
+
+			i := identity.Identity{Traits: []byte("{}")}
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
+			s = session.NewActiveSession(&i, conf, clock.Now())
+
+			c := testhelpers.NewClientWithCookies(t)
+			testhelpers.MockHydrateCookieClient(t, c, pts.URL+"/session/set")
+
+			res, err := c.Get(pts.URL + "/session/get")
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusOK, res.StatusCode)
+
+			clock.Advance(time.Minute + time.Second)
+			res, err = c.Get(pts.URL + "/session/get")
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusUnauthorized, res.StatusCode)
+		})
+
+		t.Run("case=session lookup fails", func(t *testing.T) {
+			inj := fault.NewInjector()
+			reg.WithFaultInjector(inj)
+
+			i := identity.Identity{Traits: []byte("{}")}
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
+			s = session.NewActiveSession(&i, conf, time.Now())
+
+			c := testhelpers.NewClientWithCookies(t)
+			testhelpers.MockHydrateCookieClient(t, c, pts.URL+"/session/set")
+
+			for _, tc := range []struct {
+				err    error
+				status int
+			}{
+				{err: sqlcon.ErrNoRows, status: http.StatusUnauthorized},
+				{err: fault.ErrUnavailable, status: http.StatusInternalServerError},
+			} {
+				inj.Add(fault.Fault{Method: "GetSessionByToken", Err: tc.err, Times: 1})
+				res, err := c.Get(pts.URL + "/session/get")
+				require.NoError(t, err)
+				assert.EqualValues(t, tc.status, res.StatusCode, "%s", tc.err)
+			}
+
+			res, err := c.Get(pts.URL + "/session/get")
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusOK, res.StatusCode)
+		})
+
+		t.Run("case=waits for sessions which were not replicated yet", func(t *testing.T) {
+			inj := fault.NewInjector()
+			reg.WithFaultInjector(inj)
+
+			newClient := func(t *testing.T) *http.Client {
+				i := identity.Identity{Traits: []byte("{}")}
+				require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
+				s = session.NewActiveSession(&i, conf, time.Now())
+
+				c := testhelpers.NewClientWithCookies(t)
+				testhelpers.MockHydrateCookieClient(t, c, pts.URL+"/session/set")
+				return c
+			}
+
+			get := func(t *testing.T, c *http.Client, expectCode int) {
+				inj.Add(fault.Fault{Method: "GetSessionByToken", Err: sqlcon.ErrNoRows, Times: 2})
+				t.Cleanup(inj.Reset)
+
+				res, err := c.Get(pts.URL + "/session/get-with-affinity")
+				require.NoError(t, err)
+				assert.EqualValues(t, expectCode, res.StatusCode)
+			}
+
+			t.Run("case=disabled by default", func(t *testing.T) {
+				get(t, newClient(t), http.StatusUnauthorized)
+			})
+
+			conf.MustSet(config.ViperKeyMultiRegionEnabled, true)
+			conf.MustSet(config.ViperKeyMultiRegionSessionAffinity, true)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyMultiRegionEnabled, false)
+				conf.MustSet(config.ViperKeyMultiRegionSessionAffinity, false)
+			})
+
+			t.Run("case=retries recently issued sessions", func(t *testing.T) {
+				get(t, newClient(t), http.StatusOK)
+			})
+
+			t.Run("case=does not wait for old sessions", func(t *testing.T) {
+				clock := x.NewFrozenClock(time.Now())
+				reg.WithClock(clock)
+				t.Cleanup(func() {
+					reg.WithClock(x.SystemClock{})
+				})
+
+				c := newClient(t)
+				clock.Advance(10 * time.Second)
+				get(t, c, http.StatusUnauthorized)
+			})
+		})
+
 		t.Run("case=revoked", func(t *testing.T) {
 			i := identity.Identity{Traits: []byte("{}")}
 			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
@@ -135,5 +251,20 @@ func TestManagerHTTP(t *testing.T) {
 			require.NoError(t, err)
 			assert.EqualValues(t, http.StatusUnauthorized, res.StatusCode)
 		})
+
+		t.Run("case=identity deactivated", func(t *testing.T) {
+			i := identity.Identity{Traits: []byte("{}")}
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
+			s = session.NewActiveSession(&i, conf, time.Now())
+
+			c := testhelpers.NewClientWithCookies(t)
+			testhelpers.MockHydrateCookieClient(t, c, pts.URL+"/session/set")
+
+			require.NoError(t, reg.IdentityManager().SetState(context.Background(), i.ID, identity.StateInactive))
+
+			res, err := c.Get(pts.URL + "/session/get")
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusUnauthorized, res.StatusCode)
+		})
 	})
 }
@@ -3,12 +3,15 @@ package session
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/bxcodec/faker/v3"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/x/sqlcon"
+
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/x"
@@ -25,6 +28,9 @@ type Persister interface {
 	// CreateSession adds a session to the store.
 	CreateSession(ctx context.Context, s *Session) error
 
+	// UpdateSession updates a session in the store.
+	UpdateSession(ctx context.Context, s *Session) error
+
 	// DeleteSession removes a session from the store.
 	DeleteSession(ctx context.Context, id uuid.UUID) error
 
@@ -45,6 +51,17 @@ type Persister interface {
 
 	// RevokeSessionByToken marks a session inactive with the given token.
 	RevokeSessionByToken(ctx context.Context, token string) error
+
+	// ListSessionsByIdentity returns a page of the identity's sessions, newest first, and how many sessions
+	// there are in total. If active is set, only sessions which are active, or only those which are not, are
+	// returned.
+	ListSessionsByIdentity(ctx context.Context, identity uuid.UUID, active *bool, page, perPage int) ([]Session, int64, error)
+
+	// RevokeSession marks the session inactive.
+	RevokeSession(ctx context.Context, sid uuid.UUID) error
+
+	// RevokeSessionsByIdentity marks all sessions of the identity inactive.
+	RevokeSessionsByIdentity(ctx context.Context, identity uuid.UUID) error
 }
 
 func TestPersister(ctx context.Context, conf *config.Config, p interface {
@@ -91,6 +108,26 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 			})
 		})
 
+		t.Run("case=update session", func(t *testing.T) {
+			var expected Session
+			require.NoError(t, faker.FakeData(&expected))
+			expected.Scopes = []string{ScopePasswordReset}
+			require.NoError(t, p.CreateIdentity(ctx, expected.Identity))
+			require.NoError(t, p.CreateSession(ctx, &expected))
+
+			actual, err := p.GetSession(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.True(t, actual.RequiresPasswordReset())
+
+			actual.CompletePasswordReset()
+			require.NoError(t, p.UpdateSession(ctx, actual))
+
+			actual, err = p.GetSession(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.False(t, actual.RequiresPasswordReset())
+			assert.Equal(t, expected.Token, actual.Token)
+		})
+
 		t.Run("case=delete session", func(t *testing.T) {
 			var expected Session
 			require.NoError(t, faker.FakeData(&expected))
@@ -131,6 +168,57 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 			assert.False(t, actual.Active)
 		})
 
+		t.Run("case=list and revoke sessions by identity", func(t *testing.T) {
+			var i identity.Identity
+			require.NoError(t, faker.FakeData(&i))
+			require.NoError(t, p.CreateIdentity(ctx, &i))
+
+			sessions := make([]Session, 3)
+			for k := range sessions {
+				require.NoError(t, faker.FakeData(&sessions[k]))
+				sessions[k].Identity = &i
+				sessions[k].IdentityID = i.ID
+				sessions[k].Active = true
+				sessions[k].ExpiresAt = time.Now().Add(time.Hour)
+				require.NoError(t, p.CreateSession(ctx, &sessions[k]))
+			}
+
+			var other Session
+			require.NoError(t, faker.FakeData(&other))
+			other.Active = true
+			require.NoError(t, p.CreateIdentity(ctx, other.Identity))
+			require.NoError(t, p.CreateSession(ctx, &other))
+
+			actual, total, err := p.ListSessionsByIdentity(ctx, i.ID, nil, 0, 2)
+			require.NoError(t, err)
+			assert.EqualValues(t, 3, total)
+			assert.Len(t, actual, 2)
+
+			require.NoError(t, p.RevokeSession(ctx, sessions[0].ID))
+			require.ErrorIs(t, p.RevokeSession(ctx, x.NewUUID()), sqlcon.ErrNoRows)
+
+			active, inactive := true, false
+			actual, total, err = p.ListSessionsByIdentity(ctx, i.ID, &active, 0, 10)
+			require.NoError(t, err)
+			assert.EqualValues(t, 2, total)
+			assert.Len(t, actual, 2)
+
+			actual, total, err = p.ListSessionsByIdentity(ctx, i.ID, &inactive, 0, 10)
+			require.NoError(t, err)
+			assert.EqualValues(t, 1, total)
+			require.Len(t, actual, 1)
+			assert.Equal(t, sessions[0].ID, actual[0].ID)
+
+			require.NoError(t, p.RevokeSessionsByIdentity(ctx, i.ID))
+			_, total, err = p.ListSessionsByIdentity(ctx, i.ID, &active, 0, 10)
+			require.NoError(t, err)
+			assert.EqualValues(t, 0, total)
+
+			actualOther, err := p.GetSession(ctx, other.ID)
+			require.NoError(t, err)
+			assert.True(t, actualOther.Active, "sessions of other identities are not revoked")
+		})
+
 		t.Run("case=delete session for", func(t *testing.T) {
 			var expected1 Session
 			var expected2 Session
@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"database/sql/driver"
 	"time"
 
 	"github.com/ory/kratos/corp"
@@ -9,11 +10,16 @@ import (
 	"github.com/gofrs/uuid"
 
 	"github.com/ory/x/randx"
+	"github.com/ory/x/sqlxx"
 
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/x"
 )
 
+// ScopePasswordReset restricts a session to the settings flows. It is set when an identity signs in with a
+// password which was expired by an administrator and is removed once a new password was set.
+const ScopePasswordReset = "kratos:password_reset"
+
 // swagger:model session
 type Session struct {
 	// required: true
@@ -30,9 +36,30 @@ type Session struct {
 	// required: true
 	IssuedAt time.Time `json:"issued_at" db:"issued_at" faker:"time_type"`
 
+	// Scopes restricts what a session issued for a service account may be used for. It is up to the
+	// consuming services to enforce the scopes, except for `kratos:password_reset` which is enforced by
+	// ORY Kratos.
+	Scopes sqlxx.StringSlicePipeDelimiter `json:"scopes,omitempty" faker:"-" db:"scopes"`
+
+	// UpstreamSession is set if the identity authenticated with a third party which should be notified when
+	// the session ends.
+	UpstreamSession *UpstreamSession `json:"-" faker:"-" db:"upstream_session"`
+
+	// Actor is set if the session was obtained by another identity to act on behalf of the session's identity,
+	// for example by a guardian acting on behalf of a child account.
+	Actor *Actor `json:"actor,omitempty" faker:"-" db:"actor"`
+
+	// RememberMe is true if the identity checked `remember` when signing in and received a persistent session
+	// cookie. It is always false if `session.remember_me` is disabled.
+	RememberMe bool `json:"remember_me" faker:"-" db:"remember_me"`
+
 	// required: true
 	Identity *identity.Identity `json:"identity" faker:"identity" db:"-" belongs_to:"identities" fk_id:"IdentityID"`
 
+	// Relationships lists the relationships of the session's identity, for example to its guardian. It is only
+	// set by `/sessions/whoami` and only if `identity.relationships.session_annotations` is enabled.
+	Relationships []identity.RelationshipAnnotation `json:"relationships,omitempty" faker:"-" db:"-"`
+
 	// IdentityID is a helper struct field for gobuffalo.pop.
 	IdentityID uuid.UUID `json:"-" faker:"-" db:"identity_id"`
 	// CreatedAt is a helper struct field for gobuffalo.pop.
@@ -47,6 +74,14 @@ func (s Session) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "sessions")
 }
 
+func (s Session) GetID() uuid.UUID {
+	return s.ID
+}
+
+func (s Session) GetNID() uuid.UUID {
+	return s.NID
+}
+
 func NewActiveSession(i *identity.Identity, c interface {
 	SessionLifespan() time.Duration
 }, authenticatedAt time.Time) *Session {
@@ -54,7 +89,7 @@ func NewActiveSession(i *identity.Identity, c interface {
 		ID:              x.NewUUID(),
 		ExpiresAt:       authenticatedAt.Add(c.SessionLifespan()),
 		AuthenticatedAt: authenticatedAt,
-		IssuedAt:        time.Now().UTC(),
+		IssuedAt:        authenticatedAt,
 		Identity:        i,
 		IdentityID:      i.ID,
 		Token:           randx.MustString(32, randx.AlphaNum),
@@ -62,6 +97,71 @@ func NewActiveSession(i *identity.Identity, c interface {
 	}
 }
 
+// UpstreamSession identifies the session at the OpenID Connect provider the identity signed in with.
+type UpstreamSession struct {
+	// Provider is the ID of the OpenID Connect provider.
+	Provider string `json:"provider"`
+
+	// IDToken is the ID Token issued by the provider. It is sent as the `id_token_hint` when ending the
+	// session at the provider.
+	IDToken string `json:"id_token"`
+}
+
+func (u *UpstreamSession) Scan(value interface{}) error {
+	return sqlxx.JSONScan(u, value)
+}
+
+func (u UpstreamSession) Value() (driver.Value, error) {
+	return sqlxx.JSONValue(&u)
+}
+
+// Actor identifies the identity acting on behalf of the session's identity.
+//
+// swagger:model sessionActor
+type Actor struct {
+	// IdentityID is the ID of the acting identity.
+	//
+	// required: true
+	IdentityID uuid.UUID `json:"identity_id"`
+
+	// SessionID is the ID of the acting identity's session which was used to obtain this session.
+	//
+	// required: true
+	SessionID uuid.UUID `json:"session_id"`
+
+	// RelationshipID is the ID of the relationship which allows the actor to act on behalf of the session's
+	// identity.
+	//
+	// required: true
+	RelationshipID uuid.UUID `json:"relationship_id"`
+
+	// RelationshipType is the type of that relationship, for example `guardian`.
+	//
+	// required: true
+	RelationshipType identity.RelationshipType `json:"relationship_type"`
+}
+
+func (a *Actor) Scan(value interface{}) error {
+	return sqlxx.JSONScan(a, value)
+}
+
+func (a Actor) Value() (driver.Value, error) {
+	return sqlxx.JSONValue(&a)
+}
+
+type upstreamSessionContextKey struct{}
+
+// ContextWithUpstreamSession makes sessions issued while handling the request reference the upstream session.
+func ContextWithUpstreamSession(ctx context.Context, u *UpstreamSession) context.Context {
+	return context.WithValue(ctx, upstreamSessionContextKey{}, u)
+}
+
+// UpstreamSessionFromContext returns the upstream session added by ContextWithUpstreamSession or nil.
+func UpstreamSessionFromContext(ctx context.Context) *UpstreamSession {
+	u, _ := ctx.Value(upstreamSessionContextKey{}).(*UpstreamSession)
+	return u
+}
+
 type Device struct {
 	UserAgent string      `json:"user_agent"`
 	SeenAt    []time.Time `json:"seen_at" faker:"time_types"`
@@ -72,6 +172,28 @@ func (s *Session) Declassify() *Session {
 	return s
 }
 
-func (s *Session) IsActive() bool {
-	return s.Active && s.ExpiresAt.After(time.Now())
+// IsActive returns true if the session was not revoked and did not expire at the given time.
+func (s *Session) IsActive(now time.Time) bool {
+	return s.Active && s.ExpiresAt.After(now)
+}
+
+// RequiresPasswordReset returns true if the session may only be used to set a new password.
+func (s *Session) RequiresPasswordReset() bool {
+	for _, scope := range s.Scopes {
+		if scope == ScopePasswordReset {
+			return true
+		}
+	}
+	return false
+}
+
+// CompletePasswordReset lifts the restriction added by ScopePasswordReset.
+func (s *Session) CompletePasswordReset() {
+	scopes := make(sqlxx.StringSlicePipeDelimiter, 0, len(s.Scopes))
+	for _, scope := range s.Scopes {
+		if scope != ScopePasswordReset {
+			scopes = append(scopes, scope)
+		}
+	}
+	s.Scopes = scopes
 }
@@ -16,8 +16,9 @@ func TestSession(t *testing.T) {
 	authAt := time.Now()
 
 	s := session.NewActiveSession(new(identity.Identity), conf, authAt)
-	assert.True(t, s.IsActive())
+	assert.True(t, s.IsActive(authAt))
+	assert.False(t, s.IsActive(authAt.Add(conf.SessionLifespan())))
 
-	assert.False(t, (&session.Session{ExpiresAt: time.Now().Add(time.Hour)}).IsActive())
-	assert.False(t, (&session.Session{Active: true}).IsActive())
+	assert.False(t, (&session.Session{ExpiresAt: time.Now().Add(time.Hour)}).IsActive(time.Now()))
+	assert.False(t, (&session.Session{Active: true}).IsActive(time.Now()))
 }
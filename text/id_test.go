@@ -8,6 +8,7 @@ import (
 
 func TestIDs(t *testing.T) {
 	assert.Equal(t, 1010000, int(InfoSelfServiceLoginRoot))
+	assert.Equal(t, 1010003, int(InfoSelfServiceLoginGeoCodeSent))
 
 	assert.Equal(t, 1020000, int(InfoSelfServiceLogout))
 
@@ -18,6 +19,10 @@ func TestIDs(t *testing.T) {
 
 	assert.Equal(t, 1050000, int(InfoSelfServiceSettings))
 	assert.Equal(t, 1050001, int(InfoSelfServiceSettingsUpdateSuccess))
+	assert.Equal(t, 1050004, int(InfoSelfServiceSettingsUpdateLinkWallet))
+	assert.Equal(t, 1050005, int(InfoSelfServiceSettingsUpdateUnlinkWallet))
+	assert.Equal(t, 1050006, int(InfoSelfServiceSettingsPrivilegedCodeSent))
+	assert.Equal(t, 1050007, int(InfoSelfServiceSettingsPrivilegedCodeResendThrottled))
 
 	assert.Equal(t, 1060000, int(InfoSelfServiceRecovery))
 	assert.Equal(t, 1060001, int(InfoSelfServiceRecoverySuccessful))
@@ -25,22 +30,39 @@ func TestIDs(t *testing.T) {
 
 	assert.Equal(t, 1070000, int(InfoSelfServiceVerification))
 
+	assert.Equal(t, 1080000, int(InfoSelfServiceCourier))
+	assert.Equal(t, 1080001, int(InfoSelfServiceCourierDelayed))
+
 	assert.Equal(t, 4000000, int(ErrorValidation))
 	assert.Equal(t, 4000001, int(ErrorValidationGeneric))
 	assert.Equal(t, 4000002, int(ErrorValidationRequired))
+	assert.Equal(t, 4000009, int(ErrorValidationProviderClaimsRejected))
+	assert.Equal(t, 4000010, int(ErrorValidationSIWEMessageInvalid))
+	assert.Equal(t, 4000011, int(ErrorValidationSIWEWalletUnknown))
+	assert.Equal(t, 4000012, int(ErrorValidationEmailDomainRejected))
+	assert.Equal(t, 4000013, int(ErrorValidationNameReserved))
+	assert.Equal(t, 4000014, int(ErrorValidationCaptchaFailed))
+	assert.Equal(t, 4000015, int(ErrorValidationCourierUnavailable))
+	assert.Equal(t, 4000016, int(ErrorValidationFlowNonceInvalid))
 
 	assert.Equal(t, 4010000, int(ErrorValidationLogin))
 	assert.Equal(t, 4010001, int(ErrorValidationLoginFlowExpired))
+	assert.Equal(t, 4010007, int(ErrorValidationLoginCountryBlocked))
+	assert.Equal(t, 4010008, int(ErrorValidationLoginGeoCodeInvalid))
 
 	assert.Equal(t, 4040000, int(ErrorValidationRegistration))
 	assert.Equal(t, 4040001, int(ErrorValidationRegistrationFlowExpired))
 
 	assert.Equal(t, 4050000, int(ErrorValidationSettings))
 	assert.Equal(t, 4050001, int(ErrorValidationSettingsFlowExpired))
+	assert.Equal(t, 4050002, int(ErrorValidationSettingsUsernameUnavailable))
+	assert.Equal(t, 4050003, int(ErrorValidationSettingsUsernameChangeCooldown))
+	assert.Equal(t, 4050004, int(ErrorValidationSettingsPrivilegedCodeInvalid))
 
 	assert.Equal(t, 4060000, int(ErrorValidationRecovery))
 	assert.Equal(t, 4060001, int(ErrorValidationRecoveryRetrySuccess))
 	assert.Equal(t, 4060002, int(ErrorValidationRecoveryStateFailure))
+	assert.Equal(t, 4060006, int(ErrorValidationRecoveryAnswersInvalid))
 
 	assert.Equal(t, 4070000, int(ErrorValidationVerification))
 	assert.Equal(t, 4070001, int(ErrorValidationVerificationTokenInvalidOrAlreadyUsed))
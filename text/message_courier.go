@@ -0,0 +1,15 @@
+package text
+
+const (
+	InfoSelfServiceCourier        ID = 1080000 + iota // 1080000
+	InfoSelfServiceCourierDelayed                     // 1080001
+)
+
+func NewInfoSelfServiceCourierDelayed() *Message {
+	return &Message{
+		ID:      InfoSelfServiceCourierDelayed,
+		Type:    Info,
+		Text:    "We are having trouble sending emails right now, so the email may arrive with a delay.",
+		Context: context(nil),
+	}
+}
@@ -6,9 +6,10 @@ import (
 )
 
 const (
-	InfoSelfServiceLoginRoot ID = 1010000 + iota // 1010000
-	InfoSelfServiceLogin                         // 1010001
-	InfoSelfServiceLoginWith                     // 1010002
+	InfoSelfServiceLoginRoot        ID = 1010000 + iota // 1010000
+	InfoSelfServiceLogin                                // 1010001
+	InfoSelfServiceLoginWith                            // 1010002
+	InfoSelfServiceLoginGeoCodeSent                     // 1010003
 )
 
 const (
@@ -19,6 +20,8 @@ const (
 	ErrorValidationSettingsNoStrategyFound                         // 4010004
 	ErrorValidationRecoveryNoStrategyFound                         // 4010005
 	ErrorValidationVerificationNoStrategyFound                     // 4010006
+	ErrorValidationLoginCountryBlocked                             // 4010007
+	ErrorValidationLoginGeoCodeInvalid                             // 4010008
 )
 
 func NewInfoLogin() *Message {
@@ -91,3 +94,32 @@ func NewErrorValidationVerificationNoStrategyFound() *Message {
 		Type: Error,
 	}
 }
+
+func NewErrorValidationLoginCountryBlocked() *Message {
+	return &Message{
+		ID:      ErrorValidationLoginCountryBlocked,
+		Text:    "Signing in from your current location is not allowed.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewErrorValidationLoginGeoCodeInvalid() *Message {
+	return &Message{
+		ID:      ErrorValidationLoginGeoCodeInvalid,
+		Text:    "The code is invalid or has expired. Please try again.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewInfoSelfServiceLoginGeoCodeSent(address string) *Message {
+	return &Message{
+		ID:   InfoSelfServiceLoginGeoCodeSent,
+		Text: fmt.Sprintf("You are signing in from a new location. A code has been sent to %s. Please enter it to continue.", address),
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"address": address,
+		}),
+	}
+}
@@ -1,12 +1,26 @@
 package text
 
 const (
-	InfoNodeLabel              ID = 1070000 + iota // 1070000
-	InfoNodeLabelInputPassword                     // 1070001
-	InfoNodeLabelGenerated                         // 1070002
-	InfoNodeLabelSave                              // 1070003
-	InfoNodeLabelID                                // 1070004
-	InfoNodeLabelSubmit                            // 1070005
+	InfoNodeLabel                      ID = 1070000 + iota // 1070000
+	InfoNodeLabelInputPassword                             // 1070001
+	InfoNodeLabelGenerated                                 // 1070002
+	InfoNodeLabelSave                                      // 1070003
+	InfoNodeLabelID                                        // 1070004
+	InfoNodeLabelSubmit                                    // 1070005
+	InfoNodeLabelInputUsername                             // 1070006
+	InfoNodeLabelInputChannel                              // 1070007
+	InfoNodeLabelInputLocale                               // 1070008
+	InfoNodeLabelInputQuietHoursStart                      // 1070009
+	InfoNodeLabelInputQuietHoursEnd                        // 1070010
+	InfoNodeLabelInputTimezone                             // 1070011
+	InfoNodeLabelInputPrivilegedCode                       // 1070012
+	InfoNodeLabelInputRecoveryQuestion                     // 1070013
+	InfoNodeLabelInputCaptcha                              // 1070014
+	InfoNodeLabelInputRememberMe                           // 1070015
+	InfoNodeLabelInputLoginCode                            // 1070016
+	InfoNodeLabelInputPhone                                // 1070017
+	InfoNodeLabelInputVerificationCode                     // 1070018
+	InfoNodeLabelInputRecoveryCode                         // 1070019
 )
 
 func NewInfoNodeInputPassword() *Message {
@@ -17,6 +31,125 @@ func NewInfoNodeInputPassword() *Message {
 	}
 }
 
+func NewInfoNodeInputUsername() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputUsername,
+		Text: "Username",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputChannel() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputChannel,
+		Text: "Preferred channel",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputLocale() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputLocale,
+		Text: "Preferred language",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputQuietHoursStart() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputQuietHoursStart,
+		Text: "Quiet hours start",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputQuietHoursEnd() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputQuietHoursEnd,
+		Text: "Quiet hours end",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputTimezone() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputTimezone,
+		Text: "Time zone",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputPrivilegedCode() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputPrivilegedCode,
+		Text: "Code",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputRecoveryQuestion(id, question string) *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputRecoveryQuestion,
+		Text: question,
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"question_id": id,
+		}),
+	}
+}
+
+func NewInfoNodeInputCaptcha(provider, siteKey string) *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputCaptcha,
+		Text: "Please complete the challenge to continue.",
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"provider": provider,
+			"site_key": siteKey,
+		}),
+	}
+}
+
+func NewInfoNodeInputRememberMe() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputRememberMe,
+		Text: "Remember me",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputLoginCode() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputLoginCode,
+		Text: "Code",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputPhone() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputPhone,
+		Text: "Phone number",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputVerificationCode() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputVerificationCode,
+		Text: "Verification code",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeInputRecoveryCode() *Message {
+	return &Message{
+		ID:   InfoNodeLabelInputRecoveryCode,
+		Text: "Recovery code",
+		Type: Info,
+	}
+}
+
 func NewInfoNodeLabelGenerated(title string) *Message {
 	return &Message{
 		ID:   InfoNodeLabelGenerated,
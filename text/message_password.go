@@ -0,0 +1,64 @@
+package text
+
+import "fmt"
+
+const (
+	InfoPasswordStrength                    ID = 1090000 + iota // 1090000
+	InfoPasswordStrengthTooShort                                // 1090001
+	InfoPasswordStrengthSimilarToIdentifier                     // 1090002
+	InfoPasswordStrengthRepeatedCharacters                      // 1090003
+	InfoPasswordStrengthSequence                                // 1090004
+	InfoPasswordStrengthAddCharacterKinds                       // 1090005
+	InfoPasswordStrengthAddLength                               // 1090006
+)
+
+func NewInfoPasswordStrengthTooShort(minLength int) *Message {
+	return &Message{
+		ID:   InfoPasswordStrengthTooShort,
+		Text: fmt.Sprintf("Use at least %d characters.", minLength),
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"min_length": minLength,
+		}),
+	}
+}
+
+func NewInfoPasswordStrengthSimilarToIdentifier() *Message {
+	return &Message{
+		ID:   InfoPasswordStrengthSimilarToIdentifier,
+		Text: "Avoid using your email address or username in the password.",
+		Type: Info,
+	}
+}
+
+func NewInfoPasswordStrengthRepeatedCharacters() *Message {
+	return &Message{
+		ID:   InfoPasswordStrengthRepeatedCharacters,
+		Text: "Avoid repeated characters like \"aaa\".",
+		Type: Info,
+	}
+}
+
+func NewInfoPasswordStrengthSequence() *Message {
+	return &Message{
+		ID:   InfoPasswordStrengthSequence,
+		Text: "Avoid sequences like \"abc\" or \"123\".",
+		Type: Info,
+	}
+}
+
+func NewInfoPasswordStrengthAddCharacterKinds() *Message {
+	return &Message{
+		ID:   InfoPasswordStrengthAddCharacterKinds,
+		Text: "Mix upper and lower case letters, numbers, and symbols.",
+		Type: Info,
+	}
+}
+
+func NewInfoPasswordStrengthAddLength() *Message {
+	return &Message{
+		ID:   InfoPasswordStrengthAddLength,
+		Text: "Add a few more words or characters. Longer passwords are harder to guess.",
+		Type: Info,
+	}
+}
@@ -10,9 +10,10 @@ import (
 )
 
 const (
-	InfoSelfServiceRecovery           ID = 1060000 + iota // 1060000
-	InfoSelfServiceRecoverySuccessful                     // 1060001
-	InfoSelfServiceRecoveryEmailSent                      // 1060002
+	InfoSelfServiceRecovery                  ID = 1060000 + iota // 1060000
+	InfoSelfServiceRecoverySuccessful                            // 1060001
+	InfoSelfServiceRecoveryEmailSent                             // 1060002
+	InfoSelfServiceRecoveryEmailWithCodeSent                     // 1060003
 )
 
 const (
@@ -22,6 +23,9 @@ const (
 	ErrorValidationRecoveryMissingRecoveryToken                          // 4060003
 	ErrorValidationRecoveryTokenInvalidOrAlreadyUsed                     // 4060004
 	ErrorValidationRecoveryFlowExpired                                   // 4060005
+	ErrorValidationRecoveryAnswersInvalid                                // 4060006
+	ErrorValidationRecoveryCodeInvalidOrAlreadyUsed                      // 4060007
+	ErrorValidationRecoveryCodeAttemptsExceeded                          // 4060008
 )
 
 func NewErrorValidationRecoveryFlowExpired(ago time.Duration) *Message {
@@ -56,6 +60,15 @@ func NewRecoveryEmailSent() *Message {
 	}
 }
 
+func NewRecoveryEmailWithCodeSent() *Message {
+	return &Message{
+		ID:      InfoSelfServiceRecoveryEmailWithCodeSent,
+		Type:    Info,
+		Text:    "An email containing a recovery code has been sent to the email address you provided.",
+		Context: context(nil),
+	}
+}
+
 func NewErrorValidationRecoveryMissingRecoveryToken() error {
 	return errors.WithStack(herodot.
 		ErrBadRequest.
@@ -72,6 +85,24 @@ func NewErrorValidationRecoveryTokenInvalidOrAlreadyUsed() *Message {
 	}
 }
 
+func NewErrorValidationRecoveryCodeInvalidOrAlreadyUsed() *Message {
+	return &Message{
+		ID:      ErrorValidationRecoveryCodeInvalidOrAlreadyUsed,
+		Text:    "The recovery code is invalid or has already been used. Please try again.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewErrorValidationRecoveryCodeAttemptsExceeded() *Message {
+	return &Message{
+		ID:      ErrorValidationRecoveryCodeAttemptsExceeded,
+		Text:    "The recovery code was entered incorrectly too many times. Please request a new code.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
 func NewErrorValidationRecoveryRetrySuccess() *Message {
 	return &Message{
 		ID:      ErrorValidationRecoveryRetrySuccess,
@@ -89,3 +120,12 @@ func NewErrorValidationRecoveryStateFailure() *Message {
 		Context: context(nil),
 	}
 }
+
+func NewErrorValidationRecoveryAnswersInvalid() *Message {
+	return &Message{
+		ID:      ErrorValidationRecoveryAnswersInvalid,
+		Text:    "The answers are incorrect or this account can not be recovered using recovery questions. Please contact support if this keeps happening.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
@@ -10,11 +10,18 @@ const (
 	InfoSelfServiceSettingsUpdateSuccess
 	InfoSelfServiceSettingsUpdateLinkOidc
 	InfoSelfServiceSettingsUpdateUnlinkOidc
+	InfoSelfServiceSettingsUpdateLinkWallet
+	InfoSelfServiceSettingsUpdateUnlinkWallet
+	InfoSelfServiceSettingsPrivilegedCodeSent
+	InfoSelfServiceSettingsPrivilegedCodeResendThrottled
 )
 
 const (
 	ErrorValidationSettings ID = 4050000 + iota
 	ErrorValidationSettingsFlowExpired
+	ErrorValidationSettingsUsernameUnavailable
+	ErrorValidationSettingsUsernameChangeCooldown
+	ErrorValidationSettingsPrivilegedCodeInvalid
 )
 
 func NewErrorValidationSettingsFlowExpired(ago time.Duration) *Message {
@@ -28,6 +35,57 @@ func NewErrorValidationSettingsFlowExpired(ago time.Duration) *Message {
 	}
 }
 
+func NewErrorValidationSettingsUsernameUnavailable() *Message {
+	return &Message{
+		ID:      ErrorValidationSettingsUsernameUnavailable,
+		Text:    "This username is not available.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewErrorValidationSettingsUsernameChangeCooldown(allowedAt time.Time) *Message {
+	return &Message{
+		ID:   ErrorValidationSettingsUsernameChangeCooldown,
+		Text: fmt.Sprintf("Your username was changed recently. You can change it again after %s.", allowedAt.UTC().Format(time.RFC1123)),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"allowed_at": allowedAt,
+		}),
+	}
+}
+
+func NewErrorValidationSettingsPrivilegedCodeInvalid() *Message {
+	return &Message{
+		ID:      ErrorValidationSettingsPrivilegedCodeInvalid,
+		Text:    "The code is invalid or has expired. Please try again.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewInfoSelfServiceSettingsPrivilegedCodeSent(address string) *Message {
+	return &Message{
+		ID:   InfoSelfServiceSettingsPrivilegedCodeSent,
+		Text: fmt.Sprintf("A code has been sent to %s. Please enter it to confirm your changes.", address),
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"address": address,
+		}),
+	}
+}
+
+func NewInfoSelfServiceSettingsPrivilegedCodeResendThrottled(retryAt time.Time) *Message {
+	return &Message{
+		ID:   InfoSelfServiceSettingsPrivilegedCodeResendThrottled,
+		Text: fmt.Sprintf("A code was sent recently. Please enter it to confirm your changes or request a new one after %s.", retryAt.UTC().Format(time.RFC1123)),
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"retry_at": retryAt,
+		}),
+	}
+}
+
 func NewInfoSelfServiceSettingsUpdateSuccess() *Message {
 	return &Message{
 		ID:   InfoSelfServiceSettingsUpdateSuccess,
@@ -57,3 +115,22 @@ func NewInfoSelfServiceSettingsUpdateUnlinkOIDC(provider string) *Message {
 		}),
 	}
 }
+
+func NewInfoSelfServiceSettingsUpdateLinkWallet() *Message {
+	return &Message{
+		ID:   InfoSelfServiceSettingsUpdateLinkWallet,
+		Text: "Link Ethereum wallet",
+		Type: Info,
+	}
+}
+
+func NewInfoSelfServiceSettingsUpdateUnlinkWallet(address string) *Message {
+	return &Message{
+		ID:   InfoSelfServiceSettingsUpdateUnlinkWallet,
+		Text: fmt.Sprintf("Unlink %s", address),
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"address": address,
+		}),
+	}
+}
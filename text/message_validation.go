@@ -14,6 +14,14 @@ const (
 	ErrorValidationInvalidCredentials
 	ErrorValidationDuplicateCredentials
 	ErrorValidationTOTPVerifierWrong
+	ErrorValidationProviderClaimsRejected
+	ErrorValidationSIWEMessageInvalid
+	ErrorValidationSIWEWalletUnknown
+	ErrorValidationEmailDomainRejected
+	ErrorValidationNameReserved
+	ErrorValidationCaptchaFailed
+	ErrorValidationCourierUnavailable
+	ErrorValidationFlowNonceInvalid
 )
 
 func NewValidationErrorGeneric(reason string) *Message {
@@ -97,3 +105,84 @@ func NewErrorValidationTOTPVerifierWrong() *Message {
 		Context: context(nil),
 	}
 }
+
+func NewErrorValidationProviderClaimsRejected(provider string) *Message {
+	return &Message{
+		ID:   ErrorValidationProviderClaimsRejected,
+		Text: fmt.Sprintf("Your %s account can not be used to sign in here. Please use a different account.", provider),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"provider": provider,
+		}),
+	}
+}
+
+func NewErrorValidationSIWEMessageInvalid(reason string) *Message {
+	return &Message{
+		ID:   ErrorValidationSIWEMessageInvalid,
+		Text: fmt.Sprintf("The signed message can not be used because %s.", reason),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"reason": reason,
+		}),
+	}
+}
+
+func NewErrorValidationSIWEWalletUnknown() *Message {
+	return &Message{
+		ID:      ErrorValidationSIWEWalletUnknown,
+		Text:    "No account uses this wallet. Sign up with it or link it to your account first.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewErrorValidationEmailDomainRejected(domain, reason string) *Message {
+	return &Message{
+		ID:   ErrorValidationEmailDomainRejected,
+		Text: fmt.Sprintf("Email addresses from %s can not be used here because %s. Please use a different email address.", domain, reason),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"domain": domain,
+			"reason": reason,
+		}),
+	}
+}
+
+func NewErrorValidationNameReserved(name string) *Message {
+	return &Message{
+		ID:   ErrorValidationNameReserved,
+		Text: fmt.Sprintf("%q is reserved or not allowed. Please choose a different name.", name),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"name": name,
+		}),
+	}
+}
+
+func NewErrorValidationCaptchaFailed() *Message {
+	return &Message{
+		ID:      ErrorValidationCaptchaFailed,
+		Text:    "The challenge could not be verified. Please try again.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewErrorValidationCourierUnavailable() *Message {
+	return &Message{
+		ID:      ErrorValidationCourierUnavailable,
+		Text:    "We are unable to send emails right now. Please try again later.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewErrorValidationFlowNonceInvalid() *Message {
+	return &Message{
+		ID:      ErrorValidationFlowNonceInvalid,
+		Text:    "This form was already submitted or is outdated. Please reload the page and try again.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
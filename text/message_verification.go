@@ -7,7 +7,8 @@ import (
 
 const (
 	InfoSelfServiceVerification          ID = 1070000 + iota // 1070000
-	InfoSelfServiceVerificationEmailSent                     // 1070002
+	InfoSelfServiceVerificationEmailSent                     // 1070001
+	InfoSelfServiceVerificationSMSSent                       // 1070002
 )
 
 const (
@@ -17,6 +18,8 @@ const (
 	ErrorValidationVerificationStateFailure                                  // 4070003
 	ErrorValidationVerificationMissingVerificationToken                      // 4070004
 	ErrorValidationVerificationFlowExpired                                   // 4070005
+	ErrorValidationVerificationCodeInvalidOrAlreadyUsed                      // 4070006
+	ErrorValidationVerificationCodeAttemptsExceeded                          // 4070007
 )
 
 func NewErrorValidationVerificationFlowExpired(ago time.Duration) *Message {
@@ -39,6 +42,15 @@ func NewVerificationEmailSent() *Message {
 	}
 }
 
+func NewVerificationSMSSent() *Message {
+	return &Message{
+		ID:      InfoSelfServiceVerificationSMSSent,
+		Type:    Info,
+		Text:    "An SMS containing a verification code has been sent to the phone number you provided.",
+		Context: context(nil),
+	}
+}
+
 func NewErrorValidationVerificationTokenInvalidOrAlreadyUsed() *Message {
 	return &Message{
 		ID:      ErrorValidationVerificationTokenInvalidOrAlreadyUsed,
@@ -48,6 +60,24 @@ func NewErrorValidationVerificationTokenInvalidOrAlreadyUsed() *Message {
 	}
 }
 
+func NewErrorValidationVerificationCodeInvalidOrAlreadyUsed() *Message {
+	return &Message{
+		ID:      ErrorValidationVerificationCodeInvalidOrAlreadyUsed,
+		Text:    "The verification code is invalid or has already been used. Please try again.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewErrorValidationVerificationCodeAttemptsExceeded() *Message {
+	return &Message{
+		ID:      ErrorValidationVerificationCodeAttemptsExceeded,
+		Text:    "The verification code was entered incorrectly too many times. Please request a new code.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
 func NewErrorValidationVerificationRetrySuccess() *Message {
 	return &Message{
 		ID:      ErrorValidationVerificationRetrySuccess,
@@ -13,6 +13,7 @@ const (
 	InputAttributeTypeDateTimeLocal InputAttributeType = "datetime-local"
 	InputAttributeTypeDate          InputAttributeType = "date"
 	InputAttributeTypeURI           InputAttributeType = "url"
+	InputAttributeTypeTel           InputAttributeType = "tel"
 )
 
 // swagger:model uiNodeInputAttributeType
@@ -27,12 +27,17 @@ func (g Group) String() string {
 }
 
 const (
-	DefaultGroup          Group = "default"
-	PasswordGroup         Group = "password"
-	OpenIDConnectGroup    Group = "oidc"
-	ProfileGroup          Group = "profile"
-	RecoveryLinkGroup     Group = "link"
-	VerificationLinkGroup Group = "link"
+	DefaultGroup           Group = "default"
+	PasswordGroup          Group = "password"
+	OpenIDConnectGroup     Group = "oidc"
+	ProfileGroup           Group = "profile"
+	UsernameGroup          Group = "username"
+	PreferencesGroup       Group = "preferences"
+	SIWEGroup              Group = "siwe"
+	RecoveryLinkGroup      Group = "link"
+	RecoveryQuestionsGroup Group = "questions"
+	VerificationLinkGroup  Group = "link"
+	CaptchaGroup           Group = "captcha"
 
 	Text   Type = "text"
 	Input  Type = "input"
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker is a circuit breaker per web hook target. Once a target failed threshold times in a row, calls
+// to it are rejected until the open duration passed. A single call is then let through as a probe while
+// all others are still rejected. The probe either closes the circuit or opens it again.
+type breaker struct {
+	sync.Mutex
+	targets map[string]*breakerState
+}
+
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newBreaker() *breaker {
+	return &breaker{targets: map[string]*breakerState{}}
+}
+
+func (b *breaker) state(target string) *breakerState {
+	s, ok := b.targets[target]
+	if !ok {
+		s = new(breakerState)
+		b.targets[target] = s
+	}
+	return s
+}
+
+func (b *breaker) allow(target string, threshold int) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	s := b.state(target)
+	if threshold <= 0 || s.failures < threshold {
+		return true
+	} else if s.probing || !time.Now().After(s.openUntil) {
+		return false
+	}
+
+	s.probing = true
+	return true
+}
+
+func (b *breaker) record(target string, threshold int, openDuration time.Duration, failed bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	s := b.state(target)
+	s.probing = false
+	if !failed {
+		s.failures = 0
+		openCircuits.WithLabelValues(target).Set(0)
+		return
+	}
+
+	s.failures++
+	if threshold > 0 && s.failures >= threshold {
+		s.openUntil = time.Now().Add(openDuration)
+		openCircuits.WithLabelValues(target).Set(1)
+	}
+}
@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	// maxResponseSize limits how much of a web hook response is read.
+	maxResponseSize = 1 << 20
+
+	// asyncQueueSize limits how many asynchronous requests wait to be sent, and how many wait for their delay
+	// to pass.
+	asyncQueueSize = 1024
+
+	// asyncWorkers is how many asynchronous requests are sent concurrently.
+	asyncWorkers = 8
+)
+
+var (
+	ErrCircuitOpen    = herodot.ErrInternalServerError.WithReason("The web hook is temporarily disabled because it failed too often.")
+	ErrAsyncQueueFull = herodot.ErrInternalServerError.WithReason("The web hook was not called because too many asynchronous web hook requests are waiting to be sent.")
+)
+
+type (
+	clientDependencies interface {
+		config.Provider
+		x.LoggingProvider
+	}
+	ClientProvider interface {
+		WebhookClient() *Client
+	}
+	Client struct {
+		d     clientDependencies
+		c     *http.Client
+		b     *breaker
+		queue chan asyncRequest
+
+		// delayed counts the requests waiting for their delay to pass.
+		delayed int32
+		stopped chan struct{}
+		stop    sync.Once
+	}
+	asyncRequest struct {
+		method, target string
+		body           []byte
+		onError        func(error)
+	}
+)
+
+func NewClient(d clientDependencies) *Client {
+	return &Client{
+		d:       d,
+		c:       new(http.Client),
+		b:       newBreaker(),
+		queue:   make(chan asyncRequest, asyncQueueSize),
+		stopped: make(chan struct{}),
+	}
+}
+
+// SendAsync queues the request to be sent by Work once the delay has passed. onError is called if the request
+// fails. Instead of blocking, it returns ErrAsyncQueueFull if too many requests are waiting already. Requests
+// whose delay passes while the queue is full are dropped and reported to onError.
+func (c *Client) SendAsync(method, target string, body []byte, delay time.Duration, onError func(error)) error {
+	req := asyncRequest{method: method, target: target, body: body, onError: onError}
+	if delay <= 0 {
+		return c.enqueue(req)
+	}
+
+	if atomic.AddInt32(&c.delayed, 1) > asyncQueueSize {
+		atomic.AddInt32(&c.delayed, -1)
+		return errors.WithStack(ErrAsyncQueueFull)
+	}
+
+	// The delay passes outside of the workers, so delayed requests do not hold up the ones which are due.
+	time.AfterFunc(delay, func() {
+		atomic.AddInt32(&c.delayed, -1)
+		select {
+		case <-c.stopped:
+			return
+		default:
+		}
+
+		if err := c.enqueue(req); err != nil {
+			onError(err)
+		}
+	})
+	return nil
+}
+
+func (c *Client) enqueue(req asyncRequest) error {
+	select {
+	case c.queue <- req:
+		return nil
+	default:
+		return errors.WithStack(ErrAsyncQueueFull)
+	}
+}
+
+// Work sends the requests queued by SendAsync until the context is canceled. Requests which were not sent by
+// then are dropped.
+func (c *Client) Work(ctx context.Context) {
+	defer c.stop.Do(func() { close(c.stopped) })
+
+	var wg sync.WaitGroup
+	for k := 0; k < asyncWorkers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case req := <-c.queue:
+					if err := c.Send(ctx, req.method, req.target, req.body); err != nil {
+						req.onError(err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Send signs the body and sends it to the URL. Requests are retried with exponential backoff on network
+// errors and on responses with status code 429 or 5xx. Other responses with a status code other than 2xx
+// are treated as errors right away.
+func (c *Client) Send(ctx context.Context, method, target string, body []byte) error {
+	_, err := c.Do(ctx, method, target, body)
+	return err
+}
+
+// Do works like Send but returns the body of the successful response.
+func (c *Client) Do(ctx context.Context, method, target string, body []byte) ([]byte, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The web hook URL is invalid: %s", err))
+	}
+
+	conf := c.d.Config(ctx)
+	threshold := conf.WebhookCircuitBreakerThreshold()
+	if !c.b.allow(u.Host, threshold) {
+		requests.WithLabelValues(u.Host, "rejected").Inc()
+		return nil, errors.WithStack(ErrCircuitOpen.WithDebugf("circuit breaker for %s is open", u.Host))
+	}
+
+	var bc backoff.BackOff = &backoff.StopBackOff{}
+	if max := conf.WebhookMaxRetries(); max > 0 {
+		eb := backoff.NewExponentialBackOff()
+		eb.InitialInterval = conf.WebhookRetryInitialInterval()
+		eb.MaxInterval = conf.WebhookRetryMaxInterval()
+		eb.MaxElapsedTime = 0
+
+		// WithMaxRetries treats zero as unlimited which is why it is only used if retries are enabled.
+		bc = backoff.WithMaxRetries(eb, uint64(max))
+	}
+
+	var response []byte
+	err = backoff.RetryNotify(func() (err error) {
+		response, err = c.send(ctx, method, u.String(), body)
+		return err
+	}, backoff.WithContext(bc, ctx), func(err error, next time.Duration) {
+		retries.WithLabelValues(u.Host).Inc()
+		c.d.Logger().
+			WithError(err).
+			WithField("url", target).
+			WithField("retry_in", next).
+			Debug("Retrying the web hook request.")
+	})
+
+	c.b.record(u.Host, threshold, conf.WebhookCircuitBreakerOpenDuration(), err != nil)
+	if err != nil {
+		requests.WithLabelValues(u.Host, "failure").Inc()
+		return nil, err
+	}
+
+	requests.WithLabelValues(u.Host, "success").Inc()
+	return response, nil
+}
+
+func (c *Client) send(ctx context.Context, method, target string, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.d.Config(ctx).WebhookTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, backoff.Permanent(errors.WithStack(err))
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	SignRequest(req.Header, body, time.Now().UTC(), c.d.Config(ctx).SecretsWebhook()...)
+
+	res, err := c.c.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to call the web hook: %s", err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		response, err := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+		if err != nil {
+			return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to read the web hook response: %s", err))
+		}
+		return response, nil
+	}
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	c.d.Logger().
+		WithField("url", target).
+		WithField("status_code", res.StatusCode).
+		Warn("A web hook responded with an unexpected status code.")
+
+	err = errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The web hook responded with unexpected status code %d.", res.StatusCode))
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+		return nil, err
+	}
+	return nil, backoff.Permanent(err)
+}
@@ -0,0 +1,203 @@
+package webhook_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/webhook"
+)
+
+func TestClient(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyWebhookMaxRetries, 2)
+	conf.MustSet(config.ViperKeyWebhookRetryInitialInterval, "1ms")
+	conf.MustSet(config.ViperKeyWebhookRetryMaxInterval, "1ms")
+	conf.MustSet(config.ViperKeyWebhookCircuitBreakerThreshold, 0)
+	conf.MustSet(config.ViperKeyWebhookTimeout, "100ms")
+
+	var calls int32
+	newServer := func(t *testing.T, statuses ...int) string {
+		atomic.StoreInt32(&calls, 0)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := int(atomic.AddInt32(&calls, 1)) - 1
+			if n >= len(statuses) {
+				n = len(statuses) - 1
+			}
+			if statuses[n] == 0 {
+				time.Sleep(time.Second)
+				return
+			}
+			w.WriteHeader(statuses[n])
+		}))
+		t.Cleanup(ts.Close)
+		return ts.URL
+	}
+
+	c := webhook.NewClient(reg)
+	ctx := context.Background()
+
+	t.Run("case=retries server errors", func(t *testing.T) {
+		require.NoError(t, c.Send(ctx, "POST", newServer(t, 503, 429, 200), []byte("{}")))
+		assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=gives up after max retries", func(t *testing.T) {
+		require.Error(t, c.Send(ctx, "POST", newServer(t, 500), []byte("{}")))
+		assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=does not retry client errors", func(t *testing.T) {
+		require.Error(t, c.Send(ctx, "POST", newServer(t, 400, 200), []byte("{}")))
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=times out", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyWebhookMaxRetries, 0)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyWebhookMaxRetries, 2)
+		})
+
+		start := time.Now()
+		require.Error(t, c.Send(ctx, "POST", newServer(t, 0), []byte("{}")))
+		assert.True(t, time.Since(start) < time.Second)
+	})
+
+	t.Run("case=circuit breaker", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyWebhookMaxRetries, 0)
+		conf.MustSet(config.ViperKeyWebhookCircuitBreakerThreshold, 2)
+		conf.MustSet(config.ViperKeyWebhookCircuitBreakerOpenDuration, "100ms")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyWebhookMaxRetries, 2)
+			conf.MustSet(config.ViperKeyWebhookCircuitBreakerThreshold, 0)
+		})
+
+		target := newServer(t, 500, 500, 200)
+		require.Error(t, c.Send(ctx, "POST", target, []byte("{}")))
+		require.Error(t, c.Send(ctx, "POST", target, []byte("{}")))
+
+		err := c.Send(ctx, "POST", target, []byte("{}"))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, webhook.ErrCircuitOpen), "%+v", err)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls), "the open circuit must not call the target")
+
+		time.Sleep(150 * time.Millisecond)
+		require.NoError(t, c.Send(ctx, "POST", target, []byte("{}")))
+		require.NoError(t, c.Send(ctx, "POST", target, []byte("{}")))
+	})
+
+	t.Run("case=circuit breaker lets a single probe through", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyWebhookMaxRetries, 0)
+		conf.MustSet(config.ViperKeyWebhookCircuitBreakerThreshold, 2)
+		conf.MustSet(config.ViperKeyWebhookCircuitBreakerOpenDuration, "100ms")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyWebhookMaxRetries, 2)
+			conf.MustSet(config.ViperKeyWebhookCircuitBreakerThreshold, 0)
+		})
+
+		target := newServer(t, 500, 500, 0, 200)
+		require.Error(t, c.Send(ctx, "POST", target, []byte("{}")))
+		require.Error(t, c.Send(ctx, "POST", target, []byte("{}")))
+		time.Sleep(150 * time.Millisecond)
+
+		probe := make(chan error, 1)
+		go func() { probe <- c.Send(ctx, "POST", target, []byte("{}")) }()
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 3 }, time.Second, time.Millisecond)
+
+		err := c.Send(ctx, "POST", target, []byte("{}"))
+		assert.True(t, errors.Is(err, webhook.ErrCircuitOpen), "calls must be rejected while the probe is in flight: %+v", err)
+
+		require.Error(t, <-probe)
+		err = c.Send(ctx, "POST", target, []byte("{}"))
+		assert.True(t, errors.Is(err, webhook.ErrCircuitOpen), "a failed probe must open the circuit again: %+v", err)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+
+		time.Sleep(150 * time.Millisecond)
+		require.NoError(t, c.Send(ctx, "POST", target, []byte("{}")))
+		require.NoError(t, c.Send(ctx, "POST", target, []byte("{}")))
+	})
+
+	t.Run("case=sends queued requests in the background", func(t *testing.T) {
+		c := webhook.NewClient(reg)
+		ctx, cancel := context.WithCancel(ctx)
+		t.Cleanup(cancel)
+		go c.Work(ctx)
+
+		failed := make(chan error, 1)
+		require.NoError(t, c.SendAsync("POST", newServer(t, 400), []byte("{}"), 0, func(err error) { failed <- err }))
+
+		select {
+		case err := <-failed:
+			assert.Error(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the failure was not reported")
+		}
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=delayed requests do not hold up due ones", func(t *testing.T) {
+		c := webhook.NewClient(reg)
+		ctx, cancel := context.WithCancel(ctx)
+		t.Cleanup(cancel)
+		go c.Work(ctx)
+
+		target := newServer(t, 200)
+		for k := 0; k < 20; k++ {
+			require.NoError(t, c.SendAsync("POST", target, []byte("{}"), time.Hour, func(err error) { t.Error(err) }))
+		}
+
+		sent := make(chan error, 2)
+		require.NoError(t, c.SendAsync("POST", newServer(t, 400), []byte("{}"), 0, func(err error) { sent <- err }))
+		require.NoError(t, c.SendAsync("POST", newServer(t, 400), []byte("{}"), 50*time.Millisecond, func(err error) { sent <- err }))
+
+		for k := 0; k < 2; k++ {
+			select {
+			case err := <-sent:
+				assert.Error(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("the request was not sent")
+			}
+		}
+	})
+
+	t.Run("case=drops waiting requests once stopped", func(t *testing.T) {
+		c := webhook.NewClient(reg)
+		ctx, cancel := context.WithCancel(ctx)
+		stopped := make(chan struct{})
+		go func() {
+			c.Work(ctx)
+			close(stopped)
+		}()
+
+		require.NoError(t, c.SendAsync("POST", newServer(t, 200), []byte("{}"), time.Hour, func(err error) { t.Error(err) }))
+		cancel()
+
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the workers did not stop")
+		}
+		assert.EqualValues(t, 0, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=does not block if too many requests are queued", func(t *testing.T) {
+		c := webhook.NewClient(reg)
+		target := newServer(t, 200)
+
+		var err error
+		for k := 0; k < 10000 && err == nil; k++ {
+			err = c.SendAsync("POST", target, []byte("{}"), 0, func(err error) { t.Error(err) })
+		}
+		assert.True(t, errors.Is(err, webhook.ErrAsyncQueueFull), "%+v", err)
+		assert.EqualValues(t, 0, atomic.LoadInt32(&calls), "requests are only sent by the workers")
+	})
+}
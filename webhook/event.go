@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/herodot"
+	"github.com/ory/jsonschema/v3"
+)
+
+// The events sent to web hooks. Changes to their payloads must bump Version.
+const (
+	EventRegistrationEnrich = "registration.enrich"
+	EventRegistrationAfter  = "registration.after"
+	EventLoginAfter         = "login.after"
+	EventSettingsAfter      = "settings.after"
+	EventLinkExpired        = "link.expired"
+	EventFlowCompleted      = "flow.completed"
+)
+
+//go:embed .schema/events/*.schema.json
+var eventSchemas embed.FS
+
+var events = []struct {
+	event, file, description string
+}{
+	{
+		event:       EventRegistrationEnrich,
+		file:        "flow",
+		description: "Sent by the `identity_enricher` registration hook before the identity is created. The response's `traits` are merged into the identity's traits.",
+	},
+	{
+		event:       EventRegistrationAfter,
+		file:        "flow",
+		description: "Sent by the `web_hook` registration hook after the identity was created.",
+	},
+	{
+		event:       EventLoginAfter,
+		file:        "flow",
+		description: "Sent by the `web_hook` login hook after the identity signed in.",
+	},
+	{
+		event:       EventSettingsAfter,
+		file:        "flow",
+		description: "Sent by the `web_hook` settings hook after the identity's settings were updated.",
+	},
+	{
+		event:       EventLinkExpired,
+		file:        "link_expired",
+		description: "Sent to `selfservice.flows.<verification|recovery>.expired_links.web_hook_url` when a link expired without being used. The payload has no `event` field.",
+	},
+	{
+		event:       EventFlowCompleted,
+		file:        "flow_result",
+		description: "Sent to the `callback_url` requested when initializing a verification or recovery flow once the flow completed.",
+	},
+}
+
+// EventSchema is the JSON Schema of an event's payload.
+//
+// swagger:model webhookEventSchema
+type EventSchema struct {
+	// Event is the event's name, for example `login.after`. It is the payload's `event` field.
+	//
+	// required: true
+	Event string `json:"event"`
+
+	// Version is the payload version, which is also sent in the `X-Kratos-Webhook-Version` header.
+	//
+	// required: true
+	Version string `json:"version"`
+
+	// Description describes when the event is sent.
+	//
+	// required: true
+	Description string `json:"description"`
+
+	// Schema is the JSON Schema of the payload.
+	//
+	// required: true
+	Schema json.RawMessage `json:"schema"`
+}
+
+// EventSchemaID returns the `$id` of an event's payload schema.
+func EventSchemaID(event string) string {
+	return fmt.Sprintf("https://schemas.ory.sh/kratos/webhook/%s/%s.schema.json", Version, event)
+}
+
+// EventSchemas returns the payload schemas of all events.
+func EventSchemas() ([]EventSchema, error) {
+	schemas := make([]EventSchema, len(events))
+	for k := range events {
+		s, err := eventSchema(k)
+		if err != nil {
+			return nil, err
+		}
+		schemas[k] = *s
+	}
+	return schemas, nil
+}
+
+// GetEventSchema returns the payload schema of the event.
+func GetEventSchema(event string) (*EventSchema, error) {
+	for k := range events {
+		if events[k].event == event {
+			return eventSchema(k)
+		}
+	}
+	return nil, errors.WithStack(herodot.ErrNotFound.WithReasonf("Event %s does not exist.", event))
+}
+
+// ValidateEventPayload validates the payload against the event's schema.
+func ValidateEventPayload(event string, payload []byte) error {
+	s, err := GetEventSchema(event)
+	if err != nil {
+		return err
+	}
+
+	id := EventSchemaID(event)
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(id, bytes.NewReader(s.Schema)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	schema, err := c.Compile(id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(schema.Validate(bytes.NewReader(payload)))
+}
+
+func eventSchema(k int) (*EventSchema, error) {
+	e := events[k]
+	schema, err := eventSchemas.ReadFile(".schema/events/" + e.file + ".schema.json")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	schema, err = sjson.SetBytes(schema, "$id", EventSchemaID(e.event))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	schema, err = sjson.SetBytes(schema, "title", e.event)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if e.file == "flow" {
+		// All flow events share the payload, so the event name is pinned here.
+		if schema, err = sjson.SetBytes(schema, "properties.event.const", e.event); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return &EventSchema{Event: e.event, Version: Version, Description: e.description, Schema: schema}, nil
+}
@@ -0,0 +1,73 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/webhook"
+	"github.com/ory/kratos/x"
+)
+
+func TestEventSchemas(t *testing.T) {
+	schemas, err := webhook.EventSchemas()
+	require.NoError(t, err)
+	require.NotEmpty(t, schemas)
+
+	for _, s := range schemas {
+		assert.Equal(t, webhook.Version, s.Version)
+		assert.NotEmpty(t, s.Description)
+		assert.Equal(t, webhook.EventSchemaID(s.Event), gjson.GetBytes(s.Schema, "$id").String())
+
+		// Every schema compiles.
+		assert.Error(t, webhook.ValidateEventPayload(s.Event, []byte(`{}`)), "%s", s.Event)
+	}
+
+	t.Run("case=pins the event name of flow events", func(t *testing.T) {
+		payload := []byte(`{"event":"login.after","flow_id":"` + x.NewUUID().String() + `","request_url":"http://localhost/self-service/login/browser","identity":{"id":"` + x.NewUUID().String() + `","schema_id":"default","traits":{}}}`)
+		require.NoError(t, webhook.ValidateEventPayload(webhook.EventLoginAfter, payload))
+		assert.Error(t, webhook.ValidateEventPayload(webhook.EventRegistrationAfter, payload))
+	})
+
+	t.Run("case=unknown events do not exist", func(t *testing.T) {
+		_, err := webhook.GetEventSchema("identity.exploded")
+		assert.Error(t, err)
+	})
+
+	t.Run("case=serves the schemas", func(t *testing.T) {
+		_, reg := internal.NewFastRegistryWithMocks(t)
+		router := x.NewRouterAdmin()
+		reg.WebhookHandler().RegisterAdminRoutes(router)
+		ts := httptest.NewServer(router)
+		t.Cleanup(ts.Close)
+
+		res, err := ts.Client().Get(ts.URL + webhook.RouteEventSchemas)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var listed []webhook.EventSchema
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&listed))
+		assert.Len(t, listed, len(schemas))
+
+		res, err = ts.Client().Get(ts.URL + webhook.RouteEventSchemas + "/" + webhook.EventLinkExpired)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, webhook.EventSchemaID(webhook.EventLinkExpired), gjson.GetBytes(body, "$id").String())
+
+		res, err = ts.Client().Get(ts.URL + webhook.RouteEventSchemas + "/identity.exploded")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ory/kratos/x"
+)
+
+const RouteEventSchemas = "/events/schemas"
+
+type (
+	handlerDependencies interface {
+		x.WriterProvider
+	}
+	HandlerProvider interface {
+		WebhookHandler() *Handler
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteEventSchemas, h.list)
+	admin.GET(RouteEventSchemas+"/:event", h.get)
+}
+
+// A list of web hook event schemas.
+// swagger:response webhookEventSchemaList
+// nolint:deadcode,unused
+type webhookEventSchemaListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []EventSchema
+}
+
+// swagger:route GET /events/schemas admin listWebhookEventSchemas
+//
+// List the Web Hook Event Schemas
+//
+// This endpoint returns the JSON Schemas of all payloads sent to web hooks, for example to generate code or
+// to validate received payloads. Schemas are versioned: their `$id` contains the version, which is also sent
+// in the `X-Kratos-Webhook-Version` header of every web hook request.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: webhookEventSchemaList
+//       500: genericError
+func (h *Handler) list(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	schemas, err := EventSchemas()
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, schemas)
+}
+
+// nolint:deadcode,unused
+// swagger:parameters getWebhookEventSchema
+type getWebhookEventSchemaParameters struct {
+	// Event is the event's name, for example `login.after`.
+	//
+	// required: true
+	// in: path
+	Event string `json:"event"`
+}
+
+// swagger:route GET /events/schemas/{event} admin getWebhookEventSchema
+//
+// Get a Web Hook Event Schema
+//
+// This endpoint returns the JSON Schema of an event's payload without the envelope returned when listing
+// schemas, so that it can be passed to code generators or validators directly.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: jsonSchema
+//       404: genericError
+//       500: genericError
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	s, err := GetEventSchema(ps.ByName("event"))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(s.Schema)
+}
@@ -0,0 +1,22 @@
+package webhook
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kratos_webhook_requests_total",
+		Help: "Number of web hook calls by target host and result (success, failure, or rejected by the circuit breaker).",
+	}, []string{"target", "result"})
+	retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kratos_webhook_retries_total",
+		Help: "Number of retried web hook requests by target host.",
+	}, []string{"target"})
+	openCircuits = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kratos_webhook_circuit_open",
+		Help: "Is 1 if the circuit breaker for the target host is open and 0 otherwise.",
+	}, []string{"target"})
+)
+
+func init() {
+	prometheus.MustRegister(requests, retries, openCircuits)
+}
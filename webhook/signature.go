@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// HeaderSignature contains one HMAC-SHA256 signature per configured signing secret, for example
+	// `sha256=<hex>,sha256=<hex>`. Receivers must accept the request if any of the signatures is valid.
+	HeaderSignature = "X-Kratos-Signature"
+
+	// HeaderTimestamp contains the unix timestamp at which the request was signed.
+	HeaderTimestamp = "X-Kratos-Timestamp"
+
+	// HeaderVersion contains the version of the payload schema.
+	HeaderVersion = "X-Kratos-Webhook-Version"
+
+	// Version is the current version of the webhook payload schema.
+	Version = "v1"
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrMissingSignature = errors.New("the webhook request is not signed")
+	ErrInvalidSignature = errors.New("the webhook signature is invalid")
+	ErrInvalidTimestamp = errors.New("the webhook timestamp is missing, malformed, or outside the tolerance")
+)
+
+// Sign returns the HMAC-SHA256 signature of the body for the given timestamp and payload version. The
+// signed content is `<timestamp>.<version>.<body>`.
+func Sign(secret []byte, timestamp time.Time, version string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "." + version + "."))
+	_, _ = mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest sets the timestamp, version, and signature headers. The body is signed with every secret to
+// allow receivers to rotate their secret without downtime. No signature is added if no secrets are given.
+func SignRequest(h http.Header, body []byte, timestamp time.Time, secrets ...[]byte) {
+	h.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	h.Set(HeaderVersion, Version)
+
+	if len(secrets) == 0 {
+		return
+	}
+
+	signatures := make([]string, len(secrets))
+	for k, secret := range secrets {
+		signatures[k] = Sign(secret, timestamp, Version, body)
+	}
+	h.Set(HeaderSignature, strings.Join(signatures, ","))
+}
+
+// Verify checks the signature headers of a webhook request sent by ORY Kratos. Requests whose timestamp
+// deviates more than tolerance from the current time are rejected to prevent replay attacks. Any of the
+// secrets may match which allows rotating the secret.
+func Verify(h http.Header, body []byte, tolerance time.Duration, secrets ...[]byte) error {
+	unix, err := strconv.ParseInt(h.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return errors.WithStack(ErrInvalidTimestamp)
+	}
+
+	timestamp := time.Unix(unix, 0)
+	if d := time.Since(timestamp); d > tolerance || d < -tolerance {
+		return errors.WithStack(ErrInvalidTimestamp)
+	}
+
+	header := h.Get(HeaderSignature)
+	if len(header) == 0 {
+		return errors.WithStack(ErrMissingSignature)
+	}
+
+	version := h.Get(HeaderVersion)
+	for _, secret := range secrets {
+		expected := Sign(secret, timestamp, version, body)
+		for _, actual := range strings.Split(header, ",") {
+			if hmac.Equal([]byte(expected), []byte(strings.TrimSpace(actual))) {
+				return nil
+			}
+		}
+	}
+
+	return errors.WithStack(ErrInvalidSignature)
+}
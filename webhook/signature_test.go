@@ -0,0 +1,66 @@
+package webhook_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/webhook"
+)
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"foo":"bar"}`)
+	current, previous := []byte("current-secret-1234"), []byte("previous-secret-1234")
+
+	sign := func(at time.Time, secrets ...[]byte) http.Header {
+		h := http.Header{}
+		webhook.SignRequest(h, body, at, secrets...)
+		return h
+	}
+
+	t.Run("case=valid signature", func(t *testing.T) {
+		h := sign(time.Now())
+		assert.Equal(t, webhook.Version, h.Get(webhook.HeaderVersion))
+		assert.NotEmpty(t, h.Get(webhook.HeaderTimestamp))
+		assert.Empty(t, h.Get(webhook.HeaderSignature), "no signature without secrets")
+
+		require.NoError(t, webhook.Verify(sign(time.Now(), current), body, time.Minute, current))
+	})
+
+	t.Run("case=rotated secrets", func(t *testing.T) {
+		h := sign(time.Now(), current, previous)
+		require.NoError(t, webhook.Verify(h, body, time.Minute, current))
+		require.NoError(t, webhook.Verify(h, body, time.Minute, previous))
+		require.NoError(t, webhook.Verify(sign(time.Now(), previous), body, time.Minute, current, previous))
+	})
+
+	for _, tc := range []struct {
+		d        string
+		h        http.Header
+		body     []byte
+		secrets  [][]byte
+		expected error
+	}{
+		{d: "unsigned", h: sign(time.Now()), body: body, secrets: [][]byte{current}, expected: webhook.ErrMissingSignature},
+		{d: "wrong secret", h: sign(time.Now(), previous), body: body, secrets: [][]byte{current}, expected: webhook.ErrInvalidSignature},
+		{d: "tampered body", h: sign(time.Now(), current), body: []byte(`{"foo":"baz"}`), secrets: [][]byte{current}, expected: webhook.ErrInvalidSignature},
+		{d: "expired", h: sign(time.Now().Add(-time.Hour), current), body: body, secrets: [][]byte{current}, expected: webhook.ErrInvalidTimestamp},
+		{d: "missing timestamp", h: http.Header{}, body: body, secrets: [][]byte{current}, expected: webhook.ErrInvalidTimestamp},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			err := webhook.Verify(tc.h, tc.body, time.Minute, tc.secrets...)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tc.expected), "%+v", err)
+		})
+	}
+
+	t.Run("case=tampered version", func(t *testing.T) {
+		h := sign(time.Now(), current)
+		h.Set(webhook.HeaderVersion, "v0")
+		assert.True(t, errors.Is(webhook.Verify(h, body, time.Minute, current), webhook.ErrInvalidSignature))
+	})
+}
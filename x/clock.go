@@ -0,0 +1,55 @@
+package x
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Clock is the source of the current time used when issuing and checking flows, tokens, and sessions.
+	// Replace it to freeze or skew time, for example in tests.
+	Clock interface {
+		Now() time.Time
+	}
+
+	ClockProvider interface {
+		Clock() Clock
+	}
+
+	// SystemClock returns the time of the operating system.
+	SystemClock struct{}
+
+	// FrozenClock returns a fixed time which only changes when it is set or advanced.
+	FrozenClock struct {
+		sync.RWMutex
+		now time.Time
+	}
+)
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func NewFrozenClock(now time.Time) *FrozenClock {
+	return &FrozenClock{now: now}
+}
+
+func (c *FrozenClock) Now() time.Time {
+	c.RLock()
+	defer c.RUnlock()
+	return c.now
+}
+
+// Set sets the time returned by the clock.
+func (c *FrozenClock) Set(now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by d, or backwards if d is negative.
+func (c *FrozenClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+}
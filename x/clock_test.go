@@ -0,0 +1,20 @@
+package x
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrozenClock(t *testing.T) {
+	now := time.Date(2021, 5, 7, 10, 0, 0, 0, time.UTC)
+	c := NewFrozenClock(now)
+	assert.Equal(t, now, c.Now())
+
+	c.Advance(time.Hour)
+	assert.Equal(t, now.Add(time.Hour), c.Now())
+
+	c.Set(now)
+	assert.Equal(t, now, c.Now())
+}
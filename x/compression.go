@@ -0,0 +1,170 @@
+package x
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// UncompressedPublicPathPrefixes are the public routes whose responses are never compressed. They carry CSRF and
+// session tokens next to reflected input such as identifiers and return_to URLs, which makes compressed responses
+// vulnerable to BREACH.
+var UncompressedPublicPathPrefixes = []string{"/self-service/", "/sessions"}
+
+// NewCompressionHandler compresses responses using brotli or gzip, depending on the request's
+// Accept-Encoding header. Responses smaller than minSize bytes and responses to requests whose path starts
+// with one of the excluded prefixes are sent uncompressed.
+func NewCompressionHandler(next http.Handler, minSize int, excludedPathPrefixes ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead || hasPathPrefix(r.URL.Path, excludedPathPrefixes) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func negotiateEncoding(accept string) string {
+	var gz bool
+	for _, part := range strings.Split(accept, ",") {
+		coding := strings.TrimSpace(part)
+		if idx := strings.Index(coding, ";"); idx >= 0 {
+			if q := strings.TrimSpace(coding[idx+1:]); q == "q=0" || q == "q=0.0" {
+				continue
+			}
+			coding = strings.TrimSpace(coding[:idx])
+		}
+
+		switch strings.ToLower(coding) {
+		case "br":
+			return "br"
+		case "gzip":
+			gz = true
+		}
+	}
+
+	if gz {
+		return "gzip"
+	}
+	return ""
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	buf     []byte
+	status  int
+	decided bool
+	cw      io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+
+	w.status = code
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		w.start(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		if w.cw != nil {
+			return w.cw.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// start decides whether the response is compressed and writes the buffered response.
+func (w *compressWriter) start(compress bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	h := w.Header()
+	if compress && h.Get("Content-Encoding") == "" {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		switch w.encoding {
+		case "br":
+			w.cw = brotli.NewWriter(w.ResponseWriter)
+		default:
+			w.cw = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if w.cw != nil {
+		_, err := w.cw.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.start(len(w.buf) >= w.minSize)
+	}
+
+	if f, ok := w.cw.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			// Nothing was written, let net/http write the default response.
+			return nil
+		}
+		if err := w.start(false); err != nil {
+			return err
+		}
+	}
+
+	if w.cw != nil {
+		return w.cw.Close()
+	}
+	return nil
+}
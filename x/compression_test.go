@@ -0,0 +1,121 @@
+package x
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for accept, expected := range map[string]string{
+		"":                   "",
+		"identity":           "",
+		"gzip":               "gzip",
+		"gzip, deflate, br":  "br",
+		"br;q=0, gzip;q=0.5": "gzip",
+		"GZIP;q=0":           "",
+	} {
+		assert.Equal(t, expected, negotiateEncoding(accept), "%s", accept)
+	}
+}
+
+func TestCompressionHandler(t *testing.T) {
+	large := strings.Repeat("kratos", 100)
+	h := NewCompressionHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(large[:len(large)/2]))
+			_, _ = w.Write([]byte(large[len(large)/2:]))
+		case "/small":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("small"))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}), 100)
+
+	do := func(t *testing.T, path, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("Accept-Encoding", accept)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("case=gzip", func(t *testing.T) {
+		w := do(t, "/large", "gzip")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+
+		gr, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(gr)
+		require.NoError(t, err)
+		assert.Equal(t, large, string(body))
+	})
+
+	t.Run("case=brotli", func(t *testing.T) {
+		w := do(t, "/large", "gzip, br")
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+
+		body, err := ioutil.ReadAll(brotli.NewReader(w.Body))
+		require.NoError(t, err)
+		assert.Equal(t, large, string(body))
+	})
+
+	t.Run("case=small payloads are not compressed", func(t *testing.T) {
+		w := do(t, "/small", "gzip")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "small", w.Body.String())
+	})
+
+	t.Run("case=responses without body are not compressed", func(t *testing.T) {
+		w := do(t, "/empty", "gzip")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("case=token-bearing public responses are not compressed", func(t *testing.T) {
+		h := NewCompressionHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(large))
+		}), 100, UncompressedPublicPathPrefixes...)
+
+		for path, compressed := range map[string]bool{
+			"/self-service/login/browser": false,
+			"/self-service/settings":      false,
+			"/sessions/whoami":            false,
+			"/schemas/default":            true,
+		} {
+			r := httptest.NewRequest("GET", path, nil)
+			r.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if compressed {
+				assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"), "%s", path)
+			} else {
+				assert.Empty(t, w.Header().Get("Content-Encoding"), "%s", path)
+				assert.Equal(t, large, w.Body.String(), "%s", path)
+			}
+		}
+	})
+
+	t.Run("case=client does not accept compression", func(t *testing.T) {
+		w := do(t, "/large", "")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Header().Get("Vary"))
+		assert.Equal(t, large, w.Body.String())
+	})
+}
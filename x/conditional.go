@@ -0,0 +1,66 @@
+package x
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ETag returns a strong entity tag for a response body which is written exactly as given.
+func ETag(body []byte) string {
+	return `"` + digest(body) + `"`
+}
+
+// WeakETag returns a weak entity tag for the JSON representation of v. The tag is weak because the writer
+// which encodes the response body might not produce the same bytes as json.Marshal.
+func WeakETag(v interface{}) (string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return `W/"` + digest(body) + `"`, nil
+}
+
+func digest(body []byte) string {
+	h := sha256.Sum256(body)
+	return hex.EncodeToString(h[:16])
+}
+
+// NotModified sets the ETag header and, unless lastModified is zero, the Last-Modified header. If the
+// request's If-None-Match header, or If-Modified-Since header if If-None-Match is missing, shows that the
+// client already has the current representation, it responds with 304 Not Modified and returns true. The
+// response body must not be written in that case.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if match := r.Header.Get("If-None-Match"); len(match) > 0 {
+		if !etagMatches(match, etag) {
+			return false
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err != nil ||
+		lastModified.IsZero() || lastModified.Truncate(time.Second).After(since) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches compares the entity tags of an If-None-Match header weakly, as required by RFC 7232.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
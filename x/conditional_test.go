@@ -0,0 +1,50 @@
+package x
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotModified(t *testing.T) {
+	etag, err := WeakETag(map[string]string{"foo": "bar"})
+	require.NoError(t, err)
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, ETag([]byte("foo")))
+
+	modified := time.Date(2021, 5, 20, 10, 0, 0, 500, time.UTC)
+	for _, tc := range []struct {
+		d        string
+		header   http.Header
+		modified time.Time
+		expected bool
+	}{
+		{d: "no conditions", modified: modified},
+		{d: "matching etag", header: http.Header{"If-None-Match": {etag}}, expected: true},
+		{d: "matching strong etag", header: http.Header{"If-None-Match": {`"other", ` + etag[2:]}}, expected: true},
+		{d: "wildcard", header: http.Header{"If-None-Match": {"*"}}, expected: true},
+		{d: "other etag", header: http.Header{"If-None-Match": {`"other"`}}},
+		{d: "not modified since", header: http.Header{"If-Modified-Since": {modified.Format(http.TimeFormat)}}, modified: modified, expected: true},
+		{d: "modified since", header: http.Header{"If-Modified-Since": {modified.Add(-time.Second).Format(http.TimeFormat)}}, modified: modified},
+		{d: "unknown modification time", header: http.Header{"If-Modified-Since": {modified.Format(http.TimeFormat)}}},
+		{d: "etag takes precedence", header: http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {modified.Format(http.TimeFormat)}}, modified: modified},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tc.header {
+				r.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+
+			assert.Equal(t, tc.expected, NotModified(w, r, etag, tc.modified))
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+			if tc.expected {
+				assert.Equal(t, http.StatusNotModified, w.Code)
+			}
+		})
+	}
+}
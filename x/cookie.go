@@ -2,9 +2,13 @@ package x
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/sessions"
 	"github.com/pkg/errors"
+	"github.com/urfave/negroni"
+
+	"github.com/ory/kratos/driver/config"
 )
 
 // SessionPersistValues adds values to the session store and persists the changes.
@@ -68,3 +72,42 @@ func SessionUnsetKey(w http.ResponseWriter, r *http.Request, s sessions.Store, i
 	delete(cookie.Values, key)
 	return errors.WithStack(cookie.Save(r, w))
 }
+
+// NewPartitionedCookieHandler adds the Partitioned attribute (CHIPS) to the session, anti-CSRF, and continuity
+// cookies if configured. The cookie libraries in use do not support this attribute, which is why it is appended
+// to the Set-Cookie headers right before they are written. This requires a negroni.ResponseWriter.
+func NewPartitionedCookieHandler(reg config.Provider) negroni.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if rw, ok := w.(negroni.ResponseWriter); ok {
+			rw.Before(func(rw negroni.ResponseWriter) {
+				conf := reg.Config(r.Context())
+
+				var names []string
+				if conf.SessionCookie().Partitioned {
+					names = append(names, conf.SessionName())
+				}
+				if conf.CSRFCookie().Partitioned {
+					names = append(names, conf.CSRFCookieName(r))
+				}
+				if conf.ContinuityCookie().Partitioned {
+					names = append(names, conf.ContinuityCookieName())
+				}
+
+				PartitionCookies(rw.Header(), names...)
+			})
+		}
+		next(w, r)
+	}
+}
+
+// PartitionCookies adds the Partitioned attribute to all Set-Cookie headers of the named cookies.
+func PartitionCookies(h http.Header, names ...string) {
+	cookies := h["Set-Cookie"]
+	for k, c := range cookies {
+		for _, name := range names {
+			if strings.HasPrefix(c, name+"=") && !strings.HasSuffix(c, "; Partitioned") {
+				cookies[k] = c + "; Partitioned"
+			}
+		}
+	}
+}
@@ -82,3 +82,18 @@ func TestSession(t *testing.T) {
 		mr(t, id)
 	})
 }
+
+func TestPartitionCookies(t *testing.T) {
+	h := http.Header{}
+	h.Add("Set-Cookie", "session=foo; Path=/; Secure")
+	h.Add("Set-Cookie", "csrf=bar; Path=/; Secure")
+	h.Add("Set-Cookie", "session_other=baz; Path=/; Secure")
+
+	PartitionCookies(h, "session", "unknown")
+	PartitionCookies(h, "session")
+	assert.Equal(t, []string{
+		"session=foo; Path=/; Secure; Partitioned",
+		"csrf=bar; Path=/; Secure",
+		"session_other=baz; Path=/; Secure",
+	}, h.Values("Set-Cookie"))
+}
@@ -0,0 +1,121 @@
+package x
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type csrfModeContextKey struct{}
+
+var safeMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace}
+
+// IsCSRFVerifiedByMode returns true if the request was checked by a CSRF protection mode other than the
+// double-submit cookie. Flows use this to skip comparing the anti-CSRF token with the cookie.
+func IsCSRFVerifiedByMode(r *http.Request) bool {
+	verified, _ := r.Context().Value(csrfModeContextKey{}).(bool)
+	return verified
+}
+
+// CSRFExemptByMode exempts all requests from the double-submit cookie check whose route group uses
+// another CSRF protection mode. Requests in the `origin` mode without Origin and Referer headers are not
+// exempt because their origin can not be checked.
+func CSRFExemptByMode(reg config.Provider) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		switch reg.Config(r.Context()).CSRFMode(r.URL.Path) {
+		case config.CSRFModeCookie:
+			return false
+		case config.CSRFModeOrigin:
+			return hasCSRFOrigin(r)
+		}
+		return true
+	}
+}
+
+// NewCSRFModeHandler enforces the `header` and `origin` CSRF protection modes. It must be wrapped by the
+// double-submit cookie handler which exempts these routes using CSRFExemptByMode.
+func NewCSRFModeHandler(next http.Handler, reg interface {
+	config.Provider
+	WriterProvider
+	LoggingProvider
+}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conf := reg.Config(r.Context())
+		mode := conf.CSRFMode(r.URL.Path)
+		if mode == config.CSRFModeCookie {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		for _, m := range safeMethods {
+			if r.Method == m {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if mode == config.CSRFModeOrigin && !hasCSRFOrigin(r) {
+			// The anti-CSRF token is checked instead, like in the `cookie` mode.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var err error
+		switch mode {
+		case config.CSRFModeHeader:
+			if len(r.Header.Get(conf.CSRFHeaderName())) == 0 {
+				err = errors.WithStack(herodot.ErrBadRequest.WithReasonf("The %s header is missing.", conf.CSRFHeaderName()))
+			}
+		case config.CSRFModeOrigin:
+			err = checkCSRFOrigin(r, conf)
+		default:
+			err = errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unknown CSRF protection mode %q.", mode))
+		}
+
+		if err != nil {
+			reg.Logger().
+				WithRequest(r).
+				WithField("csrf_mode", mode).
+				WithError(err).
+				Warn("A request failed the CSRF check.")
+			reg.Writer().WriteError(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfModeContextKey{}, true)))
+	})
+}
+
+func hasCSRFOrigin(r *http.Request) bool {
+	return len(r.Header.Get("Origin")) > 0 || len(r.Header.Get("Referer")) > 0
+}
+
+func checkCSRFOrigin(r *http.Request, conf *config.Config) error {
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 {
+		u, err := url.Parse(r.Header.Get("Referer"))
+		if err != nil {
+			return errors.WithStack(herodot.ErrBadRequest.WithReason("The Referer header is malformed."))
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+
+	public := conf.SelfPublicURL(r)
+	if origin == public.Scheme+"://"+public.Host {
+		return nil
+	}
+
+	for _, allowed := range conf.CSRFAllowedOrigins() {
+		if origin == allowed {
+			return nil
+		}
+	}
+
+	return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Requests from origin %s are not allowed.", origin))
+}
@@ -16,7 +16,9 @@ import (
 
 var (
 	ErrInvalidCSRFToken = herodot.ErrForbidden.WithReasonf("A request failed due to a missing or invalid csrf_token value.")
-	ErrGone             = herodot.DefaultError{
+	// ErrCSRFTokenMissingOrInvalid is returned when the anti-CSRF middleware rejects a request.
+	ErrCSRFTokenMissingOrInvalid = herodot.ErrBadRequest.WithReasonf("CSRF token is missing or invalid.")
+	ErrGone                      = herodot.DefaultError{
 		CodeField:    http.StatusGone,
 		StatusField:  http.StatusText(http.StatusGone),
 		ReasonField:  "",
@@ -88,22 +90,30 @@ func NosurfBaseCookieHandler(reg interface {
 	config.Provider
 }) func(w http.ResponseWriter, r *http.Request) http.Cookie {
 	return func(w http.ResponseWriter, r *http.Request) http.Cookie {
-		secure := !reg.Config(r.Context()).IsInsecureDevMode()
-
-		sameSite := http.SameSiteNoneMode
-		if !secure {
-			sameSite = http.SameSiteLaxMode
+		conf := reg.Config(r.Context())
+		c := conf.CSRFCookie()
+
+		sameSite := c.SameSite
+		if sameSite == http.SameSiteDefaultMode {
+			sameSite = http.SameSiteNoneMode
+			if !c.Secure {
+				sameSite = http.SameSiteLaxMode
+			}
 		}
 
-		name := base64.RawURLEncoding.EncodeToString([]byte(reg.Config(r.Context()).SelfPublicURL(r).String())) + "_csrf_token"
+		domain := stringsx.Coalesce(c.Domain, conf.SelfPublicURL(r).Hostname())
+		path := stringsx.Coalesce(c.Path, conf.SelfPublicURL(r).Path, "/")
+		if c.HostOnly() {
+			domain, path = "", "/"
+		}
 
 		return http.Cookie{
-			Name:     name,
+			Name:     conf.CSRFCookieName(r),
 			MaxAge:   nosurf.MaxAge,
-			Path:     stringsx.Coalesce(reg.Config(r.Context()).SelfPublicURL(r).Path, "/"),
-			Domain:   reg.Config(r.Context()).SelfPublicURL(r).Hostname(),
+			Path:     path,
+			Domain:   domain,
 			HttpOnly: true,
-			Secure:   secure,
+			Secure:   c.Secure,
 			SameSite: sameSite,
 		}
 	}
@@ -116,7 +126,8 @@ func NewCSRFHandler(
 		LoggingProvider
 		WriterProvider
 	}) *nosurf.CSRFHandler {
-	n := nosurf.New(router)
+	n := nosurf.New(NewCSRFModeHandler(router, reg))
+	n.ExemptFunc(CSRFExemptByMode(reg))
 
 	n.SetBaseCookieFunc(NosurfBaseCookieHandler(reg))
 	n.SetFailureHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -129,7 +140,7 @@ func NewCSRFHandler(
 			WithField("received_token_body", r.PostForm.Get("csrf_token")).
 			Warn("A request failed due to a missing or invalid csrf_token value")
 
-		reg.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("CSRF token is missing or invalid.")))
+		reg.Writer().WriteError(w, r, errors.WithStack(ErrCSRFTokenMissingOrInvalid))
 	}))
 	return n
 }
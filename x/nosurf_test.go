@@ -1,6 +1,7 @@
 package x_test
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
@@ -44,3 +45,68 @@ func TestNosurfBaseCookieHandler(t *testing.T) {
 	assert.True(t, cookie.Secure, "true because secure mode")
 	assert.True(t, cookie.HttpOnly)
 }
+
+func TestCSRFModeHandler(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	require.NoError(t, conf.Source().Set(config.ViperKeyPublicBaseURL, "https://www.ory.sh/"))
+	require.NoError(t, conf.Source().Set(config.ViperKeyPublicCSRFAllowedOrigins, []string{"https://app.ory.sh"}))
+	require.NoError(t, conf.Source().Set(config.ViperKeyPublicCSRFRouteGroups, []map[string]interface{}{
+		{"path_prefix": "/header", "mode": "header"},
+		{"path_prefix": "/origin", "mode": "origin"},
+	}))
+
+	var verified bool
+	h := x.NewCSRFModeHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verified = x.IsCSRFVerifiedByMode(r)
+		w.WriteHeader(http.StatusNoContent)
+	}), reg)
+
+	for k, tc := range []struct {
+		method, path string
+		header       http.Header
+		code         int
+		verified     bool
+	}{
+		{method: "POST", path: "/cookie", code: http.StatusNoContent},
+		{method: "GET", path: "/header", code: http.StatusNoContent},
+		{method: "POST", path: "/header", code: http.StatusBadRequest},
+		{method: "POST", path: "/header", header: http.Header{"X-Kratos-Csrf": {"1"}}, code: http.StatusNoContent, verified: true},
+		{method: "POST", path: "/origin", code: http.StatusNoContent},
+		{method: "POST", path: "/origin", header: http.Header{"Origin": {"https://www.ory.sh"}}, code: http.StatusNoContent, verified: true},
+		{method: "POST", path: "/origin", header: http.Header{"Origin": {"https://app.ory.sh"}}, code: http.StatusNoContent, verified: true},
+		{method: "POST", path: "/origin", header: http.Header{"Referer": {"https://www.ory.sh/foo"}}, code: http.StatusNoContent, verified: true},
+		{method: "POST", path: "/origin", header: http.Header{"Origin": {"https://evil.com"}}, code: http.StatusBadRequest},
+		{method: "POST", path: "/origin", header: http.Header{"Referer": {"https://evil.com/foo"}}, code: http.StatusBadRequest},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			verified = false
+			r := httptest.NewRequest(tc.method, tc.path, nil)
+			for key := range tc.header {
+				r.Header.Set(key, tc.header.Get(key))
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			assert.Equal(t, tc.code, w.Code, w.Body.String())
+			assert.Equal(t, tc.verified, verified)
+		})
+	}
+
+	t.Run("case=exempts only non-cookie route groups", func(t *testing.T) {
+		exempt := x.CSRFExemptByMode(reg)
+		assert.False(t, exempt(httptest.NewRequest("POST", "/cookie", nil)))
+		assert.True(t, exempt(httptest.NewRequest("POST", "/header/foo", nil)))
+		r := httptest.NewRequest("POST", "/origin", nil)
+		r.Header.Set("Origin", "https://www.ory.sh")
+		assert.True(t, exempt(r))
+	})
+
+	t.Run("case=checks the anti-CSRF token of origin route requests without origin", func(t *testing.T) {
+		exempt := x.CSRFExemptByMode(reg)
+		assert.False(t, exempt(httptest.NewRequest("POST", "/origin", nil)))
+
+		r := httptest.NewRequest("POST", "/origin", nil)
+		r.Header.Set("Referer", "https://www.ory.sh/foo")
+		assert.True(t, exempt(r))
+	})
+}
@@ -2,6 +2,7 @@ package x
 
 import (
 	"fmt"
+	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -11,6 +12,41 @@ import (
 const paginationMaxItems = 1000
 const paginationDefaultItems = 250
 
+// PaginationMetaProfile is the profile of the `Accept` header, for example
+// `application/json; profile="pagination-meta"`, which asks for lists to be wrapped in a meta envelope.
+const PaginationMetaProfile = "pagination-meta"
+
+type (
+	// PaginationMeta describes a page of a list.
+	//
+	// swagger:model paginationMeta
+	PaginationMeta struct {
+		// Total is the number of items in all pages.
+		//
+		// required: true
+		Total int64 `json:"total"`
+
+		// Page is the zero-based number of this page.
+		//
+		// required: true
+		Page int `json:"page"`
+
+		// PerPage is the maximum number of items per page.
+		//
+		// required: true
+		PerPage int `json:"per_page"`
+
+		// NextPage is the number of the page to request next. It is omitted on the last page.
+		NextPage *int `json:"next_page,omitempty"`
+	}
+
+	// PaginationEnvelope wraps a page of a list and its meta data.
+	PaginationEnvelope struct {
+		Items interface{}    `json:"items"`
+		Meta  PaginationMeta `json:"meta"`
+	}
+)
+
 // ParsePagination parses limit and page from *http.Request with given limits and defaults.
 func ParsePagination(r *http.Request) (page, itemsPerPage int) {
 	if offsetParam := r.URL.Query().Get("page"); offsetParam == "" {
@@ -61,6 +97,8 @@ func PaginationHeader(w http.ResponseWriter, u *url.URL, total int64, page, item
 		itemsPerPage = 1
 	}
 
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+
 	itemsPerPage64 := int64(itemsPerPage)
 	offset := int64(page) * itemsPerPage64
 
@@ -111,3 +149,34 @@ func PaginationHeader(w http.ResponseWriter, u *url.URL, total int64, page, item
 		header(u, "last", itemsPerPage64, lastOffset),
 	}, ","))
 }
+
+// PaginationBody returns the items of the page, wrapped in a PaginationEnvelope if the client asked for it
+// with the `meta=true` query parameter or the PaginationMetaProfile. Use it together with PaginationHeader
+// for clients which can not read response headers.
+func PaginationBody(r *http.Request, items interface{}, total int64, page, itemsPerPage int) interface{} {
+	if !wantsPaginationMeta(r) {
+		return items
+	}
+
+	meta := PaginationMeta{Total: total, Page: page, PerPage: itemsPerPage}
+	if itemsPerPage > 0 && int64(page+1)*int64(itemsPerPage) < total {
+		next := page + 1
+		meta.NextPage = &next
+	}
+
+	return &PaginationEnvelope{Items: items, Meta: meta}
+}
+
+func wantsPaginationMeta(r *http.Request) bool {
+	if meta, err := strconv.ParseBool(r.URL.Query().Get("meta")); err == nil {
+		return meta
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if _, params, err := mime.ParseMediaType(accept); err == nil && params["profile"] == PaginationMetaProfile {
+			return true
+		}
+	}
+
+	return false
+}
@@ -45,6 +45,7 @@ func TestPaginationHeader(t *testing.T) {
 		}, ",")
 
 		assert.EqualValues(t, expect, r.Result().Header.Get("Link"))
+		assert.EqualValues(t, "120", r.Result().Header.Get("X-Total-Count"))
 	})
 
 	t.Run("Create next and last, but not previous or first if at the beginning", func(t *testing.T) {
@@ -131,3 +132,44 @@ func TestParsePagination(t *testing.T) {
 		})
 	}
 }
+
+func TestPaginationBody(t *testing.T) {
+	items := []string{"a", "b"}
+	for _, tc := range []struct {
+		d       string
+		url     string
+		accept  string
+		meta    bool
+		total   int64
+		page    int
+		perPage int
+		next    *int
+	}{
+		{d: "without meta", url: "http://localhost/foo"},
+		{d: "query", url: "http://localhost/foo?meta=true", meta: true, total: 5, page: 0, perPage: 2, next: intPtr(1)},
+		{d: "query disabled", url: "http://localhost/foo?meta=false", accept: `application/json; profile="pagination-meta"`},
+		{d: "accept profile", url: "http://localhost/foo", accept: `text/html, application/json; profile="pagination-meta"`, meta: true, total: 5, page: 2, perPage: 2},
+		{d: "other profile", url: "http://localhost/foo", accept: `application/json; profile="foo"`},
+		{d: "last full page", url: "http://localhost/foo?meta=true", meta: true, total: 4, page: 1, perPage: 2},
+	} {
+		t.Run(fmt.Sprintf("case=%s", tc.d), func(t *testing.T) {
+			r := httptest.NewRequest("GET", tc.url, nil)
+			r.Header.Set("Accept", tc.accept)
+
+			actual := PaginationBody(r, items, tc.total, tc.page, tc.perPage)
+			if !tc.meta {
+				assert.Equal(t, items, actual)
+				return
+			}
+
+			assert.Equal(t, &PaginationEnvelope{
+				Items: items,
+				Meta:  PaginationMeta{Total: tc.total, Page: tc.page, PerPage: tc.perPage, NextPage: tc.next},
+			}, actual)
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
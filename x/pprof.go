@@ -0,0 +1,31 @@
+package x
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const RouteAdminProfiling = "/debug/pprof"
+
+// AddProfilingRoutes exposes the runtime profiles of net/http/pprof on the admin router. The profiles reveal
+// internals of the process and must therefore never be served on the public router.
+func AddProfilingRoutes(r *RouterAdmin) {
+	r.GET(RouteAdminProfiling+"/*profile", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		switch name := ps.ByName("profile")[1:]; name {
+		case "":
+			pprof.Index(w, r)
+		case "cmdline":
+			pprof.Cmdline(w, r)
+		case "profile":
+			pprof.Profile(w, r)
+		case "symbol":
+			pprof.Symbol(w, r)
+		case "trace":
+			pprof.Trace(w, r)
+		default:
+			pprof.Handler(name).ServeHTTP(w, r)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package x
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddProfilingRoutes(t *testing.T) {
+	r := NewRouterAdmin()
+	AddProfilingRoutes(r)
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	for path, expected := range map[string]string{
+		RouteAdminProfiling + "/":                  "goroutine",
+		RouteAdminProfiling + "/heap?debug=1":      "heap profile",
+		RouteAdminProfiling + "/goroutine?debug=1": "goroutine profile",
+		RouteAdminProfiling + "/cmdline":           "",
+		RouteAdminProfiling + "/does-not-exist":    "Unknown profile",
+	} {
+		t.Run("path="+path, func(t *testing.T) {
+			res, err := ts.Client().Get(ts.URL + path)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), expected)
+			if expected == "Unknown profile" {
+				assert.Equal(t, http.StatusNotFound, res.StatusCode)
+			} else {
+				assert.Equal(t, http.StatusOK, res.StatusCode)
+			}
+		})
+	}
+}
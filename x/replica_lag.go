@@ -0,0 +1,40 @@
+package x
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+)
+
+// RetryOnReplicaLag calls read until it stops failing with a not found error or until tolerance elapsed. It is used
+// for reads which directly follow writes because, in multi-region deployments, they might be served by a replica
+// which did not receive the write yet. Read is called once if tolerance is zero. Besides sqlcon.ErrNoRows and
+// herodot.ErrNotFound, the errors in notFound are retried as well.
+func RetryOnReplicaLag(ctx context.Context, tolerance time.Duration, read func() error, notFound ...error) error {
+	if tolerance <= 0 {
+		return read()
+	}
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = 25 * time.Millisecond
+	bo.MaxInterval = 250 * time.Millisecond
+	bo.MaxElapsedTime = tolerance
+
+	return backoff.Retry(func() error {
+		err := read()
+		if err == nil || errors.Is(err, sqlcon.ErrNoRows) || errors.Is(err, herodot.ErrNotFound) {
+			return err
+		}
+		for _, nf := range notFound {
+			if errors.Is(err, nf) {
+				return err
+			}
+		}
+		return backoff.Permanent(err)
+	}, backoff.WithContext(bo, ctx))
+}
@@ -0,0 +1,60 @@
+package x
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+)
+
+func TestRetryOnReplicaLag(t *testing.T) {
+	ctx := context.Background()
+	errOther := errors.New("other")
+	errCustom := herodot.ErrUnauthorized.WithReason("custom")
+
+	failing := func(times int, err error) (func() error, *int) {
+		var calls int
+		return func() error {
+			calls++
+			if calls <= times {
+				return err
+			}
+			return nil
+		}, &calls
+	}
+
+	for k, tc := range []struct {
+		d         string
+		tolerance time.Duration
+		times     int
+		err       error
+		notFound  []error
+		expectErr error
+		calls     int
+	}{
+		{d: "calls read once without tolerance", times: 1, err: sqlcon.ErrNoRows, expectErr: sqlcon.ErrNoRows, calls: 1},
+		{d: "retries missing rows", tolerance: time.Second, times: 2, err: sqlcon.ErrNoRows, calls: 3},
+		{d: "retries not found errors", tolerance: time.Second, times: 1, err: herodot.ErrNotFound, calls: 2},
+		{d: "retries custom not found errors", tolerance: time.Second, times: 1, err: errCustom, notFound: []error{errCustom}, calls: 2},
+		{d: "does not retry other errors", tolerance: time.Second, times: 1, err: errOther, expectErr: errOther, calls: 1},
+		{d: "gives up after the tolerance", tolerance: 50 * time.Millisecond, times: 1000, err: sqlcon.ErrNoRows, expectErr: sqlcon.ErrNoRows},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			read, calls := failing(tc.times, tc.err)
+			err := RetryOnReplicaLag(ctx, tc.tolerance, read, tc.notFound...)
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr, "%d", k)
+			} else {
+				assert.NoError(t, err, "%d", k)
+			}
+			if tc.calls > 0 {
+				assert.Equal(t, tc.calls, *calls, "%d", k)
+			}
+		})
+	}
+}
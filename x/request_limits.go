@@ -0,0 +1,142 @@
+package x
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+)
+
+var ErrRequestEntityTooLarge = herodot.DefaultError{
+	StatusField: http.StatusText(http.StatusRequestEntityTooLarge),
+	ErrorField:  "The request body is too large",
+	CodeField:   http.StatusRequestEntityTooLarge,
+}
+
+// NewRequestLimitsHandler rejects requests whose body exceeds maxBodyBytes with status code 413 and JSON
+// request bodies nested deeper than maxJSONDepth with status code 400. The body is read before calling the
+// next handler so that all decoders are protected alike. A limit of zero disables the respective check.
+//
+// Without a size limit the body is not read in advance, because its size would be unbounded. JSON bodies are
+// checked while the next handler reads them instead, which then fails to decode them.
+func NewRequestLimitsHandler(next http.Handler, w herodot.Writer, maxBodyBytes int64, maxJSONDepth int) http.Handler {
+	if maxBodyBytes <= 0 && maxJSONDepth <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		if maxBodyBytes <= 0 {
+			if isJSONBody(r) {
+				r.Body = &jsonDepthReader{ReadCloser: r.Body, max: maxJSONDepth}
+			}
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		tooLarge := errors.WithStack(ErrRequestEntityTooLarge.WithReasonf("The request body must not be larger than %d bytes.", maxBodyBytes))
+		if r.ContentLength > maxBodyBytes {
+			w.WriteError(rw, r, tooLarge)
+			return
+		}
+
+		raw, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+		if err != nil {
+			w.WriteError(rw, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to read the request body: %s", err)))
+			return
+		}
+		_ = r.Body.Close()
+
+		if int64(len(raw)) > maxBodyBytes {
+			w.WriteError(rw, r, tooLarge)
+			return
+		}
+
+		if maxJSONDepth > 0 && isJSONBody(r) && JSONDepthExceeds(raw, maxJSONDepth) {
+			w.WriteError(rw, r, errors.WithStack(newJSONDepthError(maxJSONDepth)))
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(raw))
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func newJSONDepthError(max int) *herodot.DefaultError {
+	return herodot.ErrBadRequest.WithReasonf("The JSON request body must not be nested deeper than %d levels.", max)
+}
+
+// jsonDepthReader fails once the JSON document read through it nests objects and arrays deeper than max levels.
+type jsonDepthReader struct {
+	io.ReadCloser
+	max, depth        int
+	inString, escaped bool
+}
+
+func (r *jsonDepthReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	for _, b := range p[:n] {
+		switch {
+		case r.escaped:
+			r.escaped = false
+		case r.inString:
+			if b == '\\' {
+				r.escaped = true
+			} else if b == '"' {
+				r.inString = false
+			}
+		case b == '"':
+			r.inString = true
+		case b == '{' || b == '[':
+			r.depth++
+			if r.depth > r.max {
+				return 0, errors.WithStack(newJSONDepthError(r.max))
+			}
+		case b == '}' || b == ']':
+			r.depth--
+		}
+	}
+	return n, err
+}
+
+func isJSONBody(r *http.Request) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// JSONDepthExceeds returns true if the JSON document nests objects and arrays deeper than max levels.
+// Invalid JSON is not reported because the decoder will reject it anyways.
+func JSONDepthExceeds(raw []byte, max int) bool {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	var depth int
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return false
+		}
+
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			if depth > max {
+				return true
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
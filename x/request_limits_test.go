@@ -0,0 +1,85 @@
+package x
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/herodot"
+)
+
+func TestRequestLimitsHandler(t *testing.T) {
+	h := NewRequestLimitsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}), herodot.NewJSONWriter(nil), 20, 2)
+
+	for k, tc := range []struct {
+		body          string
+		contentType   string
+		contentLength int64
+		expected      int
+	}{
+		{body: "0123456789", contentLength: 10, expected: http.StatusOK},
+		{body: strings.Repeat("a", 21), contentLength: 21, expected: http.StatusRequestEntityTooLarge},
+		{body: strings.Repeat("a", 21), contentLength: -1, expected: http.StatusRequestEntityTooLarge},
+		{body: `{"a":[1]}`, contentType: "application/json", contentLength: -1, expected: http.StatusOK},
+		{body: `{"a":[[1]]}`, contentType: "application/json; charset=utf-8", contentLength: -1, expected: http.StatusBadRequest},
+		{body: `{"a":[[1]]}`, contentType: "application/x-www-form-urlencoded", contentLength: -1, expected: http.StatusOK},
+	} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(tc.body))
+		r.ContentLength = tc.contentLength
+		r.Header.Set("Content-Type", tc.contentType)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, tc.expected, w.Code, "%d: %s", k, w.Body.String())
+		if tc.expected == http.StatusOK {
+			assert.Equal(t, tc.body, w.Body.String(), "%d", k)
+		}
+	}
+}
+
+func TestRequestLimitsHandlerWithoutSizeLimit(t *testing.T) {
+	hw := herodot.NewJSONWriter(nil)
+	h := NewRequestLimitsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			hw.WriteError(w, r, err)
+			return
+		}
+		hw.Write(w, r, body)
+	}), hw, 0, 2)
+
+	for k, tc := range []struct {
+		body        string
+		contentType string
+		expected    int
+	}{
+		{body: `{"a":[1]}`, contentType: "application/json", expected: http.StatusOK},
+		{body: `{"a":"[[[\"[["}`, contentType: "application/json", expected: http.StatusOK},
+		{body: `{"a":[[1]]}`, contentType: "application/json", expected: http.StatusBadRequest},
+		{body: strings.Repeat("[", 1024*1024), contentType: "application/json", expected: http.StatusBadRequest},
+	} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(tc.body))
+		r.Header.Set("Content-Type", tc.contentType)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, tc.expected, w.Code, "%d: %s", k, w.Body.String())
+		if tc.expected == http.StatusBadRequest {
+			assert.Contains(t, w.Body.String(), "must not be nested deeper than 2 levels", "%d", k)
+		}
+	}
+}
+
+func TestJSONDepthExceeds(t *testing.T) {
+	assert.False(t, JSONDepthExceeds([]byte(`{}`), 1))
+	assert.False(t, JSONDepthExceeds([]byte(`{"a":{"b":[]},"c":[]}`), 3))
+	assert.True(t, JSONDepthExceeds([]byte(`{"a":{"b":[]},"c":[]}`), 2))
+	assert.True(t, JSONDepthExceeds([]byte(strings.Repeat("[", 100)), 10))
+	assert.False(t, JSONDepthExceeds([]byte(`not json`), 1))
+}
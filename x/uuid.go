@@ -1,16 +1,52 @@
 package x
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+
 	db "github.com/gofrs/uuid"
 	"github.com/google/uuid"
 )
 
 var EmptyUUID db.UUID
 
+var timeOrderedUUIDs int32
+
+// UseTimeOrderedUUIDs makes NewUUID return time-ordered UUIDv7 identifiers. Those keep the indices of rows which
+// were created at the same time close together even if they were written in different regions.
+func UseTimeOrderedUUIDs(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&timeOrderedUUIDs, v)
+}
+
 func NewUUID() db.UUID {
+	if atomic.LoadInt32(&timeOrderedUUIDs) == 1 {
+		return NewUUIDv7(time.Now())
+	}
 	return db.UUID(uuid.New())
 }
 
+// NewUUIDv7 returns a UUIDv7 whose first 48 bits are the unix timestamp of t in milliseconds followed by random bits.
+func NewUUIDv7(t time.Time) db.UUID {
+	var id db.UUID
+	if _, err := rand.Read(id[6:]); err != nil {
+		panic(err)
+	}
+
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixNano()/int64(time.Millisecond)))
+	copy(id[:6], ms[2:])
+
+	id[6] = (id[6] & 0x0f) | 0x70 // version 7
+	id[8] = (id[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return id
+}
+
 func ParseUUID(in string) db.UUID {
 	id, _ := uuid.Parse(in)
 	return db.UUID(id)
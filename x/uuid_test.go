@@ -1,8 +1,11 @@
 package x
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
+	db "github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,3 +15,19 @@ func TestUUID(t *testing.T) {
 	assert.True(t, IsZeroUUID(ParseUUID("asfdt4ifgdsl")))
 	assert.False(t, IsZeroUUID(NewUUID()))
 }
+
+func TestNewUUIDv7(t *testing.T) {
+	now := time.Now()
+	a, b := NewUUIDv7(now), NewUUIDv7(now.Add(time.Millisecond))
+	assert.EqualValues(t, 7, a.Version())
+	assert.EqualValues(t, db.VariantRFC4122, a.Variant())
+	assert.NotEqual(t, a, NewUUIDv7(now))
+	assert.True(t, bytes.Compare(a.Bytes(), b.Bytes()) < 0, "%s %s", a, b)
+	assert.Equal(t, a.String(), ParseUUID(a.String()).String())
+
+	UseTimeOrderedUUIDs(true)
+	t.Cleanup(func() {
+		UseTimeOrderedUUIDs(false)
+	})
+	assert.EqualValues(t, 7, NewUUID().Version())
+}